@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/idgen"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
@@ -35,9 +36,18 @@ type Account struct {
 	MarginLevel  types.Money // Equity / MarginUsed × types.MoneyScale (0 when flat)
 	RiskFraction types.Rate  // fraction of equity risked per trade (e.g. 0.005 = 0.5 %)
 
+	// Margin sets the leverage and per-instrument margin rates positions
+	// are held at, and the margin closeout level. The zero value uses each
+	// market.Instrument's MarginRate with no closeout.
+	Margin MarginSchedule
+
 	Lots   LotBook
 	Trades []*Trade // closed trades, appended by CloseLot
 
+	// Interest is the total idle-balance interest AccrueInterest has
+	// booked into Balance.
+	Interest types.Money
+
 	// evtQ is the order-filled/position-closed notification queue — see
 	// account/events.go (SubmitOpen, SubmitClose, Events, ...). Lazily
 	// initialized on first use, same as Lots.
@@ -48,6 +58,10 @@ type Account struct {
 	OANDA *oanda.Client
 	Log   *slog.Logger
 
+	// marginOnce loads OANDA's margin rates into Margin before the first
+	// live order is sized.
+	marginOnce sync.Once
+
 	// snapMu guards snapshot; only one snapshot per account is ever created.
 	snapMu   sync.RWMutex
 	snapshot *AccountSnapshot
@@ -101,7 +115,7 @@ func (acct *Account) quoteToAccountRate(inst string, price types.Price) (types.R
 func quoteToAccountRateFor(currency string, inst string, price types.Price) (types.Rate, error) {
 	meta := market.GetInstrument(inst)
 	if meta == nil {
-		return 0, fmt.Errorf("unknown instrument: %s", inst)
+		return 0, fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, inst)
 	}
 	if meta.QuoteCurrency == currency {
 		return types.Rate(types.RateScale), nil
@@ -170,11 +184,43 @@ func (acct *Account) CloseLot(lot *Lot, trade *Trade) error {
 		return err
 	}
 	trade.PNL = pnl
+	lot.TrackExcursion(trade.ExitPrice)
+	trade.MAE, trade.MFE = lot.MAE, lot.MFE
+	trade.Financing = lot.Financing
+	if trade.InitialRisk, trade.RMultiple, err = acct.initialRisk(lot, pnl); err != nil {
+		return err
+	}
 	acct.Trades = append(acct.Trades, trade.Clone())
 	acct.Lots.Delete(lot.ID)
 	return acct.ResolveWithMarks(map[string]types.Price{lot.Instrument: trade.ExitPrice})
 }
 
+// initialRisk returns what closing lot at its InitialStop would have lost,
+// and pnl expressed as a multiple of that risk. Both are zero when there
+// is no initial stop or it sits on the profitable side of entry.
+func (acct *Account) initialRisk(lot *Lot, pnl types.Money) (types.Money, types.Rate, error) {
+	if lot.TradeCommon == nil || lot.InitialStop <= 0 {
+		return 0, 0, nil
+	}
+	qta, err := acct.quoteToAccountRate(lot.Instrument, lot.InitialStop)
+	if err != nil {
+		return 0, 0, err
+	}
+	atStop, err := lotUnrealizedPNL(lot, lot.InitialStop, qta)
+	if err != nil {
+		return 0, 0, err
+	}
+	if atStop >= 0 {
+		return 0, 0, nil
+	}
+	risk := -atStop
+	r, err := types.SignedMulDivRound(int64(pnl), int64(types.RateScale), int64(risk))
+	if err != nil {
+		return 0, 0, err
+	}
+	return risk, types.Rate(r), nil
+}
+
 // lotUnrealizedPNL computes the open profit/loss for a single lot at the
 // given mark price. qta is the quote-to-account rate (types.RateScale-scaled).
 // The sign follows the lot's side: long gains when mark > entry; short gains
@@ -320,8 +366,9 @@ func (acct *Account) ResolveWithMarks(marks map[string]types.Price) error {
 
 // realizePNL closes out a lot's unrealised P/L into the account Balance.
 // It updates Balance and resets Equity to the new Balance (caller must call
-// ResolveWithMarks to account for any remaining open lots afterwards).
-// Returns the realised P/L amount.
+// ResolveWithMarks to account for any remaining open lots afterwards), and
+// records the quote-currency P/L and conversion rate on trade. Returns the
+// realised P/L amount.
 func (acct *Account) realizePNL(lot *Lot, trade *Trade) (types.Money, error) {
 	if acct == nil {
 		return 0, fmt.Errorf("account is nil")
@@ -351,6 +398,12 @@ func (acct *Account) realizePNL(lot *Lot, trade *Trade) (types.Money, error) {
 	if err != nil {
 		return 0, err
 	}
+	quotePNL, err := lotUnrealizedPNL(lot, trade.ExitPrice, types.Rate(types.RateScale))
+	if err != nil {
+		return 0, err
+	}
+	trade.QuotePNL = quotePNL
+	trade.ConversionRate = quoteToAccountRate
 
 	acct.Balance += pnlMoney
 	acct.Equity = acct.Balance
@@ -358,18 +411,29 @@ func (acct *Account) realizePNL(lot *Lot, trade *Trade) (types.Money, error) {
 	return pnlMoney, nil
 }
 
+// MarginRequired returns the margin a position of units at price in inst
+// would reserve, in account currency: what opening it would add to
+// MarginUsed.
+func (acct *Account) MarginRequired(units types.Units, price types.Price, inst string) (types.Money, error) {
+	if acct == nil {
+		return 0, fmt.Errorf("account is nil")
+	}
+	return acct.marginRequired(units, price, market.NormalizeInstrument(inst))
+}
+
 // marginRequired returns the margin required to hold a position of the given
 // size at the given price for the named instrument, expressed in account
-// currency (types.Money-scaled). It uses the instrument's MarginRate and the
-// account's quote-to-account conversion.
+// currency (types.Money-scaled). It uses the instrument's rate under
+// acct.Margin and the account's quote-to-account conversion.
 func (acct *Account) marginRequired(units types.Units, price types.Price, inst string) (types.Money, error) {
 	meta := market.GetInstrument(inst)
 	if meta == nil {
-		return 0, fmt.Errorf("unknown instrument: %s", inst)
+		return 0, fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, inst)
 	}
 
-	if meta.MarginRate <= 0 {
-		return 0, fmt.Errorf("invalid margin rate for %s: %d", meta.Name, meta.MarginRate)
+	marginRate := acct.Margin.Rate(meta)
+	if marginRate <= 0 {
+		return 0, fmt.Errorf("invalid margin rate for %s: %d", meta.Name, marginRate)
 	}
 
 	u, err := types.AbsInt64Checked(int64(units))
@@ -400,7 +464,7 @@ func (acct *Account) marginRequired(units types.Units, price types.Price, inst s
 		return 0, err
 	}
 
-	marginMicro, err := types.MulDivCeil64(notionalAcctMicro, int64(meta.MarginRate), int64(types.RateScale))
+	marginMicro, err := types.MulDivCeil64(notionalAcctMicro, int64(marginRate), int64(types.RateScale))
 	if err != nil {
 		return 0, err
 	}
@@ -1,6 +1,7 @@
 package account
 
 import (
+	"math/rand/v2"
 	"testing"
 
 	"github.com/rustyeddy/trader/idgen"
@@ -169,6 +170,91 @@ func TestAccountRealizePNLAndClosePosition(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestCloseLotRecordsQuotePNLAndConversionRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		inst      string
+		entry     float64
+		exit      float64
+		wantQuote float64
+		wantRate  float64
+	}{
+		{inst: "EURUSD", entry: 1.1000, exit: 1.1010, wantQuote: 100, wantRate: 1},
+		{inst: "USDJPY", entry: 150.000, exit: 151.250, wantQuote: 125_000, wantRate: 1 / 151.25},
+	}
+	for _, tt := range tests {
+		acct := NewAccount("acct", types.MoneyFromFloat(10_000))
+		pos := newTestPosition(tt.inst, types.Long, 100_000, tt.entry)
+		acct.Lots.Add(pos)
+		trade := &Trade{TradeCommon: pos.TradeCommon, ExitPrice: types.PriceFromFloat(tt.exit)}
+
+		require.NoError(t, acct.CloseLot(pos, trade))
+		assert.Equal(t, types.MoneyFromFloat(tt.wantQuote), trade.QuotePNL, tt.inst)
+		assert.InDelta(t, tt.wantRate, trade.ConversionRate.Float64(), 1e-6, tt.inst)
+		assert.InDelta(t, tt.wantQuote*trade.ConversionRate.Float64(), trade.PNL.Float64(), 0.01, tt.inst)
+		assert.Equal(t, trade.QuotePNL, acct.Trades[0].QuotePNL)
+	}
+}
+
+// Balance and P/L are int64 fixed-point, so the same trades closed in any
+// order leave the same balance to the micro-unit, and it is exactly the
+// sum of their P/L. Float accumulation would drift with the order.
+func TestCloseLot_TotalsReproducibleAcrossOrders(t *testing.T) {
+	t.Parallel()
+
+	type spec struct {
+		inst        string
+		side        types.Side
+		units       types.Units
+		entry, exit types.Price
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	specs := make([]spec, 20_000)
+	for i := range specs {
+		sp := spec{inst: "EURUSD", side: types.Long, units: types.Units(1 + rng.IntN(250_000))}
+		base := types.Price(110_000)
+		if i%3 == 0 {
+			sp.inst, base = "USDJPY", 15_000_000 // a quote currency converted at the exit
+		}
+		if rng.IntN(2) == 0 {
+			sp.side = types.Short
+		}
+		sp.entry = base + types.Price(rng.IntN(2001)-1000)
+		sp.exit = sp.entry + types.Price(rng.IntN(601)-300)
+		specs[i] = sp
+	}
+
+	run := func(order []int) (types.Money, types.Money) {
+		acct := NewAccount("acct", types.MoneyFromFloat(100_000))
+		var sum types.Money
+		for _, i := range order {
+			sp := specs[i]
+			lot := newTestPosition(sp.inst, sp.side, sp.units, 0)
+			lot.EntryPrice = sp.entry
+			require.NoError(t, acct.AddLot(lot))
+			trade := &Trade{TradeCommon: lot.TradeCommon, EntryPrice: sp.entry, ExitPrice: sp.exit}
+			require.NoError(t, acct.CloseLot(lot, trade))
+			sum += trade.PNL
+		}
+		return acct.Balance, sum
+	}
+
+	order := make([]int, len(specs))
+	for i := range order {
+		order[i] = i
+	}
+	wantBalance, wantSum := run(order)
+	assert.Equal(t, types.MoneyFromFloat(100_000)+wantSum, wantBalance)
+
+	for k := 0; k < 3; k++ {
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		balance, sum := run(order)
+		assert.Equal(t, wantBalance, balance, "shuffle %d", k)
+		assert.Equal(t, wantSum, sum, "shuffle %d", k)
+	}
+}
+
 func TestAccountClosePositionAndPlaceholderClosePosition(t *testing.T) {
 	t.Parallel()
 
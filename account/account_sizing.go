@@ -1,12 +1,26 @@
 package account
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
 
+// Sizing refusal causes. SizePosition wraps one of these so callers can
+// classify a refused order with errors.Is; other errors (unknown
+// instrument, arithmetic overflow) are input bugs, not refusals.
+// ErrInsufficientMargin is the shared errs kind.
+var (
+	ErrInvalidStop        = errors.New("invalid stop")
+	ErrRiskBudget         = errors.New("risk budget")
+	ErrInsufficientMargin = errs.ErrInsufficientMargin
+	ErrBelowMinimumSize   = errors.New("below minimum trade size")
+)
+
 // SizingInputs carries the scalars position-sizing math actually needs.
 // It lets sizing run against any equity/margin source (a backtest Account's
 // live fields, or a live account snapshot) without requiring a full ledger.
@@ -16,9 +30,10 @@ type SizingInputs struct {
 	FreeMargin   types.Money
 	RiskFraction types.Rate
 	Currency     string
+	Margin       MarginSchedule // zero value uses market.Instrument margin rates
 }
 
-// sizingInputs snapshots the five scalars SizePosition needs from acct.
+// sizingInputs snapshots the scalars SizePosition needs from acct.
 func (acct *Account) sizingInputs() SizingInputs {
 	return SizingInputs{
 		Equity:       acct.Equity,
@@ -26,16 +41,17 @@ func (acct *Account) sizingInputs() SizingInputs {
 		FreeMargin:   acct.FreeMargin,
 		RiskFraction: acct.RiskFraction,
 		Currency:     acct.Currency,
+		Margin:       acct.Margin,
 	}
 }
 
 // riskBudget returns the max allowed loss in account-money micro-units.
 func (in SizingInputs) riskBudget() (types.Money, error) {
 	if in.Equity <= 0 {
-		return 0, fmt.Errorf("account equity must be > 0")
+		return 0, fmt.Errorf("%w: account equity must be > 0", ErrRiskBudget)
 	}
 	if in.RiskFraction <= 0 {
-		return 0, fmt.Errorf("account risk fraction must be > 0")
+		return 0, fmt.Errorf("%w: account risk fraction must be > 0", ErrRiskBudget)
 	}
 
 	v, err := types.MulDivFloor64(int64(in.Equity), int64(in.RiskFraction), int64(types.RateScale))
@@ -43,7 +59,7 @@ func (in SizingInputs) riskBudget() (types.Money, error) {
 		return 0, err
 	}
 	if v <= 0 {
-		return 0, fmt.Errorf("risk budget must be > 0")
+		return 0, fmt.Errorf("%w: risk budget must be > 0", ErrRiskBudget)
 	}
 	return types.Money(v), nil
 }
@@ -56,7 +72,7 @@ func (in SizingInputs) lossPerUnit(req *OpenRequest) (types.Money, error) {
 		return 0, err
 	}
 	if priceDist == 0 {
-		return 0, fmt.Errorf("entry and stop must differ")
+		return 0, fmt.Errorf("%w: entry and stop must differ", ErrInvalidStop)
 	}
 
 	quoteToAccountRate, err := quoteToAccountRateFor(in.Currency, req.TradeCommon.Instrument, req.Price)
@@ -85,8 +101,9 @@ func (in SizingInputs) marginRequiredPerUnit(inst *market.Instrument, price type
 	if inst == nil {
 		return 0, fmt.Errorf("instrument metadata is nil")
 	}
-	if inst.MarginRate <= 0 {
-		return 0, fmt.Errorf("invalid margin rate for %s: %d", inst.Name, inst.MarginRate)
+	marginRate := in.Margin.Rate(inst)
+	if marginRate <= 0 {
+		return 0, fmt.Errorf("invalid margin rate for %s: %d", inst.Name, marginRate)
 	}
 	if price <= 0 {
 		return 0, fmt.Errorf("invalid price %d", price)
@@ -107,7 +124,7 @@ func (in SizingInputs) marginRequiredPerUnit(inst *market.Instrument, price type
 		return 0, err
 	}
 
-	v, err = types.MulDivCeil64(v, int64(inst.MarginRate), int64(types.RateScale))
+	v, err = types.MulDivCeil64(v, int64(marginRate), int64(types.RateScale))
 	if err != nil {
 		return 0, err
 	}
@@ -148,7 +165,7 @@ func (in SizingInputs) unitsByRisk(req *OpenRequest) (types.Units, error) {
 
 	units := types.Units(int64(riskBudget) / int64(lossPerUnit))
 	if units <= 0 {
-		return 0, fmt.Errorf("risk budget too small for stop distance")
+		return 0, fmt.Errorf("%w: risk budget too small for stop distance", ErrRiskBudget)
 	}
 	return units, nil
 }
@@ -158,12 +175,12 @@ func (in SizingInputs) unitsByRisk(req *OpenRequest) (types.Units, error) {
 func (in SizingInputs) unitsByMargin(req *OpenRequest) (types.Units, error) {
 	freeMargin := in.availableMargin()
 	if freeMargin <= 0 {
-		return 0, fmt.Errorf("free margin must be > 0")
+		return 0, fmt.Errorf("%w: free margin must be > 0", ErrInsufficientMargin)
 	}
 
 	inst := market.GetInstrument(req.TradeCommon.Instrument)
 	if inst == nil {
-		return 0, fmt.Errorf("unknown instrument: %s", req.TradeCommon.Instrument)
+		return 0, fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, req.TradeCommon.Instrument)
 	}
 	marginPerUnit, err := in.marginRequiredPerUnit(inst, req.Price)
 	if err != nil {
@@ -172,44 +189,188 @@ func (in SizingInputs) unitsByMargin(req *OpenRequest) (types.Units, error) {
 
 	units := types.Units(int64(freeMargin) / int64(marginPerUnit))
 	if units <= 0 {
-		return 0, fmt.Errorf("free margin too small for minimum position")
+		return 0, fmt.Errorf("%w: free margin too small for minimum position", ErrInsufficientMargin)
 	}
 	return units, nil
 }
 
+// SizingAudit records every intermediate quantity of one sizing, so a
+// wrong position size (a JPY cross converted at the wrong rate, a stop
+// measured in the wrong pip) can be traced step by step. Quantities sizing
+// did not reach before refusing are left zero.
+type SizingAudit struct {
+	Instrument string
+	Side       types.Side
+	Currency   string      // account currency
+	Price      types.Price // entry
+	Stop       types.Price
+
+	PipSize      types.Price // one pip in price units: 0.0001, or 0.01 on JPY pairs
+	StopDistance types.Price // |Price − Stop|
+	StopPips     types.Pips  // StopDistance in deci-pips
+
+	// QuoteToAccount converts one unit of the quote currency into the
+	// account currency; PipValue is one pip on a standard lot (100,000
+	// units) at that rate.
+	QuoteToAccount types.Rate
+	PipValue       types.Money
+
+	Equity       types.Money
+	RiskFraction types.Rate  // after the request's RiskScale
+	RiskBudget   types.Money // Equity × RiskFraction
+	LossPerUnit  types.Money // StopDistance × QuoteToAccount, rounded up
+
+	MarginRate    types.Rate
+	MarginPerUnit types.Money
+	FreeMargin    types.Money
+
+	UnitsByRisk      types.Units // RiskBudget / LossPerUnit: the raw size
+	UnitsByMargin    types.Units // FreeMargin / MarginPerUnit
+	MinimumTradeSize types.Units
+	Units            types.Units // the lesser of the two; 0 when refused
+	Err              string      // why sizing refused; empty when it sized
+}
+
+// standardLot is the position PipValue is quoted for.
+const standardLot = 100_000
+
+// LogValue implements slog.LogValuer, so a logged audit reads in prices,
+// pips and account money rather than raw fixed-point integers.
+func (a SizingAudit) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("instrument", a.Instrument),
+		slog.String("side", a.Side.String()),
+		slog.String("currency", a.Currency),
+		slog.String("price", a.Price.String()),
+		slog.String("stop", a.Stop.String()),
+		slog.String("pip_size", a.PipSize.String()),
+		slog.String("stop_distance", a.StopDistance.String()),
+		slog.Float64("stop_pips", a.StopPips.Float64()),
+		slog.String("quote_to_account", a.QuoteToAccount.String()),
+		slog.String("pip_value_per_lot", a.PipValue.String()),
+		slog.String("equity", a.Equity.String()),
+		slog.String("risk_fraction", a.RiskFraction.String()),
+		slog.String("risk_budget", a.RiskBudget.String()),
+		slog.String("loss_per_unit", a.LossPerUnit.String()),
+		slog.String("margin_rate", a.MarginRate.String()),
+		slog.String("margin_per_unit", a.MarginPerUnit.String()),
+		slog.String("free_margin", a.FreeMargin.String()),
+		slog.Int64("units_by_risk", int64(a.UnitsByRisk)),
+		slog.Int64("units_by_margin", int64(a.UnitsByMargin)),
+		slog.Int64("minimum_trade_size", int64(a.MinimumTradeSize)),
+		slog.Int64("units", int64(a.Units)),
+	}
+	if a.Err != "" {
+		attrs = append(attrs, slog.String("err", a.Err))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// audit fills a with the quantities sizing req against in derives. Each
+// is recorded independently, so one that fails (an unsupported
+// conversion, say) leaves only itself and those built on it zero.
+func (in SizingInputs) audit(req *OpenRequest, a *SizingAudit) {
+	a.Currency = in.Currency
+	a.Equity = in.Equity
+	a.RiskFraction = in.RiskFraction
+	a.FreeMargin = in.availableMargin()
+	if dist, err := types.AbsInt64Checked(int64(req.Price) - int64(req.Stop)); err == nil {
+		a.StopDistance = types.Price(dist)
+	}
+
+	inst := market.GetInstrument(req.Instrument)
+	if inst == nil {
+		return
+	}
+	a.MinimumTradeSize = inst.MinimumTradeSize
+	a.MarginRate = in.Margin.Rate(inst)
+	a.PipSize = inst.PriceUnitsPerPip()
+	if a.PipSize > 0 {
+		a.StopPips = types.Pips(int64(a.StopDistance) * types.PipScale / int64(a.PipSize))
+	}
+
+	if rate, err := quoteToAccountRateFor(in.Currency, req.Instrument, req.Price); err == nil {
+		a.QuoteToAccount = rate
+		if v, err := types.MulDivFloor64(int64(a.PipSize)*standardLot, int64(types.MoneyScale), int64(types.PriceScale)); err == nil {
+			if v, err = types.MulDivFloor64(v, int64(rate), int64(types.RateScale)); err == nil {
+				a.PipValue = types.Money(v)
+			}
+		}
+	}
+	if v, err := in.riskBudget(); err == nil {
+		a.RiskBudget = v
+	}
+	if v, err := in.lossPerUnit(req); err == nil {
+		a.LossPerUnit = v
+	}
+	if v, err := in.marginRequiredPerUnit(inst, req.Price); err == nil {
+		a.MarginPerUnit = v
+	}
+}
+
 // SizePosition computes and sets req.Units as the lesser of:
-//   - the units allowed by the risk budget (unitsByRisk)
+//   - the units allowed by the risk budget (unitsByRisk), with the risk
+//     fraction scaled by req.RiskScale when set
 //   - the units allowed by available margin (unitsByMargin)
 //
 // Returns an error if the computed size is below the instrument's minimum
 // trade size or if any input is invalid.
 func SizePosition(in SizingInputs, req *OpenRequest) error {
+	return sizePosition(in, req, nil)
+}
+
+// SizePositionAudit is SizePosition that also returns the audit record of
+// the sizing, whether it sized req or refused it.
+func SizePositionAudit(in SizingInputs, req *OpenRequest) (SizingAudit, error) {
+	var a SizingAudit
+	err := sizePosition(in, req, &a)
+	if err != nil {
+		a.Err = err.Error()
+	}
+	return a, err
+}
+
+// sizePosition is SizePosition's core; a non-nil a is filled as it goes.
+func sizePosition(in SizingInputs, req *OpenRequest, a *SizingAudit) error {
 	if req == nil {
 		return fmt.Errorf("request is nil")
 	}
+	if a != nil && req.TradeCommon != nil {
+		a.Instrument, a.Side, a.Price, a.Stop = req.Instrument, req.Side, req.Price, req.Stop
+	}
 	if req.Instrument == "" {
 		return fmt.Errorf("request instrument must not be empty")
 	}
 	if req.Price <= 0 || req.Stop <= 0 {
-		return fmt.Errorf("entry and stop must be > 0")
+		return fmt.Errorf("%w: entry and stop must be > 0", ErrInvalidStop)
 	}
 	if req.Price == req.Stop {
-		return fmt.Errorf("entry and stop must differ")
+		return fmt.Errorf("%w: entry and stop must differ", ErrInvalidStop)
 	}
 
 	switch req.Side {
 	case types.Short:
 		if req.TradeCommon.Stop <= req.Price {
-			return fmt.Errorf("short stop must be greater than price")
+			return fmt.Errorf("%w: short stop must be greater than price", ErrInvalidStop)
 		}
 	case types.Long:
 		if req.Stop >= req.Price {
-			return fmt.Errorf("long stop must be less than price")
+			return fmt.Errorf("%w: long stop must be less than price", ErrInvalidStop)
 		}
 	default:
 		return fmt.Errorf("invalid side %v", req.TradeCommon.Side)
 	}
 
+	if req.RiskScale > 0 {
+		scaled, err := types.MulDivFloor64(int64(in.RiskFraction), int64(req.RiskScale), int64(types.RateScale))
+		if err != nil {
+			return err
+		}
+		in.RiskFraction = types.Rate(scaled)
+	}
+	if a != nil {
+		in.audit(req, a)
+	}
 	unitsRisk, err := in.unitsByRisk(req)
 	if err != nil {
 		return err
@@ -219,10 +380,13 @@ func SizePosition(in SizingInputs, req *OpenRequest) error {
 	if err != nil {
 		return err
 	}
+	if a != nil {
+		a.UnitsByRisk, a.UnitsByMargin = unitsRisk, unitsMargin
+	}
 
 	inst := market.GetInstrument(req.TradeCommon.Instrument)
 	if inst == nil {
-		return fmt.Errorf("unknown instrument: %s", req.TradeCommon.Instrument)
+		return fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, req.TradeCommon.Instrument)
 	}
 
 	units := unitsMargin
@@ -231,7 +395,8 @@ func SizePosition(in SizingInputs, req *OpenRequest) error {
 	}
 	if units < inst.MinimumTradeSize {
 		return fmt.Errorf(
-			"computed units %d < minimum trade size %d (risk=%d margin=%d)",
+			"%w: computed units %d < minimum trade size %d (risk=%d margin=%d)",
+			ErrBelowMinimumSize,
 			units,
 			inst.MinimumTradeSize,
 			unitsRisk,
@@ -239,6 +404,9 @@ func SizePosition(in SizingInputs, req *OpenRequest) error {
 		)
 	}
 	req.Units = units
+	if a != nil {
+		a.Units = units
+	}
 	return nil
 }
 
@@ -251,3 +419,12 @@ func (acct *Account) SizePosition(req *OpenRequest) error {
 	}
 	return SizePosition(acct.sizingInputs(), req)
 }
+
+// SizePositionAudit is SizePosition with the sizing's audit record; see
+// the package-level SizePositionAudit.
+func (acct *Account) SizePositionAudit(req *OpenRequest) (SizingAudit, error) {
+	if acct == nil {
+		return SizingAudit{}, fmt.Errorf("account is nil")
+	}
+	return SizePositionAudit(acct.sizingInputs(), req)
+}
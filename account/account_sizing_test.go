@@ -3,6 +3,7 @@ package account
 import (
 	"testing"
 
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 	"github.com/stretchr/testify/assert"
@@ -183,8 +184,8 @@ func TestMarginRequired_UnknownInstrument(t *testing.T) {
 
 	acct := sizedAccount(10_000, 0.02)
 	_, err := acct.marginRequired(1000, types.PriceFromFloat(1.1), "XXXYYY")
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "unknown instrument")
+	require.ErrorIs(t, err, errs.ErrUnknownInstrument)
+	assert.EqualError(t, err, "unknown instrument: XXXYYY")
 }
 
 func TestSizePosition_HappyPath_EURUSD(t *testing.T) {
@@ -214,6 +215,20 @@ func TestSizePosition_HappyPath_LongVsShort(t *testing.T) {
 	assert.Equal(t, long.Units, short.Units)
 }
 
+func TestSizePosition_RiskScale(t *testing.T) {
+	t.Parallel()
+
+	acct := sizedAccount(10_000, 0.01)
+	base := makeOpenRequest("EURUSD", types.Long, 1.3000, 1.2980)
+	require.NoError(t, acct.SizePosition(base))
+
+	doubled := makeOpenRequest("EURUSD", types.Long, 1.3000, 1.2980)
+	doubled.RiskScale = types.RateFromFloat(2)
+	require.NoError(t, acct.SizePosition(doubled))
+	assert.InDelta(t, float64(2*base.Units), float64(doubled.Units), 2)
+	assert.Equal(t, types.RateFromFloat(0.01), acct.RiskFraction, "the account's risk fraction is unchanged")
+}
+
 func TestSizePosition_USDJPY(t *testing.T) {
 	t.Parallel()
 
@@ -415,3 +430,43 @@ func TestSizePosition_ShortStopBelowPrice(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "short stop")
 }
+
+func TestSizePositionAudit_JPYCross(t *testing.T) {
+	t.Parallel()
+
+	acct := sizedAccount(10_000, 0.01)
+	req := makeOpenRequest("EURJPY", types.Long, 160.000, 159.500)
+	a, err := acct.SizePositionAudit(req)
+	require.NoError(t, err)
+
+	jpy, ok := market.ApproximateUSDPerUnit("JPY")
+	require.True(t, ok)
+	assert.Equal(t, "EURJPY", a.Instrument)
+	assert.Equal(t, types.PriceFromFloat(0.01), a.PipSize)
+	assert.Equal(t, types.PriceFromFloat(0.5), a.StopDistance)
+	assert.Equal(t, types.PipsFromFloat(50), a.StopPips)
+	assert.Equal(t, jpy, a.QuoteToAccount)
+	assert.Equal(t, types.Money(1000*int64(jpy)), a.PipValue, "1,000 JPY a pip on a standard lot")
+	assert.Equal(t, types.MoneyFromFloat(100), a.RiskBudget)
+	assert.Equal(t, types.Money(int64(jpy)/2), a.LossPerUnit)
+	assert.Equal(t, types.Rate(20_000), a.MarginRate)
+	assert.Equal(t, types.Units(int64(a.RiskBudget)/int64(a.LossPerUnit)), a.UnitsByRisk)
+	assert.Greater(t, a.UnitsByMargin, a.UnitsByRisk)
+	assert.Equal(t, a.UnitsByRisk, a.Units)
+	assert.Equal(t, req.Units, a.Units)
+	assert.Empty(t, a.Err)
+}
+
+func TestSizePositionAudit_RecordsRefusal(t *testing.T) {
+	t.Parallel()
+
+	acct := sizedAccount(10_000, 0.02)
+	req := makeOpenRequest("EURUSD", types.Short, 1.3000, 1.2990)
+	a, err := acct.SizePositionAudit(req)
+	require.ErrorIs(t, err, ErrInvalidStop)
+	assert.Equal(t, "EURUSD", a.Instrument)
+	assert.Equal(t, types.Short, a.Side)
+	assert.Equal(t, err.Error(), a.Err)
+	assert.Zero(t, a.Units)
+	assert.Zero(t, req.Units)
+}
@@ -0,0 +1,124 @@
+package account
+
+import (
+	"sort"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// CurrencyExposure is the net amount of one currency held across open
+// lots. Amounts are in that currency (not the account currency), scaled by
+// types.MoneyScale: a long 10,000 EURUSD lot at 1.10000 is +10,000 EUR and
+// -11,000 USD.
+type CurrencyExposure struct {
+	Currency string
+	Long     types.Money // sum of positive legs
+	Short    types.Money // sum of negative legs (negative)
+	Net      types.Money // Long + Short
+}
+
+// CurrencyExposures breaks every open lot into its base and quote currency
+// legs and sums them per currency. Quote legs are valued at marks, falling
+// back to the lot's entry price for instruments without a mark. Lots on
+// unknown instruments are skipped. The result is sorted by currency.
+func CurrencyExposures(lb *LotBook, marks map[string]types.Price) []CurrencyExposure {
+	if lb == nil {
+		return nil
+	}
+
+	byCCY := make(map[string]*CurrencyExposure)
+	addLeg := func(ccy string, amt types.Money) {
+		e, ok := byCCY[ccy]
+		if !ok {
+			e = &CurrencyExposure{Currency: ccy}
+			byCCY[ccy] = e
+		}
+		if amt >= 0 {
+			e.Long += amt
+		} else {
+			e.Short += amt
+		}
+		e.Net += amt
+	}
+
+	_ = lb.Range(func(lot *Lot) error {
+		if lot == nil || lot.State != LotOpen || lot.RemainingUnits <= 0 {
+			return nil
+		}
+		meta := market.GetInstrument(lot.Instrument)
+		if meta == nil {
+			return nil
+		}
+		price := lot.EntryPrice
+		if px, ok := marks[lot.Instrument]; ok && px > 0 {
+			price = px
+		}
+
+		units := int64(lot.RemainingUnits)
+		base := types.Money(units * int64(types.MoneyScale))
+		quote := types.Money(units * int64(price) * int64(types.MoneyScale/types.Scale7(types.PriceScale)))
+		// Long buys base and sells quote; short is the mirror image.
+		if lot.Side == types.Short {
+			base = -base
+		} else {
+			quote = -quote
+		}
+		addLeg(meta.BaseCurrency, base)
+		addLeg(meta.QuoteCurrency, quote)
+		return nil
+	})
+
+	out := make([]CurrencyExposure, 0, len(byCCY))
+	for _, e := range byCCY {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Currency < out[j].Currency })
+	return out
+}
+
+// Notional returns the value of units of inst at price in currency, the
+// account currency, scaled by types.MoneyScale.
+func Notional(currency, inst string, units types.Units, price types.Price) (types.Money, error) {
+	if units < 0 {
+		units = -units
+	}
+	rate, err := quoteToAccountRateFor(currency, inst, price)
+	if err != nil {
+		return 0, err
+	}
+	quote, err := types.MulDivCeil64(int64(units)*int64(price), int64(types.MoneyScale), int64(types.PriceScale))
+	if err != nil {
+		return 0, err
+	}
+	v, err := types.MulDivCeil64(quote, int64(rate), int64(types.RateScale))
+	if err != nil {
+		return 0, err
+	}
+	return types.Money(v), nil
+}
+
+// OpenNotional sums the Notional of every open lot in currency, long and
+// short alike, valued at marks like CurrencyExposures.
+func OpenNotional(lb *LotBook, currency string, marks map[string]types.Price) (types.Money, error) {
+	if lb == nil {
+		return 0, nil
+	}
+	var total types.Money
+	err := lb.Range(func(lot *Lot) error {
+		if lot == nil || lot.State != LotOpen || lot.RemainingUnits <= 0 {
+			return nil
+		}
+		price := lot.EntryPrice
+		if px, ok := marks[lot.Instrument]; ok && px > 0 {
+			price = px
+		}
+		n, err := Notional(currency, lot.Instrument, lot.RemainingUnits, price)
+		if err != nil {
+			return err
+		}
+		total += n
+		return nil
+	})
+	return total, err
+}
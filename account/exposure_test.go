@@ -0,0 +1,95 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyExposuresNilLotBook(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, CurrencyExposures(nil, nil))
+}
+
+func TestCurrencyExposuresNetsLegsPerCurrency(t *testing.T) {
+	t.Parallel()
+
+	var lb LotBook
+	require.NoError(t, lb.Add(&Lot{
+		TradeCommon:    &TradeCommon{ID: "l1", Instrument: "EURUSD", Side: types.Long},
+		EntryPrice:     types.PriceFromFloat(1.1000),
+		EntryTime:      1,
+		OriginalUnits:  10_000,
+		RemainingUnits: 10_000,
+		State:          LotOpen,
+	}))
+	require.NoError(t, lb.Add(&Lot{
+		TradeCommon:    &TradeCommon{ID: "s1", Instrument: "USDJPY", Side: types.Short},
+		EntryPrice:     types.PriceFromFloat(150.00),
+		EntryTime:      2,
+		OriginalUnits:  5_000,
+		RemainingUnits: 5_000,
+		State:          LotOpen,
+	}))
+	require.NoError(t, lb.Add(&Lot{
+		TradeCommon:    &TradeCommon{ID: "c1", Instrument: "EURUSD", Side: types.Long},
+		EntryPrice:     types.PriceFromFloat(1.0000),
+		EntryTime:      3,
+		OriginalUnits:  1_000,
+		RemainingUnits: 1_000,
+		State:          LotClosed,
+	}))
+
+	// EURUSD is marked at 1.2 (overriding entry); USDJPY has no mark and
+	// falls back to its entry price.
+	got := CurrencyExposures(&lb, map[string]types.Price{"EURUSD": types.PriceFromFloat(1.2000)})
+	assert.Equal(t, []CurrencyExposure{
+		{Currency: "EUR", Long: types.MoneyFromFloat(10_000), Net: types.MoneyFromFloat(10_000)},
+		{Currency: "JPY", Long: types.MoneyFromFloat(750_000), Net: types.MoneyFromFloat(750_000)},
+		{
+			Currency: "USD",
+			Long:     0,
+			Short:    types.MoneyFromFloat(-17_000),
+			Net:      types.MoneyFromFloat(-17_000),
+		},
+	}, got)
+}
+
+func TestOpenNotional(t *testing.T) {
+	t.Parallel()
+
+	var lb LotBook
+	require.NoError(t, lb.Add(&Lot{
+		TradeCommon:    &TradeCommon{ID: "l1", Instrument: "EURUSD", Side: types.Long},
+		EntryPrice:     types.PriceFromFloat(1.1000),
+		OriginalUnits:  10_000,
+		RemainingUnits: 10_000,
+		State:          LotOpen,
+	}))
+	require.NoError(t, lb.Add(&Lot{
+		TradeCommon:    &TradeCommon{ID: "s1", Instrument: "USDJPY", Side: types.Short},
+		EntryPrice:     types.PriceFromFloat(150.00),
+		OriginalUnits:  5_000,
+		RemainingUnits: 5_000,
+		State:          LotOpen,
+	}))
+
+	// 10,000 EUR at the 1.2 mark plus 5,000 USD, short or not.
+	got, err := OpenNotional(&lb, "USD", map[string]types.Price{"EURUSD": types.PriceFromFloat(1.2000)})
+	require.NoError(t, err)
+	assert.InDelta(t, 17_000, got.Float64(), 1, "the JPY conversion rounds up")
+
+	n, err := Notional("USD", "EURUSD", -1_000, types.PriceFromFloat(1.1000))
+	require.NoError(t, err)
+	assert.Equal(t, types.MoneyFromFloat(1_100), n)
+
+	_, err = Notional("USD", "XXXYYY", 1_000, types.PriceFromFloat(1))
+	assert.ErrorContains(t, err, "unknown instrument")
+
+	empty, err := OpenNotional(nil, "USD", nil)
+	require.NoError(t, err)
+	assert.Zero(t, empty)
+}
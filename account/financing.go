@@ -0,0 +1,92 @@
+package account
+
+import (
+	"fmt"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// daysPerYear converts an annual financing rate to a daily one, the
+// actual/365 convention FX brokers quote rollover in.
+const daysPerYear = 365
+
+// ChargeFinancing books days of rollover financing on lot into Balance and
+// adds it to lot.Financing. annualRate is the yearly rate on the lot's
+// notional at mark, RateScale-scaled: positive is charged to the holder,
+// negative credited. It returns the amount booked in account currency —
+// negative for a charge.
+func (acct *Account) ChargeFinancing(lot *Lot, mark types.Price, annualRate types.Rate, days int64) (types.Money, error) {
+	if acct == nil {
+		return 0, fmt.Errorf("account is nil")
+	}
+	if lot == nil || lot.TradeCommon == nil {
+		return 0, fmt.Errorf("position is nil")
+	}
+	if mark <= 0 {
+		return 0, fmt.Errorf("invalid mark for %s: %d", lot.Instrument, mark)
+	}
+	if annualRate == 0 || days <= 0 || lot.RemainingUnits <= 0 {
+		return 0, nil
+	}
+
+	notional, err := types.MulDivFloor64(int64(lot.RemainingUnits), int64(mark)*int64(types.MoneyScale), int64(types.PriceScale))
+	if err != nil {
+		return 0, err
+	}
+	rate, err := types.AbsInt64Checked(int64(annualRate))
+	if err != nil {
+		return 0, err
+	}
+	quote, err := types.MulDivFloor64(notional, rate*days, int64(types.RateScale)*daysPerYear)
+	if err != nil {
+		return 0, err
+	}
+	qta, err := acct.quoteToAccountRate(lot.Instrument, mark)
+	if err != nil {
+		return 0, err
+	}
+	amount, err := types.MulDivFloor64(quote, int64(qta), int64(types.RateScale))
+	if err != nil {
+		return 0, err
+	}
+
+	booked := types.Money(amount)
+	if annualRate > 0 {
+		booked = -booked
+	}
+	lot.Financing += booked
+	acct.Balance += booked
+	return booked, acct.ResolveWithMarks(map[string]types.Price{lot.Instrument: mark})
+}
+
+// AccrueInterest credits days of interest on the account's idle cash —
+// Balance less MarginUsed — at annualRate, RateScale-scaled and accrued
+// actual/365; a negative rate charges it. Nothing accrues while margin
+// ties up the whole balance. The amount is added to Balance, Equity,
+// FreeMargin and the running Interest total, and returned.
+func (acct *Account) AccrueInterest(annualRate types.Rate, days int64) (types.Money, error) {
+	if acct == nil {
+		return 0, fmt.Errorf("account is nil")
+	}
+	idle := acct.Balance - acct.MarginUsed
+	if annualRate == 0 || days <= 0 || idle <= 0 {
+		return 0, nil
+	}
+	rate, err := types.AbsInt64Checked(int64(annualRate))
+	if err != nil {
+		return 0, err
+	}
+	amount, err := types.MulDivFloor64(int64(idle), rate*days, int64(types.RateScale)*daysPerYear)
+	if err != nil {
+		return 0, err
+	}
+	accrued := types.Money(amount)
+	if annualRate < 0 {
+		accrued = -accrued
+	}
+	acct.Balance += accrued
+	acct.Equity += accrued
+	acct.FreeMargin += accrued
+	acct.Interest += accrued
+	return accrued, nil
+}
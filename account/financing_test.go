@@ -0,0 +1,74 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChargeFinancing(t *testing.T) {
+	t.Parallel()
+
+	acct := NewAccount("test", types.MoneyFromFloat(10_000))
+	units := types.Units(100_000)
+	lot := &Lot{
+		TradeCommon:    &TradeCommon{ID: "1", Instrument: "EURUSD", Side: types.Long, Units: units},
+		EntryPrice:     types.PriceFromFloat(1.10000),
+		OriginalUnits:  units,
+		RemainingUnits: units,
+		State:          LotOpen,
+	}
+	require.NoError(t, acct.AddLot(lot))
+	mark := types.PriceFromFloat(1.10000)
+
+	// 3.65% a year on 110,000 USD of notional is 11.00 a day.
+	got, err := acct.ChargeFinancing(lot, mark, types.RateFromFloat(0.0365), 1)
+	require.NoError(t, err)
+	assert.Equal(t, types.MoneyFromFloat(-11), got)
+
+	got, err = acct.ChargeFinancing(lot, mark, types.RateFromFloat(-0.0365), 3)
+	require.NoError(t, err)
+	assert.Equal(t, types.MoneyFromFloat(33), got, "a negative rate credits, three days at once")
+
+	assert.Equal(t, types.MoneyFromFloat(22), lot.Financing)
+	assert.Equal(t, types.MoneyFromFloat(10_022), acct.Balance)
+	assert.Equal(t, acct.Balance, acct.Equity, "marked at entry, so equity is just the balance")
+
+	require.NoError(t, acct.CloseLot(lot, &Trade{TradeCommon: lot.TradeCommon.Clone(), EntryPrice: lot.EntryPrice, ExitPrice: mark}))
+	require.Len(t, acct.Trades, 1)
+	assert.Equal(t, types.MoneyFromFloat(22), acct.Trades[0].Financing)
+	assert.Zero(t, acct.Trades[0].PNL, "financing stays out of the trade's P/L")
+
+	got, err = acct.ChargeFinancing(lot, mark, 0, 1)
+	require.NoError(t, err)
+	assert.Zero(t, got)
+}
+
+func TestAccrueInterest(t *testing.T) {
+	t.Parallel()
+
+	acct := NewAccount("test", types.MoneyFromFloat(36_500))
+	acct.Equity, acct.FreeMargin = acct.Balance, acct.Balance
+
+	// 3.65% a year on 36,500 of idle cash is 3.65 a day.
+	got, err := acct.AccrueInterest(types.RateFromFloat(0.0365), 2)
+	require.NoError(t, err)
+	assert.Equal(t, types.MoneyFromFloat(7.30), got)
+	assert.Equal(t, types.MoneyFromFloat(36_507.30), acct.Balance)
+	assert.Equal(t, acct.Balance, acct.Equity)
+	assert.Equal(t, acct.Balance, acct.FreeMargin)
+	assert.Equal(t, types.MoneyFromFloat(7.30), acct.Interest)
+
+	acct.MarginUsed = acct.Balance - types.MoneyFromFloat(3_650)
+	got, err = acct.AccrueInterest(types.RateFromFloat(-0.10), 1)
+	require.NoError(t, err)
+	assert.Equal(t, types.MoneyFromFloat(-1), got, "only the cash margin leaves idle earns, and a negative rate charges")
+	assert.Equal(t, types.MoneyFromFloat(6.30), acct.Interest)
+
+	acct.MarginUsed = acct.Balance
+	got, err = acct.AccrueInterest(types.RateFromFloat(0.0365), 1)
+	require.NoError(t, err)
+	assert.Zero(t, got, "nothing is idle")
+}
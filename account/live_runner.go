@@ -5,12 +5,13 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -176,7 +177,7 @@ func (acct *Account) runOneTick(
 			return fmt.Errorf("get pricing: %w", err)
 		}
 		if len(prices) == 0 {
-			return fmt.Errorf("no price for %s", cfg.Instrument)
+			return fmt.Errorf("%w for %s", errs.ErrNoPrice, cfg.Instrument)
 		}
 		px := prices[0]
 		livePrice = LivePrice{
@@ -418,7 +419,8 @@ func estimateTicksOpen(openTime, now time.Time, interval time.Duration) int {
 	return int(now.Sub(openTime) / interval)
 }
 
-// normalizeInstrument converts "EUR/USD" → "EUR_USD" and uppercases.
+// normalizeInstrument converts any spelling ("EUR/USD", "eurusd") to the
+// OANDA symbol, "EUR_USD".
 func normalizeInstrument(s string) string {
-	return strings.ToUpper(strings.ReplaceAll(s, "/", "_"))
+	return symbols.ToProvider(symbols.OANDA, s)
 }
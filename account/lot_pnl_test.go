@@ -54,3 +54,61 @@ func TestLotUnrealizedPL_QuoteToAccountConversion(t *testing.T) {
 	// +1.00 quote * 1.5 conversion = +1.50 account
 	assert.Equal(t, types.Money(1_500_000), pl)
 }
+
+func TestLotTrackExcursion(t *testing.T) {
+	t.Parallel()
+
+	entry := types.PriceFromFloat(1.20000)
+	long := &Lot{TradeCommon: &TradeCommon{Side: types.Long}, EntryPrice: entry}
+	for _, px := range []float64{1.19950, 1.20120, 1.19900, 1.20050} {
+		long.TrackExcursion(types.PriceFromFloat(px))
+	}
+	assert.Equal(t, types.Price(100), long.MAE)
+	assert.Equal(t, types.Price(120), long.MFE)
+
+	short := &Lot{TradeCommon: &TradeCommon{Side: types.Short}, EntryPrice: entry}
+	for _, px := range []float64{1.19950, 1.20120, 1.19900, 0} {
+		short.TrackExcursion(types.PriceFromFloat(px))
+	}
+	assert.Equal(t, types.Price(120), short.MAE)
+	assert.Equal(t, types.Price(100), short.MFE, "a zero price is ignored")
+}
+
+func TestCloseLot_RecordsInitialRiskAndRMultiple(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		side     types.Side
+		stop     float64
+		exit     float64
+		wantRisk types.Money
+		wantR    types.Rate
+		wantMAE  types.Price
+	}{
+		// 1,000 units, 20-pip stop: risk 2.00; a 30-pip win is +1.5R.
+		{name: "long winner", side: types.Long, stop: 1.19800, exit: 1.20300, wantRisk: types.MoneyFromFloat(2), wantR: types.RateFromFloat(1.5)},
+		{name: "short stopped", side: types.Short, stop: 1.20200, exit: 1.20200, wantRisk: types.MoneyFromFloat(2), wantR: types.RateFromFloat(-1), wantMAE: 200},
+		{name: "no initial stop", side: types.Long, exit: 1.20100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acct := NewAccount("test", types.MoneyFromFloat(10_000))
+			lot := &Lot{
+				TradeCommon:    &TradeCommon{ID: "lot-1", Instrument: "EURUSD", Side: tt.side, Units: 1000, InitialStop: types.PriceFromFloat(tt.stop)},
+				EntryPrice:     types.PriceFromFloat(1.20000),
+				OriginalUnits:  1000,
+				RemainingUnits: 1000,
+				State:          LotOpen,
+			}
+			require.NoError(t, acct.AddLot(lot))
+			trade := &Trade{TradeCommon: lot.TradeCommon.Clone(), EntryPrice: lot.EntryPrice, ExitPrice: types.PriceFromFloat(tt.exit)}
+			require.NoError(t, acct.CloseLot(lot, trade))
+
+			assert.Equal(t, tt.wantRisk, trade.InitialRisk)
+			assert.Equal(t, tt.wantR, trade.RMultiple)
+			assert.Equal(t, tt.wantMAE, trade.MAE, "the exit price counts toward the excursion")
+			assert.Equal(t, trade.RMultiple, acct.Trades[0].RMultiple)
+		})
+	}
+}
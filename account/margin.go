@@ -0,0 +1,124 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
+	"github.com/rustyeddy/trader/types"
+)
+
+// MarginConfig is the YAML/JSON form of a MarginSchedule. The zero value
+// keeps every instrument at its market.Instrument MarginRate with no
+// margin closeout.
+type MarginConfig struct {
+	// Leverage is the account's maximum leverage, e.g. 30 for 30:1. It
+	// sets a floor of 1/Leverage under every instrument's margin rate.
+	Leverage float64 `json:"leverage,omitempty" yaml:"leverage"`
+	// Rates overrides instrument margin rates as fractions of notional,
+	// e.g. {"USDJPY": 0.04}. Keys may use any instrument spelling.
+	Rates map[string]float64 `json:"rates,omitempty" yaml:"rates"`
+	// CloseoutPct closes every open position once equity falls below this
+	// percentage of the margin in use; OANDA closes out at 50. Zero
+	// disables margin closeout.
+	CloseoutPct float64 `json:"closeout-pct,omitempty" yaml:"closeout-pct"`
+}
+
+// IsZero reports whether c changes nothing.
+func (c MarginConfig) IsZero() bool {
+	return c.Leverage == 0 && len(c.Rates) == 0 && c.CloseoutPct == 0
+}
+
+// Compile validates c and converts it to a MarginSchedule.
+func (c MarginConfig) Compile() (MarginSchedule, error) {
+	var m MarginSchedule
+	if c.Leverage < 0 || (c.Leverage > 0 && c.Leverage < 1) {
+		return m, fmt.Errorf("leverage must be >= 1, got %v", c.Leverage)
+	}
+	if c.Leverage > 0 {
+		m.Account = types.RateFromFloat(1 / c.Leverage)
+	}
+	for inst, rate := range c.Rates {
+		name := market.NormalizeInstrument(inst)
+		if market.GetInstrument(name) == nil {
+			return MarginSchedule{}, errs.Newf(errs.ErrUnknownInstrument, "margin rate for unknown instrument %q", inst)
+		}
+		if rate <= 0 || rate > 1 {
+			return MarginSchedule{}, fmt.Errorf("margin rate for %s must be in (0, 1], got %v", name, rate)
+		}
+		if m.Instruments == nil {
+			m.Instruments = make(map[string]types.Rate, len(c.Rates))
+		}
+		m.Instruments[name] = types.RateFromFloat(rate)
+	}
+	if c.CloseoutPct < 0 || c.CloseoutPct > 100 {
+		return MarginSchedule{}, fmt.Errorf("closeout-pct must be in [0, 100], got %v", c.CloseoutPct)
+	}
+	m.Closeout = types.RateFromFloat(c.CloseoutPct / 100)
+	return m, nil
+}
+
+// MarginSchedule decides the margin rate each instrument is held at. An
+// instrument's rate is its entry in Instruments, or its market.Instrument
+// MarginRate, raised to Account when that is higher — the same way a
+// broker applies an account-level leverage cap on top of per-instrument
+// rates. The zero value uses the market.Instrument rates unchanged.
+type MarginSchedule struct {
+	Account     types.Rate            // account-wide minimum rate, 1/leverage; zero means none
+	Instruments map[string]types.Rate // per-instrument overrides, by normalized name
+	Closeout    types.Rate            // equity/margin-used fraction that triggers closeout; zero disables
+}
+
+// Rate returns the margin rate inst is held at, or zero for a nil inst.
+func (m MarginSchedule) Rate(inst *market.Instrument) types.Rate {
+	if inst == nil {
+		return 0
+	}
+	rate := inst.MarginRate
+	if r, ok := m.Instruments[inst.Name]; ok {
+		rate = r
+	}
+	return max(rate, m.Account)
+}
+
+// MarginCloseoutDue reports whether acct's equity has fallen below
+// Margin.Closeout of the margin in use, as of the last ResolveWithMarks.
+func (acct *Account) MarginCloseoutDue() bool {
+	if acct == nil || acct.Margin.Closeout <= 0 || acct.MarginUsed <= 0 {
+		return false
+	}
+	floor, err := types.MulDivCeil64(int64(acct.MarginUsed), int64(acct.Margin.Closeout), int64(types.RateScale))
+	if err != nil {
+		return false
+	}
+	return int64(acct.Equity) < floor
+}
+
+// LoadMarginRates fills Margin.Instruments from the margin rates OANDA
+// applies to this account, keeping any rate already set (configured rates
+// win). Instruments the registry does not know are skipped.
+func (acct *Account) LoadMarginRates(ctx context.Context) error {
+	if acct == nil || acct.OANDA == nil {
+		return fmt.Errorf("account has no OANDA client")
+	}
+	insts, err := acct.OANDA.GetAccountInstruments(ctx, acct.ID)
+	if err != nil {
+		return fmt.Errorf("load margin rates: %w", err)
+	}
+	rates := make(map[string]types.Rate, len(insts)+len(acct.Margin.Instruments))
+	for _, in := range insts {
+		name := symbols.FromProvider(symbols.OANDA, strings.TrimSpace(in.Name))
+		if market.GetInstrument(name) == nil || in.MarginRate <= 0 {
+			continue
+		}
+		rates[name] = types.RateFromFloat(in.MarginRate)
+	}
+	for name, r := range acct.Margin.Instruments {
+		rates[name] = r
+	}
+	acct.Margin.Instruments = rates
+	return nil
+}
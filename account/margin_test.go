@@ -0,0 +1,169 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarginConfigCompile(t *testing.T) {
+	t.Parallel()
+
+	m, err := MarginConfig{}.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, MarginSchedule{}, m)
+
+	m, err = MarginConfig{Leverage: 30, Rates: map[string]float64{"usd_jpy": 0.04}, CloseoutPct: 50}.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, types.RateFromFloat(1.0/30), m.Account)
+	assert.Equal(t, map[string]types.Rate{"USDJPY": types.RateFromFloat(0.04)}, m.Instruments)
+	assert.Equal(t, types.RateFromFloat(0.5), m.Closeout)
+
+	for _, bad := range []MarginConfig{
+		{Leverage: 0.5},
+		{Leverage: -10},
+		{Rates: map[string]float64{"EURUSD": 0}},
+		{Rates: map[string]float64{"EURUSD": 1.5}},
+		{Rates: map[string]float64{"NOPE": 0.05}},
+		{CloseoutPct: 150},
+	} {
+		_, err := bad.Compile()
+		assert.Error(t, err, "%+v", bad)
+	}
+}
+
+func TestMarginScheduleRate(t *testing.T) {
+	t.Parallel()
+
+	eur := market.GetInstrument("EURUSD")
+	jpy := market.GetInstrument("USDJPY")
+	require.NotNil(t, eur)
+	require.NotNil(t, jpy)
+
+	var zero MarginSchedule
+	assert.Equal(t, eur.MarginRate, zero.Rate(eur))
+	assert.Equal(t, types.Rate(0), zero.Rate(nil))
+
+	m := MarginSchedule{
+		Account:     types.RateFromFloat(1.0 / 30),
+		Instruments: map[string]types.Rate{"USDJPY": types.RateFromFloat(0.05)},
+	}
+	assert.Equal(t, types.RateFromFloat(1.0/30), m.Rate(eur), "leverage floor raises the 2% default")
+	assert.Equal(t, types.RateFromFloat(0.05), m.Rate(jpy), "override above the floor wins")
+}
+
+// TestMarginUsed_Leverage holds 100k EURUSD at 1.10 (110,000 USD notional)
+// under each leverage setting.
+func TestMarginUsed_Leverage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		leverage   float64
+		wantMargin float64
+	}{
+		{leverage: 20, wantMargin: 5_500},
+		{leverage: 30, wantMargin: 3_666.67},
+		{leverage: 50, wantMargin: 2_200},
+	}
+	for _, tt := range tests {
+		m, err := MarginConfig{Leverage: tt.leverage}.Compile()
+		require.NoError(t, err)
+
+		acct := NewAccount("acct", types.MoneyFromFloat(10_000))
+		acct.Margin = m
+		require.NoError(t, acct.AddLot(newTestPosition("EURUSD", types.Long, 100_000, 1.1000)))
+		require.NoError(t, acct.ResolveWithMarks(map[string]types.Price{"EURUSD": types.PriceFromFloat(1.1000)}))
+
+		assert.InDelta(t, tt.wantMargin, acct.MarginUsed.Float64(), 0.05, "%v:1", tt.leverage)
+		assert.InDelta(t, 10_000-tt.wantMargin, acct.FreeMargin.Float64(), 0.05, "%v:1", tt.leverage)
+	}
+}
+
+func TestSizePosition_MarginCapFollowsLeverage(t *testing.T) {
+	t.Parallel()
+
+	// A 1-pip stop with 100% risk would size far past what margin allows,
+	// so the units come out at free margin / margin per unit.
+	tests := []struct {
+		leverage  float64
+		wantUnits types.Units
+	}{
+		{leverage: 20, wantUnits: 18_181},
+		{leverage: 30, wantUnits: 27_272},
+		{leverage: 50, wantUnits: 45_454},
+	}
+	for _, tt := range tests {
+		m, err := MarginConfig{Leverage: tt.leverage}.Compile()
+		require.NoError(t, err)
+
+		in := SizingInputs{
+			Equity:       types.MoneyFromFloat(1_000),
+			FreeMargin:   types.MoneyFromFloat(1_000),
+			RiskFraction: types.RateFromFloat(1),
+			Currency:     "USD",
+			Margin:       m,
+		}
+		req := &OpenRequest{Request: Request{
+			TradeCommon: &TradeCommon{Instrument: "EURUSD", Side: types.Long, Stop: types.PriceFromFloat(1.0999)},
+			Price:       types.PriceFromFloat(1.1000),
+		}}
+		require.NoError(t, SizePosition(in, req))
+		assert.InDelta(t, float64(tt.wantUnits), float64(req.Units), 1, "%v:1", tt.leverage)
+	}
+}
+
+func TestMarginCloseoutDue(t *testing.T) {
+	t.Parallel()
+
+	acct := NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.MarginUsed = types.MoneyFromFloat(2_000)
+	acct.Equity = types.MoneyFromFloat(900)
+	assert.False(t, acct.MarginCloseoutDue(), "closeout disabled")
+
+	acct.Margin.Closeout = types.RateFromFloat(0.5)
+	assert.True(t, acct.MarginCloseoutDue())
+
+	acct.Equity = types.MoneyFromFloat(1_000)
+	assert.False(t, acct.MarginCloseoutDue(), "exactly at the level")
+
+	acct.MarginUsed = 0
+	acct.Equity = -1
+	assert.False(t, acct.MarginCloseoutDue(), "flat account")
+}
+
+func TestLoadMarginRates_ConfiguredRatesWin(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/accounts/ACC1/instruments", r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"instruments": []map[string]any{
+				{"name": "EUR_USD", "marginRate": "0.0333"},
+				{"name": "USD_JPY", "marginRate": "0.05"},
+				{"name": "XYZ_ABC", "marginRate": "0.2"},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	acct := &Account{
+		ID:     "ACC1",
+		OANDA:  &oanda.Client{BaseURL: srv.URL, Token: "tok", HTTP: srv.Client()},
+		Margin: MarginSchedule{Instruments: map[string]types.Rate{"USDJPY": types.RateFromFloat(0.04)}},
+	}
+	require.NoError(t, acct.LoadMarginRates(context.Background()))
+	assert.Equal(t, map[string]types.Rate{
+		"EURUSD": types.RateFromFloat(0.0333),
+		"USDJPY": types.RateFromFloat(0.04),
+	}, acct.Margin.Instruments)
+
+	require.Error(t, (&Account{}).LoadMarginRates(context.Background()))
+}
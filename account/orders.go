@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
@@ -50,6 +51,10 @@ type OrderProposal struct {
 	StopPrice  float64
 	RiskAmount float64 // USD risked
 	AccountNAV float64
+
+	// Sizing is the audit record of the risk sizing, before MaxUnits and
+	// MaxPositionUSD caps; nil when Units overrode sizing.
+	Sizing *SizingAudit `json:",omitempty"`
 }
 
 // PlaceMarketOrder handles the full risk-sized order workflow:
@@ -70,7 +75,7 @@ func (acct *Account) PlaceMarketOrder(ctx context.Context, req PlaceMarketOrderR
 		return nil, fmt.Errorf("get pricing: %w", err)
 	}
 	if len(prices) == 0 {
-		return nil, fmt.Errorf("no price returned for %s", req.Instrument)
+		return nil, fmt.Errorf("%w returned for %s", errs.ErrNoPrice, req.Instrument)
 	}
 	px := prices[0]
 	// Convert wire-format floats to fixed-point at the API boundary.
@@ -144,15 +149,22 @@ func (acct *Account) PlaceMarketOrder(ctx context.Context, req PlaceMarketOrderR
 
 	var units int64
 	var riskAmount float64
+	var sizing *SizingAudit
 	if req.Units != 0 {
 		units = req.Units
 	} else {
+		acct.marginOnce.Do(func() {
+			if err := acct.LoadMarginRates(ctx); err != nil && acct.Log != nil {
+				acct.Log.Warn("account: using default margin rates", "err", err)
+			}
+		})
 		inputs := SizingInputs{
 			Equity:       types.MoneyFromFloat(equity),
 			MarginUsed:   types.MoneyFromFloat(summary.MarginUsed),
 			FreeMargin:   types.MoneyFromFloat(summary.MarginAvail),
 			RiskFraction: req.RiskPct,
 			Currency:     currency,
+			Margin:       acct.Margin,
 		}
 		openReq := &OpenRequest{
 			Request: Request{
@@ -164,9 +176,14 @@ func (acct *Account) PlaceMarketOrder(ctx context.Context, req PlaceMarketOrderR
 				Price: entryPrice,
 			},
 		}
-		if err := SizePosition(inputs, openReq); err != nil {
+		audit, err := SizePositionAudit(inputs, openReq)
+		if acct.Log != nil {
+			acct.Log.Debug("account: sizing audit", "sizing", audit)
+		}
+		if err != nil {
 			return nil, fmt.Errorf("size position: %w", err)
 		}
+		sizing = &audit
 		units = int64(openReq.Units)
 		riskAmount = float64(inputs.RiskFraction.Float64()) * equity
 	}
@@ -197,6 +214,7 @@ func (acct *Account) PlaceMarketOrder(ctx context.Context, req PlaceMarketOrderR
 		StopPrice:  stopPrice.Float64(),
 		RiskAmount: riskAmount,
 		AccountNAV: equity,
+		Sizing:     sizing,
 	}
 	result := &PlaceMarketOrderResult{Proposal: proposal}
 
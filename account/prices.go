@@ -2,9 +2,9 @@ package account
 
 import (
 	"context"
-	"strings"
 
 	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
 )
 
 // PriceInfo holds the current bid/ask snapshot for one instrument.
@@ -38,7 +38,7 @@ func (acct *Account) GetPrices(ctx context.Context, req GetPricesRequest) ([]Pri
 		if inst == nil {
 			continue
 		}
-		oandaKey := inst.BaseCurrency + "_" + inst.QuoteCurrency
+		oandaKey := symbols.ToProvider(symbols.OANDA, inst.Name)
 		oandaNames = append(oandaNames, oandaKey)
 		instMap[oandaKey] = inst
 	}
@@ -58,7 +58,7 @@ func (acct *Account) GetPrices(ctx context.Context, req GetPricesRequest) ([]Pri
 			}
 		}
 		out = append(out, PriceInfo{
-			Instrument: strings.ReplaceAll(p.Instrument, "_", ""),
+			Instrument: symbols.FromProvider(symbols.OANDA, p.Instrument),
 			Bid:        p.Bid,
 			Ask:        p.Ask,
 			Mid:        p.Mid,
@@ -23,6 +23,9 @@ type TradeCommon struct {
 	// resolved), captured once at open. See Reason for why this needs its
 	// own field instead of reading Stop after the fact.
 	InitialStop types.Price
+	// VolRegime is the volatility regime (low, normal or high) of the bar
+	// the lot opened on, captured once like Reason; empty when unknown.
+	VolRegime string
 }
 
 // Clone is an internal helper for trader type processing.
@@ -43,6 +46,26 @@ type Trade struct {
 	ExitTime   types.Timestamp
 	PNL        types.Money // account currency (best-effort)
 	CloseCause CloseCause
+
+	// Set by Account.CloseLot. QuotePNL is the P/L in the instrument's
+	// quote currency; ConversionRate is the quote-to-account rate
+	// (RateScale-scaled) PNL was converted at, so PNL can be recomputed
+	// as QuotePNL × ConversionRate.
+	QuotePNL       types.Money
+	ConversionRate types.Rate
+
+	// Set by Account.CloseLot. MAE/MFE are the lot's excursions (see
+	// Lot.MAE). InitialRisk is the loss InitialStop would have realized,
+	// in account currency; RMultiple is PNL / InitialRisk, RateScale-scaled.
+	// Both are zero when the lot had no InitialStop.
+	MAE         types.Price
+	MFE         types.Price
+	InitialRisk types.Money
+	RMultiple   types.Rate
+
+	// Financing is the lot's rollover financing, copied by
+	// Account.CloseLot. PNL excludes it.
+	Financing types.Money
 }
 
 // Clone is an internal helper for trader type processing.
@@ -48,6 +48,33 @@ type Lot struct {
 	// ExtremePrice tracks the highest-high (long) or lowest-low (short) seen
 	// since entry. Used by trailing/chandelier exit strategies.
 	ExtremePrice types.Price
+	// MAE and MFE are the maximum adverse and favorable excursions from
+	// EntryPrice seen while the lot is open, as non-negative price
+	// distances. Updated on every price update via TrackExcursion.
+	MAE types.Price
+	MFE types.Price
+	// Financing is the rollover financing booked against the lot so far
+	// (see Account.ChargeFinancing), in account currency: negative when
+	// charged. It is already in Balance and is not part of the lot's P/L.
+	Financing types.Money
+}
+
+// TrackExcursion widens MAE/MFE to cover exit, the price the lot would
+// close at right now (bid for a long, ask for a short).
+func (lot *Lot) TrackExcursion(exit types.Price) {
+	if lot == nil || exit <= 0 || lot.EntryPrice <= 0 {
+		return
+	}
+	move := exit - lot.EntryPrice
+	if lot.Side == types.Short {
+		move = -move
+	}
+	switch {
+	case move > lot.MFE:
+		lot.MFE = move
+	case -move > lot.MAE:
+		lot.MAE = -move
+	}
 }
 
 // Clone is an internal helper for trader type processing.
@@ -47,6 +47,10 @@ type Request struct {
 // OpenRequest represents a trader domain type.
 type OpenRequest struct {
 	Request
+
+	// RiskScale multiplies the risk fraction the request is sized with,
+	// RateScale-scaled; 0 sizes at the plain risk fraction.
+	RiskScale types.Rate
 }
 
 // CloseRequest represents a trader domain type.
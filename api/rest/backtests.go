@@ -226,7 +226,7 @@ func (s *Server) handleRegressBacktest(w http.ResponseWriter, r *http.Request) {
 
 	summaries, err := (&backtestsvc.Service{Executor: s.backtests, Log: s.log}).RunBacktestPathSpecs(r.Context(), configPaths)
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, fmt.Sprintf("run backtests: %v", err))
+		writeErr(w, errStatus(err, http.StatusInternalServerError), fmt.Sprintf("run backtests: %v", err))
 		return
 	}
 	if len(summaries) == 0 {
@@ -360,7 +360,7 @@ func (s *Server) handleGetBacktestCandles(w http.ResponseWriter, r *http.Request
 		Strict:     false,
 	})
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, fmt.Sprintf("load candles: %v", err))
+		writeErr(w, errStatus(err, http.StatusInternalServerError), fmt.Sprintf("load candles: %v", err))
 		return
 	}
 	defer func() { _ = iter.Close() }()
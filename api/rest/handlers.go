@@ -78,7 +78,7 @@ func (s *Server) handlePlaceOrder(w http.ResponseWriter, r *http.Request) {
 		Confirm:    req.Confirm,
 	})
 	if err != nil {
-		writeErr(w, http.StatusBadGateway, fmt.Sprintf("place order: %v", err))
+		writeErr(w, errStatus(err, http.StatusBadGateway), fmt.Sprintf("place order: %v", err))
 		return
 	}
 	status := http.StatusOK
@@ -218,7 +218,7 @@ func (s *Server) handleRunBacktest(w http.ResponseWriter, r *http.Request) {
 
 	summaries, err := (&backtestsvc.Service{Executor: s.backtests, Log: s.log}).RunBacktestPathSpecsAndWriteReports(r.Context(), req.ConfigPaths, s.effectiveReportsDir())
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, fmt.Sprintf("run backtest: %v", err))
+		writeErr(w, errStatus(err, http.StatusInternalServerError), fmt.Sprintf("run backtest: %v", err))
 		return
 	}
 
@@ -358,7 +358,7 @@ func (s *Server) handlePipValues(w http.ResponseWriter, r *http.Request) {
 		Instruments: instruments,
 	})
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, fmt.Sprintf("pip values: %v", err))
+		writeErr(w, errStatus(err, http.StatusInternalServerError), fmt.Sprintf("pip values: %v", err))
 		return
 	}
 	writeJSON(w, http.StatusOK, result)
@@ -19,6 +19,7 @@ import (
 	"github.com/rustyeddy/trader/backtest"
 	"github.com/rustyeddy/trader/brokers/oanda"
 	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/errs"
 	accountsvc "github.com/rustyeddy/trader/service/account"
 )
 
@@ -242,6 +243,20 @@ func writeErr(w http.ResponseWriter, status int, msg string) {
 	writeJSON(w, status, map[string]string{"error": msg})
 }
 
+// errStatus maps err's errs kind to an HTTP status, or returns def when err
+// is of no shared kind.
+func errStatus(err error, def int) int {
+	switch errs.KindOf(err) {
+	case errs.ErrBadConfig, errs.ErrUnknownInstrument, errs.ErrInsufficientMargin:
+		return http.StatusUnprocessableEntity
+	case errs.ErrDataGap:
+		return http.StatusNotFound
+	case errs.ErrNoPrice:
+		return http.StatusServiceUnavailable
+	}
+	return def
+}
+
 // requireOANDA returns false and writes 503 if the OANDA client is absent.
 func (s *Server) requireOANDA(w http.ResponseWriter) bool {
 	if s.oanda == nil {
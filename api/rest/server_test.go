@@ -2,11 +2,14 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"testing"
 
 	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -38,3 +41,20 @@ func TestGetVersion(t *testing.T) {
 	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
 	assert.Equal(t, config.Version, body["version"])
 }
+
+func TestErrStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errs.Mark(errors.New("runs: none"), errs.ErrBadConfig), http.StatusUnprocessableEntity},
+		{fmt.Errorf("config %q: %w", "a.yml", fmt.Errorf("%w: EURXYZ", errs.ErrUnknownInstrument)), http.StatusUnprocessableEntity},
+		{fmt.Errorf("size: %w", errs.ErrInsufficientMargin), http.StatusUnprocessableEntity},
+		{errs.Mark(errors.New("load candles"), errs.ErrDataGap), http.StatusNotFound},
+		{fmt.Errorf("%w for EURUSD", errs.ErrNoPrice), http.StatusServiceUnavailable},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, errStatus(tt.err, http.StatusInternalServerError), tt.err.Error())
+	}
+}
@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	webhooksvc "github.com/rustyeddy/trader/service/webhook"
+)
+
+// maxAlertBytes caps an alert body; TradingView's are a few hundred bytes.
+const maxAlertBytes = 64 << 10
+
+// ── POST /webhook ─────────────────────────────────────────────────────────────
+//
+// Takes a TradingView-style alert and places its paper orders. The token
+// is the alert's token (or passphrase) field or the ?token= query
+// parameter, since TradingView can't set headers. The body is read
+// whatever its Content-Type; TradingView sends text/plain.
+//
+// Request body: webhooksvc.Alert JSON
+// Response:     webhooksvc.Result JSON; 401 on a bad token, 429 over the
+//               rate limit
+//
+// Example:
+//
+//	POST /webhook?token=secret
+//	{"ticker": "OANDA:EURUSD", "action": "buy", "contracts": "1000", "price": 1.0850, "stop_pips": 20}
+
+// ── GET /status ──────────────────────────────────────────────────────────────
+//
+// Reports the paper account the alerts trade: balance, equity, margin,
+// open and closed trade counts and the latest prices. The token is the
+// ?token= query parameter.
+//
+// Response: webhooksvc.Status JSON; 401 on a bad token
+//
+// Example:
+//
+//	GET /status?token=secret
+
+// WebhookHandler returns the handler for alert webhooks and the status of
+// the account they trade, served by svc and mounted by `trader replay
+// webhook` on its own listener. log may be nil, in which case
+// slog.Default() is used.
+func WebhookHandler(svc *webhooksvc.Service, log *slog.Logger) http.Handler {
+	if log == nil {
+		log = slog.Default()
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAlertBytes))
+		if err != nil {
+			writeErr(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("read body: %v", err))
+			return
+		}
+		alert, err := webhooksvc.ParseAlert(body)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		res, err := svc.Handle(r.Context(), alert, r.URL.Query().Get("token"))
+		if err != nil {
+			status := webhookStatus(err)
+			log.Warn("webhook alert refused", "ticker", alert.Ticker, "action", alert.Action, "status", status, "err", err)
+			writeErr(w, status, err.Error())
+			return
+		}
+		log.Info("webhook alert", "instrument", res.Instrument, "action", res.Action,
+			"trade", res.TradeID, "units", res.Units, "closed", len(res.Closed))
+		writeJSON(w, http.StatusOK, res)
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		st, err := svc.Status(r.URL.Query().Get("token"))
+		if err != nil {
+			writeErr(w, webhookStatus(err), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, st)
+	})
+	return mux
+}
+
+// webhookStatus maps a webhooksvc error to an HTTP status.
+func webhookStatus(err error) int {
+	switch {
+	case errors.Is(err, webhooksvc.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, webhooksvc.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, webhooksvc.ErrBadAlert):
+		return http.StatusBadRequest
+	}
+	return errStatus(err, http.StatusUnprocessableEntity)
+}
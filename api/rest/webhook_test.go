@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	webhooksvc "github.com/rustyeddy/trader/service/webhook"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	engine := sim.NewSimBroker(&account.Account{
+		ID: "SIM", Currency: "USD",
+		Balance: types.MoneyFromFloat(100_000), Equity: types.MoneyFromFloat(100_000),
+	}, nil)
+	svc, err := webhooksvc.New(engine, "SIM", webhooksvc.Config{Token: "secret", Units: 1000, RatePerMinute: 1, Burst: 2})
+	require.NoError(t, err)
+	h := WebhookHandler(svc, nil)
+
+	post := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8") // as TradingView sends it
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	alert := `{"ticker":"OANDA:EURUSD","action":"buy","contracts":"2000","price":1.085,"stop_pips":20}`
+
+	assert.Equal(t, http.StatusUnauthorized, post("/webhook?token=wrong", alert).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/webhook?token=secret", `{"ticker":`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/webhook?token=secret", `{"ticker":"EURUSD","action":"buy","price":"NaN"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/webhook?token=secret", `{"ticker":"EURUSD","action":"buy","price":1.085,"stop_pips":1e9}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post("/webhook?token=secret", `{"ticker":"NYSE:IBM","action":"buy"}`).Code)
+
+	rr := post("/webhook?token=secret", alert)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var res webhooksvc.Result
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	assert.Equal(t, "EURUSD", res.Instrument)
+	assert.Equal(t, int64(2000), res.Units)
+	assert.InDelta(t, 1.083, res.Stop, 1e-9)
+
+	assert.Equal(t, http.StatusOK, post("/webhook", `{"token":"secret","ticker":"EURUSD","action":"close","price":1.086}`).Code)
+	assert.Equal(t, http.StatusTooManyRequests, post("/webhook?token=secret", alert).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(t, h, "GET", "/webhook").Code)
+
+	assert.Equal(t, http.StatusUnauthorized, do(t, h, "GET", "/status?token=wrong").Code)
+	rr = do(t, h, "GET", "/status?token=secret")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var st webhooksvc.Status
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &st))
+	assert.Equal(t, "SIM", st.AccountID)
+	assert.Equal(t, 1, st.ClosedTrades, "the close alert closed the buy")
+	assert.Zero(t, st.OpenTrades)
+	assert.InDelta(t, 1.086, st.Prices["EURUSD"], 1e-9)
+	assert.False(t, st.AsOf.IsZero())
+}
@@ -1,10 +1,19 @@
 package backtest
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/idgen"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/log"
+	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/strategy"
 	"github.com/rustyeddy/trader/types"
 )
@@ -15,11 +24,28 @@ import (
 // the explicit Result field rather than anonymously merged into the run.
 type Backtest struct {
 	ID        string
-	RunConfig RunConfig // resolved config snapshot used for execution
+	RunConfig RunConfig   // resolved config snapshot used for execution
+	Defaults  RunDefaults // the config's defaults the run was compiled with
 
 	Request *BacktestRequest
 	State   *BacktestRun
 	Result  *BacktestResult
+
+	// Log receives the run's records — runner, engine and strategy alike.
+	// Nil logs to log.BacktestLog.
+	Log *slog.Logger
+
+	// Progress, when set, receives progress reports while the run
+	// replays its bars; see ProgressFunc.
+	Progress ProgressFunc
+
+	// CloseOnInterrupt closes the lots still open when the run is
+	// interrupted, as at the end of the data. Left unset they stay open
+	// and count only toward final equity.
+	CloseOnInterrupt bool
+
+	// Hooks run at fixed points of the run loop, in order; see Hook.
+	Hooks []Hook
 }
 
 // emptyLotBook is returned by OpenLots when no lot state exists, so callers can
@@ -43,12 +69,71 @@ func (b *Backtest) OpenLots() strategy.LotView {
 	return b.State.Lots
 }
 
+// HigherTimeframe implements StrategyContext: the run's higher-timeframe
+// feed, or nil when none is configured.
+func (b *Backtest) HigherTimeframe() strategy.HigherTimeframe {
+	if b == nil || b.State == nil || b.State.htf == nil {
+		return nil
+	}
+	return b.State.htf
+}
+
+// LastOrderDecisions implements StrategyContext: what the order path did
+// with the entries planned on the previous bar.
+func (b *Backtest) LastOrderDecisions() []journal.OrderDecision {
+	if b == nil || b.State == nil {
+		return nil
+	}
+	return b.State.decisions
+}
+
+// Logger implements StrategyContext: the run's logger.
+func (b *Backtest) Logger() *slog.Logger {
+	if b == nil || b.Log == nil {
+		return log.BacktestLog
+	}
+	return b.Log
+}
+
+// PreviewOrder implements strategy.OrderPreviewer: what a market order
+// for units on side would fill at and do to the run's margin, priced at
+// the bar the strategy is being updated with.
+func (b *Backtest) PreviewOrder(ctx context.Context, side types.Side, units types.Units) (brokers.OrderPreview, error) {
+	if b == nil || b.State == nil || b.State.previewer == nil {
+		return brokers.OrderPreview{}, fmt.Errorf("backtest: order preview is not available")
+	}
+	signed := int64(units)
+	switch side {
+	case types.Long:
+	case types.Short:
+		signed = -signed
+	default:
+		return brokers.OrderPreview{}, fmt.Errorf("backtest: preview needs a long or short side, got %v", side)
+	}
+	return b.State.previewer.PreviewOrder(ctx, brokers.PreviewRequest{
+		AccountID:  b.State.accountID,
+		Instrument: b.Instrument(),
+		Units:      signed,
+	})
+}
+
+// RecentTicks implements strategy.TickHistory: the last n prices the sim
+// took on the run's instrument, one per bar in a candle run. The sim keeps
+// as many as the strategy's TickDepth asks for.
+func (b *Backtest) RecentTicks(n int) []market.Tick {
+	if b == nil || b.State == nil || b.State.ticks == nil {
+		return nil
+	}
+	return b.State.ticks.History(b.Instrument(), n)
+}
+
 // CompiledBacktest is the construction-phase output for one backtest run.
 // It is immutable and contains the resolved config snapshot plus the validated
 // request used to instantiate an executable Backtest later.
 type CompiledBacktest struct {
 	ID        string
 	RunConfig RunConfig
+	Defaults  RunDefaults
 	Request   BacktestRequest
 }
 
@@ -58,15 +143,35 @@ func (c CompiledBacktest) NewRun() Backtest {
 	return Backtest{
 		ID:        c.ID,
 		RunConfig: c.RunConfig,
+		Defaults:  c.Defaults,
 		Request:   &req,
 		State:     &BacktestRun{},
 	}
 }
 
+// ResolvedConfig returns a config holding just this run, with the
+// defaults it was compiled with: loaded and run again, it reproduces the
+// run.
+func (b *Backtest) ResolvedConfig() Config {
+	return Config{
+		Version:  ConfigVersion,
+		Defaults: b.Defaults,
+		Runs:     []RunConfig{b.RunConfig},
+	}
+}
+
 // CompileBacktests converts a loaded Config into validated, immutable
 // backtest definitions. Defaults are applied during construction so execution
-// only deals with already-compiled requests.
+// only deals with already-compiled requests. Errors are errs.ErrBadConfig.
 func CompileBacktests(cfg *Config) ([]CompiledBacktest, error) {
+	compiled, err := compileBacktests(cfg)
+	if err != nil {
+		return nil, errs.Mark(err, errs.ErrBadConfig)
+	}
+	return compiled, nil
+}
+
+func compileBacktests(cfg *Config) ([]CompiledBacktest, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("nil config")
 	}
@@ -82,9 +187,63 @@ func CompileBacktests(cfg *Config) ([]CompiledBacktest, error) {
 			return nil, err
 		}
 		applyBacktestExecutionDefaults(req, runCfg, cfg.Defaults)
+		if req.StopOn, err = compileStopConditions(cfg.Defaults.StopOn); err != nil {
+			return nil, fmt.Errorf("build stop conditions for %q: %w", runCfg.Name, err)
+		}
+		if req.Robustness, err = compileRobustness(cfg.Defaults.Robustness); err != nil {
+			return nil, fmt.Errorf("build robustness runs for %q: %w", runCfg.Name, err)
+		}
+		if req.CrossValidation, err = compileCrossValidation(cfg.Defaults.CrossValidation); err != nil {
+			return nil, fmt.Errorf("build cross-validation for %q: %w", runCfg.Name, err)
+		}
+		if req.Governor, err = compileGovernor(cfg.Defaults.Governor); err != nil {
+			return nil, fmt.Errorf("build governor for %q: %w", runCfg.Name, err)
+		}
+		if strategy.RequiresSafetyLimits(req.Strategy) && !req.Governor.HasSafetyLimits() {
+			return nil, fmt.Errorf("build governor for %q: strategy %q requires governor %s and %s", runCfg.Name, runCfg.Strategy.Kind, RuleMaxOpenTrades, RuleMaxExposure)
+		}
+		if req.EquityCurve, err = compileEquityCurve(runCfg.equityCurve(cfg.Defaults)); err != nil {
+			return nil, fmt.Errorf("build equity curve throttle for %q: %w", runCfg.Name, err)
+		}
+		if req.Margin, err = cfg.Defaults.Margin.Compile(); err != nil {
+			return nil, fmt.Errorf("build margin for %q: %w", runCfg.Name, err)
+		}
+		if req.Liquidity, err = compileLiquidity(cfg.Defaults.Execution.Liquidity); err != nil {
+			return nil, fmt.Errorf("build liquidity for %q: %w", runCfg.Name, err)
+		}
+		if req.Fill, err = compileFill(cfg.Defaults.Execution); err != nil {
+			return nil, fmt.Errorf("build fill model for %q: %w", runCfg.Name, err)
+		}
+		if req.Entry, err = compileEntry(cfg.Defaults.Execution.Entry); err != nil {
+			return nil, fmt.Errorf("build entry orders for %q: %w", runCfg.Name, err)
+		}
+		if req.MinStops, err = compileMinStops(cfg.Defaults.Execution.MinStops); err != nil {
+			return nil, fmt.Errorf("build min-stops for %q: %w", runCfg.Name, err)
+		}
+		if req.SpreadGuard, err = compileSpreadGuard(cfg.Defaults.Execution.SpreadGuard, req.Instrument); err != nil {
+			return nil, fmt.Errorf("build spread guard for %q: %w", runCfg.Name, err)
+		}
+		if req.MarketHours, err = compileMarketHours(cfg.Defaults.MarketHours); err != nil {
+			return nil, fmt.Errorf("build market hours for %q: %w", runCfg.Name, err)
+		}
+		if req.Benchmark, err = compileBenchmark(cfg.Defaults.Benchmark); err != nil {
+			return nil, fmt.Errorf("build benchmark for %q: %w", runCfg.Name, err)
+		}
+		if req.PropFirm, err = compilePropFirm(cfg.Defaults.PropFirm, req.StartingBalance); err != nil {
+			return nil, fmt.Errorf("build prop-firm rules for %q: %w", runCfg.Name, err)
+		}
+		if req.ReportLocation, err = compileReportLocation(cfg.Defaults.Report); err != nil {
+			return nil, fmt.Errorf("build report settings for %q: %w", runCfg.Name, err)
+		}
+		if req.ReportCurrency, err = compileReportCurrency(cfg.Defaults.Report); err != nil {
+			return nil, fmt.Errorf("build report settings for %q: %w", runCfg.Name, err)
+		}
+		req.AuditSizing = cfg.Defaults.AuditSizing
+		req.JournalSignals = cfg.Defaults.JournalSignals
 		compiled = append(compiled, CompiledBacktest{
 			ID:        idgen.NewULID(),
 			RunConfig: runCfg,
+			Defaults:  cfg.Defaults,
 			Request:   *req,
 		})
 	}
@@ -104,17 +263,61 @@ type BacktestRequest struct {
 	StartingBalance types.Money
 	RiskPct         types.Rate // fraction of equity risked per trade (e.g. 0.005 = 0.5 %)
 
-	DefaultStopPips types.Pips // fallback stop distance when the strategy doesn't supply one
-	DefaultTakePips types.Pips // fallback take-profit distance
-	SlippagePips    types.Pips // extra adverse fill adjustment applied on every open/close
-	MaxSpreadPips   types.Pips // opens are skipped when the candle spread exceeds this
+	DefaultStopPips types.Pips             // fallback stop distance when the strategy doesn't supply one
+	DefaultTakePips types.Pips             // fallback take-profit distance
+	SlippagePips    types.Pips             // extra adverse fill adjustment applied on every open/close
+	MaxSpreadPips   types.Pips             // opens are skipped when the candle spread exceeds this
+	StopOn          StopConditions         // early-stop conditions; zero means run to the end
+	Robustness      RobustnessPlan         // perturbed reruns to make after this run; zero means none
+	CrossValidation CrossValidationPlan    // train/test split reruns to make after this run; zero means none
+	Governor        GovernorRules          // entry frequency and position limits; zero means none
+	EquityCurve     EquityCurveRules       // risk throttle on the run's own equity curve; zero means none
+	Liquidity       []LiquidityLevel       // book depth per bar; nil means infinite liquidity
+	Fill            FillModel              // when strategy orders fill; zero fills them on the deciding bar
+	Entry           EntryModel             // order type entries are sent as; zero sends market orders
+	MinStops        *sim.MinStopDistance   // least stop/take distance from the market; nil allows any
+	SpreadGuard     SpreadGuardRules       // entries blocked on wide spreads; zero blocks none
+	Margin          account.MarginSchedule // leverage, margin rates and closeout; zero uses instrument defaults
+	MarketHours     *sim.MarketHours       // trading calendar and swap the sim enforces; nil trades around the clock
+	IdleInterest    types.Rate             // annual rate credited on idle cash at each rollover; zero pays none
+	Benchmark       BenchmarkPlan          // what the report compares the run with; zero means nothing
+	PropFirm        PropFirmRules          // funded-account rules the run is evaluated against; zero means none
+	ReportLocation  *time.Location         // zone of the report's by-hour/by-weekday tables; nil means UTC
+	ReportCurrency  ReportCurrencyPlan     // currency the report's money is stated in; zero means the account's
+	AuditSizing     bool                   // log every sizing's account.SizingAudit
+	JournalSignals  bool                   // record every entry signal's fate in BacktestRun.Signals
 
-	Source     string // data source identifier (e.g. "candles", "dukascopy")
+	// Perturb and Seed are set only on the perturbed reruns built by
+	// CompiledBacktest.PerturbedRuns; the base run is never perturbed.
+	Perturb Perturbation
+	Seed    int64
+
+	Source     string // data feed kind (e.g. "candles", "dukascopy", "csv"); see datamanager.NewFeed
 	Instrument string // FX pair (e.g. "EUR_USD")
 	Strategy   strategy.Strategy
 	Exit       strategy.ExitStrategy
 	Regime     strategy.RegimeFilter
 	TimeRange  types.TimeRange
+
+	// SourceParams are the data feed's own settings (a file path, a seed).
+	SourceParams map[string]any
+
+	// Download fetches the months of the run's data missing from the
+	// store before they are read; see TraderBacktestExecutor.CandleProviders.
+	Download bool
+
+	// HigherTF, when non-zero, is the timeframe of the confirmation feed
+	// aggregated from the run's bars; HTFWarmup completed bars of it are
+	// required before new entries are allowed.
+	HigherTF  types.Timeframe
+	HTFWarmup int
+
+	// Slice drops bars outside its calendar slices; zero keeps them all.
+	Slice CalendarSlice
+
+	// Warmup is the number of bars before TimeRange.Start preloaded into
+	// the strategy and indicators; they are never traded.
+	Warmup int
 }
 
 // compileBacktestComponents resolves the time range and builds the strategy,
@@ -125,6 +328,21 @@ func compileBacktestComponents(cfg RunConfig) (*BacktestRequest, error) {
 		return nil, fmt.Errorf("build backtest time range for %q: %w", cfg.Name, err)
 	}
 
+	htf, htfWarmup, err := compileHigherTimeframe(cfg.Data.HigherTimeframe, tr.TF)
+	if err != nil {
+		return nil, fmt.Errorf("build higher timeframe for %q: %w", cfg.Name, err)
+	}
+
+	slice, err := compileSlice(cfg.Data.Slice)
+	if err != nil {
+		return nil, fmt.Errorf("build data slice for %q: %w", cfg.Name, err)
+	}
+
+	warmup, err := compileWarmup(cfg.Data.Warmup)
+	if err != nil {
+		return nil, fmt.Errorf("build data warm-up for %q: %w", cfg.Name, err)
+	}
+
 	strat, err := strategy.GetStrategy(cfg.Strategy)
 	if err != nil {
 		return nil, fmt.Errorf("build backtest strategy for %q: %w", cfg.Name, err)
@@ -143,13 +361,19 @@ func compileBacktestComponents(cfg RunConfig) (*BacktestRequest, error) {
 
 	source := firstNonEmpty(cfg.Data.Source, "candles")
 	return &BacktestRequest{
-		Name:       cfg.Name,
-		Source:     source,
-		Instrument: cfg.Data.Instrument,
-		Strategy:   strat,
-		Exit:       exit,
-		Regime:     regime,
-		TimeRange:  tr,
+		Name:         cfg.Name,
+		Source:       source,
+		SourceParams: cfg.Data.Params,
+		Download:     cfg.Data.Download,
+		Instrument:   cfg.Data.Instrument,
+		Strategy:     strat,
+		Exit:         exit,
+		Regime:       regime,
+		TimeRange:    tr,
+		HigherTF:     htf,
+		HTFWarmup:    htfWarmup,
+		Slice:        slice,
+		Warmup:       warmup,
 	}, nil
 }
 
@@ -164,8 +388,9 @@ func applyBacktestExecutionDefaults(req *BacktestRequest, cfg RunConfig, default
 	req.RiskPct = types.RateFromFloat(defaults.RiskPct / 100.0)
 	req.DefaultStopPips = types.PipsFromFloat(float64(defaults.StopPips))
 	req.DefaultTakePips = types.PipsFromFloat(float64(defaults.TakePips))
-	req.SlippagePips = types.PipsFromFloat(defaults.SlippagePips)
-	req.MaxSpreadPips = types.PipsFromFloat(defaults.MaxSpreadPips)
+	req.SlippagePips = types.PipsFromFloat(defaults.Execution.SlippagePips)
+	req.MaxSpreadPips = types.PipsFromFloat(defaults.Execution.MaxSpreadPips)
+	req.IdleInterest = types.RateFromFloat(defaults.IdleInterestPct / 100.0)
 }
 
 // BuildBacktestResult snapshots the account state into a BacktestResult and
@@ -184,18 +409,51 @@ func (run *Backtest) BuildBacktestResult(acct *account.Account) *BacktestResult
 		run.State = &BacktestRun{}
 	}
 
-	run.State.Trades = append(run.State.Trades[:0], acct.Trades...)
+	trades := acct.Trades
+	start, balance, equity, interest := run.Request.StartingBalance, acct.Balance, acct.Equity, acct.Interest
+	points := run.State.equity
+	var currency string
+	if conv := run.State.conversion; conv != nil {
+		end := run.Request.TimeRange.End
+		if run.State.StoppedAt != 0 {
+			end = run.State.StoppedAt
+		}
+		c := conv.convertRun(trades, start, balance, equity, points, run.Request.TimeRange.Start, end)
+		trades, start, balance, equity = c.trades, c.start, c.balance, c.equity
+		interest = conv.money(interest, end)
+		points, currency = c.points, conv.To
+		run.State.reportEquity = points
+	}
+	run.State.Trades = append(run.State.Trades[:0], trades...)
 
 	res := &BacktestResult{
 		Start:        run.Request.TimeRange.Start,
 		End:          run.Request.TimeRange.End,
-		StartBalance: run.Request.StartingBalance,
-		Balance:      acct.Balance,
-		Equity:       acct.Equity,
+		Currency:     currency,
+		StartBalance: start,
+		Balance:      balance,
+		Equity:       equity,
+		Interest:     interest,
+		StopReason:   run.State.StopReason,
+		Interrupted:  run.State.Interrupted,
+		Exposure:     run.State.ExposurePeaks(),
+		Skipped:      run.State.skippedByRule(),
+		Filtered:     run.State.Filtered,
+		Rejected:     run.State.rejectedByReason(),
+		Signals:      signalGroups(run.State.Signals),
+		Benchmark:    compareBenchmark(run.Request.Benchmark, points),
+		PropFirm:     run.State.propFirm.result(),
+		Rolling:      rollingMetrics(trades, rollingWindowDays),
+		ByTime:       breakdownByTime(trades, run.Request.ReportLocation),
+		ByVolatility: breakdownByVolatility(trades),
+	}
+	res.SpreadGuarded = run.State.spreadGuardedByRule()
+	if run.State.StoppedAt != 0 {
+		res.End = run.State.StoppedAt
 	}
 
 	var running, peak types.Money
-	for _, tr := range acct.Trades {
+	for _, tr := range trades {
 		if tr == nil {
 			continue
 		}
@@ -220,7 +478,7 @@ func (run *Backtest) BuildBacktestResult(acct *account.Account) *BacktestResult
 		}
 	}
 
-	res.NetPL = acct.Balance - res.StartBalance
+	res.NetPL = res.Balance - res.StartBalance
 	if res.StartBalance != 0 {
 		res.ReturnPct = types.RateFromFloat(res.NetPL.Float64() / res.StartBalance.Float64())
 		res.MaxDrawdownPct = types.RateFromFloat(res.MaxDrawdown.Float64() / res.StartBalance.Float64())
@@ -240,6 +498,7 @@ func (run *Backtest) BuildBacktestResult(acct *account.Account) *BacktestResult
 	if res.AvgLoser < 0 {
 		res.RR = types.RateFromFloat(res.AvgWinner.Float64() / -res.AvgLoser.Float64())
 	}
+	res.Sharpe = sharpeRatio(points)
 
 	run.Result = res
 	return run.Result
@@ -0,0 +1,296 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// Benchmark kinds accepted in BenchmarkConfig.Kind.
+const (
+	BenchmarkBuyAndHold = "buy-and-hold"
+	BenchmarkCSV        = "csv"
+)
+
+// benchmarkPeriodsPerYear annualises the daily benchmark statistics.
+const benchmarkPeriodsPerYear = 252
+
+// BenchmarkConfig asks for every run to be judged against a benchmark:
+// buying and holding the run's instrument over the same period, or a daily
+// returns series read from a CSV file. The report then carries the
+// benchmark's return, the strategy's alpha and beta against it, the
+// information ratio, and the relative equity curve. The benchmark does not
+// change execution, so it is not part of the config hash.
+type BenchmarkConfig struct {
+	Kind string `json:"kind,omitempty" yaml:"kind"` // buy-and-hold or csv
+	// Path is the returns CSV for kind csv: one "YYYY-MM-DD,return" row per
+	// day, the return a fraction (0.01 is 1%). A header row is allowed.
+	Path string `json:"path,omitempty" yaml:"path"`
+	// RiskFreeRate is the annual risk-free rate in percent, e.g. 4.5. Alpha
+	// and beta are computed on returns in excess of it.
+	RiskFreeRate float64 `json:"risk-free-rate,omitempty" yaml:"risk-free-rate"`
+}
+
+// IsZero reports whether no benchmark is configured.
+func (c BenchmarkConfig) IsZero() bool {
+	return c == BenchmarkConfig{}
+}
+
+// BenchmarkPlan is the compiled form of BenchmarkConfig. A zero Kind means
+// no benchmark.
+type BenchmarkPlan struct {
+	Kind     string
+	Name     string                // label for reports
+	RiskFree types.Rate            // annual, RateScale-scaled fraction
+	Returns  map[string]types.Rate // kind csv: daily returns by YYYY-MM-DD
+}
+
+// compileBenchmark validates cfg and converts it to a BenchmarkPlan,
+// reading the returns file for kind csv.
+func compileBenchmark(cfg BenchmarkConfig) (BenchmarkPlan, error) {
+	if cfg.IsZero() {
+		return BenchmarkPlan{}, nil
+	}
+	if cfg.RiskFreeRate < 0 || cfg.RiskFreeRate >= 100 {
+		return BenchmarkPlan{}, fmt.Errorf("risk-free-rate must be in [0, 100), got %v", cfg.RiskFreeRate)
+	}
+	plan := BenchmarkPlan{
+		Kind:     strings.ToLower(strings.TrimSpace(cfg.Kind)),
+		RiskFree: types.RateFromFloat(cfg.RiskFreeRate / 100),
+	}
+	switch plan.Kind {
+	case BenchmarkBuyAndHold:
+		if cfg.Path != "" {
+			return BenchmarkPlan{}, fmt.Errorf("benchmark path is only used with kind %q", BenchmarkCSV)
+		}
+		plan.Name = BenchmarkBuyAndHold
+	case BenchmarkCSV:
+		if cfg.Path == "" {
+			return BenchmarkPlan{}, fmt.Errorf("benchmark kind %q needs a path", BenchmarkCSV)
+		}
+		returns, err := readBenchmarkReturns(cfg.Path)
+		if err != nil {
+			return BenchmarkPlan{}, err
+		}
+		plan.Name = filepath.Base(cfg.Path)
+		plan.Returns = returns
+	default:
+		return BenchmarkPlan{}, fmt.Errorf("bad benchmark kind %q (use %s or %s)", cfg.Kind, BenchmarkBuyAndHold, BenchmarkCSV)
+	}
+	return plan, nil
+}
+
+// readBenchmarkReturns reads a "date,return" CSV into daily returns keyed
+// by date. A first row whose return does not parse is taken as a header.
+func readBenchmarkReturns(path string) (map[string]types.Rate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open benchmark %q: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	out := make(map[string]types.Rate)
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read benchmark %q: %w", path, err)
+		}
+		ret, perr := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		if perr != nil && line == 1 {
+			continue
+		}
+		if perr != nil || math.IsNaN(ret) || math.IsInf(ret, 0) {
+			return nil, fmt.Errorf("benchmark %q line %d: bad return %q", path, line, rec[1])
+		}
+		day, err := time.Parse("2006-01-02", strings.TrimSpace(rec[0]))
+		if err != nil {
+			return nil, fmt.Errorf("benchmark %q line %d: bad date %q", path, line, rec[0])
+		}
+		out[day.Format("2006-01-02")] = types.RateFromFloat(ret)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("benchmark %q has no returns", path)
+	}
+	return out, nil
+}
+
+// equityPoint is the run's state at the last bar close of one UTC day.
+type equityPoint struct {
+	Day    types.Timestamp // midnight UTC
+	Equity types.Money
+	Close  types.Price
+}
+
+// trackEquity records equity and close at ts, replacing the previous
+// sample when it falls on the same UTC day, so the run keeps one point per
+// day it traded.
+func (run *BacktestRun) trackEquity(ts types.Timestamp, equity types.Money, close types.Price) {
+	day := ts - ts%secondsPerDay
+	p := equityPoint{Day: day, Equity: equity, Close: close}
+	if n := len(run.equity); n > 0 && run.equity[n-1].Day == day {
+		run.equity[n-1] = p
+		return
+	}
+	run.equity = append(run.equity, p)
+}
+
+// BenchmarkResult compares a run's daily returns with its benchmark's over
+// the days both have a return for.
+type BenchmarkResult struct {
+	Name     string
+	RiskFree types.Rate // annual
+	Days     int        // daily returns compared
+
+	Return           types.Rate // benchmark's compounded return over Days
+	StrategyReturn   types.Rate // the run's compounded return over Days
+	Alpha            types.Rate // annualised excess return not explained by Beta
+	Beta             types.Rate // sensitivity of the run's excess returns to the benchmark's
+	InformationRatio types.Rate // annualised mean active return over its standard deviation
+
+	// Curve is the relative equity curve: both series indexed to 1 before
+	// the first compared day, and their ratio.
+	Curve []RelativePoint
+}
+
+// RelativePoint is one day of the relative equity curve.
+type RelativePoint struct {
+	Day       types.Timestamp
+	Strategy  types.Rate // growth of 1 in the run
+	Benchmark types.Rate // growth of 1 in the benchmark
+	Relative  types.Rate // Strategy / Benchmark
+}
+
+// compareBenchmark computes plan's benchmark statistics from the run's
+// daily samples. It returns nil when no benchmark is configured.
+func compareBenchmark(plan BenchmarkPlan, points []equityPoint) *BenchmarkResult {
+	if plan.Kind == "" {
+		return nil
+	}
+	out := &BenchmarkResult{Name: plan.Name, RiskFree: plan.RiskFree}
+
+	var days []types.Timestamp
+	var strat, bench []float64
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1], points[i]
+		if prev.Equity <= 0 {
+			continue
+		}
+		var b float64
+		switch plan.Kind {
+		case BenchmarkBuyAndHold:
+			if prev.Close <= 0 {
+				continue
+			}
+			b = cur.Close.Float64()/prev.Close.Float64() - 1
+		case BenchmarkCSV:
+			r, ok := plan.Returns[cur.Day.Time().Format("2006-01-02")]
+			if !ok {
+				continue
+			}
+			b = r.Float64()
+		}
+		days = append(days, cur.Day)
+		strat = append(strat, cur.Equity.Float64()/prev.Equity.Float64()-1)
+		bench = append(bench, b)
+	}
+	out.Days = len(days)
+	if out.Days == 0 {
+		return out
+	}
+
+	sIdx, bIdx := 1.0, 1.0
+	out.Curve = make([]RelativePoint, 0, len(days))
+	for i, day := range days {
+		sIdx *= 1 + strat[i]
+		bIdx *= 1 + bench[i]
+		p := RelativePoint{Day: day, Strategy: types.RateFromFloat(sIdx), Benchmark: types.RateFromFloat(bIdx)}
+		if bIdx > 0 {
+			p.Relative = types.RateFromFloat(sIdx / bIdx)
+		}
+		out.Curve = append(out.Curve, p)
+	}
+	out.StrategyReturn = types.RateFromFloat(sIdx - 1)
+	out.Return = types.RateFromFloat(bIdx - 1)
+
+	alpha, beta, ir := benchmarkStats(strat, bench, plan.RiskFree.Float64()/benchmarkPeriodsPerYear)
+	out.Alpha = types.RateFromFloat(alpha * benchmarkPeriodsPerYear)
+	out.Beta = types.RateFromFloat(beta)
+	out.InformationRatio = types.RateFromFloat(ir * math.Sqrt(benchmarkPeriodsPerYear))
+	return out
+}
+
+// sharpeRatio returns the annualised Sharpe ratio of the daily returns
+// between points, with a zero risk-free rate. It is zero with fewer than
+// two returns or when they do not vary.
+func sharpeRatio(points []equityPoint) types.Rate {
+	var rets []float64
+	for i := 1; i < len(points); i++ {
+		if prev := points[i-1].Equity; prev > 0 {
+			rets = append(rets, points[i].Equity.Float64()/prev.Float64()-1)
+		}
+	}
+	if len(rets) < 2 {
+		return 0
+	}
+	n := float64(len(rets))
+	var mean float64
+	for _, r := range rets {
+		mean += r
+	}
+	mean /= n
+	var ss float64
+	for _, r := range rets {
+		ss += (r - mean) * (r - mean)
+	}
+	if ss == 0 {
+		return 0
+	}
+	return types.RateFromFloat(mean / math.Sqrt(ss/(n-1)) * math.Sqrt(benchmarkPeriodsPerYear))
+}
+
+// benchmarkStats returns the per-period alpha, beta and information ratio
+// of strat against bench, both per-period returns, with rf the per-period
+// risk-free rate. Statistics that need a variance come out zero when that
+// variance is zero.
+func benchmarkStats(strat, bench []float64, rf float64) (alpha, beta, ir float64) {
+	n := float64(len(strat))
+	var ms, mb, ma float64
+	for i := range strat {
+		ms += strat[i] - rf
+		mb += bench[i] - rf
+		ma += strat[i] - bench[i]
+	}
+	ms, mb, ma = ms/n, mb/n, ma/n
+
+	var cov, varB, varA float64
+	for i := range strat {
+		ds, db := strat[i]-rf-ms, bench[i]-rf-mb
+		da := strat[i] - bench[i] - ma
+		cov += ds * db
+		varB += db * db
+		varA += da * da
+	}
+	if varB > 0 {
+		beta = cov / varB
+	}
+	alpha = ms - beta*mb
+	if len(strat) > 1 && varA > 0 {
+		ir = ma / math.Sqrt(varA/(n-1))
+	}
+	return alpha, beta, ir
+}
@@ -0,0 +1,158 @@
+package backtest
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const benchDay = types.Timestamp(1704067200) // 2024-01-01 00:00 UTC
+
+func TestCompileBenchmark(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "spx.csv")
+	require.NoError(t, os.WriteFile(good, []byte("date,return\n2024-01-02,0.01\n2024-01-03,-0.005\n"), 0o644))
+	bad := filepath.Join(dir, "bad.csv")
+	require.NoError(t, os.WriteFile(bad, []byte("2024-01-02,0.01\n2024-01-03,x\n"), 0o644))
+
+	tests := []struct {
+		name    string
+		cfg     BenchmarkConfig
+		wantErr string
+	}{
+		{name: "zero is disabled", cfg: BenchmarkConfig{}},
+		{name: "unknown kind", cfg: BenchmarkConfig{Kind: "index"}, wantErr: "bad benchmark kind"},
+		{name: "rate without kind", cfg: BenchmarkConfig{RiskFreeRate: 4}, wantErr: "bad benchmark kind"},
+		{name: "negative rate", cfg: BenchmarkConfig{Kind: "buy-and-hold", RiskFreeRate: -1}, wantErr: "risk-free-rate"},
+		{name: "csv without path", cfg: BenchmarkConfig{Kind: "csv"}, wantErr: "needs a path"},
+		{name: "path with buy-and-hold", cfg: BenchmarkConfig{Kind: "buy-and-hold", Path: good}, wantErr: "only used with kind"},
+		{name: "bad return", cfg: BenchmarkConfig{Kind: "csv", Path: bad}, wantErr: "line 2: bad return"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileBenchmark(tt.cfg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	plan, err := compileBenchmark(BenchmarkConfig{Kind: "CSV", Path: good, RiskFreeRate: 4.5})
+	require.NoError(t, err)
+	assert.Equal(t, BenchmarkPlan{
+		Kind:     BenchmarkCSV,
+		Name:     "spx.csv",
+		RiskFree: types.RateFromFloat(0.045),
+		Returns: map[string]types.Rate{
+			"2024-01-02": types.RateFromFloat(0.01),
+			"2024-01-03": types.RateFromFloat(-0.005),
+		},
+	}, plan)
+}
+
+func TestTrackEquity_OnePointPerDay(t *testing.T) {
+	run := &BacktestRun{}
+	run.trackEquity(benchDay+3600, types.MoneyFromFloat(100), types.PriceFromFloat(1.1))
+	run.trackEquity(benchDay+7200, types.MoneyFromFloat(101), types.PriceFromFloat(1.2))
+	run.trackEquity(benchDay+86400+60, types.MoneyFromFloat(102), types.PriceFromFloat(1.3))
+
+	assert.Equal(t, []equityPoint{
+		{Day: benchDay, Equity: types.MoneyFromFloat(101), Close: types.PriceFromFloat(1.2)},
+		{Day: benchDay + 86400, Equity: types.MoneyFromFloat(102), Close: types.PriceFromFloat(1.3)},
+	}, run.equity)
+}
+
+// benchPoints builds daily samples whose equity moves by twice the close's
+// daily return plus a constant edge.
+func benchPoints(closeReturns []float64, edge float64) []equityPoint {
+	equity, price := 10_000.0, 1.0
+	points := []equityPoint{{Day: benchDay, Equity: types.MoneyFromFloat(equity), Close: types.PriceFromFloat(price)}}
+	for i, r := range closeReturns {
+		price *= 1 + r
+		equity *= 1 + 2*r + edge
+		points = append(points, equityPoint{
+			Day:    benchDay + types.Timestamp(86400*(i+1)),
+			Equity: types.MoneyFromFloat(equity),
+			Close:  types.PriceFromFloat(price),
+		})
+	}
+	return points
+}
+
+func TestCompareBenchmark_BuyAndHold(t *testing.T) {
+	assert.Nil(t, compareBenchmark(BenchmarkPlan{}, nil))
+
+	points := benchPoints([]float64{0.01, -0.02, 0.015, 0.005, -0.01}, 0.001)
+	res := compareBenchmark(BenchmarkPlan{Kind: BenchmarkBuyAndHold, Name: BenchmarkBuyAndHold}, points)
+	require.NotNil(t, res)
+
+	assert.Equal(t, 5, res.Days)
+	assert.InDelta(t, 2, res.Beta.Float64(), 0.01)
+	assert.InDelta(t, 0.001*benchmarkPeriodsPerYear, res.Alpha.Float64(), 0.01)
+	assert.Greater(t, res.InformationRatio.Float64(), 0.0)
+	require.Len(t, res.Curve, 5)
+
+	last := res.Curve[4]
+	assert.Equal(t, benchDay+5*86400, last.Day)
+	assert.InDelta(t, points[5].Close.Float64(), last.Benchmark.Float64(), 1e-4)
+	assert.InDelta(t, points[5].Equity.Float64()/10_000, last.Strategy.Float64(), 1e-4)
+	assert.InDelta(t, last.Strategy.Float64()/last.Benchmark.Float64(), last.Relative.Float64(), 1e-4)
+	assert.InDelta(t, last.Benchmark.Float64()-1, res.Return.Float64(), 1e-6)
+}
+
+func TestCompareBenchmark_CSVUsesMatchingDaysOnly(t *testing.T) {
+	points := benchPoints([]float64{0.01, -0.02, 0.015}, 0)
+	plan := BenchmarkPlan{
+		Kind: BenchmarkCSV,
+		Name: "spx.csv",
+		Returns: map[string]types.Rate{
+			"2024-01-02": types.RateFromFloat(0.01),
+			"2024-01-04": types.RateFromFloat(0.015),
+		},
+	}
+	res := compareBenchmark(plan, points)
+	require.NotNil(t, res)
+	assert.Equal(t, 2, res.Days)
+	require.Len(t, res.Curve, 2)
+	assert.Equal(t, benchDay+3*86400, res.Curve[1].Day)
+}
+
+func TestPrintSummary_Benchmark(t *testing.T) {
+	var buf bytes.Buffer
+	PrintSummary(&buf, BacktestReportSummary{
+		Strategy: "ema-cross",
+		Benchmark: &BacktestReportBenchmark{
+			Name: "buy-and-hold", Days: 250, RiskFreePct: 4, ReturnPct: 3.5,
+			StrategyReturnPct: 8.25, AlphaPct: 2.1, Beta: 0.4, InformationRatio: 0.75,
+		},
+	})
+	assert.Contains(t, buf.String(), "Benchmark: buy-and-hold +3.50%   Strategy: +8.25%   (250 days, rf 4.00%)")
+	assert.Contains(t, buf.String(), "Alpha: +2.10%/yr   Beta: 0.40   IR: 0.75")
+}
+
+func TestSharpeRatio(t *testing.T) {
+	eq := func(vals ...float64) []equityPoint {
+		out := make([]equityPoint, len(vals))
+		for i, v := range vals {
+			out[i] = equityPoint{Day: types.Timestamp(i) * secondsPerDay, Equity: types.MoneyFromFloat(v)}
+		}
+		return out
+	}
+	assert.Zero(t, sharpeRatio(nil))
+	assert.Zero(t, sharpeRatio(eq(100, 101)), "one return has no deviation")
+	assert.Zero(t, sharpeRatio(eq(100, 100, 100)), "flat equity")
+
+	// Returns +1%, -0.5%, +1%: mean 0.5%, sample std 0.866%.
+	got := sharpeRatio(eq(100, 101, 100.495, 101.49995)).Float64()
+	assert.InDelta(t, 0.005/0.00866025*math.Sqrt(252), got, 0.01)
+
+	assert.Negative(t, sharpeRatio(eq(100, 99, 98.5, 97)).Float64())
+}
@@ -0,0 +1,109 @@
+package backtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+)
+
+// ReportConfig holds settings that change how reports present a run but
+// not the run itself, so they are not part of the config hash.
+type ReportConfig struct {
+	// Timezone is the IANA zone (e.g. America/New_York) the by-hour and
+	// by-weekday breakdowns are bucketed in. Empty means UTC.
+	Timezone string `json:"timezone,omitempty" yaml:"timezone"`
+
+	// Currency is the ISO code (e.g. EUR) the report's money is stated
+	// in. Empty means the account currency.
+	Currency string `json:"currency,omitempty" yaml:"currency"`
+
+	// Rates is a CSV file of "date,rate" rows giving units of Currency
+	// per unit of the account currency. Empty converts with the closes
+	// of the pair joining the two from the run's data source.
+	Rates string `json:"rates,omitempty" yaml:"rates"`
+}
+
+// compileReportLocation resolves cfg.Timezone.
+func compileReportLocation(cfg ReportConfig) (*time.Location, error) {
+	name := strings.TrimSpace(cfg.Timezone)
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("bad report timezone %q: %w", cfg.Timezone, err)
+	}
+	return loc, nil
+}
+
+// TimeBucket is the closed trades entered in one hour of the day or one
+// day of the week.
+type TimeBucket struct {
+	Trades int
+	Wins   int
+	PNL    types.Money
+}
+
+// TimeBreakdown buckets a run's closed trades by the local hour and weekday
+// of their entry, so session filters can be tuned from what happened.
+type TimeBreakdown struct {
+	Location  *time.Location
+	ByHour    [24]TimeBucket
+	ByWeekday [7]TimeBucket // indexed by time.Weekday, Sunday first
+}
+
+// breakdownByTime buckets trades by entry time in loc (UTC when nil). It
+// returns nil when there are no closed trades.
+func breakdownByTime(trades []*account.Trade, loc *time.Location) *TimeBreakdown {
+	if loc == nil {
+		loc = time.UTC
+	}
+	var out *TimeBreakdown
+	for _, tr := range trades {
+		if tr == nil {
+			continue
+		}
+		if out == nil {
+			out = &TimeBreakdown{Location: loc}
+		}
+		at := tr.EntryTime.Time().In(loc)
+		for _, b := range []*TimeBucket{&out.ByHour[at.Hour()], &out.ByWeekday[at.Weekday()]} {
+			b.Trades++
+			b.PNL += tr.PNL
+			if tr.PNL > 0 {
+				b.Wins++
+			}
+		}
+	}
+	return out
+}
+
+// breakdownByVolatility buckets trades by the volatility regime they were
+// entered in ("" for trades entered before the classifier was warm). It
+// returns nil when there are no closed trades.
+func breakdownByVolatility(trades []*account.Trade) map[string]TimeBucket {
+	var out map[string]TimeBucket
+	for _, tr := range trades {
+		if tr == nil {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]TimeBucket)
+		}
+		var regime string
+		if tr.TradeCommon != nil {
+			regime = tr.VolRegime
+		}
+		b := out[regime]
+		b.Trades++
+		b.PNL += tr.PNL
+		if tr.PNL > 0 {
+			b.Wins++
+		}
+		out[regime] = b
+	}
+	return out
+}
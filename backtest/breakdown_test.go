@@ -0,0 +1,100 @@
+package backtest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entryTrade(at time.Time, pnl float64) *account.Trade {
+	tr := &account.Trade{}
+	tr.EntryTime = types.FromTime(at)
+	tr.PNL = types.MoneyFromFloat(pnl)
+	return tr
+}
+
+func TestCompileReportLocation(t *testing.T) {
+	loc, err := compileReportLocation(ReportConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	loc, err = compileReportLocation(ReportConfig{Timezone: "America/New_York"})
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", loc.String())
+
+	_, err = compileReportLocation(ReportConfig{Timezone: "Mars/Olympus"})
+	require.ErrorContains(t, err, "bad report timezone")
+}
+
+func TestBreakdownByTime(t *testing.T) {
+	assert.Nil(t, breakdownByTime(nil, nil))
+
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// Monday 2024-01-08 13:30 UTC is 08:30 in New York; the Sunday 23:30
+	// UTC entry is still Sunday 18:30 there.
+	trades := []*account.Trade{
+		entryTrade(time.Date(2024, 1, 8, 13, 30, 0, 0, time.UTC), 40),
+		entryTrade(time.Date(2024, 1, 8, 13, 45, 0, 0, time.UTC), -10),
+		nil,
+		entryTrade(time.Date(2024, 1, 7, 23, 30, 0, 0, time.UTC), 5),
+	}
+	b := breakdownByTime(trades, ny)
+	require.NotNil(t, b)
+	assert.Equal(t, TimeBucket{Trades: 2, Wins: 1, PNL: types.MoneyFromFloat(30)}, b.ByHour[8])
+	assert.Equal(t, TimeBucket{Trades: 1, Wins: 1, PNL: types.MoneyFromFloat(5)}, b.ByHour[18])
+	assert.Equal(t, TimeBucket{Trades: 2, Wins: 1, PNL: types.MoneyFromFloat(30)}, b.ByWeekday[time.Monday])
+	assert.Equal(t, 1, b.ByWeekday[time.Sunday].Trades)
+
+	r := b.Report()
+	assert.Equal(t, "America/New_York", r.Timezone)
+	assert.Equal(t, []BacktestReportBucket{
+		{Label: "08", Trades: 2, Wins: 1, WinRate: 50, PNL: 30},
+		{Label: "18", Trades: 1, Wins: 1, WinRate: 100, PNL: 5},
+	}, r.ByHour)
+	require.Len(t, r.ByWeekday, 2)
+	assert.Equal(t, "Mon", r.ByWeekday[0].Label, "weekdays start on Monday")
+	assert.Equal(t, "Sun", r.ByWeekday[1].Label)
+
+	var buf bytes.Buffer
+	WriteOrgReport(&buf, BacktestReportSummary{Strategy: "ema-cross", ByTime: r})
+	assert.Contains(t, buf.String(), "** By Entry Hour (America/New_York)")
+	assert.Contains(t, buf.String(), "** By Entry Weekday (America/New_York)")
+}
+
+func TestBreakdownByVolatility(t *testing.T) {
+	assert.Nil(t, breakdownByVolatility(nil))
+
+	at := time.Date(2024, 1, 8, 13, 30, 0, 0, time.UTC)
+	tagged := func(regime string, pnl float64) *account.Trade {
+		tr := entryTrade(at, pnl)
+		tr.TradeCommon = &account.TradeCommon{VolRegime: regime}
+		return tr
+	}
+	trades := []*account.Trade{
+		tagged(strategy.VolHigh, -20),
+		tagged(strategy.VolLow, 15),
+		entryTrade(at, 5),
+		tagged(strategy.VolHigh, 50),
+	}
+	by := breakdownByVolatility(trades)
+	assert.Equal(t, TimeBucket{Trades: 2, Wins: 1, PNL: types.MoneyFromFloat(30)}, by[strategy.VolHigh])
+
+	r := volatilityReport(by)
+	assert.Equal(t, []BacktestReportBucket{
+		{Label: "low", Trades: 1, Wins: 1, WinRate: 100, PNL: 15},
+		{Label: "high", Trades: 2, Wins: 1, WinRate: 50, PNL: 30},
+		{Label: "unknown", Trades: 1, Wins: 1, WinRate: 100, PNL: 5},
+	}, r, "calm to volatile, unclassified last")
+
+	var buf bytes.Buffer
+	WriteOrgReport(&buf, BacktestReportSummary{Strategy: "ema-cross", ByVolatility: r})
+	assert.Contains(t, buf.String(), "** By Volatility Regime")
+}
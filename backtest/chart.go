@@ -0,0 +1,281 @@
+package backtest
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"time"
+
+	"github.com/rustyeddy/trader/indicator"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// MaxPriceChartBars is the most bars a run keeps closes for. Longer runs
+// get no price chart: a point per bar stops being readable well before
+// that, and the series would cost memory on every bar of a long run.
+const MaxPriceChartBars = 5000
+
+// pricePoint is one bar kept for the price chart: its close, drawn as the
+// line, and its high and low, which support and resistance are found from.
+type pricePoint struct {
+	Time      types.Timestamp
+	Close     types.Price
+	High, Low types.Price
+}
+
+// trackPrice records bar c for the price chart, dropping the series once
+// the run passes MaxPriceChartBars.
+func (run *BacktestRun) trackPrice(c market.Candle) {
+	if run.pricesOver {
+		return
+	}
+	if len(run.prices) >= MaxPriceChartBars {
+		run.prices, run.pricesOver = nil, true
+		return
+	}
+	run.prices = append(run.prices, pricePoint{Time: c.Timestamp, Close: c.Close, High: c.High, Low: c.Low})
+}
+
+// HasPriceChart reports whether the run kept the closes WritePriceSVG
+// draws: it replayed at least one bar and no more than MaxPriceChartBars.
+func (run *Backtest) HasPriceChart() bool {
+	return run != nil && run.State != nil && !run.State.pricesOver && len(run.State.prices) > 0
+}
+
+// WriteEquitySVG draws the run's daily equity curve, the series
+// WriteEquityCSV writes, as an SVG line chart.
+func (run *Backtest) WriteEquitySVG(w io.Writer) error {
+	c := svgChart{Title: run.chartTitle("equity"), Format: formatMoneyAxis}
+	if run != nil && run.State != nil {
+		for _, p := range run.State.equityCurve() {
+			c.Times = append(c.Times, p.Day)
+			c.Values = append(c.Values, p.Equity.Float64())
+		}
+	}
+	return c.write(w)
+}
+
+// WriteDrawdownSVG draws the run's drawdown, each day's equity less the
+// highest equity before it, as a shaded SVG chart at or below zero.
+func (run *Backtest) WriteDrawdownSVG(w io.Writer) error {
+	c := svgChart{Title: run.chartTitle("drawdown"), Format: formatMoneyAxis, Fill: true}
+	if run != nil && run.State != nil {
+		var peak types.Money
+		for i, p := range run.State.equityCurve() {
+			if i == 0 || p.Equity > peak {
+				peak = p.Equity
+			}
+			c.Times = append(c.Times, p.Day)
+			c.Values = append(c.Values, (p.Equity - peak).Float64())
+		}
+	}
+	return c.write(w)
+}
+
+// WritePriceSVG draws the bar closes of a run that HasPriceChart, with a
+// marker at every trade's entry and exit: green triangles for buys, red
+// for sells. The support and resistance zones indicator.DetectLevels finds
+// in the run's last bars, with the default config, are shaded behind the
+// line. A run without a price chart draws an empty one.
+func (run *Backtest) WritePriceSVG(w io.Writer) error {
+	c := svgChart{Title: run.chartTitle("price")}
+	if !run.HasPriceChart() {
+		return c.write(w)
+	}
+	inst := run.Request.Instrument
+	c.Format = func(v float64) string { return market.FormatPrice(inst, v) }
+	bars := make([]market.Candle, 0, len(run.State.prices))
+	for _, p := range run.State.prices {
+		c.Times = append(c.Times, p.Time)
+		c.Values = append(c.Values, p.Close.Float64())
+		bars = append(bars, market.Candle{Timestamp: p.Time, Open: p.Close, High: p.High, Low: p.Low, Close: p.Close})
+	}
+	levels, err := indicator.DetectLevels(bars, indicator.DefaultLevelsConfig(), types.Scale6(types.PriceScale))
+	if err != nil {
+		return err
+	}
+	for _, lv := range levels {
+		c.Bands = append(c.Bands, svgBand{
+			Low: lv.Low.Float64(), High: lv.High.Float64(),
+			Label: fmt.Sprintf("level %s (%d touches)", market.FormatPrice(inst, lv.Price.Float64()), lv.Touches),
+		})
+	}
+	var records []journal.TradeRecord
+	for _, tr := range run.State.GetTrades() {
+		if tr == nil || tr.TradeCommon == nil {
+			continue
+		}
+		units := tr.Units
+		if tr.Side == types.Short && units > 0 {
+			units = -units
+		}
+		records = append(records, journal.TradeRecord{
+			TradeID: tr.ID, Instrument: tr.Instrument, Units: units,
+			EntryPrice: tr.EntryPrice, ExitPrice: tr.ExitPrice,
+			OpenTime: tr.EntryTime, CloseTime: tr.ExitTime,
+			RealizedPL: tr.PNL, Reason: tr.CloseCause.String(),
+		})
+	}
+	for _, m := range journal.TradeMarkers(records, "") {
+		c.Markers = append(c.Markers, svgMarker{
+			Time: m.Time, Value: m.Price.Float64(), Buy: m.Direction == journal.MarkerBuy, Label: m.Label,
+		})
+	}
+	return c.write(w)
+}
+
+// chartTitle names a chart after the run, e.g. "eurusd-ema: equity".
+func (run *Backtest) chartTitle(what string) string {
+	if run == nil || run.Request == nil || run.Request.Name == "" {
+		return what
+	}
+	return run.Request.Name + ": " + what
+}
+
+func formatMoneyAxis(v float64) string { return fmt.Sprintf("%.2f", v) }
+
+// SVG chart geometry, in pixels.
+const (
+	svgWidth   = 800
+	svgHeight  = 300
+	svgLeft    = 80 // room for the value axis labels
+	svgRight   = 20
+	svgTop     = 30
+	svgBottom  = 30
+	svgMarkerH = 7
+)
+
+// svgChart is a single-series time chart rendered as a standalone SVG.
+type svgChart struct {
+	Title   string
+	Times   []types.Timestamp
+	Values  []float64
+	Format  func(float64) string // value axis labels; nil uses %g
+	Fill    bool                 // shade between the line and zero
+	Markers []svgMarker
+	Bands   []svgBand
+}
+
+// svgBand is a horizontal zone shaded across the plot, such as a support
+// or resistance level, with Label as its tooltip. Bands do not widen the
+// value axis; the part outside it is clipped.
+type svgBand struct {
+	Low, High float64
+	Label     string
+}
+
+// svgMarker is a triangle drawn at a point: pointing up below the point
+// for a buy, down above it for a sell, with Label as its tooltip.
+type svgMarker struct {
+	Time  types.Timestamp
+	Value float64
+	Buy   bool
+	Label string
+}
+
+// write renders the chart. An empty series renders the frame with a
+// "no data" note, so report files always exist.
+func (c svgChart) write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		svgWidth, svgHeight, svgWidth, svgHeight)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="white"/>`+"\n", svgWidth, svgHeight)
+	fmt.Fprintf(bw, `<text x="%d" y="18" font-size="13">%s</text>`+"\n", svgLeft, html.EscapeString(c.Title))
+
+	plotW := float64(svgWidth - svgLeft - svgRight)
+	plotH := float64(svgHeight - svgTop - svgBottom)
+	fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%.0f" height="%.0f" fill="none" stroke="#999"/>`+"\n", svgLeft, svgTop, plotW, plotH)
+
+	if len(c.Times) == 0 {
+		fmt.Fprintf(bw, `<text x="%.1f" y="%.1f" text-anchor="middle" fill="#999">no data</text>`+"\n",
+			svgLeft+plotW/2, svgTop+plotH/2)
+		bw.WriteString("</svg>\n")
+		return bw.Flush()
+	}
+
+	t0, t1 := c.Times[0], c.Times[len(c.Times)-1]
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range c.Values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	for _, m := range c.Markers {
+		lo, hi = math.Min(lo, m.Value), math.Max(hi, m.Value)
+		t0, t1 = min(t0, m.Time), max(t1, m.Time)
+	}
+	if c.Fill {
+		lo, hi = math.Min(lo, 0), math.Max(hi, 0)
+	}
+	if hi == lo {
+		pad := math.Max(math.Abs(hi)*0.01, 1)
+		lo, hi = lo-pad, hi+pad
+	} else {
+		pad := (hi - lo) * 0.05
+		lo, hi = lo-pad, hi+pad
+	}
+	span := float64(t1 - t0)
+	if span == 0 {
+		span = 1
+	}
+	x := func(t types.Timestamp) float64 { return svgLeft + float64(t-t0)/span*plotW }
+	y := func(v float64) float64 { return svgTop + (hi-v)/(hi-lo)*plotH }
+	format := c.Format
+	if format == nil {
+		format = func(v float64) string { return fmt.Sprintf("%g", v) }
+	}
+
+	// Value gridlines at the bottom, middle and top, and the date range.
+	for _, v := range []float64{lo, (lo + hi) / 2, hi} {
+		fmt.Fprintf(bw, `<line x1="%d" y1="%.1f" x2="%.0f" y2="%.1f" stroke="#eee"/>`+"\n", svgLeft, y(v), svgLeft+plotW, y(v))
+		fmt.Fprintf(bw, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`+"\n", svgLeft-6, y(v)+4, html.EscapeString(format(v)))
+	}
+	base := float64(svgHeight - svgBottom + 16)
+	fmt.Fprintf(bw, `<text x="%d" y="%.0f">%s</text>`+"\n", svgLeft, base, t0.Time().UTC().Format(time.DateOnly))
+	fmt.Fprintf(bw, `<text x="%.0f" y="%.0f" text-anchor="end">%s</text>`+"\n", svgLeft+plotW, base, t1.Time().UTC().Format(time.DateOnly))
+
+	for _, b := range c.Bands {
+		top, bot := math.Min(b.High, hi), math.Max(b.Low, lo)
+		if top < bot {
+			continue
+		}
+		// A zone of one pivot price still gets a visible sliver.
+		y0, h := y(top), y(bot)-y(top)
+		if h < 2 {
+			y0, h = y0-(2-h)/2, 2
+		}
+		fmt.Fprintf(bw, `<rect x="%d" y="%.1f" width="%.0f" height="%.1f" fill="#ff7f0e" fill-opacity="0.25"><title>%s</title></rect>`+"\n",
+			svgLeft, y0, plotW, h, html.EscapeString(b.Label))
+	}
+
+	if c.Fill {
+		fmt.Fprintf(bw, `<path d="M%.1f %.1f`, x(c.Times[0]), y(0))
+		for i, t := range c.Times {
+			fmt.Fprintf(bw, " L%.1f %.1f", x(t), y(c.Values[i]))
+		}
+		fmt.Fprintf(bw, ` L%.1f %.1f Z" fill="#d62728" fill-opacity="0.3" stroke="#d62728"/>`+"\n", x(c.Times[len(c.Times)-1]), y(0))
+	} else {
+		bw.WriteString(`<polyline fill="none" stroke="#1f77b4" stroke-width="1.5" points="`)
+		for i, t := range c.Times {
+			if i > 0 {
+				bw.WriteByte(' ')
+			}
+			fmt.Fprintf(bw, "%.1f,%.1f", x(t), y(c.Values[i]))
+		}
+		bw.WriteString("\"/>\n")
+	}
+
+	for _, m := range c.Markers {
+		mx, my := x(m.Time), y(m.Value)
+		color, d := "#2ca02c", fmt.Sprintf("M%.1f %.1f l-4 %d h8 z", mx, my+2, svgMarkerH)
+		if !m.Buy {
+			color, d = "#d62728", fmt.Sprintf("M%.1f %.1f l-4 -%d h8 z", mx, my-2, svgMarkerH)
+		}
+		fmt.Fprintf(bw, `<path d="%s" fill="%s"><title>%s</title></path>`+"\n", d, color, html.EscapeString(m.Label))
+	}
+
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,127 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// requireSVG checks out parses as XML with an <svg> root and returns it.
+func requireSVG(t *testing.T, out string) string {
+	t.Helper()
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(out), &doc), out)
+	return out
+}
+
+func chartRun() *Backtest {
+	run := &Backtest{Request: &BacktestRequest{Name: "ema <test>", Instrument: "EURUSD"}, State: &BacktestRun{}}
+	day := types.Timestamp(1772582400) // 2026-03-04
+	for i, eq := range []float64{1000, 1050, 1020, 1060} {
+		run.State.trackEquity(day+types.Timestamp(i)*secondsPerDay, types.MoneyFromFloat(eq), 0)
+	}
+	return run
+}
+
+func TestWriteEquitySVG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, chartRun().WriteEquitySVG(&buf))
+	out := requireSVG(t, buf.String())
+
+	assert.Contains(t, out, "ema &lt;test&gt;: equity", "the title is escaped")
+	assert.Contains(t, out, ">2026-03-04<")
+	assert.Contains(t, out, ">2026-03-07<")
+	i := strings.Index(out, `points="`)
+	require.Positive(t, i)
+	points := strings.Fields(out[i+len(`points="`) : i+strings.Index(out[i:], `"/>`)])
+	assert.Len(t, points, 4)
+	assert.Equal(t, "80.0,", points[0][:5], "the first day is at the left edge")
+}
+
+func TestWriteDrawdownSVG(t *testing.T) {
+	run := chartRun()
+	var buf bytes.Buffer
+	require.NoError(t, run.WriteDrawdownSVG(&buf))
+	out := requireSVG(t, buf.String())
+	assert.Contains(t, out, `fill-opacity="0.3"`)
+	// Drawdowns are 0, 0, -30, 0: the axis runs from below -30 to above 0.
+	assert.Contains(t, out, ">-31.50<")
+	assert.Contains(t, out, ">1.50<")
+}
+
+func TestWriteSVG_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, (&Backtest{}).WriteEquitySVG(&buf))
+	assert.Contains(t, requireSVG(t, buf.String()), "no data")
+}
+
+func TestWritePriceSVG_MarksTrades(t *testing.T) {
+	run := chartRun()
+	start := types.Timestamp(1772582400)
+	for i := 0; i < 10; i++ {
+		px := types.PriceFromFloat(1.1 + float64(i)*0.001)
+		run.State.trackPrice(market.Candle{Timestamp: start + types.Timestamp(i*3600), Close: px, High: px, Low: px})
+	}
+	run.State.Trades = []*account.Trade{{
+		TradeCommon: &account.TradeCommon{ID: "T1", Instrument: "EURUSD", Side: types.Short, Units: 1000},
+		EntryPrice:  types.PriceFromFloat(1.105), EntryTime: start + 5*3600,
+		ExitPrice: types.PriceFromFloat(1.103), ExitTime: start + 8*3600,
+		PNL: types.MoneyFromFloat(2), CloseCause: account.CloseTakeProfit,
+	}}
+	require.True(t, run.HasPriceChart())
+
+	var buf bytes.Buffer
+	require.NoError(t, run.WritePriceSVG(&buf))
+	out := requireSVG(t, buf.String())
+	assert.Contains(t, out, "ema &lt;test&gt;: price")
+	assert.Contains(t, out, `fill="#d62728"><title>Short entry T1</title>`, "a short enters with a sell")
+	assert.Contains(t, out, `fill="#2ca02c"><title>Short exit T1 +2.00 TakeProfit</title>`)
+	assert.Contains(t, out, ">1.10945<", "axis labels use the instrument's precision")
+}
+
+func TestWritePriceSVG_ShadesLevels(t *testing.T) {
+	run := chartRun()
+	start := types.Timestamp(1772582400)
+	var bars []types.Price
+	for range 4 {
+		for p := types.Price(110_000); p < 111_000; p += 100 {
+			bars = append(bars, p)
+		}
+		for p := types.Price(111_000); p > 110_000; p -= 100 {
+			bars = append(bars, p)
+		}
+	}
+	for i, px := range bars {
+		run.State.trackPrice(market.Candle{Timestamp: start + types.Timestamp(i*3600), Close: px, High: px + 20, Low: px - 20})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, run.WritePriceSVG(&buf))
+	out := requireSVG(t, buf.String())
+	assert.Contains(t, out, `fill="#ff7f0e" fill-opacity="0.25"><title>level 1.11020 (4 touches)</title>`)
+	assert.Contains(t, out, `<title>level 1.09980 (3 touches)</title>`)
+}
+
+func TestTrackPrice_DropsLongRuns(t *testing.T) {
+	run := &Backtest{State: &BacktestRun{}}
+	assert.False(t, run.HasPriceChart(), "no bars")
+	for i := 0; i < MaxPriceChartBars; i++ {
+		run.State.trackPrice(market.Candle{Timestamp: types.Timestamp(i * 60), Close: 1})
+	}
+	assert.True(t, run.HasPriceChart())
+	run.State.trackPrice(market.Candle{Timestamp: types.Timestamp(MaxPriceChartBars * 60), Close: 1})
+	assert.False(t, run.HasPriceChart())
+	assert.Nil(t, run.State.prices, "the series is freed")
+	run.State.trackPrice(market.Candle{Close: 1})
+	assert.False(t, run.HasPriceChart())
+}
@@ -8,7 +8,11 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/log"
 	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
 	"gopkg.in/yaml.v3"
 )
 
@@ -29,15 +33,97 @@ type RunDefaults struct {
 	Scale           int64   `json:"scale" yaml:"scale"`
 	Strict          bool    `json:"strict" yaml:"strict"`
 
-	RiskPct       float64 `json:"risk-pct" yaml:"risk-pct"`
-	StopPips      int32   `json:"stop-pips" yaml:"stop-pips"`
-	TakePips      int32   `json:"take-pips" yaml:"take-pips"`
-	RR            float64 `json:"rr" yaml:"rr"`
-	Units         int32   `json:"units" yaml:"units"`
+	RiskPct  float64 `json:"risk-pct" yaml:"risk-pct"`
+	StopPips int32   `json:"stop-pips" yaml:"stop-pips"`
+	TakePips int32   `json:"take-pips" yaml:"take-pips"`
+	RR       float64 `json:"rr" yaml:"rr"`
+	Units    int32   `json:"units" yaml:"units"`
+
+	// Execution holds the fill-cost settings. Version 1 configs carried
+	// these directly in defaults; see config_migrate.go.
+	Execution ExecutionConfig `json:"execution" yaml:"execution"`
+
+	// StopOn ends a run early when any of its conditions is hit.
+	StopOn StopConfig `json:"stop-on" yaml:"stop-on"`
+
+	// Robustness reruns each backtest with seeded random perturbations.
+	Robustness RobustnessConfig `json:"robustness" yaml:"robustness"`
+
+	// CrossValidation reruns each backtest over purged train/test splits
+	// of its time range.
+	CrossValidation CrossValidationConfig `json:"cross-validation" yaml:"cross-validation"`
+
+	// Governor caps how often any strategy may enter.
+	Governor GovernorConfig `json:"governor" yaml:"governor"`
+
+	// EquityCurve throttles risk by each run's own equity curve, for runs
+	// without an equity-curve block of their own.
+	EquityCurve EquityCurveConfig `json:"equity-curve" yaml:"equity-curve"`
+
+	// Margin sets the account's leverage, per-instrument margin rates and
+	// margin closeout level.
+	Margin account.MarginConfig `json:"margin" yaml:"margin"`
+
+	// MarketHours makes the simulated broker keep trading hours: entries
+	// while the market is closed, gaps over the close, and rollover swap.
+	MarketHours MarketHoursConfig `json:"market-hours" yaml:"market-hours"`
+
+	// Benchmark compares each run with buy-and-hold or a returns series.
+	Benchmark BenchmarkConfig `json:"benchmark" yaml:"benchmark"`
+
+	// PropFirm evaluates each run against funded-account rules.
+	PropFirm PropFirmConfig `json:"prop-firm" yaml:"prop-firm"`
+
+	// Report adjusts how reports present each run.
+	Report ReportConfig `json:"report" yaml:"report"`
+
+	// AuditSizing logs the audit record of every position sizing (pip
+	// size, stop pips, conversion rate, raw and clamped units; see
+	// account.SizingAudit) to each run's log. It does not change results.
+	AuditSizing bool `json:"audit-sizing,omitempty" yaml:"audit-sizing"`
+
+	// JournalSignals records every entry signal, taken or suppressed by a
+	// strategy filter or the order path, with how far price went its way
+	// before its stop (see journal.SignalRecord), to each run's
+	// signals.csv and the report. It does not change results.
+	JournalSignals bool `json:"journal-signals,omitempty" yaml:"journal-signals"`
+
+	// IdleInterestPct is the annual percentage credited on the account's
+	// idle cash — balance not tied up as margin — at each daily rollover,
+	// so cash-heavy strategies earn what an uninvested deposit would.
+	// Zero pays none; negative charges.
+	IdleInterestPct float64 `json:"idle-interest-pct,omitempty" yaml:"idle-interest-pct"`
+
+	Source string `json:"source" yaml:"source"`
+}
+
+// ExecutionConfig holds the execution-cost settings applied to every run.
+type ExecutionConfig struct {
 	SlippagePips  float64 `json:"slippage-pips" yaml:"slippage-pips"`
 	MaxSpreadPips float64 `json:"max-spread-pips" yaml:"max-spread-pips"`
 
-	Source string `json:"source" yaml:"source"`
+	// Liquidity is the simulated book's depth per bar; orders larger than
+	// it fill in parts over successive bars. Empty means infinite depth.
+	Liquidity []LiquidityLevelConfig `json:"liquidity,omitempty" yaml:"liquidity"`
+
+	// Fill is when strategy orders fill: same-tick (the default) on the
+	// bar the strategy decided on, or next-tick on the bar after it, at
+	// least LatencyMs of simulated time later. A next-tick run is also
+	// rerun same-tick and the report shows the P/L difference.
+	Fill      string `json:"fill,omitempty"       yaml:"fill"`
+	LatencyMs int    `json:"latency-ms,omitempty" yaml:"latency-ms"`
+
+	// Entry sends strategy entries as limit orders instead of market
+	// orders: offset from the decision price, under a time in force.
+	Entry EntryConfig `json:"entry,omitempty" yaml:"entry"`
+
+	// MinStops refuses entries and stop changes whose stop-loss or
+	// take-profit is nearer the market than the broker's minimum.
+	MinStops MinStopsConfig `json:"min-stops,omitempty" yaml:"min-stops"`
+
+	// SpreadGuard blocks entries while the spread is above a cap or a
+	// multiple of its recent average, per instrument.
+	SpreadGuard SpreadGuardConfig `json:"spread-guard,omitempty" yaml:"spread-guard"`
 }
 
 // RunConfig describes a single backtest run: what data to load, which
@@ -48,28 +134,73 @@ type RunConfig struct {
 	Strategy strategy.StrategyConfig `json:"strategy" yaml:"strategy"`
 	Exit     strategy.ExitConfig     `json:"exit"     yaml:"exit"`
 	Regime   strategy.RegimeConfig   `json:"regime"   yaml:"regime"`
+
+	// EquityCurve replaces defaults.equity-curve for this run. It is a
+	// pointer so configs without one keep their hash.
+	EquityCurve *EquityCurveConfig `json:"equity-curve,omitempty" yaml:"equity-curve"`
+}
+
+// equityCurve returns the run's equity-curve throttle: its own block when
+// it has one, else the defaults'.
+func (cfg RunConfig) equityCurve(defaults RunDefaults) EquityCurveConfig {
+	if cfg.EquityCurve != nil {
+		return *cfg.EquityCurve
+	}
+	return defaults.EquityCurve
 }
 
 // DataConfig specifies the data source, instrument, timeframe, and date range
-// for a run.
+// for a run. Source names the feed the bars come from (see
+// datamanager.NewFeed); Params are that feed's own settings.
 type DataConfig struct {
-	Source     string `json:"source" yaml:"source"`
-	Instrument string `json:"instrument" yaml:"instrument"`
-	Timeframe  string `json:"timeframe" yaml:"timeframe"`
-	From       string `json:"from" yaml:"from"`
-	To         string `json:"to" yaml:"to"`
-	Strict     *bool  `json:"strict" yaml:"strict"`
+	Source     string         `json:"source" yaml:"source"`
+	Params     map[string]any `json:"params,omitempty" yaml:"params"`
+	Instrument string         `json:"instrument" yaml:"instrument"`
+	Timeframe  string         `json:"timeframe" yaml:"timeframe"`
+	From       string         `json:"from" yaml:"from"`
+	To         string         `json:"to" yaml:"to"`
+	Strict     *bool          `json:"strict" yaml:"strict"`
+
+	// HigherTimeframe adds a confirmation feed aggregated from this data.
+	// It is a pointer so configs without one keep their hash.
+	HigherTimeframe *HigherTimeframeConfig `json:"higher-timeframe,omitempty" yaml:"higher-timeframe"`
+
+	// Slice restricts the run to calendar slices of From..To (weekdays,
+	// months, quarters, excluded days). A pointer for the same reason.
+	Slice *SliceConfig `json:"slice,omitempty" yaml:"slice"`
+
+	// Warmup is the number of bars before From fed to the strategy, exit
+	// and regime indicators before the run starts, so they are ready at
+	// From. Omitted when 0 so older configs keep their hash.
+	Warmup int `json:"warmup,omitempty" yaml:"warmup"`
+
+	// Download fetches the months of this data missing from the store from
+	// Source's provider before the run, so the config alone reproduces the
+	// run elsewhere. It does not change the data, so it is not hashed.
+	Download bool `json:"download,omitempty" yaml:"download,omitempty"`
 }
 
 // LoadConfig reads and parses a YAML or JSON config file from path.
 // The file extension determines the parser (.yaml/.yml → YAML; .json → JSON).
-// Returns an error if the file is missing, unparseable, or contains no runs.
+// Files written for an older schema version are upgraded in memory (see
+// MigrateConfig), so the returned Config is always at ConfigVersion.
+// Returns an error if the file is missing, unparseable, from a newer
+// schema version, or contains no runs; all but the first are
+// errs.ErrBadConfig.
 func LoadConfig(path string) (*Config, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read config %q: %w", path, err)
 	}
+	cfg, err := decodeConfig(path, b)
+	if err != nil {
+		return nil, errs.Mark(err, errs.ErrBadConfig)
+	}
+	return cfg, nil
+}
 
+// decodeConfig parses and migrates the config file at path, read as b.
+func decodeConfig(path string, b []byte) (*Config, error) {
 	cfg := &Config{}
 	ext := strings.ToLower(filepath.Ext(path))
 
@@ -86,8 +217,21 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("unsupported config extension %q (use .yaml, .yml, or .json)", ext)
 	}
 
-	if cfg.Version == 0 {
-		cfg.Version = 1
+	if cfg.Version != ConfigVersion {
+		doc, from, err := migrateConfigDoc(b)
+		if err != nil {
+			return nil, fmt.Errorf("config %q: %w", path, err)
+		}
+		for _, m := range configMigrations[from-1:] {
+			m(doc)
+		}
+		cfg = &Config{}
+		if err := doc.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("decode migrated config %q: %w", path, err)
+		}
+		cfg.Version = ConfigVersion
+		log.L.Info("backtest config uses an old schema; upgrade it with 'trader config migrate'",
+			"path", path, "version", from, "current", ConfigVersion)
 	}
 	if len(cfg.Runs) == 0 {
 		return nil, fmt.Errorf("config %q has no runs", path)
@@ -107,13 +251,45 @@ func hashBacktestConfig(cfg RunConfig, defaults RunDefaults) string {
 		Strategy strategy.StrategyConfig `json:"strategy"`
 		Exit     strategy.ExitConfig     `json:"exit"`
 		Regime   strategy.RegimeConfig   `json:"regime"`
-		Defaults struct {
+		// EquityCurve is the run's effective throttle, from the run or
+		// the defaults; omitted when unset so older hashes stay unchanged.
+		EquityCurve *EquityCurveConfig `json:"equity_curve,omitempty"`
+		Defaults    struct {
 			StartingBalance float64 `json:"starting_balance"`
 			RiskPct         float64 `json:"risk_pct"`
 			StopPips        int32   `json:"stop_pips"`
 			TakePips        int32   `json:"take_pips"`
 			SlippagePips    float64 `json:"slippage_pips"`
 			MaxSpreadPips   float64 `json:"max_spread_pips"`
+			// StopOn is omitted when unset so that hashes of configs
+			// without early-stop conditions stay unchanged.
+			StopOn *StopConfig `json:"stop_on,omitempty"`
+			// Robustness is omitted when unset for the same reason.
+			Robustness      *RobustnessConfig      `json:"robustness,omitempty"`
+			CrossValidation *CrossValidationConfig `json:"cross_validation,omitempty"`
+			Governor        *GovernorConfig        `json:"governor,omitempty"`
+			Margin          *account.MarginConfig  `json:"margin,omitempty"`
+			MarketHours     *MarketHoursConfig     `json:"market_hours,omitempty"`
+			// PropFirm only changes execution when it ends runs on the
+			// verdict; otherwise it is left out like the benchmark.
+			PropFirm *PropFirmConfig `json:"prop_firm,omitempty"`
+			// Liquidity is omitted when unset for the same reason.
+			Liquidity []LiquidityLevelConfig `json:"liquidity,omitempty"`
+			// Fill and LatencyMs are omitted for same-tick fills.
+			Fill      string `json:"fill,omitempty"`
+			LatencyMs int    `json:"latency_ms,omitempty"`
+			// Entry is omitted for market entries.
+			Entry *EntryConfig `json:"entry,omitempty"`
+			// MinStops is omitted when unset.
+			MinStops *MinStopsConfig `json:"min_stops,omitempty"`
+			// SpreadGuard is omitted when unset.
+			SpreadGuard *SpreadGuardConfig `json:"spread_guard,omitempty"`
+			// IdleInterestPct is omitted when zero.
+			IdleInterestPct float64 `json:"idle_interest_pct,omitempty"`
+			// Rollover is the global config's market.rollover, which
+			// sets the D1/H4 boundaries and when swap is charged;
+			// omitted under types.DefaultRollover.
+			Rollover string `json:"rollover,omitempty"`
 		} `json:"defaults"`
 	}
 
@@ -123,12 +299,65 @@ func hashBacktestConfig(cfg RunConfig, defaults RunDefaults) string {
 		Exit:     cfg.Exit,
 		Regime:   cfg.Regime,
 	}
+	h.Data.Download = false
 	h.Defaults.StartingBalance = defaults.StartingBalance
 	h.Defaults.RiskPct = defaults.RiskPct
 	h.Defaults.StopPips = defaults.StopPips
 	h.Defaults.TakePips = defaults.TakePips
-	h.Defaults.SlippagePips = defaults.SlippagePips
-	h.Defaults.MaxSpreadPips = defaults.MaxSpreadPips
+	h.Defaults.SlippagePips = defaults.Execution.SlippagePips
+	h.Defaults.MaxSpreadPips = defaults.Execution.MaxSpreadPips
+	h.Defaults.Liquidity = defaults.Execution.Liquidity
+	h.Defaults.IdleInterestPct = defaults.IdleInterestPct
+	if r := types.CurrentRollover().String(); r != types.DefaultRollover.String() {
+		h.Defaults.Rollover = r
+	}
+	if entry, err := compileEntry(defaults.Execution.Entry); err == nil && entry.Limit {
+		cfg := defaults.Execution.Entry
+		h.Defaults.Entry = &cfg
+	}
+	if !defaults.Execution.MinStops.IsZero() {
+		minStops := defaults.Execution.MinStops
+		h.Defaults.MinStops = &minStops
+	}
+	if !defaults.Execution.SpreadGuard.IsZero() {
+		guard := defaults.Execution.SpreadGuard
+		h.Defaults.SpreadGuard = &guard
+	}
+	if fill, err := compileFill(defaults.Execution); err == nil && fill.NextTick {
+		h.Defaults.Fill = FillNextTick
+		h.Defaults.LatencyMs = defaults.Execution.LatencyMs
+	}
+	if !defaults.StopOn.IsZero() {
+		stopOn := defaults.StopOn
+		h.Defaults.StopOn = &stopOn
+	}
+	if !defaults.Robustness.IsZero() {
+		robustness := defaults.Robustness
+		h.Defaults.Robustness = &robustness
+	}
+	if !defaults.CrossValidation.IsZero() {
+		cv := defaults.CrossValidation
+		h.Defaults.CrossValidation = &cv
+	}
+	if !defaults.Governor.IsZero() {
+		governor := defaults.Governor
+		h.Defaults.Governor = &governor
+	}
+	if ec := cfg.equityCurve(defaults); !ec.IsZero() {
+		h.EquityCurve = &ec
+	}
+	if !defaults.Margin.IsZero() {
+		margin := defaults.Margin
+		h.Defaults.Margin = &margin
+	}
+	if !defaults.MarketHours.IsZero() {
+		hours := defaults.MarketHours
+		h.Defaults.MarketHours = &hours
+	}
+	if defaults.PropFirm.EndOnVerdict {
+		propFirm := defaults.PropFirm
+		h.Defaults.PropFirm = &propFirm
+	}
 
 	b, _ := json.Marshal(h)
 	sum := sha256.Sum256(b)
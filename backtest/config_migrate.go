@@ -0,0 +1,147 @@
+package backtest
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigVersion is the backtest config schema version this build writes.
+// LoadConfig still reads every older version, upgrading it in memory.
+//
+//	1  execution costs (slippage-pips, max-spread-pips) directly in defaults
+//	2  execution costs grouped under defaults.execution
+const ConfigVersion = 2
+
+// configMigrations[i] upgrades a version i+1 document to version i+2 in
+// place and returns human-readable notes on what it changed.
+var configMigrations = []func(doc *yaml.Node) []string{
+	migrateConfigV1,
+}
+
+// MigrateConfig upgrades a YAML (or JSON) config document to
+// ConfigVersion. Comments and key order survive; the result is always
+// YAML. from is the document's original version, and notes describe each
+// change made. A document already at ConfigVersion is returned unchanged.
+func MigrateConfig(b []byte) (out []byte, from int, notes []string, err error) {
+	doc, from, err := migrateConfigDoc(b)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if from == ConfigVersion {
+		return b, from, nil, nil
+	}
+	notes = append(notes, fmt.Sprintf("version %d -> %d", from, ConfigVersion))
+	for _, m := range configMigrations[from-1:] {
+		notes = append(notes, m(doc)...)
+	}
+	setConfigVersion(doc.Content[0], ConfigVersion)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, 0, nil, fmt.Errorf("encode migrated config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, 0, nil, err
+	}
+	return buf.Bytes(), from, notes, nil
+}
+
+// migrateConfigDoc parses b and reports its schema version (0 or missing
+// means 1). It rejects versions newer than this build understands.
+func migrateConfigDoc(b []byte) (*yaml.Node, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, 0, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, 0, fmt.Errorf("config must be a mapping")
+	}
+	var probe struct {
+		Version int `yaml:"version"`
+	}
+	if err := doc.Decode(&probe); err != nil {
+		return nil, 0, err
+	}
+	version := probe.Version
+	if version == 0 {
+		version = 1
+	}
+	if version < 1 || version > ConfigVersion {
+		return nil, 0, fmt.Errorf("unsupported config version %d (this build reads 1 to %d)", version, ConfigVersion)
+	}
+	return &doc, version, nil
+}
+
+// setConfigVersion sets root's version key, adding it first when absent so
+// it leads the file as in the examples.
+func setConfigVersion(root *yaml.Node, version int) {
+	v := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprint(version)}
+	if mapValue(root, "version") != nil {
+		setMapValue(root, "version", v)
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	root.Content = append([]*yaml.Node{key, v}, root.Content...)
+}
+
+// migrateConfigV1 moves slippage-pips and max-spread-pips from defaults
+// into defaults.execution.
+func migrateConfigV1(doc *yaml.Node) []string {
+	defaults := mapValue(doc.Content[0], "defaults")
+	if defaults == nil || defaults.Kind != yaml.MappingNode {
+		return nil
+	}
+	var notes []string
+	for _, key := range []string{"slippage-pips", "max-spread-pips"} {
+		v := removeMapKey(defaults, key)
+		if v == nil {
+			continue
+		}
+		exec := mapValue(defaults, "execution")
+		if exec == nil {
+			exec = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMapValue(defaults, "execution", exec)
+		}
+		setMapValue(exec, key, v)
+		notes = append(notes, fmt.Sprintf("moved defaults.%s to defaults.execution.%s", key, key))
+	}
+	return notes
+}
+
+// mapValue returns the value node for key in mapping m, or nil.
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMapValue replaces key's value in mapping m, appending the key when
+// absent.
+func setMapValue(m *yaml.Node, key string, v *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = v
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, v)
+}
+
+// removeMapKey deletes key from mapping m and returns its value, or nil.
+func removeMapKey(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			v := m.Content[i+1]
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return v
+		}
+	}
+	return nil
+}
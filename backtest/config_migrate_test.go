@@ -0,0 +1,79 @@
+package backtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const v1Config = `# sweep defaults
+defaults:
+  starting-balance: 10000
+  slippage-pips: 0.2 # per fill
+  max-spread-pips: 3
+runs:
+  - name: r1
+    data:
+      instrument: EURUSD
+      timeframe: H1
+      from: "2026-01-01"
+      to: "2026-01-31"
+    strategy:
+      kind: buy-first
+`
+
+func TestMigrateConfig_V1(t *testing.T) {
+	out, from, notes, err := MigrateConfig([]byte(v1Config))
+	require.NoError(t, err)
+	assert.Equal(t, 1, from)
+	assert.Equal(t, []string{
+		"version 1 -> 2",
+		"moved defaults.slippage-pips to defaults.execution.slippage-pips",
+		"moved defaults.max-spread-pips to defaults.execution.max-spread-pips",
+	}, notes)
+
+	s := string(out)
+	assert.Contains(t, s, "# sweep defaults")
+	assert.Contains(t, s, "version: 2\n")
+	assert.Contains(t, s, "  execution:\n    slippage-pips: 0.2 # per fill\n    max-spread-pips: 3\n")
+	assert.Less(t, strings.Index(s, "version:"), strings.Index(s, "defaults:"))
+
+	again, from, notes, err := MigrateConfig(out)
+	require.NoError(t, err)
+	assert.Equal(t, ConfigVersion, from)
+	assert.Empty(t, notes)
+	assert.Equal(t, out, again)
+}
+
+func TestMigrateConfig_RejectsNewerVersion(t *testing.T) {
+	_, _, _, err := MigrateConfig([]byte("version: 99\nruns: []\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported config version 99")
+
+	_, _, _, err = MigrateConfig([]byte("- a\n- b\n"))
+	require.Error(t, err)
+}
+
+func TestLoadConfig_V1ExecutionDefaults(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.yaml")
+	require.NoError(t, os.WriteFile(oldPath, []byte(v1Config), 0o644))
+
+	oldCfg, err := LoadConfig(oldPath)
+	require.NoError(t, err)
+	assert.Equal(t, ConfigVersion, oldCfg.Version)
+	assert.Equal(t, 0.2, oldCfg.Defaults.Execution.SlippagePips)
+	assert.Equal(t, 3.0, oldCfg.Defaults.Execution.MaxSpreadPips)
+
+	migrated, _, _, err := MigrateConfig([]byte(v1Config))
+	require.NoError(t, err)
+	newPath := filepath.Join(dir, "new.yaml")
+	require.NoError(t, os.WriteFile(newPath, migrated, 0o644))
+	newCfg, err := LoadConfig(newPath)
+	require.NoError(t, err)
+	assert.Equal(t, oldCfg.Defaults, newCfg.Defaults)
+}
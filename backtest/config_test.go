@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,11 +37,44 @@ runs:
 	cfg, err := LoadConfig(p)
 	require.NoError(t, err)
 	require.NotNil(t, cfg)
-	assert.Equal(t, 1, cfg.Version)
+	assert.Equal(t, ConfigVersion, cfg.Version)
 	assert.Len(t, cfg.Runs, 1)
 	assert.Equal(t, "test-run", cfg.Runs[0].Name)
 }
 
+func TestLoadConfig_StrategyFilters(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cfg.yml")
+	yaml := `
+runs:
+  - name: filtered
+    data:
+      instrument: EURUSD
+      timeframe: H1
+      from: "2026-01-01"
+      to: "2026-01-31"
+    strategy:
+      kind: fake
+      filters:
+        - kind: session
+          params:
+            session_start: 8
+            session_end: 16
+        - kind: max-trades-per-day
+          params:
+            max: 2
+`
+	require.NoError(t, os.WriteFile(p, []byte(yaml), 0o644))
+
+	cfg, err := LoadConfig(p)
+	require.NoError(t, err)
+	require.Len(t, cfg.Runs[0].Strategy.Filters, 2)
+	assert.Equal(t, "max-trades-per-day", cfg.Runs[0].Strategy.Filters[1].Kind)
+
+	compiled, err := CompileBacktests(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "Fake[Session(08:00-16:00UTC)+MaxTradesPerDay(2)]", compiled[0].Request.Strategy.Name())
+}
+
 func TestLoadConfig_YML(t *testing.T) {
 	tmp := t.TempDir()
 	p := filepath.Join(tmp, "cfg.yml")
@@ -124,12 +158,14 @@ runs:
 
 	cfg, err := LoadConfig(p)
 	require.NoError(t, err)
-	assert.Equal(t, 1, cfg.Version)
+	assert.Equal(t, ConfigVersion, cfg.Version)
 }
 
 func TestLoadConfig_NotFound(t *testing.T) {
 	_, err := LoadConfig("/nonexistent/path/config.yaml")
 	require.Error(t, err)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NotErrorIs(t, err, errs.ErrBadConfig, "a missing file is not a bad config")
 }
 
 func TestLoadConfig_UnsupportedExt(t *testing.T) {
@@ -140,6 +176,7 @@ func TestLoadConfig_UnsupportedExt(t *testing.T) {
 	_, err := LoadConfig(p)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported config extension")
+	assert.ErrorIs(t, err, errs.ErrBadConfig)
 }
 
 func TestLoadConfig_InvalidYAML(t *testing.T) {
@@ -148,7 +185,7 @@ func TestLoadConfig_InvalidYAML(t *testing.T) {
 	require.NoError(t, os.WriteFile(p, []byte(":\tbad: [yaml"), 0o644))
 
 	_, err := LoadConfig(p)
-	require.Error(t, err)
+	require.ErrorIs(t, err, errs.ErrBadConfig)
 }
 
 func TestLoadConfig_InvalidJSON(t *testing.T) {
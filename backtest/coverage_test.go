@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/strategy"
 	"github.com/rustyeddy/trader/types"
 	"github.com/stretchr/testify/assert"
@@ -100,8 +101,7 @@ func TestApplyBacktestExecutionDefaults(t *testing.T) {
 		RiskPct:         1.5,
 		StopPips:        20,
 		TakePips:        40,
-		SlippagePips:    0.5,
-		MaxSpreadPips:   2.0,
+		Execution:       ExecutionConfig{SlippagePips: 0.5, MaxSpreadPips: 2.0},
 	}
 
 	applyBacktestExecutionDefaults(req, rc, defaults)
@@ -170,6 +170,7 @@ func TestCompileBacktests_ErrorCases(t *testing.T) {
 	_, err = CompileBacktests(cfg)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "build backtest strategy")
+	assert.ErrorIs(t, err, errs.ErrBadConfig)
 }
 
 func TestBuildBacktestResult(t *testing.T) {
@@ -0,0 +1,307 @@
+package backtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// maxCrossValidationSplits bounds C(folds, test-folds), the number of
+// splits (and twice the number of reruns) a config may ask for.
+const maxCrossValidationSplits = 200
+
+// CrossValidationConfig asks for combinatorial purged cross-validation
+// (CPCV) of every run. The run's time range is cut into Folds equal
+// groups; every combination of TestFolds groups is one split, whose test
+// groups are run out of sample and whose remaining groups are run in
+// sample. Bars of the in-sample set within PurgeBars before or
+// EmbargoBars after a test group are dropped, so trades straddling a
+// boundary cannot leak between the two. TestFolds == 1 is plain k-fold.
+// Folds == 0 disables cross-validation.
+type CrossValidationConfig struct {
+	Folds       int `json:"folds,omitempty"        yaml:"folds"`
+	TestFolds   int `json:"test-folds,omitempty"   yaml:"test-folds"` // groups per test set; zero means 1
+	PurgeBars   int `json:"purge-bars,omitempty"   yaml:"purge-bars"`
+	EmbargoBars int `json:"embargo-bars,omitempty" yaml:"embargo-bars"`
+}
+
+// IsZero reports whether cross-validation is not configured.
+func (c CrossValidationConfig) IsZero() bool {
+	return c == CrossValidationConfig{}
+}
+
+// CrossValidationPlan is the compiled form of CrossValidationConfig.
+type CrossValidationPlan struct {
+	Folds       int
+	TestFolds   int
+	PurgeBars   int
+	EmbargoBars int
+}
+
+// Splits returns C(Folds, TestFolds), the number of train/test splits.
+func (p CrossValidationPlan) Splits() int {
+	return binomial(p.Folds, p.TestFolds)
+}
+
+// Paths returns the number of complete out-of-sample backtest paths the
+// splits' test groups can be assembled into: TestFolds/Folds * Splits.
+func (p CrossValidationPlan) Paths() int {
+	if p.Folds == 0 {
+		return 0
+	}
+	return p.TestFolds * p.Splits() / p.Folds
+}
+
+// compileCrossValidation validates cfg and converts it to a
+// CrossValidationPlan.
+func compileCrossValidation(cfg CrossValidationConfig) (CrossValidationPlan, error) {
+	if cfg.IsZero() {
+		return CrossValidationPlan{}, nil
+	}
+	plan := CrossValidationPlan{
+		Folds:       cfg.Folds,
+		TestFolds:   max(cfg.TestFolds, 1),
+		PurgeBars:   cfg.PurgeBars,
+		EmbargoBars: cfg.EmbargoBars,
+	}
+	if plan.Folds < 2 {
+		return CrossValidationPlan{}, fmt.Errorf("folds must be >= 2, got %d", cfg.Folds)
+	}
+	if cfg.TestFolds < 0 || plan.TestFolds >= plan.Folds {
+		return CrossValidationPlan{}, fmt.Errorf("test-folds must be in [1, %d), got %d", plan.Folds, cfg.TestFolds)
+	}
+	if plan.PurgeBars < 0 || plan.EmbargoBars < 0 {
+		return CrossValidationPlan{}, fmt.Errorf("purge-bars and embargo-bars must be >= 0")
+	}
+	if n := plan.Splits(); n > maxCrossValidationSplits {
+		return CrossValidationPlan{}, fmt.Errorf("%d folds with %d test folds make %d splits; at most %d are allowed",
+			plan.Folds, plan.TestFolds, n, maxCrossValidationSplits)
+	}
+	return plan, nil
+}
+
+// CrossValidationRun is one split: the same backtest confined to the
+// split's test groups and to its purged, embargoed training groups.
+type CrossValidationRun struct {
+	TestFolds   []int // 0-based group indexes, ascending
+	InSample    Backtest
+	OutOfSample Backtest
+}
+
+// CrossValidationRuns builds the reruns for c, two per split, each with
+// freshly constructed strategy, exit and regime components like
+// PerturbedRuns. It returns nil when cross-validation is not configured.
+func (c CompiledBacktest) CrossValidationRuns() ([]CrossValidationRun, error) {
+	plan := c.Request.CrossValidation
+	if plan.Folds == 0 {
+		return nil, nil
+	}
+	groups, err := crossValidationGroups(c.Request.TimeRange, plan.Folds)
+	if err != nil {
+		return nil, err
+	}
+	bar := types.Timestamp(c.Request.TimeRange.TF)
+
+	var runs []CrossValidationRun
+	for _, test := range combinations(plan.Folds, plan.TestFolds) {
+		testSpans, trainSpans := splitSpans(groups, test, bar*types.Timestamp(plan.PurgeBars), bar*types.Timestamp(plan.EmbargoBars))
+		if len(trainSpans) == 0 {
+			return nil, fmt.Errorf("cross-validation split %s leaves no in-sample bars; lower purge-bars or embargo-bars", foldLabel(test))
+		}
+		cv := CrossValidationRun{TestFolds: test}
+		for _, side := range []struct {
+			run   *Backtest
+			spans []types.TimeRange
+			label string
+		}{
+			{&cv.InSample, trainSpans, "is"},
+			{&cv.OutOfSample, testSpans, "oos"},
+		} {
+			fresh, err := compileBacktestComponents(c.RunConfig)
+			if err != nil {
+				return nil, err
+			}
+			run := c.NewRun()
+			run.Request.Strategy = fresh.Strategy
+			run.Request.Exit = fresh.Exit
+			run.Request.Regime = fresh.Regime
+			run.Request.Slice = c.Request.Slice.Within(side.spans)
+			run.Request.Name = fmt.Sprintf("%s-cv%s-%s", c.Request.Name, foldLabel(test), side.label)
+			*side.run = run
+		}
+		runs = append(runs, cv)
+	}
+	return runs, nil
+}
+
+// crossValidationGroups cuts tr into n contiguous groups of whole bars.
+func crossValidationGroups(tr types.TimeRange, n int) ([]types.TimeRange, error) {
+	bar := types.Timestamp(tr.TF)
+	if bar <= 0 {
+		return nil, fmt.Errorf("cross-validation needs a timeframe")
+	}
+	bars := int64((tr.End - tr.Start) / bar)
+	if bars < int64(n) {
+		return nil, fmt.Errorf("cross-validation needs at least %d bars, the range has %d", n, bars)
+	}
+	groups := make([]types.TimeRange, n)
+	for i := range groups {
+		groups[i] = types.TimeRange{
+			Start: tr.Start + types.Timestamp(bars*int64(i)/int64(n))*bar,
+			End:   tr.Start + types.Timestamp(bars*int64(i+1)/int64(n))*bar,
+			TF:    tr.TF,
+		}
+	}
+	groups[n-1].End = tr.End
+	return groups, nil
+}
+
+// splitSpans returns the test and training spans of one split. Adjacent
+// test groups merge into one span; training spans lose purge before and
+// embargo after every test span, and empty ones are dropped.
+func splitSpans(groups []types.TimeRange, test []int, purge, embargo types.Timestamp) (testSpans, trainSpans []types.TimeRange) {
+	isTest := make([]bool, len(groups))
+	for _, g := range test {
+		isTest[g] = true
+	}
+	for i, g := range groups {
+		if !isTest[i] {
+			continue
+		}
+		if n := len(testSpans); n > 0 && testSpans[n-1].End == g.Start {
+			testSpans[n-1].End = g.End
+			continue
+		}
+		testSpans = append(testSpans, g)
+	}
+
+	start := groups[0].Start
+	for _, t := range testSpans {
+		if end := t.Start - purge; end > start {
+			trainSpans = append(trainSpans, types.TimeRange{Start: start, End: end, TF: t.TF})
+		}
+		start = t.End + embargo
+	}
+	if end := groups[len(groups)-1].End; end > start {
+		trainSpans = append(trainSpans, types.TimeRange{Start: start, End: end, TF: groups[0].TF})
+	}
+	return testSpans, trainSpans
+}
+
+// combinations returns every k-subset of 0..n-1 in lexicographic order.
+func combinations(n, k int) [][]int {
+	var out [][]int
+	cur := make([]int, 0, k)
+	var rec func(next int)
+	rec = func(next int) {
+		if len(cur) == k {
+			out = append(out, append([]int(nil), cur...))
+			return
+		}
+		for i := next; i <= n-(k-len(cur)); i++ {
+			cur = append(cur, i)
+			rec(i + 1)
+			cur = cur[:len(cur)-1]
+		}
+	}
+	rec(0)
+	return out
+}
+
+func binomial(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+	out := 1
+	for i := 1; i <= k; i++ {
+		out = out * (n - k + i) / i
+	}
+	return out
+}
+
+// foldLabel renders 0-based groups as the 1-based "1+3" used in names and
+// reports.
+func foldLabel(groups []int) string {
+	parts := make([]string, len(groups))
+	for i, g := range groups {
+		parts[i] = strconv.Itoa(g + 1)
+	}
+	return strings.Join(parts, "+")
+}
+
+// CrossValidationResult summarises a set of cross-validation splits.
+type CrossValidationResult struct {
+	Plan        CrossValidationPlan
+	InSample    CrossValidationStats
+	OutOfSample CrossValidationStats
+	Splits      []CrossValidationSplit
+}
+
+// CrossValidationStats is the distribution of outcomes over one side of
+// every split.
+type CrossValidationStats struct {
+	Runs        int
+	Profitable  int // runs with NetPL > 0
+	ReturnPct   RobustnessSpread[types.Rate]
+	NetPL       RobustnessSpread[types.Money]
+	MaxDrawdown RobustnessSpread[types.Money]
+	Trades      RobustnessSpread[int]
+}
+
+// CrossValidationSplit is the outcome of one split.
+type CrossValidationSplit struct {
+	TestFolds   []int
+	InSample    *BacktestResult
+	OutOfSample *BacktestResult
+}
+
+// SummarizeCrossValidation aggregates executed cross-validation runs.
+// Splits missing either result are skipped; it returns nil when none
+// remain.
+func SummarizeCrossValidation(plan CrossValidationPlan, runs []CrossValidationRun) *CrossValidationResult {
+	out := &CrossValidationResult{Plan: plan}
+	var is, oos []*BacktestResult
+	for _, r := range runs {
+		if r.InSample.Result == nil || r.OutOfSample.Result == nil {
+			continue
+		}
+		out.Splits = append(out.Splits, CrossValidationSplit{
+			TestFolds:   r.TestFolds,
+			InSample:    r.InSample.Result,
+			OutOfSample: r.OutOfSample.Result,
+		})
+		is = append(is, r.InSample.Result)
+		oos = append(oos, r.OutOfSample.Result)
+	}
+	if len(out.Splits) == 0 {
+		return nil
+	}
+	out.InSample = crossValidationStats(is)
+	out.OutOfSample = crossValidationStats(oos)
+	return out
+}
+
+func crossValidationStats(results []*BacktestResult) CrossValidationStats {
+	var (
+		rets      []types.Rate
+		pls, mdds []types.Money
+		trades    []int
+	)
+	out := CrossValidationStats{Runs: len(results)}
+	for _, r := range results {
+		if r.NetPL > 0 {
+			out.Profitable++
+		}
+		rets = append(rets, r.ReturnPct)
+		pls = append(pls, r.NetPL)
+		mdds = append(mdds, r.MaxDrawdown)
+		trades = append(trades, r.Trades)
+	}
+	out.ReturnPct = spreadOf(rets)
+	out.NetPL = spreadOf(pls)
+	out.MaxDrawdown = spreadOf(mdds)
+	out.Trades = spreadOf(trades)
+	return out
+}
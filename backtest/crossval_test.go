@@ -0,0 +1,154 @@
+package backtest
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileCrossValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  CrossValidationConfig
+		want CrossValidationPlan
+		err  string
+	}{
+		{name: "zero", cfg: CrossValidationConfig{}},
+		{name: "k-fold", cfg: CrossValidationConfig{Folds: 5}, want: CrossValidationPlan{Folds: 5, TestFolds: 1}},
+		{name: "cpcv", cfg: CrossValidationConfig{Folds: 6, TestFolds: 2, PurgeBars: 24, EmbargoBars: 12},
+			want: CrossValidationPlan{Folds: 6, TestFolds: 2, PurgeBars: 24, EmbargoBars: 12}},
+		{name: "one fold", cfg: CrossValidationConfig{Folds: 1}, err: "folds must be >= 2"},
+		{name: "all folds test", cfg: CrossValidationConfig{Folds: 4, TestFolds: 4}, err: "test-folds must be in [1, 4)"},
+		{name: "negative purge", cfg: CrossValidationConfig{Folds: 4, PurgeBars: -1}, err: "must be >= 0"},
+		{name: "too many splits", cfg: CrossValidationConfig{Folds: 16, TestFolds: 8}, err: "12870 splits"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := compileCrossValidation(tc.cfg)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCrossValidationPlan_SplitsAndPaths(t *testing.T) {
+	p := CrossValidationPlan{Folds: 6, TestFolds: 2}
+	assert.Equal(t, 15, p.Splits())
+	assert.Equal(t, 5, p.Paths())
+	assert.Len(t, combinations(6, 2), 15)
+	assert.Equal(t, [][]int{{0, 1}, {0, 2}, {1, 2}}, combinations(3, 2))
+
+	k := CrossValidationPlan{Folds: 5, TestFolds: 1}
+	assert.Equal(t, 5, k.Splits())
+	assert.Equal(t, 1, k.Paths())
+}
+
+func TestCrossValidationGroups(t *testing.T) {
+	tr := types.TimeRange{Start: 0, End: 10 * types.Timestamp(types.H1), TF: types.H1}
+	groups, err := crossValidationGroups(tr, 3)
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+	h := types.Timestamp(types.H1)
+	assert.Equal(t, types.Timestamp(0), groups[0].Start)
+	assert.Equal(t, 3*h, groups[0].End, "groups hold whole bars")
+	assert.Equal(t, groups[0].End, groups[1].Start)
+	assert.Equal(t, tr.End, groups[2].End)
+
+	_, err = crossValidationGroups(tr, 11)
+	require.ErrorContains(t, err, "at least 11 bars")
+}
+
+func TestSplitSpans_PurgeAndEmbargo(t *testing.T) {
+	h := types.Timestamp(types.H1)
+	tr := types.TimeRange{Start: 0, End: 40 * h, TF: types.H1}
+	groups, err := crossValidationGroups(tr, 4) // 10 bars each
+
+	require.NoError(t, err)
+	test, train := splitSpans(groups, []int{1, 2}, 2*h, 3*h)
+	require.Len(t, test, 1, "adjacent test groups merge")
+	assert.Equal(t, 10*h, test[0].Start)
+	assert.Equal(t, 30*h, test[0].End)
+	require.Len(t, train, 2)
+	assert.Equal(t, types.TimeRange{Start: 0, End: 8 * h, TF: types.H1}, train[0], "purged before the test set")
+	assert.Equal(t, types.TimeRange{Start: 33 * h, End: 40 * h, TF: types.H1}, train[1], "embargoed after it")
+
+	test, train = splitSpans(groups, []int{0, 3}, 2*h, 3*h)
+	assert.Len(t, test, 2)
+	require.Len(t, train, 1)
+	assert.Equal(t, types.TimeRange{Start: 13 * h, End: 28 * h, TF: types.H1}, train[0])
+}
+
+func TestCrossValidationRuns(t *testing.T) {
+	cfg := &Config{
+		Defaults: RunDefaults{
+			StartingBalance: 1000,
+			CrossValidation: CrossValidationConfig{Folds: 3, PurgeBars: 1},
+		},
+		Runs: []RunConfig{{
+			Name:     "cv",
+			Data:     DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2024-01-01", To: "2024-01-04"},
+			Strategy: strategy.StrategyConfig{Kind: "fake"},
+		}},
+	}
+	compiled, err := CompileBacktests(cfg)
+	require.NoError(t, err)
+	runs, err := compiled[0].CrossValidationRuns()
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+
+	mid := runs[1]
+	assert.Equal(t, []int{1}, mid.TestFolds)
+	assert.Equal(t, "cv-cv2-oos", mid.OutOfSample.Request.Name)
+
+	day2 := sliceTS(t, "2024-01-02T12:00:00Z")
+	day1Last := sliceTS(t, "2024-01-01T23:00:00Z")
+	day1Early := sliceTS(t, "2024-01-01T12:00:00Z")
+	assert.True(t, mid.OutOfSample.Request.Slice.Keep(day2))
+	assert.False(t, mid.OutOfSample.Request.Slice.Keep(day1Early))
+	assert.False(t, mid.InSample.Request.Slice.Keep(day2))
+	assert.False(t, mid.InSample.Request.Slice.Keep(day1Last), "purged bar before the test group")
+	assert.True(t, mid.InSample.Request.Slice.Keep(day1Early))
+
+	none, err := CompiledBacktest{Request: BacktestRequest{}}.CrossValidationRuns()
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	compiled[0].Request.CrossValidation.PurgeBars = 100
+	_, err = compiled[0].CrossValidationRuns()
+	require.ErrorContains(t, err, "no in-sample bars")
+}
+
+func TestSummarizeCrossValidation(t *testing.T) {
+	res := func(ret float64, pl float64, trades int) *BacktestResult {
+		return &BacktestResult{ReturnPct: types.RateFromFloat(ret), NetPL: types.MoneyFromFloat(pl), Trades: trades}
+	}
+	plan := CrossValidationPlan{Folds: 3, TestFolds: 1}
+	runs := []CrossValidationRun{
+		{TestFolds: []int{0}, InSample: Backtest{Result: res(0.04, 40, 8)}, OutOfSample: Backtest{Result: res(-0.01, -10, 3)}},
+		{TestFolds: []int{1}, InSample: Backtest{Result: res(0.05, 50, 9)}, OutOfSample: Backtest{Result: res(0.02, 20, 4)}},
+		{TestFolds: []int{2}, InSample: Backtest{Result: res(0.03, 30, 7)}}, // OOS missing: skipped
+	}
+	got := SummarizeCrossValidation(plan, runs)
+	require.NotNil(t, got)
+	assert.Len(t, got.Splits, 2)
+	assert.Equal(t, 2, got.InSample.Profitable)
+	assert.Equal(t, 1, got.OutOfSample.Profitable)
+	assert.Equal(t, types.RateFromFloat(-0.01), got.OutOfSample.ReturnPct.Min)
+
+	rep := got.Report()
+	require.NotNil(t, rep)
+	assert.Equal(t, 1, rep.Paths)
+	assert.Equal(t, "2", rep.Splits[1].TestFolds)
+	assert.InDelta(t, 2.0, rep.Splits[1].Return, 1e-9)
+	assert.InDelta(t, -1.0, rep.OutOfSample.ReturnPct.Min, 1e-9)
+
+	assert.Nil(t, SummarizeCrossValidation(plan, nil))
+	assert.Nil(t, (*CrossValidationResult)(nil).Report())
+}
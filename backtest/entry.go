@@ -0,0 +1,158 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Entry order types for EntryConfig.Type.
+const (
+	EntryMarket = "market"
+	EntryLimit  = "limit"
+)
+
+// EntryConfig is the kind of order a run's strategy entries are sent as:
+// market orders (the default), or limit orders OffsetPips better than the
+// price the strategy decided on, resting under a time in force. A GTD
+// limit lapses ExpiryBars bars after the bar it was placed on.
+type EntryConfig struct {
+	Type       string  `json:"type,omitempty"        yaml:"type"`
+	OffsetPips float64 `json:"offset-pips,omitempty" yaml:"offset-pips"`
+	TIF        string  `json:"tif,omitempty"         yaml:"tif"`
+	ExpiryBars int     `json:"expiry-bars,omitempty" yaml:"expiry-bars"`
+}
+
+// EntryModel is the compiled EntryConfig. The zero value sends market
+// orders.
+type EntryModel struct {
+	Limit      bool
+	Offset     types.Pips
+	TIF        brokers.TimeInForce
+	ExpiryBars int
+}
+
+// compileEntry validates cfg and converts it to an EntryModel. A limit's
+// time in force defaults to GTC; only GTD takes, and needs, expiry-bars.
+func compileEntry(cfg EntryConfig) (EntryModel, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Type)) {
+	case "", EntryMarket:
+		if cfg.OffsetPips != 0 || cfg.TIF != "" || cfg.ExpiryBars != 0 {
+			return EntryModel{}, fmt.Errorf("entry: offset-pips, tif and expiry-bars need type: %s", EntryLimit)
+		}
+		return EntryModel{}, nil
+	case EntryLimit:
+	default:
+		return EntryModel{}, fmt.Errorf("entry: unknown type %q (use %s or %s)", cfg.Type, EntryMarket, EntryLimit)
+	}
+	if cfg.OffsetPips < 0 {
+		return EntryModel{}, fmt.Errorf("entry: offset-pips must be >= 0, got %g", cfg.OffsetPips)
+	}
+	m := EntryModel{Limit: true, Offset: types.PipsFromFloat(cfg.OffsetPips), TIF: brokers.GTC}
+	if tif := strings.ToUpper(strings.TrimSpace(cfg.TIF)); tif != "" {
+		m.TIF = brokers.TimeInForce(tif)
+	}
+	switch m.TIF {
+	case brokers.GTD:
+		if cfg.ExpiryBars <= 0 {
+			return EntryModel{}, fmt.Errorf("entry: tif %s needs expiry-bars > 0", brokers.GTD)
+		}
+		m.ExpiryBars = cfg.ExpiryBars
+	case brokers.GTC, brokers.FOK, brokers.IOC:
+		if cfg.ExpiryBars != 0 {
+			return EntryModel{}, fmt.Errorf("entry: expiry-bars needs tif %s", brokers.GTD)
+		}
+	default:
+		return EntryModel{}, fmt.Errorf("entry: unknown tif %q (use GTC, GTD, FOK or IOC)", cfg.TIF)
+	}
+	return m, nil
+}
+
+// limitPrice is the limit an entry decided at ref is placed at: Offset
+// below it for a long, above it for a short.
+func (m EntryModel) limitPrice(instrument string, side types.Side, ref types.Price) types.Price {
+	inst := market.GetInstrument(instrument)
+	if inst == nil || m.Offset == 0 {
+		return ref
+	}
+	if side == types.Short {
+		return ref + inst.PriceDeltaFromPips(m.Offset)
+	}
+	return ref - inst.PriceDeltaFromPips(m.Offset)
+}
+
+// expiry is when a GTD limit placed on the bar at ts lapses; zero for
+// other orders.
+func (m EntryModel) expiry(ts types.Timestamp, tf types.Timeframe) types.Timestamp {
+	if m.TIF != brokers.GTD {
+		return 0
+	}
+	return ts + types.Timestamp(int64(m.ExpiryBars)*int64(tf))
+}
+
+// restingEntries are a run's limit entries that did not fill when placed,
+// by order ID. The lots they open later are given their request's reason
+// and initial stop, and those still resting when the run ends are
+// cancelled.
+type restingEntries map[string]*account.OpenRequest
+
+// settle labels the lots opened by resting entries that have filled since
+// the last call and forgets the entries whose orders are done.
+func (r restingEntries) settle(ctx context.Context, t *engine.Trader, volRegime string) error {
+	if len(r) == 0 {
+		return nil
+	}
+	orders, err := t.Orders(ctx)
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		req, ok := r[o.ID]
+		if !ok {
+			continue
+		}
+		for _, id := range o.TradeIDs {
+			labelEntryLot(t.Account, id, req, volRegime)
+		}
+		if o.State.Terminal() {
+			delete(r, o.ID)
+		}
+	}
+	return nil
+}
+
+// cancel cancels every entry still resting, so the order journal records
+// how each ended.
+func (r restingEntries) cancel(ctx context.Context, t *engine.Trader) error {
+	for _, id := range slices.Sorted(maps.Keys(r)) {
+		if err := t.CancelOrder(ctx, id); err != nil {
+			return err
+		}
+		delete(r, id)
+	}
+	return nil
+}
+
+// labelEntryLot copies an entry's analysis metadata onto the lot its fill
+// opened. Broker orders have no room for Reason/InitialStop (a real
+// broker order request doesn't carry app-specific analysis metadata), so
+// they are patched onto the fresh lot directly; Range gives the live
+// pointer (Lots.Get returns a clone).
+func labelEntryLot(acct *account.Account, tradeID string, req *account.OpenRequest, volRegime string) {
+	_ = acct.Lots.Range(func(lot *account.Lot) error {
+		if lot.ID == tradeID {
+			lot.Reason = req.Reason
+			lot.InitialStop = req.InitialStop
+			lot.VolRegime = volRegime
+		}
+		return nil
+	})
+}
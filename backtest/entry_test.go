@@ -0,0 +1,110 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileEntry(t *testing.T) {
+	got, err := compileEntry(EntryConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, EntryModel{}, got)
+
+	got, err = compileEntry(EntryConfig{Type: "Limit", OffsetPips: 2.5})
+	require.NoError(t, err)
+	assert.Equal(t, EntryModel{Limit: true, Offset: 25, TIF: brokers.GTC}, got)
+
+	got, err = compileEntry(EntryConfig{Type: "limit", TIF: "gtd", ExpiryBars: 3})
+	require.NoError(t, err)
+	assert.Equal(t, EntryModel{Limit: true, TIF: brokers.GTD, ExpiryBars: 3}, got)
+
+	for _, tc := range []struct {
+		cfg  EntryConfig
+		want string
+	}{
+		{EntryConfig{OffsetPips: 1}, "need type: limit"},
+		{EntryConfig{Type: "stop"}, `unknown type "stop"`},
+		{EntryConfig{Type: "limit", OffsetPips: -1}, "offset-pips must be >= 0"},
+		{EntryConfig{Type: "limit", TIF: "GTD"}, "needs expiry-bars > 0"},
+		{EntryConfig{Type: "limit", ExpiryBars: 2}, "expiry-bars needs tif GTD"},
+		{EntryConfig{Type: "limit", TIF: "day"}, `unknown tif "day"`},
+	} {
+		_, err := compileEntry(tc.cfg)
+		assert.ErrorContains(t, err, tc.want, "%+v", tc.cfg)
+	}
+}
+
+func TestRunWithIterator_LimitEntries(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i, c := range []types.Price{1100000, 1100020, 1099960, 1099900, 1100000, 1100000} {
+		candles = append(candles, market.Candle{
+			Open: c, High: c + 100, Low: c - 100, Close: c,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	run := func(entry EntryModel) (*Backtest, *engine.Trader) {
+		acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+		acct.RiskFraction = types.RateFromFloat(0.01)
+		tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+		run := &Backtest{
+			Request: &BacktestRequest{
+				Instrument:      "EURUSD",
+				Strategy:        alwaysLong{},
+				StartingBalance: types.MoneyFromFloat(10_000),
+				TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[5].Timestamp, TF: types.H1},
+				Governor:        GovernorRules{MaxTradesPerDay: 1},
+				Entry:           entry,
+			},
+			State: &BacktestRun{},
+		}
+		require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+		return run, tr
+	}
+	lastOrder := func(tr *engine.Trader) brokers.Order {
+		orders, err := tr.Orders(context.Background())
+		require.NoError(t, err)
+		require.NotEmpty(t, orders)
+		return orders[len(orders)-1]
+	}
+
+	// A 5 pip limit under bar 0's close rests until bar 3 trades through it.
+	_, tr := run(EntryModel{Limit: true, Offset: 50, TIF: brokers.GTC})
+	require.Len(t, tr.Account.Trades, 1)
+	trade := tr.Account.Trades[0]
+	assert.Equal(t, candles[3].Close, trade.EntryPrice)
+	assert.Equal(t, candles[3].Timestamp, trade.EntryTime)
+	assert.Equal(t, "always", trade.Reason)
+	assert.Equal(t, brokers.OrderFilled, lastOrder(tr).State)
+
+	// A GTD limit two bars long lapses before then.
+	_, tr = run(EntryModel{Limit: true, Offset: 50, TIF: brokers.GTD, ExpiryBars: 2})
+	assert.Empty(t, tr.Account.Trades)
+	assert.Equal(t, brokers.OrderExpired, lastOrder(tr).State)
+
+	// One the market never reaches is cancelled when the run ends.
+	_, tr = run(EntryModel{Limit: true, Offset: 200, TIF: brokers.GTC})
+	assert.Empty(t, tr.Account.Trades)
+	assert.Equal(t, brokers.OrderCancelled, lastOrder(tr).State)
+
+	// An offset past the strategy's stop is refused.
+	r, tr := run(EntryModel{Limit: true, Offset: 6000, TIF: brokers.GTC})
+	assert.Empty(t, tr.Account.Trades)
+	var reasons []string
+	for _, d := range r.State.Rejected {
+		reasons = append(reasons, d.Reason)
+	}
+	assert.Contains(t, reasons, journal.RejectInvalidStop)
+}
@@ -0,0 +1,138 @@
+package backtest
+
+import (
+	"fmt"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+)
+
+// EquityCurveConfig throttles a run by its own closed-trade equity curve:
+// while the curve is below its moving average, or in drawdown beyond a
+// limit, risk-pct is scaled down (or entries paused), and full risk comes
+// back once the curve recovers. Zero values disable the corresponding rule.
+type EquityCurveConfig struct {
+	MAPeriod       int     `json:"ma-period,omitempty"        yaml:"ma-period"`        // closed trades in the moving average
+	MaxDrawdownPct float64 `json:"max-drawdown-pct,omitempty" yaml:"max-drawdown-pct"` // drop from the curve's peak, e.g. 5 = 5%
+	RiskScale      float64 `json:"risk-scale,omitempty"       yaml:"risk-scale"`       // risk-pct multiplier while throttled; 0 pauses entries
+}
+
+// IsZero reports whether no equity-curve rule is configured.
+func (c EquityCurveConfig) IsZero() bool {
+	return c == EquityCurveConfig{}
+}
+
+// RuleEquityCurve is the rule recorded on entries skipped while the equity
+// curve throttle has paused trading.
+const RuleEquityCurve = "equity-curve"
+
+// EquityCurveRules is the compiled, fixed-point form of EquityCurveConfig.
+type EquityCurveRules struct {
+	MAPeriod    int
+	MaxDrawdown types.Rate // fraction of the curve's peak, RateScale-scaled
+	RiskScale   types.Rate // RateScale-scaled; 0 pauses entries
+}
+
+// IsZero reports whether no rule is set.
+func (r EquityCurveRules) IsZero() bool {
+	return r == EquityCurveRules{}
+}
+
+// compileEquityCurve validates cfg and converts it to EquityCurveRules.
+func compileEquityCurve(cfg EquityCurveConfig) (EquityCurveRules, error) {
+	if cfg.MAPeriod < 0 {
+		return EquityCurveRules{}, fmt.Errorf("ma-period must be >= 0, got %d", cfg.MAPeriod)
+	}
+	if cfg.MaxDrawdownPct < 0 || cfg.MaxDrawdownPct >= 100 {
+		return EquityCurveRules{}, fmt.Errorf("max-drawdown-pct must be between 0 and 100, got %v", cfg.MaxDrawdownPct)
+	}
+	if cfg.RiskScale < 0 || cfg.RiskScale >= 1 {
+		return EquityCurveRules{}, fmt.Errorf("risk-scale must be at least 0 and below 1, got %v", cfg.RiskScale)
+	}
+	if cfg.MAPeriod == 0 && cfg.MaxDrawdownPct == 0 && cfg.RiskScale != 0 {
+		return EquityCurveRules{}, fmt.Errorf("risk-scale needs ma-period or max-drawdown-pct")
+	}
+	return EquityCurveRules{
+		MAPeriod:    cfg.MAPeriod,
+		MaxDrawdown: types.RateFromFloat(cfg.MaxDrawdownPct / 100.0),
+		RiskScale:   types.RateFromFloat(cfg.RiskScale),
+	}, nil
+}
+
+// equityCurve tracks the run's closed-trade equity and decides, before each
+// bar's entries are sized, whether the run is throttled. Like the governor
+// it must see every bar (observe) so no closed trade is missed.
+type equityCurve struct {
+	rules EquityCurveRules
+
+	equity types.Money   // starting balance plus every closed trade's P/L
+	peak   types.Money   // highest equity so far
+	recent []types.Money // the last MAPeriod curve points, oldest first
+	sum    int64         // sum of recent
+
+	seen int // closed trades already added to the curve
+}
+
+// newEquityCurve returns nil when no rule is set.
+func newEquityCurve(rules EquityCurveRules, start types.Money) *equityCurve {
+	if rules.IsZero() {
+		return nil
+	}
+	return &equityCurve{rules: rules, equity: start, peak: start}
+}
+
+// observe adds the trades closed since the last call to the curve. trades
+// is the account's full closed-trade list.
+func (c *equityCurve) observe(trades []*account.Trade) {
+	for _, tr := range trades[min(c.seen, len(trades)):] {
+		if tr == nil {
+			continue
+		}
+		c.equity += tr.PNL
+		c.peak = max(c.peak, c.equity)
+		if c.rules.MAPeriod > 0 {
+			if len(c.recent) == c.rules.MAPeriod {
+				c.sum -= int64(c.recent[0])
+				c.recent = c.recent[1:]
+			}
+			c.recent = append(c.recent, c.equity)
+			c.sum += int64(c.equity)
+		}
+	}
+	c.seen = len(trades)
+}
+
+// throttled reports whether the curve is below its moving average (once
+// MAPeriod trades have closed) or further below its peak than MaxDrawdown.
+func (c *equityCurve) throttled() bool {
+	if n := c.rules.MAPeriod; n > 0 && len(c.recent) == n && int64(c.equity)*int64(n) < c.sum {
+		return true
+	}
+	if c.rules.MaxDrawdown > 0 && c.peak > 0 && c.equity < c.peak {
+		dd, err := types.MulDivFloor64(int64(c.peak-c.equity), int64(types.RateScale), int64(c.peak))
+		if err == nil && types.Rate(dd) >= c.rules.MaxDrawdown {
+			return true
+		}
+	}
+	return false
+}
+
+// riskFraction returns the per-trade risk to size this bar's entries with:
+// base normally, base scaled by RiskScale while throttled. A paused curve
+// keeps base so the entries it refuses are recorded as its skips rather
+// than as sizing rejections.
+func (c *equityCurve) riskFraction(base types.Rate, throttled bool) types.Rate {
+	if !throttled || c.rules.RiskScale == 0 {
+		return base
+	}
+	v, err := types.MulDivFloor64(int64(base), int64(c.rules.RiskScale), int64(types.RateScale))
+	if err != nil {
+		return 0
+	}
+	return types.Rate(v)
+}
+
+// paused reports whether entries are refused outright while throttled.
+func (c *equityCurve) paused(throttled bool) bool {
+	return throttled && c.rules.RiskScale == 0
+}
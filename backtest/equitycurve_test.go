@@ -0,0 +1,171 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileEquityCurve(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     EquityCurveConfig
+		want    EquityCurveRules
+		wantErr string
+	}{
+		{name: "disabled"},
+		{
+			name: "both rules",
+			cfg:  EquityCurveConfig{MAPeriod: 10, MaxDrawdownPct: 5, RiskScale: 0.5},
+			want: EquityCurveRules{MAPeriod: 10, MaxDrawdown: types.RateFromFloat(0.05), RiskScale: types.RateFromFloat(0.5)},
+		},
+		{name: "pause", cfg: EquityCurveConfig{MAPeriod: 20}, want: EquityCurveRules{MAPeriod: 20}},
+		{name: "negative period", cfg: EquityCurveConfig{MAPeriod: -1}, wantErr: "ma-period must be >= 0"},
+		{name: "drawdown range", cfg: EquityCurveConfig{MaxDrawdownPct: 100}, wantErr: "max-drawdown-pct must be between 0 and 100"},
+		{name: "scale range", cfg: EquityCurveConfig{MAPeriod: 5, RiskScale: 1}, wantErr: "risk-scale must be at least 0 and below 1"},
+		{name: "scale alone", cfg: EquityCurveConfig{RiskScale: 0.5}, wantErr: "risk-scale needs ma-period or max-drawdown-pct"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compileEquityCurve(tt.cfg)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewEquityCurve_NilWhenUnset(t *testing.T) {
+	assert.Nil(t, newEquityCurve(EquityCurveRules{}, types.MoneyFromFloat(10_000)))
+}
+
+func TestEquityCurve_MovingAverage(t *testing.T) {
+	c := newEquityCurve(EquityCurveRules{MAPeriod: 3, RiskScale: types.RateFromFloat(0.5)}, types.MoneyFromFloat(10_000))
+	base := types.RateFromFloat(0.01)
+	var trades []*account.Trade
+	close := func(pnl float64) bool {
+		trades = append(trades, &account.Trade{PNL: types.MoneyFromFloat(pnl)})
+		c.observe(trades)
+		return c.throttled()
+	}
+
+	assert.False(t, close(100))
+	assert.False(t, close(-50), "fewer than ma-period trades")
+	assert.True(t, close(-50), "9,950 is below the average of 10,100, 10,050 and 9,950")
+	assert.Equal(t, types.RateFromFloat(0.005), c.riskFraction(base, true))
+	assert.False(t, c.paused(true))
+
+	assert.False(t, close(300), "back above the average")
+	assert.Equal(t, base, c.riskFraction(base, false))
+}
+
+func TestEquityCurve_Drawdown(t *testing.T) {
+	c := newEquityCurve(EquityCurveRules{MaxDrawdown: types.RateFromFloat(0.05)}, types.MoneyFromFloat(10_000))
+	trades := []*account.Trade{{PNL: types.MoneyFromFloat(1_000)}, {PNL: types.MoneyFromFloat(-500)}}
+	c.observe(trades)
+	assert.False(t, c.throttled(), "500 is under 5% of the 11,000 peak")
+
+	trades = append(trades, &account.Trade{PNL: types.MoneyFromFloat(-100)})
+	c.observe(trades)
+	assert.True(t, c.throttled(), "600 is over 5% of the peak")
+	assert.True(t, c.paused(true), "a zero risk scale pauses entries")
+
+	trades = append(trades, &account.Trade{PNL: types.MoneyFromFloat(200)})
+	c.observe(trades)
+	assert.False(t, c.throttled(), "recovered to within 5%")
+}
+
+func TestRunWithIterator_EquityCurvePausesEntries(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	j := &signalJournal{}
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, j)}
+
+	// The first entry is stopped out on the second bar, a 1% loss; with a
+	// 0.5% drawdown limit every later entry is paused.
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 10; i++ {
+		c := market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		}
+		if i == 1 {
+			c.Low, c.Close = 1090000, 1090000
+		}
+		candles = append(candles, c)
+	}
+
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        alwaysLong{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[9].Timestamp, TF: types.H1},
+			EquityCurve:     EquityCurveRules{MaxDrawdown: types.RateFromFloat(0.005)},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	require.Len(t, acct.Trades, 1)
+	assert.Negative(t, acct.Trades[0].PNL)
+	require.NotEmpty(t, run.State.Skipped)
+	assert.Equal(t, run.State.Skipped, j.skipped)
+	assert.Equal(t, RuleEquityCurve, run.State.Skipped[0].Rule)
+
+	res := run.BuildBacktestResult(acct)
+	assert.Equal(t, map[string]int{journal.RejectEquityCurve: len(run.State.Skipped)}, res.Rejected)
+}
+
+func TestRunWithIterator_EquityCurveScalesRisk(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 4; i++ {
+		c := market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		}
+		if i == 1 {
+			c.Low, c.Close = 1090000, 1090000
+		}
+		candles = append(candles, c)
+	}
+
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        alwaysLong{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[3].Timestamp, TF: types.H1},
+			EquityCurve:     EquityCurveRules{MaxDrawdown: types.RateFromFloat(0.005), RiskScale: types.RateFromFloat(0.5)},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	// The stop-out leaves the curve at 9,900 until the later trades close
+	// on the last bar, so the three entries after it risk 0.5%.
+	require.Len(t, acct.Trades, 4)
+	assert.Empty(t, run.State.Skipped)
+	assert.Equal(t, 3, run.State.Throttled)
+	assert.Equal(t, types.MoneyFromFloat(100), acct.Trades[0].InitialRisk)
+	assert.Equal(t, types.MoneyFromFloat(49.5), acct.Trades[1].InitialRisk)
+	assert.Equal(t, types.RateFromFloat(0.01), acct.RiskFraction, "risk restored after the run")
+}
@@ -2,6 +2,7 @@ package backtest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync/atomic"
 	"time"
@@ -12,7 +13,7 @@ import (
 	"github.com/rustyeddy/trader/brokers/sim"
 	"github.com/rustyeddy/trader/datamanager"
 	"github.com/rustyeddy/trader/engine"
-	"github.com/rustyeddy/trader/log"
+	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/planner"
 	"github.com/rustyeddy/trader/strategy"
@@ -31,6 +32,22 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 	if run.State == nil {
 		run.State = &BacktestRun{}
 	}
+	run.State.StopReason, run.State.StoppedAt = "", 0
+	run.State.Interrupted = false
+	run.State.Skipped, run.State.Filtered, run.State.Throttled = nil, nil, 0
+	run.State.Rejected, run.State.decisions, run.State.Signals = nil, nil, nil
+	run.State.exposure = nil
+	run.State.prices, run.State.pricesOver = nil, false
+	run.State.propFirm = newPropFirm(run.Request.PropFirm, run.Request.StartingBalance)
+	run.State.htf = nil
+	run.State.CandleFaults = nil
+	run.State.SliceExcluded = 0
+	run.State.WarmupBars = 0
+	if run.Request.HigherTF != 0 {
+		if run.State.htf, err = newHTFFeed(run.Request.TimeRange.TF, run.Request.HigherTF, run.Request.HTFWarmup); err != nil {
+			return err
+		}
+	}
 	strat.Reset()
 
 	exit := run.Request.Exit
@@ -45,6 +62,7 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 
 	// Convert slippage and max-spread pips to Price units using instrument metadata.
 	var slippage, maxSpread types.Price
+	guard := newSpreadGuard(run.Request.SpreadGuard, market.GetInstrument(run.Request.Instrument))
 	if inst := market.GetInstrument(run.Request.Instrument); inst != nil {
 		if run.Request.SlippagePips != 0 {
 			slippage = inst.PriceDeltaFromPips(run.Request.SlippagePips)
@@ -61,7 +79,11 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 		}
 	}()
 
-	runCtx, cancel := context.WithCancel(ctx)
+	// The run's own machinery (broker stream, event handler, watchdog)
+	// runs on a context detached from ctx's cancellation, so that when ctx
+	// is cancelled the loop can stop at a bar boundary and still wind
+	// down — drain fills, optionally close lots — before it is torn down.
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
 	defer cancel()
 
 	evtQ := t.Account.Events()
@@ -101,7 +123,7 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 			if pending == 0 {
 				break
 			}
-			log.L.Debug("backtest drain events", "events", pending)
+			run.Logger().Debug("backtest drain events", "events", pending)
 			time.Sleep(1 * time.Millisecond)
 		}
 
@@ -116,6 +138,7 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 	var submittedOpens int64
 	var submittedCloses int64
 	haveLastCandle := false
+	var lastTs types.Timestamp
 
 	var lastProgressNanos int64
 	atomic.StoreInt64(&lastProgressNanos, time.Now().UnixNano())
@@ -148,7 +171,7 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 				}
 
 				if lag > 30*time.Second {
-					log.L.Warn("watchdog: backtest appears stalled",
+					run.Logger().Warn("watchdog: backtest appears stalled",
 						"noProgressFor", lag.String(),
 						"candles", candles,
 						"events", events,
@@ -160,7 +183,7 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 					continue
 				}
 
-				log.L.Debug("watchdog: progress",
+				run.Logger().Debug("watchdog: progress",
 					"candles", candles,
 					"events", events,
 					"opens", opens,
@@ -173,6 +196,154 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 	}()
 
 	var pl planner.DefaultPlanner
+	stops := stopChecker{cond: run.Request.StopOn}
+
+	// Robustness reruns perturb entries (skip / delay) and entry fills
+	// (extra slippage). The jitter is applied through Sim's own Slippage
+	// so it moves the actual fill, not just the planned price.
+	var jitter types.Price
+	if inst := market.GetInstrument(run.Request.Instrument); inst != nil && run.Request.Perturb.SlippageJitter != 0 {
+		jitter = inst.PriceDeltaFromPips(run.Request.Perturb.SlippageJitter)
+	}
+	perturb := newPerturber(run.Request.Perturb, run.Request.Seed, jitter)
+	simBroker, _ := t.Broker.(*sim.Sim)
+	if simBroker != nil && len(run.Request.Liquidity) > 0 {
+		simBroker.Liquidity = simLiquidity(run.Request.Liquidity, market.GetInstrument(run.Request.Instrument))
+	}
+	if simBroker != nil && run.Request.MarketHours != nil {
+		simBroker.Hours = run.Request.MarketHours
+	}
+	if simBroker != nil {
+		simBroker.IdleInterest = run.Request.IdleInterest
+		simBroker.MinStops = run.Request.MinStops
+		if depth := strategy.TickDepth(strat); depth > 0 {
+			simBroker.Prices().SetDepth(depth)
+		}
+		run.State.ticks = simBroker.Prices()
+	}
+	run.State.previewer, _ = t.Broker.(brokers.OrderPreviewer)
+	run.State.accountID = t.Account.ID
+	gov := newGovernor(run.Request.Governor)
+	vol := strategy.NewDefaultVolatilityClassifier(types.Scale6(types.PriceScale))
+	curve := newEquityCurve(run.Request.EquityCurve, run.Request.StartingBalance)
+	baseRisk := t.Account.RiskFraction
+	wasThrottled := false
+	if curve != nil {
+		defer func() { t.Account.RiskFraction = baseRisk }()
+	}
+	hooks := newHookChain(run.Hooks)
+	signals := newSignalLog(run.Request, exit)
+	progress := newProgressReporter(run.Progress, run.Request.Name, run.Request.TimeRange)
+
+	resting := restingEntries{}
+	// rejectOpen records an entry refused on its way to the broker.
+	rejectOpen := func(candle market.Candle, openReq *account.OpenRequest, reason, detail string) {
+		d := journal.OrderDecision{
+			Time:           candle.Timestamp,
+			Instrument:     run.Request.Instrument,
+			Side:           openReq.Side.String(),
+			Reason:         reason,
+			Detail:         detail,
+			RequestedUnits: openReq.Units,
+		}
+		run.State.Rejected = append(run.State.Rejected, d)
+		if simBroker != nil {
+			simBroker.RecordOrderDecision(d)
+		}
+	}
+
+	// submitOrders sends closes and opens to the broker at the current
+	// price: on the bar they were decided on, or a later one for a
+	// next-tick run.
+	queue := newOrderQueue(run.Request.Fill)
+	submitOrders := func(candle market.Candle, closes []*account.CloseRequest, opens []*account.OpenRequest) error {
+		for _, cl := range closes {
+			// A queued close's lot may have hit its stop in the meantime.
+			if queue != nil && !t.Account.Lots.Has(cl.Lot.ID) {
+				continue
+			}
+			run.Logger().Info("submit close request", "ID", cl.Request.ID)
+
+			atomic.StoreInt64(&lastProgressNanos, time.Now().UnixNano())
+			if _, err := t.Broker.CloseTrade(runCtx, t.Account.ID, cl.Lot.ID, 0); err != nil {
+				return err
+			}
+			if cl.Lot != nil {
+				cl.Lot.State = account.LotCloseRequested
+			}
+			atomic.AddInt64(&submittedCloses, 1)
+		}
+
+		for _, openReq := range opens {
+			run.Logger().Info("Broker event Open Position", "ID", openReq.ID)
+			run.Logger().Info("Open position size", "ID", openReq.ID, "size", openReq.Units)
+			atomic.StoreInt64(&lastProgressNanos, time.Now().UnixNano())
+
+			signedUnits := int64(openReq.Units)
+			if openReq.Side == types.Short {
+				signedUnits = -signedUnits
+			}
+			var res *oanda.OrderResult
+			var err error
+			if entry := run.Request.Entry; entry.Limit {
+				limit := entry.limitPrice(openReq.Instrument, openReq.Side, openReq.Price)
+				if stop := openReq.Stop; stop != 0 && (openReq.Side == types.Long && limit <= stop || openReq.Side == types.Short && limit >= stop) {
+					// The offset carried the limit past the stop.
+					rejectOpen(candle, openReq, journal.RejectInvalidStop, fmt.Sprintf("limit %s is beyond the stop %s", limit, stop))
+					continue
+				}
+				res, err = t.SubmitLimitOrder(runCtx, openReq.Instrument, signedUnits, limit, openReq.Stop,
+					entry.TIF, entry.expiry(candle.Timestamp, run.Request.TimeRange.TF))
+			} else {
+				var baseSlippage types.Price
+				if perturb != nil && simBroker != nil {
+					baseSlippage = simBroker.Slippage
+					simBroker.Slippage += perturb.slippage()
+				}
+				res, err = t.Broker.SubmitMarketOrder(runCtx, t.Account.ID, openReq.Instrument, signedUnits, openReq.Stop.Float64())
+				if perturb != nil && simBroker != nil {
+					simBroker.Slippage = baseSlippage
+				}
+			}
+			if errors.Is(err, sim.ErrStopTooClose) {
+				// A held order's stop can end up too near the price it
+				// is finally submitted at; the broker refuses it.
+				rejectOpen(candle, openReq, journal.RejectStopDistance, err.Error())
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if res.TradeID != "" {
+				labelEntryLot(t.Account, res.TradeID, openReq, vol.Regime())
+			} else if run.Request.Entry.Limit {
+				resting[res.OrderID] = openReq
+			}
+			atomic.AddInt64(&submittedOpens, 1)
+			if run.State.propFirm != nil {
+				run.State.propFirm.entered(candle.Timestamp)
+			}
+		}
+		return nil
+	}
+
+	// Warm-up bars go through every indicator the loop ticks, and the
+	// strategy, but nothing they signal is traded.
+	run.State.Lots = engine.SnapshotLots(&t.Account.Lots)
+	for _, candle := range run.State.preload {
+		run.State.pairs.advance(candle.Timestamp)
+		regime.Tick(candle)
+		exit.Tick(candle)
+		vol.Tick(candle)
+		if run.State.htf != nil {
+			run.State.htf.add(candle)
+		}
+		strat.Update(runCtx, &candle, run)
+		run.State.WarmupBars++
+	}
+	if run.State.WarmupBars > 0 {
+		run.Logger().Info("warm-up preloaded", "bars", run.State.WarmupBars, "ready", strat.Ready())
+	}
 
 	for {
 		atomic.StoreInt64(&lastProgressNanos, time.Now().UnixNano())
@@ -182,20 +353,34 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 		}
 		atomic.StoreInt64(&lastProgressNanos, time.Now().UnixNano())
 
-		if err := runCtx.Err(); err != nil {
-			return err
+		if ctx.Err() != nil {
+			run.interrupt(lastTs)
+			break
 		}
 		if err := t.BrokerEventError(errCh); err != nil {
 			return err
 		}
 
+		if reason := stops.before(candle.Timestamp); reason != "" {
+			run.stopEarly(reason, candle.Timestamp)
+			break
+		}
+
 		haveLastCandle = true
+		lastTs = candle.Timestamp
 		// backtest.Debug("candle", "candle", processedCandles, "candle", candle.String())
 		atomic.AddInt64(&processedCandles, 1)
+		progress.bar(candle.Timestamp)
+
+		if err := hooks.beforeTick(runCtx, candle); err != nil {
+			return err
+		}
 
 		// Tick regime filter and exit strategy indicators every bar.
+		run.State.pairs.advance(candle.Timestamp)
 		regime.Tick(candle)
 		exit.Tick(candle)
+		vol.Tick(candle)
 
 		// Update trailing/chandelier stops on all open lots.
 		if exit.Ready() {
@@ -214,7 +399,13 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 						lot.ExtremePrice = candle.Low
 					}
 				}
-				lot.Stop = exit.UpdateStop(lot.Side, lot.Stop, lot.EntryPrice, lot.ExtremePrice, candle)
+				stop := exit.UpdateStop(lot.Side, lot.Stop, lot.EntryPrice, lot.ExtremePrice, candle)
+				// A trail the broker would refuse as too close to the
+				// market leaves the stop where it was.
+				if stop != lot.Stop && run.Request.MinStops.Check(lot.Instrument, lot.Side == types.Long, candle.Close, stop, 0) != nil {
+					return nil
+				}
+				lot.Stop = stop
 				return nil
 			})
 		}
@@ -236,10 +427,66 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 		if autoExits > 0 {
 			atomic.AddInt64(&submittedCloses, int64(autoExits))
 		}
+		if err := resting.settle(runCtx, t, vol.Regime()); err != nil {
+			return err
+		}
+		if closes, opens := queue.due(candle.Timestamp); len(closes) > 0 || len(opens) > 0 {
+			if err := submitOrders(candle, closes, opens); err != nil {
+				return err
+			}
+		}
+		if err := hooks.afterClose(runCtx, t.Account.Trades); err != nil {
+			return err
+		}
+
+		run.State.trackExposure(account.CurrencyExposures(&t.Account.Lots,
+			map[string]types.Price{market.NormalizeInstrument(run.Request.Instrument): candle.Close}))
+		run.State.trackEquity(candle.Timestamp, t.Account.Equity, candle.Close)
+		run.State.trackPrice(candle)
+
+		// Early-stop conditions are checked once the bar's price and fills
+		// are in, before the strategy can open anything new.
+		if reason := stops.after(t.Account.Equity, len(t.Account.Trades)); reason != "" {
+			run.stopEarly(reason, candle.Timestamp)
+			break
+		}
+		if pf := run.State.propFirm; pf != nil && pf.observe(candle.Timestamp, t.Account.Equity, t.Account.Balance) {
+			run.Logger().Info("prop-firm evaluation settled", "passed", pf.res.Passed, "verdict", pf.res.Verdict)
+			if run.Request.PropFirm.EndOnVerdict {
+				run.stopEarly("prop-firm "+pf.res.Verdict, candle.Timestamp)
+				break
+			}
+		}
+		bar := atomic.LoadInt64(&processedCandles)
+		if gov != nil {
+			gov.observe(bar, candle.Timestamp, t.Account.Trades)
+		}
+		// The equity-curve throttle sets this bar's risk before the
+		// planner sizes anything.
+		throttled := false
+		if curve != nil {
+			curve.observe(t.Account.Trades)
+			throttled = curve.throttled()
+			t.Account.RiskFraction = curve.riskFraction(baseRisk, throttled)
+			if throttled != wasThrottled {
+				run.Logger().Debug("equity curve throttle", "throttled", throttled, "at", candle.Timestamp.String())
+				wasThrottled = throttled
+			}
+		}
 
+		if run.State.htf != nil {
+			run.State.htf.add(candle)
+		}
 		lots := engine.SnapshotLots(&t.Account.Lots)
 		run.State.Lots = lots
+		signals.observe(candle)
 		sig := strat.Update(runCtx, &candle, run)
+		if br, ok := strat.(strategy.BlockReporter); ok {
+			if blocked, filter, ok := br.LastBlocked(); ok {
+				run.skipFiltered(simBroker, candle.Timestamp, blocked, filter)
+				signals.blocked(candle, blocked, filter)
+			}
+		}
 
 		// Finalize the strategy's signal into broker-ready requests: regime gate,
 		// max-spread gate, fill-price adjustment, initial stop, and sizing all
@@ -253,12 +500,85 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 			slippage:        slippage,
 			maxSpread:       maxSpread,
 			defaultStopPips: run.Request.DefaultStopPips,
+			auditSizing:     run.Request.AuditSizing,
+			minStops:        run.Request.MinStops,
+			spreadGuard:     guard,
 		}
 		var stats planner.Stats
 		plan, stats, err := pl.PlanSignal(sig, pc)
 		if err != nil {
 			return err
 		}
+		guard.observe(candle.AvgSpread)
+		for _, a := range stats.Sizing {
+			run.Logger().Info("sizing audit", "at", candle.Timestamp.String(), "sizing", a)
+		}
+		// No new entries until the higher-timeframe feed is warm; closes
+		// (reversals, CloseAll) still go through.
+		if run.State.htf != nil && !run.State.htf.Ready() && len(plan.Opens) > 0 {
+			plan.Opens = nil
+			stats.SpreadOpened, stats.SpreadSum = 0, 0
+			rejectAccepted(stats.Decisions, journal.RejectWarmup, "")
+		}
+		// Entries on a bar when the market is closed are refused, unless
+		// the sim queues them for the reopen.
+		if len(plan.Opens) > 0 && marketClosed(run.Request.MarketHours, run.Request.Instrument, candle.Timestamp) {
+			plan.Opens = nil
+			stats.SpreadOpened, stats.SpreadSum = 0, 0
+			rejectAccepted(stats.Decisions, journal.RejectMarketClosed, "")
+		}
+		// While the equity curve is throttled, entries were sized at the
+		// reduced risk, or are skipped when the throttle pauses trading.
+		if curve != nil && throttled && len(plan.Opens) > 0 {
+			if curve.paused(throttled) {
+				run.skipEntry(simBroker, candle.Timestamp, sig, RuleEquityCurve)
+				plan.Opens = nil
+				stats.SpreadOpened, stats.SpreadSum = 0, 0
+				rejectAccepted(stats.Decisions, journal.RejectEquityCurve, "")
+			} else {
+				run.State.Throttled++
+			}
+		}
+		// The governor vets whatever entry survived the planner, first its
+		// frequency rules and then its open-trade and exposure limits; a
+		// refused entry is recorded as a skipped signal.
+		if gov != nil && len(plan.Opens) > 0 {
+			rule := gov.check(bar, candle.Timestamp)
+			if rule == "" {
+				if rule, err = gov.checkLimits(t.Account, plan, run.Request.Instrument, candle.Close); err != nil {
+					return err
+				}
+			}
+			if rule != "" {
+				run.skipEntry(simBroker, candle.Timestamp, sig, rule)
+				run.Logger().Debug("governor skipped entry", "rule", rule, "at", candle.Timestamp.String())
+				plan.Opens = nil
+				stats.SpreadOpened, stats.SpreadSum = 0, 0
+				rejectAccepted(stats.Decisions, journal.RejectGovernor, rule)
+			} else {
+				gov.admitted(bar, candle.Timestamp)
+			}
+		}
+		if kept, hook, err := hooks.beforeOrder(runCtx, candle, plan.Opens); err != nil {
+			return err
+		} else if hook != "" {
+			run.Logger().Debug("hook dropped entry", "hook", hook, "at", candle.Timestamp.String())
+			plan.Opens = kept
+			if len(kept) == 0 {
+				stats.SpreadOpened, stats.SpreadSum = 0, 0
+				rejectAccepted(stats.Decisions, journal.RejectHook, hook)
+			}
+		}
+		run.State.decisions = stats.Decisions
+		signals.decided(candle, sig, stats.Decisions)
+		for _, d := range stats.Decisions {
+			if !d.Accepted {
+				run.State.Rejected = append(run.State.Rejected, d)
+			}
+			if simBroker != nil {
+				simBroker.RecordOrderDecision(d)
+			}
+		}
 		run.State.SpreadFiltered += stats.SpreadFiltered
 		run.State.SpreadOpened += stats.SpreadOpened
 		run.State.SpreadSum += stats.SpreadSum
@@ -267,52 +587,41 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 			return fmt.Errorf("nil broker: cannot submit orders")
 		}
 
-		for _, cl := range plan.Closes {
-			log.L.Info("submit close request", "ID", cl.Request.ID)
-
-			atomic.StoreInt64(&lastProgressNanos, time.Now().UnixNano())
-			if _, err = t.Broker.CloseTrade(runCtx, t.Account.ID, cl.Lot.ID, 0); err != nil {
-				return err
-			}
-			if cl.Lot != nil {
-				cl.Lot.State = account.LotCloseRequested
-			}
-			atomic.AddInt64(&submittedCloses, 1)
+		// Next-tick runs hold the orders for a later bar's price.
+		opens := perturb.opens(bar, plan.Opens)
+		if queue != nil {
+			queue.hold(candle.Timestamp, plan.Closes, opens)
+		} else if err := submitOrders(candle, plan.Closes, opens); err != nil {
+			return err
 		}
 
-		for _, openReq := range plan.Opens {
-			log.L.Info("Broker event Open Position", "ID", openReq.ID)
-			log.L.Info("Open position size", "ID", openReq.ID, "size", openReq.Units)
-			atomic.StoreInt64(&lastProgressNanos, time.Now().UnixNano())
-
-			signedUnits := int64(openReq.Units)
-			if openReq.Side == types.Short {
-				signedUnits = -signedUnits
-			}
-			res, err := t.Broker.SubmitMarketOrder(runCtx, t.Account.ID, openReq.Instrument, signedUnits, openReq.Stop.Float64())
-			if err != nil {
-				return err
-			}
-			// SubmitMarketOrder has no room for Reason/InitialStop (a real
-			// broker order request doesn't carry app-specific analysis
-			// metadata) — Account.SubmitOpen used to carry these for free
-			// by cloning the whole OpenRequest.TradeCommon. Patch them
-			// onto the fresh lot directly; Range gives the live pointer
-			// (Lots.Get returns a clone, chunk 2's UpdateTradeStop bug).
-			_ = t.Account.Lots.Range(func(lot *account.Lot) error {
-				if lot.ID == res.TradeID {
-					lot.Reason = openReq.Reason
-					lot.InitialStop = openReq.InitialStop
-				}
-				return nil
-			})
-			atomic.AddInt64(&submittedOpens, 1)
+		if err := hooks.afterTick(runCtx, candle, t.Account); err != nil {
+			return err
 		}
 	}
 
-	if err := itr.Err(); err != nil {
+	if err := itr.Err(); err != nil && ctx.Err() == nil {
 		return err
 	}
+	// A cancelled data source ends the iterator rather than the check above.
+	if ctx.Err() != nil && !run.State.Interrupted {
+		run.interrupt(lastTs)
+	}
+	run.State.Signals = signals.finish()
+	for _, s := range run.State.Signals {
+		if simBroker != nil {
+			simBroker.RecordSignal(s)
+		}
+	}
+	if n := queue.drop(); n > 0 {
+		run.Logger().Info("orders still queued at run end were not filled", "orders", n)
+	}
+	if n := len(resting); n > 0 {
+		if err := resting.cancel(runCtx, t); err != nil {
+			return err
+		}
+		run.Logger().Info("limit entries still resting at run end were cancelled", "orders", n)
+	}
 	// Pick up this bar-loop's last opens/closes before checking idle —
 	// drainBrokerFills only sees what's already on brokerFills, and the
 	// per-bar drain above only catches fills through the *previous* bar's
@@ -321,7 +630,7 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 	if err := t.WaitForBrokerIdle(errCh, 2*time.Second); err != nil {
 		return err
 	}
-	if haveLastCandle {
+	if haveLastCandle && (!run.State.Interrupted || run.CloseOnInterrupt) {
 		var remaining []*account.Lot
 		_ = t.Account.Lots.Range(func(lot *account.Lot) error {
 			if lot != nil && lot.State == account.LotOpen {
@@ -350,8 +659,19 @@ func (run *Backtest) runWithIterator(ctx context.Context, t *engine.Trader, itr
 	if err := t.WaitForBrokerIdle(errCh, 2*time.Second); err != nil {
 		return err
 	}
+	if err := hooks.afterClose(runCtx, t.Account.Trades); err != nil {
+		return err
+	}
 
-	log.L.Info("backtest finished", "candles", atomic.LoadInt64(&processedCandles),
+	// Entries queued over a market close that the data ended before
+	// reopening never filled; say so rather than let them vanish.
+	if pending, err := t.PendingOrders(runCtx); err == nil && len(pending) > 0 {
+		run.Logger().Warn("orders still pending at the end of the run", "orders", len(pending),
+			"first", pending[0].ID, "instrument", pending[0].Instrument)
+	}
+
+	progress.done()
+	run.Logger().Info("backtest finished", "candles", atomic.LoadInt64(&processedCandles),
 		"events", atomic.LoadInt64(&processedEvents),
 		"opens", atomic.LoadInt64(&submittedOpens),
 		"closes", atomic.LoadInt64(&submittedCloses),
@@ -368,7 +688,7 @@ func (run *Backtest) Execute(ctx context.Context, t *engine.Trader) error {
 		return fmt.Errorf("nil backtest run")
 	}
 
-	log.L.Info("backtest start",
+	run.Logger().Info("backtest start",
 		"instrument", run.Request.Instrument,
 		"strategy", run.Request.Strategy.Name(),
 		"balance", run.Request.StartingBalance.Float64(),
@@ -390,18 +710,82 @@ func (run *Backtest) Execute(ctx context.Context, t *engine.Trader) error {
 		Instrument: run.Request.Instrument,
 		Range:      run.Request.TimeRange,
 	}
-	log.L.Debug("candle request prepared", "source", candlereq.Source, "instrument", candlereq.Instrument, "timeframe", candlereq.Range.TF)
+	run.Logger().Debug("candle request prepared", "source", candlereq.Source, "instrument", candlereq.Instrument, "timeframe", candlereq.Range.TF)
 
-	// Grab the candle iterator for this backtest
-	itr, err := t.DataManager.Candles(ctx, candlereq)
+	// Grab the candle iterator for this backtest from the source's feed.
+	data := feedSource{store: t.DataManager, params: run.Request.SourceParams}
+	itr, err := data.Candles(ctx, candlereq)
 	if err != nil {
 		return err
 	}
 
+	// Drop bars outside the calendar slice before they are checked, so
+	// faults are only reported for data the run actually uses.
+	var sliced *SlicedCandleIterator
+	if !run.Request.Slice.IsZero() {
+		sliced = NewSlicedCandleIterator(itr, run.Request.Slice)
+		itr = sliced
+	}
+
+	// Repair inverted bars and keep malformed ones away from indicators.
+	checked := market.NewCheckedCandleIterator(itr, &market.CandleChecker{
+		Instrument: run.Request.Instrument,
+		Repair:     true,
+	})
+
+	preload, err := run.loadWarmup(ctx, data, source)
+	if err != nil {
+		_ = checked.Close()
+		return err
+	}
+	if len(preload) < run.Request.Warmup {
+		run.Logger().Warn("warm-up data is short", "instrument", run.Request.Instrument,
+			"want", run.Request.Warmup, "got", len(preload))
+	}
+	if run.State == nil {
+		run.State = &BacktestRun{}
+	}
+	run.State.preload = preload
+	defer func() { run.State.preload = nil }()
+
+	conv, err := run.loadReportCurrency(ctx, data, source, t.Account.Currency)
+	if err != nil {
+		_ = checked.Close()
+		return err
+	}
+	run.State.conversion, run.State.reportEquity = conv, nil
+
+	pairs, err := run.loadPairFeed(ctx, data, source)
+	if err != nil {
+		_ = checked.Close()
+		return err
+	}
+	run.State.pairs = pairs
+	defer func() { run.State.pairs = nil }()
+
 	run.Result = nil
-	if err := run.runWithIterator(ctx, t, itr); err != nil {
+	err = run.runWithIterator(ctx, t, checked)
+	if closeErr := pairs.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("regime pairs: %w", closeErr)
+	}
+	if err != nil {
 		return err
 	}
+	if sliced != nil {
+		run.State.SliceExcluded = sliced.Excluded()
+		run.Logger().Info("calendar slice applied", "instrument", run.Request.Instrument,
+			"excluded", sliced.Excluded())
+	}
+	if counts := checked.Counts(); len(counts) > 0 {
+		run.State.CandleFaults = counts
+		run.Logger().Warn("candle sanity violations", "instrument", run.Request.Instrument,
+			"dropped", checked.Dropped(), "faults", market.FormatCandleFaults(counts))
+		// The bars themselves, capped, for finding them in the data.
+		for _, v := range checked.Violations() {
+			run.Logger().Debug("candle sanity violation", "instrument", run.Request.Instrument,
+				"violation", v.String())
+		}
+	}
 	if run.Result == nil {
 		run.BuildBacktestResult(t.Account)
 	}
@@ -409,6 +793,23 @@ func (run *Backtest) Execute(ctx context.Context, t *engine.Trader) error {
 	return nil
 }
 
+// stopEarly records why and when the run loop ended before its data did.
+func (run *Backtest) stopEarly(reason string, ts types.Timestamp) {
+	run.Logger().Info("backtest stopped early", "reason", reason, "at", ts.String())
+	run.State.StopReason = reason
+	run.State.StoppedAt = ts
+}
+
+// interrupt records that ctx was cancelled after the bar stamped ts.
+func (run *Backtest) interrupt(ts types.Timestamp) {
+	run.Logger().Warn("backtest interrupted", "at", ts.String(), "close_open_lots", run.CloseOnInterrupt)
+	run.State.StopReason = StopInterrupted
+	run.State.Interrupted = true
+	if ts != 0 {
+		run.State.StoppedAt = ts
+	}
+}
+
 // drainBrokerFills applies every fill currently queued on ch to acct's own
 // event queue, translating oanda.TxEvent -> account.Event so
 // engine.Trader's existing StartBrokerEventHandler/processEvent machinery
@@ -469,3 +870,47 @@ func txEventToAccountEvent(acct *account.Account, tx oanda.Transaction) *account
 	}
 	return &account.Event{Type: account.EventPositionClosed, Lot: lot, Trade: trade}
 }
+
+// skipEntry records sig's entry as a skipped signal refused by rule, in
+// the run state and the sim broker's journal.
+func (run *Backtest) skipEntry(simBroker *sim.Sim, ts types.Timestamp, sig strategy.Signal, rule string) {
+	skipped := journal.SkippedSignal{
+		Time:       ts,
+		Instrument: run.Request.Instrument,
+		Side:       sig.Side.String(),
+		Rule:       rule,
+		Reason:     sig.Reason,
+	}
+	run.State.Skipped = append(run.State.Skipped, skipped)
+	if simBroker != nil {
+		simBroker.RecordSkippedSignal(skipped)
+	}
+}
+
+// skipFiltered records an entry the strategy's filter chain blocked as a
+// skipped signal in the sim broker's journal and counts it by filter.
+func (run *Backtest) skipFiltered(simBroker *sim.Sim, ts types.Timestamp, sig strategy.Signal, filter string) {
+	if run.State.Filtered == nil {
+		run.State.Filtered = make(map[string]int)
+	}
+	run.State.Filtered[filter]++
+	if simBroker != nil {
+		simBroker.RecordSkippedSignal(journal.SkippedSignal{
+			Time:       ts,
+			Instrument: run.Request.Instrument,
+			Side:       sig.Side.String(),
+			Rule:       filter,
+			Reason:     sig.Reason,
+		})
+	}
+}
+
+// rejectAccepted turns the accepted decisions in ds into rejections for a
+// gate that runs after the planner (warm-up, governor).
+func rejectAccepted(ds []journal.OrderDecision, reason, detail string) {
+	for i := range ds {
+		if ds[i].Accepted {
+			ds[i].Accepted, ds[i].Reason, ds[i].Detail, ds[i].Units = false, reason, detail, 0
+		}
+	}
+}
@@ -1,13 +1,19 @@
 package backtest
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/sim"
 	"github.com/rustyeddy/trader/datamanager"
 	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/strategy"
 	"github.com/rustyeddy/trader/types"
@@ -125,3 +131,393 @@ func TestTraderBacktest_GuardsAndSuccess(t *testing.T) {
 	require.NotNil(t, run.Result)
 	assert.Equal(t, 0, run.Result.Trades)
 }
+
+func TestTraderBacktest_ReadsConfiguredFeed(t *testing.T) {
+	datamanager.UseTempDataDir(t)
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)
+	strat := &countingStrategy{}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        strat,
+			Source:          "synthetic",
+			SourceParams:    map[string]any{"seed": 3},
+			TimeRange:       types.NewTimeRange(types.FromTime(start), types.FromTime(start.Add(24*time.Hour)), types.H1),
+			StartingBalance: types.MoneyFromFloat(10_000),
+		},
+	}
+	tr := &engine.Trader{
+		Account:     account.NewAccount("acct", types.MoneyFromFloat(10_000)),
+		DataManager: datamanager.NewDataManager([]string{"EURUSD"}, start, start.Add(24*time.Hour)),
+	}
+	require.NoError(t, run.Execute(ctx, tr))
+	assert.Equal(t, 24, strat.calls, "one update per synthetic bar, with nothing in the store")
+
+	run.Request.SourceParams = map[string]any{"seed": 3, "bogus": 1}
+	require.ErrorContains(t, run.Execute(ctx, tr), `unknown param "bogus"`)
+}
+
+func TestTraderBacktest_ReportsCandleViolations(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	candles := make([]market.Candle, 3)
+	for i := range candles {
+		candles[i] = market.Candle{Open: 110000, High: 110100, Low: 109900, Close: 110000, Ticks: 10}
+	}
+	candles[1].High, candles[1].Low = candles[1].Low, candles[1].High
+	datamanager.SeedCandles(t, "oanda", "EURUSD", types.H1, start, candles)
+
+	var logs bytes.Buffer
+	strat := &countingStrategy{}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        strat,
+			TimeRange:       types.NewTimeRange(types.FromTime(start), types.FromTime(start.Add(3*time.Hour)), types.H1),
+			StartingBalance: types.MoneyFromFloat(10_000),
+		},
+		Log: slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	tr := &engine.Trader{
+		Account:     account.NewAccount("acct", types.MoneyFromFloat(10_000)),
+		DataManager: datamanager.NewDataManager([]string{"EURUSD"}, start, start.Add(3*time.Hour)),
+	}
+	require.NoError(t, run.Execute(ctx, tr))
+	assert.Equal(t, 3, strat.calls, "the inverted bar is repaired, not dropped")
+	assert.Equal(t, map[market.CandleFault]int{market.CandleInverted: 1}, run.State.CandleFaults)
+	assert.Contains(t, logs.String(), "inverted: high 1.09900 < low 1.10100 (repaired)")
+}
+
+// alternatingStop goes long every bar, with the stop at the entry price
+// (refused by sizing) on even bars, and records the decisions it is shown.
+type alternatingStop struct {
+	bar  int
+	seen [][]journal.OrderDecision
+}
+
+func (s *alternatingStop) Name() string            { return "alternating-stop" }
+func (s *alternatingStop) Reset()                  { s.bar = 0 }
+func (s *alternatingStop) Ready() bool             { return true }
+func (s *alternatingStop) StopDescription() string { return "" }
+func (s *alternatingStop) Update(_ context.Context, c *market.Candle, ctx strategy.StrategyContext) strategy.Signal {
+	s.seen = append(s.seen, ctx.LastOrderDecisions())
+	stop := c.Close - 5000
+	if s.bar%2 == 0 {
+		stop = c.Close
+	}
+	s.bar++
+	return strategy.Signal{Side: types.Long, Stop: stop, Reason: "alt"}
+}
+
+// decisionJournal captures order decisions.
+type decisionJournal struct {
+	signalJournal
+	decisions []journal.OrderDecision
+}
+
+func (j *decisionJournal) RecordOrderDecision(d journal.OrderDecision) error {
+	j.decisions = append(j.decisions, d)
+	return nil
+}
+
+func TestRunWithIterator_RecordsOrderDecisions(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	j := &decisionJournal{}
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, j)}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 4; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	strat := &alternatingStop{}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        strat,
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[3].Timestamp, TF: types.H1},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	require.Len(t, j.decisions, 4)
+	for i, d := range j.decisions {
+		assert.Equal(t, candles[i].Timestamp, d.Time)
+		assert.Equal(t, i%2 == 1, d.Accepted, "bar %d", i)
+	}
+	rejected := j.decisions[0]
+	assert.Equal(t, journal.RejectInvalidStop, rejected.Reason)
+	assert.Contains(t, rejected.Detail, "entry and stop must differ")
+	assert.Zero(t, rejected.Units)
+	assert.NotZero(t, j.decisions[1].Units)
+
+	// Each Update sees the previous bar's decisions.
+	require.Len(t, strat.seen, 4)
+	assert.Nil(t, strat.seen[0])
+	assert.Equal(t, j.decisions[:1], strat.seen[1])
+	assert.Equal(t, j.decisions[1:2], strat.seen[2])
+
+	assert.Equal(t, []journal.OrderDecision{j.decisions[0], j.decisions[2]}, run.State.Rejected)
+	res := run.BuildBacktestResult(acct)
+	assert.Equal(t, map[string]int{journal.RejectInvalidStop: 2}, res.Rejected)
+}
+
+func TestRunWithIterator_AuditSizingLogsEverySizing(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 2; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	var buf bytes.Buffer
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        &alternatingStop{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[1].Timestamp, TF: types.H1},
+			AuditSizing:     true,
+		},
+		State: &BacktestRun{},
+		Log:   slog.New(slog.NewTextHandler(&buf, nil)),
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	out := buf.String()
+	assert.Equal(t, 2, strings.Count(out, "sizing audit"), out)
+	assert.Contains(t, out, "sizing.err=\"invalid stop: entry and stop must differ\"")
+	assert.Contains(t, out, "sizing.stop_pips=500")
+	assert.Contains(t, out, "sizing.quote_to_account=1.000000")
+}
+
+// marginCapped previews a fixed-size long every bar and goes long only
+// while the account would stay under 30% margin utilization.
+type marginCapped struct {
+	previews []brokers.OrderPreview
+}
+
+func (s *marginCapped) Name() string            { return "margin-capped" }
+func (s *marginCapped) Reset()                  { s.previews = nil }
+func (s *marginCapped) Ready() bool             { return true }
+func (s *marginCapped) StopDescription() string { return "" }
+func (s *marginCapped) Update(ctx context.Context, c *market.Candle, sc strategy.StrategyContext) strategy.Signal {
+	p, err := sc.(strategy.OrderPreviewer).PreviewOrder(ctx, types.Long, 10_000)
+	if err != nil {
+		return strategy.Hold(err.Error())
+	}
+	s.previews = append(s.previews, p)
+	if p.MarginUsed*10 > p.Equity*3 {
+		return strategy.Hold("margin")
+	}
+	return strategy.Signal{Side: types.Long, Stop: c.Close - 5000, Reason: "preview ok"}
+}
+
+// tickReader holds every bar and records the closes its tick history
+// holds, keeping two ticks.
+type tickReader struct {
+	seen [][]types.Price
+}
+
+func (s *tickReader) Name() string            { return "tick-reader" }
+func (s *tickReader) Reset()                  { s.seen = nil }
+func (s *tickReader) Ready() bool             { return true }
+func (s *tickReader) StopDescription() string { return "" }
+func (s *tickReader) TickDepth() int          { return 2 }
+func (s *tickReader) Update(_ context.Context, _ *market.Candle, sc strategy.StrategyContext) strategy.Signal {
+	var mids []types.Price
+	for _, tick := range sc.(strategy.TickHistory).RecentTicks(5) {
+		mids = append(mids, tick.Mid())
+	}
+	s.seen = append(s.seen, mids)
+	return strategy.Hold("reading")
+}
+
+func TestRunWithIterator_StrategiesReadTickHistory(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 3; i++ {
+		c := types.Price(1100000 + i*100)
+		candles = append(candles, market.Candle{
+			Open: c, High: c + 500, Low: c - 500, Close: c,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	strat := &tickReader{}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        strat,
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[2].Timestamp, TF: types.H1},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	assert.Equal(t, [][]types.Price{
+		{1100000},
+		{1100000, 1100100},
+		{1100100, 1100200},
+	}, strat.seen, "the sim keeps the strategy's TickDepth of bar prices")
+}
+
+func TestRunWithIterator_StrategiesCanPreviewOrders(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 3; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	strat := &marginCapped{}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        strat,
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[2].Timestamp, TF: types.H1},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	require.Len(t, strat.previews, 3)
+	first := strat.previews[0]
+	assert.Equal(t, "EURUSD", first.Instrument)
+	assert.Equal(t, types.Price(1100000), first.FillPrice)
+	assert.Equal(t, first.MarginRequired, first.MarginUsed, "nothing open on the first bar")
+
+	// The second bar's preview stacks on the lot the first bar opened.
+	second := strat.previews[1]
+	assert.Equal(t, first.MarginRequired, second.MarginRequired)
+	assert.Greater(t, second.MarginUsed, second.MarginRequired)
+
+	var none Backtest
+	_, err := none.PreviewOrder(context.Background(), types.Long, 1000)
+	assert.Error(t, err)
+}
+
+// cancelAfter goes long on the first bar and cancels the run's context
+// once it has seen n bars.
+type cancelAfter struct {
+	n      int
+	seen   int
+	cancel context.CancelFunc
+}
+
+func (s *cancelAfter) Name() string            { return "cancel-after" }
+func (s *cancelAfter) Reset()                  { s.seen = 0 }
+func (s *cancelAfter) Ready() bool             { return true }
+func (s *cancelAfter) StopDescription() string { return "" }
+func (s *cancelAfter) Update(_ context.Context, c *market.Candle, _ strategy.StrategyContext) strategy.Signal {
+	s.seen++
+	if s.seen == s.n {
+		s.cancel()
+	}
+	if s.seen == 1 {
+		return strategy.Signal{Side: types.Long, Stop: c.Close - 5000, Reason: "first bar"}
+	}
+	return strategy.Signal{}
+}
+
+func TestRunWithIterator_InterruptStopsAtBarBoundary(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 10; i++ {
+		candles = append(candles, market.Candle{
+			Open: 110000, High: 110050, Low: 109950, Close: 110000 + types.Price(i*10),
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	for _, closeOpen := range []bool{false, true} {
+		acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+		acct.RiskFraction = types.RateFromFloat(0.01)
+		tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		strat := &cancelAfter{n: 4, cancel: cancel}
+		run := &Backtest{
+			Request: &BacktestRequest{
+				Name:       "interrupt",
+				Instrument: "EURUSD",
+				Strategy:   strat,
+				TimeRange:  types.TimeRange{Start: candles[0].Timestamp, End: candles[9].Timestamp, TF: types.H1},
+			},
+			State:            &BacktestRun{},
+			CloseOnInterrupt: closeOpen,
+		}
+		require.NoError(t, run.runWithIterator(ctx, tr, &fixedCandleIterator{candles: candles}))
+		run.BuildBacktestResult(acct)
+		cancel()
+
+		assert.Equal(t, 4, strat.seen, "no bar is replayed after the cancel")
+		assert.True(t, run.Result.Interrupted)
+		assert.Equal(t, StopInterrupted, run.Result.StopReason)
+		assert.Equal(t, candles[3].Timestamp, run.Result.End)
+		if closeOpen {
+			assert.Equal(t, 1, run.Result.Trades, "open lot closed on interrupt")
+		} else {
+			assert.Zero(t, run.Result.Trades, "open lot left open")
+			assert.Equal(t, 1, acct.Lots.Len())
+		}
+
+		s := run.Summary()
+		assert.True(t, s.Interrupted)
+	}
+}
+
+func TestRunWithIterator_IdleInterestCountsInNetPL(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 11; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1100500, Low: 1099500, Close: 1100000,
+			Timestamp: types.FromTime(start.AddDate(0, 0, i)),
+		})
+	}
+
+	acct := account.NewAccount("acct", types.MoneyFromFloat(36_500))
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        testFake{},
+			StartingBalance: types.MoneyFromFloat(36_500),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[10].Timestamp, TF: types.D1},
+			IdleInterest:    types.RateFromFloat(0.0365),
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+	res := run.BuildBacktestResult(acct)
+	require.NotNil(t, res)
+
+	assert.Zero(t, res.Trades)
+	assert.InDelta(t, 36.52, res.Interest.Float64(), 0.01, "ten days at about 3.65")
+	assert.Equal(t, res.Interest, res.NetPL, "a flat run's P/L is its interest")
+	var out bytes.Buffer
+	PrintSummary(&out, run.Summary())
+	assert.Contains(t, out.String(), "Interest: $36.5")
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/rustyeddy/trader/account"
 	"github.com/rustyeddy/trader/brokers/sim"
 	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/idgen"
+	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -22,6 +24,10 @@ type BacktestExecutor interface {
 type TraderBacktestExecutor struct {
 	DataManager    engine.CandleSource
 	AccountFactory func(name string, balance types.Money) *account.Account
+
+	// CandleProviders supplies the provider a run with data.download set
+	// downloads its missing months from. Nil fails such runs.
+	CandleProviders CandleProviderFunc
 }
 
 // NewTraderBacktestExecutor returns a BacktestExecutor that uses Trader as the
@@ -50,7 +56,20 @@ func (e *TraderBacktestExecutor) Execute(ctx context.Context, run *Backtest) err
 		return fmt.Errorf("nil account factory")
 	}
 
-	t := &engine.Trader{DataManager: e.DataManager}
+	data := e.DataManager
+	if run.Request.Download {
+		source := firstNonEmpty(run.Request.Source, market.SourceOanda)
+		if e.CandleProviders == nil {
+			return fmt.Errorf("download %s data: no candle providers configured", source)
+		}
+		provider, err := e.CandleProviders(source)
+		if err != nil {
+			return fmt.Errorf("download %s data: %w", source, err)
+		}
+		data = fetchingSource{store: data, provider: provider, log: run.Logger()}
+	}
+
+	t := &engine.Trader{DataManager: data, Log: run.Logger()}
 	acct := e.AccountFactory("backtest", run.Request.StartingBalance)
 	if acct == nil {
 		return fmt.Errorf("nil account")
@@ -58,13 +77,18 @@ func (e *TraderBacktestExecutor) Execute(ctx context.Context, run *Backtest) err
 	if run.Request.RiskPct != 0 {
 		acct.RiskFraction = run.Request.RiskPct
 	}
+	acct.Margin = run.Request.Margin
 	t.Account = acct
 	// Sim wraps the same Account, not a separate one — its
 	// SubmitMarketOrder/CloseTrade write directly into t.Account.Lots via
 	// AddLot/CloseLot, no reconciliation step needed between two account
 	// states (see docs/Manual/architecture-broker-account-order.org,
 	// phase 4 chunk 4).
-	t.Broker = sim.NewSimBroker(acct, nil)
+	// Fill IDs read "<run ID>-000001", "-000002", ... so journal rows can
+	// be traced to their run and ordered without a timestamp lookup.
+	broker := sim.NewSimBroker(acct, nil)
+	broker.IDs = idgen.NewSequence(run.ID)
+	t.Broker = broker
 
 	return run.Execute(ctx, t)
 }
@@ -3,9 +3,11 @@ package backtest
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/rustyeddy/trader/account"
 	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,3 +43,55 @@ func TestNewTraderBacktestExecutor_DefaultFactories(t *testing.T) {
 	assert.Equal(t, "backtest", acct.Name)
 	assert.Equal(t, types.MoneyFromFloat(10_000), acct.Balance)
 }
+
+// monthProvider serves a flat H1 bar for every slot of a month.
+type monthProvider struct{ months int }
+
+func (p *monthProvider) Name() string { return market.SourceOanda }
+
+func (p *monthProvider) FetchCandleMonth(_ context.Context, _ string, tf types.Timeframe, monthStart time.Time) (*datamanager.CandleMonth, error) {
+	p.months++
+	bounds := datamanager.MonthSlotBoundaries(monthStart, monthStart.AddDate(0, 1, 0), tf)
+	candles := make([]market.Candle, len(bounds))
+	for i, b := range bounds {
+		candles[i] = market.Candle{Open: 110000, High: 110010, Low: 109990, Close: 110000, Ticks: 1, Timestamp: types.FromTime(b)}
+	}
+	return &datamanager.CandleMonth{Candles: candles}, nil
+}
+
+func TestTraderBacktestExecutor_DownloadsMissingData(t *testing.T) {
+	datamanager.UseTempDataDir(t)
+	start := time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)
+	newRun := func() *Backtest {
+		return &Backtest{Request: &BacktestRequest{
+			Name:            "dl",
+			Instrument:      "EURUSD",
+			Source:          market.SourceOanda,
+			Download:        true,
+			Strategy:        &countingStrategy{},
+			TimeRange:       types.NewTimeRange(types.FromTime(start), types.FromTime(start.Add(24*time.Hour)), types.H1),
+			StartingBalance: types.MoneyFromFloat(10_000),
+		}}
+	}
+
+	exec := NewTraderBacktestExecutor(datamanager.NewDataManager([]string{"EURUSD"}, start, start))
+	require.ErrorContains(t, exec.Execute(context.Background(), newRun()), "no candle providers")
+
+	p := &monthProvider{}
+	exec.CandleProviders = func(source string) (datamanager.CandleProvider, error) { return p, nil }
+	run := newRun()
+	require.NoError(t, exec.Execute(context.Background(), run))
+	assert.Equal(t, 1, p.months)
+	assert.Equal(t, 24, run.Request.Strategy.(*countingStrategy).calls)
+
+	// The second run reads what the first fetched.
+	run = newRun()
+	require.NoError(t, exec.Execute(context.Background(), run))
+	assert.Equal(t, 1, p.months)
+	assert.Equal(t, 24, run.Request.Strategy.(*countingStrategy).calls)
+
+	recs, err := datamanager.SyncCatalog()
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "oanda/EURUSD/H1", recs[0].Key())
+}
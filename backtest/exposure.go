@@ -0,0 +1,53 @@
+package backtest
+
+import (
+	"sort"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+)
+
+// ExposurePeak is the largest net long and net short amount a run held in
+// one currency at any bar close, in that currency (MoneyScale-scaled).
+type ExposurePeak struct {
+	Currency string
+	MaxLong  types.Money // largest net long, >= 0
+	MaxShort types.Money // largest net short, <= 0
+}
+
+// trackExposure folds one bar's per-currency exposure into the run's peaks.
+func (run *BacktestRun) trackExposure(exps []account.CurrencyExposure) {
+	if len(exps) == 0 {
+		return
+	}
+	if run.exposure == nil {
+		run.exposure = make(map[string]*ExposurePeak)
+	}
+	for _, e := range exps {
+		p, ok := run.exposure[e.Currency]
+		if !ok {
+			p = &ExposurePeak{Currency: e.Currency}
+			run.exposure[e.Currency] = p
+		}
+		if e.Net > p.MaxLong {
+			p.MaxLong = e.Net
+		}
+		if e.Net < p.MaxShort {
+			p.MaxShort = e.Net
+		}
+	}
+}
+
+// ExposurePeaks returns the per-currency peaks recorded so far, sorted by
+// currency.
+func (run *BacktestRun) ExposurePeaks() []ExposurePeak {
+	if run == nil || len(run.exposure) == 0 {
+		return nil
+	}
+	out := make([]ExposurePeak, 0, len(run.exposure))
+	for _, p := range run.exposure {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Currency < out[j].Currency })
+	return out
+}
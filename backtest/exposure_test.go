@@ -0,0 +1,33 @@
+package backtest
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBacktestRun_TrackExposure(t *testing.T) {
+	var run BacktestRun
+	assert.Nil(t, run.ExposurePeaks())
+
+	money := types.MoneyFromFloat
+	run.trackExposure([]account.CurrencyExposure{
+		{Currency: "USD", Net: money(-11_000)},
+		{Currency: "EUR", Net: money(10_000)},
+	})
+	run.trackExposure(nil)
+	run.trackExposure([]account.CurrencyExposure{
+		{Currency: "EUR", Net: money(-5_000)},
+		{Currency: "USD", Net: money(5_500)},
+	})
+	run.trackExposure([]account.CurrencyExposure{
+		{Currency: "EUR", Net: money(2_000)},
+	})
+
+	assert.Equal(t, []ExposurePeak{
+		{Currency: "EUR", MaxLong: money(10_000), MaxShort: money(-5_000)},
+		{Currency: "USD", MaxLong: money(5_500), MaxShort: money(-11_000)},
+	}, run.ExposurePeaks())
+}
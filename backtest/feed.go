@@ -1,6 +1,7 @@
 package backtest
 
 import (
+	"bufio"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rustyeddy/trader/log"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
@@ -20,7 +22,9 @@ import (
 // where time is RFC3339 or RFC3339Nano.
 //
 // It optionally filters ticks to [From, To) if provided.
-// Header row ("time,...") is allowed.
+// Header row ("time,...") is allowed, and so is a leading metadata line
+// (see market.TickMeta); a tick for an instrument the metadata doesn't list
+// is an error.
 // Empty/short rows are skipped.
 type CSVTicksFeed struct {
 	f    *os.File
@@ -28,6 +32,19 @@ type CSVTicksFeed struct {
 	from types.Timestamp
 	to   types.Timestamp
 
+	// Sanitizer, when set, replaces the plain Tick.Validate check: bad
+	// ticks are counted and dropped (or fail the feed, per its Policy)
+	// instead of always aborting with a parse error.
+	Sanitizer *market.TickSanitizer
+
+	// Slice, when non-zero, drops ticks outside its calendar slices;
+	// Excluded counts them.
+	Slice    CalendarSlice
+	excluded int
+
+	meta    market.TickMeta
+	hasMeta bool
+
 	sawFirst bool
 }
 
@@ -40,10 +57,22 @@ func NewCSVTicksFeed(path string, from, to types.Timestamp) (*CSVTicksFeed, erro
 		return nil, err
 	}
 
-	r := csv.NewReader(f)
+	br := bufio.NewReader(f)
+	meta, hasMeta, err := market.ReadTickMeta(br)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	r := csv.NewReader(br)
 	r.FieldsPerRecord = -1
 
-	return &CSVTicksFeed{f: f, r: r, from: from, to: to}, nil
+	return &CSVTicksFeed{f: f, r: r, from: from, to: to, meta: meta, hasMeta: hasMeta}, nil
+}
+
+// Meta returns the file's metadata line; ok is false when it has none.
+func (f *CSVTicksFeed) Meta() (meta market.TickMeta, ok bool) {
+	return f.meta, f.hasMeta
 }
 
 // Close releases the underlying file handle.
@@ -54,6 +83,9 @@ func (f *CSVTicksFeed) Close() error {
 	return nil
 }
 
+// Excluded returns how many in-range ticks Slice has dropped so far.
+func (f *CSVTicksFeed) Excluded() int { return f.excluded }
+
 // Next advances the feed and returns the next in-range Tick.
 // Returns (Tick{}, false, nil) at EOF and (Tick{}, false, err) on parse errors.
 func (f *CSVTicksFeed) Next() (market.Tick, bool, error) {
@@ -77,23 +109,75 @@ func (f *CSVTicksFeed) Next() (market.Tick, bool, error) {
 			}
 		}
 
-		p, ok, err := parseTickRow(row)
+		if f.Sanitizer == nil {
+			p, ok, err := parseTickRow(row)
+			if err != nil {
+				return market.Tick{}, false, err
+			}
+			if ok && !f.meta.Covers(p.Instrument) {
+				return market.Tick{}, false, f.uncovered(p)
+			}
+			if !ok || !inRange(p.Timestamp, f.from, f.to) {
+				continue
+			}
+			if !f.Slice.Keep(p.Timestamp) {
+				f.excluded++
+				continue
+			}
+			return p, true, nil
+		}
+
+		p, ok, err := parseTickFields(row)
 		if err != nil {
 			return market.Tick{}, false, err
 		}
-		if !ok {
+		if ok && !f.meta.Covers(p.Instrument) {
+			return market.Tick{}, false, f.uncovered(p)
+		}
+		if !ok || !inRange(p.Timestamp, f.from, f.to) {
 			continue
 		}
-		if !inRange(p.Timestamp, f.from, f.to) {
+		if !f.Slice.Keep(p.Timestamp) {
+			f.excluded++
+			continue
+		}
+		v, keep, err := f.Sanitizer.Apply(p)
+		if err != nil {
+			return market.Tick{}, false, err
+		}
+		if !keep {
+			log.L.Warn("dropping bad tick", "instrument", p.Instrument, "time", p.Timestamp.String(),
+				"bid", p.Bid.String(), "ask", p.Ask.String(), "violation", string(v))
 			continue
 		}
 		return p, true, nil
 	}
 }
 
-// parseTickRow parses one CSV row into a Tick. Returns (Tick{}, false, nil)
-// for rows that are too short or have blank fields (silently skipped).
+// uncovered is the error for a tick whose instrument the metadata doesn't
+// list.
+func (f *CSVTicksFeed) uncovered(p market.Tick) error {
+	return fmt.Errorf("tick for %s at %s: file metadata lists only %s",
+		p.Instrument, p.Timestamp, strings.Join(f.meta.Instruments, ","))
+}
+
+// parseTickRow parses one CSV row into a validated Tick. Returns
+// (Tick{}, false, nil) for rows that are too short or have blank fields
+// (silently skipped).
 func parseTickRow(row []string) (market.Tick, bool, error) {
+	tick, ok, err := parseTickFields(row)
+	if err != nil || !ok {
+		return tick, ok, err
+	}
+	if err := tick.Validate(); err != nil {
+		return market.Tick{}, false, err
+	}
+	return tick, true, nil
+}
+
+// parseTickFields is parseTickRow without the Tick.Validate step, so a
+// TickSanitizer can classify crossed or non-positive quotes itself.
+func parseTickFields(row []string) (market.Tick, bool, error) {
 	// Need at least: time,instrument,bid,ask
 	if len(row) < 4 {
 		return market.Tick{}, false, nil
@@ -128,18 +212,14 @@ func parseTickRow(row []string) (market.Tick, bool, error) {
 		return market.Tick{}, false, fmt.Errorf("bad ask %q: %w", row[3], err)
 	}
 
-	tick := market.Tick{
+	return market.Tick{
 		Timestamp:  tstamp,
 		Instrument: inst,
 		BA: market.BA{
 			Bid: types.PriceFromFloat(bid),
 			Ask: types.PriceFromFloat(ask),
 		},
-	}
-	if err := tick.Validate(); err != nil {
-		return market.Tick{}, false, err
-	}
-	return tick, true, nil
+	}, true, nil
 }
 
 // inRange reports whether t falls within [from, to). A zero from or to
@@ -0,0 +1,69 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+)
+
+// feedSource reads a run's candles — its bars, warm-up and report-currency
+// pair — through the feed its data source names, with params the feed's
+// settings from the data section. Store-backed feeds read from store.
+type feedSource struct {
+	store  engine.CandleSource
+	params map[string]any
+}
+
+// Candles implements engine.CandleSource.
+func (f feedSource) Candles(ctx context.Context, req datamanager.CandleRequest) (market.CandleIterator, error) {
+	return datamanager.NewFeed(ctx, req.Source, datamanager.FeedParams{
+		Instrument: req.Instrument,
+		Range:      req.Range,
+		Strict:     req.Strict,
+		Params:     f.params,
+		Store:      f.store,
+	})
+}
+
+// CandleProviderFunc returns the provider to download a data source's
+// candles from, for runs with data.download set.
+type CandleProviderFunc func(source string) (datamanager.CandleProvider, error)
+
+// candleFetcher is the store side of a download; *datamanager.DataManager
+// implements it.
+type candleFetcher interface {
+	FetchMissingCandles(ctx context.Context, provider datamanager.CandleProvider, req datamanager.CandleRequest) (*datamanager.CandleSyncResult, error)
+}
+
+// fetchingSource fetches the months of each request for provider's source
+// missing from store before reading them, so a run's warm-up and
+// report-currency pair are downloaded along with its bars.
+type fetchingSource struct {
+	store    engine.CandleSource
+	provider datamanager.CandleProvider
+	log      *slog.Logger
+}
+
+// Candles implements engine.CandleSource.
+func (f fetchingSource) Candles(ctx context.Context, req datamanager.CandleRequest) (market.CandleIterator, error) {
+	if strings.EqualFold(strings.TrimSpace(req.Source), f.provider.Name()) {
+		fetcher, ok := f.store.(candleFetcher)
+		if !ok {
+			return nil, fmt.Errorf("candle store %T cannot download data", f.store)
+		}
+		res, err := fetcher.FetchMissingCandles(ctx, f.provider, req)
+		if err != nil {
+			return nil, fmt.Errorf("download %s %s: %w", req.Source, req.Instrument, err)
+		}
+		if res.MonthsProcessed > 0 {
+			f.log.Info("downloaded candles", "source", req.Source, "instrument", req.Instrument,
+				"timeframe", req.Range.TF, "months", res.MonthsProcessed, "candles", res.CandlesWritten)
+		}
+	}
+	return f.store.Candles(ctx, req)
+}
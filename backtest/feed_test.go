@@ -396,6 +396,66 @@ func TestCSVTicksFeed_Next(t *testing.T) {
 		assert.Len(t, ticks, 2)
 	})
 
+	t.Run("sanitizer drops bad ticks and counts them", func(t *testing.T) {
+		t.Parallel()
+
+		tmp := t.TempDir()
+		csvPath := filepath.Join(tmp, "test.csv")
+
+		csv := `2026-01-24T09:30:00Z,EUR_USD,1.1000,1.1002
+2026-01-24T09:30:05Z,EUR_USD,1.1012,1.1010
+2026-01-24T09:30:10Z,EUR_USD,1.1000,1.1050
+2026-01-24T09:30:15Z,EUR_USD,1.1020,1.1022
+`
+		require.NoError(t, os.WriteFile(csvPath, []byte(csv), 0o644))
+
+		feed, err := NewCSVTicksFeed(csvPath, 0, 0)
+		require.NoError(t, err)
+		defer feed.Close()
+		feed.Sanitizer = &market.TickSanitizer{MaxSpreadPips: types.PipsFromFloat(10)}
+
+		var ticks []market.Tick
+		for {
+			p, ok, err := feed.Next()
+			require.NoError(t, err)
+			if !ok {
+				break
+			}
+			ticks = append(ticks, p)
+		}
+
+		assert.Len(t, ticks, 2)
+		stats := feed.Sanitizer.Stats()
+		assert.Equal(t, 4, stats.Seen)
+		assert.Equal(t, 1, stats.Crossed)
+		assert.Equal(t, 1, stats.WideSpread)
+	})
+
+	t.Run("sanitizer fail policy aborts", func(t *testing.T) {
+		t.Parallel()
+
+		tmp := t.TempDir()
+		csvPath := filepath.Join(tmp, "test.csv")
+
+		csv := `2026-01-24T09:30:00Z,EUR_USD,1.1000,1.1002
+2026-01-24T09:30:05Z,EUR_USD,0,1.1010
+`
+		require.NoError(t, os.WriteFile(csvPath, []byte(csv), 0o644))
+
+		feed, err := NewCSVTicksFeed(csvPath, 0, 0)
+		require.NoError(t, err)
+		defer feed.Close()
+		feed.Sanitizer = &market.TickSanitizer{Policy: market.TickPolicyFail}
+
+		_, ok, err := feed.Next()
+		require.NoError(t, err)
+		require.True(t, ok)
+		_, ok, err = feed.Next()
+		require.Error(t, err)
+		assert.False(t, ok)
+		assert.Contains(t, err.Error(), "non-positive")
+	})
+
 	t.Run("empty file", func(t *testing.T) {
 		t.Parallel()
 
@@ -413,3 +473,43 @@ func TestCSVTicksFeed_Next(t *testing.T) {
 		assert.False(t, ok, "expected ok=false for empty file, got tick: %+v", p)
 	})
 }
+
+func TestCSVTicksFeed_Metadata(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	csvPath := filepath.Join(tmp, "ticks.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte(
+		"# schema=ticks-v1 source=oanda instruments=EURUSD price=bid-ask\n"+
+			"time,instrument,bid,ask\n"+
+			"2026-01-24T09:30:00Z,EUR_USD,1.1000,1.1002\n"+
+			"2026-01-24T09:30:01Z,USD_JPY,150.00,150.02\n"), 0o644))
+
+	feed, err := NewCSVTicksFeed(csvPath, 0, 0)
+	require.NoError(t, err)
+	defer feed.Close()
+
+	meta, ok := feed.Meta()
+	require.True(t, ok)
+	assert.Equal(t, "oanda", meta.Source)
+	assert.Equal(t, []string{"EURUSD"}, meta.Instruments)
+
+	tick, ok, err := feed.Next()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "EUR_USD", tick.Instrument)
+
+	_, _, err = feed.Next()
+	assert.ErrorContains(t, err, "tick for USD_JPY")
+
+	plain := filepath.Join(tmp, "plain.csv")
+	require.NoError(t, os.WriteFile(plain, []byte("2026-01-24T09:30:00Z,EUR_USD,1.1000,1.1002\n"), 0o644))
+	feed2, err := NewCSVTicksFeed(plain, 0, 0)
+	require.NoError(t, err)
+	defer feed2.Close()
+	_, ok = feed2.Meta()
+	assert.False(t, ok)
+	_, ok, err = feed2.Next()
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
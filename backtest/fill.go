@@ -0,0 +1,192 @@
+package backtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Fill modes for ExecutionConfig.Fill.
+const (
+	FillSameTick = "same-tick"
+	FillNextTick = "next-tick"
+)
+
+// FillModel says when a run's strategy orders reach the broker. The zero
+// value submits them on the tick the strategy decided on, so they fill at
+// the very price the decision was made from — a mild lookahead, since no
+// real order can be placed before the price it reacts to is known.
+// NextTick instead holds them for the first later tick, and Latency holds
+// them until at least that much simulated time has passed as well.
+type FillModel struct {
+	NextTick bool
+	Latency  time.Duration
+}
+
+// IsZero reports whether m is the same-tick model.
+func (m FillModel) IsZero() bool {
+	return m == FillModel{}
+}
+
+// String is the model's config name, with its latency when it has one.
+func (m FillModel) String() string {
+	if !m.NextTick {
+		return FillSameTick
+	}
+	if m.Latency > 0 {
+		return fmt.Sprintf("%s+%s", FillNextTick, m.Latency)
+	}
+	return FillNextTick
+}
+
+// compileFill validates the fill settings of cfg and converts them to a
+// FillModel. Latency only applies to next-tick fills.
+func compileFill(cfg ExecutionConfig) (FillModel, error) {
+	if cfg.LatencyMs < 0 {
+		return FillModel{}, fmt.Errorf("latency-ms must be >= 0, got %d", cfg.LatencyMs)
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Fill)) {
+	case "", FillSameTick:
+		if cfg.LatencyMs != 0 {
+			return FillModel{}, fmt.Errorf("latency-ms needs fill: %s", FillNextTick)
+		}
+		return FillModel{}, nil
+	case FillNextTick:
+		return FillModel{NextTick: true, Latency: time.Duration(cfg.LatencyMs) * time.Millisecond}, nil
+	default:
+		return FillModel{}, fmt.Errorf("unknown fill %q (use %s or %s)", cfg.Fill, FillSameTick, FillNextTick)
+	}
+}
+
+// orderQueue holds a next-tick run's orders until the tick they fill on.
+// A nil orderQueue is the same-tick model: nothing is held.
+type orderQueue struct {
+	latency time.Duration
+	pending []queuedOrders
+}
+
+// queuedOrders are the closes and opens decided on the tick at signal.
+type queuedOrders struct {
+	signal types.Timestamp
+	due    time.Time
+	closes []*account.CloseRequest
+	opens  []*account.OpenRequest
+}
+
+func newOrderQueue(m FillModel) *orderQueue {
+	if !m.NextTick {
+		return nil
+	}
+	return &orderQueue{latency: m.Latency}
+}
+
+// hold queues the closes and opens decided on the tick at ts. The lots
+// being closed are marked close-requested at once, so the planner does
+// not close them again while the order waits.
+func (q *orderQueue) hold(ts types.Timestamp, closes []*account.CloseRequest, opens []*account.OpenRequest) {
+	if len(closes) == 0 && len(opens) == 0 {
+		return
+	}
+	for _, cl := range closes {
+		if cl.Lot != nil {
+			cl.Lot.State = account.LotCloseRequested
+		}
+	}
+	q.pending = append(q.pending, queuedOrders{
+		signal: ts,
+		due:    ts.Time().Add(q.latency),
+		closes: closes,
+		opens:  opens,
+	})
+}
+
+// due removes and returns the orders that fill on the tick at ts: those
+// decided on an earlier tick whose latency has passed, oldest first.
+func (q *orderQueue) due(ts types.Timestamp) (closes []*account.CloseRequest, opens []*account.OpenRequest) {
+	if q == nil {
+		return nil, nil
+	}
+	kept := q.pending[:0]
+	for _, p := range q.pending {
+		if ts <= p.signal || ts.Time().Before(p.due) {
+			kept = append(kept, p)
+			continue
+		}
+		closes = append(closes, p.closes...)
+		opens = append(opens, p.opens...)
+	}
+	q.pending = kept
+	return closes, opens
+}
+
+// drop discards the orders still queued when the run ends, which no later
+// tick will fill, and returns how many there were. Lots whose close was
+// dropped are open again, so the run's final close-out picks them up.
+func (q *orderQueue) drop() int {
+	if q == nil {
+		return 0
+	}
+	n := 0
+	for _, p := range q.pending {
+		for _, cl := range p.closes {
+			if cl.Lot != nil && cl.Lot.State == account.LotCloseRequested {
+				cl.Lot.State = account.LotOpen
+			}
+		}
+		n += len(p.closes) + len(p.opens)
+	}
+	q.pending = nil
+	return n
+}
+
+// SameTickRun builds the same-tick counterpart of a run that fills on a
+// later tick: a fresh Backtest with newly constructed strategy, exit, and
+// regime components whose orders fill on the tick they were decided on.
+// Its outcome against the base run's is the FillComparison. It returns nil
+// when c already fills on the same tick.
+func (c CompiledBacktest) SameTickRun() (*Backtest, error) {
+	if c.Request.Fill.IsZero() {
+		return nil, nil
+	}
+	fresh, err := compileBacktestComponents(c.RunConfig)
+	if err != nil {
+		return nil, err
+	}
+	run := c.NewRun()
+	run.Request.Strategy = fresh.Strategy
+	run.Request.Exit = fresh.Exit
+	run.Request.Regime = fresh.Regime
+	run.Request.Fill = FillModel{}
+	return &run, nil
+}
+
+// FillComparison sets a run's outcome beside that of its same-tick
+// counterpart. The difference is what filling on the deciding tick's own
+// price was worth: how much of the same-tick result was lookahead.
+type FillComparison struct {
+	Model FillModel
+
+	NetPL, SameTickNetPL         types.Money
+	ReturnPct, SameTickReturnPct types.Rate
+	Trades, SameTickTrades       int
+}
+
+// CompareFills compares the result of a run filled under model with its
+// same-tick counterpart's. It returns nil when either result is missing.
+func CompareFills(model FillModel, run, sameTick *BacktestResult) *FillComparison {
+	if run == nil || sameTick == nil {
+		return nil
+	}
+	return &FillComparison{
+		Model:             model,
+		NetPL:             run.NetPL,
+		SameTickNetPL:     sameTick.NetPL,
+		ReturnPct:         run.ReturnPct,
+		SameTickReturnPct: sameTick.ReturnPct,
+		Trades:            run.Trades,
+		SameTickTrades:    sameTick.Trades,
+	}
+}
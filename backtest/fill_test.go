@@ -0,0 +1,151 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileFill(t *testing.T) {
+	got, err := compileFill(ExecutionConfig{})
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+	assert.Equal(t, "same-tick", got.String())
+
+	got, err = compileFill(ExecutionConfig{Fill: "Same-Tick"})
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+
+	got, err = compileFill(ExecutionConfig{Fill: "next-tick", LatencyMs: 250})
+	require.NoError(t, err)
+	assert.Equal(t, FillModel{NextTick: true, Latency: 250 * time.Millisecond}, got)
+	assert.Equal(t, "next-tick+250ms", got.String())
+
+	_, err = compileFill(ExecutionConfig{LatencyMs: 250})
+	require.ErrorContains(t, err, "latency-ms needs fill: next-tick")
+	_, err = compileFill(ExecutionConfig{Fill: "next-tick", LatencyMs: -1})
+	require.ErrorContains(t, err, "latency-ms must be >= 0")
+	_, err = compileFill(ExecutionConfig{Fill: "next-bar"})
+	require.ErrorContains(t, err, `unknown fill "next-bar"`)
+}
+
+func TestOrderQueue_HoldsUntilLaterTickAndLatency(t *testing.T) {
+	assert.Nil(t, newOrderQueue(FillModel{}))
+
+	t0 := types.FromTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	lot := &account.Lot{TradeCommon: &account.TradeCommon{ID: "1"}, State: account.LotOpen}
+	closeReq := &account.CloseRequest{Lot: lot}
+	openReq := &account.OpenRequest{}
+
+	q := newOrderQueue(FillModel{NextTick: true, Latency: 90 * time.Minute})
+	q.hold(t0, []*account.CloseRequest{closeReq}, []*account.OpenRequest{openReq})
+	assert.Equal(t, account.LotCloseRequested, lot.State)
+
+	closes, opens := q.due(t0)
+	assert.Empty(t, closes, "never on the deciding tick")
+	assert.Empty(t, opens)
+	closes, opens = q.due(t0 + 3600)
+	assert.Empty(t, closes, "an hour is inside the latency")
+	assert.Empty(t, opens)
+	closes, opens = q.due(t0 + 7200)
+	assert.Equal(t, []*account.CloseRequest{closeReq}, closes)
+	assert.Equal(t, []*account.OpenRequest{openReq}, opens)
+	assert.Zero(t, q.drop())
+
+	q.hold(t0, []*account.CloseRequest{closeReq}, nil)
+	assert.Equal(t, 1, q.drop())
+	assert.Equal(t, account.LotOpen, lot.State, "a dropped close leaves the lot open")
+}
+
+func TestRunWithIterator_NextTickFillsLater(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 6; i++ {
+		c := types.Price(1100000 + 100*i)
+		candles = append(candles, market.Candle{
+			Open: c, High: c + 500, Low: c - 500, Close: c,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	entry := func(fill FillModel) *account.Trade {
+		acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+		acct.RiskFraction = types.RateFromFloat(0.01)
+		tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+		run := &Backtest{
+			Request: &BacktestRequest{
+				Instrument:      "EURUSD",
+				Strategy:        alwaysLong{},
+				StartingBalance: types.MoneyFromFloat(10_000),
+				TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[5].Timestamp, TF: types.H1},
+				Governor:        GovernorRules{MaxTradesPerDay: 1},
+				Fill:            fill,
+			},
+			State: &BacktestRun{},
+		}
+		require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+		require.Len(t, acct.Trades, 1)
+		return acct.Trades[0]
+	}
+
+	same := entry(FillModel{})
+	assert.Equal(t, candles[0].Close, same.EntryPrice)
+	assert.Equal(t, candles[0].Timestamp, same.EntryTime)
+
+	next := entry(FillModel{NextTick: true})
+	assert.Equal(t, candles[1].Close, next.EntryPrice)
+	assert.Equal(t, candles[1].Timestamp, next.EntryTime)
+
+	late := entry(FillModel{NextTick: true, Latency: 90 * time.Minute})
+	assert.Equal(t, candles[2].Close, late.EntryPrice)
+}
+
+func TestCompiledBacktest_SameTickRunAndCompareFills(t *testing.T) {
+	cfg := &Config{
+		Defaults: RunDefaults{
+			StartingBalance: 1000,
+			Execution:       ExecutionConfig{Fill: "next-tick", LatencyMs: 500},
+		},
+		Runs: []RunConfig{{
+			Name:     "fills",
+			Data:     DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2026-01-01", To: "2026-01-10"},
+			Strategy: strategy.StrategyConfig{Kind: "fake"},
+		}},
+	}
+	compiled, err := CompileBacktests(cfg)
+	require.NoError(t, err)
+	require.Len(t, compiled, 1)
+	assert.Equal(t, FillModel{NextTick: true, Latency: 500 * time.Millisecond}, compiled[0].Request.Fill)
+
+	run, err := compiled[0].SameTickRun()
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	assert.True(t, run.Request.Fill.IsZero())
+	assert.NotNil(t, run.Request.Strategy)
+	assert.False(t, compiled[0].Request.Fill.IsZero(), "the base request is left alone")
+
+	cmp := CompareFills(compiled[0].Request.Fill,
+		&BacktestResult{NetPL: types.MoneyFromFloat(80), Trades: 4},
+		&BacktestResult{NetPL: types.MoneyFromFloat(100), Trades: 4})
+	rep := cmp.Report()
+	assert.Equal(t, "next-tick", rep.Fill)
+	assert.Equal(t, int64(500), rep.LatencyMs)
+	assert.InDelta(t, -20, rep.NetPLDiff, 1e-9)
+	assert.Nil(t, CompareFills(compiled[0].Request.Fill, nil, &BacktestResult{}))
+
+	cfg.Defaults.Execution = ExecutionConfig{}
+	compiled, err = CompileBacktests(cfg)
+	require.NoError(t, err)
+	run, err = compiled[0].SameTickRun()
+	require.NoError(t, err)
+	assert.Nil(t, run)
+}
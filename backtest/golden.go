@@ -0,0 +1,172 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+// GoldenDiff is one field that differs between a golden report and a run.
+// Golden and Got hold the JSON values; an empty one means the field is
+// absent on that side (e.g. a trade past the end of the shorter list).
+type GoldenDiff struct {
+	Path   string          `json:"path"` // e.g. "net_pl" or "trade_details[3].close_price"
+	Golden json.RawMessage `json:"golden,omitempty"`
+	Got    json.RawMessage `json:"got,omitempty"`
+}
+
+func (d GoldenDiff) String() string {
+	show := func(v json.RawMessage) string {
+		if len(v) == 0 {
+			return "<missing>"
+		}
+		return string(v)
+	}
+	return fmt.Sprintf("%s: golden=%s got=%s", d.Path, show(d.Golden), show(d.Got))
+}
+
+// GoldenReport returns s stripped of what legitimately changes from one
+// run to the next: the generation time, the config snapshot and its hash,
+// and trade IDs, which embed the run's ULID. What remains — metrics and
+// the trade list — is what a golden file pins.
+func GoldenReport(s BacktestReportSummary) BacktestReportSummary {
+	s.GeneratedAt = ""
+	s.ConfigHash = ""
+	s.Config = RunConfig{}
+	if s.TradeDetails != nil {
+		trades := make([]BacktestReportTrade, len(s.TradeDetails))
+		copy(trades, s.TradeDetails)
+		for i := range trades {
+			trades[i].ID = ""
+		}
+		s.TradeDetails = trades
+	}
+	return s
+}
+
+// ReadGoldenReport loads a golden report written by WriteGoldenReport (or
+// any backtest JSON report).
+func ReadGoldenReport(path string) (BacktestReportSummary, error) {
+	var s BacktestReportSummary
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("parse golden report %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// WriteGoldenReport writes GoldenReport(s) to path as indented JSON,
+// creating parent directories as needed.
+func WriteGoldenReport(path string, s BacktestReportSummary) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(GoldenReport(s), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// CompareGolden diffs got against golden field by field after both pass
+// through GoldenReport, and returns the differences in path order (trades
+// in list order). Comparisons are exact: every value derives from
+// fixed-point arithmetic, so any change is a behavior change.
+func CompareGolden(golden, got BacktestReportSummary) ([]GoldenDiff, error) {
+	g, err := goldenTree(golden)
+	if err != nil {
+		return nil, err
+	}
+	o, err := goldenTree(got)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []GoldenDiff
+	diffGolden("", g, o, &diffs)
+	return diffs, nil
+}
+
+// goldenTree round-trips s through JSON into generic values, keeping
+// numbers as their exact JSON text.
+func goldenTree(s BacktestReportSummary) (any, error) {
+	b, err := json.Marshal(GoldenReport(s))
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func diffGolden(path string, golden, got any, out *[]GoldenDiff) {
+	switch g := golden.(type) {
+	case map[string]any:
+		o, ok := got.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(g)+len(o))
+		for k := range g {
+			keys = append(keys, k)
+		}
+		for k := range o {
+			if _, dup := g[k]; !dup {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			gv, gok := g[k]
+			ov, ook := o[k]
+			switch {
+			case !gok:
+				*out = append(*out, GoldenDiff{Path: child, Got: rawGolden(ov)})
+			case !ook:
+				*out = append(*out, GoldenDiff{Path: child, Golden: rawGolden(gv)})
+			default:
+				diffGolden(child, gv, ov, out)
+			}
+		}
+		return
+	case []any:
+		o, ok := got.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(g), len(o)); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(g):
+				*out = append(*out, GoldenDiff{Path: child, Got: rawGolden(o[i])})
+			case i >= len(o):
+				*out = append(*out, GoldenDiff{Path: child, Golden: rawGolden(g[i])})
+			default:
+				diffGolden(child, g[i], o[i], out)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(golden, got) {
+		*out = append(*out, GoldenDiff{Path: path, Golden: rawGolden(golden), Got: rawGolden(got)})
+	}
+}
+
+func rawGolden(v any) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
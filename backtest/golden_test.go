@@ -0,0 +1,153 @@
+package backtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateGoldenEnv is the environment variable that makes assertGolden
+// rewrite golden files instead of comparing against them.
+const updateGoldenEnv = "TRADER_UPDATE_GOLDEN"
+
+// assertGolden compares got with the golden report at path and fails tb
+// with one line per difference. Run with TRADER_UPDATE_GOLDEN=1 to write
+// got as the new golden file instead, after a deliberate behavior change.
+func assertGolden(tb testing.TB, path string, got BacktestReportSummary) {
+	tb.Helper()
+
+	if os.Getenv(updateGoldenEnv) != "" {
+		if err := WriteGoldenReport(path, got); err != nil {
+			tb.Fatalf("update golden %s: %v", path, err)
+		}
+		return
+	}
+
+	golden, err := ReadGoldenReport(path)
+	if err != nil {
+		tb.Fatalf("read golden %s (set %s=1 to create it): %v", path, updateGoldenEnv, err)
+	}
+	diffs, err := CompareGolden(golden, got)
+	if err != nil {
+		tb.Fatalf("compare golden %s: %v", path, err)
+	}
+	for _, d := range diffs {
+		tb.Errorf("golden %s: %s", path, d)
+	}
+}
+
+func TestCompareGolden_ReportsStructuredDiffs(t *testing.T) {
+	golden := BacktestReportSummary{
+		Name:   "g",
+		Trades: 2,
+		NetPL:  12.5,
+		TradeDetails: []BacktestReportTrade{
+			{ID: "run-a-1", Side: "long", ClosePrice: 1.1},
+			{ID: "run-a-2", Side: "short", ClosePrice: 1.2},
+		},
+		GeneratedAt: "2024-01-01T00:00:00Z",
+		ConfigHash:  "aaaaaaaa",
+	}
+
+	// Run-specific fields never count as differences.
+	same := golden
+	same.GeneratedAt, same.ConfigHash = "2025-06-01T00:00:00Z", "bbbbbbbb"
+	same.TradeDetails = []BacktestReportTrade{
+		{ID: "run-b-1", Side: "long", ClosePrice: 1.1},
+		{ID: "run-b-2", Side: "short", ClosePrice: 1.2},
+	}
+	diffs, err := CompareGolden(golden, same)
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	got := same
+	got.NetPL = 10
+	got.StopReason = "max-drawdown"
+	got.TradeDetails = []BacktestReportTrade{{Side: "long", ClosePrice: 1.15}}
+	diffs, err = CompareGolden(golden, got)
+	require.NoError(t, err)
+
+	var lines []string
+	for _, d := range diffs {
+		lines = append(lines, d.String())
+	}
+	assert.Equal(t, []string{
+		"net_pl: golden=12.5 got=10",
+		`stop_reason: golden=<missing> got="max-drawdown"`,
+		"trade_details[0].close_price: golden=1.1 got=1.15",
+	}, lines[:3])
+	assert.Len(t, lines, 4)
+	assert.Equal(t, "trade_details[1]", diffs[3].Path)
+	assert.Empty(t, diffs[3].Got)
+}
+
+func TestWriteGoldenReport_RoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "run.json")
+	s := BacktestReportSummary{
+		Name:         "r",
+		Trades:       1,
+		TradeDetails: []BacktestReportTrade{{ID: "run-1", Units: 1000}},
+		GeneratedAt:  "2024-01-01T00:00:00Z",
+	}
+	require.NoError(t, WriteGoldenReport(path, s))
+
+	back, err := ReadGoldenReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, GoldenReport(s), back)
+	assert.Empty(t, back.TradeDetails[0].ID)
+	assert.Equal(t, "run-1", s.TradeDetails[0].ID, "GoldenReport must not modify its argument")
+}
+
+// TestGolden_AlwaysLongHourly pins the trade list and metrics of a small
+// deterministic run. After an intended behavior change, regenerate with
+// TRADER_UPDATE_GOLDEN=1 go test ./backtest -run TestGolden.
+func TestGolden_AlwaysLongHourly(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+
+	// A slow zig-zag: up 30 pips an hour for six hours, then down.
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	px := types.Price(110000)
+	for i := 0; i < 48; i++ {
+		step := types.Price(300)
+		if (i/6)%2 == 1 {
+			step = -step
+		}
+		open, close := px, px+step
+		candles = append(candles, market.Candle{
+			Open: open, High: max(open, close) + 50, Low: min(open, close) - 50, Close: close,
+			AvgSpread: 10, MaxSpread: 20,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+		px = close
+	}
+
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Name:            "always-long-hourly",
+			Instrument:      "EURUSD",
+			Strategy:        alwaysLong{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			RiskPct:         types.RateFromFloat(0.01),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[47].Timestamp, TF: types.H1},
+			Governor:        GovernorRules{MaxTradesPerDay: 3},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+	run.BuildBacktestResult(acct)
+
+	assertGolden(t, filepath.Join("testdata", "golden", "always-long-hourly.json"), run.Summary())
+}
@@ -0,0 +1,243 @@
+package backtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+// GovernorConfig limits how often a run may enter, and how much it may
+// hold open, whatever the strategy. Zero values disable the corresponding
+// rule. Entries a rule refuses are counted in the report and journaled as
+// skipped signals; closes are never affected.
+type GovernorConfig struct {
+	MaxTradesPerDay  int    `json:"max-trades-per-day,omitempty" yaml:"max-trades-per-day"` // entries per UTC day
+	MinBarsBetween   int    `json:"min-bars-between,omitempty"   yaml:"min-bars-between"`   // bars from one entry to the next
+	MinTimeBetween   string `json:"min-time-between,omitempty"   yaml:"min-time-between"`   // e.g. "4h"
+	LossCooldownBars int    `json:"loss-cooldown-bars,omitempty" yaml:"loss-cooldown-bars"` // bars after a losing close
+	LossCooldown     string `json:"loss-cooldown,omitempty"      yaml:"loss-cooldown"`      // e.g. "24h"
+
+	MaxOpenTrades  int     `json:"max-open-trades,omitempty"  yaml:"max-open-trades"`  // open trades, counting the new entry
+	MaxExposurePct float64 `json:"max-exposure-pct,omitempty" yaml:"max-exposure-pct"` // open notional as % of equity, e.g. 500 = 5:1
+}
+
+// IsZero reports whether no governor rule is configured.
+func (c GovernorConfig) IsZero() bool {
+	return c == GovernorConfig{}
+}
+
+// Governor rule names, as recorded on skipped signals.
+const (
+	RuleMaxTradesPerDay  = "max-trades-per-day"
+	RuleMinBarsBetween   = "min-bars-between"
+	RuleMinTimeBetween   = "min-time-between"
+	RuleLossCooldownBars = "loss-cooldown-bars"
+	RuleLossCooldown     = "loss-cooldown"
+	RuleMaxOpenTrades    = "max-open-trades"
+	RuleMaxExposure      = "max-exposure-pct"
+)
+
+// GovernorRules is the compiled form of GovernorConfig. Durations are in
+// seconds, like types.Timestamp.
+type GovernorRules struct {
+	MaxTradesPerDay  int
+	MinBarsBetween   int
+	MinTimeBetween   int64
+	LossCooldownBars int
+	LossCooldown     int64
+	MaxOpenTrades    int
+	MaxExposure      types.Rate // open notional over equity, RateScale-scaled
+}
+
+// HasSafetyLimits reports whether both the open-trade and the exposure
+// limit are set, as strategy.SafetyLimited strategies require.
+func (r GovernorRules) HasSafetyLimits() bool {
+	return r.MaxOpenTrades > 0 && r.MaxExposure > 0
+}
+
+// IsZero reports whether no rule is set.
+func (r GovernorRules) IsZero() bool {
+	return r == GovernorRules{}
+}
+
+// compileGovernor validates cfg and converts it to GovernorRules.
+func compileGovernor(cfg GovernorConfig) (GovernorRules, error) {
+	for _, c := range []struct {
+		name string
+		v    int
+	}{
+		{RuleMaxTradesPerDay, cfg.MaxTradesPerDay},
+		{RuleMinBarsBetween, cfg.MinBarsBetween},
+		{RuleLossCooldownBars, cfg.LossCooldownBars},
+		{RuleMaxOpenTrades, cfg.MaxOpenTrades},
+	} {
+		if c.v < 0 {
+			return GovernorRules{}, fmt.Errorf("%s must be >= 0, got %d", c.name, c.v)
+		}
+	}
+	minTime, err := governorSeconds(RuleMinTimeBetween, cfg.MinTimeBetween)
+	if err != nil {
+		return GovernorRules{}, err
+	}
+	cooldown, err := governorSeconds(RuleLossCooldown, cfg.LossCooldown)
+	if err != nil {
+		return GovernorRules{}, err
+	}
+	if cfg.MaxExposurePct < 0 {
+		return GovernorRules{}, fmt.Errorf("%s must be >= 0, got %v", RuleMaxExposure, cfg.MaxExposurePct)
+	}
+	return GovernorRules{
+		MaxTradesPerDay:  cfg.MaxTradesPerDay,
+		MinBarsBetween:   cfg.MinBarsBetween,
+		MinTimeBetween:   minTime,
+		LossCooldownBars: cfg.LossCooldownBars,
+		LossCooldown:     cooldown,
+		MaxOpenTrades:    cfg.MaxOpenTrades,
+		MaxExposure:      types.RateFromFloat(cfg.MaxExposurePct / 100.0),
+	}, nil
+}
+
+func governorSeconds(name, s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad %s %q (use a duration such as 4h or 90m)", name, s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must be >= 0, got %s", name, s)
+	}
+	return int64(d / time.Second), nil
+}
+
+// governor enforces GovernorRules bar by bar. It must see every bar
+// (observe) so it can notice losing closes, including stop-outs.
+type governor struct {
+	rules GovernorRules
+
+	day      int64 // UTC day of the entries counted in dayCount
+	dayCount int
+
+	entered   bool
+	entryBar  int64
+	entryTime types.Timestamp
+
+	lost     bool
+	lossBar  int64
+	lossTime types.Timestamp
+
+	seen int // closed trades already checked for losses
+}
+
+// newGovernor returns nil when no rule is set.
+func newGovernor(rules GovernorRules) *governor {
+	if rules.IsZero() {
+		return nil
+	}
+	return &governor{rules: rules}
+}
+
+// observe notes any losing trades closed since the last call. trades is
+// the account's full closed-trade list.
+func (g *governor) observe(bar int64, ts types.Timestamp, trades []*account.Trade) {
+	for _, tr := range trades[min(g.seen, len(trades)):] {
+		if tr != nil && tr.PNL < 0 {
+			g.lost, g.lossBar, g.lossTime = true, bar, ts
+		}
+	}
+	g.seen = len(trades)
+}
+
+// check returns the rule that refuses an entry on this bar, or "".
+func (g *governor) check(bar int64, ts types.Timestamp) string {
+	r := g.rules
+	if r.MaxTradesPerDay > 0 && g.dayCount >= r.MaxTradesPerDay && int64(ts)/86400 == g.day {
+		return RuleMaxTradesPerDay
+	}
+	if g.entered {
+		if r.MinBarsBetween > 0 && bar-g.entryBar < int64(r.MinBarsBetween) {
+			return RuleMinBarsBetween
+		}
+		if r.MinTimeBetween > 0 && int64(ts-g.entryTime) < r.MinTimeBetween {
+			return RuleMinTimeBetween
+		}
+	}
+	if g.lost {
+		if r.LossCooldownBars > 0 && bar-g.lossBar <= int64(r.LossCooldownBars) {
+			return RuleLossCooldownBars
+		}
+		if r.LossCooldown > 0 && int64(ts-g.lossTime) < r.LossCooldown {
+			return RuleLossCooldown
+		}
+	}
+	return ""
+}
+
+// admitted records an entry on this bar.
+func (g *governor) admitted(bar int64, ts types.Timestamp) {
+	if day := int64(ts) / 86400; day != g.day {
+		g.day, g.dayCount = day, 0
+	}
+	g.dayCount++
+	g.entered, g.entryBar, g.entryTime = true, bar, ts
+}
+
+// checkLimits returns the rule that refuses plan's entries given what acct
+// already holds, or "". Lots the plan closes no longer count. Open lots are
+// valued at mark, the bar's close on instrument.
+func (g *governor) checkLimits(acct *account.Account, plan *strategy.StrategyPlan, instrument string, mark types.Price) (string, error) {
+	r := g.rules
+	if r.MaxOpenTrades == 0 && r.MaxExposure == 0 {
+		return "", nil
+	}
+	open := 0
+	_ = acct.Lots.Range(func(lot *account.Lot) error {
+		if lot.State == account.LotOpen {
+			open++
+		}
+		return nil
+	})
+	marks := map[string]types.Price{instrument: mark}
+	exposure, err := account.OpenNotional(&acct.Lots, acct.Currency, marks)
+	if err != nil {
+		return "", err
+	}
+	for _, cl := range plan.Closes {
+		if cl == nil || cl.Lot == nil || cl.Lot.State != account.LotOpen {
+			continue
+		}
+		open--
+		n, err := account.Notional(acct.Currency, cl.Lot.Instrument, cl.Lot.RemainingUnits, mark)
+		if err != nil {
+			return "", err
+		}
+		exposure -= n
+	}
+	if r.MaxOpenTrades > 0 && open+len(plan.Opens) > r.MaxOpenTrades {
+		return RuleMaxOpenTrades, nil
+	}
+	if r.MaxExposure == 0 {
+		return "", nil
+	}
+	for _, o := range plan.Opens {
+		n, err := account.Notional(acct.Currency, o.Instrument, o.Units, o.Price)
+		if err != nil {
+			return "", err
+		}
+		exposure += n
+	}
+	limit, err := types.MulDivFloor64(int64(max(acct.Equity, 0)), int64(r.MaxExposure), int64(types.RateScale))
+	if err != nil {
+		return "", err
+	}
+	if exposure > types.Money(limit) {
+		return RuleMaxExposure, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,307 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alwaysLong asks to go long on every bar.
+type alwaysLong struct{}
+
+func (alwaysLong) Name() string            { return "always-long" }
+func (alwaysLong) Reset()                  {}
+func (alwaysLong) Ready() bool             { return true }
+func (alwaysLong) StopDescription() string { return "" }
+func (alwaysLong) Update(_ context.Context, c *market.Candle, _ strategy.StrategyContext) strategy.Signal {
+	return strategy.Signal{Side: types.Long, Stop: c.Close - 5000, Reason: "always"}
+}
+
+// signalJournal captures skipped signals.
+type signalJournal struct {
+	skipped []journal.SkippedSignal
+}
+
+func (j *signalJournal) RecordTrade(journal.TradeRecord) error     { return nil }
+func (j *signalJournal) RecordEquity(journal.EquitySnapshot) error { return nil }
+func (j *signalJournal) Close() error                              { return nil }
+func (j *signalJournal) RecordSkippedSignal(s journal.SkippedSignal) error {
+	j.skipped = append(j.skipped, s)
+	return nil
+}
+
+func TestCompileGovernor(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     GovernorConfig
+		want    GovernorRules
+		wantErr string
+	}{
+		{name: "disabled"},
+		{
+			name: "all rules",
+			cfg:  GovernorConfig{MaxTradesPerDay: 3, MinBarsBetween: 2, MinTimeBetween: "4h", LossCooldownBars: 5, LossCooldown: "90m"},
+			want: GovernorRules{MaxTradesPerDay: 3, MinBarsBetween: 2, MinTimeBetween: 4 * 3600, LossCooldownBars: 5, LossCooldown: 90 * 60},
+		},
+		{name: "negative cap", cfg: GovernorConfig{MaxTradesPerDay: -1}, wantErr: "max-trades-per-day must be >= 0"},
+		{name: "negative bars", cfg: GovernorConfig{LossCooldownBars: -2}, wantErr: "loss-cooldown-bars must be >= 0"},
+		{name: "bad duration", cfg: GovernorConfig{MinTimeBetween: "soon"}, wantErr: "bad min-time-between"},
+		{name: "negative duration", cfg: GovernorConfig{LossCooldown: "-1h"}, wantErr: "loss-cooldown must be >= 0"},
+		{
+			name: "position limits",
+			cfg:  GovernorConfig{MaxOpenTrades: 5, MaxExposurePct: 500},
+			want: GovernorRules{MaxOpenTrades: 5, MaxExposure: types.RateFromFloat(5)},
+		},
+		{name: "negative open trades", cfg: GovernorConfig{MaxOpenTrades: -1}, wantErr: "max-open-trades must be >= 0"},
+		{name: "negative exposure", cfg: GovernorConfig{MaxExposurePct: -1}, wantErr: "max-exposure-pct must be >= 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compileGovernor(tt.cfg)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewGovernor_NilWhenUnset(t *testing.T) {
+	assert.Nil(t, newGovernor(GovernorRules{}))
+}
+
+func TestGovernor_Rules(t *testing.T) {
+	const hour = types.Timestamp(3600)
+	day := types.FromTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	t.Run("max trades per day resets at midnight UTC", func(t *testing.T) {
+		g := newGovernor(GovernorRules{MaxTradesPerDay: 2})
+		g.admitted(1, day+9*hour)
+		g.admitted(2, day+10*hour)
+		assert.Equal(t, RuleMaxTradesPerDay, g.check(3, day+11*hour))
+		assert.Empty(t, g.check(4, day+24*hour))
+	})
+
+	t.Run("min bars and time between entries", func(t *testing.T) {
+		g := newGovernor(GovernorRules{MinBarsBetween: 3, MinTimeBetween: int64(5 * hour)})
+		assert.Empty(t, g.check(1, day))
+		g.admitted(1, day)
+		assert.Equal(t, RuleMinBarsBetween, g.check(3, day+2*hour))
+		assert.Equal(t, RuleMinTimeBetween, g.check(4, day+3*hour))
+		assert.Empty(t, g.check(6, day+5*hour))
+	})
+
+	t.Run("cooldown follows losses only", func(t *testing.T) {
+		g := newGovernor(GovernorRules{LossCooldownBars: 2, LossCooldown: int64(4 * hour)})
+		trades := []*account.Trade{{PNL: types.MoneyFromFloat(10)}}
+		g.observe(5, day+5*hour, trades)
+		assert.Empty(t, g.check(5, day+5*hour), "a winner starts no cooldown")
+
+		trades = append(trades, &account.Trade{PNL: types.MoneyFromFloat(-10)})
+		g.observe(6, day+6*hour, trades)
+		assert.Equal(t, RuleLossCooldownBars, g.check(8, day+8*hour))
+		assert.Equal(t, RuleLossCooldown, g.check(9, day+9*hour))
+		assert.Empty(t, g.check(10, day+10*hour))
+
+		g.observe(10, day+10*hour, trades)
+		assert.Empty(t, g.check(10, day+10*hour), "trades already seen are not counted again")
+	})
+}
+
+func TestRunWithIterator_GovernorSkipsAndJournalsEntries(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	j := &signalJournal{}
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, j)}
+
+	// 30 hourly bars from midnight: two entries allowed on each UTC day.
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 30; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        alwaysLong{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[29].Timestamp, TF: types.H1},
+			Governor:        GovernorRules{MaxTradesPerDay: 2},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	require.Len(t, acct.Trades, 4)
+	require.Len(t, run.State.Skipped, 26)
+	assert.Equal(t, run.State.Skipped, j.skipped)
+	first := run.State.Skipped[0]
+	assert.Equal(t, candles[2].Timestamp, first.Time)
+	assert.Equal(t, "EURUSD", first.Instrument)
+	assert.Equal(t, "long", first.Side)
+	assert.Equal(t, RuleMaxTradesPerDay, first.Rule)
+	assert.Equal(t, "always", first.Reason)
+
+	res := run.BuildBacktestResult(acct)
+	assert.Equal(t, map[string]int{RuleMaxTradesPerDay: 26}, res.Skipped)
+	assert.Equal(t, map[string]int{journal.RejectGovernor: 26}, res.Rejected)
+}
+
+func TestGovernorRules_HasSafetyLimits(t *testing.T) {
+	assert.False(t, GovernorRules{MaxOpenTrades: 3}.HasSafetyLimits())
+	assert.False(t, GovernorRules{MaxExposure: types.RateFromFloat(5)}.HasSafetyLimits())
+	assert.True(t, GovernorRules{MaxOpenTrades: 3, MaxExposure: types.RateFromFloat(5)}.HasSafetyLimits())
+}
+
+// limitRun runs alwaysLong, which adds a lot on every bar, over ten flat
+// hourly bars under rules.
+func limitRun(t *testing.T, rules GovernorRules) (*Backtest, *account.Account) {
+	t.Helper()
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 10; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        alwaysLong{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[9].Timestamp, TF: types.H1},
+			Governor:        rules,
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+	return run, acct
+}
+
+func TestRunWithIterator_GovernorMaxOpenTrades(t *testing.T) {
+	run, acct := limitRun(t, GovernorRules{MaxOpenTrades: 3})
+
+	require.Len(t, acct.Trades, 3, "the open lots are closed at the end of the run")
+	require.Len(t, run.State.Skipped, 7)
+	assert.Equal(t, RuleMaxOpenTrades, run.State.Skipped[0].Rule)
+	res := run.BuildBacktestResult(acct)
+	assert.Equal(t, map[string]int{journal.RejectGovernor: 7}, res.Rejected)
+}
+
+func TestRunWithIterator_GovernorMaxExposure(t *testing.T) {
+	// Each entry risks $100 over a 0.05 stop: 2,000 units, $22,000 notional
+	// at 11.0. A 5:1 limit on $10,000 equity admits two.
+	run, acct := limitRun(t, GovernorRules{MaxExposure: types.RateFromFloat(5)})
+
+	require.Len(t, acct.Trades, 2)
+	require.Len(t, run.State.Skipped, 8)
+	assert.Equal(t, RuleMaxExposure, run.State.Skipped[0].Rule)
+}
+
+func TestGovernor_CheckLimitsDiscountsClosingLots(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	lot := &account.Lot{
+		TradeCommon:    &account.TradeCommon{ID: "l1", Instrument: "EURUSD", Side: types.Short},
+		EntryPrice:     types.PriceFromFloat(1.1),
+		OriginalUnits:  10_000,
+		RemainingUnits: 10_000,
+		State:          account.LotOpen,
+	}
+	require.NoError(t, acct.Lots.Add(lot))
+	c := market.Candle{Close: types.PriceFromFloat(1.1)}
+	open := account.NewOpenRequest("EURUSD", &c, types.Long, 0, 0, "")
+	open.Units = 10_000
+
+	g := newGovernor(GovernorRules{MaxOpenTrades: 1, MaxExposure: types.RateFromFloat(1.5)})
+	rule, err := g.checkLimits(acct, &strategy.StrategyPlan{Opens: []*account.OpenRequest{open}}, "EURUSD", c.Close)
+	require.NoError(t, err)
+	assert.Equal(t, RuleMaxOpenTrades, rule)
+
+	reversal := &strategy.StrategyPlan{
+		Closes: []*account.CloseRequest{{Lot: lot}},
+		Opens:  []*account.OpenRequest{open},
+	}
+	rule, err = g.checkLimits(acct, reversal, "EURUSD", c.Close)
+	require.NoError(t, err)
+	assert.Empty(t, rule, "the reversed short no longer counts")
+}
+
+func TestCompileBacktests_SafetyLimitedNeedsLimits(t *testing.T) {
+	cfg := &Config{
+		Defaults: RunDefaults{Governor: GovernorConfig{MaxOpenTrades: 5}},
+		Runs: []RunConfig{{
+			Name:     "grid",
+			Data:     DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2026-01-01", To: "2026-01-10"},
+			Strategy: strategy.StrategyConfig{Kind: "grid", Filters: []strategy.FilterConfig{{Kind: "session"}}},
+		}},
+	}
+	_, err := CompileBacktests(cfg)
+	require.ErrorContains(t, err, `strategy "grid" requires governor max-open-trades and max-exposure-pct`)
+
+	cfg.Defaults.Governor.MaxExposurePct = 500
+	compiled, err := CompileBacktests(cfg)
+	require.NoError(t, err)
+	assert.True(t, compiled[0].Request.Governor.HasSafetyLimits())
+}
+
+func TestRunWithIterator_CountsFilterSkips(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	j := &signalJournal{}
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, j)}
+
+	open, err := strategy.GetSignalFilter(strategy.FilterConfig{Kind: "session-open", Params: map[string]any{
+		"minutes": 120, "opens": "00:00", "market_open": false,
+	}}, types.Scale6(types.PriceScale))
+	require.NoError(t, err)
+	strat, err := strategy.NewFilteredStrategy(alwaysLong{}, open)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 4; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        strat,
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[3].Timestamp, TF: types.H1},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	assert.Len(t, acct.Trades, 2, "entries resume two hours after the open")
+	assert.Empty(t, run.State.Skipped, "filter blocks are not governor skips")
+	res := run.BuildBacktestResult(acct)
+	assert.Equal(t, map[string]int{"SessionOpen(120m:00:00)": 2}, res.Filtered)
+	require.Len(t, j.skipped, 2)
+	assert.Equal(t, "SessionOpen(120m:00:00)", j.skipped[0].Rule)
+	assert.Equal(t, "always", j.skipped[0].Reason)
+}
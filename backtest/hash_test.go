@@ -3,7 +3,9 @@ package backtest
 import (
 	"testing"
 
+	"github.com/rustyeddy/trader/account"
 	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -50,6 +52,13 @@ func TestHashBacktestConfig_NameIgnored(t *testing.T) {
 	assert.Equal(t, hashBacktestConfig(a, RunDefaults{}), hashBacktestConfig(b, RunDefaults{}), "name change must not affect hash")
 }
 
+func TestHashBacktestConfig_DownloadIgnored(t *testing.T) {
+	// Downloading missing data does not change it.
+	a := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1", Source: "oanda"}}
+	b := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1", Source: "oanda", Download: true}}
+	assert.Equal(t, hashBacktestConfig(a, RunDefaults{}), hashBacktestConfig(b, RunDefaults{}))
+}
+
 func TestCompileBacktests_SetsConfigHash(t *testing.T) {
 	cfg := &Config{
 		Defaults: RunDefaults{StartingBalance: 10000, RiskPct: 1.0, Source: "oanda"},
@@ -117,7 +126,136 @@ func TestHashBacktestConfig_DefaultsChangeHash(t *testing.T) {
 		Data:     DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2024-01-01", To: "2024-03-31"},
 		Strategy: strategy.StrategyConfig{Kind: "fake"},
 	}
-	a := RunDefaults{StartingBalance: 10000, RiskPct: 1.0, SlippagePips: 0.5}
-	b := RunDefaults{StartingBalance: 20000, RiskPct: 1.0, SlippagePips: 0.5}
+	a := RunDefaults{StartingBalance: 10000, RiskPct: 1.0, Execution: ExecutionConfig{SlippagePips: 0.5}}
+	b := RunDefaults{StartingBalance: 20000, RiskPct: 1.0, Execution: ExecutionConfig{SlippagePips: 0.5}}
 	assert.NotEqual(t, hashBacktestConfig(cfg, a), hashBacktestConfig(cfg, b))
 }
+
+func TestHashBacktestConfig_StopOn(t *testing.T) {
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+	withStop := hashBacktestConfig(cfg, RunDefaults{StopOn: StopConfig{MaxTrades: 10}})
+	assert.NotEqual(t, base, withStop, "stop conditions change results, so they must change the hash")
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{StopOn: StopConfig{}}), "unset stop conditions must not change the hash")
+}
+
+func TestHashBacktestConfig_Robustness(t *testing.T) {
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+	robust := hashBacktestConfig(cfg, RunDefaults{Robustness: RobustnessConfig{Runs: 5, SkipProbability: 0.1}})
+	assert.NotEqual(t, base, robust)
+	assert.NotEqual(t, robust, hashBacktestConfig(cfg, RunDefaults{Robustness: RobustnessConfig{Runs: 5, Seed: 2, SkipProbability: 0.1}}), "the seed changes the perturbed results")
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{Robustness: RobustnessConfig{}}))
+}
+
+func TestHashBacktestConfig_Governor(t *testing.T) {
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+	governed := hashBacktestConfig(cfg, RunDefaults{Governor: GovernorConfig{MaxTradesPerDay: 2}})
+	assert.NotEqual(t, base, governed)
+	assert.NotEqual(t, governed, hashBacktestConfig(cfg, RunDefaults{Governor: GovernorConfig{MaxTradesPerDay: 2, LossCooldown: "4h"}}))
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{Governor: GovernorConfig{}}))
+}
+
+func TestHashBacktestConfig_Fill(t *testing.T) {
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+	next := hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{Fill: "next-tick"}})
+	assert.NotEqual(t, base, next)
+	assert.NotEqual(t, next, hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{Fill: "next-tick", LatencyMs: 100}}))
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{Fill: "same-tick"}}))
+}
+
+func TestHashBacktestConfig_Rollover(t *testing.T) {
+	defer types.SetRollover(types.DefaultRollover)
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+
+	types.SetRollover(types.Rollover{Hour: 0})
+	assert.NotEqual(t, base, hashBacktestConfig(cfg, RunDefaults{}))
+
+	types.SetRollover(types.DefaultRollover)
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{}))
+}
+
+func TestHashBacktestConfig_Entry(t *testing.T) {
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+	limit := hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{Entry: EntryConfig{Type: "limit"}}})
+	assert.NotEqual(t, base, limit)
+	assert.NotEqual(t, limit, hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{Entry: EntryConfig{Type: "limit", OffsetPips: 2}}}))
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{Entry: EntryConfig{Type: "market"}}}))
+}
+
+func TestHashBacktestConfig_EquityCurve(t *testing.T) {
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+	defaults := RunDefaults{EquityCurve: EquityCurveConfig{MAPeriod: 10}}
+	throttled := hashBacktestConfig(cfg, defaults)
+	assert.NotEqual(t, base, throttled)
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{EquityCurve: EquityCurveConfig{}}))
+
+	// A run's own block is what counts, whether or not it matches the
+	// defaults; an empty one turns the throttle off.
+	own := cfg
+	own.EquityCurve = &EquityCurveConfig{MAPeriod: 10}
+	assert.Equal(t, throttled, hashBacktestConfig(own, RunDefaults{}))
+	own.EquityCurve = &EquityCurveConfig{}
+	assert.Equal(t, base, hashBacktestConfig(own, defaults))
+}
+
+func TestCompileBacktests_EquityCurvePerRun(t *testing.T) {
+	cfg := &Config{
+		Defaults: RunDefaults{Source: "oanda", EquityCurve: EquityCurveConfig{MaxDrawdownPct: 5, RiskScale: 0.5}},
+		Runs: []RunConfig{
+			{
+				Name:     "defaults",
+				Data:     DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2024-01-01", To: "2024-03-31"},
+				Strategy: strategy.StrategyConfig{Kind: "fake"},
+			},
+			{
+				Name:        "own",
+				Data:        DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2024-01-01", To: "2024-03-31"},
+				Strategy:    strategy.StrategyConfig{Kind: "fake"},
+				EquityCurve: &EquityCurveConfig{MAPeriod: 20},
+			},
+		},
+	}
+	runs, err := CompileBacktests(cfg)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, EquityCurveRules{MaxDrawdown: types.RateFromFloat(0.05), RiskScale: types.RateFromFloat(0.5)}, runs[0].Request.EquityCurve)
+	assert.Equal(t, EquityCurveRules{MAPeriod: 20}, runs[1].Request.EquityCurve)
+
+	cfg.Runs[1].EquityCurve.RiskScale = 2
+	_, err = CompileBacktests(cfg)
+	require.ErrorContains(t, err, "build equity curve throttle for \"own\"")
+}
+
+func TestCompileBacktests_Margin(t *testing.T) {
+	cfg := &Config{
+		Defaults: RunDefaults{Source: "oanda", Margin: account.MarginConfig{Leverage: 20, CloseoutPct: 50}},
+		Runs: []RunConfig{{
+			Name:     "levered",
+			Data:     DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2024-01-01", To: "2024-03-31"},
+			Strategy: strategy.StrategyConfig{Kind: "fake"},
+		}},
+	}
+	runs, err := CompileBacktests(cfg)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, types.RateFromFloat(0.05), runs[0].Request.Margin.Account)
+	assert.Equal(t, types.RateFromFloat(0.5), runs[0].Request.Margin.Closeout)
+	assert.NotEqual(t, hashBacktestConfig(runs[0].RunConfig, RunDefaults{}), runs[0].Request.ConfigHash, "margin settings change execution")
+
+	cfg.Defaults.Margin.Leverage = 0.5
+	_, err = CompileBacktests(cfg)
+	require.ErrorContains(t, err, "build margin")
+}
+
+func TestHashBacktestConfig_IdleInterest(t *testing.T) {
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+	assert.NotEqual(t, base, hashBacktestConfig(cfg, RunDefaults{IdleInterestPct: 4}))
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{IdleInterestPct: 0}))
+}
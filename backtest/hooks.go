@@ -0,0 +1,136 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/market"
+)
+
+// Hook attaches cross-cutting behaviour — metrics, risk guards, logging,
+// recording — to the run loop without changing it. Every field is
+// optional. A hook returning an error ends the run with that error,
+// wrapped with the hook's Name.
+//
+// Hooks run on the run loop's goroutine, in the order they appear in
+// Backtest.Hooks, and see the same Account the strategy does; they must not
+// submit orders themselves.
+type Hook struct {
+	Name string
+
+	// BeforeTick runs for every bar before the exit, regime, broker and
+	// strategy see it.
+	BeforeTick func(ctx context.Context, c market.Candle) error
+
+	// AfterTick runs for every bar once the bar's orders are submitted.
+	AfterTick func(ctx context.Context, c market.Candle, acct *account.Account) error
+
+	// BeforeOrder runs for every entry that survived the planner and the
+	// governor. Returning false drops the entry; it is recorded as
+	// rejected with journal.RejectHook and the hook's name.
+	BeforeOrder func(ctx context.Context, c market.Candle, req *account.OpenRequest) (bool, error)
+
+	// AfterClose runs once for every trade the run books, at the first
+	// hook point after it closes: the next bar, or the end of the run.
+	AfterClose func(ctx context.Context, tr *account.Trade) error
+}
+
+// hookChain runs a run's hooks.
+type hookChain struct {
+	hooks  []Hook
+	closed int // account trades already passed to AfterClose
+}
+
+func newHookChain(hooks []Hook) *hookChain {
+	if len(hooks) == 0 {
+		return nil
+	}
+	return &hookChain{hooks: hooks}
+}
+
+func (h *hookChain) beforeTick(ctx context.Context, c market.Candle) error {
+	if h == nil {
+		return nil
+	}
+	for _, hk := range h.hooks {
+		if hk.BeforeTick == nil {
+			continue
+		}
+		if err := hk.BeforeTick(ctx, c); err != nil {
+			return fmt.Errorf("hook %s: before tick: %w", hk.Name, err)
+		}
+	}
+	return nil
+}
+
+func (h *hookChain) afterTick(ctx context.Context, c market.Candle, acct *account.Account) error {
+	if h == nil {
+		return nil
+	}
+	for _, hk := range h.hooks {
+		if hk.AfterTick == nil {
+			continue
+		}
+		if err := hk.AfterTick(ctx, c, acct); err != nil {
+			return fmt.Errorf("hook %s: after tick: %w", hk.Name, err)
+		}
+	}
+	return nil
+}
+
+// beforeOrder filters opens through every BeforeOrder hook. It returns the
+// opens kept and, when any was dropped, the name of the first hook that
+// dropped one.
+func (h *hookChain) beforeOrder(ctx context.Context, c market.Candle, opens []*account.OpenRequest) ([]*account.OpenRequest, string, error) {
+	if h == nil || len(opens) == 0 {
+		return opens, "", nil
+	}
+	var (
+		kept    []*account.OpenRequest
+		dropped string
+	)
+	for _, req := range opens {
+		keep := true
+		for _, hk := range h.hooks {
+			if hk.BeforeOrder == nil {
+				continue
+			}
+			ok, err := hk.BeforeOrder(ctx, c, req)
+			if err != nil {
+				return nil, "", fmt.Errorf("hook %s: before order: %w", hk.Name, err)
+			}
+			if !ok {
+				keep = false
+				if dropped == "" {
+					dropped = hk.Name
+				}
+				break
+			}
+		}
+		if keep {
+			kept = append(kept, req)
+		}
+	}
+	return kept, dropped, nil
+}
+
+// afterClose passes trades booked since the last call to every AfterClose
+// hook.
+func (h *hookChain) afterClose(ctx context.Context, trades []*account.Trade) error {
+	if h == nil {
+		return nil
+	}
+	for ; h.closed < len(trades); h.closed++ {
+		tr := trades[h.closed]
+		for _, hk := range h.hooks {
+			if hk.AfterClose == nil {
+				continue
+			}
+			if err := hk.AfterClose(ctx, tr); err != nil {
+				return fmt.Errorf("hook %s: after close: %w", hk.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hookRun(t *testing.T, bars int, hooks ...Hook) (*Backtest, *engine.Trader, []market.Candle) {
+	t.Helper()
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, &signalJournal{})}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < bars; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        alwaysLong{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[bars-1].Timestamp, TF: types.H1},
+		},
+		State: &BacktestRun{},
+		Hooks: hooks,
+	}
+	return run, tr, candles
+}
+
+func TestRunWithIterator_Hooks(t *testing.T) {
+	var (
+		events []string
+		before []types.Timestamp
+		after  int
+		closed []*account.Trade
+	)
+	// The guard admits the first two entries only; the logger sees every
+	// point, so its events show the order hooks run in.
+	guard := Hook{
+		Name: "guard",
+		BeforeOrder: func(_ context.Context, _ market.Candle, req *account.OpenRequest) (bool, error) {
+			require.NotNil(t, req)
+			return len(events) < 6, nil
+		},
+	}
+	logger := Hook{
+		Name: "logger",
+		BeforeTick: func(_ context.Context, c market.Candle) error {
+			events = append(events, "tick")
+			before = append(before, c.Timestamp)
+			return nil
+		},
+		BeforeOrder: func(context.Context, market.Candle, *account.OpenRequest) (bool, error) {
+			events = append(events, "order")
+			return true, nil
+		},
+		AfterTick: func(_ context.Context, _ market.Candle, acct *account.Account) error {
+			require.NotNil(t, acct)
+			events = append(events, "after")
+			after++
+			return nil
+		},
+		AfterClose: func(_ context.Context, tr *account.Trade) error {
+			closed = append(closed, tr)
+			return nil
+		},
+	}
+
+	run, tr, candles := hookRun(t, 5, guard, logger)
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	assert.Len(t, before, 5)
+	assert.Equal(t, candles[0].Timestamp, before[0])
+	assert.Equal(t, 5, after)
+	assert.Equal(t, []string{"tick", "order", "after", "tick", "order", "after", "tick", "after"}, events[:8],
+		"a dropped entry never reaches later hooks")
+
+	require.Len(t, tr.Account.Trades, 2)
+	assert.Equal(t, tr.Account.Trades, closed, "every trade closed at the end of the run is passed on")
+
+	require.Len(t, run.State.Rejected, 3)
+	for _, d := range run.State.Rejected {
+		assert.Equal(t, journal.RejectHook, d.Reason)
+		assert.Equal(t, "guard", d.Detail)
+	}
+}
+
+func TestRunWithIterator_HookErrorEndsRun(t *testing.T) {
+	boom := errors.New("risk limit")
+	bars := 0
+	run, tr, candles := hookRun(t, 5, Hook{
+		Name: "risk",
+		BeforeTick: func(context.Context, market.Candle) error {
+			if bars++; bars == 3 {
+				return boom
+			}
+			return nil
+		},
+	})
+	err := run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles})
+	require.ErrorIs(t, err, boom)
+	assert.ErrorContains(t, err, "hook risk: before tick")
+	assert.Equal(t, 3, bars)
+}
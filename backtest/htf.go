@@ -0,0 +1,89 @@
+package backtest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+// HigherTimeframeConfig asks the runner to aggregate the run's bars into a
+// second, higher-timeframe series (e.g. trade H1, confirm on D1) that
+// strategies read through StrategyContext.HigherTimeframe.
+type HigherTimeframeConfig struct {
+	Timeframe string `json:"timeframe"        yaml:"timeframe"`
+	Warmup    int    `json:"warmup,omitempty" yaml:"warmup"` // completed bars required before entries are allowed
+}
+
+// htfHistory is the minimum number of completed higher-timeframe bars a
+// feed retains.
+const htfHistory = 256
+
+// compileHigherTimeframe validates cfg against the run's base timeframe.
+// A nil cfg disables the feed.
+func compileHigherTimeframe(cfg *HigherTimeframeConfig, base types.Timeframe) (types.Timeframe, int, error) {
+	if cfg == nil {
+		return 0, 0, nil
+	}
+	tf, err := types.ParseTimeframe(cfg.Timeframe)
+	if err != nil {
+		return 0, 0, err
+	}
+	if cfg.Warmup < 0 {
+		return 0, 0, fmt.Errorf("warmup must be >= 0, got %d", cfg.Warmup)
+	}
+	if _, err := datamanager.NewCandleAggregator(base, tf); err != nil {
+		return 0, 0, fmt.Errorf("timeframe %s cannot confirm a %s run: %w", strings.ToUpper(tf.String()), strings.ToUpper(base.String()), err)
+	}
+	return tf, cfg.Warmup, nil
+}
+
+// htfFeed implements strategy.HigherTimeframe for the run loop: add is
+// called with every bar before the strategy sees it.
+type htfFeed struct {
+	agg    *datamanager.CandleAggregator
+	tf     types.Timeframe
+	warmup int
+	keep   int
+
+	bars   []market.Candle // completed bars, oldest first
+	total  int             // completed bars ever seen
+	closed bool
+}
+
+func newHTFFeed(base, tf types.Timeframe, warmup int) (*htfFeed, error) {
+	agg, err := datamanager.NewCandleAggregator(base, tf)
+	if err != nil {
+		return nil, err
+	}
+	return &htfFeed{agg: agg, tf: tf, warmup: warmup, keep: max(htfHistory, warmup)}, nil
+}
+
+func (f *htfFeed) add(c market.Candle) {
+	done := f.agg.Add(c)
+	f.closed = len(done) > 0
+	for _, bar := range done {
+		f.bars = append(f.bars, bar)
+		f.total++
+	}
+	if over := len(f.bars) - f.keep; over > 0 {
+		f.bars = append(f.bars[:0], f.bars[over:]...)
+	}
+}
+
+func (f *htfFeed) Timeframe() types.Timeframe { return f.tf }
+func (f *htfFeed) Ready() bool                { return f.total >= f.warmup }
+func (f *htfFeed) Closed() bool               { return f.closed }
+func (f *htfFeed) Len() int                   { return len(f.bars) }
+
+func (f *htfFeed) Last(i int) (market.Candle, bool) {
+	if i < 0 || i >= len(f.bars) {
+		return market.Candle{}, false
+	}
+	return f.bars[len(f.bars)-1-i], true
+}
+
+var _ strategy.HigherTimeframe = (*htfFeed)(nil)
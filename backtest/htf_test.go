@@ -0,0 +1,139 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// htfProbe goes long every bar and records what the higher-timeframe
+// feed looked like when it did.
+type htfProbe struct {
+	seen []htfSeen
+}
+
+type htfSeen struct {
+	ready, closed bool
+	bars          int
+}
+
+func (s *htfProbe) Name() string            { return "htf-probe" }
+func (s *htfProbe) Reset()                  { s.seen = nil }
+func (s *htfProbe) Ready() bool             { return true }
+func (s *htfProbe) StopDescription() string { return "" }
+func (s *htfProbe) Update(_ context.Context, c *market.Candle, sc strategy.StrategyContext) strategy.Signal {
+	htf := sc.HigherTimeframe()
+	s.seen = append(s.seen, htfSeen{ready: htf.Ready(), closed: htf.Closed(), bars: htf.Len()})
+	return strategy.Signal{Side: types.Long, Stop: c.Close - 500, Reason: "probe"}
+}
+
+func TestCompileHigherTimeframe(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *HigherTimeframeConfig
+		base    types.Timeframe
+		want    types.Timeframe
+		wantErr string
+	}{
+		{name: "disabled", base: types.H1},
+		{name: "h4 over h1", cfg: &HigherTimeframeConfig{Timeframe: "H4", Warmup: 20}, base: types.H1, want: types.H4},
+		{name: "d1 over m1", cfg: &HigherTimeframeConfig{Timeframe: "d1"}, base: types.M1, want: types.D1},
+		{name: "same timeframe", cfg: &HigherTimeframeConfig{Timeframe: "H1"}, base: types.H1, wantErr: "cannot confirm a H1 run"},
+		{name: "lower timeframe", cfg: &HigherTimeframeConfig{Timeframe: "M1"}, base: types.H1, wantErr: "cannot confirm"},
+		{name: "unknown", cfg: &HigherTimeframeConfig{Timeframe: "W1"}, base: types.H1, wantErr: "unsupported timeframe"},
+		{name: "negative warmup", cfg: &HigherTimeframeConfig{Timeframe: "H4", Warmup: -1}, base: types.H1, wantErr: "warmup"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf, _, err := compileHigherTimeframe(tt.cfg, tt.base)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tf)
+		})
+	}
+}
+
+func TestHTFFeed_KeepsRecentBars(t *testing.T) {
+	f, err := newHTFFeed(types.M1, types.H1, 2)
+	require.NoError(t, err)
+	f.keep = 3
+
+	assert.False(t, f.Ready())
+	_, ok := f.Last(0)
+	assert.False(t, ok)
+
+	for i := 0; i < 5*60; i++ {
+		f.add(market.Candle{Open: 1, High: 2, Low: 1, Close: types.Price(i), Timestamp: types.Timestamp(i * 60)})
+		assert.Equal(t, i%60 == 59, f.Closed(), "minute %d", i)
+	}
+	assert.True(t, f.Ready())
+	assert.Equal(t, 3, f.Len())
+	last, ok := f.Last(0)
+	require.True(t, ok)
+	assert.Equal(t, types.Timestamp(4*3600), last.Timestamp)
+	oldest, ok := f.Last(2)
+	require.True(t, ok)
+	assert.Equal(t, types.Timestamp(2*3600), oldest.Timestamp)
+	_, ok = f.Last(3)
+	assert.False(t, ok)
+}
+
+func TestRunWithIterator_HigherTimeframeWarmupBlocksEntries(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+
+	// January (EST): H4 windows open at 22:00, 02:00, 06:00 ... UTC. The
+	// run starts inside the 22:00 window, so that one is dropped; the
+	// 02:00 window completes on the 05:00 bar.
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 8; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	probe := &htfProbe{}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        probe,
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[7].Timestamp, TF: types.H1},
+			HigherTF:        types.H4,
+			HTFWarmup:       1,
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	require.Len(t, probe.seen, 8)
+	for i, s := range probe.seen {
+		assert.Equal(t, i >= 5, s.ready, "bar %d ready", i)
+		assert.Equal(t, i == 5, s.closed, "bar %d closed", i)
+	}
+
+	require.NotEmpty(t, acct.Trades)
+	for _, trade := range acct.Trades {
+		assert.GreaterOrEqual(t, trade.EntryTime, candles[5].Timestamp, "no entry before the feed is warm")
+	}
+}
+
+func TestBacktest_HigherTimeframeNilWithoutFeed(t *testing.T) {
+	run := &Backtest{State: &BacktestRun{}}
+	assert.Nil(t, run.HigherTimeframe())
+}
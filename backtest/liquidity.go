@@ -0,0 +1,58 @@
+package backtest
+
+import (
+	"fmt"
+
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// LiquidityLevelConfig is one level of the simulated book: Units offered on
+// each bar at SlippagePips beyond the quote. Levels are listed best first.
+// With no levels configured every order fills in full at the quote.
+type LiquidityLevelConfig struct {
+	Units        int64   `json:"units"         yaml:"units"`
+	SlippagePips float64 `json:"slippage-pips" yaml:"slippage-pips"`
+}
+
+// LiquidityLevel is the compiled form of LiquidityLevelConfig.
+type LiquidityLevel struct {
+	Units        types.Units
+	SlippagePips types.Pips
+}
+
+// compileLiquidity validates cfg and converts it to LiquidityLevels. The
+// depth curve must only get worse: each level's slippage is at least the
+// one before it.
+func compileLiquidity(cfg []LiquidityLevelConfig) ([]LiquidityLevel, error) {
+	var out []LiquidityLevel
+	var prev float64
+	for i, lv := range cfg {
+		if lv.Units <= 0 {
+			return nil, fmt.Errorf("liquidity level %d: units must be > 0, got %d", i+1, lv.Units)
+		}
+		if lv.SlippagePips < 0 || lv.SlippagePips < prev {
+			return nil, fmt.Errorf("liquidity level %d: slippage-pips must be >= 0 and >= the level before it, got %g", i+1, lv.SlippagePips)
+		}
+		prev = lv.SlippagePips
+		out = append(out, LiquidityLevel{
+			Units:        types.Units(lv.Units),
+			SlippagePips: types.PipsFromFloat(lv.SlippagePips),
+		})
+	}
+	return out, nil
+}
+
+// simLiquidity converts levels to the sim's book for inst; nil when no
+// levels are set.
+func simLiquidity(levels []LiquidityLevel, inst *market.Instrument) *sim.Liquidity {
+	if len(levels) == 0 || inst == nil {
+		return nil
+	}
+	l := &sim.Liquidity{Levels: make([]sim.DepthLevel, len(levels))}
+	for i, lv := range levels {
+		l.Levels[i] = sim.DepthLevel{Units: lv.Units, Offset: inst.PriceDeltaFromPips(lv.SlippagePips)}
+	}
+	return l
+}
@@ -0,0 +1,84 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileLiquidity(t *testing.T) {
+	got, err := compileLiquidity(nil)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	got, err = compileLiquidity([]LiquidityLevelConfig{{Units: 1_000_000}, {Units: 2_000_000, SlippagePips: 0.5}})
+	require.NoError(t, err)
+	assert.Equal(t, []LiquidityLevel{
+		{Units: 1_000_000},
+		{Units: 2_000_000, SlippagePips: types.PipsFromFloat(0.5)},
+	}, got)
+
+	_, err = compileLiquidity([]LiquidityLevelConfig{{Units: 0}})
+	require.ErrorContains(t, err, "liquidity level 1: units must be > 0")
+	_, err = compileLiquidity([]LiquidityLevelConfig{{Units: 10, SlippagePips: 1}, {Units: 10, SlippagePips: 0.5}})
+	require.ErrorContains(t, err, "liquidity level 2: slippage-pips must be >= 0")
+}
+
+func TestSimLiquidity_ConvertsPips(t *testing.T) {
+	assert.Nil(t, simLiquidity(nil, market.GetInstrument("EURUSD")))
+
+	l := simLiquidity([]LiquidityLevel{{Units: 100, SlippagePips: types.PipsFromFloat(0.5)}}, market.GetInstrument("EURUSD"))
+	require.NotNil(t, l)
+	assert.Equal(t, []sim.DepthLevel{{Units: 100, Offset: 5}}, l.Levels)
+}
+
+func TestRunWithIterator_LiquiditySplitsLargeEntry(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	broker := sim.NewSimBroker(acct, nil)
+	tr := &engine.Trader{Account: acct, Broker: broker}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 8; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	// $100 risk over a 5000-unit stop sizes the one entry to 2000 units;
+	// a 500-unit book fills it over four bars.
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        alwaysLong{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[7].Timestamp, TF: types.H1},
+			Governor:        GovernorRules{MaxTradesPerDay: 1},
+			Liquidity:       []LiquidityLevel{{Units: 500}},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	require.Len(t, acct.Trades, 4)
+	var total types.Units
+	entries := map[types.Timestamp]bool{}
+	for _, tr := range acct.Trades {
+		assert.Equal(t, types.Units(500), tr.Units)
+		total += tr.Units
+		entries[tr.EntryTime] = true
+	}
+	assert.Equal(t, types.Units(2000), total)
+	assert.Len(t, entries, 4, "each partial fill lands on its own bar")
+	assert.Empty(t, broker.PendingOrders(acct.ID))
+}
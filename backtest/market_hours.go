@@ -0,0 +1,127 @@
+package backtest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// MarketHoursConfig makes the simulated broker keep trading hours. Off
+// unless ClosedOrders is set.
+type MarketHoursConfig struct {
+	// ClosedOrders is what becomes of an entry on a bar when the market is
+	// closed: "reject" drops it, reported as a market-closed rejection;
+	// "queue" fills it at the first bar after the reopen.
+	ClosedOrders string `json:"closed-orders,omitempty" yaml:"closed-orders"`
+
+	// Close and Open are the weekly close and reopen in UTC, e.g.
+	// "Fri 22:00" and "Sun 22:00" (the defaults, the FX week).
+	Close string `json:"close,omitempty" yaml:"close"`
+	Open  string `json:"open,omitempty"  yaml:"open"`
+
+	// Instruments overrides Close and Open per instrument.
+	Instruments map[string]SessionConfig `json:"instruments,omitempty" yaml:"instruments"`
+
+	// Swap charges rollover financing on open positions; nil charges none.
+	Swap *SwapConfig `json:"swap,omitempty" yaml:"swap"`
+}
+
+// SessionConfig is one instrument's weekly close and reopen.
+type SessionConfig struct {
+	Close string `json:"close" yaml:"close"`
+	Open  string `json:"open"  yaml:"open"`
+}
+
+// SwapConfig sets the rollover financing charged once a day, at the
+// rollover (the global config's market.rollover), on every open position.
+type SwapConfig struct {
+	LongPct  float64 `json:"long-pct"  yaml:"long-pct"`  // annual % of notional charged on longs; negative credits
+	ShortPct float64 `json:"short-pct" yaml:"short-pct"` // the same for shorts
+
+	// TripleDay is the weekday whose rollover charges three days to cover
+	// the weekend, e.g. "wednesday"; empty charges weekdays only.
+	TripleDay string `json:"triple-day,omitempty" yaml:"triple-day"`
+}
+
+// Closed-order policies.
+const (
+	ClosedOrdersReject = "reject"
+	ClosedOrdersQueue  = "queue"
+)
+
+// IsZero reports whether nothing is configured.
+func (c MarketHoursConfig) IsZero() bool {
+	return c.ClosedOrders == "" && c.Close == "" && c.Open == "" && len(c.Instruments) == 0 && c.Swap == nil
+}
+
+// compileMarketHours validates cfg and converts it to the sim's calendar;
+// nil when it is not configured.
+func compileMarketHours(cfg MarketHoursConfig) (*sim.MarketHours, error) {
+	if cfg.IsZero() {
+		return nil, nil
+	}
+	h := &sim.MarketHours{}
+	switch strings.ToLower(strings.TrimSpace(cfg.ClosedOrders)) {
+	case ClosedOrdersReject:
+	case ClosedOrdersQueue:
+		h.Queue = true
+	default:
+		return nil, fmt.Errorf("market-hours: closed-orders must be %q or %q, got %q", ClosedOrdersReject, ClosedOrdersQueue, cfg.ClosedOrders)
+	}
+
+	var err error
+	if h.Calendar.Default, err = compileSession(SessionConfig{Close: cfg.Close, Open: cfg.Open}, market.FXHours); err != nil {
+		return nil, fmt.Errorf("market-hours: %w", err)
+	}
+	for inst, s := range cfg.Instruments {
+		hours, err := compileSession(s, h.Calendar.Default)
+		if err != nil {
+			return nil, fmt.Errorf("market-hours: %s: %w", inst, err)
+		}
+		if h.Calendar.Instruments == nil {
+			h.Calendar.Instruments = make(map[string]market.TradingHours)
+		}
+		h.Calendar.Instruments[market.NormalizeInstrument(inst)] = hours
+	}
+
+	if sw := cfg.Swap; sw != nil {
+		h.Swap = &sim.Swap{
+			Long:  types.RateFromFloat(sw.LongPct / 100),
+			Short: types.RateFromFloat(sw.ShortPct / 100),
+		}
+		if day := strings.TrimSpace(sw.TripleDay); day != "" {
+			w, err := market.ParseWeeklyTime(day + " 00:00")
+			if err != nil {
+				return nil, fmt.Errorf("market-hours: swap triple-day %q is not a weekday", sw.TripleDay)
+			}
+			h.Swap.Triple, h.Swap.TripleDay = true, w.Day
+		}
+	}
+	return h, nil
+}
+
+// compileSession parses s, taking a blank close or open from def.
+func compileSession(s SessionConfig, def market.TradingHours) (market.TradingHours, error) {
+	hours := def
+	var err error
+	if s.Close != "" {
+		if hours.Close, err = market.ParseWeeklyTime(s.Close); err != nil {
+			return hours, err
+		}
+	}
+	if s.Open != "" {
+		if hours.Open, err = market.ParseWeeklyTime(s.Open); err != nil {
+			return hours, err
+		}
+	}
+	return hours, nil
+}
+
+// marketClosed reports whether an entry on inst at ts is refused because
+// its market is closed and closed-market entries are not queued.
+func marketClosed(h *sim.MarketHours, inst string, ts types.Timestamp) bool {
+	return h != nil && !h.Queue && !h.Calendar.Hours(inst).IsOpen(ts.Time())
+}
@@ -0,0 +1,106 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileMarketHours(t *testing.T) {
+	got, err := compileMarketHours(MarketHoursConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, got, "off unless configured")
+
+	got, err = compileMarketHours(MarketHoursConfig{
+		ClosedOrders: "queue",
+		Instruments:  map[string]SessionConfig{"xau_usd": {Close: "Fri 21:00"}},
+		Swap:         &SwapConfig{LongPct: 2.5, ShortPct: -0.5, TripleDay: "wednesday"},
+	})
+	require.NoError(t, err)
+	assert.True(t, got.Queue)
+	assert.Equal(t, market.FXHours, got.Calendar.Default)
+	gold := got.Calendar.Hours("XAUUSD")
+	assert.Equal(t, market.WeeklyTime{Day: time.Friday, Hour: 21}, gold.Close)
+	assert.Equal(t, market.FXHours.Open, gold.Open, "a blank open falls back to the default")
+	require.NotNil(t, got.Swap)
+	assert.Equal(t, types.RateFromFloat(0.025), got.Swap.Long)
+	assert.Equal(t, types.RateFromFloat(-0.005), got.Swap.Short)
+	assert.True(t, got.Swap.Triple)
+	assert.Equal(t, time.Wednesday, got.Swap.TripleDay)
+
+	for _, tt := range []struct {
+		cfg  MarketHoursConfig
+		want string
+	}{
+		{MarketHoursConfig{Close: "Fri 21:00"}, "closed-orders must be"},
+		{MarketHoursConfig{ClosedOrders: "hold"}, "closed-orders must be"},
+		{MarketHoursConfig{ClosedOrders: "reject", Open: "Sun 25:00"}, "bad time of day"},
+		{MarketHoursConfig{ClosedOrders: "reject", Instruments: map[string]SessionConfig{"EURUSD": {Open: "Someday 10:00"}}}, "EURUSD"},
+		{MarketHoursConfig{ClosedOrders: "reject", Swap: &SwapConfig{TripleDay: "wed-ish"}}, "not a weekday"},
+	} {
+		_, err := compileMarketHours(tt.cfg)
+		assert.ErrorContains(t, err, tt.want)
+	}
+}
+
+func TestRunWithIterator_MarketClosedRejectsEntries(t *testing.T) {
+	hours, err := compileMarketHours(MarketHoursConfig{ClosedOrders: ClosedOrdersReject})
+	require.NoError(t, err)
+
+	run, tr, _ := hookRun(t, 1)
+	// Hourly bars from Friday 20:00 to Sunday 23:00 UTC.
+	start := time.Date(2024, 1, 5, 20, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 52; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	run.Request.TimeRange = types.TimeRange{Start: candles[0].Timestamp, End: candles[len(candles)-1].Timestamp, TF: types.H1}
+	run.Request.MarketHours = hours
+
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	assert.Len(t, tr.Account.Trades, 4, "Friday 20:00 and 21:00, Sunday 22:00 and 23:00")
+	require.Len(t, run.State.Rejected, 48)
+	for _, d := range run.State.Rejected {
+		assert.Equal(t, journal.RejectMarketClosed, d.Reason)
+	}
+}
+
+func TestRunWithIterator_QueuedOrdersPendingAtEndAreLogged(t *testing.T) {
+	hours, err := compileMarketHours(MarketHoursConfig{ClosedOrders: ClosedOrdersQueue})
+	require.NoError(t, err)
+
+	run, tr, _ := hookRun(t, 1)
+	var logs bytes.Buffer
+	run.Log = slog.New(slog.NewTextHandler(&logs, nil))
+	// Hourly bars from Friday 20:00 into Saturday: the entries queued
+	// after the close never fill.
+	start := time.Date(2024, 1, 5, 20, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 6; i++ {
+		candles = append(candles, market.Candle{
+			Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	run.Request.TimeRange = types.TimeRange{Start: candles[0].Timestamp, End: candles[len(candles)-1].Timestamp, TF: types.H1}
+	run.Request.MarketHours = hours
+
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	pending, err := tr.PendingOrders(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, pending)
+	assert.Contains(t, logs.String(), "orders still pending at the end of the run")
+}
@@ -0,0 +1,63 @@
+package backtest
+
+import (
+	"fmt"
+
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// MinStopsConfig is the least distance, in pips, the simulated broker lets
+// a stop-loss or take-profit sit from the market, like a real broker's
+// minimum stop level. Entries and stop changes nearer than that are
+// refused. Off when nothing is set.
+type MinStopsConfig struct {
+	StopPips float64 `json:"stop-pips,omitempty" yaml:"stop-pips"`
+	TakePips float64 `json:"take-pips,omitempty" yaml:"take-pips"`
+
+	// Instruments overrides StopPips and TakePips per instrument.
+	Instruments map[string]StopDistanceConfig `json:"instruments,omitempty" yaml:"instruments"`
+}
+
+// StopDistanceConfig is one instrument's minimum stop and take distance.
+type StopDistanceConfig struct {
+	StopPips float64 `json:"stop-pips,omitempty" yaml:"stop-pips"`
+	TakePips float64 `json:"take-pips,omitempty" yaml:"take-pips"`
+}
+
+// IsZero reports whether nothing is configured.
+func (c MinStopsConfig) IsZero() bool {
+	return c.StopPips == 0 && c.TakePips == 0 && len(c.Instruments) == 0
+}
+
+// compileMinStops validates cfg and converts it to the sim's minimums; nil
+// when it is not configured.
+func compileMinStops(cfg MinStopsConfig) (*sim.MinStopDistance, error) {
+	if cfg.IsZero() {
+		return nil, nil
+	}
+	def, err := compileStopDistance(StopDistanceConfig{StopPips: cfg.StopPips, TakePips: cfg.TakePips})
+	if err != nil {
+		return nil, fmt.Errorf("min-stops: %w", err)
+	}
+	m := &sim.MinStopDistance{Default: def}
+	for inst, d := range cfg.Instruments {
+		dist, err := compileStopDistance(d)
+		if err != nil {
+			return nil, fmt.Errorf("min-stops: %s: %w", inst, err)
+		}
+		if m.Instruments == nil {
+			m.Instruments = make(map[string]sim.StopDistance)
+		}
+		m.Instruments[market.NormalizeInstrument(inst)] = dist
+	}
+	return m, nil
+}
+
+func compileStopDistance(c StopDistanceConfig) (sim.StopDistance, error) {
+	if c.StopPips < 0 || c.TakePips < 0 {
+		return sim.StopDistance{}, fmt.Errorf("stop-pips and take-pips must be >= 0, got %g and %g", c.StopPips, c.TakePips)
+	}
+	return sim.StopDistance{Stop: types.PipsFromFloat(c.StopPips), Take: types.PipsFromFloat(c.TakePips)}, nil
+}
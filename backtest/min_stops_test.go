@@ -0,0 +1,92 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileMinStops(t *testing.T) {
+	got, err := compileMinStops(MinStopsConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	got, err = compileMinStops(MinStopsConfig{StopPips: 5, Instruments: map[string]StopDistanceConfig{"usd_jpy": {StopPips: 10, TakePips: 2.5}}})
+	require.NoError(t, err)
+	assert.Equal(t, sim.StopDistance{Stop: types.PipsFromFloat(5)}, got.For("EURUSD"))
+	assert.Equal(t, sim.StopDistance{Stop: types.PipsFromFloat(10), Take: types.PipsFromFloat(2.5)}, got.For("USDJPY"))
+
+	_, err = compileMinStops(MinStopsConfig{TakePips: -1})
+	require.ErrorContains(t, err, "min-stops: stop-pips and take-pips must be >= 0")
+	_, err = compileMinStops(MinStopsConfig{Instruments: map[string]StopDistanceConfig{"GBPUSD": {StopPips: -2}}})
+	require.ErrorContains(t, err, "min-stops: GBPUSD:")
+}
+
+func TestHashBacktestConfig_MinStops(t *testing.T) {
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+	assert.NotEqual(t, base, hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{MinStops: MinStopsConfig{StopPips: 5}}}))
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{MinStops: MinStopsConfig{}}}))
+}
+
+func TestRunWithIterator_MinStopsRejectEntries(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	// Each bar closes 200 pips under the one before.
+	var candles []market.Candle
+	for i := 0; i < 4; i++ {
+		c := types.Price(1200000 - 2000*i)
+		candles = append(candles, market.Candle{
+			Open: c, High: c + 500, Low: c - 500, Close: c,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	// alwaysLong stops 500 pips under the close.
+	run := func(minPips float64, fill FillModel) (*account.Account, *BacktestRun) {
+		acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+		acct.RiskFraction = types.RateFromFloat(0.01)
+		tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+		minStops, err := compileMinStops(MinStopsConfig{StopPips: minPips})
+		require.NoError(t, err)
+		r := &Backtest{
+			Request: &BacktestRequest{
+				Instrument:      "EURUSD",
+				Strategy:        alwaysLong{},
+				StartingBalance: types.MoneyFromFloat(10_000),
+				TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[3].Timestamp, TF: types.H1},
+				Governor:        GovernorRules{MaxTradesPerDay: 1},
+				MinStops:        minStops,
+				Fill:            fill,
+			},
+			State: &BacktestRun{},
+		}
+		require.NoError(t, r.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+		return acct, r.State
+	}
+
+	acct, state := run(600, FillModel{})
+	assert.Empty(t, acct.Trades, "every entry's stop is too close")
+	require.NotEmpty(t, state.Rejected)
+	assert.Equal(t, journal.RejectStopDistance, state.Rejected[0].Reason)
+	assert.Contains(t, state.Rejected[0].Detail, "stop-loss 500.0 pips from market, minimum 600.0")
+
+	acct, _ = run(400, FillModel{})
+	assert.Len(t, acct.Trades, 1)
+
+	// Held for the next bar, the stop is only 300 pips from where the
+	// order is submitted, and the sim refuses it then.
+	acct, state = run(400, FillModel{NextTick: true})
+	assert.Empty(t, acct.Trades)
+	require.NotEmpty(t, state.Rejected)
+	assert.Equal(t, journal.RejectStopDistance, state.Rejected[0].Reason)
+	assert.Contains(t, state.Rejected[0].Detail, "300.0 pips")
+}
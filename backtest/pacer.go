@@ -0,0 +1,170 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// PaceMode selects how a Pacer spaces out ticks in wall-clock time.
+type PaceMode int
+
+const (
+	// PaceMax replays as fast as possible (no waiting). The default.
+	PaceMax PaceMode = iota
+	// PaceFixed yields a fixed number of ticks per wall-clock second,
+	// regardless of the gaps between tick timestamps.
+	PaceFixed
+	// PaceScaled follows tick timestamps, compressed by a speed factor:
+	// at 60x one simulated minute takes one wall-clock second.
+	PaceScaled
+)
+
+// Pacer throttles a replay loop. Call Wait with each tick's timestamp
+// before processing it; Wait blocks until that tick is due.
+//
+// The zero value is PaceMax and never blocks. A Pacer is stateful (it
+// anchors simulated time to wall time on the first Wait) and not safe for
+// concurrent use.
+type Pacer struct {
+	Mode PaceMode
+	// TicksPerSecond is the fixed rate under PaceFixed.
+	TicksPerSecond int64
+	// Speed is the time-compression factor under PaceScaled (1 = real
+	// time, 60 = one simulated minute per wall-clock second).
+	Speed int64
+
+	// now and sleep are injectable for tests; nil means the real clock.
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+
+	started   bool
+	wallStart time.Time
+	simStart  types.Timestamp
+	ticks     int64
+}
+
+// ParsePace parses a --pace flag value:
+//
+//	"" or "max"      as fast as possible
+//	"realtime"       tick timestamps at 1x
+//	"<n>x"           tick timestamps compressed n times (e.g. "60x")
+//	"<n>/s"          fixed n ticks per second (e.g. "200/s")
+func ParsePace(s string) (*Pacer, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case v == "" || v == "max":
+		return &Pacer{}, nil
+	case v == "realtime":
+		return &Pacer{Mode: PaceScaled, Speed: 1}, nil
+	case strings.HasSuffix(v, "x"):
+		n, err := parsePaceNumber(strings.TrimSuffix(v, "x"))
+		if err != nil {
+			return nil, fmt.Errorf("bad pace %q: %w", s, err)
+		}
+		return &Pacer{Mode: PaceScaled, Speed: n}, nil
+	case strings.HasSuffix(v, "/s"):
+		n, err := parsePaceNumber(strings.TrimSuffix(v, "/s"))
+		if err != nil {
+			return nil, fmt.Errorf("bad pace %q: %w", s, err)
+		}
+		return &Pacer{Mode: PaceFixed, TicksPerSecond: n}, nil
+	default:
+		return nil, fmt.Errorf("bad pace %q (use max, realtime, <n>x, or <n>/s)", s)
+	}
+}
+
+func parsePaceNumber(s string) (int64, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("must be > 0")
+	}
+	return n, nil
+}
+
+// String renders the pacer in ParsePace's syntax.
+func (p *Pacer) String() string {
+	if p == nil {
+		return "max"
+	}
+	switch p.Mode {
+	case PaceFixed:
+		return strconv.FormatInt(p.TicksPerSecond, 10) + "/s"
+	case PaceScaled:
+		if p.Speed == 1 {
+			return "realtime"
+		}
+		return strconv.FormatInt(p.Speed, 10) + "x"
+	default:
+		return "max"
+	}
+}
+
+// Wait blocks until the tick stamped ts is due, or ctx is cancelled.
+// Due times are computed from the first tick, not the previous one, so
+// processing time never accumulates into drift. Ticks stamped earlier
+// than the first one seen are not waited for.
+func (p *Pacer) Wait(ctx context.Context, ts types.Timestamp) error {
+	if p == nil || p.Mode == PaceMax {
+		return ctx.Err()
+	}
+	now := p.clock()
+	if !p.started {
+		p.started = true
+		p.wallStart = now
+		p.simStart = ts
+		return ctx.Err()
+	}
+
+	var offset time.Duration
+	switch p.Mode {
+	case PaceFixed:
+		if p.TicksPerSecond <= 0 {
+			return ctx.Err()
+		}
+		p.ticks++
+		offset = time.Duration(p.ticks) * time.Second / time.Duration(p.TicksPerSecond)
+	case PaceScaled:
+		if p.Speed <= 0 {
+			return ctx.Err()
+		}
+		elapsed := int64(ts - p.simStart)
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		offset = time.Duration(elapsed) * time.Second / time.Duration(p.Speed)
+	}
+
+	if d := p.wallStart.Add(offset).Sub(now); d > 0 {
+		return p.doSleep(ctx, d)
+	}
+	return ctx.Err()
+}
+
+func (p *Pacer) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+func (p *Pacer) doSleep(ctx context.Context, d time.Duration) error {
+	if p.sleep != nil {
+		return p.sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock lets pacer tests observe requested sleeps without waiting.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) install(p *Pacer) {
+	p.now = func() time.Time { return c.now }
+	p.sleep = func(_ context.Context, d time.Duration) error {
+		c.sleeps = append(c.sleeps, d)
+		c.now = c.now.Add(d)
+		return nil
+	}
+}
+
+func TestParsePace(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		mode    PaceMode
+		tps     int64
+		speed   int64
+		str     string
+		wantErr bool
+	}{
+		{in: "", mode: PaceMax, str: "max"},
+		{in: "max", mode: PaceMax, str: "max"},
+		{in: "realtime", mode: PaceScaled, speed: 1, str: "realtime"},
+		{in: "60x", mode: PaceScaled, speed: 60, str: "60x"},
+		{in: "200/s", mode: PaceFixed, tps: 200, str: "200/s"},
+		{in: "0x", wantErr: true},
+		{in: "1.5x", wantErr: true},
+		{in: "-5/s", wantErr: true},
+		{in: "fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		p, err := ParsePace(tt.in)
+		if tt.wantErr {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.mode, p.Mode, tt.in)
+		assert.Equal(t, tt.tps, p.TicksPerSecond, tt.in)
+		assert.Equal(t, tt.speed, p.Speed, tt.in)
+		assert.Equal(t, tt.str, p.String(), tt.in)
+	}
+}
+
+func TestPacer_MaxNeverSleeps(t *testing.T) {
+	t.Parallel()
+
+	p := &Pacer{}
+	clk := &fakeClock{now: time.Unix(0, 0)}
+	clk.install(p)
+	for ts := types.Timestamp(0); ts < 10; ts++ {
+		require.NoError(t, p.Wait(context.Background(), ts*3600))
+	}
+	assert.Empty(t, clk.sleeps)
+}
+
+func TestPacer_ScaledFollowsTimestamps(t *testing.T) {
+	t.Parallel()
+
+	p := &Pacer{Mode: PaceScaled, Speed: 60}
+	clk := &fakeClock{now: time.Unix(0, 0)}
+	clk.install(p)
+
+	require.NoError(t, p.Wait(context.Background(), 1000)) // anchors, no sleep
+	require.NoError(t, p.Wait(context.Background(), 1060)) // one sim minute later
+	require.NoError(t, p.Wait(context.Background(), 1060)) // same stamp: already due
+	require.NoError(t, p.Wait(context.Background(), 1180)) // two more sim minutes
+
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clk.sleeps)
+}
+
+func TestPacer_FixedIgnoresTimestampGaps(t *testing.T) {
+	t.Parallel()
+
+	p := &Pacer{Mode: PaceFixed, TicksPerSecond: 10}
+	clk := &fakeClock{now: time.Unix(0, 0)}
+	clk.install(p)
+
+	require.NoError(t, p.Wait(context.Background(), 0))
+	require.NoError(t, p.Wait(context.Background(), 86400))
+	require.NoError(t, p.Wait(context.Background(), 86401))
+
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 100 * time.Millisecond}, clk.sleeps)
+}
+
+func TestPacer_WaitHonorsCancellation(t *testing.T) {
+	t.Parallel()
+
+	p := &Pacer{Mode: PaceScaled, Speed: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, p.Wait(ctx, 0))
+	cancel()
+	err := p.Wait(ctx, 10)
+	assert.ErrorIs(t, err, context.Canceled)
+}
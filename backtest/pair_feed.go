@@ -0,0 +1,112 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+// pairFeed feeds the run's strategy.PairRegimes the bars of the pairs they
+// read besides the traded instrument, merged in time order up to each
+// traded bar.
+type pairFeed struct {
+	pairs []*pairBars
+}
+
+// pairBars is one pair's bars and the regimes reading them.
+type pairBars struct {
+	instrument string
+	itr        market.CandleIterator
+	next       market.Candle
+	ok         bool
+	regimes    []strategy.PairRegime
+}
+
+// loadPairFeed binds the request's PairRegimes to the traded instrument and
+// opens each pair they read, from the warm-up's first bar (the run's start
+// without one) to the run's end. It returns nil when no regime reads other
+// pairs.
+func (run *Backtest) loadPairFeed(ctx context.Context, dm engine.CandleSource, source string) (*pairFeed, error) {
+	regimes := strategy.PairRegimes(run.Request.Regime)
+	if len(regimes) == 0 {
+		return nil, nil
+	}
+	tr := run.Request.TimeRange
+	if preload := run.State.preload; len(preload) > 0 {
+		tr = types.NewTimeRange(preload[0].Timestamp, tr.End, tr.TF)
+	}
+	traded := market.NormalizeInstrument(run.Request.Instrument)
+	feed := &pairFeed{}
+	byInst := make(map[string]*pairBars)
+	for _, r := range regimes {
+		if err := r.Bind(traded); err != nil {
+			_ = feed.Close()
+			return nil, fmt.Errorf("regime %s: %w", r.Name(), err)
+		}
+		for _, inst := range r.Pairs() {
+			if inst == traded {
+				continue
+			}
+			if p, ok := byInst[inst]; ok {
+				p.regimes = append(p.regimes, r)
+				continue
+			}
+			itr, err := dm.Candles(ctx, datamanager.CandleRequest{Source: source, Instrument: inst, Range: tr})
+			if err != nil {
+				_ = feed.Close()
+				return nil, fmt.Errorf("regime %s: load %s: %w", r.Name(), inst, err)
+			}
+			p := &pairBars{
+				instrument: inst,
+				itr:        market.NewCheckedCandleIterator(itr, &market.CandleChecker{Instrument: inst, Repair: true}),
+				regimes:    []strategy.PairRegime{r},
+			}
+			p.next, p.ok = p.itr.Next()
+			byInst[inst] = p
+			feed.pairs = append(feed.pairs, p)
+		}
+	}
+	return feed, nil
+}
+
+// advance ticks every pair bar at or before ts into its regimes, oldest
+// first. A nil feed does nothing.
+func (f *pairFeed) advance(ts types.Timestamp) {
+	if f == nil {
+		return
+	}
+	for {
+		var first *pairBars
+		for _, p := range f.pairs {
+			if p.ok && p.next.Timestamp <= ts && (first == nil || p.next.Timestamp < first.next.Timestamp) {
+				first = p
+			}
+		}
+		if first == nil {
+			return
+		}
+		for _, r := range first.regimes {
+			r.TickPair(first.instrument, first.next)
+		}
+		first.next, first.ok = first.itr.Next()
+	}
+}
+
+// Close closes every pair's iterator, returning their read and close
+// errors.
+func (f *pairFeed) Close() error {
+	if f == nil {
+		return nil
+	}
+	var errs []error
+	for _, p := range f.pairs {
+		errs = append(errs, p.itr.Err(), p.itr.Close())
+	}
+	return errors.Join(errs...)
+}
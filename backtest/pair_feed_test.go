@@ -0,0 +1,89 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+// pairSource serves each instrument's own candles in the request's range.
+type pairSource struct {
+	candles map[string][]market.Candle
+	reqs    []datamanager.CandleRequest
+}
+
+func (s *pairSource) Candles(_ context.Context, req datamanager.CandleRequest) (market.CandleIterator, error) {
+	s.reqs = append(s.reqs, req)
+	var out []market.Candle
+	for _, c := range s.candles[req.Instrument] {
+		if req.Range.Contains(c.Timestamp) {
+			out = append(out, c)
+		}
+	}
+	return &fixedCandleIterator{candles: out}, nil
+}
+
+// tickedPairs records the pair bars a PairRegime is fed.
+type tickedPairs struct {
+	strategy.NoopRegime
+	bound string
+	got   []string
+}
+
+func (r *tickedPairs) Pairs() []string { return []string{"EURUSD", "EURJPY", "GBPUSD"} }
+func (r *tickedPairs) Bind(instrument string) error {
+	r.bound = instrument
+	return nil
+}
+func (r *tickedPairs) TickPair(instrument string, c market.Candle) {
+	r.got = append(r.got, instrument+"@"+c.Timestamp.String())
+}
+
+func TestLoadPairFeed_MergesPairsInTimeOrder(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	bars := hourlyCandles(start, 4)
+	src := &pairSource{candles: map[string][]market.Candle{
+		"EURJPY": bars,
+		"GBPUSD": bars[1:],
+	}}
+	r := &tickedPairs{}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument: "EUR_USD",
+			Regime:     strategy.NewCompositeRegimeFilter([]strategy.RegimeFilter{strategy.NoopRegime{}, r}),
+			TimeRange:  types.NewTimeRange(types.FromTime(start), types.FromTime(start.Add(4*time.Hour)), types.H1),
+		},
+		State: &BacktestRun{},
+	}
+
+	feed, err := run.loadPairFeed(context.Background(), src, "candles")
+	require.NoError(t, err)
+	assert.Equal(t, "EURUSD", r.bound)
+	require.Len(t, src.reqs, 2, "the traded instrument is not loaded again")
+
+	feed.advance(bars[1].Timestamp)
+	assert.Equal(t, []string{
+		"EURJPY@" + bars[0].Timestamp.String(),
+		"EURJPY@" + bars[1].Timestamp.String(),
+		"GBPUSD@" + bars[1].Timestamp.String(),
+	}, r.got)
+	feed.advance(bars[1].Timestamp)
+	assert.Len(t, r.got, 3, "bars are fed once")
+	feed.advance(bars[3].Timestamp)
+	assert.Len(t, r.got, 7)
+	require.NoError(t, feed.Close())
+
+	run.Request.Regime = strategy.NoopRegime{}
+	feed, err = run.loadPairFeed(context.Background(), src, "candles")
+	require.NoError(t, err)
+	assert.Nil(t, feed)
+	feed.advance(bars[3].Timestamp)
+}
@@ -2,6 +2,7 @@ package backtest
 
 import (
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/strategy"
 	"github.com/rustyeddy/trader/types"
@@ -19,6 +20,9 @@ type runPlanContext struct {
 	slippage        types.Price
 	maxSpread       types.Price
 	defaultStopPips types.Pips
+	auditSizing     bool
+	minStops        *sim.MinStopDistance
+	spreadGuard     *spreadGuard
 }
 
 func (c runPlanContext) Instrument() string            { return c.instrument }
@@ -29,3 +33,16 @@ func (c runPlanContext) Candle() market.Candle         { return c.candle }
 func (c runPlanContext) Slippage() types.Price         { return c.slippage }
 func (c runPlanContext) MaxSpread() types.Price        { return c.maxSpread }
 func (c runPlanContext) DefaultStopPips() types.Pips   { return c.defaultStopPips }
+
+// AuditSizing implements planner.SizingAuditor.
+func (c runPlanContext) AuditSizing() bool { return c.auditSizing }
+
+// BlockSpread implements planner.SpreadGuard with the run's spread guard;
+// without one it blocks nothing.
+func (c runPlanContext) BlockSpread(spread types.Price) string { return c.spreadGuard.check(spread) }
+
+// CheckStops implements planner.StopChecker with the run's minimum stop
+// distances, the ones the sim enforces; without any it allows every stop.
+func (c runPlanContext) CheckStops(o *account.OpenRequest, ref types.Price) error {
+	return c.minStops.Check(c.instrument, o.Side == types.Long, ref, o.Stop, o.Take)
+}
@@ -0,0 +1,100 @@
+package backtest
+
+import (
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// progressInterval is the least wall time between two progress reports.
+const progressInterval = 250 * time.Millisecond
+
+// Progress is a snapshot of a running backtest, handed to a ProgressFunc.
+type Progress struct {
+	Run     string
+	Bars    int64           // bars replayed so far
+	SimTime types.Timestamp // timestamp of the last bar replayed
+	From    types.Timestamp // run start, inclusive
+	To      types.Timestamp // run end, exclusive
+	Elapsed time.Duration   // wall time since the run started
+	Done    bool            // set on the final report only
+}
+
+// Fraction returns how much of the run's time range has been replayed,
+// from 0 to 1. Weekends and gaps count as time, so it moves in steps
+// rather than strictly linearly with bars.
+func (p Progress) Fraction() float64 {
+	if p.Done {
+		return 1
+	}
+	span := p.To - p.From
+	if span <= 0 || p.SimTime <= p.From {
+		return 0
+	}
+	return min(float64(p.SimTime-p.From)/float64(span), 1)
+}
+
+// ETA projects the wall time left from the rate so far. It is zero
+// until there is a rate to project from, and once the run is done.
+func (p Progress) ETA() time.Duration {
+	f := p.Fraction()
+	if f <= 0 || f >= 1 {
+		return 0
+	}
+	return time.Duration(float64(p.Elapsed) * (1 - f) / f)
+}
+
+// ProgressFunc receives progress reports from a running backtest: at
+// most one per progressInterval while bars are replayed, and a last one
+// with Done set when the run ends. It is called on the run's goroutine,
+// so it must not block.
+type ProgressFunc func(Progress)
+
+// progressReporter throttles a run's calls to its ProgressFunc.
+type progressReporter struct {
+	fn    ProgressFunc
+	p     Progress
+	start time.Time
+	last  time.Time
+	now   func() time.Time
+}
+
+func newProgressReporter(fn ProgressFunc, name string, tr types.TimeRange) *progressReporter {
+	if fn == nil {
+		return nil
+	}
+	start := time.Now()
+	return &progressReporter{
+		fn:    fn,
+		p:     Progress{Run: name, From: tr.Start, To: tr.End},
+		start: start,
+		last:  start,
+		now:   time.Now,
+	}
+}
+
+// bar records one replayed bar and reports when the interval has passed.
+func (r *progressReporter) bar(ts types.Timestamp) {
+	if r == nil {
+		return
+	}
+	r.p.Bars++
+	r.p.SimTime = ts
+	now := r.now()
+	if now.Sub(r.last) < progressInterval {
+		return
+	}
+	r.last = now
+	r.p.Elapsed = now.Sub(r.start)
+	r.fn(r.p)
+}
+
+// done sends the final report.
+func (r *progressReporter) done() {
+	if r == nil {
+		return
+	}
+	r.p.Elapsed = r.now().Sub(r.start)
+	r.p.Done = true
+	r.fn(r.p)
+}
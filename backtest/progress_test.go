@@ -0,0 +1,89 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestProgress_FractionAndETA(t *testing.T) {
+	p := Progress{From: 1000, To: 2000, SimTime: 1250, Elapsed: 10 * time.Second}
+	assert.InDelta(t, 0.25, p.Fraction(), 1e-9)
+	assert.Equal(t, 30*time.Second, p.ETA())
+
+	p.SimTime = 900
+	assert.Zero(t, p.Fraction())
+	assert.Zero(t, p.ETA(), "no rate to project from before the range starts")
+
+	p.Done = true
+	assert.Equal(t, 1.0, p.Fraction())
+	assert.Zero(t, p.ETA())
+}
+
+func TestProgressReporter_Throttles(t *testing.T) {
+	var got []Progress
+	r := newProgressReporter(func(p Progress) { got = append(got, p) }, "run", types.TimeRange{Start: 0, End: 100})
+	clock := r.start
+	r.now = func() time.Time { return clock }
+
+	for ts := types.Timestamp(1); ts <= 10; ts++ {
+		clock = clock.Add(100 * time.Millisecond)
+		r.bar(ts)
+	}
+	r.done()
+
+	// 1s of bars at one report per 250ms, plus the final report.
+	require.Len(t, got, 4)
+	assert.Equal(t, int64(3), got[0].Bars)
+	assert.Equal(t, types.Timestamp(3), got[0].SimTime)
+	last := got[len(got)-1]
+	assert.True(t, last.Done)
+	assert.Equal(t, int64(10), last.Bars)
+	assert.Equal(t, time.Second, last.Elapsed)
+
+	var nilReporter *progressReporter
+	assert.NotPanics(t, func() { nilReporter.bar(1); nilReporter.done() })
+}
+
+func TestRunWithIterator_ReportsFinalProgress(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var candles []market.Candle
+	for i := 0; i < 5; i++ {
+		candles = append(candles, market.Candle{
+			Open: 110000, High: 110050, Low: 109950, Close: 110000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	var reports []Progress
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Name:       "progress",
+			Instrument: "EURUSD",
+			Strategy:   alwaysLong{},
+			TimeRange:  types.TimeRange{Start: candles[0].Timestamp, End: candles[4].Timestamp, TF: types.H1},
+		},
+		State:    &BacktestRun{},
+		Progress: func(p Progress) { reports = append(reports, p) },
+	}
+	require.NoError(t, run.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	require.NotEmpty(t, reports)
+	last := reports[len(reports)-1]
+	assert.True(t, last.Done)
+	assert.Equal(t, "progress", last.Run)
+	assert.Equal(t, int64(5), last.Bars)
+	assert.Equal(t, candles[4].Timestamp, last.SimTime)
+}
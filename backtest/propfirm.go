@@ -0,0 +1,234 @@
+package backtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// Prop-firm rules named in PropFirmResult.Breach.
+const (
+	PropRuleDailyLoss = "daily-loss"
+	PropRuleMaxLoss   = "max-loss"
+)
+
+// PropFirmConfig evaluates every run the way a funded-account ("prop
+// firm") challenge would: a daily loss limit, an overall loss limit, a
+// profit target and a minimum number of trading days. Limits and target
+// are percentages of the starting balance. The evaluation is settled at
+// the first breach (fail) or when the target is reached after enough
+// trading days (pass); a run that ends undecided passes only if it had no
+// target. Zero values disable the corresponding rule.
+type PropFirmConfig struct {
+	DailyLossPct    float64 `json:"daily-loss-pct,omitempty"    yaml:"daily-loss-pct"`    // loss within one day, from the day's opening equity
+	MaxLossPct      float64 `json:"max-loss-pct,omitempty"      yaml:"max-loss-pct"`      // equity drop below the starting balance
+	ProfitTargetPct float64 `json:"profit-target-pct,omitempty" yaml:"profit-target-pct"` // closed balance gain that passes
+	MinTradingDays  int     `json:"min-trading-days,omitempty"  yaml:"min-trading-days"`  // days with at least one entry
+	// Timezone is the IANA zone whose midnight starts a trading day; empty
+	// means UTC.
+	Timezone string `json:"timezone,omitempty" yaml:"timezone"`
+	// EndOnVerdict ends the run once the evaluation is settled, as the
+	// challenge account would be.
+	EndOnVerdict bool `json:"end-on-verdict,omitempty" yaml:"end-on-verdict"`
+}
+
+// IsZero reports whether no prop-firm evaluation is configured.
+func (c PropFirmConfig) IsZero() bool {
+	return c == PropFirmConfig{}
+}
+
+// PropFirmRules is the compiled, fixed-point form of PropFirmConfig, with
+// the percentages resolved against the starting balance.
+type PropFirmRules struct {
+	DailyLoss      types.Money
+	MaxLoss        types.Money
+	ProfitTarget   types.Money
+	MinTradingDays int
+	Location       *time.Location
+	EndOnVerdict   bool
+}
+
+// IsZero reports whether no rule is set.
+func (r PropFirmRules) IsZero() bool {
+	return r.DailyLoss == 0 && r.MaxLoss == 0 && r.ProfitTarget == 0 && r.MinTradingDays == 0
+}
+
+// compilePropFirm validates cfg and converts it to PropFirmRules for an
+// account starting at balance.
+func compilePropFirm(cfg PropFirmConfig, balance types.Money) (PropFirmRules, error) {
+	for _, f := range []struct {
+		name string
+		pct  float64
+	}{
+		{"daily-loss-pct", cfg.DailyLossPct},
+		{"max-loss-pct", cfg.MaxLossPct},
+	} {
+		if f.pct < 0 || f.pct >= 100 {
+			return PropFirmRules{}, fmt.Errorf("%s must be in [0, 100), got %v", f.name, f.pct)
+		}
+	}
+	if cfg.ProfitTargetPct < 0 {
+		return PropFirmRules{}, fmt.Errorf("profit-target-pct must be >= 0, got %v", cfg.ProfitTargetPct)
+	}
+	if cfg.MinTradingDays < 0 {
+		return PropFirmRules{}, fmt.Errorf("min-trading-days must be >= 0, got %d", cfg.MinTradingDays)
+	}
+	rules := PropFirmRules{
+		MinTradingDays: cfg.MinTradingDays,
+		Location:       time.UTC,
+		EndOnVerdict:   cfg.EndOnVerdict,
+	}
+	for _, f := range []struct {
+		dst *types.Money
+		pct float64
+	}{
+		{&rules.DailyLoss, cfg.DailyLossPct},
+		{&rules.MaxLoss, cfg.MaxLossPct},
+		{&rules.ProfitTarget, cfg.ProfitTargetPct},
+	} {
+		v, err := types.MulDivFloor64(int64(balance), int64(types.RateFromFloat(f.pct/100)), int64(types.RateScale))
+		if err != nil {
+			return PropFirmRules{}, fmt.Errorf("prop-firm limits on balance %.2f: %w", balance.Float64(), err)
+		}
+		*f.dst = types.Money(v)
+	}
+	if rules.IsZero() {
+		if !cfg.IsZero() {
+			return PropFirmRules{}, fmt.Errorf("prop-firm needs at least one of daily-loss-pct, max-loss-pct, profit-target-pct, min-trading-days")
+		}
+		return PropFirmRules{}, nil
+	}
+	if name := strings.TrimSpace(cfg.Timezone); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return PropFirmRules{}, fmt.Errorf("bad prop-firm timezone %q: %w", cfg.Timezone, err)
+		}
+		rules.Location = loc
+	}
+	return rules, nil
+}
+
+// PropFirmResult is a run's prop-firm verdict.
+type PropFirmResult struct {
+	Rules  PropFirmRules
+	Passed bool
+	// Verdict says why the run passed or failed, e.g. "profit target
+	// reached" or "daily-loss limit 500.00 breached: lost 512.30".
+	Verdict string
+	// DecidedAt is when the evaluation was settled; zero when it was
+	// settled only by the end of the run.
+	DecidedAt types.Timestamp
+
+	Breach          string          // rule breached (PropRule*); empty if none
+	BreachedAt      types.Timestamp // bar the breach happened on
+	TargetReachedAt types.Timestamp // bar the closed balance first reached the target
+
+	TradingDays    int         // days with at least one entry, over the whole run
+	WorstDailyLoss types.Money // largest loss within one day, from the day's opening equity
+	WorstDay       string      // YYYY-MM-DD of WorstDailyLoss
+	MaxLoss        types.Money // deepest equity drop below the starting balance
+}
+
+// propFirm evaluates PropFirmRules bar by bar. It must see every bar's
+// equity, after fills, and every entry.
+type propFirm struct {
+	rules PropFirmRules
+	start types.Money
+	res   PropFirmResult
+
+	day      string      // current trading day, YYYY-MM-DD
+	dayOpen  types.Money // equity when the day began
+	last     types.Money // equity at the previous bar
+	tradedOn string      // last day with an entry
+}
+
+// newPropFirm returns nil when no rule is set.
+func newPropFirm(rules PropFirmRules, start types.Money) *propFirm {
+	if rules.IsZero() {
+		return nil
+	}
+	return &propFirm{rules: rules, start: start, last: start, res: PropFirmResult{Rules: rules}}
+}
+
+// dayOf returns the trading day of ts.
+func (p *propFirm) dayOf(ts types.Timestamp) string {
+	return ts.Time().In(p.rules.Location).Format(time.DateOnly)
+}
+
+// entered counts the day of ts as a trading day.
+func (p *propFirm) entered(ts types.Timestamp) {
+	if day := p.dayOf(ts); day != p.tradedOn {
+		p.tradedOn = day
+		p.res.TradingDays++
+	}
+}
+
+// observe takes the bar's equity and closed balance and reports whether
+// the evaluation was settled on this bar.
+func (p *propFirm) observe(ts types.Timestamp, equity, balance types.Money) bool {
+	if day := p.dayOf(ts); day != p.day {
+		p.day, p.dayOpen = day, p.last
+	}
+	p.last = equity
+
+	dailyLoss := p.dayOpen - equity
+	if dailyLoss > p.res.WorstDailyLoss {
+		p.res.WorstDailyLoss, p.res.WorstDay = dailyLoss, p.day
+	}
+	if loss := p.start - equity; loss > p.res.MaxLoss {
+		p.res.MaxLoss = loss
+	}
+
+	// A breach after the evaluation passed no longer counts.
+	if p.res.Breach == "" && !p.res.Passed {
+		switch {
+		case p.rules.DailyLoss > 0 && dailyLoss >= p.rules.DailyLoss:
+			p.breach(ts, PropRuleDailyLoss, p.rules.DailyLoss, dailyLoss)
+		case p.rules.MaxLoss > 0 && p.start-equity >= p.rules.MaxLoss:
+			p.breach(ts, PropRuleMaxLoss, p.rules.MaxLoss, p.start-equity)
+		}
+	}
+	if p.rules.ProfitTarget > 0 && p.res.TargetReachedAt == 0 && balance-p.start >= p.rules.ProfitTarget {
+		p.res.TargetReachedAt = ts
+	}
+	if p.res.DecidedAt != 0 {
+		return false
+	}
+	switch {
+	case p.res.Breach != "":
+		p.res.DecidedAt = ts
+	case p.res.TargetReachedAt != 0 && p.res.TradingDays >= p.rules.MinTradingDays:
+		p.res.Passed, p.res.Verdict, p.res.DecidedAt = true, "profit target reached", ts
+	default:
+		return false
+	}
+	return true
+}
+
+func (p *propFirm) breach(ts types.Timestamp, rule string, limit, loss types.Money) {
+	p.res.Breach, p.res.BreachedAt = rule, ts
+	p.res.Verdict = fmt.Sprintf("%s limit %.2f breached: lost %.2f", rule, limit.Float64(), loss.Float64())
+}
+
+// result settles an evaluation still open at the end of the run and
+// returns the verdict. It returns nil for a nil tracker.
+func (p *propFirm) result() *PropFirmResult {
+	if p == nil {
+		return nil
+	}
+	res := p.res
+	if res.DecidedAt != 0 {
+		return &res
+	}
+	switch {
+	case p.rules.ProfitTarget > 0 && res.TargetReachedAt == 0:
+		res.Verdict = "profit target not reached"
+	case res.TradingDays < p.rules.MinTradingDays:
+		res.Verdict = fmt.Sprintf("traded %d of %d required days", res.TradingDays, p.rules.MinTradingDays)
+	default:
+		res.Passed, res.Verdict = true, "no rule breached"
+	}
+	return &res
+}
@@ -0,0 +1,201 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestCompilePropFirm(t *testing.T) {
+	money := types.MoneyFromFloat
+	balance := money(100_000)
+
+	got, err := compilePropFirm(PropFirmConfig{}, balance)
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+
+	got, err = compilePropFirm(PropFirmConfig{DailyLossPct: 5, MaxLossPct: 10, ProfitTargetPct: 8, MinTradingDays: 4}, balance)
+	require.NoError(t, err)
+	assert.Equal(t, PropFirmRules{
+		DailyLoss: money(5_000), MaxLoss: money(10_000), ProfitTarget: money(8_000),
+		MinTradingDays: 4, Location: time.UTC,
+	}, got)
+
+	got, err = compilePropFirm(PropFirmConfig{MaxLossPct: 10, Timezone: "Europe/Prague", EndOnVerdict: true}, balance)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Prague", got.Location.String())
+	assert.True(t, got.EndOnVerdict)
+
+	for _, tt := range []struct {
+		cfg     PropFirmConfig
+		wantErr string
+	}{
+		{PropFirmConfig{DailyLossPct: 100}, "daily-loss-pct"},
+		{PropFirmConfig{MaxLossPct: -1}, "max-loss-pct"},
+		{PropFirmConfig{ProfitTargetPct: -1}, "profit-target-pct"},
+		{PropFirmConfig{MinTradingDays: -1}, "min-trading-days"},
+		{PropFirmConfig{EndOnVerdict: true}, "needs at least one"},
+		{PropFirmConfig{MaxLossPct: 10, Timezone: "Mars/Olympus"}, "bad prop-firm timezone"},
+	} {
+		_, err := compilePropFirm(tt.cfg, balance)
+		assert.ErrorContains(t, err, tt.wantErr)
+	}
+}
+
+// propDay is 2024-01-01 00:00 UTC, a Monday.
+const propDay = types.Timestamp(1704067200)
+
+func propRules() PropFirmRules {
+	money := types.MoneyFromFloat
+	return PropFirmRules{
+		DailyLoss: money(500), MaxLoss: money(1_000), ProfitTarget: money(800),
+		MinTradingDays: 2, Location: time.UTC,
+	}
+}
+
+func TestPropFirm_DailyLossMeasuredFromDayOpen(t *testing.T) {
+	money := types.MoneyFromFloat
+	p := newPropFirm(propRules(), money(10_000))
+
+	assert.False(t, p.observe(propDay, money(9_700), money(10_000)))
+	assert.False(t, p.observe(propDay+3600, money(9_600), money(10_000)), "400 down on the day")
+	// The next day opens at 9,600: another 450 is within its limit, even
+	// though the run is 850 down.
+	assert.False(t, p.observe(propDay+secondsPerDay, money(9_150), money(9_150)))
+	assert.True(t, p.observe(propDay+secondsPerDay+3600, money(9_100), money(9_100)), "500 down on the day")
+
+	res := p.result()
+	assert.False(t, res.Passed)
+	assert.Equal(t, PropRuleDailyLoss, res.Breach)
+	assert.Equal(t, propDay+secondsPerDay+3600, res.BreachedAt)
+	assert.Equal(t, res.BreachedAt, res.DecidedAt)
+	assert.Equal(t, "daily-loss limit 500.00 breached: lost 500.00", res.Verdict)
+	assert.Equal(t, money(500), res.WorstDailyLoss)
+	assert.Equal(t, "2024-01-02", res.WorstDay)
+	assert.Equal(t, money(900), res.MaxLoss)
+
+	assert.False(t, p.observe(propDay+2*secondsPerDay, money(12_000), money(12_000)), "settled evaluations stay settled")
+	assert.False(t, p.result().Passed)
+}
+
+func TestPropFirm_MaxLoss(t *testing.T) {
+	money := types.MoneyFromFloat
+	p := newPropFirm(propRules(), money(10_000))
+	for i, eq := range []float64{9_700, 9_400, 9_100} {
+		assert.False(t, p.observe(propDay+types.Timestamp(i)*secondsPerDay, money(eq), money(eq)))
+	}
+	assert.True(t, p.observe(propDay+3*secondsPerDay, money(9_000), money(9_000)))
+	assert.Equal(t, PropRuleMaxLoss, p.result().Breach)
+}
+
+func TestPropFirm_PassesOnTargetAfterMinDays(t *testing.T) {
+	money := types.MoneyFromFloat
+	p := newPropFirm(propRules(), money(10_000))
+
+	p.entered(propDay)
+	p.entered(propDay + 60)
+	assert.False(t, p.observe(propDay+3600, money(10_900), money(10_900)), "target reached on the first trading day")
+	assert.Equal(t, propDay+3600, p.res.TargetReachedAt)
+
+	p.entered(propDay + secondsPerDay)
+	assert.True(t, p.observe(propDay+secondsPerDay+3600, money(10_850), money(10_850)))
+	// A later breach no longer counts.
+	assert.False(t, p.observe(propDay+2*secondsPerDay, money(8_000), money(8_000)))
+
+	res := p.result()
+	assert.True(t, res.Passed)
+	assert.Equal(t, "profit target reached", res.Verdict)
+	assert.Equal(t, 2, res.TradingDays)
+	assert.Equal(t, propDay+secondsPerDay+3600, res.DecidedAt)
+	assert.Empty(t, res.Breach)
+}
+
+func TestPropFirm_UndecidedAtEnd(t *testing.T) {
+	money := types.MoneyFromFloat
+
+	p := newPropFirm(propRules(), money(10_000))
+	p.entered(propDay)
+	p.observe(propDay, money(10_100), money(10_100))
+	assert.Equal(t, "profit target not reached", p.result().Verdict)
+
+	rules := propRules()
+	rules.ProfitTarget = 0
+	p = newPropFirm(rules, money(10_000))
+	p.entered(propDay)
+	p.observe(propDay, money(10_100), money(10_100))
+	assert.Equal(t, "traded 1 of 2 required days", p.result().Verdict)
+	p.entered(propDay + secondsPerDay)
+	res := p.result()
+	assert.True(t, res.Passed)
+	assert.Equal(t, "no rule breached", res.Verdict)
+	assert.Zero(t, res.DecidedAt)
+
+	assert.Nil(t, newPropFirm(PropFirmRules{}, money(10_000)))
+	assert.Nil(t, (*propFirm)(nil).result())
+}
+
+func TestPropFirm_TradingDayTimezone(t *testing.T) {
+	rules := propRules()
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	rules.Location = loc
+	p := newPropFirm(rules, types.MoneyFromFloat(10_000))
+
+	// 01:00 and 23:00 UTC on Jan 1 are Dec 31 and Jan 1 in New York.
+	p.entered(propDay + 3600)
+	p.entered(propDay + 23*3600)
+	p.entered(propDay + 24*3600)
+	assert.Equal(t, 2, p.res.TradingDays)
+}
+
+func TestRunWithIterator_PropFirmVerdict(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        &countingStrategy{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: propDay, End: propDay + 3*3600, TF: types.H1},
+			PropFirm:        PropFirmRules{MinTradingDays: 1, Location: time.UTC},
+		},
+		State: &BacktestRun{},
+	}
+	candle := func(ts types.Timestamp) market.Candle {
+		return market.Candle{Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000, Timestamp: ts}
+	}
+	itr := &fixedCandleIterator{candles: []market.Candle{candle(propDay), candle(propDay + 3600)}}
+	require.NoError(t, run.runWithIterator(context.Background(), &engine.Trader{Account: acct}, itr))
+
+	res := run.BuildBacktestResult(acct)
+	require.NotNil(t, res.PropFirm)
+	assert.False(t, res.PropFirm.Passed)
+	assert.Equal(t, "traded 0 of 1 required days", res.PropFirm.Verdict)
+
+	run.Result = res
+	s := run.Summary()
+	require.NotNil(t, s.PropFirm)
+	assert.Equal(t, 1, s.PropFirm.MinTradingDays)
+	assert.Equal(t, "UTC", s.PropFirm.Timezone)
+}
+
+func TestPrintSummary_PropFirm(t *testing.T) {
+	var buf bytes.Buffer
+	PrintSummary(&buf, BacktestReportSummary{
+		Strategy: "ema-cross",
+		PropFirm: &BacktestReportPropFirm{
+			Verdict: "daily-loss limit 500.00 breached: lost 512.30", DecidedAt: "2024-01-02T01:00:00Z",
+			Breach: PropRuleDailyLoss, TradingDays: 3, WorstDailyLoss: 512.3, WorstDay: "2024-01-02", MaxLoss: 700,
+		},
+	})
+	assert.Contains(t, buf.String(), "Prop firm: FAIL, daily-loss limit 500.00 breached: lost 512.30 (2024-01-02T01:00:00Z)\n")
+	assert.Contains(t, buf.String(), "Trading days: 3   Worst day: 512.30 (2024-01-02)   Max loss: 700.00\n")
+}
@@ -3,7 +3,14 @@ package backtest
 import (
 	"fmt"
 	"io"
+	"slices"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
 )
 
 // BacktestReportSummary is a normalized machine-readable summary used for
@@ -23,9 +30,14 @@ type BacktestReportSummary struct {
 	Wins   int `json:"wins"`
 	Losses int `json:"losses"`
 
+	// Currency is the report currency the money figures were restated
+	// in; empty means they are in the account currency.
+	Currency     string  `json:"currency,omitempty"`
 	StartBalance float64 `json:"start_balance"`
 	EndBalance   float64 `json:"end_balance"`
 	NetPL        float64 `json:"net_pl"`
+	// Interest is the idle-balance interest credited, part of NetPL.
+	Interest float64 `json:"interest,omitempty"`
 
 	// Stored as human-friendly percentages, e.g. 12.34 means 12.34%
 	ReturnPct float64 `json:"return_pct"`
@@ -41,17 +53,88 @@ type BacktestReportSummary struct {
 	AvgSpreadPips  float64 `json:"avg_spread_pips"`
 	SpreadFiltered int     `json:"spread_filtered"`
 	RR             float64 `json:"rr"`
-	MaxDrawdown    float64 `json:"max_drawdown"` // largest peak-to-trough drop in dollars (negative)
+	ProfitFactor   float64 `json:"profit_factor,omitempty"`
+	Sharpe         float64 `json:"sharpe,omitempty"` // annualised, from daily equity returns
+	MaxDrawdown    float64 `json:"max_drawdown"`     // largest peak-to-trough drop in dollars (negative)
 	AvgWinner      float64 `json:"avg_winner"`
 	AvgLoser       float64 `json:"avg_loser"` // negative
 
+	// StopReason is set when the run ended early on a stop-on condition.
+	StopReason string `json:"stop_reason,omitempty"`
+	// Interrupted marks a partial report from a run cut short by Ctrl-C.
+	Interrupted bool `json:"interrupted,omitempty"`
+
+	// SliceExcluded counts the bars dropped by the data's calendar slice.
+	SliceExcluded int `json:"slice_excluded,omitempty"`
+	// WarmupBars counts the bars before the start preloaded into the
+	// strategy and indicators.
+	WarmupBars int `json:"warmup_bars,omitempty"`
+	// EquityCurveThrottled counts the entries sized at reduced risk while
+	// the run's equity curve was throttled.
+	EquityCurveThrottled int `json:"equity_curve_throttled,omitempty"`
+
+	// GovernorSkipped counts the entries the governor (or a paused equity
+	// curve throttle) refused, by rule.
+	GovernorSkipped map[string]int `json:"governor_skipped,omitempty"`
+	// FilterSkipped counts the entries the strategy's filter chain
+	// blocked, by filter name.
+	FilterSkipped map[string]int `json:"filter_skipped,omitempty"`
+	// OrderRejections counts the entries the order path refused, by reason
+	// code (planner gates, sizing, governor, warm-up).
+	OrderRejections map[string]int `json:"order_rejections,omitempty"`
+	// SpreadGuarded counts the entries the spread guard blocked, by rule:
+	// max-pips or avg-ratio.
+	SpreadGuarded map[string]int `json:"spread_guarded,omitempty"`
+
+	// Signals groups the entry signals by fate, taken or suppressed by a
+	// filter or the order path, with how far price went their way, when
+	// the run journals signals.
+	Signals []BacktestReportSignals `json:"signals,omitempty"`
+
+	// Exposure is the peak net long/short held per currency during the run.
+	Exposure []BacktestReportExposure `json:"exposure,omitempty"`
+
+	// Robustness is the spread of outcomes across the perturbed reruns,
+	// when robustness runs were configured.
+	Robustness *BacktestReportRobustness `json:"robustness,omitempty"`
+
+	// CrossValidation compares in-sample and out-of-sample outcomes over
+	// purged train/test splits, when cross-validation was configured.
+	CrossValidation *BacktestReportCrossValidation `json:"cross_validation,omitempty"`
+
+	// FillComparison sets the run beside its same-tick rerun, when its
+	// orders filled on a later tick.
+	FillComparison *BacktestReportFillComparison `json:"fill_comparison,omitempty"`
+
+	// Rolling is win rate, profit factor and drawdown over trailing
+	// windows, so a strategy that degrades over the run shows it.
+	Rolling []BacktestReportRolling `json:"rolling,omitempty"`
+
+	// ByTime buckets the trades by entry hour and weekday in the
+	// configured report timezone.
+	ByTime *BacktestReportByTime `json:"by_time,omitempty"`
+	// ByVolatility buckets the trades by the volatility regime they were
+	// entered in, calm to volatile; "unknown" holds the trades entered
+	// before the classifier was warm.
+	ByVolatility []BacktestReportBucket `json:"by_volatility,omitempty"`
+
+	// Benchmark compares the run with buy-and-hold or a returns series,
+	// when a benchmark was configured.
+	Benchmark *BacktestReportBenchmark `json:"benchmark,omitempty"`
+
+	// PropFirm is the run's pass/fail verdict against funded-account
+	// rules, when prop-firm rules were configured.
+	PropFirm *BacktestReportPropFirm `json:"prop_firm,omitempty"`
+
 	TradeDetails []BacktestReportTrade `json:"trade_details,omitempty"`
 
 	// Provenance links generated reports back to their origin. Older fixtures
 	// and manually constructed summaries may leave these fields empty.
-	ConfigHash  string    `json:"config_hash"`  // 8-char SHA256 prefix of the run config params
-	GeneratedAt string    `json:"generated_at"` // RFC3339 UTC timestamp of when the run completed
-	Config      RunConfig `json:"config"`       // full config snapshot that produced this result
+	ConfigHash  string    `json:"config_hash"`       // 8-char SHA256 prefix of the run config params
+	GeneratedAt string    `json:"generated_at"`      // RFC3339 UTC timestamp of when the run completed
+	Config      RunConfig `json:"config"`            // full config snapshot that produced this result
+	Rollover    string    `json:"rollover"`          // daily boundary for D1/H4 candles and swap, e.g. "17:00 America/New_York"
+	RunDir      string    `json:"run_dir,omitempty"` // the run's own directory, relative to the reports directory
 }
 
 // BacktestReportTrade is a JSON-serialisable record of a single closed trade
@@ -80,6 +163,457 @@ type BacktestReportTrade struct {
 	// strategy's "signalreplay:<date>" marker), used by analysis tooling to
 	// join a trade back to what opened it.
 	Reason string `json:"reason,omitempty"`
+	// VolRegime is the volatility regime (low, normal, high) the trade
+	// was entered in; empty when the classifier was still warming up.
+	VolRegime string `json:"vol_regime,omitempty"`
+
+	// InitialRisk is what InitialStopPrice would have lost in account
+	// currency; RMultiple is PNL in units of it. MAE/MFE are the worst and
+	// best open moves from OpenPrice, in price units.
+	InitialRisk float64 `json:"initial_risk,omitempty"`
+	RMultiple   float64 `json:"r_multiple,omitempty"`
+	MAE         float64 `json:"mae,omitempty"`
+	MFE         float64 `json:"mfe,omitempty"`
+
+	// QuotePNL is PNL in QuoteCurrency, the instrument's quote currency,
+	// before conversion; ConversionRate is the quote-to-account rate it was
+	// converted to PNL at when the trade closed.
+	QuoteCurrency  string  `json:"quote_currency,omitempty"`
+	QuotePNL       float64 `json:"quote_pnl,omitempty"`
+	ConversionRate float64 `json:"conversion_rate,omitempty"`
+}
+
+// TradeRecord converts a report trade back to the journal's fixed-point
+// form, so report trades can go through the same analysis as journaled
+// ones.
+func (t BacktestReportTrade) TradeRecord() journal.TradeRecord {
+	return journal.TradeRecord{
+		TradeID:     t.ID,
+		Instrument:  t.Instrument,
+		Units:       types.Units(t.Units),
+		EntryPrice:  types.PriceFromFloat(t.OpenPrice),
+		ExitPrice:   types.PriceFromFloat(t.ClosePrice),
+		RealizedPL:  types.MoneyFromFloat(t.PNL),
+		Reason:      t.Reason,
+		VolRegime:   t.VolRegime,
+		InitialStop: types.PriceFromFloat(t.InitialStopPrice),
+		InitialRisk: types.MoneyFromFloat(t.InitialRisk),
+		RMultiple:   types.RateFromFloat(t.RMultiple),
+		MAE:         types.PriceFromFloat(t.MAE),
+		MFE:         types.PriceFromFloat(t.MFE),
+
+		QuoteCurrency:  t.QuoteCurrency,
+		QuotePL:        types.MoneyFromFloat(t.QuotePNL),
+		ConversionRate: types.RateFromFloat(t.ConversionRate),
+	}
+}
+
+// BacktestReportExposure is the JSON form of one ExposurePeak, in units of
+// Currency.
+type BacktestReportExposure struct {
+	Currency string  `json:"currency"`
+	MaxLong  float64 `json:"max_long"`
+	MaxShort float64 `json:"max_short"` // negative
+}
+
+// BacktestReportRobustness is the JSON form of a RobustnessResult.
+type BacktestReportRobustness struct {
+	Runs        int                  `json:"runs"`
+	Seed        int64                `json:"seed"`
+	Profitable  int                  `json:"profitable"`
+	ReturnPct   BacktestReportSpread `json:"return_pct"` // percent, like ReturnPct above
+	NetPL       BacktestReportSpread `json:"net_pl"`
+	MaxDrawdown BacktestReportSpread `json:"max_drawdown"` // negative
+	Trades      BacktestReportSpread `json:"trades"`
+}
+
+// BacktestReportCrossValidation is the JSON form of a
+// CrossValidationResult.
+type BacktestReportCrossValidation struct {
+	Folds       int                        `json:"folds"`
+	TestFolds   int                        `json:"test_folds"`
+	PurgeBars   int                        `json:"purge_bars,omitempty"`
+	EmbargoBars int                        `json:"embargo_bars,omitempty"`
+	Paths       int                        `json:"paths"`
+	InSample    BacktestReportOutcomes     `json:"in_sample"`
+	OutOfSample BacktestReportOutcomes     `json:"out_of_sample"`
+	Splits      []BacktestReportCrossSplit `json:"splits"`
+}
+
+// BacktestReportFillComparison is the JSON form of a FillComparison.
+type BacktestReportFillComparison struct {
+	Fill              string  `json:"fill"` // e.g. "next-tick"
+	LatencyMs         int64   `json:"latency_ms,omitempty"`
+	NetPL             float64 `json:"net_pl"`
+	SameTickNetPL     float64 `json:"same_tick_net_pl"`
+	NetPLDiff         float64 `json:"net_pl_diff"`          // NetPL - SameTickNetPL
+	ReturnPct         float64 `json:"return_pct"`           // percent, like ReturnPct above
+	SameTickReturnPct float64 `json:"same_tick_return_pct"` // percent
+	Trades            int     `json:"trades"`
+	SameTickTrades    int     `json:"same_tick_trades"`
+}
+
+// BacktestReportOutcomes is the JSON form of a CrossValidationStats.
+type BacktestReportOutcomes struct {
+	Runs        int                  `json:"runs"`
+	Profitable  int                  `json:"profitable"`
+	ReturnPct   BacktestReportSpread `json:"return_pct"` // percent, like ReturnPct above
+	NetPL       BacktestReportSpread `json:"net_pl"`
+	MaxDrawdown BacktestReportSpread `json:"max_drawdown"` // negative
+	Trades      BacktestReportSpread `json:"trades"`
+}
+
+// BacktestReportCrossSplit is one cross-validation split's outcome.
+type BacktestReportCrossSplit struct {
+	TestFolds      string  `json:"test_folds"` // 1-based, e.g. "2+5"
+	InSampleReturn float64 `json:"in_sample_return_pct"`
+	InSampleTrades int     `json:"in_sample_trades"`
+	Return         float64 `json:"out_of_sample_return_pct"`
+	NetPL          float64 `json:"out_of_sample_net_pl"`
+	Trades         int     `json:"out_of_sample_trades"`
+}
+
+// BacktestReportSpread is the min/median/max of one metric across runs.
+type BacktestReportSpread struct {
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Max    float64 `json:"max"`
+}
+
+// BacktestReportRolling is the JSON form of a RollingSeries.
+type BacktestReportRolling struct {
+	Days   int                          `json:"days"`
+	Points []BacktestReportRollingPoint `json:"points"`
+}
+
+// BacktestReportRollingPoint is the JSON form of a RollingPoint. WinRate is
+// a percentage, like WinRate above.
+type BacktestReportRollingPoint struct {
+	Date         string  `json:"date"`
+	Trades       int     `json:"trades"`
+	WinRate      float64 `json:"win_rate"`
+	ProfitFactor float64 `json:"profit_factor"`
+	MaxDrawdown  float64 `json:"max_drawdown"` // negative
+}
+
+// rollingSummary converts rolling series for the report.
+func rollingSummary(series []RollingSeries) []BacktestReportRolling {
+	if len(series) == 0 {
+		return nil
+	}
+	out := make([]BacktestReportRolling, 0, len(series))
+	for _, s := range series {
+		r := BacktestReportRolling{Days: s.Days, Points: make([]BacktestReportRollingPoint, 0, len(s.Points))}
+		for _, p := range s.Points {
+			r.Points = append(r.Points, BacktestReportRollingPoint{
+				Date:         p.Day.Time().Format("2006-01-02"),
+				Trades:       p.Trades,
+				WinRate:      p.WinRate.Float64() * 100,
+				ProfitFactor: p.ProfitFactor.Float64(),
+				MaxDrawdown:  p.MaxDrawdown.Float64(),
+			})
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// BacktestReportByTime is the JSON form of a TimeBreakdown. Buckets without
+// trades are left out.
+type BacktestReportByTime struct {
+	Timezone  string                 `json:"timezone"`
+	ByHour    []BacktestReportBucket `json:"by_hour"`
+	ByWeekday []BacktestReportBucket `json:"by_weekday"` // Monday first
+}
+
+// BacktestReportBucket is the JSON form of one TimeBucket. Label is "00"
+// to "23" for hours and "Mon" to "Sun" for weekdays; WinRate is a
+// percentage.
+type BacktestReportBucket struct {
+	Label   string  `json:"label"`
+	Trades  int     `json:"trades"`
+	Wins    int     `json:"wins"`
+	WinRate float64 `json:"win_rate"`
+	PNL     float64 `json:"pnl"`
+}
+
+// Report converts b for BacktestReportSummary.ByTime. It returns nil for a
+// nil b.
+func (b *TimeBreakdown) Report() *BacktestReportByTime {
+	if b == nil {
+		return nil
+	}
+	bucket := func(label string, tb TimeBucket) BacktestReportBucket {
+		out := BacktestReportBucket{Label: label, Trades: tb.Trades, Wins: tb.Wins, PNL: tb.PNL.Float64()}
+		if tb.Trades > 0 {
+			out.WinRate = float64(tb.Wins) / float64(tb.Trades) * 100
+		}
+		return out
+	}
+	out := &BacktestReportByTime{Timezone: b.Location.String()}
+	for h, tb := range b.ByHour {
+		if tb.Trades > 0 {
+			out.ByHour = append(out.ByHour, bucket(fmt.Sprintf("%02d", h), tb))
+		}
+	}
+	for i := range 7 {
+		day := time.Weekday((i + 1) % 7)
+		if tb := b.ByWeekday[day]; tb.Trades > 0 {
+			out.ByWeekday = append(out.ByWeekday, bucket(day.String()[:3], tb))
+		}
+	}
+	return out
+}
+
+// volatilityReport converts a by-regime breakdown for
+// BacktestReportSummary.ByVolatility, in strategy.VolatilityRegimes order.
+func volatilityReport(by map[string]TimeBucket) []BacktestReportBucket {
+	var out []BacktestReportBucket
+	for _, regime := range append(slices.Clone(strategy.VolatilityRegimes), "") {
+		tb, ok := by[regime]
+		if !ok {
+			continue
+		}
+		label := regime
+		if label == "" {
+			label = "unknown"
+		}
+		b := BacktestReportBucket{Label: label, Trades: tb.Trades, Wins: tb.Wins, PNL: tb.PNL.Float64()}
+		if tb.Trades > 0 {
+			b.WinRate = float64(tb.Wins) / float64(tb.Trades) * 100
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// BacktestReportSignals is the JSON form of a SignalGroup. AvgMaxR is in
+// multiples of the stop distance.
+type BacktestReportSignals struct {
+	Gate      string  `json:"gate,omitempty"`
+	Rule      string  `json:"rule,omitempty"`
+	Signals   int     `json:"signals"`
+	Reached1R int     `json:"reached_1r"`
+	Stopped   int     `json:"stopped"`
+	AvgMaxR   float64 `json:"avg_max_r"`
+}
+
+// label names the group's fate for the text report.
+func (s BacktestReportSignals) label() string {
+	if s.Gate == "" {
+		return "taken"
+	}
+	return s.Gate + " " + s.Rule
+}
+
+// signalsReport converts the signal groups for the report.
+func signalsReport(gs []SignalGroup) []BacktestReportSignals {
+	var out []BacktestReportSignals
+	for _, g := range gs {
+		out = append(out, BacktestReportSignals{
+			Gate: g.Gate, Rule: g.Rule, Signals: g.Signals, Reached1R: g.Reached1R,
+			Stopped: g.Stopped, AvgMaxR: g.AvgMaxR.Float64(),
+		})
+	}
+	return out
+}
+
+// BacktestReportBenchmark is the JSON form of a BenchmarkResult. Returns
+// and alpha are percentages, like ReturnPct above.
+type BacktestReportBenchmark struct {
+	Name              string                   `json:"name"`
+	RiskFreePct       float64                  `json:"risk_free_pct"`
+	Days              int                      `json:"days"`
+	ReturnPct         float64                  `json:"return_pct"`
+	StrategyReturnPct float64                  `json:"strategy_return_pct"`
+	AlphaPct          float64                  `json:"alpha_pct"` // annualised
+	Beta              float64                  `json:"beta"`
+	InformationRatio  float64                  `json:"information_ratio"` // annualised
+	Curve             []BacktestReportRelative `json:"curve,omitempty"`
+}
+
+// BacktestReportRelative is one day of the relative equity curve.
+type BacktestReportRelative struct {
+	Date      string  `json:"date"`
+	Strategy  float64 `json:"strategy"`
+	Benchmark float64 `json:"benchmark"`
+	Relative  float64 `json:"relative"`
+}
+
+// Report converts b for BacktestReportSummary.Benchmark. It returns nil
+// for a nil b.
+func (b *BenchmarkResult) Report() *BacktestReportBenchmark {
+	if b == nil {
+		return nil
+	}
+	out := &BacktestReportBenchmark{
+		Name:              b.Name,
+		RiskFreePct:       b.RiskFree.Float64() * 100,
+		Days:              b.Days,
+		ReturnPct:         b.Return.Float64() * 100,
+		StrategyReturnPct: b.StrategyReturn.Float64() * 100,
+		AlphaPct:          b.Alpha.Float64() * 100,
+		Beta:              b.Beta.Float64(),
+		InformationRatio:  b.InformationRatio.Float64(),
+	}
+	for _, p := range b.Curve {
+		out.Curve = append(out.Curve, BacktestReportRelative{
+			Date:      p.Day.Time().Format("2006-01-02"),
+			Strategy:  p.Strategy.Float64(),
+			Benchmark: p.Benchmark.Float64(),
+			Relative:  p.Relative.Float64(),
+		})
+	}
+	return out
+}
+
+// BacktestReportPropFirm is the JSON form of a PropFirmResult. Limits and
+// losses are in account currency; losses are positive.
+type BacktestReportPropFirm struct {
+	Passed          bool    `json:"passed"`
+	Verdict         string  `json:"verdict"`
+	DecidedAt       string  `json:"decided_at,omitempty"`
+	Breach          string  `json:"breach,omitempty"`
+	BreachedAt      string  `json:"breached_at,omitempty"`
+	TargetReachedAt string  `json:"target_reached_at,omitempty"`
+	TradingDays     int     `json:"trading_days"`
+	WorstDailyLoss  float64 `json:"worst_daily_loss"`
+	WorstDay        string  `json:"worst_day,omitempty"`
+	MaxLoss         float64 `json:"max_loss"`
+
+	DailyLossLimit float64 `json:"daily_loss_limit,omitempty"`
+	MaxLossLimit   float64 `json:"max_loss_limit,omitempty"`
+	ProfitTarget   float64 `json:"profit_target,omitempty"`
+	MinTradingDays int     `json:"min_trading_days,omitempty"`
+	Timezone       string  `json:"timezone,omitempty"`
+}
+
+// worstDay formats the worst daily loss with its date.
+func (pf *BacktestReportPropFirm) worstDay() string {
+	if pf.WorstDay == "" {
+		return fmt.Sprintf("%.2f", pf.WorstDailyLoss)
+	}
+	return fmt.Sprintf("%.2f (%s)", pf.WorstDailyLoss, pf.WorstDay)
+}
+
+// Report converts p for BacktestReportSummary.PropFirm. It returns nil
+// for a nil p.
+func (p *PropFirmResult) Report() *BacktestReportPropFirm {
+	if p == nil {
+		return nil
+	}
+	out := &BacktestReportPropFirm{
+		Passed:          p.Passed,
+		Verdict:         p.Verdict,
+		DecidedAt:       formatBacktestSummaryTime(p.DecidedAt),
+		Breach:          p.Breach,
+		BreachedAt:      formatBacktestSummaryTime(p.BreachedAt),
+		TargetReachedAt: formatBacktestSummaryTime(p.TargetReachedAt),
+		TradingDays:     p.TradingDays,
+		WorstDailyLoss:  p.WorstDailyLoss.Float64(),
+		WorstDay:        p.WorstDay,
+		MaxLoss:         p.MaxLoss.Float64(),
+		DailyLossLimit:  p.Rules.DailyLoss.Float64(),
+		MaxLossLimit:    p.Rules.MaxLoss.Float64(),
+		ProfitTarget:    p.Rules.ProfitTarget.Float64(),
+		MinTradingDays:  p.Rules.MinTradingDays,
+	}
+	if p.Rules.Location != nil {
+		out.Timezone = p.Rules.Location.String()
+	}
+	return out
+}
+
+// Report converts r for BacktestReportSummary.Robustness. It returns nil
+// for a nil r.
+func (r *RobustnessResult) Report() *BacktestReportRobustness {
+	if r == nil {
+		return nil
+	}
+	return &BacktestReportRobustness{
+		Runs:        r.Runs,
+		Seed:        r.Seed,
+		Profitable:  r.Profitable,
+		ReturnPct:   percentSpread(r.ReturnPct),
+		NetPL:       moneySpread(r.NetPL),
+		MaxDrawdown: moneySpread(r.MaxDrawdown),
+		Trades:      countSpread(r.Trades),
+	}
+}
+
+func percentSpread(s RobustnessSpread[types.Rate]) BacktestReportSpread {
+	return BacktestReportSpread{Min: s.Min.Float64() * 100, Median: s.Median.Float64() * 100, Max: s.Max.Float64() * 100}
+}
+
+func moneySpread(s RobustnessSpread[types.Money]) BacktestReportSpread {
+	return BacktestReportSpread{Min: s.Min.Float64(), Median: s.Median.Float64(), Max: s.Max.Float64()}
+}
+
+func countSpread(s RobustnessSpread[int]) BacktestReportSpread {
+	return BacktestReportSpread{Min: float64(s.Min), Median: float64(s.Median), Max: float64(s.Max)}
+}
+
+// Report converts f for BacktestReportSummary.FillComparison. It returns
+// nil for a nil f.
+func (f *FillComparison) Report() *BacktestReportFillComparison {
+	if f == nil {
+		return nil
+	}
+	fill := FillSameTick
+	if f.Model.NextTick {
+		fill = FillNextTick
+	}
+	return &BacktestReportFillComparison{
+		Fill:              fill,
+		LatencyMs:         f.Model.Latency.Milliseconds(),
+		NetPL:             f.NetPL.Float64(),
+		SameTickNetPL:     f.SameTickNetPL.Float64(),
+		NetPLDiff:         (f.NetPL - f.SameTickNetPL).Float64(),
+		ReturnPct:         f.ReturnPct.Float64() * 100,
+		SameTickReturnPct: f.SameTickReturnPct.Float64() * 100,
+		Trades:            f.Trades,
+		SameTickTrades:    f.SameTickTrades,
+	}
+}
+
+// Report converts r for BacktestReportSummary.CrossValidation. It
+// returns nil for a nil r.
+func (r *CrossValidationResult) Report() *BacktestReportCrossValidation {
+	if r == nil {
+		return nil
+	}
+	out := &BacktestReportCrossValidation{
+		Folds:       r.Plan.Folds,
+		TestFolds:   r.Plan.TestFolds,
+		PurgeBars:   r.Plan.PurgeBars,
+		EmbargoBars: r.Plan.EmbargoBars,
+		Paths:       r.Plan.Paths(),
+		InSample:    r.InSample.report(),
+		OutOfSample: r.OutOfSample.report(),
+		Splits:      make([]BacktestReportCrossSplit, 0, len(r.Splits)),
+	}
+	for _, sp := range r.Splits {
+		out.Splits = append(out.Splits, BacktestReportCrossSplit{
+			TestFolds:      foldLabel(sp.TestFolds),
+			InSampleReturn: sp.InSample.ReturnPct.Float64() * 100,
+			InSampleTrades: sp.InSample.Trades,
+			Return:         sp.OutOfSample.ReturnPct.Float64() * 100,
+			NetPL:          sp.OutOfSample.NetPL.Float64(),
+			Trades:         sp.OutOfSample.Trades,
+		})
+	}
+	return out
+}
+
+func (s CrossValidationStats) report() BacktestReportOutcomes {
+	return BacktestReportOutcomes{
+		Runs:        s.Runs,
+		Profitable:  s.Profitable,
+		ReturnPct:   percentSpread(s.ReturnPct),
+		NetPL:       moneySpread(s.NetPL),
+		MaxDrawdown: moneySpread(s.MaxDrawdown),
+		Trades:      countSpread(s.Trades),
+	}
 }
 
 // PrintSummary writes a human-readable backtest report to w.
@@ -108,6 +642,7 @@ func PrintSummary(w io.Writer, s BacktestReportSummary) {
 	if absRetPct < 0 {
 		absRetPct = -absRetPct
 	}
+	cur := currencySymbol(s.Currency)
 
 	stopStr := s.Stop
 	if stopStr == "" {
@@ -123,16 +658,19 @@ func PrintSummary(w io.Writer, s BacktestReportSummary) {
 	fmt.Fprintf(w, "  %s %s  %s → %s\n", s.Instrument, strings.ToUpper(s.Timeframe), start, end)
 	ddStr := "—"
 	if s.MaxDrawdown < 0 {
-		ddStr = fmt.Sprintf("-$%.2f", -s.MaxDrawdown)
+		ddStr = fmt.Sprintf("-%s%.2f", cur, -s.MaxDrawdown)
 	}
 
 	fmt.Fprintln(w, bar)
 	fmt.Fprintf(w, "  Trades : %d   Wins: %d (%.1f%%)   Losses: %d\n",
 		s.Trades, s.Wins, s.WinRate, s.Losses)
-	fmt.Fprintf(w, "  Balance: $%.2f → $%.2f   (%s$%.2f / %s%.2f%%)\n",
-		s.StartBalance, s.EndBalance, sign, absNetPL, sign, absRetPct)
-	fmt.Fprintf(w, "  Drawdown: %s   Avg W: $%.2f   Avg L: $%.2f\n",
-		ddStr, s.AvgWinner, s.AvgLoser)
+	fmt.Fprintf(w, "  Balance: %s%.2f → %s%.2f   (%s%s%.2f / %s%.2f%%)\n",
+		cur, s.StartBalance, cur, s.EndBalance, sign, cur, absNetPL, sign, absRetPct)
+	fmt.Fprintf(w, "  Drawdown: %s   Avg W: %s%.2f   Avg L: %s%.2f\n",
+		ddStr, cur, s.AvgWinner, cur, s.AvgLoser)
+	if s.Interest != 0 {
+		fmt.Fprintf(w, "  Interest: %s%.2f on idle balance (in the P/L above)\n", cur, s.Interest)
+	}
 	regimeStr := ""
 	if s.Regime != "" {
 		regimeStr = fmt.Sprintf("   Regime: %s", s.Regime)
@@ -154,5 +692,103 @@ func PrintSummary(w io.Writer, s BacktestReportSummary) {
 		}
 		fmt.Fprintf(w, "  AvgSpread: %.2fp%s%s\n", s.AvgSpreadPips, slipStr, filtStr)
 	}
+	if len(s.Exposure) > 0 {
+		parts := make([]string, 0, len(s.Exposure))
+		for _, e := range s.Exposure {
+			parts = append(parts, fmt.Sprintf("%s +%.0f/%.0f", e.Currency, e.MaxLong, e.MaxShort))
+		}
+		fmt.Fprintf(w, "  Exposure: %s\n", strings.Join(parts, "   "))
+	}
+	if s.Interrupted {
+		fmt.Fprintln(w, "  Interrupted: partial results")
+	} else if s.StopReason != "" {
+		fmt.Fprintf(w, "  Stopped early: %s\n", s.StopReason)
+	}
+	if s.SliceExcluded > 0 {
+		fmt.Fprintf(w, "  Calendar slice: %d bars excluded\n", s.SliceExcluded)
+	}
+	if s.WarmupBars > 0 {
+		fmt.Fprintf(w, "  Warm-up: %d bars preloaded before the start\n", s.WarmupBars)
+	}
+	if s.EquityCurveThrottled > 0 {
+		fmt.Fprintf(w, "  Equity curve: %d entries at reduced risk\n", s.EquityCurveThrottled)
+	}
+	if len(s.GovernorSkipped) > 0 {
+		fmt.Fprintf(w, "  Governor skipped: %s\n", joinCounts(s.GovernorSkipped))
+	}
+	if len(s.FilterSkipped) > 0 {
+		fmt.Fprintf(w, "  Filters skipped: %s\n", joinCounts(s.FilterSkipped))
+	}
+	if len(s.OrderRejections) > 0 {
+		fmt.Fprintf(w, "  Orders rejected: %s\n", joinCounts(s.OrderRejections))
+	}
+	if len(s.SpreadGuarded) > 0 {
+		fmt.Fprintf(w, "  Spread guard blocked: %s\n", joinCounts(s.SpreadGuarded))
+	}
+	if len(s.Signals) > 0 {
+		fmt.Fprintln(w, "  Signals (best move before the stop, in R):")
+		for _, g := range s.Signals {
+			fmt.Fprintf(w, "    %-24s %5d   reached 1R: %d   stopped: %d   avg: %.2fR\n",
+				g.label(), g.Signals, g.Reached1R, g.Stopped, g.AvgMaxR)
+		}
+	}
+	if r := s.Robustness; r != nil {
+		fmt.Fprintf(w, "  Robustness: %d runs (seed %d), %d profitable\n", r.Runs, r.Seed, r.Profitable)
+		fmt.Fprintf(w, "    min/med/max  Return: %+.2f%% / %+.2f%% / %+.2f%%   DD: %.2f / %.2f / %.2f\n",
+			r.ReturnPct.Min, r.ReturnPct.Median, r.ReturnPct.Max,
+			r.MaxDrawdown.Min, r.MaxDrawdown.Median, r.MaxDrawdown.Max)
+	}
+	if cv := s.CrossValidation; cv != nil {
+		fmt.Fprintf(w, "  Cross-validation: %d folds, %d test, %d splits, %d paths\n",
+			cv.Folds, cv.TestFolds, len(cv.Splits), cv.Paths)
+		fmt.Fprintf(w, "    OOS  Return: %+.2f%% / %+.2f%% / %+.2f%%   %d/%d profitable   IS median %+.2f%%\n",
+			cv.OutOfSample.ReturnPct.Min, cv.OutOfSample.ReturnPct.Median, cv.OutOfSample.ReturnPct.Max,
+			cv.OutOfSample.Profitable, cv.OutOfSample.Runs, cv.InSample.ReturnPct.Median)
+	}
+	if f := s.FillComparison; f != nil {
+		fmt.Fprintf(w, "  Fills: %s   Net P/L %.2f vs %.2f same-tick (%+.2f)   Trades %d vs %d\n",
+			f.label(), f.NetPL, f.SameTickNetPL, f.NetPLDiff, f.Trades, f.SameTickTrades)
+	}
+	if b := s.Benchmark; b != nil {
+		fmt.Fprintf(w, "  Benchmark: %s %+.2f%%   Strategy: %+.2f%%   (%d days, rf %.2f%%)\n",
+			b.Name, b.ReturnPct, b.StrategyReturnPct, b.Days, b.RiskFreePct)
+		fmt.Fprintf(w, "    Alpha: %+.2f%%/yr   Beta: %.2f   IR: %.2f\n",
+			b.AlphaPct, b.Beta, b.InformationRatio)
+	}
+	if pf := s.PropFirm; pf != nil {
+		verdict := "FAIL"
+		if pf.Passed {
+			verdict = "PASS"
+		}
+		fmt.Fprintf(w, "  Prop firm: %s, %s", verdict, pf.Verdict)
+		if pf.DecidedAt != "" {
+			fmt.Fprintf(w, " (%s)", pf.DecidedAt)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "    Trading days: %d   Worst day: %s   Max loss: %.2f\n",
+			pf.TradingDays, pf.worstDay(), pf.MaxLoss)
+	}
 	fmt.Fprintln(w, bar)
 }
+
+// label names the fill model, with its latency when it has one.
+func (f *BacktestReportFillComparison) label() string {
+	if f.LatencyMs > 0 {
+		return fmt.Sprintf("%s +%dms", f.Fill, f.LatencyMs)
+	}
+	return f.Fill
+}
+
+// joinCounts formats counts as "key n" pairs sorted by key.
+func joinCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s %d", k, counts[k]))
+	}
+	return strings.Join(parts, "   ")
+}
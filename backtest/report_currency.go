@@ -0,0 +1,247 @@
+package backtest
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// ReportCurrencyPlan is the compiled form of ReportConfig's currency
+// settings. A zero Currency reports in the account currency.
+type ReportCurrencyPlan struct {
+	Currency string
+	// Rates is the rates file, by day; nil converts with the candles of
+	// the currency pair from the run's data source.
+	Rates     []conversionRate
+	RatesName string // label for reports
+}
+
+// conversionRate is how much of the report currency one unit of the
+// account currency bought on Day.
+type conversionRate struct {
+	Day  types.Timestamp // midnight UTC
+	Rate types.Rate
+}
+
+// compileReportCurrency validates cfg's currency and reads its rates file.
+func compileReportCurrency(cfg ReportConfig) (ReportCurrencyPlan, error) {
+	ccy := strings.ToUpper(strings.TrimSpace(cfg.Currency))
+	if ccy == "" {
+		if cfg.Rates != "" {
+			return ReportCurrencyPlan{}, fmt.Errorf("report rates need a report currency")
+		}
+		return ReportCurrencyPlan{}, nil
+	}
+	if len(ccy) != 3 {
+		return ReportCurrencyPlan{}, fmt.Errorf("bad report currency %q: want a 3-letter code such as EUR", cfg.Currency)
+	}
+	plan := ReportCurrencyPlan{Currency: ccy}
+	if cfg.Rates != "" {
+		rates, err := readConversionRates(cfg.Rates)
+		if err != nil {
+			return ReportCurrencyPlan{}, err
+		}
+		plan.Rates = rates
+		plan.RatesName = filepath.Base(cfg.Rates)
+	}
+	return plan, nil
+}
+
+// readConversionRates reads a "date,rate" CSV, oldest first after sorting.
+// A first row whose rate does not parse is taken as a header.
+func readConversionRates(path string) ([]conversionRate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open report rates %q: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	var out []conversionRate
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read report rates %q: %w", path, err)
+		}
+		rate, perr := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		if perr != nil && line == 1 {
+			continue
+		}
+		if perr != nil || math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+			return nil, fmt.Errorf("report rates %q line %d: bad rate %q", path, line, rec[1])
+		}
+		day, err := time.Parse("2006-01-02", strings.TrimSpace(rec[0]))
+		if err != nil {
+			return nil, fmt.Errorf("report rates %q line %d: bad date %q", path, line, rec[0])
+		}
+		out = append(out, conversionRate{Day: types.FromTime(day), Rate: types.RateFromFloat(rate)})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("report rates %q has no rates", path)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Day < out[j].Day })
+	return out, nil
+}
+
+// conversionSeries converts account-currency money into the report
+// currency at the rate of the day it was booked.
+type conversionSeries struct {
+	From, To string
+	Source   string // where the rates came from: a file name or an instrument
+	rates    []conversionRate
+}
+
+// at returns the rate in force at ts: the latest day at or before it, or
+// the first day for times before the series starts.
+func (c *conversionSeries) at(ts types.Timestamp) types.Rate {
+	i := sort.Search(len(c.rates), func(i int) bool { return c.rates[i].Day > ts })
+	if i == 0 {
+		return c.rates[0].Rate
+	}
+	return c.rates[i-1].Rate
+}
+
+// money converts m at the rate in force at ts.
+func (c *conversionSeries) money(m types.Money, ts types.Timestamp) types.Money {
+	return convertMoney(m, c.at(ts))
+}
+
+func convertMoney(m types.Money, rate types.Rate) types.Money {
+	v, err := types.SignedMulDivRound(int64(m), int64(rate), int64(types.RateScale))
+	if err != nil {
+		return m
+	}
+	return types.Money(v)
+}
+
+// loadReportCurrency builds the run's conversion series from the plan:
+// nil when the report is in the account currency. Without a rates file
+// it loads the run's bars of the pair joining the two currencies, e.g.
+// EURUSD to report a USD account in EUR, and keeps each day's last close.
+func (run *Backtest) loadReportCurrency(ctx context.Context, dm engine.CandleSource, source, accountCcy string) (*conversionSeries, error) {
+	plan := run.Request.ReportCurrency
+	if plan.Currency == "" || plan.Currency == accountCcy {
+		return nil, nil
+	}
+	if plan.Rates != nil {
+		return &conversionSeries{From: accountCcy, To: plan.Currency, Source: plan.RatesName, rates: plan.Rates}, nil
+	}
+
+	inst, invert := market.GetInstrument(accountCcy+plan.Currency), false
+	if inst == nil {
+		inst, invert = market.GetInstrument(plan.Currency+accountCcy), true
+	}
+	if inst == nil {
+		return nil, fmt.Errorf("report currency %s: no %s/%s instrument to convert with; give report rates", plan.Currency, accountCcy, plan.Currency)
+	}
+	itr, err := dm.Candles(ctx, datamanager.CandleRequest{Source: source, Instrument: inst.Name, Range: run.Request.TimeRange})
+	if err != nil {
+		return nil, fmt.Errorf("report currency %s: load %s: %w", plan.Currency, inst.Name, err)
+	}
+	defer itr.Close()
+
+	conv := &conversionSeries{From: accountCcy, To: plan.Currency, Source: inst.Name}
+	for {
+		c, ok := itr.Next()
+		if !ok {
+			break
+		}
+		if c.Close <= 0 {
+			continue
+		}
+		rate := types.Rate(int64(c.Close) * int64(types.RateScale) / int64(types.PriceScale))
+		if invert {
+			rate = types.Rate(int64(types.RateScale) * int64(types.PriceScale) / int64(c.Close))
+		}
+		day := c.Timestamp - c.Timestamp%secondsPerDay
+		if n := len(conv.rates); n > 0 && conv.rates[n-1].Day == day {
+			conv.rates[n-1].Rate = rate
+			continue
+		}
+		conv.rates = append(conv.rates, conversionRate{Day: day, Rate: rate})
+	}
+	if err := itr.Err(); err != nil {
+		return nil, fmt.Errorf("report currency %s: load %s: %w", plan.Currency, inst.Name, err)
+	}
+	if len(conv.rates) == 0 {
+		return nil, fmt.Errorf("report currency %s: no %s data over the run", plan.Currency, inst.Name)
+	}
+	return conv, nil
+}
+
+// convertedRun is a run's money restated in the report currency.
+type convertedRun struct {
+	trades                 []*account.Trade
+	start, balance, equity types.Money
+	points                 []equityPoint
+}
+
+// convertRun restates a run that started with start and ended with balance
+// and equity as if the account had been kept in the report currency: the
+// start at the first day's rate, each trade's P/L at the rate of the day it
+// closed, and everything else (swap, open P/L) at the last day's rate.
+// Moves in the exchange rate itself are therefore not P/L. Each daily
+// equity sample is the start plus the P/L to date at that day's rate.
+func (c *conversionSeries) convertRun(trades []*account.Trade, start, balance, equity types.Money, points []equityPoint, from, to types.Timestamp) convertedRun {
+	out := convertedRun{start: c.money(start, from)}
+	var tradePL, tradePLConv types.Money
+	for _, tr := range trades {
+		if tr == nil {
+			continue
+		}
+		rate := c.at(tr.ExitTime)
+		cp := tr.Clone()
+		cp.PNL = convertMoney(tr.PNL, rate)
+		cp.InitialRisk = convertMoney(tr.InitialRisk, rate)
+		cp.Financing = convertMoney(tr.Financing, rate)
+		cp.ConversionRate = types.Rate(int64(tr.ConversionRate) * int64(rate) / int64(types.RateScale))
+		out.trades = append(out.trades, cp)
+		tradePL += tr.PNL
+		tradePLConv += cp.PNL
+	}
+	end := c.at(to)
+	out.balance = out.start + tradePLConv + convertMoney(balance-start-tradePL, end)
+	out.equity = out.balance + convertMoney(equity-balance, end)
+	for _, p := range points {
+		p.Equity = out.start + c.money(p.Equity-start, p.Day)
+		out.points = append(out.points, p)
+	}
+	return out
+}
+
+// currencySymbol is the prefix reports put on money in ccy: "$" for the
+// default USD, a symbol for the common others, else the code and a space.
+func currencySymbol(ccy string) string {
+	switch ccy {
+	case "", "USD":
+		return "$"
+	case "EUR":
+		return "€"
+	case "GBP":
+		return "£"
+	case "JPY":
+		return "¥"
+	default:
+		return ccy + " "
+	}
+}
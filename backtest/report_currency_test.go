@@ -0,0 +1,148 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestCompileReportCurrency(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "usdeur.csv")
+	require.NoError(t, os.WriteFile(good, []byte("date,rate\n2024-01-03,0.92\n2024-01-02,0.9\n"), 0o644))
+	bad := filepath.Join(dir, "bad.csv")
+	require.NoError(t, os.WriteFile(bad, []byte("2024-01-02,0.9\n2024-01-03,-1\n"), 0o644))
+
+	tests := []struct {
+		name    string
+		cfg     ReportConfig
+		wantErr string
+	}{
+		{name: "zero is the account currency", cfg: ReportConfig{}},
+		{name: "currency from the dataset", cfg: ReportConfig{Currency: "eur"}},
+		{name: "bad code", cfg: ReportConfig{Currency: "euro"}, wantErr: "bad report currency"},
+		{name: "rates without currency", cfg: ReportConfig{Rates: good}, wantErr: "need a report currency"},
+		{name: "missing file", cfg: ReportConfig{Currency: "EUR", Rates: filepath.Join(dir, "none.csv")}, wantErr: "open report rates"},
+		{name: "bad rate", cfg: ReportConfig{Currency: "EUR", Rates: bad}, wantErr: "line 2: bad rate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileReportCurrency(tt.cfg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	plan, err := compileReportCurrency(ReportConfig{Currency: "EUR", Rates: good})
+	require.NoError(t, err)
+	assert.Equal(t, ReportCurrencyPlan{
+		Currency:  "EUR",
+		RatesName: "usdeur.csv",
+		Rates: []conversionRate{
+			{Day: benchDay + secondsPerDay, Rate: types.RateFromFloat(0.9)},
+			{Day: benchDay + 2*secondsPerDay, Rate: types.RateFromFloat(0.92)},
+		},
+	}, plan)
+}
+
+func TestLoadReportCurrency_FromTheDataset(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	candles := hourlyCandles(start, 48) // EURUSD 1.10000 throughout
+	candles[47].Close = 125000          // the second day closes at 1.25
+	src := &rangeSource{candles: candles}
+	run := &Backtest{Request: &BacktestRequest{
+		Instrument:     "GBPUSD",
+		TimeRange:      types.NewTimeRange(types.FromTime(start), types.FromTime(start.Add(48*time.Hour)), types.H1),
+		ReportCurrency: ReportCurrencyPlan{Currency: "EUR"},
+	}}
+
+	conv, err := run.loadReportCurrency(context.Background(), src, "candles", "USD")
+	require.NoError(t, err)
+	require.NotNil(t, conv)
+	assert.Equal(t, "EURUSD", conv.Source)
+	require.Len(t, src.reqs, 1)
+	assert.Equal(t, "EURUSD", src.reqs[0].Instrument)
+	assert.InDelta(t, 1/1.1, conv.at(types.FromTime(start)).Float64(), 1e-6, "USD→EUR inverts EURUSD")
+	assert.InDelta(t, 0.8, conv.at(types.FromTime(start.Add(30*time.Hour))).Float64(), 1e-6, "each day's last close")
+
+	run.Request.ReportCurrency = ReportCurrencyPlan{Currency: "USD"}
+	conv, err = run.loadReportCurrency(context.Background(), src, "candles", "USD")
+	require.NoError(t, err)
+	assert.Nil(t, conv, "the account currency needs no conversion")
+
+	run.Request.ReportCurrency = ReportCurrencyPlan{Currency: "XYZ"}
+	_, err = run.loadReportCurrency(context.Background(), src, "candles", "USD")
+	assert.ErrorContains(t, err, "give report rates")
+
+	run.Request.ReportCurrency = ReportCurrencyPlan{Currency: "EUR"}
+	_, err = run.loadReportCurrency(context.Background(), &rangeSource{}, "candles", "USD")
+	assert.ErrorContains(t, err, "no EURUSD data")
+}
+
+func TestBuildBacktestResult_RestatesMoneyInTheReportCurrency(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.Balance = types.MoneyFromFloat(10_150)
+	acct.Equity = types.MoneyFromFloat(10_150)
+	acct.Trades = []*account.Trade{
+		{TradeCommon: &account.TradeCommon{Instrument: "EURUSD"}, ExitTime: benchDay + 3600, PNL: types.MoneyFromFloat(200), ConversionRate: types.RateFromFloat(1)},
+		{TradeCommon: &account.TradeCommon{Instrument: "EURUSD"}, ExitTime: benchDay + secondsPerDay + 3600, PNL: types.MoneyFromFloat(-50), ConversionRate: types.RateFromFloat(1)},
+	}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Strategy:        &longAfter{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.NewTimeRange(benchDay, benchDay+2*secondsPerDay, types.H1),
+		},
+		State: &BacktestRun{
+			equity: []equityPoint{
+				{Day: benchDay, Equity: types.MoneyFromFloat(10_200)},
+				{Day: benchDay + secondsPerDay, Equity: types.MoneyFromFloat(10_150)},
+			},
+			conversion: &conversionSeries{From: "USD", To: "EUR", rates: []conversionRate{
+				{Day: benchDay, Rate: types.RateFromFloat(0.5)},
+				{Day: benchDay + secondsPerDay, Rate: types.RateFromFloat(2)},
+			}},
+		},
+	}
+
+	res := run.BuildBacktestResult(acct)
+	require.NotNil(t, res)
+	assert.Equal(t, "EUR", res.Currency)
+	assert.Equal(t, types.MoneyFromFloat(5_000), res.StartBalance)
+	assert.Equal(t, types.MoneyFromFloat(100), res.GrossProfit, "200 at 0.5")
+	assert.Equal(t, types.MoneyFromFloat(-100), res.GrossLoss, "-50 at 2")
+	assert.Equal(t, types.MoneyFromFloat(5_000), res.Balance, "the rate's own move is not P/L")
+	assert.Equal(t, types.MoneyFromFloat(0), res.NetPL)
+	assert.Equal(t, types.MoneyFromFloat(-100), res.MaxDrawdown)
+	assert.Equal(t, types.MoneyFromFloat(200), acct.Trades[0].PNL, "the account's trades are untouched")
+	assert.Equal(t, types.MoneyFromFloat(100), run.State.Trades[0].PNL)
+	assert.Equal(t, types.RateFromFloat(0.5), run.State.Trades[0].ConversionRate)
+	assert.Equal(t, []equityPoint{
+		{Day: benchDay, Equity: types.MoneyFromFloat(5_100)},
+		{Day: benchDay + secondsPerDay, Equity: types.MoneyFromFloat(5_300)},
+	}, run.State.equityCurve())
+
+	s := run.Summary()
+	assert.Equal(t, "EUR", s.Currency)
+	var buf bytes.Buffer
+	PrintSummary(&buf, s)
+	assert.Contains(t, buf.String(), "Balance: €5000.00 → €5000.00")
+}
+
+func TestCurrencySymbol(t *testing.T) {
+	assert.Equal(t, "$", currencySymbol(""))
+	assert.Equal(t, "€", currencySymbol("EUR"))
+	assert.Equal(t, "CHF ", currencySymbol("CHF"))
+}
@@ -5,6 +5,8 @@ import (
 	"io"
 	"strings"
 	"time"
+
+	"github.com/rustyeddy/trader/market"
 )
 
 // WriteOrgReport writes a full per-run org-mode report to w.
@@ -25,6 +27,63 @@ func WriteOrgReport(w io.Writer, s BacktestReportSummary) {
 	fmt.Fprintln(w, "\n** Summary")
 	writeSummaryTable(w, s)
 
+	if len(s.Exposure) > 0 {
+		fmt.Fprintln(w, "\n** Exposure")
+		writeExposureTable(w, s.Exposure)
+	}
+
+	if s.Robustness != nil {
+		fmt.Fprintf(w, "\n** Robustness (%d runs, seed %d, %d profitable)\n",
+			s.Robustness.Runs, s.Robustness.Seed, s.Robustness.Profitable)
+		writeRobustnessTable(w, s.Robustness)
+	}
+
+	if cv := s.CrossValidation; cv != nil {
+		fmt.Fprintf(w, "\n** Cross-Validation (%d folds, %d test, purge %d, embargo %d bars; %d paths)\n",
+			cv.Folds, cv.TestFolds, cv.PurgeBars, cv.EmbargoBars, cv.Paths)
+		writeCrossValidationTables(w, cv)
+	}
+
+	if f := s.FillComparison; f != nil {
+		fmt.Fprintf(w, "\n** Fill Model (%s)\n", f.label())
+		writeFillComparisonTable(w, f)
+	}
+
+	if b := s.ByTime; b != nil {
+		fmt.Fprintf(w, "\n** By Entry Hour (%s)\n", b.Timezone)
+		writeBucketTable(w, "Hour", b.ByHour)
+		fmt.Fprintf(w, "\n** By Entry Weekday (%s)\n", b.Timezone)
+		writeBucketTable(w, "Day", b.ByWeekday)
+	}
+
+	if len(s.ByVolatility) > 0 {
+		fmt.Fprintln(w, "\n** By Volatility Regime")
+		writeBucketTable(w, "Regime", s.ByVolatility)
+	}
+
+	if len(s.Rolling) > 0 {
+		fmt.Fprintln(w, "\n** Rolling Performance (month end)")
+		writeRollingTable(w, s.Rolling)
+	}
+
+	if s.Benchmark != nil {
+		fmt.Fprintf(w, "\n** Benchmark (%s)\n", s.Benchmark.Name)
+		writeBenchmarkTable(w, s.Benchmark)
+		if len(s.Benchmark.Curve) > 0 {
+			fmt.Fprintln(w, "\n*** Relative Equity (month end)")
+			writeRelativeCurveTable(w, s.Benchmark.Curve)
+		}
+	}
+
+	if pf := s.PropFirm; pf != nil {
+		verdict := "FAIL"
+		if pf.Passed {
+			verdict = "PASS"
+		}
+		fmt.Fprintf(w, "\n** Prop Firm (%s)\n", verdict)
+		writePropFirmTable(w, pf)
+	}
+
 	// Monthly breakdown.
 	if len(s.TradeDetails) > 0 {
 		fmt.Fprintln(w, "\n** Monthly Breakdown")
@@ -51,7 +110,7 @@ func WriteOrgIndex(w io.Writer, summaries []BacktestReportSummary) {
 
 		dd := "—"
 		if s.MaxDrawdown < 0 {
-			dd = fmt.Sprintf("-$%.0f", -s.MaxDrawdown)
+			dd = fmt.Sprintf("-%s%.0f", currencySymbol(s.Currency), -s.MaxDrawdown)
 		}
 		rr := "—"
 		if s.RR > 0 {
@@ -104,6 +163,12 @@ func writeProperties(w io.Writer, s BacktestReportSummary) {
 	if s.Regime != "" {
 		prop("regime", s.Regime)
 	}
+	if s.StopReason != "" {
+		prop("stop_reason", s.StopReason)
+	}
+	if s.Interrupted {
+		prop("interrupted", "t")
+	}
 	fmt.Fprintln(w, "  :END:")
 }
 
@@ -113,9 +178,10 @@ func writeSummaryTable(w io.Writer, s BacktestReportSummary) {
 	start := shortDate(s.Start)
 	end := shortDate(s.End)
 
+	cur := currencySymbol(s.Currency)
 	ddStr := "—"
 	if s.MaxDrawdown < 0 {
-		ddStr = fmt.Sprintf("-$%.2f", -s.MaxDrawdown)
+		ddStr = fmt.Sprintf("-%s%.2f", cur, -s.MaxDrawdown)
 	}
 	rrStr := "—"
 	if s.RR > 0 {
@@ -132,20 +198,198 @@ func writeSummaryTable(w io.Writer, s BacktestReportSummary) {
 	tbl.addRow("Trades", fmt.Sprintf("%d", s.Trades))
 	tbl.addRow("Wins", fmt.Sprintf("%d  (%.1f%%)", s.Wins, s.WinRate))
 	tbl.addRow("Losses", fmt.Sprintf("%d  (%.1f%%)", s.Losses, 100-s.WinRate))
-	tbl.addRow("Start Balance", fmt.Sprintf("$%.2f", s.StartBalance))
-	tbl.addRow("End Balance", fmt.Sprintf("$%.2f", s.EndBalance))
+	tbl.addRow("Start Balance", fmt.Sprintf("%s%.2f", cur, s.StartBalance))
+	tbl.addRow("End Balance", fmt.Sprintf("%s%.2f", cur, s.EndBalance))
 	tbl.addRow("Net P/L", fmt.Sprintf("%+.2f", s.NetPL))
+	if s.Interest != 0 {
+		tbl.addRow("Idle Interest", fmt.Sprintf("%+.2f", s.Interest))
+	}
 	tbl.addRow("Return", fmt.Sprintf("%+.2f%%", s.ReturnPct))
 	tbl.addRow("Max Drawdown", ddStr)
-	tbl.addRow("Avg Winner", fmt.Sprintf("$%.2f", s.AvgWinner))
-	tbl.addRow("Avg Loser", fmt.Sprintf("$%.2f", s.AvgLoser))
+	tbl.addRow("Avg Winner", fmt.Sprintf("%s%.2f", cur, s.AvgWinner))
+	tbl.addRow("Avg Loser", fmt.Sprintf("%s%.2f", cur, s.AvgLoser))
 	tbl.addRow("Risk/Reward", rrStr)
+	if s.ProfitFactor > 0 {
+		tbl.addRow("Profit Factor", fmt.Sprintf("%.2f", s.ProfitFactor))
+	}
+	if s.Sharpe != 0 {
+		tbl.addRow("Sharpe", fmt.Sprintf("%.2f", s.Sharpe))
+	}
 	tbl.addRow("Risk/Trade", fmt.Sprintf("%.2f%%", s.RiskPct))
 	tbl.addRow("Stop", stopStr)
 	if s.Regime != "" {
 		tbl.addRow("Regime", s.Regime)
 	}
+	if s.StopReason != "" {
+		tbl.addRow("Stopped Early", s.StopReason)
+	}
+
+	tbl.write(w, "   ")
+}
 
+func writeExposureTable(w io.Writer, exps []BacktestReportExposure) {
+	tbl := newOrgTable("Currency", "Max Long", "Max Short")
+	tbl.setRight(1, 2)
+	for _, e := range exps {
+		tbl.addRow(e.Currency, fmt.Sprintf("%.2f", e.MaxLong), fmt.Sprintf("%.2f", e.MaxShort))
+	}
+	tbl.write(w, "   ")
+}
+
+func writeRobustnessTable(w io.Writer, r *BacktestReportRobustness) {
+	tbl := newOrgTable("Metric", "Min", "Median", "Max")
+	tbl.setRight(1, 2, 3)
+	row := func(name, format string, sp BacktestReportSpread) {
+		tbl.addRow(name, fmt.Sprintf(format, sp.Min), fmt.Sprintf(format, sp.Median), fmt.Sprintf(format, sp.Max))
+	}
+	row("Return", "%+.2f%%", r.ReturnPct)
+	row("Net P/L", "%+.2f", r.NetPL)
+	row("Max Drawdown", "%.2f", r.MaxDrawdown)
+	row("Trades", "%.0f", r.Trades)
+	tbl.write(w, "   ")
+}
+
+func writeCrossValidationTables(w io.Writer, cv *BacktestReportCrossValidation) {
+	tbl := newOrgTable("Metric", "IS Median", "OOS Min", "OOS Median", "OOS Max")
+	tbl.setRight(1, 2, 3, 4)
+	row := func(name, format string, is, oos BacktestReportSpread) {
+		tbl.addRow(name, fmt.Sprintf(format, is.Median),
+			fmt.Sprintf(format, oos.Min), fmt.Sprintf(format, oos.Median), fmt.Sprintf(format, oos.Max))
+	}
+	row("Return", "%+.2f%%", cv.InSample.ReturnPct, cv.OutOfSample.ReturnPct)
+	row("Net P/L", "%+.2f", cv.InSample.NetPL, cv.OutOfSample.NetPL)
+	row("Max Drawdown", "%.2f", cv.InSample.MaxDrawdown, cv.OutOfSample.MaxDrawdown)
+	row("Trades", "%.0f", cv.InSample.Trades, cv.OutOfSample.Trades)
+	tbl.addRow("Profitable", fmt.Sprintf("%d/%d", cv.InSample.Profitable, cv.InSample.Runs),
+		"", fmt.Sprintf("%d/%d", cv.OutOfSample.Profitable, cv.OutOfSample.Runs), "")
+	tbl.write(w, "   ")
+
+	fmt.Fprintln(w)
+	splits := newOrgTable("Test Folds", "IS Return", "IS Trades", "OOS Return", "OOS P/L", "OOS Trades")
+	splits.setRight(1, 2, 3, 4, 5)
+	for _, sp := range cv.Splits {
+		splits.addRow(sp.TestFolds, fmt.Sprintf("%+.2f%%", sp.InSampleReturn), fmt.Sprintf("%d", sp.InSampleTrades),
+			fmt.Sprintf("%+.2f%%", sp.Return), fmt.Sprintf("%+.2f", sp.NetPL), fmt.Sprintf("%d", sp.Trades))
+	}
+	splits.write(w, "   ")
+}
+
+func writeFillComparisonTable(w io.Writer, f *BacktestReportFillComparison) {
+	tbl := newOrgTable("Metric", f.Fill, FillSameTick, "Difference")
+	tbl.setRight(1, 2, 3)
+	tbl.addRow("Return", fmt.Sprintf("%+.2f%%", f.ReturnPct), fmt.Sprintf("%+.2f%%", f.SameTickReturnPct),
+		fmt.Sprintf("%+.2f%%", f.ReturnPct-f.SameTickReturnPct))
+	tbl.addRow("Net P/L", fmt.Sprintf("%+.2f", f.NetPL), fmt.Sprintf("%+.2f", f.SameTickNetPL), fmt.Sprintf("%+.2f", f.NetPLDiff))
+	tbl.addRow("Trades", fmt.Sprintf("%d", f.Trades), fmt.Sprintf("%d", f.SameTickTrades),
+		fmt.Sprintf("%+d", f.Trades-f.SameTickTrades))
+	tbl.write(w, "   ")
+}
+
+func writeBucketTable(w io.Writer, label string, buckets []BacktestReportBucket) {
+	tbl := newOrgTable(label, "Trades", "Win%", "P/L")
+	tbl.setRight(1, 2, 3)
+	for _, b := range buckets {
+		tbl.addRow(b.Label, fmt.Sprintf("%d", b.Trades), fmt.Sprintf("%.1f", b.WinRate), fmt.Sprintf("%+.2f", b.PNL))
+	}
+	tbl.write(w, "   ")
+}
+
+// writeRollingTable writes each window's last point of every month side by
+// side; the full series are in the JSON report.
+func writeRollingTable(w io.Writer, series []BacktestReportRolling) {
+	headers := []string{"Month"}
+	for _, s := range series {
+		d := fmt.Sprintf("%dd", s.Days)
+		headers = append(headers, d+" Trades", d+" Win%", d+" PF", d+" DD")
+	}
+	tbl := newOrgTable(headers...)
+	right := make([]int, 0, len(headers)-1)
+	for i := 1; i < len(headers); i++ {
+		right = append(right, i)
+	}
+	tbl.setRight(right...)
+
+	var months []string
+	byMonth := make([]map[string]BacktestReportRollingPoint, len(series))
+	for i, s := range series {
+		byMonth[i] = make(map[string]BacktestReportRollingPoint)
+		for _, p := range s.Points {
+			if len(p.Date) < 7 {
+				continue
+			}
+			m := p.Date[:7]
+			if _, ok := byMonth[i][m]; !ok && i == 0 {
+				months = append(months, m)
+			}
+			byMonth[i][m] = p
+		}
+	}
+	for _, m := range months {
+		row := []string{m}
+		for i := range series {
+			p := byMonth[i][m]
+			pf := "—"
+			if p.ProfitFactor > 0 {
+				pf = fmt.Sprintf("%.2f", p.ProfitFactor)
+			}
+			row = append(row, fmt.Sprintf("%d", p.Trades), fmt.Sprintf("%.1f", p.WinRate), pf, fmt.Sprintf("%.2f", p.MaxDrawdown))
+		}
+		tbl.addRow(row...)
+	}
+	tbl.write(w, "   ")
+}
+
+func writeBenchmarkTable(w io.Writer, b *BacktestReportBenchmark) {
+	tbl := newOrgTable("Metric", "Value")
+	tbl.setRight(1)
+	tbl.addRow("Days compared", fmt.Sprintf("%d", b.Days))
+	tbl.addRow("Risk-free rate", fmt.Sprintf("%.2f%%", b.RiskFreePct))
+	tbl.addRow("Benchmark return", fmt.Sprintf("%+.2f%%", b.ReturnPct))
+	tbl.addRow("Strategy return", fmt.Sprintf("%+.2f%%", b.StrategyReturnPct))
+	tbl.addRow("Alpha (annual)", fmt.Sprintf("%+.2f%%", b.AlphaPct))
+	tbl.addRow("Beta", fmt.Sprintf("%.2f", b.Beta))
+	tbl.addRow("Information ratio", fmt.Sprintf("%.2f", b.InformationRatio))
+	tbl.write(w, "   ")
+}
+
+func writePropFirmTable(w io.Writer, pf *BacktestReportPropFirm) {
+	tbl := newOrgTable("Rule", "Limit", "Result")
+	tbl.setRight(1, 2)
+	tbl.addRow("Verdict", "", pf.Verdict)
+	if pf.DecidedAt != "" {
+		tbl.addRow("Decided", "", pf.DecidedAt)
+	}
+	limit := func(v float64) string {
+		if v == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f", v)
+	}
+	tbl.addRow("Daily loss", limit(pf.DailyLossLimit), pf.worstDay())
+	tbl.addRow("Max loss", limit(pf.MaxLossLimit), fmt.Sprintf("%.2f", pf.MaxLoss))
+	tbl.addRow("Profit target", limit(pf.ProfitTarget), pf.TargetReachedAt)
+	tbl.addRow("Trading days", fmt.Sprintf("%d", pf.MinTradingDays), fmt.Sprintf("%d", pf.TradingDays))
+	if pf.Breach != "" {
+		tbl.addRow("Breached", pf.Breach, pf.BreachedAt)
+	}
+	tbl.write(w, "   ")
+}
+
+// writeRelativeCurveTable writes the last point of each month of the
+// relative equity curve; the full daily curve is in the JSON report.
+func writeRelativeCurveTable(w io.Writer, curve []BacktestReportRelative) {
+	tbl := newOrgTable("Month", "Strategy", "Benchmark", "Relative")
+	tbl.setRight(1, 2, 3)
+	for i, p := range curve {
+		if i+1 < len(curve) && len(p.Date) >= 7 && strings.HasPrefix(curve[i+1].Date, p.Date[:7]) {
+			continue
+		}
+		month := p.Date
+		if len(month) >= 7 {
+			month = month[:7]
+		}
+		tbl.addRow(month, fmt.Sprintf("%.4f", p.Strategy), fmt.Sprintf("%.4f", p.Benchmark), fmt.Sprintf("%.4f", p.Relative))
+	}
 	tbl.write(w, "   ")
 }
 
@@ -208,8 +452,8 @@ func writeTradeTable(w io.Writer, trades []BacktestReportTrade) {
 			titleASCII(tr.Side),
 			shortDateTime(tr.OpenTime),
 			shortDateTime(tr.CloseTime),
-			fmt.Sprintf("%.5f", tr.OpenPrice),
-			fmt.Sprintf("%.5f", tr.ClosePrice),
+			market.FormatPrice(tr.Instrument, tr.OpenPrice),
+			market.FormatPrice(tr.Instrument, tr.ClosePrice),
 			fmt.Sprintf("%d", tr.Units),
 			fmt.Sprintf("%+.2f", tr.PNL),
 		)
@@ -139,6 +139,59 @@ func TestPrintSummary_WithSpreadStats(t *testing.T) {
 	assert.Contains(t, out, "Filtered: 7")
 }
 
+func TestPrintSummary_StoppedEarly(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	PrintSummary(&buf, minSummary())
+	assert.NotContains(t, buf.String(), "Stopped early")
+
+	s := minSummary()
+	s.StopReason = "completed 10 trades"
+	buf.Reset()
+	PrintSummary(&buf, s)
+	assert.Contains(t, buf.String(), "Stopped early: completed 10 trades")
+
+	buf.Reset()
+	WriteOrgReport(&buf, s)
+	assert.Contains(t, buf.String(), ":stop_reason:")
+	assert.Contains(t, buf.String(), "| Stopped Early")
+}
+
+func TestPrintSummary_Exposure(t *testing.T) {
+	t.Parallel()
+
+	s := minSummary()
+	s.Exposure = []BacktestReportExposure{
+		{Currency: "EUR", MaxLong: 10000, MaxShort: -5000},
+		{Currency: "USD", MaxLong: 5500, MaxShort: -11000},
+	}
+
+	var buf bytes.Buffer
+	PrintSummary(&buf, s)
+	assert.Contains(t, buf.String(), "Exposure: EUR +10000/-5000   USD +5500/-11000")
+
+	buf.Reset()
+	WriteOrgReport(&buf, s)
+	assert.Contains(t, buf.String(), "** Exposure")
+	assert.Contains(t, buf.String(), "-11000.00")
+}
+
+func TestPrintSummary_SkipCounts(t *testing.T) {
+	t.Parallel()
+
+	s := minSummary()
+	s.GovernorSkipped = map[string]int{"max-open-trades": 3}
+	s.FilterSkipped = map[string]int{"SessionOpen(30m:07:00,12:00,market)": 4, "AbnormalCandle(14×3.0)": 1}
+	s.SpreadGuarded = map[string]int{SpreadRuleAvgRatio: 2}
+
+	var buf bytes.Buffer
+	PrintSummary(&buf, s)
+	assert.Contains(t, buf.String(), "Spread guard blocked: avg-ratio 2\n")
+	assert.Contains(t, buf.String(), "Governor skipped: max-open-trades 3\n")
+	assert.Contains(t, buf.String(), "Filters skipped: AbnormalCandle(14×3.0) 1   SessionOpen(30m:07:00,12:00,market) 4\n")
+}
+
 func TestPrintSummary_DateTruncation(t *testing.T) {
 	t.Parallel()
 
@@ -7,15 +7,69 @@ import "github.com/rustyeddy/trader/types"
 type BacktestResult struct {
 	Start        types.Timestamp
 	End          types.Timestamp
+	Currency     string      // report currency money is restated in; empty means the account's
 	StartBalance types.Money // starting account balance
 	Balance      types.Money // final account balance, realised only
 	Equity       types.Money // final equity including any open positions at run end
+	Interest     types.Money // idle-balance interest credited, included in Balance
 
 	Trades int // total non-nil closed trades
 	Wins   int // trades with PNL > 0
 	Losses int // trades with PNL < 0
 	Flat   int // trades with PNL == 0
 
+	// StopReason explains why the run ended early when one of the request's
+	// StopOn conditions was hit; empty for a run that reached its end date.
+	// End is the timestamp of the bar the run stopped on in that case.
+	StopReason string
+
+	// Interrupted marks a partial result from a run cut short by its
+	// context; StopReason is then StopInterrupted.
+	Interrupted bool
+
+	// Exposure is the peak net long/short per currency across the run,
+	// sampled at each bar close after fills.
+	Exposure []ExposurePeak
+
+	// Skipped counts the entries the governor refused, by rule.
+	Skipped map[string]int
+
+	// Filtered counts the entries the strategy's filter chain blocked, by
+	// filter name.
+	Filtered map[string]int
+
+	// Rejected counts the entries the order path refused, by reason code
+	// (journal.Reject*).
+	Rejected map[string]int
+
+	// SpreadGuarded counts the entries the spread guard blocked, by rule
+	// (SpreadRule*).
+	SpreadGuarded map[string]int
+
+	// Signals groups the journaled entry signals by fate; nil unless the
+	// run journals signals.
+	Signals []SignalGroup
+
+	// Rolling holds the closed-trade metrics over trailing 30 and 90 day
+	// windows; nil when no trade closed.
+	Rolling []RollingSeries
+
+	// ByTime buckets closed trades by entry hour and weekday; nil when no
+	// trade closed.
+	ByTime *TimeBreakdown
+
+	// ByVolatility buckets closed trades by the volatility regime they
+	// were entered in; nil when no trade closed.
+	ByVolatility map[string]TimeBucket
+
+	// Benchmark compares the run's daily returns with the request's
+	// benchmark; nil when none is configured.
+	Benchmark *BenchmarkResult
+
+	// PropFirm is the run's verdict against the request's prop-firm rules;
+	// nil when none are configured.
+	PropFirm *PropFirmResult
+
 	// Derived fields populated by BuildBacktestResult.
 	NetPL          types.Money // Balance - StartBalance
 	ReturnPct      types.Rate  // NetPL / StartBalance, RateScale-scaled
@@ -28,4 +82,5 @@ type BacktestResult struct {
 	RR             types.Rate  // AvgWinner / abs(AvgLoser), RateScale-scaled
 	MaxDrawdown    types.Money // largest peak-to-trough drop in cumulative PNL, negative
 	MaxDrawdownPct types.Rate  // MaxDrawdown / StartBalance, RateScale-scaled
+	Sharpe         types.Rate  // annualised Sharpe ratio of daily equity returns, risk-free rate 0
 }
@@ -0,0 +1,229 @@
+package backtest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+)
+
+// RobustnessConfig asks for every run to be repeated Runs more times with
+// seeded random perturbations on top of the normal, unperturbed run. A
+// strategy whose results swing wildly when entries land a bar late, fills
+// slip a little more, or the odd signal is missed is fragile; the spread
+// of the perturbed outcomes goes into the report next to the base result.
+// Runs == 0 disables robustness runs.
+type RobustnessConfig struct {
+	Runs               int     `json:"runs,omitempty"                 yaml:"runs"`
+	Seed               int64   `json:"seed,omitempty"                 yaml:"seed"`                 // perturbed run i uses Seed+i
+	MaxEntryDelay      int     `json:"max-entry-delay,omitempty"      yaml:"max-entry-delay"`      // delay each entry by 0..N bars
+	SlippageJitterPips float64 `json:"slippage-jitter-pips,omitempty" yaml:"slippage-jitter-pips"` // extra fill slippage, 0..N pips
+	SkipProbability    float64 `json:"skip-probability,omitempty"     yaml:"skip-probability"`     // chance an entry is dropped, 0..1
+}
+
+// IsZero reports whether robustness runs are not configured.
+func (c RobustnessConfig) IsZero() bool {
+	return c == RobustnessConfig{}
+}
+
+// Perturbation is the compiled, fixed-point form of the random changes
+// applied to one perturbed run.
+type Perturbation struct {
+	MaxEntryDelay  int        // bars
+	SlippageJitter types.Pips // upper bound of the extra slippage per fill
+	SkipProb       types.Rate // RateScale-scaled probability of dropping an entry
+}
+
+// IsZero reports whether p changes nothing.
+func (p Perturbation) IsZero() bool {
+	return p == Perturbation{}
+}
+
+// RobustnessPlan is the compiled form of RobustnessConfig.
+type RobustnessPlan struct {
+	Runs    int
+	Seed    int64
+	Perturb Perturbation
+}
+
+// compileRobustness validates cfg and converts it to a RobustnessPlan.
+func compileRobustness(cfg RobustnessConfig) (RobustnessPlan, error) {
+	if cfg.Runs < 0 {
+		return RobustnessPlan{}, fmt.Errorf("runs must be >= 0, got %d", cfg.Runs)
+	}
+	if cfg.MaxEntryDelay < 0 {
+		return RobustnessPlan{}, fmt.Errorf("max-entry-delay must be >= 0, got %d", cfg.MaxEntryDelay)
+	}
+	if cfg.SlippageJitterPips < 0 {
+		return RobustnessPlan{}, fmt.Errorf("slippage-jitter-pips must be >= 0, got %v", cfg.SlippageJitterPips)
+	}
+	if cfg.SkipProbability < 0 || cfg.SkipProbability >= 1 {
+		return RobustnessPlan{}, fmt.Errorf("skip-probability must be in [0, 1), got %v", cfg.SkipProbability)
+	}
+	plan := RobustnessPlan{
+		Runs: cfg.Runs,
+		Seed: cfg.Seed,
+		Perturb: Perturbation{
+			MaxEntryDelay:  cfg.MaxEntryDelay,
+			SlippageJitter: types.PipsFromFloat(cfg.SlippageJitterPips),
+			SkipProb:       types.RateFromFloat(cfg.SkipProbability),
+		},
+	}
+	if plan.Runs > 0 && plan.Perturb.IsZero() {
+		return RobustnessPlan{}, fmt.Errorf("robustness runs need at least one perturbation")
+	}
+	return plan, nil
+}
+
+// PerturbedRuns builds the robustness reruns for c: Robustness.Runs fresh
+// Backtests, each with newly constructed strategy, exit, and regime
+// components (they carry state between bars, so they cannot be shared
+// with the base run) and its own seed. It returns nil when robustness
+// runs are not configured.
+func (c CompiledBacktest) PerturbedRuns() ([]Backtest, error) {
+	plan := c.Request.Robustness
+	if plan.Runs == 0 {
+		return nil, nil
+	}
+	runs := make([]Backtest, 0, plan.Runs)
+	for i := 0; i < plan.Runs; i++ {
+		fresh, err := compileBacktestComponents(c.RunConfig)
+		if err != nil {
+			return nil, err
+		}
+		run := c.NewRun()
+		run.Request.Strategy = fresh.Strategy
+		run.Request.Exit = fresh.Exit
+		run.Request.Regime = fresh.Regime
+		run.Request.Perturb = plan.Perturb
+		run.Request.Seed = plan.Seed + int64(i)
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// perturber applies a Perturbation inside the run loop. A nil perturber
+// leaves everything unchanged, so the unperturbed path costs nothing.
+type perturber struct {
+	p       Perturbation
+	jitter  types.Price // SlippageJitter in price units
+	rng     *rand.Rand
+	delayed []delayedOpen
+}
+
+type delayedOpen struct {
+	due int64 // bar index at which to submit
+	req *account.OpenRequest
+}
+
+func newPerturber(p Perturbation, seed int64, jitter types.Price) *perturber {
+	if p.IsZero() {
+		return nil
+	}
+	return &perturber{p: p, jitter: jitter, rng: rand.New(rand.NewSource(seed))}
+}
+
+// opens returns the entries to submit on bar: any delayed entries now due,
+// followed by this bar's planned entries that were neither skipped nor
+// delayed. A delayed entry is submitted unchanged (same size and stop) at
+// the later bar's price.
+func (pt *perturber) opens(bar int64, planned []*account.OpenRequest) []*account.OpenRequest {
+	if pt == nil {
+		return planned
+	}
+	var out []*account.OpenRequest
+	kept := pt.delayed[:0]
+	for _, d := range pt.delayed {
+		if d.due <= bar {
+			out = append(out, d.req)
+		} else {
+			kept = append(kept, d)
+		}
+	}
+	pt.delayed = kept
+
+	for _, req := range planned {
+		if pt.p.SkipProb > 0 && types.Rate(pt.rng.Int63n(int64(types.RateScale))) < pt.p.SkipProb {
+			continue
+		}
+		if pt.p.MaxEntryDelay > 0 {
+			if delay := int64(pt.rng.Intn(pt.p.MaxEntryDelay + 1)); delay > 0 {
+				pt.delayed = append(pt.delayed, delayedOpen{due: bar + delay, req: req})
+				continue
+			}
+		}
+		out = append(out, req)
+	}
+	return out
+}
+
+// slippage draws the extra slippage for one fill, uniform in [0, jitter].
+func (pt *perturber) slippage() types.Price {
+	if pt == nil || pt.jitter <= 0 {
+		return 0
+	}
+	return types.Price(pt.rng.Int63n(int64(pt.jitter) + 1))
+}
+
+// RobustnessResult summarises the outcomes of a set of perturbed runs.
+type RobustnessResult struct {
+	Runs       int
+	Seed       int64
+	Profitable int // runs with NetPL > 0
+
+	ReturnPct   RobustnessSpread[types.Rate]
+	NetPL       RobustnessSpread[types.Money]
+	MaxDrawdown RobustnessSpread[types.Money]
+	Trades      RobustnessSpread[int]
+}
+
+// RobustnessSpread is the distribution of one metric across perturbed runs.
+type RobustnessSpread[T types.Rate | types.Money | int] struct {
+	Min, Median, Max T
+}
+
+// SummarizeRobustness aggregates the results of perturbed runs. Nil
+// results are skipped; it returns nil when none remain.
+func SummarizeRobustness(plan RobustnessPlan, results []*BacktestResult) *RobustnessResult {
+	var (
+		rets      []types.Rate
+		pls, mdds []types.Money
+		trades    []int
+	)
+	out := &RobustnessResult{Seed: plan.Seed}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		out.Runs++
+		if r.NetPL > 0 {
+			out.Profitable++
+		}
+		rets = append(rets, r.ReturnPct)
+		pls = append(pls, r.NetPL)
+		mdds = append(mdds, r.MaxDrawdown)
+		trades = append(trades, r.Trades)
+	}
+	if out.Runs == 0 {
+		return nil
+	}
+	out.ReturnPct = spreadOf(rets)
+	out.NetPL = spreadOf(pls)
+	out.MaxDrawdown = spreadOf(mdds)
+	out.Trades = spreadOf(trades)
+	return out
+}
+
+// spreadOf sorts vals in place and returns their min, median, and max. The
+// median of an even count is the lower middle value, so it is always an
+// observed outcome.
+func spreadOf[T types.Rate | types.Money | int](vals []T) RobustnessSpread[T] {
+	sort.Slice(vals, func(i, j int) bool { return vals[i] < vals[j] })
+	return RobustnessSpread[T]{
+		Min:    vals[0],
+		Median: vals[(len(vals)-1)/2],
+		Max:    vals[len(vals)-1],
+	}
+}
@@ -0,0 +1,169 @@
+package backtest
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileRobustness(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     RobustnessConfig
+		wantErr string
+	}{
+		{name: "zero is disabled", cfg: RobustnessConfig{}},
+		{name: "negative runs", cfg: RobustnessConfig{Runs: -1}, wantErr: "runs must be >= 0"},
+		{name: "negative delay", cfg: RobustnessConfig{Runs: 1, MaxEntryDelay: -1}, wantErr: "max-entry-delay"},
+		{name: "negative jitter", cfg: RobustnessConfig{Runs: 1, SlippageJitterPips: -0.5}, wantErr: "slippage-jitter-pips"},
+		{name: "skip probability of one", cfg: RobustnessConfig{Runs: 1, SkipProbability: 1}, wantErr: "skip-probability"},
+		{name: "runs without perturbation", cfg: RobustnessConfig{Runs: 5, Seed: 1}, wantErr: "at least one perturbation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileRobustness(tt.cfg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	plan, err := compileRobustness(RobustnessConfig{Runs: 10, Seed: 7, MaxEntryDelay: 2, SlippageJitterPips: 0.5, SkipProbability: 0.1})
+	require.NoError(t, err)
+	assert.Equal(t, RobustnessPlan{
+		Runs: 10,
+		Seed: 7,
+		Perturb: Perturbation{
+			MaxEntryDelay:  2,
+			SlippageJitter: types.PipsFromFloat(0.5),
+			SkipProb:       types.RateFromFloat(0.1),
+		},
+	}, plan)
+}
+
+func TestPerturber_NilPassesThrough(t *testing.T) {
+	assert.Nil(t, newPerturber(Perturbation{}, 1, 0))
+
+	var pt *perturber
+	reqs := []*account.OpenRequest{{}, {}}
+	assert.Equal(t, reqs, pt.opens(0, reqs))
+	assert.Zero(t, pt.slippage())
+}
+
+func TestPerturber_SkipsAndDelaysDeterministically(t *testing.T) {
+	p := Perturbation{MaxEntryDelay: 2, SkipProb: types.RateFromFloat(0.3)}
+	decide := func(seed int64) []int64 {
+		pt := newPerturber(p, seed, 0)
+		submitted := make(map[*account.OpenRequest]int64)
+		var reqs []*account.OpenRequest
+		for bar := int64(0); bar < 200; bar++ {
+			req := &account.OpenRequest{}
+			reqs = append(reqs, req)
+			for _, r := range pt.opens(bar, []*account.OpenRequest{req}) {
+				submitted[r] = bar
+			}
+		}
+		out := make([]int64, len(reqs))
+		for i, r := range reqs {
+			out[i] = -1 // skipped
+			if bar, ok := submitted[r]; ok {
+				out[i] = bar - int64(i) // delay in bars
+			}
+		}
+		return out
+	}
+
+	a := decide(42)
+	assert.Equal(t, a, decide(42), "same seed, same perturbations")
+	assert.NotEqual(t, a, decide(43))
+
+	counts := map[int64]int{}
+	for _, d := range a {
+		counts[d]++
+	}
+	assert.Positive(t, counts[-1], "some entries are skipped")
+	assert.Positive(t, counts[0])
+	assert.Positive(t, counts[1])
+	assert.Positive(t, counts[2])
+	assert.Len(t, counts, 4, "delays never exceed MaxEntryDelay")
+}
+
+func TestPerturber_SlippageWithinJitter(t *testing.T) {
+	pt := newPerturber(Perturbation{SlippageJitter: types.PipsFromFloat(0.5)}, 3, 5)
+	seen := map[types.Price]bool{}
+	for i := 0; i < 500; i++ {
+		s := pt.slippage()
+		require.GreaterOrEqual(t, s, types.Price(0))
+		require.LessOrEqual(t, s, types.Price(5))
+		seen[s] = true
+	}
+	assert.Len(t, seen, 6)
+}
+
+func TestCompiledBacktest_PerturbedRuns(t *testing.T) {
+	cfg := &Config{
+		Defaults: RunDefaults{
+			StartingBalance: 1000,
+			Robustness:      RobustnessConfig{Runs: 3, Seed: 100, SkipProbability: 0.2},
+		},
+		Runs: []RunConfig{{
+			Name:     "robust",
+			Data:     DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2026-01-01", To: "2026-01-10"},
+			Strategy: strategy.StrategyConfig{Kind: "fake"},
+		}},
+	}
+	compiled, err := CompileBacktests(cfg)
+	require.NoError(t, err)
+	require.Len(t, compiled, 1)
+	assert.True(t, compiled[0].Request.Perturb.IsZero(), "the base run is never perturbed")
+
+	runs, err := compiled[0].PerturbedRuns()
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	for i, run := range runs {
+		assert.Equal(t, int64(100+i), run.Request.Seed)
+		assert.Equal(t, compiled[0].Request.Robustness.Perturb, run.Request.Perturb)
+		assert.NotNil(t, run.Request.Strategy)
+	}
+
+	cfg.Defaults.Robustness = RobustnessConfig{}
+	compiled, err = CompileBacktests(cfg)
+	require.NoError(t, err)
+	runs, err = compiled[0].PerturbedRuns()
+	require.NoError(t, err)
+	assert.Nil(t, runs)
+}
+
+func TestSummarizeRobustness(t *testing.T) {
+	plan := RobustnessPlan{Runs: 4, Seed: 9}
+	assert.Nil(t, SummarizeRobustness(plan, nil))
+
+	money := types.MoneyFromFloat
+	results := []*BacktestResult{
+		{NetPL: money(300), ReturnPct: types.RateFromFloat(0.03), MaxDrawdown: money(-100), Trades: 12},
+		{NetPL: money(-200), ReturnPct: types.RateFromFloat(-0.02), MaxDrawdown: money(-400), Trades: 9},
+		nil,
+		{NetPL: money(100), ReturnPct: types.RateFromFloat(0.01), MaxDrawdown: money(-150), Trades: 10},
+		{NetPL: money(500), ReturnPct: types.RateFromFloat(0.05), MaxDrawdown: money(-50), Trades: 14},
+	}
+	r := SummarizeRobustness(plan, results)
+	require.NotNil(t, r)
+	assert.Equal(t, 4, r.Runs)
+	assert.Equal(t, int64(9), r.Seed)
+	assert.Equal(t, 3, r.Profitable)
+	assert.Equal(t, RobustnessSpread[types.Money]{Min: money(-200), Median: money(100), Max: money(500)}, r.NetPL)
+	assert.Equal(t, RobustnessSpread[types.Money]{Min: money(-400), Median: money(-150), Max: money(-50)}, r.MaxDrawdown)
+	assert.Equal(t, RobustnessSpread[int]{Min: 9, Median: 10, Max: 14}, r.Trades)
+
+	rep := r.Report()
+	assert.InDelta(t, -2.0, rep.ReturnPct.Min, 1e-9)
+	assert.InDelta(t, 1.0, rep.ReturnPct.Median, 1e-9)
+	assert.InDelta(t, 5.0, rep.ReturnPct.Max, 1e-9)
+	assert.Equal(t, 14.0, rep.Trades.Max)
+}
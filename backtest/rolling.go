@@ -0,0 +1,93 @@
+package backtest
+
+import (
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+)
+
+// rollingWindowDays are the trailing windows, in days, the report's rolling
+// metrics are computed over.
+var rollingWindowDays = []int{30, 90}
+
+const secondsPerDay = types.Timestamp(24 * 60 * 60)
+
+// RollingSeries is one rolling-window metric series: a point for every UTC
+// day on which a trade closed, each covering the trades closed in the
+// Days days up to the end of that day.
+type RollingSeries struct {
+	Days   int
+	Points []RollingPoint
+}
+
+// RollingPoint is the closed-trade performance over one trailing window.
+type RollingPoint struct {
+	Day          types.Timestamp // midnight UTC of the window's last day
+	Trades       int
+	WinRate      types.Rate  // wins / trades, RateScale-scaled
+	ProfitFactor types.Rate  // gross profit / abs(gross loss); zero without a loss
+	MaxDrawdown  types.Money // largest peak-to-trough drop in the window's cumulative PNL, negative
+}
+
+// rollingMetrics computes a RollingSeries per window in days over trades,
+// which must be in close order as the account records them. It returns nil
+// when there are no closed trades.
+func rollingMetrics(trades []*account.Trade, windows []int) []RollingSeries {
+	var closed []*account.Trade
+	for _, tr := range trades {
+		if tr != nil {
+			closed = append(closed, tr)
+		}
+	}
+	if len(closed) == 0 {
+		return nil
+	}
+
+	out := make([]RollingSeries, 0, len(windows))
+	for _, days := range windows {
+		series := RollingSeries{Days: days}
+		span := types.Timestamp(days) * secondsPerDay
+		first := 0
+		for i, tr := range closed {
+			day := tr.ExitTime - tr.ExitTime%secondsPerDay
+			if i+1 < len(closed) && closed[i+1].ExitTime < day+secondsPerDay {
+				continue // the point is taken at the day's last close
+			}
+			end := day + secondsPerDay
+			for first <= i && closed[first].ExitTime < end-span {
+				first++
+			}
+			series.Points = append(series.Points, rollingPoint(day, closed[first:i+1]))
+		}
+		out = append(out, series)
+	}
+	return out
+}
+
+func rollingPoint(day types.Timestamp, trades []*account.Trade) RollingPoint {
+	p := RollingPoint{Day: day, Trades: len(trades)}
+	var wins int
+	var gross, loss, running, peak types.Money
+	for _, tr := range trades {
+		switch {
+		case tr.PNL > 0:
+			wins++
+			gross += tr.PNL
+		case tr.PNL < 0:
+			loss += tr.PNL
+		}
+		running += tr.PNL
+		if running > peak {
+			peak = running
+		}
+		if drop := peak - running; drop > -p.MaxDrawdown {
+			p.MaxDrawdown = -drop
+		}
+	}
+	if p.Trades > 0 {
+		p.WinRate = types.RateFromFloat(float64(wins) / float64(p.Trades))
+	}
+	if loss < 0 {
+		p.ProfitFactor = types.RateFromFloat(gross.Float64() / -loss.Float64())
+	}
+	return p
+}
@@ -0,0 +1,68 @@
+package backtest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rollingTrade(day int, pnl float64) *account.Trade {
+	tr := &account.Trade{}
+	tr.ExitTime = benchDay + types.Timestamp(day)*secondsPerDay + 3600
+	tr.PNL = types.MoneyFromFloat(pnl)
+	return tr
+}
+
+func TestRollingMetrics(t *testing.T) {
+	assert.Nil(t, rollingMetrics(nil, rollingWindowDays))
+
+	trades := []*account.Trade{
+		rollingTrade(0, 100),
+		rollingTrade(0, -50), // same day: one point for day 0
+		nil,
+		rollingTrade(20, -30),
+		rollingTrade(40, 60), // day 0 has left the 30-day window
+	}
+	series := rollingMetrics(trades, []int{30, 90})
+	require.Len(t, series, 2)
+
+	m30 := series[0]
+	assert.Equal(t, 30, m30.Days)
+	assert.Equal(t, []RollingPoint{
+		{Day: benchDay, Trades: 2, WinRate: types.RateFromFloat(0.5), ProfitFactor: types.RateFromFloat(2), MaxDrawdown: types.MoneyFromFloat(-50)},
+		{Day: benchDay + 20*secondsPerDay, Trades: 3, WinRate: types.RateFromFloat(1.0 / 3), ProfitFactor: types.RateFromFloat(1.25), MaxDrawdown: types.MoneyFromFloat(-80)},
+		{Day: benchDay + 40*secondsPerDay, Trades: 2, WinRate: types.RateFromFloat(0.5), ProfitFactor: types.RateFromFloat(2), MaxDrawdown: types.MoneyFromFloat(-30)},
+	}, m30.Points)
+
+	m90 := series[1]
+	require.Len(t, m90.Points, 3)
+	assert.Equal(t, 4, m90.Points[2].Trades)
+	assert.Equal(t, types.MoneyFromFloat(-80), m90.Points[2].MaxDrawdown)
+}
+
+func TestRollingMetrics_NoLossHasZeroProfitFactor(t *testing.T) {
+	series := rollingMetrics([]*account.Trade{rollingTrade(0, 10)}, []int{30})
+	require.Len(t, series, 1)
+	assert.Equal(t, types.Rate(0), series[0].Points[0].ProfitFactor)
+	assert.Equal(t, types.RateFromFloat(1), series[0].Points[0].WinRate)
+}
+
+func TestWriteOrgReport_Rolling(t *testing.T) {
+	var buf bytes.Buffer
+	WriteOrgReport(&buf, BacktestReportSummary{
+		Strategy: "ema-cross",
+		Rolling: rollingSummary(rollingMetrics([]*account.Trade{
+			rollingTrade(0, 100), rollingTrade(20, -30), rollingTrade(40, 60),
+		}, rollingWindowDays)),
+	})
+	out := buf.String()
+	assert.Contains(t, out, "** Rolling Performance (month end)")
+	assert.Contains(t, out, "30d Win%")
+	assert.Contains(t, out, "90d DD")
+	assert.Contains(t, out, "| 2024-01 ")
+	assert.Contains(t, out, "| 2024-02 ")
+}
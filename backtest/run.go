@@ -2,6 +2,10 @@ package backtest
 
 import (
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -16,6 +20,142 @@ type BacktestRun struct {
 	SpreadFiltered int         // opens suppressed by the max-spread filter
 	SpreadOpened   int         // opens that went through (for avg spread calc)
 	SpreadSum      types.Price // sum of candle.AvgSpread at each accepted open
+
+	// Early stop — set by the run loop when a StopOn condition is hit.
+	StopReason string
+	StoppedAt  types.Timestamp
+
+	// Interrupted is set when the caller's context was cancelled mid-run;
+	// the run then stops like an early stop, with StopReason
+	// StopInterrupted.
+	Interrupted bool
+
+	// CandleFaults counts the candle sanity violations seen while loading
+	// the run's data (see market.CandleChecker); nil for clean data.
+	CandleFaults map[market.CandleFault]int
+
+	// SliceExcluded counts the bars dropped by the request's calendar
+	// slice.
+	SliceExcluded int
+
+	// WarmupBars counts the bars before the start that were preloaded
+	// into the strategy and indicators.
+	WarmupBars int
+
+	// preload holds the warm-up bars Execute loaded for runWithIterator.
+	preload []market.Candle
+
+	// pairs feeds the regime's other pairs, nil unless a regime reads
+	// them; see loadPairFeed.
+	pairs *pairFeed
+
+	// Skipped lists the entry signals the governor or a paused equity
+	// curve throttle refused, in bar order.
+	Skipped []journal.SkippedSignal
+
+	// Filtered counts the entry signals the strategy's filter chain
+	// blocked, by filter name.
+	Filtered map[string]int
+
+	// Signals lists every entry signal with its filter decision and how
+	// price moved after it, in bar order, when the request journals
+	// signals.
+	Signals []journal.SignalRecord
+
+	// Throttled counts the entries sized at reduced risk because the
+	// run's equity curve was throttled.
+	Throttled int
+
+	// Rejected lists the entries the order path refused (planner gates,
+	// sizing, governor, warm-up), in bar order.
+	Rejected []journal.OrderDecision
+
+	// decisions holds the most recent bar's order decisions, for
+	// LastOrderDecisions.
+	decisions []journal.OrderDecision
+
+	// exposure holds per-currency peaks; see trackExposure.
+	exposure map[string]*ExposurePeak
+
+	// propFirm evaluates the request's prop-firm rules; nil without any.
+	propFirm *propFirm
+
+	// equity holds one sample per UTC day; see trackEquity.
+	equity []equityPoint
+
+	// conversion restates the run's money in the report currency; nil
+	// reports in the account currency. reportEquity is equity so restated,
+	// set by BuildBacktestResult.
+	conversion   *conversionSeries
+	reportEquity []equityPoint
+
+	// prices holds every bar close for the price chart, until pricesOver
+	// records that the run passed MaxPriceChartBars; see trackPrice.
+	prices     []pricePoint
+	pricesOver bool
+
+	// htf is the higher-timeframe feed, nil unless the request asks for one.
+	htf *htfFeed
+
+	// previewer and accountID are the run's broker and account, for
+	// PreviewOrder; previewer is nil when the broker cannot preview.
+	previewer brokers.OrderPreviewer
+	accountID string
+
+	// ticks is the sim's price store, for RecentTicks; nil when the
+	// broker is not a sim.
+	ticks *sim.PriceStore
+}
+
+// equityCurve is the daily equity the report shows: in the report
+// currency when the run has one, else the account's own.
+func (run *BacktestRun) equityCurve() []equityPoint {
+	if run.conversion != nil {
+		return run.reportEquity
+	}
+	return run.equity
+}
+
+// skippedByRule counts Skipped by governor rule; nil when nothing was
+// skipped.
+func (run *BacktestRun) skippedByRule() map[string]int {
+	if len(run.Skipped) == 0 {
+		return nil
+	}
+	out := make(map[string]int)
+	for _, s := range run.Skipped {
+		out[s.Rule]++
+	}
+	return out
+}
+
+// rejectedByReason counts Rejected by reason code; nil when nothing was
+// rejected.
+func (run *BacktestRun) rejectedByReason() map[string]int {
+	if len(run.Rejected) == 0 {
+		return nil
+	}
+	out := make(map[string]int)
+	for _, d := range run.Rejected {
+		out[d.Reason]++
+	}
+	return out
+}
+
+// spreadGuardedByRule counts the entries the spread guard blocked, by
+// rule; nil when it blocked none.
+func (run *BacktestRun) spreadGuardedByRule() map[string]int {
+	var out map[string]int
+	for _, d := range run.Rejected {
+		if d.Reason != journal.RejectSpreadGuard {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]int)
+		}
+		out[d.Detail]++
+	}
+	return out
 }
 
 // GetTrades returns the run's closed trade list, or nil if run is nil.
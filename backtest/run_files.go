@@ -0,0 +1,94 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+)
+
+// WriteTradesCSV writes trades as CSV, one row per closed trade, with the
+// same fields and units as the report's trade_details.
+func WriteTradesCSV(w io.Writer, trades []BacktestReportTrade) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"id", "instrument", "side", "units", "open_time", "open_price", "close_time", "close_price",
+		"pnl", "initial_stop", "stop", "take", "close_cause", "r_multiple", "mae", "mfe", "reason",
+	})
+	for _, t := range trades {
+		_ = cw.Write([]string{
+			t.ID,
+			t.Instrument,
+			t.Side,
+			fmt.Sprintf("%d", t.Units),
+			t.OpenTime,
+			market.FormatPrice(t.Instrument, t.OpenPrice),
+			t.CloseTime,
+			market.FormatPrice(t.Instrument, t.ClosePrice),
+			fmt.Sprintf("%.2f", t.PNL),
+			market.FormatPrice(t.Instrument, t.InitialStopPrice),
+			market.FormatPrice(t.Instrument, t.StopPrice),
+			market.FormatPrice(t.Instrument, t.TakeProfitPrice),
+			t.CloseCause,
+			fmt.Sprintf("%.2f", t.RMultiple),
+			market.FormatPrice(t.Instrument, t.MAE),
+			market.FormatPrice(t.Instrument, t.MFE),
+			t.Reason,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSignalsCSV writes the run's journaled entry signals as CSV, one
+// row per signal, taken or suppressed, with its filter decision and how
+// far price went its way (max_r, in multiples of the stop distance).
+func (run *Backtest) WriteSignalsCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"time", "account_id", "instrument", "side", "price", "stop", "taken",
+		"gate", "rule", "detail", "reason", "max_r", "stopped",
+	})
+	if run != nil && run.State != nil {
+		for _, s := range run.State.Signals {
+			_ = cw.Write([]string{
+				s.Time.Time().UTC().Format(time.RFC3339),
+				s.AccountID,
+				s.Instrument,
+				s.Side,
+				market.FormatPrice(s.Instrument, s.Price.Float64()),
+				market.FormatPrice(s.Instrument, s.Stop.Float64()),
+				strconv.FormatBool(s.Taken),
+				s.Gate,
+				s.Rule,
+				s.Detail,
+				s.Reason,
+				fmt.Sprintf("%.2f", s.MaxR.Float64()),
+				strconv.FormatBool(s.Stopped),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteEquityCSV writes the run's equity curve as CSV: the equity at the
+// last bar of each UTC day the run replayed, the series its Sharpe ratio
+// is computed from.
+func (run *Backtest) WriteEquityCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"date", "equity"})
+	if run != nil && run.State != nil {
+		for _, p := range run.State.equityCurve() {
+			_ = cw.Write([]string{
+				p.Day.Time().UTC().Format(time.DateOnly),
+				fmt.Sprintf("%.2f", p.Equity.Float64()),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,36 @@
+package backtest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestWriteEquityCSV(t *testing.T) {
+	run := &Backtest{State: &BacktestRun{}}
+	day := types.Timestamp(1772582400) // 2026-03-04
+	run.State.trackEquity(day+3600, types.MoneyFromFloat(1000), 0)
+	run.State.trackEquity(day+7200, types.MoneyFromFloat(1010.5), 0)
+	run.State.trackEquity(day+secondsPerDay, types.MoneyFromFloat(990), 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, run.WriteEquityCSV(&buf))
+	assert.Equal(t, "date,equity\n2026-03-04,1010.50\n2026-03-05,990.00\n", buf.String())
+}
+
+func TestWriteTradesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteTradesCSV(&buf, []BacktestReportTrade{{
+		ID: "T1", Instrument: "EURUSD", Side: "long", Units: 1000,
+		OpenTime: "2026-03-04T10:00:00Z", OpenPrice: 1.1, CloseTime: "2026-03-04T12:00:00Z", ClosePrice: 1.102,
+		PNL: 2, InitialStopPrice: 1.099, CloseCause: "TakeProfit", RMultiple: 2, Reason: "cross, up",
+	}}))
+	assert.Equal(t,
+		"id,instrument,side,units,open_time,open_price,close_time,close_price,pnl,initial_stop,stop,take,close_cause,r_multiple,mae,mfe,reason\n"+
+			"T1,EURUSD,long,1000,2026-03-04T10:00:00Z,1.10000,2026-03-04T12:00:00Z,1.10200,2.00,1.09900,0.00000,0.00000,TakeProfit,2.00,0.00000,0.00000,\"cross, up\"\n",
+		buf.String())
+}
@@ -0,0 +1,570 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// ScenarioConfig is a replay scenario script: trading actions and price
+// shocks applied to the sim at given times or once a price condition
+// holds, for reproducible stress runs ("gap 200 pips at 15:00") without
+// editing the ticks file. In YAML:
+//
+//	actions:
+//	  - name: long before the gap
+//	    at: 2024-03-01T14:00:00Z
+//	    do: open
+//	    instrument: EURUSD
+//	    units: 10000
+//	    stop-pips: 30
+//	  - at: 2024-03-01T15:00:00Z
+//	    do: shock
+//	    instrument: EURUSD
+//	    pips: -200
+//	  - when: bid <= 1.0650
+//	    instrument: EURUSD
+//	    do: close-all
+//
+// Each action fires once, on the first tick at or after At on which When
+// holds.
+//
+// Accounts allocates sim sub-accounts with their own starting balances
+// (see sim.Sim.AllocateSubAccount); an action naming one in its account
+// trades it instead of the replay's account, so several scripted
+// strategies can share one feed with separate balances, margin and
+// journal attribution:
+//
+//	accounts:
+//	  breakout: 5000
+//	  fade: 5000
+//	actions:
+//	  - at: +1h
+//	    account: fade
+//	    do: open
+//	    instrument: EURUSD
+//	    units: -10000
+type ScenarioConfig struct {
+	Accounts map[string]float64     `yaml:"accounts"`
+	Actions  []ScenarioActionConfig `yaml:"actions"`
+}
+
+// ScenarioActionConfig is one action of a ScenarioConfig. Prices are
+// floats here and fixed-point once compiled.
+type ScenarioActionConfig struct {
+	Name       string  `yaml:"name"`       // label for the report; defaults to "<do> #<n>"
+	At         string  `yaml:"at"`         // RFC3339 time, or +duration after the first tick (e.g. +90m)
+	When       string  `yaml:"when"`       // price condition on Instrument: "bid|ask|mid <op> <price>", op one of < <= > >=
+	Do         string  `yaml:"do"`         // open, close, close-all, modify or shock
+	Account    string  `yaml:"account"`    // sub-account from Accounts acted on; empty for the replay's account
+	Instrument string  `yaml:"instrument"` // the instrument acted on and watched by When
+	Units      int64   `yaml:"units"`      // open: signed units, negative sells
+	Stop       float64 `yaml:"stop"`       // open, modify: stop-loss price
+	Take       float64 `yaml:"take"`       // open, modify: take-profit price
+	StopPips   float64 `yaml:"stop-pips"`  // open, modify: stop-loss distance from the entry price
+	TakePips   float64 `yaml:"take-pips"`  // open, modify: take-profit distance from the entry price
+	Pips       float64 `yaml:"pips"`       // shock: signed move added to bid and ask
+	For        string  `yaml:"for"`        // shock: how long it lasts (Go duration); empty keeps it to the end
+}
+
+// Scenario actions (ScenarioActionConfig.Do).
+const (
+	ScenarioOpen     = "open"
+	ScenarioClose    = "close"
+	ScenarioCloseAll = "close-all"
+	ScenarioModify   = "modify"
+	ScenarioShock    = "shock"
+)
+
+// ScenarioFired records an action that has fired.
+type ScenarioFired struct {
+	Name       string
+	Do         string
+	Instrument string
+	At         types.Timestamp
+}
+
+// Scenario is a compiled ScenarioConfig, driven tick by tick by a replay:
+// Shock shifts each tick before the sim sees it, Apply then runs the
+// trading actions due on it.
+type Scenario struct {
+	accounts map[string]types.Money // sub-accounts to allocate, by ID
+	actions  []*scenarioAction
+	shocks   []scenarioShock
+	start    types.Timestamp // first tick seen; relative At values count from it
+	fired    []ScenarioFired
+}
+
+type scenarioAction struct {
+	name     string
+	do       string
+	inst     string
+	account  string // sub-account acted on; empty for the replay's
+	at       types.Timestamp
+	after    int64 // seconds after the first tick, for a relative At
+	relative bool
+	when     *priceCondition
+
+	units              int64
+	stop, take         types.Price
+	stopPips, takePips types.Pips
+	shift              types.Price
+	dur                int64 // seconds; 0 = to the end
+
+	done bool
+}
+
+type scenarioShock struct {
+	inst  string
+	shift types.Price
+	until types.Timestamp // 0 = to the end
+}
+
+type priceCondition struct {
+	field string // bid, ask or mid
+	op    string
+	level types.Price
+}
+
+// LoadScenario reads and compiles the scenario script at path.
+func LoadScenario(path string) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := ParseScenario(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sc, nil
+}
+
+// ParseScenario compiles a scenario script. Unknown keys are errors, so a
+// misspelt field doesn't silently drop part of a stress test.
+func ParseScenario(b []byte) (*Scenario, error) {
+	var cfg ScenarioConfig
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("scenario: %w", err)
+	}
+	return CompileScenario(cfg)
+}
+
+// CompileScenario validates cfg and converts it to fixed-point form.
+func CompileScenario(cfg ScenarioConfig) (*Scenario, error) {
+	sc := &Scenario{}
+	for id, balance := range cfg.Accounts {
+		id = strings.TrimSpace(id)
+		if id == "" || balance <= 0 {
+			return nil, errs.Newf(errs.ErrBadConfig, "scenario account %q: needs an ID and a balance > 0", id)
+		}
+		if sc.accounts == nil {
+			sc.accounts = make(map[string]types.Money)
+		}
+		sc.accounts[id] = types.MoneyFromFloat(balance)
+	}
+	for i, ac := range cfg.Actions {
+		a, err := compileScenarioAction(ac)
+		if err == nil && a.account != "" {
+			if _, ok := sc.accounts[a.account]; !ok {
+				err = errs.Newf(errs.ErrBadConfig, "account %q is not in accounts", a.account)
+			}
+		}
+		if err != nil {
+			name := ac.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("scenario action %s: %w", name, err)
+		}
+		if a.name == "" {
+			a.name = fmt.Sprintf("%s #%d", a.do, i+1)
+		}
+		sc.actions = append(sc.actions, a)
+	}
+	return sc, nil
+}
+
+func compileScenarioAction(ac ScenarioActionConfig) (*scenarioAction, error) {
+	a := &scenarioAction{
+		name:    strings.TrimSpace(ac.Name),
+		do:      strings.ToLower(strings.TrimSpace(ac.Do)),
+		inst:    market.NormalizeInstrument(strings.TrimSpace(ac.Instrument)),
+		account: strings.TrimSpace(ac.Account),
+		units:   ac.Units,
+	}
+
+	at := strings.TrimSpace(ac.At)
+	when := strings.TrimSpace(ac.When)
+	if at == "" && when == "" {
+		return nil, fmt.Errorf("needs at or when")
+	}
+	var err error
+	if a.at, a.after, a.relative, err = parseReplayAt(at); err != nil {
+		return nil, err
+	}
+	if when != "" {
+		if a.inst == "" {
+			return nil, fmt.Errorf("when needs an instrument")
+		}
+		cond, err := parsePriceCondition(when)
+		if err != nil {
+			return nil, err
+		}
+		a.when = cond
+	}
+
+	if ac.Stop < 0 || ac.Take < 0 || ac.StopPips < 0 || ac.TakePips < 0 {
+		return nil, fmt.Errorf("stop, take, stop-pips and take-pips must be >= 0")
+	}
+	if ac.Stop > 0 && ac.StopPips > 0 || ac.Take > 0 && ac.TakePips > 0 {
+		return nil, fmt.Errorf("give a price or pips for stop and take, not both")
+	}
+	a.stop, a.take = types.PriceFromFloat(ac.Stop), types.PriceFromFloat(ac.Take)
+	a.stopPips, a.takePips = types.PipsFromFloat(ac.StopPips), types.PipsFromFloat(ac.TakePips)
+	if (a.stopPips != 0 || a.takePips != 0) && market.GetInstrument(a.inst) == nil {
+		return nil, errs.Newf(errs.ErrUnknownInstrument, "unknown instrument %q: pips need its pip size", ac.Instrument)
+	}
+
+	switch a.do {
+	case ScenarioOpen:
+		if a.inst == "" || a.units == 0 {
+			return nil, fmt.Errorf("open needs an instrument and non-zero units")
+		}
+	case ScenarioClose:
+		if a.inst == "" {
+			return nil, fmt.Errorf("close needs an instrument")
+		}
+	case ScenarioCloseAll:
+	case ScenarioModify:
+		if a.inst == "" {
+			return nil, fmt.Errorf("modify needs an instrument")
+		}
+		if a.stop == 0 && a.take == 0 && a.stopPips == 0 && a.takePips == 0 {
+			return nil, fmt.Errorf("modify needs a stop or take")
+		}
+	case ScenarioShock:
+		inst := market.GetInstrument(a.inst)
+		if inst == nil {
+			return nil, fmt.Errorf("shock needs a known instrument, got %q", ac.Instrument)
+		}
+		if ac.Pips == 0 {
+			return nil, fmt.Errorf("shock needs non-zero pips")
+		}
+		a.shift = inst.PriceDeltaFromPips(types.PipsFromFloat(ac.Pips))
+		if f := strings.TrimSpace(ac.For); f != "" {
+			d, err := time.ParseDuration(f)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("bad for %q: want a positive duration", f)
+			}
+			a.dur = int64(d / time.Second)
+		}
+	case "":
+		return nil, fmt.Errorf("needs do")
+	default:
+		return nil, fmt.Errorf("unknown do %q (want open, close, close-all, modify or shock)", ac.Do)
+	}
+	return a, nil
+}
+
+// parseReplayAt parses an At value: an RFC3339 time, or +duration after
+// a replay's first tick (relative). Empty is the zero time.
+func parseReplayAt(at string) (ts types.Timestamp, after int64, relative bool, err error) {
+	if strings.HasPrefix(at, "+") {
+		d, err := time.ParseDuration(at[1:])
+		if err != nil || d < 0 {
+			return 0, 0, false, fmt.Errorf("bad at %q: want RFC3339 or +duration", at)
+		}
+		return 0, int64(d / time.Second), true, nil
+	}
+	if at == "" {
+		return 0, 0, false, nil
+	}
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("bad at %q: want RFC3339 or +duration", at)
+	}
+	return types.FromTime(t), 0, false, nil
+}
+
+// parsePriceCondition parses "bid >= 1.1050" and the like.
+func parsePriceCondition(s string) (*priceCondition, error) {
+	f := strings.Fields(s)
+	if len(f) != 3 {
+		return nil, fmt.Errorf("bad when %q: want \"bid|ask|mid <op> <price>\"", s)
+	}
+	c := &priceCondition{field: strings.ToLower(f[0]), op: f[1]}
+	switch c.field {
+	case "bid", "ask", "mid":
+	default:
+		return nil, fmt.Errorf("bad when %q: price must be bid, ask or mid", s)
+	}
+	switch c.op {
+	case "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("bad when %q: op must be <, <=, > or >=", s)
+	}
+	v, err := strconv.ParseFloat(f[2], 64)
+	if err != nil || v <= 0 {
+		return nil, fmt.Errorf("bad when %q: bad price %q", s, f[2])
+	}
+	c.level = types.PriceFromFloat(v)
+	return c, nil
+}
+
+func (c *priceCondition) holds(t market.Tick) bool {
+	px := t.Bid
+	switch c.field {
+	case "ask":
+		px = t.Ask
+	case "mid":
+		px = t.Mid()
+	}
+	switch c.op {
+	case "<":
+		return px < c.level
+	case "<=":
+		return px <= c.level
+	case ">":
+		return px > c.level
+	default:
+		return px >= c.level
+	}
+}
+
+// due reports whether a fires on t.
+func (a *scenarioAction) due(t market.Tick, start types.Timestamp) bool {
+	if a.done {
+		return false
+	}
+	if a.inst != "" && market.NormalizeInstrument(t.Instrument) != a.inst {
+		return false
+	}
+	at := a.at
+	if a.relative {
+		at = start + types.Timestamp(a.after)
+	}
+	if t.Timestamp < at {
+		return false
+	}
+	return a.when == nil || a.when.holds(t)
+}
+
+// Shock fires the shock actions due on t and returns t shifted by every
+// shock in force on its instrument. Conditions see the tick with earlier
+// shocks already applied, the prices the sim trades on.
+func (s *Scenario) Shock(t market.Tick) market.Tick {
+	if s == nil {
+		return t
+	}
+	if s.start == 0 {
+		s.start = t.Timestamp
+	}
+	inst := market.NormalizeInstrument(t.Instrument)
+	live := s.shocks[:0]
+	for _, sh := range s.shocks {
+		if sh.until == 0 || t.Timestamp < sh.until {
+			live = append(live, sh)
+		}
+	}
+	s.shocks = live
+
+	t = s.shifted(t, inst)
+	for _, a := range s.actions {
+		if a.do != ScenarioShock || !a.due(t, s.start) {
+			continue
+		}
+		a.done = true
+		sh := scenarioShock{inst: a.inst, shift: a.shift}
+		if a.dur > 0 {
+			sh.until = t.Timestamp + types.Timestamp(a.dur)
+		}
+		s.shocks = append(s.shocks, sh)
+		t.Bid += a.shift
+		t.Ask += a.shift
+		s.fire(a, t.Timestamp)
+	}
+	return t
+}
+
+func (s *Scenario) shifted(t market.Tick, inst string) market.Tick {
+	for _, sh := range s.shocks {
+		if sh.inst == inst {
+			t.Bid += sh.shift
+			t.Ask += sh.shift
+		}
+	}
+	return t
+}
+
+// Allocate creates the scenario's sub-accounts on e, skipping any a
+// restored state already holds. Call it once, before the first Apply.
+func (s *Scenario) Allocate(e *sim.Sim) error {
+	if s == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(s.accounts))
+	for id := range s.accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if e.SubAccount(id) != nil {
+			continue
+		}
+		if _, err := e.AllocateSubAccount(id, s.accounts[id]); err != nil {
+			return fmt.Errorf("scenario: %w", err)
+		}
+	}
+	return nil
+}
+
+// Apply runs the trading actions due on t against accountID on b, or the
+// sub-account an action names. Call it after the sim has been given t
+// (the value Shock returned), so orders fill at that tick's prices.
+func (s *Scenario) Apply(ctx context.Context, b brokers.Broker, accountID string, t market.Tick) error {
+	if s == nil {
+		return nil
+	}
+	if s.start == 0 {
+		s.start = t.Timestamp
+	}
+	for _, a := range s.actions {
+		if a.do == ScenarioShock || !a.due(t, s.start) {
+			continue
+		}
+		a.done = true
+		id := accountID
+		if a.account != "" {
+			id = a.account
+		}
+		if err := a.run(ctx, b, id, t); err != nil {
+			return fmt.Errorf("scenario %s at %s: %w", a.name, t.Timestamp, err)
+		}
+		s.fire(a, t.Timestamp)
+	}
+	return nil
+}
+
+func (s *Scenario) fire(a *scenarioAction, at types.Timestamp) {
+	s.fired = append(s.fired, ScenarioFired{Name: a.name, Do: a.do, Instrument: a.inst, At: at})
+}
+
+// Fired returns the actions that have fired, in order.
+func (s *Scenario) Fired() []ScenarioFired {
+	if s == nil {
+		return nil
+	}
+	return s.fired
+}
+
+// Pending returns the names of the actions that have not fired.
+func (s *Scenario) Pending() []string {
+	if s == nil {
+		return nil
+	}
+	var out []string
+	for _, a := range s.actions {
+		if !a.done {
+			out = append(out, a.name)
+		}
+	}
+	return out
+}
+
+func (a *scenarioAction) run(ctx context.Context, b brokers.Broker, accountID string, t market.Tick) error {
+	reason := "scenario: " + a.name
+	switch a.do {
+	case ScenarioOpen:
+		entry := t.Bid
+		if a.units > 0 {
+			entry = t.Ask
+		}
+		stop, take := a.levels(entry, a.units > 0)
+		res, err := b.SubmitMarketOrder(ctx, accountID, a.inst, a.units, stop.Float64())
+		if err != nil {
+			return err
+		}
+		if take > 0 && res != nil && res.TradeID != "" {
+			return b.UpdateTradeStop(ctx, accountID, res.TradeID, 0, take.Float64())
+		}
+		return nil
+	case ScenarioClose:
+		_, err := brokers.CloseAllForInstrument(ctx, b, accountID, a.inst, reason)
+		return err
+	case ScenarioCloseAll:
+		open, err := b.GetOpenTrades(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		var errs []error
+		for _, tr := range open {
+			inst := market.NormalizeInstrument(tr.Instrument)
+			if seen[inst] {
+				continue
+			}
+			seen[inst] = true
+			if _, err := brokers.CloseAllForInstrument(ctx, b, accountID, inst, reason); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	case ScenarioModify:
+		open, err := b.GetOpenTrades(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, tr := range open {
+			if market.NormalizeInstrument(tr.Instrument) != a.inst || tr.Units == 0 {
+				continue
+			}
+			stop, take := a.levels(types.PriceFromFloat(tr.EntryPrice), tr.Units > 0)
+			if err := b.UpdateTradeStop(ctx, accountID, tr.ID, stop.Float64(), take.Float64()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// levels resolves the action's stop and take for a trade entered at entry:
+// the absolute prices when given, otherwise the pip distances on the
+// losing and winning side of entry. Zero means unset.
+func (a *scenarioAction) levels(entry types.Price, long bool) (stop, take types.Price) {
+	stop, take = a.stop, a.take
+	inst := market.GetInstrument(a.inst)
+	if a.stopPips > 0 {
+		if long {
+			stop = inst.SubPips(entry, a.stopPips)
+		} else {
+			stop = inst.AddPips(entry, a.stopPips)
+		}
+	}
+	if a.takePips > 0 {
+		if long {
+			take = inst.AddPips(entry, a.takePips)
+		} else {
+			take = inst.SubPips(entry, a.takePips)
+		}
+	}
+	return stop, take
+}
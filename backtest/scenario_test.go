@@ -0,0 +1,215 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestParseScenario_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "unknown key", yaml: "actions:\n  - at: +1m\n    do: close-all\n    units2: 5\n", wantErr: "units2"},
+		{name: "no trigger", yaml: "actions:\n  - do: close-all\n", wantErr: "needs at or when"},
+		{name: "bad do", yaml: "actions:\n  - at: +1m\n    do: hedge\n", wantErr: `unknown do "hedge"`},
+		{name: "when without instrument", yaml: "actions:\n  - when: bid > 1.1\n    do: close-all\n", wantErr: "when needs an instrument"},
+		{name: "bad when", yaml: "actions:\n  - when: spread > 2\n    instrument: EURUSD\n    do: close-all\n", wantErr: "bid, ask or mid"},
+		{name: "open without units", yaml: "actions:\n  - at: +1m\n    do: open\n    instrument: EURUSD\n", wantErr: "non-zero units"},
+		{name: "stop twice", yaml: "actions:\n  - at: +1m\n    do: open\n    instrument: EURUSD\n    units: 1000\n    stop: 1.09\n    stop-pips: 10\n", wantErr: "not both"},
+		{name: "shock unknown instrument", yaml: "actions:\n  - name: gap\n    at: +1m\n    do: shock\n    instrument: FOOBAR\n    pips: 10\n", wantErr: "scenario action gap: shock needs a known instrument"},
+		{name: "account not allocated", yaml: "actions:\n  - name: fade\n    at: +1m\n    account: fade\n    do: close-all\n", wantErr: `scenario action fade: account "fade" is not in accounts`},
+		{name: "account without balance", yaml: "accounts:\n  fade: 0\n", wantErr: "balance > 0"},
+		{name: "bad for", yaml: "actions:\n  - at: +1m\n    do: shock\n    instrument: EURUSD\n    pips: 10\n    for: soon\n", wantErr: `bad for "soon"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScenario([]byte(tt.yaml))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	sc, err := ParseScenario(nil)
+	require.NoError(t, err)
+	assert.Empty(t, sc.Pending())
+}
+
+func TestScenario_ShockGapsThroughStop(t *testing.T) {
+	sc, err := ParseScenario([]byte(`
+actions:
+  - name: long
+    at: +1m
+    do: open
+    instrument: EURUSD
+    units: 10000
+    stop-pips: 30
+  - name: gap
+    at: +2m
+    do: shock
+    instrument: EURUSD
+    pips: -200
+  - name: never
+    when: bid > 2.0
+    instrument: EURUSD
+    do: close-all
+`))
+	require.NoError(t, err)
+
+	acct := account.NewAccount("acct", types.MoneyFromFloat(100_000))
+	eng := sim.NewSimBroker(acct, nil)
+	ctx := context.Background()
+
+	start := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	var seen []market.Tick
+	for i := 0; i < 4; i++ {
+		tick := market.Tick{
+			Timestamp:  types.FromTime(start.Add(time.Duration(i) * time.Minute)),
+			Instrument: "EURUSD",
+			BA:         market.BA{Bid: 110000, Ask: 110010},
+		}
+		tick = sc.Shock(tick)
+		seen = append(seen, tick)
+		require.NoError(t, eng.UpdatePrice(tick))
+		require.NoError(t, sc.Apply(ctx, eng, acct.ID, tick))
+		if i == 1 {
+			open, err := eng.GetOpenTrades(ctx, acct.ID)
+			require.NoError(t, err)
+			require.Len(t, open, 1)
+			assert.InDelta(t, 1.0971, open[0].StopLoss, 1e-9, "stop is 30 pips under the 1.10010 fill")
+		}
+	}
+
+	assert.Equal(t, types.Price(110000), seen[1].Bid)
+	assert.Equal(t, types.Price(108000), seen[2].Bid, "the shock moves bid and ask by 200 pips")
+	assert.Equal(t, types.Price(108010), seen[3].Ask, "and stays in force")
+
+	open, err := eng.GetOpenTrades(ctx, acct.ID)
+	require.NoError(t, err)
+	assert.Empty(t, open, "the gap takes the stop out")
+	assert.True(t, acct.Balance < types.MoneyFromFloat(100_000))
+
+	fired := sc.Fired()
+	require.Len(t, fired, 2)
+	assert.Equal(t, "long", fired[0].Name)
+	assert.Equal(t, ScenarioShock, fired[1].Do)
+	assert.Equal(t, seen[2].Timestamp, fired[1].At)
+	assert.Equal(t, []string{"never"}, sc.Pending())
+}
+
+func TestScenario_ModifyCloseAndShockExpiry(t *testing.T) {
+	sc, err := ParseScenario([]byte(`
+actions:
+  - at: +0s
+    do: open
+    instrument: EURUSD
+    units: -5000
+  - name: protect
+    when: mid <= 1.0990
+    instrument: EURUSD
+    do: modify
+    stop-pips: 20
+    take: 1.0900
+  - name: dip
+    at: +1m
+    do: shock
+    instrument: EURUSD
+    pips: -15
+    for: 2m
+  - name: flat
+    at: 2024-03-01T14:05:00Z
+    do: close
+    instrument: EURUSD
+`))
+	require.NoError(t, err)
+
+	acct := account.NewAccount("acct", types.MoneyFromFloat(100_000))
+	eng := sim.NewSimBroker(acct, nil)
+	ctx := context.Background()
+
+	start := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	var bids []types.Price
+	for i := 0; i < 6; i++ {
+		tick := market.Tick{
+			Timestamp:  types.FromTime(start.Add(time.Duration(i) * time.Minute)),
+			Instrument: "EURUSD",
+			BA:         market.BA{Bid: 110000, Ask: 110010},
+		}
+		tick = sc.Shock(tick)
+		bids = append(bids, tick.Bid)
+		require.NoError(t, eng.UpdatePrice(tick))
+		require.NoError(t, sc.Apply(ctx, eng, acct.ID, tick))
+		if i == 1 {
+			open, err := eng.GetOpenTrades(ctx, acct.ID)
+			require.NoError(t, err)
+			require.Len(t, open, 1)
+			assert.InDelta(t, 1.1020, open[0].StopLoss, 1e-9, "20 pips above the 1.10000 short entry")
+			assert.InDelta(t, 1.0900, open[0].TakeProfit, 1e-9)
+		}
+	}
+
+	assert.Equal(t, []types.Price{110000, 109850, 109850, 110000, 110000, 110000}, bids, "the dip lasts two minutes")
+	open, err := eng.GetOpenTrades(ctx, acct.ID)
+	require.NoError(t, err)
+	assert.Empty(t, open)
+	assert.Empty(t, sc.Pending())
+}
+
+func TestScenario_SubAccounts(t *testing.T) {
+	sc, err := ParseScenario([]byte(`
+accounts:
+  breakout: 5000
+  fade: 2000
+actions:
+  - at: +1m
+    do: open
+    instrument: EURUSD
+    units: 10000
+  - at: +1m
+    account: fade
+    do: open
+    instrument: EURUSD
+    units: -3000
+`))
+	require.NoError(t, err)
+
+	acct := account.NewAccount("acct", types.MoneyFromFloat(100_000))
+	eng := sim.NewSimBroker(acct, nil)
+	_, err = eng.AllocateSubAccount("breakout", types.MoneyFromFloat(7000))
+	require.NoError(t, err, "as if restored from state")
+	require.NoError(t, sc.Allocate(eng))
+	assert.Equal(t, types.MoneyFromFloat(7000), eng.SubAccount("breakout").Balance, "a restored sub-account is kept")
+	require.NotNil(t, eng.SubAccount("fade"))
+	assert.Equal(t, types.MoneyFromFloat(2000), eng.SubAccount("fade").Balance)
+
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		tick := market.Tick{
+			Timestamp:  types.FromTime(start.Add(time.Duration(i) * time.Minute)),
+			Instrument: "EURUSD",
+			BA:         market.BA{Bid: 110000, Ask: 110010},
+		}
+		require.NoError(t, eng.UpdatePrice(tick))
+		require.NoError(t, sc.Apply(ctx, eng, acct.ID, tick))
+	}
+
+	primary, err := eng.GetOpenTrades(ctx, acct.ID)
+	require.NoError(t, err)
+	require.Len(t, primary, 1)
+	assert.Equal(t, int64(10000), primary[0].Units)
+	fade, err := eng.GetOpenTrades(ctx, "fade")
+	require.NoError(t, err)
+	require.Len(t, fade, 1)
+	assert.Equal(t, int64(-3000), fade[0].Units)
+}
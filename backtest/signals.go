@@ -0,0 +1,222 @@
+package backtest
+
+import (
+	"sort"
+
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+// signalLog records a run's entry signals, taken and suppressed, and
+// follows each past the bar it fired on until price reaches its stop or
+// the run ends (BacktestRequest.JournalSignals). Every signal is measured
+// the same way, from the close of its bar with the stop the planner
+// would place, so a filter's suppressed signals compare directly with
+// the ones taken.
+type signalLog struct {
+	instrument  string
+	exit        strategy.ExitStrategy
+	defaultStop types.Pips
+
+	all  []*signalTrack // in the order recorded
+	open []*signalTrack // the ones still followed
+}
+
+// signalTrack is one recorded signal, done once price reaches its stop
+// or the run ends.
+type signalTrack struct {
+	rec  journal.SignalRecord
+	done bool
+	long bool
+	risk types.Price // close to stop
+	best types.Price // best move in the signal's favour so far
+}
+
+// newSignalLog returns the run's signal log; nil unless the request
+// journals signals. A nil log records nothing.
+func newSignalLog(req *BacktestRequest, exit strategy.ExitStrategy) *signalLog {
+	if !req.JournalSignals {
+		return nil
+	}
+	return &signalLog{instrument: req.Instrument, exit: exit, defaultStop: req.DefaultStopPips}
+}
+
+// blocked records sig, which the strategy's filter named filter blocked.
+func (l *signalLog) blocked(c market.Candle, sig strategy.Signal, filter string) {
+	if l == nil {
+		return
+	}
+	l.add(journal.SignalRecord{
+		Time:       c.Timestamp,
+		Instrument: l.instrument,
+		Side:       sig.Side.String(),
+		Gate:       journal.SignalGateFilter,
+		Rule:       filter,
+		Reason:     sig.Reason,
+	}, sig, c)
+}
+
+// decided records the order path's verdict on each of sig's entries.
+func (l *signalLog) decided(c market.Candle, sig strategy.Signal, ds []journal.OrderDecision) {
+	if l == nil {
+		return
+	}
+	for _, d := range ds {
+		rec := journal.SignalRecord{
+			Time:       c.Timestamp,
+			AccountID:  d.AccountID,
+			Instrument: d.Instrument,
+			Side:       d.Side,
+			Taken:      d.Accepted,
+			Reason:     sig.Reason,
+		}
+		if !d.Accepted {
+			rec.Gate, rec.Rule, rec.Detail = journal.SignalGateOrder, d.Reason, d.Detail
+		}
+		l.add(rec, sig, c)
+	}
+}
+
+// add prices rec at c's close and starts following it; one without a
+// usable stop has no R to measure and is done at once.
+func (l *signalLog) add(rec journal.SignalRecord, sig strategy.Signal, c market.Candle) {
+	rec.Price = c.Close
+	rec.Stop = l.stopFor(sig, c)
+	long := sig.Side == types.Long
+	risk := rec.Price - rec.Stop
+	if !long {
+		risk = rec.Stop - rec.Price
+	}
+	s := &signalTrack{rec: rec, long: long, risk: risk}
+	l.all = append(l.all, s)
+	if rec.Stop <= 0 || risk <= 0 {
+		s.done = true
+		return
+	}
+	l.open = append(l.open, s)
+}
+
+// stopFor is the initial stop the planner would give sig entered at c's
+// close: the exit strategy's, else the signal's own, else the run's
+// default stop distance.
+func (l *signalLog) stopFor(sig strategy.Signal, c market.Candle) types.Price {
+	if l.exit != nil && l.exit.Ready() {
+		if s := l.exit.InitialStop(sig.Side, c.Close, c); s != 0 {
+			return s
+		}
+	}
+	if sig.Stop != 0 {
+		return sig.Stop
+	}
+	inst := market.GetInstrument(l.instrument)
+	if l.defaultStop <= 0 || inst == nil {
+		return 0
+	}
+	if sig.Side == types.Long {
+		return inst.SubPips(c.Close, l.defaultStop)
+	}
+	return inst.AddPips(c.Close, l.defaultStop)
+}
+
+// observe moves the open signals through bar c. A bar that reaches the
+// stop ends the signal without counting its other extreme, since the
+// candle doesn't say which came first.
+func (l *signalLog) observe(c market.Candle) {
+	if l == nil || len(l.open) == 0 {
+		return
+	}
+	open := l.open[:0]
+	for _, s := range l.open {
+		stopped, move := c.Low <= s.rec.Stop, c.High-s.rec.Price
+		if !s.long {
+			stopped, move = c.High >= s.rec.Stop, s.rec.Price-c.Low
+		}
+		if stopped {
+			s.rec.Stopped = true
+			s.finish()
+			continue
+		}
+		s.best = max(s.best, move)
+		open = append(open, s)
+	}
+	clear(l.open[len(open):])
+	l.open = open
+}
+
+// finish ends the signals still open at the run's end and returns them
+// all in the order recorded.
+func (l *signalLog) finish() []journal.SignalRecord {
+	if l == nil {
+		return nil
+	}
+	out := make([]journal.SignalRecord, 0, len(l.all))
+	for _, s := range l.all {
+		if !s.done {
+			s.finish()
+		}
+		out = append(out, s.rec)
+	}
+	l.all, l.open = nil, nil
+	return out
+}
+
+// finish stops following s and sets its MaxR from the best move so far.
+func (s *signalTrack) finish() {
+	s.done = true
+	if r, err := types.MulDivFloor64(int64(s.best), int64(types.RateScale), int64(s.risk)); err == nil {
+		s.rec.MaxR = types.Rate(r)
+	}
+}
+
+// SignalGroup summarizes the journaled signals that met one fate: taken,
+// or suppressed by one filter or order-path rule.
+type SignalGroup struct {
+	Gate      string // journal.SignalGate*; empty for the signals taken
+	Rule      string
+	Signals   int
+	Reached1R int        // signals whose MaxR reached 1R
+	Stopped   int        // signals whose stop was reached before the run ended
+	AvgMaxR   types.Rate // mean MaxR
+}
+
+// signalGroups groups recs by fate, the taken signals first and then the
+// suppressed ones by gate and rule; nil when there are none.
+func signalGroups(recs []journal.SignalRecord) []SignalGroup {
+	if len(recs) == 0 {
+		return nil
+	}
+	type key struct{ gate, rule string }
+	sums := make(map[key]int64)
+	idx := make(map[key]int)
+	var out []SignalGroup
+	for _, r := range recs {
+		k := key{r.Gate, r.Rule}
+		i, ok := idx[k]
+		if !ok {
+			i = len(out)
+			idx[k] = i
+			out = append(out, SignalGroup{Gate: r.Gate, Rule: r.Rule})
+		}
+		g := &out[i]
+		g.Signals++
+		if r.MaxR >= types.Rate(types.RateScale) {
+			g.Reached1R++
+		}
+		if r.Stopped {
+			g.Stopped++
+		}
+		sums[k] += int64(r.MaxR)
+	}
+	for i := range out {
+		out[i].AvgMaxR = types.Rate(sums[key{out[i].Gate, out[i].Rule}] / int64(out[i].Signals))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Gate != out[j].Gate {
+			return out[i].Gate < out[j].Gate
+		}
+		return out[i].Rule < out[j].Rule
+	})
+	return out
+}
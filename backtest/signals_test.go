@@ -0,0 +1,141 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalLog_FollowsSignalsToTheirStop(t *testing.T) {
+	l := &signalLog{instrument: "EURUSD", defaultStop: types.PipsFromFloat(20)}
+	bar := func(ts types.Timestamp, high, low, cl types.Price) market.Candle {
+		return market.Candle{High: high, Low: low, Close: cl, Timestamp: ts}
+	}
+
+	// A short the session filter blocked, stopped 20 pips above the close
+	// by default, and a long the governor refused with its own stop.
+	l.blocked(bar(100, 110_050, 109_950, 110_000), strategy.Signal{Side: types.Short, Reason: "cross"}, "session")
+	l.decided(bar(100, 110_050, 109_950, 110_000), strategy.Signal{Side: types.Long, Stop: 109_750},
+		[]journal.OrderDecision{{Instrument: "EURUSD", Side: "long", Reason: journal.RejectGovernor, Detail: "max-trades-per-day"}})
+	require.Len(t, l.open, 2)
+
+	l.observe(bar(200, 110_100, 109_800, 109_900)) // the short 1R in favour
+	l.observe(bar(300, 110_250, 109_900, 110_200)) // the short stopped, its low not counted; the long 1R
+
+	got := l.finish()
+	require.Len(t, got, 2)
+	assert.Equal(t, journal.SignalRecord{
+		Time: 100, Instrument: "EURUSD", Side: "short", Price: 110_000, Stop: 110_200,
+		Gate: journal.SignalGateFilter, Rule: "session", Reason: "cross",
+		MaxR: types.RateFromFloat(1), Stopped: true,
+	}, got[0])
+	assert.Equal(t, journal.SignalGateOrder, got[1].Gate)
+	assert.Equal(t, journal.RejectGovernor, got[1].Rule)
+	assert.Equal(t, "max-trades-per-day", got[1].Detail)
+	assert.Equal(t, types.RateFromFloat(1), got[1].MaxR)
+	assert.False(t, got[1].Stopped)
+
+	// Without a stop there is no R to measure.
+	l = &signalLog{instrument: "EURUSD"}
+	l.decided(bar(100, 0, 0, 110_000), strategy.Signal{Side: types.Long}, []journal.OrderDecision{{Side: "long", Accepted: true}})
+	assert.Empty(t, l.open)
+	got = l.finish()
+	require.Len(t, got, 1)
+	assert.True(t, got[0].Taken)
+	assert.Zero(t, got[0].Stop)
+
+	var none *signalLog
+	none.observe(bar(100, 0, 0, 0))
+	assert.Nil(t, none.finish())
+}
+
+func TestRunWithIterator_JournalSignals(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	// Each bar closes 200 pips over the one before.
+	var candles []market.Candle
+	for i := 0; i < 4; i++ {
+		c := types.Price(120_000 + 2000*i)
+		candles = append(candles, market.Candle{
+			Open: c, High: c + 500, Low: c - 500, Close: c,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	j := &signalRecordJournal{}
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, j)}
+	r := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        alwaysLong{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[3].Timestamp, TF: types.H1},
+			Governor:        GovernorRules{MaxTradesPerDay: 1},
+			JournalSignals:  true,
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, r.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	// alwaysLong's stop is 500 pips under each close.
+	sigs := r.State.Signals
+	require.Len(t, sigs, 4)
+	assert.True(t, sigs[0].Taken)
+	assert.Equal(t, types.RateFromFloat(1.3), sigs[0].MaxR)
+	for i, s := range sigs[1:] {
+		assert.False(t, s.Taken)
+		assert.Equal(t, journal.RejectGovernor, s.Rule)
+		assert.Equal(t, types.RateFromFloat([]float64{0.9, 0.5, 0}[i]), s.MaxR)
+	}
+	assert.Equal(t, sigs, j.signals, "journaled through the sim")
+
+	groups := signalGroups(sigs)
+	require.Len(t, groups, 2)
+	assert.Equal(t, SignalGroup{Signals: 1, Reached1R: 1, AvgMaxR: types.RateFromFloat(1.3)}, groups[0])
+	assert.Equal(t, SignalGroup{Gate: journal.SignalGateOrder, Rule: journal.RejectGovernor, Signals: 3, AvgMaxR: 466_666}, groups[1])
+
+	var csv bytes.Buffer
+	require.NoError(t, r.WriteSignalsCSV(&csv))
+	assert.Contains(t, csv.String(), "2024-01-02T01:00:00Z,,EURUSD,long,1.22000,1.17000,false,order,governor,")
+
+	// Off by default.
+	r.Request.JournalSignals = false
+	acct = account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	require.NoError(t, r.runWithIterator(context.Background(), &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}, &fixedCandleIterator{candles: candles}))
+	assert.Empty(t, r.State.Signals)
+}
+
+func TestPrintSummary_Signals(t *testing.T) {
+	s := minSummary()
+	s.Signals = []BacktestReportSignals{
+		{Signals: 4, Reached1R: 2, Stopped: 1, AvgMaxR: 1.25},
+		{Gate: journal.SignalGateFilter, Rule: "adx", Signals: 3, Reached1R: 1, Stopped: 2, AvgMaxR: 0.5},
+	}
+	var buf bytes.Buffer
+	PrintSummary(&buf, s)
+	assert.Regexp(t, `taken\s+4   reached 1R: 2   stopped: 1   avg: 1\.25R`, buf.String())
+	assert.Regexp(t, `filter adx\s+3   reached 1R: 1   stopped: 2   avg: 0\.50R`, buf.String())
+}
+
+// signalRecordJournal captures journaled signals.
+type signalRecordJournal struct {
+	signalJournal
+	signals []journal.SignalRecord
+}
+
+func (j *signalRecordJournal) RecordSignal(s journal.SignalRecord) error {
+	j.signals = append(j.signals, s)
+	return nil
+}
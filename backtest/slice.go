@@ -0,0 +1,172 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// SliceConfig restricts a run's data to calendar slices, e.g. to train on
+// Q1-Q3 and test on Q4 of the same year. Bars and ticks outside the slice
+// are dropped before the strategy sees them and counted in the report.
+// Days are UTC calendar days.
+type SliceConfig struct {
+	// WeekdaysOnly drops Saturday and Sunday.
+	WeekdaysOnly bool `json:"weekdays-only,omitempty" yaml:"weekdays-only"`
+	// Months keeps only these months, 1-12.
+	Months []int `json:"months,omitempty" yaml:"months"`
+	// Quarters keeps only these quarters, 1-4; combined with Months, a
+	// month in either list is kept.
+	Quarters []int `json:"quarters,omitempty" yaml:"quarters"`
+	// Exclude drops whole days, e.g. holidays.
+	Exclude []DateRangeConfig `json:"exclude,omitempty" yaml:"exclude"`
+}
+
+// DateRangeConfig is an inclusive range of "YYYY-MM-DD" days; an empty To
+// means the single day From.
+type DateRangeConfig struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to,omitempty" yaml:"to"`
+}
+
+// IsZero reports whether c keeps everything.
+func (c SliceConfig) IsZero() bool {
+	return !c.WeekdaysOnly && len(c.Months) == 0 && len(c.Quarters) == 0 && len(c.Exclude) == 0
+}
+
+// CalendarSlice is the compiled form of a SliceConfig. The zero value
+// keeps everything.
+type CalendarSlice struct {
+	weekdaysOnly bool
+	months       uint16     // bit m set keeps month m; zero keeps every month
+	exclude      []timeSpan // sorted by from
+	within       []timeSpan // when set, only these spans are kept; sorted by from
+}
+
+// timeSpan is the half-open range [from, to).
+type timeSpan struct {
+	from, to types.Timestamp
+}
+
+// compileSlice validates c and converts it to a CalendarSlice.
+func compileSlice(c *SliceConfig) (CalendarSlice, error) {
+	var s CalendarSlice
+	if c == nil {
+		return s, nil
+	}
+	s.weekdaysOnly = c.WeekdaysOnly
+	for _, m := range c.Months {
+		if m < 1 || m > 12 {
+			return CalendarSlice{}, fmt.Errorf("slice month must be 1-12, got %d", m)
+		}
+		s.months |= 1 << m
+	}
+	for _, q := range c.Quarters {
+		if q < 1 || q > 4 {
+			return CalendarSlice{}, fmt.Errorf("slice quarter must be 1-4, got %d", q)
+		}
+		first := 3*(q-1) + 1
+		s.months |= 1<<first | 1<<(first+1) | 1<<(first+2)
+	}
+	for _, r := range c.Exclude {
+		from, err := time.Parse(time.DateOnly, strings.TrimSpace(r.From))
+		if err != nil {
+			return CalendarSlice{}, fmt.Errorf("slice exclude from %q: want YYYY-MM-DD", r.From)
+		}
+		to := from
+		if strings.TrimSpace(r.To) != "" {
+			if to, err = time.Parse(time.DateOnly, strings.TrimSpace(r.To)); err != nil {
+				return CalendarSlice{}, fmt.Errorf("slice exclude to %q: want YYYY-MM-DD", r.To)
+			}
+		}
+		if to.Before(from) {
+			return CalendarSlice{}, fmt.Errorf("slice exclude %s..%s: to is before from", r.From, r.To)
+		}
+		s.exclude = append(s.exclude, timeSpan{
+			from: types.FromTime(from),
+			to:   types.FromTime(to.AddDate(0, 0, 1)),
+		})
+	}
+	sort.Slice(s.exclude, func(i, j int) bool { return s.exclude[i].from < s.exclude[j].from })
+	return s, nil
+}
+
+// IsZero reports whether s keeps everything.
+func (s CalendarSlice) IsZero() bool {
+	return !s.weekdaysOnly && s.months == 0 && len(s.exclude) == 0 && len(s.within) == 0
+}
+
+// Within returns s further restricted to spans, which must be sorted and
+// not overlap. Cross-validation uses it to confine a run to its folds.
+func (s CalendarSlice) Within(spans []types.TimeRange) CalendarSlice {
+	s.within = make([]timeSpan, 0, len(spans))
+	for _, r := range spans {
+		s.within = append(s.within, timeSpan{from: r.Start, to: r.End})
+	}
+	return s
+}
+
+// Keep reports whether ts falls inside the slice.
+func (s CalendarSlice) Keep(ts types.Timestamp) bool {
+	if s.IsZero() {
+		return true
+	}
+	t := ts.Time().UTC()
+	if s.weekdaysOnly {
+		if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			return false
+		}
+	}
+	if s.months != 0 && s.months&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	if s.within != nil && !inSpans(ts, s.within) {
+		return false
+	}
+	return !inSpans(ts, s.exclude)
+}
+
+// inSpans reports whether ts falls in one of spans, sorted by from.
+func inSpans(ts types.Timestamp, spans []timeSpan) bool {
+	for _, r := range spans {
+		if ts < r.from {
+			return false
+		}
+		if ts < r.to {
+			return true
+		}
+	}
+	return false
+}
+
+// SlicedCandleIterator drops the candles of an underlying iterator that
+// fall outside a CalendarSlice.
+type SlicedCandleIterator struct {
+	market.CandleIterator
+
+	slice    CalendarSlice
+	excluded int
+}
+
+// NewSlicedCandleIterator wraps it with s.
+func NewSlicedCandleIterator(it market.CandleIterator, s CalendarSlice) *SlicedCandleIterator {
+	return &SlicedCandleIterator{CandleIterator: it, slice: s}
+}
+
+// Next yields the next candle inside the slice.
+func (it *SlicedCandleIterator) Next() (market.Candle, bool) {
+	for {
+		c, ok := it.CandleIterator.Next()
+		if !ok || it.slice.Keep(c.Timestamp) {
+			return c, ok
+		}
+		it.excluded++
+	}
+}
+
+// Excluded returns how many candles were dropped.
+func (it *SlicedCandleIterator) Excluded() int { return it.excluded }
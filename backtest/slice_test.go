@@ -0,0 +1,148 @@
+package backtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sliceTS(t *testing.T, s string) types.Timestamp {
+	t.Helper()
+	tm, err := time.Parse(time.RFC3339, s)
+	require.NoError(t, err)
+	return types.FromTime(tm)
+}
+
+func TestCompileSlice(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  SliceConfig
+		err  string
+	}{
+		{name: "zero", cfg: SliceConfig{}},
+		{name: "months and quarters", cfg: SliceConfig{Months: []int{1, 12}, Quarters: []int{2}}},
+		{name: "bad month", cfg: SliceConfig{Months: []int{13}}, err: "month must be 1-12"},
+		{name: "bad quarter", cfg: SliceConfig{Quarters: []int{0}}, err: "quarter must be 1-4"},
+		{name: "bad date", cfg: SliceConfig{Exclude: []DateRangeConfig{{From: "12/25/2024"}}}, err: "want YYYY-MM-DD"},
+		{name: "reversed range", cfg: SliceConfig{Exclude: []DateRangeConfig{{From: "2024-12-26", To: "2024-12-25"}}}, err: "to is before from"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := compileSlice(&tc.cfg)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	s, err := compileSlice(nil)
+	require.NoError(t, err)
+	assert.True(t, s.IsZero())
+}
+
+func TestCalendarSlice_Keep(t *testing.T) {
+	s, err := compileSlice(&SliceConfig{
+		WeekdaysOnly: true,
+		Quarters:     []int{1, 2, 3},
+		Exclude: []DateRangeConfig{
+			{From: "2024-07-04"},
+			{From: "2024-01-01", To: "2024-01-02"},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		ts   string
+		keep bool
+	}{
+		{"2024-01-01T10:00:00Z", false}, // excluded
+		{"2024-01-02T23:59:59Z", false}, // last second of an inclusive range
+		{"2024-01-03T00:00:00Z", true},
+		{"2024-01-06T12:00:00Z", false}, // Saturday
+		{"2024-07-03T12:00:00Z", true},
+		{"2024-07-04T12:00:00Z", false}, // single excluded day
+		{"2024-07-05T00:00:00Z", true},
+		{"2024-09-30T12:00:00Z", true},
+		{"2024-10-01T12:00:00Z", false}, // Q4
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.keep, s.Keep(sliceTS(t, tc.ts)), tc.ts)
+	}
+
+	assert.True(t, CalendarSlice{}.Keep(sliceTS(t, "2024-01-06T12:00:00Z")), "zero slice keeps weekends")
+}
+
+func TestSlicedCandleIterator(t *testing.T) {
+	s, err := compileSlice(&SliceConfig{WeekdaysOnly: true})
+	require.NoError(t, err)
+
+	var candles []market.Candle
+	for d := 5; d <= 8; d++ { // Fri 5 Jan 2024 .. Mon 8 Jan
+		candles = append(candles, market.Candle{Timestamp: types.FromTime(time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC))})
+	}
+	it := NewSlicedCandleIterator(&fixedCandleIterator{candles: candles}, s)
+
+	var days []int
+	for c, ok := it.Next(); ok; c, ok = it.Next() {
+		days = append(days, c.Timestamp.Time().UTC().Day())
+	}
+	assert.Equal(t, []int{5, 8}, days)
+	assert.Equal(t, 2, it.Excluded())
+}
+
+func TestCSVTicksFeed_Slice(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "ticks.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte(`time,instrument,bid,ask
+2024-12-24T09:00:00Z,EUR_USD,1.1000,1.1002
+2024-12-25T09:00:00Z,EUR_USD,1.1010,1.1012
+2024-12-26T09:00:00Z,EUR_USD,1.1020,1.1022
+`), 0o644))
+
+	feed, err := NewCSVTicksFeed(csvPath, 0, 0)
+	require.NoError(t, err)
+	defer feed.Close()
+	feed.Slice, err = compileSlice(&SliceConfig{Exclude: []DateRangeConfig{{From: "2024-12-25"}}})
+	require.NoError(t, err)
+
+	var n int
+	for {
+		_, ok, err := feed.Next()
+		require.NoError(t, err)
+		if !ok {
+			break
+		}
+		n++
+	}
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, feed.Excluded())
+}
+
+func TestCompileBacktests_Slice(t *testing.T) {
+	run := RunConfig{
+		Name:     "q4",
+		Data:     DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2024-01-01", To: "2025-01-01"},
+		Strategy: strategy.StrategyConfig{Kind: "fake"},
+	}
+	base := hashBacktestConfig(run, RunDefaults{})
+
+	run.Data.Slice = &SliceConfig{Quarters: []int{4}}
+	runs, err := CompileBacktests(&Config{Runs: []RunConfig{run}})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.False(t, runs[0].Request.Slice.IsZero())
+	assert.False(t, runs[0].Request.Slice.Keep(sliceTS(t, "2024-03-01T00:00:00Z")))
+	assert.NotEqual(t, base, runs[0].Request.ConfigHash, "slicing changes the data a run sees")
+
+	run.Data.Slice = &SliceConfig{Months: []int{0}}
+	_, err = CompileBacktests(&Config{Runs: []RunConfig{run}})
+	require.ErrorContains(t, err, "build data slice")
+}
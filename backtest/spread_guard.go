@@ -0,0 +1,156 @@
+package backtest
+
+import (
+	"fmt"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Spread guard rules, the Detail of the journal.RejectSpreadGuard
+// decisions they cause.
+const (
+	SpreadRuleMaxPips  = "max-pips"  // spread above the instrument's cap
+	SpreadRuleAvgRatio = "avg-ratio" // spread above a multiple of its rolling average
+)
+
+// DefaultSpreadGuardWindow is the rolling-average window, in bars, of a
+// spread guard with a max-ratio and no window.
+const DefaultSpreadGuardWindow = 20
+
+// SpreadGuardConfig blocks entries on a bar whose spread exceeds MaxPips,
+// or MaxRatio times the average spread of the Window bars before it,
+// whatever the strategy. Either limit may be set per instrument, which
+// replaces both. Off when nothing is set.
+type SpreadGuardConfig struct {
+	MaxPips  float64 `json:"max-pips,omitempty"  yaml:"max-pips"`
+	MaxRatio float64 `json:"max-ratio,omitempty" yaml:"max-ratio"`
+	Window   int     `json:"window,omitempty"    yaml:"window"`
+
+	// Instruments overrides MaxPips and MaxRatio per instrument.
+	Instruments map[string]SpreadLimitConfig `json:"instruments,omitempty" yaml:"instruments"`
+}
+
+// SpreadLimitConfig is one instrument's spread limits.
+type SpreadLimitConfig struct {
+	MaxPips  float64 `json:"max-pips,omitempty"  yaml:"max-pips"`
+	MaxRatio float64 `json:"max-ratio,omitempty" yaml:"max-ratio"`
+}
+
+// IsZero reports whether nothing is configured.
+func (c SpreadGuardConfig) IsZero() bool {
+	return c.MaxPips == 0 && c.MaxRatio == 0 && c.Window == 0 && len(c.Instruments) == 0
+}
+
+// SpreadGuardRules is the spread guard compiled for one run's instrument.
+// The zero value blocks nothing.
+type SpreadGuardRules struct {
+	MaxPips  types.Pips // zero sets no cap
+	MaxRatio types.Rate // multiple of the rolling average; zero sets none
+	Window   int        // bars in the rolling average
+}
+
+// IsZero reports whether the rules block nothing.
+func (r SpreadGuardRules) IsZero() bool { return r.MaxPips == 0 && r.MaxRatio == 0 }
+
+// compileSpreadGuard validates cfg and picks instrument's limits from it.
+func compileSpreadGuard(cfg SpreadGuardConfig, instrument string) (SpreadGuardRules, error) {
+	if cfg.Window < 0 {
+		return SpreadGuardRules{}, fmt.Errorf("spread-guard: window must be >= 0, got %d", cfg.Window)
+	}
+	limits := SpreadLimitConfig{MaxPips: cfg.MaxPips, MaxRatio: cfg.MaxRatio}
+	if err := validSpreadLimit(limits); err != nil {
+		return SpreadGuardRules{}, fmt.Errorf("spread-guard: %w", err)
+	}
+	want := market.NormalizeInstrument(instrument)
+	for inst, l := range cfg.Instruments {
+		if err := validSpreadLimit(l); err != nil {
+			return SpreadGuardRules{}, fmt.Errorf("spread-guard: %s: %w", inst, err)
+		}
+		if market.NormalizeInstrument(inst) == want {
+			limits = l
+		}
+	}
+	r := SpreadGuardRules{
+		MaxPips:  types.PipsFromFloat(limits.MaxPips),
+		MaxRatio: types.RateFromFloat(limits.MaxRatio),
+	}
+	if r.MaxRatio > 0 {
+		r.Window = cfg.Window
+		if r.Window == 0 {
+			r.Window = DefaultSpreadGuardWindow
+		}
+	}
+	return r, nil
+}
+
+func validSpreadLimit(l SpreadLimitConfig) error {
+	if l.MaxPips < 0 {
+		return fmt.Errorf("max-pips must be >= 0, got %g", l.MaxPips)
+	}
+	if l.MaxRatio != 0 && l.MaxRatio < 1 {
+		return fmt.Errorf("max-ratio must be >= 1, got %g", l.MaxRatio)
+	}
+	return nil
+}
+
+// spreadGuard applies SpreadGuardRules bar by bar, keeping the rolling
+// average spread the ratio limit is measured against.
+type spreadGuard struct {
+	max   types.Price
+	ratio types.Rate
+
+	window []types.Price // the last bars' spreads, a ring
+	next   int
+	full   bool
+	sum    int64
+}
+
+// newSpreadGuard returns the guard for r on inst; nil when r blocks
+// nothing. A nil guard allows every spread.
+func newSpreadGuard(r SpreadGuardRules, inst *market.Instrument) *spreadGuard {
+	if r.IsZero() || inst == nil {
+		return nil
+	}
+	g := &spreadGuard{ratio: r.MaxRatio}
+	if r.MaxPips > 0 {
+		g.max = inst.PriceDeltaFromPips(r.MaxPips)
+	}
+	if r.MaxRatio > 0 {
+		g.window = make([]types.Price, r.Window)
+	}
+	return g
+}
+
+// check returns the rule spread breaks, or "" when entries may go ahead.
+// The ratio only applies once the window is full and its average is
+// above zero, so data with equal bid and ask never trips it.
+func (g *spreadGuard) check(spread types.Price) string {
+	if g == nil || spread <= 0 {
+		return ""
+	}
+	if g.max > 0 && spread > g.max {
+		return SpreadRuleMaxPips
+	}
+	if g.ratio > 0 && g.full && g.sum > 0 {
+		// spread > ratio × sum/n, kept in integers.
+		if int64(spread)*int64(types.RateScale)*int64(len(g.window)) > int64(g.ratio)*g.sum {
+			return SpreadRuleAvgRatio
+		}
+	}
+	return ""
+}
+
+// observe adds a bar's spread to the rolling average, after its entries
+// were checked, so a spike isn't measured against itself.
+func (g *spreadGuard) observe(spread types.Price) {
+	if g == nil || len(g.window) == 0 {
+		return
+	}
+	g.sum += int64(spread) - int64(g.window[g.next])
+	g.window[g.next] = spread
+	g.next++
+	if g.next == len(g.window) {
+		g.next, g.full = 0, true
+	}
+}
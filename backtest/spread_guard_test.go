@@ -0,0 +1,112 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileSpreadGuard(t *testing.T) {
+	got, err := compileSpreadGuard(SpreadGuardConfig{}, "EURUSD")
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+
+	cfg := SpreadGuardConfig{
+		MaxPips: 3, MaxRatio: 2.5,
+		Instruments: map[string]SpreadLimitConfig{"usd_jpy": {MaxPips: 4}},
+	}
+	got, err = compileSpreadGuard(cfg, "EURUSD")
+	require.NoError(t, err)
+	assert.Equal(t, SpreadGuardRules{MaxPips: types.PipsFromFloat(3), MaxRatio: types.RateFromFloat(2.5), Window: DefaultSpreadGuardWindow}, got)
+	got, err = compileSpreadGuard(cfg, "USD_JPY")
+	require.NoError(t, err)
+	assert.Equal(t, SpreadGuardRules{MaxPips: types.PipsFromFloat(4)}, got, "the instrument's limits replace both")
+
+	_, err = compileSpreadGuard(SpreadGuardConfig{MaxRatio: 0.5}, "EURUSD")
+	require.ErrorContains(t, err, "spread-guard: max-ratio must be >= 1")
+	_, err = compileSpreadGuard(SpreadGuardConfig{Instruments: map[string]SpreadLimitConfig{"GBPUSD": {MaxPips: -1}}}, "EURUSD")
+	require.ErrorContains(t, err, "spread-guard: GBPUSD: max-pips must be >= 0")
+	_, err = compileSpreadGuard(SpreadGuardConfig{Window: -1}, "EURUSD")
+	require.ErrorContains(t, err, "window must be >= 0")
+}
+
+func TestSpreadGuard_CapAndRollingAverage(t *testing.T) {
+	inst := market.GetInstrument("EURUSD")
+	g := newSpreadGuard(SpreadGuardRules{MaxPips: types.PipsFromFloat(5), MaxRatio: types.RateFromFloat(2), Window: 3}, inst)
+
+	assert.Equal(t, SpreadRuleMaxPips, g.check(51))
+	assert.Empty(t, g.check(30), "no ratio until the window is full")
+	for _, s := range []types.Price{10, 10, 16} {
+		g.observe(s)
+	}
+	assert.Empty(t, g.check(24), "exactly twice the average of 12")
+	assert.Equal(t, SpreadRuleAvgRatio, g.check(25))
+	g.observe(40) // the 10 drops out: average 22
+	assert.Empty(t, g.check(44))
+
+	// Data with equal bid and ask has nothing to average.
+	flat := newSpreadGuard(SpreadGuardRules{MaxRatio: types.RateFromFloat(2), Window: 2}, inst)
+	flat.observe(0)
+	flat.observe(0)
+	assert.Empty(t, flat.check(0))
+	assert.Empty(t, flat.check(10))
+
+	assert.Nil(t, newSpreadGuard(SpreadGuardRules{}, inst))
+	var none *spreadGuard
+	none.observe(10)
+	assert.Empty(t, none.check(1000))
+}
+
+func TestHashBacktestConfig_SpreadGuard(t *testing.T) {
+	cfg := RunConfig{Data: DataConfig{Instrument: "EURUSD", Timeframe: "H1"}}
+	base := hashBacktestConfig(cfg, RunDefaults{})
+	assert.NotEqual(t, base, hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{SpreadGuard: SpreadGuardConfig{MaxRatio: 3}}}))
+	assert.Equal(t, base, hashBacktestConfig(cfg, RunDefaults{Execution: ExecutionConfig{SpreadGuard: SpreadGuardConfig{}}}))
+}
+
+func TestRunWithIterator_SpreadGuardBlocksEntries(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	// A 1 pip spread that widens to 4 pips on the third bar and 8 on the
+	// fourth.
+	var candles []market.Candle
+	for i, spread := range []types.Price{10, 10, 40, 80, 10} {
+		c := types.Price(120_000)
+		candles = append(candles, market.Candle{
+			Open: c, High: c + 500, Low: c - 500, Close: c, AvgSpread: spread,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil)}
+	r := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        alwaysLong{},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: candles[0].Timestamp, End: candles[4].Timestamp, TF: types.H1},
+			SpreadGuard:     SpreadGuardRules{MaxPips: types.PipsFromFloat(6), MaxRatio: types.RateFromFloat(3), Window: 2},
+		},
+		State: &BacktestRun{},
+	}
+	require.NoError(t, r.runWithIterator(context.Background(), tr, &fixedCandleIterator{candles: candles}))
+
+	var guarded []string
+	for _, d := range r.State.Rejected {
+		if d.Reason == journal.RejectSpreadGuard {
+			guarded = append(guarded, d.Detail)
+		}
+	}
+	assert.Equal(t, []string{SpreadRuleAvgRatio, SpreadRuleMaxPips}, guarded)
+	assert.Equal(t, map[string]int{SpreadRuleAvgRatio: 1, SpreadRuleMaxPips: 1}, r.State.spreadGuardedByRule())
+}
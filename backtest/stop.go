@@ -0,0 +1,111 @@
+package backtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// StopConfig lists the early-stop ("break-on") conditions for a run. Any
+// condition that is hit ends the run at the end of that bar: open lots are
+// flattened as on a normal finish and the report records why the run
+// stopped. Zero values disable the corresponding condition. These are
+// meant for sweeps, to abandon obviously bad parameter sets quickly.
+type StopConfig struct {
+	MaxDrawdownPct float64 `json:"max-drawdown-pct,omitempty" yaml:"max-drawdown-pct"` // peak-to-trough equity drop, e.g. 20 = 20%
+	EquityFloor    float64 `json:"equity-floor,omitempty"     yaml:"equity-floor"`     // stop once equity falls below this
+	MaxTrades      int     `json:"max-trades,omitempty"       yaml:"max-trades"`       // stop once this many trades have closed
+	At             string  `json:"at,omitempty"               yaml:"at"`               // stop at this date (2006-01-02) or RFC3339 time
+}
+
+// IsZero reports whether no stop condition is configured.
+func (c StopConfig) IsZero() bool {
+	return c == StopConfig{}
+}
+
+// StopConditions is the compiled, fixed-point form of StopConfig.
+type StopConditions struct {
+	MaxDrawdownPct types.Rate  // fraction of peak equity, RateScale-scaled
+	EquityFloor    types.Money // minimum equity
+	MaxTrades      int         // closed-trade count
+	At             types.Timestamp
+}
+
+// IsZero reports whether no stop condition is set.
+func (c StopConditions) IsZero() bool {
+	return c == StopConditions{}
+}
+
+// compileStopConditions validates cfg and converts it to fixed-point form.
+func compileStopConditions(cfg StopConfig) (StopConditions, error) {
+	if cfg.MaxDrawdownPct < 0 || cfg.MaxDrawdownPct > 100 {
+		return StopConditions{}, fmt.Errorf("max-drawdown-pct must be between 0 and 100, got %v", cfg.MaxDrawdownPct)
+	}
+	if cfg.EquityFloor < 0 {
+		return StopConditions{}, fmt.Errorf("equity-floor must be >= 0, got %v", cfg.EquityFloor)
+	}
+	if cfg.MaxTrades < 0 {
+		return StopConditions{}, fmt.Errorf("max-trades must be >= 0, got %d", cfg.MaxTrades)
+	}
+
+	sc := StopConditions{
+		MaxDrawdownPct: types.RateFromFloat(cfg.MaxDrawdownPct / 100.0),
+		EquityFloor:    types.MoneyFromFloat(cfg.EquityFloor),
+		MaxTrades:      cfg.MaxTrades,
+	}
+	if at := strings.TrimSpace(cfg.At); at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", at)
+		}
+		if err != nil {
+			return StopConditions{}, fmt.Errorf("bad stop time %q (use 2006-01-02 or RFC3339)", cfg.At)
+		}
+		sc.At = types.FromTime(t)
+	}
+	return sc, nil
+}
+
+// StopInterrupted is the StopReason of a run whose context was cancelled
+// (Ctrl-C) before its data ran out.
+const StopInterrupted = "interrupted"
+
+// stopChecker evaluates StopConditions bar by bar. It tracks the equity
+// high-water mark itself, so it must see every bar's equity.
+type stopChecker struct {
+	cond StopConditions
+	peak types.Money
+}
+
+// before reports a stop reason if the bar stamped ts is at or past the
+// configured stop time. It runs before the bar is processed.
+func (s *stopChecker) before(ts types.Timestamp) string {
+	if s.cond.At != 0 && ts >= s.cond.At {
+		return fmt.Sprintf("reached stop time %s", s.cond.At.Time().UTC().Format(time.RFC3339))
+	}
+	return ""
+}
+
+// after reports a stop reason once the bar's fills have been applied, given
+// the account's current equity and closed-trade count.
+func (s *stopChecker) after(equity types.Money, trades int) string {
+	if equity > s.peak {
+		s.peak = equity
+	}
+	if s.cond.EquityFloor > 0 && equity < s.cond.EquityFloor {
+		return fmt.Sprintf("equity %.2f below floor %.2f", equity.Float64(), s.cond.EquityFloor.Float64())
+	}
+	if s.cond.MaxDrawdownPct > 0 && s.peak > 0 && equity < s.peak {
+		dd, err := types.MulDivFloor64(int64(s.peak-equity), int64(types.RateScale), int64(s.peak))
+		if err == nil && types.Rate(dd) >= s.cond.MaxDrawdownPct {
+			return fmt.Sprintf("drawdown %.2f%% reached limit %.2f%%",
+				types.Rate(dd).Float64()*100, s.cond.MaxDrawdownPct.Float64()*100)
+		}
+	}
+	if s.cond.MaxTrades > 0 && trades >= s.cond.MaxTrades {
+		return fmt.Sprintf("completed %d trades", trades)
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileStopConditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     StopConfig
+		want    StopConditions
+		wantErr string
+	}{
+		{name: "zero", cfg: StopConfig{}, want: StopConditions{}},
+		{
+			name: "all",
+			cfg:  StopConfig{MaxDrawdownPct: 20, EquityFloor: 5000, MaxTrades: 50, At: "2024-06-01"},
+			want: StopConditions{
+				MaxDrawdownPct: types.RateFromFloat(0.20),
+				EquityFloor:    types.MoneyFromFloat(5000),
+				MaxTrades:      50,
+				At:             types.Timestamp(1717200000),
+			},
+		},
+		{name: "rfc3339", cfg: StopConfig{At: "2024-06-01T12:00:00Z"}, want: StopConditions{At: types.Timestamp(1717243200)}},
+		{name: "bad drawdown", cfg: StopConfig{MaxDrawdownPct: 120}, wantErr: "max-drawdown-pct"},
+		{name: "negative floor", cfg: StopConfig{EquityFloor: -1}, wantErr: "equity-floor"},
+		{name: "negative trades", cfg: StopConfig{MaxTrades: -1}, wantErr: "max-trades"},
+		{name: "bad time", cfg: StopConfig{At: "June"}, wantErr: "bad stop time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compileStopConditions(tt.cfg)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStopChecker(t *testing.T) {
+	money := types.MoneyFromFloat
+
+	t.Run("disabled", func(t *testing.T) {
+		s := stopChecker{}
+		assert.Empty(t, s.before(types.Timestamp(1<<40)))
+		assert.Empty(t, s.after(0, 1000))
+	})
+
+	t.Run("drawdown from peak", func(t *testing.T) {
+		s := stopChecker{cond: StopConditions{MaxDrawdownPct: types.RateFromFloat(0.10)}}
+		assert.Empty(t, s.after(money(10_000), 0))
+		assert.Empty(t, s.after(money(12_000), 0))
+		assert.Empty(t, s.after(money(10_900), 0), "9.2% below the 12k peak")
+		assert.Equal(t, "drawdown 10.00% reached limit 10.00%", s.after(money(10_800), 0))
+	})
+
+	t.Run("equity floor", func(t *testing.T) {
+		s := stopChecker{cond: StopConditions{EquityFloor: money(9_000)}}
+		assert.Empty(t, s.after(money(9_000), 0))
+		assert.Equal(t, "equity 8999.99 below floor 9000.00", s.after(money(8_999.99), 0))
+	})
+
+	t.Run("max trades", func(t *testing.T) {
+		s := stopChecker{cond: StopConditions{MaxTrades: 3}}
+		assert.Empty(t, s.after(money(10_000), 2))
+		assert.Equal(t, "completed 3 trades", s.after(money(10_000), 3))
+	})
+
+	t.Run("stop time", func(t *testing.T) {
+		s := stopChecker{cond: StopConditions{At: types.Timestamp(1704070800)}}
+		assert.Empty(t, s.before(types.Timestamp(1704067200)))
+		assert.Equal(t, "reached stop time 2024-01-01T01:00:00Z", s.before(types.Timestamp(1704070800)))
+	})
+}
+
+func TestRunWithIterator_StopsEarly(t *testing.T) {
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	tr := &engine.Trader{Account: acct}
+
+	strat := &countingStrategy{}
+	run := &Backtest{
+		Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        strat,
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.TimeRange{Start: types.Timestamp(1704067200), End: types.Timestamp(1704078000), TF: types.H1},
+			StopOn:          StopConditions{At: types.Timestamp(1704070800)},
+		},
+		State: &BacktestRun{},
+	}
+	candle := func(ts int64) market.Candle {
+		return market.Candle{Open: 1100000, High: 1101000, Low: 1099000, Close: 1100000, Timestamp: types.Timestamp(ts)}
+	}
+	itr := &fixedCandleIterator{candles: []market.Candle{candle(1704067200), candle(1704070800), candle(1704074400)}}
+
+	require.NoError(t, run.runWithIterator(context.Background(), tr, itr))
+	assert.Equal(t, 1, strat.calls, "bars at or after the stop time are not processed")
+	assert.Equal(t, "reached stop time 2024-01-01T01:00:00Z", run.State.StopReason)
+
+	res := run.BuildBacktestResult(acct)
+	require.NotNil(t, res)
+	assert.Equal(t, run.State.StopReason, res.StopReason)
+	assert.Equal(t, types.Timestamp(1704070800), res.End)
+}
@@ -21,6 +21,11 @@ func (testFake) Update(_ context.Context, _ *market.Candle, _ strategy.StrategyC
 	return strategy.Hold("hold")
 }
 
+// testGrid stands in for strategies/grid, which needs safety limits.
+type testGrid struct{ testFake }
+
+func (testGrid) RequiresSafetyLimits() bool { return true }
+
 func init() {
 	build := func(map[string]any) (strategy.Strategy, error) {
 		return testFake{}, nil
@@ -33,4 +38,7 @@ func init() {
 	strategy.MustRegisterStrategy(build, "ema-cross")
 	strategy.MustRegisterStrategy(build, "ema-cross-adx")
 	strategy.MustRegisterStrategy(build, "donchian", "donchian-breakout")
+	strategy.MustRegisterStrategy(func(map[string]any) (strategy.Strategy, error) {
+		return testGrid{}, nil
+	}, "grid")
 }
@@ -0,0 +1,485 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// StressConfig is a set of price shocks injected into a replay to see how
+// the account holds up before a strategy goes live: how far equity falls,
+// how close margin comes to a closeout, and where stops actually fill when
+// the price jumps past them. In YAML:
+//
+//	seed: 7
+//	shocks:
+//	  - name: NFP gap
+//	    kind: gap
+//	    instrument: EURUSD
+//	    at: 2024-03-08T13:30:00Z
+//	    pips: -150
+//	  - kind: spread
+//	    instrument: EURUSD
+//	    within: 8h
+//	    pips: 25
+//	    for: 10m
+//	  - kind: whipsaw
+//	    instrument: GBPUSD
+//	    at: +2h
+//	    pips: 40
+//	    for: 1m
+//	    count: 6
+//
+// A shock with no At lands at a random time within Within of the first
+// tick, drawn from Seed, so a stress run repeats exactly.
+type StressConfig struct {
+	Seed   int64               `yaml:"seed"`
+	Shocks []StressShockConfig `yaml:"shocks"`
+}
+
+// StressShockConfig is one shock of a StressConfig.
+type StressShockConfig struct {
+	Name       string  `yaml:"name"`       // label for the report; defaults to "<kind> #<n>"
+	Kind       string  `yaml:"kind"`       // gap, spread or whipsaw
+	Instrument string  `yaml:"instrument"` // the instrument shocked
+	At         string  `yaml:"at"`         // RFC3339 time, or +duration after the first tick; empty picks one at random
+	Within     string  `yaml:"within"`     // random At: how long after the first tick it may land
+	Pips       float64 `yaml:"pips"`       // gap: signed move; spread: extra spread; whipsaw: swing either side
+	For        string  `yaml:"for"`        // spread: how long it lasts; whipsaw: how long each swing lasts
+	Count      int     `yaml:"count"`      // whipsaw: number of swings, alternating up and down
+}
+
+// Stress shock kinds (StressShockConfig.Kind).
+const (
+	StressGap     = "gap"     // instant, lasting move of bid and ask
+	StressSpread  = "spread"  // spread blowout for a while
+	StressWhipsaw = "whipsaw" // price swinging up and down, then back
+)
+
+// StressEvent records a shock starting, moving on or ending.
+type StressEvent struct {
+	Name       string
+	Kind       string
+	Instrument string
+	At         types.Timestamp
+	Note       string
+}
+
+// StressStopFill records a stop-loss fill during a stress run and how far
+// it slipped past the stop.
+type StressStopFill struct {
+	TradeID    string
+	Instrument string
+	At         types.Timestamp
+	Stop       types.Price
+	Fill       types.Price
+	Slippage   types.Pips // adverse distance from Stop to Fill
+	Shock      string     // latest shock on the instrument, "" if none yet
+}
+
+// StressReport is what a stress run found.
+type StressReport struct {
+	Events []StressEvent
+	Stops  []StressStopFill
+
+	Closeouts int // trades closed by a margin closeout
+
+	StartEquity    types.Money
+	EndEquity      types.Money
+	MinEquity      types.Money
+	MaxDrawdown    types.Money
+	MaxDrawdownPct types.Rate // of the peak equity it fell from
+	MaxMarginUsed  types.Money
+	MinMarginLevel types.Rate // lowest equity / margin used while margin was in use; 0 when it never was
+}
+
+// MaxSlippage returns the largest stop slippage.
+func (r StressReport) MaxSlippage() types.Pips {
+	var worst types.Pips
+	for _, f := range r.Stops {
+		worst = max(worst, f.Slippage)
+	}
+	return worst
+}
+
+// Stress is a compiled StressConfig, driven tick by tick by a replay:
+// Apply shocks each tick before the broker sees it, Observe then records
+// what the broker did with it.
+type Stress struct {
+	shocks []*stressShock
+	rng    *rand.Rand
+	start  types.Timestamp
+
+	stops  map[string]stressStop // open trades' stops as of the last Apply
+	fills  <-chan oanda.TxEvent
+	latest map[string]string // instrument → latest shock name
+	peak   types.Money
+	seen   bool
+
+	report StressReport
+}
+
+type stressStop struct {
+	stop types.Price
+	long bool
+}
+
+type stressShock struct {
+	name     string
+	kind     string
+	inst     string
+	at       types.Timestamp
+	after    int64 // seconds after the first tick, for a relative or random At
+	relative bool
+	within   int64 // seconds; > 0 draws after at random
+	shift    types.Price
+	pips     float64
+	dur      int64 // seconds
+	count    int
+	phase    int
+}
+
+// LoadStress reads and compiles the stress config at path.
+func LoadStress(path string) (*Stress, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseStress(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ParseStress compiles a stress config; unknown keys are errors.
+func ParseStress(b []byte) (*Stress, error) {
+	var cfg StressConfig
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("stress: %w", err)
+	}
+	return CompileStress(cfg)
+}
+
+// CompileStress validates cfg and converts it to fixed-point form.
+func CompileStress(cfg StressConfig) (*Stress, error) {
+	s := &Stress{
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+		stops:  make(map[string]stressStop),
+		latest: make(map[string]string),
+	}
+	for i, sc := range cfg.Shocks {
+		sh, err := compileStressShock(sc)
+		if err != nil {
+			name := sc.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("stress shock %s: %w", name, err)
+		}
+		if sh.name == "" {
+			sh.name = fmt.Sprintf("%s #%d", sh.kind, i+1)
+		}
+		s.shocks = append(s.shocks, sh)
+	}
+	return s, nil
+}
+
+func compileStressShock(sc StressShockConfig) (*stressShock, error) {
+	sh := &stressShock{
+		name:  strings.TrimSpace(sc.Name),
+		kind:  strings.ToLower(strings.TrimSpace(sc.Kind)),
+		inst:  market.NormalizeInstrument(strings.TrimSpace(sc.Instrument)),
+		pips:  sc.Pips,
+		count: sc.Count,
+	}
+	inst := market.GetInstrument(sh.inst)
+	if inst == nil {
+		return nil, fmt.Errorf("needs a known instrument, got %q", sc.Instrument)
+	}
+
+	at := strings.TrimSpace(sc.At)
+	within := strings.TrimSpace(sc.Within)
+	switch {
+	case at != "" && within != "":
+		return nil, fmt.Errorf("give at or within, not both")
+	case at != "":
+		var err error
+		if sh.at, sh.after, sh.relative, err = parseReplayAt(at); err != nil {
+			return nil, err
+		}
+	case within != "":
+		d, err := time.ParseDuration(within)
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("bad within %q: want a duration of at least 1s", within)
+		}
+		sh.within, sh.relative = int64(d/time.Second), true
+	default:
+		return nil, fmt.Errorf("needs at or within")
+	}
+
+	var dur time.Duration
+	if f := strings.TrimSpace(sc.For); f != "" {
+		d, err := time.ParseDuration(f)
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("bad for %q: want a duration of at least 1s", f)
+		}
+		dur = d
+	}
+	sh.dur = int64(dur / time.Second)
+
+	switch sh.kind {
+	case StressGap:
+		if sc.Pips == 0 {
+			return nil, fmt.Errorf("gap needs non-zero pips")
+		}
+	case StressSpread:
+		if sc.Pips <= 0 || sh.dur == 0 {
+			return nil, fmt.Errorf("spread needs pips > 0 and for")
+		}
+	case StressWhipsaw:
+		if sc.Pips <= 0 || sh.dur == 0 || sc.Count < 1 {
+			return nil, fmt.Errorf("whipsaw needs pips > 0, for and count >= 1")
+		}
+	case "":
+		return nil, fmt.Errorf("needs kind")
+	default:
+		return nil, fmt.Errorf("unknown kind %q (want gap, spread or whipsaw)", sc.Kind)
+	}
+	if sh.kind != StressWhipsaw && sc.Count != 0 {
+		return nil, fmt.Errorf("count is for whipsaw only")
+	}
+	if sh.kind == StressGap && sh.dur != 0 {
+		return nil, fmt.Errorf("a gap lasts to the end; use for with spread or whipsaw")
+	}
+	sh.shift = inst.PriceDeltaFromPips(types.PipsFromFloat(sc.Pips))
+	return sh, nil
+}
+
+// phaseAt returns the shock's phase at ts: 0 before it starts, then 1 for
+// a gap; 1 during and 2 after a spread; 1..count through the swings and
+// count+1 after a whipsaw.
+func (sh *stressShock) phaseAt(ts, start types.Timestamp) int {
+	at := sh.at
+	if sh.relative {
+		at = start + types.Timestamp(sh.after)
+	}
+	if ts < at {
+		return 0
+	}
+	elapsed := int64(ts - at)
+	switch sh.kind {
+	case StressSpread:
+		if elapsed < sh.dur {
+			return 1
+		}
+		return 2
+	case StressWhipsaw:
+		return int(min(elapsed/sh.dur, int64(sh.count))) + 1
+	}
+	return 1
+}
+
+// apply adds the shock's current phase to t.
+func (sh *stressShock) apply(t market.Tick) market.Tick {
+	switch {
+	case sh.kind == StressGap && sh.phase == 1:
+		t.Bid += sh.shift
+		t.Ask += sh.shift
+	case sh.kind == StressSpread && sh.phase == 1:
+		t.Bid -= sh.shift / 2
+		t.Ask += sh.shift - sh.shift/2
+	case sh.kind == StressWhipsaw && sh.phase >= 1 && sh.phase <= sh.count:
+		shift := sh.shift
+		if sh.phase%2 == 0 {
+			shift = -shift
+		}
+		t.Bid += shift
+		t.Ask += shift
+	}
+	return t
+}
+
+// note describes the shock entering its current phase.
+func (sh *stressShock) note() string {
+	switch sh.kind {
+	case StressGap:
+		return fmt.Sprintf("gap %+.1f pips", sh.pips)
+	case StressSpread:
+		if sh.phase == 1 {
+			return fmt.Sprintf("spread +%.1f pips", sh.pips)
+		}
+		return "spread restored"
+	default:
+		if sh.phase > sh.count {
+			return "whipsaw over"
+		}
+		pips := sh.pips
+		if sh.phase%2 == 0 {
+			pips = -pips
+		}
+		return fmt.Sprintf("swing %d/%d %+.1f pips", sh.phase, sh.count, pips)
+	}
+}
+
+// Apply shocks t and returns it for the broker; accountID's open trades
+// are noted so Observe can tell how their stops filled. A gap or whipsaw
+// swing landing on t is marked as a gap on b when it is a
+// brokers.GapMarker, so stops it jumps past fill at the shocked price.
+func (s *Stress) Apply(ctx context.Context, b brokers.Broker, accountID string, t market.Tick) (market.Tick, error) {
+	if s == nil {
+		return t, nil
+	}
+	if s.start == 0 {
+		s.start = t.Timestamp
+		for _, sh := range s.shocks {
+			if sh.within > 0 {
+				sh.after = s.rng.Int63n(sh.within)
+			}
+		}
+	}
+	if s.fills == nil {
+		fills, err := b.StreamTransactions(ctx, accountID, oanda.StreamOptions{})
+		if err != nil {
+			return t, fmt.Errorf("stress: %w", err)
+		}
+		s.fills = fills
+	}
+
+	inst := market.NormalizeInstrument(t.Instrument)
+	gap := false
+	for _, sh := range s.shocks {
+		if sh.inst != inst {
+			continue
+		}
+		phase := sh.phaseAt(t.Timestamp, s.start)
+		if phase != sh.phase {
+			sh.phase = phase
+			gap = gap || sh.kind != StressSpread
+			s.latest[inst] = sh.name
+			s.report.Events = append(s.report.Events, StressEvent{
+				Name: sh.name, Kind: sh.kind, Instrument: inst, At: t.Timestamp, Note: sh.note(),
+			})
+		}
+		t = sh.apply(t)
+	}
+	if gm, ok := b.(brokers.GapMarker); ok && gap {
+		gm.MarkGap(inst)
+	}
+
+	open, err := b.GetOpenTrades(ctx, accountID)
+	if err != nil {
+		return t, fmt.Errorf("stress: %w", err)
+	}
+	clear(s.stops)
+	for _, tr := range open {
+		if tr.StopLoss > 0 {
+			s.stops[tr.ID] = stressStop{stop: types.PriceFromFloat(tr.StopLoss), long: tr.Units > 0}
+		}
+	}
+	return t, nil
+}
+
+// Observe records what the broker did with t, the tick Apply returned:
+// the stop and margin-closeout fills it produced and the account's equity
+// and margin after it.
+func (s *Stress) Observe(ctx context.Context, b brokers.Broker, accountID string, t market.Tick) error {
+	if s == nil {
+		return nil
+	}
+	for drained := false; !drained; {
+		select {
+		case ev := <-s.fills:
+			s.fill(ev.Tx)
+		default:
+			drained = true
+		}
+	}
+
+	sum, err := b.GetAccountSummary(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("stress: %w", err)
+	}
+	equity := types.MoneyFromFloat(sum.NAV)
+	used := types.MoneyFromFloat(sum.MarginUsed)
+	r := &s.report
+	if !s.seen {
+		s.seen = true
+		r.StartEquity, r.MinEquity, s.peak = equity, equity, equity
+	}
+	r.EndEquity = equity
+	r.MinEquity = min(r.MinEquity, equity)
+	s.peak = max(s.peak, equity)
+	if dd := s.peak - equity; dd > r.MaxDrawdown {
+		r.MaxDrawdown = dd
+		if pct, err := types.MulDivFloor64(int64(dd), int64(types.RateScale), int64(s.peak)); err == nil && s.peak > 0 {
+			r.MaxDrawdownPct = types.Rate(pct)
+		}
+	}
+	r.MaxMarginUsed = max(r.MaxMarginUsed, used)
+	if used > 0 {
+		var level types.Rate
+		if lv, err := types.MulDivFloor64(int64(max(equity, 0)), int64(types.RateScale), int64(used)); err == nil {
+			level = types.Rate(lv)
+		}
+		if r.MinMarginLevel == 0 || level < r.MinMarginLevel {
+			r.MinMarginLevel = level
+		}
+	}
+	return nil
+}
+
+// fill records a stop or margin-closeout fill.
+func (s *Stress) fill(tx oanda.Transaction) {
+	switch tx.Reason {
+	case "MARGIN_CLOSEOUT":
+		s.report.Closeouts++
+	case "STOP":
+		st, ok := s.stops[tx.TradeID]
+		if !ok {
+			return
+		}
+		inst := market.NormalizeInstrument(tx.Instrument)
+		fill := types.PriceFromFloat(tx.Price)
+		slip := st.stop - fill
+		if !st.long {
+			slip = fill - st.stop
+		}
+		var pips types.Pips
+		if perPip := market.GetInstrument(inst).PriceUnitsPerPip(); perPip > 0 && slip > 0 {
+			pips = types.Pips(int64(slip) * types.PipScale / int64(perPip))
+		}
+		s.report.Stops = append(s.report.Stops, StressStopFill{
+			TradeID:    tx.TradeID,
+			Instrument: inst,
+			At:         types.FromTime(tx.Time),
+			Stop:       st.stop,
+			Fill:       fill,
+			Slippage:   pips,
+			Shock:      s.latest[inst],
+		})
+	}
+}
+
+// Report returns what the run has found so far.
+func (s *Stress) Report() StressReport {
+	if s == nil {
+		return StressReport{}
+	}
+	return s.report
+}
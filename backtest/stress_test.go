@@ -0,0 +1,153 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestParseStress_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "unknown key", yaml: "shocks:\n  - kind: gap\n    instrument: EURUSD\n    at: +1m\n    pip: 5\n", wantErr: "pip"},
+		{name: "unknown instrument", yaml: "shocks:\n  - kind: gap\n    instrument: FOOBAR\n    at: +1m\n    pips: 5\n", wantErr: "known instrument"},
+		{name: "no time", yaml: "shocks:\n  - kind: gap\n    instrument: EURUSD\n    pips: 5\n", wantErr: "needs at or within"},
+		{name: "at and within", yaml: "shocks:\n  - kind: gap\n    instrument: EURUSD\n    at: +1m\n    within: 1h\n    pips: 5\n", wantErr: "not both"},
+		{name: "bad kind", yaml: "shocks:\n  - kind: crash\n    instrument: EURUSD\n    at: +1m\n    pips: 5\n", wantErr: `unknown kind "crash"`},
+		{name: "spread without for", yaml: "shocks:\n  - name: wide\n    kind: spread\n    instrument: EURUSD\n    at: +1m\n    pips: 5\n", wantErr: "stress shock wide: spread needs pips > 0 and for"},
+		{name: "whipsaw without count", yaml: "shocks:\n  - kind: whipsaw\n    instrument: EURUSD\n    at: +1m\n    pips: 5\n    for: 1m\n", wantErr: "count >= 1"},
+		{name: "gap with for", yaml: "shocks:\n  - kind: gap\n    instrument: EURUSD\n    at: +1m\n    pips: 5\n    for: 1m\n", wantErr: "lasts to the end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStress([]byte(tt.yaml))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// stressRun replays n EURUSD ticks a minute apart at a fixed 1.10000 /
+// 1.10010 quote through s and a fresh sim, opening a 10k long with a 1.0970
+// stop on the first tick, and returns the quotes the sim saw.
+func stressRun(t *testing.T, s *Stress, n int) ([]market.Tick, *account.Account) {
+	t.Helper()
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	eng := sim.NewSimBroker(acct, nil)
+	ctx := context.Background()
+	start := time.Date(2024, 3, 8, 13, 0, 0, 0, time.UTC)
+
+	var seen []market.Tick
+	for i := 0; i < n; i++ {
+		tick := market.Tick{
+			Timestamp:  types.FromTime(start.Add(time.Duration(i) * time.Minute)),
+			Instrument: "EURUSD",
+			BA:         market.BA{Bid: 110000, Ask: 110010},
+		}
+		tick, err := s.Apply(ctx, eng, acct.ID, tick)
+		require.NoError(t, err)
+		seen = append(seen, tick)
+		require.NoError(t, eng.UpdatePrice(tick))
+		if i == 0 {
+			_, err := eng.SubmitMarketOrder(ctx, acct.ID, "EURUSD", 10_000, 1.0970)
+			require.NoError(t, err)
+		}
+		require.NoError(t, s.Observe(ctx, eng, acct.ID, tick))
+	}
+	return seen, acct
+}
+
+func TestStress_GapFillsStopAtShockedPrice(t *testing.T) {
+	s, err := ParseStress([]byte(`
+shocks:
+  - name: NFP
+    kind: gap
+    instrument: EURUSD
+    at: 2024-03-08T13:02:00Z
+    pips: -150
+`))
+	require.NoError(t, err)
+	seen, acct := stressRun(t, s, 4)
+
+	assert.Equal(t, types.Price(108500), seen[2].Bid)
+	assert.Equal(t, types.Price(108500), seen[3].Bid, "a gap lasts")
+	assert.Equal(t, 0, acct.Lots.Len())
+
+	r := s.Report()
+	require.Len(t, r.Events, 1)
+	assert.Equal(t, "gap -150.0 pips", r.Events[0].Note)
+	require.Len(t, r.Stops, 1)
+	fill := r.Stops[0]
+	assert.Equal(t, types.PriceFromFloat(1.0970), fill.Stop)
+	assert.Equal(t, types.Price(108500), fill.Fill, "fills at the gapped bid, not the stop")
+	assert.Equal(t, types.PipsFromFloat(120), fill.Slippage)
+	assert.Equal(t, "NFP", fill.Shock)
+	assert.Equal(t, types.PipsFromFloat(120), r.MaxSlippage())
+
+	assert.Equal(t, types.MoneyFromFloat(10_000), r.StartEquity)
+	assert.Less(t, r.EndEquity, r.StartEquity)
+	assert.Equal(t, r.StartEquity-r.EndEquity, r.MaxDrawdown)
+	assert.Positive(t, r.MaxDrawdownPct)
+	assert.Positive(t, r.MaxMarginUsed)
+	assert.Positive(t, r.MinMarginLevel)
+}
+
+func TestStress_SpreadAndWhipsawPhases(t *testing.T) {
+	s, err := ParseStress([]byte(`
+shocks:
+  - kind: spread
+    instrument: EURUSD
+    at: +1m
+    pips: 20
+    for: 2m
+  - kind: whipsaw
+    instrument: EURUSD
+    at: +4m
+    pips: 10
+    for: 1m
+    count: 2
+`))
+	require.NoError(t, err)
+	seen, _ := stressRun(t, s, 8)
+
+	var bids, asks []types.Price
+	for _, tick := range seen {
+		bids = append(bids, tick.Bid)
+		asks = append(asks, tick.Ask)
+	}
+	assert.Equal(t, []types.Price{110000, 109900, 109900, 110000, 110100, 109900, 110000, 110000}, bids)
+	assert.Equal(t, []types.Price{110010, 110110, 110110, 110010, 110110, 109910, 110010, 110010}, asks)
+
+	var notes []string
+	for _, ev := range s.Report().Events {
+		notes = append(notes, ev.Note)
+	}
+	assert.Equal(t, []string{"spread +20.0 pips", "spread restored", "swing 1/2 +10.0 pips", "swing 2/2 -10.0 pips", "whipsaw over"}, notes)
+}
+
+func TestStress_RandomTimeIsSeeded(t *testing.T) {
+	cfg := "seed: 42\nshocks:\n  - kind: gap\n    instrument: EURUSD\n    within: 5m\n    pips: 30\n"
+	at := func() types.Timestamp {
+		s, err := ParseStress([]byte(cfg))
+		require.NoError(t, err)
+		stressRun(t, s, 6)
+		events := s.Report().Events
+		require.Len(t, events, 1)
+		return events[0].At
+	}
+	first := at()
+	assert.Equal(t, first, at())
+	start := types.FromTime(time.Date(2024, 3, 8, 13, 0, 0, 0, time.UTC))
+	assert.True(t, first >= start && first <= start+5*60)
+}
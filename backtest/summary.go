@@ -22,22 +22,38 @@ func (run *Backtest) Summary() BacktestReportSummary {
 			if tr == nil {
 				continue
 			}
+			inst := market.GetInstrument(tr.Instrument)
+			var quoteCcy string
+			if inst != nil {
+				quoteCcy = inst.QuoteCurrency
+			}
+			// Prices are reported at the instrument's display precision,
+			// as a broker would quote them.
+			price := func(p types.Price) float64 { return inst.RoundPrice(p).Float64() }
 
 			trades = append(trades, BacktestReportTrade{
 				ID:               tr.ID,
 				Instrument:       tr.Instrument,
 				Side:             tr.Side.String(),
 				Units:            int64(tr.Units),
-				OpenPrice:        tr.EntryPrice.Float64(),
-				ClosePrice:       tr.ExitPrice.Float64(),
+				OpenPrice:        price(tr.EntryPrice),
+				ClosePrice:       price(tr.ExitPrice),
 				OpenTime:         formatBacktestSummaryTime(tr.EntryTime),
 				CloseTime:        formatBacktestSummaryTime(tr.ExitTime),
 				PNL:              tr.PNL.Float64(),
-				StopPrice:        tr.Stop.Float64(),
-				TakeProfitPrice:  tr.Take.Float64(),
-				InitialStopPrice: tr.InitialStop.Float64(),
+				StopPrice:        price(tr.Stop),
+				TakeProfitPrice:  price(tr.Take),
+				InitialStopPrice: price(tr.InitialStop),
 				CloseCause:       tr.CloseCause.String(),
 				Reason:           tr.Reason,
+				VolRegime:        tr.VolRegime,
+				InitialRisk:      tr.InitialRisk.Float64(),
+				RMultiple:        tr.RMultiple.Float64(),
+				MAE:              price(tr.MAE),
+				MFE:              price(tr.MFE),
+				QuoteCurrency:    quoteCcy,
+				QuotePNL:         tr.QuotePNL.Float64(),
+				ConversionRate:   tr.ConversionRate.Float64(),
 			})
 		}
 	}
@@ -53,32 +69,69 @@ func (run *Backtest) Summary() BacktestReportSummary {
 		Start:      formatBacktestSummaryTime(run.Result.Start),
 		End:        formatBacktestSummaryTime(run.Result.End),
 
-		Trades:         run.Result.Trades,
-		Wins:           run.Result.Wins,
-		Losses:         run.Result.Losses,
-		StartBalance:   run.Result.StartBalance.Float64(),
-		EndBalance:     run.Result.Balance.Float64(),
-		NetPL:          run.Result.NetPL.Float64(),
-		ReturnPct:      run.Result.ReturnPct.Float64() * 100,
-		WinRate:        run.Result.WinRate.Float64() * 100,
-		RiskPct:        run.Request.RiskPct.Float64() * 100,
-		Stop:           stopDescription(run),
-		Regime:         regimeDescription(run),
-		MaxSpread:      maxSpreadDescription(run),
-		Slippage:       slippageDescription(run),
-		AvgSpreadPips:  avgSpreadPips,
-		SpreadFiltered: spreadFiltered,
-		MaxDrawdown:    run.Result.MaxDrawdown.Float64(),
-		AvgWinner:      run.Result.AvgWinner.Float64(),
-		AvgLoser:       run.Result.AvgLoser.Float64(),
-		RR:             run.Result.RR.Float64(),
+		Trades:               run.Result.Trades,
+		Wins:                 run.Result.Wins,
+		Losses:               run.Result.Losses,
+		Currency:             run.Result.Currency,
+		StartBalance:         run.Result.StartBalance.Float64(),
+		EndBalance:           run.Result.Balance.Float64(),
+		NetPL:                run.Result.NetPL.Float64(),
+		Interest:             run.Result.Interest.Float64(),
+		ReturnPct:            run.Result.ReturnPct.Float64() * 100,
+		WinRate:              run.Result.WinRate.Float64() * 100,
+		RiskPct:              run.Request.RiskPct.Float64() * 100,
+		Stop:                 stopDescription(run),
+		Regime:               regimeDescription(run),
+		MaxSpread:            maxSpreadDescription(run),
+		Slippage:             slippageDescription(run),
+		AvgSpreadPips:        avgSpreadPips,
+		SpreadFiltered:       spreadFiltered,
+		MaxDrawdown:          run.Result.MaxDrawdown.Float64(),
+		AvgWinner:            run.Result.AvgWinner.Float64(),
+		AvgLoser:             run.Result.AvgLoser.Float64(),
+		RR:                   run.Result.RR.Float64(),
+		ProfitFactor:         run.Result.ProfitFactor.Float64(),
+		Sharpe:               run.Result.Sharpe.Float64(),
+		StopReason:           run.Result.StopReason,
+		Interrupted:          run.Result.Interrupted,
+		SliceExcluded:        run.State.SliceExcluded,
+		WarmupBars:           run.State.WarmupBars,
+		EquityCurveThrottled: run.State.Throttled,
+		GovernorSkipped:      run.Result.Skipped,
+		FilterSkipped:        run.Result.Filtered,
+		OrderRejections:      run.Result.Rejected,
+		SpreadGuarded:        run.Result.SpreadGuarded,
+		Signals:              signalsReport(run.Result.Signals),
+		Exposure:             exposureSummary(run.Result.Exposure),
+		Rolling:              rollingSummary(run.Result.Rolling),
+		ByTime:               run.Result.ByTime.Report(),
+		ByVolatility:         volatilityReport(run.Result.ByVolatility),
+		Benchmark:            run.Result.Benchmark.Report(),
+		PropFirm:             run.Result.PropFirm.Report(),
 
 		TradeDetails: trades,
 
 		ConfigHash:  run.Request.ConfigHash,
 		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
 		Config:      run.RunConfig,
+		Rollover:    types.CurrentRollover().String(),
+	}
+}
+
+// exposureSummary converts per-currency exposure peaks for the report.
+func exposureSummary(peaks []ExposurePeak) []BacktestReportExposure {
+	if len(peaks) == 0 {
+		return nil
+	}
+	out := make([]BacktestReportExposure, 0, len(peaks))
+	for _, p := range peaks {
+		out = append(out, BacktestReportExposure{
+			Currency: p.Currency,
+			MaxLong:  p.MaxLong.Float64(),
+			MaxShort: p.MaxShort.Float64(),
+		})
 	}
+	return out
 }
 
 // regimeDescription returns the regime filter's name for display in the
@@ -0,0 +1,247 @@
+package backtest
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3" // registers the "sqlite3" driver
+
+	"github.com/rustyeddy/trader/log"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// tickArchiveSchema is the tick archive's one table. Prices are stored as
+// scaled types.Price and times as Unix seconds; rows of one instrument and
+// second keep their insertion (rowid) order.
+const tickArchiveSchema = `
+CREATE TABLE IF NOT EXISTS ticks (
+	instrument TEXT    NOT NULL,
+	ts         INTEGER NOT NULL,
+	bid        INTEGER NOT NULL,
+	ask        INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS ticks_instrument_ts ON ticks (instrument, ts);
+CREATE INDEX IF NOT EXISTS ticks_ts ON ticks (ts);
+`
+
+// importBatch is how many ticks Import commits per transaction.
+const importBatch = 50_000
+
+// TickArchive is a SQLite file of ticks. Importing a tick CSV once and
+// replaying from the archive avoids parsing the CSV on every run, and the
+// indexes make selecting a date range a query rather than a scan.
+type TickArchive struct {
+	db   *sql.DB
+	path string
+}
+
+// OpenTickArchive opens the archive at path, creating the file and its
+// schema if they don't exist.
+func OpenTickArchive(path string) (*TickArchive, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("open tick archive %s: %w", path, err)
+	}
+	if _, err := db.Exec(tickArchiveSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("open tick archive %s: %w", path, err)
+	}
+	return &TickArchive{db: db, path: path}, nil
+}
+
+// Close closes the archive.
+func (a *TickArchive) Close() error {
+	if a == nil || a.db == nil {
+		return nil
+	}
+	return a.db.Close()
+}
+
+// Import appends every tick src yields, validated and with its instrument
+// normalized, and returns how many it stored. Ticks already committed
+// stay in the archive if a later one fails.
+func (a *TickArchive) Import(src market.TickSource) (int, error) {
+	n := 0
+	for {
+		tx, err := a.db.Begin()
+		if err != nil {
+			return n, err
+		}
+		stmt, err := tx.Prepare(`INSERT INTO ticks (instrument, ts, bid, ask) VALUES (?, ?, ?, ?)`)
+		if err != nil {
+			_ = tx.Rollback()
+			return n, err
+		}
+		batch, done := 0, false
+		for batch < importBatch {
+			t, ok, err := src.Next()
+			if err == nil && ok {
+				err = t.Validate()
+			}
+			if err != nil {
+				_ = stmt.Close()
+				_ = tx.Rollback()
+				return n, fmt.Errorf("import tick %d: %w", n+batch+1, err)
+			}
+			if !ok {
+				done = true
+				break
+			}
+			if _, err := stmt.Exec(market.NormalizeInstrument(t.Instrument), int64(t.Timestamp), int64(t.Bid), int64(t.Ask)); err != nil {
+				_ = stmt.Close()
+				_ = tx.Rollback()
+				return n, err
+			}
+			batch++
+		}
+		_ = stmt.Close()
+		if err := tx.Commit(); err != nil {
+			return n, err
+		}
+		n += batch
+		if done {
+			return n, nil
+		}
+	}
+}
+
+// ImportCSV imports the canonical tick CSV at path (see CSVTicksFeed).
+func (a *TickArchive) ImportCSV(path string) (int, error) {
+	feed, err := NewCSVTicksFeed(path, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer feed.Close()
+	n, err := a.Import(feed)
+	if err != nil {
+		return n, fmt.Errorf("%s: %w", path, err)
+	}
+	return n, nil
+}
+
+// TickArchiveStats describes the ticks an archive holds for one
+// instrument.
+type TickArchiveStats struct {
+	Instrument string
+	Ticks      int
+	First      types.Timestamp
+	Last       types.Timestamp
+}
+
+// Stats returns tick counts and time spans per instrument, by instrument.
+func (a *TickArchive) Stats() ([]TickArchiveStats, error) {
+	rows, err := a.db.Query(`SELECT instrument, COUNT(*), MIN(ts), MAX(ts) FROM ticks GROUP BY instrument ORDER BY instrument`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TickArchiveStats
+	for rows.Next() {
+		var s TickArchiveStats
+		if err := rows.Scan(&s.Instrument, &s.Ticks, &s.First, &s.Last); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// Feed returns the archive's ticks in [from, to), in time order, limited to
+// instruments when any are given. Zero from or to leaves that end open.
+// Rows are streamed from the query, not loaded up front.
+func (a *TickArchive) Feed(from, to types.Timestamp, instruments ...string) (*SQLiteTicksFeed, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if !from.IsZero() {
+		where, args = append(where, "ts >= ?"), append(args, int64(from))
+	}
+	if !to.IsZero() {
+		where, args = append(where, "ts < ?"), append(args, int64(to))
+	}
+	if len(instruments) > 0 {
+		marks := make([]string, len(instruments))
+		for i, inst := range instruments {
+			marks[i] = "?"
+			args = append(args, market.NormalizeInstrument(inst))
+		}
+		where = append(where, "instrument IN ("+strings.Join(marks, ",")+")")
+	}
+	q := `SELECT instrument, ts, bid, ask FROM ticks`
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += " ORDER BY ts, rowid"
+
+	rows, err := a.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("tick archive %s: %w", a.path, err)
+	}
+	return &SQLiteTicksFeed{rows: rows}, nil
+}
+
+// SQLiteTicksFeed streams ticks from a TickArchive query. Like
+// CSVTicksFeed it satisfies market.TickSource and paper.TickFeed.
+type SQLiteTicksFeed struct {
+	rows *sql.Rows
+
+	// Sanitizer, when set, counts and drops (or fails on, per its Policy)
+	// bad ticks; see CSVTicksFeed.Sanitizer. Import already rejects
+	// invalid quotes, so it only matters for its spike checks.
+	Sanitizer *market.TickSanitizer
+
+	// Slice, when non-zero, drops ticks outside its calendar slices;
+	// Excluded counts them.
+	Slice    CalendarSlice
+	excluded int
+}
+
+// Close ends the query.
+func (f *SQLiteTicksFeed) Close() error {
+	if f.rows == nil {
+		return nil
+	}
+	return f.rows.Close()
+}
+
+// Excluded returns how many ticks Slice has dropped so far.
+func (f *SQLiteTicksFeed) Excluded() int { return f.excluded }
+
+// Next returns the next tick, or (Tick{}, false, nil) once the query is
+// exhausted.
+func (f *SQLiteTicksFeed) Next() (market.Tick, bool, error) {
+	if f.rows == nil {
+		return market.Tick{}, false, errors.New("tick archive feed is closed")
+	}
+	for f.rows.Next() {
+		var (
+			p        market.Tick
+			bid, ask int64
+		)
+		if err := f.rows.Scan(&p.Instrument, &p.Timestamp, &bid, &ask); err != nil {
+			return market.Tick{}, false, err
+		}
+		p.Bid, p.Ask = types.Price(bid), types.Price(ask)
+		if !f.Slice.Keep(p.Timestamp) {
+			f.excluded++
+			continue
+		}
+		if f.Sanitizer != nil {
+			v, keep, err := f.Sanitizer.Apply(p)
+			if err != nil {
+				return market.Tick{}, false, err
+			}
+			if !keep {
+				log.L.Warn("dropping bad tick", "instrument", p.Instrument, "time", p.Timestamp.String(),
+					"bid", p.Bid.String(), "ask", p.Ask.String(), "violation", string(v))
+				continue
+			}
+		}
+		return p, true, nil
+	}
+	return market.Tick{}, false, f.rows.Err()
+}
@@ -0,0 +1,96 @@
+package backtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+func drainTicks(t *testing.T, src market.TickSource) []market.Tick {
+	t.Helper()
+	var out []market.Tick
+	for {
+		p, ok, err := src.Next()
+		require.NoError(t, err)
+		if !ok {
+			return out
+		}
+		out = append(out, p)
+	}
+}
+
+func TestTickArchive_ImportAndFeed(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte(`time,instrument,bid,ask
+2026-01-24T09:30:00Z,EUR_USD,1.1000,1.1002
+2026-01-24T09:30:00Z,USD_JPY,150.000,150.020
+2026-01-24T09:30:01Z,EUR_USD,1.1001,1.1003
+2026-01-24T09:30:02Z,EUR_USD,1.1002,1.1004
+`), 0o644))
+
+	archive, err := OpenTickArchive(filepath.Join(dir, "ticks.db"))
+	require.NoError(t, err)
+	defer archive.Close()
+
+	n, err := archive.ImportCSV(csvPath)
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	start := types.FromTime(time.Date(2026, 1, 24, 9, 30, 0, 0, time.UTC))
+	stats, err := archive.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, []TickArchiveStats{
+		{Instrument: "EURUSD", Ticks: 3, First: start, Last: start + 2},
+		{Instrument: "USDJPY", Ticks: 1, First: start, Last: start},
+	}, stats)
+
+	feed, err := archive.Feed(0, 0)
+	require.NoError(t, err)
+	all := drainTicks(t, feed)
+	require.NoError(t, feed.Close())
+	require.Len(t, all, 4)
+	assert.Equal(t, "EURUSD", all[0].Instrument, "same-second ticks keep their import order")
+	assert.Equal(t, "USDJPY", all[1].Instrument)
+	assert.Equal(t, types.PriceFromFloat(150.02), all[1].Ask)
+
+	feed, err = archive.Feed(start+1, start+2, "EUR_USD")
+	require.NoError(t, err)
+	got := drainTicks(t, feed)
+	require.NoError(t, feed.Close())
+	require.Len(t, got, 1, "[from, to) of one instrument")
+	assert.Equal(t, market.Tick{Instrument: "EURUSD", Timestamp: start + 1,
+		BA: market.BA{Bid: types.PriceFromFloat(1.1001), Ask: types.PriceFromFloat(1.1003)}}, got[0])
+
+	feed, err = archive.Feed(0, 0, "EURUSD")
+	require.NoError(t, err)
+	feed.Slice = CalendarSlice{weekdaysOnly: true} // 2026-01-24 is a Saturday
+	assert.Empty(t, drainTicks(t, feed))
+	assert.Equal(t, 3, feed.Excluded())
+	require.NoError(t, feed.Close())
+}
+
+func TestTickArchive_ImportRejectsBadTicks(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "crossed.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte(
+		"2026-01-24T09:30:00Z,EUR_USD,1.1000,1.1002\n"+
+			"2026-01-24T09:30:01Z,EUR_USD,1.1005,1.1001\n"), 0o644))
+
+	archive, err := OpenTickArchive(filepath.Join(dir, "ticks.db"))
+	require.NoError(t, err)
+	defer archive.Close()
+
+	_, err = archive.ImportCSV(csvPath)
+	require.Error(t, err)
+	stats, err := archive.Stats()
+	require.NoError(t, err)
+	assert.Empty(t, stats, "a failed batch is rolled back")
+}
@@ -0,0 +1,76 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// warmupPadding is added to a warm-up window on top of
+// datamanager.CandleWindow's weekend allowance, to cover holidays and gaps
+// in the data.
+const warmupPadding = 4 * 24 * time.Hour
+
+// compileWarmup validates the data's warm-up bar count.
+func compileWarmup(bars int) (int, error) {
+	if bars < 0 {
+		return 0, fmt.Errorf("warmup must be >= 0, got %d", bars)
+	}
+	return bars, nil
+}
+
+// warmupRange returns the window before tr.Start that should hold at least
+// bars bars of tr.TF.
+func warmupRange(tr types.TimeRange, bars int) types.TimeRange {
+	span := datamanager.CandleWindow(tr.TF, bars) + warmupPadding
+	return types.NewTimeRange(tr.Start-types.Timestamp(span/time.Second), tr.Start, tr.TF)
+}
+
+// loadWarmup reads the last req.Warmup bars before the run's start from
+// the same source as the run itself. Fewer bars come back when the data
+// does not reach that far; missing data is not an error.
+func (run *Backtest) loadWarmup(ctx context.Context, dm engine.CandleSource, source string) ([]market.Candle, error) {
+	n := run.Request.Warmup
+	if n <= 0 {
+		return nil, nil
+	}
+	itr, err := dm.Candles(ctx, datamanager.CandleRequest{
+		Source:     source,
+		Instrument: run.Request.Instrument,
+		Range:      warmupRange(run.Request.TimeRange, n),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load warm-up candles: %w", err)
+	}
+	checked := market.NewCheckedCandleIterator(itr, &market.CandleChecker{
+		Instrument: run.Request.Instrument,
+		Repair:     true,
+	})
+	defer checked.Close()
+
+	// Keep the last n bars in a ring, then unroll it in order.
+	ring := make([]market.Candle, 0, n)
+	next := 0
+	for {
+		c, ok := checked.Next()
+		if !ok {
+			break
+		}
+		if len(ring) < n {
+			ring = append(ring, c)
+			continue
+		}
+		ring[next] = c
+		next = (next + 1) % n
+	}
+	if err := checked.Err(); err != nil {
+		return nil, fmt.Errorf("load warm-up candles: %w", err)
+	}
+	out := append([]market.Candle(nil), ring[next:]...)
+	return append(out, ring[:next]...), nil
+}
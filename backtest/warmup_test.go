@@ -0,0 +1,141 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+// rangeSource serves the candles that fall in each request's range.
+type rangeSource struct {
+	candles []market.Candle
+	reqs    []datamanager.CandleRequest
+}
+
+func (s *rangeSource) Candles(_ context.Context, req datamanager.CandleRequest) (market.CandleIterator, error) {
+	s.reqs = append(s.reqs, req)
+	var out []market.Candle
+	for _, c := range s.candles {
+		if req.Range.Contains(c.Timestamp) {
+			out = append(out, c)
+		}
+	}
+	return &fixedCandleIterator{candles: out}, nil
+}
+
+// hourlyCandles returns n flat H1 bars from start.
+func hourlyCandles(start time.Time, n int) []market.Candle {
+	candles := make([]market.Candle, 0, n)
+	for i := 0; i < n; i++ {
+		candles = append(candles, market.Candle{
+			Open: 110000, High: 110100, Low: 109900, Close: 110000,
+			Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour)),
+		})
+	}
+	return candles
+}
+
+// longAfter goes long on every bar once it has seen n bars.
+type longAfter struct {
+	n    int
+	seen int
+}
+
+func (s *longAfter) Name() string            { return "long-after" }
+func (s *longAfter) Reset()                  { s.seen = 0 }
+func (s *longAfter) Ready() bool             { return s.seen >= s.n }
+func (s *longAfter) StopDescription() string { return "" }
+func (s *longAfter) Update(_ context.Context, c *market.Candle, _ strategy.StrategyContext) strategy.Signal {
+	s.seen++
+	if !s.Ready() {
+		return strategy.Hold("warming up")
+	}
+	return strategy.Signal{Side: types.Long, Stop: c.Close - 500, Reason: "ready"}
+}
+
+func TestCompileWarmup(t *testing.T) {
+	n, err := compileWarmup(200)
+	require.NoError(t, err)
+	assert.Equal(t, 200, n)
+	_, err = compileWarmup(-1)
+	assert.ErrorContains(t, err, "warmup must be >= 0")
+}
+
+func TestWarmupRange(t *testing.T) {
+	start := types.FromTime(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	tr := types.NewTimeRange(start, start+86400, types.D1)
+	got := warmupRange(tr, 50)
+	assert.Equal(t, start, got.End)
+	assert.Equal(t, start-types.Timestamp(70*86400+4*86400), got.Start, "50 trading days span 70 calendar days")
+	assert.Equal(t, types.D1, got.TF)
+}
+
+func TestLoadWarmup_KeepsTheLastBarsBeforeStart(t *testing.T) {
+	start := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	src := &rangeSource{candles: hourlyCandles(start.Add(-48*time.Hour), 60)}
+	run := &Backtest{Request: &BacktestRequest{
+		Instrument: "EURUSD",
+		TimeRange:  types.NewTimeRange(types.FromTime(start), types.FromTime(start.Add(12*time.Hour)), types.H1),
+		Warmup:     5,
+	}}
+
+	got, err := run.loadWarmup(context.Background(), src, "candles")
+	require.NoError(t, err)
+	require.Len(t, got, 5)
+	assert.Equal(t, types.FromTime(start.Add(-5*time.Hour)), got[0].Timestamp)
+	assert.Equal(t, types.FromTime(start.Add(-time.Hour)), got[4].Timestamp)
+	require.Len(t, src.reqs, 1)
+	assert.Equal(t, "candles", src.reqs[0].Source)
+
+	run.Request.Warmup = 100
+	got, err = run.loadWarmup(context.Background(), src, "candles")
+	require.NoError(t, err)
+	assert.Len(t, got, 48, "short data returns what there is")
+
+	run.Request.Warmup = 0
+	got, err = run.loadWarmup(context.Background(), src, "candles")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.Len(t, src.reqs, 2, "no request without a warm-up")
+}
+
+func TestExecute_PreloadsWarmup(t *testing.T) {
+	start := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	src := &rangeSource{candles: hourlyCandles(start.Add(-24*time.Hour), 28)}
+
+	runWith := func(warmup int) (*Backtest, *account.Account) {
+		acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+		acct.RiskFraction = types.RateFromFloat(0.01)
+		run := &Backtest{Request: &BacktestRequest{
+			Instrument:      "EURUSD",
+			Strategy:        &longAfter{n: 3},
+			StartingBalance: types.MoneyFromFloat(10_000),
+			TimeRange:       types.NewTimeRange(types.FromTime(start), types.FromTime(start.Add(4*time.Hour)), types.H1),
+			Warmup:          warmup,
+		}}
+		tr := &engine.Trader{Account: acct, Broker: sim.NewSimBroker(acct, nil), DataManager: src}
+		require.NoError(t, run.Execute(context.Background(), tr))
+		return run, acct
+	}
+
+	run, acct := runWith(0)
+	assert.Len(t, acct.Trades, 2, "the strategy spends the first two bars warming up")
+	assert.Zero(t, run.State.WarmupBars)
+
+	run, acct = runWith(3)
+	assert.Len(t, acct.Trades, 4, "ready on the first bar of the run")
+	assert.Equal(t, 3, run.State.WarmupBars)
+	assert.Nil(t, run.State.preload)
+	assert.Equal(t, 3, run.Summary().WarmupBars)
+}
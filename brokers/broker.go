@@ -26,7 +26,8 @@ import (
 // in a later phase.
 //
 // brokers/sim also satisfies Broker — a simulated fill against tracked
-// prices instead of a real network round-trip. See
+// prices instead of a real network round-trip — and brokers/paper wraps a
+// Sim priced from a live feed for paper trading. See
 // docs/Manual/architecture-broker-account-order.org, phase 4.
 type Broker interface {
 	GetAccountSummary(ctx context.Context, accountID string) (*oanda.AccountSummary, error)
@@ -57,3 +58,12 @@ var _ Broker = (*oanda.Client)(nil)
 type PriceUpdater interface {
 	UpdatePrice(tick market.Tick) error
 }
+
+// GapMarker is implemented by Broker implementations that can be told the
+// next price on an instrument is a gap, so resting stops and takes it
+// jumps past fill at that price rather than at their level (Sim). Like
+// PriceUpdater it only makes sense for a simulated venue; stress tests
+// type-assert Broker against it.
+type GapMarker interface {
+	MarkGap(instrument string)
+}
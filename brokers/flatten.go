@@ -0,0 +1,76 @@
+package brokers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// ReasonCloser is implemented by Broker implementations that record why a
+// trade was closed (Sim journals it as the trade's Reason). Optional, like
+// PriceUpdater: CloseAllForInstrument and FlattenDirection use it when
+// present and fall back to CloseTrade otherwise.
+type ReasonCloser interface {
+	CloseTradeWithReason(ctx context.Context, accountID, tradeID string, units int64, reason string) (*oanda.CloseTradeResult, error)
+}
+
+// CloseAllForInstrument closes every open trade on instrument in
+// accountID, long and short alike, and returns the closes that went
+// through. A failed close does not stop the rest; the failures are
+// returned joined.
+func CloseAllForInstrument(ctx context.Context, b Broker, accountID, instrument, reason string) ([]*oanda.CloseTradeResult, error) {
+	return closeWhere(ctx, b, accountID, instrument, 0, reason)
+}
+
+// FlattenDirection closes accountID's open trades on instrument on one side
+// only — side is types.Long or types.Short — leaving the other side's
+// trades open. Failures are handled as in CloseAllForInstrument.
+func FlattenDirection(ctx context.Context, b Broker, accountID, instrument string, side types.Side, reason string) ([]*oanda.CloseTradeResult, error) {
+	if !side.Valid() {
+		return nil, fmt.Errorf("flatten %s: invalid side %d", instrument, side)
+	}
+	return closeWhere(ctx, b, accountID, instrument, side, reason)
+}
+
+// closeWhere closes the open trades on instrument whose direction matches
+// side; a zero side matches both.
+func closeWhere(ctx context.Context, b Broker, accountID, instrument string, side types.Side, reason string) ([]*oanda.CloseTradeResult, error) {
+	if b == nil {
+		return nil, fmt.Errorf("close %s: no broker", instrument)
+	}
+	open, err := b.GetOpenTrades(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("close %s: get open trades: %w", instrument, err)
+	}
+	inst := market.NormalizeInstrument(instrument)
+	rc, withReason := b.(ReasonCloser)
+
+	var (
+		closed []*oanda.CloseTradeResult
+		errs   []error
+	)
+	for _, tr := range open {
+		if market.NormalizeInstrument(tr.Instrument) != inst || tr.Units == 0 {
+			continue
+		}
+		if (side == types.Long && tr.Units < 0) || (side == types.Short && tr.Units > 0) {
+			continue
+		}
+		var res *oanda.CloseTradeResult
+		if withReason {
+			res, err = rc.CloseTradeWithReason(ctx, accountID, tr.ID, 0, reason)
+		} else {
+			res, err = b.CloseTrade(ctx, accountID, tr.ID, 0)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("close trade %s: %w", tr.ID, err))
+			continue
+		}
+		closed = append(closed, res)
+	}
+	return closed, errors.Join(errs...)
+}
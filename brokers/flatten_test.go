@@ -0,0 +1,92 @@
+package brokers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeBroker serves a fixed set of open trades and records closes. Only
+// the two methods the flatten helpers use are implemented.
+type closeBroker struct {
+	Broker
+	open    []oanda.OpenTrade
+	fail    string
+	closed  []string
+	reasons []string
+}
+
+func (b *closeBroker) GetOpenTrades(context.Context, string) ([]oanda.OpenTrade, error) {
+	return b.open, nil
+}
+
+func (b *closeBroker) CloseTrade(_ context.Context, _, tradeID string, _ int64) (*oanda.CloseTradeResult, error) {
+	if tradeID == b.fail {
+		return nil, errors.New("rejected")
+	}
+	b.closed = append(b.closed, tradeID)
+	return &oanda.CloseTradeResult{TradeID: tradeID}, nil
+}
+
+// reasonBroker also implements ReasonCloser.
+type reasonBroker struct{ *closeBroker }
+
+func (b reasonBroker) CloseTradeWithReason(ctx context.Context, accountID, tradeID string, units int64, reason string) (*oanda.CloseTradeResult, error) {
+	b.reasons = append(b.reasons, reason)
+	return b.CloseTrade(ctx, accountID, tradeID, units)
+}
+
+func openTrades() []oanda.OpenTrade {
+	return []oanda.OpenTrade{
+		{ID: "1", Instrument: "EUR_USD", Units: 1000},
+		{ID: "2", Instrument: "EURUSD", Units: -500},
+		{ID: "3", Instrument: "GBP_USD", Units: 1000},
+		{ID: "4", Instrument: "EUR_USD", Units: 2000},
+	}
+}
+
+func TestCloseAllForInstrument(t *testing.T) {
+	b := &closeBroker{open: openTrades()}
+	res, err := CloseAllForInstrument(context.Background(), b, "acct", "eur_usd", "rebalance")
+	require.NoError(t, err)
+	assert.Len(t, res, 3)
+	assert.Equal(t, []string{"1", "2", "4"}, b.closed, "instrument names match however they are spelled")
+}
+
+func TestFlattenDirection(t *testing.T) {
+	tests := []struct {
+		side types.Side
+		want []string
+	}{
+		{types.Long, []string{"1", "4"}},
+		{types.Short, []string{"2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.side.String(), func(t *testing.T) {
+			b := &closeBroker{open: openTrades()}
+			_, err := FlattenDirection(context.Background(), reasonBroker{b}, "acct", "EURUSD", tt.side, "flip")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, b.closed)
+			assert.Len(t, b.reasons, len(tt.want))
+			for _, r := range b.reasons {
+				assert.Equal(t, "flip", r)
+			}
+		})
+	}
+
+	_, err := FlattenDirection(context.Background(), &closeBroker{}, "acct", "EURUSD", 0, "flip")
+	assert.ErrorContains(t, err, "invalid side")
+}
+
+func TestCloseAllForInstrument_KeepsGoingPastFailures(t *testing.T) {
+	b := &closeBroker{open: openTrades(), fail: "1"}
+	res, err := CloseAllForInstrument(context.Background(), b, "acct", "EURUSD", "")
+	require.ErrorContains(t, err, "close trade 1: rejected")
+	assert.Len(t, res, 2)
+	assert.Equal(t, []string{"2", "4"}, b.closed)
+}
@@ -11,6 +11,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/rustyeddy/trader/types"
 )
 
 type CandlesOptions struct {
@@ -81,6 +83,7 @@ func (c *Client) DownloadCandlesToCSV(ctx context.Context, opts CandlesOptions,
 	q := u.Query()
 	q.Set("granularity", opts.Granularity)
 	q.Set("price", price)
+	setAlignment(q)
 
 	if opts.Count > 0 {
 		q.Set("count", strconv.Itoa(opts.Count))
@@ -177,3 +180,13 @@ func (c *Client) DownloadCandlesToCSV(ctx context.Context, opts CandlesOptions,
 
 	return written, nil
 }
+
+// setAlignment anchors daily-aligned granularities (D, and H4 by
+// subdivision) to the configured rollover, so downloaded candles share
+// their boundaries with locally aggregated ones. OANDA's own default is
+// types.DefaultRollover.
+func setAlignment(q url.Values) {
+	r := types.CurrentRollover()
+	q.Set("dailyAlignment", strconv.Itoa(r.Hour))
+	q.Set("alignmentTimezone", r.Location.String())
+}
@@ -101,6 +101,7 @@ func (c *Client) FetchCandles(ctx context.Context, opts FetchCandlesOptions) ([]
 		q := u.Query()
 		q.Set("granularity", opts.Granularity)
 		q.Set("price", "BA")
+		setAlignment(q)
 		q.Set("count", strconv.Itoa(chunk))
 		q.Set("from", cursor.Format(time.RFC3339Nano))
 		u.RawQuery = q.Encode()
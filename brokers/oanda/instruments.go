@@ -0,0 +1,54 @@
+package oanda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// AccountInstrument is the part of an OANDA Instrument the account needs:
+// its name and the margin rate the account trades it at.
+type AccountInstrument struct {
+	Name       string  // OANDA name, e.g. "EUR_USD"
+	Type       string  // CURRENCY, CFD or METAL
+	MarginRate float64 // fraction of notional, e.g. 0.0333 for 30:1
+}
+
+type accountInstrumentsResp struct {
+	Instruments []struct {
+		Name       string `json:"name"`
+		Type       string `json:"type"`
+		MarginRate string `json:"marginRate"`
+	} `json:"instruments"`
+}
+
+// GetAccountInstruments returns the instruments accountID can trade, with
+// the margin rate OANDA applies to each (GET /v3/accounts/{id}/instruments).
+func (c *Client) GetAccountInstruments(ctx context.Context, accountID string) ([]AccountInstrument, error) {
+	body, err := c.Get(ctx, fmt.Sprintf("/v3/accounts/%s/instruments", accountID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp accountInstrumentsResp
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("oanda: parse account instruments: %w", err)
+	}
+
+	out := make([]AccountInstrument, 0, len(resp.Instruments))
+	for _, in := range resp.Instruments {
+		rate, err := parseOptionalFloatField(in.Name+" marginRate", in.MarginRate)
+		if err != nil {
+			return nil, fmt.Errorf("oanda: %w", err)
+		}
+		out = append(out, AccountInstrument{Name: in.Name, Type: in.Type, MarginRate: rate})
+	}
+	return out, nil
+}
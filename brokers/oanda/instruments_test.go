@@ -0,0 +1,49 @@
+package oanda
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountInstruments_ParsesMarginRates(t *testing.T) {
+	srv := accountSummaryServer(t, 200, map[string]any{
+		"instruments": []map[string]any{
+			{"name": "EUR_USD", "type": "CURRENCY", "marginRate": "0.0333"},
+			{"name": "USD_JPY", "type": "CURRENCY", "marginRate": "0.05"},
+		},
+	})
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Token: "tok", HTTP: srv.Client()}
+	got, err := c.GetAccountInstruments(context.Background(), "ACC1")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "EUR_USD", got[0].Name)
+	assert.Equal(t, "CURRENCY", got[0].Type)
+	assert.InDelta(t, 0.0333, got[0].MarginRate, 1e-12)
+	assert.InDelta(t, 0.05, got[1].MarginRate, 1e-12)
+}
+
+func TestGetAccountInstruments_BadMarginRateReturnsError(t *testing.T) {
+	srv := accountSummaryServer(t, 200, map[string]any{
+		"instruments": []map[string]any{{"name": "EUR_USD", "marginRate": "x"}},
+	})
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Token: "tok", HTTP: srv.Client()}
+	_, err := c.GetAccountInstruments(context.Background(), "ACC1")
+	require.ErrorContains(t, err, "EUR_USD marginRate")
+}
+
+func TestGetAccountInstruments_HTTPErrorPropagated(t *testing.T) {
+	srv := accountSummaryServer(t, http.StatusUnauthorized, map[string]any{"errorMessage": "bad token"})
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Token: "tok", HTTP: srv.Client()}
+	_, err := c.GetAccountInstruments(context.Background(), "ACC1")
+	require.Error(t, err)
+}
@@ -11,6 +11,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/rustyeddy/trader/market"
 )
 
 // PriceTick is one tradeable price update from the OANDA pricing stream.
@@ -201,7 +203,8 @@ func parsePricingStream(ctx context.Context, r io.Reader, onHB func(time.Time),
 }
 
 // StreamPricingToCSV opens the OANDA pricing stream and writes each tradeable
-// price update as a CSV row (time, instrument, bid, ask) to w.
+// price update as a CSV row (time, instrument, bid, ask) to w, after a
+// market.TickMeta line naming the source and the streamed instruments.
 // It stops when ctx is done or maxTicks > 0 rows have been written.
 func (c *Client) StreamPricingToCSV(ctx context.Context, opts PricingStreamOptions, w io.Writer, maxTicks int) (int, error) {
 	ch, err := c.StreamPricing(ctx, opts)
@@ -209,6 +212,18 @@ func (c *Client) StreamPricingToCSV(ctx context.Context, opts PricingStreamOptio
 		return 0, err
 	}
 
+	meta := market.TickMeta{
+		Source:      "oanda",
+		Instruments: opts.Instruments,
+		Price:       market.PriceBidAsk,
+		Params: map[string]string{
+			"generator": "oanda-pricing-stream",
+			"started":   time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	if _, err := fmt.Fprintln(w, meta.String()); err != nil {
+		return 0, err
+	}
 	cw := csv.NewWriter(w)
 	if err := cw.Write([]string{"time", "instrument", "bid", "ask"}); err != nil {
 		return 0, err
@@ -13,6 +13,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/market"
 )
 
 // pricingStreamServer returns a test server that writes newline-delimited
@@ -281,11 +283,17 @@ func TestStreamPricingToCSV_WritesHeaderAndRows(t *testing.T) {
 	assert.Equal(t, 2, n)
 
 	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
-	require.Len(t, lines, 3) // header + 2 rows
-	assert.Equal(t, "time,instrument,bid,ask", lines[0])
-	assert.Contains(t, lines[1], "EUR_USD")
-	assert.Contains(t, lines[1], "1.0849")
-	assert.Contains(t, lines[2], "USD_JPY")
+	require.Len(t, lines, 4) // metadata + header + 2 rows
+	meta, ok, err := market.ParseTickMeta(lines[0])
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "oanda", meta.Source)
+	assert.Equal(t, []string{"EURUSD", "USDJPY"}, meta.Instruments)
+	assert.Equal(t, "oanda-pricing-stream", meta.Params["generator"])
+	assert.Equal(t, "time,instrument,bid,ask", lines[1])
+	assert.Contains(t, lines[2], "EUR_USD")
+	assert.Contains(t, lines[2], "1.0849")
+	assert.Contains(t, lines[3], "USD_JPY")
 }
 
 func TestStreamPricingToCSV_RespectsMaxTicks(t *testing.T) {
@@ -306,7 +314,7 @@ func TestStreamPricingToCSV_RespectsMaxTicks(t *testing.T) {
 	assert.Equal(t, 2, n)
 
 	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
-	assert.Len(t, lines, 3) // header + 2 rows (stopped after maxTicks)
+	assert.Len(t, lines, 4) // metadata + header + 2 rows (stopped after maxTicks)
 }
 
 // ── streamURLFor ─────────────────────────────────────────────────────────────
@@ -0,0 +1,100 @@
+package brokers
+
+import (
+	"context"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/types"
+)
+
+// OrderState is where an Order is in its lifecycle. An order starts
+// OrderPending and ends in exactly one of the terminal states; a fill
+// produces one or more trades, which live on as positions (account.Lot)
+// while the order itself is done.
+type OrderState string
+
+const (
+	OrderPending   OrderState = "PENDING"   // submitted, waiting (wholly or partly) for a fill
+	OrderFilled    OrderState = "FILLED"    // every unit filled; see Order.TradeIDs
+	OrderCancelled OrderState = "CANCELLED" // removed unfilled, by request or as not marketable
+	OrderExpired   OrderState = "EXPIRED"   // its time in force lapsed before it filled
+)
+
+// Terminal reports whether s is a final state.
+func (s OrderState) Terminal() bool {
+	return s == OrderFilled || s == OrderCancelled || s == OrderExpired
+}
+
+// OrderType is the kind of order a request was submitted as.
+type OrderType string
+
+const (
+	MarketOrder OrderType = "MARKET"
+	LimitOrder  OrderType = "LIMIT"
+)
+
+// TimeInForce controls how long a limit order may wait for a fill. Values
+// match OANDA's timeInForce strings.
+type TimeInForce string
+
+const (
+	GTC TimeInForce = "GTC" // good till cancelled: rests until filled or cancelled
+	GTD TimeInForce = "GTD" // good till date: rests until filled or its expiry
+	FOK TimeInForce = "FOK" // fill or kill: fill now in full or cancel
+	IOC TimeInForce = "IOC" // immediate or cancel: fill now or cancel
+)
+
+// Order is a request to trade, kept apart from the trades it results in:
+// submitted → filled, cancelled or expired, with each fill opening a trade
+// whose ID is appended to TradeIDs. Names follow OANDA's order states so a
+// live broker's orders map onto it directly. account.Order is the request
+// as a caller phrases it; Order is the venue's record of what became of it.
+type Order struct {
+	ID         string
+	AccountID  string
+	Instrument string
+	Type       OrderType
+	Units      int64       // signed units requested: positive buy, negative sell
+	Price      types.Price // limit price; 0 for market orders
+	Stop       types.Price // stop-loss for the resulting trades; 0 for none
+	State      OrderState
+	Reason     string // why a cancelled or expired order left the book
+
+	// FilledUnits counts the signed units filled so far; an order filling
+	// in parts stays OrderPending until it reaches Units. FillPrice is the
+	// price of the latest fill.
+	FilledUnits int64
+	FillPrice   types.Price
+	TradeIDs    []string
+
+	Submitted types.Timestamp
+	Updated   types.Timestamp // time of the latest state change or fill
+}
+
+// Remaining returns the signed units still to fill.
+func (o *Order) Remaining() int64 {
+	return o.Units - o.FilledUnits
+}
+
+// OrderBook is implemented by Broker implementations that can list orders
+// through their lifecycle, not just the trades they opened. Like
+// PriceUpdater it is optional: callers type-assert Broker against it.
+type OrderBook interface {
+	// Orders returns accountID's orders, pending and finished, in
+	// submission order.
+	Orders(ctx context.Context, accountID string) ([]Order, error)
+}
+
+// LimitOrderer is implemented by Broker implementations that take limit
+// orders with a time in force (Sim). Like OrderBook it is optional:
+// callers type-assert Broker against it.
+type LimitOrderer interface {
+	// SubmitLimitOrder places a limit order for units (positive buy,
+	// negative sell) at price. expiry is the time a GTD order lapses;
+	// other orders ignore it. The result has an empty TradeID when the
+	// order did not fill at once.
+	SubmitLimitOrder(ctx context.Context, accountID, instrument string, units int64, price, stop types.Price, tif TimeInForce, expiry types.Timestamp) (*oanda.OrderResult, error)
+
+	// CancelOrder removes one of accountID's resting orders.
+	CancelOrder(ctx context.Context, accountID, orderID string) error
+}
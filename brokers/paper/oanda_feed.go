@@ -0,0 +1,83 @@
+package paper
+
+import (
+	"context"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/log"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// OANDAFeed adapts an OANDA pricing stream to TickFeed.
+type OANDAFeed struct {
+	ctx    context.Context
+	events <-chan oanda.PriceEvent
+
+	// Sanitizer, when set, screens each tick the way it does for
+	// backtest.CSVTicksFeed: bad ticks are logged and dropped, or end the
+	// feed with an error, per its Policy.
+	Sanitizer *market.TickSanitizer
+}
+
+// NewOANDAFeed opens the pricing stream described by opts. The stream, and
+// the feed, end when ctx is cancelled.
+func NewOANDAFeed(ctx context.Context, c *oanda.Client, opts oanda.PricingStreamOptions) (*OANDAFeed, error) {
+	events, err := c.StreamPricing(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewOANDAFeedFromEvents(ctx, events), nil
+}
+
+// NewOANDAFeedFromEvents wraps an already-open pricing stream channel.
+func NewOANDAFeedFromEvents(ctx context.Context, events <-chan oanda.PriceEvent) *OANDAFeed {
+	return &OANDAFeed{ctx: ctx, events: events}
+}
+
+// Next blocks for the next price update. It returns ok=false when ctx is
+// done or the stream closes cleanly, and the stream's error if it fails.
+func (f *OANDAFeed) Next() (market.Tick, bool, error) {
+	for {
+		var ev oanda.PriceEvent
+		var open bool
+		select {
+		case <-f.ctx.Done():
+			return market.Tick{}, false, nil
+		case ev, open = <-f.events:
+		}
+		if !open {
+			return market.Tick{}, false, nil
+		}
+		if ev.Err != nil {
+			return market.Tick{}, false, ev.Err
+		}
+
+		tick := TickFromPrice(ev.Tick)
+		if f.Sanitizer == nil {
+			return tick, true, nil
+		}
+		v, keep, err := f.Sanitizer.Apply(tick)
+		if err != nil {
+			return market.Tick{}, false, err
+		}
+		if !keep {
+			log.L.Warn("dropping bad tick", "instrument", tick.Instrument, "time", tick.Timestamp.String(),
+				"bid", tick.Bid.String(), "ask", tick.Ask.String(), "violation", string(v))
+			continue
+		}
+		return tick, true, nil
+	}
+}
+
+// TickFromPrice converts an OANDA stream price to a market.Tick.
+func TickFromPrice(p oanda.PriceTick) market.Tick {
+	return market.Tick{
+		Instrument: market.NormalizeInstrument(p.Instrument),
+		Timestamp:  types.FromTime(p.Time),
+		BA: market.BA{
+			Bid: types.PriceFromFloat(p.Bid),
+			Ask: types.PriceFromFloat(p.Ask),
+		},
+	}
+}
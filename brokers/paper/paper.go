@@ -0,0 +1,119 @@
+// Package paper is a paper-trading broker: live market data, simulated
+// fills. It pumps ticks from a TickFeed (the OANDA pricing stream, or any
+// other source) into a brokers/sim.Sim and exposes that Sim to strategies
+// as a brokers.Broker, so orders fill against real-time prices without
+// reaching a venue.
+//
+// This differs from a backtest in where prices come from and who drives
+// the clock: a backtest feeds Sim bar by bar from stored candles and calls
+// the strategy in lockstep, while here the feed runs on its own goroutine
+// (Run) and strategies call the Broker whenever they like.
+package paper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/market"
+)
+
+var (
+	_ brokers.Broker    = (*Broker)(nil)
+	_ brokers.OrderBook = (*Broker)(nil)
+)
+
+// TickFeed is a source of ticks. Next blocks until the next tick is
+// available and returns (Tick{}, false, nil) once the feed has ended.
+// backtest.CSVTicksFeed and OANDAFeed both satisfy it.
+type TickFeed interface {
+	Next() (market.Tick, bool, error)
+}
+
+// TickRecorder receives every tick Run applies, e.g. a
+// datamanager.CandleRecorder building candles from the session.
+type TickRecorder interface {
+	Record(market.Tick) error
+}
+
+// Broker is a brokers.Broker backed by a Sim that Run keeps priced from a
+// live feed. Every Broker method is the embedded Sim's; the Sim's own lock
+// makes them safe to call while Run is feeding it.
+type Broker struct {
+	*sim.Sim
+
+	// Recorder, when set, is handed each tick after the Sim has applied
+	// it. The caller owns it and closes it after Run returns.
+	Recorder TickRecorder
+
+	feed  TickFeed
+	ticks atomic.Int64
+}
+
+// New returns a paper Broker filling against engine, priced from feed.
+func New(engine *sim.Sim, feed TickFeed) *Broker {
+	return &Broker{Sim: engine, feed: feed}
+}
+
+// SeedPrices prices the Sim from the feed's first tick of each instrument
+// within its first lookahead ticks (see sim.Sim.SeedPrices), so a strategy
+// started before Run has delivered anything trades at the data's real
+// opening quote. Call it once, before Run; the ticks read ahead are still
+// applied by Run in order. It returns the instruments the lookahead
+// found.
+func (b *Broker) SeedPrices(lookahead int) ([]string, error) {
+	if b == nil || b.Sim == nil || b.feed == nil {
+		return nil, fmt.Errorf("paper: broker needs a sim and a feed")
+	}
+	first, rest, err := market.FirstTicks(b.feed, lookahead)
+	if err != nil {
+		return nil, fmt.Errorf("paper: feed: %w", err)
+	}
+	b.feed = rest
+	insts := make([]string, 0, len(first))
+	ticks := make([]market.Tick, 0, len(first))
+	for inst, tick := range first {
+		insts = append(insts, inst)
+		ticks = append(ticks, tick)
+	}
+	sort.Strings(insts)
+	return insts, b.Sim.SeedPrices(ticks...)
+}
+
+// Run applies ticks from the feed to the Sim — marks, stop/take triggers,
+// and resting orders — until the feed ends or ctx is cancelled. A feed
+// ending is a clean stop (nil error); so is cancellation.
+func (b *Broker) Run(ctx context.Context) error {
+	if b == nil || b.Sim == nil || b.feed == nil {
+		return fmt.Errorf("paper: broker needs a sim and a feed")
+	}
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+		tick, ok, err := b.feed.Next()
+		if err != nil {
+			return fmt.Errorf("paper: feed: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+		if err := b.UpdatePrice(tick); err != nil {
+			return fmt.Errorf("paper: apply %s tick: %w", tick.Instrument, err)
+		}
+		if b.Recorder != nil {
+			if err := b.Recorder.Record(tick); err != nil {
+				return fmt.Errorf("paper: record %s tick: %w", tick.Instrument, err)
+			}
+		}
+		b.ticks.Add(1)
+	}
+}
+
+// Ticks returns how many ticks Run has applied so far.
+func (b *Broker) Ticks() int64 {
+	return b.ticks.Load()
+}
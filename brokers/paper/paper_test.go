@@ -0,0 +1,161 @@
+package paper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// sliceFeed yields its ticks in order, then ends.
+type sliceFeed struct {
+	ticks []market.Tick
+	err   error
+}
+
+func (f *sliceFeed) Next() (market.Tick, bool, error) {
+	if len(f.ticks) == 0 {
+		return market.Tick{}, false, f.err
+	}
+	t := f.ticks[0]
+	f.ticks = f.ticks[1:]
+	return t, true, nil
+}
+
+func eurusd(bid, ask float64) market.Tick {
+	return market.Tick{
+		Instrument: "EURUSD",
+		BA:         market.BA{Bid: types.PriceFromFloat(bid), Ask: types.PriceFromFloat(ask)},
+	}
+}
+
+func TestBroker_FillsAtFeedPricesAndTriggersStops(t *testing.T) {
+	ctx := context.Background()
+	engine := sim.NewSimBroker(account.NewAccount("paper", types.MoneyFromFloat(10000)), nil)
+
+	b := New(engine, &sliceFeed{ticks: []market.Tick{eurusd(1.10000, 1.10010)}})
+	require.NoError(t, b.Run(ctx))
+	assert.EqualValues(t, 1, b.Ticks())
+
+	res, err := b.SubmitMarketOrder(ctx, "", "EUR_USD", 1000, 1.09900)
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	open, err := b.GetOpenTrades(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.InDelta(t, 1.10010, open[0].EntryPrice, 1e-9, "long fills at the feed's ask")
+
+	b.feed = &sliceFeed{ticks: []market.Tick{eurusd(1.09950, 1.09960), eurusd(1.09890, 1.09900)}}
+	require.NoError(t, b.Run(ctx))
+	assert.EqualValues(t, 3, b.Ticks())
+
+	open, err = b.GetOpenTrades(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, open, "stop hit by the second live tick")
+}
+
+func TestBroker_RunStops(t *testing.T) {
+	engine := sim.NewSimBroker(nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := New(engine, &sliceFeed{ticks: []market.Tick{eurusd(1.1, 1.1001)}})
+	require.NoError(t, b.Run(ctx))
+	assert.Zero(t, b.Ticks())
+
+	boom := errors.New("boom")
+	b = New(engine, &sliceFeed{err: boom})
+	require.ErrorIs(t, b.Run(context.Background()), boom)
+
+	b = New(engine, &sliceFeed{ticks: []market.Tick{{Instrument: "EURUSD"}}})
+	require.ErrorContains(t, b.Run(context.Background()), "apply EURUSD tick")
+
+	require.Error(t, New(nil, nil).Run(context.Background()))
+}
+
+func TestOANDAFeed(t *testing.T) {
+	events := make(chan oanda.PriceEvent, 3)
+	ts := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	events <- oanda.PriceEvent{Tick: oanda.PriceTick{Instrument: "EUR_USD", Bid: 1.1, Ask: 1.2, Time: ts}}
+	events <- oanda.PriceEvent{Tick: oanda.PriceTick{Instrument: "EUR_USD", Bid: 1.1, Ask: 1.10010, Time: ts.Add(time.Second)}}
+	events <- oanda.PriceEvent{Err: errors.New("stream reset")}
+	close(events)
+
+	f := NewOANDAFeedFromEvents(context.Background(), events)
+	f.Sanitizer = &market.TickSanitizer{MaxSpreadPips: types.PipsFromFloat(5), Policy: market.TickPolicyLog}
+
+	tick, ok, err := f.Next()
+	require.NoError(t, err)
+	require.True(t, ok, "wide-spread tick dropped, next one yielded")
+	assert.Equal(t, "EURUSD", tick.Instrument)
+	assert.Equal(t, types.PriceFromFloat(1.10010), tick.Ask)
+	assert.Equal(t, types.FromTime(ts.Add(time.Second)), tick.Timestamp)
+
+	_, ok, err = f.Next()
+	require.ErrorContains(t, err, "stream reset")
+	assert.False(t, ok)
+
+	_, ok, err = f.Next()
+	require.NoError(t, err)
+	assert.False(t, ok, "closed stream ends the feed")
+}
+
+func TestOANDAFeed_ContextCancelEndsFeed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := NewOANDAFeedFromEvents(ctx, make(chan oanda.PriceEvent))
+	cancel()
+	_, ok, err := f.Next()
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+type recordFunc func(market.Tick) error
+
+func (f recordFunc) Record(t market.Tick) error { return f(t) }
+
+func TestBroker_RecorderSeesAppliedTicks(t *testing.T) {
+	engine := sim.NewSimBroker(nil, nil)
+	var got []market.Tick
+	b := New(engine, &sliceFeed{ticks: []market.Tick{eurusd(1.1, 1.1001), eurusd(1.1002, 1.1003)}})
+	b.Recorder = recordFunc(func(t market.Tick) error {
+		got = append(got, t)
+		return nil
+	})
+	require.NoError(t, b.Run(context.Background()))
+	assert.Len(t, got, 2)
+
+	b = New(engine, &sliceFeed{ticks: []market.Tick{eurusd(1.1, 1.1001)}})
+	b.Recorder = recordFunc(func(market.Tick) error { return errors.New("disk full") })
+	require.ErrorContains(t, b.Run(context.Background()), "record EURUSD tick: disk full")
+}
+
+func TestBroker_SeedPricesFromFeed(t *testing.T) {
+	ctx := context.Background()
+	engine := sim.NewSimBroker(account.NewAccount("paper", types.MoneyFromFloat(10000)), nil)
+	jpy := market.Tick{Instrument: "USDJPY", BA: market.BA{Bid: types.PriceFromFloat(150.0), Ask: types.PriceFromFloat(150.02)}}
+	b := New(engine, &sliceFeed{ticks: []market.Tick{eurusd(1.10000, 1.10010), jpy, eurusd(1.10100, 1.10110)}})
+
+	insts, err := b.SeedPrices(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"EURUSD", "USDJPY"}, insts)
+	assert.Zero(t, b.Ticks(), "seeding applies nothing")
+
+	_, err = b.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	open, err := b.GetOpenTrades(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.InDelta(t, 1.10010, open[0].EntryPrice, 1e-9, "filled at the feed's first ask")
+
+	require.NoError(t, b.Run(ctx))
+	assert.EqualValues(t, 3, b.Ticks(), "the ticks read ahead are still applied")
+}
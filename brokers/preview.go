@@ -0,0 +1,36 @@
+package brokers
+
+import (
+	"context"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// PreviewRequest is a market order to preview: what placing it now would
+// do, without placing it.
+type PreviewRequest struct {
+	AccountID  string
+	Instrument string
+	Units      int64 // signed units: positive buy, negative sell
+}
+
+// OrderPreview is what a market order would fill at and do to the
+// account's margin, were it placed at the current price. Money is in
+// account currency.
+type OrderPreview struct {
+	Instrument string
+	Units      int64
+	FillPrice  types.Price // expected fill: ask for a buy, bid for a sell, plus slippage
+
+	MarginRequired types.Money // margin the new position would reserve
+	Equity         types.Money // account equity now; opening leaves it unchanged
+	MarginUsed     types.Money // margin in use after the fill
+	FreeMargin     types.Money // Equity − MarginUsed after the fill; negative when it would not fit
+}
+
+// OrderPreviewer is implemented by Broker implementations that can preview
+// a market order without placing it (Sim). Like PriceUpdater it is
+// optional: callers type-assert Broker against it.
+type OrderPreviewer interface {
+	PreviewOrder(ctx context.Context, req PreviewRequest) (OrderPreview, error)
+}
@@ -22,12 +22,21 @@ func (e *Sim) GetAccountChanges(ctx context.Context, accountID string, sinceID i
 	return &oanda.AccountChangesResult{}, nil
 }
 
-// GetAccounts returns the one account Sim wraps.
+// GetAccounts returns the primary account followed by any sub-accounts,
+// ordered by ID.
 func (e *Sim) GetAccounts(ctx context.Context) ([]oanda.AccountRef, error) {
 	if e == nil || e.account == nil {
 		return nil, nil
 	}
-	return []oanda.AccountRef{{ID: e.account.ID}}, nil
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	accts := e.accounts()
+	refs := make([]oanda.AccountRef, 0, len(accts))
+	for _, acct := range accts {
+		refs = append(refs, oanda.AccountRef{ID: acct.ID})
+	}
+	return refs, nil
 }
 
 // GetTransactions returns no transaction history — Sim doesn't keep one
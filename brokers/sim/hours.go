@@ -0,0 +1,168 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// ErrMarketClosed is returned for an order submitted while its
+// instrument's market is closed and MarketHours.Queue is unset.
+var ErrMarketClosed = errors.New("market closed")
+
+// MarketHours is the trading calendar Sim enforces (see Sim.Hours).
+//
+// While an instrument's market is closed its ticks still mark positions,
+// but nothing fills: stops, takes, resting limits and margin closeouts
+// wait for the first tick after the reopen, and stops and takes the price
+// gapped past fill at that tick's quote. Closing a trade directly
+// (CloseTrade, CloseAll) is not restricted.
+type MarketHours struct {
+	Calendar market.Calendar
+
+	// Queue holds market orders submitted while closed and fills them at
+	// the first tick after the reopen; GTC and GTD limit orders rest as
+	// usual and FOK/IOC are cancelled. Unset rejects every new order with
+	// ErrMarketClosed.
+	Queue bool
+
+	// Swap, when set, charges rollover financing on open lots.
+	Swap *Swap
+}
+
+// Swap is rollover financing: once a day, at the rollover (see
+// types.SetRollover; 17:00 New York by default), every lot open across it
+// is charged (or credited) a day of interest on its notional. Rollovers
+// fall Monday to Friday in the rollover's zone.
+type Swap struct {
+	// Long and Short are the annual rates charged on a long or short
+	// position's notional, RateScale-scaled; negative rates are credits.
+	Long  types.Rate
+	Short types.Rate
+
+	// Triple charges three days at TripleDay's rollover, covering the
+	// weekend the market is closed over (Wednesday in spot FX, where value
+	// dates skip the weekend). Unset charges weekdays only.
+	Triple    bool
+	TripleDay time.Weekday
+}
+
+// isOpen reports whether inst's market is open at ts; always true without
+// a calendar.
+func (h *MarketHours) isOpen(inst string, ts types.Timestamp) bool {
+	return h == nil || h.Calendar.Hours(inst).IsOpen(ts.Time())
+}
+
+// reopened reports whether ts is the first price on inst after its market
+// closed, prev being the one before it.
+func (h *MarketHours) reopened(inst string, prev, ts types.Timestamp) bool {
+	return h != nil && h.Calendar.Hours(inst).Reopened(prev.Time(), ts.Time())
+}
+
+// MarkGap flags instrument's next price as a gap, the way the first price
+// after a reopen is one: a stop or take it jumps past fills at its quote,
+// not at the level. Stress tests call it ahead of a price shock. It
+// implements brokers.GapMarker.
+func (e *Sim) MarkGap(instrument string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.gaps == nil {
+		e.gaps = make(map[string]bool)
+	}
+	e.gaps[market.NormalizeInstrument(instrument)] = true
+}
+
+// marketClosed handles a market order submitted while inst's market is
+// closed: rejected with ErrMarketClosed, or queued as a working market
+// order with no fill yet. Callers hold e.mu.
+func (e *Sim) marketClosed(accountID, inst string, units int64, stop types.Price, ts types.Timestamp) (*oanda.OrderResult, error) {
+	if !e.Hours.Queue {
+		return nil, fmt.Errorf("sim: %s: %w", inst, ErrMarketClosed)
+	}
+	order := &PendingOrder{
+		ID:         e.newID(),
+		AccountID:  accountID,
+		Instrument: inst,
+		Units:      units,
+		Stop:       stop,
+		Created:    ts,
+		Market:     true,
+	}
+	e.trackOrder(order, brokers.MarketOrder)
+	e.orders = append(e.orders, order)
+	return &oanda.OrderResult{OrderID: order.ID, Instrument: inst, Units: units}, nil
+}
+
+// chargeRollovers books everything due at the rollovers between the last
+// tick and to: the swap on each open lot, valued at the latest mid, and
+// the interest on each account's idle cash. Callers hold e.mu.
+func (e *Sim) chargeRollovers(to types.Timestamp) error {
+	from := e.clock
+	if to > e.clock {
+		e.clock = to
+	}
+	if from == 0 || to <= from {
+		return nil
+	}
+	if err := e.chargeSwap(from, to); err != nil {
+		return err
+	}
+	return e.accrueInterest(from, to)
+}
+
+// chargeSwap books the swap for every rollover in (from, to] on each open
+// lot. Callers hold e.mu.
+func (e *Sim) chargeSwap(from, to types.Timestamp) error {
+	if e.Hours == nil || e.Hours.Swap == nil {
+		return nil
+	}
+	sw := e.Hours.Swap
+	for _, acct := range e.accounts() {
+		var lots []*account.Lot
+		_ = acct.Lots.Range(func(lot *account.Lot) error {
+			lots = append(lots, lot)
+			return nil
+		})
+		for _, lot := range lots {
+			start := max(from, lot.EntryTime)
+			days := sw.days(start.Time(), to.Time(), types.CurrentRollover())
+			if days == 0 {
+				continue
+			}
+			px, ok := e.prices.latest[lot.Instrument]
+			if !ok {
+				continue
+			}
+			rate := sw.Long
+			if lot.Side == types.Short {
+				rate = sw.Short
+			}
+			if _, err := acct.ChargeFinancing(lot, px.Mid(), rate, days); err != nil {
+				return fmt.Errorf("sim: swap on %s: %w", lot.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// days counts the days of financing charged at the rollovers r places in
+// (from, to].
+func (sw *Swap) days(from, to time.Time, r types.Rollover) int64 {
+	var n int64
+	for roll := r.Next(from); !roll.After(to); roll = r.Next(roll) {
+		switch wd := roll.In(r.Location).Weekday(); {
+		case wd == time.Saturday || wd == time.Sunday:
+		case sw.Triple && wd == sw.TripleDay:
+			n += 3
+		default:
+			n++
+		}
+	}
+	return n
+}
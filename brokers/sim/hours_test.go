@@ -0,0 +1,184 @@
+package sim
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// weekTick is a EURUSD tick at mid on the given day of the week of
+// 2024-06-03 (a Monday, day 0) and UTC hour.
+func weekTick(mid types.Price, day, hour int) market.Tick {
+	tick := eurusdTick(mid)
+	tick.Timestamp = types.FromTime(time.Date(2024, 6, 3+day, hour, 0, 0, 0, time.UTC))
+	return tick
+}
+
+func fxHours() *MarketHours {
+	return &MarketHours{Calendar: market.Calendar{Default: market.FXHours}}
+}
+
+func TestMarketHours_RejectsOrdersWhileClosed(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), nil)
+	s.Hours = fxHours()
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 5, 10))) // Saturday
+
+	_, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.ErrorIs(t, err, ErrMarketClosed)
+	_, err = s.SubmitLimitOrder(ctx, "", "EURUSD", 1000, 109_000, 0, brokers.GTC, 0)
+	require.ErrorIs(t, err, ErrMarketClosed)
+	assert.Equal(t, 0, s.account.Lots.Len())
+
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 6, 22))) // Sunday's open
+	_, err = s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.account.Lots.Len())
+}
+
+func TestMarketHours_QueuesMarketOrdersUntilReopen(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), nil)
+	s.Hours = fxHours()
+	s.Hours.Queue = true
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 5, 10)))
+
+	res, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 1.09)
+	require.NoError(t, err)
+	assert.Empty(t, res.TradeID)
+	require.Len(t, s.PendingOrders(""), 1)
+
+	require.NoError(t, s.UpdatePrice(weekTick(110_200, 6, 12)))
+	assert.Equal(t, 0, s.account.Lots.Len(), "still closed on Sunday afternoon")
+
+	require.NoError(t, s.UpdatePrice(weekTick(110_500, 6, 22)))
+	lots := s.account.Lots.Slice()
+	require.Len(t, lots, 1)
+	assert.Equal(t, types.Price(110_501), lots[0].EntryPrice, "fills at the reopening ask")
+	assert.Equal(t, types.PriceFromFloat(1.09), lots[0].Stop)
+	assert.Empty(t, s.PendingOrders(""))
+
+	orders, err := s.Orders(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, brokers.OrderFilled, orders[0].State)
+	assert.Equal(t, res.OrderID, orders[0].ID)
+}
+
+func TestMarketHours_GappedStopFillsAtReopen(t *testing.T) {
+	ctx := context.Background()
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	s.Hours = fxHours()
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 4, 21))) // Friday
+	_, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 1.09)
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdatePrice(weekTick(108_000, 5, 12)))
+	assert.Equal(t, 1, acct.Lots.Len(), "nothing fills while closed")
+
+	require.NoError(t, s.UpdatePrice(weekTick(108_500, 6, 22)))
+	require.Equal(t, 0, acct.Lots.Len())
+	require.Len(t, acct.Trades, 1)
+	assert.Equal(t, types.Price(108_499), acct.Trades[0].ExitPrice, "the stop fills at the reopening bid, below its level")
+}
+
+func TestMarketHours_StopOnOrdinaryTickFillsAtLevel(t *testing.T) {
+	ctx := context.Background()
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	s.Hours = fxHours()
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 1, 10)))
+	_, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 1.09)
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdatePrice(weekTick(108_500, 1, 11)))
+	require.Len(t, acct.Trades, 1)
+	assert.Equal(t, types.PriceFromFloat(1.09), acct.Trades[0].ExitPrice)
+}
+
+func TestMarketHours_SwapTripleCharge(t *testing.T) {
+	ctx := context.Background()
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	s.Hours = fxHours()
+	s.Hours.Swap = &Swap{Long: types.RateFromFloat(0.0365), Short: types.RateFromFloat(-0.0365), Triple: true, TripleDay: time.Wednesday}
+
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 0, 10))) // Monday
+	_, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 100_000, 0)
+	require.NoError(t, err)
+	_, err = s.SubmitMarketOrder(ctx, "", "EURUSD", -100_000, 0)
+	require.NoError(t, err)
+	balance := acct.Balance
+
+	// Monday, Tuesday and Wednesday's rollovers: 1 + 1 + 3 days at 11.00.
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 3, 10)))
+	var long, short *account.Lot
+	_ = acct.Lots.Range(func(l *account.Lot) error {
+		if l.Side == types.Long {
+			long = l
+		} else {
+			short = l
+		}
+		return nil
+	})
+	require.NotNil(t, long)
+	require.NotNil(t, short)
+	assert.Equal(t, types.MoneyFromFloat(-55), long.Financing)
+	assert.Equal(t, types.MoneyFromFloat(55), short.Financing)
+	assert.Equal(t, balance, acct.Balance, "the charge and the credit cancel")
+}
+
+func TestIdleInterest_AccruesEveryDay(t *testing.T) {
+	acct := account.NewAccount("test", types.MoneyFromFloat(36_500))
+	s := NewSimBroker(acct, nil)
+	s.IdleInterest = types.RateFromFloat(0.0365)
+
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 0, 10))) // Monday
+	// Monday through Sunday's rollovers: seven days at about 3.65.
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 7, 10)))
+	assert.InDelta(t, 25.56, acct.Interest.Float64(), 0.01)
+	assert.Equal(t, types.MoneyFromFloat(36_500)+acct.Interest, acct.Balance)
+	assert.Equal(t, acct.Balance, acct.Equity)
+}
+
+func TestSwapDays(t *testing.T) {
+	// June: 17:00 New York is 21:00 UTC.
+	ny := types.DefaultRollover
+	day := func(d, h int) time.Time { return time.Date(2024, 6, 3+d, h, 0, 0, 0, time.UTC) }
+	weekdays := &Swap{}
+	triple := &Swap{Triple: true, TripleDay: time.Wednesday}
+
+	assert.Equal(t, int64(0), weekdays.days(day(0, 10), day(0, 20), ny))
+	assert.Equal(t, int64(1), weekdays.days(day(0, 10), day(0, 21), ny), "the rollover instant counts")
+	assert.Equal(t, int64(0), weekdays.days(day(0, 21), day(0, 23), ny), "but not twice")
+	assert.Equal(t, int64(5), weekdays.days(day(0, 0), day(7, 0), ny), "a whole week, weekdays only")
+	assert.Equal(t, int64(7), triple.days(day(0, 0), day(7, 0), ny), "Wednesday covers the weekend")
+
+	utc, err := types.ParseRollover("00:00 UTC")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), weekdays.days(day(0, 10), day(0, 23), utc))
+	assert.Equal(t, int64(1), weekdays.days(day(0, 10), day(1, 0), utc), "a midnight rollover")
+}
+
+func TestMarkGap_StopFillsAtQuote(t *testing.T) {
+	ctx := context.Background()
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	require.NoError(t, s.UpdatePrice(weekTick(110_000, 1, 10)))
+	_, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 1.09)
+	require.NoError(t, err)
+
+	s.MarkGap("EUR_USD")
+	require.NoError(t, s.UpdatePrice(weekTick(108_000, 1, 11)))
+	require.Len(t, acct.Trades, 1)
+	assert.Equal(t, types.Price(107_999), acct.Trades[0].ExitPrice, "the stop fills at the gapped bid")
+	assert.Empty(t, s.gaps, "the mark covers one price only")
+}
@@ -0,0 +1,36 @@
+package sim
+
+import (
+	"fmt"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// accrueInterest credits IdleInterest on each account's idle cash for
+// every rollover in (from, to]. Unlike swap it accrues on every calendar
+// day, weekends included, the way a cash deposit earns. Callers hold
+// e.mu.
+func (e *Sim) accrueInterest(from, to types.Timestamp) error {
+	if e.IdleInterest == 0 {
+		return nil
+	}
+	days := rolloverDays(from, to, types.CurrentRollover())
+	if days == 0 {
+		return nil
+	}
+	for _, acct := range e.accounts() {
+		if _, err := acct.AccrueInterest(e.IdleInterest, days); err != nil {
+			return fmt.Errorf("sim: interest on %s: %w", acct.ID, err)
+		}
+	}
+	return nil
+}
+
+// rolloverDays counts the rollovers r places in (from, to].
+func rolloverDays(from, to types.Timestamp, r types.Rollover) int64 {
+	var n int64
+	for roll := r.Next(from.Time()); !roll.After(to.Time()); roll = r.Next(roll) {
+		n++
+	}
+	return n
+}
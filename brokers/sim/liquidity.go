@@ -0,0 +1,109 @@
+package sim
+
+import (
+	"fmt"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// DepthLevel is one rung of a Liquidity book: Units offered on each tick
+// at Offset beyond the quoted price (above the ask for buys, below the
+// bid for sells).
+type DepthLevel struct {
+	Units  types.Units
+	Offset types.Price
+}
+
+// Liquidity is the depth curve of a finite book that refills on every
+// tick. A market order walks the levels best first; whatever the book
+// can't absorb on one tick waits for the next, so a large order fills in
+// several partial fills, each at the volume-weighted price of the levels
+// it took.
+type Liquidity struct {
+	Levels []DepthLevel
+}
+
+// Validate checks that the book has depth and that each level offers
+// units at an offset no better than the one before it.
+func (l *Liquidity) Validate() error {
+	if l == nil || len(l.Levels) == 0 {
+		return fmt.Errorf("liquidity needs at least one depth level")
+	}
+	var prev types.Price
+	for i, lv := range l.Levels {
+		if lv.Units <= 0 {
+			return fmt.Errorf("depth level %d: units must be > 0, got %d", i+1, lv.Units)
+		}
+		if lv.Offset < 0 || lv.Offset < prev {
+			return fmt.Errorf("depth level %d: offset %s must be >= 0 and >= the level before it", i+1, lv.Offset)
+		}
+		prev = lv.Offset
+	}
+	return nil
+}
+
+// take fills up to want units from one tick's book and returns the units
+// filled and their volume-weighted offset, rounded against the taker.
+func (l *Liquidity) take(want types.Units) (types.Units, types.Price) {
+	var filled types.Units
+	var cost int64
+	for _, lv := range l.Levels {
+		if filled == want {
+			break
+		}
+		n := min(lv.Units, want-filled)
+		filled += n
+		cost += int64(n) * int64(lv.Offset)
+	}
+	if filled == 0 {
+		return 0, 0
+	}
+	return filled, types.Price((cost + int64(filled) - 1) / int64(filled))
+}
+
+// bookFill fills as much of the working market order o as tick's book
+// allows, opens a lot for it, and journals the partial fill (and, on the
+// last one, the order filled). o.Units is reduced by the amount filled.
+// Callers hold e.mu.
+func (e *Sim) bookFill(o *PendingOrder, tick market.Tick) (*oanda.OrderResult, error) {
+	isBuy := o.Units > 0
+	want := types.Units(o.Units)
+	if !isBuy {
+		want = -want
+	}
+	filled, offset := e.Liquidity.take(want)
+	if filled == 0 {
+		return nil, nil
+	}
+
+	price := tick.Bid - offset
+	units := -int64(filled)
+	if isBuy {
+		price = tick.Ask + offset
+		units = int64(filled)
+	}
+	price = e.fillPrice(o.Instrument, isBuy, price)
+
+	res, err := e.openLot(o.AccountID, o.Instrument, units, price, o.Stop, tick.Timestamp, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	o.Units -= units
+
+	e.recordOrderEvent(journal.OrderEvent{
+		Type:       journal.OrderPartialFill,
+		OrderID:    o.ID,
+		TradeID:    res.TradeID,
+		AccountID:  e.journalAccountID(e.orderAccount(o.AccountID)),
+		Instrument: o.Instrument,
+		Units:      units,
+		Price:      price,
+		Time:       tick.Timestamp,
+		Remaining:  o.Units,
+	})
+	e.orderFilled(o.ID, res.TradeID, units, price, tick.Timestamp)
+	return res, nil
+}
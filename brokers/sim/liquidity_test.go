@@ -0,0 +1,126 @@
+package sim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiquidity_Validate(t *testing.T) {
+	require.NoError(t, (&Liquidity{Levels: []DepthLevel{{Units: 100}, {Units: 200, Offset: 5}}}).Validate())
+
+	for name, l := range map[string]*Liquidity{
+		"nil":        nil,
+		"empty":      {},
+		"zero units": {Levels: []DepthLevel{{Units: 0}}},
+		"negative":   {Levels: []DepthLevel{{Units: 100, Offset: -1}}},
+		"improving":  {Levels: []DepthLevel{{Units: 100, Offset: 5}, {Units: 100, Offset: 2}}},
+	} {
+		assert.Error(t, l.Validate(), name)
+	}
+}
+
+func TestLiquidity_TakeWeightsOffsets(t *testing.T) {
+	l := &Liquidity{Levels: []DepthLevel{{Units: 100, Offset: 0}, {Units: 200, Offset: 3}}}
+
+	filled, off := l.take(50)
+	assert.Equal(t, types.Units(50), filled)
+	assert.Equal(t, types.Price(0), off)
+
+	// 100 at 0 and 50 at 3: 150/150 = 1.
+	filled, off = l.take(150)
+	assert.Equal(t, types.Units(150), filled)
+	assert.Equal(t, types.Price(1), off)
+
+	// 100 at 0 and 200 at 3: 600/300 = 2, capped at the book's depth.
+	filled, off = l.take(1000)
+	assert.Equal(t, types.Units(300), filled)
+	assert.Equal(t, types.Price(2), off)
+
+	// 100 at 0 and 100 at 3: 1.5 rounds against the taker.
+	_, off = l.take(200)
+	assert.Equal(t, types.Price(2), off)
+}
+
+func TestSubmitMarketOrder_PartialFillsAcrossTicks(t *testing.T) {
+	ctx := context.Background()
+	j := &stubJournal{}
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(1_000_000)), j)
+	s.Liquidity = &Liquidity{Levels: []DepthLevel{{Units: 100_000, Offset: 0}, {Units: 100_000, Offset: 10}}}
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	res, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 500_000, 1.09)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200_000), res.Units)
+	assert.InDelta(t, 1.10006, res.Price, 1e-9, "ask 1.10001 plus the weighted 5-unit offset")
+
+	pending := s.PendingOrders("")
+	require.Len(t, pending, 1)
+	assert.True(t, pending[0].Market)
+	assert.Equal(t, int64(300_000), pending[0].Units)
+	assert.Equal(t, res.OrderID, pending[0].ID)
+
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_020, 101)))
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_040, 102)))
+	assert.Empty(t, s.PendingOrders(""), "order is done once every unit has filled")
+
+	lots := s.account.Lots.Slice()
+	require.Len(t, lots, 3)
+	var total types.Units
+	for _, lot := range lots {
+		total += lot.Units
+		assert.Equal(t, types.PriceFromFloat(1.09), lot.Stop)
+	}
+	assert.Equal(t, types.Units(500_000), total)
+
+	require.Len(t, j.orders, 5)
+	assert.Equal(t, journal.OrderSubmitted, j.orders[0].Type)
+	for i, want := range []struct {
+		units, remaining int64
+		price            types.Price
+	}{
+		{200_000, 300_000, 110_006},
+		{200_000, 100_000, 110_026},
+		{100_000, 0, 110_041},
+	} {
+		ev := j.orders[i+1]
+		assert.Equal(t, journal.OrderPartialFill, ev.Type)
+		assert.Equal(t, res.OrderID, ev.OrderID)
+		assert.Equal(t, want.units, ev.Units, "fill %d", i)
+		assert.Equal(t, want.remaining, ev.Remaining, "fill %d", i)
+		assert.Equal(t, want.price, ev.Price, "fill %d", i)
+		assert.Equal(t, lots[i].ID, ev.TradeID, "fill %d", i)
+	}
+	filled := j.orders[4]
+	assert.Equal(t, journal.OrderFilled, filled.Type)
+	assert.Equal(t, lots[2].ID, filled.TradeID)
+}
+
+func TestSubmitMarketOrder_PartialFillSellAndCancel(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(1_000_000)), nil)
+	s.Liquidity = &Liquidity{Levels: []DepthLevel{{Units: 100_000, Offset: 4}}}
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	res, err := s.SubmitMarketOrder(ctx, "", "EURUSD", -250_000, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-100_000), res.Units)
+	assert.InDelta(t, 1.09995, res.Price, 1e-9, "bid 1.09999 less the 4-unit offset")
+
+	require.NoError(t, s.CancelOrder(ctx, "", res.OrderID))
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 101)))
+	assert.Equal(t, 1, s.account.Lots.Len(), "a cancelled remainder stops filling")
+}
+
+func TestSubmitMarketOrder_InvalidLiquidity(t *testing.T) {
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), nil)
+	s.Liquidity = &Liquidity{}
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+	_, err := s.SubmitMarketOrder(context.Background(), "", "EURUSD", 1_000, 0)
+	require.ErrorContains(t, err, "at least one depth level")
+}
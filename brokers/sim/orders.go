@@ -0,0 +1,409 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Cancel reasons carried on ORDER_CANCEL transactions and order events,
+// named after OANDA's transaction reasons.
+const (
+	cancelReasonExpired   = "TIME_IN_FORCE_EXPIRED"
+	cancelReasonUnfilled  = "MARKET_HALTED_OR_NOT_MARKETABLE"
+	cancelReasonRequested = "CLIENT_REQUEST"
+)
+
+// PendingOrder is a resting limit order: buy (Units > 0) once the ask is at
+// or below Price, sell (Units < 0) once the bid is at or above it. With
+// Market set it is instead the unfilled remainder of a market order under
+// a Liquidity model, filled from the book on each tick until done, or a
+// market order queued while its market was closed (see MarketHours).
+type PendingOrder struct {
+	ID         string
+	AccountID  string
+	Instrument string
+	Units      int64       // signed: positive buy, negative sell
+	Price      types.Price // limit price
+	Stop       types.Price // stop-loss for the resulting lot; 0 for none
+	TIF        brokers.TimeInForce
+	Expiry     types.Timestamp // GTD only; the order expires at the first tick at or after it
+	Created    types.Timestamp
+	Market     bool // working market order; Price is unused
+}
+
+// SubmitLimitOrder places a limit order for units (positive buy, negative
+// sell) at price. A limit that is marketable on submission fills at once
+// against the tracked bid/ask, capped at price; otherwise FOK and IOC are
+// cancelled immediately (Sim has no depth, so every fill is all-or-nothing
+// and the two behave alike), and GTC/GTD rest until UpdatePrice makes them
+// marketable, a GTD order's Expiry passes, or CancelOrder removes them.
+//
+// The returned OrderResult has an empty TradeID when the order did not
+// fill.
+func (e *Sim) SubmitLimitOrder(ctx context.Context, accountID, instrument string, units int64, price, stop types.Price, tif brokers.TimeInForce, expiry types.Timestamp) (*oanda.OrderResult, error) {
+	if e == nil || e.account == nil {
+		return nil, fmt.Errorf("sim broker account is nil")
+	}
+	if units == 0 {
+		return nil, fmt.Errorf("sim: units must be non-zero")
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("sim: limit price must be > 0")
+	}
+	tif = brokers.TimeInForce(strings.ToUpper(strings.TrimSpace(string(tif))))
+	switch tif {
+	case "":
+		tif = brokers.GTC
+	case brokers.GTC, brokers.FOK, brokers.IOC:
+	case brokers.GTD:
+		if expiry <= 0 {
+			return nil, fmt.Errorf("sim: GTD order needs an expiry")
+		}
+	default:
+		return nil, fmt.Errorf("sim: unknown time in force %q", tif)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, err := e.accountFor(accountID); err != nil {
+		return nil, err
+	}
+	inst := market.NormalizeInstrument(instrument)
+	px, ok := e.prices.latest[inst]
+	if !ok {
+		return nil, errs.Newf(errs.ErrNoPrice, "sim: no market price for %s", inst)
+	}
+	price, stop = market.RoundPrice(inst, price), market.RoundPrice(inst, stop)
+	if err := e.MinStops.Check(inst, units > 0, price, stop, 0); err != nil {
+		return nil, fmt.Errorf("sim: %w", err)
+	}
+	if tif == brokers.GTD && expiry <= px.Timestamp {
+		return nil, fmt.Errorf("sim: GTD expiry %s is not after the current time", expiry)
+	}
+	open := e.Hours.isOpen(inst, px.Timestamp)
+	if !open && !e.Hours.Queue {
+		return nil, fmt.Errorf("sim: %s: %w", inst, ErrMarketClosed)
+	}
+
+	order := &PendingOrder{
+		ID:         e.newID(),
+		AccountID:  accountID,
+		Instrument: inst,
+		Units:      units,
+		Price:      price,
+		Stop:       stop,
+		TIF:        tif,
+		Expiry:     expiry,
+		Created:    px.Timestamp,
+	}
+	e.trackOrder(order, brokers.LimitOrder)
+	if fillPrice, ok := e.limitFillPrice(order, px); ok && open {
+		res, err := e.openLot(accountID, inst, units, fillPrice, stop, px.Timestamp, order.ID)
+		if err != nil {
+			return nil, err
+		}
+		e.orderFilled(order.ID, res.TradeID, units, fillPrice, px.Timestamp)
+		return res, nil
+	}
+
+	result := &oanda.OrderResult{OrderID: order.ID, Instrument: inst, Units: units}
+	if tif == brokers.FOK || tif == brokers.IOC {
+		e.cancelOrder(order, px.Timestamp, cancelReasonUnfilled)
+		return result, nil
+	}
+	e.orders = append(e.orders, order)
+	return result, nil
+}
+
+// CancelOrder removes a resting order. Like CloseTrade, an order is only
+// reachable through the account that placed it.
+func (e *Sim) CancelOrder(ctx context.Context, accountID, orderID string) error {
+	if e == nil || e.account == nil {
+		return fmt.Errorf("sim broker account is nil")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	acct, err := e.accountFor(accountID)
+	if err != nil {
+		return err
+	}
+	for i, o := range e.orders {
+		if o.ID != orderID || e.orderAccount(o.AccountID) != acct {
+			continue
+		}
+		e.orders = append(e.orders[:i], e.orders[i+1:]...)
+		e.cancelOrder(o, e.prices.latest[o.Instrument].Timestamp, cancelReasonRequested)
+		return nil
+	}
+	return fmt.Errorf("sim: no pending order %s", orderID)
+}
+
+// PendingOrders returns copies of accountID's resting orders in submission
+// order.
+func (e *Sim) PendingOrders(accountID string) []PendingOrder {
+	if e == nil {
+		return nil
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	acct, err := e.accountFor(accountID)
+	if err != nil {
+		return nil
+	}
+	var out []PendingOrder
+	for _, o := range e.orders {
+		if e.orderAccount(o.AccountID) == acct {
+			out = append(out, *o)
+		}
+	}
+	return out
+}
+
+// processOrders runs on every UpdatePrice after tick has been recorded:
+// GTD orders whose Expiry has been reached (by a tick on any instrument —
+// simulated time is shared) are expired first, then resting orders on the
+// ticked instrument that have become marketable are filled, oldest first.
+// Working market orders take what the tick's book offers.
+func (e *Sim) processOrders(inst string, tick market.Tick) error {
+	if len(e.orders) == 0 {
+		return nil
+	}
+	kept := e.orders[:0]
+	var firstErr error
+	for _, o := range e.orders {
+		if o.TIF == brokers.GTD && tick.Timestamp >= o.Expiry {
+			e.cancelOrder(o, tick.Timestamp, cancelReasonExpired)
+			continue
+		}
+		if o.Market && o.Instrument == inst && firstErr == nil {
+			if err := e.fillWorking(o, tick); err != nil {
+				firstErr = fmt.Errorf("sim: fill order %s: %w", o.ID, err)
+			} else if o.Units == 0 {
+				continue
+			}
+		} else if o.Instrument == inst && firstErr == nil {
+			if fillPrice, ok := e.limitFillPrice(o, tick); ok {
+				if res, err := e.openLot(o.AccountID, inst, o.Units, fillPrice, o.Stop, tick.Timestamp, o.ID); err != nil {
+					firstErr = fmt.Errorf("sim: fill order %s: %w", o.ID, err)
+				} else {
+					e.orderFilled(o.ID, res.TradeID, o.Units, fillPrice, tick.Timestamp)
+					continue
+				}
+			}
+		}
+		kept = append(kept, o)
+	}
+	for i := len(kept); i < len(e.orders); i++ {
+		e.orders[i] = nil
+	}
+	e.orders = kept
+	return firstErr
+}
+
+// fillWorking fills the working market order o from tick: from the book
+// under a Liquidity model, otherwise in full at the quote. Callers hold
+// e.mu.
+func (e *Sim) fillWorking(o *PendingOrder, tick market.Tick) error {
+	if e.Liquidity != nil {
+		_, err := e.bookFill(o, tick)
+		return err
+	}
+	isBuy := o.Units > 0
+	price := tick.Bid
+	if isBuy {
+		price = tick.Ask
+	}
+	price = e.fillPrice(o.Instrument, isBuy, price)
+	res, err := e.openLot(o.AccountID, o.Instrument, o.Units, price, o.Stop, tick.Timestamp, o.ID)
+	if err != nil {
+		return err
+	}
+	e.orderFilled(o.ID, res.TradeID, o.Units, price, tick.Timestamp)
+	o.Units = 0
+	return nil
+}
+
+// limitFillPrice reports whether o is marketable at px and, if so, its
+// fill price: the tracked ask (buy) or bid (sell) plus Slippage, never
+// worse than the limit.
+func (e *Sim) limitFillPrice(o *PendingOrder, px market.Tick) (types.Price, bool) {
+	isBuy := o.Units > 0
+	if isBuy {
+		if px.Ask > o.Price {
+			return 0, false
+		}
+		return min(e.fillPrice(o.Instrument, true, px.Ask), o.Price), true
+	}
+	if px.Bid < o.Price {
+		return 0, false
+	}
+	return max(e.fillPrice(o.Instrument, false, px.Bid), o.Price), true
+}
+
+// cancelOrder emits an ORDER_CANCEL for o, marks its Order cancelled (or
+// expired, for a lapsed GTD order) and journals that.
+func (e *Sim) cancelOrder(o *PendingOrder, ts types.Timestamp, reason string) {
+	e.emitFill(oanda.Transaction{
+		Type:       "ORDER_CANCEL",
+		AccountID:  o.AccountID,
+		Time:       ts.Time(),
+		Reason:     reason,
+		Instrument: o.Instrument,
+		Units:      o.Units,
+		Price:      o.Price.Float64(),
+		OrderID:    o.ID,
+	})
+
+	kind, state := journal.OrderCancelled, brokers.OrderCancelled
+	if reason == cancelReasonExpired {
+		kind, state = journal.OrderExpired, brokers.OrderExpired
+	}
+	if h := e.orderIDs[o.ID]; h != nil {
+		h.State, h.Reason, h.Updated = state, reason, ts
+	}
+	e.recordOrderEvent(journal.OrderEvent{
+		Type:       kind,
+		OrderID:    o.ID,
+		AccountID:  e.journalAccountID(e.orderAccount(o.AccountID)),
+		Instrument: o.Instrument,
+		Units:      o.Units,
+		Price:      o.Price,
+		Time:       ts,
+		Reason:     reason,
+	})
+}
+
+// Orders returns copies of accountID's orders, pending and finished, in
+// submission order. It implements OrderBook.
+func (e *Sim) Orders(ctx context.Context, accountID string) ([]brokers.Order, error) {
+	if e == nil || e.account == nil {
+		return nil, fmt.Errorf("sim broker account is nil")
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	acct, err := e.accountFor(accountID)
+	if err != nil {
+		return nil, err
+	}
+	var out []brokers.Order
+	for _, o := range e.history {
+		if e.orderAccount(o.AccountID) == acct {
+			c := *o
+			c.TradeIDs = slices.Clone(o.TradeIDs)
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// trackOrder adds o to the order history as pending and journals its
+// submission. Callers hold e.mu.
+func (e *Sim) trackOrder(o *PendingOrder, typ brokers.OrderType) {
+	if e.orderIDs == nil {
+		e.orderIDs = make(map[string]*brokers.Order)
+	}
+	h := &brokers.Order{
+		ID:         o.ID,
+		AccountID:  o.AccountID,
+		Instrument: o.Instrument,
+		Type:       typ,
+		Units:      o.Units,
+		Price:      o.Price,
+		Stop:       o.Stop,
+		State:      brokers.OrderPending,
+		Submitted:  o.Created,
+		Updated:    o.Created,
+	}
+	e.history = append(e.history, h)
+	e.orderIDs[h.ID] = h
+	e.recordOrderEvent(journal.OrderEvent{
+		Type:       journal.OrderSubmitted,
+		OrderID:    h.ID,
+		AccountID:  e.journalAccountID(e.orderAccount(h.AccountID)),
+		Instrument: h.Instrument,
+		Units:      h.Units,
+		Price:      h.Price,
+		Time:       h.Submitted,
+	})
+}
+
+// orderFilled books a fill of units at price, opening tradeID, against
+// orderID's history entry, and marks it filled — journaling that — once
+// no units remain. Callers hold e.mu.
+func (e *Sim) orderFilled(orderID, tradeID string, units int64, price types.Price, ts types.Timestamp) {
+	h := e.orderIDs[orderID]
+	if h == nil {
+		return
+	}
+	h.FilledUnits += units
+	h.FillPrice = price
+	h.TradeIDs = append(h.TradeIDs, tradeID)
+	h.Updated = ts
+	if h.Remaining() != 0 {
+		return
+	}
+	h.State = brokers.OrderFilled
+	e.recordOrderEvent(journal.OrderEvent{
+		Type:       journal.OrderFilled,
+		OrderID:    h.ID,
+		TradeID:    tradeID,
+		AccountID:  e.journalAccountID(e.orderAccount(h.AccountID)),
+		Instrument: h.Instrument,
+		Units:      units,
+		Price:      price,
+		Time:       ts,
+	})
+}
+
+// recordOrderEvent journals ev when the journal records order events;
+// otherwise it is dropped.
+func (e *Sim) recordOrderEvent(ev journal.OrderEvent) {
+	oj, ok := e.journal.(journal.OrderJournal)
+	if !ok {
+		return
+	}
+	_ = oj.RecordOrderEvent(ev)
+}
+
+// RecordSkippedSignal journals s when the journal records skipped signals;
+// otherwise it is dropped. Sim holds the run's journal, so callers that
+// drive it (the backtest loop) report skipped entries through here.
+func (e *Sim) RecordSkippedSignal(s journal.SkippedSignal) {
+	sj, ok := e.journal.(journal.SignalJournal)
+	if !ok {
+		return
+	}
+	_ = sj.RecordSkippedSignal(s)
+}
+
+// RecordSignal journals s when the journal records signals; otherwise it
+// is dropped, as with RecordSkippedSignal.
+func (e *Sim) RecordSignal(s journal.SignalRecord) {
+	sj, ok := e.journal.(journal.SignalRecordJournal)
+	if !ok {
+		return
+	}
+	_ = sj.RecordSignal(s)
+}
+
+// RecordOrderDecision journals d when the journal records order decisions;
+// otherwise it is dropped, as with RecordSkippedSignal.
+func (e *Sim) RecordOrderDecision(d journal.OrderDecision) {
+	dj, ok := e.journal.(journal.DecisionJournal)
+	if !ok {
+		return
+	}
+	_ = dj.RecordOrderDecision(d)
+}
@@ -0,0 +1,242 @@
+package sim
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eurusdTickAt(mid types.Price, ts types.Timestamp) market.Tick {
+	tick := eurusdTick(mid)
+	tick.Timestamp = ts
+	return tick
+}
+
+func TestSubmitLimitOrder_Validation(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), nil)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	tests := []struct {
+		name   string
+		units  int64
+		price  types.Price
+		tif    brokers.TimeInForce
+		expiry types.Timestamp
+		want   string
+	}{
+		{"zero units", 0, 109_000, brokers.GTC, 0, "units must be non-zero"},
+		{"zero price", 1000, 0, brokers.GTC, 0, "limit price must be > 0"},
+		{"unknown tif", 1000, 109_000, "DAY", 0, "unknown time in force"},
+		{"gtd without expiry", 1000, 109_000, brokers.GTD, 0, "needs an expiry"},
+		{"gtd expiry in the past", 1000, 109_000, brokers.GTD, 100, "not after the current time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.SubmitLimitOrder(ctx, "", "EURUSD", tt.units, tt.price, 0, tt.tif, tt.expiry)
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestSubmitLimitOrder_MarketableFillsImmediately(t *testing.T) {
+	ctx := context.Background()
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	res, err := s.SubmitLimitOrder(ctx, "", "EURUSD", 1000, 110_050, 0, brokers.FOK, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, res.TradeID)
+	assert.NotEqual(t, res.OrderID, res.TradeID)
+	assert.InDelta(t, 1.10001, res.Price, 1e-9, "fills at the ask, not the limit")
+	assert.Equal(t, 1, acct.Lots.Len())
+	assert.Empty(t, s.PendingOrders(""))
+}
+
+func TestSubmitLimitOrder_ImmediateTIFCancelsWhenNotMarketable(t *testing.T) {
+	for _, tif := range []brokers.TimeInForce{brokers.FOK, brokers.IOC} {
+		t.Run(string(tif), func(t *testing.T) {
+			acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+			j := &stubJournal{}
+			s := NewSimBroker(acct, j)
+			require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+			res, err := s.SubmitLimitOrder(context.Background(), "", "EURUSD", 1000, 109_000, 0, tif, 0)
+			require.NoError(t, err)
+			assert.Empty(t, res.TradeID)
+			assert.Equal(t, 0, acct.Lots.Len())
+			assert.Empty(t, s.PendingOrders(""))
+			require.Len(t, j.orders, 2)
+			assert.Equal(t, journal.OrderSubmitted, j.orders[0].Type)
+			assert.Equal(t, journal.OrderCancelled, j.orders[1].Type)
+		})
+	}
+}
+
+func TestPendingOrder_FillsWhenPriceReachesLimit(t *testing.T) {
+	ctx := context.Background()
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	buy, err := s.SubmitLimitOrder(ctx, "", "EURUSD", 1000, 109_500, 109_000, brokers.GTC, 0)
+	require.NoError(t, err)
+	sell, err := s.SubmitLimitOrder(ctx, "", "EURUSD", -1000, 111_000, 0, "", 0)
+	require.NoError(t, err)
+	require.Len(t, s.PendingOrders(""), 2)
+
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(109_600, 200)))
+	assert.Equal(t, 0, acct.Lots.Len(), "ask 1.09601 is above the buy limit")
+
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(109_400, 300)))
+	require.Equal(t, 1, acct.Lots.Len())
+	pending := s.PendingOrders("")
+	require.Len(t, pending, 1)
+	assert.Equal(t, sell.OrderID, pending[0].ID)
+
+	var lot *account.Lot
+	_ = acct.Lots.Range(func(l *account.Lot) error { lot = l; return nil })
+	assert.Equal(t, types.Price(109_401), lot.EntryPrice)
+	assert.Equal(t, types.Price(109_000), lot.Stop)
+	assert.Equal(t, types.Timestamp(300), lot.EntryTime)
+
+	fill := <-s.events
+	assert.Equal(t, "ORDER_FILL", fill.Tx.Type)
+	assert.Equal(t, buy.OrderID, fill.Tx.OrderID)
+	assert.Equal(t, lot.ID, fill.Tx.TradeID)
+}
+
+func TestPendingOrder_GTDExpiresAsTimeAdvances(t *testing.T) {
+	ctx := context.Background()
+	j := &stubJournal{}
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), j)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	res, err := s.SubmitLimitOrder(ctx, "", "EURUSD", 1000, 109_000, 0, brokers.GTD, 500)
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 499)))
+	require.Len(t, s.PendingOrders(""), 1)
+
+	// A tick on another instrument advances simulated time too.
+	require.NoError(t, s.UpdatePrice(market.Tick{
+		Instrument: "GBPUSD",
+		Timestamp:  500,
+		BA:         market.BA{Bid: 127_000, Ask: 127_002},
+	}))
+	assert.Empty(t, s.PendingOrders(""))
+	require.Len(t, j.orders, 2)
+	assert.Equal(t, journal.OrderSubmitted, j.orders[0].Type)
+	ev := j.orders[1]
+	assert.Equal(t, journal.OrderExpired, ev.Type)
+	assert.Equal(t, res.OrderID, ev.OrderID)
+	assert.Equal(t, types.Timestamp(500), ev.Time)
+	assert.Equal(t, "TIME_IN_FORCE_EXPIRED", ev.Reason)
+
+	cancel := <-s.events
+	assert.Equal(t, "ORDER_CANCEL", cancel.Tx.Type)
+	assert.Equal(t, res.OrderID, cancel.Tx.OrderID)
+
+	// An expired order never fills afterwards.
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(108_000, 600)))
+	assert.Equal(t, 0, s.account.Lots.Len())
+}
+
+func TestPendingOrder_ExpiryInFileJournal(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath := filepath.Join(dir, "run-trades.jsonl")
+	j, err := journal.Open(journal.Config{Kind: "json", TradesPath: tradesPath, EquityPath: filepath.Join(dir, "run-equity.jsonl")})
+	require.NoError(t, err)
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), j)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	res, err := s.SubmitLimitOrder(context.Background(), "", "EURUSD", 1000, 109_000, 0, brokers.GTD, 500)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 500)))
+	require.NoError(t, j.Close())
+
+	f, err := os.Open(filepath.Join(dir, "run-trades.orders.jsonl"))
+	require.NoError(t, err)
+	defer f.Close()
+	var events []journal.OrderEvent
+	for dec := json.NewDecoder(f); dec.More(); {
+		var ev journal.OrderEvent
+		require.NoError(t, dec.Decode(&ev))
+		events = append(events, ev)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, journal.OrderExpired, events[1].Type)
+	assert.Equal(t, res.OrderID, events[1].OrderID)
+	assert.Equal(t, types.Timestamp(500), events[1].Time)
+}
+
+func TestCancelOrder(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), nil)
+	_, err := s.AllocateSubAccount("ema", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	res, err := s.SubmitLimitOrder(ctx, "ema", "EURUSD", 1000, 109_000, 0, brokers.GTC, 0)
+	require.NoError(t, err)
+	assert.Empty(t, s.PendingOrders(""))
+	require.Len(t, s.PendingOrders("ema"), 1)
+
+	require.ErrorContains(t, s.CancelOrder(ctx, "", res.OrderID), "no pending order")
+	require.NoError(t, s.CancelOrder(ctx, "ema", res.OrderID))
+	assert.Empty(t, s.PendingOrders("ema"))
+}
+
+func TestOrders_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(1_000_000)), nil)
+	_, err := s.AllocateSubAccount("ema", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	mkt, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	limit, err := s.SubmitLimitOrder(ctx, "", "EURUSD", 1000, 109_500, 0, brokers.GTC, 0)
+	require.NoError(t, err)
+	gone, err := s.SubmitLimitOrder(ctx, "", "EURUSD", -1000, 112_000, 0, brokers.GTC, 0)
+	require.NoError(t, err)
+	_, err = s.SubmitLimitOrder(ctx, "ema", "EURUSD", 1000, 109_000, 0, brokers.GTC, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.CancelOrder(ctx, "", gone.OrderID))
+
+	orders, err := s.Orders(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, orders, 3, "the sub-account's order is not listed")
+	assert.Equal(t, brokers.MarketOrder, orders[0].Type)
+	assert.Equal(t, brokers.OrderFilled, orders[0].State)
+	assert.Equal(t, []string{mkt.TradeID}, orders[0].TradeIDs)
+	assert.Equal(t, brokers.OrderPending, orders[1].State)
+	assert.Equal(t, brokers.LimitOrder, orders[1].Type)
+	assert.Equal(t, int64(1000), orders[1].Remaining())
+	assert.Equal(t, brokers.OrderCancelled, orders[2].State)
+	assert.Equal(t, "CLIENT_REQUEST", orders[2].Reason)
+	assert.True(t, orders[2].State.Terminal())
+
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(109_400, 200)))
+	orders, err = s.Orders(ctx, "")
+	require.NoError(t, err)
+	o := orders[1]
+	assert.Equal(t, limit.OrderID, o.ID)
+	assert.Equal(t, brokers.OrderFilled, o.State)
+	assert.Equal(t, int64(1000), o.FilledUnits)
+	assert.Equal(t, types.Price(109_401), o.FillPrice)
+	assert.Equal(t, types.Timestamp(200), o.Updated)
+	require.Len(t, o.TradeIDs, 1)
+	assert.NotEqual(t, o.ID, o.TradeIDs[0], "a resting order and its trade have their own IDs")
+}
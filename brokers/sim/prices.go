@@ -0,0 +1,171 @@
+package sim
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rustyeddy/trader/market"
+)
+
+// PriceStore holds Sim's prices: the latest tick per instrument and,
+// once SetDepth is given a positive depth, a bounded history of the last
+// ticks per instrument in a ring buffer. Strategies that want tick
+// microstructure (tick momentum, spread averaging) read it through
+// strategy.TickHistory, which a backtest serves from here, instead of
+// keeping buffers of their own.
+//
+// Writes come only from Sim, which holds its own lock while it makes
+// them, so Sim's internals read latest directly. Everyone else goes
+// through the exported methods, which take the store's lock and are safe
+// to call while a feed drives UpdatePrice.
+type PriceStore struct {
+	mu     sync.RWMutex
+	depth  int
+	latest map[string]market.Tick
+	rings  map[string]*tickRing
+}
+
+func newPriceStore() *PriceStore {
+	return &PriceStore{latest: make(map[string]market.Tick)}
+}
+
+// Prices returns Sim's price store.
+func (e *Sim) Prices() *PriceStore {
+	if e == nil {
+		return nil
+	}
+	return e.prices
+}
+
+// SeedPrices sets the latest price of each tick's instrument ahead of the
+// feed, so orders placed before the first tick arrives fill at a real
+// quote — typically the feed's own first tick (see market.FirstTicks).
+// Unlike UpdatePrice it only records the price: nothing is marked, no
+// stop or order is checked, the clock doesn't move and the tick history
+// is untouched, so the feed can apply the same tick again as usual. An
+// instrument already priced at or after the seed's time, by an earlier
+// tick or a restored state, keeps its price.
+func (e *Sim) SeedPrices(ticks ...market.Tick) error {
+	if e == nil || e.account == nil {
+		return fmt.Errorf("sim broker account is nil")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, tick := range ticks {
+		inst := market.NormalizeInstrument(tick.Instrument)
+		if inst == "" {
+			return fmt.Errorf("blank instrument")
+		}
+		tick.Instrument = inst
+		if err := tick.Validate(); err != nil {
+			return err
+		}
+		if held, ok := e.prices.latest[inst]; ok && held.Timestamp >= tick.Timestamp {
+			continue
+		}
+		e.prices.seed(tick)
+	}
+	return nil
+}
+
+// SetDepth sets how many ticks of history to keep per instrument. Zero
+// (the default) keeps only the latest tick. Shrinking keeps the newest
+// ticks already held.
+func (p *PriceStore) SetDepth(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.depth = max(n, 0)
+	for inst, r := range p.rings {
+		if p.depth == 0 {
+			delete(p.rings, inst)
+			continue
+		}
+		p.rings[inst] = r.resize(p.depth)
+	}
+}
+
+// History returns up to the last n ticks seen for instrument, oldest
+// first. It returns fewer when fewer are held, and nil when history is
+// off or nothing has been seen. The slice is a copy.
+func (p *PriceStore) History(instrument string, n int) []market.Tick {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	r := p.rings[market.NormalizeInstrument(instrument)]
+	if r == nil || n <= 0 {
+		return nil
+	}
+	return r.last(n)
+}
+
+// put records tick, whose instrument is already normalized.
+func (p *PriceStore) put(tick market.Tick) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latest[tick.Instrument] = tick
+	if p.depth == 0 {
+		return
+	}
+	if p.rings == nil {
+		p.rings = make(map[string]*tickRing)
+	}
+	r := p.rings[tick.Instrument]
+	if r == nil {
+		r = &tickRing{buf: make([]market.Tick, p.depth)}
+		p.rings[tick.Instrument] = r
+	}
+	r.push(tick)
+}
+
+// seed records tick as the latest price without adding it to the history.
+func (p *PriceStore) seed(tick market.Tick) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latest[tick.Instrument] = tick
+}
+
+// reset replaces the latest ticks with prices and drops all history.
+// The depth setting is kept.
+func (p *PriceStore) reset(prices map[string]market.Tick) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latest = prices
+	p.rings = nil
+}
+
+// tickRing is a fixed-capacity ring of ticks; once full, each push
+// overwrites the oldest.
+type tickRing struct {
+	buf  []market.Tick
+	next int // index the next push writes
+	n    int // ticks held, up to len(buf)
+}
+
+func (r *tickRing) push(t market.Tick) {
+	r.buf[r.next] = t
+	r.next = (r.next + 1) % len(r.buf)
+	r.n = min(r.n+1, len(r.buf))
+}
+
+// last copies out the newest min(n, r.n) ticks, oldest first.
+func (r *tickRing) last(n int) []market.Tick {
+	n = min(n, r.n)
+	out := make([]market.Tick, n)
+	start := r.next - n
+	if start < 0 {
+		start += len(r.buf)
+	}
+	for i := range out {
+		out[i] = r.buf[(start+i)%len(r.buf)]
+	}
+	return out
+}
+
+// resize returns a ring of capacity depth holding r's newest ticks.
+func (r *tickRing) resize(depth int) *tickRing {
+	nr := &tickRing{buf: make([]market.Tick, depth)}
+	for _, t := range r.last(depth) {
+		nr.push(t)
+	}
+	return nr
+}
@@ -0,0 +1,127 @@
+package sim
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mids(t *testing.T, s *Sim, instrument string, n int) []types.Price {
+	t.Helper()
+	var out []types.Price
+	for _, tick := range s.Prices().History(instrument, n) {
+		out = append(out, tick.Mid())
+	}
+	return out
+}
+
+// latest returns the last tick s holds for instrument.
+func latest(s *Sim, instrument string) (market.Tick, bool) {
+	s.prices.mu.RLock()
+	defer s.prices.mu.RUnlock()
+	tick, ok := s.prices.latest[market.NormalizeInstrument(instrument)]
+	return tick, ok
+}
+
+func TestPrices_LatestWithoutHistory(t *testing.T) {
+	s := NewSimBroker(nil, nil)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 1)))
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_010, 2)))
+
+	tick, ok := latest(s, "EUR_USD")
+	require.True(t, ok)
+	assert.Equal(t, types.Price(110_010), tick.Mid())
+	assert.Nil(t, s.Prices().History("EURUSD", 5), "depth 0 keeps no history")
+
+	_, ok = latest(s, "USDJPY")
+	assert.False(t, ok)
+}
+
+func TestPrices_HistoryRingKeepsNewest(t *testing.T) {
+	s := NewSimBroker(nil, nil)
+	s.Prices().SetDepth(3)
+	for i := range 5 {
+		require.NoError(t, s.UpdatePrice(eurusdTickAt(types.Price(110_000+i*10), types.Timestamp(i+1))))
+	}
+
+	assert.Equal(t, []types.Price{110_020, 110_030, 110_040}, mids(t, s, "EURUSD", 10))
+	assert.Equal(t, []types.Price{110_030, 110_040}, mids(t, s, "eur_usd", 2))
+	assert.Nil(t, s.Prices().History("EURUSD", 0))
+	assert.Nil(t, s.Prices().History("USDJPY", 3))
+
+	// The slice is a copy: changing it leaves the store alone.
+	h := s.Prices().History("EURUSD", 1)
+	h[0].Bid = 0
+	assert.Equal(t, []types.Price{110_040}, mids(t, s, "EURUSD", 1))
+}
+
+func TestPrices_SetDepthResizes(t *testing.T) {
+	s := NewSimBroker(nil, nil)
+	s.Prices().SetDepth(4)
+	for i := range 4 {
+		require.NoError(t, s.UpdatePrice(eurusdTickAt(types.Price(110_000+i*10), types.Timestamp(i+1))))
+	}
+
+	s.Prices().SetDepth(2)
+	assert.Equal(t, 2, s.prices.depth)
+	assert.Equal(t, []types.Price{110_020, 110_030}, mids(t, s, "EURUSD", 4))
+
+	s.Prices().SetDepth(3)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_040, 5)))
+	assert.Equal(t, []types.Price{110_020, 110_030, 110_040}, mids(t, s, "EURUSD", 4))
+
+	s.Prices().SetDepth(0)
+	assert.Nil(t, s.Prices().History("EURUSD", 4))
+	_, ok := latest(s, "EURUSD")
+	assert.True(t, ok, "dropping history keeps the latest tick")
+}
+
+func TestPrices_LoadStateClearsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := NewSimBroker(nil, nil)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 1)))
+	require.NoError(t, s.SaveState(path))
+
+	s.Prices().SetDepth(2)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_050, 2)))
+	require.NoError(t, s.LoadState(path))
+
+	assert.Nil(t, s.Prices().History("EURUSD", 2))
+	assert.Equal(t, 2, s.prices.depth)
+	tick, ok := latest(s, "EURUSD")
+	require.True(t, ok)
+	assert.Equal(t, types.Price(110_000), tick.Mid())
+}
+
+func TestSeedPrices(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), nil)
+	s.Prices().SetDepth(3)
+
+	_, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.Error(t, err, "no price before the feed starts")
+
+	require.NoError(t, s.SeedPrices(eurusdTickAt(110_000, 10)))
+	tick, ok := latest(s, "EURUSD")
+	require.True(t, ok)
+	assert.Equal(t, types.Price(110_000), tick.Mid())
+	assert.Nil(t, s.Prices().History("EURUSD", 3), "seeding leaves the history alone")
+
+	res, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	assert.InDelta(t, tick.Ask.Float64(), res.Price, 1e-9)
+
+	// A price already held at or after the seed's time wins.
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_050, 20)))
+	require.NoError(t, s.SeedPrices(eurusdTickAt(109_000, 15)))
+	tick, _ = latest(s, "EURUSD")
+	assert.Equal(t, types.Price(110_050), tick.Mid())
+
+	assert.Error(t, s.SeedPrices(market.Tick{Timestamp: 1}))
+}
@@ -3,11 +3,13 @@ package sim
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/rustyeddy/trader/account"
 	"github.com/rustyeddy/trader/brokers"
 	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/idgen"
 	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/log"
@@ -15,13 +17,18 @@ import (
 	"github.com/rustyeddy/trader/types"
 )
 
-// compile-time assertions: *Sim satisfies brokers.Broker and brokers.PriceUpdater.
+// compile-time assertions: *Sim satisfies brokers.Broker and its optional
+// extensions.
 // Asserted here rather than in package brokers, to avoid an import cycle
 // (brokers/sim already depends on account, which depends on brokers for the
 // Broker type itself).
 var (
-	_ brokers.Broker       = (*Sim)(nil)
-	_ brokers.PriceUpdater = (*Sim)(nil)
+	_ brokers.Broker         = (*Sim)(nil)
+	_ brokers.PriceUpdater   = (*Sim)(nil)
+	_ brokers.OrderBook      = (*Sim)(nil)
+	_ brokers.ReasonCloser   = (*Sim)(nil)
+	_ brokers.GapMarker      = (*Sim)(nil)
+	_ brokers.OrderPreviewer = (*Sim)(nil)
 )
 
 // eventQueueSize mirrors account.Account's brokerEventQueueSize (same
@@ -33,19 +40,79 @@ const eventQueueSize = 1024
 // own tracked prices (via UpdatePrice, or synthesized from candles via
 // TickFromCandle) instead of a real network round-trip, using the same
 // account.Account-adjacent bookkeeping (Account.AddLot/CloseLot) a real
-// broker's fills would eventually feed. Wraps exactly one account — the
-// accountID parameter on Broker methods is accepted for interface
-// compliance but not used to look up among multiple accounts.
+// broker's fills would eventually feed. Wraps one primary account plus any
+// sub-accounts allocated with AllocateSubAccount — the accountID parameter
+// on Broker methods selects the primary account (by its ID, or empty) or a
+// sub-account by ID; any other ID is an errs.ErrNotFound error.
 type Sim struct {
+	// mu guards the accounts and prices against concurrent readers (see
+	// Snapshot): every exported method that reads or mutates them takes
+	// it, so a monitoring goroutine can poll state while a feed drives
+	// UpdatePrice. Unexported helpers assume the caller already holds it.
+	mu      sync.RWMutex
 	account *account.Account
 	journal journal.Journal
-	prices  map[string]market.Tick
+	prices  *PriceStore
+
+	// subs holds the sub-accounts by ID (see subaccounts.go). Each has its
+	// own balance, margin, lots, and trade history; all share prices.
+	subs map[string]*account.Account
+
+	// accts is the primary account followed by the sub-accounts in ID
+	// order (see accounts), rebuilt whenever subs changes.
+	accts []*account.Account
+
+	// orders holds resting limit orders across every account, in
+	// submission order (see orders.go).
+	orders []*PendingOrder
+
+	// history holds every order submitted, pending or finished, in
+	// submission order, and orderIDs indexes it by ID (see Orders).
+	history  []*brokers.Order
+	orderIDs map[string]*brokers.Order
 
 	// Slippage is added beyond the tracked bid/ask spread on every fill,
 	// mirroring backtest/execute.go's slippage parameter. Zero by default
 	// (no extra adverse movement beyond the quoted spread).
 	Slippage types.Price
 
+	// Liquidity, when set, replaces infinite top-of-book liquidity with a
+	// finite book: a market order larger than one tick's depth fills in
+	// partial fills across successive ticks (see liquidity.go), and its
+	// remainder shows up in PendingOrders until done. Nil fills every
+	// market order in full at the quote.
+	Liquidity *Liquidity
+
+	// Hours, when set, limits trading to each instrument's session: orders
+	// are rejected or queued while its market is closed, stops and takes
+	// gapped over a close fill at the reopening price, and optional
+	// rollover swap is charged on open lots (see hours.go). Nil trades
+	// around the clock.
+	Hours *MarketHours
+
+	// IdleInterest, when non-zero, is the annual rate (RateScale-scaled)
+	// credited on each account's idle cash at every daily rollover (see
+	// interest.go). Zero pays none.
+	IdleInterest types.Rate
+
+	// MinStops, when set, refuses orders and stop changes whose stop-loss
+	// or take-profit is nearer the market than its per-instrument minimum,
+	// with a *StopDistanceError (see stopdistance.go). Nil allows any.
+	MinStops *MinStopDistance
+
+	// clock is the latest tick time seen on any instrument; rollovers
+	// between it and the next tick are charged when that tick arrives.
+	clock types.Timestamp
+
+	// gaps holds the instruments MarkGap flagged: their next price is
+	// treated as the first after a market reopen.
+	gaps map[string]bool
+
+	// IDs mints the order/trade ID for every fill. Nil means opaque ULIDs
+	// (idgen.NewULID); backtests inject an idgen.Sequence prefixed with the
+	// run ID so journals read in fill order.
+	IDs idgen.Generator
+
 	// events is StreamTransactions' feed: every fill (SubmitMarketOrder,
 	// CloseTrade, or a stop/take triggered internally by UpdatePrice)
 	// pushes here. A resting stop-loss only "happens" when price actually
@@ -67,9 +134,18 @@ func NewSimBroker(acct *account.Account, j journal.Journal) *Sim {
 	return &Sim{
 		account: acct,
 		journal: j,
-		prices:  make(map[string]market.Tick),
+		prices:  newPriceStore(),
 		events:  make(chan oanda.TxEvent, eventQueueSize),
+		accts:   []*account.Account{acct},
+	}
+}
+
+// newID mints the next fill ID from IDs, or a ULID when none is set.
+func (e *Sim) newID() string {
+	if e.IDs == nil {
+		return idgen.NewULID()
 	}
+	return e.IDs.New()
 }
 
 // emitFill pushes a fill transaction onto the event stream, dropping it
@@ -88,6 +164,9 @@ func (e *Sim) UpdatePrice(tick market.Tick) error {
 	if e == nil || e.account == nil {
 		return fmt.Errorf("sim broker account is nil")
 	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	inst := market.NormalizeInstrument(tick.Instrument)
 	if inst == "" {
 		return fmt.Errorf("blank instrument")
@@ -96,17 +175,93 @@ func (e *Sim) UpdatePrice(tick market.Tick) error {
 	if err := tick.Validate(); err != nil {
 		return err
 	}
-	e.prices[inst] = tick
+	if err := e.chargeRollovers(tick.Timestamp); err != nil {
+		return err
+	}
+	prev, seen := e.prices.latest[inst]
+	e.prices.put(tick)
+	open := e.Hours.isOpen(inst, tick.Timestamp)
+	gapped := seen && e.Hours.reopened(inst, prev.Timestamp, tick.Timestamp) || e.gaps[inst]
+	delete(e.gaps, inst)
 
-	marks := make(map[string]types.Price, len(e.prices))
-	for instrument, px := range e.prices {
+	marks := make(map[string]types.Price, len(e.prices.latest))
+	for instrument, px := range e.prices.latest {
 		marks[instrument] = px.Mid()
 	}
-	if err := e.account.ResolveWithMarks(marks); err != nil {
-		return err
+	for _, acct := range e.accounts() {
+		if err := acct.ResolveWithMarks(marks); err != nil {
+			return err
+		}
+		trackExcursions(acct, inst, tick)
+		if !open {
+			continue
+		}
+		if err := e.checkStopsAndTakes(acct, inst, tick, gapped); err != nil {
+			return err
+		}
+		if err := e.checkMarginCloseout(acct, marks); err != nil {
+			return err
+		}
+	}
+	if !open {
+		return nil
+	}
+	return e.processOrders(inst, tick)
+}
+
+// marginCloseoutReason is the fill reason for lots closed by
+// checkMarginCloseout, after OANDA's MARKET_ORDER_MARGIN_CLOSEOUT.
+const marginCloseoutReason = "MARGIN_CLOSEOUT"
+
+// checkMarginCloseout closes every open lot in acct once its equity falls
+// below the closeout level set in acct.Margin, the way a broker liquidates
+// an account that can no longer carry its positions. Each lot fills at
+// the price it would close at (bid for longs, ask for shorts) and its
+// trade is recorded with CloseBrokerLiquidation.
+func (e *Sim) checkMarginCloseout(acct *account.Account, marks map[string]types.Price) error {
+	if !acct.MarginCloseoutDue() {
+		return nil
+	}
+	var lots []*account.Lot
+	_ = acct.Lots.Range(func(lot *account.Lot) error {
+		lots = append(lots, lot)
+		return nil
+	})
+	for _, lot := range lots {
+		px, ok := e.prices.latest[lot.Instrument]
+		if !ok {
+			return errs.Newf(errs.ErrNoPrice, "sim: no market price for %s", lot.Instrument)
+		}
+		isBuy := lot.Side == types.Short
+		exitPrice := px.Bid
+		if isBuy {
+			exitPrice = px.Ask
+		}
+		exitPrice = e.fillPrice(lot.Instrument, isBuy, exitPrice)
+		if _, err := e.closeLotAndEmit(acct, lot, exitPrice, px.Timestamp, marginCloseoutReason); err != nil {
+			return err
+		}
 	}
+	log.L.Warn("sim: margin closeout", "account", acct.ID, "lots", len(lots), "equity", acct.Equity.Float64())
+	return acct.ResolveWithMarks(marks)
+}
 
-	return e.checkStopsAndTakes(inst, tick)
+// trackExcursions advances MAE/MFE on every open lot on instrument,
+// measured at the price each would close at (bid for longs, ask for
+// shorts). Runs before checkStopsAndTakes so a lot stopped out on this
+// tick still records the move that stopped it.
+func trackExcursions(acct *account.Account, inst string, tick market.Tick) {
+	_ = acct.Lots.Range(func(lot *account.Lot) error {
+		if lot.Instrument != inst {
+			return nil
+		}
+		if lot.Side == types.Short {
+			lot.TrackExcursion(tick.Ask)
+		} else {
+			lot.TrackExcursion(tick.Bid)
+		}
+		return nil
+	})
 }
 
 // checkStopsAndTakes closes any open lot on instrument whose Stop/Take was
@@ -117,9 +272,13 @@ func (e *Sim) UpdatePrice(tick market.Tick) error {
 // same-tick double hit), adapted for bid/ask instead of a candle's OHLC:
 // closing a long fills at bid, closing a short fills at ask — the same
 // convention CloseTrade already uses.
-func (e *Sim) checkStopsAndTakes(inst string, tick market.Tick) error {
+//
+// gapped marks the first tick after the market reopened (see MarketHours):
+// a stop or take the price jumped past while closed fills at that tick's
+// quote instead of at its level, as a broker fills it at the open.
+func (e *Sim) checkStopsAndTakes(acct *account.Account, inst string, tick market.Tick, gapped bool) error {
 	var closeErr error
-	_ = e.account.Lots.Range(func(lot *account.Lot) error {
+	_ = acct.Lots.Range(func(lot *account.Lot) error {
 		if closeErr != nil || lot.Instrument != inst {
 			return nil
 		}
@@ -138,8 +297,14 @@ func (e *Sim) checkStopsAndTakes(inst string, tick market.Tick) error {
 			switch {
 			case stopHit:
 				exitPrice, reason = lot.Stop, "STOP"
+				if gapped {
+					exitPrice = min(lot.Stop, tick.Bid)
+				}
 			case takeHit:
 				exitPrice, reason = lot.Take, "TAKE"
+				if gapped {
+					exitPrice = max(lot.Take, tick.Bid)
+				}
 			default:
 				return nil
 			}
@@ -149,8 +314,14 @@ func (e *Sim) checkStopsAndTakes(inst string, tick market.Tick) error {
 			switch {
 			case stopHit:
 				exitPrice, reason = lot.Stop, "STOP"
+				if gapped {
+					exitPrice = max(lot.Stop, tick.Ask)
+				}
 			case takeHit:
 				exitPrice, reason = lot.Take, "TAKE"
+				if gapped {
+					exitPrice = min(lot.Take, tick.Ask)
+				}
 			default:
 				return nil
 			}
@@ -158,8 +329,8 @@ func (e *Sim) checkStopsAndTakes(inst string, tick market.Tick) error {
 			return nil
 		}
 
-		exitPrice += account.FillAdjust(lot.Side == types.Short, 0, e.Slippage)
-		if _, err := e.closeLotAndEmit(lot, exitPrice, tick.Timestamp, reason); err != nil {
+		exitPrice = e.fillPrice(lot.Instrument, lot.Side == types.Short, exitPrice)
+		if _, err := e.closeLotAndEmit(acct, lot, exitPrice, tick.Timestamp, reason); err != nil {
 			closeErr = err
 		}
 		return nil
@@ -171,17 +342,30 @@ func (e *Sim) CloseAll(ctx context.Context, reason string) error {
 	if e == nil || e.account == nil {
 		return fmt.Errorf("sim broker account is nil")
 	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
+	for _, acct := range e.accounts() {
+		if err := e.closeAllIn(acct, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeAllIn is CloseAll for a single account: close every open lot at the
+// tracked mid and record a final equity snapshot.
+func (e *Sim) closeAllIn(acct *account.Account, reason string) error {
 	var lots []*account.Lot
-	_ = e.account.Lots.Range(func(lot *account.Lot) error {
+	_ = acct.Lots.Range(func(lot *account.Lot) error {
 		lots = append(lots, lot)
 		return nil
 	})
 
 	for _, lot := range lots {
-		px, ok := e.prices[lot.Instrument]
+		px, ok := e.prices.latest[lot.Instrument]
 		if !ok {
-			return fmt.Errorf("no market price for %s", lot.Instrument)
+			return errs.Newf(errs.ErrNoPrice, "no market price for %s", lot.Instrument)
 		}
 		exitPrice := px.Mid()
 		exitTime := types.FromTime(time.Now().UTC())
@@ -192,20 +376,32 @@ func (e *Sim) CloseAll(ctx context.Context, reason string) error {
 			ExitPrice:   exitPrice,
 			ExitTime:    exitTime,
 		}
-		if err := e.account.CloseLot(lot, trade); err != nil {
+		if err := acct.CloseLot(lot, trade); err != nil {
 			return err
 		}
 		if e.journal != nil {
 			_ = e.journal.RecordTrade(journal.TradeRecord{
-				TradeID:    trade.ID,
-				Instrument: trade.Instrument,
-				Units:      trade.Units,
-				EntryPrice: lot.EntryPrice,
-				ExitPrice:  trade.ExitPrice,
-				OpenTime:   lot.EntryTime,
-				CloseTime:  trade.ExitTime,
-				RealizedPL: trade.PNL,
-				Reason:     reason,
+				TradeID:     trade.ID,
+				AccountID:   e.journalAccountID(acct),
+				Instrument:  trade.Instrument,
+				Units:       trade.Units,
+				EntryPrice:  lot.EntryPrice,
+				ExitPrice:   trade.ExitPrice,
+				OpenTime:    lot.EntryTime,
+				CloseTime:   trade.ExitTime,
+				RealizedPL:  trade.PNL,
+				Reason:      reason,
+				VolRegime:   trade.VolRegime,
+				InitialStop: trade.InitialStop,
+				InitialRisk: trade.InitialRisk,
+				RMultiple:   trade.RMultiple,
+				MAE:         trade.MAE,
+				MFE:         trade.MFE,
+
+				QuoteCurrency:   quoteCurrency(trade.Instrument),
+				AccountCurrency: acct.Currency,
+				QuotePL:         trade.QuotePNL,
+				ConversionRate:  trade.ConversionRate,
 			})
 		}
 	}
@@ -213,17 +409,20 @@ func (e *Sim) CloseAll(ctx context.Context, reason string) error {
 	if e.journal != nil {
 		_ = e.journal.RecordEquity(journal.EquitySnapshot{
 			Timestamp:   types.FromTime(time.Now().UTC()),
-			Balance:     e.account.Balance,
-			Equity:      e.account.Equity,
-			MarginUsed:  e.account.MarginUsed,
-			FreeMargin:  e.account.FreeMargin,
-			MarginLevel: e.account.MarginLevel,
+			AccountID:   e.journalAccountID(acct),
+			Balance:     acct.Balance,
+			Equity:      acct.Equity,
+			MarginUsed:  acct.MarginUsed,
+			FreeMargin:  acct.FreeMargin,
+			MarginLevel: acct.MarginLevel,
 		})
 	}
 
 	return nil
 }
 
+// GetAccount returns the live Account Sim writes into. The pointer is not
+// guarded by Sim's lock — concurrent readers should use Snapshot instead.
 func (e *Sim) GetAccount(context.Context) (*account.Account, error) {
 	if e == nil || e.account == nil {
 		return nil, fmt.Errorf("sim broker account is nil")
@@ -237,6 +436,10 @@ func (e *Sim) GetAccount(context.Context) (*account.Account, error) {
 // instrument (positive units = long, filled at ask; negative = short,
 // filled at bid), plus Slippage, and opens a Lot via Account.AddLot — the
 // same bookkeeping path account.Account.SubmitOpen uses.
+//
+// Under a Liquidity model only what the current tick's book holds fills
+// now; the result reports that first partial fill, and each later one
+// opens its own lot under the same OrderID.
 func (e *Sim) SubmitMarketOrder(ctx context.Context, accountID, instrument string, units int64, stopPrice float64) (*oanda.OrderResult, error) {
 	if e == nil || e.account == nil {
 		return nil, fmt.Errorf("sim broker account is nil")
@@ -244,58 +447,170 @@ func (e *Sim) SubmitMarketOrder(ctx context.Context, accountID, instrument strin
 	if units == 0 {
 		return nil, fmt.Errorf("sim: units must be non-zero")
 	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, err := e.accountFor(accountID); err != nil {
+		return nil, err
+	}
 	inst := market.NormalizeInstrument(instrument)
-	px, ok := e.prices[inst]
+	px, ok := e.prices.latest[inst]
 	if !ok {
-		return nil, fmt.Errorf("sim: no market price for %s", inst)
+		return nil, errs.Newf(errs.ErrNoPrice, "sim: no market price for %s", inst)
+	}
+	stop := market.RoundPrice(inst, types.PriceFromFloat(stopPrice))
+	if err := e.MinStops.Check(inst, units > 0, px.Mid(), stop, 0); err != nil {
+		return nil, fmt.Errorf("sim: %w", err)
+	}
+
+	if !e.Hours.isOpen(inst, px.Timestamp) {
+		return e.marketClosed(accountID, inst, units, stop, px.Timestamp)
+	}
+
+	if e.Liquidity != nil {
+		if err := e.Liquidity.Validate(); err != nil {
+			return nil, fmt.Errorf("sim: %w", err)
+		}
+		order := &PendingOrder{
+			ID:         e.newID(),
+			AccountID:  accountID,
+			Instrument: inst,
+			Units:      units,
+			Stop:       stop,
+			Created:    px.Timestamp,
+			Market:     true,
+		}
+		e.trackOrder(order, brokers.MarketOrder)
+		res, err := e.bookFill(order, px)
+		if err != nil {
+			return nil, err
+		}
+		if order.Units != 0 {
+			e.orders = append(e.orders, order)
+		}
+		return res, nil
 	}
 
-	isBuy := units > 0
-	side := types.Short
 	fillPrice := px.Bid
-	if isBuy {
-		side = types.Long
+	if units > 0 {
 		fillPrice = px.Ask
 	}
-	fillPrice += account.FillAdjust(isBuy, 0, e.Slippage)
+	fillPrice = e.fillPrice(inst, units > 0, fillPrice)
+	res, err := e.openLot(accountID, inst, units, fillPrice, stop, px.Timestamp, "")
+	if err != nil {
+		return nil, err
+	}
+	// The order only exists once its ID does, which the fill mints.
+	e.trackOrder(&PendingOrder{
+		ID:         res.OrderID,
+		AccountID:  accountID,
+		Instrument: inst,
+		Units:      units,
+		Stop:       stop,
+		Created:    px.Timestamp,
+		Market:     true,
+	}, brokers.MarketOrder)
+	e.orderFilled(res.OrderID, res.TradeID, units, fillPrice, px.Timestamp)
+	return res, nil
+}
 
-	absUnits := units
-	if absUnits < 0 {
-		absUnits = -absUnits
+// PreviewOrder reports what SubmitMarketOrder would fill req at and do to
+// the account's margin, without placing it or touching any state. The
+// fill is priced as SubmitMarketOrder prices it, at the current bid or ask
+// plus Slippage; a Liquidity model's partial fills are not simulated.
+func (e *Sim) PreviewOrder(ctx context.Context, req brokers.PreviewRequest) (brokers.OrderPreview, error) {
+	if e == nil || e.account == nil {
+		return brokers.OrderPreview{}, fmt.Errorf("sim broker account is nil")
+	}
+	if req.Units == 0 {
+		return brokers.OrderPreview{}, fmt.Errorf("sim: units must be non-zero")
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	inst := market.NormalizeInstrument(req.Instrument)
+	px, ok := e.prices.latest[inst]
+	if !ok {
+		return brokers.OrderPreview{}, errs.Newf(errs.ErrNoPrice, "sim: no market price for %s", inst)
+	}
+	fillPrice := px.Bid
+	if req.Units > 0 {
+		fillPrice = px.Ask
+	}
+	fillPrice = e.fillPrice(inst, req.Units > 0, fillPrice)
+
+	acct, err := e.accountFor(req.AccountID)
+	if err != nil {
+		return brokers.OrderPreview{}, err
+	}
+	margin, err := acct.MarginRequired(types.Units(req.Units), fillPrice, inst)
+	if err != nil {
+		return brokers.OrderPreview{}, fmt.Errorf("sim: preview %s: %w", inst, err)
+	}
+	used := acct.MarginUsed + margin
+	return brokers.OrderPreview{
+		Instrument:     inst,
+		Units:          req.Units,
+		FillPrice:      fillPrice,
+		MarginRequired: margin,
+		Equity:         acct.Equity,
+		MarginUsed:     used,
+		FreeMargin:     acct.Equity - used,
+	}, nil
+}
+
+// openLot books a fill of units (signed) at fillPrice as a new Lot in
+// accountID's account and emits its ORDER_FILL. orderID names the order
+// that filled; empty means a market order, whose order and trade share
+// the new lot's ID. Callers hold e.mu and book the fill against the
+// order's history with orderFilled.
+func (e *Sim) openLot(accountID, inst string, units int64, fillPrice, stop types.Price, ts types.Timestamp, orderID string) (*oanda.OrderResult, error) {
+	acct := e.orderAccount(accountID)
+	side := types.Short
+	absUnits := -units
+	if units > 0 {
+		side = types.Long
+		absUnits = units
 	}
 
 	lot := &account.Lot{
 		TradeCommon: &account.TradeCommon{
-			ID:         idgen.NewULID(),
+			ID:         e.newID(),
 			Instrument: inst,
 			Side:       side,
 			Units:      types.Units(absUnits),
-			Stop:       types.PriceFromFloat(stopPrice),
+			Stop:       stop,
+			// Callers that resolve the stop differently (the backtest
+			// loop's planner) overwrite this after the fill.
+			InitialStop: stop,
 		},
 		EntryPrice:     fillPrice,
-		EntryTime:      px.Timestamp,
+		EntryTime:      ts,
 		OriginalUnits:  types.Units(absUnits),
 		RemainingUnits: types.Units(absUnits),
 		State:          account.LotOpen,
 	}
+	if orderID == "" {
+		orderID = lot.ID
+	}
 
-	if err := e.account.AddLot(lot); err != nil {
+	if err := acct.AddLot(lot); err != nil {
 		return nil, fmt.Errorf("sim: open lot: %w", err)
 	}
 
 	e.emitFill(oanda.Transaction{
 		Type:       "ORDER_FILL",
 		AccountID:  accountID,
-		Time:       px.Timestamp.Time(),
+		Time:       ts.Time(),
 		Instrument: inst,
 		Units:      units,
 		Price:      fillPrice.Float64(),
-		OrderID:    lot.ID,
+		OrderID:    orderID,
 		TradeID:    lot.ID,
 	})
 
 	return &oanda.OrderResult{
-		OrderID:    lot.ID,
+		OrderID:    orderID,
 		TradeID:    lot.ID,
 		Instrument: inst,
 		Units:      units,
@@ -309,16 +624,29 @@ func (e *Sim) SubmitMarketOrder(ctx context.Context, accountID, instrument strin
 // implements — partial closes are a later chunk's concern, once
 // account.Account's own partial-close handling (if any) needs mirroring.
 func (e *Sim) CloseTrade(ctx context.Context, accountID, tradeID string, units int64) (*oanda.CloseTradeResult, error) {
+	return e.CloseTradeWithReason(ctx, accountID, tradeID, units, "sim close")
+}
+
+// CloseTradeWithReason is CloseTrade journaling reason as the trade's close
+// reason. It implements brokers.ReasonCloser.
+func (e *Sim) CloseTradeWithReason(ctx context.Context, accountID, tradeID string, units int64, reason string) (*oanda.CloseTradeResult, error) {
 	if e == nil || e.account == nil {
 		return nil, fmt.Errorf("sim broker account is nil")
 	}
-	lot := e.account.Lots.Get(tradeID)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	acct, err := e.accountFor(accountID)
+	if err != nil {
+		return nil, err
+	}
+	lot := acct.Lots.Get(tradeID)
 	if lot == nil {
 		return nil, fmt.Errorf("sim: no open trade %s", tradeID)
 	}
-	px, ok := e.prices[lot.Instrument]
+	px, ok := e.prices.latest[lot.Instrument]
 	if !ok {
-		return nil, fmt.Errorf("sim: no market price for %s", lot.Instrument)
+		return nil, errs.Newf(errs.ErrNoPrice, "sim: no market price for %s", lot.Instrument)
 	}
 
 	// Closing a long means selling (fills at bid); closing a short means
@@ -328,9 +656,26 @@ func (e *Sim) CloseTrade(ctx context.Context, accountID, tradeID string, units i
 	if isBuy {
 		exitPrice = px.Ask
 	}
-	exitPrice += account.FillAdjust(isBuy, 0, e.Slippage)
+	exitPrice = e.fillPrice(lot.Instrument, isBuy, exitPrice)
+
+	return e.closeLotAndEmit(acct, lot, exitPrice, px.Timestamp, reason)
+}
+
+// fillPrice returns the price a buy (isBuy) or sell of instrument fills at
+// when quoted at px: px plus Slippage, rounded to the instrument's display
+// precision so fills, and the journal records built from them, carry
+// quotable prices rather than sub-tick arithmetic results.
+func (e *Sim) fillPrice(instrument string, isBuy bool, px types.Price) types.Price {
+	return market.RoundPrice(instrument, px+account.FillAdjust(isBuy, 0, e.Slippage))
+}
 
-	return e.closeLotAndEmit(lot, exitPrice, px.Timestamp, "sim close")
+// quoteCurrency returns instrument's quote currency, or "" when the
+// registry does not know it.
+func quoteCurrency(instrument string) string {
+	if inst := market.GetInstrument(instrument); inst != nil {
+		return inst.QuoteCurrency
+	}
+	return ""
 }
 
 // closeLotAndEmit is the single close-and-notify path both CloseTrade and
@@ -338,7 +683,7 @@ func (e *Sim) CloseTrade(ctx context.Context, accountID, tradeID string, units i
 // journal entry, and emit the fill event — the same three things happen
 // whether the close was caller-requested or a resting stop/take firing on
 // its own.
-func (e *Sim) closeLotAndEmit(lot *account.Lot, exitPrice types.Price, exitTime types.Timestamp, reason string) (*oanda.CloseTradeResult, error) {
+func (e *Sim) closeLotAndEmit(acct *account.Account, lot *account.Lot, exitPrice types.Price, exitTime types.Timestamp, reason string) (*oanda.CloseTradeResult, error) {
 	trade := &account.Trade{
 		TradeCommon: lot.TradeCommon.Clone(),
 		EntryPrice:  lot.EntryPrice,
@@ -346,21 +691,36 @@ func (e *Sim) closeLotAndEmit(lot *account.Lot, exitPrice types.Price, exitTime
 		ExitPrice:   exitPrice,
 		ExitTime:    exitTime,
 	}
-	if err := e.account.CloseLot(lot, trade); err != nil {
+	if reason == marginCloseoutReason {
+		trade.CloseCause = account.CloseBrokerLiquidation
+	}
+	if err := acct.CloseLot(lot, trade); err != nil {
 		return nil, fmt.Errorf("sim: close lot: %w", err)
 	}
 
 	if e.journal != nil {
 		_ = e.journal.RecordTrade(journal.TradeRecord{
-			TradeID:    trade.ID,
-			Instrument: trade.Instrument,
-			Units:      trade.Units,
-			EntryPrice: lot.EntryPrice,
-			ExitPrice:  trade.ExitPrice,
-			OpenTime:   lot.EntryTime,
-			CloseTime:  trade.ExitTime,
-			RealizedPL: trade.PNL,
-			Reason:     reason,
+			TradeID:     trade.ID,
+			AccountID:   e.journalAccountID(acct),
+			Instrument:  trade.Instrument,
+			Units:       trade.Units,
+			EntryPrice:  lot.EntryPrice,
+			ExitPrice:   trade.ExitPrice,
+			OpenTime:    lot.EntryTime,
+			CloseTime:   trade.ExitTime,
+			RealizedPL:  trade.PNL,
+			Reason:      reason,
+			VolRegime:   trade.VolRegime,
+			InitialStop: trade.InitialStop,
+			InitialRisk: trade.InitialRisk,
+			RMultiple:   trade.RMultiple,
+			MAE:         trade.MAE,
+			MFE:         trade.MFE,
+
+			QuoteCurrency:   quoteCurrency(trade.Instrument),
+			AccountCurrency: acct.Currency,
+			QuotePL:         trade.QuotePNL,
+			ConversionRate:  trade.ConversionRate,
 		})
 	}
 
@@ -370,6 +730,7 @@ func (e *Sim) closeLotAndEmit(lot *account.Lot, exitPrice types.Price, exitTime
 	}
 	e.emitFill(oanda.Transaction{
 		Type:       "ORDER_FILL",
+		AccountID:  acct.ID,
 		Time:       exitTime.Time(),
 		Reason:     reason,
 		Instrument: trade.Instrument,
@@ -398,29 +759,53 @@ func (e *Sim) UpdateTradeStop(ctx context.Context, accountID, tradeID string, st
 	if e == nil || e.account == nil {
 		return fmt.Errorf("sim broker account is nil")
 	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	// LotBook.Get returns a clone (mutations wouldn't persist) — Range
 	// yields the live stored pointers, which this needs to actually
 	// update the book.
+	acct, err := e.accountFor(accountID)
+	if err != nil {
+		return err
+	}
 	found := false
-	_ = e.account.Lots.Range(func(lot *account.Lot) error {
+	err = acct.Lots.Range(func(lot *account.Lot) error {
 		if lot.ID != tradeID {
 			return nil
 		}
 		found = true
+		var stop, take types.Price
+		if stopPrice > 0 {
+			stop = market.RoundPrice(lot.Instrument, types.PriceFromFloat(stopPrice))
+		}
+		if takePrice > 0 {
+			take = market.RoundPrice(lot.Instrument, types.PriceFromFloat(takePrice))
+		}
+		// A new stop or take is held to the minimum distance from the
+		// current mid; cancelling one never is.
+		if px, ok := e.prices.latest[lot.Instrument]; ok {
+			if err := e.MinStops.Check(lot.Instrument, lot.Side == types.Long, px.Mid(), stop, take); err != nil {
+				return fmt.Errorf("sim: %w", err)
+			}
+		}
 		switch {
 		case stopPrice > 0:
-			lot.Stop = types.PriceFromFloat(stopPrice)
+			lot.Stop = stop
 		case stopPrice < 0:
 			lot.Stop = 0
 		}
 		switch {
 		case takePrice > 0:
-			lot.Take = types.PriceFromFloat(takePrice)
+			lot.Take = take
 		case takePrice < 0:
 			lot.Take = 0
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 	if !found {
 		return fmt.Errorf("sim: no open trade %s", tradeID)
 	}
@@ -429,12 +814,20 @@ func (e *Sim) UpdateTradeStop(ctx context.Context, accountID, tradeID string, st
 
 // ── brokers.Broker: account state ───────────────────────────────────────────
 
-// GetOpenTrades returns every open lot as an oanda.OpenTrade.
+// GetOpenTrades returns every open lot in the selected account as an
+// oanda.OpenTrade.
 func (e *Sim) GetOpenTrades(ctx context.Context, accountID string) ([]oanda.OpenTrade, error) {
 	if e == nil || e.account == nil {
 		return nil, fmt.Errorf("sim broker account is nil")
 	}
-	lots := e.account.Lots.Slice()
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	acct, err := e.accountFor(accountID)
+	if err != nil {
+		return nil, err
+	}
+	lots := acct.Lots.Slice()
 	out := make([]oanda.OpenTrade, 0, len(lots))
 	for _, lot := range lots {
 		units := int64(lot.RemainingUnits)
@@ -455,13 +848,19 @@ func (e *Sim) GetOpenTrades(ctx context.Context, accountID string) ([]oanda.Open
 	return out, nil
 }
 
-// GetAccountSummary maps the simulated Account's ledger state onto OANDA's
+// GetAccountSummary maps the selected account's ledger state onto OANDA's
 // account-summary shape.
 func (e *Sim) GetAccountSummary(ctx context.Context, accountID string) (*oanda.AccountSummary, error) {
 	if e == nil || e.account == nil {
 		return nil, fmt.Errorf("sim broker account is nil")
 	}
-	a := e.account
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	a, err := e.accountFor(accountID)
+	if err != nil {
+		return nil, err
+	}
 	return &oanda.AccountSummary{
 		ID:           a.ID,
 		Currency:     a.Currency,
@@ -6,7 +6,10 @@ import (
 	"time"
 
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
 	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/idgen"
 	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
@@ -20,6 +23,7 @@ import (
 type stubJournal struct {
 	trades   []journal.TradeRecord
 	equity   []journal.EquitySnapshot
+	orders   []journal.OrderEvent
 	closeErr error
 }
 
@@ -31,6 +35,10 @@ func (j *stubJournal) RecordEquity(s journal.EquitySnapshot) error {
 	j.equity = append(j.equity, s)
 	return nil
 }
+func (j *stubJournal) RecordOrderEvent(ev journal.OrderEvent) error {
+	j.orders = append(j.orders, ev)
+	return nil
+}
 func (j *stubJournal) Close() error { return j.closeErr }
 
 // eurusdTick returns a valid EURUSD tick at the given mid (bid = mid-1, ask = mid+1).
@@ -118,7 +126,7 @@ func TestUpdatePrice_ValidTickUpdatesEquity(t *testing.T) {
 
 	err := s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.08)))
 	require.NoError(t, err)
-	assert.Contains(t, s.prices, "EURUSD")
+	assert.Contains(t, s.prices.latest, "EURUSD")
 }
 
 func TestUpdatePrice_NormalizesInstrumentName(t *testing.T) {
@@ -128,8 +136,8 @@ func TestUpdatePrice_NormalizesInstrumentName(t *testing.T) {
 		BA:         market.BA{Bid: 1_080_000, Ask: 1_080_200},
 	}
 	require.NoError(t, s.UpdatePrice(tick))
-	assert.Contains(t, s.prices, "EURUSD")
-	assert.NotContains(t, s.prices, "EUR_USD")
+	assert.Contains(t, s.prices.latest, "EURUSD")
+	assert.NotContains(t, s.prices.latest, "EUR_USD")
 }
 
 func TestUpdatePrice_BlankInstrumentReturnsError(t *testing.T) {
@@ -168,9 +176,9 @@ func TestUpdatePrice_MultipleInstrumentsTrackedSeparately(t *testing.T) {
 	}
 	require.NoError(t, s.UpdatePrice(eurTick))
 	require.NoError(t, s.UpdatePrice(jpyTick))
-	assert.Len(t, s.prices, 2)
-	assert.Contains(t, s.prices, "EURUSD")
-	assert.Contains(t, s.prices, "USDJPY")
+	assert.Len(t, s.prices.latest, 2)
+	assert.Contains(t, s.prices.latest, "EURUSD")
+	assert.Contains(t, s.prices.latest, "USDJPY")
 }
 
 // ── CloseAll ─────────────────────────────────────────────────────────────────
@@ -218,6 +226,7 @@ func TestCloseAll_MissingPriceReturnsError(t *testing.T) {
 	err := s.CloseAll(context.Background(), "reason")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "no market price")
+	assert.ErrorIs(t, err, errs.ErrNoPrice)
 }
 
 func TestCloseAll_NilReceiverReturnsError(t *testing.T) {
@@ -245,7 +254,7 @@ func TestSubmitMarketOrder_LongFillsAtAsk(t *testing.T) {
 	s := NewSimBroker(acct, nil)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
 
-	res, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	res, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 	require.NotNil(t, res)
 	assert.Equal(t, int64(1000), res.Units)
@@ -260,7 +269,7 @@ func TestSubmitMarketOrder_ShortFillsAtBid(t *testing.T) {
 	s := NewSimBroker(acct, nil)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
 
-	res, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", -1000, 0)
+	res, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", -1000, 0)
 	require.NoError(t, err)
 	require.NotNil(t, res)
 	assert.Equal(t, int64(-1000), res.Units)
@@ -278,7 +287,7 @@ func TestSubmitMarketOrder_SlippageWorsensLongFill(t *testing.T) {
 	s.Slippage = types.PriceFromFloat(0.0002)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
 
-	noSlip, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	noSlip, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 	assert.Greater(t, noSlip.Price, types.PriceFromFloat(1.1000).Float64(),
 		"slippage must worsen (raise) a long fill above the ask")
@@ -288,7 +297,7 @@ func TestSubmitMarketOrder_NoPriceReturnsError(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
 
-	_, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	_, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "no market price")
 }
@@ -298,7 +307,7 @@ func TestSubmitMarketOrder_ZeroUnitsReturnsError(t *testing.T) {
 	s := NewSimBroker(acct, nil)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
 
-	_, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 0, 0)
+	_, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 0, 0)
 	require.Error(t, err)
 }
 
@@ -308,11 +317,11 @@ func TestCloseTrade_ClosesLongAtBid(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1050))))
-	res, err := s.CloseTrade(context.Background(), "acct", open.TradeID, 0)
+	res, err := s.CloseTrade(context.Background(), acct.ID, open.TradeID, 0)
 	require.NoError(t, err)
 	require.NotNil(t, res)
 	assert.Equal(t, open.TradeID, res.TradeID)
@@ -321,11 +330,97 @@ func TestCloseTrade_ClosesLongAtBid(t *testing.T) {
 	assert.Greater(t, acct.Trades[0].PNL, types.Money(0), "long closed higher than it opened must be profitable")
 }
 
+func TestCloseTrade_JournalsQuotePLAndConversion(t *testing.T) {
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	j := &stubJournal{}
+	s := NewSimBroker(acct, j)
+	tick := func(mid float64) market.Tick {
+		px := types.PriceFromFloat(mid)
+		return market.Tick{Instrument: "USDJPY", BA: market.BA{Bid: px - 1, Ask: px + 1}}
+	}
+	require.NoError(t, s.UpdatePrice(tick(150.000)))
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "USDJPY", 1000, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdatePrice(tick(151.250)))
+	_, err = s.CloseTrade(context.Background(), acct.ID, open.TradeID, 0)
+	require.NoError(t, err)
+
+	require.Len(t, j.trades, 1)
+	rec := j.trades[0]
+	assert.Equal(t, "JPY", rec.QuoteCurrency)
+	assert.Equal(t, "USD", rec.AccountCurrency)
+	// The sub-pip spread rounds away: fills are at USDJPY's three decimals.
+	assert.Equal(t, types.MoneyFromFloat(1250), rec.QuotePL)
+	assert.InDelta(t, 1/151.24999, rec.ConversionRate.Float64(), 1e-6)
+	assert.InDelta(t, rec.QuotePL.Float64()*rec.ConversionRate.Float64(), rec.RealizedPL.Float64(), 0.01)
+	assert.Equal(t, acct.Trades[0].QuotePNL, rec.QuotePL)
+}
+
+func TestSubmitMarketOrder_RoundsToInstrumentPrecision(t *testing.T) {
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	j := &stubJournal{}
+	s := NewSimBroker(acct, j)
+	s.Slippage = types.Price(7) // 0.00007: below USDJPY's 0.001 tick
+	ctx := context.Background()
+
+	require.NoError(t, s.UpdatePrice(market.Tick{Instrument: "USDJPY",
+		BA: market.BA{Bid: types.PriceFromFloat(150.000), Ask: types.PriceFromFloat(150.01049)}}))
+	open, err := s.SubmitMarketOrder(ctx, acct.ID, "USDJPY", 1000, 149.50004)
+	require.NoError(t, err)
+	assert.Equal(t, 150.011, open.Price)
+
+	lot := acct.Lots.Get(open.TradeID)
+	require.NotNil(t, lot)
+	assert.Equal(t, types.PriceFromFloat(150.011), lot.EntryPrice)
+	assert.Equal(t, types.PriceFromFloat(149.500), lot.Stop)
+
+	require.NoError(t, s.UpdateTradeStop(ctx, acct.ID, open.TradeID, 149.70000000000002, 151.0999))
+	var stop, take types.Price
+	_ = acct.Lots.Range(func(l *account.Lot) error {
+		stop, take = l.Stop, l.Take
+		return nil
+	})
+	assert.Equal(t, types.PriceFromFloat(149.700), stop)
+	assert.Equal(t, types.PriceFromFloat(151.100), take)
+
+	require.NoError(t, s.UpdatePrice(market.Tick{Instrument: "USDJPY",
+		BA: market.BA{Bid: types.PriceFromFloat(150.50049), Ask: types.PriceFromFloat(150.510)}}))
+	res, err := s.CloseTrade(ctx, acct.ID, open.TradeID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 150.500, res.Price, "bid less slippage, rounded")
+
+	require.Len(t, j.trades, 1)
+	assert.Equal(t, types.PriceFromFloat(150.011), j.trades[0].EntryPrice)
+	assert.Equal(t, types.PriceFromFloat(150.500), j.trades[0].ExitPrice)
+	assert.Equal(t, types.PriceFromFloat(149.500), j.trades[0].InitialStop)
+}
+
+func TestCloseTradeWithReason_JournalsReason(t *testing.T) {
+	ctx := context.Background()
+	j := &stubJournal{}
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, j)
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
+	first, err := s.SubmitMarketOrder(ctx, acct.ID, "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	second, err := s.SubmitMarketOrder(ctx, acct.ID, "EURUSD", 1000, 0)
+	require.NoError(t, err)
+
+	_, err = s.CloseTrade(ctx, acct.ID, first.TradeID, 0)
+	require.NoError(t, err)
+	_, err = s.CloseTradeWithReason(ctx, acct.ID, second.TradeID, 0, "flatten")
+	require.NoError(t, err)
+
+	require.Len(t, j.trades, 2)
+	assert.Equal(t, "sim close", j.trades[0].Reason)
+	assert.Equal(t, "flatten", j.trades[1].Reason)
+}
+
 func TestCloseTrade_UnknownTradeIDReturnsError(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
 
-	_, err := s.CloseTrade(context.Background(), "acct", "no-such-trade", 0)
+	_, err := s.CloseTrade(context.Background(), acct.ID, "no-such-trade", 0)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "no open trade")
 }
@@ -335,11 +430,11 @@ func TestCloseTrade_RecordsJournalEntry(t *testing.T) {
 	j := &stubJournal{}
 	s := NewSimBroker(acct, j)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1050))))
-	_, err = s.CloseTrade(context.Background(), "acct", open.TradeID, 0)
+	_, err = s.CloseTrade(context.Background(), acct.ID, open.TradeID, 0)
 	require.NoError(t, err)
 	require.Len(t, j.trades, 1)
 	assert.Equal(t, open.TradeID, j.trades[0].TradeID)
@@ -351,10 +446,10 @@ func TestUpdateTradeStop_SetsStopAndTake(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 
-	require.NoError(t, s.UpdateTradeStop(context.Background(), "acct", open.TradeID, 1.0950, 1.1100))
+	require.NoError(t, s.UpdateTradeStop(context.Background(), acct.ID, open.TradeID, 1.0950, 1.1100))
 
 	lot := acct.Lots.Get(open.TradeID)
 	require.NotNil(t, lot)
@@ -366,11 +461,11 @@ func TestUpdateTradeStop_NegativeCancels(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
-	require.NoError(t, s.UpdateTradeStop(context.Background(), "acct", open.TradeID, 1.0950, 0))
+	require.NoError(t, s.UpdateTradeStop(context.Background(), acct.ID, open.TradeID, 1.0950, 0))
 
-	require.NoError(t, s.UpdateTradeStop(context.Background(), "acct", open.TradeID, -1, 0))
+	require.NoError(t, s.UpdateTradeStop(context.Background(), acct.ID, open.TradeID, -1, 0))
 
 	lot := acct.Lots.Get(open.TradeID)
 	require.NotNil(t, lot)
@@ -381,7 +476,7 @@ func TestUpdateTradeStop_UnknownTradeIDReturnsError(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
 
-	err := s.UpdateTradeStop(context.Background(), "acct", "no-such-trade", 1.0950, 0)
+	err := s.UpdateTradeStop(context.Background(), acct.ID, "no-such-trade", 1.0950, 0)
 	require.Error(t, err)
 }
 
@@ -391,12 +486,12 @@ func TestGetOpenTrades_ReflectsOpenLots(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	_, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	_, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
-	_, err = s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", -500, 0)
+	_, err = s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", -500, 0)
 	require.NoError(t, err)
 
-	trades, err := s.GetOpenTrades(context.Background(), "acct")
+	trades, err := s.GetOpenTrades(context.Background(), acct.ID)
 	require.NoError(t, err)
 	require.Len(t, trades, 2)
 
@@ -417,7 +512,7 @@ func TestGetAccountSummary_ReflectsLedgerState(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
 
-	summary, err := s.GetAccountSummary(context.Background(), "acct")
+	summary, err := s.GetAccountSummary(context.Background(), acct.ID)
 	require.NoError(t, err)
 	assert.Equal(t, acct.ID, summary.ID)
 	assert.Equal(t, acct.Balance.Float64(), summary.Balance)
@@ -428,10 +523,10 @@ func TestGetAccountDetails_IncludesOpenTrades(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	_, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	_, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 
-	details, err := s.GetAccountDetails(context.Background(), "acct")
+	details, err := s.GetAccountDetails(context.Background(), acct.ID)
 	require.NoError(t, err)
 	assert.Equal(t, acct.ID, details.ID)
 	require.Len(t, details.OpenTrades, 1)
@@ -442,11 +537,11 @@ func TestGetAccountDetails_IncludesOpenTrades(t *testing.T) {
 func TestStreamTransactions_ReceivesEventAfterMarketOrderFill(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
-	ch, err := s.StreamTransactions(context.Background(), "acct", oanda.StreamOptions{})
+	ch, err := s.StreamTransactions(context.Background(), acct.ID, oanda.StreamOptions{})
 	require.NoError(t, err)
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 
 	select {
@@ -463,16 +558,16 @@ func TestStreamTransactions_ReceivesEventAfterMarketOrderFill(t *testing.T) {
 func TestStreamTransactions_ReceivesEventAfterCloseTrade(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
-	ch, err := s.StreamTransactions(context.Background(), "acct", oanda.StreamOptions{})
+	ch, err := s.StreamTransactions(context.Background(), acct.ID, oanda.StreamOptions{})
 	require.NoError(t, err)
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 	<-ch // drain the open fill
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1050))))
-	_, err = s.CloseTrade(context.Background(), "acct", open.TradeID, 0)
+	_, err = s.CloseTrade(context.Background(), acct.ID, open.TradeID, 0)
 	require.NoError(t, err)
 
 	select {
@@ -494,7 +589,7 @@ func TestEmitFill_DropsWhenChannelIsFull(t *testing.T) {
 	s.events <- oanda.TxEvent{Tx: oanda.Transaction{Type: "ORDER_FILL"}} // fill the queue
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	_, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	_, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err, "a full event queue must not block or fail the fill itself")
 	assert.Equal(t, 1, acct.Lots.Len())
 }
@@ -504,14 +599,14 @@ func TestEmitFill_DropsWhenChannelIsFull(t *testing.T) {
 func TestUpdatePrice_TriggersLongStopLoss(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
-	ch, err := s.StreamTransactions(context.Background(), "acct", oanda.StreamOptions{})
+	ch, err := s.StreamTransactions(context.Background(), acct.ID, oanda.StreamOptions{})
 	require.NoError(t, err)
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 	<-ch // drain the open fill
-	require.NoError(t, s.UpdateTradeStop(context.Background(), "acct", open.TradeID, 1.0950, 0))
+	require.NoError(t, s.UpdateTradeStop(context.Background(), acct.ID, open.TradeID, 1.0950, 0))
 
 	// Bid falls through the stop.
 	require.NoError(t, s.UpdatePrice(market.Tick{
@@ -528,17 +623,82 @@ func TestUpdatePrice_TriggersLongStopLoss(t *testing.T) {
 	}
 }
 
+// ── Margin closeout (checkMarginCloseout) ───────────────────────────────────
+
+func TestUpdatePrice_MarginCloseoutFollowsLeverage(t *testing.T) {
+	// 15k EURUSD on a 1,000 USD account, then a 500-pip drop leaves 250 of
+	// equity: below half the margin at 20:1 (787.50), above it at 50:1 (315).
+	tests := []struct {
+		leverage   float64
+		wantClosed bool
+	}{
+		{leverage: 20, wantClosed: true},
+		{leverage: 50, wantClosed: false},
+	}
+	for _, tt := range tests {
+		margin, err := account.MarginConfig{Leverage: tt.leverage, CloseoutPct: 50}.Compile()
+		require.NoError(t, err)
+		acct := account.NewAccount("test", types.MoneyFromFloat(1_000))
+		acct.Margin = margin
+		s := NewSimBroker(acct, nil)
+		ch, err := s.StreamTransactions(context.Background(), acct.ID, oanda.StreamOptions{})
+		require.NoError(t, err)
+
+		require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
+		_, err = s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 15_000, 0)
+		require.NoError(t, err)
+		<-ch // drain the open fill
+
+		require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.0500))))
+		if !tt.wantClosed {
+			assert.Equal(t, 1, acct.Lots.Len(), "%v:1 must not close out", tt.leverage)
+			continue
+		}
+		assert.Equal(t, 0, acct.Lots.Len(), "%v:1 must close out", tt.leverage)
+		require.Len(t, acct.Trades, 1)
+		assert.Equal(t, account.CloseBrokerLiquidation, acct.Trades[0].CloseCause)
+		assert.Equal(t, types.Money(0), acct.MarginUsed)
+		select {
+		case evt := <-ch:
+			assert.Equal(t, "MARGIN_CLOSEOUT", evt.Tx.Reason)
+		case <-time.After(time.Second):
+			t.Fatal("expected a margin closeout event")
+		}
+	}
+}
+
+func TestUpdatePrice_TracksExcursionsIntoJournal(t *testing.T) {
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	j := &stubJournal{}
+	s := NewSimBroker(acct, j)
+
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
+	require.NoError(t, err) // filled at ask 1.10001
+	for _, mid := range []float64{1.0990, 1.1030, 1.1010} {
+		require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(mid))))
+	}
+	_, err = s.CloseTrade(context.Background(), acct.ID, open.TradeID, 1000)
+	require.NoError(t, err)
+
+	// Longs are measured at bid (mid-1): worst 1.09899, best 1.10299.
+	require.Len(t, j.trades, 1)
+	assert.Equal(t, types.Price(102), j.trades[0].MAE)
+	assert.Equal(t, types.Price(298), j.trades[0].MFE)
+	assert.Equal(t, j.trades[0].MAE, acct.Trades[0].MAE)
+}
+
 func TestUpdatePrice_TriggersLongTakeProfit(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
-	ch, err := s.StreamTransactions(context.Background(), "acct", oanda.StreamOptions{})
+	ch, err := s.StreamTransactions(context.Background(), acct.ID, oanda.StreamOptions{})
 	require.NoError(t, err)
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 	<-ch
-	require.NoError(t, s.UpdateTradeStop(context.Background(), "acct", open.TradeID, 0, 1.1100))
+	require.NoError(t, s.UpdateTradeStop(context.Background(), acct.ID, open.TradeID, 0, 1.1100))
 
 	require.NoError(t, s.UpdatePrice(market.Tick{
 		Instrument: "EURUSD",
@@ -557,14 +717,14 @@ func TestUpdatePrice_TriggersLongTakeProfit(t *testing.T) {
 func TestUpdatePrice_TriggersShortStopLoss(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
-	ch, err := s.StreamTransactions(context.Background(), "acct", oanda.StreamOptions{})
+	ch, err := s.StreamTransactions(context.Background(), acct.ID, oanda.StreamOptions{})
 	require.NoError(t, err)
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", -1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", -1000, 0)
 	require.NoError(t, err)
 	<-ch
-	require.NoError(t, s.UpdateTradeStop(context.Background(), "acct", open.TradeID, 1.1050, 0))
+	require.NoError(t, s.UpdateTradeStop(context.Background(), acct.ID, open.TradeID, 1.1050, 0))
 
 	// Ask rises through the short's stop.
 	require.NoError(t, s.UpdatePrice(market.Tick{
@@ -584,14 +744,14 @@ func TestUpdatePrice_TriggersShortStopLoss(t *testing.T) {
 func TestUpdatePrice_TriggersShortTakeProfit(t *testing.T) {
 	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
 	s := NewSimBroker(acct, nil)
-	ch, err := s.StreamTransactions(context.Background(), "acct", oanda.StreamOptions{})
+	ch, err := s.StreamTransactions(context.Background(), acct.ID, oanda.StreamOptions{})
 	require.NoError(t, err)
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	open, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", -1000, 0)
+	open, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", -1000, 0)
 	require.NoError(t, err)
 	<-ch
-	require.NoError(t, s.UpdateTradeStop(context.Background(), "acct", open.TradeID, 0, 1.0900))
+	require.NoError(t, s.UpdateTradeStop(context.Background(), acct.ID, open.TradeID, 0, 1.0900))
 
 	require.NoError(t, s.UpdatePrice(market.Tick{
 		Instrument: "EURUSD",
@@ -612,7 +772,7 @@ func TestUpdatePrice_NoStopOrTakeSet_LeavesLotOpen(t *testing.T) {
 	s := NewSimBroker(acct, nil)
 
 	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
-	_, err := s.SubmitMarketOrder(context.Background(), "acct", "EURUSD", 1000, 0)
+	_, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 1000, 0)
 	require.NoError(t, err)
 
 	// A large adverse move — would trigger a stop/take if one were set.
@@ -620,3 +780,60 @@ func TestUpdatePrice_NoStopOrTakeSet_LeavesLotOpen(t *testing.T) {
 
 	assert.Equal(t, 1, acct.Lots.Len(), "lot with no stop/take must never be auto-closed")
 }
+
+func TestSubmitMarketOrder_UsesInjectedIDs(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), nil)
+	s.IDs = idgen.NewSequence("run1")
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
+
+	first, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	second, err := s.SubmitMarketOrder(ctx, "", "EURUSD", -1000, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "run1-000001", first.TradeID)
+	assert.Equal(t, "run1-000002", second.TradeID)
+	assert.NotNil(t, s.account.Lots.Get("run1-000002"))
+}
+
+// ── PreviewOrder ─────────────────────────────────────────────────────────────
+
+func TestPreviewOrder_MatchesTheFillWithoutPlacingIt(t *testing.T) {
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	s.Slippage = types.PriceFromFloat(0.0002)
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
+
+	req := brokers.PreviewRequest{AccountID: acct.ID, Instrument: "EUR_USD", Units: 10_000}
+	p, err := s.PreviewOrder(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "EURUSD", p.Instrument)
+	assert.Zero(t, acct.Lots.Len(), "a preview places nothing")
+	assert.Zero(t, acct.MarginUsed)
+	assert.Equal(t, acct.Equity, p.Equity)
+	assert.Positive(t, p.MarginRequired)
+	assert.Equal(t, p.MarginRequired, p.MarginUsed)
+	assert.Equal(t, p.Equity-p.MarginUsed, p.FreeMargin)
+	assert.GreaterOrEqual(t, p.FreeMargin, types.Money(0), "the order fits")
+
+	res, err := s.SubmitMarketOrder(context.Background(), acct.ID, "EURUSD", 10_000, 0)
+	require.NoError(t, err)
+	assert.InDelta(t, p.FillPrice.Float64(), res.Price, 1e-9)
+	assert.Equal(t, p.MarginUsed, acct.MarginUsed)
+
+	// A second preview stacks on the margin now in use.
+	short, err := s.PreviewOrder(context.Background(), brokers.PreviewRequest{Instrument: "EURUSD", Units: -10_000})
+	require.NoError(t, err)
+	assert.Less(t, short.FillPrice, p.FillPrice, "a sell previews at the bid")
+	assert.Equal(t, acct.MarginUsed+short.MarginRequired, short.MarginUsed)
+}
+
+func TestPreviewOrder_Errors(t *testing.T) {
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), nil)
+	_, err := s.PreviewOrder(context.Background(), brokers.PreviewRequest{Instrument: "EURUSD", Units: 1000})
+	assert.ErrorIs(t, err, errs.ErrNoPrice)
+
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
+	_, err = s.PreviewOrder(context.Background(), brokers.PreviewRequest{Instrument: "EURUSD"})
+	assert.Error(t, err)
+}
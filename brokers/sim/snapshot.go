@@ -0,0 +1,80 @@
+package sim
+
+import (
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Snapshot is an immutable point-in-time copy of Sim's state: account
+// ledger figures, the open lots, and the latest tick seen per instrument.
+// Nothing in it aliases Sim's live state — every lot is a Clone and the
+// price map is a fresh copy — so a caller can hold on to it, serialize it,
+// or hand it to another goroutine without racing the feed driving
+// UpdatePrice.
+type Snapshot struct {
+	AccountID   string
+	Currency    string
+	Balance     types.Money
+	Equity      types.Money
+	MarginUsed  types.Money
+	FreeMargin  types.Money
+	MarginLevel types.Money
+
+	// OpenLots is ordered the same way LotBook.Slice orders it (entry
+	// time, then ID).
+	OpenLots []*account.Lot
+	// ClosedTrades is the number of trades the account has realized so
+	// far; the trades themselves stay in the journal, not the snapshot.
+	ClosedTrades int
+	// Prices holds the last tick per normalized instrument.
+	Prices map[string]market.Tick
+	// AsOf is the newest tick timestamp seen across all instruments —
+	// simulated time, not wall-clock time.
+	AsOf types.Timestamp
+
+	// SubAccounts holds one entry per sub-account, ordered by ID. Entries
+	// carry the account figures and lots only; Prices and AsOf are shared
+	// and live on the top-level snapshot.
+	SubAccounts []Snapshot
+}
+
+// Snapshot returns a copy of Sim's current state. It holds Sim's read
+// lock only for as long as copying takes, so it is safe to poll from a
+// monitoring goroutine or HTTP handler while another goroutine feeds
+// UpdatePrice.
+func (e *Sim) Snapshot() Snapshot {
+	if e == nil || e.account == nil {
+		return Snapshot{}
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	snap := accountSnapshot(e.account)
+	snap.Prices = make(map[string]market.Tick, len(e.prices.latest))
+	for inst, tick := range e.prices.latest {
+		snap.Prices[inst] = tick
+		if tick.Timestamp > snap.AsOf {
+			snap.AsOf = tick.Timestamp
+		}
+	}
+	for _, sub := range e.accounts()[1:] {
+		snap.SubAccounts = append(snap.SubAccounts, accountSnapshot(sub))
+	}
+	return snap
+}
+
+// accountSnapshot copies one account's ledger figures and lots.
+func accountSnapshot(a *account.Account) Snapshot {
+	return Snapshot{
+		AccountID:    a.ID,
+		Currency:     a.Currency,
+		Balance:      a.Balance,
+		Equity:       a.Equity,
+		MarginUsed:   a.MarginUsed,
+		FreeMargin:   a.FreeMargin,
+		MarginLevel:  a.MarginLevel,
+		OpenLots:     a.Lots.Slice(),
+		ClosedTrades: len(a.Trades),
+	}
+}
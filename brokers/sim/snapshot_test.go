@@ -0,0 +1,82 @@
+package sim
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_NilReceiverReturnsZero(t *testing.T) {
+	var s *Sim
+	assert.Equal(t, Snapshot{}, s.Snapshot())
+}
+
+func TestSnapshot_CopiesAccountLotsAndPrices(t *testing.T) {
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+
+	tick := eurusdTick(types.PriceFromFloat(1.08))
+	tick.Timestamp = 1_700_000_000
+	require.NoError(t, s.UpdatePrice(tick))
+	openLot(t, acct, types.PriceFromFloat(1.08))
+
+	snap := s.Snapshot()
+	assert.Equal(t, acct.ID, snap.AccountID)
+	assert.Equal(t, acct.Balance, snap.Balance)
+	assert.Equal(t, acct.Equity, snap.Equity)
+	require.Len(t, snap.OpenLots, 1)
+	assert.Equal(t, "lot-sim-1", snap.OpenLots[0].ID)
+	require.Contains(t, snap.Prices, "EURUSD")
+	assert.Equal(t, types.Timestamp(1_700_000_000), snap.AsOf)
+}
+
+func TestSnapshot_IsIsolatedFromLaterMutation(t *testing.T) {
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.08))))
+	openLot(t, acct, types.PriceFromFloat(1.08))
+
+	snap := s.Snapshot()
+	snap.OpenLots[0].Stop = 42
+	snap.Prices["EURUSD"] = eurusdTick(1)
+
+	require.NoError(t, s.UpdateTradeStop(context.Background(), "", "lot-sim-1", 1.07, 0))
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.09))))
+
+	assert.Equal(t, types.Price(42), snap.OpenLots[0].Stop)
+	assert.Equal(t, types.PriceFromFloat(1.07), acct.Lots.Get("lot-sim-1").Stop)
+	assert.Equal(t, types.PriceFromFloat(1.09)+1, s.Snapshot().Prices["EURUSD"].Ask)
+}
+
+func TestSnapshot_ConcurrentWithUpdatePrice(t *testing.T) {
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.08))))
+	_, err := s.SubmitMarketOrder(context.Background(), "", "EURUSD", 1000, 0)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			_ = s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.08) + types.Price(i)))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			snap := s.Snapshot()
+			_ = snap.Equity
+			_ = len(snap.OpenLots)
+		}
+	}()
+	wg.Wait()
+
+	assert.Len(t, s.Snapshot().OpenLots, 1)
+}
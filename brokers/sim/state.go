@@ -0,0 +1,189 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// StateVersion is the schema version SaveState writes. LoadState refuses
+// any other version rather than guessing at a layout it does not know.
+const StateVersion = 1
+
+// State is the persisted form of a Sim: everything a paper-trading
+// session needs to pick up where it stopped, closed-trade history
+// included, so a resumed session reports on the whole run. It is one JSON
+// document rather than a CSV or JSONL journal: it is not appended to but
+// replaced whole on every save, and holds nested records (lots, orders)
+// that have no flat row form.
+type State struct {
+	Version  int                    `json:"version"`
+	AsOf     types.Timestamp        `json:"as_of"`
+	Accounts []AccountState         `json:"accounts"` // primary first, then sub-accounts by ID
+	Orders   []PendingOrder         `json:"orders,omitempty"`
+	Prices   map[string]market.Tick `json:"prices,omitempty"`
+}
+
+// AccountState is one account's persisted ledger. Equity and margin are
+// not stored: they are recomputed from the lots and prices on load.
+type AccountState struct {
+	ID           string           `json:"id"`
+	Currency     string           `json:"currency"`
+	Balance      types.Money      `json:"balance"`
+	RiskFraction types.Rate       `json:"risk_fraction"`
+	Lots         []*account.Lot   `json:"lots,omitempty"`
+	Trades       []*account.Trade `json:"trades,omitempty"` // closed, oldest first
+}
+
+// SaveState writes Sim's accounts, open lots, closed trades, resting
+// orders, and latest prices to path as versioned JSON. The file is
+// written to path+".part" and renamed into place, so a crash mid-save
+// leaves the previous state intact.
+func (e *Sim) SaveState(path string) error {
+	if e == nil || e.account == nil {
+		return fmt.Errorf("sim broker account is nil")
+	}
+	e.mu.RLock()
+	st := State{Version: StateVersion, Prices: make(map[string]market.Tick, len(e.prices.latest))}
+	for inst, tick := range e.prices.latest {
+		st.Prices[inst] = tick
+		st.AsOf = max(st.AsOf, tick.Timestamp)
+	}
+	for _, a := range e.accounts() {
+		st.Accounts = append(st.Accounts, AccountState{
+			ID:           a.ID,
+			Currency:     a.Currency,
+			Balance:      a.Balance,
+			RiskFraction: a.RiskFraction,
+			Lots:         a.Lots.Slice(),
+			Trades:       cloneTrades(a.Trades),
+		})
+	}
+	for _, o := range e.orders {
+		st.Orders = append(st.Orders, *o)
+	}
+	e.mu.RUnlock()
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("sim: encode state: %w", err)
+	}
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("sim: write state %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("sim: rename %s -> %s: %w", tmp, path, err)
+	}
+	return nil
+}
+
+// LoadState restores state saved by SaveState into a freshly constructed
+// Sim. The primary account keeps its identity but takes the saved
+// balance, risk fraction, lots, and closed trades; sub-accounts, resting
+// orders, and prices are replaced wholesale. The saved primary account ID must match,
+// so a state file is never applied to the wrong account. A missing file
+// returns an error satisfying errors.Is(err, os.ErrNotExist), which
+// callers treat as a fresh start.
+func (e *Sim) LoadState(path string) error {
+	if e == nil || e.account == nil {
+		return fmt.Errorf("sim broker account is nil")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("sim: read state: %w", err)
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("sim: decode state %s: %w", path, err)
+	}
+	if st.Version != StateVersion {
+		return fmt.Errorf("sim: state %s has schema version %d, want %d", path, st.Version, StateVersion)
+	}
+	if len(st.Accounts) == 0 {
+		return fmt.Errorf("sim: state %s has no accounts", path)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if st.Accounts[0].ID != e.account.ID {
+		return fmt.Errorf("sim: state %s belongs to account %s, not %s", path, st.Accounts[0].ID, e.account.ID)
+	}
+	if e.account.Lots.Len() > 0 || len(e.account.Trades) > 0 || len(e.subs) > 0 || len(e.orders) > 0 {
+		return fmt.Errorf("sim: load state into a sim that has already traded")
+	}
+
+	subs := make(map[string]*account.Account, len(st.Accounts)-1)
+	for i, as := range st.Accounts {
+		acct := e.account
+		if i > 0 {
+			if _, dup := subs[as.ID]; dup || as.ID == e.account.ID {
+				return fmt.Errorf("sim: state %s repeats account %s", path, as.ID)
+			}
+			acct = account.NewAccount(as.ID, as.Balance)
+			acct.ID = as.ID
+			subs[as.ID] = acct
+		}
+		if err := restoreAccount(acct, as); err != nil {
+			return fmt.Errorf("sim: restore account %s: %w", as.ID, err)
+		}
+	}
+
+	orders := make([]*PendingOrder, 0, len(st.Orders))
+	for i := range st.Orders {
+		orders = append(orders, &st.Orders[i])
+	}
+
+	prices := make(map[string]market.Tick, len(st.Prices))
+	marks := make(map[string]types.Price, len(st.Prices))
+	for inst, tick := range st.Prices {
+		prices[inst] = tick
+		marks[inst] = tick.Mid()
+	}
+
+	e.subs = subs
+	e.indexAccounts()
+	e.orders = orders
+	e.prices.reset(prices)
+	for _, acct := range e.accounts() {
+		if err := acct.ResolveWithMarks(marks); err != nil {
+			return fmt.Errorf("sim: mark account %s: %w", acct.ID, err)
+		}
+	}
+	return nil
+}
+
+// restoreAccount resets acct's ledger to as and re-adds its lots.
+func restoreAccount(acct *account.Account, as AccountState) error {
+	acct.Currency = as.Currency
+	acct.Balance = as.Balance
+	acct.Equity = as.Balance
+	acct.RiskFraction = as.RiskFraction
+	acct.Trades = as.Trades
+	acct.Lots = account.LotBook{}
+	for _, lot := range as.Lots {
+		if err := acct.AddLot(lot); err != nil {
+			return fmt.Errorf("lot %s: %w", lot.ID, err)
+		}
+	}
+	return nil
+}
+
+// cloneTrades copies trades so the saved state does not alias the live
+// ledger.
+func cloneTrades(trades []*account.Trade) []*account.Trade {
+	if len(trades) == 0 {
+		return nil
+	}
+	out := make([]*account.Trade, len(trades))
+	for i, t := range trades {
+		out[i] = t.Clone()
+	}
+	return out
+}
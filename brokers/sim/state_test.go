@@ -0,0 +1,140 @@
+package sim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStateSim(t *testing.T) *Sim {
+	t.Helper()
+	return NewSimBroker(&account.Account{
+		ID:       "SIM-REPLAY",
+		Currency: "USD",
+		Balance:  types.MoneyFromFloat(10_000),
+		Equity:   types.MoneyFromFloat(10_000),
+	}, nil)
+}
+
+func TestSaveLoadState_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "run-state.json")
+
+	s := newStateSim(t)
+	_, err := s.AllocateSubAccount("ema", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+	open, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 1.0950)
+	require.NoError(t, err)
+	_, err = s.SubmitMarketOrder(ctx, "ema", "EURUSD", -2000, 0)
+	require.NoError(t, err)
+	order, err := s.SubmitLimitOrder(ctx, "", "EURUSD", 1000, 109_000, 0, brokers.GTD, 1_000)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_200, 200)))
+	require.NoError(t, s.SaveState(path))
+	_, err = os.Stat(path + ".part")
+	assert.True(t, os.IsNotExist(err), "temp file must be renamed away")
+
+	restored := newStateSim(t)
+	require.NoError(t, restored.LoadState(path))
+
+	want, got := s.Snapshot(), restored.Snapshot()
+	assert.Equal(t, want.Balance, got.Balance)
+	assert.Equal(t, want.Equity, got.Equity)
+	assert.Equal(t, want.MarginUsed, got.MarginUsed)
+	assert.Equal(t, want.AsOf, got.AsOf)
+	assert.Equal(t, want.Prices, got.Prices)
+	require.Len(t, got.OpenLots, 1)
+	assert.Equal(t, open.TradeID, got.OpenLots[0].ID)
+	assert.Equal(t, types.PriceFromFloat(1.0950), got.OpenLots[0].Stop)
+	require.Len(t, got.SubAccounts, 1)
+	assert.Equal(t, want.SubAccounts[0].Equity, got.SubAccounts[0].Equity)
+
+	pending := restored.PendingOrders("")
+	require.Len(t, pending, 1)
+	assert.Equal(t, order.OrderID, pending[0].ID)
+
+	// The restored session carries on: the stop still triggers and the GTD
+	// order still expires.
+	require.NoError(t, restored.UpdatePrice(eurusdTickAt(109_400, 1_000)))
+	assert.Equal(t, 0, restored.account.Lots.Len())
+	assert.Empty(t, restored.PendingOrders(""))
+}
+
+func TestSaveLoadState_ClosedTrades(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "run-state.json")
+
+	s := newStateSim(t)
+	_, err := s.AllocateSubAccount("ema", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+	first, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	sub, err := s.SubmitMarketOrder(ctx, "ema", "EURUSD", -2000, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_200, 200)))
+	_, err = s.CloseTrade(ctx, "", first.TradeID, 0)
+	require.NoError(t, err)
+	_, err = s.CloseTrade(ctx, "ema", sub.TradeID, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveState(path))
+
+	restored := newStateSim(t)
+	require.NoError(t, restored.LoadState(path))
+	assert.Equal(t, s.account.Trades, restored.account.Trades)
+	require.Len(t, restored.account.Trades, 1)
+	assert.Equal(t, first.TradeID, restored.account.Trades[0].ID)
+	wantSub, gotSub := s.SubAccount("ema"), restored.SubAccount("ema")
+	require.NotNil(t, gotSub)
+	assert.Equal(t, wantSub.Trades, gotSub.Trades)
+	assert.Equal(t, wantSub.Balance, gotSub.Balance)
+
+	// History keeps growing after the resume, and survives a second save.
+	require.NoError(t, restored.UpdatePrice(eurusdTickAt(110_300, 300)))
+	next, err := restored.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	_, err = restored.CloseTrade(ctx, "", next.TradeID, 0)
+	require.NoError(t, err)
+	require.NoError(t, restored.SaveState(path))
+	again := newStateSim(t)
+	require.NoError(t, again.LoadState(path))
+	require.Len(t, again.account.Trades, 2)
+	assert.Equal(t, []string{first.TradeID, next.TradeID}, []string{again.account.Trades[0].ID, again.account.Trades[1].ID})
+}
+
+func TestLoadState_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	err := newStateSim(t).LoadState(filepath.Join(dir, "missing.json"))
+	require.True(t, errors.Is(err, os.ErrNotExist))
+
+	write := func(st State) string {
+		data, err := json.Marshal(st)
+		require.NoError(t, err)
+		path := filepath.Join(dir, "state.json")
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		return path
+	}
+
+	path := write(State{Version: StateVersion + 1, Accounts: []AccountState{{ID: "SIM-REPLAY"}}})
+	require.ErrorContains(t, newStateSim(t).LoadState(path), "schema version")
+
+	path = write(State{Version: StateVersion, Accounts: []AccountState{{ID: "other"}}})
+	require.ErrorContains(t, newStateSim(t).LoadState(path), "belongs to account other")
+
+	path = write(State{Version: StateVersion, Accounts: []AccountState{{ID: "SIM-REPLAY"}}})
+	s := newStateSim(t)
+	_, err = s.AllocateSubAccount("ema", types.MoneyFromFloat(1_000))
+	require.NoError(t, err)
+	require.ErrorContains(t, s.LoadState(path), "already traded")
+}
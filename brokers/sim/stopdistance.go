@@ -0,0 +1,100 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// ErrStopTooClose is the kind of every StopDistanceError: a stop-loss or
+// take-profit placed nearer the market than the broker allows.
+var ErrStopTooClose = errors.New("stop too close to market")
+
+// StopDistance is the least distance, in pips, a stop-loss (Stop) and a
+// take-profit (Take) must keep from the market; zero allows any.
+type StopDistance struct {
+	Stop types.Pips
+	Take types.Pips
+}
+
+// MinStopDistance is the minimum stop and take distance Sim enforces,
+// the way real brokers refuse stops placed too close to the market (see
+// Sim.MinStops). Orders are checked when submitted and stops when changed
+// with UpdateTradeStop; a stop or take on the wrong side of the market
+// is as close as can be and is refused too.
+type MinStopDistance struct {
+	Default     StopDistance
+	Instruments map[string]StopDistance // by normalized instrument; overrides Default
+}
+
+// For returns inst's minimum distances.
+func (m *MinStopDistance) For(inst string) StopDistance {
+	if m == nil {
+		return StopDistance{}
+	}
+	if d, ok := m.Instruments[market.NormalizeInstrument(inst)]; ok {
+		return d
+	}
+	return m.Default
+}
+
+// StopDistanceError is returned for an order or stop change whose
+// stop-loss or take-profit is nearer the market than MinStopDistance
+// allows. It wraps ErrStopTooClose.
+type StopDistanceError struct {
+	Instrument string
+	Order      string     // "stop-loss" or "take-profit"
+	Distance   types.Pips // from the reference price, negative on the wrong side
+	Min        types.Pips
+}
+
+func (e *StopDistanceError) Error() string {
+	return fmt.Sprintf("%s %s %.1f pips from market, minimum %.1f: %s",
+		e.Instrument, e.Order, e.Distance.Float64(), e.Min.Float64(), ErrStopTooClose)
+}
+
+func (e *StopDistanceError) Unwrap() error { return ErrStopTooClose }
+
+// Check validates a long (or short) position's stop and take on inst
+// against ref, the price they are measured from: the mid for a market
+// order or a stop change, the limit price for a limit order. A zero stop
+// or take is not checked.
+func (m *MinStopDistance) Check(inst string, long bool, ref, stop, take types.Price) error {
+	d := m.For(inst)
+	if d.Stop <= 0 && d.Take <= 0 {
+		return nil
+	}
+	meta := market.GetInstrument(inst)
+	if meta == nil {
+		return nil
+	}
+	check := func(order string, level, away types.Price, minPips types.Pips) error {
+		if level <= 0 || minPips <= 0 {
+			return nil
+		}
+		if away < meta.PriceDeltaFromPips(minPips) {
+			return &StopDistanceError{Instrument: inst, Order: order, Distance: pipsFromDelta(meta, away), Min: minPips}
+		}
+		return nil
+	}
+	stopAway, takeAway := ref-stop, take-ref
+	if !long {
+		stopAway, takeAway = stop-ref, ref-take
+	}
+	if err := check("stop-loss", stop, stopAway, d.Stop); err != nil {
+		return err
+	}
+	return check("take-profit", take, takeAway, d.Take)
+}
+
+// pipsFromDelta converts a price distance on inst to pips, truncated to
+// the tenth.
+func pipsFromDelta(inst *market.Instrument, delta types.Price) types.Pips {
+	perPip := int64(inst.PriceUnitsPerPip())
+	if perPip == 0 {
+		return 0
+	}
+	return types.Pips(int64(delta) * types.PipScale / perPip)
+}
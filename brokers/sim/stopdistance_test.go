@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinStopDistance_Check(t *testing.T) {
+	m := &MinStopDistance{
+		Default:     StopDistance{Stop: types.PipsFromFloat(5), Take: types.PipsFromFloat(3)},
+		Instruments: map[string]StopDistance{"USDJPY": {Stop: types.PipsFromFloat(10)}},
+	}
+	ref := types.Price(110_000)
+
+	require.NoError(t, m.Check("EURUSD", true, ref, ref-50, ref+30), "exactly the minimum is allowed")
+	require.NoError(t, m.Check("EURUSD", true, ref, 0, 0), "no stop, nothing to check")
+	require.NoError(t, m.Check("EURUSD", false, ref, ref+50, ref-30))
+
+	err := m.Check("EURUSD", true, ref, ref-40, 0)
+	var sde *StopDistanceError
+	require.ErrorAs(t, err, &sde)
+	assert.True(t, errors.Is(err, ErrStopTooClose))
+	assert.Equal(t, StopDistanceError{Instrument: "EURUSD", Order: "stop-loss", Distance: types.PipsFromFloat(4), Min: types.PipsFromFloat(5)}, *sde)
+	assert.EqualError(t, err, "EURUSD stop-loss 4.0 pips from market, minimum 5.0: stop too close to market")
+
+	require.ErrorAs(t, m.Check("EURUSD", false, ref, 0, ref-20), &sde)
+	assert.Equal(t, "take-profit", sde.Order)
+	require.ErrorAs(t, m.Check("EURUSD", true, ref, ref+10, 0), &sde, "a stop on the wrong side")
+	assert.Equal(t, types.PipsFromFloat(-1), sde.Distance)
+
+	require.ErrorIs(t, m.Check("USD_JPY", true, 15_000_000, 14_991_000, 0), ErrStopTooClose, "9 pips under the instrument's own 10")
+	require.NoError(t, m.Check("USDJPY", true, 15_000_000, 0, 15_000_100), "its take has no minimum")
+
+	var none *MinStopDistance
+	require.NoError(t, none.Check("EURUSD", true, ref, ref-1, ref+1))
+}
+
+func TestMinStops_EnforcedOnOrdersAndStopChanges(t *testing.T) {
+	ctx := context.Background()
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+	s.MinStops = &MinStopDistance{Default: StopDistance{Stop: types.PipsFromFloat(5), Take: types.PipsFromFloat(5)}}
+	require.NoError(t, s.UpdatePrice(eurusdTickAt(110_000, 100)))
+
+	_, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 1.0998)
+	require.ErrorIs(t, err, ErrStopTooClose)
+	assert.Zero(t, acct.Lots.Len(), "nothing opened")
+
+	_, err = s.SubmitLimitOrder(ctx, "", "EURUSD", 1000, 109_000, 108_980, brokers.GTC, 0)
+	require.ErrorIs(t, err, ErrStopTooClose, "measured from the limit price")
+	assert.Empty(t, s.PendingOrders(""))
+
+	res, err := s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 1.0990)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, s.UpdateTradeStop(ctx, "", res.TradeID, 1.0997, 0), ErrStopTooClose)
+	require.ErrorIs(t, s.UpdateTradeStop(ctx, "", res.TradeID, 0, 1.1002), ErrStopTooClose)
+	require.NoError(t, s.UpdateTradeStop(ctx, "", res.TradeID, 1.0995, 1.1010))
+	require.NoError(t, s.UpdateTradeStop(ctx, "", res.TradeID, -1, -1), "cancelling is never refused")
+
+	lots := acct.Lots.Slice()
+	require.Len(t, lots, 1)
+	assert.Zero(t, lots[0].Stop)
+	assert.Zero(t, lots[0].Take)
+}
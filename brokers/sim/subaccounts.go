@@ -0,0 +1,117 @@
+package sim
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/types"
+)
+
+// AllocateSubAccount creates a virtual sub-account with its own starting
+// balance, for running several strategies against one Sim (and one price
+// feed) without their P/L, margin, or sizing bleeding into each other.
+// Broker calls made with id as the accountID act on the sub-account;
+// UpdatePrice marks and triggers stops across every account; journal
+// records for the sub-account carry id in their AccountID field.
+//
+// The sub-account inherits the primary account's currency and risk
+// fraction. Its balance is independent: allocating does not debit the
+// primary account.
+func (e *Sim) AllocateSubAccount(id string, balance types.Money) (*account.Account, error) {
+	if e == nil || e.account == nil {
+		return nil, fmt.Errorf("sim broker account is nil")
+	}
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("sim: sub-account id must not be blank")
+	}
+	if balance <= 0 {
+		return nil, fmt.Errorf("sim: sub-account %s balance must be > 0", id)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if id == e.account.ID {
+		return nil, fmt.Errorf("sim: sub-account id %s is the primary account's", id)
+	}
+	if _, ok := e.subs[id]; ok {
+		return nil, fmt.Errorf("sim: sub-account %s already exists", id)
+	}
+
+	sub := account.NewAccount(id, balance)
+	sub.ID = id
+	sub.Currency = e.account.Currency
+	sub.RiskFraction = e.account.RiskFraction
+	if e.subs == nil {
+		e.subs = make(map[string]*account.Account)
+	}
+	e.subs[id] = sub
+	e.indexAccounts()
+	return sub, nil
+}
+
+// SubAccount returns the live sub-account with the given ID, or nil. Like
+// GetAccount, the pointer is not guarded by Sim's lock.
+func (e *Sim) SubAccount(id string) *account.Account {
+	if e == nil {
+		return nil
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.subs[id]
+}
+
+// accountFor resolves a Broker accountID: the primary account for its own
+// ID or an empty one, a sub-account for its ID, and an errs.ErrNotFound
+// error for anything else.
+func (e *Sim) accountFor(accountID string) (*account.Account, error) {
+	if accountID == "" || accountID == e.account.ID {
+		return e.account, nil
+	}
+	if sub, ok := e.subs[accountID]; ok {
+		return sub, nil
+	}
+	return nil, errs.Newf(errs.ErrNotFound, "sim: no account %s", accountID)
+}
+
+// orderAccount is the account behind an order's AccountID, which
+// accountFor accepted when the order was submitted.
+func (e *Sim) orderAccount(accountID string) *account.Account {
+	if sub, ok := e.subs[accountID]; ok {
+		return sub
+	}
+	return e.account
+}
+
+// accounts returns the primary account followed by the sub-accounts in ID
+// order, so multi-account work (marking, stop checks, CloseAll) runs in a
+// deterministic order. The slice is shared; callers must not modify it.
+func (e *Sim) accounts() []*account.Account {
+	if e.accts == nil {
+		return []*account.Account{e.account}
+	}
+	return e.accts
+}
+
+// indexAccounts rebuilds accts after subs changes.
+func (e *Sim) indexAccounts() {
+	out := make([]*account.Account, 0, 1+len(e.subs))
+	out = append(out, e.account)
+	for _, id := range slices.Sorted(maps.Keys(e.subs)) {
+		out = append(out, e.subs[id])
+	}
+	e.accts = out
+}
+
+// journalAccountID is the AccountID journal records carry for acct: empty
+// for the primary account, so single-account journals are unchanged.
+func (e *Sim) journalAccountID(acct *account.Account) string {
+	if acct == e.account {
+		return ""
+	}
+	return acct.ID
+}
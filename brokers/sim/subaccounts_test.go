@@ -0,0 +1,148 @@
+package sim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocateSubAccount_Validation(t *testing.T) {
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	s := NewSimBroker(acct, nil)
+
+	_, err := s.AllocateSubAccount(" ", types.MoneyFromFloat(1_000))
+	require.ErrorContains(t, err, "blank")
+	_, err = s.AllocateSubAccount("ema", 0)
+	require.ErrorContains(t, err, "balance must be > 0")
+	_, err = s.AllocateSubAccount(acct.ID, types.MoneyFromFloat(1_000))
+	require.ErrorContains(t, err, "primary")
+
+	sub, err := s.AllocateSubAccount("ema", types.MoneyFromFloat(2_000))
+	require.NoError(t, err)
+	assert.Equal(t, "ema", sub.ID)
+	assert.Equal(t, types.MoneyFromFloat(2_000), sub.Balance)
+	assert.Equal(t, acct.Currency, sub.Currency)
+	assert.Equal(t, acct.RiskFraction, sub.RiskFraction)
+	assert.Equal(t, types.MoneyFromFloat(10_000), acct.Balance, "allocation must not debit the primary account")
+	assert.Same(t, sub, s.SubAccount("ema"))
+
+	_, err = s.AllocateSubAccount("ema", types.MoneyFromFloat(1_000))
+	require.ErrorContains(t, err, "already exists")
+}
+
+func TestSubAccount_OrdersAreIsolated(t *testing.T) {
+	ctx := context.Background()
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	j := &stubJournal{}
+	s := NewSimBroker(acct, j)
+	ema, err := s.AllocateSubAccount("ema", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+	rsi, err := s.AllocateSubAccount("rsi", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
+	open, err := s.SubmitMarketOrder(ctx, "ema", "EURUSD", 10_000, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ema.Lots.Len())
+	assert.Equal(t, 0, rsi.Lots.Len())
+	assert.Equal(t, 0, acct.Lots.Len())
+	assert.Positive(t, ema.MarginUsed)
+	assert.Zero(t, rsi.MarginUsed)
+
+	trades, err := s.GetOpenTrades(ctx, "ema")
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	trades, err = s.GetOpenTrades(ctx, "rsi")
+	require.NoError(t, err)
+	assert.Empty(t, trades)
+
+	_, err = s.CloseTrade(ctx, "rsi", open.TradeID, 0)
+	require.ErrorContains(t, err, "no open trade", "a trade is only reachable through its own account")
+
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1050))))
+	_, err = s.CloseTrade(ctx, "ema", open.TradeID, 0)
+	require.NoError(t, err)
+	assert.Greater(t, ema.Balance, types.MoneyFromFloat(5_000))
+	assert.Equal(t, types.MoneyFromFloat(5_000), rsi.Balance)
+	assert.Equal(t, types.MoneyFromFloat(10_000), acct.Balance)
+
+	require.Len(t, j.trades, 1)
+	assert.Equal(t, "ema", j.trades[0].AccountID)
+
+	summary, err := s.GetAccountSummary(ctx, "ema")
+	require.NoError(t, err)
+	assert.Equal(t, "ema", summary.ID)
+
+	_, err = s.SubmitMarketOrder(ctx, "macd", "EURUSD", 1000, 0)
+	require.ErrorIs(t, err, errs.ErrNotFound, "an unknown account is not the primary one")
+	_, err = s.GetOpenTrades(ctx, "macd")
+	require.ErrorIs(t, err, errs.ErrNotFound)
+	assert.Nil(t, s.PendingOrders("macd"))
+	_, err = s.SubmitMarketOrder(ctx, "", "EURUSD", 1000, 0)
+	require.NoError(t, err, "an empty ID is the primary account")
+	assert.Equal(t, 1, acct.Lots.Len())
+}
+
+func TestSubAccount_UpdatePriceTriggersStops(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimBroker(account.NewAccount("test", types.MoneyFromFloat(10_000)), nil)
+	sub, err := s.AllocateSubAccount("ema", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
+	_, err = s.SubmitMarketOrder(ctx, "ema", "EURUSD", 1000, 1.0950)
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdatePrice(market.Tick{
+		Instrument: "EURUSD",
+		BA:         market.BA{Bid: types.PriceFromFloat(1.0940), Ask: types.PriceFromFloat(1.0942)},
+	}))
+	assert.Equal(t, 0, sub.Lots.Len(), "stop-hit lot in a sub-account must be closed")
+	require.Len(t, sub.Trades, 1)
+	assert.Negative(t, sub.Trades[0].PNL)
+}
+
+func TestSubAccount_CloseAllAndAccountsListing(t *testing.T) {
+	ctx := context.Background()
+	acct := account.NewAccount("test", types.MoneyFromFloat(10_000))
+	j := &stubJournal{}
+	s := NewSimBroker(acct, j)
+	_, err := s.AllocateSubAccount("rsi", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+	_, err = s.AllocateSubAccount("ema", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdatePrice(eurusdTick(types.PriceFromFloat(1.1000))))
+	_, err = s.SubmitMarketOrder(ctx, acct.ID, "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	_, err = s.SubmitMarketOrder(ctx, "rsi", "EURUSD", 1000, 0)
+	require.NoError(t, err)
+
+	refs, err := s.GetAccounts(ctx)
+	require.NoError(t, err)
+	require.Len(t, refs, 3)
+	assert.Equal(t, acct.ID, refs[0].ID)
+	assert.Equal(t, "ema", refs[1].ID)
+	assert.Equal(t, "rsi", refs[2].ID)
+
+	snap := s.Snapshot()
+	require.Len(t, snap.SubAccounts, 2)
+	assert.Equal(t, "ema", snap.SubAccounts[0].AccountID)
+	assert.Len(t, snap.SubAccounts[1].OpenLots, 1)
+	assert.Nil(t, snap.SubAccounts[1].Prices)
+
+	require.NoError(t, s.CloseAll(ctx, "end"))
+	assert.Equal(t, 0, acct.Lots.Len())
+	assert.Equal(t, 0, s.SubAccount("rsi").Lots.Len())
+	require.Len(t, j.trades, 2)
+	assert.Equal(t, "", j.trades[0].AccountID)
+	assert.Equal(t, "rsi", j.trades[1].AccountID)
+	require.Len(t, j.equity, 3, "one equity snapshot per account")
+	assert.Equal(t, "ema", j.equity[1].AccountID)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/market"
 	accountsvc "github.com/rustyeddy/trader/service/account"
 )
 
@@ -160,10 +161,10 @@ func ordersCmd(rc *config.RootConfig) *cobra.Command {
 			for _, t := range trades {
 				stopStr := "—"
 				if t.StopLoss > 0 {
-					stopStr = fmt.Sprintf("%.5f", t.StopLoss)
+					stopStr = market.FormatPrice(t.Instrument, t.StopLoss)
 				}
-				fmt.Fprintf(w, "  %-10s %-10s %8d %12.5f %10s %+10.2f\n",
-					t.ID, t.Instrument, t.Units, t.EntryPrice, stopStr, t.UnrealizedPL)
+				fmt.Fprintf(w, "  %-10s %-10s %8d %12s %10s %+10.2f\n",
+					t.ID, t.Instrument, t.Units, market.FormatPrice(t.Instrument, t.EntryPrice), stopStr, t.UnrealizedPL)
 			}
 			fmt.Fprintln(w, bar)
 			return nil
@@ -0,0 +1,134 @@
+// Package analyze hosts the `trader analyze` CLI commands: cross-instrument
+// analysis over stored candle data, trade excursion analysis over
+// journals and backtest reports, and indicator value dumps. Business logic lives in market/,
+// journal/, and service/; this package parses flags, calls them, and
+// formats output.
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/config"
+	datasvc "github.com/rustyeddy/trader/service/data"
+)
+
+// New returns the top-level "analyze" cobra command.
+func New(rc *config.RootConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyze instrument relationships, closed-trade excursions and indicator values",
+	}
+	cmd.AddCommand(newCorrelationCmd())
+	cmd.AddCommand(newExcursionCmd(rc))
+	cmd.AddCommand(newIndicatorsCmd())
+	return cmd
+}
+
+func newCorrelationCmd() *cobra.Command {
+	var (
+		instrumentsCSV string
+		timeframe      string
+		fromStr        string
+		toStr          string
+		source         string
+		window         int
+		output         string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "correlation",
+		Short: "Print the return-correlation matrix across instruments",
+		Long: `Correlate bar-over-bar close returns across two or more instruments.
+
+Candles are aligned on common timestamps; a bar missing from any instrument is
+dropped from all of them. The matrix covers the whole period. The pair table
+adds the most recent, lowest, and highest correlation over a rolling --window
+of bars, which shows how stable each relationship is.
+
+--from and --to are inclusive dates in YYYY-MM-DD format.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("bad --output %q (use table or json)", output)
+			}
+			res, err := (&datasvc.Service{}).Correlation(context.Background(), datasvc.CorrelationRequest{
+				Instruments: splitCSV(instrumentsCSV),
+				Timeframe:   timeframe,
+				From:        fromStr,
+				To:          toStr,
+				Source:      source,
+				Window:      window,
+			})
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(res)
+			}
+			printCorrelation(cmd.OutOrStdout(), res)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&instrumentsCSV, "instruments", "", "Comma-separated FX pairs (e.g. EURUSD,GBPUSD,USDJPY)")
+	cmd.Flags().StringVar(&timeframe, "timeframe", "H1", "Candle timeframe: M1, H1, or D1")
+	cmd.Flags().StringVar(&fromStr, "from", "", "Start date inclusive (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&toStr, "to", "", "End date inclusive (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&source, "source", "", "Data source override (default: oanda)")
+	cmd.Flags().IntVar(&window, "window", 100, "Rolling correlation window in bars")
+	cmd.Flags().StringVar(&output, "output", "table", "Output format: table|json")
+
+	_ = cmd.MarkFlagRequired("instruments")
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+func printCorrelation(w io.Writer, res *datasvc.CorrelationResult) {
+	fmt.Fprintf(w, "Correlation  %s  %s → %s   (%d bars, window %d)\n",
+		res.Timeframe, res.From, res.To, res.Bars, res.Window)
+	fmt.Fprintln(w, strings.Repeat("━", 64))
+
+	fmt.Fprintf(w, "%-8s", "")
+	for _, inst := range res.Instruments {
+		fmt.Fprintf(w, " %8s", inst)
+	}
+	fmt.Fprintln(w)
+	for i, inst := range res.Instruments {
+		fmt.Fprintf(w, "%-8s", inst)
+		for _, c := range res.Matrix[i] {
+			fmt.Fprintf(w, " %+8.2f", c)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "\n%-16s %7s %7s %7s %7s\n", "Pair", "Full", "Last", "Min", "Max")
+	for _, p := range res.Pairs {
+		pair := p.A + "/" + p.B
+		if p.Windows == 0 {
+			fmt.Fprintf(w, "%-16s %+7.2f %7s %7s %7s\n", pair, p.Full, "—", "—", "—")
+			continue
+		}
+		fmt.Fprintf(w, "%-16s %+7.2f %+7.2f %+7.2f %+7.2f\n", pair, p.Full, p.Last, p.Min, p.Max)
+	}
+}
+
+func splitCSV(s string) []string {
+	parts := strings.Split(s, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package analyze
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	datasvc "github.com/rustyeddy/trader/service/data"
+)
+
+func TestNew_HasCorrelationSubcommand(t *testing.T) {
+	cmd := New(nil)
+	sub, _, err := cmd.Find([]string{"correlation"})
+	require.NoError(t, err)
+	assert.Equal(t, "correlation", sub.Name())
+	for _, name := range []string{"instruments", "timeframe", "from", "to", "source", "window", "output"} {
+		assert.NotNil(t, sub.Flags().Lookup(name), "expected flag --%s", name)
+	}
+}
+
+func TestCorrelationCmd_RejectsBadOutput(t *testing.T) {
+	cmd := New(nil)
+	cmd.SetArgs([]string{"correlation", "--instruments", "EURUSD,GBPUSD", "--from", "2024-01-01", "--to", "2024-01-31", "--output", "xml"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	require.ErrorContains(t, cmd.Execute(), "bad --output")
+}
+
+func TestIndicatorsCmd_WritesCSV(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "bars.csv")
+	require.NoError(t, os.WriteFile(data, []byte("2024-01-08T00:00:00Z,1.1,1.1005,1.0995,1.1\n2024-01-08T01:00:00Z,1.1,1.1005,1.0995,1.1002\n"), 0o644))
+	out := filepath.Join(dir, "values.csv")
+
+	cmd := New(nil)
+	var stdout bytes.Buffer
+	cmd.SetArgs([]string{"indicators", "--data", data, "--indicator", "ema:2", "--out", out})
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "Wrote 2 bars to "+out)
+
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "time,open,high,low,close,ema_2\n"+
+		"2024-01-08T00:00:00Z,1.10000,1.10050,1.09950,1.10000,\n"+
+		"2024-01-08T01:00:00Z,1.10000,1.10050,1.09950,1.10020,1.10013\n", string(b))
+}
+
+func TestPrintCorrelation(t *testing.T) {
+	res := &datasvc.CorrelationResult{
+		Instruments: []string{"EURUSD", "USDCHF"},
+		Timeframe:   "H1",
+		From:        "2024-01-01",
+		To:          "2024-01-31",
+		Window:      100,
+		Bars:        500,
+		Matrix:      [][]float64{{1, -0.91}, {-0.91, 1}},
+		Pairs: []datasvc.CorrelationPair{
+			{A: "EURUSD", B: "USDCHF", Full: -0.91, Last: -0.85, Min: -0.97, Max: -0.62, Windows: 400},
+		},
+	}
+
+	var buf bytes.Buffer
+	printCorrelation(&buf, res)
+	out := buf.String()
+	assert.Contains(t, out, "H1  2024-01-01 → 2024-01-31   (500 bars, window 100)")
+	assert.Contains(t, out, "EURUSD      +1.00    -0.91")
+	assert.Contains(t, out, "EURUSD/USDCHF      -0.91   -0.85   -0.97   -0.62")
+
+	res.Pairs[0].Windows = 0
+	buf.Reset()
+	printCorrelation(&buf, res)
+	assert.Contains(t, buf.String(), "EURUSD/USDCHF      -0.91       —       —       —")
+}
@@ -0,0 +1,149 @@
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/journal"
+	backtestsvc "github.com/rustyeddy/trader/service/backtest"
+)
+
+func newExcursionCmd(rc *config.RootConfig) *cobra.Command {
+	var scatter string
+
+	cmd := &cobra.Command{
+		Use:   "excursion <trades.jsonl|report.json>...",
+		Short: "Summarize MAE/MFE in R and export an R vs MAE scatter CSV",
+		Long: `Measure how far closed trades moved against (MAE) and for (MFE) the
+position before closing, in units of their initial risk (R).
+
+Inputs are trade journals (*.jsonl) or backtest JSON reports (*.json).
+Trades without an initial stop have no R scale and are skipped.
+
+The summary shows the MAE percentiles of winners — "90% of winners never
+went more than 0.40R against" means a stop tighter than 0.40R would have
+cut at least one winner in ten — and the MFE percentiles of losers.
+--scatter writes one row per trade for plotting r_multiple against mae_r.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var trades []journal.TradeRecord
+			for _, path := range args {
+				recs, err := readExcursionTrades(path)
+				if err != nil {
+					return fmt.Errorf("read %s: %w", path, err)
+				}
+				trades = append(trades, recs...)
+			}
+			points, skipped := journal.ExcursionPoints(trades)
+			if scatter != "" {
+				f, err := os.Create(scatter)
+				if err != nil {
+					return err
+				}
+				if err := journal.WriteExcursionCSV(f, points); err != nil {
+					_ = f.Close()
+					return err
+				}
+				if err := f.Close(); err != nil {
+					return err
+				}
+			}
+			st := journal.SummarizeExcursions(points)
+			if rc.JSONOutput() {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(newExcursionJSON(st, skipped))
+			}
+			printExcursion(cmd.OutOrStdout(), st, skipped)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&scatter, "scatter", "", "Write the per-trade R/MAE/MFE scatter CSV to this path")
+	return cmd
+}
+
+// readExcursionTrades loads closed trades from a JSONL journal or a
+// backtest JSON report, chosen by extension.
+func readExcursionTrades(path string) ([]journal.TradeRecord, error) {
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		return journal.ReadTradesJSONL(path)
+	}
+	summary, err := backtestsvc.ReadBacktestSummaryFile(path)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]journal.TradeRecord, 0, len(summary.TradeDetails))
+	for _, t := range summary.TradeDetails {
+		out = append(out, t.TradeRecord())
+	}
+	return out, nil
+}
+
+// excursionJSON is the --output json form of ExcursionStats, in float R.
+type excursionJSON struct {
+	Trades    int                      `json:"trades"`
+	Winners   int                      `json:"winners"`
+	Losers    int                      `json:"losers"`
+	Skipped   int                      `json:"skipped"`
+	AvgR      float64                  `json:"avg_r"`
+	WinnerMAE excursionPercentilesJSON `json:"winner_mae_r"`
+	LoserMFE  excursionPercentilesJSON `json:"loser_mfe_r"`
+}
+
+type excursionPercentilesJSON struct {
+	P50 float64 `json:"p50"`
+	P75 float64 `json:"p75"`
+	P90 float64 `json:"p90"`
+	Max float64 `json:"max"`
+}
+
+func newExcursionJSON(st journal.ExcursionStats, skipped int) excursionJSON {
+	pct := func(p journal.ExcursionPercentiles) excursionPercentilesJSON {
+		return excursionPercentilesJSON{P50: p.P50.Float64(), P75: p.P75.Float64(), P90: p.P90.Float64(), Max: p.Max.Float64()}
+	}
+	return excursionJSON{
+		Trades:    st.Trades,
+		Winners:   st.Winners,
+		Losers:    st.Losers,
+		Skipped:   skipped,
+		AvgR:      st.AvgR.Float64(),
+		WinnerMAE: pct(st.WinnerMAE),
+		LoserMFE:  pct(st.LoserMFE),
+	}
+}
+
+func printExcursion(w io.Writer, st journal.ExcursionStats, skipped int) {
+	fmt.Fprintf(w, "Excursions  %d trades (%d winners, %d losers), avg %+.2fR\n",
+		st.Trades, st.Winners, st.Losers, st.AvgR.Float64())
+	if skipped > 0 {
+		fmt.Fprintf(w, "  %d trades skipped: no initial stop\n", skipped)
+	}
+	fmt.Fprintln(w, strings.Repeat("━", 64))
+	if st.Trades == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "%-12s %7s %7s %7s %7s\n", "", "P50", "P75", "P90", "Max")
+	row := func(label string, n int, p journal.ExcursionPercentiles) {
+		if n == 0 {
+			fmt.Fprintf(w, "%-12s %7s %7s %7s %7s\n", label, "—", "—", "—", "—")
+			return
+		}
+		fmt.Fprintf(w, "%-12s %6.2fR %6.2fR %6.2fR %6.2fR\n",
+			label, p.P50.Float64(), p.P75.Float64(), p.P90.Float64(), p.Max.Float64())
+	}
+	row("Winner MAE", st.Winners, st.WinnerMAE)
+	row("Loser MFE", st.Losers, st.LoserMFE)
+
+	if st.Winners > 0 {
+		fmt.Fprintf(w, "\n90%% of winners never went more than %.2fR against.\n", st.WinnerMAE.P90.Float64())
+	}
+}
@@ -0,0 +1,117 @@
+package analyze
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestExcursionCmd_ReadsJournalAndReport(t *testing.T) {
+	dir := t.TempDir()
+
+	tradesPath := filepath.Join(dir, "run-trades.jsonl")
+	j, err := journal.NewJSON(tradesPath, filepath.Join(dir, "run-equity.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{
+		TradeID:     "j1",
+		Instrument:  "EURUSD",
+		EntryPrice:  types.PriceFromFloat(1.10000),
+		InitialStop: types.PriceFromFloat(1.09800),
+		InitialRisk: types.MoneyFromFloat(20),
+		RMultiple:   types.RateFromFloat(2),
+		MAE:         types.PriceFromFloat(0.0006),
+	}))
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{TradeID: "live", Instrument: "EURUSD"}))
+	require.NoError(t, j.Close())
+
+	reportPath := filepath.Join(dir, "run.json")
+	b, err := json.Marshal(backtest.BacktestReportSummary{TradeDetails: []backtest.BacktestReportTrade{{
+		ID:               "r1",
+		Instrument:       "EURUSD",
+		OpenPrice:        1.1,
+		InitialStopPrice: 1.102,
+		InitialRisk:      20,
+		RMultiple:        -1,
+		MAE:              0.002,
+		MFE:              0.0008,
+	}}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reportPath, b, 0o644))
+
+	scatter := filepath.Join(dir, "scatter.csv")
+	var out bytes.Buffer
+	cmd := New(nil)
+	cmd.SetArgs([]string{"excursion", tradesPath, reportPath, "--scatter", scatter})
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, out.String(), "2 trades (1 winners, 1 losers), avg +0.50R")
+	assert.Contains(t, out.String(), "1 trades skipped: no initial stop")
+	assert.Contains(t, out.String(), "Winner MAE     0.30R   0.30R   0.30R   0.30R")
+	assert.Contains(t, out.String(), "Loser MFE      0.40R   0.40R   0.40R   0.40R")
+	assert.Contains(t, out.String(), "90% of winners never went more than 0.30R against.")
+
+	csv, err := os.ReadFile(scatter)
+	require.NoError(t, err)
+	assert.Equal(t, "trade_id,instrument,r_multiple,mae_r,mfe_r\n"+
+		"j1,EURUSD,2.000000,0.300000,0.000000\n"+
+		"r1,EURUSD,-1.000000,1.000000,0.400000\n", string(csv))
+}
+
+func TestExcursionCmd_MissingFile(t *testing.T) {
+	cmd := New(nil)
+	cmd.SetArgs([]string{"excursion", filepath.Join(t.TempDir(), "nope.json")})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	require.ErrorContains(t, cmd.Execute(), "read ")
+}
+
+func TestPrintExcursion_NoTrades(t *testing.T) {
+	var buf bytes.Buffer
+	printExcursion(&buf, journal.ExcursionStats{}, 3)
+	assert.Contains(t, buf.String(), "0 trades (0 winners, 0 losers)")
+	assert.Contains(t, buf.String(), "3 trades skipped")
+	assert.NotContains(t, buf.String(), "P50")
+}
+
+func TestExcursionCmd_JSONOutput(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath := filepath.Join(dir, "run-trades.jsonl")
+	j, err := journal.NewJSON(tradesPath, filepath.Join(dir, "run-equity.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{
+		TradeID:     "j1",
+		Instrument:  "EURUSD",
+		EntryPrice:  types.PriceFromFloat(1.10000),
+		InitialStop: types.PriceFromFloat(1.09800),
+		InitialRisk: types.MoneyFromFloat(20),
+		RMultiple:   types.RateFromFloat(2),
+		MAE:         types.PriceFromFloat(0.0006),
+	}))
+	require.NoError(t, j.Close())
+
+	var out bytes.Buffer
+	cmd := New(&config.RootConfig{Output: config.OutputJSON})
+	cmd.SetArgs([]string{"excursion", tradesPath})
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	var got excursionJSON
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, 1, got.Trades)
+	assert.Equal(t, 1, got.Winners)
+	assert.InDelta(t, 2.0, got.AvgR, 1e-9)
+	assert.InDelta(t, 0.3, got.WinnerMAE.P90, 1e-9)
+}
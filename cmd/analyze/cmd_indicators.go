@@ -0,0 +1,72 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	datasvc "github.com/rustyeddy/trader/service/data"
+)
+
+func newIndicatorsCmd() *cobra.Command {
+	var (
+		dataPath      string
+		indicatorsCSV string
+		outPath       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "indicators",
+		Short: "Dump streaming indicator values over a candle file as CSV",
+		Long: `Run streaming indicators over a candle CSV file and write their values,
+one row per bar, for checking an indicator against a charting platform.
+
+Each row holds the bar's time (RFC3339, UTC), its open, high, low and close,
+and then the values of every indicator after that bar. A value is blank
+until its indicator is ready. --data takes the store's own candle files, or
+plain time,open,high,low,close[,spread] rows with RFC3339 or Unix-second
+times.
+
+--indicator takes comma-separated specs:
+
+` + datasvc.IndicatorSpecs + `
+
+Without --out the CSV goes to stdout. For example:
+
+  trader analyze indicators --data eurusd-h1.csv --indicator ema:20,ema:50,atr:14 --out values.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var w io.Writer = cmd.OutOrStdout()
+			if outPath != "" {
+				f, err := os.Create(outPath)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			rows, err := (&datasvc.Service{}).DumpIndicators(context.Background(), datasvc.IndicatorDumpRequest{
+				DataPath:   dataPath,
+				Indicators: splitCSV(indicatorsCSV),
+			}, w)
+			if err != nil {
+				return err
+			}
+			if outPath != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d bars to %s\n", rows, outPath)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dataPath, "data", "", "Candle CSV file to run the indicators over")
+	cmd.Flags().StringVar(&indicatorsCSV, "indicator", "", "Comma-separated indicator specs (e.g. ema:20,ema:50,atr:14)")
+	cmd.Flags().StringVar(&outPath, "out", "", "CSV file to write (default: stdout)")
+
+	_ = cmd.MarkFlagRequired("data")
+	_ = cmd.MarkFlagRequired("indicator")
+
+	return cmd
+}
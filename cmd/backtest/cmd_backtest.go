@@ -21,7 +21,9 @@ func init() {
 	CMDBacktest.AddCommand(CMDBacktestGet)
 	CMDBacktest.AddCommand(CMDBacktestOrg)
 	CMDBacktest.AddCommand(CMDBacktestCandles)
+	CMDBacktest.AddCommand(CMDBacktestRolling)
 	CMDBacktest.AddCommand(CMDBacktestConfigs)
+	CMDBacktest.AddCommand(CMDBacktestOptimize)
 }
 
 var CMDBacktest = &cobra.Command{
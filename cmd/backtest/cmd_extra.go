@@ -128,6 +128,68 @@ func runBacktestCandles(cmd *cobra.Command, args []string) error {
 	return w.Error()
 }
 
+// ── backtest rolling ──────────────────────────────────────────────────────
+
+var rollingReportsDir string
+
+var CMDBacktestRolling = &cobra.Command{
+	Use:   "rolling <name>",
+	Short: "Print the rolling-window metrics of a saved backtest result as CSV",
+	Long: `Print the rolling 30- and 90-day win rate, profit factor and drawdown
+recorded in a named backtest report. Output is CSV with columns:
+date,window_days,trades,win_rate,profit_factor,max_drawdown.
+
+There is one row per window for every day on which a trade closed, covering
+the trades closed in the window up to the end of that day. Win rate is a
+percentage; profit factor is 0 for a window without a losing trade.
+
+The name argument is the report filename without the .json extension.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBacktestRolling,
+}
+
+func init() {
+	CMDBacktestRolling.Flags().StringVar(
+		&rollingReportsDir,
+		"dir",
+		"",
+		fmt.Sprintf("Reports directory (default: $TRADER_BACKTEST_DIR/reports or %s/reports)", backtestBaseDir()),
+	)
+}
+
+func runBacktestRolling(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	dir := resolveReportsDir(rollingReportsDir)
+
+	summary, err := backtestsvc.ReadBacktestSummaryByName(dir, name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("report %q not found in %s", name, dir)
+		}
+		return fmt.Errorf("read backtest result: %w", err)
+	}
+	if len(summary.Rolling) == 0 {
+		return fmt.Errorf("report %q has no rolling metrics (no closed trades, or written before they were recorded)", name)
+	}
+
+	w := csv.NewWriter(cmd.OutOrStdout())
+	_ = w.Write([]string{"date", "window_days", "trades", "win_rate", "profit_factor", "max_drawdown"})
+	for _, s := range summary.Rolling {
+		for _, p := range s.Points {
+			_ = w.Write([]string{
+				p.Date,
+				fmt.Sprintf("%d", s.Days),
+				fmt.Sprintf("%d", p.Trades),
+				fmt.Sprintf("%.2f", p.WinRate),
+				fmt.Sprintf("%.4f", p.ProfitFactor),
+				fmt.Sprintf("%.2f", p.MaxDrawdown),
+			})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
 // ── backtest configs ──────────────────────────────────────────────────────
 
 var configsDir string
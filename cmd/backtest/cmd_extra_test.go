@@ -107,3 +107,34 @@ func TestRunBacktestCandles_ReportNotFound(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "not found")
 }
+
+// ── backtest rolling ──────────────────────────────────────────────────────
+
+func TestRunBacktestRolling_PrintsCSV(t *testing.T) {
+	dir := t.TempDir()
+	rollingReportsDir = dir
+	defer func() { rollingReportsDir = "" }()
+
+	report := `{"name":"my-run","rolling":[
+		{"days":30,"points":[{"date":"2024-01-05","trades":2,"win_rate":50,"profit_factor":1.5,"max_drawdown":-20}]},
+		{"days":90,"points":[{"date":"2024-01-05","trades":2,"win_rate":50,"profit_factor":1.5,"max_drawdown":-20}]}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "my-run.json"), []byte(report), 0o644))
+
+	var buf bytes.Buffer
+	CMDBacktestRolling.SetOut(&buf)
+	require.NoError(t, CMDBacktestRolling.RunE(CMDBacktestRolling, []string{"my-run"}))
+	assert.Equal(t, "date,window_days,trades,win_rate,profit_factor,max_drawdown\n"+
+		"2024-01-05,30,2,50.00,1.5000,-20.00\n"+
+		"2024-01-05,90,2,50.00,1.5000,-20.00\n", buf.String())
+}
+
+func TestRunBacktestRolling_NoMetrics(t *testing.T) {
+	dir := t.TempDir()
+	rollingReportsDir = dir
+	defer func() { rollingReportsDir = "" }()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old.json"), []byte(`{"name":"old"}`), 0o644))
+	err := CMDBacktestRolling.RunE(CMDBacktestRolling, []string{"old"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no rolling metrics")
+}
@@ -0,0 +1,137 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/rustyeddy/trader/backtest"
+	backtestsvc "github.com/rustyeddy/trader/service/backtest"
+)
+
+// goldenResult is one run's outcome against its golden report.
+type goldenResult struct {
+	Name   string                `json:"name"`
+	Path   string                `json:"path"`
+	Passed bool                  `json:"passed"`
+	Error  string                `json:"error,omitempty"`
+	Diffs  []backtest.GoldenDiff `json:"diffs,omitempty"`
+}
+
+// goldenPath returns where the golden report for run name lives under
+// spec: spec itself when it names a .json file, else <spec>/<name>.json.
+func goldenPath(spec, name string) string {
+	if strings.EqualFold(filepath.Ext(spec), ".json") {
+		return spec
+	}
+	return filepath.Join(spec, name+".json")
+}
+
+// checkGolden compares each summary with its golden report, or rewrites
+// the golden reports when update is set. It returns an error when any run
+// differs or has no golden report.
+func checkGolden(w io.Writer, spec string, update bool, summaries []backtest.BacktestReportSummary, asJSON bool) error {
+	spec = strings.TrimSpace(spec)
+	if strings.EqualFold(filepath.Ext(spec), ".json") && len(summaries) > 1 {
+		return fmt.Errorf("--golden %s names one file but the config produced %d runs; pass a directory", spec, len(summaries))
+	}
+
+	if update {
+		for _, s := range summaries {
+			path := goldenPath(spec, s.Name)
+			if err := backtest.WriteGoldenReport(path, s); err != nil {
+				return fmt.Errorf("write golden report for %q: %w", s.Name, err)
+			}
+			fmt.Fprintf(w, "  updated  %s\n", path)
+		}
+		return nil
+	}
+
+	results := make([]goldenResult, 0, len(summaries))
+	failed := 0
+	for _, s := range summaries {
+		r := goldenResult{Name: s.Name, Path: goldenPath(spec, s.Name)}
+		golden, err := backtest.ReadGoldenReport(r.Path)
+		if err == nil {
+			r.Diffs, err = backtest.CompareGolden(golden, s)
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		r.Passed = err == nil && len(r.Diffs) == 0
+		if !r.Passed {
+			failed++
+		}
+		results = append(results, r)
+	}
+
+	if asJSON {
+		if err := writeJSON(w, results); err != nil {
+			return err
+		}
+	} else {
+		printGoldenResults(w, results)
+	}
+	if failed > 0 {
+		return fmt.Errorf("golden mismatch in %d of %d runs", failed, len(results))
+	}
+	return nil
+}
+
+// printGoldenResults writes a PASS/FAIL line per run, followed by its
+// differences, in the same layout as backtest regress.
+func printGoldenResults(w io.Writer, results []goldenResult) {
+	for _, r := range results {
+		status := "PASS"
+		if !r.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "  [%s] %s (%s)\n", status, r.Name, r.Path)
+		if r.Error != "" {
+			fmt.Fprintf(w, "         %s\n", r.Error)
+		}
+		for _, d := range r.Diffs {
+			fmt.Fprintf(w, "         %s\n", d)
+		}
+	}
+}
+
+// reportDeterminism prints a PASS/FAIL line per run of a determinism
+// check, followed by the fields that differed between the two passes, and
+// returns an error when any run differed.
+func reportDeterminism(w io.Writer, results []backtestsvc.DeterminismResult, asJSON bool) error {
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+		}
+	}
+	if asJSON {
+		if err := writeJSON(w, results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			status := "PASS"
+			if !r.Passed {
+				status = "FAIL"
+			}
+			fmt.Fprintf(w, "  [%s] %s (%s)\n", status, r.Name, r.ConfigHash)
+			for _, d := range r.Diffs {
+				fmt.Fprintf(w, "         %s: first=%s second=%s\n", d.Path, rawOrMissing(d.Golden), rawOrMissing(d.Got))
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("nondeterministic: %d of %d runs differed between passes", failed, len(results))
+	}
+	return nil
+}
+
+func rawOrMissing(v []byte) string {
+	if len(v) == 0 {
+		return "<missing>"
+	}
+	return string(v)
+}
@@ -0,0 +1,56 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/optimize"
+	backtestsvc "github.com/rustyeddy/trader/service/backtest"
+)
+
+var optimizeOut string
+var optimizeTop int
+
+// CMDBacktestOptimize searches a backtest run's parameter space with
+// random search or successive halving and prints the best trials.
+var CMDBacktestOptimize = &cobra.Command{
+	Use:   "optimize <optimize-config>",
+	Short: "Tune a run's parameters with random search or successive halving",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBacktestOptimize,
+}
+
+func init() {
+	CMDBacktestOptimize.Flags().StringVar(&optimizeOut, "out", "", "Write every trial as JSON to this file")
+	CMDBacktestOptimize.Flags().IntVar(&optimizeTop, "top", 10, "Number of trials to print (0 prints all)")
+	addProfileFlags(CMDBacktestOptimize, &profiling)
+}
+
+func runBacktestOptimize(cmd *cobra.Command, args []string) error {
+	cfg, err := optimize.LoadConfig(args[0])
+	if err != nil {
+		return err
+	}
+	svc := &backtestsvc.Service{Log: l}
+	res, err := optimize.Run(cmd.Context(), cfg, svc.RunBacktest)
+	if err != nil {
+		return err
+	}
+	optimize.PrintResult(os.Stdout, res, optimizeTop)
+
+	if out := strings.TrimSpace(optimizeOut); out != "" {
+		b, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(out, append(b, '\n'), 0o644); err != nil {
+			return fmt.Errorf("write %q: %w", out, err)
+		}
+		l.Info("wrote optimize trials", "path", out)
+	}
+	return nil
+}
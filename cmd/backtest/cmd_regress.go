@@ -41,6 +41,7 @@ func init() {
 		defaultBaselineDir,
 		"Directory containing committed baseline JSON reports",
 	)
+	addProfileFlags(CMDBacktestRegress, &profiling)
 }
 
 // regressResult holds the pass/fail outcome and diff lines for one run.
@@ -1,7 +1,9 @@
 package backtest
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -64,6 +66,13 @@ func runBacktestList(cmd *cobra.Command, args []string) error {
 		filtered = append(filtered, s)
 	}
 
+	if rootCfg.JSONOutput() {
+		if filtered == nil {
+			filtered = []backtest.BacktestReportSummary{}
+		}
+		return writeJSON(out, filtered)
+	}
+
 	if len(filtered) == 0 {
 		fmt.Fprintln(out, "No backtest results found.")
 		return nil
@@ -134,10 +143,20 @@ func runBacktestGet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("read backtest result: %w", err)
 	}
 
+	if rootCfg.JSONOutput() {
+		return writeJSON(cmd.OutOrStdout(), summary)
+	}
 	backtest.PrintSummary(cmd.OutOrStdout(), summary)
 	return nil
 }
 
+// writeJSON writes v as indented JSON for --output json.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
 // resolveReportsDir returns the effective reports directory, honouring an
 // explicit override flag, then the TRADER_BACKTEST_DIR env var, then the
 // default /srv/trading/backtests/reports path.
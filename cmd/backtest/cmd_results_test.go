@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -169,3 +170,24 @@ func TestRunBacktestGet_Found(t *testing.T) {
 	assert.Contains(t, out, "BBFade")
 	assert.Contains(t, out, "EUR_USD")
 }
+
+func TestRunBacktestList_JSONOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, backtest.BacktestReportSummary{Name: "run-a", Instrument: "EURUSD", Trades: 3})
+	listReportsDir = dir
+	rootCfg = &config.RootConfig{Output: config.OutputJSON}
+	defer func() {
+		listReportsDir = ""
+		rootCfg = nil
+	}()
+
+	var buf bytes.Buffer
+	CMDBacktestList.SetOut(&buf)
+	require.NoError(t, CMDBacktestList.RunE(CMDBacktestList, nil))
+
+	var got []backtest.BacktestReportSummary
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	require.Len(t, got, 1)
+	assert.Equal(t, "run-a", got[0].Name)
+	assert.Equal(t, 3, got[0].Trades)
+}
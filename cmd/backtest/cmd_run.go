@@ -1,10 +1,13 @@
 package backtest
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -19,8 +22,9 @@ import (
 //
 // Layout under this directory:
 //
-//	configs/   — YAML backtest configs
-//	reports/   — hash-named JSON + org reports
+//	configs/                  — YAML backtest configs
+//	reports/                  — hash-named JSON + org reports and index.org
+//	reports/<date>/<run-id>/  — each run's own directory (see backtestsvc.RunDir)
 func backtestBaseDir() string {
 	if d := strings.TrimSpace(os.Getenv("TRADER_BACKTEST_DIR")); d != "" {
 		return d
@@ -29,8 +33,15 @@ func backtestBaseDir() string {
 }
 
 var (
-	runConfigPath string
-	runOutDir     string
+	runConfigPath   string
+	runOutDir       string
+	runGolden       string
+	runUpdateGolden bool
+	runNoProgress   bool
+	runCloseOnStop  bool
+	runParallel     int
+	runVerifyDet    bool
+	runVerifyPar    int
 )
 
 // CMDBacktestRun runs one or more backtest configs and writes reports named
@@ -46,8 +57,54 @@ re-running the same config overwrites the same file rather than
 creating a new timestamped copy. Changing any parameter produces a
 distinct file alongside the previous one.
 
+Each run also gets a directory of its own, reports/<date>/<name>-<hash>/,
+dated with the UTC day it ran, holding:
+
+  report.json, report.org  the run's reports
+  trades.csv               its closed trades
+  equity.csv               its daily equity curve
+  equity.svg, drawdown.svg charts of the equity curve and its drawdown
+  price.svg                the bar closes with entries and exits marked,
+                           for runs of up to 5000 bars
+  log.txt                  its log
+  config.yaml              the resolved config (the run and its defaults),
+                           which trader backtest run reruns as is
+
 Config and result directories default to $TRADER_BACKTEST_DIR/{configs,reports}
-(falling back to /srv/trading/backtests/{configs,reports} when the env var is unset).`,
+(falling back to /srv/trading/backtests/{configs,reports} when the env var is unset).
+
+With --golden, each run's trade list and final metrics are then compared
+against a stored golden report, and the command fails with a field-by-field
+diff when anything changed. --golden takes a .json file for a single run,
+or a directory holding <run-name>.json per run. Add --update-golden to
+write the current results as the golden reports instead.
+
+--verify-determinism checks the runner's determinism instead: it runs
+the configs twice, writing no reports, and diffs every run's report
+between the two passes like a golden comparison, failing on any
+difference. The first pass runs --parallel runs at once and the second
+--verify-parallel (default: the same), so a run that depends on what
+else is running shows up too.
+
+--parallel runs that many runs of a sweep at once; reports come out in
+the same order either way.
+
+Progress is drawn as a bar on stderr when it is a terminal, and logged
+every few seconds otherwise; --no-progress turns it off. Progress is off
+when --parallel is above 1.
+
+Ctrl-C stops the run in progress at the next bar and skips the runs after
+it. The interrupted run still writes its reports, marked as interrupted
+and covering the bars replayed so far; its open trades are left open
+unless --close-on-interrupt is given. The command then exits non-zero.
+
+A run whose data section sets download: true first fetches the months
+of its data missing from the store, from OANDA with the token of the
+global config or OANDA_TOKEN, and records them in the data catalog.
+
+--cpuprofile, --memprofile and --trace write a CPU profile, a heap
+profile and an execution trace of the whole command, for go tool pprof
+and go tool trace.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runBacktestRun,
 }
@@ -65,9 +122,61 @@ func init() {
 		"",
 		fmt.Sprintf("Output directory for reports (default: $TRADER_BACKTEST_DIR/reports or %s/reports)", backtestBaseDir()),
 	)
+	CMDBacktestRun.Flags().StringVar(
+		&runGolden,
+		"golden",
+		"",
+		"Golden report file or directory to compare results against; exit 1 on any difference",
+	)
+	CMDBacktestRun.Flags().BoolVar(
+		&runUpdateGolden,
+		"update-golden",
+		false,
+		"With --golden, write current results as the golden reports instead of comparing",
+	)
+	CMDBacktestRun.Flags().BoolVar(
+		&runNoProgress,
+		"no-progress",
+		false,
+		"Do not report progress while runs replay",
+	)
+	CMDBacktestRun.Flags().BoolVar(
+		&runCloseOnStop,
+		"close-on-interrupt",
+		false,
+		"Close open trades when a run is interrupted, as at the end of its data",
+	)
+	CMDBacktestRun.Flags().IntVar(
+		&runParallel,
+		"parallel",
+		1,
+		"How many runs to execute at once",
+	)
+	CMDBacktestRun.Flags().BoolVar(
+		&runVerifyDet,
+		"verify-determinism",
+		false,
+		"Run the configs twice without writing reports and fail if any run's report differs",
+	)
+	CMDBacktestRun.Flags().IntVar(
+		&runVerifyPar,
+		"verify-parallel",
+		0,
+		"With --verify-determinism, runs to execute at once in the second pass (default: --parallel)",
+	)
+	addProfileFlags(CMDBacktestRun, &profiling)
 }
 
 func runBacktestRun(cmd *cobra.Command, args []string) error {
+	if runUpdateGolden && strings.TrimSpace(runGolden) == "" {
+		return fmt.Errorf("--update-golden needs --golden")
+	}
+	if runVerifyDet && strings.TrimSpace(runGolden) != "" {
+		return fmt.Errorf("--verify-determinism and --golden are separate checks; pass one")
+	}
+	if runParallel < 1 || runVerifyPar < 0 {
+		return fmt.Errorf("--parallel must be >= 1 and --verify-parallel >= 0")
+	}
 	base := backtestBaseDir()
 
 	configPath := backtestRunConfigPath(base, args, runConfigPath, rootCfg)
@@ -77,18 +186,48 @@ func runBacktestRun(cmd *cobra.Command, args []string) error {
 		outDir = filepath.Join(base, "reports")
 	}
 
-	svc := &backtestsvc.Service{Log: l}
-	summaries, err := svc.RunBacktestPathSpecsAndWriteReports(cmd.Context(), []string{configPath}, outDir)
+	parent := cmd.Context()
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	svc := &backtestsvc.Service{Log: l, RunDirs: outDir, CloseOnInterrupt: runCloseOnStop, Parallel: runParallel,
+		CandleProviders: candleProviders(rootCfg)}
+	if !runNoProgress && runParallel == 1 {
+		svc.Progress = newProgressPrinter(os.Stderr).Report
+	}
+	if runVerifyDet {
+		second := runVerifyPar
+		if second == 0 {
+			second = runParallel
+		}
+		results, err := svc.VerifyDeterminism(ctx, []string{configPath}, second)
+		if err != nil {
+			return err
+		}
+		return reportDeterminism(cmd.OutOrStdout(), results, rootCfg != nil && rootCfg.JSONOutput())
+	}
+	summaries, err := svc.RunBacktestPathSpecsAndWriteReports(ctx, []string{configPath}, outDir)
 	if err != nil {
 		return err
 	}
 
 	for _, summary := range summaries {
 		backtest.PrintSummary(os.Stdout, summary)
-		l.Info("wrote reports", "name", summary.Name, "config_hash", summary.ConfigHash, "dir", outDir)
+		l.Info("wrote reports", "name", summary.Name, "config_hash", summary.ConfigHash, "dir", outDir, "run_dir", summary.RunDir)
+		fmt.Fprintf(os.Stdout, "Run directory: %s\n", filepath.Join(outDir, summary.RunDir))
 	}
 
 	fmt.Fprintf(os.Stdout, "\nOutput directory: %s\n", outDir)
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted: partial reports written to %s", outDir)
+	}
+	if strings.TrimSpace(runGolden) != "" {
+		return checkGolden(cmd.OutOrStdout(), runGolden, runUpdateGolden, summaries, rootCfg != nil && rootCfg.JSONOutput())
+	}
 	return nil
 }
 
@@ -1,11 +1,15 @@
 package backtest
 
 import (
+	"bytes"
 	"path/filepath"
 	"testing"
 
+	"github.com/rustyeddy/trader/backtest"
 	"github.com/rustyeddy/trader/config"
+	backtestsvc "github.com/rustyeddy/trader/service/backtest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBacktestRunConfigPathPrecedence(t *testing.T) {
@@ -21,3 +25,59 @@ func TestBacktestRunConfigPathPrecedence(t *testing.T) {
 	assert.Equal(t, filepath.Join(base, "configs"),
 		backtestRunConfigPath(base, nil, "", nil))
 }
+
+func TestGoldenPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("golden", "one.json"), goldenPath(filepath.Join("golden", "one.json"), "ignored"))
+	assert.Equal(t, filepath.Join("golden", "run-a.json"), goldenPath("golden", "run-a"))
+}
+
+func TestCheckGolden_UpdateThenCompare(t *testing.T) {
+	dir := t.TempDir()
+	summaries := []backtest.BacktestReportSummary{
+		{Name: "run-a", Trades: 2, NetPL: 10},
+		{Name: "run-b", Trades: 1, NetPL: -4},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, checkGolden(&out, dir, true, summaries, false))
+	assert.Contains(t, out.String(), filepath.Join(dir, "run-a.json"))
+
+	out.Reset()
+	require.NoError(t, checkGolden(&out, dir, false, summaries, false))
+	assert.Contains(t, out.String(), "[PASS] run-a")
+
+	summaries[1].NetPL = -5
+	out.Reset()
+	err := checkGolden(&out, dir, false, summaries, false)
+	require.Error(t, err)
+	assert.Contains(t, out.String(), "[FAIL] run-b")
+	assert.Contains(t, out.String(), "net_pl: golden=-4 got=-5")
+
+	err = checkGolden(&out, filepath.Join(dir, "one.json"), false, summaries, false)
+	require.ErrorContains(t, err, "pass a directory")
+}
+
+func TestReportDeterminism(t *testing.T) {
+	results := []backtestsvc.DeterminismResult{
+		{Name: "ok", ConfigHash: "abc", Passed: true},
+		{Name: "drift", ConfigHash: "def", Diffs: []backtest.GoldenDiff{
+			{Path: "net_pl", Golden: []byte("12.5"), Got: []byte("13")},
+			{Path: "trade_details[2]", Got: []byte(`{"units":1}`)},
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, reportDeterminism(&buf, results[:1], false))
+	assert.Equal(t, "  [PASS] ok (abc)\n", buf.String())
+
+	buf.Reset()
+	err := reportDeterminism(&buf, results, false)
+	require.EqualError(t, err, "nondeterministic: 1 of 2 runs differed between passes")
+	assert.Contains(t, buf.String(), "  [FAIL] drift (def)\n")
+	assert.Contains(t, buf.String(), "         net_pl: first=12.5 second=13\n")
+	assert.Contains(t, buf.String(), `trade_details[2]: first=<missing> second={"units":1}`)
+
+	buf.Reset()
+	require.Error(t, reportDeterminism(&buf, results, true))
+	assert.Contains(t, buf.String(), `"path": "net_pl"`)
+}
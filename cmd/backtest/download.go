@@ -0,0 +1,45 @@
+package backtest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/datamanager"
+	oandaprovider "github.com/rustyeddy/trader/datamanager/oanda"
+)
+
+// candleProviders returns the providers runs with data.download set fetch
+// their data from. The OANDA client is only built when a run asks for it,
+// with the token and environment of the global config, else OANDA_TOKEN
+// and practice.
+func candleProviders(rc *config.RootConfig) backtest.CandleProviderFunc {
+	var (
+		once     sync.Once
+		provider datamanager.CandleProvider
+		err      error
+	)
+	return func(source string) (datamanager.CandleProvider, error) {
+		if !strings.EqualFold(strings.TrimSpace(source), oandaprovider.SourceName) {
+			return nil, fmt.Errorf("no candle provider for source %q (only %s can be downloaded)", source, oandaprovider.SourceName)
+		}
+		once.Do(func() {
+			tok, env := os.Getenv("OANDA_TOKEN"), "practice"
+			if rc != nil && rc.OANDA.Token != "" {
+				tok = rc.OANDA.Token
+			}
+			if rc != nil && rc.OANDA.Env != "" {
+				env = rc.OANDA.Env
+			}
+			var client *oanda.Client
+			if client, err = oanda.NewClient(env, tok); err == nil {
+				provider = oandaprovider.New(client)
+			}
+		})
+		return provider, err
+	}
+}
@@ -0,0 +1,103 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// profileFlags holds the --cpuprofile, --memprofile and --trace paths of
+// the long-running backtest commands. Empty paths are off.
+type profileFlags struct {
+	cpu   string
+	mem   string
+	trace string
+}
+
+var profiling profileFlags
+
+// addProfileFlags registers the profiling flags on cmd and wraps its RunE
+// so the whole command runs under them.
+func addProfileFlags(cmd *cobra.Command, p *profileFlags) {
+	cmd.Flags().StringVar(&p.cpu, "cpuprofile", "", "Write a pprof CPU profile of the run to this file")
+	cmd.Flags().StringVar(&p.mem, "memprofile", "", "Write a pprof heap profile to this file when the run ends")
+	cmd.Flags().StringVar(&p.trace, "trace", "", "Write a runtime execution trace of the run to this file")
+
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) (err error) {
+		stop, err := p.start()
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, stop()) }()
+		return run(cmd, args)
+	}
+}
+
+// start begins CPU profiling and tracing as configured. The returned stop
+// function ends them, writes the heap profile, and reports the first
+// error; it must be called even when the run fails.
+func (p *profileFlags) start() (stop func() error, err error) {
+	var stops []func() error
+	stop = func() error {
+		var errs []error
+		for i := len(stops) - 1; i >= 0; i-- {
+			errs = append(errs, stops[i]())
+		}
+		return errors.Join(errs...)
+	}
+
+	if path := strings.TrimSpace(p.cpu); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("cpuprofile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("cpuprofile: %w", err)
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+
+	if path := strings.TrimSpace(p.trace); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			stop()
+			return nil, fmt.Errorf("trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			stop()
+			return nil, fmt.Errorf("trace: %w", err)
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return f.Close()
+		})
+	}
+
+	if path := strings.TrimSpace(p.mem); path != "" {
+		stops = append(stops, func() error {
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("memprofile: %w", err)
+			}
+			defer f.Close()
+			runtime.GC() // materialise up-to-date allocation statistics
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				return fmt.Errorf("memprofile: %w", err)
+			}
+			return nil
+		})
+	}
+	return stop, nil
+}
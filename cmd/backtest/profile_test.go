@@ -0,0 +1,59 @@
+package backtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddProfileFlags_WritesProfiles(t *testing.T) {
+	dir := t.TempDir()
+	var p profileFlags
+	ran := false
+	cmd := &cobra.Command{
+		Use: "x",
+		RunE: func(*cobra.Command, []string) error {
+			ran = true
+			return errors.New("run failed")
+		},
+	}
+	addProfileFlags(cmd, &p)
+	cmd.SetArgs([]string{
+		"--cpuprofile", filepath.Join(dir, "cpu.pprof"),
+		"--memprofile", filepath.Join(dir, "mem.pprof"),
+		"--trace", filepath.Join(dir, "run.trace"),
+	})
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+
+	err := cmd.Execute()
+	require.ErrorContains(t, err, "run failed")
+	assert.True(t, ran)
+	for _, name := range []string{"cpu.pprof", "mem.pprof", "run.trace"} {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+		assert.NotZero(t, fi.Size(), "%s is written even when the run fails", name)
+	}
+}
+
+func TestProfileFlags_StartErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "no-such-dir", "out")
+
+	_, err := (&profileFlags{cpu: missing}).start()
+	require.ErrorContains(t, err, "cpuprofile")
+
+	_, err = (&profileFlags{trace: missing}).start()
+	require.ErrorContains(t, err, "trace")
+
+	stop, err := (&profileFlags{mem: missing}).start()
+	require.NoError(t, err, "the heap profile is only written at stop")
+	require.ErrorContains(t, stop(), "memprofile")
+
+	stop, err = (&profileFlags{}).start()
+	require.NoError(t, err)
+	assert.NoError(t, stop())
+}
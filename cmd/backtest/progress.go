@@ -0,0 +1,81 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/backtest"
+)
+
+const (
+	progressBarWidth = 30
+	progressLogEvery = 10 * time.Second
+)
+
+// progressPrinter renders backtest progress for the CLI: a bar redrawn in
+// place when the output is a terminal, otherwise a log line every
+// progressLogEvery so redirected output stays readable.
+type progressPrinter struct {
+	w       io.Writer
+	tty     bool
+	now     func() time.Time
+	lastLog time.Time
+}
+
+func newProgressPrinter(w io.Writer) *progressPrinter {
+	return &progressPrinter{w: w, tty: isTerminal(w), now: time.Now}
+}
+
+// Report is a backtest.ProgressFunc.
+func (pp *progressPrinter) Report(p backtest.Progress) {
+	if pp.tty {
+		fmt.Fprintf(pp.w, "\r%s", progressLine(p))
+		if p.Done {
+			fmt.Fprintln(pp.w)
+		}
+		return
+	}
+	if p.Done {
+		return // the runner logs "backtest finished"
+	}
+	now := pp.now()
+	if !pp.lastLog.IsZero() && now.Sub(pp.lastLog) < progressLogEvery {
+		return
+	}
+	pp.lastLog = now
+	l.Info("backtest progress",
+		"run", p.Run,
+		"bars", p.Bars,
+		"pct", fmt.Sprintf("%.1f", p.Fraction()*100),
+		"sim_time", p.SimTime.String(),
+		"eta", p.ETA().Round(time.Second).String())
+}
+
+// progressLine formats p as one line of a progress bar.
+func progressLine(p backtest.Progress) string {
+	f := p.Fraction()
+	filled := int(f * progressBarWidth)
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+	line := fmt.Sprintf("  %s [%s] %5.1f%%  %s  bars %d", p.Run, bar, f*100,
+		p.SimTime.Time().UTC().Format("2006-01-02 15:04"), p.Bars)
+	if p.Done {
+		return line + fmt.Sprintf("  done in %s", p.Elapsed.Round(time.Second))
+	}
+	if eta := p.ETA(); eta > 0 {
+		line += fmt.Sprintf("  eta %s", eta.Round(time.Second))
+	}
+	return line + "   " // overwrite leftovers from a longer previous line
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
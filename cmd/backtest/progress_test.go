@@ -0,0 +1,51 @@
+package backtest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestProgressLine(t *testing.T) {
+	from := types.FromTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	to := types.FromTime(time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC))
+	p := backtest.Progress{
+		Run: "ema", Bars: 120, From: from, To: to,
+		SimTime: types.FromTime(time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)),
+		Elapsed: 20 * time.Second,
+	}
+
+	line := progressLine(p)
+	assert.Contains(t, line, "ema [###############---------------]  50.0%")
+	assert.Contains(t, line, "2024-01-06 00:00")
+	assert.Contains(t, line, "bars 120")
+	assert.Contains(t, line, "eta 20s")
+
+	p.Done = true
+	assert.Contains(t, progressLine(p), "100.0%")
+	assert.Contains(t, progressLine(p), "done in 20s")
+}
+
+func TestProgressPrinter_TTYRedrawsInPlace(t *testing.T) {
+	var buf bytes.Buffer
+	pp := &progressPrinter{w: &buf, tty: true, now: time.Now}
+	pp.Report(backtest.Progress{Run: "a", From: 0, To: 10, SimTime: 5})
+	pp.Report(backtest.Progress{Run: "a", From: 0, To: 10, SimTime: 10, Done: true})
+
+	out := buf.String()
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("\r")))
+	assert.True(t, out[len(out)-1] == '\n', "final report ends the line")
+}
+
+func TestProgressPrinter_NotTTYWritesNothingToOutput(t *testing.T) {
+	var buf bytes.Buffer
+	pp := newProgressPrinter(&buf)
+	assert.False(t, pp.tty)
+	pp.Report(backtest.Progress{Run: "a", From: 0, To: 10, SimTime: 5})
+	assert.Empty(t, buf.String(), "non-terminal progress goes to the log")
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/rustyeddy/trader/config"
 	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/log"
+	"github.com/rustyeddy/trader/market"
 	accountsvc "github.com/rustyeddy/trader/service/account"
 	botsvc "github.com/rustyeddy/trader/service/bots"
 	"github.com/rustyeddy/trader/strategy"
@@ -237,10 +238,10 @@ func printBotPL(out io.Writer, botID string, trades []journal.TradeRecord) {
 		} else {
 			losses++
 		}
-		fmt.Fprintf(out, "    %-8s  entry=%.5f  exit=%.5f  pl=%+.2f  %s\n",
+		fmt.Fprintf(out, "    %-8s  entry=%s  exit=%s  pl=%+.2f  %s\n",
 			t.TradeID,
-			t.EntryPrice.Float64(),
-			t.ExitPrice.Float64(),
+			market.FormatPrice(t.Instrument, t.EntryPrice.Float64()),
+			market.FormatPrice(t.Instrument, t.ExitPrice.Float64()),
 			pl,
 			t.Reason,
 		)
@@ -432,6 +433,7 @@ func startFromConfig(cmd *cobra.Command, configFile string) error {
 				Regime:          regimeCfg,
 				WarmupBars:      inst.WarmupBars,
 				LocalWarmupBars: localWarmup,
+				StatePath:       cfg.StatePath(inst.Instrument, inst.Strategy.Kind),
 			},
 		}
 		if err := startOne(cmd, bc); err != nil {
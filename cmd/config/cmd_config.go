@@ -0,0 +1,145 @@
+// Package config hosts the `trader config` CLI commands for maintaining
+// backtest config files. Schema knowledge lives in backtest/; this package
+// reads and writes files and reports what changed.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/backtest"
+	rootconfig "github.com/rustyeddy/trader/config"
+)
+
+// New returns the top-level "config" cobra command.
+func New(rc *rootconfig.RootConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Maintain backtest config files",
+	}
+	cmd.AddCommand(newMigrateCmd())
+	cmd.AddCommand(newValidateCmd(rc))
+	return cmd
+}
+
+func newMigrateCmd() *cobra.Command {
+	var in, out string
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade a backtest config to the current schema version",
+		Long: fmt.Sprintf(`Rewrite a backtest config written for an older schema version as version %d.
+
+Comments and key order are kept. The result is YAML even when the input is
+JSON. --out may name the input file to upgrade it in place; without --out the
+result goes to stdout. Each change is listed on stderr.
+
+Older files still load without migrating, so this is only needed to silence
+the upgrade notice or to edit a file in the current layout.`, backtest.ConfigVersion),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := os.ReadFile(in)
+			if err != nil {
+				return err
+			}
+			migrated, from, notes, err := backtest.MigrateConfig(b)
+			if err != nil {
+				return fmt.Errorf("migrate %s: %w", in, err)
+			}
+
+			errOut := cmd.ErrOrStderr()
+			if from == backtest.ConfigVersion {
+				fmt.Fprintf(errOut, "%s is already at version %d\n", in, from)
+			}
+			for _, n := range notes {
+				fmt.Fprintf(errOut, "  %s\n", n)
+			}
+
+			if strings.TrimSpace(out) == "" {
+				_, err := cmd.OutOrStdout().Write(migrated)
+				return err
+			}
+			return os.WriteFile(out, migrated, 0o644)
+		},
+	}
+
+	cmd.Flags().StringVar(&in, "in", "", "Config file to upgrade (YAML or JSON)")
+	cmd.Flags().StringVar(&out, "out", "", "Where to write the upgraded YAML (default: stdout)")
+	_ = cmd.MarkFlagRequired("in")
+	return cmd
+}
+
+// validateResult is one file's outcome, and the --output json row.
+type validateResult struct {
+	Path    string   `json:"path"`
+	OK      bool     `json:"ok"`
+	Version int      `json:"version,omitempty"`
+	Runs    []string `json:"runs,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func newValidateCmd(rc *rootconfig.RootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate <config>...",
+		Short: "Check that backtest configs load and compile",
+		Long: `Load each backtest config, upgrading older schema versions in memory, and
+compile its runs (strategy, exit, regime, and default settings) without
+fetching data or running anything. Exits non-zero if any file is invalid.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := make([]validateResult, 0, len(args))
+			bad := 0
+			for _, path := range args {
+				res := validateConfig(path)
+				if !res.OK {
+					bad++
+				}
+				results = append(results, res)
+			}
+
+			out := cmd.OutOrStdout()
+			if rc.JSONOutput() {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(results); err != nil {
+					return err
+				}
+			} else {
+				for _, res := range results {
+					if res.OK {
+						fmt.Fprintf(out, "ok    %s (version %d, %d runs)\n", res.Path, res.Version, len(res.Runs))
+					} else {
+						fmt.Fprintf(out, "FAIL  %s: %s\n", res.Path, res.Error)
+					}
+				}
+			}
+			if bad > 0 {
+				return fmt.Errorf("%d of %d configs invalid", bad, len(results))
+			}
+			return nil
+		},
+	}
+}
+
+func validateConfig(path string) validateResult {
+	res := validateResult{Path: path}
+	cfg, err := backtest.LoadConfig(path)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Version = cfg.Version
+	compiled, err := backtest.CompileBacktests(cfg)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	for _, c := range compiled {
+		res.Runs = append(res.Runs, c.RunConfig.Name)
+	}
+	res.OK = true
+	return res
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rootconfig "github.com/rustyeddy/trader/config"
+	_ "github.com/rustyeddy/trader/strategies/noop"
+)
+
+func TestMigrateCmd_WritesUpgradedConfig(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "old.yaml")
+	out := filepath.Join(dir, "new.yaml")
+	require.NoError(t, os.WriteFile(in, []byte("defaults:\n  slippage-pips: 0.5\nruns: []\n"), 0o644))
+
+	cmd := New(nil)
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{"migrate", "--in", in, "--out", out})
+	require.NoError(t, cmd.Execute())
+
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "version: 2\ndefaults:\n  execution:\n    slippage-pips: 0.5\nruns: []\n", string(b))
+	assert.Contains(t, stderr.String(), "moved defaults.slippage-pips to defaults.execution.slippage-pips")
+}
+
+func TestMigrateCmd_StdoutWhenNoOut(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "cur.yaml")
+	require.NoError(t, os.WriteFile(in, []byte("version: 2\nruns: []\n"), 0o644))
+
+	cmd := New(nil)
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{"migrate", "--in", in})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "version: 2\nruns: []\n", stdout.String())
+	assert.Contains(t, stderr.String(), "already at version 2")
+}
+
+const validConfig = `version: 2
+runs:
+  - name: eurusd-noop
+    data:
+      instrument: EURUSD
+      timeframe: H1
+      from: "2026-01-01"
+      to: "2026-01-31"
+    strategy:
+      kind: noop
+`
+
+func TestValidateCmd_Text(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	bad := filepath.Join(dir, "bad.yaml")
+	require.NoError(t, os.WriteFile(good, []byte(validConfig), 0o644))
+	require.NoError(t, os.WriteFile(bad, []byte(strings.Replace(validConfig, "kind: noop", "kind: no-such-strategy", 1)), 0o644))
+
+	cmd := New(&rootconfig.RootConfig{Output: rootconfig.OutputText})
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"validate", good, bad})
+	require.ErrorContains(t, cmd.Execute(), "1 of 2 configs invalid")
+
+	assert.Contains(t, stdout.String(), "ok    "+good+" (version 2, 1 runs)")
+	assert.Contains(t, stdout.String(), "FAIL  "+bad+": ")
+}
+
+func TestValidateCmd_JSON(t *testing.T) {
+	good := filepath.Join(t.TempDir(), "good.yaml")
+	require.NoError(t, os.WriteFile(good, []byte(validConfig), 0o644))
+
+	cmd := New(&rootconfig.RootConfig{Output: rootconfig.OutputJSON})
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{"validate", good})
+	require.NoError(t, cmd.Execute())
+
+	var got []validateResult
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &got))
+	assert.Equal(t, []validateResult{{Path: good, OK: true, Version: 2, Runs: []string{"eurusd-noop"}}}, got)
+}
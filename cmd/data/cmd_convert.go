@@ -0,0 +1,116 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/config"
+	datasvc "github.com/rustyeddy/trader/service/data"
+)
+
+func newConvertCmd(rc *config.RootConfig) *cobra.Command {
+	var (
+		outPath    string
+		dialect    string
+		instrument string
+		timeframe  string
+		sourceTZ   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "convert <file>",
+		Short: "Convert a vendor CSV (Dukascopy, OANDA ticks, MT4) to the canonical format",
+		Long: `Read a price file in one of the common vendor CSV dialects and write it
+in the trader's canonical form, with timestamps normalized to UTC:
+
+  dukascopy    semicolon bars   20200102 170000;1.12120;1.12130;1.12110;1.12125;0
+  mt4          MetaTrader bars  2020.01.02,17:00,1.12120,1.12130,1.12110,1.12125,0
+  oanda-ticks  tick CSV with a time,[instrument,]bid,ask header
+
+Bars become the canonical candle format; ticks become the replay format
+(time,instrument,bid,ask) read by "trader replay", headed by a metadata line
+naming the source, dialect and (when the input has no instrument column)
+the instrument, which replay's --expect-* flags check. The dialect is detected
+from the first line unless --dialect is given. Instrument and bar timeframe
+are taken from the file name (e.g. DAT_MT_EURUSD_M1_2020.csv) when not
+given as flags.
+
+Bar files are assumed to be stamped in EST with no daylight saving, the
+vendor convention. Use --source-tz America/New_York for exports taken on a
+DST-observing clock, or --source-tz UTC for GMT exports.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := datasvc.ParseConvertDialect(dialect)
+			if err != nil {
+				return err
+			}
+
+			in, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+
+			// The converted data goes to stdout unless --out is set, in
+			// which case stdout carries the summary instead.
+			out, summary := cmd.OutOrStdout(), cmd.ErrOrStderr()
+			var f *os.File
+			if outPath != "" {
+				if f, err = os.Create(outPath); err != nil {
+					return err
+				}
+				defer f.Close()
+				out, summary = f, cmd.OutOrStdout()
+			}
+
+			res, err := (&datasvc.Service{}).Convert(cmd.Context(), datasvc.ConvertRequest{
+				Name:       args[0],
+				Input:      in,
+				Output:     out,
+				Dialect:    d,
+				Instrument: instrument,
+				Timeframe:  timeframe,
+				SourceZone: sourceTZ,
+			})
+			if err != nil {
+				return fmt.Errorf("convert %s: %w", args[0], err)
+			}
+			if f != nil {
+				if err := f.Close(); err != nil {
+					return err
+				}
+			}
+			return printConvertResult(summary, res, rc.JSONOutput())
+		},
+	}
+
+	cmd.Flags().StringVar(&outPath, "out", "", "Output path (default: stdout)")
+	cmd.Flags().StringVar(&dialect, "dialect", "auto", "Input dialect: auto, dukascopy, oanda-ticks, or mt4")
+	cmd.Flags().StringVar(&instrument, "instrument", "", "Instrument, when the file name or rows don't carry it")
+	cmd.Flags().StringVar(&timeframe, "timeframe", "", "Bar timeframe (M1, H1, H4, D1), when the file name doesn't carry it")
+	cmd.Flags().StringVar(&sourceTZ, "source-tz", "EST", "Zone bar times are stamped in: EST (fixed UTC-5), UTC, or an IANA name such as America/New_York")
+	_ = cmd.RegisterFlagCompletionFunc("dialect", cobra.FixedCompletions(
+		[]string{"auto", "dukascopy", "oanda-ticks", "mt4"}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func printConvertResult(w io.Writer, res *datasvc.ConvertResult, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	what := res.Format
+	if res.Timeframe != "" {
+		what = res.Timeframe + " " + what
+	}
+	if res.Instrument != "" {
+		what += " for " + res.Instrument
+	}
+	_, err := fmt.Fprintf(w, "Converted %d %s rows to %s\n", res.Rows, res.Dialect, what)
+	return err
+}
@@ -22,6 +22,9 @@ func New(rc *config.RootConfig) *cobra.Command {
 		newStatsCmd(rc),
 		newPipValueCmd(rc),
 		newPositionCmd(rc),
+		newConvertCmd(rc),
+		newImportTicksCmd(rc),
+		newPackCandlesCmd(),
 	)
 
 	return cmd
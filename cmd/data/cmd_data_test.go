@@ -2,9 +2,16 @@ package data
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,7 +27,8 @@ func TestNew_HasExpectedSubcommands(t *testing.T) {
 	for _, want := range []string{
 		"sync", "download-ticks", "build-candles",
 		"oanda", "update", "candles", "stats",
-		"pip-value", "position", "validate-candles",
+		"pip-value", "position", "validate-candles", "convert",
+		"import-ticks", "pack-candles",
 	} {
 		assert.True(t, names[want], "expected subcommand %q", want)
 	}
@@ -80,3 +88,97 @@ func TestUpdateCmd_DryRun_BadFromReturnsError(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "--from")
 }
+
+// ── oanda sync ────────────────────────────────────────────────────────────────
+
+func TestOandaSyncCmd_StatusPrintsCatalog(t *testing.T) {
+	datamanager.UseTempDataDir(t)
+
+	cmd := New(&config.RootConfig{})
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"oanda", "sync", "--status"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "No syncs recorded.")
+}
+
+// ── convert ───────────────────────────────────────────────────────────────────
+
+func TestConvertCmd_WritesCanonicalFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "DAT_MT_EURUSD_M1_2020.csv")
+	require.NoError(t, os.WriteFile(in, []byte("2020.01.02,17:00,1.12120,1.12130,1.12110,1.12125,0\n"), 0o644))
+	out := filepath.Join(dir, "eurusd-m1.csv")
+
+	cmd := New(&config.RootConfig{})
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"convert", in, "--out", out})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "Converted 1 mt4 rows to m1 candles for EURUSD\n", buf.String())
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "# schema=candle-v2 source=mt4 instrument=EURUSD tf=m1")
+}
+
+func TestConvertCmd_JSONSummaryAndBadDialect(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(in, []byte("time,instrument,bid,ask\n2026-01-24T09:30:00Z,EUR_USD,1.1,1.1002\n"), 0o644))
+
+	cmd := New(&config.RootConfig{Output: config.OutputJSON})
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"convert", in, "--out", filepath.Join(dir, "out.csv")})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), `"format": "ticks"`)
+
+	cmd = New(&config.RootConfig{})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"convert", in, "--dialect", "metastock"})
+	require.ErrorContains(t, cmd.Execute(), "unknown dialect")
+}
+
+func TestConvertCmd_SourceTZ(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "DAT_MT_EURUSD_H1_2020.csv")
+	require.NoError(t, os.WriteFile(in, []byte("2020.07.01,17:00,1.12120,1.12130,1.12110,1.12125,0\n"), 0o644))
+
+	cmd := New(&config.RootConfig{})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"convert", in, "--source-tz", "America/New_York"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "\n1593637200,112120,") // 2020-07-01 21:00 UTC
+
+	cmd = New(&config.RootConfig{})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"convert", in, "--source-tz", "Nowhere/Special"})
+	require.ErrorContains(t, cmd.Execute(), "bad source zone")
+}
+
+// ── pack-candles ──────────────────────────────────────────────────────────────
+
+func TestPackCandlesCmd_PacksTheSelectedMonths(t *testing.T) {
+	datamanager.UseTempDataDir(t)
+	june := time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+	bar := []market.Candle{{Open: 110000, High: 110050, Low: 109950, Close: 110020, Ticks: 1, Timestamp: types.FromTime(june)}}
+	datamanager.WriteCandles(t, "candles", "EURUSD", types.H1, june, bar)
+	datamanager.WriteCandles(t, "candles", "USDJPY", types.H1, june, bar)
+
+	cmd := New(&config.RootConfig{})
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"pack-candles", "--instruments", "EUR_USD"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "Packed 1 candle months")
+
+	csvPath := datamanager.PathForMonthlyCandle(datamanager.Key{Instrument: "EURUSD", Source: "candles",
+		Kind: datamanager.KindCandle, TF: types.H1, Year: 2020, Month: 6})
+	_, err := os.Stat(strings.TrimSuffix(csvPath, ".csv") + ".bin")
+	assert.NoError(t, err)
+}
@@ -81,5 +81,6 @@ func newOandaDownloadCmd(rc *config.RootConfig) *cobra.Command {
 	_ = cmd.MarkFlagRequired("from")
 	_ = cmd.MarkFlagRequired("to")
 
+	cmd.AddCommand(newOandaSyncCmd(rc))
 	return cmd
 }
@@ -0,0 +1,144 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/datamanager"
+	datasvc "github.com/rustyeddy/trader/service/data"
+)
+
+func newOandaSyncCmd(rc *config.RootConfig) *cobra.Command {
+	var (
+		instrumentsCSV string
+		timeframesCSV  string
+		fromStr        string
+		token          string
+		env            string
+		rawDir         string
+		status         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Append newly completed OANDA candles to the local archive",
+		Long: `Bring the local candle archive up to date with OANDA.
+
+For every instrument and timeframe the newest stored candle is found, and
+only the candles that have completed since then are fetched and appended.
+Stored candles are never rewritten, each month file is replaced atomically,
+and the outcome of every pair is recorded in the data catalog
+(catalog.json at the root of the data directory). Running it again with
+nothing new does nothing, so it is safe to run from cron at any interval.
+
+A pair with no stored data is an error unless --from gives a seed date.
+The command exits non-zero when any pair failed.
+
+Examples:
+  trader data oanda sync
+  trader data oanda sync --instruments EUR_USD,GBP_USD --timeframes H1,D
+  trader data oanda sync --instruments XAU_USD --timeframes H1 --from 2024-01-01
+  trader data oanda sync --status`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_ = rc
+			out := cmd.OutOrStdout()
+
+			if status {
+				recs, err := datamanager.SyncCatalog()
+				if err != nil {
+					return err
+				}
+				printSyncCatalog(out, recs)
+				return nil
+			}
+
+			instruments := splitCSV(instrumentsCSV)
+			if len(instruments) == 0 {
+				instruments = defaultInstruments
+			}
+			timeframes := splitCSV(timeframesCSV)
+			if len(timeframes) == 0 {
+				timeframes = defaultTimeframes
+			}
+
+			var seedFrom time.Time
+			if fromStr != "" {
+				var err error
+				seedFrom, err = time.Parse("2006-01-02", fromStr)
+				if err != nil {
+					return fmt.Errorf("bad --from %q: %w", fromStr, err)
+				}
+			}
+
+			client, err := oanda.NewClient(env, token)
+			if err != nil {
+				return err
+			}
+
+			parent := cmd.Context()
+			if parent == nil {
+				parent = context.Background()
+			}
+			ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			result, err := (&datasvc.Service{OANDA: client}).SyncOandaCandles(ctx, datasvc.SyncOandaCandlesRequest{
+				Instruments: instruments,
+				Timeframes:  timeframes,
+				SeedFrom:    seedFrom,
+				RawDir:      rawDir,
+				OnProgress:  func(msg string) { fmt.Fprintln(out, msg) },
+			})
+			if err != nil {
+				return err
+			}
+
+			var appended int
+			for _, r := range result.Results {
+				appended += r.Appended
+			}
+			failed := result.Failed()
+			fmt.Fprintf(out, "\nDone. %d candles appended across %d pairs. %d errors.\n",
+				appended, len(result.Results), failed)
+			if failed > 0 {
+				return fmt.Errorf("sync: %d of %d pairs failed", failed, len(result.Results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&instrumentsCSV, "instruments", "", "Comma-separated instruments to sync (default: all 24)")
+	cmd.Flags().StringVar(&timeframesCSV, "timeframes", "", "Comma-separated timeframes (default: M1,H1,H4,D)")
+	cmd.Flags().StringVar(&fromStr, "from", "", "Seed start date YYYY-MM-DD for pairs with no stored data")
+	cmd.Flags().StringVar(&token, "token", os.Getenv("OANDA_TOKEN"), "OANDA API token")
+	cmd.Flags().StringVar(&env, "env", "practice", "OANDA environment: practice|live")
+	cmd.Flags().StringVar(&rawDir, "raw-dir", "/srv/trading/data/raw", "Root for raw bid+ask preservation")
+	cmd.Flags().BoolVar(&status, "status", false, "Print the sync state recorded in the data catalog and exit")
+	return cmd
+}
+
+func printSyncCatalog(w io.Writer, recs []datamanager.SyncRecord) {
+	if len(recs) == 0 {
+		fmt.Fprintln(w, "No syncs recorded.")
+		return
+	}
+	fmt.Fprintf(w, "%-8s  %-10s  %-4s  %-20s  %-20s  %8s  %s\n",
+		"Source", "Instrument", "TF", "Last candle", "Last sync", "Appended", "Error")
+	for _, r := range recs {
+		last := "-"
+		if !r.LastCandle.IsZero() {
+			last = r.LastCandle.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%-8s  %-10s  %-4s  %-20s  %-20s  %8d  %s\n",
+			r.Source, r.Instrument, r.Timeframe, last, r.LastSync.Format(time.RFC3339), r.Appended, r.Error)
+	}
+}
@@ -0,0 +1,87 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+func newPackCandlesCmd() *cobra.Command {
+	var (
+		instrumentsCSV string
+		source         string
+		timeframe      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "pack-candles",
+		Short: "Write binary copies of stored candle months for memory-mapped reads",
+		Long: `Write a binary copy (.bin, beside the CSV) of each stored candle month.
+Backtests then map the binary file instead of parsing the CSV: loading a
+month costs no parsing, and concurrent backtests — in one process or
+several — share one copy of the data in the page cache instead of each
+holding its own on the heap.
+
+A binary copy is used only while it is at least as new as its CSV, and
+rewriting a month's CSV deletes it, so packing never serves stale data.
+The current month, still being written, is always read from its CSV.
+Re-run pack-candles after a download or sync to pack the new months.
+
+By default every stored month is packed; --instruments, --source and
+--timeframe narrow the selection.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var tf types.Timeframe
+			if timeframe != "" {
+				var err error
+				if tf, err = types.ParseTimeframe(timeframe); err != nil {
+					return err
+				}
+			}
+			want := map[string]bool{}
+			for _, s := range strings.Split(instrumentsCSV, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					want[market.NormalizeInstrument(s)] = true
+				}
+			}
+
+			keys, err := datamanager.ListCandleKeys()
+			if err != nil {
+				return err
+			}
+			var packed int
+			var size int64
+			for _, k := range keys {
+				if len(want) > 0 && !want[k.Instrument] {
+					continue
+				}
+				if source != "" && !strings.EqualFold(k.Source, source) {
+					continue
+				}
+				if tf != 0 && k.TF != tf {
+					continue
+				}
+				path, err := datamanager.PackCandles(k)
+				if err != nil {
+					return fmt.Errorf("pack %s %s %04d-%02d: %w", k.Instrument, k.TF, k.Year, k.Month, err)
+				}
+				if info, err := os.Stat(path); err == nil {
+					size += info.Size()
+				}
+				packed++
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Packed %d candle months (%.1f MB)\n", packed, float64(size)/(1<<20))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&instrumentsCSV, "instruments", "", "Comma-separated instruments to pack (default: all)")
+	cmd.Flags().StringVar(&source, "source", "", "Data source to pack (default: all)")
+	cmd.Flags().StringVar(&timeframe, "timeframe", "", "Timeframe to pack: M1, H1, H4 or D1 (default: all)")
+	return cmd
+}
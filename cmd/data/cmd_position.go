@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
 )
 
@@ -44,7 +45,7 @@ Notional = units × price  (USD-quoted pairs: EURUSD, GBPUSD, AUDUSD, NZDUSD)
 			inst := market.NormalizeInstrument(instrument)
 			instMeta := market.GetInstrument(inst)
 			if instMeta == nil {
-				return fmt.Errorf("unknown instrument: %s", instrument)
+				return fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, instrument)
 			}
 
 			ctx := context.Background()
@@ -55,7 +56,7 @@ Notional = units × price  (USD-quoted pairs: EURUSD, GBPUSD, AUDUSD, NZDUSD)
 				}
 				price = mid[inst]
 				if price == 0 {
-					return fmt.Errorf("OANDA returned zero price for %s", inst)
+					return errs.Newf(errs.ErrNoPrice, "OANDA returned zero price for %s", inst)
 				}
 			}
 
@@ -115,8 +116,8 @@ func printPositionTable(inst *market.Instrument, price, pips float64) {
 		pipsHeader = fmt.Sprintf("  %12s", fmt.Sprintf("%.0f pips", pips))
 	}
 
-	fmt.Fprintf(os.Stdout, "\nPosition calculator — %s @ %.5f  (margin %.1f%%)\n\n",
-		inst.Name, price, marginPct)
+	fmt.Fprintf(os.Stdout, "\nPosition calculator — %s @ %s  (margin %.1f%%)\n\n",
+		inst.Name, market.FormatPrice(inst.Name, price), marginPct)
 	fmt.Fprintf(os.Stdout, "%-18s  %10s  %16s  %14s%s\n",
 		"Lot size", "Units", "Notional (USD)", "Margin req.", pipsHeader)
 	fmt.Fprintf(os.Stdout, "%-18s  %10s  %16s  %14s%s\n",
@@ -149,7 +150,7 @@ func printSinglePosition(inst *market.Instrument, price float64, units int64, pi
 	m := n * inst.MarginRate.Float64()
 	lots := float64(units) / 100_000
 
-	fmt.Fprintf(os.Stdout, "\n%s units of %s @ %.5f\n", commaInt(units), inst.Name, price)
+	fmt.Fprintf(os.Stdout, "\n%s units of %s @ %s\n", commaInt(units), inst.Name, market.FormatPrice(inst.Name, price))
 	fmt.Fprintf(os.Stdout, "  Lots             %.4f\n", lots)
 	fmt.Fprintf(os.Stdout, "  Notional         %s\n", fmtDollar(n))
 	fmt.Fprintf(os.Stdout, "  Margin (%.1f%%)    %s\n", inst.MarginRate.Float64()*100, fmtDollar(m))
@@ -165,7 +166,7 @@ func printUnitsForNotional(inst *market.Instrument, price float64, targetUSD flo
 	actual := notionalUSD(inst, price, u)
 	m := actual * inst.MarginRate.Float64()
 
-	fmt.Fprintf(os.Stdout, "\n$%.2f notional of %s @ %.5f\n", targetUSD, inst.Name, price)
+	fmt.Fprintf(os.Stdout, "\n$%.2f notional of %s @ %s\n", targetUSD, inst.Name, market.FormatPrice(inst.Name, price))
 	fmt.Fprintf(os.Stdout, "  Units            %s\n", commaInt(u))
 	fmt.Fprintf(os.Stdout, "  Lots             %.4f\n", lots)
 	fmt.Fprintf(os.Stdout, "  Actual notional  %s\n", fmtDollar(actual))
@@ -11,6 +11,7 @@ import (
 
 	"github.com/rustyeddy/trader/config"
 	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
@@ -43,7 +44,7 @@ func newStatsCmd(_ *config.RootConfig) *cobra.Command {
 			}
 			instMeta := market.GetInstrument(inst)
 			if instMeta == nil {
-				return fmt.Errorf("unknown instrument: %s", inst)
+				return fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, inst)
 			}
 
 			from, err := time.Parse("2006-01-02", fromStr)
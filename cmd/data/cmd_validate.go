@@ -13,6 +13,7 @@ import (
 	"github.com/rustyeddy/trader/datamanager"
 	"github.com/rustyeddy/trader/market"
 	datasvc "github.com/rustyeddy/trader/service/data"
+	"github.com/rustyeddy/trader/symbols"
 	"github.com/rustyeddy/trader/types"
 	"github.com/spf13/cobra"
 )
@@ -315,10 +316,7 @@ func repairMissingCandles(
 			cmd.Printf("  [raw partial] %s %s %04d-%02d: %d expected slot(s) unfilled by existing raw — re-downloading\n",
 				k.instrument, k.timeframe, k.year, k.month, preMissing)
 		}
-		oandaInst := k.instrument
-		if len(k.instrument) == 6 {
-			oandaInst = k.instrument[:3] + "_" + k.instrument[3:]
-		}
+		oandaInst := symbols.ToProvider(symbols.OANDA, k.instrument)
 		monthStart := time.Date(k.year, time.Month(k.month), 1, 0, 0, 0, 0, time.UTC)
 		monthEnd := monthStart.AddDate(0, 1, 0).Add(-24 * time.Hour)
 
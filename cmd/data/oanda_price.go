@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -13,6 +12,7 @@ import (
 	"github.com/rustyeddy/trader/config"
 	"github.com/rustyeddy/trader/market"
 	accountsvc "github.com/rustyeddy/trader/service/account"
+	"github.com/rustyeddy/trader/symbols"
 )
 
 // oandaAuth holds the optional OANDA credentials shared by data commands that
@@ -72,7 +72,7 @@ func fetchMidPrices(ctx context.Context, auth oandaAuth, instruments []string) (
 		if inst == nil {
 			continue
 		}
-		oandaNames = append(oandaNames, inst.BaseCurrency+"_"+inst.QuoteCurrency)
+		oandaNames = append(oandaNames, symbols.ToProvider(symbols.OANDA, inst.Name))
 	}
 
 	prices, err := client.GetPricing(ctx, resolvedID, oandaNames...)
@@ -82,7 +82,7 @@ func fetchMidPrices(ctx context.Context, auth oandaAuth, instruments []string) (
 
 	result := make(map[string]float64, len(prices))
 	for _, p := range prices {
-		traderName := strings.ReplaceAll(p.Instrument, "_", "")
+		traderName := symbols.FromProvider(symbols.OANDA, p.Instrument)
 		result[traderName] = p.Mid
 	}
 	return result, nil
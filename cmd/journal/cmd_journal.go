@@ -0,0 +1,790 @@
+// Package journal hosts the `trader journal` CLI commands: attaching notes
+// and review ratings to journaled trades after the fact, printing trades
+// with their notes, listing and summarizing filtered trades, rendering a trading day as org, following a journal as
+// it is written, exporting trades as TradingView chart markers, importing
+// MetaTrader statements, and combining several journals' equity into a
+// portfolio. Business logic lives in journal/; this package parses flags,
+// calls it, and formats output.
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
+	"github.com/rustyeddy/trader/types"
+)
+
+// defaultTradesPath matches the trades file `trader live` and `trader
+// serve` write by default.
+const defaultTradesPath = "./live-trades.jsonl"
+
+// dateLayout is the accepted format for the day argument.
+const dateLayout = "2006-01-02"
+
+// New returns the top-level "journal" cobra command.
+func New(rc *config.RootConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "journal",
+		Short: "Annotate, print, and export journaled trades",
+	}
+	cmd.AddCommand(newAnnotateCmd())
+	cmd.AddCommand(newShowCmd(rc))
+	cmd.AddCommand(newListCmd(rc))
+	cmd.AddCommand(newSummaryCmd(rc))
+	cmd.AddCommand(newDayCmd())
+	cmd.AddCommand(newExportTVCmd())
+	cmd.AddCommand(newTailCmd(rc))
+	cmd.AddCommand(newImportMTCmd())
+	cmd.AddCommand(newPortfolioCmd(rc))
+	return cmd
+}
+
+func newAnnotateCmd() *cobra.Command {
+	var (
+		tradesPath string
+		notesPath  string
+		section    string
+		note       string
+		rating     int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "annotate <trade-id>",
+		Short: "Attach a note and/or review rating to a closed trade",
+		Long: `Attach a note, a review rating, or both to a trade in a trade
+journal, JSONL or (for a .csv path) CSV. The trade ID may be shortened to
+any unique prefix, such as the one shown in the org heading.
+
+Notes are appended to a sidecar file next to the journal
+(live-trades.jsonl → live-trades.notes.jsonl), so the journal itself is
+never rewritten. Each note is filed under the Thesis, Execution or Review
+section of the trade's org entry; a later rating replaces an earlier one.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trades, err := journal.ReadTrades(tradesPath)
+			if err != nil {
+				return fmt.Errorf("read journal %s: %w", tradesPath, err)
+			}
+			trade, err := journal.FindTrade(trades, args[0])
+			if err != nil {
+				return err
+			}
+			if notesPath == "" {
+				notesPath = journal.AnnotationsPath(tradesPath)
+			}
+			a := journal.Annotation{
+				TradeID: trade.TradeID,
+				Time:    types.FromTime(time.Now().UTC()),
+				Section: section,
+				Note:    note,
+				Rating:  rating,
+			}
+			if err := journal.AppendAnnotation(notesPath, a); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Annotated %s %s in %s\n", trade.Instrument, trade.TradeID, notesPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tradesPath, "journal", defaultTradesPath, "Trades journal the trade is in (JSONL, or CSV for a .csv path)")
+	cmd.Flags().StringVar(&notesPath, "notes", "", "Annotations file (default: <journal>.notes.jsonl)")
+	cmd.Flags().StringVar(&section, "section", journal.SectionReview, "Section the note belongs to: thesis|execution|review")
+	cmd.Flags().StringVar(&note, "note", "", "Note text")
+	cmd.Flags().IntVar(&rating, "rating", 0, fmt.Sprintf("Review rating, 1-%d", journal.MaxRating))
+	_ = cmd.RegisterFlagCompletionFunc("section", cobra.FixedCompletions(
+		[]string{journal.SectionThesis, journal.SectionExecution, journal.SectionReview}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func newShowCmd(rc *config.RootConfig) *cobra.Command {
+	var (
+		tradesPath string
+		notesPath  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "show [trade-id]",
+		Short: "Print journaled trades with their notes as org (or JSON with --output json)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trades, err := readAnnotatedTrades(tradesPath, notesPath)
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				trade, err := journal.FindTrade(trades, args[0])
+				if err != nil {
+					return err
+				}
+				trades = []journal.TradeRecord{trade}
+			}
+			if rc.JSONOutput() {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(trades)
+			}
+			return printTradesOrg(cmd.OutOrStdout(), trades)
+		},
+	}
+
+	cmd.Flags().StringVar(&tradesPath, "journal", defaultTradesPath, "Trades journal to read (JSONL, or CSV for a .csv path)")
+	cmd.Flags().StringVar(&notesPath, "notes", "", "Annotations file (default: <journal>.notes.jsonl)")
+	return cmd
+}
+
+// readAnnotatedTrades reads the trades journal and joins its annotations
+// onto it.
+func readAnnotatedTrades(tradesPath, notesPath string) ([]journal.TradeRecord, error) {
+	trades, err := journal.ReadTrades(tradesPath)
+	if err != nil {
+		return nil, fmt.Errorf("read journal %s: %w", tradesPath, err)
+	}
+	if notesPath == "" {
+		notesPath = journal.AnnotationsPath(tradesPath)
+	}
+	notes, err := journal.ReadAnnotations(notesPath)
+	if err != nil {
+		return nil, fmt.Errorf("read notes: %w", err)
+	}
+	return journal.Annotate(trades, notes), nil
+}
+
+// tradeFilterFlags are the trade selection flags shared by list and
+// summary.
+type tradeFilterFlags struct {
+	instrument string
+	strategy   string
+	reason     string
+	minPL      float64
+	maxPL      float64
+}
+
+func (f *tradeFilterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.instrument, "instrument", "", "Only trades on this instrument (default: all)")
+	cmd.Flags().StringVar(&f.strategy, "strategy", "", "Only trades placed by this bot or run ID, e.g. live-oanda (default: all)")
+	cmd.Flags().StringVar(&f.reason, "reason", "", "Only trades closed for this reason, e.g. STOP or TAKE (default: all)")
+	cmd.Flags().Float64Var(&f.minPL, "min-pl", 0, "Only trades with realized P/L of at least this much")
+	cmd.Flags().Float64Var(&f.maxPL, "max-pl", 0, "Only trades with realized P/L of at most this much")
+}
+
+// filter returns the journal.TradeFilter the flags select. --min-pl and
+// --max-pl bound P/L only when given, so --max-pl 0 selects losers and
+// breakevens.
+func (f *tradeFilterFlags) filter(cmd *cobra.Command) (journal.TradeFilter, error) {
+	tf := journal.TradeFilter{Strategy: f.strategy, Reason: f.reason}
+	if f.instrument != "" {
+		tf.Instrument = market.NormalizeInstrument(f.instrument)
+	}
+	if cmd.Flags().Changed("min-pl") {
+		m := types.MoneyFromFloat(f.minPL)
+		tf.MinPL = &m
+	}
+	if cmd.Flags().Changed("max-pl") {
+		m := types.MoneyFromFloat(f.maxPL)
+		tf.MaxPL = &m
+	}
+	if tf.MinPL != nil && tf.MaxPL != nil && *tf.MinPL > *tf.MaxPL {
+		return journal.TradeFilter{}, fmt.Errorf("--min-pl %.2f is above --max-pl %.2f", f.minPL, f.maxPL)
+	}
+	return tf, nil
+}
+
+// readFilteredTrades reads the trades journal and keeps the trades that
+// pass the filter flags.
+func readFilteredTrades(cmd *cobra.Command, tradesPath string, ff *tradeFilterFlags) ([]journal.TradeRecord, error) {
+	tf, err := ff.filter(cmd)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := journal.ReadTrades(tradesPath)
+	if err != nil {
+		return nil, fmt.Errorf("read journal %s: %w", tradesPath, err)
+	}
+	return journal.FilterTrades(trades, tf), nil
+}
+
+func newListCmd(rc *config.RootConfig) *cobra.Command {
+	var (
+		tradesPath string
+		ff         tradeFilterFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List journaled trades one per line, filtered by instrument, strategy, reason or P/L",
+		Long: `List the trades of a trades journal (JSONL, or CSV for a .csv path), one
+line each: close time, instrument, units, entry and exit price, realized
+P/L, close reason and trade ID.
+
+The filter flags combine: --instrument EURUSD --reason STOP --max-pl 0
+lists the EURUSD trades stopped out at a loss. --strategy matches the bot
+ID of trades a bot opened, or the run ID of imported history (live-oanda,
+live-mt4, live-mt5).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trades, err := readFilteredTrades(cmd, tradesPath, &ff)
+			if err != nil {
+				return err
+			}
+			if rc.JSONOutput() {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(trades)
+			}
+			printTradeList(cmd.OutOrStdout(), trades)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tradesPath, "journal", defaultTradesPath, "Trades journal to read (JSONL, or CSV for a .csv path)")
+	ff.register(cmd)
+	return cmd
+}
+
+func printTradeList(w io.Writer, trades []journal.TradeRecord) {
+	if len(trades) == 0 {
+		fmt.Fprintln(w, "No trades.")
+		return
+	}
+	for _, t := range trades {
+		fmt.Fprintf(w, "%s  %s%-7s %+8d  %s → %s  P/L %+.2f  %s  %s\n",
+			t.CloseTime, tailAccount(t.AccountID), t.Instrument, t.Units.Int64(), t.EntryPrice, t.ExitPrice,
+			t.RealizedPL.Float64(), t.Reason, t.TradeID)
+	}
+}
+
+func newSummaryCmd(rc *config.RootConfig) *cobra.Command {
+	var (
+		tradesPath string
+		ff         tradeFilterFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Summarize journaled trades (count, win rate, P/L), filtered like list",
+		Long: `Total the trades of a trades journal that pass the filter flags: trade
+count, wins and losses, win rate, net, gross and average P/L, profit
+factor and the best and worst trade, overall and per instrument. The
+filter flags are the same as for trader journal list.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trades, err := readFilteredTrades(cmd, tradesPath, &ff)
+			if err != nil {
+				return err
+			}
+			sum := journal.SummarizeTrades(trades)
+			if rc.JSONOutput() {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(sum)
+			}
+			printTradeSummary(cmd.OutOrStdout(), sum)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tradesPath, "journal", defaultTradesPath, "Trades journal to read (JSONL, or CSV for a .csv path)")
+	ff.register(cmd)
+	return cmd
+}
+
+func printTradeSummary(w io.Writer, sum journal.TradeSummary) {
+	if sum.Trades == 0 {
+		fmt.Fprintln(w, "No trades.")
+		return
+	}
+	s := sum.TradeStats
+	fmt.Fprintf(w, "Trades:        %d (%d wins, %d losses)\n", s.Trades, s.Wins, s.Losses)
+	fmt.Fprintf(w, "Win rate:      %.1f%%\n", s.WinRate()*100)
+	fmt.Fprintf(w, "Net P/L:       %+.2f (avg %+.2f)\n", s.NetPL.Float64(), s.NetPL.Float64()/float64(s.Trades))
+	fmt.Fprintf(w, "Gross P/L:     %+.2f / %+.2f\n", s.GrossWin.Float64(), s.GrossLoss.Float64())
+	fmt.Fprintf(w, "Profit factor: %.2f\n", s.ProfitFactor())
+	fmt.Fprintf(w, "Best / worst:  %+.2f / %+.2f\n", s.BestTrade.Float64(), s.WorstTrade.Float64())
+
+	fmt.Fprintf(w, "\n%-10s %6s %6s %8s %12s\n", "Instrument", "Trades", "Wins", "Win %", "Net P/L")
+	for _, is := range sum.ByInstrument {
+		fmt.Fprintf(w, "%-10s %6d %6d %7.1f%% %+12.2f\n",
+			is.Instrument, is.Trades, is.Wins, is.WinRate()*100, is.NetPL.Float64())
+	}
+}
+
+func printTradesOrg(w io.Writer, trades []journal.TradeRecord) error {
+	if len(trades) == 0 {
+		_, err := fmt.Fprintln(w, "No trades.")
+		return err
+	}
+	_, err := fmt.Fprintln(w, journal.FormatTradesOrg(trades))
+	return err
+}
+
+func newDayCmd() *cobra.Command {
+	var (
+		tradesPath string
+		equityPath string
+		notesPath  string
+		tz         string
+		rollover   string
+		outPath    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "day [YYYY-MM-DD]",
+		Short: "Render one trading day (equity, P/L and trades) as an org heading",
+		Long: `Render a trading day as a top-level org heading: start and end equity,
+the day's realized P/L, and every trade closed that day (with its notes) as
+a subheading, ready to append to an org journal. The day defaults to
+today in --tz.
+
+A day runs midnight to midnight in --tz unless --rollover sets the
+broker's day instead: "broker" uses the configured rollover (market.rollover
+in the global config, 17:00 America/New_York by default), or give one as
+"HH:00 Zone". A rollover day is named by the date it ends on, as FX value
+dates are: under 17:00 New York, 2024-03-15 runs from 17:00 on the 14th.
+
+Equity comes from the equity journal written next to the trades journal
+(live-trades.jsonl → live-equity.jsonl); the equity properties are left out
+when it has no snapshots.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loc, err := time.LoadLocation(tz)
+			if err != nil {
+				return fmt.Errorf("bad --tz %q: %w", tz, err)
+			}
+			var r types.Rollover
+			switch rollover {
+			case "":
+			case "broker":
+				r = types.CurrentRollover()
+			default:
+				if r, err = types.ParseRollover(rollover); err != nil {
+					return fmt.Errorf("bad --rollover: %w", err)
+				}
+			}
+			if r.Location != nil && !cmd.Flags().Changed("tz") {
+				loc = r.Location
+			}
+			date := time.Now().In(loc)
+			if r.Location != nil {
+				// Past today's rollover, today's trading day has ended.
+				_, end := r.Day(date)
+				if !date.Before(end) {
+					date = date.AddDate(0, 0, 1)
+				}
+			}
+			if len(args) == 1 {
+				if date, err = time.ParseInLocation(dateLayout, args[0], loc); err != nil {
+					return fmt.Errorf("bad date %q (want YYYY-MM-DD)", args[0])
+				}
+			}
+
+			trades, err := readAnnotatedTrades(tradesPath, notesPath)
+			if err != nil {
+				return err
+			}
+			if equityPath == "" {
+				equityPath = equityPathFor(tradesPath)
+			}
+			equity, err := journal.ReadEquity(equityPath)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("read equity %s: %w", equityPath, err)
+			}
+
+			day := journal.BuildDay(date, trades, equity)
+			if r.Location != nil {
+				day = journal.BuildRolloverDay(date, r, trades, equity)
+			}
+			out := journal.FormatDayOrg(day)
+			if outPath == "" {
+				_, err = io.WriteString(cmd.OutOrStdout(), out)
+				return err
+			}
+			if err := os.WriteFile(outPath, []byte(out), 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\n", outPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tradesPath, "journal", defaultTradesPath, "Trades journal to read (JSONL, or CSV for a .csv path)")
+	cmd.Flags().StringVar(&equityPath, "equity", "", "Equity journal, JSONL or CSV (default: the trades path with trades replaced by equity)")
+	cmd.Flags().StringVar(&notesPath, "notes", "", "Annotations file (default: <journal>.notes.jsonl)")
+	cmd.Flags().StringVar(&tz, "tz", "UTC", "IANA timezone the day runs midnight to midnight in")
+	cmd.Flags().StringVar(&rollover, "rollover", "", `Run the day rollover to rollover: "broker" (the configured rollover) or "HH:00 Zone"`)
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "Write the org text to this file instead of stdout")
+	return cmd
+}
+
+func newExportTVCmd() *cobra.Command {
+	var (
+		tradesPath string
+		instrument string
+		format     string
+		outPath    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export-tv",
+		Short: "Export trades as TradingView chart markers (CSV or Pine Script)",
+		Long: `Convert journaled trades into chart markers, one at each entry and one at
+each exit, to overlay on a TradingView chart and check a backtest's signals
+by eye.
+
+--format csv writes an annotation list: timestamp (Unix seconds), price,
+direction (buy or sell) and label. --format pine writes a Pine Script v5
+indicator that draws the same markers as labels; paste it into the Pine
+editor and add it to a chart of the instrument. Pine keeps at most 500
+labels, so the script holds the latest 500 markers.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if instrument != "" {
+				instrument = market.NormalizeInstrument(instrument)
+			}
+			trades, err := journal.ReadTrades(tradesPath)
+			if err != nil {
+				return fmt.Errorf("read journal %s: %w", tradesPath, err)
+			}
+			markers := journal.TradeMarkers(trades, instrument)
+
+			var b strings.Builder
+			switch format {
+			case "csv":
+				if err := journal.WriteMarkersCSV(&b, markers); err != nil {
+					return err
+				}
+			case "pine":
+				title := "trader trades"
+				if instrument != "" {
+					title += ": " + instrument
+				}
+				b.WriteString(journal.FormatMarkersPine(title, markers))
+			default:
+				return fmt.Errorf("bad --format %q (want csv or pine)", format)
+			}
+
+			if outPath == "" {
+				_, err = io.WriteString(cmd.OutOrStdout(), b.String())
+				return err
+			}
+			if err := os.WriteFile(outPath, []byte(b.String()), 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d markers to %s\n", len(markers), outPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tradesPath, "journal", defaultTradesPath, "Trades journal to read (JSONL, or CSV for a .csv path)")
+	cmd.Flags().StringVar(&instrument, "instrument", "", "Only export trades on this instrument (default: all)")
+	cmd.Flags().StringVar(&format, "format", "csv", "Output format: csv or pine")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "Write to this file instead of stdout")
+	return cmd
+}
+
+// equityPathFor returns the equity journal written alongside tradesPath:
+// "live-trades.jsonl" → "live-equity.jsonl", "run-trades.csv" →
+// "run-equity.csv".
+func equityPathFor(tradesPath string) string {
+	dir, file := filepath.Split(tradesPath)
+	if i := strings.LastIndex(file, "trades"); i >= 0 {
+		return dir + file[:i] + "equity" + file[i+len("trades"):]
+	}
+	ext := filepath.Ext(tradesPath)
+	if ext == "" {
+		ext = ".jsonl"
+	}
+	return strings.TrimSuffix(tradesPath, ext) + "-equity" + ext
+}
+
+func newTailCmd(rc *config.RootConfig) *cobra.Command {
+	var (
+		tradesPath  string
+		equityPath  string
+		lines       int
+		equityEvery time.Duration
+		interval    time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Follow a trades journal, printing trades and equity as they are written",
+		Long: `Follow the trades and equity journals of a running backtest, replay or
+live session, like tail -f: print the last --lines trades already in the
+journal and its latest equity, then each trade and equity snapshot as it is
+written, until interrupted.
+
+The journal defaults to the one --db names (./trader-journal →
+./trader-journal-trades.jsonl); --journal picks another, JSONL or (for a
+.csv path) CSV, such as live-trades.jsonl. Equity snapshots are printed at
+most once per --equity-every of journal time. A journal that does not exist
+yet is waited for, and one that a new run starts over is read again from
+the top.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if tradesPath == "" {
+				tradesPath, _ = journal.JournalRecordPaths(rc.DBPath)
+			}
+			if equityPath == "" {
+				equityPath = equityPathFor(tradesPath)
+			}
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return tailJournal(ctx, cmd.OutOrStdout(), journal.NewFollower(tradesPath, equityPath), lines, equityEvery, interval)
+		},
+	}
+
+	cmd.Flags().StringVar(&tradesPath, "journal", "", "Trades journal to follow, JSONL or CSV (default: the trades journal of --db)")
+	cmd.Flags().StringVar(&equityPath, "equity", "", "Equity journal to follow (default: the trades path with trades replaced by equity)")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 10, "Trades already in the journal to print first")
+	cmd.Flags().DurationVar(&equityEvery, "equity-every", time.Hour, "Journal time between equity lines; 0 prints every snapshot")
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "How often to check the journal for new records")
+	return cmd
+}
+
+// tailJournal prints what f has, the last lines trades and the latest
+// equity snapshot, then polls it every interval until ctx is done.
+func tailJournal(ctx context.Context, w io.Writer, f *journal.Follower, lines int, equityEvery, interval time.Duration) error {
+	trades, equity, err := f.Poll()
+	if err != nil {
+		return err
+	}
+	trades = trades[max(len(trades)-lines, 0):]
+	if len(equity) > 0 {
+		equity = equity[len(equity)-1:]
+	}
+	var lastEquity types.Timestamp
+	for {
+		for _, t := range trades {
+			printTailTrade(w, t)
+		}
+		for _, e := range equity {
+			if lastEquity != 0 && e.Timestamp >= lastEquity && e.Timestamp < lastEquity.Add(equityEvery) {
+				continue
+			}
+			printTailEquity(w, e)
+			lastEquity = e.Timestamp
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+		if trades, equity, err = f.Poll(); err != nil {
+			return err
+		}
+	}
+}
+
+func printTailTrade(w io.Writer, t journal.TradeRecord) {
+	fmt.Fprintf(w, "%s  TRADE   %s%-7s %+8d  %s → %s  P/L %+.2f  %s  %s\n",
+		t.CloseTime, tailAccount(t.AccountID), t.Instrument, t.Units.Int64(), t.EntryPrice, t.ExitPrice,
+		t.RealizedPL.Float64(), t.Reason, t.TradeID)
+}
+
+func printTailEquity(w io.Writer, e journal.EquitySnapshot) {
+	fmt.Fprintf(w, "%s  EQUITY  %sequity %.2f  balance %.2f  margin used %.2f  free %.2f\n",
+		e.Timestamp, tailAccount(e.AccountID), e.Equity.Float64(), e.Balance.Float64(),
+		e.MarginUsed.Float64(), e.FreeMargin.Float64())
+}
+
+// tailAccount labels a record of a sim sub-account; the primary account's
+// records carry no label.
+func tailAccount(id string) string {
+	if id == "" {
+		return ""
+	}
+	return "[" + id + "] "
+}
+
+func newImportMTCmd() *cobra.Command {
+	var (
+		tradesPath string
+		tz         string
+		lotSize    int64
+		symbolMap  []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import-mt <statement>...",
+		Short: "Import the closed trades of MetaTrader 4/5 statements into a trades journal",
+		Long: `Read MT4 detailed statements or MT5 history reports, HTML as the terminal
+saves them or CSV, and append their closed trades to a trades journal
+(JSONL, or CSV for a .csv path), tagged with run ID live-mt4 or live-mt5, so
+they are analyzed with the same tooling as backtests. Trades already in the
+journal are not written again.
+
+Statement times are in the broker's server time; --tz names its zone (many
+brokers run Europe/Athens time) and the journal gets UTC. Volumes are lots
+of --lot-size units. P/L is net of commission, taxes and swap.
+
+A symbol maps to the instrument it names, with any broker suffix dropped
+("EURUSD.m" → EURUSD). Others are mapped with --symbol INSTRUMENT=SYMBOL or
+the metatrader entries of the global config's symbols section; trades on
+symbols that map to no instrument are skipped and counted.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loc, err := time.LoadLocation(tz)
+			if err != nil {
+				return fmt.Errorf("bad --tz %q: %w", tz, err)
+			}
+			for _, m := range symbolMap {
+				inst, sym, ok := strings.Cut(m, "=")
+				if !ok {
+					return fmt.Errorf("bad --symbol %q (want INSTRUMENT=SYMBOL)", m)
+				}
+				if err := symbols.Register(symbols.MetaTrader, inst, sym); err != nil {
+					return err
+				}
+			}
+
+			opts := journal.MetaTraderOptions{Location: loc, LotSize: types.Units(lotSize)}
+			out := cmd.OutOrStdout()
+			for _, path := range args {
+				got, err := journal.ReadMetaTraderStatement(path, opts)
+				if err != nil {
+					return fmt.Errorf("read statement %s: %w", path, err)
+				}
+				n, err := journal.AppendNewTrades(tradesPath, got.Trades)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Imported %d trades from %s into %s (%d already there)\n",
+					n, path, tradesPath, len(got.Trades)-n)
+				unmapped := make([]string, 0, len(got.Unmapped))
+				for sym := range got.Unmapped {
+					unmapped = append(unmapped, sym)
+				}
+				sort.Strings(unmapped)
+				for _, sym := range unmapped {
+					fmt.Fprintf(out, "  skipped %d trades on %s: no instrument (map it with --symbol INSTRUMENT=%s)\n",
+						got.Unmapped[sym], sym, sym)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tradesPath, "journal", defaultTradesPath, "Trades journal to append to (JSONL, or CSV for a .csv path)")
+	cmd.Flags().StringVar(&tz, "tz", "UTC", "IANA timezone of the statement's server time")
+	cmd.Flags().Int64Var(&lotSize, "lot-size", int64(journal.DefaultLotSize), "Units per lot")
+	cmd.Flags().StringArrayVar(&symbolMap, "symbol", nil, "Map a statement symbol to an instrument, INSTRUMENT=SYMBOL (repeatable)")
+	return cmd
+}
+
+func newPortfolioCmd(rc *config.RootConfig) *cobra.Command {
+	var tz string
+
+	cmd := &cobra.Command{
+		Use:   "portfolio [name=]<equity-journal>...",
+		Short: "Combine several equity journals into one curve and correlate their daily returns",
+		Long: `Read the equity journals of several strategies or accounts (JSONL, or
+CSV for a .csv path) and print their combined daily equity curve and the
+correlation of their daily returns, to see how well they complement each
+other before allocating capital to them together.
+
+Each journal is named by its file name unless given as name=path. A
+journal's equity for a day is its last snapshot that day, carried forward
+over days it recorded nothing. The curve starts on the first day every
+journal has equity. Correlations near +1 mean the journals win and lose
+together; near zero or below, they diversify each other.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loc, err := time.LoadLocation(tz)
+			if err != nil {
+				return fmt.Errorf("bad --tz %q: %w", tz, err)
+			}
+			members := make([]journal.PortfolioMember, 0, len(args))
+			for _, arg := range args {
+				name, path := portfolioArg(arg)
+				equity, err := journal.ReadEquity(path)
+				if err != nil {
+					return fmt.Errorf("read equity %s: %w", path, err)
+				}
+				members = append(members, journal.PortfolioMember{Name: name, Equity: equity})
+			}
+			p, err := journal.BuildPortfolio(members, loc)
+			if err != nil {
+				return err
+			}
+			if rc.JSONOutput() {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(p)
+			}
+			printPortfolio(cmd.OutOrStdout(), p)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tz, "tz", "UTC", "IANA timezone the days run midnight to midnight in")
+	return cmd
+}
+
+// portfolioArg splits a "name=path" argument; a bare path is named by its
+// file name without the extension.
+func portfolioArg(arg string) (name, path string) {
+	if name, path, ok := strings.Cut(arg, "="); ok && name != "" {
+		return name, path
+	}
+	base := filepath.Base(arg)
+	return strings.TrimSuffix(base, filepath.Ext(base)), arg
+}
+
+func printPortfolio(w io.Writer, p journal.Portfolio) {
+	width := 12
+	for _, name := range p.Names {
+		width = max(width, len(name))
+	}
+
+	fmt.Fprintf(w, "%-10s", "Date")
+	for _, name := range p.Names {
+		fmt.Fprintf(w, " %*s", width, name)
+	}
+	fmt.Fprintf(w, " %*s\n", width, "Total")
+	for _, d := range p.Days {
+		fmt.Fprintf(w, "%-10s", d.Date.Format(dateLayout))
+		for _, e := range d.Equity {
+			fmt.Fprintf(w, " %*.2f", width, e.Float64())
+		}
+		fmt.Fprintf(w, " %*.2f\n", width, d.Total.Float64())
+	}
+	if start := p.Start(); start > 0 {
+		fmt.Fprintf(w, "\nCombined: %.2f → %.2f (%+.2f%%)\n",
+			start.Float64(), p.End().Float64(), (p.End()-start).Float64()/start.Float64()*100)
+	}
+
+	fmt.Fprintf(w, "\nDaily return correlation\n%-*s", width, "")
+	for _, name := range p.Names {
+		fmt.Fprintf(w, " %*s", width, name)
+	}
+	fmt.Fprintln(w)
+	for i, name := range p.Names {
+		fmt.Fprintf(w, "%-*s", width, name)
+		for _, c := range p.Correlation[i] {
+			fmt.Fprintf(w, " %+*.2f", width, c.Float64())
+		}
+		fmt.Fprintln(w)
+	}
+}
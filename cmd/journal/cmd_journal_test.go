@@ -0,0 +1,382 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/types"
+)
+
+func writeTrades(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "live-trades.jsonl")
+	j, err := journal.NewJSON(path, filepath.Join(dir, "live-equity.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{
+		TradeID:    "01HQTRADE001",
+		Instrument: "EURUSD",
+		Units:      1000,
+		EntryPrice: types.PriceFromFloat(1.085),
+		ExitPrice:  types.PriceFromFloat(1.0875),
+		RealizedPL: types.MoneyFromFloat(2.5),
+	}))
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{TradeID: "01HQTRADE002", Instrument: "USDJPY"}))
+	require.NoError(t, j.Close())
+	return path
+}
+
+func run(t *testing.T, rc *config.RootConfig, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := New(rc)
+	cmd.SetArgs(args)
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestAnnotateAndShow(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath := writeTrades(t, dir)
+
+	out, err := run(t, nil, "annotate", "01HQTRADE001", "--journal", tradesPath,
+		"--section", "thesis", "--note", "London open breakout")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Annotated EURUSD 01HQTRADE001")
+
+	_, err = run(t, nil, "annotate", "01HQTRADE001", "--journal", tradesPath, "--rating", "4", "--note", "good patience")
+	require.NoError(t, err)
+
+	out, err = run(t, nil, "show", "01HQTRADE001", "--journal", tradesPath)
+	require.NoError(t, err)
+	assert.Contains(t, out, ":REVIEW_RATING: 4/5")
+	assert.Contains(t, out, "London open breakout")
+	assert.Contains(t, out, "good patience")
+	assert.NotContains(t, out, "USDJPY")
+
+	out, err = run(t, &config.RootConfig{Output: config.OutputJSON}, "show", "--journal", tradesPath)
+	require.NoError(t, err)
+	var trades []journal.TradeRecord
+	require.NoError(t, json.Unmarshal([]byte(out), &trades))
+	require.Len(t, trades, 2)
+	assert.Equal(t, 4, trades[0].Rating)
+	require.Len(t, trades[0].Notes, 2)
+	assert.Equal(t, journal.SectionThesis, trades[0].Notes[0].Section)
+	assert.Empty(t, trades[1].Notes)
+}
+
+func TestListAndSummaryFilters(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath := filepath.Join(dir, "live-trades.jsonl")
+	j, err := journal.NewJSON(tradesPath, filepath.Join(dir, "live-equity.jsonl"))
+	require.NoError(t, err)
+	for _, tr := range []journal.TradeRecord{
+		{TradeID: "t1", BotID: "bot-a", Instrument: "EUR_USD", Units: 1000, Reason: "STOP", RealizedPL: types.MoneyFromFloat(-12)},
+		{TradeID: "t2", BotID: "bot-a", Instrument: "EUR_USD", Units: 1000, Reason: "TAKE", RealizedPL: types.MoneyFromFloat(30)},
+		{TradeID: "t3", BotID: "bot-b", Instrument: "USD_JPY", Units: -500, Reason: "STOP", RealizedPL: types.MoneyFromFloat(-8)},
+	} {
+		require.NoError(t, j.RecordTrade(tr))
+	}
+	require.NoError(t, j.Close())
+
+	out, err := run(t, nil, "list", "--journal", tradesPath, "--instrument", "eurusd", "--reason", "stop")
+	require.NoError(t, err)
+	assert.Contains(t, out, "t1")
+	assert.NotContains(t, out, "t2")
+	assert.NotContains(t, out, "t3")
+
+	out, err = run(t, &config.RootConfig{Output: config.OutputJSON}, "list", "--journal", tradesPath, "--max-pl", "0")
+	require.NoError(t, err)
+	var trades []journal.TradeRecord
+	require.NoError(t, json.Unmarshal([]byte(out), &trades))
+	require.Len(t, trades, 2)
+	assert.Equal(t, "t1", trades[0].TradeID)
+	assert.Equal(t, "t3", trades[1].TradeID)
+
+	out, err = run(t, nil, "list", "--journal", tradesPath, "--strategy", "bot-c")
+	require.NoError(t, err)
+	assert.Equal(t, "No trades.\n", out)
+
+	out, err = run(t, nil, "summary", "--journal", tradesPath, "--strategy", "bot-a")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Trades:        2 (1 wins, 1 losses)")
+	assert.Contains(t, out, "Win rate:      50.0%")
+	assert.Contains(t, out, "Net P/L:       +18.00")
+	assert.NotContains(t, out, "USD_JPY")
+
+	_, err = run(t, nil, "summary", "--journal", tradesPath, "--min-pl", "10", "--max-pl", "5")
+	require.ErrorContains(t, err, "--min-pl 10.00 is above --max-pl 5.00")
+}
+
+func TestAnnotateErrors(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath := writeTrades(t, dir)
+
+	_, err := run(t, nil, "annotate", "01HQTRADE", "--journal", tradesPath, "--note", "x")
+	require.ErrorContains(t, err, "ambiguous")
+
+	_, err = run(t, nil, "annotate", "missing", "--journal", tradesPath, "--note", "x")
+	require.ErrorContains(t, err, "no trade")
+
+	_, err = run(t, nil, "annotate", "01HQTRADE002", "--journal", tradesPath, "--rating", "9")
+	require.ErrorContains(t, err, "rating must be 1-5")
+
+	_, err = run(t, nil, "annotate", "01HQTRADE002", "--journal", filepath.Join(dir, "none.jsonl"), "--note", "x")
+	require.ErrorContains(t, err, "read journal")
+}
+
+func TestDay(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath := filepath.Join(dir, "live-trades.jsonl")
+	j, err := journal.NewJSON(tradesPath, filepath.Join(dir, "live-equity.jsonl"))
+	require.NoError(t, err)
+	closedAt := types.Timestamp(1710500000) // 2024-03-15 10:53 UTC
+	require.NoError(t, j.RecordEquity(journal.EquitySnapshot{Timestamp: closedAt - 86400, Equity: types.MoneyFromFloat(10_000)}))
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{
+		TradeID:    "01HQDAY",
+		Instrument: "EURUSD",
+		CloseTime:  closedAt,
+		RealizedPL: types.MoneyFromFloat(42.5),
+	}))
+	require.NoError(t, j.RecordEquity(journal.EquitySnapshot{Timestamp: closedAt, Equity: types.MoneyFromFloat(10_042.5)}))
+	require.NoError(t, j.Close())
+
+	_, err = run(t, nil, "annotate", "01HQDAY", "--journal", tradesPath, "--note", "clean trend day")
+	require.NoError(t, err)
+
+	out, err := run(t, nil, "day", "2024-03-15", "--journal", tradesPath)
+	require.NoError(t, err)
+	assert.Contains(t, out, "* 2024-03-15 Friday\n")
+	assert.Contains(t, out, ":START_EQUITY: 10000.00\n:END_EQUITY: 10042.50\n")
+	assert.Contains(t, out, ":DAILY_PL: +42.50\n")
+	assert.Contains(t, out, "** Trade: EURUSD")
+	assert.Contains(t, out, "clean trend day")
+
+	orgPath := filepath.Join(dir, "2024-03-15.org")
+	out, err = run(t, nil, "day", "2024-03-14", "--journal", tradesPath, "--out", orgPath)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Wrote "+orgPath)
+	b, err := os.ReadFile(orgPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "No trades closed.")
+
+	// 10:53 UTC on the 15th is in the 15th's New York trading day, which
+	// ends at 21:00 UTC; the 14th's ended before it.
+	out, err = run(t, nil, "day", "2024-03-15", "--journal", tradesPath, "--rollover", "broker")
+	require.NoError(t, err)
+	assert.Contains(t, out, ":DAY_START: 2024-03-14T21:00:00Z\n")
+	assert.Contains(t, out, ":DAILY_PL: +42.50\n")
+	out, err = run(t, nil, "day", "2024-03-14", "--journal", tradesPath, "--rollover", "17:00 America/New_York")
+	require.NoError(t, err)
+	assert.Contains(t, out, "No trades closed.")
+	_, err = run(t, nil, "day", "--rollover", "5pm", "--journal", tradesPath)
+	require.ErrorContains(t, err, "bad --rollover")
+
+	_, err = run(t, nil, "day", "15/03/2024", "--journal", tradesPath)
+	require.ErrorContains(t, err, "bad date")
+	_, err = run(t, nil, "day", "--tz", "Mars/Base", "--journal", tradesPath)
+	require.ErrorContains(t, err, "bad --tz")
+}
+
+func TestExportTV(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath := filepath.Join(dir, "bt-trades.jsonl")
+	j, err := journal.NewJSON(tradesPath, filepath.Join(dir, "bt-equity.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{
+		TradeID: "01HQTV", Instrument: "EURUSD", Units: 10_000,
+		EntryPrice: types.PriceFromFloat(1.1), ExitPrice: types.PriceFromFloat(1.101),
+		OpenTime: 1710500000, CloseTime: 1710503600, RealizedPL: types.MoneyFromFloat(10),
+	}))
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{TradeID: "01HQJPY", Instrument: "USDJPY", Units: 1_000}))
+	require.NoError(t, j.Close())
+
+	out, err := run(t, nil, "export-tv", "--journal", tradesPath, "--instrument", "EUR_USD")
+	require.NoError(t, err)
+	assert.Equal(t, "timestamp,price,direction,label\n"+
+		"1710500000,1.10000,buy,Long entry 01HQTV\n"+
+		"1710503600,1.10100,sell,Long exit 01HQTV +10.00\n", out)
+
+	pinePath := filepath.Join(dir, "eurusd.pine")
+	out, err = run(t, nil, "export-tv", "--journal", tradesPath, "--instrument", "EURUSD", "--format", "pine", "-o", pinePath)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Wrote 2 markers to "+pinePath)
+	pine, err := os.ReadFile(pinePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(pine), `indicator("trader trades: EURUSD"`)
+
+	_, err = run(t, nil, "export-tv", "--journal", tradesPath, "--format", "svg")
+	require.ErrorContains(t, err, "bad --format")
+}
+
+func TestEquityPathFor(t *testing.T) {
+	assert.Equal(t, "/j/live-equity.jsonl", equityPathFor("/j/live-trades.jsonl"))
+	assert.Equal(t, "run-equity.jsonl", equityPathFor("run.jsonl"))
+	assert.Equal(t, "/j/run-equity.csv", equityPathFor("/j/run-trades.csv"))
+	assert.Equal(t, "run-equity.csv", equityPathFor("run.csv"))
+}
+
+func TestDay_CSVJournal(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath := filepath.Join(dir, "run-trades.csv")
+	j, err := journal.NewCSV(tradesPath, filepath.Join(dir, "run-equity.csv"))
+	require.NoError(t, err)
+	closedAt := types.Timestamp(1710500000) // 2024-03-15 10:53 UTC
+	require.NoError(t, j.RecordEquity(journal.EquitySnapshot{Timestamp: closedAt - 86400, Equity: types.MoneyFromFloat(10_000)}))
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{
+		TradeID:    "01HQCSV",
+		Instrument: "EURUSD",
+		CloseTime:  closedAt,
+		RealizedPL: types.MoneyFromFloat(-12.25),
+	}))
+	require.NoError(t, j.RecordEquity(journal.EquitySnapshot{Timestamp: closedAt, Equity: types.MoneyFromFloat(9_987.75)}))
+	require.NoError(t, j.Close())
+
+	out, err := run(t, nil, "show", "01HQ", "--journal", tradesPath)
+	require.NoError(t, err)
+	assert.Contains(t, out, "EURUSD")
+
+	out, err = run(t, nil, "day", "2024-03-15", "--journal", tradesPath)
+	require.NoError(t, err)
+	assert.Contains(t, out, ":START_EQUITY: 10000.00\n:END_EQUITY: 9987.75\n")
+	assert.Contains(t, out, ":DAILY_PL: -12.25\n")
+}
+
+func TestPortfolio(t *testing.T) {
+	dir := t.TempDir()
+	day := types.Timestamp(1710500000) // 2024-03-15 10:53 UTC
+	writeEquity := func(path string, equity ...float64) {
+		kind := strings.TrimPrefix(filepath.Ext(path), ".")
+		if kind == "jsonl" {
+			kind = "json"
+		}
+		j, err := journal.Open(journal.Config{Kind: kind, TradesPath: path + ".trades", EquityPath: path})
+		require.NoError(t, err)
+		for i, e := range equity {
+			require.NoError(t, j.RecordEquity(journal.EquitySnapshot{Timestamp: day + types.Timestamp(i*86400), Equity: types.MoneyFromFloat(e)}))
+		}
+		require.NoError(t, j.Close())
+	}
+	trend := filepath.Join(dir, "trend-equity.jsonl")
+	revert := filepath.Join(dir, "revert-equity.csv")
+	writeEquity(trend, 10_000, 10_100, 10_050)
+	writeEquity(revert, 5_000, 4_950, 5_000)
+
+	out, err := run(t, nil, "portfolio", trend, "mr="+revert)
+	require.NoError(t, err)
+	assert.Contains(t, out, "trend-equity")
+	assert.Contains(t, out, "2024-03-16     10100.00      4950.00     15050.00\n")
+	assert.Contains(t, out, "Combined: 15000.00 → 15050.00 (+0.33%)")
+	assert.Contains(t, out, "mr                  -1.00        +1.00\n")
+
+	out, err = run(t, &config.RootConfig{Output: config.OutputJSON}, "portfolio", trend, revert)
+	require.NoError(t, err)
+	var p journal.Portfolio
+	require.NoError(t, json.Unmarshal([]byte(out), &p))
+	assert.Equal(t, []string{"trend-equity", "revert-equity"}, p.Names)
+	assert.Len(t, p.Days, 3)
+
+	_, err = run(t, nil, "portfolio", filepath.Join(dir, "missing.jsonl"))
+	assert.Error(t, err)
+}
+
+func TestTail(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath := filepath.Join(dir, "run-trades.jsonl")
+	j, err := journal.NewJSON(tradesPath, filepath.Join(dir, "run-equity.jsonl"))
+	require.NoError(t, err)
+	defer j.Close()
+	for _, id := range []string{"T1", "T2", "T3"} {
+		require.NoError(t, j.RecordTrade(journal.TradeRecord{TradeID: id, Instrument: "EURUSD", Units: 1000}))
+	}
+	require.NoError(t, j.RecordEquity(journal.EquitySnapshot{Timestamp: 3600, Equity: types.MoneyFromFloat(1000)}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	f := journal.NewFollower(tradesPath, equityPathFor(tradesPath))
+	done := make(chan error)
+	go func() { done <- tailJournal(ctx, out, f, 2, time.Hour, time.Millisecond) }()
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "EQUITY") }, time.Second, time.Millisecond)
+
+	// Written while following: one trade, an equity snapshot inside the
+	// hour (skipped) and one an hour on.
+	require.NoError(t, j.RecordTrade(journal.TradeRecord{TradeID: "T4", Instrument: "USDJPY", Units: -500, RealizedPL: types.MoneyFromFloat(-1.25)}))
+	require.NoError(t, j.RecordEquity(journal.EquitySnapshot{Timestamp: 5400, Equity: types.MoneyFromFloat(999)}))
+	require.NoError(t, j.RecordEquity(journal.EquitySnapshot{Timestamp: 7200, Equity: types.MoneyFromFloat(998.75)}))
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "998.75") }, time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5, out.String())
+	assert.Contains(t, lines[0], "T2", "only the last --lines trades already there")
+	assert.Contains(t, lines[1], "T3")
+	assert.Contains(t, lines[2], "equity 1000.00")
+	assert.Contains(t, lines[3], "TRADE   USDJPY")
+	assert.Contains(t, lines[3], "-500")
+	assert.Contains(t, lines[3], "P/L -1.25")
+	assert.Contains(t, lines[4], "equity 998.75")
+}
+
+// syncBuffer is a bytes.Buffer safe to read while tailJournal writes it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestImportMT(t *testing.T) {
+	dir := t.TempDir()
+	statement := filepath.Join(dir, "statement.csv")
+	require.NoError(t, os.WriteFile(statement, []byte(
+		"Time;Position;Symbol;Type;Volume;Price;Time;Price;Commission;Swap;Profit\n"+
+			"2024.06.03 10:00:00;77;EURUSD.pro;sell;0.5;1.08000;2024.06.03 12:00:00;1.07900;-2.00;0.00;50.00\n"+
+			"2024.06.03 10:00:00;78;FIBER;buy;0.1;1.08000;2024.06.03 12:00:00;1.08100;0.00;0.00;10.00\n"+
+			"2024.06.03 10:00:00;79;GER40;buy;1;18000.0;2024.06.03 12:00:00;18010.0;0.00;0.00;10.00\n"), 0o644))
+	tradesPath := filepath.Join(dir, "mt-trades.jsonl")
+
+	args := []string{"import-mt", statement, "--journal", tradesPath, "--tz", "Europe/Athens", "--symbol", "EURUSD=FIBER"}
+	out, err := run(t, nil, args...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Imported 2 trades from "+statement)
+	assert.Contains(t, out, "skipped 1 trades on GER40")
+
+	out, err = run(t, nil, args...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Imported 0 trades", "a second import adds nothing")
+	assert.Contains(t, out, "(2 already there)")
+
+	trades, err := journal.ReadTrades(tradesPath)
+	require.NoError(t, err)
+	require.Len(t, trades, 2)
+	assert.Equal(t, "mt5-77", trades[0].TradeID)
+	assert.Equal(t, journal.MT5RunID, trades[0].RunID)
+	assert.Equal(t, types.Units(-50_000), trades[0].Units)
+	assert.Equal(t, "2024-06-03T07:00:00Z", trades[0].OpenTime.String(), "server time EEST to UTC")
+	assert.Equal(t, types.MoneyFromFloat(48), trades[0].RealizedPL)
+	assert.Equal(t, "EURUSD", trades[1].Instrument)
+}
@@ -1,5 +1,6 @@
-// Package live hosts the journal command for streaming OANDA transactions.
-// Strategy bots are managed via 'trader bot' instead.
+// Package live hosts the commands that journal OANDA transactions: streaming
+// them as they happen, and importing the account's history. Strategy bots
+// are managed via 'trader bot' instead.
 package live
 
 import (
@@ -26,6 +27,7 @@ func New(rc *config.RootConfig) *cobra.Command {
 		Short: "Live trading subsystem",
 	}
 	cmd.AddCommand(newJournalCmd(rc))
+	cmd.AddCommand(newImportCmd(rc))
 	return cmd
 }
 
@@ -51,41 +53,10 @@ func newJournalCmd(rc *config.RootConfig) *cobra.Command {
 			ctx, cancel := notifyContext(cmd.Context())
 			defer cancel()
 
-			// Token: explicit flag > global config > env var.
-			tok := token
-			if !cmd.Flags().Changed("token") {
-				if rc.OANDA.Token != "" {
-					tok = rc.OANDA.Token
-				} else {
-					tok = os.Getenv("OANDA_TOKEN")
-				}
-			}
-
-			// Account: explicit flag > global config > env var.
-			resolvedAccount := accountID
-			if !cmd.Flags().Changed("account-id") {
-				if rc.OANDA.AccountID != "" {
-					resolvedAccount = rc.OANDA.AccountID
-				} else {
-					resolvedAccount = os.Getenv("OANDA_ACCOUNT_ID")
-				}
-			}
-
-			client, err := oanda.NewClient(env, tok)
+			client, resolvedID, err := resolveAccount(ctx, cmd, rc, token, accountID, env)
 			if err != nil {
 				return err
 			}
-			resolvedID, err := accountsvc.ResolveAccountID(ctx, client, resolvedAccount)
-			if err != nil {
-				var amb accountsvc.AmbiguousAccountError
-				if errors.As(err, &amb) {
-					fmt.Println("Multiple accounts found — specify one with --account-id:")
-					for _, id := range amb.Accounts {
-						fmt.Printf("  %s\n", id)
-					}
-				}
-				return err
-			}
 			acc, err := accountsvc.Resolve(ctx, resolvedID, client, log.L)
 			if err != nil {
 				return err
@@ -124,3 +95,94 @@ func newJournalCmd(rc *config.RootConfig) *cobra.Command {
 	cmd.Flags().Int64Var(&backfillFrom, "backfill-from", 0, "If >0, poll GetTransactions from this ID before starting the stream")
 	return cmd
 }
+
+// resolveAccount builds the OANDA client and resolves the account from the
+// command's --token, --account-id and --env. Token and account each come
+// from the explicit flag, then the global config, then the environment.
+func resolveAccount(ctx context.Context, cmd *cobra.Command, rc *config.RootConfig, token, accountID, env string) (*oanda.Client, string, error) {
+	tok := token
+	if !cmd.Flags().Changed("token") {
+		if rc.OANDA.Token != "" {
+			tok = rc.OANDA.Token
+		} else {
+			tok = os.Getenv("OANDA_TOKEN")
+		}
+	}
+
+	resolvedAccount := accountID
+	if !cmd.Flags().Changed("account-id") {
+		if rc.OANDA.AccountID != "" {
+			resolvedAccount = rc.OANDA.AccountID
+		} else {
+			resolvedAccount = os.Getenv("OANDA_ACCOUNT_ID")
+		}
+	}
+
+	client, err := oanda.NewClient(env, tok)
+	if err != nil {
+		return nil, "", err
+	}
+	resolvedID, err := accountsvc.ResolveAccountID(ctx, client, resolvedAccount)
+	if err != nil {
+		var amb accountsvc.AmbiguousAccountError
+		if errors.As(err, &amb) {
+			fmt.Println("Multiple accounts found — specify one with --account-id:")
+			for _, id := range amb.Accounts {
+				fmt.Printf("  %s\n", id)
+			}
+		}
+		return nil, "", err
+	}
+	return client, resolvedID, nil
+}
+
+func newImportCmd(rc *config.RootConfig) *cobra.Command {
+	var (
+		accountID  string
+		token      string
+		env        string
+		tradesPath string
+		sinceID    int64
+	)
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import the account's OANDA trade history into a trades journal",
+		Long: `Read the account's transaction history from OANDA and append every trade
+it closed to a trades journal (JSONL, or CSV for a .csv path), tagged with
+run ID live-oanda, so real trading is analyzed with the same journal tooling
+as backtests.
+
+--since imports only transactions after that ID; the last transaction ID is
+printed at the end to pass next time. A trade whose open came before
+--since is kept with what its close carries, and trades still open are left
+for a later import. Trades already in the journal are not written again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := notifyContext(cmd.Context())
+			defer cancel()
+
+			client, resolvedID, err := resolveAccount(ctx, cmd, rc, token, accountID, env)
+			if err != nil {
+				return err
+			}
+			trades, lastID, err := journalpkg.ImportOANDA(ctx, client, resolvedID, sinceID, log.L)
+			if err != nil {
+				return err
+			}
+			n, err := journalpkg.AppendNewTrades(tradesPath, trades)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Imported %d trades into %s (%d already there). Last transaction ID %d.\n",
+				n, tradesPath, len(trades)-n, lastID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountID, "account-id", os.Getenv("OANDA_ACCOUNT_ID"), "OANDA account ID (auto-discovered if omitted)")
+	cmd.Flags().StringVar(&token, "token", os.Getenv("OANDA_TOKEN"), "OANDA API token (falls back to ~/.config/oanda/pat.txt)")
+	cmd.Flags().StringVar(&env, "env", "practice", "OANDA environment: practice|live")
+	cmd.Flags().StringVar(&tradesPath, "trades-file", "live-trades.jsonl", "Trades journal to append to (JSONL, or CSV for a .csv path)")
+	cmd.Flags().Int64Var(&sinceID, "since", 0, "Import only transactions after this ID; 0 imports the whole history")
+	return cmd
+}
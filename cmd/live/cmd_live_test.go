@@ -23,6 +23,7 @@ func TestNew_HasJournalSubcommand(t *testing.T) {
 		names[sub.Name()] = true
 	}
 	assert.True(t, names["journal"], "expected 'journal' subcommand")
+	assert.True(t, names["import"], "expected 'import' subcommand")
 }
 
 // ── journal command flags ─────────────────────────────────────────────────────
@@ -117,6 +118,27 @@ func TestJournalCmd_EnvTokenUsedWhenFlagAndRCAreEmpty(t *testing.T) {
 	assert.NotContains(t, err.Error(), "no OANDA token")
 }
 
+// ── import command ───────────────────────────────────────────────────────────
+
+func TestImportCmd_HasExpectedFlags(t *testing.T) {
+	cmd := newImportCmd(&config.RootConfig{})
+	for _, name := range []string{"account-id", "token", "env", "trades-file", "since"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "expected flag --%s", name)
+	}
+	assert.Equal(t, "live-trades.jsonl", cmd.Flags().Lookup("trades-file").DefValue)
+	assert.Equal(t, "0", cmd.Flags().Lookup("since").DefValue)
+}
+
+func TestImportCmd_NoToken_ReturnsError(t *testing.T) {
+	t.Setenv("OANDA_TOKEN", "")
+	t.Setenv("HOME", t.TempDir())
+	cmd := newImportCmd(&config.RootConfig{})
+	cmd.SetContext(context.Background())
+	err := cmd.RunE(cmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token")
+}
+
 // ── notifyContext ─────────────────────────────────────────────────────────────
 
 func TestNotifyContext_ReturnsCancellableContext(t *testing.T) {
@@ -5,21 +5,28 @@ import (
 	"os"
 
 	"github.com/rustyeddy/trader/cmd/account"
+	"github.com/rustyeddy/trader/cmd/analyze"
 	"github.com/rustyeddy/trader/cmd/backtest"
 	"github.com/rustyeddy/trader/cmd/bot"
+	cmdconfig "github.com/rustyeddy/trader/cmd/config"
 	"github.com/rustyeddy/trader/cmd/data"
 	cmddocs "github.com/rustyeddy/trader/cmd/docs"
 	"github.com/rustyeddy/trader/cmd/health"
+	cmdjournal "github.com/rustyeddy/trader/cmd/journal"
 	"github.com/rustyeddy/trader/cmd/live"
 	cmdmcp "github.com/rustyeddy/trader/cmd/mcp"
 	"github.com/rustyeddy/trader/cmd/order"
+	cmdreconcile "github.com/rustyeddy/trader/cmd/reconcile"
 	"github.com/rustyeddy/trader/cmd/replay"
+	cmdreport "github.com/rustyeddy/trader/cmd/report"
 	cmdreview "github.com/rustyeddy/trader/cmd/review"
 	"github.com/rustyeddy/trader/cmd/serve"
 	cmdsignalreplay "github.com/rustyeddy/trader/cmd/signalreplay"
 	"github.com/rustyeddy/trader/config"
 	"github.com/rustyeddy/trader/datamanager"
 	"github.com/rustyeddy/trader/log"
+	"github.com/rustyeddy/trader/symbols"
+	"github.com/rustyeddy/trader/types"
 	"github.com/spf13/cobra"
 
 	// Provider registration via init().
@@ -27,10 +34,12 @@ import (
 
 	// Strategy registration via init().
 	_ "github.com/rustyeddy/trader/strategies/bollingerfade"
+	_ "github.com/rustyeddy/trader/strategies/breakout"
 	_ "github.com/rustyeddy/trader/strategies/donchian"
 	_ "github.com/rustyeddy/trader/strategies/emacross"
 	_ "github.com/rustyeddy/trader/strategies/emacrossadx"
 	_ "github.com/rustyeddy/trader/strategies/fake"
+	_ "github.com/rustyeddy/trader/strategies/grid"
 	_ "github.com/rustyeddy/trader/strategies/lifecycle"
 	_ "github.com/rustyeddy/trader/strategies/noop"
 	_ "github.com/rustyeddy/trader/strategies/pulse"
@@ -58,9 +67,22 @@ func NewRootCmd() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&rc.LogLevel, "log-level", "debug", "Log level: debug|info|warn|error")
 	cmd.PersistentFlags().StringVar(&rc.LogFormat, "log-format", "text", "Log format: text|json")
 	cmd.PersistentFlags().StringVar(&rc.LogFile, "log-file", "./trader.log", "Path to log file (written in addition to stdout)")
+	cmd.PersistentFlags().BoolVar(&rc.Quiet, "quiet", false, "Log warnings and errors only (overrides --log-level)")
+	cmd.PersistentFlags().BoolVar(&rc.Verbose, "verbose", false, "Log everything down to debug (overrides --log-level)")
 	cmd.PersistentFlags().BoolVar(&rc.NoColor, "no-color", false, "Disable colored output")
+	cmd.PersistentFlags().StringVar(&rc.Output, "output", config.OutputText, "Result format: text|json")
+
+	_ = cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+		[]string{config.OutputText, config.OutputJSON}, cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions(
+		[]string{"debug", "info", "warn", "error"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("log-format", cobra.FixedCompletions(
+		[]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
 
 	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if rc.Output != config.OutputText && rc.Output != config.OutputJSON {
+			return fmt.Errorf("bad --output %q (use text or json)", rc.Output)
+		}
 		// Load global config files, then apply fields that the user did not
 		// explicitly set via CLI flags (flags always win).
 		globalConfigPath := rc.ConfigPath
@@ -75,6 +97,9 @@ func NewRootCmd() *cobra.Command {
 		if !flags.Changed("log-level") && gcfg.Log.Level != "" {
 			rc.LogLevel = gcfg.Log.Level
 		}
+		if err := rc.ResolveLogLevel(); err != nil {
+			return err
+		}
 		if !flags.Changed("log-format") && gcfg.Log.Format != "" {
 			rc.LogFormat = gcfg.Log.Format
 		}
@@ -90,6 +115,16 @@ func NewRootCmd() *cobra.Command {
 		// OANDA creds have no root-level CLI flags; always take from global config.
 		rc.OANDA = gcfg.OANDA
 		rc.ReviewThresholds = gcfg.Review.ToThresholds()
+		if err := symbols.Load(gcfg.Symbols); err != nil {
+			return fmt.Errorf("global config: %w", err)
+		}
+		if gcfg.Market.Rollover != "" {
+			r, err := types.ParseRollover(gcfg.Market.Rollover)
+			if err != nil {
+				return fmt.Errorf("global config: market: %w", err)
+			}
+			types.SetRollover(r)
+		}
 
 		datamanager.SetDataDir(rc.DataDir)
 		return log.Setup(log.LogConfig{
@@ -103,17 +138,22 @@ func NewRootCmd() *cobra.Command {
 	// Subcommands
 	cmd.AddCommand(
 		account.New(rc),
+		analyze.New(rc),
 		cmdreview.New(rc),
 		backtest.New(rc),
 		bot.New(rc),
+		cmdconfig.New(rc),
 		cmddocs.New(rc),
 		health.New(rc),
+		cmdjournal.New(rc),
 		cmdmcp.New(rc),
 		serve.New(rc),
 		data.New(rc),
 		live.New(rc),
 		order.New(rc),
+		cmdreconcile.New(rc),
 		replay.New(rc),
+		cmdreport.New(rc),
 		cmdsignalreplay.New(rc),
 	)
 
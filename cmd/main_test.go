@@ -27,7 +27,7 @@ func TestNewRootCmd_HasExpectedSubcommands(t *testing.T) {
 	for _, sub := range cmd.Commands() {
 		names[sub.Name()] = true
 	}
-	for _, want := range []string{"backtest", "bot", "data", "health", "serve", "live", "account", "replay", "version"} {
+	for _, want := range []string{"analyze", "backtest", "bot", "config", "data", "health", "serve", "live", "account", "replay", "version"} {
 		assert.True(t, names[want], "expected subcommand %q", want)
 	}
 }
@@ -66,3 +66,31 @@ func TestNewRootCmd_VersionExecutes(t *testing.T) {
 	cmd.PersistentPreRunE = nil
 	require.NoError(t, cmd.Execute())
 }
+
+func TestNewRootCmd_RejectsBadOutput(t *testing.T) {
+	cmd := NewRootCmd()
+	cmd.SetArgs([]string{"--output", "xml", "--log-file", "", "version"})
+	require.ErrorContains(t, cmd.Execute(), `bad --output "xml"`)
+}
+
+func TestNewRootCmd_CompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		cmd := NewRootCmd()
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"completion", shell})
+		cmd.PersistentPreRunE = nil
+		require.NoError(t, cmd.Execute(), shell)
+		assert.Contains(t, buf.String(), "trader", shell)
+	}
+}
+
+func TestNewRootCmd_CompletesOutputValues(t *testing.T) {
+	cmd := NewRootCmd()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"__complete", "--output", ""})
+	cmd.PersistentPreRunE = nil
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "text\njson\n")
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/rustyeddy/trader/brokers/oanda"
 	"github.com/rustyeddy/trader/config"
 	"github.com/rustyeddy/trader/log"
+	"github.com/rustyeddy/trader/market"
 	accountsvc "github.com/rustyeddy/trader/service/account"
 	"github.com/rustyeddy/trader/types"
 )
@@ -36,6 +37,7 @@ var (
 	env        string
 	tradeID    string
 	closeUnits int64
+	audit      bool
 )
 
 func New(rc *config.RootConfig) *cobra.Command {
@@ -121,6 +123,7 @@ func newOrderCmd(rc *config.RootConfig) *cobra.Command {
 	cmd.Flags().StringVar(&side, "side", "", "Trade direction: long or short (required)")
 	cmd.Flags().Float64Var(&riskPct, "risk-pct", 1.0, "Percent of account equity to risk per trade")
 	cmd.Flags().Float64Var(&stopPips, "stop-pips", 0, "Stop distance in pips (required)")
+	cmd.Flags().BoolVar(&audit, "audit", false, "Print every intermediate quantity of the position sizing")
 	addCommonFlags(cmd)
 	_ = cmd.MarkFlagRequired("instrument")
 	_ = cmd.MarkFlagRequired("side")
@@ -151,6 +154,9 @@ func runNewOrder(cmd *cobra.Command, args []string, rc *config.RootConfig) error
 	}
 
 	printProposal(env, preview.Proposal, riskPct)
+	if audit && preview.Proposal.Sizing != nil {
+		printSizingAudit(*preview.Proposal.Sizing)
+	}
 
 	fmt.Print("Submit order? [y/N] ")
 	reader := bufio.NewReader(os.Stdin)
@@ -178,7 +184,7 @@ func runNewOrder(cmd *cobra.Command, args []string, rc *config.RootConfig) error
 	fmt.Printf("  Order ID : %s\n", final.Filled.OrderID)
 	fmt.Printf("  Trade ID : %s\n", final.Filled.TradeID)
 	fmt.Printf("  Units    : %d\n", final.Filled.Units)
-	fmt.Printf("  Price    : %.5f\n", final.Filled.Price)
+	fmt.Printf("  Price    : %s\n", market.FormatPrice(instrument, final.Filled.Price))
 	return nil
 }
 
@@ -189,8 +195,8 @@ func printProposal(env string, p account.OrderProposal, riskPct float64) {
 	fmt.Println("├─────────────────────────────────────────────────┤")
 	fmt.Printf("│  Instrument   : %-32s│\n", p.Instrument)
 	fmt.Printf("│  Side         : %-32s│\n", strings.ToUpper(p.Side))
-	fmt.Printf("│  Entry price  : %-32.5f│\n", p.EntryPrice)
-	fmt.Printf("│  Stop price   : %-32.5f│\n", p.StopPrice)
+	fmt.Printf("│  Entry price  : %-32s│\n", market.FormatPrice(p.Instrument, p.EntryPrice))
+	fmt.Printf("│  Stop price   : %-32s│\n", market.FormatPrice(p.Instrument, p.StopPrice))
 	fmt.Printf("│  Units        : %-32d│\n", p.Units)
 	fmt.Println("├─────────────────────────────────────────────────┤")
 	fmt.Printf("│  Account NAV  : $%-31.2f│\n", p.AccountNAV)
@@ -200,6 +206,25 @@ func printProposal(env string, p account.OrderProposal, riskPct float64) {
 	fmt.Println()
 }
 
+// printSizingAudit prints the sizing's intermediate quantities, one per
+// line, in the units a trader checks them in.
+func printSizingAudit(a account.SizingAudit) {
+	inst := a.Instrument
+	fmt.Println("Sizing audit")
+	fmt.Printf("  Pip size          : %s\n", market.FormatPrice(inst, a.PipSize.Float64()))
+	fmt.Printf("  Stop distance     : %s (%.1f pips)\n", market.FormatPrice(inst, a.StopDistance.Float64()), a.StopPips.Float64())
+	fmt.Printf("  Quote→%s rate    : %s\n", a.Currency, a.QuoteToAccount)
+	fmt.Printf("  Pip value / lot   : %.2f %s\n", a.PipValue.Float64(), a.Currency)
+	fmt.Printf("  Risk budget       : %.2f %s (%.2f%% of %.2f)\n", a.RiskBudget.Float64(), a.Currency, a.RiskFraction.Float64()*100, a.Equity.Float64())
+	fmt.Printf("  Loss per unit     : %s %s\n", a.LossPerUnit, a.Currency)
+	fmt.Printf("  Margin per unit   : %s %s (rate %s, free %.2f)\n", a.MarginPerUnit, a.Currency, a.MarginRate, a.FreeMargin.Float64())
+	fmt.Printf("  Units by risk     : %d\n", a.UnitsByRisk)
+	fmt.Printf("  Units by margin   : %d\n", a.UnitsByMargin)
+	fmt.Printf("  Minimum size      : %d\n", a.MinimumTradeSize)
+	fmt.Printf("  Sized units       : %d\n", a.Units)
+	fmt.Println()
+}
+
 // ── order close ───────────────────────────────────────────────────────────
 
 func closeOrderCmd(rc *config.RootConfig) *cobra.Command {
@@ -300,7 +325,7 @@ func transactionsCmd(rc *config.RootConfig) *cobra.Command {
 				timeStr := t.Time.Format("2006-01-02 15:04")
 				priceStr := "—"
 				if t.Price != 0 {
-					priceStr = fmt.Sprintf("%.5f", t.Price)
+					priceStr = market.FormatPrice(t.Instrument, t.Price)
 				}
 				plStr := "—"
 				if t.PL != 0 {
@@ -362,7 +387,7 @@ func transactionsStreamCmd(rc *config.RootConfig) *cobra.Command {
 				t := ev.Tx
 				priceStr := "—"
 				if t.Price != 0 {
-					priceStr = fmt.Sprintf("%.5f", t.Price)
+					priceStr = market.FormatPrice(t.Instrument, t.Price)
 				}
 				plStr := ""
 				if t.PL != 0 {
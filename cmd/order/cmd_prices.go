@@ -10,7 +10,9 @@ import (
 
 	"github.com/rustyeddy/trader/brokers/oanda"
 	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
 )
 
 func pricesCmd(rc *config.RootConfig) *cobra.Command {
@@ -42,9 +44,9 @@ Defaults to all seven major pairs. Supply --instruments to restrict the list.`,
 			for _, name := range names {
 				inst := market.GetInstrument(name)
 				if inst == nil {
-					return fmt.Errorf("unknown instrument: %s", name)
+					return fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, name)
 				}
-				oandaNames = append(oandaNames, inst.BaseCurrency+"_"+inst.QuoteCurrency)
+				oandaNames = append(oandaNames, symbols.ToProvider(symbols.OANDA, inst.Name))
 			}
 
 			prices, err := client.GetPricing(ctx, resolvedAccountID, oandaNames...)
@@ -72,7 +74,7 @@ func printPrices(prices []oanda.Price, units int64, envName string) {
 		"──────────", "──────────", "──────────", "──────────", "──────────", "────────────")
 
 	for _, p := range prices {
-		traderName := strings.ReplaceAll(p.Instrument, "_", "")
+		traderName := symbols.FromProvider(symbols.OANDA, p.Instrument)
 		inst := market.GetInstrument(traderName)
 		if inst == nil {
 			fmt.Printf("%-10s  %10.5f  %10.5f  %10.5f\n", traderName, p.Bid, p.Ask, p.Mid)
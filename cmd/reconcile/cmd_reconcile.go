@@ -0,0 +1,167 @@
+// Package reconcile provides the "reconcile" CLI command, which checks a
+// live or paper broker account against its journal.
+package reconcile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/log"
+	accountsvc "github.com/rustyeddy/trader/service/account"
+	reconcilesvc "github.com/rustyeddy/trader/service/reconcile"
+	"github.com/rustyeddy/trader/types"
+)
+
+// ErrDiverged is returned when a check finds broker and journal disagree,
+// so scripts and cron jobs can act on the exit status.
+var ErrDiverged = errors.New("broker and journal diverge")
+
+// New returns the "reconcile" cobra command.
+func New(rc *config.RootConfig) *cobra.Command {
+	var (
+		broker           string
+		accountID        string
+		env              string
+		journalTrades    string
+		rotate           string
+		statePath        string
+		balanceTolerance float64
+		marginTolerance  float64
+		webhook          string
+		every            time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Check a live/paper broker account against the live journal",
+		Long: `Compare the broker's open trades, balance and margin with the live
+journal and report where they diverge.
+
+Each check saves the broker state it saw to --state; the next check
+measures the broker's changes since then against the trades the journal
+recorded in between:
+
+  open-but-journaled-closed  a trade the broker holds open that the
+                             journal recorded closed
+  closed-not-journaled       a trade gone from the broker with no
+                             journal record of its close
+  units-changed              a trade partially closed or added to
+                             with no journal record
+  balance-mismatch           the balance moved by more than the journal
+                             realized, beyond --balance-tolerance
+  margin-mismatch            margin moved beyond --margin-tolerance with
+                             the same trades open, or is in use with none
+
+The first check (no state file yet) can only compare open trades with
+the journal's closes; it records the baseline for the next.
+
+Divergences are logged and, with --alert-webhook, POSTed as a JSON
+report. With --every the check repeats until interrupted; otherwise it
+runs once and exits non-zero if anything diverged. trader serve runs the
+same check periodically (see --reconcile-every).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			targetBroker, resolvedID, err := accountsvc.ResolveTarget(broker, cmd.Flags().Changed("broker"),
+				accountID, cmd.Flags().Changed("account-id"), rc.OANDA.AccountID)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("env") && rc.OANDA.Env != "" {
+				env = rc.OANDA.Env
+			}
+			tok := rc.OANDA.Token
+			if tok == "" {
+				tok = os.Getenv("OANDA_TOKEN")
+			}
+			b, err := accountsvc.NewBroker(targetBroker, env, tok)
+			if err != nil {
+				return err
+			}
+			if resolvedID, err = accountsvc.ResolveAccountID(ctx, b, resolvedID); err != nil {
+				return err
+			}
+
+			r := &reconcilesvc.Reconciler{
+				Broker:    b,
+				AccountID: resolvedID,
+				Journal:   journal.Config{TradesPath: journalTrades, Rotate: rotate},
+				StatePath: statePath,
+				Tolerance: reconcilesvc.Tolerance{
+					Balance: types.MoneyFromFloat(balanceTolerance),
+					Margin:  types.RateFromFloat(marginTolerance),
+				},
+				Log: log.L,
+			}
+			if webhook != "" {
+				r.Sinks = append(r.Sinks, reconcilesvc.WebhookSink{URL: webhook})
+			}
+
+			if every > 0 {
+				r.Run(ctx, every)
+				return nil
+			}
+			rep, err := r.Check(ctx)
+			if err != nil {
+				return err
+			}
+			if rc.JSONOutput() {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(rep); err != nil {
+					return err
+				}
+			} else {
+				printReport(cmd.OutOrStdout(), rep)
+			}
+			if !rep.OK() {
+				return ErrDiverged
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&broker, "broker", "oanda", "Broker to target")
+	cmd.Flags().StringVar(&accountID, "account-id", "", "Account ID to target")
+	cmd.Flags().StringVar(&env, "env", "practice", "OANDA environment: practice|live")
+	cmd.Flags().StringVar(&journalTrades, "journal-trades", "./live-trades.jsonl", "Live journal trade-record path")
+	cmd.Flags().StringVar(&rotate, "journal-rotate", "", "Journal rotation the live run uses: daily|weekly|monthly (default none)")
+	cmd.Flags().StringVar(&statePath, "state", "./reconcile-state.json", "File holding the broker state the last check saw")
+	cmd.Flags().Float64Var(&balanceTolerance, "balance-tolerance", reconcilesvc.DefaultTolerance.Balance.Float64(), "Balance drift accepted, in account currency (financing, fees)")
+	cmd.Flags().Float64Var(&marginTolerance, "margin-tolerance", reconcilesvc.DefaultTolerance.Margin.Float64(), "Fractional margin move accepted while the open trades are unchanged")
+	cmd.Flags().StringVar(&webhook, "alert-webhook", "", "URL to POST a JSON report to when a check diverges")
+	cmd.Flags().DurationVar(&every, "every", 0, "Repeat the check at this interval until interrupted (default: run once)")
+	return cmd
+}
+
+func printReport(w io.Writer, rep reconcilesvc.Report) {
+	fmt.Fprintf(w, "Account %s  balance %.2f %s  margin used %.2f  open trades %d\n",
+		rep.AccountID, rep.Balance, rep.Currency, rep.MarginUsed, rep.OpenTrades)
+	if rep.Baseline.IsZero() {
+		fmt.Fprintln(w, "No baseline yet: compared open trades with the journal only.")
+	} else {
+		fmt.Fprintf(w, "Since %s: %d journaled closes\n", rep.Baseline.Format(time.RFC3339), rep.Journaled)
+	}
+	if rep.OK() {
+		fmt.Fprintln(w, "OK: broker and journal agree")
+		return
+	}
+	fmt.Fprintf(w, "%d divergences:\n", len(rep.Divergences))
+	for _, d := range rep.Divergences {
+		subject := d.TradeID
+		if d.Instrument != "" {
+			subject += " " + d.Instrument
+		}
+		if subject == "" {
+			subject = "-"
+		}
+		fmt.Fprintf(w, "  %-26s %-18s %s\n", d.Kind, subject, d.Detail)
+	}
+}
@@ -1,6 +1,7 @@
 package replay
 
 import (
+	"bufio"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rustyeddy/trader/log"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
@@ -28,6 +30,13 @@ type CSVEventsFeed struct {
 	from types.Timestamp
 	to   types.Timestamp
 
+	// Sanitizer, when set, screens each row's tick before it is returned.
+	// A rejected row is dropped whole, scripted event included, and logged.
+	Sanitizer *market.TickSanitizer
+
+	meta    market.TickMeta
+	hasMeta bool
+
 	sawFirst bool
 }
 
@@ -36,9 +45,20 @@ func NewCSVEventsFeed(path string, from, to types.Timestamp) (*CSVEventsFeed, er
 	if err != nil {
 		return nil, err
 	}
-	r := csv.NewReader(f)
+	br := bufio.NewReader(f)
+	meta, hasMeta, err := market.ReadTickMeta(br)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r := csv.NewReader(br)
 	r.FieldsPerRecord = -1
-	return &CSVEventsFeed{f: f, r: r, from: from, to: to}, nil
+	return &CSVEventsFeed{f: f, r: r, from: from, to: to, meta: meta, hasMeta: hasMeta}, nil
+}
+
+// Meta returns the file's metadata line; ok is false when it has none.
+func (f *CSVEventsFeed) Meta() (meta market.TickMeta, ok bool) {
+	return f.meta, f.hasMeta
 }
 
 func (f *CSVEventsFeed) Close() error {
@@ -76,16 +96,35 @@ func (f *CSVEventsFeed) Next() (EventRow, bool, error) {
 			continue
 		}
 
-		p, ok, err := parseTickRowCompat(row)
+		parse := parseTickRowCompat
+		if f.Sanitizer != nil {
+			parse = parseTickFieldsCompat
+		}
+		p, ok, err := parse(row)
 		if err != nil {
 			return EventRow{}, false, err
 		}
 		if !ok {
 			continue
 		}
+		if !f.meta.Covers(p.Instrument) {
+			return EventRow{}, false, fmt.Errorf("tick for %s at %s: file metadata lists only %s",
+				p.Instrument, p.Timestamp, strings.Join(f.meta.Instruments, ","))
+		}
 		if !inRange(p.Timestamp, f.from, f.to) {
 			continue
 		}
+		if f.Sanitizer != nil {
+			v, keep, err := f.Sanitizer.Apply(p)
+			if err != nil {
+				return EventRow{}, false, err
+			}
+			if !keep {
+				log.L.Warn("replay: dropping bad tick", "instrument", p.Instrument, "time", p.Timestamp.String(),
+					"bid", p.Bid.String(), "ask", p.Ask.String(), "violation", string(v), "event", strings.TrimSpace(valueAt(row, 4)))
+				continue
+			}
+		}
 
 		ev := ""
 		p1, p2, p3, p4 := "", "", "", ""
@@ -132,6 +171,19 @@ func inRange(t, from, to types.Timestamp) bool {
 // parseTickRowCompat duplicates minimal parsing soreplay doesn't import backtest.
 // (Avoids internal package coupling.)
 func parseTickRowCompat(row []string) (market.Tick, bool, error) {
+	tick, ok, err := parseTickFieldsCompat(row)
+	if err != nil || !ok {
+		return tick, ok, err
+	}
+	if err := tick.Validate(); err != nil {
+		return market.Tick{}, false, err
+	}
+	return tick, true, nil
+}
+
+// parseTickFieldsCompat is parseTickRowCompat without Tick.Validate, for
+// feeds whose Sanitizer classifies bad quotes itself.
+func parseTickFieldsCompat(row []string) (market.Tick, bool, error) {
 	// time,instrument,bid,ask
 	ts := strings.TrimSpace(row[0])
 	if ts == "" {
@@ -160,18 +212,21 @@ func parseTickRowCompat(row []string) (market.Tick, bool, error) {
 		return market.Tick{}, false, fmt.Errorf("bad ask %q: %w", row[3], err)
 	}
 
-	tick := market.Tick{
+	return market.Tick{
 		Timestamp:  types.FromTime(t),
 		Instrument: inst,
 		BA: market.BA{
 			Bid: types.PriceFromFloat(bid),
 			Ask: types.PriceFromFloat(ask),
 		},
+	}, true, nil
+}
+
+func valueAt(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
 	}
-	if err := tick.Validate(); err != nil {
-		return market.Tick{}, false, err
-	}
-	return tick, true, nil
+	return ""
 }
 
 func parseFloat(s string) (float64, error) {
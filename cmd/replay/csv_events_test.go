@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -229,3 +231,53 @@ func TestCSVEventsFeed_MultipleRows(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, ok)
 }
+
+func TestCSVEventsFeed_SanitizerDropsBadRows(t *testing.T) {
+	t1 := rfc3339(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	t2 := rfc3339(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC))
+	t3 := rfc3339(time.Date(2024, 1, 1, 0, 0, 2, 0, time.UTC))
+	csv := t1 + ",EURUSD,1.0800,1.0802\n" +
+		t2 + ",EURUSD,1.0802,1.0800,OPEN,1000\n" +
+		t3 + ",EURUSD,1.0801,1.0803\n"
+	feed, err := NewCSVEventsFeed(writeCSV(t, csv), 0, 0)
+	require.NoError(t, err)
+	defer feed.Close()
+	feed.Sanitizer = &market.TickSanitizer{}
+
+	var rows []EventRow
+	for {
+		row, ok, err := feed.Next()
+		require.NoError(t, err)
+		if !ok {
+			break
+		}
+		rows = append(rows, row)
+	}
+	require.Len(t, rows, 2)
+	assert.Empty(t, rows[1].Event, "the crossed row is dropped along with its event")
+	assert.Equal(t, 1, feed.Sanitizer.Stats().Crossed)
+}
+
+func TestTickFilterFlags_Sanitizer(t *testing.T) {
+	f := tickFilterFlags{maxSpreadPips: 3, maxJumpPips: 50, badTicks: "log"}
+	s, err := f.sanitizer()
+	require.NoError(t, err)
+	assert.Equal(t, types.PipsFromFloat(3), s.MaxSpreadPips)
+	assert.Equal(t, types.PipsFromFloat(50), s.MaxJumpPips)
+	assert.Equal(t, market.TickPolicyLog, s.Policy)
+
+	_, err = (&tickFilterFlags{badTicks: "ignore"}).sanitizer()
+	require.Error(t, err)
+
+	_, err = (&tickFilterFlags{maxSpreadPips: -1, badTicks: "fail"}).sanitizer()
+	require.Error(t, err)
+
+	// Unset, the flag keeps the sanitizer's own default policy.
+	var flags tickFilterFlags
+	cmd := &cobra.Command{}
+	flags.register(cmd)
+	require.NoError(t, cmd.ParseFlags(nil))
+	s, err = flags.sanitizer()
+	require.NoError(t, err)
+	assert.Equal(t, market.TickPolicyLog, s.Policy)
+}
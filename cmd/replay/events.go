@@ -3,15 +3,18 @@ package replay
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/backtest"
 	"github.com/rustyeddy/trader/brokers/sim"
 	"github.com/rustyeddy/trader/config"
-	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -25,6 +28,15 @@ func newEventsCmd(rc *config.RootConfig) *cobra.Command {
 
 		fromStr string
 		toStr   string
+
+		filter tickFilterFlags
+		state  stateFlags
+		jrnl   journalFlags
+		record recordFlags
+		expect expectFlags
+		script scenarioFlags
+		stress stressFlags
+		pace   string
 	)
 
 	cmd := &cobra.Command{
@@ -67,15 +79,40 @@ func newEventsCmd(rc *config.RootConfig) *cobra.Command {
 			if !from.IsZero() && !to.IsZero() && !from.Before(to) {
 				return fmt.Errorf("-from must be before -to")
 			}
+			sanitizer, err := filter.sanitizer()
+			if err != nil {
+				return err
+			}
+			pacer, err := backtest.ParsePace(pace)
+			if err != nil {
+				return err
+			}
+			rec, err := record.recorder()
+			if err != nil {
+				return err
+			}
+			scenario, err := script.load()
+			if err != nil {
+				return err
+			}
+			shocks, err := stress.load()
+			if err != nil {
+				return err
+			}
 
 			ctx := context.Background()
+			// Ctrl-C stops the loop at the next tick; the state save,
+			// recording, and journal close below still run, on ctx.
+			sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			var interruptedAt types.Timestamp
 
-			tradesPath, equityPath := journal.JournalRecordPaths(rc.DBPath)
-			j, err := journal.NewJSON(tradesPath, equityPath)
+			j, stopRotate, err := jrnl.open(rc.DBPath)
 			if err != nil {
 				return err
 			}
 			defer j.Close()
+			defer stopRotate()
 
 			engine := sim.NewSimBroker(&account.Account{
 				ID:       accountID,
@@ -83,12 +120,22 @@ func newEventsCmd(rc *config.RootConfig) *cobra.Command {
 				Balance:  types.MoneyFromFloat(startingBalance),
 				Equity:   types.MoneyFromFloat(startingBalance),
 			}, j)
+			if err := state.restore(engine, rc.DBPath); err != nil {
+				return err
+			}
+			if err := scenario.Allocate(engine); err != nil {
+				return err
+			}
 
-			feed, err := NewCSVEventsFeed(path, types.FromTime(from), types.FromTime(to))
+			feed, err := NewCSVEventsFeed(path, feedBound(from), feedBound(to))
 			if err != nil {
 				return err
 			}
 			defer feed.Close()
+			if err := expect.check(feed.Meta()); err != nil {
+				return err
+			}
+			feed.Sanitizer = sanitizer
 
 			for {
 				row, ok, err := feed.Next()
@@ -98,10 +145,36 @@ func newEventsCmd(rc *config.RootConfig) *cobra.Command {
 				if !ok {
 					break
 				}
+				if sigCtx.Err() != nil {
+					interruptedAt = row.Tick.Timestamp
+					break
+				}
 
+				if err := pacer.Wait(sigCtx, row.Tick.Timestamp); err != nil {
+					if sigCtx.Err() != nil {
+						interruptedAt = row.Tick.Timestamp
+						break
+					}
+					return err
+				}
+				row.Tick = scenario.Shock(row.Tick)
+				if row.Tick, err = shocks.Apply(ctx, engine, accountID, row.Tick); err != nil {
+					return err
+				}
 				if err := engine.UpdatePrice(row.Tick); err != nil {
 					return err
 				}
+				if rec != nil {
+					if err := rec.Record(row.Tick); err != nil {
+						return err
+					}
+				}
+				if err := scenario.Apply(ctx, engine, accountID, row.Tick); err != nil {
+					return err
+				}
+				if err := shocks.Observe(ctx, engine, accountID, row.Tick); err != nil {
+					return err
+				}
 
 				if strings.TrimSpace(row.Event) != "" {
 					if err := applyEvent(ctx, engine, row); err != nil {
@@ -113,9 +186,21 @@ func newEventsCmd(rc *config.RootConfig) *cobra.Command {
 			if closeEnd {
 				_ = engine.CloseAll(ctx, "EndOfReplay")
 			}
+			if err := state.save(engine, rc.DBPath); err != nil {
+				return err
+			}
+			if err := record.finish(rec); err != nil {
+				return err
+			}
+			script.report(scenario)
+			stress.report(shocks)
 
 			acct, _ := engine.GetAccount(ctx)
 			fmt.Printf("Done. balance=%.2f equity=%.2f\n", acct.Balance.Float64(), acct.Equity.Float64())
+			fmt.Printf("Ticks: %s\n", sanitizer.Stats())
+			if interruptedAt != 0 {
+				return fmt.Errorf("interrupted at %s; state and journal saved", interruptedAt)
+			}
 			return nil
 		},
 	}
@@ -127,6 +212,14 @@ func newEventsCmd(rc *config.RootConfig) *cobra.Command {
 
 	cmd.Flags().StringVar(&fromStr, "from", "", "Optional RFC3339 start time")
 	cmd.Flags().StringVar(&toStr, "to", "", "Optional RFC3339 end time")
+	filter.register(cmd)
+	state.register(cmd)
+	jrnl.register(cmd)
+	record.register(cmd)
+	expect.register(cmd)
+	script.register(cmd)
+	stress.register(cmd)
+	cmd.Flags().StringVar(&pace, "pace", "max", "Replay pacing: max, realtime, <n>x (e.g. 60x), or <n>/s ticks per second")
 
 	return cmd
 }
@@ -3,6 +3,11 @@ package replay
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -11,7 +16,7 @@ import (
 	"github.com/rustyeddy/trader/backtest"
 	"github.com/rustyeddy/trader/brokers/sim"
 	"github.com/rustyeddy/trader/config"
-	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -25,11 +30,22 @@ func newPricingCmd(rc *config.RootConfig) *cobra.Command {
 
 		fromStr string
 		toStr   string
+
+		filter tickFilterFlags
+		order  tickOrderFlags
+		state  stateFlags
+		jrnl   journalFlags
+		record recordFlags
+		expect expectFlags
+		script scenarioFlags
+		stress stressFlags
+		pace   string
+		seed   int
 	)
 
 	cmd := &cobra.Command{
 		Use:   "pricing",
-		Short: "Replay pricing ticks from CSV (time,instrument,bid,ask[,event,p1,p2,p3,p4])",
+		Short: "Replay pricing ticks from CSV (time,instrument,bid,ask[,event,p1,p2,p3,p4]) or a tick archive",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if ticksPath == "" {
 				return fmt.Errorf("-ticks is required")
@@ -68,15 +84,40 @@ func newPricingCmd(rc *config.RootConfig) *cobra.Command {
 			if !from.IsZero() && !to.IsZero() && !from.Before(to) {
 				return fmt.Errorf("-from must be before -to")
 			}
+			sanitizer, err := filter.sanitizer()
+			if err != nil {
+				return err
+			}
+			pacer, err := backtest.ParsePace(pace)
+			if err != nil {
+				return err
+			}
+			rec, err := record.recorder()
+			if err != nil {
+				return err
+			}
+			scenario, err := script.load()
+			if err != nil {
+				return err
+			}
+			shocks, err := stress.load()
+			if err != nil {
+				return err
+			}
 
 			ctx := context.Background()
+			// Ctrl-C stops the loop at the next tick; the state save,
+			// recording, and journal close below still run, on ctx.
+			sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			var interruptedAt types.Timestamp
 
-			tradesPath, equityPath := journal.JournalRecordPaths(rc.DBPath)
-			j, err := journal.NewJSON(tradesPath, equityPath)
+			j, stopRotate, err := jrnl.open(rc.DBPath)
 			if err != nil {
 				return err
 			}
 			defer j.Close()
+			defer stopRotate()
 
 			engine := sim.NewSimBroker(&account.Account{
 				ID:       accountID,
@@ -84,43 +125,171 @@ func newPricingCmd(rc *config.RootConfig) *cobra.Command {
 				Balance:  types.MoneyFromFloat(startingBalance),
 				Equity:   types.MoneyFromFloat(startingBalance),
 			}, j)
+			if err := state.restore(engine, rc.DBPath); err != nil {
+				return err
+			}
+			if err := scenario.Allocate(engine); err != nil {
+				return err
+			}
 
-			feed, err := backtest.NewCSVTicksFeed(ticksPath, types.FromTime(from), types.FromTime(to))
+			feed, err := openTicks(ticksPath, feedBound(from), feedBound(to), sanitizer, &expect)
 			if err != nil {
 				return err
 			}
 			defer feed.Close()
+			ticks, err := order.sequencer(feed)
+			if err != nil {
+				return err
+			}
+			var src market.TickSource = ticks
+			if seed > 0 {
+				first, rest, err := market.FirstTicks(ticks, seed)
+				if err != nil {
+					return err
+				}
+				seeds := make([]market.Tick, 0, len(first))
+				for _, tick := range first {
+					seeds = append(seeds, tick)
+				}
+				if err := engine.SeedPrices(seeds...); err != nil {
+					return err
+				}
+				src = rest
+				fmt.Printf("Seeded prices for %d instruments from the first %d ticks\n", len(first), seed)
+			}
 
 			for {
-				p, ok, err := feed.Next()
+				p, ok, err := src.Next()
 				if err != nil {
 					return err
 				}
 				if !ok {
 					break
 				}
+				if sigCtx.Err() != nil {
+					interruptedAt = p.Timestamp
+					break
+				}
+				if err := pacer.Wait(sigCtx, p.Timestamp); err != nil {
+					if sigCtx.Err() != nil {
+						interruptedAt = p.Timestamp
+						break
+					}
+					return err
+				}
+				p = scenario.Shock(p)
+				if p, err = shocks.Apply(ctx, engine, accountID, p); err != nil {
+					return err
+				}
 				if err := engine.UpdatePrice(p); err != nil {
 					return err
 				}
+				if rec != nil {
+					if err := rec.Record(p); err != nil {
+						return err
+					}
+				}
+				if err := scenario.Apply(ctx, engine, accountID, p); err != nil {
+					return err
+				}
+				if err := shocks.Observe(ctx, engine, accountID, p); err != nil {
+					return err
+				}
 			}
 
 			if closeEnd {
 				_ = engine.CloseAll(ctx, "EndOfReplay")
 			}
+			if err := state.save(engine, rc.DBPath); err != nil {
+				return err
+			}
+			if err := record.finish(rec); err != nil {
+				return err
+			}
+			script.report(scenario)
+			stress.report(shocks)
 
 			acct, _ := engine.GetAccount(ctx)
 			fmt.Printf("Done. balance=%.2f equity=%.2f\n", acct.Balance.Float64(), acct.Equity.Float64())
+			fmt.Printf("Ticks: %s\n", sanitizer.Stats())
+			fmt.Printf("Order: %s\n", ticks.Stats())
+			if interruptedAt != 0 {
+				return fmt.Errorf("interrupted at %s; state and journal saved", interruptedAt)
+			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&ticksPath, "ticks", "", "CSV path")
+	cmd.Flags().StringVar(&ticksPath, "ticks", "", "CSV path, or a SQLite tick archive (.db, .sqlite, .sqlite3; see data import-ticks)")
 	cmd.Flags().Float64Var(&startingBalance, "starting-balance", 100000, "Starting balance")
 	cmd.Flags().StringVar(&accountID, "account", "SIM-REPLAY", "Account ID")
 	cmd.Flags().BoolVar(&closeEnd, "close-end", false, "Close open trades at end")
 
 	cmd.Flags().StringVar(&fromStr, "from", "", "Optional RFC3339 start time")
 	cmd.Flags().StringVar(&toStr, "to", "", "Optional RFC3339 end time")
+	filter.register(cmd)
+	order.register(cmd)
+	state.register(cmd)
+	jrnl.register(cmd)
+	record.register(cmd)
+	expect.register(cmd)
+	script.register(cmd)
+	stress.register(cmd)
+	cmd.Flags().StringVar(&pace, "pace", "max", "Replay pacing: max, realtime, <n>x (e.g. 60x), or <n>/s ticks per second")
+	cmd.Flags().IntVar(&seed, "seed-prices", 0, "Before replaying, price each instrument from its first tick within this many ticks (0 = off)")
 
 	return cmd
 }
+
+// tickFeed is the tick file pricing replays: a CSV or a tick archive.
+type tickFeed interface {
+	market.TickSource
+	Close() error
+}
+
+// openTicks opens path as a tick archive when its extension says so, and
+// as a tick CSV otherwise, bounded to [from, to). Archives carry no
+// metadata line, so the --expect-* flags are checked only against CSVs.
+func openTicks(path string, from, to types.Timestamp, sanitizer *market.TickSanitizer, expect *expectFlags) (tickFeed, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite", ".sqlite3":
+		if err := expect.check(market.TickMeta{}, false); err != nil {
+			return nil, err
+		}
+		archive, err := backtest.OpenTickArchive(path)
+		if err != nil {
+			return nil, err
+		}
+		feed, err := archive.Feed(from, to)
+		if err != nil {
+			_ = archive.Close()
+			return nil, err
+		}
+		feed.Sanitizer = sanitizer
+		return archiveFeed{SQLiteTicksFeed: feed, archive: archive}, nil
+	}
+	feed, err := backtest.NewCSVTicksFeed(path, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := expect.check(feed.Meta()); err != nil {
+		_ = feed.Close()
+		return nil, err
+	}
+	feed.Sanitizer = sanitizer
+	return feed, nil
+}
+
+// archiveFeed closes the archive along with its feed.
+type archiveFeed struct {
+	*backtest.SQLiteTicksFeed
+	archive *backtest.TickArchive
+}
+
+func (f archiveFeed) Close() error {
+	err := f.SQLiteTicksFeed.Close()
+	if cerr := f.archive.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -1,7 +1,21 @@
 package replay
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/brokers/sim"
 	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
 	"github.com/spf13/cobra"
 )
 
@@ -14,7 +28,319 @@ func New(rc *config.RootConfig) *cobra.Command {
 	cmd.AddCommand(
 		newPricingCmd(rc),
 		newEventsCmd(rc),
+		newWebhookCmd(rc),
 	)
 
 	return cmd
 }
+
+// feedBound converts an optional --from/--to time to the feed's bound;
+// an unset time is the zero Timestamp (no bound), not the Unix time of
+// year 1, which would filter out every tick.
+func feedBound(t time.Time) types.Timestamp {
+	if t.IsZero() {
+		return 0
+	}
+	return types.FromTime(t)
+}
+
+// tickFilterFlags holds the tick-sanitizer flags shared by the pricing
+// and events subcommands.
+type tickFilterFlags struct {
+	maxSpreadPips float64
+	maxJumpPips   float64
+	badTicks      string
+}
+
+func (f *tickFilterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().Float64Var(&f.maxSpreadPips, "max-spread-pips", 0, "Reject ticks whose spread exceeds this many pips (0 = no limit)")
+	cmd.Flags().Float64Var(&f.maxJumpPips, "max-jump-pips", 0, "Reject ticks whose mid moves more than this many pips from the previous tick (0 = no limit)")
+	cmd.Flags().StringVar(&f.badTicks, "bad-ticks", "log", "What to do with a bad tick: log (drop and count) or fail")
+}
+
+func (f *tickFilterFlags) sanitizer() (*market.TickSanitizer, error) {
+	policy, err := market.ParseTickPolicy(f.badTicks)
+	if err != nil {
+		return nil, err
+	}
+	if f.maxSpreadPips < 0 || f.maxJumpPips < 0 {
+		return nil, fmt.Errorf("-max-spread-pips and -max-jump-pips must be >= 0")
+	}
+	return &market.TickSanitizer{
+		MaxSpreadPips: types.PipsFromFloat(f.maxSpreadPips),
+		MaxJumpPips:   types.PipsFromFloat(f.maxJumpPips),
+		Policy:        policy,
+	}, nil
+}
+
+// expectFlags holds the flags naming what a replay expects its ticks file
+// to hold, checked against the file's metadata line (market.TickMeta)
+// before anything is replayed.
+type expectFlags struct {
+	instruments string
+	source      string
+}
+
+func (f *expectFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.instruments, "expect-instruments", "", "Comma-separated instruments the ticks file must hold, checked against its metadata line")
+	cmd.Flags().StringVar(&f.source, "expect-source", "", "Price source the ticks file must come from, checked against its metadata line")
+}
+
+// check validates meta, the file's metadata (ok false when it has none),
+// against the flags and reports what the file holds.
+func (f *expectFlags) check(meta market.TickMeta, ok bool) error {
+	var insts []string
+	for _, s := range strings.Split(f.instruments, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			insts = append(insts, s)
+		}
+	}
+	if !ok {
+		if len(insts) > 0 || f.source != "" {
+			fmt.Println("Ticks file has no metadata line; --expect-instruments and --expect-source not checked")
+		}
+		return nil
+	}
+	fmt.Printf("Ticks file: %s\n", strings.TrimPrefix(meta.String(), "# "))
+	if err := meta.Expect(f.source, insts); err != nil {
+		return fmt.Errorf("ticks file: %w", err)
+	}
+	return nil
+}
+
+// scenarioFlags holds the flag naming a scenario script
+// (backtest.ScenarioConfig) to run against the replay.
+type scenarioFlags struct {
+	path string
+}
+
+func (f *scenarioFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.path, "scenario", "", "YAML scenario script of timed or price-triggered actions (open, close, close-all, modify, shock) to apply during the replay")
+}
+
+// load compiles the script; nil when the flag is unset, which Scenario's
+// methods treat as a no-op.
+func (f *scenarioFlags) load() (*backtest.Scenario, error) {
+	if f.path == "" {
+		return nil, nil
+	}
+	return backtest.LoadScenario(f.path)
+}
+
+// report prints what the scenario did.
+func (f *scenarioFlags) report(sc *backtest.Scenario) {
+	if sc == nil {
+		return
+	}
+	for _, a := range sc.Fired() {
+		fmt.Printf("Scenario: %s (%s) at %s\n", a.Name, strings.TrimSpace(a.Do+" "+a.Instrument), a.At)
+	}
+	if pending := sc.Pending(); len(pending) > 0 {
+		fmt.Printf("Scenario: not fired: %s\n", strings.Join(pending, ", "))
+	}
+}
+
+// stressFlags holds the flag naming a stress config
+// (backtest.StressConfig) of price shocks to inject into the replay.
+type stressFlags struct {
+	path string
+}
+
+func (f *stressFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.path, "stress", "", "YAML stress config of price shocks (gap, spread, whipsaw) to inject, with a report of equity, margin and stop fills")
+}
+
+// load compiles the config; nil when the flag is unset, which Stress's
+// methods treat as a no-op.
+func (f *stressFlags) load() (*backtest.Stress, error) {
+	if f.path == "" {
+		return nil, nil
+	}
+	return backtest.LoadStress(f.path)
+}
+
+// report prints what the stress run found.
+func (f *stressFlags) report(st *backtest.Stress) {
+	if st == nil {
+		return
+	}
+	r := st.Report()
+	for _, ev := range r.Events {
+		fmt.Printf("Stress: %s %s %s at %s\n", ev.Name, ev.Instrument, ev.Note, ev.At)
+	}
+	fmt.Printf("Stress equity: start=%.2f end=%.2f min=%.2f max-drawdown=%.2f (%.2f%%)\n",
+		r.StartEquity.Float64(), r.EndEquity.Float64(), r.MinEquity.Float64(),
+		r.MaxDrawdown.Float64(), r.MaxDrawdownPct.Float64()*100)
+	level := "n/a"
+	if r.MinMarginLevel > 0 {
+		level = fmt.Sprintf("%.1f%%", r.MinMarginLevel.Float64()*100)
+	}
+	fmt.Printf("Stress margin: max-used=%.2f min-level=%s closeouts=%d\n", r.MaxMarginUsed.Float64(), level, r.Closeouts)
+	for _, s := range r.Stops {
+		fmt.Printf("Stress stop: %s %s stop=%s fill=%s slippage=%.1f pips shock=%q at %s\n",
+			s.TradeID, s.Instrument, s.Stop, s.Fill, s.Slippage.Float64(), s.Shock, s.At)
+	}
+	fmt.Printf("Stress stops: %d filled, max slippage %.1f pips\n", len(r.Stops), r.MaxSlippage().Float64())
+}
+
+// tickOrderFlags holds the flags that choose how a replay handles
+// duplicate and out-of-order ticks.
+type tickOrderFlags struct {
+	policy string
+	window int
+}
+
+func (f *tickOrderFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.policy, "tick-order", "dedupe", "Duplicate and out-of-order ticks: dedupe (drop and count), sort (reorder within --tick-window), or fail")
+	cmd.Flags().IntVar(&f.window, "tick-window", market.DefaultTickOrderWindow, "Ticks buffered for --tick-order sort")
+}
+
+// sequencer wraps src in a TickSequencer configured from the flags.
+func (f *tickOrderFlags) sequencer(src market.TickSource) (*market.TickSequencer, error) {
+	policy, err := market.ParseTickOrderPolicy(f.policy)
+	if err != nil {
+		return nil, err
+	}
+	if f.window < 1 {
+		return nil, fmt.Errorf("--tick-window must be >= 1")
+	}
+	return &market.TickSequencer{Source: src, Policy: policy, Window: f.window}, nil
+}
+
+// stateFlags holds the --persist-state flag shared by the pricing, events
+// and webhook subcommands. With it set, a replay resumes from the sim state
+// saved next to the journal and saves its own state on exit, so a
+// paper-trading session can be stopped and restarted without losing open
+// trades, resting orders, or balances.
+type stateFlags struct {
+	persist bool
+}
+
+func (f *stateFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.persist, "persist-state", false, "Restore sim state from the journal's state file on start and save it on exit")
+}
+
+// restore loads the saved state into engine. A missing state file is a
+// fresh start, not an error.
+func (f *stateFlags) restore(engine *sim.Sim, dbPath string) error {
+	if !f.persist {
+		return nil
+	}
+	path := journal.JournalStatePath(dbPath)
+	if err := engine.LoadState(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	fmt.Printf("Resumed sim state from %s\n", path)
+	return nil
+}
+
+func (f *stateFlags) save(engine *sim.Sim, dbPath string) error {
+	if !f.persist {
+		return nil
+	}
+	return engine.SaveState(journal.JournalStatePath(dbPath))
+}
+
+// journalFlags holds the journal rotation flags shared by the pricing,
+// events and webhook subcommands. With --journal-rotate set, a long paper
+// session writes one pair of journal files per day or week instead of
+// growing one pair without bound, and SIGHUP rotates mid-run (see
+// journal.RotatingJournal).
+type journalFlags struct {
+	rotate     string
+	retainDays int
+}
+
+func (f *journalFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.rotate, "journal-rotate", "", "Start new journal files every period: daily or weekly")
+	cmd.Flags().IntVar(&f.retainDays, "retain-equity-days", 0, "With --journal-rotate, delete equity files older than this many days; trades are kept")
+}
+
+// open opens the session's JSONL journal next to dbPath. When it rotates,
+// SIGHUP triggers a rotation until the returned stop func is called.
+func (f *journalFlags) open(dbPath string) (journal.Journal, func(), error) {
+	tradesPath, equityPath := journal.JournalRecordPaths(dbPath)
+	j, err := journal.Open(journal.Config{
+		Kind:             "json",
+		TradesPath:       tradesPath,
+		EquityPath:       equityPath,
+		Rotate:           f.rotate,
+		RetainEquityDays: f.retainDays,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	r, ok := j.(journal.Rotator)
+	if !ok {
+		return j, func() {}, nil
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-hup:
+				if err := r.Rotate(); err != nil {
+					fmt.Fprintf(os.Stderr, "journal rotate: %v\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return j, func() {
+		signal.Stop(hup)
+		close(done)
+	}, nil
+}
+
+// recordFlags holds the candle-recording flags shared by the pricing and
+// events subcommands. With --record-candles set, the replayed ticks are
+// built into candles and written to the data dir under --record-source,
+// where a backtest can load them like any downloaded dataset.
+type recordFlags struct {
+	timeframes string
+	source     string
+}
+
+func (f *recordFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.timeframes, "record-candles", "", "Comma-separated timeframes to build from the ticks and write to the data dir (e.g. M1,H1)")
+	cmd.Flags().StringVar(&f.source, "record-source", "recorded", "Data source name the recorded candles are written under")
+}
+
+// recorder returns the configured candle recorder, or nil when recording
+// is off.
+func (f *recordFlags) recorder() (*datamanager.CandleRecorder, error) {
+	if strings.TrimSpace(f.timeframes) == "" {
+		return nil, nil
+	}
+	var tfs []types.Timeframe
+	for _, s := range strings.Split(f.timeframes, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		tf, err := types.ParseTimeframe(s)
+		if err != nil {
+			return nil, fmt.Errorf("bad --record-candles: %w", err)
+		}
+		tfs = append(tfs, tf)
+	}
+	return datamanager.NewCandleRecorder(f.source, tfs...)
+}
+
+// finish writes the recorder's completed candles and reports how many.
+func (f *recordFlags) finish(rec *datamanager.CandleRecorder) error {
+	if rec == nil {
+		return nil
+	}
+	if err := rec.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Recorded %d candles under source %q\n", rec.Written(), f.source)
+	return nil
+}
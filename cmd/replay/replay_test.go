@@ -0,0 +1,225 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestPricingCmd_RecordsCandles(t *testing.T) {
+	datamanager.UseTempDataDir(t)
+	dir := t.TempDir()
+
+	ticks := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(ticks, []byte(
+		"time,instrument,bid,ask\n"+
+			"2026-03-04T10:00:05Z,EUR_USD,1.10000,1.10010\n"+
+			"2026-03-04T10:00:40Z,EUR_USD,1.10040,1.10050\n"+
+			"2026-03-04T10:01:10Z,EUR_USD,1.10020,1.10030\n"+
+			"2026-03-04T10:02:00Z,EUR_USD,1.10020,1.10030\n"), 0o644))
+
+	cmd := New(&config.RootConfig{DBPath: filepath.Join(dir, "replay.db")})
+	cmd.SetArgs([]string{"pricing", "--ticks", ticks, "--record-candles", "M1,H1", "--record-source", "practice"})
+	require.NoError(t, cmd.Execute())
+
+	path := datamanager.PathForMonthlyCandle(datamanager.Key{
+		Instrument: "EURUSD", Source: "practice", Kind: datamanager.KindCandle,
+		TF: types.M1, Year: 2026, Month: 3,
+	})
+	_, err := os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestRecordFlags(t *testing.T) {
+	rec, err := (&recordFlags{source: "recorded"}).recorder()
+	require.NoError(t, err)
+	assert.Nil(t, rec, "recording is off without --record-candles")
+
+	_, err = (&recordFlags{timeframes: "M1,X9", source: "recorded"}).recorder()
+	require.ErrorContains(t, err, "bad --record-candles")
+}
+
+func TestJournalFlags(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "paper.db")
+
+	j, stop, err := (&journalFlags{}).open(db)
+	require.NoError(t, err)
+	_, ok := j.(journal.Rotator)
+	assert.False(t, ok, "journals do not rotate without --journal-rotate")
+	stop()
+	require.NoError(t, j.Close())
+
+	j, stop, err = (&journalFlags{rotate: journal.RotateDaily, retainDays: 7}).open(db)
+	require.NoError(t, err)
+	assert.Implements(t, (*journal.Rotator)(nil), j)
+	stop()
+	require.NoError(t, j.Close())
+
+	_, _, err = (&journalFlags{retainDays: 7}).open(db)
+	require.ErrorContains(t, err, "retain_equity_days needs rotate")
+}
+
+func TestPricingCmd_RejectsBadJournalRotate(t *testing.T) {
+	dir := t.TempDir()
+	ticks := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(ticks, []byte("2026-03-04T10:00:05Z,EUR_USD,1.10000,1.10010\n"), 0o644))
+
+	cmd := New(&config.RootConfig{DBPath: filepath.Join(dir, "replay.db")})
+	cmd.SetArgs([]string{"pricing", "--ticks", ticks, "--journal-rotate", "hourly"})
+	require.ErrorContains(t, cmd.Execute(), `journal rotate must be "daily" or "weekly"`)
+}
+
+func TestPricingCmd_TickOrderFailRejectsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	ticks := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(ticks, []byte(
+		"2026-03-04T10:00:05Z,EUR_USD,1.10000,1.10010\n"+
+			"2026-03-04T10:00:05Z,EUR_USD,1.10000,1.10010\n"), 0o644))
+
+	run := func(order string) error {
+		cmd := New(&config.RootConfig{DBPath: filepath.Join(dir, order+".db")})
+		cmd.SetArgs([]string{"pricing", "--ticks", ticks, "--tick-order", order})
+		return cmd.Execute()
+	}
+	require.NoError(t, run("dedupe"))
+	require.ErrorContains(t, run("fail"), "duplicate tick")
+	require.ErrorContains(t, run("shuffle"), "bad tick order policy")
+}
+
+func TestPricingCmd_SeedPrices(t *testing.T) {
+	dir := t.TempDir()
+	ticks := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(ticks, []byte(
+		"2026-03-04T10:00:05Z,EUR_USD,1.10000,1.10010\n"+
+			"2026-03-04T10:00:06Z,USD_JPY,150.000,150.020\n"+
+			"2026-03-04T10:00:40Z,EUR_USD,1.10040,1.10050\n"), 0o644))
+
+	db := filepath.Join(dir, "replay.db")
+	cmd := New(&config.RootConfig{DBPath: db})
+	cmd.SetArgs([]string{"pricing", "--ticks", ticks, "--seed-prices", "2", "--persist-state"})
+	require.NoError(t, cmd.Execute())
+
+	// The seeded ticks are replayed too: the saved prices are the last ones.
+	engine := sim.NewSimBroker(&account.Account{ID: "SIM-REPLAY", Currency: "USD"}, nil)
+	require.NoError(t, engine.LoadState(journal.JournalStatePath(db)))
+	tick, ok := engine.Snapshot().Prices["EURUSD"]
+	require.True(t, ok)
+	assert.Equal(t, types.PriceFromFloat(1.10040), tick.Bid)
+	_, ok = engine.Snapshot().Prices["USDJPY"]
+	assert.True(t, ok)
+}
+
+func TestPricingCmd_ExpectChecksMetadata(t *testing.T) {
+	dir := t.TempDir()
+	ticks := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(ticks, []byte(
+		"# schema=ticks-v1 source=oanda instruments=EURUSD price=bid-ask\n"+
+			"2026-03-04T10:00:05Z,EUR_USD,1.10000,1.10010\n"), 0o644))
+
+	run := func(args ...string) error {
+		cmd := New(&config.RootConfig{DBPath: filepath.Join(dir, "replay.db")})
+		cmd.SetArgs(append([]string{"pricing", "--ticks", ticks}, args...))
+		return cmd.Execute()
+	}
+	require.NoError(t, run("--expect-instruments", "EUR_USD", "--expect-source", "oanda"))
+	require.ErrorContains(t, run("--expect-instruments", "EURUSD,GBPUSD"), "ticks hold EURUSD, not GBPUSD")
+	require.ErrorContains(t, run("--expect-source", "dukascopy"), "ticks are from oanda, want dukascopy")
+}
+
+func TestPricingCmd_Scenario(t *testing.T) {
+	dir := t.TempDir()
+	ticks := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(ticks, []byte(
+		"2026-03-04T10:00:00Z,EUR_USD,1.10000,1.10010\n"+
+			"2026-03-04T10:01:00Z,EUR_USD,1.10000,1.10010\n"+
+			"2026-03-04T10:02:00Z,EUR_USD,1.10000,1.10010\n"), 0o644))
+	script := filepath.Join(dir, "gap.yaml")
+	require.NoError(t, os.WriteFile(script, []byte(
+		"actions:\n"+
+			"  - {name: long, at: +0s, do: open, instrument: EURUSD, units: 1000}\n"+
+			"  - {name: gap, at: 2026-03-04T10:01:00Z, do: shock, instrument: EURUSD, pips: 200}\n"), 0o644))
+
+	db := filepath.Join(dir, "replay.db")
+	cmd := New(&config.RootConfig{DBPath: db})
+	cmd.SetArgs([]string{"pricing", "--ticks", ticks, "--scenario", script, "--persist-state"})
+	require.NoError(t, cmd.Execute())
+
+	engine := sim.NewSimBroker(&account.Account{ID: "SIM-REPLAY", Currency: "USD"}, nil)
+	require.NoError(t, engine.LoadState(journal.JournalStatePath(db)))
+	tick, ok := engine.Snapshot().Prices["EURUSD"]
+	require.True(t, ok)
+	assert.Equal(t, types.PriceFromFloat(1.12000), tick.Bid, "the shock holds to the end")
+	open, err := engine.GetOpenTrades(context.Background(), "SIM-REPLAY")
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+
+	require.NoError(t, os.WriteFile(script, []byte("actions:\n  - {at: +0s, do: hedge}\n"), 0o644))
+	cmd = New(&config.RootConfig{DBPath: db})
+	cmd.SetArgs([]string{"pricing", "--ticks", ticks, "--scenario", script})
+	require.ErrorContains(t, cmd.Execute(), `unknown do "hedge"`)
+}
+
+func TestPricingCmd_Stress(t *testing.T) {
+	dir := t.TempDir()
+	ticks := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(ticks, []byte(
+		"2026-03-04T10:00:00Z,EUR_USD,1.10000,1.10010\n"+
+			"2026-03-04T10:01:00Z,EUR_USD,1.10000,1.10010\n"), 0o644))
+	cfg := filepath.Join(dir, "stress.yaml")
+	require.NoError(t, os.WriteFile(cfg, []byte(
+		"shocks:\n  - {kind: gap, instrument: EURUSD, at: +1m, pips: -50}\n"), 0o644))
+
+	db := filepath.Join(dir, "replay.db")
+	cmd := New(&config.RootConfig{DBPath: db})
+	cmd.SetArgs([]string{"pricing", "--ticks", ticks, "--stress", cfg, "--persist-state"})
+	require.NoError(t, cmd.Execute())
+
+	engine := sim.NewSimBroker(&account.Account{ID: "SIM-REPLAY", Currency: "USD"}, nil)
+	require.NoError(t, engine.LoadState(journal.JournalStatePath(db)))
+	tick, ok := engine.Snapshot().Prices["EURUSD"]
+	require.True(t, ok)
+	assert.Equal(t, types.PriceFromFloat(1.09500), tick.Bid)
+
+	require.NoError(t, os.WriteFile(cfg, []byte("shocks:\n  - {kind: crash, instrument: EURUSD, at: +1m, pips: 5}\n"), 0o644))
+	cmd = New(&config.RootConfig{DBPath: db})
+	cmd.SetArgs([]string{"pricing", "--ticks", ticks, "--stress", cfg})
+	require.ErrorContains(t, cmd.Execute(), `unknown kind "crash"`)
+}
+
+func TestPricingCmd_ReplaysATickArchive(t *testing.T) {
+	dir := t.TempDir()
+	ticks := filepath.Join(dir, "ticks.csv")
+	require.NoError(t, os.WriteFile(ticks, []byte(
+		"2026-03-04T10:00:05Z,EUR_USD,1.10000,1.10010\n"+
+			"2026-03-04T10:00:40Z,EUR_USD,1.10040,1.10050\n"+
+			"2026-03-04T10:01:10Z,EUR_USD,1.10020,1.10030\n"), 0o644))
+	archivePath := filepath.Join(dir, "ticks.sqlite")
+	archive, err := backtest.OpenTickArchive(archivePath)
+	require.NoError(t, err)
+	_, err = archive.ImportCSV(ticks)
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	db := filepath.Join(dir, "replay.db")
+	cmd := New(&config.RootConfig{DBPath: db})
+	cmd.SetArgs([]string{"pricing", "--ticks", archivePath, "--to", "2026-03-04T10:01:00Z", "--persist-state"})
+	require.NoError(t, cmd.Execute())
+
+	engine := sim.NewSimBroker(&account.Account{ID: "SIM-REPLAY", Currency: "USD"}, nil)
+	require.NoError(t, engine.LoadState(journal.JournalStatePath(db)))
+	tick, ok := engine.Snapshot().Prices["EURUSD"]
+	require.True(t, ok)
+	assert.Equal(t, types.PriceFromFloat(1.10040), tick.Bid, "--to bounds the archive query")
+}
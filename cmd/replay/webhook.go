@@ -0,0 +1,205 @@
+package replay
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/api/rest"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/brokers/paper"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/config"
+	"github.com/rustyeddy/trader/market"
+	webhooksvc "github.com/rustyeddy/trader/service/webhook"
+	"github.com/rustyeddy/trader/symbols"
+	"github.com/rustyeddy/trader/types"
+)
+
+// webhookTokenEnv holds the webhook token when --token is not given.
+const webhookTokenEnv = "TRADER_WEBHOOK_TOKEN"
+
+func newWebhookCmd(rc *config.RootConfig) *cobra.Command {
+	var (
+		addr  string
+		token string
+		cfg   webhooksvc.Config
+
+		startingBalance float64
+		accountID       string
+		closeEnd        bool
+
+		state stateFlags
+		jrnl  journalFlags
+
+		livePrices []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Paper-trade TradingView-style alert webhooks (POST /webhook) through the sim engine",
+		Long: `Serve POST /webhook and turn each alert into paper orders on a sim
+account, journaled like any other replay. Alerts carry the token in their
+token field or the ?token= query parameter; a buy reverses a short and
+opens long, a sell the reverse, and close, exit or market_position "flat"
+closes the ticker's trades. The sim is priced from each alert's price, so
+stops and takes are checked when alerts arrive; with --oanda-prices it is
+priced from OANDA's live stream instead (through the paper broker), alert
+prices are ignored and orders fill at live quotes. GET /status?token=...
+reports the account's balance, equity, margin and trades.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if startingBalance <= 0 {
+				return fmt.Errorf("invalid -starting-balance")
+			}
+			if accountID == "" {
+				return fmt.Errorf("invalid -account")
+			}
+			cfg.Token = token
+			if cfg.Token == "" {
+				cfg.Token = os.Getenv(webhookTokenEnv)
+			}
+			if cfg.Token == "" {
+				return fmt.Errorf("--token or %s is required", webhookTokenEnv)
+			}
+
+			ctx := context.Background()
+			// Ctrl-C stops the listener; the state save and journal close
+			// below still run, on ctx.
+			sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			j, stopRotate, err := jrnl.open(rc.DBPath)
+			if err != nil {
+				return err
+			}
+			defer j.Close()
+			defer stopRotate()
+
+			engine := sim.NewSimBroker(&account.Account{
+				ID:       accountID,
+				Currency: "USD",
+				Balance:  types.MoneyFromFloat(startingBalance),
+				Equity:   types.MoneyFromFloat(startingBalance),
+			}, j)
+			if err := state.restore(engine, rc.DBPath); err != nil {
+				return err
+			}
+			var broker brokers.Broker = engine
+			feedErr := make(chan error, 1)
+			if len(livePrices) > 0 {
+				pb, err := openOANDAPrices(sigCtx, rc, engine, livePrices)
+				if err != nil {
+					return err
+				}
+				broker, cfg.LivePrices = pb, true
+				go func() { feedErr <- pb.Run(sigCtx) }()
+				fmt.Printf("Pricing %s from the OANDA stream\n", strings.Join(livePrices, ","))
+			}
+			svc, err := webhooksvc.New(broker, accountID, cfg)
+			if err != nil {
+				return err
+			}
+
+			srv := &http.Server{
+				Handler:      rest.WebhookHandler(svc, nil),
+				ReadTimeout:  30 * time.Second,
+				WriteTimeout: 30 * time.Second,
+				IdleTimeout:  60 * time.Second,
+			}
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Listening for alerts on http://%s/webhook\n", ln.Addr())
+			errCh := make(chan error, 1)
+			go func() { errCh <- srv.Serve(ln) }()
+			shutdown := func() error {
+				shutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+				defer cancel()
+				return srv.Shutdown(shutCtx)
+			}
+			select {
+			case <-sigCtx.Done():
+				err = shutdown()
+			case err = <-errCh:
+				if errors.Is(err, http.ErrServerClosed) {
+					err = nil
+				}
+			case err = <-feedErr:
+				if sigCtx.Err() == nil {
+					// The stream only ends on its own when it fails.
+					err = errors.Join(fmt.Errorf("oanda price stream ended: %w", cmp.Or(err, io.EOF)), shutdown())
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			if closeEnd {
+				_ = engine.CloseAll(ctx, "EndOfReplay")
+			}
+			if err := state.save(engine, rc.DBPath); err != nil {
+				return err
+			}
+			acct, _ := engine.GetAccount(ctx)
+			fmt.Printf("Done. balance=%.2f equity=%.2f\n", acct.Balance.Float64(), acct.Equity.Float64())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8090", "Address to listen on")
+	cmd.Flags().StringVar(&token, "token", "", "Token every alert must carry (default $"+webhookTokenEnv+")")
+	cmd.Flags().StringToStringVar(&cfg.Symbols, "symbol", nil, "Map an alert ticker to an instrument, e.g. OANDA:EURUSD=EURUSD (repeatable); when set, unmapped tickers are refused")
+	cmd.Flags().Int64Var(&cfg.Units, "units", 1000, "Order size of an alert without contracts")
+	cmd.Flags().IntVar(&cfg.RatePerMinute, "rate", 30, "Alerts taken per minute (0 = no limit)")
+	cmd.Flags().IntVar(&cfg.Burst, "burst", 0, "Alerts taken at once within --rate (0 = the rate)")
+	cmd.Flags().Float64Var(&cfg.SpreadPips, "spread-pips", 0, "Spread set around each alert's price")
+	cmd.Flags().Float64Var(&startingBalance, "starting-balance", 100000, "Starting balance")
+	cmd.Flags().StringVar(&accountID, "account", "SIM-WEBHOOK", "Account ID")
+	cmd.Flags().BoolVar(&closeEnd, "close-end", false, "Close open trades on exit")
+	cmd.Flags().StringSliceVar(&livePrices, "oanda-prices", nil, "Price the sim from OANDA's live stream for these instruments, e.g. EURUSD,GBPUSD, instead of from alert prices (uses the config's oanda token, account_id and env)")
+	state.register(cmd)
+	jrnl.register(cmd)
+
+	return cmd
+}
+
+// openOANDAPrices returns a paper broker over engine priced by OANDA's
+// pricing stream for instruments, with the credentials from rc's oanda
+// section, falling back to OANDA_TOKEN and OANDA_ACCOUNT_ID. The stream
+// ends when ctx does.
+func openOANDAPrices(ctx context.Context, rc *config.RootConfig, engine *sim.Sim, instruments []string) (*paper.Broker, error) {
+	tok, env, acctID := rc.OANDA.Token, cmp.Or(rc.OANDA.Env, "practice"), cmp.Or(rc.OANDA.AccountID, os.Getenv("OANDA_ACCOUNT_ID"))
+	if acctID == "" {
+		return nil, fmt.Errorf("--oanda-prices needs an OANDA account: set oanda.account_id or OANDA_ACCOUNT_ID")
+	}
+	opts := oanda.PricingStreamOptions{AccountID: acctID}
+	for _, inst := range instruments {
+		if market.GetInstrument(inst) == nil {
+			return nil, fmt.Errorf("--oanda-prices: unknown instrument %q", inst)
+		}
+		opts.Instruments = append(opts.Instruments, symbols.ToProvider(symbols.OANDA, market.NormalizeInstrument(inst)))
+	}
+	client, err := oanda.NewClient(env, tok)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := paper.NewOANDAFeed(ctx, client, opts)
+	if err != nil {
+		return nil, fmt.Errorf("open oanda price stream: %w", err)
+	}
+	return paper.New(engine, feed), nil
+}
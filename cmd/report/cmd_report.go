@@ -0,0 +1,129 @@
+// Package report hosts the `trader report` CLI commands, which work over
+// saved backtest reports as a set. Business logic lives in
+// service/backtest; this package parses flags, calls it, and formats
+// output.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rustyeddy/trader/config"
+	backtestsvc "github.com/rustyeddy/trader/service/backtest"
+)
+
+// New returns the top-level "report" cobra command.
+func New(rc *config.RootConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Work with saved backtest reports",
+	}
+	cmd.AddCommand(newAggregateCmd(rc))
+	return cmd
+}
+
+func newAggregateCmd(rc *config.RootConfig) *cobra.Command {
+	var (
+		by     string
+		csvOut string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "aggregate <reports-dir>",
+		Short: "Summarize saved backtest reports by strategy, dataset and parameters",
+		Long: `Scan a reports directory for saved backtest JSON reports, flat or in run
+directories, group them, and print the mean, median and standard deviation of
+each group's key metrics: trades, win rate, return, net P/L, profit factor,
+Sharpe, max drawdown and reward/risk.
+
+--by picks the grouping from strategy, dataset (instrument, timeframe, date
+range and source) and params (the strategy parameters); the default is all
+three. --by strategy,dataset, for example, pools every parameter set tried on
+each dataset. Interrupted runs' partial reports are left out.
+
+--csv writes one row per group, with <metric>_mean, <metric>_median and
+<metric>_stddev columns, for analysis elsewhere.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			res, err := backtestsvc.AggregateReports(backtestsvc.AggregateRequest{
+				Dir: args[0],
+				By:  splitCSV(by),
+			})
+			if err != nil {
+				return err
+			}
+			if csvOut != "" {
+				f, err := os.Create(csvOut)
+				if err != nil {
+					return err
+				}
+				if err := backtestsvc.WriteAggregateCSV(f, res); err != nil {
+					_ = f.Close()
+					return err
+				}
+				if err := f.Close(); err != nil {
+					return err
+				}
+			}
+			if rc.JSONOutput() {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(res)
+			}
+			printAggregate(cmd.OutOrStdout(), res)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&by, "by", "strategy,dataset,params", "Comma-separated group keys: strategy, dataset, params")
+	cmd.Flags().StringVar(&csvOut, "csv", "", "Also write the per-group statistics as CSV to this path")
+	return cmd
+}
+
+func printAggregate(w io.Writer, res *backtestsvc.AggregateResult) {
+	if len(res.Groups) == 0 {
+		fmt.Fprintf(w, "No backtest reports found in %s.\n", res.Dir)
+		return
+	}
+	bar := strings.Repeat("─", 72)
+	for _, g := range res.Groups {
+		var keys []string
+		for _, k := range []string{g.Strategy, g.Dataset, g.Params} {
+			if k != "" {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			keys = append(keys, "all reports")
+		}
+		fmt.Fprintln(w, bar)
+		fmt.Fprintf(w, "  %s   (%d runs)\n", strings.Join(keys, "   "), g.Runs)
+		fmt.Fprintln(w, bar)
+		fmt.Fprintf(w, "  %-16s %12s %12s %12s\n", "Metric", "Mean", "Median", "StdDev")
+		for _, st := range g.Metrics {
+			fmt.Fprintf(w, "  %-16s %12.2f %12.2f %12.2f\n", st.Metric, st.Mean, st.Median, st.StdDev)
+		}
+	}
+	fmt.Fprintln(w, bar)
+	fmt.Fprintf(w, "  %d report(s) in %d group(s)", res.Reports, len(res.Groups))
+	if res.Skipped > 0 {
+		fmt.Fprintf(w, ", %d interrupted skipped", res.Skipped)
+	}
+	fmt.Fprintf(w, "  dir: %s\n", res.Dir)
+}
+
+// splitCSV splits a comma-separated flag value, dropping blanks.
+func splitCSV(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/backtest"
+	backtestsvc "github.com/rustyeddy/trader/service/backtest"
+)
+
+func TestAggregateCmd_PrintsGroupsAndWritesCSV(t *testing.T) {
+	dir := t.TempDir()
+	for i, ret := range []float64{2, 6} {
+		s := backtest.BacktestReportSummary{Strategy: "ema-cross", Instrument: "EURUSD", Timeframe: "h1", ReturnPct: ret, Trades: 5 + i}
+		require.NoError(t, backtestsvc.WriteBacktestSummaryJSON(filepath.Join(dir, s.Strategy+string(rune('a'+i))+".json"), s))
+	}
+	csvPath := filepath.Join(t.TempDir(), "agg.csv")
+
+	cmd := New(nil)
+	var out bytes.Buffer
+	cmd.SetArgs([]string{"aggregate", dir, "--by", "strategy", "--csv", csvPath})
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, out.String(), "ema-cross   (2 runs)")
+	assert.Regexp(t, `return_pct\s+4\.00\s+4\.00\s+2\.83`, out.String())
+	assert.Contains(t, out.String(), "2 report(s) in 1 group(s)")
+
+	b, err := os.ReadFile(csvPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[1], "ema-cross,,,2,5.5000,5.5000,0.7071,"), lines[1])
+}
+
+func TestAggregateCmd_RejectsUnknownGroupKey(t *testing.T) {
+	cmd := New(nil)
+	cmd.SetArgs([]string{"aggregate", t.TempDir(), "--by", "seed"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	require.ErrorContains(t, cmd.Execute(), "unknown group key")
+}
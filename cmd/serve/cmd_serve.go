@@ -29,6 +29,8 @@ import (
 	"github.com/rustyeddy/trader/log"
 	accountsvc "github.com/rustyeddy/trader/service/account"
 	botsvc "github.com/rustyeddy/trader/service/bots"
+	reconcilesvc "github.com/rustyeddy/trader/service/reconcile"
+	"github.com/rustyeddy/trader/types"
 	traderui "github.com/rustyeddy/trader/ui"
 )
 
@@ -46,6 +48,16 @@ type DaemonConfig struct {
 
 	Journal journalpkg.Config `yaml:"journal"`
 
+	// Reconcile periodically checks the broker account against the
+	// journal (see trader reconcile). Disabled when Every is zero.
+	Reconcile struct {
+		Every            time.Duration `yaml:"every"`
+		StatePath        string        `yaml:"state_path"`        // default ./reconcile-state.json
+		Webhook          string        `yaml:"webhook"`           // POST a JSON report on divergence
+		BalanceTolerance float64       `yaml:"balance_tolerance"` // account currency; default 1.00
+		MarginTolerance  float64       `yaml:"margin_tolerance"`  // fraction; default 0.05
+	} `yaml:"reconcile"`
+
 	Data struct {
 		Dir string `yaml:"dir"`
 	} `yaml:"data"`
@@ -70,6 +82,7 @@ func New(rc *config.RootConfig) *cobra.Command {
 		reportsDir            string
 		reviewSweepReportsDir string
 		reviewSweepConfigsDir string
+		reconcileEvery        time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -82,8 +95,9 @@ Boots:
   2. DataManager with warm candle cache
   3. OANDA broker connection
   4. Transaction stream → journal writer (reconnects on disconnect)
-  5. REST API server (:9999 by default)
-  6. Graceful shutdown on SIGTERM / SIGINT
+  5. Broker/journal reconciliation every reconcile.every (off by default)
+  6. REST API server (:9999 by default)
+  7. Graceful shutdown on SIGTERM / SIGINT
 
 Configuration can be loaded from a YAML file (--config) with CLI flags
 taking precedence over file values.
@@ -98,6 +112,10 @@ Example config file (see deploy/trader.yaml.example):
     kind: json
     trades_path: /var/lib/trader/live-trades.jsonl
     equity_path: /var/lib/trader/live-equity.jsonl
+  reconcile:
+    every: 15m
+    state_path: /var/lib/trader/reconcile-state.json
+    webhook: ""      # POST a JSON report on divergence
   log:
     level: info
 `,
@@ -140,6 +158,9 @@ Example config file (see deploy/trader.yaml.example):
 				cfg.Journal.Kind = "json"
 				cfg.Journal.EquityPath = journalEquity
 			}
+			if cmd.Flags().Changed("reconcile-every") {
+				cfg.Reconcile.Every = reconcileEvery
+			}
 
 			// Apply defaults.
 			if cfg.Env == "" {
@@ -157,6 +178,9 @@ Example config file (see deploy/trader.yaml.example):
 			if cfg.Journal.EquityPath == "" {
 				cfg.Journal.EquityPath = "./live-equity.jsonl"
 			}
+			if cfg.Reconcile.StatePath == "" {
+				cfg.Reconcile.StatePath = "./reconcile-state.json"
+			}
 			if cfg.Log.Level == "" {
 				cfg.Log.Level = "info"
 			}
@@ -269,6 +293,15 @@ Example config file (see deploy/trader.yaml.example):
 							log.Error("serve: live journal failed", "err", err)
 						}
 					}()
+					if cfg.Reconcile.Every > 0 {
+						rec := newReconciler(&cfg, client, accountID, log)
+						wg.Add(1)
+						go func() {
+							defer wg.Done()
+							log.Info("serve: reconciliation started", "every", cfg.Reconcile.Every, "state", cfg.Reconcile.StatePath)
+							rec.Run(ctx, cfg.Reconcile.Every)
+						}()
+					}
 				}
 			}
 
@@ -301,11 +334,36 @@ Example config file (see deploy/trader.yaml.example):
 	cmd.Flags().StringVar(&journalEquity, "journal-equity", "", "Journal equity-record path (default ./live-equity.jsonl)")
 	cmd.Flags().StringVar(&reportsDir, "reports-dir", "", "Backtest reports directory (default /srv/trading/backtests/reports)")
 	cmd.Flags().StringVar(&reviewSweepReportsDir, "review-sweep-reports-dir", "", "Review-sweep reports directory (default /srv/trading/review-sweeps/reports)")
+	cmd.Flags().DurationVar(&reconcileEvery, "reconcile-every", 0, "Check the broker account against the journal at this interval (default off)")
 	cmd.Flags().StringVar(&reviewSweepConfigsDir, "review-sweep-configs-dir", "", "Review-sweep configs directory (default /srv/trading/review-sweeps/configs)")
 
 	return cmd
 }
 
+// newReconciler builds the periodic broker/journal check from cfg's
+// reconcile section, defaulting unset tolerances.
+func newReconciler(cfg *DaemonConfig, client *oanda.Client, accountID string, log *slog.Logger) *reconcilesvc.Reconciler {
+	tol := reconcilesvc.DefaultTolerance
+	if cfg.Reconcile.BalanceTolerance > 0 {
+		tol.Balance = types.MoneyFromFloat(cfg.Reconcile.BalanceTolerance)
+	}
+	if cfg.Reconcile.MarginTolerance > 0 {
+		tol.Margin = types.RateFromFloat(cfg.Reconcile.MarginTolerance)
+	}
+	r := &reconcilesvc.Reconciler{
+		Broker:    client,
+		AccountID: accountID,
+		Journal:   cfg.Journal,
+		StatePath: cfg.Reconcile.StatePath,
+		Tolerance: tol,
+		Log:       log,
+	}
+	if cfg.Reconcile.Webhook != "" {
+		r.Sinks = append(r.Sinks, reconcilesvc.WebhookSink{URL: cfg.Reconcile.Webhook})
+	}
+	return r
+}
+
 // liveJournalRunner runs the live-journal reconnect/backoff subscription
 // loop until ctx is cancelled, retrying on disconnect with exponential
 // backoff (cap 5 min).
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,6 +37,11 @@ journal:
   kind: csv
   tradespath: /var/trades.jsonl
   equitypath: /var/equity.jsonl
+reconcile:
+  every: 15m
+  state_path: /var/reconcile.json
+  webhook: http://alerts.local/hook
+  margin_tolerance: 0.1
 data:
   dir: /srv/data
 log:
@@ -53,6 +59,10 @@ log:
 	assert.Equal(t, "csv", cfg.Journal.Kind)
 	assert.Equal(t, "/var/trades.jsonl", cfg.Journal.TradesPath)
 	assert.Equal(t, "/var/equity.jsonl", cfg.Journal.EquityPath)
+	assert.Equal(t, 15*time.Minute, cfg.Reconcile.Every)
+	assert.Equal(t, "/var/reconcile.json", cfg.Reconcile.StatePath)
+	assert.Equal(t, "http://alerts.local/hook", cfg.Reconcile.Webhook)
+	assert.Equal(t, 0.1, cfg.Reconcile.MarginTolerance)
 	assert.Equal(t, "/srv/data", cfg.Data.Dir)
 	assert.Equal(t, "info", cfg.Log.Level)
 	assert.Equal(t, "/var/log/trader.log", cfg.Log.File)
@@ -151,14 +151,14 @@ func GenerateConfig(opts GenOptions) (*backtest.Config, error) {
 	}
 
 	return &backtest.Config{
-		Version: 1,
+		Version: backtest.ConfigVersion,
 		Defaults: backtest.RunDefaults{
 			StartingBalance: opts.StartingBalance,
 			AccountCCY:      opts.AccountCCY,
 			Scale:           opts.Scale,
 			RiskPct:         opts.RiskPct,
 			Source:          opts.Source,
-			MaxSpreadPips:   opts.MaxSpreadPips,
+			Execution:       backtest.ExecutionConfig{MaxSpreadPips: opts.MaxSpreadPips},
 		},
 		Runs: runs,
 	}, nil
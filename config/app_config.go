@@ -1,6 +1,9 @@
 package config
 
-import "github.com/rustyeddy/trader/review"
+import (
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/review"
+)
 
 type RootConfig struct {
 	ConfigPath string
@@ -14,6 +17,15 @@ type RootConfig struct {
 	LogFormat string
 	NoColor   bool
 
+	// Quiet and Verbose are shorthands for the log level; see
+	// ResolveLogLevel.
+	Quiet   bool
+	Verbose bool
+
+	// Output is the root --output format for command results: "text"
+	// (default) or "json". Commands with their own --output flag shadow it.
+	Output string
+
 	// ReviewThresholds populated from global config's `review:` section;
 	// `trader review`'s own flags override these per-run (see cmd/review).
 	ReviewThresholds review.Thresholds
@@ -23,3 +35,29 @@ type RootConfig struct {
 	// --token/--account-id/--env flags take precedence when set.
 	OANDA GlobalOANDAConfig
 }
+
+// Output formats accepted by the root --output flag.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// JSONOutput reports whether command results should be written as JSON.
+func (rc *RootConfig) JSONOutput() bool {
+	return rc != nil && rc.Output == OutputJSON
+}
+
+// ResolveLogLevel applies the Quiet and Verbose shorthands to LogLevel:
+// quiet logs warnings and errors only, verbose everything down to debug.
+// Either overrides LogLevel; setting both is an error.
+func (rc *RootConfig) ResolveLogLevel() error {
+	switch {
+	case rc.Quiet && rc.Verbose:
+		return errs.Newf(errs.ErrBadConfig, "--quiet and --verbose are mutually exclusive")
+	case rc.Quiet:
+		rc.LogLevel = "warn"
+	case rc.Verbose:
+		rc.LogLevel = "debug"
+	}
+	return nil
+}
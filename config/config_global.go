@@ -2,12 +2,14 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"sort"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/review"
 )
 
@@ -26,7 +28,13 @@ type GlobalConfig struct {
 	Data   GlobalDataConfig   `yaml:"data"`
 	OANDA  GlobalOANDAConfig  `yaml:"oanda"`
 	Review GlobalReviewConfig `yaml:"review"`
+	Market GlobalMarketConfig `yaml:"market"`
 	DB     string             `yaml:"db"`
+
+	// Symbols adds per-provider instrument symbols, keyed provider →
+	// canonical ID → venue symbol, on top of the built-in mapping rules
+	// (see package symbols).
+	Symbols map[string]map[string]string `yaml:"symbols"`
 }
 
 // GlobalLogConfig holds log-related global settings.
@@ -41,6 +49,13 @@ type GlobalDataConfig struct {
 	Dir string `yaml:"dir"`
 }
 
+// GlobalMarketConfig holds market-calendar settings.
+type GlobalMarketConfig struct {
+	// Rollover is the daily boundary, "HH:00 Zone" (see
+	// types.ParseRollover); empty keeps 17:00 America/New_York.
+	Rollover string `yaml:"rollover"`
+}
+
 // GlobalOANDAConfig holds OANDA broker credentials.
 type GlobalOANDAConfig struct {
 	Token     string `yaml:"token"`
@@ -135,7 +150,7 @@ func mergeGlobalConfigFile(dst *GlobalConfig, path string) error {
 	}
 	var src GlobalConfig
 	if err := yaml.Unmarshal(data, &src); err != nil {
-		return fmt.Errorf("global config: parse %q: %w", path, err)
+		return errs.Newf(errs.ErrBadConfig, "global config: parse %q: %w", path, err)
 	}
 	mergeGlobalConfig(dst, &src)
 	return nil
@@ -164,10 +179,22 @@ func mergeGlobalConfig(dst, src *GlobalConfig) {
 	if src.OANDA.Env != "" {
 		dst.OANDA.Env = src.OANDA.Env
 	}
+	if src.Market.Rollover != "" {
+		dst.Market.Rollover = src.Market.Rollover
+	}
 	if src.DB != "" {
 		dst.DB = src.DB
 	}
 	mergeGlobalReviewConfig(&dst.Review, &src.Review)
+	for provider, entries := range src.Symbols {
+		if dst.Symbols == nil {
+			dst.Symbols = map[string]map[string]string{}
+		}
+		if dst.Symbols[provider] == nil {
+			dst.Symbols[provider] = map[string]string{}
+		}
+		maps.Copy(dst.Symbols[provider], entries)
+	}
 }
 
 // mergeGlobalReviewConfig copies non-zero threshold fields from src into
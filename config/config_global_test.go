@@ -126,6 +126,38 @@ func TestLoadGlobalConfig_DBField(t *testing.T) {
 	assert.Equal(t, "/var/lib/trader/journal.db", cfg.DB)
 }
 
+func TestLoadGlobalConfig_MarketRollover(t *testing.T) {
+	dir := t.TempDir()
+	writeYAML(t, dir, "a.yml", "market:\n  rollover: \"17:00 America/New_York\"\n")
+	writeYAML(t, dir, "b.yml", "market:\n  rollover: \"00:00 UTC\"\n")
+	cfg, err := loadGlobalConfig([]string{dir}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "00:00 UTC", cfg.Market.Rollover)
+}
+
+func TestLoadGlobalConfig_SymbolsMergePerEntry(t *testing.T) {
+	dir := t.TempDir()
+	writeYAML(t, dir, "a.yml", `
+symbols:
+  oanda:
+    SPX500USD: SPX500_USD
+    XAUUSD: XAU_USD
+`)
+	writeYAML(t, dir, "b.yml", `
+symbols:
+  oanda:
+    XAUUSD: GOLD
+  mybroker:
+    EURUSD: EURUSD.m
+`)
+	cfg, err := loadGlobalConfig([]string{dir}, "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"oanda":    {"SPX500USD": "SPX500_USD", "XAUUSD": "GOLD"},
+		"mybroker": {"EURUSD": "EURUSD.m"},
+	}, cfg.Symbols)
+}
+
 func TestMergeGlobalConfig_EmptyDoesNotOverwrite(t *testing.T) {
 	dst := &GlobalConfig{}
 	dst.Log.Level = "info"
@@ -163,3 +195,20 @@ func TestGlobalReviewConfig_ToThresholds_OverridesOnlyConfiguredFields(t *testin
 	assert.Equal(t, 15.0, th.H4ADXFloor)
 	assert.Equal(t, review.DefaultThresholds().HotD1ADXFloor, th.HotD1ADXFloor)
 }
+
+func TestRootConfigResolveLogLevel(t *testing.T) {
+	rc := &RootConfig{LogLevel: "info", Quiet: true}
+	require.NoError(t, rc.ResolveLogLevel())
+	assert.Equal(t, "warn", rc.LogLevel)
+
+	rc = &RootConfig{LogLevel: "info", Verbose: true}
+	require.NoError(t, rc.ResolveLogLevel())
+	assert.Equal(t, "debug", rc.LogLevel)
+
+	rc = &RootConfig{LogLevel: "error"}
+	require.NoError(t, rc.ResolveLogLevel())
+	assert.Equal(t, "error", rc.LogLevel)
+
+	rc = &RootConfig{Quiet: true, Verbose: true}
+	assert.Error(t, rc.ResolveLogLevel())
+}
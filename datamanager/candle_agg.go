@@ -2,6 +2,7 @@ package datamanager
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rustyeddy/trader/market"
@@ -214,3 +215,99 @@ func aggregateWindow(cs *CandleSet, fromIdx, toIdx int, hasValidBits bool) (mark
 	}
 	return outC, validCount
 }
+
+// CandleAggregator rolls a stream of lower-timeframe candles into
+// higher-timeframe candles as they arrive, on the same bucket grid as
+// Aggregate (D1/H4 anchored to 17:00 America/New_York, DST-aware). A
+// higher-timeframe candle is emitted once it is complete: with the input
+// candle that ends its window or, when the window's last input candles are
+// missing, with the first candle of the next window. The leading window is
+// dropped when the stream starts part-way through it, so every emitted
+// candle covers its whole window; the trailing window is never emitted.
+type CandleAggregator struct {
+	in, out types.Timeframe
+
+	cur       market.Candle // window being built; Timestamp is its open
+	has       bool
+	partial   bool // cur is the leading window and started late
+	seen      bool
+	sumTicks  int64
+	sumSpread int64
+
+	closed [2]market.Candle // backing array for Add's result
+}
+
+// NewCandleAggregator returns an aggregator from in-timeframe candles to
+// out-timeframe candles. out must be longer than in and, except for the
+// daily-aligned D1/H4, a whole multiple of it.
+func NewCandleAggregator(in, out types.Timeframe) (*CandleAggregator, error) {
+	if in <= 0 || out <= in {
+		return nil, fmt.Errorf("aggregate %s to %s: output timeframe must be longer than input", in, out)
+	}
+	if !dailyAligned(out) && out%in != 0 {
+		return nil, fmt.Errorf("aggregate %s to %s: output timeframe must be a multiple of input", in, out)
+	}
+	return &CandleAggregator{in: in, out: out}, nil
+}
+
+// Name describes the aggregation, e.g. "Aggregate(M1→H1)".
+func (a *CandleAggregator) Name() string {
+	return fmt.Sprintf("Aggregate(%s→%s)", strings.ToUpper(a.in.String()), strings.ToUpper(a.out.String()))
+}
+
+// Reset discards the window being built, so the next candle starts the
+// stream afresh.
+func (a *CandleAggregator) Reset() {
+	*a = CandleAggregator{in: a.in, out: a.out}
+}
+
+// Add folds c into its window and returns the windows completed by it,
+// oldest first. The returned slice is reused by the next call. Zero
+// (gap-fill) candles are ignored.
+func (a *CandleAggregator) Add(c market.Candle) []market.Candle {
+	done := a.closed[:0]
+	if c.IsZero() {
+		return done
+	}
+	open := slotOpen(a.out, c.Timestamp)
+	if a.has && open != a.cur.Timestamp {
+		done = a.flush(done)
+	}
+	if !a.has {
+		a.cur = market.Candle{Timestamp: open, Open: c.Open, High: c.High, Low: c.Low}
+		a.partial = !a.seen && c.Timestamp != open
+		a.has, a.seen = true, true
+		a.sumTicks, a.sumSpread = 0, 0
+	} else {
+		a.cur.High = max(a.cur.High, c.High)
+		a.cur.Low = min(a.cur.Low, c.Low)
+	}
+	a.cur.Close = c.Close
+	a.cur.MaxSpread = max(a.cur.MaxSpread, c.MaxSpread)
+	if ticks := int64(c.Ticks); ticks > 0 {
+		a.sumTicks += ticks
+		a.sumSpread += int64(c.AvgSpread) * ticks
+	}
+
+	if slotOpen(a.out, c.Timestamp+types.Timestamp(a.in)) != open {
+		done = a.flush(done)
+	}
+	return done
+}
+
+func (a *CandleAggregator) flush(done []market.Candle) []market.Candle {
+	if !a.partial {
+		done = append(done, a.finish())
+	}
+	a.has = false
+	return done
+}
+
+func (a *CandleAggregator) finish() market.Candle {
+	c := a.cur
+	c.Ticks = int32(a.sumTicks)
+	if a.sumTicks > 0 {
+		c.AvgSpread = types.Price((a.sumSpread + a.sumTicks/2) / a.sumTicks)
+	}
+	return c
+}
@@ -102,6 +102,29 @@ func TestAggregate_H4FromH1_DSTTransition(t *testing.T) {
 	}
 }
 
+// TestAggregate_D1AndH4FollowConfiguredRollover aggregates under a UTC
+// midnight rollover: D1 opens at 00:00 UTC and H4 on the 4-hour UTC grid.
+// Not parallel: the rollover is process-wide.
+func TestAggregate_D1AndH4FollowConfiguredRollover(t *testing.T) {
+	types.SetRollover(types.Rollover{Location: time.UTC, Hour: 0})
+	defer types.SetRollover(types.DefaultRollover)
+
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	cs := buildAggTestCandleSet(t, start, types.H1, 48)
+
+	d1, err := cs.Aggregate(types.D1)
+	require.NoError(t, err)
+	require.Equal(t, start, d1.Time(0))
+	require.Equal(t, start.Add(24*time.Hour), d1.Time(1))
+	require.True(t, d1.IsValid(1))
+
+	h4, err := cs.Aggregate(types.H4)
+	require.NoError(t, err)
+	for i := 0; i < 12; i++ {
+		require.Equal(t, start.Add(time.Duration(i)*4*time.Hour), h4.Time(i), "slot %d", i)
+	}
+}
+
 // TestAggregate_H1FromM1_UnaffectedByDailyAlignmentFix proves buildH1
 // (M1->H1) stays a no-op change from the D1 daily-alignment fix: M1 has no
 // daily-alignment concept, so H1 bucket boundaries are true UTC-epoch-hour
@@ -116,3 +139,93 @@ func TestAggregate_H1FromM1_UnaffectedByDailyAlignmentFix(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, cs.Start, h1.Start)
 }
+
+func TestCandleAggregator_DropsLeadingPartialWindow(t *testing.T) {
+	t.Parallel()
+
+	agg, err := NewCandleAggregator(types.M1, types.H1)
+	require.NoError(t, err)
+
+	// Starts at 10:30, so the 10:00 window is incomplete and never emitted.
+	start := time.Date(2026, 6, 1, 10, 30, 0, 0, time.UTC)
+	var got []market.Candle
+	for i := 0; i < 91; i++ {
+		ts := types.FromTime(start.Add(time.Duration(i) * time.Minute))
+		price := types.Price(100 + i)
+		got = append(got, agg.Add(market.Candle{Open: price, High: price + 5, Low: price - 5, Close: price, Ticks: 2, AvgSpread: 3, Timestamp: ts})...)
+	}
+
+	require.Len(t, got, 1, "only the 11:00 window completes")
+	h := got[0]
+	require.Equal(t, types.FromTime(time.Date(2026, 6, 1, 11, 0, 0, 0, time.UTC)), h.Timestamp)
+	require.Equal(t, types.Price(130), h.Open)
+	require.Equal(t, types.Price(189), h.Close)
+	require.Equal(t, types.Price(194), h.High)
+	require.Equal(t, types.Price(125), h.Low)
+	require.Equal(t, int32(120), h.Ticks)
+	require.Equal(t, types.Price(3), h.AvgSpread)
+
+	// The 12:00 window holds the one bar seen so far; the next hour's
+	// first bar closes it.
+	next := agg.Add(market.Candle{Open: 200, High: 200, Low: 200, Close: 200, Timestamp: types.FromTime(time.Date(2026, 6, 1, 13, 0, 0, 0, time.UTC))})
+	require.Len(t, next, 1)
+	require.Equal(t, types.FromTime(time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)), next[0].Timestamp)
+	require.Equal(t, types.Price(190), next[0].Open)
+	require.Equal(t, types.Price(190), next[0].Close)
+}
+
+func TestCandleAggregator_ClosesOnNextWindowAcrossGap(t *testing.T) {
+	t.Parallel()
+
+	agg, err := NewCandleAggregator(types.H1, types.H4)
+	require.NoError(t, err)
+
+	// June (EDT): H4 slots open at 17:00, 21:00, 01:00 ... NY = 21:00, 01:00, 05:00 UTC.
+	open := time.Date(2026, 6, 1, 21, 0, 0, 0, time.UTC)
+	c := func(at time.Time) market.Candle {
+		return market.Candle{Open: 1, High: 2, Low: 1, Close: 1, Timestamp: types.FromTime(at)}
+	}
+	require.Empty(t, agg.Add(c(open)))
+	require.Empty(t, agg.Add(c(open.Add(time.Hour))))
+	require.Empty(t, agg.Add(market.Candle{Timestamp: types.FromTime(open.Add(2 * time.Hour))}), "zero candles are ignored")
+
+	// The 23:00 and 00:00 bars are missing; the 01:00 bar closes the window.
+	got := agg.Add(c(open.Add(4 * time.Hour)))
+	require.Len(t, got, 1)
+	require.Equal(t, types.FromTime(open), got[0].Timestamp)
+
+	// Three more bars complete the 01:00 window on its last bar.
+	require.Empty(t, agg.Add(c(open.Add(5*time.Hour))))
+	require.Empty(t, agg.Add(c(open.Add(6*time.Hour))))
+	got = agg.Add(c(open.Add(7 * time.Hour)))
+	require.Len(t, got, 1)
+	require.Equal(t, types.FromTime(open.Add(4*time.Hour)), got[0].Timestamp)
+}
+
+func TestCandleAggregator_D1UsesNYDailyAlignment(t *testing.T) {
+	t.Parallel()
+
+	agg, err := NewCandleAggregator(types.H1, types.D1)
+	require.NoError(t, err)
+
+	// UTC midnight start: the 21:00 UTC (17:00 EDT) day that began the
+	// evening before is partial and dropped.
+	start := time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC)
+	var got []market.Candle
+	for i := 0; i < 48; i++ {
+		got = append(got, agg.Add(market.Candle{Open: 1, High: 2, Low: 1, Close: 1, Timestamp: types.FromTime(start.Add(time.Duration(i) * time.Hour))})...)
+	}
+	require.Len(t, got, 1)
+	require.Equal(t, types.FromTime(time.Date(2026, 6, 2, 21, 0, 0, 0, time.UTC)), got[0].Timestamp)
+}
+
+func TestNewCandleAggregator_RejectsBadTimeframes(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewCandleAggregator(types.H1, types.H1)
+	require.Error(t, err)
+	_, err = NewCandleAggregator(types.H1, types.M1)
+	require.Error(t, err)
+	_, err = NewCandleAggregator(types.Timeframe(7*60), types.H1)
+	require.Error(t, err)
+}
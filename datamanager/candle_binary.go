@@ -0,0 +1,294 @@
+package datamanager
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/rustyeddy/trader/log"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// A binary CandleSet file holds one CandleSet with each candle stored in
+// the layout market.Candle has in memory on a little-endian machine:
+//
+//	header   128 bytes: magic, count, start, timeframe, scale,
+//	         instrument and source (NUL-padded, 32 bytes each)
+//	candles  count × 40 bytes: open, high, low, close, avgspread,
+//	         maxspread, ticks (int32), 4 bytes padding, timestamp (int64)
+//	valid    ⌈count/64⌉ uint64 words, CandleSet.Valid
+//
+// All integers are little-endian. Because the records match memory, a
+// reader can map the file and use its pages as the candle and valid
+// slices directly: concurrent backtests, in one process or several, then
+// share the page cache's one copy of the dataset instead of each parsing
+// a private copy onto the heap.
+const (
+	candleBinMagic      = "TRCNDLB1"
+	candleBinHeaderSize = 128
+	candleBinRecordSize = 40
+	candleBinNameSize   = 32
+)
+
+// candleLayoutIsNative reports whether market.Candle's memory layout is
+// the file's, so mapped records can be used in place; otherwise they are
+// decoded into a heap copy.
+var candleLayoutIsNative = func() bool {
+	var c market.Candle
+	one := uint16(1)
+	return *(*byte)(unsafe.Pointer(&one)) == 1 &&
+		unsafe.Sizeof(c) == candleBinRecordSize &&
+		unsafe.Offsetof(c.Open) == 0 && unsafe.Offsetof(c.High) == 4 &&
+		unsafe.Offsetof(c.Low) == 8 && unsafe.Offsetof(c.Close) == 12 &&
+		unsafe.Offsetof(c.AvgSpread) == 16 && unsafe.Offsetof(c.MaxSpread) == 20 &&
+		unsafe.Offsetof(c.Ticks) == 24 && unsafe.Offsetof(c.Timestamp) == 32
+}()
+
+// candleBinSize is the size of a binary CandleSet file of n candles.
+func candleBinSize(n int) int {
+	return candleBinHeaderSize + n*candleBinRecordSize + (n+63)/64*8
+}
+
+// WriteCandleSetBinary writes cs to w in the binary CandleSet format.
+func WriteCandleSetBinary(w io.Writer, cs *CandleSet) error {
+	if cs == nil {
+		return errors.New("nil CandleSet")
+	}
+	if len(cs.Instrument) > candleBinNameSize || len(cs.Source) > candleBinNameSize {
+		return fmt.Errorf("binary candle set: instrument %q or source %q longer than %d bytes",
+			cs.Instrument, cs.Source, candleBinNameSize)
+	}
+	n := len(cs.Candles)
+	le := binary.LittleEndian
+
+	hdr := make([]byte, candleBinHeaderSize)
+	copy(hdr, candleBinMagic)
+	le.PutUint64(hdr[8:], uint64(n))
+	le.PutUint64(hdr[16:], uint64(cs.Start))
+	le.PutUint64(hdr[24:], uint64(cs.Timeframe))
+	le.PutUint64(hdr[32:], uint64(cs.Scale))
+	copy(hdr[40:40+candleBinNameSize], cs.Instrument)
+	copy(hdr[72:72+candleBinNameSize], cs.Source)
+
+	bw := bufio.NewWriterSize(w, 256*1024)
+	if _, err := bw.Write(hdr); err != nil {
+		return err
+	}
+	var rec [candleBinRecordSize]byte
+	for _, c := range cs.Candles {
+		le.PutUint32(rec[0:], uint32(c.Open))
+		le.PutUint32(rec[4:], uint32(c.High))
+		le.PutUint32(rec[8:], uint32(c.Low))
+		le.PutUint32(rec[12:], uint32(c.Close))
+		le.PutUint32(rec[16:], uint32(c.AvgSpread))
+		le.PutUint32(rec[20:], uint32(c.MaxSpread))
+		le.PutUint32(rec[24:], uint32(c.Ticks))
+		le.PutUint64(rec[32:], uint64(c.Timestamp))
+		if _, err := bw.Write(rec[:]); err != nil {
+			return err
+		}
+	}
+	var word [8]byte
+	for i := 0; i < (n+63)/64; i++ {
+		var v uint64
+		if i < len(cs.Valid) {
+			v = cs.Valid[i]
+		}
+		le.PutUint64(word[:], v)
+		if _, err := bw.Write(word[:]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// decodeCandleSetBinary parses a binary CandleSet file's bytes. When the
+// layout is native the returned set's Candles and Valid alias b, which
+// must then outlive it; otherwise they are copies.
+func decodeCandleSetBinary(b []byte) (*CandleSet, error) {
+	if len(b) < candleBinHeaderSize || string(b[:len(candleBinMagic)]) != candleBinMagic {
+		return nil, errors.New("not a binary candle set")
+	}
+	le := binary.LittleEndian
+	count := le.Uint64(b[8:])
+	if count > uint64(len(b)/candleBinRecordSize) {
+		return nil, fmt.Errorf("binary candle set: bad candle count %d", count)
+	}
+	n := int(count)
+	if len(b) != candleBinSize(n) {
+		return nil, fmt.Errorf("binary candle set: %d bytes for %d candles, want %d", len(b), n, candleBinSize(n))
+	}
+	cs := &CandleSet{
+		Instrument: strings.TrimRight(string(b[40:40+candleBinNameSize]), "\x00"),
+		Source:     strings.TrimRight(string(b[72:72+candleBinNameSize]), "\x00"),
+		Start:      types.Timestamp(le.Uint64(b[16:])),
+		Timeframe:  types.Timeframe(le.Uint64(b[24:])),
+		Scale:      types.Scale6(le.Uint64(b[32:])),
+	}
+	if n == 0 {
+		return cs, nil
+	}
+	recs := b[candleBinHeaderSize : candleBinHeaderSize+n*candleBinRecordSize]
+	valid := b[candleBinHeaderSize+n*candleBinRecordSize:]
+	words := (n + 63) / 64
+
+	if candleLayoutIsNative && uintptr(unsafe.Pointer(&recs[0]))%8 == 0 {
+		cs.Candles = unsafe.Slice((*market.Candle)(unsafe.Pointer(&recs[0])), n)
+		cs.Valid = unsafe.Slice((*uint64)(unsafe.Pointer(&valid[0])), words)
+		return cs, nil
+	}
+
+	cs.Candles = make([]market.Candle, n)
+	for i := range cs.Candles {
+		r := recs[i*candleBinRecordSize:]
+		cs.Candles[i] = market.Candle{
+			Open:      types.Price(le.Uint32(r[0:])),
+			High:      types.Price(le.Uint32(r[4:])),
+			Low:       types.Price(le.Uint32(r[8:])),
+			Close:     types.Price(le.Uint32(r[12:])),
+			AvgSpread: types.Price(le.Uint32(r[16:])),
+			MaxSpread: types.Price(le.Uint32(r[20:])),
+			Ticks:     int32(le.Uint32(r[24:])),
+			Timestamp: types.Timestamp(le.Uint64(r[32:])),
+		}
+	}
+	cs.Valid = make([]uint64, words)
+	for i := range cs.Valid {
+		cs.Valid[i] = le.Uint64(valid[i*8:])
+	}
+	return cs, nil
+}
+
+// OpenCandleSetBinary maps the binary CandleSet file at path read-only to
+// other processes and copy-on-write to this one, so the set's candles are
+// the file's pages until something writes to them. The mapping lives
+// until Close; ReadCSV's cache closes the sets it maps when it drops them.
+func OpenCandleSetBinary(path string) (*CandleSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < candleBinHeaderSize {
+		return nil, fmt.Errorf("%s: not a binary candle set", path)
+	}
+	b, unmap, err := mapCandleFile(f, int(info.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("map %s: %w", path, err)
+	}
+	cs, err := decodeCandleSetBinary(b)
+	if err != nil {
+		_ = unmap()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cs.Filepath = path
+	if len(cs.Candles) > 0 && unsafe.Pointer(&cs.Candles[0]) == unsafe.Pointer(&b[candleBinHeaderSize]) {
+		cs.unmap = unmap
+	} else if err := unmap(); err != nil {
+		return nil, fmt.Errorf("unmap %s: %w", path, err)
+	}
+	return cs, nil
+}
+
+// Close releases the file mapping behind a set OpenCandleSetBinary
+// returned, after which its Candles and Valid are gone. Sets holding their
+// own copy have nothing to release.
+func (cs *CandleSet) Close() error {
+	if cs.unmap == nil {
+		return nil
+	}
+	unmap := cs.unmap
+	cs.unmap, cs.Candles, cs.Valid = nil, nil, nil
+	return unmap()
+}
+
+// binaryCandlePath is where PackCandles writes the binary form of the
+// candle CSV at csvPath: beside it, with a .bin extension.
+func binaryCandlePath(csvPath string) string {
+	return strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + ".bin"
+}
+
+// readBinary returns the binary form of the candle CSV at csvPath when one
+// exists and is at least as new as the CSV. A stale or unreadable binary
+// is ignored and the CSV read instead, as is the current month's: ReadCSV
+// never caches it, so nothing would close its mapping.
+func (s *store) readBinary(key Key, csvPath string) (*CandleSet, bool) {
+	if isCurrentMonth(key) {
+		return nil, false
+	}
+	binPath := binaryCandlePath(csvPath)
+	binInfo, err := os.Stat(binPath)
+	if err != nil {
+		return nil, false
+	}
+	csvInfo, err := os.Stat(csvPath)
+	if err != nil || csvInfo.ModTime().After(binInfo.ModTime()) {
+		return nil, false
+	}
+	cs, err := OpenCandleSetBinary(binPath)
+	if err == nil && (cs.Instrument != market.NormalizeInstrument(key.Instrument) || cs.Timeframe != key.TF) {
+		err = fmt.Errorf("holds %s %s, not %s %s", cs.Instrument, cs.Timeframe, key.Instrument, key.TF)
+	}
+	if err != nil {
+		if cs != nil {
+			_ = cs.Close()
+		}
+		log.Data.Warn("ignoring binary candle file", "path", binPath, "error", err)
+		return nil, false
+	}
+	return cs, true
+}
+
+// PackCandles writes the binary form of key's stored candle month beside
+// its CSV, where ReadCSV maps it in place of parsing the CSV from then on.
+// WriteCSV removes it again, so it never outlives the data it was built
+// from. It returns the binary file's path.
+func PackCandles(key Key) (string, error) {
+	return getStore().packCandles(key)
+}
+
+func (s *store) packCandles(key Key) (string, error) {
+	path, err := s.KeyPath(key)
+	if err != nil {
+		return "", err
+	}
+	cs, err := s.readCSVUncached(key)
+	if err != nil {
+		return "", err
+	}
+	defer cs.Close()
+	binPath := binaryCandlePath(path)
+	f, err := os.CreateTemp(filepath.Dir(binPath), "."+filepath.Base(binPath)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	tmp := f.Name()
+	defer func() {
+		f.Close()
+		os.Remove(tmp)
+	}()
+	if err := WriteCandleSetBinary(f, cs); err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, binPath); err != nil {
+		return "", err
+	}
+	return binPath, nil
+}
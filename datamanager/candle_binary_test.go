@@ -0,0 +1,130 @@
+package datamanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestCandleSetBinary_RoundTrip(t *testing.T) {
+	cs := makeTestCandleSet(t, "EUR_USD", 2020, time.June, types.H1)
+	cs.Candles[0] = market.Candle{Open: 110000, High: 110050, Low: 109900, Close: 110020,
+		AvgSpread: 12, MaxSpread: 30, Ticks: 412, Timestamp: cs.Candles[0].Timestamp}
+	cs.SetValid(0)
+	cs.Candles[70].Close = -1 // signed prices survive
+	cs.SetValid(70)
+
+	path := filepath.Join(t.TempDir(), "eurusd.bin")
+	var buf bytes.Buffer
+	require.NoError(t, WriteCandleSetBinary(&buf, cs))
+	assert.Equal(t, candleBinSize(len(cs.Candles)), buf.Len())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	got, err := OpenCandleSetBinary(path)
+	require.NoError(t, err)
+	assert.Equal(t, cs.Instrument, got.Instrument)
+	assert.Equal(t, cs.Source, got.Source)
+	assert.Equal(t, cs.Start, got.Start)
+	assert.Equal(t, cs.Timeframe, got.Timeframe)
+	assert.Equal(t, cs.Scale, got.Scale)
+	assert.Equal(t, cs.Candles, got.Candles)
+	assert.Equal(t, cs.Valid, got.Valid)
+	assert.Equal(t, path, got.Filepath)
+
+	// Writes land in this process's copy of the page, not the file.
+	got.Candles[0].Close = 1
+	again, err := OpenCandleSetBinary(path)
+	require.NoError(t, err)
+	assert.Equal(t, types.Price(110020), again.Candles[0].Close)
+
+	// The portable decoder reads the same set.
+	native := candleLayoutIsNative
+	candleLayoutIsNative = false
+	defer func() { candleLayoutIsNative = native }()
+	decoded, err := decodeCandleSetBinary(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, cs.Candles, decoded.Candles)
+	assert.Equal(t, cs.Valid, decoded.Valid)
+}
+
+func TestOpenCandleSetBinary_RejectsBadFiles(t *testing.T) {
+	cs := makeTestCandleSet(t, "EUR_USD", 2020, time.June, types.H1)
+	var buf bytes.Buffer
+	require.NoError(t, WriteCandleSetBinary(&buf, cs))
+
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"short":     buf.Bytes()[:candleBinHeaderSize-1],
+		"truncated": buf.Bytes()[:buf.Len()-8],
+		"csv":       bytes.Repeat([]byte("1,2,3,4\n"), 32),
+	} {
+		path := filepath.Join(dir, name+".bin")
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		_, err := OpenCandleSetBinary(path)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestStorePackCandles_ReadCSVMapsThePackedMonth(t *testing.T) {
+	s := newTestStore(t)
+	cs := makeTestCandleSet(t, "EUR_USD", 2020, time.June, types.H1)
+	cs.Candles[0] = market.Candle{Open: 100, High: 105, Low: 99, Close: 103, Ticks: 1, Timestamp: cs.Candles[0].Timestamp}
+	cs.SetValid(0)
+	require.NoError(t, s.WriteCSV(cs))
+	key := keyForSet(cs)
+
+	binPath, err := s.packCandles(key)
+	require.NoError(t, err)
+	csvPath, err := s.KeyPath(key)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(filepath.Dir(csvPath), "EURUSD-2020-06-h1.bin"), binPath)
+
+	got, err := s.readCSVUncached(key)
+	require.NoError(t, err)
+	assert.Equal(t, binPath, got.Filepath, "served from the binary")
+	assert.Equal(t, cs.Candles, got.Candles)
+
+	// A newer CSV makes the binary stale.
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(csvPath, later, later))
+	got, err = s.readCSVUncached(key)
+	require.NoError(t, err)
+	assert.Empty(t, got.Filepath, "stale binary ignored")
+
+	// WriteCSV removes the binary it supersedes.
+	require.NoError(t, s.WriteCSV(cs))
+	_, err = os.Stat(binPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStoreReadCSV_InvalidateClosesTheMappedMonth(t *testing.T) {
+	s := newTestStore(t)
+	cs := makeTestCandleSet(t, "EUR_USD", 2020, time.June, types.H1)
+	cs.Candles[0] = market.Candle{Open: 100, High: 105, Low: 99, Close: 103, Ticks: 1, Timestamp: cs.Candles[0].Timestamp}
+	cs.SetValid(0)
+	require.NoError(t, s.WriteCSV(cs))
+	key := keyForSet(cs)
+	binPath, err := s.packCandles(key)
+	require.NoError(t, err)
+
+	got, err := s.ReadCSV(key)
+	require.NoError(t, err)
+	require.Equal(t, binPath, got.Filepath)
+	if !candleLayoutIsNative {
+		t.Skip("candles are decoded onto the heap, nothing is mapped")
+	}
+	require.NotNil(t, got.unmap)
+
+	require.NoError(t, s.WriteCSV(cs))
+	assert.Nil(t, got.unmap, "the cache closed the set it dropped")
+	assert.Nil(t, got.Candles)
+	assert.NoError(t, got.Close(), "closing twice is a no-op")
+}
@@ -0,0 +1,19 @@
+package datamanager
+
+import (
+	"github.com/rustyeddy/trader/indicator"
+	"github.com/rustyeddy/trader/market"
+)
+
+// Levels detects support and resistance zones across the set's valid
+// candles (gaps are skipped) with indicator.DetectLevels. The zones are as
+// of the last valid candle; cfg.Lookback bounds how far back they reach.
+func (cs *CandleSet) Levels(cfg indicator.LevelsConfig) ([]indicator.Level, error) {
+	candles := make([]market.Candle, 0, len(cs.Candles))
+	for i, c := range cs.Candles {
+		if cs.IsValid(i) {
+			candles = append(candles, c)
+		}
+	}
+	return indicator.DetectLevels(candles, cfg, cs.Scale)
+}
@@ -0,0 +1,49 @@
+package datamanager
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/indicator"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCandleSet_LevelsSkipsGaps(t *testing.T) {
+	var closes []types.Price
+	for range 3 {
+		for p := types.Price(110_000); p < 111_000; p += 200 {
+			closes = append(closes, p)
+		}
+		for p := types.Price(111_000); p > 110_000; p -= 200 {
+			closes = append(closes, p)
+		}
+	}
+
+	// Every third slot is a gap holding a spike that would otherwise be
+	// the only swing high.
+	n := len(closes) + len(closes)/2
+	cs := &CandleSet{Instrument: "EURUSD", Timeframe: types.H1, Scale: types.PriceScale,
+		Candles: make([]market.Candle, n), Valid: make([]uint64, (n+63)/64)}
+	next := 0
+	for i := range cs.Candles {
+		if i%3 == 2 {
+			cs.Candles[i] = market.Candle{Open: 120_000, High: 120_000, Low: 120_000, Close: 120_000}
+			continue
+		}
+		if next == len(closes) {
+			break
+		}
+		c := closes[next]
+		next++
+		cs.Candles[i] = market.Candle{Open: c, High: c + 10, Low: c - 10, Close: c}
+		cs.SetValid(i)
+	}
+
+	levels, err := cs.Levels(indicator.LevelsConfig{Strength: 2, Lookback: 200, ATRPeriod: 5, ZoneATR: 0.5, MinTouches: 2})
+	require.NoError(t, err)
+	require.Len(t, levels, 2)
+	assert.Equal(t, types.Price(109_990), levels[0].Price)
+	assert.Equal(t, types.Price(111_010), levels[1].Price)
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package datamanager
+
+import (
+	"io"
+	"os"
+)
+
+// mapCandleFile reads f onto the heap where mmap isn't available.
+func mapCandleFile(f *os.File, size int) ([]byte, func() error, error) {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(f, b); err != nil {
+		return nil, nil, err
+	}
+	return b, func() error { return nil }, nil
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package datamanager
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapCandleFile maps size bytes of f privately: other processes mapping
+// the same file share its pages, and a write here copies the page rather
+// than reaching the file.
+func mapCandleFile(f *os.File, size int) ([]byte, func() error, error) {
+	b, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, func() error { return syscall.Munmap(b) }, nil
+}
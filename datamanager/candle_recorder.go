@@ -0,0 +1,250 @@
+package datamanager
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// CandleRecorder builds candles from a live or replayed tick stream and
+// persists them to the canonical monthly candle files, so a practice
+// session leaves behind a dataset the backtester can load for the same
+// period.
+//
+// Candles use the same construction as the tick builder: OHLC of the mid
+// price, average and max spread, and tick count, on the same slot grid as
+// downloaded data (DST-aware for D1/H4). A candle is complete once a tick
+// for a later slot arrives; only complete candles are written. Ticks older
+// than the instrument's current slot are dropped.
+//
+// CandleRecorder is safe for concurrent use.
+type CandleRecorder struct {
+	mu     sync.Mutex
+	source string
+	tfs    []types.Timeframe
+
+	open    map[recorderSlot]*recordingCandle
+	pending map[Key][]market.Candle
+	written int
+	dropped int
+}
+
+type recorderSlot struct {
+	instrument string
+	tf         types.Timeframe
+}
+
+type recordingCandle struct {
+	candle    market.Candle
+	spreadSum int64
+}
+
+// NewCandleRecorder returns a recorder that writes candles for each of
+// tfs under source (e.g. "recorded") in the global store.
+func NewCandleRecorder(source string, tfs ...types.Timeframe) (*CandleRecorder, error) {
+	source = normalizeSource(source)
+	if source == "" {
+		return nil, fmt.Errorf("candle recorder: empty source")
+	}
+	if len(tfs) == 0 {
+		return nil, fmt.Errorf("candle recorder: no timeframes")
+	}
+	seen := make(map[types.Timeframe]bool, len(tfs))
+	uniq := make([]types.Timeframe, 0, len(tfs))
+	for _, tf := range tfs {
+		if tf <= 0 {
+			return nil, fmt.Errorf("candle recorder: invalid timeframe %d", tf)
+		}
+		if !seen[tf] {
+			seen[tf] = true
+			uniq = append(uniq, tf)
+		}
+	}
+	return &CandleRecorder{
+		source:  source,
+		tfs:     uniq,
+		open:    make(map[recorderSlot]*recordingCandle),
+		pending: make(map[Key][]market.Candle),
+	}, nil
+}
+
+// Record folds tick into the in-progress candle of every configured
+// timeframe, completing any candle whose slot the tick has moved past.
+func (r *CandleRecorder) Record(tick market.Tick) error {
+	if tick.Instrument == "" {
+		return fmt.Errorf("candle recorder: tick has no instrument")
+	}
+	if tick.Timestamp <= 0 {
+		return fmt.Errorf("candle recorder: bad tick timestamp %d", tick.Timestamp)
+	}
+	inst := market.NormalizeInstrument(tick.Instrument)
+	mid := tick.Mid()
+	spread := tick.Spread()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tf := range r.tfs {
+		slot := recorderSlot{instrument: inst, tf: tf}
+		open := slotOpen(tf, tick.Timestamp)
+
+		cur := r.open[slot]
+		if cur != nil {
+			switch {
+			case open < cur.candle.Timestamp:
+				r.dropped++
+				continue
+			case open > cur.candle.Timestamp:
+				r.complete(slot, cur)
+				cur = nil
+			}
+		}
+		if cur == nil {
+			r.open[slot] = &recordingCandle{
+				candle: market.Candle{
+					Open:      mid,
+					High:      mid,
+					Low:       mid,
+					Close:     mid,
+					MaxSpread: spread,
+					Ticks:     1,
+					Timestamp: open,
+				},
+				spreadSum: int64(spread),
+			}
+			continue
+		}
+
+		c := &cur.candle
+		if mid > c.High {
+			c.High = mid
+		}
+		if mid < c.Low {
+			c.Low = mid
+		}
+		c.Close = mid
+		c.Ticks++
+		if spread > c.MaxSpread {
+			c.MaxSpread = spread
+		}
+		cur.spreadSum += int64(spread)
+	}
+	return nil
+}
+
+// complete moves an in-progress candle into the pending set for its
+// month file. Callers hold r.mu.
+func (r *CandleRecorder) complete(slot recorderSlot, rc *recordingCandle) {
+	c := rc.candle
+	ticks := int64(c.Ticks)
+	c.AvgSpread = types.Price((rc.spreadSum + ticks/2) / ticks)
+
+	t := c.Timestamp.Time()
+	key := Key{
+		Instrument: slot.instrument,
+		Source:     r.source,
+		Kind:       KindCandle,
+		TF:         slot.tf,
+		Year:       t.Year(),
+		Month:      int(t.Month()),
+	}
+	r.pending[key] = append(r.pending[key], c)
+	delete(r.open, slot)
+}
+
+// Flush writes every completed candle not yet persisted, merging into any
+// existing month file so repeated sessions over the same month accumulate.
+// In-progress candles stay open.
+func (r *CandleRecorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flush()
+}
+
+func (r *CandleRecorder) flush() error {
+	keys := make([]Key, 0, len(r.pending))
+	for k := range r.pending {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Instrument != b.Instrument {
+			return a.Instrument < b.Instrument
+		}
+		if a.TF != b.TF {
+			return a.TF < b.TF
+		}
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		return a.Month < b.Month
+	})
+
+	s := getStore()
+	for _, k := range keys {
+		if err := r.writeMonth(s, k, r.pending[k]); err != nil {
+			return fmt.Errorf("candle recorder: write %s %s %04d-%02d: %w", k.Instrument, k.TF, k.Year, k.Month, err)
+		}
+		r.written += len(r.pending[k])
+		delete(r.pending, k)
+	}
+	return nil
+}
+
+func (r *CandleRecorder) writeMonth(s *store, k Key, candles []market.Candle) error {
+	monthStart := time.Date(k.Year, time.Month(k.Month), 1, 0, 0, 0, 0, time.UTC)
+	cs, err := NewMonthlyCandleSet(k.Instrument, k.TF, types.FromTime(monthStart), types.PriceScale, k.Source)
+	if err != nil {
+		return err
+	}
+
+	exists, err := s.Exists(k)
+	if err != nil {
+		return err
+	}
+	if exists {
+		prev, err := s.ReadCSV(k)
+		if err != nil {
+			return err
+		}
+		if err := cs.Merge(prev); err != nil {
+			return err
+		}
+	}
+	for _, c := range candles {
+		if err := cs.AddCandle(c.Timestamp, c); err != nil {
+			return err
+		}
+	}
+	return s.WriteCSV(cs)
+}
+
+// Close flushes completed candles and discards the in-progress ones: a
+// candle cut off by the end of the session is partial, and writing it
+// would leave a bar that disagrees with the same slot from a full feed.
+func (r *CandleRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.flush()
+	r.open = make(map[recorderSlot]*recordingCandle)
+	return err
+}
+
+// Written returns how many completed candles have been persisted.
+func (r *CandleRecorder) Written() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.written
+}
+
+// Dropped returns how many per-timeframe tick updates were ignored because
+// the tick fell before the instrument's current slot.
+func (r *CandleRecorder) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
@@ -0,0 +1,118 @@
+package datamanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+func recTick(t time.Time, bid, ask float64) market.Tick {
+	return market.Tick{
+		Instrument: "EUR_USD",
+		Timestamp:  types.FromTime(t),
+		BA:         market.BA{Bid: types.PriceFromFloat(bid), Ask: types.PriceFromFloat(ask)},
+	}
+}
+
+func TestCandleRecorder_WritesCompletedCandles(t *testing.T) {
+	UseTempDataDir(t)
+
+	rec, err := NewCandleRecorder("recorded", types.M1, types.H1)
+	require.NoError(t, err)
+
+	base := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	for _, tk := range []market.Tick{
+		recTick(base.Add(5*time.Second), 1.10000, 1.10010),
+		recTick(base.Add(20*time.Second), 1.10040, 1.10050),
+		recTick(base.Add(40*time.Second), 1.09980, 1.10000),
+		recTick(base.Add(61*time.Second), 1.10020, 1.10030),
+		recTick(base.Add(30*time.Second), 1.10000, 1.10010), // stale for M1 only
+		recTick(base.Add(125*time.Second), 1.10100, 1.10110),
+	} {
+		require.NoError(t, rec.Record(tk))
+	}
+	require.NoError(t, rec.Close())
+
+	// Minutes 10:00 and 10:01 are complete; 10:02 and the 10:00 hour are
+	// still open at Close and are discarded.
+	assert.Equal(t, 2, rec.Written())
+	assert.Equal(t, 1, rec.Dropped())
+
+	cs, err := getStore().ReadCSV(Key{
+		Instrument: "EURUSD", Source: "recorded", Kind: KindCandle,
+		TF: types.M1, Year: 2026, Month: 3,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, cs.CountValid())
+
+	idx := SlotIndexForTime(cs.Time(0), types.M1, base)
+	require.True(t, cs.IsValid(idx))
+	c := cs.Candles[idx]
+	assert.Equal(t, types.FromTime(base), c.Timestamp)
+	assert.Equal(t, types.PriceFromFloat(1.10005), c.Open)
+	assert.Equal(t, types.PriceFromFloat(1.10045), c.High)
+	assert.Equal(t, types.PriceFromFloat(1.09990), c.Low)
+	assert.Equal(t, types.PriceFromFloat(1.09990), c.Close)
+	assert.Equal(t, int32(3), c.Ticks)
+	assert.Equal(t, types.PriceFromFloat(0.00020), c.MaxSpread)
+	assert.Equal(t, types.PriceFromFloat(0.00013), c.AvgSpread)
+	assert.True(t, cs.IsValid(idx+1))
+}
+
+func TestCandleRecorder_MergesWithExistingMonth(t *testing.T) {
+	UseTempDataDir(t)
+
+	base := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	session := func(start time.Time) {
+		rec, err := NewCandleRecorder("recorded", types.M1)
+		require.NoError(t, err)
+		require.NoError(t, rec.Record(recTick(start, 1.1, 1.1001)))
+		require.NoError(t, rec.Record(recTick(start.Add(time.Minute), 1.1, 1.1001)))
+		require.NoError(t, rec.Close())
+	}
+	session(base)
+	session(base.Add(time.Hour))
+
+	cs, err := getStore().ReadCSV(Key{
+		Instrument: "EURUSD", Source: "recorded", Kind: KindCandle,
+		TF: types.M1, Year: 2026, Month: 3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, cs.CountValid())
+}
+
+func TestCandleRecorder_D1UsesDailyAlignment(t *testing.T) {
+	UseTempDataDir(t)
+
+	rec, err := NewCandleRecorder("recorded", types.D1)
+	require.NoError(t, err)
+
+	// EDT is in effect from 8 March 2026, so the trading day opens at
+	// 21:00 UTC: 20:00 UTC on the 10th belongs to the day opened on the 9th.
+	day := time.Date(2026, 3, 9, 21, 0, 0, 0, time.UTC)
+	require.NoError(t, rec.Record(recTick(time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC), 1.1, 1.1001)))
+	require.NoError(t, rec.Record(recTick(time.Date(2026, 3, 10, 21, 30, 0, 0, time.UTC), 1.1, 1.1001)))
+	require.NoError(t, rec.Close())
+
+	cs, err := getStore().ReadCSV(Key{
+		Instrument: "EURUSD", Source: "recorded", Kind: KindCandle,
+		TF: types.D1, Year: 2026, Month: 3,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, cs.CountValid())
+	idx := SlotIndexForTime(cs.Time(0), types.D1, day)
+	require.True(t, cs.IsValid(idx))
+	assert.Equal(t, types.FromTime(day), cs.Candles[idx].Timestamp)
+}
+
+func TestNewCandleRecorder_Validates(t *testing.T) {
+	_, err := NewCandleRecorder("", types.M1)
+	require.ErrorContains(t, err, "empty source")
+	_, err = NewCandleRecorder("recorded")
+	require.ErrorContains(t, err, "no timeframes")
+}
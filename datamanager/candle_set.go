@@ -33,6 +33,8 @@ type CandleSet struct {
 
 	duplicates int
 	outOfRange int
+
+	unmap func() error // releases the file pages Candles and Valid alias; see Close
 }
 
 // NewMonthlyCandleSet is an internal helper for trader type processing.
@@ -0,0 +1,208 @@
+package datamanager
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rustyeddy/trader/indicator"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// CandleTransform turns a stream of candles into a different candle series,
+// such as Heikin-Ashi candles or Renko bricks, for indicators and
+// strategies to run on. Like CandleAggregator, Add takes candles oldest
+// first and returns the candles they complete; the returned slice is
+// reused by the next call.
+type CandleTransform interface {
+	Name() string
+	Reset()
+	Add(c market.Candle) []market.Candle
+}
+
+// Transform feeds the set's valid candles through t, from a fresh state,
+// and returns the series it builds. The output is not on the set's time
+// grid (Renko emits any number of bricks per candle), so it is returned as
+// a plain slice rather than a CandleSet.
+func (cs *CandleSet) Transform(t CandleTransform) ([]market.Candle, error) {
+	if cs == nil {
+		return nil, fmt.Errorf("nil input candleset")
+	}
+	if t == nil {
+		return nil, fmt.Errorf("nil candle transform")
+	}
+	t.Reset()
+	var out []market.Candle
+	for i := range cs.Candles {
+		if !cs.IsValid(i) {
+			continue
+		}
+		out = append(out, t.Add(cs.Candles[i])...)
+	}
+	return out, nil
+}
+
+// HeikinAshi converts candles to Heikin-Ashi candles: the close is the
+// bar's OHLC average and the open is the midpoint of the previous
+// Heikin-Ashi body, which smooths out noise so trends read as runs of
+// same-coloured candles. Each input candle yields one output candle with
+// the input's timestamp, spread and tick count.
+type HeikinAshi struct {
+	prev market.Candle
+	has  bool
+	out  [1]market.Candle
+}
+
+func NewHeikinAshi() *HeikinAshi { return &HeikinAshi{} }
+
+func (h *HeikinAshi) Name() string { return "HeikinAshi" }
+
+func (h *HeikinAshi) Reset() { h.prev, h.has = market.Candle{}, false }
+
+// Add returns the Heikin-Ashi candle for c. Zero (gap-fill) candles are
+// ignored.
+func (h *HeikinAshi) Add(c market.Candle) []market.Candle {
+	if c.IsZero() {
+		return h.out[:0]
+	}
+	ha := c
+	ha.Close = types.Price((int64(c.Open) + int64(c.High) + int64(c.Low) + int64(c.Close) + 2) / 4)
+	if h.has {
+		ha.Open = types.Price((int64(h.prev.Open) + int64(h.prev.Close) + 1) / 2)
+	} else {
+		ha.Open = types.Price((int64(c.Open) + int64(c.Close) + 1) / 2)
+	}
+	ha.High = max(c.High, ha.Open, ha.Close)
+	ha.Low = min(c.Low, ha.Open, ha.Close)
+	h.prev, h.has = ha, true
+	h.out[0] = ha
+	return h.out[:]
+}
+
+// Renko converts candles to Renko bricks of a fixed price size, or of a
+// multiple of the ATR. Bricks are built from closes: a brick in the
+// current direction is added each time the close moves one brick past the
+// last brick, and a reversal takes a move of two bricks, the usual Renko
+// rule. A brick has no wicks: Open and Close are its edges, High and Low
+// the same two prices. It carries the timestamp, spread and tick count of
+// the candle that completed it, so several bricks can share a timestamp.
+//
+// With an ATR size, no bricks form until the ATR is warm; the first close
+// after that anchors the chart, and each brick is sized by the ATR as of
+// the candle that forms it.
+type Renko struct {
+	size types.Price // fixed brick size, 0 when sized by ATR
+	mult int64       // ATR multiplier ×1000; e.g. 1.5 → 1500
+	atr  *indicator.ATR
+
+	multF float64 // display only
+
+	open   types.Price // open of the last brick, or the anchor
+	last   types.Price // close of the last brick, or the anchor
+	dir    int         // direction of the last brick: 1 up, -1 down, 0 none yet
+	has    bool
+	bricks []market.Candle
+}
+
+// NewRenko returns a Renko transform with fixed bricks of size.
+func NewRenko(size types.Price) (*Renko, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("renko brick size must be > 0")
+	}
+	return &Renko{size: size}, nil
+}
+
+// NewATRRenko returns a Renko transform whose bricks are mult × ATR(period).
+func NewATRRenko(period int, mult float64, scale types.Scale6) (*Renko, error) {
+	if math.Round(mult*1000) <= 0 {
+		return nil, fmt.Errorf("renko atr multiplier must be >= 0.001")
+	}
+	atr, err := indicator.NewATR(period, scale)
+	if err != nil {
+		return nil, fmt.Errorf("renko: %w", err)
+	}
+	return &Renko{mult: int64(math.Round(mult * 1000)), atr: atr, multF: mult}, nil
+}
+
+func (r *Renko) Name() string {
+	if r.atr != nil {
+		return fmt.Sprintf("Renko(%.1f×%s)", r.multF, r.atr.Name())
+	}
+	return fmt.Sprintf("Renko(%s)", r.size)
+}
+
+func (r *Renko) Reset() {
+	if r.atr != nil {
+		r.atr.Reset()
+	}
+	r.open, r.last, r.dir, r.has = 0, 0, 0, false
+	r.bricks = r.bricks[:0]
+}
+
+// BrickSize returns the size the next brick will be, 0 while an ATR size
+// is still warming up.
+func (r *Renko) BrickSize() types.Price {
+	if r.atr == nil {
+		return r.size
+	}
+	if !r.atr.Ready() {
+		return 0
+	}
+	return max(types.Price((int64(r.atr.Price())*r.mult+500)/1000), 1)
+}
+
+// Add returns the bricks c's close completes, oldest first. Zero
+// (gap-fill) candles are ignored.
+func (r *Renko) Add(c market.Candle) []market.Candle {
+	r.bricks = r.bricks[:0]
+	if c.IsZero() {
+		return r.bricks
+	}
+	if r.atr != nil {
+		r.atr.Update(c)
+	}
+	size := r.BrickSize()
+	if size == 0 {
+		return r.bricks
+	}
+	if !r.has {
+		r.open, r.last, r.has = c.Close, c.Close, true
+		return r.bricks
+	}
+	for {
+		switch {
+		case r.dir >= 0 && c.Close >= r.last+size:
+			r.brick(c, r.last, r.last+size, 1)
+		case r.dir <= 0 && c.Close <= r.last-size:
+			r.brick(c, r.last, r.last-size, -1)
+		case r.dir > 0 && c.Close <= r.open-size:
+			// A reversal brick opens where the last brick opened.
+			r.brick(c, r.open, r.open-size, -1)
+		case r.dir < 0 && c.Close >= r.open+size:
+			r.brick(c, r.open, r.open+size, 1)
+		default:
+			return r.bricks
+		}
+	}
+}
+
+func (r *Renko) brick(c market.Candle, open, close types.Price, dir int) {
+	b := market.Candle{
+		Open:      open,
+		Close:     close,
+		High:      max(open, close),
+		Low:       min(open, close),
+		AvgSpread: c.AvgSpread,
+		MaxSpread: c.MaxSpread,
+		Ticks:     c.Ticks,
+		Timestamp: c.Timestamp,
+	}
+	r.bricks = append(r.bricks, b)
+	r.open, r.last, r.dir = open, close, dir
+}
+
+var (
+	_ CandleTransform = (*HeikinAshi)(nil)
+	_ CandleTransform = (*Renko)(nil)
+	_ CandleTransform = (*CandleAggregator)(nil)
+)
@@ -0,0 +1,120 @@
+package datamanager
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeikinAshi_SmoothsOpenAndClose(t *testing.T) {
+	h := NewHeikinAshi()
+
+	out := h.Add(market.Candle{Open: 100, High: 120, Low: 90, Close: 110, Ticks: 5, Timestamp: 60})
+	require.Len(t, out, 1)
+	assert.Equal(t, market.Candle{Open: 105, High: 120, Low: 90, Close: 105, Ticks: 5, Timestamp: 60}, out[0])
+
+	out = h.Add(market.Candle{Open: 110, High: 130, Low: 108, Close: 128, Timestamp: 120})
+	require.Len(t, out, 1)
+	// Open is the midpoint of the previous Heikin-Ashi body; close is the
+	// OHLC average.
+	assert.Equal(t, types.Price(105), out[0].Open)
+	assert.Equal(t, types.Price(119), out[0].Close)
+	assert.Equal(t, types.Price(130), out[0].High)
+	assert.Equal(t, types.Price(105), out[0].Low)
+
+	assert.Empty(t, h.Add(market.Candle{}), "gap-fill candles are ignored")
+
+	h.Reset()
+	out = h.Add(market.Candle{Open: 200, High: 200, Low: 200, Close: 200})
+	assert.Equal(t, types.Price(200), out[0].Open)
+}
+
+func TestRenko_FixedBricks(t *testing.T) {
+	r, err := NewRenko(10)
+	require.NoError(t, err)
+	assert.Equal(t, "Renko(0.00010)", r.Name())
+
+	assert.Empty(t, r.Add(market.Candle{Open: 100, High: 100, Low: 100, Close: 100, Timestamp: 1}), "the first close anchors the chart")
+	assert.Empty(t, r.Add(market.Candle{Open: 100, High: 109, Low: 100, Close: 109, Timestamp: 2}))
+
+	bricks := r.Add(market.Candle{Open: 109, High: 135, Low: 109, Close: 131, Timestamp: 3})
+	require.Len(t, bricks, 3)
+	for i, b := range bricks {
+		open := types.Price(100 + 10*i)
+		assert.Equal(t, market.Candle{Open: open, High: open + 10, Low: open, Close: open + 10, Timestamp: 3}, b)
+	}
+
+	// Pulling back one brick is not a reversal.
+	assert.Empty(t, r.Add(market.Candle{Open: 131, High: 131, Low: 120, Close: 120, Timestamp: 4}))
+
+	// Two bricks down from the top is: the reversal brick opens at the
+	// last up brick's open.
+	bricks = r.Add(market.Candle{Open: 120, High: 120, Low: 95, Close: 98, Timestamp: 5})
+	require.Len(t, bricks, 2)
+	assert.Equal(t, market.Candle{Open: 120, High: 120, Low: 110, Close: 110, Timestamp: 5}, bricks[0])
+	assert.Equal(t, market.Candle{Open: 110, High: 110, Low: 100, Close: 100, Timestamp: 5}, bricks[1])
+
+	_, err = NewRenko(0)
+	assert.Error(t, err)
+}
+
+func TestRenko_ATRBricksWaitForWarmup(t *testing.T) {
+	r, err := NewATRRenko(3, 2, types.PriceScale)
+	require.NoError(t, err)
+
+	// Every candle has a 10-point range, so the bricks are 20 points.
+	price := types.Price(1000)
+	var bricks []market.Candle
+	for i := range 20 {
+		c := market.Candle{Open: price, High: price + 10, Low: price, Close: price + 10, Timestamp: types.Timestamp(i)}
+		if r.BrickSize() == 0 {
+			assert.Empty(t, r.Add(c))
+		} else {
+			bricks = append(bricks, r.Add(c)...)
+		}
+		price += 10
+	}
+	assert.Equal(t, types.Price(20), r.BrickSize())
+	require.NotEmpty(t, bricks)
+	for _, b := range bricks {
+		assert.Equal(t, types.Price(20), b.Close-b.Open)
+	}
+	assert.Contains(t, r.Name(), "Renko(2.0×ATR")
+
+	_, err = NewATRRenko(14, 0, types.PriceScale)
+	assert.Error(t, err)
+}
+
+func TestCandleSet_TransformSkipsGaps(t *testing.T) {
+	cs := &CandleSet{Instrument: "EURUSD", Timeframe: types.M1, Scale: types.PriceScale,
+		Candles: make([]market.Candle, 4), Valid: make([]uint64, 1)}
+	for i, c := range []types.Price{100, 500, 110, 120} {
+		cs.Candles[i] = market.Candle{Open: c, High: c, Low: c, Close: c}
+		if i != 1 {
+			cs.SetValid(i)
+		}
+	}
+	r, err := NewRenko(10)
+	require.NoError(t, err)
+
+	bricks, err := cs.Transform(r)
+	require.NoError(t, err)
+	require.Len(t, bricks, 2)
+	assert.Equal(t, types.Price(120), bricks[1].Close)
+}
+
+func TestCandleAggregator_IsATransform(t *testing.T) {
+	agg, err := NewCandleAggregator(types.M1, types.H1)
+	require.NoError(t, err)
+	assert.Equal(t, "Aggregate(M1→H1)", agg.Name())
+
+	// Reset discards the open window, so the next hour's bar closes none.
+	c := market.Candle{Open: 1, High: 1, Low: 1, Close: 1, Timestamp: 0}
+	agg.Add(c)
+	agg.Reset()
+	c.Timestamp = types.Timestamp(types.H1)
+	assert.Empty(t, agg.Add(c))
+}
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/log"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
@@ -739,7 +740,12 @@ func (dm *DataManager) Candles(ctx context.Context, req CandleRequest) (market.C
 				continue
 			}
 			_ = closeCandleIterators(iters)
-			return nil, fmt.Errorf("load candles %v: %w", key, err)
+			err = fmt.Errorf("load candles %v: %w", key, err)
+			if errors.Is(err, os.ErrNotExist) {
+				// A strict request with a month missing from the store.
+				err = errs.Mark(err, errs.ErrDataGap)
+			}
+			return nil, err
 		}
 
 		iters = append(iters, newCandleSetIterator(cs, req.Range))
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 	"github.com/stretchr/testify/require"
@@ -361,6 +362,8 @@ func TestCandles_StrictMissingFileWrapsError(t *testing.T) {
 	_, err := dm.Candles(context.Background(), req)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "load candles")
+	require.ErrorIs(t, err, errs.ErrDataGap)
+	require.ErrorIs(t, err, os.ErrNotExist)
 }
 
 func TestCandles_ContextCancelledDuringIteration(t *testing.T) {
@@ -123,6 +123,35 @@ func (dm *DataManager) FetchCandleMonths(ctx context.Context, provider CandlePro
 // instead of each re-implementing their own "what's the last good date"
 // logic.
 func (dm *DataManager) LastCompleteDate(instrument string, tf types.Timeframe, source string) (time.Time, error) {
+	cs, err := newestCandleSet(instrument, tf, source)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, ok := cs.LastValidTime()
+	if !ok {
+		return time.Time{}, fmt.Errorf("no non-zero candles in %s/%s %s", instrument, tf, time.Unix(int64(cs.Start), 0).UTC().Format("2006-01"))
+	}
+	return t, nil
+}
+
+// LastCandleTime is LastCompleteDate at full resolution: the open time of
+// the newest valid candle stored for instrument/tf/source.
+func (dm *DataManager) LastCandleTime(instrument string, tf types.Timeframe, source string) (time.Time, error) {
+	cs, err := newestCandleSet(instrument, tf, source)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for i := len(cs.Candles) - 1; i >= 0; i-- {
+		if cs.IsValid(i) {
+			return cs.Time(i), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no non-zero candles in %s/%s %s", instrument, tf, time.Unix(int64(cs.Start), 0).UTC().Format("2006-01"))
+}
+
+// newestCandleSet reads the newest month file on disk for
+// instrument/tf/source, walking backward from the current month.
+func newestCandleSet(instrument string, tf types.Timeframe, source string) (*CandleSet, error) {
 	inst := market.NormalizeInstrument(instrument)
 	source = normalizeSource(source)
 	if source == "" {
@@ -132,9 +161,7 @@ func (dm *DataManager) LastCompleteDate(instrument string, tf types.Timeframe, s
 	s := getStore()
 	now := time.Now().UTC()
 
-	var found bool
-	var key Key
-	for year := now.Year(); year >= 2010 && !found; year-- {
+	for year := now.Year(); year >= 2010; year-- {
 		startMonth := 12
 		if year == now.Year() {
 			startMonth = int(now.Month())
@@ -143,29 +170,14 @@ func (dm *DataManager) LastCompleteDate(instrument string, tf types.Timeframe, s
 			k := Key{Instrument: inst, Source: source, Kind: KindCandle, TF: tf, Year: year, Month: month}
 			exists, err := s.Exists(k)
 			if err != nil {
-				return time.Time{}, err
+				return nil, err
 			}
 			if exists {
-				key = k
-				found = true
-				break
+				return s.ReadCSV(k)
 			}
 		}
 	}
-	if !found {
-		return time.Time{}, fmt.Errorf("no candle files found for %s/%s", instrument, tf)
-	}
-
-	cs, err := s.ReadCSV(key)
-	if err != nil {
-		return time.Time{}, err
-	}
-
-	t, ok := cs.LastValidTime()
-	if !ok {
-		return time.Time{}, fmt.Errorf("no non-zero candles in %s/%s %04d-%02d", instrument, tf, key.Year, key.Month)
-	}
-	return t, nil
+	return nil, fmt.Errorf("no candle files found for %s/%s", instrument, tf)
 }
 
 // DeriveResult is returned by DeriveCanonicalFromRaw.
@@ -0,0 +1,250 @@
+package datamanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// catalogFile is the name of the sync catalog, kept at the root of the
+// candles tree next to the provider directories.
+const catalogFile = "catalog.json"
+
+// SyncRecord is the catalog entry for one source/instrument/timeframe
+// series: where the last incremental sync left it.
+type SyncRecord struct {
+	Source     string    `json:"source"`
+	Instrument string    `json:"instrument"`
+	Timeframe  string    `json:"timeframe"`
+	LastCandle time.Time `json:"last_candle,omitzero"` // open time of the newest stored candle
+	LastSync   time.Time `json:"last_sync"`
+	Appended   int       `json:"appended"` // candles added by the last sync
+	Error      string    `json:"error,omitempty"`
+}
+
+// Key returns the record's catalog key, "<source>/<instrument>/<tf>".
+func (r SyncRecord) Key() string {
+	return r.Source + "/" + r.Instrument + "/" + r.Timeframe
+}
+
+// catalogMu serialises catalog read-modify-write cycles within a process;
+// the rename in writeCatalog keeps the file itself whole across processes.
+var catalogMu sync.Mutex
+
+// SyncCatalog returns every record in the current store's sync catalog,
+// sorted by key. A store that has never been synced has an empty catalog.
+func SyncCatalog() ([]SyncRecord, error) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	recs, err := readCatalog(getStore())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SyncRecord, 0, len(recs))
+	for _, r := range recs {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key() < out[j].Key() })
+	return out, nil
+}
+
+func recordSync(rec SyncRecord) error {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	s := getStore()
+	recs, err := readCatalog(s)
+	if err != nil {
+		return err
+	}
+	recs[rec.Key()] = rec
+	return writeCatalog(s, recs)
+}
+
+func readCatalog(s *store) (map[string]SyncRecord, error) {
+	recs := map[string]SyncRecord{}
+	data, err := os.ReadFile(filepath.Join(s.basedir, catalogFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return recs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &recs); err != nil {
+		return nil, fmt.Errorf("read sync catalog: %w", err)
+	}
+	return recs, nil
+}
+
+func writeCatalog(s *store, recs map[string]SyncRecord) error {
+	data, err := json.MarshalIndent(recs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.basedir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(s.basedir, catalogFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// IncrementalSyncRequest describes one SyncCandles call.
+type IncrementalSyncRequest struct {
+	Instrument string
+	Timeframe  types.Timeframe
+
+	// SeedFrom is where to start when nothing is stored yet for the
+	// series; with no seed an empty series is an error.
+	SeedFrom time.Time
+
+	// Now bounds the sync: only candles that closed at or before it are
+	// stored. Zero means time.Now().
+	Now time.Time
+
+	RawDir string // optional raw bid+ask archive root, as for FetchCandleMonths
+}
+
+// IncrementalSyncResult reports what SyncCandles did.
+type IncrementalSyncResult struct {
+	From       time.Time // first slot asked for: one step after the newest stored candle
+	LastCandle time.Time // newest stored candle after the sync; zero if none
+	Appended   int
+	UpToDate   bool // nothing new had closed yet; the provider was not called
+}
+
+// SyncCandles brings the stored series for req.Instrument/req.Timeframe up
+// to date from provider. It starts one step after the newest stored candle,
+// fetches only the months from there to req.Now, and fills only the slots
+// after that candle whose bar has completed, leaving everything already
+// stored untouched. Each month file is replaced atomically, and the outcome
+// — including failures — is recorded in the sync catalog. Running it again
+// with nothing new is a no-op apart from the catalog timestamp.
+func (dm *DataManager) SyncCandles(ctx context.Context, provider CandleProvider, req IncrementalSyncRequest) (*IncrementalSyncResult, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("nil candle provider")
+	}
+	if req.Instrument == "" {
+		return nil, fmt.Errorf("missing instrument")
+	}
+	tf := req.Timeframe
+	if tf <= 0 {
+		return nil, fmt.Errorf("invalid timeframe %v", tf)
+	}
+	now := req.Now.UTC()
+	if req.Now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	inst := market.NormalizeInstrument(req.Instrument)
+	rec := SyncRecord{Source: provider.Name(), Instrument: inst, Timeframe: strings.ToUpper(tf.String()), LastSync: now}
+	res, err := dm.syncCandles(ctx, provider, inst, tf, now, req)
+	if res != nil {
+		rec.LastCandle, rec.Appended = res.LastCandle, res.Appended
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if cerr := recordSync(rec); cerr != nil && err == nil {
+		err = fmt.Errorf("record sync: %w", cerr)
+	}
+	return res, err
+}
+
+func (dm *DataManager) syncCandles(ctx context.Context, provider CandleProvider, inst string, tf types.Timeframe, now time.Time, req IncrementalSyncRequest) (*IncrementalSyncResult, error) {
+	step := time.Duration(tf) * time.Second
+	res := &IncrementalSyncResult{}
+
+	last, err := dm.LastCandleTime(inst, tf, provider.Name())
+	switch {
+	case err == nil:
+		res.From, res.LastCandle = last.Add(step), last
+	case !req.SeedFrom.IsZero():
+		res.From = req.SeedFrom.UTC()
+	default:
+		return res, fmt.Errorf("detect last candle: %w (seed an empty series with a start date)", err)
+	}
+	if res.From.Add(step).After(now) {
+		res.UpToDate = true
+		return res, nil
+	}
+
+	s := getStore()
+	cursor := time.Date(res.From.Year(), res.From.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !cursor.After(now) {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		month, err := provider.FetchCandleMonth(ctx, inst, tf, cursor)
+		if err != nil {
+			return res, fmt.Errorf("fetch %s: %w", cursor.Format("2006-01"), err)
+		}
+
+		key := Key{Kind: KindCandle, Source: provider.Name(), Instrument: inst, TF: tf, Year: cursor.Year(), Month: int(cursor.Month())}
+		stored := map[types.Timestamp]market.Candle{}
+		if ok, _ := s.Exists(key); ok {
+			cs, err := s.ReadCSV(key)
+			if err != nil {
+				return res, fmt.Errorf("read %s: %w", cursor.Format("2006-01"), err)
+			}
+			for i := range cs.Candles {
+				if cs.IsValid(i) {
+					stored[cs.Candles[i].Timestamp] = cs.Candles[i]
+				}
+			}
+		}
+
+		merged, appended, newest := mergeNewCandles(month.Candles, stored, res.From, now, step)
+		if appended > 0 {
+			if err := s.WriteMonthlyCandleTimes(provider.Name(), inst, tf, cursor, merged); err != nil {
+				return res, fmt.Errorf("write %s: %w", cursor.Format("2006-01"), err)
+			}
+			if req.RawDir != "" && len(month.Raw) > 0 {
+				if err := writeRawMonth(req.RawDir, key, cursor, month.Raw); err != nil {
+					return res, fmt.Errorf("write raw %s: %w", cursor.Format("2006-01"), err)
+				}
+			}
+			res.Appended += appended
+			res.LastCandle = newest
+		}
+		cursor = cursor.AddDate(0, 1, 0)
+	}
+	return res, nil
+}
+
+// mergeNewCandles lays fetched over the stored candles of one month. Stored
+// slots always win; a fetched candle is taken only when it opens at or
+// after from and has closed by now. It returns the merged month, how many
+// candles it added and the open time of the newest one added.
+func mergeNewCandles(fetched []market.Candle, stored map[types.Timestamp]market.Candle, from, now time.Time, step time.Duration) ([]market.Candle, int, time.Time) {
+	merged := make([]market.Candle, len(fetched))
+	var appended int
+	var newest time.Time
+	for i, c := range fetched {
+		if old, ok := stored[c.Timestamp]; ok {
+			merged[i] = old
+			continue
+		}
+		open := c.Timestamp.Time()
+		if c.IsZero() || open.Before(from) || open.Add(step).After(now) {
+			merged[i] = market.Candle{Timestamp: c.Timestamp}
+			continue
+		}
+		merged[i] = c
+		appended++
+		newest = open
+	}
+	return merged, appended, newest
+}
@@ -214,3 +214,135 @@ func TestLastCompleteDate_PicksNewestMonth(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, time.Date(2026, 5, 5, 0, 0, 0, 0, time.UTC), got)
 }
+
+// stepProvider serves every slot of a month as a candle whose close is
+// base, so a test can tell which fetch a stored candle came from.
+type stepProvider struct {
+	base   types.Price
+	months int
+}
+
+func (p *stepProvider) Name() string { return market.SourceOanda }
+
+func (p *stepProvider) FetchCandleMonth(_ context.Context, _ string, tf types.Timeframe, monthStart time.Time) (*CandleMonth, error) {
+	p.months++
+	bounds := MonthSlotBoundaries(monthStart, monthStart.AddDate(0, 1, 0), tf)
+	candles := make([]market.Candle, len(bounds))
+	for i, b := range bounds {
+		candles[i] = market.Candle{Open: p.base, High: p.base + 10, Low: p.base - 10, Close: p.base, Ticks: 1, Timestamp: types.FromTime(b)}
+	}
+	return &CandleMonth{Candles: candles}, nil
+}
+
+func TestSyncCandles_AppendsOnlyNewCompleteCandles(t *testing.T) {
+	UseTempDataDir(t)
+	dm := NewDataManager([]string{"EURUSD"}, time.Now(), time.Now())
+	ctx := context.Background()
+
+	_, err := dm.SyncCandles(ctx, &stepProvider{base: 110000}, IncrementalSyncRequest{Instrument: "EUR_USD", Timeframe: types.H1})
+	require.Error(t, err, "an empty series needs a seed")
+
+	seed := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	p := &stepProvider{base: 110000}
+	res, err := dm.SyncCandles(ctx, p, IncrementalSyncRequest{
+		Instrument: "EUR_USD", Timeframe: types.H1, SeedFrom: seed,
+		Now: time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC), res.LastCandle, "the 10:00 bar has not closed")
+	assert.Positive(t, res.Appended)
+
+	// Nothing new has closed: the provider is not called again.
+	res, err = dm.SyncCandles(ctx, p, IncrementalSyncRequest{
+		Instrument: "EURUSD", Timeframe: types.H1,
+		Now: time.Date(2024, 3, 5, 10, 59, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.True(t, res.UpToDate)
+	assert.Equal(t, 1, p.months)
+
+	// Later, a provider serving different prices only adds the two new bars.
+	res, err = dm.SyncCandles(ctx, &stepProvider{base: 120000}, IncrementalSyncRequest{
+		Instrument: "EURUSD", Timeframe: types.H1,
+		Now: time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Appended)
+	assert.Equal(t, time.Date(2024, 3, 5, 11, 0, 0, 0, time.UTC), res.LastCandle)
+
+	cs, err := getStore().ReadCSV(Key{Kind: KindCandle, Source: market.SourceOanda, Instrument: "EURUSD", TF: types.H1, Year: 2024, Month: 3})
+	require.NoError(t, err)
+	for i := range cs.Candles {
+		if !cs.IsValid(i) {
+			continue
+		}
+		want := types.Price(110000)
+		if !cs.Time(i).Before(time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)) {
+			want = 120000
+		}
+		assert.Equal(t, want, cs.Candles[i].Close, "slot %s", cs.Time(i))
+	}
+
+	recs, err := SyncCatalog()
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "oanda/EURUSD/H1", recs[0].Key())
+	assert.Equal(t, 2, recs[0].Appended)
+	assert.Equal(t, time.Date(2024, 3, 5, 11, 0, 0, 0, time.UTC), recs[0].LastCandle)
+	assert.Empty(t, recs[0].Error)
+}
+
+func TestFetchMissingCandles_FetchesOnlyMissingMonths(t *testing.T) {
+	UseTempDataDir(t)
+	dm := NewDataManager([]string{"EURUSD"}, time.Now(), time.Now())
+	ctx := context.Background()
+	req := CandleRequest{
+		Source:     market.SourceOanda,
+		Instrument: "EUR_USD",
+		Range: types.NewTimeRange(
+			types.FromTime(time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)),
+			types.FromTime(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)),
+			types.H1),
+	}
+
+	p := &stepProvider{base: 110000}
+	res, err := dm.FetchMissingCandles(ctx, p, req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.MonthsProcessed)
+	assert.Positive(t, res.CandlesWritten)
+	assert.Equal(t, 2, p.months)
+
+	it, err := dm.Candles(ctx, CandleRequest{Source: market.SourceOanda, Instrument: "EURUSD", Range: req.Range, Strict: true})
+	require.NoError(t, err)
+	c, ok := it.Next()
+	require.True(t, ok)
+	assert.Equal(t, types.Price(110000), c.Close)
+	require.NoError(t, it.Close())
+
+	recs, err := SyncCatalog()
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "oanda/EURUSD/H1", recs[0].Key())
+	assert.Equal(t, time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC), recs[0].LastCandle)
+
+	// Everything is stored now: the provider is not called again.
+	res, err = dm.FetchMissingCandles(ctx, p, req)
+	require.NoError(t, err)
+	assert.Zero(t, res.MonthsProcessed)
+	assert.Equal(t, 2, p.months)
+
+	// March was still in progress at the last recorded sync, so it is
+	// fetched again, keeping the candles already stored.
+	rec := recs[0]
+	rec.LastSync = time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, recordSync(rec))
+	p2 := &stepProvider{base: 120000}
+	res, err = dm.FetchMissingCandles(ctx, p2, req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.MonthsProcessed)
+	assert.Zero(t, res.CandlesWritten)
+	assert.Equal(t, 1, p2.months)
+
+	_, err = dm.FetchMissingCandles(ctx, nil, req)
+	require.Error(t, err)
+}
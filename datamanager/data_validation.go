@@ -35,6 +35,7 @@ type CandleValidationIssue struct {
 	Present       int      `json:"present"`
 	Missing       int      `json:"missing"`
 	SampleMissing []string `json:"sample_missing,omitempty"`
+	SampleInvalid []string `json:"sample_invalid,omitempty"`
 	Message       string   `json:"message"`
 }
 
@@ -140,6 +141,8 @@ type candleCoverage struct {
 	Missing       int
 	Invalid       int
 	SampleMissing []string
+	SampleInvalid []string
+	Faults        map[market.CandleFault]int
 }
 
 func validateCandleMonth(key Key, includeRaw bool, rawDir string) ([]CandleValidationIssue, error) {
@@ -181,7 +184,7 @@ func validateCandleMonth(key Key, includeRaw bool, rawDir string) ([]CandleValid
 	}
 
 	monthEnd := time.Date(key.Year, time.Month(key.Month), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
-	coverage := analyzeCandleCoverage(cs, now, monthEnd)
+	coverage := analyzeCandleCoverage(cs, key.Instrument, now, monthEnd)
 	issues := make([]CandleValidationIssue, 0, 4)
 	if coverage.Missing > 0 {
 		issues = append(issues, CandleValidationIssue{
@@ -202,17 +205,19 @@ func validateCandleMonth(key Key, includeRaw bool, rawDir string) ([]CandleValid
 	}
 	if coverage.Invalid > 0 {
 		issues = append(issues, CandleValidationIssue{
-			Kind:       "invalid_candles",
-			Severity:   "error",
-			Source:     key.Source,
-			Instrument: key.Instrument,
-			Timeframe:  key.TF.String(),
-			Year:       key.Year,
-			Month:      key.Month,
-			Path:       path,
-			Expected:   coverage.Expected,
-			Present:    coverage.Present,
-			Message:    fmt.Sprintf("%d valid candle rows have invalid OHLC shape", coverage.Invalid),
+			Kind:          "invalid_candles",
+			Severity:      "error",
+			Source:        key.Source,
+			Instrument:    key.Instrument,
+			Timeframe:     key.TF.String(),
+			Year:          key.Year,
+			Month:         key.Month,
+			Path:          path,
+			Expected:      coverage.Expected,
+			Present:       coverage.Present,
+			SampleInvalid: coverage.SampleInvalid,
+			Message: fmt.Sprintf("%d candle rows failed sanity checks (%s)",
+				coverage.Invalid, market.FormatCandleFaults(coverage.Faults)),
 		})
 	}
 
@@ -232,14 +237,17 @@ func validateCandleMonth(key Key, includeRaw bool, rawDir string) ([]CandleValid
 // day's true daily-alignment window can run a few hours into the next
 // calendar month, but that data structurally lives in next month's own
 // raw/canonical file, so a slot at or after monthEnd is never fillable
-// from this file and must not count as "expected" here.
-func analyzeCandleCoverage(cs *CandleSet, now, monthEnd time.Time) candleCoverage {
+// from this file and must not count as "expected" here. Present candles
+// go through a market.CandleChecker for instrument; a row that isn't
+// usable counts as Invalid.
+func analyzeCandleCoverage(cs *CandleSet, instrument string, now, monthEnd time.Time) candleCoverage {
 	if cs == nil || cs.Timeframe <= 0 {
 		return candleCoverage{}
 	}
 
 	step := time.Duration(cs.Timeframe) * time.Second
 	var cov candleCoverage
+	chk := &market.CandleChecker{Instrument: instrument}
 	for i := range cs.Candles {
 		// cs.Time reads the slot's own true timestamp rather than
 		// reconstructing it from Start+idx*step, which drifts an hour
@@ -264,7 +272,19 @@ func analyzeCandleCoverage(cs *CandleSet, now, monthEnd time.Time) candleCoverag
 			continue
 		}
 		cov.Present++
-		if !cs.Candles[i].Validate() {
+		c := cs.Candles[i]
+		c.Timestamp = types.FromTime(slotStart)
+		vs := chk.Check(&c)
+		for _, v := range vs {
+			if cov.Faults == nil {
+				cov.Faults = make(map[market.CandleFault]int)
+			}
+			cov.Faults[v.Fault]++
+			if len(cov.SampleInvalid) < 10 {
+				cov.SampleInvalid = append(cov.SampleInvalid, v.String())
+			}
+		}
+		if !market.CandleUsable(vs) {
 			cov.Invalid++
 		}
 	}
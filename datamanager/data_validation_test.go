@@ -65,7 +65,7 @@ func TestValidateCandleData_MissingRawOandaMonth(t *testing.T) {
 		if !timeRangeMayHaveForexData(slotStart, slotEnd) {
 			continue
 		}
-		cs.Candles[i] = market.Candle{Open: 100, High: 101, Low: 99, Close: 100, Ticks: 1, Timestamp: cs.Candles[i].Timestamp}
+		cs.Candles[i] = market.Candle{Open: 110000, High: 110100, Low: 109900, Close: 110000, Ticks: 1, Timestamp: cs.Candles[i].Timestamp}
 		cs.SetValid(i)
 	}
 	require.NoError(t, s.WriteCSV(cs))
@@ -113,3 +113,43 @@ func TestValidateCandleData_RawMismatch(t *testing.T) {
 	}
 	require.True(t, kinds["raw_complete_missing_canonical"])
 }
+
+func TestValidateCandleData_InvalidCandles(t *testing.T) {
+	s := useTempStore(t)
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cs, err := NewMonthlyCandleSet("EURUSD", types.H1, types.FromTime(start), types.PriceScale, market.SourceOanda)
+	require.NoError(t, err)
+
+	// Tuesday 6 January, 10:00-12:00 UTC: inverted, implausible, clean.
+	first := 5*24 + 10
+	for i, c := range []market.Candle{
+		{Open: 110000, High: 109900, Low: 110100, Close: 110000, Ticks: 1},
+		{Open: 500, High: 501, Low: 499, Close: 500, Ticks: 1},
+		{Open: 110000, High: 110100, Low: 109900, Close: 110000, Ticks: 1},
+	} {
+		c.Timestamp = cs.Candles[first+i].Timestamp
+		cs.Candles[first+i] = c
+		cs.SetValid(first + i)
+	}
+	require.NoError(t, s.WriteCSV(cs))
+
+	report, err := ValidateCandleData(context.Background(), CandleValidationRequest{
+		Instruments: []string{"EURUSD"},
+		Source:      market.SourceOanda,
+		Timeframe:   types.H1,
+		Start:       start,
+		End:         start.AddDate(0, 1, 0),
+	})
+	require.NoError(t, err)
+
+	var issue *CandleValidationIssue
+	for i := range report.Issues {
+		if report.Issues[i].Kind == "invalid_candles" {
+			issue = &report.Issues[i]
+		}
+	}
+	require.NotNil(t, issue)
+	require.Equal(t, "2 candle rows failed sanity checks (implausible=1 inverted=1)", issue.Message)
+	require.Len(t, issue.SampleInvalid, 2)
+	require.Contains(t, issue.SampleInvalid[0], "inverted")
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/rustyeddy/trader/datamanager"
 	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -64,7 +65,7 @@ func (f *File) Instrument() string {
 func (f *File) URL() string {
 	return fmt.Sprintf(
 		"https://datafeed.dukascopy.com/datafeed/%s/%04d/%02d/%02d/%02dh_ticks.bi5",
-		f.symbol,
+		symbols.ToProvider(symbols.Dukascopy, f.symbol),
 		f.t.Year(),
 		f.t.Month()-1,
 		f.t.Day(),
@@ -0,0 +1,203 @@
+package datamanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// CandleReader reads candles from the local store. *DataManager
+// implements it; store-backed feeds read through it.
+type CandleReader interface {
+	Candles(context.Context, CandleRequest) (market.CandleIterator, error)
+}
+
+// FeedParams is what a feed is opened with: the bars asked for, and the
+// feed's own settings from the dataset section's params.
+type FeedParams struct {
+	Instrument string
+	Range      types.TimeRange
+	Strict     bool           // fail on months missing from the store, for store-backed feeds
+	Params     map[string]any // kind-specific: path, seed, ...
+	Store      CandleReader   // for store-backed feeds; nil when there is no store
+}
+
+// FeedConstructor opens a candle feed for p. The iterator yields the bars
+// of p.Range in time order.
+type FeedConstructor func(ctx context.Context, p FeedParams) (market.CandleIterator, error)
+
+var (
+	feedMu       sync.RWMutex
+	feedRegistry = map[string]FeedConstructor{}
+)
+
+// RegisterFeed registers ctor under one or more feed kinds, the names a
+// dataset's source selects it by. Kinds are case-insensitive; registering
+// a kind again replaces it, so a custom feed can override a built-in.
+func RegisterFeed(ctor FeedConstructor, kinds ...string) error {
+	if ctor == nil {
+		return fmt.Errorf("RegisterFeed: nil constructor")
+	}
+	if len(kinds) == 0 {
+		return fmt.Errorf("RegisterFeed: no feed kinds provided")
+	}
+	feedMu.Lock()
+	defer feedMu.Unlock()
+	for _, kind := range kinds {
+		normalized := normalizeSource(kind)
+		if normalized == "" {
+			return fmt.Errorf("RegisterFeed: blank feed kind")
+		}
+		feedRegistry[normalized] = ctor
+	}
+	return nil
+}
+
+// MustRegisterFeed is RegisterFeed for init functions: it panics on error.
+func MustRegisterFeed(ctor FeedConstructor, kinds ...string) {
+	if err := RegisterFeed(ctor, kinds...); err != nil {
+		panic(err)
+	}
+}
+
+// LookupFeed returns the constructor registered under kind, or nil.
+func LookupFeed(kind string) FeedConstructor {
+	feedMu.RLock()
+	defer feedMu.RUnlock()
+	return feedRegistry[normalizeSource(kind)]
+}
+
+// RegisteredFeeds returns the registered feed kinds, sorted.
+func RegisteredFeeds() []string {
+	feedMu.RLock()
+	defer feedMu.RUnlock()
+	out := make([]string, 0, len(feedRegistry))
+	for k := range feedRegistry {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// NewFeed opens the feed of the given kind. A kind nothing is registered
+// under names a source in the local store, read as StoreFeed reads it, so
+// data imported under a source name of its own needs no registration.
+// When that read fails the error lists the registered kinds, in case kind
+// was meant to be one of them.
+func NewFeed(ctx context.Context, kind string, p FeedParams) (market.CandleIterator, error) {
+	kind = normalizeSource(kind)
+	if kind == "" {
+		kind = market.SourceOanda
+	}
+	ctor := LookupFeed(kind)
+	if ctor == nil {
+		it, err := StoreFeed(kind)(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("feed %s (not a registered kind: %s; read as a store source): %w",
+				kind, strings.Join(RegisteredFeeds(), ", "), err)
+		}
+		return it, nil
+	}
+	it, err := ctor(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("feed %s: %w", kind, err)
+	}
+	return it, nil
+}
+
+// StoreFeed returns a feed reading source's candles from the local store.
+// It takes no params.
+func StoreFeed(source string) FeedConstructor {
+	return func(ctx context.Context, p FeedParams) (market.CandleIterator, error) {
+		if err := checkFeedParams(p.Params); err != nil {
+			return nil, err
+		}
+		if p.Store == nil {
+			return nil, fmt.Errorf("no candle store to read %s from", source)
+		}
+		return p.Store.Candles(ctx, CandleRequest{
+			Source:     source,
+			Instrument: p.Instrument,
+			Range:      p.Range,
+			Strict:     p.Strict,
+		})
+	}
+}
+
+func init() {
+	MustRegisterFeed(StoreFeed(market.SourceCandles), market.SourceCandles)
+	MustRegisterFeed(StoreFeed(market.SourceOanda), market.SourceOanda, "oanda-candles")
+	MustRegisterFeed(StoreFeed(market.SourceDukascopy), market.SourceDukascopy)
+	MustRegisterFeed(csvFeed, "csv")
+	MustRegisterFeed(csvDirFeed, "csv-dir")
+	MustRegisterFeed(sqliteFeed, "sqlite")
+	MustRegisterFeed(syntheticFeed, "synthetic")
+}
+
+// checkFeedParams fails on a param not in known, so a misspelt param
+// fails loudly rather than the feed running with its default.
+func checkFeedParams(params map[string]any, known ...string) error {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !contains(known, k) {
+			if len(known) == 0 {
+				return fmt.Errorf("unknown param %q: this feed takes none", k)
+			}
+			return fmt.Errorf("unknown param %q (known: %s)", k, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredStringParam returns params[key], failing when it is missing or
+// blank.
+func requiredStringParam(params map[string]any, key string) (string, error) {
+	v, ok, err := types.GetStringParam(params, key)
+	if err != nil {
+		return "", err
+	}
+	if !ok || strings.TrimSpace(v) == "" {
+		return "", fmt.Errorf("param %q is required", key)
+	}
+	return strings.TrimSpace(v), nil
+}
+
+// sliceCandleIterator yields a slice of candles already in time order.
+type sliceCandleIterator struct {
+	candles []market.Candle
+	idx     int
+}
+
+func newSliceCandleIterator(candles []market.Candle) market.CandleIterator {
+	return &sliceCandleIterator{candles: candles}
+}
+
+func (it *sliceCandleIterator) Next() (market.Candle, bool) {
+	if it.idx >= len(it.candles) {
+		return market.Candle{}, false
+	}
+	c := it.candles[it.idx]
+	it.idx++
+	return c, true
+}
+
+func (it *sliceCandleIterator) Err() error   { return nil }
+func (it *sliceCandleIterator) Close() error { return nil }
@@ -0,0 +1,246 @@
+package datamanager
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// csvFeed reads one candle CSV file: params path, and optionally
+// instrument, the instrument the file holds. With instrument set, asking
+// the feed for any other fails instead of replaying the wrong pair's bars.
+func csvFeed(_ context.Context, p FeedParams) (market.CandleIterator, error) {
+	if err := checkFeedParams(p.Params, "path", "instrument"); err != nil {
+		return nil, err
+	}
+	path, err := requiredStringParam(p.Params, "path")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFeedInstrument(p); err != nil {
+		return nil, err
+	}
+	candles, err := readCandleCSVFile(path, p.Range)
+	if err != nil {
+		return nil, err
+	}
+	return newSliceCandleIterator(candles), nil
+}
+
+// csvDirFeed reads every *.csv file under params dir, recursively, as one
+// series, e.g. a directory of monthly files or a copy of a store source.
+// Like csvFeed it takes an optional instrument.
+func csvDirFeed(_ context.Context, p FeedParams) (market.CandleIterator, error) {
+	if err := checkFeedParams(p.Params, "dir", "instrument"); err != nil {
+		return nil, err
+	}
+	dir, err := requiredStringParam(p.Params, "dir")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFeedInstrument(p); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".csv") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .csv files under %s", dir)
+	}
+
+	var candles []market.Candle
+	for _, path := range paths {
+		cs, err := readCandleCSVFile(path, p.Range)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, cs...)
+	}
+	sort.SliceStable(candles, func(i, j int) bool { return candles[i].Timestamp < candles[j].Timestamp })
+	for i := 1; i < len(candles); i++ {
+		if candles[i].Timestamp == candles[i-1].Timestamp {
+			return nil, fmt.Errorf("%s: two bars at %s", dir, candles[i].Timestamp)
+		}
+	}
+	return newSliceCandleIterator(candles), nil
+}
+
+// checkFeedInstrument fails when p.Params names an instrument other than
+// the one asked for.
+func checkFeedInstrument(p FeedParams) error {
+	inst, ok, err := types.GetStringParam(p.Params, "instrument")
+	if err != nil || !ok {
+		return err
+	}
+	if market.NormalizeInstrument(inst) != market.NormalizeInstrument(p.Instrument) {
+		return fmt.Errorf("feed holds %s, not %s", inst, p.Instrument)
+	}
+	return nil
+}
+
+func readCandleCSVFile(path string, rng types.TimeRange) ([]market.Candle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readCandleCSV(f, path, rng)
+}
+
+// readCandleCSV reads candle rows from r, keeping the bars in rng. It
+// takes either of two layouts:
+//
+//   - the store's own files, marked by their "# schema=candle-v2" line:
+//     raw scaled prices, and flags whose low bit marks a real bar rather
+//     than a gap;
+//   - plain "time,open,high,low,close[,spread]" rows, with RFC3339 or
+//     Unix-second times and decimal prices.
+//
+// A header row is skipped; rows must be in time order.
+func readCandleCSV(r io.Reader, name string, rng types.TimeRange) ([]market.Candle, error) {
+	var (
+		out      []market.Candle
+		raw      bool
+		prev     types.Timestamp
+		havePrev bool
+		rowNum   int
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if strings.Contains(line, "schema=candle") {
+				raw = true
+			}
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if looksLikeHeader(fields) {
+			continue
+		}
+		rowNum++
+
+		var (
+			c   market.Candle
+			ok  bool
+			err error
+		)
+		if raw {
+			c, ok, err = parseStoreCandleRow(fields)
+		} else {
+			c, ok, err = parsePlainCandleRow(fields)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s row %d: %w", name, rowNum, err)
+		}
+		if !ok {
+			continue
+		}
+		if havePrev && c.Timestamp <= prev {
+			return nil, fmt.Errorf("%s row %d: time %s not after the previous row's %s", name, rowNum, c.Timestamp, prev)
+		}
+		prev, havePrev = c.Timestamp, true
+		if rng.Valid() && !rng.Contains(c.Timestamp) {
+			continue
+		}
+		out = append(out, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
+
+// parseStoreCandleRow parses a row of the store's CSV layout; ok is false
+// for gap rows.
+func parseStoreCandleRow(fields []string) (c market.Candle, ok bool, err error) {
+	if len(fields) < 9 {
+		return c, false, fmt.Errorf("expected 9 fields, got %d", len(fields))
+	}
+	ts, err := strconv.ParseInt(strings.TrimSpace(fields[0]), 10, 64)
+	if err != nil {
+		return c, false, fmt.Errorf("parse timestamp: %w", err)
+	}
+	c.Timestamp = types.Timestamp(ts)
+	prices := []*types.Price{&c.Open, &c.High, &c.Low, &c.Close, &c.AvgSpread, &c.MaxSpread}
+	for i, dst := range prices {
+		if *dst, err = types.ParseRawPrice(fields[i+1]); err != nil {
+			return c, false, fmt.Errorf("parse price column %d: %w", i+2, err)
+		}
+	}
+	ticks, err := strconv.ParseInt(strings.TrimSpace(fields[7]), 10, 32)
+	if err != nil {
+		return c, false, fmt.Errorf("parse ticks: %w", err)
+	}
+	c.Ticks = int32(ticks)
+	flags, err := strconv.ParseUint(strings.TrimSpace(fields[8]), 0, 64)
+	if err != nil {
+		return c, false, fmt.Errorf("parse flags: %w", err)
+	}
+	return c, flags&0x0001 != 0, nil
+}
+
+// maxDecimalPrice is the largest decimal price a types.Price holds.
+const maxDecimalPrice = float64(math.MaxInt32) / float64(types.PriceScale)
+
+// parsePlainCandleRow parses a "time,open,high,low,close[,spread]" row.
+func parsePlainCandleRow(fields []string) (c market.Candle, ok bool, err error) {
+	if len(fields) < 5 {
+		return c, false, fmt.Errorf("expected time,open,high,low,close[,spread], got %d fields", len(fields))
+	}
+	ts := strings.TrimSpace(fields[0])
+	if n, err := strconv.ParseInt(ts, 10, 64); err == nil {
+		c.Timestamp = types.Timestamp(n)
+	} else {
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return c, false, fmt.Errorf("bad time %q: want RFC3339 or Unix seconds", ts)
+		}
+		c.Timestamp = types.FromTime(t)
+	}
+
+	// Decimal prices are converted to fixed point here, at the file
+	// boundary.
+	prices := []*types.Price{&c.Open, &c.High, &c.Low, &c.Close}
+	if len(fields) > 5 {
+		prices = append(prices, &c.AvgSpread)
+	}
+	for i, dst := range prices {
+		v, err := strconv.ParseFloat(strings.TrimSpace(fields[i+1]), 64)
+		if err != nil {
+			return c, false, fmt.Errorf("bad price %q: %w", fields[i+1], err)
+		}
+		if !(v >= 0 && v <= maxDecimalPrice) {
+			return c, false, fmt.Errorf("price %q out of range", fields[i+1])
+		}
+		*dst = types.PriceFromFloat(v)
+	}
+	c.MaxSpread = c.AvgSpread
+	return c, true, nil
+}
@@ -0,0 +1,85 @@
+package datamanager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	_ "github.com/mattn/go-sqlite3" // registers the "sqlite3" driver
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// sqliteTableName keeps the table param to a plain identifier, since it
+// is spliced into the query.
+var sqliteTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sqliteFeed reads candles from a SQLite file: params path, and table
+// (default "candles"), which must have the columns
+//
+//	instrument TEXT, timeframe TEXT, ts INTEGER,
+//	open INTEGER, high INTEGER, low INTEGER, close INTEGER, avg_spread INTEGER
+//
+// with ts in Unix seconds, prices as scaled types.Price, instruments as
+// EURUSD and timeframes as m1, h1, h4 or d1 (either case). One file can
+// hold any number of instruments and timeframes.
+func sqliteFeed(ctx context.Context, p FeedParams) (market.CandleIterator, error) {
+	if err := checkFeedParams(p.Params, "path", "table"); err != nil {
+		return nil, err
+	}
+	path, err := requiredStringParam(p.Params, "path")
+	if err != nil {
+		return nil, err
+	}
+	table, ok, err := types.GetStringParam(p.Params, "table")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		table = "candles"
+	}
+	if !sqliteTableName.MatchString(table) {
+		return nil, fmt.Errorf("param table %q is not a table name", table)
+	}
+
+	// mode=ro: a feed never writes, and a missing file is an error rather
+	// than a new empty database.
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	q := fmt.Sprintf(`SELECT ts, open, high, low, close, avg_spread FROM %s
+		WHERE instrument = ? AND lower(timeframe) = ? AND ts >= ? AND ts < ?
+		ORDER BY ts`, table)
+	rows, err := db.QueryContext(ctx, q, market.NormalizeInstrument(p.Instrument), p.Range.TF.String(),
+		int64(p.Range.Start), int64(p.Range.End))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer rows.Close()
+
+	var candles []market.Candle
+	for rows.Next() {
+		var ts, o, h, l, c, spread int64
+		if err := rows.Scan(&ts, &o, &h, &l, &c, &spread); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		candles = append(candles, market.Candle{
+			Timestamp: types.Timestamp(ts),
+			Open:      types.Price(o),
+			High:      types.Price(h),
+			Low:       types.Price(l),
+			Close:     types.Price(c),
+			AvgSpread: types.Price(spread),
+			MaxSpread: types.Price(spread),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return newSliceCandleIterator(candles), nil
+}
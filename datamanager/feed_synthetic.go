@@ -0,0 +1,77 @@
+package datamanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// syntheticFeed generates candles instead of reading them, for plumbing
+// tests and strategy smoke runs: a seeded random walk (see
+// SyntheticCandleConfig) over the weekday trading hours of the range.
+// Params: seed, start-price (decimal, default 1.08), volatility and trend
+// (per bar, defaults 0.002 and 0.00005) and ticks-per-bar. The walk
+// starts at start-price at the start of the range asked for, so a run
+// and its warm-up are separate walks.
+func syntheticFeed(ctx context.Context, p FeedParams) (market.CandleIterator, error) {
+	if err := checkFeedParams(p.Params, "seed", "start-price", "volatility", "trend", "ticks-per-bar"); err != nil {
+		return nil, err
+	}
+	if !p.Range.Valid() {
+		return nil, fmt.Errorf("invalid candle range: %s", p.Range)
+	}
+	cfg := DefaultSyntheticConfig(market.NormalizeInstrument(p.Instrument))
+	cfg.Timeframe = p.Range.TF
+
+	if v, ok, err := types.GetIntParam(p.Params, "seed"); err != nil {
+		return nil, err
+	} else if ok {
+		cfg.Seed = int64(v)
+	}
+	if v, ok, err := types.GetFloat64Param(p.Params, "start-price"); err != nil {
+		return nil, err
+	} else if ok {
+		if !(v > 0 && v <= maxDecimalPrice) {
+			return nil, fmt.Errorf("param start-price %v out of range", v)
+		}
+		cfg.StartPrice = types.PriceFromFloat(v)
+	}
+	if v, ok, err := types.GetFloat64Param(p.Params, "volatility"); err != nil {
+		return nil, err
+	} else if ok {
+		cfg.Volatility = v
+	}
+	if v, ok, err := types.GetFloat64Param(p.Params, "trend"); err != nil {
+		return nil, err
+	} else if ok {
+		cfg.Trend = v
+	}
+	if v, ok, err := types.GetInt32Param(p.Params, "ticks-per-bar"); err != nil {
+		return nil, err
+	} else if ok {
+		cfg.TicksPerBar = v
+	}
+
+	var iters []market.CandleIterator
+	for _, ym := range p.Range.MonthsInRange() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cs, err := cfg.GenerateSyntheticMonthlyCandles(ym.Year, time.Month(ym.Month))
+		if err != nil {
+			return nil, err
+		}
+		// Carry the walk across months rather than restarting it.
+		for i := len(cs.Candles) - 1; i >= 0; i-- {
+			if cs.IsValid(i) {
+				cfg.StartPrice = cs.Candles[i].Close
+				break
+			}
+		}
+		iters = append(iters, newCandleSetIterator(cs, p.Range))
+	}
+	return newChainedCandleIterator(iters...), nil
+}
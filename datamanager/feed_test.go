@@ -0,0 +1,274 @@
+package datamanager
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func feedRange(start, end time.Time) types.TimeRange {
+	return types.NewTimeRange(types.FromTime(start), types.FromTime(end), types.H1)
+}
+
+func drainFeed(t *testing.T, it market.CandleIterator) []market.Candle {
+	t.Helper()
+	defer it.Close()
+	var out []market.Candle
+	for {
+		c, ok := it.Next()
+		if !ok {
+			break
+		}
+		out = append(out, c)
+	}
+	require.NoError(t, it.Err())
+	return out
+}
+
+func writeFeedFile(t *testing.T, path, body string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+}
+
+// fakeCandleReader records the request it is asked for.
+type fakeCandleReader struct {
+	got     CandleRequest
+	candles []market.Candle
+}
+
+func (f *fakeCandleReader) Candles(_ context.Context, req CandleRequest) (market.CandleIterator, error) {
+	f.got = req
+	return newSliceCandleIterator(f.candles), nil
+}
+
+func TestNewFeed_CSVReadsPlainRowsInRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eurusd.csv")
+	writeFeedFile(t, path, `time,open,high,low,close,spread
+2025-01-06T00:00:00Z,1.03000,1.03100,1.02900,1.03050,0.00010
+2025-01-06T01:00:00Z,1.03050,1.03200,1.03000,1.03150,0.00010
+1736128800,1.03150,1.03300,1.03100,1.03250,0.00012
+`)
+	rng := feedRange(time.Date(2025, 1, 6, 1, 0, 0, 0, time.UTC), time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC))
+
+	it, err := NewFeed(context.Background(), "CSV", FeedParams{
+		Instrument: "EUR_USD",
+		Range:      rng,
+		Params:     map[string]any{"path": path, "instrument": "EURUSD"},
+	})
+	require.NoError(t, err)
+	got := drainFeed(t, it)
+	require.Len(t, got, 2)
+	assert.Equal(t, types.FromTime(time.Date(2025, 1, 6, 1, 0, 0, 0, time.UTC)), got[0].Timestamp)
+	assert.Equal(t, types.Price(103150), got[0].Close)
+	assert.Equal(t, types.Price(103250), got[1].Close)
+	assert.Equal(t, types.Price(12), got[1].AvgSpread)
+}
+
+func TestNewFeed_CSVRejectsOtherInstrumentsAndDisorder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "eurusd.csv")
+	writeFeedFile(t, path, "1736125200,1.03,1.04,1.02,1.03\n1736121600,1.03,1.04,1.02,1.03\n")
+	rng := feedRange(time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC))
+
+	_, err := NewFeed(context.Background(), "csv", FeedParams{
+		Instrument: "GBPUSD",
+		Range:      rng,
+		Params:     map[string]any{"path": path, "instrument": "EURUSD"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not GBPUSD")
+
+	_, err = NewFeed(context.Background(), "csv", FeedParams{
+		Instrument: "EURUSD",
+		Range:      rng,
+		Params:     map[string]any{"path": path},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not after the previous row")
+}
+
+func TestNewFeed_CSVDirMergesFilesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeFeedFile(t, filepath.Join(dir, "2025", "02.csv"), "2025-02-03T00:00:00Z,1.04,1.05,1.03,1.045\n")
+	writeFeedFile(t, filepath.Join(dir, "2025", "01.csv"), "2025-01-06T00:00:00Z,1.03,1.04,1.02,1.035\n")
+	writeFeedFile(t, filepath.Join(dir, "notes.txt"), "not candles\n")
+	rng := feedRange(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	it, err := NewFeed(context.Background(), "csv-dir", FeedParams{
+		Instrument: "EURUSD",
+		Range:      rng,
+		Params:     map[string]any{"dir": dir},
+	})
+	require.NoError(t, err)
+	got := drainFeed(t, it)
+	require.Len(t, got, 2)
+	assert.Equal(t, types.Price(103500), got[0].Close)
+	assert.Equal(t, types.Price(104500), got[1].Close)
+
+	// The same bar in two files is ambiguous.
+	writeFeedFile(t, filepath.Join(dir, "dup.csv"), "2025-01-06T00:00:00Z,1.03,1.04,1.02,1.035\n")
+	_, err = NewFeed(context.Background(), "csv-dir", FeedParams{
+		Instrument: "EURUSD",
+		Range:      rng,
+		Params:     map[string]any{"dir": dir},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "two bars")
+}
+
+func TestNewFeed_SQLiteSelectsInstrumentTimeframeAndRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "candles.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE bars (instrument TEXT, timeframe TEXT, ts INTEGER,
+		open INTEGER, high INTEGER, low INTEGER, close INTEGER, avg_spread INTEGER)`)
+	require.NoError(t, err)
+	t0 := types.FromTime(time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC))
+	rows := []struct {
+		inst, tf string
+		ts       types.Timestamp
+		close    int
+	}{
+		{"EURUSD", "H1", t0 + 3600, 103200},
+		{"EURUSD", "h1", t0, 103100},
+		{"EURUSD", "m1", t0, 999},
+		{"GBPUSD", "h1", t0, 125000},
+		{"EURUSD", "h1", t0 + 86400, 104000}, // past the range
+	}
+	for _, r := range rows {
+		_, err = db.Exec(`INSERT INTO bars VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.inst, r.tf, int64(r.ts), r.close, r.close+50, r.close-50, r.close, 8)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	it, err := NewFeed(context.Background(), "sqlite", FeedParams{
+		Instrument: "EUR_USD",
+		Range:      types.NewTimeRange(t0, t0+86400, types.H1),
+		Params:     map[string]any{"path": path, "table": "bars"},
+	})
+	require.NoError(t, err)
+	got := drainFeed(t, it)
+	require.Len(t, got, 2)
+	assert.Equal(t, types.Price(103100), got[0].Close)
+	assert.Equal(t, types.Price(103200), got[1].Close)
+	assert.Equal(t, types.Price(8), got[1].AvgSpread)
+
+	_, err = NewFeed(context.Background(), "sqlite", FeedParams{
+		Instrument: "EURUSD",
+		Range:      types.NewTimeRange(t0, t0+86400, types.H1),
+		Params:     map[string]any{"path": path, "table": "bars; DROP TABLE bars"},
+	})
+	require.Error(t, err)
+}
+
+func TestNewFeed_SyntheticIsDeterministicPerSeed(t *testing.T) {
+	rng := feedRange(time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC))
+	open := func(seed int) []market.Candle {
+		it, err := NewFeed(context.Background(), "synthetic", FeedParams{
+			Instrument: "EURUSD",
+			Range:      rng,
+			Params:     map[string]any{"seed": seed, "start-price": 1.1},
+		})
+		require.NoError(t, err)
+		return drainFeed(t, it)
+	}
+
+	a, b, c := open(7), open(7), open(8)
+	require.NotEmpty(t, a)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	for i := 1; i < len(a); i++ {
+		assert.Less(t, a[i-1].Timestamp, a[i].Timestamp)
+	}
+	assert.True(t, rng.Contains(a[0].Timestamp))
+	assert.True(t, rng.Contains(a[len(a)-1].Timestamp))
+}
+
+func TestNewFeed_UnknownParamsFail(t *testing.T) {
+	rng := feedRange(time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC))
+	_, err := NewFeed(context.Background(), "synthetic", FeedParams{
+		Instrument: "EURUSD",
+		Range:      rng,
+		Params:     map[string]any{"sead": 1},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown param "sead"`)
+
+	_, err = NewFeed(context.Background(), "oanda", FeedParams{
+		Instrument: "EURUSD",
+		Range:      rng,
+		Params:     map[string]any{"path": "x"},
+		Store:      &fakeCandleReader{},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "takes none")
+}
+
+func TestNewFeed_StoreSourcesReadTheStore(t *testing.T) {
+	rng := feedRange(time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC))
+	for _, tc := range []struct{ kind, source string }{
+		{"", market.SourceOanda},
+		{"oanda-candles", market.SourceOanda},
+		{"dukascopy", market.SourceDukascopy},
+		{"my-import", "my-import"}, // unregistered: a store source of its own
+	} {
+		store := &fakeCandleReader{candles: []market.Candle{{Timestamp: rng.Start}}}
+		it, err := NewFeed(context.Background(), tc.kind, FeedParams{
+			Instrument: "EURUSD",
+			Range:      rng,
+			Strict:     true,
+			Store:      store,
+		})
+		require.NoError(t, err, tc.kind)
+		assert.Len(t, drainFeed(t, it), 1)
+		assert.Equal(t, tc.source, store.got.Source, tc.kind)
+		assert.Equal(t, "EURUSD", store.got.Instrument)
+		assert.True(t, store.got.Strict)
+	}
+
+	_, err := NewFeed(context.Background(), "oanda", FeedParams{Instrument: "EURUSD", Range: rng})
+	require.Error(t, err)
+}
+
+func TestRegisterFeed_CustomFeed(t *testing.T) {
+	kind := "Stub-" + t.Name()
+	var got FeedParams
+	require.NoError(t, RegisterFeed(func(_ context.Context, p FeedParams) (market.CandleIterator, error) {
+		got = p
+		return newSliceCandleIterator(nil), nil
+	}, kind))
+	t.Cleanup(func() {
+		feedMu.Lock()
+		delete(feedRegistry, normalizeSource(kind))
+		feedMu.Unlock()
+	})
+
+	assert.Contains(t, RegisteredFeeds(), normalizeSource(kind))
+	assert.NotNil(t, LookupFeed(kind))
+	_, err := NewFeed(context.Background(), kind, FeedParams{Instrument: "EURUSD", Params: map[string]any{"k": 1}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Params["k"])
+
+	assert.Error(t, RegisterFeed(nil, "x"))
+	assert.Error(t, RegisterFeed(nopFeed, " "))
+	assert.Error(t, RegisterFeed(nopFeed))
+	assert.Subset(t, RegisteredFeeds(), []string{"csv", "csv-dir", "sqlite", "synthetic", "oanda", "oanda-candles"})
+
+	// A misspelt kind is read from the store, and the error names the real ones.
+	_, err = NewFeed(context.Background(), "cvs", FeedParams{Instrument: "EURUSD", Params: map[string]any{"path": "x.csv"}})
+	require.ErrorContains(t, err, `unknown param "path"`)
+	assert.ErrorContains(t, err, "not a registered kind: ")
+	assert.ErrorContains(t, err, "csv, csv-dir")
+}
+
+func nopFeed(context.Context, FeedParams) (market.CandleIterator, error) { return nil, nil }
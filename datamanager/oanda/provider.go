@@ -15,6 +15,7 @@ import (
 	oandaclient "github.com/rustyeddy/trader/brokers/oanda"
 	"github.com/rustyeddy/trader/datamanager"
 	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -35,7 +36,8 @@ func New(client *oandaclient.Client) *Provider {
 func (p *Provider) Name() string { return SourceName }
 
 // FetchCandleMonth fetches one calendar month of candles for instrument
-// (OANDA wire format, e.g. "EUR_USD") at the given timeframe, converting to
+// (any spelling; it is sent as the OANDA symbol, e.g. "EUR_USD") at the
+// given timeframe, converting to
 // the trader canonical (bid OHLC + computed spread) representation while
 // also preserving the raw bid+ask rows for optional archival.
 func (p *Provider) FetchCandleMonth(ctx context.Context, instrument string, tf types.Timeframe, monthStart time.Time) (*datamanager.CandleMonth, error) {
@@ -47,7 +49,7 @@ func (p *Provider) FetchCandleMonth(ctx context.Context, instrument string, tf t
 	monthEnd := monthStart.AddDate(0, 1, 0)
 
 	raw, err := p.client.FetchCandles(ctx, oandaclient.FetchCandlesOptions{
-		Instrument:  instrument,
+		Instrument:  symbols.ToProvider(symbols.OANDA, instrument),
 		Granularity: toOandaGranularity(tf),
 		From:        monthStart,
 		To:          monthEnd,
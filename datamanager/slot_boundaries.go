@@ -119,6 +119,29 @@ func SlotIndexForTime(start time.Time, tf types.Timeframe, t time.Time) int {
 	}
 }
 
+// slotOpen returns the open time of the tf slot containing ts, on the
+// same grid SlotBoundaries walks: a fixed epoch stride, or for D1/H4 the
+// DST-aware daily-alignment boundaries.
+func slotOpen(tf types.Timeframe, ts types.Timestamp) types.Timestamp {
+	if !dailyAligned(tf) {
+		step := types.Timestamp(tf)
+		return ts - ts%step
+	}
+	t := ts.Time()
+	day := types.DailyAlignmentBoundary(t)
+	if tf == types.D1 {
+		return types.FromTime(day)
+	}
+	open := day
+	for _, s := range h4SlotsInDay(day, nextDailyBoundary(day)) {
+		if s.After(t) {
+			break
+		}
+		open = s
+	}
+	return types.FromTime(open)
+}
+
 // firstDailyBoundaryAtOrAfter returns the earliest daily-alignment
 // boundary that is not before start.
 func firstDailyBoundaryAtOrAfter(start time.Time) time.Time {
@@ -154,20 +177,24 @@ func nextDailyBoundary(b time.Time) time.Time {
 // On fall-back days the 1:00 local hour occurs twice an hour apart in UTC;
 // only the first occurrence opens a candle, so a candidate under 2h after
 // the previous kept slot is skipped (legitimate spacing is never below 3h).
+//
+// Under a configured rollover (types.SetRollover) the local hours are the
+// rollover hour and every fourth hour from it, in the rollover's zone.
 func h4SlotsInDay(dayOpen, nextOpen time.Time) []time.Time {
+	r := types.CurrentRollover()
 	loc := types.DailyAlignmentLocation()
 	out := make([]time.Time, 0, 7)
 	var prev time.Time
-	// dayOpen is a 17:00-local boundary, so hourly steps stay on the hour.
+	// dayOpen is an on-the-hour boundary, so hourly steps stay on the hour.
 	for t := dayOpen; t.Before(nextOpen); t = t.Add(time.Hour) {
-		switch t.In(loc).Hour() {
-		case 1, 5, 9, 13, 17, 21:
-			if !prev.IsZero() && t.Sub(prev) < 2*time.Hour {
-				continue
-			}
-			out = append(out, t)
-			prev = t
+		if (t.In(loc).Hour()-r.Hour+24)%4 != 0 {
+			continue
+		}
+		if !prev.IsZero() && t.Sub(prev) < 2*time.Hour {
+			continue
 		}
+		out = append(out, t)
+		prev = t
 	}
 	return out
 }
@@ -522,11 +522,17 @@ func (s *store) ReadCSV(key Key) (*CandleSet, error) {
 
 	if !isCurrentMonth(key) {
 		s.cacheMu.Lock()
+		defer s.cacheMu.Unlock()
+		if cached, ok := s.cache[key]; ok {
+			// A concurrent read cached the month first; ours was never
+			// handed out.
+			_ = cs.Close()
+			return cached, nil
+		}
 		if s.cache == nil {
 			s.cache = make(map[Key]*CandleSet)
 		}
 		s.cache[key] = cs
-		s.cacheMu.Unlock()
 	}
 	return cs, nil
 }
@@ -541,11 +547,19 @@ func isCurrentMonth(key Key) bool {
 
 // invalidateCache drops any cached entry for key, called after WriteCSV so a
 // subsequent ReadCSV in the same process sees freshly written data instead
-// of a stale cache hit from before the write.
+// of a stale cache hit from before the write. A dropped set mapped from a
+// binary file is closed, so sets from ReadCSV must not be used across a
+// rewrite of their month.
 func (s *store) invalidateCache(key Key) {
 	s.cacheMu.Lock()
+	cs, ok := s.cache[key]
 	delete(s.cache, key)
 	s.cacheMu.Unlock()
+	if ok {
+		if err := cs.Close(); err != nil {
+			log.Data.Warn("unmapping candle set", "path", cs.Filepath, "error", err)
+		}
+	}
 }
 
 func (s *store) readCSVUncached(key Key) (cs *CandleSet, err error) {
@@ -563,6 +577,9 @@ func (s *store) readCSVUncached(key Key) (cs *CandleSet, err error) {
 	if err != nil {
 		return nil, err
 	}
+	if cs, ok := s.readBinary(key, path); ok {
+		return cs, nil
+	}
 
 	f, err := os.Open(path)
 	if err != nil {
@@ -746,11 +763,17 @@ func (s *store) WriteCSV(cs *CandleSet) error {
 		return err
 	}
 
-	f, err := os.Create(path)
+	// Write to a temp file beside the target and rename it into place, so a
+	// reader (or a crash mid-write) never sees a half-written month.
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	tmp := f.Name()
+	defer func() {
+		f.Close()
+		os.Remove(tmp)
+	}()
 
 	bw := bufio.NewWriterSize(f, 256*1024)
 	if err := s.writeMetadata(cs, bw); err != nil {
@@ -793,6 +816,19 @@ func (s *store) WriteCSV(cs *CandleSet) error {
 	if err := bw.Flush(); err != nil {
 		return err
 	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	// A packed month no longer matches the CSV it was built from.
+	if err := os.Remove(binaryCandlePath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 
 	s.invalidateCache(key)
 	return nil
@@ -8,12 +8,15 @@ package engine
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync/atomic"
 	"time"
 
 	"github.com/rustyeddy/trader/account"
 	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/oanda"
 	"github.com/rustyeddy/trader/log"
+	"github.com/rustyeddy/trader/types"
 )
 
 // Trader couples a candle source with the account it drives. It owns the
@@ -29,6 +32,17 @@ type Trader struct {
 	// ownership points Broker -> Account, not the reverse, so Trader (the
 	// session composition root) is where this belongs.
 	Broker brokers.Broker
+
+	// Log receives the event loop's records; nil logs to log.L. The
+	// backtest runner sets it to the run's logger.
+	Log *slog.Logger
+}
+
+func (t *Trader) logger() *slog.Logger {
+	if t.Log != nil {
+		return t.Log
+	}
+	return log.L
 }
 
 // StartBrokerEventHandler launches the goroutine that drains the broker event
@@ -46,11 +60,11 @@ func (t *Trader) StartBrokerEventHandler(ctx context.Context, evtQ <-chan *accou
 				return
 			case evt, ok := <-evtQ:
 				if !ok {
-					log.L.Info("broker event channel closed")
+					t.logger().Info("broker event channel closed")
 					return
 				}
 
-				log.L.Debug("Broker event received",
+				t.logger().Debug("Broker event received",
 					"type", evt.Type.String(),
 					"positionID", eventPositionID(evt),
 				)
@@ -83,6 +97,72 @@ func (t *Trader) BrokerEventError(errCh <-chan error) error {
 	}
 }
 
+// Orders returns the account's orders from the broker — pending ones and
+// the record of those already filled, cancelled or expired — in
+// submission order. It errors when the Broker does not implement
+// brokers.OrderBook.
+func (t *Trader) Orders(ctx context.Context) ([]brokers.Order, error) {
+	ob, ok := t.Broker.(brokers.OrderBook)
+	if !ok {
+		return nil, fmt.Errorf("broker %T does not list orders", t.Broker)
+	}
+	return ob.Orders(ctx, t.accountID())
+}
+
+// PendingOrders returns the account's orders still waiting for a fill.
+func (t *Trader) PendingOrders(ctx context.Context) ([]brokers.Order, error) {
+	orders, err := t.Orders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending := orders[:0]
+	for _, o := range orders {
+		if !o.State.Terminal() {
+			pending = append(pending, o)
+		}
+	}
+	return pending, nil
+}
+
+// SubmitLimitOrder places a limit order for the account through the
+// Broker (see brokers.LimitOrderer). It errors when the Broker does not
+// take limit orders.
+func (t *Trader) SubmitLimitOrder(ctx context.Context, instrument string, units int64, price, stop types.Price, tif brokers.TimeInForce, expiry types.Timestamp) (*oanda.OrderResult, error) {
+	lo, ok := t.Broker.(brokers.LimitOrderer)
+	if !ok {
+		return nil, fmt.Errorf("broker %T does not take limit orders", t.Broker)
+	}
+	return lo.SubmitLimitOrder(ctx, t.accountID(), instrument, units, price, stop, tif, expiry)
+}
+
+// CancelOrder cancels one of the account's resting orders.
+func (t *Trader) CancelOrder(ctx context.Context, orderID string) error {
+	lo, ok := t.Broker.(brokers.LimitOrderer)
+	if !ok {
+		return fmt.Errorf("broker %T does not take limit orders", t.Broker)
+	}
+	return lo.CancelOrder(ctx, t.accountID(), orderID)
+}
+
+// CloseAllForInstrument closes every open trade the account holds on
+// instrument through the Broker. See brokers.CloseAllForInstrument.
+func (t *Trader) CloseAllForInstrument(ctx context.Context, instrument, reason string) ([]*oanda.CloseTradeResult, error) {
+	return brokers.CloseAllForInstrument(ctx, t.Broker, t.accountID(), instrument, reason)
+}
+
+// FlattenDirection closes the account's open trades on instrument on one
+// side only, leaving the other side open. See brokers.FlattenDirection.
+func (t *Trader) FlattenDirection(ctx context.Context, instrument string, side types.Side, reason string) ([]*oanda.CloseTradeResult, error) {
+	return brokers.FlattenDirection(ctx, t.Broker, t.accountID(), instrument, side, reason)
+}
+
+func (t *Trader) accountID() string {
+	if t.Account == nil {
+		return ""
+	}
+	return t.Account.ID
+}
+
 // SnapshotLots returns a deep copy of the open/pending lots in src so callers
 // can hand a stable view to a strategy without racing the broker.
 func SnapshotLots(src *account.LotBook) *account.LotBook {
@@ -138,7 +218,7 @@ func (t *Trader) processEvent(ctx context.Context, evt *account.Event) error {
 		return fmt.Errorf("nil broker event")
 	}
 
-	log.L.Info("broker event recieved",
+	t.logger().Info("broker event recieved",
 		"type", evt.Type.String(),
 		"positionID", eventPositionID(evt))
 
@@ -160,7 +240,7 @@ func (t *Trader) processEvent(ctx context.Context, evt *account.Event) error {
 		}
 
 	default:
-		log.L.Warn("unsupported broker event", "eventType", evt.Type)
+		t.logger().Warn("unsupported broker event", "eventType", evt.Type)
 	}
 
 	return nil
@@ -8,7 +8,10 @@ import (
 	"time"
 
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/sim"
 	"github.com/rustyeddy/trader/idgen"
+	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -119,3 +122,69 @@ func TestSnapshotLots_FiltersByState(t *testing.T) {
 	assert.Contains(t, lots, "close-req")
 	assert.NotContains(t, lots, "closed")
 }
+
+func TestTraderOrders(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	acct := account.NewAccount("acct", types.MoneyFromFloat(10_000))
+	s := sim.NewSimBroker(acct, nil)
+	require.NoError(t, s.UpdatePrice(market.Tick{Instrument: "EURUSD", Timestamp: 100, BA: market.BA{Bid: 109_999, Ask: 110_001}}))
+	_, err := s.SubmitMarketOrder(ctx, acct.ID, "EURUSD", 1000, 0)
+	require.NoError(t, err)
+	tr := &Trader{Account: acct, Broker: s}
+	limit, err := tr.SubmitLimitOrder(ctx, "EURUSD", 1000, 109_000, 0, brokers.GTC, 0)
+	require.NoError(t, err)
+	cancelled, err := tr.SubmitLimitOrder(ctx, "EURUSD", 1000, 108_000, 0, brokers.GTC, 0)
+	require.NoError(t, err)
+	require.NoError(t, tr.CancelOrder(ctx, cancelled.OrderID))
+
+	orders, err := tr.Orders(ctx)
+	require.NoError(t, err)
+	require.Len(t, orders, 3)
+	assert.Equal(t, brokers.OrderFilled, orders[0].State)
+	assert.Equal(t, brokers.OrderCancelled, orders[2].State)
+
+	pending, err := tr.PendingOrders(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, limit.OrderID, pending[0].ID)
+
+	_, err = (&Trader{Account: acct}).Orders(ctx)
+	assert.ErrorContains(t, err, "does not list orders")
+	_, err = (&Trader{Account: acct}).SubmitLimitOrder(ctx, "EURUSD", 1000, 109_000, 0, brokers.GTC, 0)
+	assert.ErrorContains(t, err, "does not take limit orders")
+}
+
+func TestTraderFlattenHelpers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	acct := account.NewAccount("acct", types.MoneyFromFloat(1_000_000))
+	s := sim.NewSimBroker(acct, nil)
+	for _, tick := range []market.Tick{
+		{Instrument: "EURUSD", Timestamp: 100, BA: market.BA{Bid: 109_999, Ask: 110_001}},
+		{Instrument: "GBPUSD", Timestamp: 100, BA: market.BA{Bid: 126_999, Ask: 127_001}},
+	} {
+		require.NoError(t, s.UpdatePrice(tick))
+	}
+	for _, o := range []struct {
+		inst  string
+		units int64
+	}{{"EURUSD", 1000}, {"EURUSD", -1000}, {"EURUSD", 2000}, {"GBPUSD", 1000}} {
+		_, err := s.SubmitMarketOrder(ctx, acct.ID, o.inst, o.units, 0)
+		require.NoError(t, err)
+	}
+	tr := &Trader{Account: acct, Broker: s}
+
+	res, err := tr.FlattenDirection(ctx, "EURUSD", types.Long, "flatten longs")
+	require.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, 2, acct.Lots.Len())
+
+	res, err = tr.CloseAllForInstrument(ctx, "EURUSD", "close eurusd")
+	require.NoError(t, err)
+	assert.Len(t, res, 1)
+	require.Equal(t, 1, acct.Lots.Len())
+	assert.Equal(t, "GBPUSD", acct.Lots.Slice()[0].Instrument)
+}
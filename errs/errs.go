@@ -0,0 +1,74 @@
+// Package errs defines the error kinds shared across the trader packages.
+// An error of a kind wraps its sentinel, so callers branch with errors.Is
+// rather than matching messages:
+//
+//	if errors.Is(err, errs.ErrUnknownInstrument) {
+//		// skip the instrument
+//	}
+//
+// Where the kind's text reads naturally in the message, wrap the sentinel
+// with %w ("unknown instrument: EURXYZ"); otherwise Mark or Newf tag an
+// error with a kind without changing its message.
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoPrice: no usable price or quote for an instrument.
+	ErrNoPrice = errors.New("no price")
+
+	// ErrUnknownInstrument: the instrument has no metadata.
+	ErrUnknownInstrument = errors.New("unknown instrument")
+
+	// ErrInsufficientMargin: the account cannot margin the order.
+	ErrInsufficientMargin = errors.New("insufficient margin")
+
+	// ErrBadConfig: a config file or config value is invalid.
+	ErrBadConfig = errors.New("bad config")
+
+	// ErrDataGap: market data the caller needs is missing.
+	ErrDataGap = errors.New("data gap")
+
+	// ErrNotFound: the account, order or trade named does not exist.
+	ErrNotFound = errors.New("not found")
+)
+
+// kinds lists the shared kinds in the order KindOf checks them.
+var kinds = []error{ErrNoPrice, ErrUnknownInstrument, ErrInsufficientMargin, ErrBadConfig, ErrDataGap, ErrNotFound}
+
+// marked is an error tagged with a kind; its message is the error's own.
+type marked struct {
+	err  error
+	kind error
+}
+
+func (m *marked) Error() string   { return m.err.Error() }
+func (m *marked) Unwrap() []error { return []error{m.err, m.kind} }
+
+// Mark returns err tagged with kind, so errors.Is(result, kind) holds while
+// the message and the rest of err's chain are unchanged. A nil err, or one
+// already of kind, is returned as is.
+func Mark(err, kind error) error {
+	if err == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &marked{err: err, kind: kind}
+}
+
+// Newf formats an error like fmt.Errorf and tags it with kind.
+func Newf(kind error, format string, args ...any) error {
+	return Mark(fmt.Errorf(format, args...), kind)
+}
+
+// KindOf returns the shared kind err is of, or nil when it is none of them.
+func KindOf(err error) error {
+	for _, k := range kinds {
+		if errors.Is(err, k) {
+			return k
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMark_KeepsMessageAndChain(t *testing.T) {
+	t.Parallel()
+	base := fmt.Errorf("load candles EURUSD 2024-01: %w", fs.ErrNotExist)
+	err := Mark(base, ErrDataGap)
+
+	assert.Equal(t, base.Error(), err.Error())
+	assert.ErrorIs(t, err, ErrDataGap)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Same(t, err, Mark(err, ErrDataGap), "already of the kind")
+	assert.NoError(t, Mark(nil, ErrDataGap))
+
+	wrapped := fmt.Errorf("run %q: %w", "ema", err)
+	assert.ErrorIs(t, wrapped, ErrDataGap)
+}
+
+func TestNewf(t *testing.T) {
+	t.Parallel()
+	err := Newf(ErrUnknownInstrument, "margin rate for unknown instrument %q", "EURXYZ")
+	assert.EqualError(t, err, `margin rate for unknown instrument "EURXYZ"`)
+	assert.ErrorIs(t, err, ErrUnknownInstrument)
+}
+
+func TestKindOf(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, ErrUnknownInstrument, KindOf(fmt.Errorf("%w: EURXYZ", ErrUnknownInstrument)))
+	assert.Equal(t, ErrBadConfig, KindOf(Mark(errors.New("runs: none"), ErrBadConfig)))
+	assert.Nil(t, KindOf(errors.New("something else")))
+	assert.Nil(t, KindOf(nil))
+}
@@ -0,0 +1,43 @@
+package idgen
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequence_PrefixAndOrder(t *testing.T) {
+	s := NewSequence(" run42 ")
+	assert.Equal(t, "run42-000001", s.New())
+	assert.Equal(t, "run42-000002", s.New())
+}
+
+func TestSequence_BlankPrefixFallsBackToULID(t *testing.T) {
+	id := NewSequence("").New()
+	require.Len(t, id, 26+len("-000001"))
+	assert.Equal(t, "-000001", id[26:])
+}
+
+func TestSequence_ConcurrentUniqueness(t *testing.T) {
+	s := NewSequence("run")
+	const n = 500
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool, n)
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := s.New()
+			mu.Lock()
+			seen[id] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, n)
+}
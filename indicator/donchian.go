@@ -0,0 +1,71 @@
+package indicator
+
+import (
+	"fmt"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Donchian computes a Donchian channel: the highest high and lowest low of
+// the last n candles. Middle is their midpoint.
+//
+// The channel includes the most recent Update, so a breakout strategy should
+// read it before feeding the bar it tests against the channel.
+type Donchian struct {
+	n int
+
+	highs []types.Price
+	lows  []types.Price
+	pos   int
+	count int
+
+	upper types.Price
+	lower types.Price
+}
+
+func NewDonchian(period int) (*Donchian, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("Donchian period must be > 0")
+	}
+	return &Donchian{
+		n:     period,
+		highs: make([]types.Price, period),
+		lows:  make([]types.Price, period),
+	}, nil
+}
+
+func (d *Donchian) Name() string { return fmt.Sprintf("Donchian(%d)", d.n) }
+func (d *Donchian) Period() int  { return d.n }
+func (d *Donchian) Warmup() int  { return d.n }
+func (d *Donchian) Ready() bool  { return d.count >= d.n }
+
+func (d *Donchian) Upper() types.Price  { return d.upper }
+func (d *Donchian) Lower() types.Price  { return d.lower }
+func (d *Donchian) Middle() types.Price { return d.lower + (d.upper-d.lower)/2 }
+
+func (d *Donchian) Update(c market.Candle) {
+	d.highs[d.pos] = c.High
+	d.lows[d.pos] = c.Low
+	d.pos = (d.pos + 1) % d.n
+	if d.count < d.n {
+		d.count++
+	}
+
+	d.upper, d.lower = d.highs[0], d.lows[0]
+	for i := 1; i < d.count; i++ {
+		d.upper = max(d.upper, d.highs[i])
+		d.lower = min(d.lower, d.lows[i])
+	}
+}
+
+func (d *Donchian) Reset() {
+	clear(d.highs)
+	clear(d.lows)
+	d.pos = 0
+	d.count = 0
+	d.upper = 0
+	d.lower = 0
+}
+
+var _ CandleIndicator = (*Donchian)(nil)
@@ -0,0 +1,63 @@
+package indicator
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hlCandle(high, low types.Price) market.Candle {
+	return market.Candle{Open: low, High: high, Low: low, Close: high}
+}
+
+func TestNewDonchian_BadPeriod(t *testing.T) {
+	t.Parallel()
+	_, err := NewDonchian(0)
+	assert.Error(t, err)
+}
+
+func TestDonchian_Channel(t *testing.T) {
+	t.Parallel()
+	d, err := NewDonchian(3)
+	require.NoError(t, err)
+	assert.Equal(t, "Donchian(3)", d.Name())
+	assert.Equal(t, 3, d.Warmup())
+
+	d.Update(hlCandle(110_000, 100_000))
+	d.Update(hlCandle(115_000, 105_000))
+	assert.False(t, d.Ready())
+	d.Update(hlCandle(112_000, 102_000))
+	require.True(t, d.Ready())
+	assert.Equal(t, types.Price(115_000), d.Upper())
+	assert.Equal(t, types.Price(100_000), d.Lower())
+	assert.Equal(t, types.Price(107_500), d.Middle())
+
+	// The first bar rolls out of the window.
+	d.Update(hlCandle(108_000, 104_000))
+	assert.Equal(t, types.Price(115_000), d.Upper())
+	assert.Equal(t, types.Price(102_000), d.Lower())
+
+	// And so does the second.
+	d.Update(hlCandle(109_000, 103_000))
+	assert.Equal(t, types.Price(112_000), d.Upper())
+	assert.Equal(t, types.Price(102_000), d.Lower())
+}
+
+func TestDonchian_Reset(t *testing.T) {
+	t.Parallel()
+	d, err := NewDonchian(2)
+	require.NoError(t, err)
+	d.Update(hlCandle(110_000, 100_000))
+	d.Update(hlCandle(110_000, 100_000))
+	require.True(t, d.Ready())
+
+	d.Reset()
+	assert.False(t, d.Ready())
+	d.Update(hlCandle(120_000, 118_000))
+	d.Update(hlCandle(121_000, 119_000))
+	assert.Equal(t, types.Price(121_000), d.Upper())
+	assert.Equal(t, types.Price(118_000), d.Lower())
+}
@@ -0,0 +1,236 @@
+package indicator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Level is a horizontal support/resistance zone: swing highs and lows whose
+// prices cluster within a fraction of the ATR of each other.
+type Level struct {
+	Price   types.Price     // mean price of the zone's pivots
+	Low     types.Price     // lowest pivot in the zone
+	High    types.Price     // highest pivot in the zone
+	Touches int             // swing highs and lows in the zone
+	Last    types.Timestamp // time of the zone's latest pivot
+}
+
+// Contains reports whether p lies within the zone.
+func (l Level) Contains(p types.Price) bool { return p >= l.Low && p <= l.High }
+
+// LevelsConfig parameterizes Levels.
+type LevelsConfig struct {
+	// Strength is the bars on each side a swing high (low) must top
+	// (undercut) to count as a pivot. A pivot is confirmed Strength bars
+	// after it forms.
+	Strength int
+	// Lookback is how many bars pivots are kept for.
+	Lookback int
+	// ATRPeriod and ZoneATR set the clustering width: pivots within
+	// ZoneATR × ATR(ATRPeriod) of a zone's mean join it.
+	ATRPeriod int
+	ZoneATR   float64
+	// MinTouches is the fewest pivots a zone needs to be a level.
+	MinTouches int
+}
+
+// DefaultLevelsConfig returns the config reports draw levels with: 3-bar
+// swings over the last 200 bars, clustered within half an ATR(14), and
+// touched at least twice.
+func DefaultLevelsConfig() LevelsConfig {
+	return LevelsConfig{Strength: 3, Lookback: 200, ATRPeriod: 14, ZoneATR: 0.5, MinTouches: 2}
+}
+
+// Levels detects support and resistance from the candles it is fed: it
+// finds swing highs and lows over the last Lookback bars and clusters them
+// into zones. Levels() lists the zones; Support and Resistance find the
+// nearest one below or above a price.
+type Levels struct {
+	cfg  LevelsConfig
+	atr  *ATR
+	zone int64 // ZoneATR ×1000; e.g. 0.5 → 500
+
+	window []market.Candle // the last 2*Strength+1 bars, oldest first
+	pivots []levelPivot
+	sorted []levelPivot // pivots in price order; nil when pivots changed since
+	bars   int
+}
+
+type levelPivot struct {
+	price types.Price
+	time  types.Timestamp
+	bar   int // index of the bar the pivot formed on
+}
+
+func NewLevels(cfg LevelsConfig, scale types.Scale6) (*Levels, error) {
+	switch {
+	case cfg.Strength <= 0:
+		return nil, fmt.Errorf("levels strength must be > 0")
+	case cfg.Lookback <= 2*cfg.Strength:
+		return nil, fmt.Errorf("levels lookback must be > 2×strength (%d), got %d", 2*cfg.Strength, cfg.Lookback)
+	case math.Round(cfg.ZoneATR*1000) <= 0:
+		return nil, fmt.Errorf("levels zone_atr must be >= 0.001")
+	case cfg.MinTouches <= 0:
+		return nil, fmt.Errorf("levels min_touches must be > 0")
+	}
+	atr, err := NewATR(cfg.ATRPeriod, scale)
+	if err != nil {
+		return nil, fmt.Errorf("levels: %w", err)
+	}
+	return &Levels{
+		cfg:    cfg,
+		atr:    atr,
+		zone:   int64(math.Round(cfg.ZoneATR * 1000)),
+		window: make([]market.Candle, 0, 2*cfg.Strength+1),
+	}, nil
+}
+
+func (l *Levels) Name() string {
+	return fmt.Sprintf("Levels(%d,%d,%.2f×ATR(%d),%d)", l.cfg.Strength, l.cfg.Lookback, l.cfg.ZoneATR, l.cfg.ATRPeriod, l.cfg.MinTouches)
+}
+func (l *Levels) Period() int { return l.cfg.Lookback }
+func (l *Levels) Warmup() int { return max(l.atr.Warmup(), 2*l.cfg.Strength+1) }
+func (l *Levels) Ready() bool { return l.atr.Ready() && l.bars >= 2*l.cfg.Strength+1 }
+
+// ATR returns the ATR zones are sized by, 0 until it is warm.
+func (l *Levels) ATR() types.Price { return l.atr.Price() }
+
+func (l *Levels) Reset() {
+	l.atr.Reset()
+	l.window = l.window[:0]
+	l.pivots = nil
+	l.sorted = nil
+	l.bars = 0
+}
+
+func (l *Levels) Update(c market.Candle) {
+	l.atr.Update(c)
+	l.bars++
+	if len(l.window) == cap(l.window) {
+		copy(l.window, l.window[1:])
+		l.window = l.window[:len(l.window)-1]
+	}
+	l.window = append(l.window, c)
+
+	if len(l.window) == cap(l.window) {
+		mid := l.cfg.Strength
+		bar := l.bars - 1 - mid
+		if l.isSwing(mid, func(a, b market.Candle) bool { return a.High > b.High }) {
+			l.pivots = append(l.pivots, levelPivot{price: l.window[mid].High, time: l.window[mid].Timestamp, bar: bar})
+			l.sorted = nil
+		}
+		if l.isSwing(mid, func(a, b market.Candle) bool { return a.Low < b.Low }) {
+			l.pivots = append(l.pivots, levelPivot{price: l.window[mid].Low, time: l.window[mid].Timestamp, bar: bar})
+			l.sorted = nil
+		}
+	}
+
+	oldest := l.bars - l.cfg.Lookback
+	n := 0
+	for n < len(l.pivots) && l.pivots[n].bar < oldest {
+		n++
+	}
+	if n > 0 {
+		l.pivots = l.pivots[n:]
+		l.sorted = nil
+	}
+}
+
+// isSwing reports whether the bar at mid beats every bar after it and is
+// not beaten by any bar before it, so a flat top of equal highs forms one
+// pivot, on its last bar.
+func (l *Levels) isSwing(mid int, beats func(a, b market.Candle) bool) bool {
+	for i, c := range l.window {
+		switch {
+		case i < mid && beats(c, l.window[mid]):
+			return false
+		case i > mid && !beats(l.window[mid], c):
+			return false
+		}
+	}
+	return true
+}
+
+// Levels returns the current zones touched at least MinTouches times, in
+// price order, or nil until the ATR is warm.
+func (l *Levels) Levels() []Level {
+	if !l.atr.Ready() || len(l.pivots) == 0 {
+		return nil
+	}
+	tol := types.Price((int64(l.atr.Price())*l.zone + 500) / 1000)
+
+	if l.sorted == nil {
+		l.sorted = make([]levelPivot, len(l.pivots))
+		copy(l.sorted, l.pivots)
+		sort.Slice(l.sorted, func(i, j int) bool { return l.sorted[i].price < l.sorted[j].price })
+	}
+	pivots := l.sorted
+
+	var out []Level
+	var sum int64
+	var cur Level
+	flush := func() {
+		if cur.Touches >= l.cfg.MinTouches {
+			cur.Price = types.Price(sum / int64(cur.Touches))
+			out = append(out, cur)
+		}
+	}
+	for i, p := range pivots {
+		if i > 0 && p.price-types.Price(sum/int64(cur.Touches)) > tol {
+			flush()
+			cur, sum = Level{}, 0
+		}
+		if cur.Touches == 0 {
+			cur.Low = p.price
+		}
+		cur.High = p.price
+		cur.Touches++
+		cur.Last = max(cur.Last, p.time)
+		sum += int64(p.price)
+	}
+	flush()
+	return out
+}
+
+// NearestSupport returns the nearest zone at or below price: the highest
+// of levels (in price order) whose Low is at or below it. A zone containing
+// price is its support.
+func NearestSupport(levels []Level, price types.Price) (Level, bool) {
+	for i := len(levels) - 1; i >= 0; i-- {
+		if levels[i].Low <= price {
+			return levels[i], true
+		}
+	}
+	return Level{}, false
+}
+
+// NearestResistance returns the nearest zone at or above price: the lowest
+// of levels (in price order) whose High is at or above it. A zone
+// containing price is its resistance.
+func NearestResistance(levels []Level, price types.Price) (Level, bool) {
+	for _, lv := range levels {
+		if lv.High >= price {
+			return lv, true
+		}
+	}
+	return Level{}, false
+}
+
+// DetectLevels feeds candles, oldest first, through a fresh Levels and
+// returns the zones as of the last one.
+func DetectLevels(candles []market.Candle, cfg LevelsConfig, scale types.Scale6) ([]Level, error) {
+	l, err := NewLevels(cfg, scale)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range candles {
+		l.Update(c)
+	}
+	return l.Levels(), nil
+}
+
+var _ CandleIndicator = (*Levels)(nil)
@@ -0,0 +1,132 @@
+package indicator
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zigzag returns bars closing from 110_000 up to peak and back down, cycles
+// times, each with a 10-unit wick either side of the close.
+func zigzag(cycles int, peak types.Price) []market.Candle {
+	var out []market.Candle
+	add := func(c types.Price) {
+		ts := types.Timestamp(len(out) * 3600)
+		out = append(out, market.Candle{Open: c, High: c + 10, Low: c - 10, Close: c, Timestamp: ts})
+	}
+	for range cycles {
+		for p := types.Price(110_000); p < peak; p += 200 {
+			add(p)
+		}
+		for p := peak; p > 110_000; p -= 200 {
+			add(p)
+		}
+	}
+	add(110_000)
+	return out
+}
+
+func testLevelsConfig() LevelsConfig {
+	return LevelsConfig{Strength: 2, Lookback: 200, ATRPeriod: 5, ZoneATR: 0.5, MinTouches: 2}
+}
+
+func TestNewLevels_BadConfig(t *testing.T) {
+	t.Parallel()
+	for _, mod := range []func(*LevelsConfig){
+		func(c *LevelsConfig) { c.Strength = 0 },
+		func(c *LevelsConfig) { c.Lookback = 4 },
+		func(c *LevelsConfig) { c.ZoneATR = 0 },
+		func(c *LevelsConfig) { c.MinTouches = 0 },
+		func(c *LevelsConfig) { c.ATRPeriod = 0 },
+	} {
+		cfg := testLevelsConfig()
+		mod(&cfg)
+		_, err := NewLevels(cfg, 100_000)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func TestLevels_ClustersSwingsIntoZones(t *testing.T) {
+	t.Parallel()
+	l, err := NewLevels(testLevelsConfig(), 100_000)
+	require.NoError(t, err)
+	assert.Equal(t, "Levels(2,200,0.50×ATR(5),2)", l.Name())
+
+	for _, c := range zigzag(3, 111_000) {
+		l.Update(c)
+	}
+	require.True(t, l.Ready())
+	levels := l.Levels()
+	require.Len(t, levels, 2)
+
+	support, resistance := levels[0], levels[1]
+	assert.Equal(t, types.Price(109_990), support.Price)
+	assert.Equal(t, 2, support.Touches, "the troughs between three peaks")
+	assert.Equal(t, types.Price(111_010), resistance.Price)
+	assert.Equal(t, 3, resistance.Touches)
+	assert.True(t, resistance.Contains(111_010))
+
+	got, ok := NearestSupport(levels, 110_500)
+	require.True(t, ok)
+	assert.Equal(t, support, got)
+	got, ok = NearestResistance(levels, 110_500)
+	require.True(t, ok)
+	assert.Equal(t, resistance, got)
+	_, ok = NearestSupport(levels, 109_000)
+	assert.False(t, ok, "nothing below the lowest zone")
+	_, ok = NearestResistance(levels, 112_000)
+	assert.False(t, ok, "nothing above the highest zone")
+
+	l.Reset()
+	assert.False(t, l.Ready())
+	assert.Empty(t, l.Levels())
+}
+
+func TestLevels_NearbyPeaksShareAZone(t *testing.T) {
+	t.Parallel()
+	candles := zigzag(2, 111_000)
+	candles = append(candles, zigzag(1, 111_200)...)
+	for i := range candles {
+		candles[i].Timestamp = types.Timestamp(i * 3600)
+	}
+
+	cfg := testLevelsConfig()
+	cfg.ZoneATR = 2
+	levels, err := DetectLevels(candles, cfg, 100_000)
+	require.NoError(t, err)
+	require.NotEmpty(t, levels)
+	top := levels[len(levels)-1]
+	assert.Equal(t, 3, top.Touches)
+	assert.Equal(t, types.Price(111_010), top.Low)
+	assert.Equal(t, types.Price(111_210), top.High)
+	assert.Equal(t, candles[len(candles)-1].Timestamp-6*3600, top.Last, "the last peak")
+}
+
+func TestLevels_LookbackDropsOldPivots(t *testing.T) {
+	t.Parallel()
+	cfg := testLevelsConfig()
+	cfg.Lookback = 15
+	levels, err := DetectLevels(zigzag(4, 111_000), cfg, 100_000)
+	require.NoError(t, err)
+	for _, lv := range levels {
+		assert.LessOrEqual(t, lv.Touches, 2, "a 10-bar cycle leaves at most two pivots per side in 15 bars")
+	}
+}
+
+func TestLevels_QueriedEveryBarMatchesDetectLevels(t *testing.T) {
+	t.Parallel()
+	cfg := testLevelsConfig()
+	cfg.Lookback = 15
+	candles := zigzag(4, 111_000)
+	l, err := NewLevels(cfg, 100_000)
+	require.NoError(t, err)
+	for i, c := range candles {
+		l.Update(c)
+		want, err := DetectLevels(candles[:i+1], cfg, 100_000)
+		require.NoError(t, err)
+		assert.Equal(t, want, l.Levels(), "bar %d: the sorted pivots follow each new and dropped pivot", i)
+	}
+}
@@ -0,0 +1,116 @@
+package indicator
+
+import (
+	"fmt"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// This file exposes the warm state of the indicators strategies build on,
+// so a strategy can save it (see strategy.StatefulStrategy) and resume
+// without replaying its warm-up bars. States are plain fixed-point values
+// and round-trip through JSON exactly. SetState only accepts a state taken
+// from an indicator with the same period.
+
+// EMAState is an EMA's warm state.
+type EMAState struct {
+	Period int            `json:"period"`
+	Seen   int            `json:"seen"`
+	Value  types.PriceSum `json:"value"`
+}
+
+// State returns e's warm state.
+func (e *EMA) State() EMAState {
+	return EMAState{Period: e.n, Seen: e.seen, Value: e.value}
+}
+
+// SetState replaces e's warm state with s.
+func (e *EMA) SetState(s EMAState) error {
+	if s.Period != e.n {
+		return fmt.Errorf("%s: state is for period %d", e.name, s.Period)
+	}
+	e.seen = s.Seen
+	e.value = s.Value
+	e.ready = s.Seen >= e.n
+	return nil
+}
+
+// ATRState is an ATR's warm state.
+type ATRState struct {
+	Period  int            `json:"period"`
+	Prev    market.Candle  `json:"prev"`
+	HasPrev bool           `json:"has_prev"`
+	Ready   bool           `json:"ready"`
+	Value   types.PriceSum `json:"value"`
+	Periods int            `json:"periods"`
+	SumTR   types.PriceSum `json:"sum_tr"`
+}
+
+// State returns a's warm state.
+func (a *ATR) State() ATRState {
+	return ATRState{
+		Period: a.n, Prev: a.prev, HasPrev: a.hasPrev, Ready: a.ready,
+		Value: a.value, Periods: a.periods, SumTR: a.sumTR,
+	}
+}
+
+// SetState replaces a's warm state with s.
+func (a *ATR) SetState(s ATRState) error {
+	if s.Period != a.n {
+		return fmt.Errorf("%s: state is for period %d", a.name, s.Period)
+	}
+	a.prev, a.hasPrev, a.ready = s.Prev, s.HasPrev, s.Ready
+	a.value, a.periods, a.sumTR = s.Value, s.Periods, s.SumTR
+	return nil
+}
+
+// ADXState is an ADX's warm state.
+type ADXState struct {
+	Period     int            `json:"period"`
+	Prev       market.Candle  `json:"prev"`
+	HasPrev    bool           `json:"has_prev"`
+	Ready      bool           `json:"ready"`
+	ADX        int64          `json:"adx"`
+	PlusDI     int64          `json:"plus_di"`
+	MinusDI    int64          `json:"minus_di"`
+	LastDX     int64          `json:"last_dx"`
+	Periods    int            `json:"periods"`
+	SumTR      types.PriceSum `json:"sum_tr"`
+	SumPlusDM  types.PriceSum `json:"sum_plus_dm"`
+	SumMinusDM types.PriceSum `json:"sum_minus_dm"`
+	SmTR       types.PriceSum `json:"sm_tr"`
+	SmPlusDM   types.PriceSum `json:"sm_plus_dm"`
+	SmMinusDM  types.PriceSum `json:"sm_minus_dm"`
+	DXSum      int64          `json:"dx_sum"`
+	DXCount    int            `json:"dx_count"`
+}
+
+// State returns a's warm state.
+func (a *ADX) State() ADXState {
+	return ADXState{
+		Period: a.n, Prev: a.prev, HasPrev: a.hasPrev, Ready: a.ready,
+		ADX: a.adx, PlusDI: a.plusDI, MinusDI: a.minusDI, LastDX: a.lastDX,
+		Periods: a.periods,
+		SumTR:   a.sumTR, SumPlusDM: a.sumPlusDM, SumMinusDM: a.sumMinusDM,
+		SmTR: a.smTR, SmPlusDM: a.smPlusDM, SmMinusDM: a.smMinusDM,
+		DXSum: a.dxSum, DXCount: a.dxCount,
+	}
+}
+
+// SetState replaces a's warm state with s.
+func (a *ADX) SetState(s ADXState) error {
+	if s.Period != a.n {
+		return fmt.Errorf("%s: state is for period %d", a.name, s.Period)
+	}
+	*a = ADX{
+		n: a.n, name: a.name,
+		prev: s.Prev, hasPrev: s.HasPrev, ready: s.Ready,
+		adx: s.ADX, plusDI: s.PlusDI, minusDI: s.MinusDI, lastDX: s.LastDX,
+		periods: s.Periods,
+		sumTR:   s.SumTR, sumPlusDM: s.SumPlusDM, sumMinusDM: s.SumMinusDM,
+		smTR: s.SmTR, smPlusDM: s.SmPlusDM, smMinusDM: s.SmMinusDM,
+		dxSum: s.DXSum, dxCount: s.DXCount,
+	}
+	return nil
+}
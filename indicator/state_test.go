@@ -0,0 +1,86 @@
+package indicator
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waveCandles returns n candles oscillating around 1.1000.
+func waveCandles(n int) []market.Candle {
+	out := make([]market.Candle, n)
+	for i := range out {
+		c := 1.1 + 0.003*math.Sin(float64(i)/4)
+		out[i] = mkCandle(int32(types.PriceScale), c-0.0004, c+0.0009, c-0.0011, c)
+	}
+	return out
+}
+
+// roundTrip sends v through JSON into out, as a saved snapshot would go.
+func roundTrip(t *testing.T, v, out any) {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, out))
+}
+
+func TestIndicatorState_RestoredIndicatorContinuesIdentically(t *testing.T) {
+	candles := waveCandles(80)
+	half := 37
+
+	ema, _ := NewEMA(10, types.PriceScale)
+	atr, _ := NewATR(14, types.PriceScale)
+	adx, _ := NewADX(14, types.PriceScale)
+	for _, c := range candles[:half] {
+		ema.Update(c)
+		atr.Update(c)
+		adx.Update(c)
+	}
+
+	var es EMAState
+	var as ATRState
+	var xs ADXState
+	roundTrip(t, ema.State(), &es)
+	roundTrip(t, atr.State(), &as)
+	roundTrip(t, adx.State(), &xs)
+
+	ema2, _ := NewEMA(10, types.PriceScale)
+	atr2, _ := NewATR(14, types.PriceScale)
+	adx2, _ := NewADX(14, types.PriceScale)
+	require.NoError(t, ema2.SetState(es))
+	require.NoError(t, atr2.SetState(as))
+	require.NoError(t, adx2.SetState(xs))
+	assert.Equal(t, ema.Ready(), ema2.Ready())
+
+	for _, c := range candles[half:] {
+		ema.Update(c)
+		ema2.Update(c)
+		atr.Update(c)
+		atr2.Update(c)
+		adx.Update(c)
+		adx2.Update(c)
+		require.Equal(t, ema.PriceSum(), ema2.PriceSum())
+		require.Equal(t, atr.PriceSum(), atr2.PriceSum())
+		require.Equal(t, adx.Value(), adx2.Value())
+		require.Equal(t, adx.PlusDIRaw(), adx2.PlusDIRaw())
+	}
+	assert.True(t, adx2.Ready())
+}
+
+func TestIndicatorState_PeriodMismatch(t *testing.T) {
+	ema, _ := NewEMA(10, types.PriceScale)
+	other, _ := NewEMA(20, types.PriceScale)
+	other.Update(mkCandle(int32(types.PriceScale), 1, 1, 1, 1))
+	require.Error(t, ema.SetState(other.State()))
+	assert.Zero(t, ema.PriceSum(), "a rejected state leaves the EMA untouched")
+
+	atr, _ := NewATR(14, types.PriceScale)
+	require.Error(t, atr.SetState(ATRState{Period: 7}))
+	adx, _ := NewADX(14, types.PriceScale)
+	require.Error(t, adx.SetState(ADXState{Period: 7}))
+}
@@ -0,0 +1,159 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// Annotation sections, matching the narrative headings FormatTradeOrg
+// writes.
+const (
+	SectionThesis    = "thesis"
+	SectionExecution = "execution"
+	SectionReview    = "review"
+)
+
+// MaxRating is the top of the 1..MaxRating review rating scale.
+const MaxRating = 5
+
+// Annotation is a note attached to a closed trade after the fact. Trade
+// journals are written append-only by live and replay code, so annotations
+// live in a sidecar file (see AnnotationsPath) and are joined onto trades
+// by TradeID when read.
+type Annotation struct {
+	TradeID string          `json:"trade_id"`
+	Time    types.Timestamp `json:"time"`
+	Section string          `json:"section,omitempty"` // thesis, execution or review; empty means review
+	Note    string          `json:"note,omitempty"`
+	Rating  int             `json:"rating,omitempty"` // 1..MaxRating; 0 leaves the rating unchanged
+}
+
+// Validate normalizes a.Section and checks a is worth recording.
+func (a *Annotation) Validate() error {
+	if strings.TrimSpace(a.TradeID) == "" {
+		return fmt.Errorf("annotation needs a trade ID")
+	}
+	a.Section = strings.ToLower(strings.TrimSpace(a.Section))
+	switch a.Section {
+	case "":
+		a.Section = SectionReview
+	case SectionThesis, SectionExecution, SectionReview:
+	default:
+		return fmt.Errorf("bad section %q (use %s, %s or %s)", a.Section, SectionThesis, SectionExecution, SectionReview)
+	}
+	if a.Rating < 0 || a.Rating > MaxRating {
+		return fmt.Errorf("rating must be 1-%d, got %d", MaxRating, a.Rating)
+	}
+	if strings.TrimSpace(a.Note) == "" && a.Rating == 0 {
+		return fmt.Errorf("annotation needs a note or a rating")
+	}
+	return nil
+}
+
+// AnnotationsPath returns the sidecar annotations file for a trades
+// journal: "live-trades.jsonl" → "live-trades.notes.jsonl".
+func AnnotationsPath(tradesPath string) string {
+	ext := filepath.Ext(tradesPath)
+	return strings.TrimSuffix(tradesPath, ext) + ".notes.jsonl"
+}
+
+// AppendAnnotation validates a and appends it to the JSONL file at path,
+// creating the file if needed.
+func AppendAnnotation(path string, a Annotation) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(a); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadAnnotations reads all annotations from the JSONL file at path, in
+// the order they were written. A missing file has no annotations.
+func ReadAnnotations(path string) ([]Annotation, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Annotation
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var a Annotation
+		if err := json.Unmarshal([]byte(text), &a); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		out = append(out, a)
+	}
+	return out, scanner.Err()
+}
+
+// Annotate attaches notes to the trades they name, in order, and sets
+// each trade's Rating to the last rating given to it. Notes for trades not
+// in trades are ignored.
+func Annotate(trades []TradeRecord, notes []Annotation) []TradeRecord {
+	byID := make(map[string]int, len(trades))
+	for i, t := range trades {
+		byID[t.TradeID] = i
+	}
+	for _, a := range notes {
+		i, ok := byID[a.TradeID]
+		if !ok {
+			continue
+		}
+		if strings.TrimSpace(a.Note) != "" {
+			trades[i].Notes = append(trades[i].Notes, a)
+		}
+		if a.Rating > 0 {
+			trades[i].Rating = a.Rating
+		}
+	}
+	return trades
+}
+
+// FindTrade returns the trade whose ID is id, or else the only one whose ID
+// starts with it, so the short IDs FormatTradeOrg prints can be used.
+func FindTrade(trades []TradeRecord, id string) (TradeRecord, error) {
+	var match []TradeRecord
+	for _, t := range trades {
+		if t.TradeID == id {
+			return t, nil
+		}
+		if id != "" && strings.HasPrefix(t.TradeID, id) {
+			match = append(match, t)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return TradeRecord{}, fmt.Errorf("no trade %q in journal", id)
+	case 1:
+		return match[0], nil
+	default:
+		return TradeRecord{}, fmt.Errorf("trade ID %q is ambiguous (%d matches)", id, len(match))
+	}
+}
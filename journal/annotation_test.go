@@ -0,0 +1,112 @@
+package journal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationsPath(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "/j/live-trades.notes.jsonl", AnnotationsPath("/j/live-trades.jsonl"))
+	assert.Equal(t, "trades.notes.jsonl", AnnotationsPath("trades"))
+}
+
+func TestAnnotationValidate(t *testing.T) {
+	t.Parallel()
+
+	a := Annotation{TradeID: "T1", Note: "chased the entry", Section: " Execution "}
+	require.NoError(t, a.Validate())
+	assert.Equal(t, SectionExecution, a.Section)
+
+	a = Annotation{TradeID: "T1", Rating: 3}
+	require.NoError(t, a.Validate())
+	assert.Equal(t, SectionReview, a.Section, "empty section files under review")
+
+	for _, bad := range []Annotation{
+		{Note: "no trade"},
+		{TradeID: "T1"},
+		{TradeID: "T1", Note: "x", Section: "postmortem"},
+		{TradeID: "T1", Rating: 6},
+		{TradeID: "T1", Rating: -1},
+	} {
+		assert.Error(t, bad.Validate(), "%+v", bad)
+	}
+}
+
+func TestAppendAndReadAnnotations(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "trades.notes.jsonl")
+	notes, err := ReadAnnotations(path)
+	require.NoError(t, err)
+	assert.Empty(t, notes, "missing file has no annotations")
+
+	require.NoError(t, AppendAnnotation(path, Annotation{TradeID: "T1", Time: 100, Note: "first"}))
+	require.NoError(t, AppendAnnotation(path, Annotation{TradeID: "T1", Time: 200, Rating: 4}))
+	require.Error(t, AppendAnnotation(path, Annotation{TradeID: "T1"}))
+
+	notes, err = ReadAnnotations(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Annotation{
+		{TradeID: "T1", Time: 100, Section: SectionReview, Note: "first"},
+		{TradeID: "T1", Time: 200, Section: SectionReview, Rating: 4},
+	}, notes)
+
+	require.NoError(t, os.WriteFile(path, []byte("{bad\n"), 0o644))
+	_, err = ReadAnnotations(path)
+	require.ErrorContains(t, err, "line 1")
+}
+
+func TestAnnotateAndFormatTradeOrg(t *testing.T) {
+	t.Parallel()
+
+	day := types.FromTime(time.Date(2024, 3, 16, 9, 0, 0, 0, time.UTC))
+	trades := Annotate([]TradeRecord{{TradeID: "T1", Instrument: "EUR_USD"}, {TradeID: "T2"}}, []Annotation{
+		{TradeID: "T1", Time: day, Section: SectionThesis, Note: "D1 breakout retest"},
+		{TradeID: "T1", Time: day, Section: SectionReview, Note: "held too long\nshould have scaled out", Rating: 2},
+		{TradeID: "T1", Time: day, Rating: 4},
+		{TradeID: "T9", Time: day, Note: "orphan"},
+	})
+	require.Len(t, trades[0].Notes, 2)
+	assert.Equal(t, 4, trades[0].Rating, "latest rating wins")
+	assert.Empty(t, trades[1].Notes)
+
+	out := FormatTradeOrg(trades[0])
+	assert.Contains(t, out, ":REVIEW_RATING: 4/5\n")
+	assert.Contains(t, out, "*** Thesis\n- [2024-03-16] D1 breakout retest\n\n*** Execution\n- \n\n")
+	assert.Contains(t, out, "*** Review\n- [2024-03-16] held too long\n  should have scaled out\n")
+	assert.NotContains(t, FormatTradeOrg(trades[1]), "REVIEW_RATING")
+
+	b, err := json.Marshal(trades[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"Rating":4`)
+	assert.Contains(t, string(b), `"note":"D1 breakout retest"`)
+	b, err = json.Marshal(trades[1])
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "Notes")
+}
+
+func TestFindTrade(t *testing.T) {
+	t.Parallel()
+
+	trades := []TradeRecord{{TradeID: "01HABC1"}, {TradeID: "01HABC2"}, {TradeID: "01HXYZ"}}
+	got, err := FindTrade(trades, "01HX")
+	require.NoError(t, err)
+	assert.Equal(t, "01HXYZ", got.TradeID)
+
+	got, err = FindTrade(trades, "01HABC2")
+	require.NoError(t, err)
+	assert.Equal(t, "01HABC2", got.TradeID)
+
+	_, err = FindTrade(trades, "01HABC")
+	require.ErrorContains(t, err, "ambiguous")
+	_, err = FindTrade(trades, "nope")
+	require.ErrorContains(t, err, "no trade")
+}
@@ -0,0 +1,31 @@
+package journal
+
+import (
+	"github.com/rustyeddy/trader/types"
+)
+
+// QuotePL returns the P/L in the quote currency of units (signed: positive
+// long) opened at entry and closed at exit.
+func QuotePL(units types.Units, entry, exit types.Price) (types.Money, error) {
+	delta := int64(exit) - int64(entry)
+	absDelta, err := types.AbsInt64Checked(delta)
+	if err != nil {
+		return 0, err
+	}
+	absUnits, err := types.AbsInt64Checked(int64(units))
+	if err != nil {
+		return 0, err
+	}
+	moved, err := types.MulChecked64(absDelta, absUnits)
+	if err != nil {
+		return 0, err
+	}
+	v, err := types.SignedMulDivRound(moved, int64(types.MoneyScale), int64(types.PriceScale))
+	if err != nil {
+		return 0, err
+	}
+	if (delta < 0) != (units < 0) {
+		v = -v
+	}
+	return types.Money(v), nil
+}
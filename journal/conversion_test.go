@@ -0,0 +1,69 @@
+package journal
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotePL(t *testing.T) {
+	t.Parallel()
+
+	pl, err := QuotePL(1000, types.PriceFromFloat(150.000), types.PriceFromFloat(151.250))
+	require.NoError(t, err)
+	assert.Equal(t, types.MoneyFromFloat(1250), pl)
+
+	pl, err = QuotePL(-10_000, types.PriceFromFloat(1.10000), types.PriceFromFloat(1.10500))
+	require.NoError(t, err)
+	assert.Equal(t, types.MoneyFromFloat(-50), pl, "short loses when price rises")
+}
+
+func TestLiveJournalRecordsConversion(t *testing.T) {
+	t.Parallel()
+
+	journal := &captureJournal{}
+	lj := NewLiveJournal(nil, "", journal, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	openTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	lj.recordOpen(oanda.Transaction{TradeID: "t1", Instrument: "USD_JPY", Units: 1000, Price: 150.000, Time: openTime})
+	lj.handleTransaction(oanda.Transaction{
+		ID:   "7",
+		Type: "ORDER_FILL",
+		Time: openTime.Add(time.Hour),
+		TradesClosed: []oanda.ClosedTrade{
+			{TradeID: "t1", Units: -1000, Price: 151.250, RealizedPL: 8.26},
+		},
+	})
+
+	require.Len(t, journal.trades, 1)
+	rec := journal.trades[0]
+	assert.Equal(t, "JPY", rec.QuoteCurrency)
+	assert.Equal(t, types.MoneyFromFloat(1250), rec.QuotePL)
+	assert.Equal(t, types.RateFromFloat(0.006608), rec.ConversionRate)
+	assert.InDelta(t, rec.QuotePL.Float64()*rec.ConversionRate.Float64(), rec.RealizedPL.Float64(), 0.01)
+}
+
+func TestFormatTradeOrgConversion(t *testing.T) {
+	t.Parallel()
+
+	out := FormatTradeOrg(TradeRecord{
+		TradeID:         "T1",
+		Instrument:      "USD_JPY",
+		RealizedPL:      types.MoneyFromFloat(8.26),
+		QuoteCurrency:   "JPY",
+		QuotePL:         types.MoneyFromFloat(1250),
+		AccountCurrency: "USD",
+		ConversionRate:  types.RateFromFloat(0.006608),
+	})
+	assert.Contains(t, out, ":QUOTE_PL: 1250.00 JPY\n")
+	assert.Contains(t, out, ":ACCOUNT_CURRENCY: USD\n")
+	assert.Contains(t, out, ":CONVERSION_RATE: 0.006608\n")
+
+	assert.NotContains(t, FormatTradeOrg(TradeRecord{TradeID: "T2"}), "CONVERSION_RATE")
+}
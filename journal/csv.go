@@ -3,22 +3,60 @@ package journal
 import (
 	"encoding/csv"
 	"errors"
+	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
 )
 
 var tradeCSVHeader = []string{
 	"trade_id", "instrument", "units", "entry_price", "exit_price", "open_time", "close_time", "realized_pl", "reason",
+	"initial_risk", "r_multiple", "mae", "mfe",
+	"quote_currency", "quote_pl", "account_currency", "conversion_rate",
+	"account_id",
 }
 
 var equityCSVHeader = []string{
 	"time", "balance", "equity", "margin_used", "free_margin", "margin_level",
 }
 
+var orderEventCSVHeader = []string{
+	"type", "order_id", "trade_id", "account_id", "instrument", "units", "price", "time", "reason", "remaining",
+}
+
+var orderDecisionCSVHeader = []string{
+	"time", "account_id", "instrument", "side", "accepted", "reason", "detail", "requested_units", "units",
+}
+
+var skippedSignalCSVHeader = []string{
+	"time", "account_id", "instrument", "side", "rule", "reason",
+}
+
+var signalCSVHeader = []string{
+	"time", "account_id", "instrument", "side", "price", "stop", "taken", "gate", "rule", "detail", "reason", "max_r", "stopped",
+}
+
 type csvJournal struct {
 	tradeWriter  *csv.Writer
 	equityWriter *csv.Writer
 	tradesFile   *os.File
 	equityFile   *os.File
+
+	// Sidecar files for the optional record kinds, opened on their first
+	// record next to tradesPath (see sidecarPath).
+	tradesPath string
+	sidecars   map[string]*csvSidecar
+}
+
+type csvSidecar struct {
+	f *os.File
+	w *csv.Writer
 }
 
 func NewCSV(tradesPath, equityPath string) (*csvJournal, error) {
@@ -38,6 +76,7 @@ func NewCSV(tradesPath, equityPath string) (*csvJournal, error) {
 		equityWriter: equityWriter,
 		tradesFile:   tradesFile,
 		equityFile:   equityFile,
+		tradesPath:   tradesPath,
 	}, nil
 }
 
@@ -70,6 +109,7 @@ func openCSVJournalFile(path string, header []string) (*os.File, *csv.Writer, er
 }
 
 func (j *csvJournal) RecordTrade(t TradeRecord) error {
+	t = t.RoundPrices()
 	err := j.tradeWriter.Write([]string{
 		t.TradeID,
 		t.Instrument,
@@ -80,6 +120,15 @@ func (j *csvJournal) RecordTrade(t TradeRecord) error {
 		t.CloseTime.String(),
 		t.RealizedPL.String(),
 		t.Reason,
+		t.InitialRisk.String(),
+		t.RMultiple.String(),
+		t.MAE.String(),
+		t.MFE.String(),
+		t.QuoteCurrency,
+		t.QuotePL.String(),
+		t.AccountCurrency,
+		t.ConversionRate.String(),
+		t.AccountID,
 	})
 	if err != nil {
 		return err
@@ -105,6 +154,86 @@ func (j *csvJournal) RecordEquity(e EquitySnapshot) error {
 	return j.equityWriter.Error()
 }
 
+func (j *csvJournal) RecordOrderEvent(e OrderEvent) error {
+	return j.writeSidecar(sidecarOrders, orderEventCSVHeader, []string{
+		e.Type,
+		e.OrderID,
+		e.TradeID,
+		e.AccountID,
+		e.Instrument,
+		strconv.FormatInt(e.Units, 10),
+		e.Price.String(),
+		e.Time.String(),
+		e.Reason,
+		strconv.FormatInt(e.Remaining, 10),
+	})
+}
+
+func (j *csvJournal) RecordOrderDecision(d OrderDecision) error {
+	return j.writeSidecar(sidecarDecisions, orderDecisionCSVHeader, []string{
+		d.Time.String(),
+		d.AccountID,
+		d.Instrument,
+		d.Side,
+		strconv.FormatBool(d.Accepted),
+		d.Reason,
+		d.Detail,
+		d.RequestedUnits.String(),
+		d.Units.String(),
+	})
+}
+
+func (j *csvJournal) RecordSkippedSignal(s SkippedSignal) error {
+	return j.writeSidecar(sidecarSkipped, skippedSignalCSVHeader, []string{
+		s.Time.String(),
+		s.AccountID,
+		s.Instrument,
+		s.Side,
+		s.Rule,
+		s.Reason,
+	})
+}
+
+func (j *csvJournal) RecordSignal(s SignalRecord) error {
+	return j.writeSidecar(sidecarSignals, signalCSVHeader, []string{
+		s.Time.String(),
+		s.AccountID,
+		s.Instrument,
+		s.Side,
+		s.Price.String(),
+		s.Stop.String(),
+		strconv.FormatBool(s.Taken),
+		s.Gate,
+		s.Rule,
+		s.Detail,
+		s.Reason,
+		s.MaxR.String(),
+		strconv.FormatBool(s.Stopped),
+	})
+}
+
+// writeSidecar appends row to kind's sidecar file, opening it, with
+// header, first.
+func (j *csvJournal) writeSidecar(kind string, header, row []string) error {
+	sc, ok := j.sidecars[kind]
+	if !ok {
+		f, w, err := openCSVJournalFile(sidecarPath(j.tradesPath, kind), header)
+		if err != nil {
+			return err
+		}
+		sc = &csvSidecar{f: f, w: w}
+		if j.sidecars == nil {
+			j.sidecars = make(map[string]*csvSidecar)
+		}
+		j.sidecars[kind] = sc
+	}
+	if err := sc.w.Write(row); err != nil {
+		return err
+	}
+	sc.w.Flush()
+	return sc.w.Error()
+}
+
 func (j *csvJournal) Close() error {
 	var errs []error
 
@@ -116,5 +245,189 @@ func (j *csvJournal) Close() error {
 
 	errs = append(errs, j.tradesFile.Close(), j.equityFile.Close())
 
+	for _, sc := range j.sidecars {
+		sc.w.Flush()
+		errs = append(errs, sc.w.Error(), sc.f.Close())
+	}
+
 	return errors.Join(errs...)
 }
+
+// ReadTradesCSV reads all TradeRecords from a CSV trades journal written
+// by NewCSV. Columns are taken by position, so files started before later
+// columns were added read with those fields zero. Like ReadTradesJSONL it
+// skips the header and malformed rows.
+func ReadTradesCSV(path string) ([]TradeRecord, error) {
+	return readCSVJournal(path, tradeCSVHeader[0], parseTradeCSVRow)
+}
+
+// ReadEquityCSV reads all EquitySnapshots from a CSV equity journal
+// written by NewCSV, skipping malformed rows like ReadTradesCSV.
+func ReadEquityCSV(path string) ([]EquitySnapshot, error) {
+	return readCSVJournal(path, equityCSVHeader[0], parseEquityCSVRow)
+}
+
+// ReadTrades reads a trades journal, CSV for a .csv path and JSONL
+// otherwise.
+func ReadTrades(path string) ([]TradeRecord, error) {
+	if isCSVPath(path) {
+		return ReadTradesCSV(path)
+	}
+	return ReadTradesJSONL(path)
+}
+
+// ReadEquity reads an equity journal, CSV for a .csv path and JSONL
+// otherwise.
+func ReadEquity(path string) ([]EquitySnapshot, error) {
+	if isCSVPath(path) {
+		return ReadEquityCSV(path)
+	}
+	return ReadEquityJSONL(path)
+}
+
+func isCSVPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".csv")
+}
+
+func readCSVJournal[T any](path, firstColumn string, parse func(csvFields) (T, error)) ([]T, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	var records []T
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				continue
+			}
+			return nil, err
+		}
+		if len(row) == 0 || strings.TrimSpace(row[0]) == firstColumn {
+			continue
+		}
+		if rec, err := parse(csvFields(row)); err == nil {
+			records = append(records, rec)
+		}
+	}
+}
+
+func parseTradeCSVRow(f csvFields) (TradeRecord, error) {
+	var errs []error
+	t := TradeRecord{
+		TradeID:         f.str(0),
+		Instrument:      f.str(1),
+		Units:           types.Units(f.int(2, &errs)),
+		EntryPrice:      f.price(3, &errs),
+		ExitPrice:       f.price(4, &errs),
+		OpenTime:        f.time(5, &errs),
+		CloseTime:       f.time(6, &errs),
+		RealizedPL:      f.money(7, &errs),
+		Reason:          f.str(8),
+		InitialRisk:     f.money(9, &errs),
+		RMultiple:       f.rate(10, &errs),
+		MAE:             f.price(11, &errs),
+		MFE:             f.price(12, &errs),
+		QuoteCurrency:   f.str(13),
+		QuotePL:         f.money(14, &errs),
+		AccountCurrency: f.str(15),
+		ConversionRate:  f.rate(16, &errs),
+		AccountID:       f.str(17),
+	}
+	if t.TradeID == "" {
+		errs = append(errs, errors.New("missing trade_id"))
+	}
+	return t, errors.Join(errs...)
+}
+
+func parseEquityCSVRow(f csvFields) (EquitySnapshot, error) {
+	var errs []error
+	e := EquitySnapshot{
+		Timestamp:   f.time(0, &errs),
+		Balance:     f.money(1, &errs),
+		Equity:      f.money(2, &errs),
+		MarginUsed:  f.money(3, &errs),
+		FreeMargin:  f.money(4, &errs),
+		MarginLevel: f.money(5, &errs),
+	}
+	return e, errors.Join(errs...)
+}
+
+// csvFields reads one journal row by column; columns past the end of the
+// row read as zero.
+type csvFields []string
+
+func (f csvFields) str(i int) string {
+	if i >= len(f) {
+		return ""
+	}
+	return strings.TrimSpace(f[i])
+}
+
+func (f csvFields) int(i int, errs *[]error) int64 {
+	s := f.str(i)
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		*errs = append(*errs, err)
+	}
+	return v
+}
+
+func (f csvFields) time(i int, errs *[]error) types.Timestamp {
+	s := f.str(i)
+	if s == "" {
+		return 0
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		*errs = append(*errs, err)
+		return 0
+	}
+	return types.FromTime(t)
+}
+
+// scaled parses the decimal text the types' String methods write into a
+// value at scale, rejecting values the fixed-point type cannot hold.
+func (f csvFields) scaled(i int, scale int64, errs *[]error) int64 {
+	s := f.str(i)
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err == nil && (math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v*float64(scale)) >= math.MaxInt64) {
+		err = fmt.Errorf("value %q out of range", s)
+	}
+	if err != nil {
+		*errs = append(*errs, err)
+		return 0
+	}
+	return int64(math.Round(v * float64(scale)))
+}
+
+func (f csvFields) price(i int, errs *[]error) types.Price {
+	v := f.scaled(i, int64(types.PriceScale), errs)
+	if v > math.MaxInt32 || v < math.MinInt32 {
+		*errs = append(*errs, fmt.Errorf("price %q out of range", f.str(i)))
+		return 0
+	}
+	return types.Price(v)
+}
+
+func (f csvFields) money(i int, errs *[]error) types.Money {
+	return types.Money(f.scaled(i, int64(types.MoneyScale), errs))
+}
+
+func (f csvFields) rate(i int, errs *[]error) types.Rate {
+	return types.Rate(f.scaled(i, int64(types.RateScale), errs))
+}
@@ -70,15 +70,25 @@ func TestCSVJournalRecordTrade(t *testing.T) {
 	realizedPL := types.MoneyFromFloat(-12.5)
 
 	err = j.RecordTrade(TradeRecord{
-		TradeID:    "T1",
-		Instrument: "EUR_USD",
-		Units:      units,
-		EntryPrice: entryPrice,
-		ExitPrice:  exitPrice,
-		OpenTime:   types.FromTime(open),
-		CloseTime:  types.FromTime(closeT),
-		RealizedPL: realizedPL,
-		Reason:     "test",
+		TradeID:     "T1",
+		Instrument:  "EUR_USD",
+		Units:       units,
+		EntryPrice:  entryPrice,
+		ExitPrice:   exitPrice,
+		OpenTime:    types.FromTime(open),
+		CloseTime:   types.FromTime(closeT),
+		RealizedPL:  realizedPL,
+		Reason:      "test",
+		InitialRisk: types.MoneyFromFloat(25),
+		RMultiple:   types.RateFromFloat(-0.5),
+		MAE:         types.PriceFromFloat(0.0031),
+		MFE:         types.PriceFromFloat(0.0012),
+
+		QuoteCurrency:   "USD",
+		QuotePL:         realizedPL,
+		AccountCurrency: "USD",
+		ConversionRate:  types.RateFromFloat(1),
+		AccountID:       "ema",
 	})
 	assert.NoError(t, err)
 
@@ -103,6 +113,15 @@ func TestCSVJournalRecordTrade(t *testing.T) {
 		types.FromTime(closeT).String(),
 		"-12.500000",
 		"test",
+		"25.000000",
+		"-0.500000",
+		"0.00310",
+		"0.00120",
+		"USD",
+		"-12.500000",
+		"USD",
+		"1.000000",
+		"ema",
 	}
 	assert.Equal(t, want, row)
 }
@@ -351,3 +370,75 @@ func TestCSVJournalReopenAppendsWithoutDuplicatingHeaders(t *testing.T) {
 	require.Len(t, equityRows, 3)
 	assert.Equal(t, equityCSVHeader, equityRows[0])
 }
+
+func TestReadCSVJournal_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tradesPath := filepath.Join(dir, "trades.csv")
+	equityPath := filepath.Join(dir, "equity.csv")
+
+	trade := TradeRecord{
+		TradeID:         "t-1",
+		Instrument:      "EURUSD",
+		Units:           -2500,
+		EntryPrice:      types.PriceFromFloat(1.08512),
+		ExitPrice:       types.PriceFromFloat(1.08301),
+		OpenTime:        types.Timestamp(1710400000),
+		CloseTime:       types.Timestamp(1710500000),
+		RealizedPL:      types.MoneyFromFloat(5.275),
+		Reason:          "breakout, retest",
+		InitialRisk:     types.MoneyFromFloat(10),
+		RMultiple:       types.RateFromFloat(0.5275),
+		MAE:             types.PriceFromFloat(0.0004),
+		MFE:             types.PriceFromFloat(0.0025),
+		QuoteCurrency:   "USD",
+		QuotePL:         types.MoneyFromFloat(5.275),
+		AccountCurrency: "USD",
+		ConversionRate:  types.RateFromFloat(1),
+		AccountID:       "ema",
+	}
+	snap := EquitySnapshot{
+		Timestamp:   types.Timestamp(1710500000),
+		Balance:     types.MoneyFromFloat(10_005.275),
+		Equity:      types.MoneyFromFloat(10_001.5),
+		MarginUsed:  types.MoneyFromFloat(90.25),
+		FreeMargin:  types.MoneyFromFloat(9_911.25),
+		MarginLevel: types.MoneyFromFloat(11081.99),
+	}
+
+	j, err := NewCSV(tradesPath, equityPath)
+	require.NoError(t, err)
+	require.NoError(t, j.RecordTrade(trade))
+	require.NoError(t, j.RecordEquity(snap))
+	require.NoError(t, j.Close())
+
+	trades, err := ReadTrades(tradesPath)
+	require.NoError(t, err)
+	assert.Equal(t, []TradeRecord{trade}, trades)
+
+	equity, err := ReadEquity(equityPath)
+	require.NoError(t, err)
+	assert.Equal(t, []EquitySnapshot{snap}, equity)
+}
+
+func TestReadTradesCSV_OldAndMalformedRows(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "trades.csv")
+	require.NoError(t, os.WriteFile(path, []byte(`trade_id,instrument,units,entry_price,exit_price,open_time,close_time,realized_pl,reason
+old,EURUSD,1000,1.10000,1.10100,2024-03-15T09:00:00Z,2024-03-15T10:00:00Z,1.000000,tp
+bad,EURUSD,lots,1.1,1.1,2024-03-15T09:00:00Z,2024-03-15T10:00:00Z,1,x
+,EURUSD,1,1.1,1.1,,,0,no id
+`), 0o644))
+
+	trades, err := ReadTradesCSV(path)
+	require.NoError(t, err)
+	require.Len(t, trades, 1, "malformed rows are skipped")
+	assert.Equal(t, "old", trades[0].TradeID)
+	assert.Equal(t, types.MoneyFromFloat(1), trades[0].RealizedPL)
+	assert.Zero(t, trades[0].InitialRisk, "columns the old file lacks read as zero")
+
+	_, err = ReadTradesCSV(filepath.Join(t.TempDir(), "missing.csv"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
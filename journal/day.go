@@ -0,0 +1,124 @@
+package journal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// Day is one trading day of the journal: the trades closed on it and the
+// primary account's equity either side of it.
+type Day struct {
+	Date time.Time // local midnight of the day's calendar date
+
+	// Start and End bound the day, [Start, End): local midnight to
+	// midnight for BuildDay, rollover to rollover for BuildRolloverDay.
+	Start, End time.Time
+
+	// StartEquity is the last equity recorded before the day began (or
+	// the first recorded during it); EndEquity the last recorded before it
+	// ended. Both are zero when HasEquity is false.
+	HasEquity   bool
+	StartEquity types.Money
+	EndEquity   types.Money
+
+	PL     types.Money // realized P/L of Trades
+	Trades []TradeRecord
+}
+
+// EquityChange is EndEquity - StartEquity.
+func (d Day) EquityChange() types.Money {
+	return d.EndEquity - d.StartEquity
+}
+
+// BuildDay collects the trades closed on the calendar day containing date
+// in date's location, in close order, and the primary account's equity
+// around it from equity. Sim sub-account snapshots are ignored.
+func BuildDay(date time.Time, trades []TradeRecord, equity []EquitySnapshot) Day {
+	y, m, dd := date.Date()
+	start := time.Date(y, m, dd, 0, 0, 0, 0, date.Location())
+	return buildDay(start, start, start.AddDate(0, 0, 1), trades, equity)
+}
+
+// BuildRolloverDay is BuildDay for the broker's trading day named by
+// date's calendar date, which runs rollover to rollover (see
+// types.Rollover.Day): under a 17:00 New York rollover, 2024-03-15 holds
+// the trades closed from 17:00 on the 14th until 17:00 on the 15th.
+func BuildRolloverDay(date time.Time, r types.Rollover, trades []TradeRecord, equity []EquitySnapshot) Day {
+	y, m, dd := date.Date()
+	start, end := r.Day(date)
+	return buildDay(time.Date(y, m, dd, 0, 0, 0, 0, date.Location()), start, end, trades, equity)
+}
+
+func buildDay(date, start, end time.Time, trades []TradeRecord, equity []EquitySnapshot) Day {
+	from, to := types.FromTime(start), types.FromTime(end)
+
+	d := Day{Date: date, Start: start, End: end}
+	for _, t := range trades {
+		if t.CloseTime >= from && t.CloseTime < to {
+			d.Trades = append(d.Trades, t)
+			d.PL += t.RealizedPL
+		}
+	}
+	sort.SliceStable(d.Trades, func(i, j int) bool { return d.Trades[i].CloseTime < d.Trades[j].CloseTime })
+
+	snaps := make([]EquitySnapshot, 0, len(equity))
+	for _, e := range equity {
+		if e.AccountID == "" && e.Timestamp < to {
+			snaps = append(snaps, e)
+		}
+	}
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].Timestamp < snaps[j].Timestamp })
+	if len(snaps) == 0 {
+		return d
+	}
+	d.HasEquity = true
+	d.StartEquity = snaps[0].Equity
+	for _, e := range snaps {
+		if e.Timestamp >= from {
+			break
+		}
+		d.StartEquity = e.Equity
+	}
+	d.EndEquity = snaps[len(snaps)-1].Equity
+	return d
+}
+
+// FormatDayOrg renders d as a top-level Org heading whose PROPERTIES
+// drawer carries the day's equity and P/L, with every trade as a
+// FormatTradeOrg subheading, ready to append to an org journal.
+func FormatDayOrg(d Day) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "* %s %s\n", d.Date.Format("2006-01-02"), d.Date.Format("Monday"))
+	b.WriteString(":PROPERTIES:\n")
+	writeOrgProperty(&b, "DATE", d.Date.Format("2006-01-02"))
+	if !d.Start.IsZero() && !d.Start.Equal(d.Date) {
+		writeOrgProperty(&b, "DAY_START", d.Start.UTC().Format(time.RFC3339))
+		writeOrgProperty(&b, "DAY_END", d.End.UTC().Format(time.RFC3339))
+	}
+	if d.HasEquity {
+		writeOrgProperty(&b, "START_EQUITY", fmt.Sprintf("%.2f", d.StartEquity.Float64()))
+		writeOrgProperty(&b, "END_EQUITY", fmt.Sprintf("%.2f", d.EndEquity.Float64()))
+		writeOrgProperty(&b, "EQUITY_CHANGE", fmt.Sprintf("%+.2f", d.EquityChange().Float64()))
+	}
+	writeOrgProperty(&b, "DAILY_PL", fmt.Sprintf("%+.2f", d.PL.Float64()))
+	writeOrgProperty(&b, "TRADES", fmt.Sprintf("%d", len(d.Trades)))
+	var wins int
+	for _, t := range d.Trades {
+		if t.RealizedPL > 0 {
+			wins++
+		}
+	}
+	writeOrgProperty(&b, "WINS", fmt.Sprintf("%d", wins))
+	b.WriteString(":END:\n")
+	if len(d.Trades) == 0 {
+		b.WriteString("No trades closed.\n")
+		return b.String()
+	}
+	b.WriteString("\n")
+	b.WriteString(FormatTradesOrg(d.Trades))
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package journal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDayAndFormatDayOrg(t *testing.T) {
+	t.Parallel()
+
+	at := func(day, hour int) types.Timestamp {
+		return types.FromTime(time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC))
+	}
+	trades := []TradeRecord{
+		{TradeID: "late", Instrument: "EURUSD", CloseTime: at(15, 20), RealizedPL: types.MoneyFromFloat(-40)},
+		{TradeID: "early", Instrument: "EURUSD", CloseTime: at(15, 9), RealizedPL: types.MoneyFromFloat(120)},
+		{TradeID: "prev", Instrument: "EURUSD", CloseTime: at(14, 22), RealizedPL: types.MoneyFromFloat(10)},
+		{TradeID: "next", Instrument: "EURUSD", CloseTime: at(16, 1), RealizedPL: types.MoneyFromFloat(10)},
+	}
+	equity := []EquitySnapshot{
+		{Timestamp: at(14, 23), Equity: types.MoneyFromFloat(10_000)},
+		{Timestamp: at(15, 12), Equity: types.MoneyFromFloat(10_120)},
+		{Timestamp: at(15, 12), AccountID: "sub", Equity: types.MoneyFromFloat(1)},
+		{Timestamp: at(15, 21), Equity: types.MoneyFromFloat(10_075)},
+		{Timestamp: at(16, 2), Equity: types.MoneyFromFloat(99_999)},
+	}
+
+	d := BuildDay(time.Date(2024, 3, 15, 13, 0, 0, 0, time.UTC), trades, equity)
+	require.Len(t, d.Trades, 2)
+	assert.Equal(t, "early", d.Trades[0].TradeID)
+	assert.Equal(t, "late", d.Trades[1].TradeID)
+	assert.Equal(t, types.MoneyFromFloat(80), d.PL)
+	require.True(t, d.HasEquity)
+	assert.Equal(t, types.MoneyFromFloat(10_000), d.StartEquity)
+	assert.Equal(t, types.MoneyFromFloat(10_075), d.EndEquity)
+
+	out := FormatDayOrg(d)
+	assert.True(t, strings.HasPrefix(out, "* 2024-03-15 Friday\n:PROPERTIES:\n:DATE: 2024-03-15\n"), out)
+	assert.Contains(t, out, ":START_EQUITY: 10000.00\n:END_EQUITY: 10075.00\n:EQUITY_CHANGE: +75.00\n")
+	assert.Contains(t, out, ":DAILY_PL: +80.00\n:TRADES: 2\n:WINS: 1\n:END:\n\n** Trade: EURUSD (early)")
+	assert.Contains(t, out, "** Trade: EURUSD (late)")
+}
+
+func TestBuildRolloverDay(t *testing.T) {
+	t.Parallel()
+
+	at := func(day, hour int) types.Timestamp {
+		return types.FromTime(time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC))
+	}
+	trades := []TradeRecord{
+		{TradeID: "prev", CloseTime: at(14, 20), RealizedPL: types.MoneyFromFloat(5)},
+		{TradeID: "evening", CloseTime: at(14, 22), RealizedPL: types.MoneyFromFloat(10)},
+		{TradeID: "day", CloseTime: at(15, 20), RealizedPL: types.MoneyFromFloat(-40)},
+		{TradeID: "rolled", CloseTime: at(15, 21), RealizedPL: types.MoneyFromFloat(7)},
+	}
+	equity := []EquitySnapshot{
+		{Timestamp: at(14, 12), Equity: types.MoneyFromFloat(10_000)},
+		{Timestamp: at(14, 23), Equity: types.MoneyFromFloat(10_010)},
+		{Timestamp: at(15, 21), Equity: types.MoneyFromFloat(99_999)},
+	}
+
+	// 17:00 New York is 21:00 UTC in mid-March (EDT): the 15th's trading
+	// day opens on the evening of the 14th.
+	d := BuildRolloverDay(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), types.DefaultRollover, trades, equity)
+	require.Len(t, d.Trades, 2)
+	assert.Equal(t, "evening", d.Trades[0].TradeID)
+	assert.Equal(t, "day", d.Trades[1].TradeID)
+	assert.Equal(t, types.MoneyFromFloat(-30), d.PL)
+	assert.Equal(t, types.MoneyFromFloat(10_000), d.StartEquity)
+	assert.Equal(t, types.MoneyFromFloat(10_010), d.EndEquity)
+
+	out := FormatDayOrg(d)
+	assert.Contains(t, out, ":DATE: 2024-03-15\n:DAY_START: 2024-03-14T21:00:00Z\n:DAY_END: 2024-03-15T21:00:00Z\n")
+}
+
+func TestBuildDay_Timezone(t *testing.T) {
+	t.Parallel()
+
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// 02:00 UTC on the 16th is still the 15th in New York.
+	tr := TradeRecord{TradeID: "T1", CloseTime: types.FromTime(time.Date(2024, 3, 16, 2, 0, 0, 0, time.UTC))}
+	d := BuildDay(time.Date(2024, 3, 15, 0, 0, 0, 0, ny), []TradeRecord{tr}, nil)
+	assert.Len(t, d.Trades, 1)
+	assert.False(t, d.HasEquity)
+
+	out := FormatDayOrg(BuildDay(time.Date(2024, 3, 17, 0, 0, 0, 0, ny), []TradeRecord{tr}, nil))
+	assert.NotContains(t, out, "START_EQUITY")
+	assert.Contains(t, out, ":DAILY_PL: +0.00\n:TRADES: 0\n")
+	assert.Contains(t, out, "No trades closed.\n")
+}
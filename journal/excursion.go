@@ -0,0 +1,136 @@
+package journal
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// ExcursionPoint is one closed trade's outcome and excursions expressed in
+// units of its initial risk (R), all RateScale-scaled. MAER of 0.4R means
+// the trade went 40% of the way to its initial stop before closing.
+type ExcursionPoint struct {
+	TradeID    string
+	Instrument string
+	R          types.Rate // realized R multiple
+	MAER       types.Rate // MAE / initial stop distance
+	MFER       types.Rate // MFE / initial stop distance
+}
+
+// ExcursionPoints converts trades to ExcursionPoints, in input order.
+// Trades without an initial stop or risk (live fills, older journals) have
+// no R scale and are left out; the second result counts them.
+func ExcursionPoints(trades []TradeRecord) ([]ExcursionPoint, int) {
+	var points []ExcursionPoint
+	skipped := 0
+	for _, t := range trades {
+		dist := int64(t.EntryPrice) - int64(t.InitialStop)
+		if dist < 0 {
+			dist = -dist
+		}
+		if t.InitialStop <= 0 || t.InitialRisk <= 0 || dist == 0 {
+			skipped++
+			continue
+		}
+		points = append(points, ExcursionPoint{
+			TradeID:    t.TradeID,
+			Instrument: t.Instrument,
+			R:          t.RMultiple,
+			MAER:       inRisk(t.MAE, dist),
+			MFER:       inRisk(t.MFE, dist),
+		})
+	}
+	return points, skipped
+}
+
+func inRisk(move types.Price, dist int64) types.Rate {
+	r, err := types.MulDivFloor64(int64(move), int64(types.RateScale), dist)
+	if err != nil {
+		return 0
+	}
+	return types.Rate(r)
+}
+
+// ExcursionPercentiles are nearest-rank percentiles of an excursion, in R.
+type ExcursionPercentiles struct {
+	P50 types.Rate
+	P75 types.Rate
+	P90 types.Rate
+	Max types.Rate
+}
+
+// ExcursionStats summarizes ExcursionPoints for stop placement tuning:
+// how far winners went against the trade (a stop tighter than their MAE
+// would have cut them) and how far losers went in favor first.
+type ExcursionStats struct {
+	Trades  int
+	Winners int
+	Losers  int
+	AvgR    types.Rate
+
+	WinnerMAE ExcursionPercentiles
+	LoserMFE  ExcursionPercentiles
+}
+
+// SummarizeExcursions computes ExcursionStats. A trade at exactly 0R
+// counts toward Trades only.
+func SummarizeExcursions(points []ExcursionPoint) ExcursionStats {
+	st := ExcursionStats{Trades: len(points)}
+	var winnerMAE, loserMFE []types.Rate
+	var sumR int64
+	for _, p := range points {
+		sumR += int64(p.R)
+		switch {
+		case p.R > 0:
+			st.Winners++
+			winnerMAE = append(winnerMAE, p.MAER)
+		case p.R < 0:
+			st.Losers++
+			loserMFE = append(loserMFE, p.MFER)
+		}
+	}
+	if st.Trades > 0 {
+		st.AvgR = types.Rate(sumR / int64(st.Trades))
+	}
+	st.WinnerMAE = excursionPercentiles(winnerMAE)
+	st.LoserMFE = excursionPercentiles(loserMFE)
+	return st
+}
+
+func excursionPercentiles(vals []types.Rate) ExcursionPercentiles {
+	if len(vals) == 0 {
+		return ExcursionPercentiles{}
+	}
+	sort.Slice(vals, func(i, j int) bool { return vals[i] < vals[j] })
+	rank := func(pct int) types.Rate {
+		// Nearest rank: the smallest value with at least pct% of the
+		// sample at or below it.
+		i := (pct*len(vals)+99)/100 - 1
+		return vals[max(i, 0)]
+	}
+	return ExcursionPercentiles{
+		P50: rank(50),
+		P75: rank(75),
+		P90: rank(90),
+		Max: vals[len(vals)-1],
+	}
+}
+
+var excursionCSVHeader = []string{"trade_id", "instrument", "r_multiple", "mae_r", "mfe_r"}
+
+// WriteExcursionCSV writes points as a scatter-ready CSV, one row per trade.
+func WriteExcursionCSV(w io.Writer, points []ExcursionPoint) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(excursionCSVHeader); err != nil {
+		return err
+	}
+	for _, p := range points {
+		if err := cw.Write([]string{p.TradeID, p.Instrument, p.R.String(), p.MAER.String(), p.MFER.String()}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
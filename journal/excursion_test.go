@@ -0,0 +1,91 @@
+package journal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// excursionTrade is a 20-pip-stop trade entered at 1.10000.
+func excursionTrade(id string, r, mae, mfe float64) TradeRecord {
+	return TradeRecord{
+		TradeID:     id,
+		Instrument:  "EURUSD",
+		EntryPrice:  types.PriceFromFloat(1.10000),
+		InitialStop: types.PriceFromFloat(1.09800),
+		InitialRisk: types.MoneyFromFloat(20),
+		RMultiple:   types.RateFromFloat(r),
+		MAE:         types.PriceFromFloat(mae),
+		MFE:         types.PriceFromFloat(mfe),
+	}
+}
+
+func TestExcursionPoints(t *testing.T) {
+	t.Parallel()
+
+	trades := []TradeRecord{
+		excursionTrade("a", 1.5, 0.0008, 0.0035),
+		{TradeID: "live", EntryPrice: types.PriceFromFloat(1.1)},
+		excursionTrade("b", -1, 0.0020, 0.0004),
+	}
+	points, skipped := ExcursionPoints(trades)
+	assert.Equal(t, 1, skipped)
+	require.Len(t, points, 2)
+	assert.Equal(t, ExcursionPoint{
+		TradeID:    "a",
+		Instrument: "EURUSD",
+		R:          types.RateFromFloat(1.5),
+		MAER:       types.RateFromFloat(0.4),
+		MFER:       types.RateFromFloat(1.75),
+	}, points[0])
+	assert.Equal(t, types.RateFromFloat(1), points[1].MAER)
+	assert.Equal(t, types.RateFromFloat(0.2), points[1].MFER)
+}
+
+func TestSummarizeExcursions(t *testing.T) {
+	t.Parallel()
+
+	var trades []TradeRecord
+	// Ten winners with MAE 0.1R..1.0R, two losers, one scratch.
+	for i := 1; i <= 10; i++ {
+		trades = append(trades, excursionTrade("w", 2, 0.0002*float64(i), 0.004))
+	}
+	trades = append(trades,
+		excursionTrade("l1", -1, 0.002, 0.0006),
+		excursionTrade("l2", -1, 0.002, 0.0010),
+		excursionTrade("s", 0, 0.001, 0.001),
+	)
+	points, _ := ExcursionPoints(trades)
+	st := SummarizeExcursions(points)
+
+	assert.Equal(t, 13, st.Trades)
+	assert.Equal(t, 10, st.Winners)
+	assert.Equal(t, 2, st.Losers)
+	assert.Equal(t, types.RateFromFloat(18.0/13), st.AvgR)
+	assert.Equal(t, ExcursionPercentiles{
+		P50: types.RateFromFloat(0.5),
+		P75: types.RateFromFloat(0.8),
+		P90: types.RateFromFloat(0.9),
+		Max: types.RateFromFloat(1.0),
+	}, st.WinnerMAE)
+	assert.Equal(t, types.RateFromFloat(0.3), st.LoserMFE.P50)
+	assert.Equal(t, types.RateFromFloat(0.5), st.LoserMFE.Max)
+}
+
+func TestSummarizeExcursions_Empty(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ExcursionStats{}, SummarizeExcursions(nil))
+}
+
+func TestWriteExcursionCSV(t *testing.T) {
+	t.Parallel()
+
+	points, _ := ExcursionPoints([]TradeRecord{excursionTrade("a", 1.5, 0.0008, 0.0035)})
+	var buf bytes.Buffer
+	require.NoError(t, WriteExcursionCSV(&buf, points))
+	assert.Equal(t, "trade_id,instrument,r_multiple,mae_r,mfe_r\na,EURUSD,1.500000,0.400000,1.750000\n", buf.String())
+}
@@ -0,0 +1,136 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+)
+
+// OANDARunID is the RunID of trades imported from OANDA transaction
+// history, setting real trading apart from backtest runs in one journal.
+const OANDARunID = "live-oanda"
+
+// oandaPageSize is the most transactions OANDA returns per sinceid call.
+const oandaPageSize = 1000
+
+// TransactionHistory is the part of the OANDA client ImportOANDA reads
+// from; *oanda.Client satisfies it.
+type TransactionHistory interface {
+	GetTransactions(ctx context.Context, accountID string, sinceID int64) ([]oanda.Transaction, int64, error)
+}
+
+// ImportOANDA reads accountID's transactions after sinceID (0 for the
+// whole history) and returns the trades they closed, tagged with
+// OANDARunID, along with the account's last transaction ID to import from
+// next time. Fills are paired exactly as LiveJournal pairs streamed ones;
+// a close whose open came before sinceID is kept with what the close
+// itself carries, and trades still open are left out.
+func ImportOANDA(ctx context.Context, client TransactionHistory, accountID string, sinceID int64, log *slog.Logger) ([]TradeRecord, int64, error) {
+	var collected tradeCollector
+	lj := NewLiveJournal(historyOnly{client}, accountID, &collected, log)
+	lj.runID = OANDARunID
+
+	lastID := sinceID
+	for {
+		txns, last, err := client.GetTransactions(ctx, accountID, sinceID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("import oanda transactions: %w", err)
+		}
+		for _, tx := range txns {
+			lj.handleTransaction(tx)
+		}
+		lastID = max(lastID, last)
+		if len(txns) < oandaPageSize {
+			return collected.trades, lastID, nil
+		}
+		sinceID = parseTxID(txns[len(txns)-1].ID)
+	}
+}
+
+// AppendNewTrades appends to the trades journal at path (JSONL, or CSV for
+// a .csv path) each trade it does not already hold, matching on trade ID
+// and close time so partial closes of one trade are each kept. It returns
+// how many it wrote. A missing journal is created.
+func AppendNewTrades(path string, trades []TradeRecord) (int, error) {
+	existing, err := ReadTrades(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("read journal %s: %w", path, err)
+	}
+	type key struct {
+		id    string
+		close int64
+	}
+	seen := make(map[key]bool, len(existing))
+	for _, t := range existing {
+		seen[key{t.TradeID, t.CloseTime.Int64()}] = true
+	}
+	var fresh []TradeRecord
+	for _, t := range trades {
+		k := key{t.TradeID, t.CloseTime.Int64()}
+		if !seen[k] {
+			seen[k] = true
+			fresh = append(fresh, t)
+		}
+	}
+	if len(fresh) == 0 {
+		return 0, nil
+	}
+
+	if isCSVPath(path) {
+		f, w, err := openCSVJournalFile(path, tradeCSVHeader)
+		if err != nil {
+			return 0, err
+		}
+		j := &csvJournal{tradeWriter: w, tradesFile: f}
+		for _, t := range fresh {
+			if err := j.RecordTrade(t); err != nil {
+				_ = f.Close()
+				return 0, err
+			}
+		}
+		return len(fresh), f.Close()
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	for _, t := range fresh {
+		if err := enc.Encode(t); err != nil {
+			_ = f.Close()
+			return 0, err
+		}
+	}
+	return len(fresh), f.Close()
+}
+
+// tradeCollector is a Journal that keeps the trades recorded to it.
+type tradeCollector struct {
+	trades []TradeRecord
+}
+
+func (c *tradeCollector) RecordTrade(t TradeRecord) error {
+	c.trades = append(c.trades, t)
+	return nil
+}
+
+func (c *tradeCollector) RecordEquity(EquitySnapshot) error { return nil }
+func (c *tradeCollector) Close() error                      { return nil }
+
+// historyOnly adapts a TransactionHistory to the client LiveJournal
+// takes; ImportOANDA never streams.
+type historyOnly struct {
+	TransactionHistory
+}
+
+func (historyOnly) StreamTransactions(context.Context, string, oanda.StreamOptions) (<-chan oanda.TxEvent, error) {
+	return nil, errors.New("journal: transaction history has no stream")
+}
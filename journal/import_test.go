@@ -0,0 +1,93 @@
+package journal
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/types"
+)
+
+// fakeHistory serves txns through sinceid pages of oandaPageSize, like
+// OANDA: every transaction after the requested ID, and the account's last
+// transaction ID.
+type fakeHistory struct {
+	txns  []oanda.Transaction
+	calls []int64
+}
+
+func (h *fakeHistory) GetTransactions(_ context.Context, _ string, sinceID int64) ([]oanda.Transaction, int64, error) {
+	h.calls = append(h.calls, sinceID)
+	var page []oanda.Transaction
+	for _, tx := range h.txns {
+		if parseTxID(tx.ID) > sinceID && len(page) < oandaPageSize {
+			page = append(page, tx)
+		}
+	}
+	return page, parseTxID(h.txns[len(h.txns)-1].ID), nil
+}
+
+func TestImportOANDA(t *testing.T) {
+	open := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	h := &fakeHistory{}
+	add := func(tx oanda.Transaction) {
+		tx.ID = strconv.Itoa(len(h.txns) + 1)
+		h.txns = append(h.txns, tx)
+	}
+	add(oanda.Transaction{Type: "ORDER_FILL", TradeID: "T1", Instrument: "EUR_USD", Units: 1000, Price: 1.07, Time: open})
+	// Enough other transactions that the close lands on the second page.
+	for i := 0; i < oandaPageSize; i++ {
+		add(oanda.Transaction{Type: "DAILY_FINANCING"})
+	}
+	add(oanda.Transaction{
+		Type: "ORDER_FILL", Instrument: "EUR_USD", Time: open.Add(time.Hour), Reason: "TAKE_PROFIT_ORDER",
+		TradesClosed: []oanda.ClosedTrade{{TradeID: "T1", Units: -1000, Price: 1.075, RealizedPL: 5}},
+	})
+	add(oanda.Transaction{Type: "ORDER_FILL", TradeID: "T2", Instrument: "EUR_USD", Units: -1000, Price: 1.08, Time: open})
+
+	trades, lastID, err := ImportOANDA(context.Background(), h, "acct", 0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, oandaPageSize}, h.calls, "pages from the last transaction of the previous page")
+	assert.Equal(t, int64(oandaPageSize+3), lastID)
+
+	require.Len(t, trades, 1, "T2 is still open")
+	tr := trades[0]
+	assert.Equal(t, "T1", tr.TradeID)
+	assert.Equal(t, OANDARunID, tr.RunID)
+	assert.Equal(t, types.PriceFromFloat(1.07), tr.EntryPrice, "paired with its open on the first page")
+	assert.Equal(t, types.PriceFromFloat(1.075), tr.ExitPrice)
+	assert.Equal(t, types.MoneyFromFloat(5), tr.RealizedPL)
+	assert.Equal(t, "TAKE_PROFIT_ORDER", tr.Reason)
+}
+
+func TestAppendNewTrades(t *testing.T) {
+	for _, name := range []string{"live-trades.jsonl", "live-trades.csv"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			first := []TradeRecord{
+				{TradeID: "T1", Instrument: "EURUSD", CloseTime: 100, RealizedPL: types.MoneyFromFloat(5)},
+				{TradeID: "T1", Instrument: "EURUSD", CloseTime: 200, RealizedPL: types.MoneyFromFloat(1)},
+			}
+			n, err := AppendNewTrades(path, first)
+			require.NoError(t, err)
+			assert.Equal(t, 2, n, "partial closes of one trade are both kept")
+
+			n, err = AppendNewTrades(path, append(first, TradeRecord{TradeID: "T2", Instrument: "USDJPY", CloseTime: 300}))
+			require.NoError(t, err)
+			assert.Equal(t, 1, n, "only the new trade")
+
+			got, err := ReadTrades(path)
+			require.NoError(t, err)
+			require.Len(t, got, 3)
+			assert.Equal(t, "T2", got[2].TradeID)
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package journal
 
-import "github.com/rustyeddy/trader/types"
+import (
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
 
 // TradeRecord is the canonical persisted representation of a completed trade.
 // It is shared by live journaling, replay/sim journaling, and export formats
@@ -8,6 +11,8 @@ import "github.com/rustyeddy/trader/types"
 type TradeRecord struct {
 	TradeID    string
 	BotID      string // set by the bot manager; empty for backtest/journal-only runs
+	RunID      string `json:",omitempty"` // run the trade came from, e.g. OANDARunID for imported history
+	AccountID  string // sim sub-account that held the trade; empty for the primary account
 	Instrument string
 	Units      types.Units
 	EntryPrice types.Price
@@ -16,12 +21,53 @@ type TradeRecord struct {
 	CloseTime  types.Timestamp
 	RealizedPL types.Money
 	Reason     string
+	VolRegime  string `json:",omitempty"` // volatility regime at entry: low, normal or high; empty when unknown
+
+	// InitialStop is the stop the trade opened with, before any trailing.
+	// InitialRisk is the loss the trade's initial stop would have
+	// realized; RMultiple is RealizedPL / InitialRisk, RateScale-scaled.
+	// Both are zero when the trade had no initial stop (e.g. live fills).
+	InitialStop types.Price
+	InitialRisk types.Money
+	RMultiple   types.Rate
+	// MAE and MFE are the maximum adverse and favorable excursions from
+	// EntryPrice while the trade was open, as non-negative price distances.
+	MAE types.Price
+	MFE types.Price
+
+	// QuotePL is the P/L in QuoteCurrency, the instrument's quote currency;
+	// ConversionRate is the quote-to-account rate (RateScale-scaled) it
+	// was converted at when the trade closed, into AccountCurrency. All
+	// three are zero/empty when the writer did not know them.
+	QuoteCurrency   string
+	AccountCurrency string
+	QuotePL         types.Money
+	ConversionRate  types.Rate
+
+	// Notes and Rating are attached from the annotations sidecar by
+	// Annotate; writers leave them empty.
+	Notes  []Annotation `json:",omitempty"`
+	Rating int          `json:",omitempty"` // latest review rating, 1..MaxRating; 0 when unrated
+}
+
+// RoundPrices returns t with its prices — entry, exit, initial stop and
+// the MAE/MFE distances — rounded to the instrument's display precision,
+// the form the file journals persist them in.
+func (t TradeRecord) RoundPrices() TradeRecord {
+	inst := market.GetInstrument(t.Instrument)
+	t.EntryPrice = inst.RoundPrice(t.EntryPrice)
+	t.ExitPrice = inst.RoundPrice(t.ExitPrice)
+	t.InitialStop = inst.RoundPrice(t.InitialStop)
+	t.MAE = inst.RoundPrice(t.MAE)
+	t.MFE = inst.RoundPrice(t.MFE)
+	return t
 }
 
 // EquitySnapshot captures account state at a point in time for journal backends
 // that persist balance/equity history alongside completed trades.
 type EquitySnapshot struct {
 	Timestamp   types.Timestamp
+	AccountID   string // sim sub-account the figures belong to; empty for the primary account
 	Balance     types.Money
 	Equity      types.Money
 	MarginUsed  types.Money
@@ -29,6 +75,109 @@ type EquitySnapshot struct {
 	MarginLevel types.Money
 }
 
+// OrderEvent records one step of an order's lifecycle: its submission, a
+// partial fill of a market order working against a finite book, and how it
+// ended — filled, cancelled or expired.
+type OrderEvent struct {
+	Type       string // one of the Order* event types below
+	OrderID    string
+	TradeID    string // OrderFilled and OrderPartialFill: the trade the fill opened
+	AccountID  string // sim sub-account that placed the order; empty for the primary account
+	Instrument string
+	Units      int64       // signed: positive buy, negative sell; the units filled for OrderPartialFill
+	Price      types.Price // limit price (0 for market orders); the fill price for OrderFilled and OrderPartialFill
+	Time       types.Timestamp
+	Reason     string
+	Remaining  int64 // OrderPartialFill: signed units still to fill
+}
+
+// OrderEvent types.
+const (
+	OrderSubmitted   = "OrderSubmitted"
+	OrderFilled      = "OrderFilled"
+	OrderExpired     = "OrderExpired"
+	OrderCancelled   = "OrderCancelled"
+	OrderPartialFill = "OrderPartialFill"
+)
+
+// SkippedSignal records an entry signal that was not acted on because a
+// trade-frequency governor (daily cap, spacing, loss cooldown) or a paused
+// equity-curve throttle refused it.
+type SkippedSignal struct {
+	Time       types.Timestamp
+	AccountID  string // sim sub-account the entry was for; empty for the primary account
+	Instrument string
+	Side       string // "long" or "short"
+	Rule       string // governor rule that refused the entry, e.g. "max-trades-per-day"
+	Reason     string // the strategy's Signal.Reason
+}
+
+// SignalRecord records one entry signal and what became of it: taken, or
+// suppressed by one of the strategy's filters (session, ADX, cooldown) or
+// by the order path (regime, governor, sizing). Either way MaxR and
+// Stopped follow the price after the signal, so the signals a filter
+// suppressed can be weighed against the ones taken.
+type SignalRecord struct {
+	Time       types.Timestamp
+	AccountID  string // sim sub-account the entry was for; empty for the primary account
+	Instrument string
+	Side       string      // "long" or "short"
+	Price      types.Price // close of the bar the signal fired on
+	Stop       types.Price // the entry's initial stop; 0 when it had none
+	Taken      bool
+	Gate       string // what suppressed it: SignalGateFilter or SignalGateOrder; empty when taken
+	Rule       string // the filter's name, or the OrderDecision's Reject* code
+	Detail     string // the rejection's detail, e.g. the governor rule
+	Reason     string // the strategy's Signal.Reason
+
+	// MaxR is the best move in the signal's favour after its bar, in
+	// multiples of the stop distance, until the stop was reached (Stopped)
+	// or the run ended. Zero without a stop.
+	MaxR    types.Rate
+	Stopped bool
+}
+
+// SignalRecord gates.
+const (
+	SignalGateFilter = "filter" // a strategy filter blocked the signal
+	SignalGateOrder  = "order"  // the order path rejected its entry
+)
+
+// OrderDecision records what the order path did with one entry request:
+// accepted (with the units it was sized to) or rejected with a reason code.
+// Parameter sweeps count rejections by Reason.
+type OrderDecision struct {
+	Time           types.Timestamp
+	AccountID      string // sim sub-account the entry was for; empty for the primary account
+	Instrument     string
+	Side           string // "long" or "short"
+	Accepted       bool
+	Reason         string      // one of the Reject* codes; empty when accepted
+	Detail         string      // underlying error text, for sizing rejections
+	RequestedUnits types.Units // units the strategy asked for; 0 means size by risk
+	Units          types.Units // units submitted; 0 when rejected
+}
+
+// OrderDecision rejection reasons.
+const (
+	RejectRegime       = "regime"        // regime filter reports no trend
+	RejectRegimeSide   = "regime-side"   // regime filter disallows this side
+	RejectMaxSpread    = "max-spread"    // candle spread above the configured maximum
+	RejectInvalidStop  = "invalid-stop"  // stop missing, equal to, or on the wrong side of entry
+	RejectRiskBudget   = "risk-budget"   // risk budget too small for the stop distance
+	RejectMargin       = "margin"        // not enough free margin
+	RejectMinimumSize  = "minimum-size"  // sized below the instrument's minimum trade size
+	RejectGovernor     = "governor"      // trade-frequency governor refused the entry
+	RejectWarmup       = "htf-warmup"    // higher-timeframe feed not warm yet
+	RejectHook         = "hook"          // a backtest BeforeOrder hook dropped the entry
+	RejectMarketClosed = "market-closed" // the instrument's market was closed and entries are not queued
+	RejectEquityCurve  = "equity-curve"  // the equity-curve throttle paused entries
+	RejectSpreadGuard  = "spread-guard"  // spread above the instrument's cap or its recent average; Detail names the rule
+	RejectStopDistance = "stop-distance" // stop or take nearer the market than the broker's minimum
+	RejectRateLimit    = "rate-limit"    // an alert webhook came in over its rate limit
+	RejectBroker       = "broker"        // the broker refused the order; Detail has its error
+)
+
 // Journal is the storage contract used by live trading and replay code to
 // persist completed trades and optional equity snapshots.
 type Journal interface {
@@ -36,3 +185,28 @@ type Journal interface {
 	RecordEquity(EquitySnapshot) error
 	Close() error
 }
+
+// OrderJournal is implemented by journals that also persist order
+// lifecycle events. It is optional: writers type-assert for it so trade-only
+// backends need no changes.
+type OrderJournal interface {
+	RecordOrderEvent(OrderEvent) error
+}
+
+// SignalJournal is implemented by journals that also persist skipped entry
+// signals. Like OrderJournal it is optional.
+type SignalJournal interface {
+	RecordSkippedSignal(SkippedSignal) error
+}
+
+// SignalRecordJournal is implemented by journals that also persist every
+// entry signal with its filter decision. Like OrderJournal it is optional.
+type SignalRecordJournal interface {
+	RecordSignal(SignalRecord) error
+}
+
+// DecisionJournal is implemented by journals that also persist order
+// decisions. Like OrderJournal it is optional.
+type DecisionJournal interface {
+	RecordOrderDecision(OrderDecision) error
+}
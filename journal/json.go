@@ -3,6 +3,7 @@ package journal
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,15 +14,32 @@ type jsonJournal struct {
 	equity *json.Encoder
 	tf     *os.File
 	ef     *os.File
+
+	// Sidecar files for the optional record kinds, opened with flag on
+	// their first record next to tradesPath (see sidecarPath).
+	tradesPath string
+	flag       int
+	sidecars   map[string]*jsonSidecar
+}
+
+type jsonSidecar struct {
+	f   *os.File
+	enc *json.Encoder
 }
 
 func NewJSON(tradesPath, equityPath string) (*jsonJournal, error) {
-	tf, err := os.Create(tradesPath)
+	return openJSON(tradesPath, equityPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
+}
+
+// openJSON opens both JSONL files with flag; NewJSON truncates them, a
+// RotatingJournal appends.
+func openJSON(tradesPath, equityPath string, flag int) (*jsonJournal, error) {
+	tf, err := os.OpenFile(tradesPath, flag, 0o666)
 	if err != nil {
 		return nil, err
 	}
 
-	ef, err := os.Create(equityPath)
+	ef, err := os.OpenFile(equityPath, flag, 0o666)
 	if err != nil {
 		_ = tf.Close()
 		return nil, err
@@ -33,41 +51,86 @@ func NewJSON(tradesPath, equityPath string) (*jsonJournal, error) {
 	eenc.SetEscapeHTML(false)
 
 	return &jsonJournal{
-		trades: tenc,
-		equity: eenc,
-		tf:     tf,
-		ef:     ef,
+		trades:     tenc,
+		equity:     eenc,
+		tf:         tf,
+		ef:         ef,
+		tradesPath: tradesPath,
+		flag:       flag,
 	}, nil
 }
 
 func (j *jsonJournal) RecordTrade(t TradeRecord) error {
-	return j.trades.Encode(t)
+	return j.trades.Encode(t.RoundPrices())
 }
 
 func (j *jsonJournal) RecordEquity(e EquitySnapshot) error {
 	return j.equity.Encode(e)
 }
 
-func (j *jsonJournal) Close() error {
-	if err := j.tf.Close(); err != nil {
-		return err
+func (j *jsonJournal) RecordOrderEvent(e OrderEvent) error {
+	return j.encodeSidecar(sidecarOrders, e)
+}
+
+func (j *jsonJournal) RecordOrderDecision(d OrderDecision) error {
+	return j.encodeSidecar(sidecarDecisions, d)
+}
+
+func (j *jsonJournal) RecordSkippedSignal(s SkippedSignal) error {
+	return j.encodeSidecar(sidecarSkipped, s)
+}
+
+func (j *jsonJournal) RecordSignal(s SignalRecord) error {
+	return j.encodeSidecar(sidecarSignals, s)
+}
+
+// encodeSidecar appends v to kind's sidecar file, opening it first.
+func (j *jsonJournal) encodeSidecar(kind string, v any) error {
+	sc, ok := j.sidecars[kind]
+	if !ok {
+		f, err := os.OpenFile(sidecarPath(j.tradesPath, kind), j.flag, 0o666)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetEscapeHTML(false)
+		sc = &jsonSidecar{f: f, enc: enc}
+		if j.sidecars == nil {
+			j.sidecars = make(map[string]*jsonSidecar)
+		}
+		j.sidecars[kind] = sc
 	}
-	if err := j.ef.Close(); err != nil {
-		return err
+	return sc.enc.Encode(v)
+}
+
+func (j *jsonJournal) Close() error {
+	errList := []error{j.tf.Close(), j.ef.Close()}
+	for _, sc := range j.sidecars {
+		errList = append(errList, sc.f.Close())
 	}
-	return nil
+	return errors.Join(errList...)
 }
 
 // ReadTradesJSONL reads all TradeRecords from a JSONL file. Malformed/invalid
 // lines are silently skipped (forward-compatible with mixed journal data).
 func ReadTradesJSONL(path string) ([]TradeRecord, error) {
+	return readJSONL[TradeRecord](path)
+}
+
+// ReadEquityJSONL reads all EquitySnapshots from a JSONL file, skipping
+// malformed lines like ReadTradesJSONL.
+func ReadEquityJSONL(path string) ([]EquitySnapshot, error) {
+	return readJSONL[EquitySnapshot](path)
+}
+
+func readJSONL[T any](path string) ([]T, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	var records []TradeRecord
+	var records []T
 	scanner := bufio.NewScanner(f)
 	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
 	for scanner.Scan() {
@@ -75,7 +138,7 @@ func ReadTradesJSONL(path string) ([]TradeRecord, error) {
 		if line == "" {
 			continue
 		}
-		var r TradeRecord
+		var r T
 		if err := json.Unmarshal([]byte(line), &r); err == nil {
 			records = append(records, r)
 		}
@@ -87,6 +150,17 @@ func ReadTradesJSONL(path string) ([]TradeRecord, error) {
 }
 
 func JournalRecordPaths(base string) (tradesPath, equityPath string) {
+	base = journalBase(base)
+	return base + "-trades.jsonl", base + "-equity.jsonl"
+}
+
+// JournalStatePath is where a sim session's state (sim.Sim.SaveState)
+// lives next to the trades and equity files for the same base.
+func JournalStatePath(base string) string {
+	return journalBase(base) + "-state.json"
+}
+
+func journalBase(base string) string {
 	base = strings.TrimSpace(base)
 	if base == "" {
 		base = "./trader-journal"
@@ -100,6 +174,5 @@ func JournalRecordPaths(base string) (tradesPath, equityPath string) {
 	if ext != "" {
 		base = strings.TrimSuffix(base, ext)
 	}
-
-	return base + "-trades.jsonl", base + "-equity.jsonl"
+	return base
 }
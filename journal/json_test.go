@@ -69,6 +69,37 @@ func TestJSONJournalRecordTradeAndEquity(t *testing.T) {
 	assert.Equal(t, equity, gotEquity)
 }
 
+func TestJSONJournalRecordTrade_RoundsPrices(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tradesPath := filepath.Join(dir, "trades.jsonl")
+	j, err := NewJSON(tradesPath, filepath.Join(dir, "equity.jsonl"))
+	require.NoError(t, err)
+
+	trade := TradeRecord{
+		TradeID:     "T1",
+		Instrument:  "USD_JPY",
+		EntryPrice:  types.PriceFromFloat(150.01049),
+		ExitPrice:   types.PriceFromFloat(150.5005),
+		InitialStop: types.PriceFromFloat(149.70000000000002),
+		MAE:         types.PriceFromFloat(0.12345),
+		MFE:         types.PriceFromFloat(0.49001),
+	}
+	require.NoError(t, j.RecordTrade(trade))
+	require.NoError(t, j.Close())
+
+	got, err := ReadTradesJSONL(tradesPath)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, types.PriceFromFloat(150.010), got[0].EntryPrice)
+	assert.Equal(t, types.PriceFromFloat(150.501), got[0].ExitPrice)
+	assert.Equal(t, types.PriceFromFloat(149.700), got[0].InitialStop)
+	assert.Equal(t, types.PriceFromFloat(0.123), got[0].MAE)
+	assert.Equal(t, types.PriceFromFloat(0.490), got[0].MFE)
+	assert.Equal(t, trade.RoundPrices(), got[0])
+}
+
 func TestNewJSONCreateErrors(t *testing.T) {
 	t.Parallel()
 
@@ -102,6 +133,13 @@ func TestJournalRecordPaths(t *testing.T) {
 	}
 }
 
+func TestJournalStatePath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "./trader-journal-state.json", JournalStatePath(""))
+	assert.Equal(t, "./run-state.json", JournalStatePath("./run.db"))
+}
+
 func TestJSONJournalCloseError(t *testing.T) {
 	t.Parallel()
 
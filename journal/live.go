@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -39,6 +40,8 @@ type LiveJournal struct {
 	// botIDLookup is called on each trade close to find which managed bot
 	// opened the trade. Nil means no bot tagging.
 	botIDLookup func(tradeID string) string
+	// runID tags every recorded trade; empty leaves RunID unset.
+	runID string
 
 	mu           sync.Mutex
 	pendingOpens map[string]*pendingOpen // by OANDA tradeID
@@ -200,6 +203,7 @@ func (lj *LiveJournal) recordClose(tx oanda.Transaction, closed oanda.ClosedTrad
 	record := TradeRecord{
 		TradeID:    closed.TradeID,
 		BotID:      botID,
+		RunID:      lj.runID,
 		Instrument: po.Instrument,
 		Units:      po.Units,
 		EntryPrice: po.EntryPrice,
@@ -209,6 +213,9 @@ func (lj *LiveJournal) recordClose(tx oanda.Transaction, closed oanda.ClosedTrad
 		RealizedPL: types.MoneyFromFloat(closed.RealizedPL),
 		Reason:     tx.Reason,
 	}
+	if ok {
+		recordLiveConversion(&record, types.Units(-closed.Units))
+	}
 	if err := lj.journal.RecordTrade(record); err != nil {
 		lj.log.Error("live-journal RecordTrade failed",
 			"trade_id", closed.TradeID,
@@ -231,6 +238,27 @@ func (lj *LiveJournal) recordClose(tx oanda.Transaction, closed oanda.ClosedTrad
 	)
 }
 
+// recordLiveConversion fills record's quote-currency P/L from its prices
+// and the closed units, and backs out the conversion rate OANDA applied
+// from the account-currency RealizedPL it reported.
+func recordLiveConversion(record *TradeRecord, units types.Units) {
+	inst := market.GetInstrument(record.Instrument)
+	if inst == nil || record.EntryPrice <= 0 || record.ExitPrice <= 0 {
+		return
+	}
+	quotePL, err := QuotePL(units, record.EntryPrice, record.ExitPrice)
+	if err != nil || quotePL == 0 {
+		return
+	}
+	rate, err := types.SignedMulDivRound(int64(record.RealizedPL), int64(types.RateScale), int64(quotePL))
+	if err != nil || rate <= 0 {
+		return
+	}
+	record.QuoteCurrency = inst.QuoteCurrency
+	record.QuotePL = quotePL
+	record.ConversionRate = types.Rate(rate)
+}
+
 func (lj *LiveJournal) noteLastSeenTxID(id int64) {
 	lj.mu.Lock()
 	if id > lj.lastSeenTxID {
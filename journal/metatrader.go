@@ -0,0 +1,431 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Run IDs of trades imported from MetaTrader statements.
+const (
+	MT4RunID = "live-mt4"
+	MT5RunID = "live-mt5"
+)
+
+// DefaultLotSize is the units in one standard FX lot, the size
+// MetaTrader statements give volumes in.
+const DefaultLotSize = types.Units(100_000)
+
+// MetaTraderOptions control how a statement's trades are converted.
+type MetaTraderOptions struct {
+	// Location is the trade server's time zone, which statement times are
+	// written in; nil means they are UTC. Times are converted to UTC.
+	Location *time.Location
+	// LotSize is the units per lot; zero means DefaultLotSize.
+	LotSize types.Units
+	// Provider is the symbols provider statement symbols are mapped with;
+	// empty means symbols.MetaTrader.
+	Provider string
+}
+
+// MetaTraderImport is the result of parsing a statement.
+type MetaTraderImport struct {
+	Trades []TradeRecord
+	// Unmapped counts the closed trades skipped per statement symbol that
+	// maps to no known instrument; register those symbols for the
+	// options' Provider to import them.
+	Unmapped map[string]int
+}
+
+// ReadMetaTraderStatement reads the MT4 or MT5 statement at path; see
+// ParseMetaTraderStatement.
+func ReadMetaTraderStatement(path string, opts MetaTraderOptions) (MetaTraderImport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MetaTraderImport{}, err
+	}
+	return ParseMetaTraderStatement(data, opts)
+}
+
+// ParseMetaTraderStatement converts the closed trades of an MT4 or MT5
+// account statement, HTML as the terminal saves it (UTF-8 or UTF-16) or
+// CSV, into TradeRecords: the MT4 "Closed Transactions" table, or the
+// MT5 "Positions" table. Trades still open, pending orders and balance
+// rows are left out.
+//
+// Each trade's ID is its ticket, prefixed "mt4-" or "mt5-", and its RunID
+// is MT4RunID or MT5RunID. Symbols go through symbols.FromProvider with
+// opts.Provider; one that is not a known instrument but starts with one
+// ("EURUSD.m") maps to it. RealizedPL is the net of profit,
+// commission, taxes and swap; the quote conversion fields stay empty.
+func ParseMetaTraderStatement(data []byte, opts MetaTraderOptions) (MetaTraderImport, error) {
+	text := decodeStatementText(data)
+	var rows [][]string
+	if strings.Contains(strings.ToLower(text), "<tr") {
+		rows = htmlTableRows(text)
+	} else {
+		var err error
+		if rows, err = csvStatementRows(text); err != nil {
+			return MetaTraderImport{}, fmt.Errorf("read statement csv: %w", err)
+		}
+	}
+
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.LotSize <= 0 {
+		opts.LotSize = DefaultLotSize
+	}
+	if opts.Provider == "" {
+		opts.Provider = symbols.MetaTrader
+	}
+
+	out := MetaTraderImport{Unmapped: map[string]int{}}
+	var (
+		cols    mtColumns
+		inTable bool
+		found   bool
+	)
+	for _, row := range rows {
+		if c, ok := matchMTHeader(row); ok {
+			cols, inTable, found = c, true, true
+			continue
+		}
+		if nonEmptyCells(row) <= 1 {
+			// A section title ("Open Trades:", "Orders") ends the table.
+			inTable = false
+			continue
+		}
+		if !inTable {
+			continue
+		}
+		rec, sym, ok := cols.trade(row, opts)
+		if !ok {
+			continue
+		}
+		if rec.Instrument == "" {
+			out.Unmapped[sym]++
+			continue
+		}
+		out.Trades = append(out.Trades, rec)
+	}
+	if !found {
+		return MetaTraderImport{}, fmt.Errorf("no MT4 closed transactions or MT5 positions table found")
+	}
+	sort.SliceStable(out.Trades, func(i, j int) bool { return out.Trades[i].CloseTime < out.Trades[j].CloseTime })
+	return out, nil
+}
+
+// mtColumns are the positions of a trade table's columns; -1 is absent.
+type mtColumns struct {
+	ticket, openTime, typ, size, symbol, openPrice, closeTime, closePrice int
+	commission, taxes, swap, profit                                       int
+	idPrefix, runID                                                       string
+}
+
+// matchMTHeader recognizes the header row of an MT4 closed transactions
+// table (Ticket, Open Time, Type, Size, Item, Price, ..., Close Time,
+// Price, Commission, Taxes, Swap, Profit) or an MT5 positions table (Time,
+// Position, Symbol, Type, Volume, Price, ..., Time, Price, Commission,
+// Swap, Profit).
+func matchMTHeader(row []string) (mtColumns, bool) {
+	c := mtColumns{
+		ticket: -1, openTime: -1, typ: -1, size: -1, symbol: -1, openPrice: -1, closeTime: -1, closePrice: -1,
+		commission: -1, taxes: -1, swap: -1, profit: -1,
+	}
+	for i, cell := range row {
+		switch strings.ToLower(strings.TrimSpace(cell)) {
+		case "ticket":
+			c.ticket, c.idPrefix, c.runID = i, "mt4-", MT4RunID
+		case "position":
+			c.ticket, c.idPrefix, c.runID = i, "mt5-", MT5RunID
+		case "open time":
+			c.openTime = i
+		case "close time":
+			c.closeTime = i
+		case "time":
+			if c.openTime < 0 {
+				c.openTime = i
+			} else {
+				c.closeTime = i
+			}
+		case "type":
+			c.typ = i
+		case "size", "volume":
+			c.size = i
+		case "item", "symbol":
+			c.symbol = i
+		case "price":
+			if c.openPrice < 0 {
+				c.openPrice = i
+			} else {
+				c.closePrice = i
+			}
+		case "commission":
+			c.commission = i
+		case "taxes":
+			c.taxes = i
+		case "swap":
+			c.swap = i
+		case "profit":
+			c.profit = i
+		}
+	}
+	for _, i := range []int{c.ticket, c.openTime, c.typ, c.size, c.symbol, c.openPrice, c.closeTime, c.closePrice, c.profit} {
+		if i < 0 {
+			return c, false
+		}
+	}
+	return c, true
+}
+
+// trade converts one table row. ok is false for rows that are not a
+// closed buy or sell: balance rows, totals, cancelled orders, open trades.
+// A symbol that maps to no instrument yields a record without Instrument,
+// and the symbol.
+func (c mtColumns) trade(row []string, opts MetaTraderOptions) (TradeRecord, string, bool) {
+	f := csvFields(row)
+	var side types.Units
+	switch strings.ToLower(f.str(c.typ)) {
+	case "buy":
+		side = 1
+	case "sell":
+		side = -1
+	default:
+		return TradeRecord{}, "", false
+	}
+	ticket := f.str(c.ticket)
+	openTime, err1 := parseMTTime(f.str(c.openTime), opts.Location)
+	closeTime, err2 := parseMTTime(f.str(c.closeTime), opts.Location)
+	lots, err3 := strconv.ParseFloat(mtNumber(f.str(c.size)), 64)
+	if ticket == "" || err1 != nil || err2 != nil || err3 != nil || lots <= 0 {
+		return TradeRecord{}, "", false
+	}
+	sym := f.str(c.symbol)
+	inst := mtInstrument(opts.Provider, sym)
+	if inst == "" {
+		return TradeRecord{}, sym, true
+	}
+
+	// Numbers are parsed like the CSV journal's, with MT5's thousands
+	// spaces removed, so out-of-range values are rejected, not wrapped.
+	num := make(csvFields, len(row))
+	for _, i := range []int{c.openPrice, c.closePrice, c.commission, c.taxes, c.swap, c.profit} {
+		if i >= 0 && i < len(row) {
+			num[i] = mtNumber(row[i])
+		}
+	}
+	var errs []error
+	entry, exit := num.price(c.openPrice, &errs), num.price(c.closePrice, &errs)
+	pl := num.money(c.profit, &errs)
+	for _, i := range []int{c.commission, c.taxes, c.swap} {
+		if i >= 0 {
+			pl += num.money(i, &errs)
+		}
+	}
+	if len(errs) > 0 || entry <= 0 || exit <= 0 {
+		return TradeRecord{}, "", false
+	}
+	return TradeRecord{
+		TradeID:    c.idPrefix + ticket,
+		RunID:      c.runID,
+		Instrument: inst,
+		Units:      side * types.Units(math.Round(lots*float64(opts.LotSize))),
+		EntryPrice: entry,
+		ExitPrice:  exit,
+		OpenTime:   openTime,
+		CloseTime:  closeTime,
+		RealizedPL: pl,
+	}, sym, true
+}
+
+// mtInstrument returns the instrument a statement symbol stands for, or
+// "" when it is none the registry knows.
+func mtInstrument(provider, sym string) string {
+	id := symbols.FromProvider(provider, sym)
+	if _, ok := market.LookupInstrument(id); ok {
+		return id
+	}
+	if len(id) > 6 {
+		if _, ok := market.LookupInstrument(id[:6]); ok {
+			return id[:6]
+		}
+	}
+	return ""
+}
+
+var mtTimeLayouts = []string{"2006.01.02 15:04:05", "2006.01.02 15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+func parseMTTime(s string, loc *time.Location) (types.Timestamp, error) {
+	for _, layout := range mtTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return types.FromTime(t.UTC()), nil
+		}
+	}
+	return 0, fmt.Errorf("bad time %q", s)
+}
+
+// mtNumber strips the spaces MT5 groups thousands with ("1 234.56").
+func mtNumber(s string) string {
+	return strings.NewReplacer(" ", "", "\u00a0", "").Replace(s)
+}
+
+func nonEmptyCells(row []string) int {
+	n := 0
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// decodeStatementText returns data as a string, decoding the UTF-16 the
+// MT5 terminal saves reports in when data starts with its byte order mark.
+func decodeStatementText(data []byte) string {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		order = binary.BigEndian
+	default:
+		return string(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF}))
+	}
+	data = data[2:]
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// htmlTableRows returns the text of every table row's cells in s, a cell
+// with colspan n repeated n times so columns line up with the header.
+// Statements are simple generated tables, so this reads tags directly
+// rather than parsing the document.
+func htmlTableRows(s string) [][]string {
+	lower := strings.ToLower(s)
+	var rows [][]string
+	for pos := 0; ; {
+		start := strings.Index(lower[pos:], "<tr")
+		if start < 0 {
+			return rows
+		}
+		start += pos
+		end := strings.Index(lower[start+3:], "<tr")
+		if end < 0 {
+			end = len(s)
+		} else {
+			end += start + 3
+		}
+		rows = append(rows, htmlRowCells(s[start:end], lower[start:end]))
+		pos = end
+	}
+}
+
+func htmlRowCells(s, lower string) []string {
+	var cells []string
+	for pos := 0; ; {
+		start := nextCellTag(lower, pos)
+		if start < 0 {
+			return cells
+		}
+		tagEnd := strings.IndexByte(lower[start:], '>')
+		if tagEnd < 0 {
+			return cells
+		}
+		tagEnd += start + 1
+		end := nextCellTag(lower, tagEnd)
+		if close := strings.Index(lower[tagEnd:], "</tr"); close >= 0 && (end < 0 || tagEnd+close < end) {
+			end = tagEnd + close
+		}
+		if end < 0 {
+			end = len(s)
+		}
+		text := cellText(s[tagEnd:end])
+		for n := colspan(lower[start:tagEnd]); n > 0; n-- {
+			cells = append(cells, text)
+		}
+		pos = end
+	}
+}
+
+func nextCellTag(lower string, from int) int {
+	td := strings.Index(lower[from:], "<td")
+	th := strings.Index(lower[from:], "<th")
+	switch {
+	case td < 0 && th < 0:
+		return -1
+	case td < 0 || (th >= 0 && th < td):
+		return from + th
+	default:
+		return from + td
+	}
+}
+
+func colspan(tag string) int {
+	i := strings.Index(tag, "colspan=")
+	if i < 0 {
+		return 1
+	}
+	v := strings.TrimLeft(tag[i+len("colspan="):], `"'`)
+	j := 0
+	for j < len(v) && v[j] >= '0' && v[j] <= '9' {
+		j++
+	}
+	n, err := strconv.Atoi(v[:j])
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// cellText strips the tags and entities from a cell's HTML.
+func cellText(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	text := strings.ReplaceAll(html.UnescapeString(b.String()), "\u00a0", " ")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// csvStatementRows reads a statement exported as CSV, delimited by tabs,
+// semicolons or commas, whichever its first line uses most.
+func csvStatementRows(text string) ([][]string, error) {
+	first, _, _ := strings.Cut(strings.TrimLeft(text, "\r\n"), "\n")
+	comma := ','
+	best := strings.Count(first, ",")
+	for _, d := range []rune{';', '\t'} {
+		if n := strings.Count(first, string(d)); n > best {
+			comma, best = d, n
+		}
+	}
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return r.ReadAll()
+}
@@ -0,0 +1,125 @@
+package journal
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/symbols"
+	"github.com/rustyeddy/trader/types"
+)
+
+// mt4Statement is trimmed from an MT4 detailed statement: a balance row,
+// two closed trades (one on a suffixed symbol), a cancelled order, a
+// totals row, and an open trade that must not be imported.
+const mt4Statement = `<html><body><table>
+<tr align=left><td colspan=2><b>Account: 123456</b></td></tr>
+<tr align=left><td colspan=13><b>Closed Transactions:</b></td></tr>
+<tr align=center bgcolor="#C0C0C0"><td>Ticket</td><td nowrap>Open Time</td><td>Type</td><td>Size</td><td>Item</td><td>Price</td><td>S&nbsp;/&nbsp;L</td><td>T&nbsp;/&nbsp;P</td><td nowrap>Close Time</td><td>Price</td><td>Commission</td><td>Taxes</td><td>Swap</td><td>Profit</td></tr>
+<tr align=right><td>1000</td><td class=msdate nowrap>2024.03.01 09:00:00</td><td>balance</td><td colspan=10 align=left>Deposit</td><td class=mspt>10&nbsp;000.00</td></tr>
+<tr bgcolor="#E0E0E0" align=right><td title="[tp]">1001</td><td class=msdate nowrap>2024.03.04 10:00:00</td><td>buy</td><td class=mspt>0.10</td><td>eurusd</td><td style="mso-number-format:0\.00000;">1.08000</td><td>1.07800</td><td>1.08300</td><td class=msdate nowrap>2024.03.04 12:30:00</td><td>1.08300</td><td class=mspt>-0.70</td><td class=mspt>0.00</td><td class=mspt>0.00</td><td class=mspt>30.00</td></tr>
+<tr align=right><td>1002</td><td class=msdate nowrap>2024.03.05 15:00</td><td>sell</td><td class=mspt>0.05</td><td>usdjpy.m</td><td>150.100</td><td>0.000</td><td>0.000</td><td class=msdate nowrap>2024.03.06 09:15</td><td>150.300</td><td class=mspt>0.00</td><td class=mspt>0.00</td><td class=mspt>-0.45</td><td class=mspt>-6.66</td></tr>
+<tr align=right><td>1003</td><td>2024.03.05 16:00:00</td><td>buy limit</td><td>0.10</td><td>eurusd</td><td>1.07000</td><td>0.00000</td><td>0.00000</td><td>2024.03.06 16:00:00</td><td colspan=4>cancelled</td><td></td></tr>
+<tr align=right><td>1004</td><td>2024.03.07 10:00:00</td><td>buy</td><td>1.00</td><td>us30</td><td>39000.0</td><td>0.0</td><td>0.0</td><td>2024.03.07 11:00:00</td><td>39050.0</td><td>0.00</td><td>0.00</td><td>0.00</td><td>50.00</td></tr>
+<tr align=right><td colspan=10>&nbsp;</td><td class=mspt>-0.70</td><td class=mspt>0.00</td><td class=mspt>-0.45</td><td class=mspt>73.34</td></tr>
+<tr align=left><td colspan=13><b>Open Trades:</b></td></tr>
+<tr align=center bgcolor="#C0C0C0"><td>Ticket</td><td nowrap>Open Time</td><td>Type</td><td>Size</td><td>Item</td><td>Price</td><td>S&nbsp;/&nbsp;L</td><td>T&nbsp;/&nbsp;P</td><td nowrap>&nbsp;</td><td>Price</td><td>Commission</td><td>Taxes</td><td>Swap</td><td>Profit</td></tr>
+<tr align=right><td>1005</td><td>2024.03.08 10:00:00</td><td>buy</td><td>0.10</td><td>eurusd</td><td>1.09000</td><td>0.00000</td><td>0.00000</td><td>&nbsp;</td><td>1.09100</td><td>0.00</td><td>0.00</td><td>0.00</td><td>10.00</td></tr>
+</table></body></html>`
+
+func TestParseMetaTraderStatement_MT4HTML(t *testing.T) {
+	athens, err := time.LoadLocation("Europe/Athens")
+	require.NoError(t, err)
+
+	got, err := ParseMetaTraderStatement([]byte(mt4Statement), MetaTraderOptions{Location: athens})
+	require.NoError(t, err)
+	require.Len(t, got.Trades, 2)
+	assert.Equal(t, map[string]int{"us30": 1}, got.Unmapped)
+
+	buy := got.Trades[0]
+	assert.Equal(t, TradeRecord{
+		TradeID:    "mt4-1001",
+		RunID:      MT4RunID,
+		Instrument: "EURUSD",
+		Units:      10_000,
+		EntryPrice: types.PriceFromFloat(1.08),
+		ExitPrice:  types.PriceFromFloat(1.083),
+		OpenTime:   types.FromTime(time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)),
+		CloseTime:  types.FromTime(time.Date(2024, 3, 4, 10, 30, 0, 0, time.UTC)),
+		RealizedPL: types.MoneyFromFloat(29.30),
+	}, buy, "server time UTC+2 normalized, commission netted")
+
+	sell := got.Trades[1]
+	assert.Equal(t, "USDJPY", sell.Instrument, "broker suffix dropped")
+	assert.Equal(t, types.Units(-5_000), sell.Units)
+	assert.Equal(t, types.MoneyFromFloat(-7.11), sell.RealizedPL)
+}
+
+func TestParseMetaTraderStatement_SymbolEntry(t *testing.T) {
+	// Entries for a provider name of the test's own, so the shared
+	// metatrader table is left alone.
+	require.NoError(t, symbols.Register("mt-test", "GBPUSD", "Cable"))
+	statement := "Time,Position,Symbol,Type,Volume,Price,Time,Price,Profit\n" +
+		"2024.06.03 09:00,1,Cable,buy,0.1,1.27000,2024.06.03 10:00,1.27100,10.00\n" +
+		"2024.06.03 09:00,2,US30,buy,0.1,39000.0,2024.06.03 10:00,39010.0,1.00\n"
+
+	got, err := ParseMetaTraderStatement([]byte(statement), MetaTraderOptions{Provider: "mt-test"})
+	require.NoError(t, err)
+	require.Len(t, got.Trades, 1)
+	assert.Equal(t, "GBPUSD", got.Trades[0].Instrument)
+	assert.Equal(t, types.FromTime(time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)), got.Trades[0].OpenTime, "no location is UTC")
+	assert.Equal(t, map[string]int{"US30": 1}, got.Unmapped)
+}
+
+// mt5Report is trimmed from an MT5 history report, which the terminal
+// saves as UTF-16: the positions table, whose header repeats Time and
+// Price and has hidden columns, followed by the orders table.
+const mt5Report = `<html><body><table>
+<tr align="center"><th colspan="14"><div><b>Positions</b></div></th></tr>
+<tr align="center" bgcolor="#E5F0FC"><td nowrap><b>Time</b></td><td nowrap><b>Position</b></td><td nowrap><b>Symbol</b></td><td nowrap><b>Type</b></td><td class="hidden" colspan="8"></td><td nowrap><b>Volume</b></td><td nowrap><b>Price</b></td><td nowrap><b>S / L</b></td><td nowrap><b>T / P</b></td><td nowrap><b>Time</b></td><td nowrap><b>Price</b></td><td nowrap><b>Commission</b></td><td nowrap><b>Swap</b></td><td nowrap colspan="2"><b>Profit</b></td></tr>
+<tr bgcolor="#FFFFFF" align="right"><td>2024.06.03 09:00:00</td><td>5551</td><td>GBPUSD</td><td>sell</td><td class="hidden" colspan="8"></td><td>1.5</td><td>1.27500</td><td>1.27800</td><td></td><td>2024.06.03 17:00:00</td><td>1.27000</td><td>-10.50</td><td>0.00</td><td colspan="2">750.00</td></tr>
+<tr align="center"><th colspan="14"><div><b>Orders</b></div></th></tr>
+<tr align="center" bgcolor="#E5F0FC"><td nowrap><b>Open Time</b></td><td nowrap><b>Order</b></td><td nowrap><b>Symbol</b></td><td nowrap><b>Type</b></td><td nowrap><b>Volume</b></td><td nowrap><b>Price</b></td><td nowrap><b>S / L</b></td><td nowrap><b>T / P</b></td><td nowrap><b>Time</b></td><td nowrap><b>State</b></td><td nowrap><b>Comment</b></td></tr>
+<tr bgcolor="#FFFFFF" align="right"><td>2024.06.03 09:00:00</td><td>5551</td><td>GBPUSD</td><td>sell</td><td>1.5 / 1.5</td><td>market</td><td></td><td></td><td>2024.06.03 09:00:00</td><td>filled</td><td></td></tr>
+</table></body></html>`
+
+func utf16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := []byte{0xFF, 0xFE}
+	for _, u := range units {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
+
+func TestParseMetaTraderStatement_MT5UTF16(t *testing.T) {
+	got, err := ParseMetaTraderStatement(utf16LE(mt5Report), MetaTraderOptions{})
+	require.NoError(t, err)
+	require.Len(t, got.Trades, 1, "the orders table is not read as positions")
+	tr := got.Trades[0]
+	assert.Equal(t, "mt5-5551", tr.TradeID)
+	assert.Equal(t, MT5RunID, tr.RunID)
+	assert.Equal(t, types.Units(-150_000), tr.Units)
+	assert.Equal(t, types.PriceFromFloat(1.275), tr.EntryPrice)
+	assert.Equal(t, types.PriceFromFloat(1.27), tr.ExitPrice)
+	assert.Equal(t, types.MoneyFromFloat(739.50), tr.RealizedPL)
+}
+
+func TestParseMetaTraderStatement_CSV(t *testing.T) {
+	csv := "Time;Position;Symbol;Type;Volume;Price;S / L;T / P;Time;Price;Commission;Swap;Profit\n" +
+		"2024.06.03 09:00:00;5551;EURUSD;buy;0.2;1.08000;;;2024.06.03 10:00:00;1.08100;0.00;0.00;1 020.00\n"
+	got, err := ParseMetaTraderStatement([]byte(csv), MetaTraderOptions{LotSize: 1_000_000})
+	require.NoError(t, err)
+	require.Len(t, got.Trades, 1)
+	assert.Equal(t, types.Units(200_000), got.Trades[0].Units)
+	assert.Equal(t, types.MoneyFromFloat(1020), got.Trades[0].RealizedPL, "space thousands separator")
+}
+
+func TestParseMetaTraderStatement_NoTable(t *testing.T) {
+	_, err := ParseMetaTraderStatement([]byte("<html><table><tr><td>hello</td></tr></table></html>"), MetaTraderOptions{})
+	assert.ErrorContains(t, err, "no MT4 closed transactions or MT5 positions table")
+}
@@ -10,11 +10,25 @@ type Config struct {
 	// File-backed journals use one file for trades and one for equity snapshots.
 	TradesPath string
 	EquityPath string
+
+	// Rotate starts a new pair of files every period: "daily" or "weekly"
+	// (see RotatingJournal). Empty writes TradesPath and EquityPath as is.
+	Rotate string `yaml:"rotate,omitempty"`
+	// RetainEquityDays, when > 0, deletes rotated equity files older than
+	// that many days; trades are kept. Requires Rotate.
+	RetainEquityDays int `yaml:"retain_equity_days,omitempty"`
 }
 
 // Open opens the Journal configured by cfg. Caller is responsible for
 // calling .Close() on the returned journal.
 func Open(cfg Config) (Journal, error) {
+	if cfg.Rotate != "" || cfg.RetainEquityDays != 0 {
+		j, err := NewRotating(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("open %s journal: %w", cfg.Kind, err)
+		}
+		return j, nil
+	}
 	switch cfg.Kind {
 	case "csv":
 		j, err := NewCSV(cfg.TradesPath, cfg.EquityPath)
@@ -5,7 +5,7 @@ import (
 	"strings"
 
 	"github.com/rustyeddy/trader/idgen"
-	"github.com/rustyeddy/trader/types"
+	"github.com/rustyeddy/trader/market"
 )
 
 // FormatTradeOrg renders a TradeRecord as an Org-mode block suitable for pasting into a journal.
@@ -21,21 +21,64 @@ func FormatTradeOrg(t TradeRecord) string {
 	writeOrgProperty(&b, "TRADE_ID", t.TradeID)
 	writeOrgProperty(&b, "INSTRUMENT", t.Instrument)
 	writeOrgProperty(&b, "UNITS", t.Units.String())
-	writeOrgProperty(&b, "ENTRY_PRICE", types.FormatScaledPrice(t.EntryPrice, int32(types.PriceScale)))
-	writeOrgProperty(&b, "EXIT_PRICE", types.FormatScaledPrice(t.ExitPrice, int32(types.PriceScale)))
+	writeOrgProperty(&b, "ENTRY_PRICE", market.FormatPrice(t.Instrument, t.EntryPrice.Float64()))
+	writeOrgProperty(&b, "EXIT_PRICE", market.FormatPrice(t.Instrument, t.ExitPrice.Float64()))
 	writeOrgProperty(&b, "OPEN_TIME", t.OpenTime.String())
 	writeOrgProperty(&b, "CLOSE_TIME", t.CloseTime.String())
 	writeOrgProperty(&b, "REALIZED_PL", fmt.Sprintf("%.2f", t.RealizedPL.Float64()))
 	writeOrgProperty(&b, "REASON", t.Reason)
+	if t.VolRegime != "" {
+		writeOrgProperty(&b, "VOL_REGIME", t.VolRegime)
+	}
+	if t.ConversionRate != 0 {
+		writeOrgProperty(&b, "QUOTE_PL", fmt.Sprintf("%.2f %s", t.QuotePL.Float64(), t.QuoteCurrency))
+		writeOrgProperty(&b, "ACCOUNT_CURRENCY", t.AccountCurrency)
+		writeOrgProperty(&b, "CONVERSION_RATE", fmt.Sprintf("%.6f", t.ConversionRate.Float64()))
+	}
+	if t.InitialRisk != 0 {
+		writeOrgProperty(&b, "INITIAL_RISK", fmt.Sprintf("%.2f", t.InitialRisk.Float64()))
+		writeOrgProperty(&b, "R_MULTIPLE", fmt.Sprintf("%.2f", t.RMultiple.Float64()))
+	}
+	if t.MAE != 0 || t.MFE != 0 {
+		writeOrgProperty(&b, "MAE", market.FormatPrice(t.Instrument, t.MAE.Float64()))
+		writeOrgProperty(&b, "MFE", market.FormatPrice(t.Instrument, t.MFE.Float64()))
+	}
+	if t.Rating > 0 {
+		writeOrgProperty(&b, "REVIEW_RATING", fmt.Sprintf("%d/%d", t.Rating, MaxRating))
+	}
 	b.WriteString(":END:\n")
 	b.WriteString("\n")
-	b.WriteString("*** Thesis\n- \n\n")
-	b.WriteString("*** Execution\n- \n\n")
-	b.WriteString("*** Review\n- \n")
+	writeOrgSection(&b, "Thesis", SectionThesis, t.Notes)
+	b.WriteString("\n")
+	writeOrgSection(&b, "Execution", SectionExecution, t.Notes)
+	b.WriteString("\n")
+	writeOrgSection(&b, "Review", SectionReview, t.Notes)
 
 	return b.String()
 }
 
+// writeOrgSection writes a narrative heading with the notes filed under
+// section as list items, each stamped with its date (continuation lines
+// indented under it), or an empty item to
+// fill in when there are none.
+func writeOrgSection(b *strings.Builder, heading, section string, notes []Annotation) {
+	b.WriteString("*** ")
+	b.WriteString(heading)
+	b.WriteString("\n")
+	var wrote bool
+	for _, n := range notes {
+		if n.Section != section && (n.Section != "" || section != SectionReview) {
+			continue
+		}
+		note := strings.ReplaceAll(strings.TrimSpace(n.Note), "\n", "\n  ")
+		fmt.Fprintf(b, "- [%s] %s\n", n.Time.Time().Format("2006-01-02"), note)
+		wrote = true
+	}
+	if !wrote {
+		b.WriteString("- \n")
+	}
+}
+
 // FormatTradesOrg renders multiple trades separated by blank lines.
 func FormatTradesOrg(trades []TradeRecord) string {
 	var b strings.Builder
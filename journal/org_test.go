@@ -46,6 +46,27 @@ func TestFormatTradeOrg(t *testing.T) {
 	assert.Contains(t, result, ":REALIZED_PL: 250.00")
 	assert.Contains(t, result, ":REASON: trend-following")
 	assert.Contains(t, result, ":END:")
+	assert.NotContains(t, result, ":R_MULTIPLE:", "risk fields are omitted without an initial stop")
+	assert.NotContains(t, result, ":MAE:")
+	assert.NotContains(t, result, ":VOL_REGIME:", "regime is omitted when unknown")
+
+	trade.InitialRisk = types.MoneyFromFloat(100)
+	trade.RMultiple = types.RateFromFloat(2.5)
+	trade.MAE = types.PriceFromFloat(0.0008)
+	trade.MFE = types.PriceFromFloat(0.0031)
+	trade.VolRegime = "high"
+	result = FormatTradeOrg(trade)
+	assert.Contains(t, result, ":VOL_REGIME: high")
+	assert.Contains(t, result, ":INITIAL_RISK: 100.00")
+	assert.Contains(t, result, ":R_MULTIPLE: 2.50")
+	assert.Contains(t, result, ":MAE: 0.00080")
+	assert.Contains(t, result, ":MFE: 0.00310")
+
+	jpy := trade
+	jpy.Instrument = "USD_JPY"
+	jpy.EntryPrice = types.PriceFromFloat(151.234)
+	result = FormatTradeOrg(jpy)
+	assert.Contains(t, result, ":ENTRY_PRICE: 151.234\n", "prices follow the instrument's precision")
 
 	// Check narrative sections
 	assert.Contains(t, result, "*** Thesis")
@@ -0,0 +1,189 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// PortfolioMember is one journal going into a Portfolio: a strategy or
+// account's equity snapshots under the name it is reported by.
+type PortfolioMember struct {
+	Name   string
+	Equity []EquitySnapshot
+}
+
+// PortfolioDay is the combined equity at the end of one day.
+type PortfolioDay struct {
+	Date   time.Time     // local midnight the day starts at
+	Equity []types.Money // each member's equity, in Portfolio.Names order
+	Total  types.Money
+}
+
+// Portfolio is several journals' equity curves laid side by side on a
+// common daily calendar and summed, with the correlation of their daily
+// returns: how far the strategies behind them move together.
+type Portfolio struct {
+	Names []string
+	Days  []PortfolioDay
+
+	// Correlation[i][j] is the Pearson correlation of members i and j's
+	// day-over-day returns across Days, RateScale-scaled; 0 where it is
+	// undefined (fewer than two returns, or a flat curve). The diagonal is
+	// RateScale.
+	Correlation [][]types.Rate
+}
+
+// Start is the combined equity on the first day; zero when there are no days.
+func (p Portfolio) Start() types.Money {
+	if len(p.Days) == 0 {
+		return 0
+	}
+	return p.Days[0].Total
+}
+
+// End is the combined equity on the last day; zero when there are no days.
+func (p Portfolio) End() types.Money {
+	if len(p.Days) == 0 {
+		return 0
+	}
+	return p.Days[len(p.Days)-1].Total
+}
+
+// BuildPortfolio combines the primary-account equity of every member, day
+// by day in loc. Each member's equity for a day is its last snapshot at or
+// before the day's end, carried forward over days it recorded nothing, so
+// a journal that stopped early keeps its final equity. The curve starts on
+// the first day every member has a snapshot and keeps only days on which
+// at least one member recorded one, so weekends and other idle days don't
+// count as flat returns. Sim sub-account snapshots are ignored.
+func BuildPortfolio(members []PortfolioMember, loc *time.Location) (Portfolio, error) {
+	if len(members) == 0 {
+		return Portfolio{}, errors.New("portfolio: no journals")
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	curves := make([][]dailyEquity, len(members))
+	p := Portfolio{Names: make([]string, len(members))}
+	var start time.Time
+	days := map[time.Time]bool{}
+	for i, m := range members {
+		p.Names[i] = m.Name
+		curves[i] = dailyEquityCurve(m.Equity, loc)
+		if len(curves[i]) == 0 {
+			return Portfolio{}, fmt.Errorf("portfolio: journal %q has no equity snapshots", m.Name)
+		}
+		if first := curves[i][0].date; first.After(start) {
+			start = first
+		}
+		for _, d := range curves[i] {
+			days[d.date] = true
+		}
+	}
+
+	dates := make([]time.Time, 0, len(days))
+	for d := range days {
+		if !d.Before(start) {
+			dates = append(dates, d)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	next := make([]int, len(members))
+	for _, date := range dates {
+		day := PortfolioDay{Date: date, Equity: make([]types.Money, len(members))}
+		for i, curve := range curves {
+			for next[i] < len(curve) && !curve[next[i]].date.After(date) {
+				next[i]++
+			}
+			day.Equity[i] = curve[next[i]-1].equity
+			day.Total += day.Equity[i]
+		}
+		p.Days = append(p.Days, day)
+	}
+
+	returns := make([][]types.Rate, len(members))
+	for i := range members {
+		returns[i] = p.dailyReturns(i)
+	}
+	p.Correlation = make([][]types.Rate, len(members))
+	for i := range members {
+		p.Correlation[i] = make([]types.Rate, len(members))
+		for j := range members {
+			if i == j {
+				p.Correlation[i][j] = types.Rate(types.RateScale)
+				continue
+			}
+			if c, ok := market.Correlation(returns[i], returns[j]); ok {
+				p.Correlation[i][j] = c
+			}
+		}
+	}
+	return p, nil
+}
+
+// dailyEquity is a member's equity at the end of one day.
+type dailyEquity struct {
+	date   time.Time
+	equity types.Money
+}
+
+// dailyEquityCurve reduces snapshots to the primary account's last equity
+// of each day in loc, in date order.
+func dailyEquityCurve(snaps []EquitySnapshot, loc *time.Location) []dailyEquity {
+	primary := make([]EquitySnapshot, 0, len(snaps))
+	for _, s := range snaps {
+		if s.AccountID == "" {
+			primary = append(primary, s)
+		}
+	}
+	sort.SliceStable(primary, func(i, j int) bool { return primary[i].Timestamp < primary[j].Timestamp })
+
+	var out []dailyEquity
+	for _, s := range primary {
+		y, m, d := s.Timestamp.Time().In(loc).Date()
+		date := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		if n := len(out); n > 0 && out[n-1].date.Equal(date) {
+			out[n-1].equity = s.Equity
+			continue
+		}
+		out = append(out, dailyEquity{date: date, equity: s.Equity})
+	}
+	return out
+}
+
+// dailyReturns returns member i's day-over-day returns, RateScale-scaled;
+// one shorter than Days. A non-positive previous equity yields a zero
+// return.
+func (p Portfolio) dailyReturns(i int) []types.Rate {
+	if len(p.Days) < 2 {
+		return nil
+	}
+	out := make([]types.Rate, len(p.Days)-1)
+	for k := 1; k < len(p.Days); k++ {
+		prev := int64(p.Days[k-1].Equity[i])
+		if prev <= 0 {
+			continue
+		}
+		diff := int64(p.Days[k].Equity[i]) - prev
+		abs, err := types.AbsInt64Checked(diff)
+		if err != nil {
+			continue
+		}
+		r, err := types.MulDivFloor64(abs, int64(types.RateScale), prev)
+		if err != nil {
+			continue
+		}
+		if diff < 0 {
+			r = -r
+		}
+		out[k-1] = types.Rate(r)
+	}
+	return out
+}
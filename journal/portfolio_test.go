@@ -0,0 +1,63 @@
+package journal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPortfolio(t *testing.T) {
+	t.Parallel()
+
+	at := func(day, hour int) types.Timestamp {
+		return types.FromTime(time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC))
+	}
+	snaps := func(points ...any) []EquitySnapshot {
+		var out []EquitySnapshot
+		for i := 0; i < len(points); i += 2 {
+			out = append(out, EquitySnapshot{Timestamp: points[i].(types.Timestamp), Equity: types.MoneyFromFloat(points[i+1].(float64))})
+		}
+		return out
+	}
+	a := snaps(at(11, 9), 1000.0, at(12, 9), 1050.0, at(12, 20), 1100.0, at(13, 20), 1000.0, at(14, 20), 1100.0)
+	b := append(snaps(at(11, 20), 2000.0, at(12, 20), 1800.0, at(13, 20), 2000.0),
+		EquitySnapshot{Timestamp: at(13, 21), AccountID: "sub", Equity: types.MoneyFromFloat(1)})
+	c := snaps(at(14, 20), 605.0, at(12, 20), 500.0, at(13, 20), 550.0)
+
+	p, err := BuildPortfolio([]PortfolioMember{{"a", a}, {"b", b}, {"c", c}}, time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, p.Names)
+
+	// c starts on the 12th; b carries its last equity into the 14th.
+	require.Len(t, p.Days, 3)
+	assert.Equal(t, time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), p.Days[0].Date)
+	assert.Equal(t, []types.Money{types.MoneyFromFloat(1100), types.MoneyFromFloat(1800), types.MoneyFromFloat(500)}, p.Days[0].Equity)
+	assert.Equal(t, types.MoneyFromFloat(2000), p.Days[2].Equity[1])
+	assert.Equal(t, types.MoneyFromFloat(3400), p.Start())
+	assert.Equal(t, types.MoneyFromFloat(3550), p.Days[1].Total)
+	assert.Equal(t, types.MoneyFromFloat(3705), p.End())
+
+	// a falls then rises while b rises then holds: opposite moves. c grows
+	// 10% a day, a flat return series with no defined correlation.
+	require.Len(t, p.Correlation, 3)
+	assert.Equal(t, types.Rate(types.RateScale), p.Correlation[0][0])
+	assert.InDelta(t, -1.0, p.Correlation[0][1].Float64(), 1e-5)
+	assert.Equal(t, p.Correlation[0][1], p.Correlation[1][0])
+	assert.Zero(t, p.Correlation[0][2])
+	assert.Zero(t, p.Correlation[2][1])
+}
+
+func TestBuildPortfolio_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := BuildPortfolio(nil, time.UTC)
+	assert.Error(t, err)
+
+	_, err = BuildPortfolio([]PortfolioMember{
+		{Name: "sub-only", Equity: []EquitySnapshot{{Timestamp: 1, AccountID: "sub", Equity: 1}}},
+	}, time.UTC)
+	assert.ErrorContains(t, err, `"sub-only" has no equity snapshots`)
+}
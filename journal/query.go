@@ -0,0 +1,66 @@
+package journal
+
+import (
+	"strings"
+
+	"github.com/rustyeddy/trader/symbols"
+	"github.com/rustyeddy/trader/types"
+)
+
+// TradeFilter selects trades by instrument, strategy, close reason and
+// realized P/L. Zero fields match everything; journals are filtered in
+// memory with Match.
+type TradeFilter struct {
+	// Instrument is compared in canonical form, so "EUR_USD" matches a
+	// trade journaled as "EURUSD".
+	Instrument string
+	// Strategy matches the bot or run that placed the trade: BotID for
+	// trades opened by a bot, RunID for imported history such as
+	// live-oanda or live-mt4.
+	Strategy string
+	// Reason matches the close reason, case-insensitively.
+	Reason string
+	// MinPL and MaxPL bound RealizedPL, inclusive; nil leaves that side
+	// open.
+	MinPL *types.Money
+	MaxPL *types.Money
+}
+
+// IsZero reports whether f matches every trade.
+func (f TradeFilter) IsZero() bool {
+	return f.Instrument == "" && f.Strategy == "" && f.Reason == "" && f.MinPL == nil && f.MaxPL == nil
+}
+
+// Match reports whether t passes f.
+func (f TradeFilter) Match(t TradeRecord) bool {
+	if f.Instrument != "" && symbols.Canonical(t.Instrument) != symbols.Canonical(f.Instrument) {
+		return false
+	}
+	if f.Strategy != "" && t.BotID != f.Strategy && t.RunID != f.Strategy {
+		return false
+	}
+	if f.Reason != "" && !strings.EqualFold(t.Reason, f.Reason) {
+		return false
+	}
+	if f.MinPL != nil && t.RealizedPL < *f.MinPL {
+		return false
+	}
+	if f.MaxPL != nil && t.RealizedPL > *f.MaxPL {
+		return false
+	}
+	return true
+}
+
+// FilterTrades returns the trades that pass f, in their journal order.
+func FilterTrades(trades []TradeRecord, f TradeFilter) []TradeRecord {
+	if f.IsZero() {
+		return trades
+	}
+	out := make([]TradeRecord, 0, len(trades))
+	for _, t := range trades {
+		if f.Match(t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
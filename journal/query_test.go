@@ -0,0 +1,39 @@
+package journal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+func moneyPtr(f float64) *types.Money {
+	m := types.MoneyFromFloat(f)
+	return &m
+}
+
+func TestTradeFilter_Match(t *testing.T) {
+	trades := []TradeRecord{
+		{TradeID: "a", Instrument: "EUR_USD", BotID: "bot-1", Reason: "STOP", RealizedPL: types.MoneyFromFloat(-10)},
+		{TradeID: "b", Instrument: "EURUSD", RunID: OANDARunID, Reason: "TAKE", RealizedPL: types.MoneyFromFloat(25)},
+		{TradeID: "c", Instrument: "USDJPY", BotID: "bot-1", Reason: "stop", RealizedPL: 0},
+	}
+	ids := func(f TradeFilter) []string {
+		var out []string
+		for _, tr := range FilterTrades(trades, f) {
+			out = append(out, tr.TradeID)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"a", "b", "c"}, ids(TradeFilter{}))
+	assert.Equal(t, []string{"a", "b"}, ids(TradeFilter{Instrument: "eur/usd"}))
+	assert.Equal(t, []string{"a", "c"}, ids(TradeFilter{Strategy: "bot-1"}))
+	assert.Equal(t, []string{"b"}, ids(TradeFilter{Strategy: OANDARunID}))
+	assert.Equal(t, []string{"a", "c"}, ids(TradeFilter{Reason: "Stop"}))
+	assert.Equal(t, []string{"a", "c"}, ids(TradeFilter{MaxPL: moneyPtr(0)}))
+	assert.Equal(t, []string{"b", "c"}, ids(TradeFilter{MinPL: moneyPtr(0)}))
+	assert.Equal(t, []string{"c"}, ids(TradeFilter{MinPL: moneyPtr(0), MaxPL: moneyPtr(0), Reason: "STOP"}))
+	assert.Empty(t, ids(TradeFilter{Instrument: "GBPUSD"}))
+}
@@ -0,0 +1,278 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Journal rotation periods for Config.Rotate.
+const (
+	RotateDaily  = "daily"
+	RotateWeekly = "weekly"
+)
+
+// rotatedDateLayout is the period-start date RotatedPath puts in file names.
+const rotatedDateLayout = "2006-01-02"
+
+// Rotator is implemented by journals that can close their files and start
+// new ones mid-run. Callers type-assert for it, as for OrderJournal.
+type Rotator interface {
+	Rotate() error
+}
+
+// RotatingJournal writes trades and equity snapshots to one pair of files
+// per day or week, named by RotatedPath. A record dated in a later period
+// than the open files closes them and opens the next pair, so a
+// long-running paper session never grows one file without bound; a late
+// record goes to the newest files rather than reopening an old period.
+// With RetainEquityDays set, every rotation also deletes the equity files
+// whose period ended more than that many days before the newest record.
+// Trade files are always kept. The trades file's sidecars (order events
+// and the like) rotate with it.
+//
+// RotatingJournal is safe for concurrent use, so Rotate can be called from
+// a signal handler while the session keeps recording.
+type RotatingJournal struct {
+	cfg  Config
+	open func(tradesPath, equityPath string) (Journal, error)
+
+	mu     sync.Mutex
+	cur    Journal
+	period time.Time // start of the newest period opened; zero before the first record
+	latest time.Time // newest record time seen, for retention
+}
+
+// NewRotating returns a RotatingJournal for cfg. No files are created until
+// the first record arrives, since its time picks the period.
+func NewRotating(cfg Config) (*RotatingJournal, error) {
+	if err := cfg.validateRotation(); err != nil {
+		return nil, err
+	}
+	r := &RotatingJournal{cfg: cfg}
+	switch cfg.Kind {
+	case "csv":
+		r.open = func(tradesPath, equityPath string) (Journal, error) { return NewCSV(tradesPath, equityPath) }
+	case "json":
+		// Append: a restarted session or a forced Rotate reopens the
+		// period's files and must not truncate them.
+		r.open = func(tradesPath, equityPath string) (Journal, error) {
+			return openJSON(tradesPath, equityPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY)
+		}
+	default:
+		return nil, errs.Newf(errs.ErrBadConfig, "journal kind must be 'csv' or 'json'; got %q", cfg.Kind)
+	}
+	return r, nil
+}
+
+// validateRotation checks the rotation and retention fields.
+func (cfg Config) validateRotation() error {
+	switch cfg.Rotate {
+	case "", RotateDaily, RotateWeekly:
+	default:
+		return errs.Newf(errs.ErrBadConfig, "journal rotate must be %q or %q; got %q", RotateDaily, RotateWeekly, cfg.Rotate)
+	}
+	if cfg.RetainEquityDays < 0 {
+		return errs.Newf(errs.ErrBadConfig, "journal retain_equity_days must be >= 0; got %d", cfg.RetainEquityDays)
+	}
+	if cfg.RetainEquityDays > 0 && cfg.Rotate == "" {
+		return errs.Newf(errs.ErrBadConfig, "journal retain_equity_days needs rotate: equity is pruned a whole file at a time")
+	}
+	return nil
+}
+
+func (r *RotatingJournal) RecordTrade(t TradeRecord) error {
+	return r.record(t.CloseTime, func(j Journal) error { return j.RecordTrade(t) })
+}
+
+func (r *RotatingJournal) RecordEquity(e EquitySnapshot) error {
+	return r.record(e.Timestamp, func(j Journal) error { return j.RecordEquity(e) })
+}
+
+// RecordOrderEvent writes e to the order events sidecar of e's period.
+func (r *RotatingJournal) RecordOrderEvent(e OrderEvent) error {
+	return r.record(e.Time, func(j Journal) error {
+		if oj, ok := j.(OrderJournal); ok {
+			return oj.RecordOrderEvent(e)
+		}
+		return nil
+	})
+}
+
+// RecordOrderDecision writes d to the order decisions sidecar of d's
+// period.
+func (r *RotatingJournal) RecordOrderDecision(d OrderDecision) error {
+	return r.record(d.Time, func(j Journal) error {
+		if dj, ok := j.(DecisionJournal); ok {
+			return dj.RecordOrderDecision(d)
+		}
+		return nil
+	})
+}
+
+// RecordSkippedSignal writes s to the skipped signals sidecar of s's
+// period.
+func (r *RotatingJournal) RecordSkippedSignal(s SkippedSignal) error {
+	return r.record(s.Time, func(j Journal) error {
+		if sj, ok := j.(SignalJournal); ok {
+			return sj.RecordSkippedSignal(s)
+		}
+		return nil
+	})
+}
+
+// RecordSignal writes s to the signals sidecar of s's period.
+func (r *RotatingJournal) RecordSignal(s SignalRecord) error {
+	return r.record(s.Time, func(j Journal) error {
+		if sj, ok := j.(SignalRecordJournal); ok {
+			return sj.RecordSignal(s)
+		}
+		return nil
+	})
+}
+
+// record writes through the files for ts's period, rotating first when ts
+// starts a new one. A zero ts counts as the newest time seen.
+func (r *RotatingJournal) record(ts types.Timestamp, write func(Journal) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	at := r.latest
+	if ts != 0 {
+		at = ts.Time().UTC()
+	} else if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	if at.After(r.latest) {
+		r.latest = at
+	}
+
+	p := periodStart(r.cfg.Rotate, at)
+	if r.cur == nil || p.After(r.period) {
+		if p.Before(r.period) {
+			p = r.period
+		}
+		if err := r.openPeriod(p); err != nil {
+			return err
+		}
+	}
+	return write(r.cur)
+}
+
+// openPeriod closes the open files, opens the pair for period p and
+// applies retention.
+func (r *RotatingJournal) openPeriod(p time.Time) error {
+	if err := r.closeCurrent(); err != nil {
+		return err
+	}
+	j, err := r.open(RotatedPath(r.cfg.TradesPath, r.cfg.Rotate, p), RotatedPath(r.cfg.EquityPath, r.cfg.Rotate, p))
+	if err != nil {
+		return fmt.Errorf("rotate journal: %w", err)
+	}
+	r.cur, r.period = j, p
+	return r.prune()
+}
+
+// Rotate closes the open files, flushing them, and applies retention. The
+// next record reopens its period's files in append mode, so files moved
+// aside before Rotate are recreated rather than written through a stale
+// handle.
+func (r *RotatingJournal) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return errors.Join(r.closeCurrent(), r.prune())
+}
+
+// Close closes the open files. A journal that never recorded has none.
+func (r *RotatingJournal) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeCurrent()
+}
+
+func (r *RotatingJournal) closeCurrent() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
+
+// prune deletes the equity files whose whole period is older than
+// RetainEquityDays before the newest record. The open period's file is
+// never deleted, and files that do not parse as rotated names are left
+// alone.
+func (r *RotatingJournal) prune() error {
+	if r.cfg.RetainEquityDays <= 0 || r.latest.IsZero() {
+		return nil
+	}
+	cutoff := r.latest.AddDate(0, 0, -r.cfg.RetainEquityDays)
+
+	dir, name := filepath.Split(r.cfg.EquityPath)
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("prune journal equity: %w", err)
+	}
+
+	var errList []error
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || !strings.HasPrefix(n, prefix) || !strings.HasSuffix(n, ext) {
+			continue
+		}
+		start, err := time.Parse(rotatedDateLayout, strings.TrimSuffix(strings.TrimPrefix(n, prefix), ext))
+		if err != nil || start.Equal(r.period) {
+			continue
+		}
+		if periodEnd(r.cfg.Rotate, start).After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, n)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errList = append(errList, err)
+		}
+	}
+	return errors.Join(errList...)
+}
+
+// RotatedPath returns path with the start date of t's rotation period
+// inserted before its extension: trades.jsonl becomes
+// trades-2026-01-05.jsonl. Weekly periods start on Monday, daily ones at
+// midnight, both UTC. An empty rotate returns path unchanged.
+func RotatedPath(path, rotate string, t time.Time) string {
+	if rotate == "" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + periodStart(rotate, t).Format(rotatedDateLayout) + ext
+}
+
+// periodStart returns the UTC start of the day or week containing t.
+func periodStart(rotate string, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if rotate == RotateWeekly {
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
+
+// periodEnd returns the end of the period starting at start.
+func periodEnd(rotate string, start time.Time) time.Time {
+	if rotate == RotateWeekly {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
@@ -0,0 +1,174 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rotTS(date string) types.Timestamp {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		panic(err)
+	}
+	return types.FromTime(t)
+}
+
+func rotatingConfig(t *testing.T, kind, rotate string, retain int) Config {
+	dir := t.TempDir()
+	return Config{
+		Kind:             kind,
+		TradesPath:       filepath.Join(dir, "paper-trades."+kind),
+		EquityPath:       filepath.Join(dir, "paper-equity."+kind),
+		Rotate:           rotate,
+		RetainEquityDays: retain,
+	}
+}
+
+func TestRotatedPath(t *testing.T) {
+	wed := time.Date(2026, 1, 7, 15, 4, 0, 0, time.UTC)
+	assert.Equal(t, "/j/trades-2026-01-07.jsonl", RotatedPath("/j/trades.jsonl", RotateDaily, wed))
+	assert.Equal(t, "/j/trades-2026-01-05.jsonl", RotatedPath("/j/trades.jsonl", RotateWeekly, wed), "weeks start on Monday")
+	sun := time.Date(2026, 1, 11, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, "trades-2026-01-05.csv", RotatedPath("trades.csv", RotateWeekly, sun))
+	assert.Equal(t, "/j/trades.jsonl", RotatedPath("/j/trades.jsonl", "", wed))
+}
+
+func TestRotatingJournal_DailyFilesFollowRecordTime(t *testing.T) {
+	cfg := rotatingConfig(t, "json", RotateDaily, 0)
+	j, err := Open(cfg)
+	require.NoError(t, err)
+	require.Implements(t, (*Rotator)(nil), j)
+
+	require.NoError(t, j.RecordEquity(EquitySnapshot{Timestamp: rotTS("2026-01-05T10:00:00Z"), Equity: 1}))
+	require.NoError(t, j.RecordTrade(TradeRecord{TradeID: "t1", CloseTime: rotTS("2026-01-05T11:00:00Z")}))
+	require.NoError(t, j.RecordEquity(EquitySnapshot{Timestamp: rotTS("2026-01-06T00:00:00Z"), Equity: 2}))
+	// A late record stays in the newest period's files.
+	require.NoError(t, j.RecordTrade(TradeRecord{TradeID: "t2", CloseTime: rotTS("2026-01-05T23:59:00Z")}))
+	require.NoError(t, j.Close())
+
+	day1 := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	eq1, err := ReadEquityJSONL(RotatedPath(cfg.EquityPath, RotateDaily, day1))
+	require.NoError(t, err)
+	eq2, err := ReadEquityJSONL(RotatedPath(cfg.EquityPath, RotateDaily, day2))
+	require.NoError(t, err)
+	assert.Len(t, eq1, 1)
+	assert.Len(t, eq2, 1)
+
+	tr1, err := ReadTradesJSONL(RotatedPath(cfg.TradesPath, RotateDaily, day1))
+	require.NoError(t, err)
+	tr2, err := ReadTradesJSONL(RotatedPath(cfg.TradesPath, RotateDaily, day2))
+	require.NoError(t, err)
+	require.Len(t, tr1, 1)
+	require.Len(t, tr2, 1)
+	assert.Equal(t, "t2", tr2[0].TradeID)
+
+	_, err = os.Stat(cfg.TradesPath)
+	assert.ErrorIs(t, err, os.ErrNotExist, "the unrotated path is never written")
+}
+
+func TestRotatingJournal_RotateReopensAndAppends(t *testing.T) {
+	cfg := rotatingConfig(t, "json", RotateWeekly, 0)
+	j, err := NewRotating(cfg)
+	require.NoError(t, err)
+	defer j.Close()
+
+	require.NoError(t, j.RecordTrade(TradeRecord{TradeID: "a", CloseTime: rotTS("2026-01-05T10:00:00Z")}))
+	require.NoError(t, j.Rotate())
+
+	// Moved aside mid-run, as logrotate would; the next record recreates it.
+	path := RotatedPath(cfg.TradesPath, RotateWeekly, rotTS("2026-01-05T10:00:00Z").Time())
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, j.RecordTrade(TradeRecord{TradeID: "b", CloseTime: rotTS("2026-01-07T10:00:00Z")}))
+	require.NoError(t, j.Rotate())
+	require.NoError(t, j.RecordTrade(TradeRecord{TradeID: "c", CloseTime: rotTS("2026-01-08T10:00:00Z")}))
+	require.NoError(t, j.Close())
+
+	old, err := ReadTradesJSONL(path + ".1")
+	require.NoError(t, err)
+	assert.Len(t, old, 1)
+	cur, err := ReadTradesJSONL(path)
+	require.NoError(t, err)
+	require.Len(t, cur, 2, "reopening the same period appends")
+	assert.Equal(t, "b", cur[0].TradeID)
+	assert.Equal(t, "c", cur[1].TradeID)
+}
+
+func TestRotatingJournal_RetentionPrunesEquityKeepsTrades(t *testing.T) {
+	cfg := rotatingConfig(t, "csv", RotateDaily, 2)
+	j, err := Open(cfg)
+	require.NoError(t, err)
+
+	for _, day := range []string{"2026-01-05", "2026-01-06", "2026-01-07", "2026-01-08", "2026-01-09"} {
+		ts := rotTS(day + "T12:00:00Z")
+		require.NoError(t, j.RecordEquity(EquitySnapshot{Timestamp: ts}))
+		require.NoError(t, j.RecordTrade(TradeRecord{TradeID: day, CloseTime: ts}))
+	}
+	require.NoError(t, j.Close())
+
+	stray := filepath.Join(filepath.Dir(cfg.EquityPath), "paper-equity-notes.csv")
+	require.NoError(t, os.WriteFile(stray, nil, 0o644))
+	require.NoError(t, j.(Rotator).Rotate())
+
+	// Newest record 2026-01-09 12:00; cutoff 2026-01-07 12:00. Day files
+	// that ended by the cutoff go; the one spanning it stays.
+	for day, kept := range map[string]bool{
+		"2026-01-05": false, "2026-01-06": false,
+		"2026-01-07": true, "2026-01-08": true, "2026-01-09": true,
+	} {
+		d, _ := time.Parse(rotatedDateLayout, day)
+		_, err := os.Stat(RotatedPath(cfg.EquityPath, RotateDaily, d))
+		assert.Equal(t, kept, err == nil, "equity %s", day)
+		_, err = os.Stat(RotatedPath(cfg.TradesPath, RotateDaily, d))
+		assert.NoError(t, err, "trades %s are always kept", day)
+	}
+	assert.FileExists(t, stray, "files that are not rotated names are left alone")
+}
+
+func TestRotatingJournal_ConcurrentRotate(t *testing.T) {
+	cfg := rotatingConfig(t, "json", RotateDaily, 0)
+	j, err := NewRotating(cfg)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			assert.NoError(t, j.RecordEquity(EquitySnapshot{Timestamp: rotTS("2026-01-05T00:00:00Z").Add(time.Duration(i) * time.Minute)}))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			assert.NoError(t, j.Rotate())
+		}
+	}()
+	wg.Wait()
+	require.NoError(t, j.Close())
+
+	eq, err := ReadEquityJSONL(RotatedPath(cfg.EquityPath, RotateDaily, rotTS("2026-01-05T00:00:00Z").Time()))
+	require.NoError(t, err)
+	assert.Len(t, eq, 200, "no snapshot is lost across rotations")
+}
+
+func TestOpen_RotationConfigErrors(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"bad period":       {Kind: "json", Rotate: "hourly"},
+		"negative retain":  {Kind: "json", Rotate: RotateDaily, RetainEquityDays: -1},
+		"retain no rotate": {Kind: "json", RetainEquityDays: 7},
+		"bad kind":         {Kind: "sqlite", Rotate: RotateDaily},
+	} {
+		_, err := Open(cfg)
+		require.Error(t, err, name)
+		assert.ErrorIs(t, err, errs.ErrBadConfig, name)
+	}
+}
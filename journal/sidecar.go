@@ -0,0 +1,26 @@
+package journal
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Sidecar kinds. The CSV and JSONL journals write each optional record
+// kind (OrderJournal and the like) to its own file next to the trades
+// file, opened on the kind's first record, so a run that never produces
+// one leaves no empty file behind.
+const (
+	sidecarOrders    = "orders"
+	sidecarDecisions = "decisions"
+	sidecarSkipped   = "skipped"
+	sidecarSignals   = "signals"
+)
+
+// sidecarPath returns the file kind's records go to for a trades journal
+// at tradesPath, named like AnnotationsPath: trades.jsonl keeps its order
+// events in trades.orders.jsonl. A rotated trades file has rotated
+// sidecars.
+func sidecarPath(tradesPath, kind string) string {
+	ext := filepath.Ext(tradesPath)
+	return strings.TrimSuffix(tradesPath, ext) + "." + kind + ext
+}
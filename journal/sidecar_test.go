@@ -0,0 +1,161 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSidecarPath(t *testing.T) {
+	assert.Equal(t, "/j/run-trades.orders.jsonl", sidecarPath("/j/run-trades.jsonl", sidecarOrders))
+	assert.Equal(t, "run-trades-2026-01-05.orders.csv", sidecarPath("run-trades-2026-01-05.csv", sidecarOrders))
+}
+
+// readSidecar reads kind's records back from the journal at tradesPath,
+// CSV rows through parse after the header.
+func readSidecar[T any](t *testing.T, kind, tradesPath string, header []string, parse func(csvFields) (T, error)) []T {
+	t.Helper()
+	path := sidecarPath(tradesPath, kind)
+	var (
+		recs []T
+		err  error
+	)
+	if isCSVPath(path) {
+		recs, err = readCSVJournal(path, header[0], parse)
+	} else {
+		recs, err = readJSONL[T](path)
+	}
+	require.NoError(t, err)
+	return recs
+}
+
+func parseOrderEventRow(f csvFields) (OrderEvent, error) {
+	var errs []error
+	e := OrderEvent{
+		Type: f.str(0), OrderID: f.str(1), TradeID: f.str(2), AccountID: f.str(3), Instrument: f.str(4),
+		Units: f.int(5, &errs), Price: f.price(6, &errs), Time: f.time(7, &errs), Reason: f.str(8),
+		Remaining: f.int(9, &errs),
+	}
+	return e, errors.Join(errs...)
+}
+
+func TestJournal_OrderEventsSidecar(t *testing.T) {
+	for _, kind := range []string{"csv", "json"} {
+		t.Run(kind, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := Config{Kind: kind, TradesPath: filepath.Join(dir, "run-trades."+kind), EquityPath: filepath.Join(dir, "run-equity."+kind)}
+			j, err := Open(cfg)
+			require.NoError(t, err)
+			require.NoError(t, j.RecordTrade(TradeRecord{TradeID: "t1", CloseTime: rotTS("2026-01-05T10:00:00Z")}))
+			_, err = os.Stat(sidecarPath(cfg.TradesPath, sidecarOrders))
+			require.ErrorIs(t, err, os.ErrNotExist, "opened on the first order event")
+
+			oj, ok := j.(OrderJournal)
+			require.True(t, ok)
+			want := OrderEvent{
+				Type: OrderExpired, OrderID: "O1", AccountID: "ema", Instrument: "EURUSD",
+				Units: -1000, Price: 108_500, Time: rotTS("2026-01-05T11:00:00Z"), Reason: "TIME_IN_FORCE_EXPIRED",
+			}
+			require.NoError(t, oj.RecordOrderEvent(OrderEvent{Type: OrderSubmitted, OrderID: "O1", Units: -1000, Time: rotTS("2026-01-05T10:00:00Z")}))
+			require.NoError(t, oj.RecordOrderEvent(want))
+			require.NoError(t, j.Close())
+
+			got := readSidecar(t, sidecarOrders, cfg.TradesPath, orderEventCSVHeader, parseOrderEventRow)
+			require.Len(t, got, 2)
+			assert.Equal(t, want, got[1])
+		})
+	}
+}
+
+func TestRotatingJournal_SidecarsRotate(t *testing.T) {
+	cfg := rotatingConfig(t, "json", RotateDaily, 0)
+	j, err := Open(cfg)
+	require.NoError(t, err)
+	oj := j.(OrderJournal)
+	require.NoError(t, oj.RecordOrderEvent(OrderEvent{Type: OrderSubmitted, OrderID: "O1", Time: rotTS("2026-01-05T10:00:00Z")}))
+	require.NoError(t, oj.RecordOrderEvent(OrderEvent{Type: OrderExpired, OrderID: "O1", Time: rotTS("2026-01-06T10:00:00Z")}))
+	require.NoError(t, j.Close())
+
+	day1 := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	for i, typ := range []string{OrderSubmitted, OrderExpired} {
+		got := readSidecar[OrderEvent](t, sidecarOrders, RotatedPath(cfg.TradesPath, RotateDaily, day1.AddDate(0, 0, i)), nil, nil)
+		require.Len(t, got, 1)
+		assert.Equal(t, typ, got[0].Type)
+	}
+}
+
+func TestJournal_OrderDecisionsSidecar(t *testing.T) {
+	for _, kind := range []string{"csv", "json"} {
+		t.Run(kind, func(t *testing.T) {
+			cfg := rotatingConfig(t, kind, RotateDaily, 0)
+			j, err := Open(cfg)
+			require.NoError(t, err)
+			want := []OrderDecision{
+				{Time: rotTS("2026-01-05T10:00:00Z"), Instrument: "EURUSD", Side: "long", Accepted: true, Units: 1000},
+				{Time: rotTS("2026-01-05T11:00:00Z"), AccountID: "ema", Instrument: "EURUSD", Side: "short",
+					Reason: RejectBroker, Detail: "market closed, reopens Sun", RequestedUnits: 2000},
+			}
+			for _, d := range want {
+				require.NoError(t, j.(DecisionJournal).RecordOrderDecision(d))
+			}
+			require.NoError(t, j.Close())
+
+			got := readSidecar(t, sidecarDecisions, RotatedPath(cfg.TradesPath, RotateDaily, want[0].Time.Time()),
+				orderDecisionCSVHeader, func(f csvFields) (OrderDecision, error) {
+					var errs []error
+					d := OrderDecision{
+						Time: f.time(0, &errs), AccountID: f.str(1), Instrument: f.str(2), Side: f.str(3),
+						Accepted: f.str(4) == "true", Reason: f.str(5), Detail: f.str(6),
+						RequestedUnits: types.Units(f.int(7, &errs)), Units: types.Units(f.int(8, &errs)),
+					}
+					return d, errors.Join(errs...)
+				})
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestJournal_SignalSidecars(t *testing.T) {
+	for _, kind := range []string{"csv", "json"} {
+		t.Run(kind, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := Config{Kind: kind, TradesPath: filepath.Join(dir, "run-trades."+kind), EquityPath: filepath.Join(dir, "run-equity."+kind)}
+			j, err := Open(cfg)
+			require.NoError(t, err)
+			skipped := SkippedSignal{Time: rotTS("2026-01-05T10:00:00Z"), AccountID: "ema", Instrument: "EURUSD", Side: "long", Rule: "max-trades-per-day", Reason: "ema cross"}
+			signal := SignalRecord{
+				Time: rotTS("2026-01-05T10:00:00Z"), Instrument: "EURUSD", Side: "long", Price: 108_500, Stop: 108_300,
+				Gate: SignalGateOrder, Rule: RejectGovernor, Detail: "max-trades-per-day", Reason: "ema cross",
+				MaxR: types.RateFromFloat(1.5), Stopped: true,
+			}
+			require.NoError(t, j.(SignalJournal).RecordSkippedSignal(skipped))
+			require.NoError(t, j.(SignalRecordJournal).RecordSignal(signal))
+			require.NoError(t, j.Close())
+
+			gotSkipped := readSidecar(t, sidecarSkipped, cfg.TradesPath, skippedSignalCSVHeader, func(f csvFields) (SkippedSignal, error) {
+				var errs []error
+				s := SkippedSignal{Time: f.time(0, &errs), AccountID: f.str(1), Instrument: f.str(2), Side: f.str(3), Rule: f.str(4), Reason: f.str(5)}
+				return s, errors.Join(errs...)
+			})
+			assert.Equal(t, []SkippedSignal{skipped}, gotSkipped)
+
+			gotSignals := readSidecar(t, sidecarSignals, cfg.TradesPath, signalCSVHeader, func(f csvFields) (SignalRecord, error) {
+				var errs []error
+				s := SignalRecord{
+					Time: f.time(0, &errs), AccountID: f.str(1), Instrument: f.str(2), Side: f.str(3),
+					Price: f.price(4, &errs), Stop: f.price(5, &errs), Taken: f.str(6) == "true",
+					Gate: f.str(7), Rule: f.str(8), Detail: f.str(9), Reason: f.str(10),
+					MaxR: f.rate(11, &errs), Stopped: f.str(12) == "true",
+				}
+				return s, errors.Join(errs...)
+			})
+			assert.Equal(t, []SignalRecord{signal}, gotSignals)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package journal
+
+import (
+	"sort"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// TradeSummary totals a set of journaled trades: the whole set, and each
+// instrument in it.
+type TradeSummary struct {
+	TradeStats
+	ByInstrument []InstrumentStats `json:",omitempty"`
+}
+
+// InstrumentStats is TradeStats for the trades on one instrument.
+type InstrumentStats struct {
+	Instrument string
+	TradeStats
+}
+
+// TradeStats counts trades and totals their realized P/L. Breakeven
+// trades count toward Trades but are neither wins nor losses.
+type TradeStats struct {
+	Trades     int
+	Wins       int
+	Losses     int
+	NetPL      types.Money
+	GrossWin   types.Money
+	GrossLoss  types.Money // negative
+	BestTrade  types.Money
+	WorstTrade types.Money
+}
+
+// WinRate is Wins / Trades, 0 for no trades.
+func (s TradeStats) WinRate() float64 {
+	if s.Trades == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Trades)
+}
+
+// ProfitFactor is GrossWin / -GrossLoss, 0 when there were no losses.
+func (s TradeStats) ProfitFactor() float64 {
+	if s.GrossLoss == 0 {
+		return 0
+	}
+	return s.GrossWin.Float64() / -s.GrossLoss.Float64()
+}
+
+func (s *TradeStats) add(t TradeRecord) {
+	pl := t.RealizedPL
+	if s.Trades == 0 || pl > s.BestTrade {
+		s.BestTrade = pl
+	}
+	if s.Trades == 0 || pl < s.WorstTrade {
+		s.WorstTrade = pl
+	}
+	s.Trades++
+	s.NetPL += pl
+	switch {
+	case pl > 0:
+		s.Wins++
+		s.GrossWin += pl
+	case pl < 0:
+		s.Losses++
+		s.GrossLoss += pl
+	}
+}
+
+// SummarizeTrades totals trades overall and per instrument, instruments in
+// name order.
+func SummarizeTrades(trades []TradeRecord) TradeSummary {
+	var sum TradeSummary
+	byInst := map[string]*InstrumentStats{}
+	for _, t := range trades {
+		sum.add(t)
+		is, ok := byInst[t.Instrument]
+		if !ok {
+			is = &InstrumentStats{Instrument: t.Instrument}
+			byInst[t.Instrument] = is
+		}
+		is.add(t)
+	}
+	for _, is := range byInst {
+		sum.ByInstrument = append(sum.ByInstrument, *is)
+	}
+	sort.Slice(sum.ByInstrument, func(i, j int) bool {
+		return sum.ByInstrument[i].Instrument < sum.ByInstrument[j].Instrument
+	})
+	return sum
+}
@@ -0,0 +1,44 @@
+package journal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestSummarizeTrades(t *testing.T) {
+	sum := SummarizeTrades([]TradeRecord{
+		{Instrument: "USDJPY", RealizedPL: types.MoneyFromFloat(30)},
+		{Instrument: "EURUSD", RealizedPL: types.MoneyFromFloat(-10)},
+		{Instrument: "EURUSD", RealizedPL: types.MoneyFromFloat(20)},
+		{Instrument: "EURUSD", RealizedPL: 0},
+	})
+
+	assert.Equal(t, 4, sum.Trades)
+	assert.Equal(t, 2, sum.Wins)
+	assert.Equal(t, 1, sum.Losses)
+	assert.Equal(t, 0.5, sum.WinRate())
+	assert.Equal(t, types.MoneyFromFloat(40), sum.NetPL)
+	assert.Equal(t, types.MoneyFromFloat(50), sum.GrossWin)
+	assert.Equal(t, types.MoneyFromFloat(-10), sum.GrossLoss)
+	assert.InDelta(t, 5.0, sum.ProfitFactor(), 1e-9)
+	assert.Equal(t, types.MoneyFromFloat(30), sum.BestTrade)
+	assert.Equal(t, types.MoneyFromFloat(-10), sum.WorstTrade)
+
+	require.Len(t, sum.ByInstrument, 2)
+	assert.Equal(t, "EURUSD", sum.ByInstrument[0].Instrument)
+	assert.Equal(t, 3, sum.ByInstrument[0].Trades)
+	assert.Equal(t, types.MoneyFromFloat(10), sum.ByInstrument[0].NetPL)
+	assert.Equal(t, "USDJPY", sum.ByInstrument[1].Instrument)
+	assert.Zero(t, sum.ByInstrument[1].ProfitFactor(), "no losses")
+}
+
+func TestSummarizeTrades_Empty(t *testing.T) {
+	sum := SummarizeTrades(nil)
+	assert.Zero(t, sum.Trades)
+	assert.Zero(t, sum.WinRate())
+	assert.Empty(t, sum.ByInstrument)
+}
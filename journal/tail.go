@@ -0,0 +1,132 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Follower reads the records appended to a trades journal and its equity
+// journal (JSONL, or CSV for a .csv path) while another process is still
+// writing them, like tail -f. Each Poll returns what was added since the
+// last one; only whole lines are read, so a record caught half written is
+// picked up by the next Poll.
+type Follower struct {
+	trades    fileFollower
+	equity    fileFollower
+	tradesCSV bool
+	equityCSV bool
+}
+
+// NewFollower returns a Follower of the journals at tradesPath and
+// equityPath. Either may be empty, or not exist yet: a journal is read
+// once it appears.
+func NewFollower(tradesPath, equityPath string) *Follower {
+	return &Follower{
+		trades:    fileFollower{path: tradesPath},
+		equity:    fileFollower{path: equityPath},
+		tradesCSV: isCSVPath(tradesPath),
+		equityCSV: isCSVPath(equityPath),
+	}
+}
+
+// Poll returns the trades and equity snapshots written since the last
+// Poll; the first Poll returns everything already in the journals. A
+// journal that shrinks was truncated or recreated, as a new replay over
+// the same journal does, and is read again from its start. Malformed
+// lines are skipped like ReadTrades does.
+func (f *Follower) Poll() ([]TradeRecord, []EquitySnapshot, error) {
+	tradeLines, err := f.trades.next()
+	if err != nil {
+		return nil, nil, err
+	}
+	equityLines, err := f.equity.next()
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseLines(tradeLines, f.tradesCSV, tradeCSVHeader[0], parseTradeCSVRow),
+		parseLines(equityLines, f.equityCSV, equityCSVHeader[0], parseEquityCSVRow), nil
+}
+
+// fileFollower hands out the whole lines appended to one file.
+type fileFollower struct {
+	path    string
+	off     int64
+	partial []byte
+}
+
+func (f *fileFollower) next() ([][]byte, error) {
+	if f.path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		f.off, f.partial = 0, nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < f.off {
+		f.off, f.partial = 0, nil
+	}
+	if info.Size() == f.off {
+		return nil, nil
+	}
+	buf := make([]byte, info.Size()-f.off)
+	n, err := file.ReadAt(buf, f.off)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	f.off += int64(n)
+
+	data := append(f.partial, buf[:n]...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		f.partial = data
+		return nil, nil
+	}
+	f.partial = append([]byte(nil), data[end+1:]...)
+
+	var lines [][]byte
+	for _, line := range bytes.Split(data[:end], []byte{'\n'}) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func parseLines[T any](lines [][]byte, isCSV bool, firstColumn string, parse func(csvFields) (T, error)) []T {
+	var records []T
+	for _, line := range lines {
+		var (
+			rec T
+			err error
+		)
+		if isCSV {
+			var row []string
+			row, err = csv.NewReader(bytes.NewReader(line)).Read()
+			if err != nil || len(row) == 0 || row[0] == firstColumn {
+				continue
+			}
+			rec, err = parse(csvFields(row))
+		} else {
+			err = json.Unmarshal(line, &rec)
+		}
+		if err == nil {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
@@ -0,0 +1,92 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestFollower_JSONL(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath, equityPath := filepath.Join(dir, "run-trades.jsonl"), filepath.Join(dir, "run-equity.jsonl")
+
+	f := NewFollower(tradesPath, equityPath)
+	trades, equity, err := f.Poll()
+	require.NoError(t, err, "journals that do not exist yet are not an error")
+	assert.Empty(t, trades)
+	assert.Empty(t, equity)
+
+	j, err := NewJSON(tradesPath, equityPath)
+	require.NoError(t, err)
+	require.NoError(t, j.RecordTrade(TradeRecord{TradeID: "T1", Instrument: "EURUSD"}))
+	require.NoError(t, j.RecordEquity(EquitySnapshot{Equity: types.MoneyFromFloat(1000)}))
+
+	trades, equity, err = f.Poll()
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	assert.Equal(t, "T1", trades[0].TradeID)
+	require.Len(t, equity, 1)
+	assert.Equal(t, types.MoneyFromFloat(1000), equity[0].Equity)
+
+	trades, _, err = f.Poll()
+	require.NoError(t, err)
+	assert.Empty(t, trades, "nothing new")
+
+	// A line caught half written is held back until it is finished.
+	file, err := os.OpenFile(tradesPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"TradeID":"T2",`)
+	require.NoError(t, err)
+	trades, _, err = f.Poll()
+	require.NoError(t, err)
+	assert.Empty(t, trades)
+	_, err = file.WriteString(`"Instrument":"USDJPY"}` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	trades, _, err = f.Poll()
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	assert.Equal(t, "T2", trades[0].TradeID)
+	require.NoError(t, j.Close())
+
+	// A new run over the same journal truncates it; it is read from the top.
+	j, err = NewJSON(tradesPath, equityPath)
+	require.NoError(t, err)
+	require.NoError(t, j.RecordTrade(TradeRecord{TradeID: "T3"}))
+	require.NoError(t, j.Close())
+	trades, _, err = f.Poll()
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	assert.Equal(t, "T3", trades[0].TradeID)
+}
+
+func TestFollower_CSV(t *testing.T) {
+	dir := t.TempDir()
+	tradesPath, equityPath := filepath.Join(dir, "trades.csv"), filepath.Join(dir, "equity.csv")
+	j, err := NewCSV(tradesPath, equityPath)
+	require.NoError(t, err)
+	defer j.Close()
+
+	f := NewFollower(tradesPath, equityPath)
+	require.NoError(t, j.RecordTrade(TradeRecord{
+		TradeID:    "T1",
+		Instrument: "EURUSD",
+		Units:      1000,
+		EntryPrice: types.PriceFromFloat(1.085),
+		RealizedPL: types.MoneyFromFloat(-2.5),
+	}))
+	require.NoError(t, j.RecordEquity(EquitySnapshot{Balance: types.MoneyFromFloat(997.5)}))
+
+	trades, equity, err := f.Poll()
+	require.NoError(t, err)
+	require.Len(t, trades, 1, "the header row is skipped")
+	assert.Equal(t, types.PriceFromFloat(1.085), trades[0].EntryPrice)
+	assert.Equal(t, types.MoneyFromFloat(-2.5), trades[0].RealizedPL)
+	require.Len(t, equity, 1)
+	assert.Equal(t, types.MoneyFromFloat(997.5), equity[0].Balance)
+}
@@ -0,0 +1,134 @@
+package journal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rustyeddy/trader/idgen"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Chart marker directions: the side of the fill the marker stands for.
+const (
+	MarkerBuy  = "buy"
+	MarkerSell = "sell"
+)
+
+// ChartMarker is one fill to overlay on a price chart: a trade's entry or
+// its exit, at the fill's time and price.
+type ChartMarker struct {
+	Time       types.Timestamp
+	Price      types.Price
+	Direction  string // MarkerBuy or MarkerSell
+	Label      string
+	Instrument string
+	TradeID    string
+	Entry      bool // false for the exit
+}
+
+// TradeMarkers returns an entry and an exit marker for every trade on
+// instrument (all trades when instrument is empty), in time order with an
+// entry ahead of an exit at the same time. A long enters with a buy and
+// exits with a sell; a short the reverse.
+func TradeMarkers(trades []TradeRecord, instrument string) []ChartMarker {
+	var out []ChartMarker
+	for _, t := range trades {
+		if instrument != "" && t.Instrument != instrument {
+			continue
+		}
+		side, in, outDir := "Long", MarkerBuy, MarkerSell
+		if t.Units < 0 {
+			side, in, outDir = "Short", MarkerSell, MarkerBuy
+		}
+		id := idgen.ShortDisplayID(t.TradeID)
+		exit := fmt.Sprintf("%s exit %s %+.2f", side, id, t.RealizedPL.Float64())
+		if t.Reason != "" {
+			exit += " " + t.Reason
+		}
+		out = append(out,
+			ChartMarker{Time: t.OpenTime, Price: t.EntryPrice, Direction: in, Label: side + " entry " + id,
+				Instrument: t.Instrument, TradeID: t.TradeID, Entry: true},
+			ChartMarker{Time: t.CloseTime, Price: t.ExitPrice, Direction: outDir, Label: exit,
+				Instrument: t.Instrument, TradeID: t.TradeID},
+		)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Time != out[j].Time {
+			return out[i].Time < out[j].Time
+		}
+		return out[i].Entry && !out[j].Entry
+	})
+	return out
+}
+
+// markerCSVHeader is the column layout WriteMarkersCSV writes. timestamp
+// is Unix seconds, the time format TradingView's CSV import reads.
+var markerCSVHeader = []string{"timestamp", "price", "direction", "label"}
+
+// WriteMarkersCSV writes markers as a CSV annotation list, one row per
+// marker with a header, prices at the instrument's display precision.
+func WriteMarkersCSV(w io.Writer, markers []ChartMarker) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(markerCSVHeader); err != nil {
+		return err
+	}
+	for _, m := range markers {
+		row := []string{
+			strconv.FormatInt(m.Time.Int64(), 10),
+			market.FormatPrice(m.Instrument, m.Price.Float64()),
+			m.Direction,
+			m.Label,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// pineMaxLabels is the most labels a Pine script can keep on a chart.
+const pineMaxLabels = 500
+
+// FormatMarkersPine renders markers as a Pine Script v5 indicator that
+// draws each one as a label on the chart: buys below the bar in green,
+// sells above it in red. Pine keeps at most 500 labels, so only the latest
+// 500 markers are drawn; title names the indicator.
+func FormatMarkersPine(title string, markers []ChartMarker) string {
+	if len(markers) > pineMaxLabels {
+		markers = markers[len(markers)-pineMaxLabels:]
+	}
+	ts := make([]string, len(markers))
+	px := make([]string, len(markers))
+	buy := make([]string, len(markers))
+	txt := make([]string, len(markers))
+	for i, m := range markers {
+		ts[i] = strconv.FormatInt(m.Time.Int64()*1000, 10) // Pine times are Unix milliseconds
+		px[i] = market.FormatPrice(m.Instrument, m.Price.Float64())
+		buy[i] = strconv.FormatBool(m.Direction == MarkerBuy)
+		txt[i] = strconv.Quote(m.Label)
+	}
+
+	var b strings.Builder
+	b.WriteString("//@version=5\n")
+	fmt.Fprintf(&b, "indicator(%s, overlay=true, max_labels_count=%d)\n\n", strconv.Quote(title), pineMaxLabels)
+	if len(markers) == 0 {
+		return b.String()
+	}
+	fmt.Fprintf(&b, "var ts = array.from(%s)\n", strings.Join(ts, ", "))
+	fmt.Fprintf(&b, "var px = array.from(%s)\n", strings.Join(px, ", "))
+	fmt.Fprintf(&b, "var buy = array.from(%s)\n", strings.Join(buy, ", "))
+	fmt.Fprintf(&b, "var txt = array.from(%s)\n\n", strings.Join(txt, ", "))
+	b.WriteString("if barstate.isfirst\n")
+	b.WriteString("    for i = 0 to array.size(ts) - 1\n")
+	b.WriteString("        isBuy = array.get(buy, i)\n")
+	b.WriteString("        label.new(array.get(ts, i), array.get(px, i), array.get(txt, i), xloc=xloc.bar_time,\n")
+	b.WriteString("             style=isBuy ? label.style_label_up : label.style_label_down,\n")
+	b.WriteString("             color=isBuy ? color.green : color.red, textcolor=color.white, size=size.small)\n")
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package journal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+func markerTrades() []TradeRecord {
+	return []TradeRecord{
+		{
+			TradeID: "short-1", Instrument: "EURUSD", Units: -10_000,
+			EntryPrice: types.PriceFromFloat(1.10500), ExitPrice: types.PriceFromFloat(1.10200),
+			OpenTime: 1_704_448_800, CloseTime: 1_704_456_000,
+			RealizedPL: types.MoneyFromFloat(30), Reason: "TakeProfit",
+		},
+		{
+			TradeID: "long-1", Instrument: "EURUSD", Units: 10_000,
+			EntryPrice: types.PriceFromFloat(1.10000), ExitPrice: types.PriceFromFloat(1.09900),
+			OpenTime: 1_704_445_200, CloseTime: 1_704_448_800,
+			RealizedPL: types.MoneyFromFloat(-10),
+		},
+		{
+			TradeID: "jpy-1", Instrument: "USDJPY", Units: 1_000,
+			EntryPrice: types.PriceFromFloat(145.123), ExitPrice: types.PriceFromFloat(145.5),
+			OpenTime: 1_704_445_200, CloseTime: 1_704_448_800,
+		},
+	}
+}
+
+func TestTradeMarkers(t *testing.T) {
+	ms := TradeMarkers(markerTrades(), "EURUSD")
+	require.Len(t, ms, 4)
+
+	assert.Equal(t, types.Timestamp(1_704_445_200), ms[0].Time)
+	assert.Equal(t, MarkerBuy, ms[0].Direction)
+	assert.Equal(t, "Long entry long-1", ms[0].Label)
+
+	// The long's exit and the short's entry share a time: entry first.
+	assert.True(t, ms[1].Entry)
+	assert.Equal(t, MarkerSell, ms[1].Direction, "a short enters with a sell")
+	assert.Equal(t, "Long exit long-1 -10.00", ms[2].Label)
+	assert.Equal(t, MarkerSell, ms[2].Direction)
+
+	assert.Equal(t, MarkerBuy, ms[3].Direction, "a short exits with a buy")
+	assert.Equal(t, "Short exit short-1 +30.00 TakeProfit", ms[3].Label)
+	assert.Equal(t, types.PriceFromFloat(1.10200), ms[3].Price)
+
+	assert.Len(t, TradeMarkers(markerTrades(), ""), 6, "empty instrument keeps every trade")
+}
+
+func TestWriteMarkersCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteMarkersCSV(&buf, TradeMarkers(markerTrades(), "USDJPY")))
+	assert.Equal(t, "timestamp,price,direction,label\n"+
+		"1704445200,145.123,buy,Long entry jpy-1\n"+
+		"1704448800,145.500,sell,Long exit jpy-1 +0.00\n", buf.String())
+}
+
+func TestFormatMarkersPine(t *testing.T) {
+	out := FormatMarkersPine("EURUSD trades", TradeMarkers(markerTrades(), "EURUSD"))
+	assert.True(t, strings.HasPrefix(out, "//@version=5\nindicator(\"EURUSD trades\", overlay=true, max_labels_count=500)\n"))
+	assert.Contains(t, out, "var ts = array.from(1704445200000, 1704448800000, 1704448800000, 1704456000000)\n")
+	assert.Contains(t, out, "var px = array.from(1.10000, 1.10500, 1.09900, 1.10200)\n")
+	assert.Contains(t, out, "var buy = array.from(true, false, false, true)\n")
+	assert.Contains(t, out, `var txt = array.from("Long entry long-1", "Short entry short-1", "Long exit long-1 -10.00", "Short exit short-1 +30.00 TakeProfit")`)
+	assert.Contains(t, out, "label.new(array.get(ts, i), array.get(px, i), array.get(txt, i), xloc=xloc.bar_time,")
+
+	empty := FormatMarkersPine("none", nil)
+	assert.NotContains(t, empty, "array.from", "no markers draws nothing")
+}
+
+func TestFormatMarkersPine_KeepsLatestLabels(t *testing.T) {
+	ms := make([]ChartMarker, pineMaxLabels+2)
+	for i := range ms {
+		ms[i] = ChartMarker{Time: types.Timestamp(i + 1), Instrument: "EURUSD", Direction: MarkerBuy}
+	}
+	out := FormatMarkersPine("many", ms)
+	assert.NotContains(t, out, "array.from(1000, ")
+	assert.Contains(t, out, "array.from(3000, ")
+}
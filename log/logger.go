@@ -75,6 +75,7 @@ var (
 	mu sync.Mutex
 
 	level        = new(slog.LevelVar) // dynamic; adjusted by Setup
+	format       string               // handler format from the last Setup
 	handlerState *switchHandlerState
 	defLog       *slog.Logger
 	sinkClosers  []io.Closer
@@ -137,6 +138,7 @@ func Setup(cfg LogConfig) error {
 
 	// --- log level ---
 	level.Set(parseLevel(cfg.Level))
+	format = strings.ToLower(cfg.Format)
 
 	if cfg.File == "" && !cfg.Stdout && !cfg.Syslog {
 		cfg.File = defaultLogFile
@@ -0,0 +1,67 @@
+package log
+
+// This file implements per-run log files: a logger whose records go to the
+// configured sinks as usual and are also written to a file of their own, so
+// each backtest leaves a reviewable log next to its report.
+//
+// Typical usage:
+//
+//	rl, err := OpenRunLog("reports/ema-cross-1a2b3c.log", "run", "ema-cross")
+//	if err != nil { … }
+//	defer rl.Close()
+//	rl.Info("backtest start")
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// RunLog is a logger that also writes to a file of its own. The file uses
+// the level and format of the last Setup call. Close it when the run ends.
+type RunLog struct {
+	*slog.Logger
+	f *os.File
+}
+
+// OpenRunLog creates (truncating) the log file at path and returns a
+// logger that writes to it and to the configured sinks. attrs are added
+// to every record, as with slog.Logger.With.
+func OpenRunLog(path string, attrs ...any) (*RunLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("log: create run log directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("log: open run log %q: %w", path, err)
+	}
+
+	mu.Lock()
+	opts := &slog.HandlerOptions{Level: level}
+	var fh slog.Handler = slog.NewTextHandler(f, opts)
+	if format == "json" {
+		fh = slog.NewJSONHandler(f, opts)
+	}
+	mu.Unlock()
+
+	h := &multiHandler{handlers: []slog.Handler{&switchHandler{state: handlerState}, fh}}
+	return &RunLog{Logger: slog.New(h).With(attrs...), f: f}, nil
+}
+
+// Path returns the run log's file path.
+func (r *RunLog) Path() string {
+	if r == nil || r.f == nil {
+		return ""
+	}
+	return r.f.Name()
+}
+
+// Close closes the run log's file. Records logged afterwards still reach
+// the configured sinks.
+func (r *RunLog) Close() error {
+	if r == nil || r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
@@ -0,0 +1,56 @@
+package log_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tlog "github.com/rustyeddy/trader/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenRunLog_WritesRunFileAndSharedSinks(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "trader.log")
+	require.NoError(t, tlog.Setup(tlog.LogConfig{Level: "info", File: shared}))
+
+	rl, err := tlog.OpenRunLog(filepath.Join(dir, "reports", "run-a.log"), "run", "run-a")
+	require.NoError(t, err)
+	rl.Debug("below level")
+	rl.Info("bar processed", "n", 3)
+	require.NoError(t, rl.Close())
+	tlog.Info("after run")
+
+	run, err := os.ReadFile(rl.Path())
+	require.NoError(t, err)
+	assert.Contains(t, string(run), "bar processed")
+	assert.Contains(t, string(run), "run=run-a")
+	assert.NotContains(t, string(run), "below level")
+	assert.NotContains(t, string(run), "after run")
+
+	all, err := os.ReadFile(shared)
+	require.NoError(t, err)
+	assert.Contains(t, string(all), "bar processed")
+	assert.Contains(t, string(all), "after run")
+}
+
+func TestOpenRunLog_FollowsJSONFormat(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, tlog.Setup(tlog.LogConfig{Level: "debug", Format: "json", File: filepath.Join(dir, "trader.log")}))
+
+	rl, err := tlog.OpenRunLog(filepath.Join(dir, "run.log"))
+	require.NoError(t, err)
+	rl.Warn("spread filter", "pips", 2.5)
+	require.NoError(t, rl.Close())
+
+	data, err := os.ReadFile(rl.Path())
+	require.NoError(t, err)
+	var rec map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(string(data))), &rec))
+	assert.Equal(t, "spread filter", rec["msg"])
+	assert.Equal(t, "WARN", rec["level"])
+}
@@ -0,0 +1,206 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// CandleFault names the sanity check a candle failed.
+type CandleFault string
+
+const (
+	CandleNonPositive   CandleFault = "non-positive"    // a price <= 0
+	CandleInverted      CandleFault = "inverted"        // High < Low
+	CandleHighBelowBody CandleFault = "high-below-body" // High < max(Open, Close)
+	CandleLowAboveBody  CandleFault = "low-above-body"  // Low > min(Open, Close)
+	CandleImplausible   CandleFault = "implausible"     // price outside the instrument's plausible range
+	CandleOutOfOrder    CandleFault = "out-of-order"    // timestamp not after the previous candle's
+	CandleBadSpread     CandleFault = "bad-spread"      // negative spread, or MaxSpread < AvgSpread
+)
+
+// CandleViolation is one failed check on one candle.
+type CandleViolation struct {
+	Timestamp types.Timestamp `json:"timestamp"`
+	Fault     CandleFault     `json:"fault"`
+	Detail    string          `json:"detail"`
+	Repaired  bool            `json:"repaired,omitempty"`
+}
+
+func (v CandleViolation) String() string {
+	s := fmt.Sprintf("%s %s: %s", v.Timestamp, v.Fault, v.Detail)
+	if v.Repaired {
+		s += " (repaired)"
+	}
+	return s
+}
+
+// CandleUsable reports whether a candle with violations vs can still be
+// fed to indicators: every price or ordering fault was repaired. Spread
+// faults leave the OHLC intact and don't disqualify a candle.
+func CandleUsable(vs []CandleViolation) bool {
+	for _, v := range vs {
+		if !v.Repaired && v.Fault != CandleBadSpread {
+			return false
+		}
+	}
+	return true
+}
+
+// CandleChecker validates candles one at a time in stream order. It checks
+// OHLC shape, positive prices, spread consistency, strictly increasing
+// timestamps (zero timestamps are timeless and skip the check), and, when
+// Instrument is a known instrument, that prices fall in its plausible range.
+//
+// With Repair set, a candle whose only shape problem is swapped High and
+// Low is fixed in place and its violation marked Repaired. Nothing else is
+// repaired: the other faults have no unambiguous fix.
+//
+// A CandleChecker is stateful and not safe for concurrent use.
+type CandleChecker struct {
+	Instrument string
+	Repair     bool
+
+	prev   types.Timestamp
+	lo, hi types.Price
+	ranged bool
+	init   bool
+}
+
+// PlausibleRange returns bounds no real quote for inst falls outside:
+// 100 to 100,000 pips, i.e. 0.01-10 for EURUSD and 1-1000 for USDJPY.
+// A price outside it is a scale or parsing error, not a market move.
+func (inst *Instrument) PlausibleRange() (lo, hi types.Price) {
+	ppp := inst.PriceUnitsPerPip()
+	return ppp * 100, ppp * 100_000
+}
+
+// Check runs every check on c, repairing it in place when allowed, and
+// returns its violations (nil when it passed).
+func (k *CandleChecker) Check(c *Candle) []CandleViolation {
+	if !k.init {
+		k.init = true
+		if inst := GetInstrument(k.Instrument); inst != nil {
+			k.lo, k.hi = inst.PlausibleRange()
+			k.ranged = true
+		}
+	}
+
+	var out []CandleViolation
+	add := func(f CandleFault, repaired bool, format string, args ...any) {
+		out = append(out, CandleViolation{
+			Timestamp: c.Timestamp,
+			Fault:     f,
+			Detail:    fmt.Sprintf(format, args...),
+			Repaired:  repaired,
+		})
+	}
+
+	if c.Timestamp != 0 {
+		if k.prev != 0 && c.Timestamp <= k.prev {
+			add(CandleOutOfOrder, false, "after %s", k.prev)
+		} else {
+			k.prev = c.Timestamp
+		}
+	}
+
+	if c.Open <= 0 || c.High <= 0 || c.Low <= 0 || c.Close <= 0 {
+		add(CandleNonPositive, false, "ohlc %s", c)
+		return out
+	}
+
+	// A candle that is valid once High and Low trade places reports only
+	// the inversion, not the body faults the swap explains.
+	swapOnly := false
+	if c.High < c.Low {
+		swapped := *c
+		swapped.High, swapped.Low = c.Low, c.High
+		swapOnly = swapped.Validate()
+		fix := k.Repair && swapOnly
+		add(CandleInverted, fix, "high %s < low %s", c.High, c.Low)
+		if fix {
+			*c = swapped
+		}
+	}
+	if !swapOnly && !c.Validate() {
+		if body := max(c.Open, c.Close); c.High < body {
+			add(CandleHighBelowBody, false, "high %s < body %s", c.High, body)
+		}
+		if body := min(c.Open, c.Close); c.Low > body {
+			add(CandleLowAboveBody, false, "low %s > body %s", c.Low, body)
+		}
+	}
+
+	if k.ranged && (c.Low < k.lo || c.High > k.hi) {
+		add(CandleImplausible, false, "range %s-%s outside %s-%s", c.Low, c.High, k.lo, k.hi)
+	}
+
+	if c.AvgSpread < 0 || c.MaxSpread < 0 || c.MaxSpread < c.AvgSpread {
+		add(CandleBadSpread, false, "avg %s max %s", c.AvgSpread, c.MaxSpread)
+	}
+	return out
+}
+
+// maxKeptViolations bounds how many violations a CheckedCandleIterator
+// keeps verbatim; the per-fault counts keep counting past it.
+const maxKeptViolations = 100
+
+// CheckedCandleIterator passes every candle of an underlying iterator
+// through a CandleChecker. Repaired candles are yielded fixed; candles
+// that aren't CandleUsable are dropped so they can't reach indicators.
+type CheckedCandleIterator struct {
+	CandleIterator
+
+	chk        *CandleChecker
+	violations []CandleViolation
+	counts     map[CandleFault]int
+	dropped    int
+}
+
+// NewCheckedCandleIterator wraps it with chk.
+func NewCheckedCandleIterator(it CandleIterator, chk *CandleChecker) *CheckedCandleIterator {
+	return &CheckedCandleIterator{CandleIterator: it, chk: chk, counts: make(map[CandleFault]int)}
+}
+
+// Next yields the next usable candle.
+func (it *CheckedCandleIterator) Next() (Candle, bool) {
+	for {
+		c, ok := it.CandleIterator.Next()
+		if !ok {
+			return c, false
+		}
+		vs := it.chk.Check(&c)
+		for _, v := range vs {
+			it.counts[v.Fault]++
+			if len(it.violations) < maxKeptViolations {
+				it.violations = append(it.violations, v)
+			}
+		}
+		if CandleUsable(vs) {
+			return c, true
+		}
+		it.dropped++
+	}
+}
+
+// Violations returns the first violations seen, up to a fixed cap.
+func (it *CheckedCandleIterator) Violations() []CandleViolation { return it.violations }
+
+// Dropped returns how many candles were withheld as unusable.
+func (it *CheckedCandleIterator) Dropped() int { return it.dropped }
+
+// Counts returns how many violations of each fault were seen.
+func (it *CheckedCandleIterator) Counts() map[CandleFault]int { return it.counts }
+
+// FormatCandleFaults renders fault counts as "inverted=2 implausible=1",
+// sorted by fault name, for summary lines.
+func FormatCandleFaults(counts map[CandleFault]int) string {
+	parts := make([]string, 0, len(counts))
+	for f, n := range counts {
+		parts = append(parts, fmt.Sprintf("%s=%d", f, n))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,139 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentPlausibleRange(t *testing.T) {
+	t.Parallel()
+
+	lo, hi := GetInstrument("EURUSD").PlausibleRange()
+	assert.Equal(t, types.PriceFromFloat(0.01), lo)
+	assert.Equal(t, types.PriceFromFloat(10), hi)
+
+	lo, hi = GetInstrument("USDJPY").PlausibleRange()
+	assert.Equal(t, types.PriceFromFloat(1), lo)
+	assert.Equal(t, types.PriceFromFloat(1000), hi)
+}
+
+func faults(vs []CandleViolation) []CandleFault {
+	var out []CandleFault
+	for _, v := range vs {
+		out = append(out, v.Fault)
+	}
+	return out
+}
+
+func TestCandleChecker_Check(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		c    Candle
+		want []CandleFault
+	}{
+		{"clean", Candle{Open: 110000, High: 110100, Low: 109900, Close: 110050, AvgSpread: 10, MaxSpread: 20}, nil},
+		{"non-positive", Candle{Open: 110000, High: 110100, Low: 0, Close: 110050}, []CandleFault{CandleNonPositive}},
+		{"inverted", Candle{Open: 110000, High: 109900, Low: 110100, Close: 110050}, []CandleFault{CandleInverted}},
+		{"high below body", Candle{Open: 110000, High: 110020, Low: 109900, Close: 110050}, []CandleFault{CandleHighBelowBody}},
+		{"low above body", Candle{Open: 110000, High: 110100, Low: 110020, Close: 110050}, []CandleFault{CandleLowAboveBody}},
+		{"implausible", Candle{Open: 500, High: 510, Low: 490, Close: 505}, []CandleFault{CandleImplausible}},
+		{"bad spread", Candle{Open: 110000, High: 110100, Low: 109900, Close: 110050, AvgSpread: 20, MaxSpread: 10}, []CandleFault{CandleBadSpread}},
+	}
+	for _, tt := range tests {
+		k := &CandleChecker{Instrument: "EURUSD"}
+		c := tt.c
+		vs := k.Check(&c)
+		assert.Equal(t, tt.want, faults(vs), tt.name)
+		assert.Equal(t, tt.c, c, "%s: checking without Repair must not modify the candle", tt.name)
+	}
+
+	// An unknown instrument skips the range check.
+	k := &CandleChecker{}
+	assert.Empty(t, k.Check(&Candle{Open: 500, High: 510, Low: 490, Close: 505}))
+}
+
+func TestCandleChecker_RepairsInvertedHighLow(t *testing.T) {
+	t.Parallel()
+
+	k := &CandleChecker{Instrument: "EURUSD", Repair: true}
+	c := Candle{Open: 110000, High: 109900, Low: 110100, Close: 110050}
+	vs := k.Check(&c)
+	require.Len(t, vs, 1)
+	assert.Equal(t, CandleInverted, vs[0].Fault)
+	assert.True(t, vs[0].Repaired)
+	assert.True(t, CandleUsable(vs))
+	assert.Equal(t, types.Price(110100), c.High)
+	assert.Equal(t, types.Price(109900), c.Low)
+
+	// Swapping wouldn't make this one valid, so it's left alone.
+	c = Candle{Open: 110200, High: 109900, Low: 110100, Close: 110050}
+	vs = k.Check(&c)
+	assert.Equal(t, []CandleFault{CandleInverted, CandleHighBelowBody, CandleLowAboveBody}, faults(vs))
+	assert.False(t, vs[0].Repaired)
+	assert.False(t, CandleUsable(vs))
+	assert.Equal(t, types.Price(109900), c.High)
+}
+
+func TestCandleChecker_Ordering(t *testing.T) {
+	t.Parallel()
+
+	k := &CandleChecker{}
+	ok := Candle{Open: 110000, High: 110100, Low: 109900, Close: 110050}
+	at := func(ts types.Timestamp) []CandleFault {
+		c := ok
+		c.Timestamp = ts
+		return faults(k.Check(&c))
+	}
+	assert.Empty(t, at(3600))
+	assert.Empty(t, at(7200))
+	assert.Equal(t, []CandleFault{CandleOutOfOrder}, at(7200))
+	assert.Equal(t, []CandleFault{CandleOutOfOrder}, at(3600))
+	assert.Empty(t, at(0), "timeless candles skip the ordering check")
+	assert.Empty(t, at(10800))
+}
+
+type candleSlice struct {
+	candles []Candle
+	i       int
+}
+
+func (s *candleSlice) Next() (Candle, bool) {
+	if s.i >= len(s.candles) {
+		return Candle{}, false
+	}
+	s.i++
+	return s.candles[s.i-1], true
+}
+func (s *candleSlice) Err() error   { return nil }
+func (s *candleSlice) Close() error { return nil }
+
+func TestCheckedCandleIterator(t *testing.T) {
+	t.Parallel()
+
+	src := &candleSlice{candles: []Candle{
+		{Timestamp: 3600, Open: 110000, High: 110100, Low: 109900, Close: 110050},
+		{Timestamp: 7200, Open: 110000, High: 109900, Low: 110100, Close: 110050},                              // inverted, repaired
+		{Timestamp: 10800, Open: 500, High: 510, Low: 490, Close: 505},                                         // implausible, dropped
+		{Timestamp: 10800, Open: 110000, High: 110100, Low: 109900, Close: 110050},                             // out of order, dropped
+		{Timestamp: 14400, Open: 110000, High: 110100, Low: 109900, Close: 110050, AvgSpread: -1},              // bad spread, kept
+		{Timestamp: 18000, Open: 110000, High: 110020, Low: 109900, Close: 110050, AvgSpread: 1, MaxSpread: 2}, // high below body, dropped
+	}}
+	it := NewCheckedCandleIterator(src, &CandleChecker{Instrument: "EURUSD", Repair: true})
+
+	var got []types.Timestamp
+	for c, ok := it.Next(); ok; c, ok = it.Next() {
+		require.True(t, c.Validate(), "yielded candle at %s must be well-formed", c.Timestamp)
+		got = append(got, c.Timestamp)
+	}
+	assert.Equal(t, []types.Timestamp{3600, 7200, 14400}, got)
+	assert.Equal(t, 3, it.Dropped())
+	assert.Equal(t, "bad-spread=1 high-below-body=1 implausible=1 inverted=1 out-of-order=1", FormatCandleFaults(it.Counts()))
+	require.Len(t, it.Violations(), 5)
+	assert.True(t, it.Violations()[0].Repaired)
+	assert.NoError(t, it.Err())
+}
@@ -0,0 +1,181 @@
+package market
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// AlignCloses lines several candle series up on their common timestamps.
+// It returns the shared timestamps in ascending order and, for each input
+// series, the close at each of them. Zero-filled gap candles and candles
+// with a non-positive close are ignored, so a bar missing from any one
+// series is dropped from all of them.
+func AlignCloses(series ...[]Candle) ([]types.Timestamp, [][]types.Price) {
+	if len(series) == 0 {
+		return nil, nil
+	}
+
+	byTS := make([]map[types.Timestamp]types.Price, len(series))
+	for i, candles := range series {
+		byTS[i] = make(map[types.Timestamp]types.Price, len(candles))
+		for j := range candles {
+			c := &candles[j]
+			if c.IsZero() || c.Close <= 0 {
+				continue
+			}
+			byTS[i][c.Timestamp] = c.Close
+		}
+	}
+
+	var common []types.Timestamp
+	for ts := range byTS[0] {
+		inAll := true
+		for _, m := range byTS[1:] {
+			if _, ok := m[ts]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			common = append(common, ts)
+		}
+	}
+	sort.Slice(common, func(i, j int) bool { return common[i] < common[j] })
+
+	closes := make([][]types.Price, len(series))
+	for i, m := range byTS {
+		closes[i] = make([]types.Price, len(common))
+		for j, ts := range common {
+			closes[i][j] = m[ts]
+		}
+	}
+	return common, closes
+}
+
+// Returns converts a close series into simple bar-over-bar returns,
+// RateScale-scaled: (c[i] - c[i-1]) / c[i-1]. The result is one shorter
+// than closes. A non-positive previous close yields a zero return.
+func Returns(closes []types.Price) []types.Rate {
+	if len(closes) < 2 {
+		return nil
+	}
+	out := make([]types.Rate, len(closes)-1)
+	for i := 1; i < len(closes); i++ {
+		prev := int64(closes[i-1])
+		if prev <= 0 {
+			continue
+		}
+		out[i-1] = types.Rate((int64(closes[i]) - prev) * int64(types.RateScale) / prev)
+	}
+	return out
+}
+
+// Correlation returns the Pearson correlation of a and b, RateScale-scaled
+// (RateScale = +1, -RateScale = -1). Only the first min(len(a), len(b))
+// values are used. ok is false when there are fewer than two values or
+// either series is constant, in which case correlation is undefined.
+func Correlation(a, b []types.Rate) (types.Rate, bool) {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	var s corrSums
+	for i := 0; i < n; i++ {
+		s.add(a[i], b[i])
+	}
+	return s.corr()
+}
+
+// RollingCorrelation returns the correlation of a and b over every window
+// of the given length, one value per window end: out[i] covers
+// a[i : i+window]. Windows where correlation is undefined yield 0. It
+// returns nil when window < 2 or the series are shorter than window.
+func RollingCorrelation(a, b []types.Rate, window int) []types.Rate {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	if window < 2 || n < window {
+		return nil
+	}
+
+	out := make([]types.Rate, 0, n-window+1)
+	var s corrSums
+	for i := 0; i < n; i++ {
+		s.add(a[i], b[i])
+		if i >= window {
+			s.remove(a[i-window], b[i-window])
+		}
+		if i >= window-1 {
+			c, _ := s.corr()
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// corrSums holds the running sums a Pearson correlation needs. The sums
+// themselves fit in int64 for any realistic series (returns are a few
+// percent at most, i.e. ~1e4 at RateScale); the cross terms in corr are
+// formed with big.Int because n*sxy can exceed int64.
+type corrSums struct {
+	n             int64
+	sx, sy        int64
+	sxx, syy, sxy int64
+}
+
+func (s *corrSums) add(x, y types.Rate) {
+	s.n++
+	s.sx += int64(x)
+	s.sy += int64(y)
+	s.sxx += int64(x) * int64(x)
+	s.syy += int64(y) * int64(y)
+	s.sxy += int64(x) * int64(y)
+}
+
+func (s *corrSums) remove(x, y types.Rate) {
+	s.n--
+	s.sx -= int64(x)
+	s.sy -= int64(y)
+	s.sxx -= int64(x) * int64(x)
+	s.syy -= int64(y) * int64(y)
+	s.sxy -= int64(x) * int64(y)
+}
+
+// corr computes (n·Σxy − Σx·Σy) / √((n·Σx² − (Σx)²)(n·Σy² − (Σy)²)).
+func (s *corrSums) corr() (types.Rate, bool) {
+	if s.n < 2 {
+		return 0, false
+	}
+	n := big.NewInt(s.n)
+	sx, sy := big.NewInt(s.sx), big.NewInt(s.sy)
+
+	centered := func(sq int64, a, b *big.Int) *big.Int {
+		v := new(big.Int).Mul(n, big.NewInt(sq))
+		return v.Sub(v, new(big.Int).Mul(a, b))
+	}
+	num := centered(s.sxy, sx, sy)
+	vx := centered(s.sxx, sx, sx)
+	vy := centered(s.syy, sy, sy)
+	if vx.Sign() <= 0 || vy.Sign() <= 0 {
+		return 0, false
+	}
+
+	den := new(big.Int).Sqrt(new(big.Int).Mul(vx, vy))
+	if den.Sign() == 0 {
+		return 0, false
+	}
+	num.Mul(num, big.NewInt(int64(types.RateScale)))
+	r := num.Quo(num, den).Int64()
+
+	// Integer square-root truncation can push a perfect correlation a hair
+	// past ±1.
+	if r > int64(types.RateScale) {
+		r = int64(types.RateScale)
+	} else if r < -int64(types.RateScale) {
+		r = -int64(types.RateScale)
+	}
+	return types.Rate(r), true
+}
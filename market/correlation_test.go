@@ -0,0 +1,94 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rates(vs ...int64) []types.Rate {
+	out := make([]types.Rate, len(vs))
+	for i, v := range vs {
+		out[i] = types.Rate(v)
+	}
+	return out
+}
+
+func TestAlignCloses_KeepsCommonTimestamps(t *testing.T) {
+	a := []Candle{
+		{Open: 1, High: 1, Low: 1, Close: 100, Timestamp: 10},
+		{Open: 1, High: 1, Low: 1, Close: 101, Timestamp: 20},
+		{Timestamp: 30}, // gap
+		{Open: 1, High: 1, Low: 1, Close: 103, Timestamp: 40},
+	}
+	b := []Candle{
+		{Open: 1, High: 1, Low: 1, Close: 200, Timestamp: 40},
+		{Open: 1, High: 1, Low: 1, Close: 202, Timestamp: 30},
+		{Open: 1, High: 1, Low: 1, Close: 201, Timestamp: 10},
+	}
+	ts, closes := AlignCloses(a, b)
+	assert.Equal(t, []types.Timestamp{10, 40}, ts)
+	require.Len(t, closes, 2)
+	assert.Equal(t, []types.Price{100, 103}, closes[0])
+	assert.Equal(t, []types.Price{201, 200}, closes[1])
+}
+
+func TestReturns(t *testing.T) {
+	got := Returns([]types.Price{100000, 101000, 99990, 0, 100000})
+	assert.Equal(t, rates(10_000, -10_000, -1_000_000, 0), got)
+	assert.Nil(t, Returns([]types.Price{100000}))
+}
+
+func TestCorrelation(t *testing.T) {
+	x := rates(100, -200, 300, 50, -75, 10)
+	neg := make([]types.Rate, len(x))
+	scaled := make([]types.Rate, len(x))
+	for i, v := range x {
+		neg[i] = -v
+		scaled[i] = 3*v + 7
+	}
+
+	tests := []struct {
+		name   string
+		a, b   []types.Rate
+		want   types.Rate
+		wantOK bool
+	}{
+		{name: "identical", a: x, b: x, want: types.Rate(types.RateScale), wantOK: true},
+		{name: "affine", a: x, b: scaled, want: types.Rate(types.RateScale), wantOK: true},
+		{name: "inverse", a: x, b: neg, want: -types.Rate(types.RateScale), wantOK: true},
+		{name: "uncorrelated", a: rates(1, -1, 1, -1), b: rates(1, 1, -1, -1), want: 0, wantOK: true},
+		{name: "constant", a: x, b: rates(5, 5, 5, 5, 5, 5), wantOK: false},
+		{name: "too short", a: rates(1), b: rates(2), wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Correlation(tt.a, tt.b)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCorrelation_PartialMatchesKnownValue(t *testing.T) {
+	// x = 1..5, y = 2,1,4,3,5 → r = 0.8
+	got, ok := Correlation(rates(1, 2, 3, 4, 5), rates(2, 1, 4, 3, 5))
+	require.True(t, ok)
+	assert.Equal(t, types.RateFromFloat(0.8), got)
+}
+
+func TestRollingCorrelation_MatchesPerWindowCorrelation(t *testing.T) {
+	a := rates(5, -3, 8, 2, -7, 4, 6, -1)
+	b := rates(4, -2, 9, -1, -6, 1, 5, 3)
+	got := RollingCorrelation(a, b, 4)
+	require.Len(t, got, 5)
+	for i := range got {
+		want, _ := Correlation(a[i:i+4], b[i:i+4])
+		assert.Equal(t, want, got[i], "window %d", i)
+	}
+
+	assert.Nil(t, RollingCorrelation(a, b, 1))
+	assert.Nil(t, RollingCorrelation(a, b, 9))
+}
@@ -0,0 +1,134 @@
+package market
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const week = 7 * 24 * time.Hour
+
+// WeeklyTime is a moment in the trading week, in UTC: a weekday and a time
+// of day.
+type WeeklyTime struct {
+	Day    time.Weekday
+	Hour   int
+	Minute int
+}
+
+// ParseWeeklyTime parses "Fri 22:00" (any unambiguous weekday prefix of
+// three or more letters, case-insensitive; 24-hour UTC clock).
+func ParseWeeklyTime(s string) (WeeklyTime, error) {
+	day, clock, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return WeeklyTime{}, fmt.Errorf("weekly time %q: want \"<weekday> HH:MM\"", s)
+	}
+	var w WeeklyTime
+	found := false
+	day = strings.ToLower(day)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if len(day) >= 3 && strings.HasPrefix(strings.ToLower(d.String()), day) {
+			w.Day, found = d, true
+			break
+		}
+	}
+	if !found {
+		return WeeklyTime{}, fmt.Errorf("weekly time %q: unknown weekday %q", s, day)
+	}
+	hh, mm, ok := strings.Cut(strings.TrimSpace(clock), ":")
+	h, herr := strconv.Atoi(hh)
+	m, merr := strconv.Atoi(mm)
+	if !ok || herr != nil || merr != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return WeeklyTime{}, fmt.Errorf("weekly time %q: bad time of day %q", s, clock)
+	}
+	w.Hour, w.Minute = h, m
+	return w, nil
+}
+
+// String formats w the way ParseWeeklyTime reads it.
+func (w WeeklyTime) String() string {
+	return fmt.Sprintf("%s %02d:%02d", w.Day.String()[:3], w.Hour, w.Minute)
+}
+
+// offset is w's distance from Sunday 00:00 UTC.
+func (w WeeklyTime) offset() time.Duration {
+	return time.Duration(w.Day)*24*time.Hour + time.Duration(w.Hour)*time.Hour + time.Duration(w.Minute)*time.Minute
+}
+
+// TradingHours is an instrument's weekly session: the market closes at
+// Close and reopens at Open each week. Equal Close and Open (the zero
+// value) means it never closes.
+type TradingHours struct {
+	Close WeeklyTime
+	Open  WeeklyTime
+}
+
+// FXHours is the spot FX week: closed from Friday 22:00 to Sunday 22:00
+// UTC. Unlike IsForexMarketClosed it follows a fixed UTC clock, with no
+// New York daylight saving or holidays, so simulated sessions are the
+// same length every week.
+var FXHours = TradingHours{
+	Close: WeeklyTime{Day: time.Friday, Hour: 22},
+	Open:  WeeklyTime{Day: time.Sunday, Hour: 22},
+}
+
+// weekOffset is t's distance from the start of its UTC week.
+func weekOffset(t time.Time) time.Duration {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return time.Duration(t.Weekday())*24*time.Hour + t.Sub(midnight)
+}
+
+// cyclic returns d modulo a week, in [0, week).
+func cyclic(d time.Duration) time.Duration {
+	d %= week
+	if d < 0 {
+		d += week
+	}
+	return d
+}
+
+// IsOpen reports whether the market is open at t. The close instant is
+// closed and the open instant is open.
+func (h TradingHours) IsOpen(t time.Time) bool {
+	closed := cyclic(h.Open.offset() - h.Close.offset())
+	if closed == 0 {
+		return true
+	}
+	return cyclic(weekOffset(t)-h.Close.offset()) >= closed
+}
+
+// nextClose returns the first close strictly after t.
+func (h TradingHours) nextClose(t time.Time) time.Time {
+	d := cyclic(h.Close.offset() - weekOffset(t))
+	if d == 0 {
+		d = week
+	}
+	return t.Add(d)
+}
+
+// Reopened reports whether t is open and the market was closed at some
+// point after prev: t is the first price after a weekend or other close,
+// which may have gapped.
+func (h TradingHours) Reopened(prev, t time.Time) bool {
+	if cyclic(h.Open.offset()-h.Close.offset()) == 0 || !h.IsOpen(t) || !t.After(prev) {
+		return false
+	}
+	return !h.IsOpen(prev) || !h.nextClose(prev).After(t)
+}
+
+// Calendar holds the trading hours of every instrument: Instruments
+// overrides Default, keyed by normalized instrument name.
+type Calendar struct {
+	Default     TradingHours
+	Instruments map[string]TradingHours
+}
+
+// Hours returns the trading hours for instrument.
+func (c Calendar) Hours(instrument string) TradingHours {
+	if h, ok := c.Instruments[NormalizeInstrument(instrument)]; ok {
+		return h
+	}
+	return c.Default
+}
@@ -0,0 +1,65 @@
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utc(day, hour, minute int) time.Time {
+	// 2024-06-02 is a Sunday.
+	return time.Date(2024, 6, 2+day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseWeeklyTime(t *testing.T) {
+	w, err := ParseWeeklyTime("fri 22:30")
+	require.NoError(t, err)
+	assert.Equal(t, WeeklyTime{Day: time.Friday, Hour: 22, Minute: 30}, w)
+	assert.Equal(t, "Fri 22:30", w.String())
+
+	w, err = ParseWeeklyTime("Sunday 07:05")
+	require.NoError(t, err)
+	assert.Equal(t, WeeklyTime{Day: time.Sunday, Hour: 7, Minute: 5}, w)
+
+	for _, bad := range []string{"", "Fri", "Fr 22:00", "Fri 24:00", "Fri 22", "Noday 10:00", "Fri 10:60"} {
+		_, err := ParseWeeklyTime(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestTradingHours_IsOpen(t *testing.T) {
+	tests := []struct {
+		at   time.Time
+		open bool
+	}{
+		{utc(5, 21, 59), true},  // Friday before the close
+		{utc(5, 22, 0), false},  // the close itself
+		{utc(6, 12, 0), false},  // Saturday
+		{utc(7, 21, 59), false}, // Sunday before the open
+		{utc(7, 22, 0), true},   // the open itself
+		{utc(3, 3, 0), true},    // midweek
+		{utc(0, 23, 0), true},   // Sunday evening
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.open, FXHours.IsOpen(tt.at), tt.at.Format(time.RFC1123))
+	}
+	assert.True(t, TradingHours{}.IsOpen(utc(6, 12, 0)), "the zero value never closes")
+}
+
+func TestTradingHours_Reopened(t *testing.T) {
+	assert.True(t, FXHours.Reopened(utc(5, 21, 0), utc(7, 22, 0)), "Friday's last price to Sunday's first")
+	assert.True(t, FXHours.Reopened(utc(6, 10, 0), utc(8, 1, 0)), "a price seen while closed")
+	assert.True(t, FXHours.Reopened(utc(4, 10, 0), utc(15, 10, 0)), "a gap spanning whole weeks")
+	assert.False(t, FXHours.Reopened(utc(3, 10, 0), utc(3, 11, 0)))
+	assert.False(t, FXHours.Reopened(utc(5, 21, 0), utc(6, 10, 0)), "still closed")
+	assert.False(t, TradingHours{}.Reopened(utc(5, 21, 0), utc(7, 22, 0)))
+}
+
+func TestCalendar_Hours(t *testing.T) {
+	gold := TradingHours{Close: WeeklyTime{Day: time.Friday, Hour: 21}, Open: WeeklyTime{Day: time.Sunday, Hour: 23}}
+	c := Calendar{Default: FXHours, Instruments: map[string]TradingHours{"XAUUSD": gold}}
+	assert.Equal(t, gold, c.Hours("XAU_USD"))
+	assert.Equal(t, FXHours, c.Hours("EURUSD"))
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/rustyeddy/trader/symbols"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -25,6 +26,7 @@ type Instrument struct {
 	BaseCurrency        string
 	QuoteCurrency       string
 	PipLocation         int
+	DisplayPrecision    int // decimals a quote is shown with; see PriceDecimals
 	TradeUnitsPrecision int
 	MinimumTradeSize    types.Units
 	MarginRate          types.Rate
@@ -47,6 +49,7 @@ func makeInstrument(name, base, quote string, pipLocation int, marginRate types.
 		BaseCurrency:        base,
 		QuoteCurrency:       quote,
 		PipLocation:         pipLocation,
+		DisplayPrecision:    1 - pipLocation, // fractional pips: 5 for EURUSD, 3 for USDJPY
 		TradeUnitsPrecision: 0,
 		MinimumTradeSize:    1,
 		MarginRate:          marginRate,
@@ -198,12 +201,10 @@ func (inst *Instrument) SubPips(px types.Price, pips types.Pips) types.Price {
 	return px - delta
 }
 
-// NormalizeInstrument is an internal helper for trader type processing.
+// NormalizeInstrument returns the canonical instrument ID for sym
+// ("EUR_USD" → "EURUSD"); see symbols.Canonical.
 func NormalizeInstrument(sym string) string {
-	sym = strings.TrimSpace(sym)
-	sym = strings.ReplaceAll(sym, "_", "")
-	sym = strings.ReplaceAll(sym, "/", "")
-	return strings.ToUpper(sym)
+	return symbols.Canonical(sym)
 }
 
 // PipSize is an internal helper for trader type processing.
@@ -0,0 +1,60 @@
+package market
+
+import (
+	"strconv"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// defaultPriceDecimals is the precision used for instruments the registry
+// does not know: fractional pips of a four-decimal pair.
+const defaultPriceDecimals = 5
+
+// priceScaleDecimals is the number of decimals a types.Price carries.
+const priceScaleDecimals = 5
+
+// PriceDecimals returns how many decimals inst's quotes are shown with:
+// DisplayPrecision when set, otherwise one past the pip location so
+// fractional pips show. A nil instrument gets five.
+func (inst *Instrument) PriceDecimals() int {
+	if inst == nil {
+		return defaultPriceDecimals
+	}
+	if inst.DisplayPrecision > 0 {
+		return inst.DisplayPrecision
+	}
+	return max(1-inst.PipLocation, 0)
+}
+
+// FormatPrice formats value as a quote for instrument (any spelling), at
+// the instrument's precision: "1.08425" for EURUSD, "151.234" for USDJPY.
+// Unknown instruments are formatted to five decimals.
+func FormatPrice(instrument string, value float64) string {
+	return strconv.FormatFloat(value, 'f', GetInstrument(instrument).PriceDecimals(), 64)
+}
+
+// RoundPrice rounds p, half away from zero, to the precision inst's quotes
+// are shown with, so computed prices (a fill plus slippage, a stop placed
+// a float distance away) land on a quotable tick. Prices already at or
+// below that precision, and those of a nil instrument, are unchanged.
+func (inst *Instrument) RoundPrice(p types.Price) types.Price {
+	decimals := inst.PriceDecimals()
+	if decimals >= priceScaleDecimals {
+		return p
+	}
+	step := types.Price(1)
+	for range priceScaleDecimals - decimals {
+		step *= 10
+	}
+	half := step / 2
+	if p < 0 {
+		return -((-p + half) / step * step)
+	}
+	return (p + half) / step * step
+}
+
+// RoundPrice rounds p to instrument's (any spelling) display precision;
+// see Instrument.RoundPrice.
+func RoundPrice(instrument string, p types.Price) types.Price {
+	return GetInstrument(instrument).RoundPrice(p)
+}
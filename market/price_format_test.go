@@ -0,0 +1,62 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestFormatPrice(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		inst  string
+		value float64
+		want  string
+	}{
+		{"EURUSD", 1.084251, "1.08425"},
+		{"EUR_USD", 1.1, "1.10000"},
+		{"USDJPY", 151.2346, "151.235"},
+		{"usd/jpy", 151.2, "151.200"},
+		{"XXXYYY", 1.234567, "1.23457"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, FormatPrice(tt.inst, tt.value), tt.inst)
+	}
+}
+
+func TestPriceDecimals(t *testing.T) {
+	t.Parallel()
+
+	var unknown *Instrument
+	assert.Equal(t, 5, unknown.PriceDecimals())
+	assert.Equal(t, 3, (&Instrument{PipLocation: -2}).PriceDecimals())
+	assert.Equal(t, 2, (&Instrument{PipLocation: -2, DisplayPrecision: 2}).PriceDecimals())
+	assert.Equal(t, 5, GetInstrument("GBPUSD").PriceDecimals())
+}
+
+func TestRoundPrice(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		inst string
+		in   types.Price
+		want types.Price
+	}{
+		{"USDJPY", 15012349, 15012300},
+		{"USDJPY", 15012350, 15012400}, // half rounds away from zero
+		{"usd_jpy", 15012300, 15012300},
+		{"USDJPY", -151, -200},
+		{"EURUSD", 108501, 108501}, // already at five decimals
+		{"XXXYYY", 108501, 108501},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, RoundPrice(tt.inst, tt.in), "%s %d", tt.inst, tt.in)
+	}
+
+	var unknown *Instrument
+	assert.Equal(t, types.Price(123457), unknown.RoundPrice(123457))
+	assert.Equal(t, types.Price(124000), (&Instrument{PipLocation: -2, DisplayPrecision: 2}).RoundPrice(123557))
+}
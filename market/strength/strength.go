@@ -0,0 +1,215 @@
+// Package strength aggregates candles from several FX pairs into one
+// strength index per currency.
+//
+// Every pair update splits its bar-over-bar return between the two legs:
+// the base currency gains the return and the quote currency loses it. Each
+// currency's share is averaged over the pairs that quote it, so a currency
+// traded in six pairs moves no further than one traded in two. Summing the
+// shares gives a cumulative strength line per currency (Index); Strength
+// is that line's distance above its own EMA, i.e. how far the currency has
+// run recently. All values are RateScale-scaled.
+package strength
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Meter is a streaming currency-strength aggregator. Feed it candles from
+// any set of pairs, in timestamp order, through Update; pairs at the same
+// timestamp belong to the same bar. A Meter is not safe for concurrent use.
+type Meter struct {
+	period int
+
+	pairs map[string]*pair
+	lines map[string]*line
+
+	bar  types.Timestamp // timestamp of the bar being built
+	open bool            // true once the first candle has been seen
+}
+
+type pair struct {
+	base, quote string
+	last        types.Price
+}
+
+type line struct {
+	pairs int        // number of tracked pairs quoting this currency
+	index types.Rate // cumulative strength line
+	ema   types.Rate // EMA of index as of the previous bar
+	bars  int        // completed bars folded into ema
+}
+
+// Line is a snapshot of one currency's strength.
+type Line struct {
+	Currency string
+	Index    types.Rate // cumulative strength line
+	Strength types.Rate // Index minus its EMA; positive = strengthening
+	Pairs    int        // pairs contributing to this currency
+}
+
+// NewMeter returns a Meter whose Strength uses an EMA of the given period,
+// in bars.
+func NewMeter(period int) (*Meter, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("strength period must be > 0")
+	}
+	return &Meter{
+		period: period,
+		pairs:  make(map[string]*pair),
+		lines:  make(map[string]*line),
+	}, nil
+}
+
+func (m *Meter) Period() int { return m.period }
+
+// Reset discards every pair and strength line.
+func (m *Meter) Reset() {
+	m.pairs = make(map[string]*pair)
+	m.lines = make(map[string]*line)
+	m.bar = 0
+	m.open = false
+}
+
+// Update folds one candle for instrument into the strength lines. The
+// first candle of a pair only seeds its previous close. Zero-filled gap
+// candles and non-positive closes are ignored. A candle older than the
+// bar being built is rejected; a newer one closes that bar first.
+func (m *Meter) Update(instrument string, c market.Candle) error {
+	name := market.NormalizeInstrument(instrument)
+	meta := market.GetInstrument(name)
+	if meta == nil {
+		return errs.Newf(errs.ErrUnknownInstrument, "strength: unknown instrument %q", instrument)
+	}
+	if c.IsZero() || c.Close <= 0 {
+		return nil
+	}
+	if m.open && c.Timestamp < m.bar {
+		return fmt.Errorf("strength: %s candle at %d is older than bar %d", name, c.Timestamp, m.bar)
+	}
+	if m.open && c.Timestamp > m.bar {
+		m.closeBar()
+	}
+	m.bar = c.Timestamp
+	m.open = true
+
+	p, ok := m.pairs[name]
+	if !ok {
+		m.pairs[name] = &pair{base: meta.BaseCurrency, quote: meta.QuoteCurrency, last: c.Close}
+		m.lineFor(meta.BaseCurrency).pairs++
+		m.lineFor(meta.QuoteCurrency).pairs++
+		return nil
+	}
+
+	prev := int64(p.last)
+	p.last = c.Close
+	ret := (int64(c.Close) - prev) * int64(types.RateScale) / prev
+
+	base, quote := m.lines[p.base], m.lines[p.quote]
+	base.index += types.Rate(ret / int64(base.pairs))
+	quote.index -= types.Rate(ret / int64(quote.pairs))
+	return nil
+}
+
+// Ready reports whether ccy's strength has a full EMA period of history.
+func (m *Meter) Ready(ccy string) bool {
+	l, ok := m.lines[ccy]
+	return ok && l.bars >= m.period
+}
+
+// Strength returns ccy's Index minus its EMA, counting the bar being built
+// as if it closed now. ok is false for a currency no tracked pair quotes.
+func (m *Meter) Strength(ccy string) (types.Rate, bool) {
+	l, ok := m.lines[ccy]
+	if !ok {
+		return 0, false
+	}
+	return l.index - m.emaWith(l), true
+}
+
+// Differential returns Strength(base) - Strength(quote) for instrument.
+// ok is false unless both currencies are tracked and Ready.
+func (m *Meter) Differential(instrument string) (types.Rate, bool) {
+	meta := market.GetInstrument(market.NormalizeInstrument(instrument))
+	if meta == nil || !m.Ready(meta.BaseCurrency) || !m.Ready(meta.QuoteCurrency) {
+		return 0, false
+	}
+	base, _ := m.Strength(meta.BaseCurrency)
+	quote, _ := m.Strength(meta.QuoteCurrency)
+	return base - quote, true
+}
+
+// AllowSide reports whether an entry on side agrees with relative
+// currency strength: a long needs the base currency at least margin
+// stronger than the quote, a short the reverse. It returns true while
+// either currency is not Ready so warmup does not block trading.
+func (m *Meter) AllowSide(instrument string, side types.Side, margin types.Rate) bool {
+	diff, ok := m.Differential(instrument)
+	if !ok {
+		return true
+	}
+	if side == types.Short {
+		return diff <= -margin
+	}
+	return diff >= margin
+}
+
+// Lines returns a snapshot of every tracked currency, strongest first.
+// Ties are broken by currency code.
+func (m *Meter) Lines() []Line {
+	out := make([]Line, 0, len(m.lines))
+	for ccy, l := range m.lines {
+		out = append(out, Line{
+			Currency: ccy,
+			Index:    l.index,
+			Strength: l.index - m.emaWith(l),
+			Pairs:    l.pairs,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Strength != out[j].Strength {
+			return out[i].Strength > out[j].Strength
+		}
+		return out[i].Currency < out[j].Currency
+	})
+	return out
+}
+
+func (m *Meter) lineFor(ccy string) *line {
+	l, ok := m.lines[ccy]
+	if !ok {
+		l = &line{}
+		m.lines[ccy] = l
+	}
+	return l
+}
+
+// closeBar folds every currency's index into its EMA, including
+// currencies that saw no update this bar.
+func (m *Meter) closeBar() {
+	for _, l := range m.lines {
+		l.ema = m.emaWith(l)
+		l.bars++
+	}
+}
+
+// emaWith is l's EMA after folding in the current index.
+func (m *Meter) emaWith(l *line) types.Rate {
+	if l.bars == 0 {
+		// Seed with the first bar's index (simple, deterministic).
+		return l.index
+	}
+	n := int64(m.period)
+	return types.Rate(roundDivSigned(2*int64(l.index)+(n-1)*int64(l.ema), n+1))
+}
+
+func roundDivSigned(num, den int64) int64 {
+	if num >= 0 {
+		return (num + den/2) / den
+	}
+	return -((-num + den/2) / den)
+}
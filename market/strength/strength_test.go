@@ -0,0 +1,92 @@
+package strength
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bar(ts int64, close types.Price) market.Candle {
+	return market.Candle{Open: close, High: close, Low: close, Close: close, Timestamp: types.Timestamp(ts)}
+}
+
+// feedEURStrong drives EUR up against USD and JPY while USDJPY is flat.
+func feedEURStrong(t *testing.T, m *Meter, bars int) {
+	t.Helper()
+	eurusd, eurjpy := types.Price(110_000), types.Price(16_000_000)
+	for i := 0; i < bars; i++ {
+		ts := int64(3600 * (i + 1))
+		require.NoError(t, m.Update("EUR_USD", bar(ts, eurusd)))
+		require.NoError(t, m.Update("EURJPY", bar(ts, eurjpy)))
+		require.NoError(t, m.Update("USDJPY", bar(ts, 14_500_000)))
+		eurusd += eurusd / 1000
+		eurjpy += eurjpy / 1000
+	}
+}
+
+func TestNewMeter_Validation(t *testing.T) {
+	_, err := NewMeter(0)
+	require.Error(t, err)
+
+	m, err := NewMeter(5)
+	require.NoError(t, err)
+	assert.Equal(t, 5, m.Period())
+	require.ErrorContains(t, m.Update("XXXYYY", bar(1, 100_000)), "unknown instrument")
+}
+
+func TestMeter_RanksStrengthenedCurrencyFirst(t *testing.T) {
+	m, err := NewMeter(3)
+	require.NoError(t, err)
+	feedEURStrong(t, m, 10)
+
+	for _, ccy := range []string{"EUR", "USD", "JPY"} {
+		assert.True(t, m.Ready(ccy), ccy)
+	}
+	eur, ok := m.Strength("EUR")
+	require.True(t, ok)
+	usd, _ := m.Strength("USD")
+	jpy, _ := m.Strength("JPY")
+	assert.Positive(t, eur)
+	assert.Negative(t, usd)
+	assert.Negative(t, jpy)
+
+	lines := m.Lines()
+	require.Len(t, lines, 3)
+	assert.Equal(t, "EUR", lines[0].Currency)
+	assert.Equal(t, 2, lines[0].Pairs)
+	assert.Positive(t, lines[0].Index)
+
+	_, ok = m.Strength("GBP")
+	assert.False(t, ok)
+}
+
+func TestMeter_AllowSide(t *testing.T) {
+	m, err := NewMeter(3)
+	require.NoError(t, err)
+	assert.True(t, m.AllowSide("EURUSD", types.Short, 0), "not ready must not block")
+
+	feedEURStrong(t, m, 10)
+	diff, ok := m.Differential("EURUSD")
+	require.True(t, ok)
+	assert.Positive(t, diff)
+
+	assert.True(t, m.AllowSide("EURUSD", types.Long, 0))
+	assert.False(t, m.AllowSide("EURUSD", types.Short, 0))
+	assert.False(t, m.AllowSide("EURUSD", types.Long, diff+1), "margin not met")
+	assert.True(t, m.AllowSide("EURUSD", types.Long, diff))
+}
+
+func TestMeter_UpdateOrdering(t *testing.T) {
+	m, err := NewMeter(2)
+	require.NoError(t, err)
+	require.NoError(t, m.Update("EURUSD", bar(7200, 110_000)))
+	require.ErrorContains(t, m.Update("EURUSD", bar(3600, 110_000)), "older than bar")
+	require.NoError(t, m.Update("EURUSD", market.Candle{}), "gap candles are ignored")
+
+	m.Reset()
+	assert.Empty(t, m.Lines())
+	require.NoError(t, m.Update("EURUSD", bar(3600, 110_000)))
+}
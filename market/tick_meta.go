@@ -0,0 +1,142 @@
+package market
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// TickCSVSchema is the schema named by a replay tick CSV's metadata line.
+const TickCSVSchema = "ticks-v1"
+
+// PriceBidAsk is the TickMeta.Price of files whose bid and ask columns are
+// the quoted bid and ask, the only component the replay format carries
+// today.
+const PriceBidAsk = "bid-ask"
+
+// TickMeta describes a replay tick CSV (time,instrument,bid,ask): what it
+// holds and how it was produced. Writers put it on a comment line ahead of
+// the column header, in the key=value style of the candle CSV's schema
+// line:
+//
+//	# schema=ticks-v1 source=oanda instruments=EURUSD,USDJPY price=bid-ask generator=oanda-stream
+//
+// Keys other than schema, source, instruments and price are generation
+// parameters and land in Params. Values are query-escaped. The line is
+// optional; readers that don't know it skip it as a comment.
+type TickMeta struct {
+	Source      string            // where the prices came from, e.g. oanda or a convert dialect
+	Instruments []string          // normalized instruments the rows are limited to; empty when not stated
+	Price       string            // price component of the bid/ask columns, e.g. PriceBidAsk
+	Params      map[string]string // how the file was generated: generator, input file, and so on
+}
+
+// String formats m as the metadata line, without the trailing newline.
+func (m TickMeta) String() string {
+	var b strings.Builder
+	b.WriteString("# schema=" + TickCSVSchema)
+	field := func(k, v string) {
+		if v != "" {
+			fmt.Fprintf(&b, " %s=%s", k, url.QueryEscape(v))
+		}
+	}
+	field("source", m.Source)
+	insts := make([]string, len(m.Instruments))
+	for i, inst := range m.Instruments {
+		insts[i] = NormalizeInstrument(inst)
+	}
+	field("instruments", strings.Join(insts, ","))
+	field("price", m.Price)
+	keys := make([]string, 0, len(m.Params))
+	for k := range m.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		field(k, m.Params[k])
+	}
+	return b.String()
+}
+
+// ParseTickMeta parses a metadata line. ok is false, with no error, when
+// line is not one (a header, a data row, any other comment).
+func ParseTickMeta(line string) (meta TickMeta, ok bool, err error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "\ufeff"))
+	if len(fields) < 2 || fields[0] != "#" || fields[1] != "schema="+TickCSVSchema {
+		return TickMeta{}, false, nil
+	}
+	for _, f := range fields[2:] {
+		k, raw, found := strings.Cut(f, "=")
+		if !found || k == "" {
+			return TickMeta{}, true, fmt.Errorf("tick metadata: bad field %q", f)
+		}
+		v, err := url.QueryUnescape(raw)
+		if err != nil {
+			return TickMeta{}, true, fmt.Errorf("tick metadata: bad %s value %q: %w", k, raw, err)
+		}
+		switch k {
+		case "source":
+			meta.Source = v
+		case "instruments":
+			for _, inst := range strings.Split(v, ",") {
+				if inst = NormalizeInstrument(inst); inst != "" {
+					meta.Instruments = append(meta.Instruments, inst)
+				}
+			}
+		case "price":
+			meta.Price = v
+		default:
+			if meta.Params == nil {
+				meta.Params = make(map[string]string)
+			}
+			meta.Params[k] = v
+		}
+	}
+	return meta, true, nil
+}
+
+// ReadTickMeta reads the metadata line from the start of br if there is
+// one, leaving br at the line after it; otherwise it consumes nothing.
+func ReadTickMeta(br *bufio.Reader) (meta TickMeta, ok bool, err error) {
+	buf, err := br.Peek(br.Size())
+	if len(buf) == 0 {
+		return TickMeta{}, false, nil
+	}
+	line := buf
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		line = buf[:i+1]
+	} else if err == nil {
+		return TickMeta{}, false, nil // a line longer than the buffer is no metadata line
+	}
+	meta, ok, err = ParseTickMeta(string(line))
+	if !ok || err != nil {
+		return TickMeta{}, ok, err
+	}
+	_, err = br.Discard(len(line))
+	return meta, true, err
+}
+
+// Covers reports whether the file may hold ticks for instrument: always
+// true when the metadata names no instruments.
+func (m TickMeta) Covers(instrument string) bool {
+	return len(m.Instruments) == 0 || slices.Contains(m.Instruments, NormalizeInstrument(instrument))
+}
+
+// Expect checks that the file holds what a replay expects: every one of
+// instruments, and source when it is not empty. Metadata that doesn't say
+// passes.
+func (m TickMeta) Expect(source string, instruments []string) error {
+	if source != "" && m.Source != "" && !strings.EqualFold(source, m.Source) {
+		return fmt.Errorf("ticks are from %s, want %s", m.Source, source)
+	}
+	for _, inst := range instruments {
+		if !m.Covers(inst) {
+			return fmt.Errorf("ticks hold %s, not %s", strings.Join(m.Instruments, ","), NormalizeInstrument(inst))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package market
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTickMeta_RoundTrip(t *testing.T) {
+	meta := TickMeta{
+		Source:      "oanda",
+		Instruments: []string{"eur_usd", "USDJPY"},
+		Price:       PriceBidAsk,
+		Params:      map[string]string{"input": "my ticks.csv", "generator": "test"},
+	}
+	line := meta.String()
+	assert.Equal(t, "# schema=ticks-v1 source=oanda instruments=EURUSD%2CUSDJPY price=bid-ask generator=test input=my+ticks.csv", line)
+
+	got, ok, err := ParseTickMeta(line + "\n")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "oanda", got.Source)
+	assert.Equal(t, []string{"EURUSD", "USDJPY"}, got.Instruments)
+	assert.Equal(t, PriceBidAsk, got.Price)
+	assert.Equal(t, map[string]string{"input": "my ticks.csv", "generator": "test"}, got.Params)
+
+	for _, other := range []string{"time,instrument,bid,ask", "# a comment", "# schema=candle-v2 source=x", ""} {
+		_, ok, err := ParseTickMeta(other)
+		assert.NoError(t, err, other)
+		assert.False(t, ok, other)
+	}
+	_, ok, err = ParseTickMeta("# schema=ticks-v1 bogus")
+	assert.True(t, ok)
+	assert.ErrorContains(t, err, `bad field "bogus"`)
+}
+
+func TestReadTickMeta(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("# schema=ticks-v1 source=oanda\ntime,instrument,bid,ask\n"))
+	meta, ok, err := ReadTickMeta(br)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "oanda", meta.Source)
+	rest, _ := io.ReadAll(br)
+	assert.Equal(t, "time,instrument,bid,ask\n", string(rest))
+
+	// Without a metadata line nothing is consumed.
+	for _, in := range []string{"time,instrument,bid,ask\n", "", "# schema=ticks-v1"} {
+		br = bufio.NewReader(strings.NewReader(in))
+		_, ok, err = ReadTickMeta(br)
+		require.NoError(t, err)
+		rest, _ = io.ReadAll(br)
+		if ok {
+			assert.Empty(t, rest, "an unterminated metadata line is the whole file")
+			continue
+		}
+		assert.Equal(t, in, string(rest))
+	}
+}
+
+func TestTickMeta_Expect(t *testing.T) {
+	meta := TickMeta{Source: "oanda", Instruments: []string{"EURUSD"}}
+	assert.True(t, meta.Covers("EUR_USD"))
+	assert.False(t, meta.Covers("USDJPY"))
+	assert.True(t, TickMeta{}.Covers("USDJPY"), "no instruments listed covers all")
+
+	assert.NoError(t, meta.Expect("OANDA", []string{"eur_usd"}))
+	assert.NoError(t, meta.Expect("", nil))
+	assert.ErrorContains(t, meta.Expect("", []string{"USDJPY"}), "ticks hold EURUSD, not USDJPY")
+	assert.ErrorContains(t, meta.Expect("dukascopy", nil), "ticks are from oanda, want dukascopy")
+	assert.NoError(t, TickMeta{}.Expect("dukascopy", []string{"USDJPY"}))
+}
@@ -0,0 +1,165 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// TickViolation names the sanity check a tick failed. The empty value means
+// the tick passed.
+type TickViolation string
+
+const (
+	TickOK          TickViolation = ""
+	TickNonPositive TickViolation = "non-positive" // bid or ask <= 0
+	TickCrossed     TickViolation = "crossed"      // ask < bid
+	TickWideSpread  TickViolation = "wide-spread"  // ask - bid > MaxSpreadPips
+	TickJump        TickViolation = "jump"         // mid moved > MaxJumpPips vs previous tick
+)
+
+// TickPolicy decides what a feed does with a tick that fails sanitization.
+type TickPolicy int
+
+const (
+	// TickPolicyLog drops the offending tick, counts it, and lets the feed
+	// log it. This is the default.
+	TickPolicyLog TickPolicy = iota
+	// TickPolicyFail aborts the feed with an error on the first violation.
+	TickPolicyFail
+)
+
+// ParseTickPolicy parses a --bad-ticks style flag value ("log" or "fail").
+// An empty string selects TickPolicyLog.
+func ParseTickPolicy(s string) (TickPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "log":
+		return TickPolicyLog, nil
+	case "fail":
+		return TickPolicyFail, nil
+	default:
+		return 0, fmt.Errorf("bad tick policy %q (use log or fail)", s)
+	}
+}
+
+// TickSanitizerStats counts sanitizer outcomes over a feed's lifetime.
+type TickSanitizerStats struct {
+	Seen        int
+	NonPositive int
+	Crossed     int
+	WideSpread  int
+	Jump        int
+}
+
+// Rejected is the total number of ticks that failed any check.
+func (s TickSanitizerStats) Rejected() int {
+	return s.NonPositive + s.Crossed + s.WideSpread + s.Jump
+}
+
+// String formats the counters for an end-of-replay summary line.
+func (s TickSanitizerStats) String() string {
+	return fmt.Sprintf("seen=%d rejected=%d non-positive=%d crossed=%d wide-spread=%d jump=%d",
+		s.Seen, s.Rejected(), s.NonPositive, s.Crossed, s.WideSpread, s.Jump)
+}
+
+// TickSanitizer is an optional filter stage for tick feeds. It rejects
+// ticks with non-positive or crossed quotes unconditionally, and ticks
+// whose spread or mid-to-mid move exceeds the configured pip limits. Zero
+// limits disable the corresponding check. Pip limits are converted per
+// instrument through the instrument registry; ticks for unknown
+// instruments skip the spread and jump checks.
+//
+// The jump check compares against the last accepted mid for the same
+// instrument. A single spike is rejected, but if the next tick lands
+// within MaxJumpPips of the rejected one the market really did move, so
+// the move is accepted and becomes the new reference.
+//
+// A TickSanitizer is stateful and not safe for concurrent use; give each
+// feed its own.
+type TickSanitizer struct {
+	MaxSpreadPips types.Pips
+	MaxJumpPips   types.Pips
+	Policy        TickPolicy
+
+	stats   TickSanitizerStats
+	last    map[string]types.Price // last accepted mid per instrument
+	pending map[string]types.Price // mid of the last jump-rejected tick
+}
+
+// Stats returns the counters accumulated so far.
+func (s *TickSanitizer) Stats() TickSanitizerStats {
+	if s == nil {
+		return TickSanitizerStats{}
+	}
+	return s.stats
+}
+
+// Check classifies tick and updates the counters and per-instrument
+// reference price. It never returns an error; see Apply for the
+// policy-aware form feeds use.
+func (s *TickSanitizer) Check(tick Tick) TickViolation {
+	if s.last == nil {
+		s.last = make(map[string]types.Price)
+		s.pending = make(map[string]types.Price)
+	}
+	s.stats.Seen++
+
+	switch {
+	case tick.Bid <= 0 || tick.Ask <= 0:
+		s.stats.NonPositive++
+		return TickNonPositive
+	case tick.Ask < tick.Bid:
+		s.stats.Crossed++
+		return TickCrossed
+	}
+
+	inst := NormalizeInstrument(tick.Instrument)
+	meta := GetInstrument(inst)
+	if meta == nil {
+		return TickOK
+	}
+
+	if s.MaxSpreadPips > 0 && tick.Spread() > meta.PriceDeltaFromPips(s.MaxSpreadPips) {
+		s.stats.WideSpread++
+		return TickWideSpread
+	}
+
+	mid := tick.Mid()
+	if s.MaxJumpPips > 0 {
+		maxJump := meta.PriceDeltaFromPips(s.MaxJumpPips)
+		if prev, ok := s.last[inst]; ok && absPrice(mid-prev) > maxJump {
+			spike, hadSpike := s.pending[inst]
+			if !hadSpike || absPrice(mid-spike) > maxJump {
+				s.pending[inst] = mid
+				s.stats.Jump++
+				return TickJump
+			}
+		}
+	}
+	delete(s.pending, inst)
+	s.last[inst] = mid
+	return TickOK
+}
+
+// Apply runs Check and maps the result through Policy: keep reports
+// whether the feed should yield the tick, and err is non-nil only under
+// TickPolicyFail.
+func (s *TickSanitizer) Apply(tick Tick) (TickViolation, bool, error) {
+	v := s.Check(tick)
+	if v == TickOK {
+		return v, true, nil
+	}
+	if s.Policy == TickPolicyFail {
+		return v, false, fmt.Errorf("bad tick %s %s bid=%s ask=%s: %s",
+			tick.Instrument, tick.Timestamp, tick.Bid, tick.Ask, v)
+	}
+	return v, false, nil
+}
+
+func absPrice(p types.Price) types.Price {
+	if p < 0 {
+		return -p
+	}
+	return p
+}
@@ -0,0 +1,114 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eurTick(bid, ask float64) Tick {
+	return Tick{
+		Instrument: "EURUSD",
+		BA:         BA{Bid: types.PriceFromFloat(bid), Ask: types.PriceFromFloat(ask)},
+	}
+}
+
+func TestParseTickPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    TickPolicy
+		wantErr bool
+	}{
+		{"", TickPolicyLog, false},
+		{"log", TickPolicyLog, false},
+		{" FAIL ", TickPolicyFail, false},
+		{"ignore", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTickPolicy(tt.in)
+		if tt.wantErr {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestTickSanitizer_Check(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		tick Tick
+		want TickViolation
+	}{
+		{"valid", eurTick(1.1000, 1.1002), TickOK},
+		{"zero bid", eurTick(0, 1.1002), TickNonPositive},
+		{"crossed", eurTick(1.1002, 1.1000), TickCrossed},
+		{"wide spread", eurTick(1.1000, 1.1010), TickWideSpread},
+		{"unknown instrument skips spread check", Tick{Instrument: "XXXYYY", BA: BA{Bid: 100, Ask: 900}}, TickOK},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := &TickSanitizer{MaxSpreadPips: types.PipsFromFloat(5)}
+			assert.Equal(t, tt.want, s.Check(tt.tick))
+		})
+	}
+}
+
+func TestTickSanitizer_JumpRejectsSpikeButAcceptsConfirmedMove(t *testing.T) {
+	t.Parallel()
+
+	s := &TickSanitizer{MaxJumpPips: types.PipsFromFloat(20)}
+	assert.Equal(t, TickOK, s.Check(eurTick(1.1000, 1.1002)))
+	assert.Equal(t, TickOK, s.Check(eurTick(1.1010, 1.1012)), "10 pips is within limit")
+
+	// Isolated spike: rejected, and the reference stays at the last good mid.
+	assert.Equal(t, TickJump, s.Check(eurTick(1.1510, 1.1512)))
+	assert.Equal(t, TickOK, s.Check(eurTick(1.1011, 1.1013)))
+
+	// Gap that persists: the first tick is rejected, the confirming one is not.
+	assert.Equal(t, TickJump, s.Check(eurTick(1.1300, 1.1302)))
+	assert.Equal(t, TickOK, s.Check(eurTick(1.1305, 1.1307)))
+	assert.Equal(t, TickOK, s.Check(eurTick(1.1306, 1.1308)))
+
+	stats := s.Stats()
+	assert.Equal(t, 7, stats.Seen)
+	assert.Equal(t, 2, stats.Jump)
+	assert.Equal(t, 2, stats.Rejected())
+}
+
+func TestTickSanitizer_ApplyPolicy(t *testing.T) {
+	t.Parallel()
+
+	logS := &TickSanitizer{}
+	v, keep, err := logS.Apply(eurTick(1.1002, 1.1000))
+	require.NoError(t, err)
+	assert.False(t, keep)
+	assert.Equal(t, TickCrossed, v)
+
+	failS := &TickSanitizer{Policy: TickPolicyFail}
+	_, keep, err = failS.Apply(eurTick(1.1002, 1.1000))
+	require.Error(t, err)
+	assert.False(t, keep)
+	assert.Contains(t, err.Error(), "crossed")
+
+	_, keep, err = failS.Apply(eurTick(1.1000, 1.1002))
+	require.NoError(t, err)
+	assert.True(t, keep)
+}
+
+func TestTickSanitizerStats_NilSanitizer(t *testing.T) {
+	t.Parallel()
+
+	var s *TickSanitizer
+	assert.Equal(t, TickSanitizerStats{}, s.Stats())
+	assert.Contains(t, s.Stats().String(), "rejected=0")
+}
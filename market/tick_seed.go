@@ -0,0 +1,50 @@
+package market
+
+// FirstTicks reads up to lookahead ticks from src and returns the first
+// tick of each instrument among them, keyed by normalized instrument,
+// along with a TickSource that yields every tick src would have: the ones
+// read ahead, then the rest. It is how a replay seeds a broker's prices
+// from the data it is about to replay rather than from made-up opening
+// quotes. An instrument whose first tick lies beyond the lookahead is
+// left out.
+func FirstTicks(src TickSource, lookahead int) (map[string]Tick, TickSource, error) {
+	first := make(map[string]Tick)
+	ahead := &lookaheadSource{src: src}
+	for len(ahead.buf) < lookahead {
+		t, ok, err := src.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			ahead.done = true
+			break
+		}
+		ahead.buf = append(ahead.buf, t)
+		inst := NormalizeInstrument(t.Instrument)
+		if _, seen := first[inst]; !seen {
+			t.Instrument = inst
+			first[inst] = t
+		}
+	}
+	return first, ahead, nil
+}
+
+// lookaheadSource replays the ticks FirstTicks read ahead before reading
+// on from src.
+type lookaheadSource struct {
+	src  TickSource
+	buf  []Tick
+	done bool
+}
+
+func (l *lookaheadSource) Next() (Tick, bool, error) {
+	if len(l.buf) > 0 {
+		t := l.buf[0]
+		l.buf = l.buf[1:]
+		return t, true, nil
+	}
+	if l.done {
+		return Tick{}, false, nil
+	}
+	return l.src.Next()
+}
@@ -0,0 +1,47 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirstTicks(t *testing.T) {
+	jpy := Tick{Instrument: "usd_jpy", Timestamp: 11, BA: BA{Bid: 15_000_000, Ask: 15_000_200}}
+	ticks := []Tick{seqTick(10, 1.1000), jpy, seqTick(12, 1.1001), seqTick(13, 1.1002)}
+
+	first, rest, err := FirstTicks(&sliceTicks{ticks: append([]Tick(nil), ticks...)}, 3)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, ticks[0], first["EURUSD"])
+	assert.Equal(t, "USDJPY", first["USDJPY"].Instrument)
+	assert.Equal(t, jpy.Bid, first["USDJPY"].Bid)
+
+	// Nothing read ahead is lost.
+	var got []Tick
+	for {
+		tick, ok, err := rest.Next()
+		require.NoError(t, err)
+		if !ok {
+			break
+		}
+		got = append(got, tick)
+	}
+	assert.Equal(t, ticks, got)
+}
+
+func TestFirstTicks_ShortFeed(t *testing.T) {
+	first, rest, err := FirstTicks(&sliceTicks{ticks: []Tick{seqTick(10, 1.1)}}, 5)
+	require.NoError(t, err)
+	assert.Len(t, first, 1)
+	_, ok, _ := rest.Next()
+	assert.True(t, ok)
+	_, ok, err = rest.Next()
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	first, _, err = FirstTicks(&sliceTicks{ticks: []Tick{seqTick(10, 1.1)}}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, first)
+}
@@ -0,0 +1,182 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// TickSource is anything that yields ticks one at a time, returning
+// (Tick{}, false, nil) once it has ended — backtest.CSVTicksFeed and the
+// paper broker's feeds among them.
+type TickSource interface {
+	Next() (Tick, bool, error)
+}
+
+// TickOrderPolicy decides what a TickSequencer does with duplicate and
+// out-of-order ticks.
+type TickOrderPolicy int
+
+const (
+	// TickOrderDedupe drops exact duplicates and ticks older than the last
+	// one yielded, counting both. This is the default.
+	TickOrderDedupe TickOrderPolicy = iota
+	// TickOrderSort buffers Window ticks and yields them in time order, so
+	// rows that are only slightly out of order are put back in place.
+	// Duplicates, and ticks too late for the buffer, are dropped and
+	// counted.
+	TickOrderSort
+	// TickOrderFail ends the feed with an error on the first duplicate or
+	// out-of-order tick.
+	TickOrderFail
+)
+
+// DefaultTickOrderWindow is the TickOrderSort buffer size used when
+// TickSequencer.Window is zero.
+const DefaultTickOrderWindow = 64
+
+// ParseTickOrderPolicy parses a --tick-order style flag value ("dedupe",
+// "sort" or "fail"). An empty string selects TickOrderDedupe.
+func ParseTickOrderPolicy(s string) (TickOrderPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "dedupe":
+		return TickOrderDedupe, nil
+	case "sort":
+		return TickOrderSort, nil
+	case "fail":
+		return TickOrderFail, nil
+	default:
+		return 0, fmt.Errorf("bad tick order policy %q (use dedupe, sort or fail)", s)
+	}
+}
+
+// TickOrderStats counts sequencer outcomes over a feed's lifetime.
+type TickOrderStats struct {
+	Seen       int
+	Duplicates int // exact repeats, dropped
+	OutOfOrder int // older than a tick already yielded, dropped
+	Reordered  int // arrived early in the sort buffer and were moved back into place
+}
+
+// String formats the counters for an end-of-replay summary line.
+func (s TickOrderStats) String() string {
+	return fmt.Sprintf("seen=%d duplicates=%d out-of-order=%d reordered=%d",
+		s.Seen, s.Duplicates, s.OutOfOrder, s.Reordered)
+}
+
+// TickSequencer wraps a TickSource so that what it yields is free of exact
+// duplicates and never goes back in time, handling the bad rows per
+// Policy. A duplicate is a tick equal in instrument, timestamp, bid and
+// ask to one already seen at that timestamp; order is by timestamp across
+// all instruments, with ticks sharing a timestamp kept in arrival order.
+//
+// A TickSequencer is stateful and not safe for concurrent use; give each
+// feed its own.
+type TickSequencer struct {
+	Source TickSource
+	Policy TickOrderPolicy
+	Window int // TickOrderSort buffer size; zero means DefaultTickOrderWindow
+
+	stats   TickOrderStats
+	buf     []Tick            // TickOrderSort: pending ticks, sorted by time
+	seen    map[Tick]struct{} // yielded or buffered ticks at or after last
+	last    types.Timestamp   // timestamp of the last tick yielded
+	yielded bool
+	done    bool
+}
+
+// Stats returns the counters accumulated so far.
+func (s *TickSequencer) Stats() TickOrderStats {
+	if s == nil {
+		return TickOrderStats{}
+	}
+	return s.stats
+}
+
+// Next returns the next tick in order. Under TickOrderFail a duplicate or
+// out-of-order tick is returned as an error; a Source error is passed
+// through as is.
+func (s *TickSequencer) Next() (Tick, bool, error) {
+	if s.seen == nil {
+		s.seen = make(map[Tick]struct{})
+	}
+	window := s.Window
+	if window <= 0 {
+		window = DefaultTickOrderWindow
+	}
+
+	for {
+		if s.Policy == TickOrderSort && len(s.buf) > 0 && (s.done || len(s.buf) > window) {
+			t := s.buf[0]
+			s.buf = s.buf[1:]
+			s.yield(t)
+			return t, true, nil
+		}
+		if s.done {
+			return Tick{}, false, nil
+		}
+
+		t, ok, err := s.Source.Next()
+		if err != nil {
+			return Tick{}, false, err
+		}
+		if !ok {
+			s.done = true
+			continue
+		}
+		s.stats.Seen++
+
+		if _, dup := s.seen[t]; dup {
+			s.stats.Duplicates++
+			if s.Policy == TickOrderFail {
+				return Tick{}, false, fmt.Errorf("duplicate tick %s %s bid=%s ask=%s",
+					t.Instrument, t.Timestamp, t.Bid, t.Ask)
+			}
+			continue
+		}
+		if s.yielded && t.Timestamp < s.last {
+			s.stats.OutOfOrder++
+			if s.Policy == TickOrderFail {
+				return Tick{}, false, fmt.Errorf("out-of-order tick %s %s after %s",
+					t.Instrument, t.Timestamp, s.last)
+			}
+			continue
+		}
+
+		s.seen[t] = struct{}{}
+		if s.Policy != TickOrderSort {
+			s.yield(t)
+			return t, true, nil
+		}
+		s.insert(t)
+	}
+}
+
+// insert places t in the sort buffer after every tick at or before its
+// timestamp.
+func (s *TickSequencer) insert(t Tick) {
+	i := len(s.buf)
+	for i > 0 && s.buf[i-1].Timestamp > t.Timestamp {
+		i--
+	}
+	if i < len(s.buf) {
+		s.stats.Reordered++
+	}
+	s.buf = append(s.buf, Tick{})
+	copy(s.buf[i+1:], s.buf[i:])
+	s.buf[i] = t
+}
+
+// yield records t as yielded and forgets duplicate candidates older than
+// it, which can no longer be yielded anyway.
+func (s *TickSequencer) yield(t Tick) {
+	if s.yielded && t.Timestamp > s.last {
+		for k := range s.seen {
+			if k.Timestamp < t.Timestamp {
+				delete(s.seen, k)
+			}
+		}
+	}
+	s.last, s.yielded = t.Timestamp, true
+}
@@ -0,0 +1,114 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sliceTicks struct{ ticks []Tick }
+
+func (s *sliceTicks) Next() (Tick, bool, error) {
+	if len(s.ticks) == 0 {
+		return Tick{}, false, nil
+	}
+	t := s.ticks[0]
+	s.ticks = s.ticks[1:]
+	return t, true, nil
+}
+
+func seqTick(ts types.Timestamp, bid float64) Tick {
+	t := eurTick(bid, bid+0.0002)
+	t.Timestamp = ts
+	return t
+}
+
+// messyTicks has an exact duplicate (t=10), a same-second distinct quote
+// (t=10, 1.1001) that must survive, and a row (t=11) arriving after t=12.
+func messyTicks() []Tick {
+	return []Tick{
+		seqTick(10, 1.1000),
+		seqTick(10, 1.1000),
+		seqTick(10, 1.1001),
+		seqTick(12, 1.1003),
+		seqTick(11, 1.1002),
+		seqTick(13, 1.1004),
+	}
+}
+
+func drain(t *testing.T, s *TickSequencer) ([]types.Timestamp, error) {
+	t.Helper()
+	var got []types.Timestamp
+	for {
+		tick, ok, err := s.Next()
+		if err != nil || !ok {
+			return got, err
+		}
+		got = append(got, tick.Timestamp)
+	}
+}
+
+func TestParseTickOrderPolicy(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]TickOrderPolicy{"": TickOrderDedupe, "dedupe": TickOrderDedupe, " SORT ": TickOrderSort, "fail": TickOrderFail} {
+		got, err := ParseTickOrderPolicy(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := ParseTickOrderPolicy("keep")
+	assert.Error(t, err)
+}
+
+func TestTickSequencer_Dedupe(t *testing.T) {
+	t.Parallel()
+
+	s := &TickSequencer{Source: &sliceTicks{messyTicks()}}
+	got, err := drain(t, s)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Timestamp{10, 10, 12, 13}, got)
+	assert.Equal(t, TickOrderStats{Seen: 6, Duplicates: 1, OutOfOrder: 1}, s.Stats())
+}
+
+func TestTickSequencer_SortWithinWindow(t *testing.T) {
+	t.Parallel()
+
+	s := &TickSequencer{Source: &sliceTicks{messyTicks()}, Policy: TickOrderSort, Window: 2}
+	got, err := drain(t, s)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Timestamp{10, 10, 11, 12, 13}, got)
+	assert.Equal(t, TickOrderStats{Seen: 6, Duplicates: 1, Reordered: 1}, s.Stats())
+
+	// A row later than the window can absorb is dropped, not yielded.
+	late := []Tick{seqTick(10, 1.1), seqTick(11, 1.1), seqTick(12, 1.1), seqTick(13, 1.1), seqTick(9, 1.1)}
+	s = &TickSequencer{Source: &sliceTicks{late}, Policy: TickOrderSort, Window: 1}
+	got, err = drain(t, s)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Timestamp{10, 11, 12, 13}, got)
+	assert.Equal(t, 1, s.Stats().OutOfOrder)
+}
+
+func TestTickSequencer_Fail(t *testing.T) {
+	t.Parallel()
+
+	s := &TickSequencer{Source: &sliceTicks{messyTicks()}, Policy: TickOrderFail}
+	got, err := drain(t, s)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate tick")
+	assert.Equal(t, []types.Timestamp{10}, got)
+
+	s = &TickSequencer{Source: &sliceTicks{messyTicks()[2:]}, Policy: TickOrderFail}
+	_, err = drain(t, s)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out-of-order tick")
+}
+
+func TestTickOrderStats_NilSequencer(t *testing.T) {
+	t.Parallel()
+
+	var s *TickSequencer
+	assert.Equal(t, TickOrderStats{}, s.Stats())
+	assert.Equal(t, "seen=0 duplicates=0 out-of-order=0 reordered=0", s.Stats().String())
+}
@@ -0,0 +1,80 @@
+package optimize
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rustyeddy/trader/backtest"
+)
+
+// Objective metrics accepted in ObjectiveConfig.Metric.
+const (
+	MetricSharpe       = "sharpe"
+	MetricProfitFactor = "profit-factor"
+	MetricReturn       = "return"
+	MetricNetPL        = "net-pl"
+	MetricWinRate      = "win-rate"
+	MetricDrawdown     = "drawdown"
+)
+
+// ObjectiveConfig selects what a trial is scored on; higher is better.
+//
+// The score is Metric minus DrawdownPenalty for every percent of the
+// starting balance lost at the worst drawdown, so "sharpe" with a penalty
+// of 0.1 trades one point of Sharpe for ten points of drawdown. The
+// "drawdown" metric is the drawdown percentage itself, negated. Trials with
+// fewer than MinTrades trades are ineligible to be best.
+type ObjectiveConfig struct {
+	Metric          string  `json:"metric,omitempty" yaml:"metric"` // default sharpe
+	DrawdownPenalty float64 `json:"drawdown-penalty,omitempty" yaml:"drawdown-penalty"`
+	MinTrades       int     `json:"min-trades,omitempty" yaml:"min-trades"`
+}
+
+func (o *ObjectiveConfig) validate() error {
+	o.Metric = strings.ToLower(strings.TrimSpace(o.Metric))
+	switch o.Metric {
+	case "":
+		o.Metric = MetricSharpe
+	case MetricSharpe, MetricProfitFactor, MetricReturn, MetricNetPL, MetricWinRate, MetricDrawdown:
+	default:
+		return fmt.Errorf("unknown objective metric %q", o.Metric)
+	}
+	if o.DrawdownPenalty < 0 || math.IsNaN(o.DrawdownPenalty) {
+		return fmt.Errorf("drawdown-penalty must be >= 0")
+	}
+	if o.MinTrades < 0 {
+		return fmt.Errorf("min-trades must be >= 0")
+	}
+	return nil
+}
+
+// Score returns the objective value of s and whether s is eligible.
+func (o ObjectiveConfig) Score(s backtest.BacktestReportSummary) (float64, bool) {
+	dd := drawdownPct(s)
+	var v float64
+	switch o.Metric {
+	case MetricProfitFactor:
+		v = s.ProfitFactor
+	case MetricReturn:
+		v = s.ReturnPct
+	case MetricNetPL:
+		v = s.NetPL
+	case MetricWinRate:
+		v = s.WinRate
+	case MetricDrawdown:
+		v = -dd
+	default:
+		v = s.Sharpe
+	}
+	return v - o.DrawdownPenalty*dd, s.Trades >= o.MinTrades
+}
+
+// drawdownPct is the report's max drawdown as a positive percentage of the
+// starting balance.
+func drawdownPct(s backtest.BacktestReportSummary) float64 {
+	if s.StartBalance <= 0 {
+		return 0
+	}
+	return math.Abs(s.MaxDrawdown) / s.StartBalance * 100
+}
@@ -0,0 +1,237 @@
+package optimize
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/backtest"
+	_ "github.com/rustyeddy/trader/strategies/fake"
+)
+
+const baseBacktestYAML = `version: 2
+defaults:
+  starting-balance: 10000
+  robustness:
+    runs: 5
+runs:
+  - name: first
+    data: {instrument: EURUSD, timeframe: H1, from: "2024-01-01", to: "2024-04-01"}
+    strategy: {kind: fake}
+  - name: tuned
+    data: {instrument: EURUSD, timeframe: H1, from: "2024-01-01", to: "2024-04-01"}
+    strategy: {kind: fake, params: {keep: 1}}
+    exit: {kind: noop}
+`
+
+func writeOptimizeConfig(t *testing.T, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.yaml"), []byte(baseBacktestYAML), 0o644))
+	path := filepath.Join(dir, "opt.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+	return path
+}
+
+// scoreEval scores a run by its strategy's "fast" param, so the best set
+// is known in advance. It records every compiled run it sees.
+type scoreEval struct {
+	runs []backtest.CompiledBacktest
+}
+
+func (e *scoreEval) eval(_ context.Context, c backtest.CompiledBacktest) (backtest.BacktestReportSummary, error) {
+	e.runs = append(e.runs, c)
+	fast, _ := c.RunConfig.Strategy.Params["fast"].(int)
+	return backtest.BacktestReportSummary{
+		Trades:       fast,
+		StartBalance: 10000,
+		Sharpe:       float64(fast) / 10,
+		MaxDrawdown:  -100,
+	}, nil
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeOptimizeConfig(t, `backtest: base.yaml
+trials: 10
+params:
+  - {name: fast, min: 2, max: 20, int: true}
+`)
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "base.yaml"), cfg.Backtest)
+	assert.Equal(t, MethodRandom, cfg.Method)
+	assert.Equal(t, MetricSharpe, cfg.Objective.Metric)
+	assert.Equal(t, HalvingConfig{Eta: 3, Rungs: 3}, cfg.Halving)
+
+	tests := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{"no backtest", "trials: 1\nparams: [{name: a, max: 1}]\n", "needs a backtest"},
+		{"bad method", "backtest: b.yaml\nmethod: grid\ntrials: 1\nparams: [{name: a, max: 1}]\n", "unknown method"},
+		{"no trials", "backtest: b.yaml\nparams: [{name: a, max: 1}]\n", "trials must be >= 1"},
+		{"bad metric", "backtest: b.yaml\ntrials: 1\nobjective: {metric: sortino}\nparams: [{name: a, max: 1}]\n", "unknown objective metric"},
+		{"no params", "backtest: b.yaml\ntrials: 1\n", "no params"},
+		{"bad prefix", "backtest: b.yaml\ntrials: 1\nparams: [{name: filter.a, max: 1}]\n", "bad param name"},
+		{"reversed", "backtest: b.yaml\ntrials: 1\nparams: [{name: a, min: 2, max: 1}]\n", "max must be >= min"},
+		{"log from zero", "backtest: b.yaml\ntrials: 1\nparams: [{name: a, max: 1, log: true}]\n", "log scale needs min > 0"},
+		{"duplicate", "backtest: b.yaml\ntrials: 1\nparams: [{name: a, max: 1}, {name: a, max: 2}]\n", "listed twice"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadConfig(writeOptimizeConfig(t, tc.body))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestSample(t *testing.T) {
+	params := []ParamConfig{
+		{Name: "fast", Min: 2, Max: 9, Int: true},
+		{Name: "exit.multiplier", Min: 0.5, Max: 4, Log: true},
+		{Name: "regime.kind", Values: []any{"adx", "chop"}},
+	}
+	sets := Sample(params, 40, 7)
+	require.Len(t, sets, 40)
+	for _, s := range sets {
+		fast := s["fast"].(int)
+		assert.True(t, fast >= 2 && fast <= 9, "fast %d", fast)
+		m := s["exit.multiplier"].(float64)
+		assert.True(t, m >= 0.5 && m <= 4, "multiplier %v", m)
+		assert.Contains(t, []any{"adx", "chop"}, s["regime.kind"])
+	}
+	assert.Equal(t, sets, Sample(params, 40, 7), "same seed, same sets")
+	assert.NotEqual(t, sets, Sample(params, 40, 8))
+
+	small := Sample([]ParamConfig{{Name: "fast", Min: 1, Max: 3, Int: true}}, 10, 1)
+	assert.Len(t, small, 3, "a space of three sets yields three distinct sets")
+}
+
+func TestObjectiveScore(t *testing.T) {
+	s := backtest.BacktestReportSummary{
+		Trades: 12, StartBalance: 10000, MaxDrawdown: -500,
+		Sharpe: 1.5, ProfitFactor: 1.8, ReturnPct: 9, NetPL: 900, WinRate: 55,
+	}
+	tests := []struct {
+		obj      ObjectiveConfig
+		score    float64
+		eligible bool
+	}{
+		{ObjectiveConfig{Metric: MetricSharpe}, 1.5, true},
+		{ObjectiveConfig{Metric: MetricSharpe, DrawdownPenalty: 0.1}, 1.0, true},
+		{ObjectiveConfig{Metric: MetricProfitFactor}, 1.8, true},
+		{ObjectiveConfig{Metric: MetricReturn}, 9, true},
+		{ObjectiveConfig{Metric: MetricNetPL}, 900, true},
+		{ObjectiveConfig{Metric: MetricWinRate, MinTrades: 20}, 55, false},
+		{ObjectiveConfig{Metric: MetricDrawdown}, -5, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.obj.Metric, func(t *testing.T) {
+			score, eligible := tc.obj.Score(s)
+			assert.InDelta(t, tc.score, score, 1e-9)
+			assert.Equal(t, tc.eligible, eligible)
+		})
+	}
+}
+
+func TestRun_Random(t *testing.T) {
+	cfg, err := LoadConfig(writeOptimizeConfig(t, `backtest: base.yaml
+run: tuned
+trials: 6
+seed: 3
+objective: {min-trades: 1}
+params:
+  - {name: fast, min: 0, max: 30, int: true}
+  - {name: exit.multiplier, values: [1.5, 2.5]}
+`))
+	require.NoError(t, err)
+
+	e := &scoreEval{}
+	res, err := Run(context.Background(), cfg, e.eval)
+	require.NoError(t, err)
+	require.Len(t, res.Trials, 6)
+	require.Len(t, e.runs, 6)
+	assert.Equal(t, "tuned", res.Run)
+
+	for _, c := range e.runs {
+		assert.Equal(t, 1, c.RunConfig.Strategy.Params["keep"], "base params are kept")
+		assert.Contains(t, []any{1.5, 2.5}, c.RunConfig.Exit.Params["multiplier"])
+		assert.Zero(t, c.Request.Robustness.Runs, "trials skip robustness reruns")
+		assert.Equal(t, "2024-04-01", c.RunConfig.Data.To)
+	}
+
+	require.NotNil(t, res.Best)
+	for _, tr := range res.Trials {
+		if tr.Eligible {
+			assert.GreaterOrEqual(t, res.Best.Score, tr.Score)
+		}
+	}
+
+	var buf bytes.Buffer
+	PrintResult(&buf, res, 3)
+	assert.Contains(t, buf.String(), "Best: ")
+}
+
+func TestRun_Halving(t *testing.T) {
+	cfg, err := LoadConfig(writeOptimizeConfig(t, `backtest: base.yaml
+method: halving
+trials: 9
+halving: {eta: 3, rungs: 3}
+params:
+  - {name: fast, min: 1, max: 100, int: true}
+`))
+	require.NoError(t, err)
+
+	e := &scoreEval{}
+	res, err := Run(context.Background(), cfg, e.eval)
+	require.NoError(t, err)
+
+	perRung := map[int]int{}
+	ends := map[int]string{}
+	for _, tr := range res.Trials {
+		perRung[tr.Rung]++
+		ends[tr.Rung] = tr.To
+	}
+	assert.Equal(t, map[int]int{0: 9, 1: 3, 2: 1}, perRung)
+	// 91 days: a ninth is 11 days, a third 31, then the full range.
+	assert.Equal(t, map[int]string{0: "2024-01-12", 1: "2024-02-01", 2: "2024-04-01"}, ends)
+
+	top := 0
+	for _, tr := range res.Trials {
+		if tr.Rung == 0 {
+			top = max(top, tr.Params["fast"].(int))
+		}
+	}
+	require.NotNil(t, res.Best)
+	assert.Equal(t, 2, res.Best.Rung)
+	assert.Equal(t, top, res.Best.Params["fast"], "the best first-rung set survives to the end")
+}
+
+func TestRun_TrialErrorsAreRecorded(t *testing.T) {
+	cfg, err := LoadConfig(writeOptimizeConfig(t, `backtest: base.yaml
+trials: 2
+params:
+  - {name: fast, min: 1, max: 9, int: true}
+`))
+	require.NoError(t, err)
+
+	res, err := Run(context.Background(), cfg, func(context.Context, backtest.CompiledBacktest) (backtest.BacktestReportSummary, error) {
+		return backtest.BacktestReportSummary{}, errors.New("no data")
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Trials, 2)
+	assert.Equal(t, "no data", res.Trials[0].Err)
+	assert.Nil(t, res.Best)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, cfg, (&scoreEval{}).eval)
+	require.ErrorIs(t, err, context.Canceled)
+}
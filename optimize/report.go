@@ -0,0 +1,54 @@
+package optimize
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// PrintResult writes the best top trials of the final rung to w, best
+// first, followed by the best eligible trial. top <= 0 prints them all.
+func PrintResult(w io.Writer, res *Result, top int) {
+	last := 0
+	for _, t := range res.Trials {
+		last = max(last, t.Rung)
+	}
+	var final []Trial
+	failed := 0
+	for _, t := range res.Trials {
+		if t.Err != "" {
+			failed++
+		}
+		if t.Rung == last {
+			final = append(final, t)
+		}
+	}
+	ranked := rank(final, false)
+	if top > 0 && len(ranked) > top {
+		ranked = ranked[:top]
+	}
+
+	fmt.Fprintf(w, "Optimize %s: %s, objective %s, %d trials", res.Run, res.Method, res.Objective.Metric, len(res.Trials))
+	if failed > 0 {
+		fmt.Fprintf(w, " (%d failed)", failed)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Score\tTrades\tReturn %\tSharpe\tPF\tMax DD\t  Params\t")
+	for _, t := range ranked {
+		mark := ""
+		if !t.Eligible {
+			mark = " (ineligible)"
+		}
+		fmt.Fprintf(tw, "%.4f\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t  %s%s\t\n",
+			t.Score, t.Trades, t.ReturnPct, t.Sharpe, t.ProfitFactor, t.MaxDrawdown, paramKey(t.Params), mark)
+	}
+	tw.Flush()
+
+	if res.Best == nil {
+		fmt.Fprintln(w, "\nNo eligible trial.")
+		return
+	}
+	fmt.Fprintf(w, "\nBest: %s (score %.4f, %s..%s)\n", paramKey(res.Best.Params), res.Best.Score, res.Best.From, res.Best.To)
+}
@@ -0,0 +1,305 @@
+package optimize
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/backtest"
+)
+
+// maxSampleAttempts bounds how many draws per trial Sample makes looking
+// for a parameter set it has not produced yet; a small categorical space
+// may hold fewer sets than Trials asks for.
+const maxSampleAttempts = 50
+
+// Evaluator runs one compiled backtest and returns its report summary.
+// The CLI passes the backtest service; tests pass a fake.
+type Evaluator func(ctx context.Context, c backtest.CompiledBacktest) (backtest.BacktestReportSummary, error)
+
+// Trial is one evaluation of one parameter set.
+type Trial struct {
+	Params map[string]any `json:"params"`
+	Rung   int            `json:"rung"` // 0-based halving round; always 0 for random search
+	From   string         `json:"from"`
+	To     string         `json:"to"`
+
+	Score    float64 `json:"score"`
+	Eligible bool    `json:"eligible"`
+	Err      string  `json:"error,omitempty"`
+
+	Trades       int     `json:"trades"`
+	ReturnPct    float64 `json:"return_pct"`
+	Sharpe       float64 `json:"sharpe"`
+	ProfitFactor float64 `json:"profit_factor"`
+	MaxDrawdown  float64 `json:"max_drawdown"`
+	ConfigHash   string  `json:"config_hash,omitempty"`
+}
+
+// Result is the outcome of an optimization.
+type Result struct {
+	Method    string          `json:"method"`
+	Objective ObjectiveConfig `json:"objective"`
+	Run       string          `json:"run"`
+	Trials    []Trial         `json:"trials"`
+	// Best is the highest-scoring eligible trial over the full date range,
+	// or nil when none was eligible.
+	Best *Trial `json:"best,omitempty"`
+}
+
+// Run optimizes the run cfg names, calling eval once per trial. Trials
+// that fail to compile or run are recorded with Err and never best; only
+// a cancelled ctx stops the search early.
+func Run(ctx context.Context, cfg *Config, eval Evaluator) (*Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	base, err := backtest.LoadConfig(cfg.Backtest)
+	if err != nil {
+		return nil, err
+	}
+	run, err := pickRun(base, cfg.Run)
+	if err != nil {
+		return nil, err
+	}
+	// Robustness and cross-validation multiply every trial's cost without
+	// changing its score.
+	base.Defaults.Robustness = backtest.RobustnessConfig{}
+	base.Defaults.CrossValidation = backtest.CrossValidationConfig{}
+
+	from, err := time.Parse(time.DateOnly, strings.TrimSpace(run.Data.From))
+	if err != nil {
+		return nil, fmt.Errorf("run %q: bad from date %q", run.Name, run.Data.From)
+	}
+	to, err := time.Parse(time.DateOnly, strings.TrimSpace(run.Data.To))
+	if err != nil {
+		return nil, fmt.Errorf("run %q: bad to date %q", run.Name, run.Data.To)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("run %q: from %s must be before to %s", run.Name, run.Data.From, run.Data.To)
+	}
+
+	o := &optimizer{cfg: cfg, base: base, run: run, eval: eval}
+	sets := Sample(cfg.Params, cfg.Trials, cfg.Seed)
+
+	res := &Result{Method: cfg.Method, Objective: cfg.Objective, Run: run.Name}
+	rungs := 1
+	if cfg.Method == MethodHalving {
+		rungs = cfg.Halving.Rungs
+	}
+	for r := 0; r < rungs && len(sets) > 0; r++ {
+		end := rungEnd(from, to, math.Pow(float64(cfg.Halving.Eta), -float64(rungs-1-r)))
+		trials := make([]Trial, 0, len(sets))
+		for _, p := range sets {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			trials = append(trials, o.trial(ctx, p, r, from, end))
+		}
+		res.Trials = append(res.Trials, trials...)
+		if r < rungs-1 {
+			sets = survivors(trials, cfg.Halving.Eta)
+		} else {
+			res.Best = best(trials)
+		}
+	}
+	return res, nil
+}
+
+// pickRun returns the run named name, or the first run when name is empty.
+func pickRun(cfg *backtest.Config, name string) (backtest.RunConfig, error) {
+	if len(cfg.Runs) == 0 {
+		return backtest.RunConfig{}, fmt.Errorf("backtest config has no runs")
+	}
+	if name == "" {
+		return cfg.Runs[0], nil
+	}
+	for _, r := range cfg.Runs {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+	return backtest.RunConfig{}, fmt.Errorf("backtest config has no run %q", name)
+}
+
+// rungEnd returns the end date of a halving rung that covers frac of
+// [from, to), rounded up to a whole day.
+func rungEnd(from, to time.Time, frac float64) time.Time {
+	if frac >= 1 {
+		return to
+	}
+	days := to.Sub(from).Hours() / 24
+	n := max(int(math.Ceil(days*frac)), 1)
+	if end := from.AddDate(0, 0, n); end.Before(to) {
+		return end
+	}
+	return to
+}
+
+type optimizer struct {
+	cfg  *Config
+	base *backtest.Config
+	run  backtest.RunConfig
+	eval Evaluator
+}
+
+// trial runs params over [from, to).
+func (o *optimizer) trial(ctx context.Context, params map[string]any, rung int, from, to time.Time) Trial {
+	t := Trial{
+		Params: params,
+		Rung:   rung,
+		From:   from.Format(time.DateOnly),
+		To:     to.Format(time.DateOnly),
+	}
+	run := withParams(o.run, params)
+	run.Data.From, run.Data.To = t.From, t.To
+
+	cfg := *o.base
+	cfg.Runs = []backtest.RunConfig{run}
+	compiled, err := backtest.CompileBacktests(&cfg)
+	if err != nil {
+		t.Err = err.Error()
+		return t
+	}
+	s, err := o.eval(ctx, compiled[0])
+	if err != nil {
+		t.Err = err.Error()
+		return t
+	}
+	t.Score, t.Eligible = o.cfg.Objective.Score(s)
+	t.Trades = s.Trades
+	t.ReturnPct = s.ReturnPct
+	t.Sharpe = s.Sharpe
+	t.ProfitFactor = s.ProfitFactor
+	t.MaxDrawdown = s.MaxDrawdown
+	t.ConfigHash = compiled[0].Request.ConfigHash
+	return t
+}
+
+// withParams returns a copy of run with params set. The params maps are
+// cloned so trials never share them.
+func withParams(run backtest.RunConfig, params map[string]any) backtest.RunConfig {
+	run.Strategy.Params = maps.Clone(run.Strategy.Params)
+	run.Exit.Params = maps.Clone(run.Exit.Params)
+	run.Regime.Params = maps.Clone(run.Regime.Params)
+	for name, v := range params {
+		section, key := splitParamName(name)
+		var m *map[string]any
+		switch section {
+		case "exit":
+			m = &run.Exit.Params
+		case "regime":
+			m = &run.Regime.Params
+		default:
+			m = &run.Strategy.Params
+		}
+		if *m == nil {
+			*m = map[string]any{}
+		}
+		(*m)[key] = v
+	}
+	return run
+}
+
+// survivors returns the parameter sets of the best ceil(len/eta) trials.
+// Eligibility is not applied here: a short rung may simply not have had
+// time for MinTrades trades.
+func survivors(trials []Trial, eta int) []map[string]any {
+	ranked := rank(trials, false)
+	n := (len(trials) + eta - 1) / eta
+	if n > len(ranked) {
+		n = len(ranked)
+	}
+	out := make([]map[string]any, n)
+	for i := range out {
+		out[i] = ranked[i].Params
+	}
+	return out
+}
+
+// best returns the highest-scoring eligible trial, or nil.
+func best(trials []Trial) *Trial {
+	ranked := rank(trials, true)
+	if len(ranked) == 0 {
+		return nil
+	}
+	b := ranked[0]
+	return &b
+}
+
+// rank returns trials without errors (and, with eligibleOnly, only the
+// eligible ones) by descending score, keeping sample order among ties.
+func rank(trials []Trial, eligibleOnly bool) []Trial {
+	out := make([]Trial, 0, len(trials))
+	for _, t := range trials {
+		if t.Err != "" || (eligibleOnly && !t.Eligible) {
+			continue
+		}
+		out = append(out, t)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+// Sample draws up to n distinct parameter sets from params with a
+// math/rand source seeded by seed, so a seed always yields the same sets.
+func Sample(params []ParamConfig, n int, seed int64) []map[string]any {
+	rng := rand.New(rand.NewSource(seed))
+	seen := make(map[string]bool, n)
+	var out []map[string]any
+	for attempts := 0; len(out) < n && attempts < n*maxSampleAttempts; attempts++ {
+		set := make(map[string]any, len(params))
+		for _, p := range params {
+			set[p.Name] = p.sample(rng)
+		}
+		key := paramKey(set)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, set)
+	}
+	return out
+}
+
+func (p ParamConfig) sample(rng *rand.Rand) any {
+	if len(p.Values) > 0 {
+		return p.Values[rng.Intn(len(p.Values))]
+	}
+	if p.Int {
+		lo, hi := int(math.Ceil(p.Min)), int(math.Floor(p.Max))
+		if p.Log {
+			v := int(math.Round(math.Exp(math.Log(float64(lo)) + rng.Float64()*(math.Log(float64(hi))-math.Log(float64(lo))))))
+			return min(max(v, lo), hi)
+		}
+		return lo + rng.Intn(hi-lo+1)
+	}
+	if p.Log {
+		return math.Exp(math.Log(p.Min) + rng.Float64()*(math.Log(p.Max)-math.Log(p.Min)))
+	}
+	return p.Min + rng.Float64()*(p.Max-p.Min)
+}
+
+// paramKey renders set deterministically for de-duplication and display.
+func paramKey(set map[string]any) string {
+	names := make([]string, 0, len(set))
+	for k := range set {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, k := range names {
+		v := set[k]
+		if f, ok := v.(float64); ok {
+			parts[i] = fmt.Sprintf("%s=%.6g", k, f)
+			continue
+		}
+		parts[i] = fmt.Sprintf("%s=%v", k, v)
+	}
+	return strings.Join(parts, " ")
+}
@@ -8,7 +8,10 @@
 package planner
 
 import (
+	"errors"
+
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/strategy"
 	"github.com/rustyeddy/trader/types"
@@ -32,12 +35,47 @@ type PlanContext interface {
 	DefaultStopPips() types.Pips
 }
 
+// SizingAuditor is implemented by PlanContexts that want the audit record
+// of every sizing the planner runs (see account.SizingAudit). It is
+// optional: contexts that don't implement it are sized without one.
+type SizingAuditor interface {
+	AuditSizing() bool
+}
+
+// StopChecker is implemented by PlanContexts whose broker refuses stops
+// placed too close to the market. The planner offers it every open once
+// its initial stop is placed, measured from the candle close; an error
+// rejects the open with journal.RejectStopDistance. Optional like
+// SizingAuditor.
+type StopChecker interface {
+	CheckStops(o *account.OpenRequest, ref types.Price) error
+}
+
+// SpreadGuard is implemented by PlanContexts that gate entries on the
+// spread beyond the flat MaxSpread, such as per-instrument caps or a
+// multiple of the recent average. BlockSpread returns the rule the
+// candle's spread breaks, or ""; a broken rule rejects every open with
+// journal.RejectSpreadGuard and the rule as its detail. Optional like
+// SizingAuditor.
+type SpreadGuard interface {
+	BlockSpread(spread types.Price) string
+}
+
 // Stats reports the execution-cost bookkeeping a Planner produces while
 // finalizing a plan. Callers fold these into their run state.
 type Stats struct {
 	SpreadFiltered int         // opens suppressed by the max-spread gate
 	SpreadOpened   int         // opens accepted (denominator for avg-spread)
 	SpreadSum      types.Price // sum of candle AvgSpread over accepted opens
+
+	// Decisions holds one entry per open the plan considered, in plan
+	// order: accepted with its sized units, or rejected with the gate or
+	// sizing cause. Nil when the plan had no opens.
+	Decisions []journal.OrderDecision
+
+	// Sizing holds the audit record of every open the planner sized, in
+	// plan order, when the PlanContext audits sizing; nil otherwise.
+	Sizing []account.SizingAudit
 }
 
 // DefaultPlanner is the behavior-preserving extraction of the logic that used
@@ -61,18 +99,50 @@ func (DefaultPlanner) finalize(raw *strategy.StrategyPlan, pc PlanContext) (*str
 	exit := pc.Exit()
 	regime := pc.Regime()
 	acct := pc.Account()
+	sa, audit := pc.(SizingAuditor)
+	audit = audit && sa.AuditSizing()
+	stops, _ := pc.(StopChecker)
+	guard, _ := pc.(SpreadGuard)
+
+	// decide records the verdict on o; requested is the units the
+	// strategy asked for, before sizing filled them in.
+	decide := func(o *account.OpenRequest, requested types.Units, reason, detail string) {
+		d := journal.OrderDecision{
+			Time:           candle.Timestamp,
+			Instrument:     pc.Instrument(),
+			Side:           o.Side.String(),
+			Accepted:       reason == "",
+			Reason:         reason,
+			Detail:         detail,
+			RequestedUnits: requested,
+		}
+		if d.Accepted {
+			d.Units = o.Units
+		}
+		stats.Decisions = append(stats.Decisions, d)
+	}
+	rejectAll := func(reason, detail string) {
+		for _, o := range raw.Opens {
+			if o != nil {
+				decide(o, o.Units, reason, detail)
+			}
+		}
+		raw.Opens = nil
+	}
 
 	// Regime filter: suppress new entries in ranging/consolidating markets.
 	// Existing positions continue to be managed by the exit strategy.
 	if regime != nil && regime.Ready() {
 		if !regime.Trending() {
-			raw.Opens = nil
+			rejectAll(journal.RejectRegime, "")
 		} else if len(raw.Opens) > 0 {
 			filtered := raw.Opens[:0]
 			for _, o := range raw.Opens {
-				if regime.AllowSide(o.Side) {
-					filtered = append(filtered, o)
+				if o != nil && !regime.AllowSide(o.Side) {
+					decide(o, o.Units, journal.RejectRegimeSide, "")
+					continue
 				}
+				filtered = append(filtered, o)
 			}
 			raw.Opens = filtered
 		}
@@ -82,7 +152,12 @@ func (DefaultPlanner) finalize(raw *strategy.StrategyPlan, pc PlanContext) (*str
 	// (market opens, news events, low-liquidity periods).
 	if maxSpread > 0 && candle.AvgSpread > maxSpread && len(raw.Opens) > 0 {
 		stats.SpreadFiltered++
-		raw.Opens = nil
+		rejectAll(journal.RejectMaxSpread, "")
+	}
+	if guard != nil && len(raw.Opens) > 0 {
+		if rule := guard.BlockSpread(candle.AvgSpread); rule != "" {
+			rejectAll(journal.RejectSpreadGuard, rule)
+		}
 	}
 
 	// Strategy-driven closes: short closes by buying at ask, long closes by
@@ -94,17 +169,18 @@ func (DefaultPlanner) finalize(raw *strategy.StrategyPlan, pc PlanContext) (*str
 		}
 	}
 
-	// Opens: resolve fill price, initial stop, and size.
+	// Opens: resolve fill price, initial stop, and size. An open that
+	// sizing refuses is dropped from the plan with a rejected decision.
+	opens := raw.Opens[:0]
 	for _, openReq := range raw.Opens {
 		if openReq == nil {
 			continue
 		}
+		requested := openReq.Units
 
 		// Long buys at ask; short sells at bid.
 		isBuy := openReq.Side == types.Long
 		openReq.Price += account.FillAdjust(isBuy, candle.AvgSpread, slippage)
-		stats.SpreadOpened++
-		stats.SpreadSum += candle.AvgSpread
 
 		// Let the exit strategy override the initial stop when configured.
 		if exit != nil && exit.Ready() {
@@ -128,20 +204,62 @@ func (DefaultPlanner) finalize(raw *strategy.StrategyPlan, pc PlanContext) (*str
 			}
 		}
 
+		if stops != nil {
+			if err := stops.CheckStops(openReq, candle.Close); err != nil {
+				decide(openReq, requested, journal.RejectStopDistance, err.Error())
+				continue
+			}
+		}
+
 		if openReq.Units == 0 && acct != nil {
-			if err := acct.SizePosition(openReq); err != nil {
-				return raw, stats, err
+			var err error
+			if audit {
+				var a account.SizingAudit
+				a, err = acct.SizePositionAudit(openReq)
+				stats.Sizing = append(stats.Sizing, a)
+			} else {
+				err = acct.SizePosition(openReq)
+			}
+			if err != nil {
+				reason := sizingRejectReason(err)
+				if reason == "" {
+					return raw, stats, err
+				}
+				decide(openReq, requested, reason, err.Error())
+				continue
 			}
 		}
+		stats.SpreadOpened++
+		stats.SpreadSum += candle.AvgSpread
 
 		// Capture the stop actually used to open, before trailing/chandelier
 		// updates start overwriting Stop bar by bar.
 		openReq.InitialStop = openReq.Stop
+
+		decide(openReq, requested, "", "")
+		opens = append(opens, openReq)
 	}
+	raw.Opens = opens
 
 	return raw, stats, nil
 }
 
+// sizingRejectReason maps a SizePosition error to its rejection reason, or
+// "" when the error is not a refusal and should abort planning.
+func sizingRejectReason(err error) string {
+	switch {
+	case errors.Is(err, account.ErrInvalidStop):
+		return journal.RejectInvalidStop
+	case errors.Is(err, account.ErrRiskBudget):
+		return journal.RejectRiskBudget
+	case errors.Is(err, account.ErrInsufficientMargin):
+		return journal.RejectMargin
+	case errors.Is(err, account.ErrBelowMinimumSize):
+		return journal.RejectMinimumSize
+	}
+	return ""
+}
+
 // PlanSignal translates a strategy.Signal into a finalized StrategyPlan using
 // the same gates and order-construction logic as Plan.
 //
@@ -150,6 +268,7 @@ func (DefaultPlanner) finalize(raw *strategy.StrategyPlan, pc PlanContext) (*str
 //   - Directional + !CloseAll → reversal-close opposing lots only.
 //   - Directional side → open a new position at candle close; then run the
 //     full Plan pipeline (regime gate, max-spread gate, fill-price, sizing).
+//     sig.RiskScale carries over to the open's sizing.
 func (p DefaultPlanner) PlanSignal(sig strategy.Signal, pc PlanContext) (*strategy.StrategyPlan, Stats, error) {
 	plan := &strategy.StrategyPlan{Reason: sig.Reason}
 
@@ -203,9 +322,9 @@ func (p DefaultPlanner) PlanSignal(sig strategy.Signal, pc PlanContext) (*strate
 	}
 
 	if sig.Side != types.Flat {
-		plan.Opens = append(plan.Opens, account.NewOpenRequest(
-			pc.Instrument(), &candle, sig.Side, sig.Stop, 0, sig.Reason,
-		))
+		open := account.NewOpenRequest(pc.Instrument(), &candle, sig.Side, sig.Stop, 0, sig.Reason)
+		open.RiskScale = sig.RiskScale
+		plan.Opens = append(plan.Opens, open)
 	}
 
 	return p.finalize(plan, pc)
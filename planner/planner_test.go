@@ -1,9 +1,11 @@
 package planner
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/strategy"
 	"github.com/rustyeddy/trader/types"
@@ -64,6 +66,42 @@ func (c testCtx) Slippage() types.Price         { return c.slippage }
 func (c testCtx) MaxSpread() types.Price        { return c.maxSpread }
 func (c testCtx) DefaultStopPips() types.Pips   { return 0 }
 
+// auditCtx is a testCtx that asks for sizing audits.
+type auditCtx struct{ testCtx }
+
+func (auditCtx) AuditSizing() bool { return true }
+
+// stopCtx is a testCtx whose broker wants stops at least minStop from the
+// reference price.
+type stopCtx struct {
+	testCtx
+	minStop types.Price
+}
+
+func (c stopCtx) CheckStops(o *account.OpenRequest, ref types.Price) error {
+	away := ref - o.Stop
+	if o.Side == types.Short {
+		away = -away
+	}
+	if away < c.minStop {
+		return errors.New("stop too close to market")
+	}
+	return nil
+}
+
+// guardCtx is a testCtx whose spread guard blocks spreads above max.
+type guardCtx struct {
+	testCtx
+	max types.Price
+}
+
+func (c guardCtx) BlockSpread(spread types.Price) string {
+	if spread > c.max {
+		return "max-pips"
+	}
+	return ""
+}
+
 func openReq(id string, side types.Side, price, stop types.Price, units types.Units) *account.OpenRequest {
 	return &account.OpenRequest{Request: account.Request{
 		TradeCommon: &account.TradeCommon{ID: id, Instrument: "EURUSD", Side: side, Units: units, Stop: stop},
@@ -228,16 +266,122 @@ func TestDefaultPlanner_SizesWhenUnitsZero(t *testing.T) {
 	assert.NotZero(t, op.Units, "planner should size the position")
 }
 
-func TestDefaultPlanner_SizingErrorPropagates(t *testing.T) {
+func TestDefaultPlanner_SizingRefusalRejectsOpen(t *testing.T) {
 	t.Parallel()
 	acct := account.NewAccount("t", types.MoneyFromFloat(10_000))
 	acct.Equity = acct.Balance
 	acct.RiskFraction = types.RateFromFloat(0.01)
 
-	// Entry == Stop is an invalid sizing request.
+	// Entry == Stop is refused by sizing: the open is dropped, not fatal.
 	op := openReq("o1", types.Long, types.PriceFromFloat(1.10), types.PriceFromFloat(1.10), 0)
 	plan := &strategy.StrategyPlan{Opens: []*account.OpenRequest{op}}
 
+	out, stats, err := DefaultPlanner{}.finalize(plan, testCtx{
+		instrument: "EURUSD",
+		acct:       acct,
+		regime:     strategy.NoopRegime{},
+		exit:       strategy.NoopExit{},
+		candle:     candleTime(0),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, out.Opens)
+	assert.Zero(t, stats.SpreadOpened)
+	require.Len(t, stats.Decisions, 1)
+	d := stats.Decisions[0]
+	assert.False(t, d.Accepted)
+	assert.Equal(t, journal.RejectInvalidStop, d.Reason)
+	assert.Contains(t, d.Detail, "entry and stop must differ")
+	assert.Equal(t, "long", d.Side)
+	assert.Equal(t, "EURUSD", d.Instrument)
+}
+
+func TestDefaultPlanner_AuditsSizingWhenAsked(t *testing.T) {
+	t.Parallel()
+	acct := account.NewAccount("t", types.MoneyFromFloat(10_000))
+	acct.Equity = acct.Balance
+	acct.RiskFraction = types.RateFromFloat(0.01)
+
+	ctx := testCtx{acct: acct, regime: strategy.NoopRegime{}, exit: strategy.NoopExit{}, candle: candleTime(0)}
+	newPlan := func() *strategy.StrategyPlan {
+		return &strategy.StrategyPlan{Opens: []*account.OpenRequest{
+			openReq("o1", types.Long, types.PriceFromFloat(1.10), types.PriceFromFloat(1.09), 0),
+			openReq("o2", types.Long, types.PriceFromFloat(1.10), types.PriceFromFloat(1.10), 0),
+			openReq("o3", types.Long, types.PriceFromFloat(1.10), types.PriceFromFloat(1.09), 500),
+		}}
+	}
+
+	_, stats, err := DefaultPlanner{}.finalize(newPlan(), ctx)
+	require.NoError(t, err)
+	assert.Nil(t, stats.Sizing, "no audit unless the context asks")
+
+	plan := newPlan()
+	_, stats, err = DefaultPlanner{}.finalize(plan, auditCtx{ctx})
+	require.NoError(t, err)
+	require.Len(t, stats.Sizing, 2, "the fixed-size open is not sized")
+	assert.Equal(t, plan.Opens[0].Units, stats.Sizing[0].Units)
+	assert.Equal(t, types.PipsFromFloat(100), stats.Sizing[0].StopPips)
+	assert.Zero(t, stats.Sizing[1].Units)
+	assert.Contains(t, stats.Sizing[1].Err, "entry and stop must differ")
+}
+
+func TestDefaultPlanner_StopCheckerRejectsCloseStops(t *testing.T) {
+	t.Parallel()
+	plan := &strategy.StrategyPlan{Opens: []*account.OpenRequest{
+		openReq("o1", types.Long, types.PriceFromFloat(1.10), types.PriceFromFloat(1.0999), 1000),
+		openReq("o2", types.Short, types.PriceFromFloat(1.10), types.PriceFromFloat(1.1010), 1000),
+	}}
+
+	out, stats, err := DefaultPlanner{}.finalize(plan, stopCtx{
+		testCtx: testCtx{instrument: "EURUSD", regime: strategy.NoopRegime{}, exit: strategy.NoopExit{}, candle: candleTime(0)},
+		minStop: types.PriceFromFloat(0.0005),
+	})
+	require.NoError(t, err)
+	require.Len(t, out.Opens, 1)
+	assert.Equal(t, "o2", out.Opens[0].ID)
+	require.Len(t, stats.Decisions, 2)
+	assert.Equal(t, journal.RejectStopDistance, stats.Decisions[0].Reason)
+	assert.Equal(t, "stop too close to market", stats.Decisions[0].Detail)
+	assert.True(t, stats.Decisions[1].Accepted)
+}
+
+func TestDefaultPlanner_SpreadGuardRejectsOpens(t *testing.T) {
+	t.Parallel()
+	newPlan := func() *strategy.StrategyPlan {
+		return &strategy.StrategyPlan{Opens: []*account.OpenRequest{
+			openReq("o1", types.Long, types.PriceFromFloat(1.10), types.PriceFromFloat(1.09), 1000),
+		}}
+	}
+	ctx := func(spread types.Price) guardCtx {
+		return guardCtx{
+			testCtx: testCtx{instrument: "EURUSD", regime: strategy.NoopRegime{}, exit: strategy.NoopExit{}, candle: candleTime(spread)},
+			max:     20,
+		}
+	}
+
+	out, stats, err := DefaultPlanner{}.finalize(newPlan(), ctx(21))
+	require.NoError(t, err)
+	assert.Empty(t, out.Opens)
+	require.Len(t, stats.Decisions, 1)
+	assert.Equal(t, journal.RejectSpreadGuard, stats.Decisions[0].Reason)
+	assert.Equal(t, "max-pips", stats.Decisions[0].Detail)
+	assert.Zero(t, stats.SpreadFiltered, "counted apart from the flat max-spread gate")
+
+	out, _, err = DefaultPlanner{}.finalize(newPlan(), ctx(20))
+	require.NoError(t, err)
+	assert.Len(t, out.Opens, 1)
+}
+
+func TestDefaultPlanner_SizingErrorPropagates(t *testing.T) {
+	t.Parallel()
+	acct := account.NewAccount("t", types.MoneyFromFloat(10_000))
+	acct.Equity = acct.Balance
+	acct.RiskFraction = types.RateFromFloat(0.01)
+
+	// An unknown instrument is an input bug, not a refusal.
+	op := openReq("o1", types.Long, types.PriceFromFloat(1.10), types.PriceFromFloat(1.09), 0)
+	op.Instrument = "ZZZQQQ"
+	plan := &strategy.StrategyPlan{Opens: []*account.OpenRequest{op}}
+
 	_, _, err := DefaultPlanner{}.finalize(plan, testCtx{
 		acct:   acct,
 		regime: strategy.NoopRegime{},
@@ -247,6 +391,85 @@ func TestDefaultPlanner_SizingErrorPropagates(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestDefaultPlanner_DecisionsRecordGatesAndSizing(t *testing.T) {
+	t.Parallel()
+	newAcct := func(equity float64) *account.Account {
+		acct := account.NewAccount("t", types.MoneyFromFloat(equity))
+		acct.Equity = acct.Balance
+		acct.RiskFraction = types.RateFromFloat(0.01)
+		return acct
+	}
+	long := func() *account.OpenRequest {
+		return openReq("o1", types.Long, types.PriceFromFloat(1.10), types.PriceFromFloat(1.09), 0)
+	}
+	short := func() *account.OpenRequest {
+		return openReq("o2", types.Short, types.PriceFromFloat(1.10), types.PriceFromFloat(1.11), 500)
+	}
+
+	cases := []struct {
+		name   string
+		opens  []*account.OpenRequest
+		ctx    testCtx
+		reason []string
+	}{
+		{
+			name:   "regime not trending",
+			opens:  []*account.OpenRequest{long(), short()},
+			ctx:    testCtx{regime: fakeRegime{ready: true}},
+			reason: []string{journal.RejectRegime, journal.RejectRegime},
+		},
+		{
+			name:   "regime disallows side",
+			opens:  []*account.OpenRequest{long(), short()},
+			ctx:    testCtx{regime: fakeRegime{ready: true, trending: true, allow: map[types.Side]bool{types.Short: true}}},
+			reason: []string{journal.RejectRegimeSide, ""},
+		},
+		{
+			name:   "spread too wide",
+			opens:  []*account.OpenRequest{long()},
+			ctx:    testCtx{maxSpread: 10, candle: candleTime(20)},
+			reason: []string{journal.RejectMaxSpread},
+		},
+		{
+			name:   "free margin exhausted",
+			opens:  []*account.OpenRequest{long()},
+			ctx:    testCtx{acct: func() *account.Account { a := newAcct(10_000); a.FreeMargin, a.MarginUsed = 0, a.Equity; return a }()},
+			reason: []string{journal.RejectMargin},
+		},
+		{
+			name:   "sized",
+			opens:  []*account.OpenRequest{long()},
+			ctx:    testCtx{acct: newAcct(10_000)},
+			reason: []string{""},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ctx.instrument = "EURUSD"
+			_, stats, err := DefaultPlanner{}.finalize(&strategy.StrategyPlan{Opens: tc.opens}, tc.ctx)
+			require.NoError(t, err)
+			require.Len(t, stats.Decisions, len(tc.reason))
+			for i, want := range tc.reason {
+				d := stats.Decisions[i]
+				assert.Equal(t, want, d.Reason)
+				assert.Equal(t, want == "", d.Accepted)
+				if d.Accepted {
+					assert.NotZero(t, d.Units)
+				} else {
+					assert.Zero(t, d.Units)
+				}
+			}
+		})
+	}
+
+	// A pre-sized open keeps its requested units.
+	_, stats, err := DefaultPlanner{}.finalize(&strategy.StrategyPlan{Opens: []*account.OpenRequest{short()}}, testCtx{instrument: "EURUSD"})
+	require.NoError(t, err)
+	require.Len(t, stats.Decisions, 1)
+	assert.Equal(t, types.Units(500), stats.Decisions[0].RequestedUnits)
+	assert.Equal(t, types.Units(500), stats.Decisions[0].Units)
+}
+
 // --- PlanSignal tests -------------------------------------------------------
 
 func TestPlanSignal_FlatHolds(t *testing.T) {
@@ -390,6 +613,29 @@ func TestPlanSignal_SizesWhenAccountPresent(t *testing.T) {
 	assert.NotZero(t, plan.Opens[0].Units, "planner must size the position")
 }
 
+func TestPlanSignal_RiskScaleSizesLarger(t *testing.T) {
+	t.Parallel()
+	acct := account.NewAccount("t", types.MoneyFromFloat(10_000))
+	acct.Equity = acct.Balance
+	acct.RiskFraction = types.RateFromFloat(0.01)
+	pc := testCtx{
+		instrument: "EURUSD",
+		acct:       acct,
+		regime:     strategy.NoopRegime{},
+		candle:     market.Candle{Close: types.PriceFromFloat(1.10)},
+	}
+	stop := types.PriceFromFloat(1.09)
+
+	base, _, err := DefaultPlanner{}.PlanSignal(strategy.Signal{Side: types.Long, Stop: stop}, pc)
+	require.NoError(t, err)
+	scaled, _, err := DefaultPlanner{}.PlanSignal(strategy.Signal{Side: types.Long, Stop: stop, RiskScale: types.RateFromFloat(1.5)}, pc)
+	require.NoError(t, err)
+	require.Len(t, base.Opens, 1)
+	require.Len(t, scaled.Opens, 1)
+	assert.Equal(t, types.RateFromFloat(1.5), scaled.Opens[0].RiskScale)
+	assert.InDelta(t, 1.5*float64(base.Opens[0].Units), float64(scaled.Opens[0].Units), 2)
+}
+
 func TestDefaultPlanner_CloseFillAdjust(t *testing.T) {
 	t.Parallel()
 	avgSpread := types.Price(10)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/indicator"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
@@ -15,7 +16,7 @@ import (
 func ReviewPair(instrument string, w1, d1, h4 []market.Candle, th Thresholds) (ReviewResult, error) {
 	inst := market.GetInstrument(instrument)
 	if inst == nil {
-		return ReviewResult{}, fmt.Errorf("review: unknown instrument %q", instrument)
+		return ReviewResult{}, errs.Newf(errs.ErrUnknownInstrument, "review: unknown instrument %q", instrument)
 	}
 
 	d1Snap, d1Bias, err := computeD1(inst, d1)
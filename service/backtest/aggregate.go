@@ -0,0 +1,287 @@
+package backtestsvc
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rustyeddy/trader/backtest"
+)
+
+// The keys AggregateReports can group reports by.
+const (
+	GroupByStrategy = "strategy"
+	GroupByDataset  = "dataset"
+	GroupByParams   = "params"
+)
+
+// aggregateMetrics are the report metrics AggregateReports summarises, in
+// table and CSV column order.
+var aggregateMetrics = []struct {
+	name  string
+	value func(backtest.BacktestReportSummary) float64
+}{
+	{"trades", func(s backtest.BacktestReportSummary) float64 { return float64(s.Trades) }},
+	{"win_rate", func(s backtest.BacktestReportSummary) float64 { return s.WinRate }},
+	{"return_pct", func(s backtest.BacktestReportSummary) float64 { return s.ReturnPct }},
+	{"net_pl", func(s backtest.BacktestReportSummary) float64 { return s.NetPL }},
+	{"profit_factor", func(s backtest.BacktestReportSummary) float64 { return s.ProfitFactor }},
+	{"sharpe", func(s backtest.BacktestReportSummary) float64 { return s.Sharpe }},
+	{"max_drawdown", func(s backtest.BacktestReportSummary) float64 { return s.MaxDrawdown }},
+	{"rr", func(s backtest.BacktestReportSummary) float64 { return s.RR }},
+}
+
+// AggregateRequest parameterises AggregateReports.
+type AggregateRequest struct {
+	Dir string   // reports directory, searched recursively
+	By  []string // group keys, GroupBy*; empty means all three
+}
+
+// AggregateResult is the per-group summary of a directory of reports.
+type AggregateResult struct {
+	Dir     string        `json:"dir"`
+	By      []string      `json:"by"`
+	Reports int           `json:"reports"` // reports aggregated
+	Skipped int           `json:"skipped"` // interrupted (partial) reports left out
+	Groups  []ReportGroup `json:"groups"`
+}
+
+// ReportGroup is the summary of the reports sharing one group key. Keys
+// not grouped by are blank.
+type ReportGroup struct {
+	Strategy string        `json:"strategy,omitempty"`
+	Dataset  string        `json:"dataset,omitempty"`
+	Params   string        `json:"params,omitempty"`
+	Runs     int           `json:"runs"`
+	Metrics  []MetricStats `json:"metrics"`
+}
+
+// MetricStats is the spread of one report metric across a group. StdDev is
+// the sample standard deviation, zero for a group of one.
+type MetricStats struct {
+	Metric string  `json:"metric"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	StdDev float64 `json:"stddev"`
+}
+
+// AggregateReports reads every backtest JSON report under req.Dir — flat
+// reports and run directories' report.json alike — groups them by
+// strategy, dataset (instrument, timeframe, date range and source) and/or
+// strategy parameters, and summarises each group's key metrics. A run
+// found both flat and in its run directory counts once; interrupted runs'
+// partial reports are skipped. The statistics are over the reports' own
+// display figures (percentages as percent, money in report currency).
+func AggregateReports(req AggregateRequest) (*AggregateResult, error) {
+	by, err := normalizeGroupBy(req.By)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := findBacktestSummaries(req.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AggregateResult{Dir: req.Dir, By: by}
+	type key struct{ strategy, dataset, params string }
+	groups := map[key][]backtest.BacktestReportSummary{}
+	for _, s := range summaries {
+		if s.Interrupted {
+			res.Skipped++
+			continue
+		}
+		res.Reports++
+		g := groupKey(s, by)
+		k := key{g.Strategy, g.Dataset, g.Params}
+		groups[k] = append(groups[k], s)
+	}
+
+	for k, members := range groups {
+		g := ReportGroup{Strategy: k.strategy, Dataset: k.dataset, Params: k.params, Runs: len(members)}
+		for _, m := range aggregateMetrics {
+			vals := make([]float64, len(members))
+			for i, s := range members {
+				vals[i] = m.value(s)
+			}
+			g.Metrics = append(g.Metrics, metricStats(m.name, vals))
+		}
+		res.Groups = append(res.Groups, g)
+	}
+	sort.Slice(res.Groups, func(i, j int) bool {
+		a, b := res.Groups[i], res.Groups[j]
+		if a.Strategy != b.Strategy {
+			return a.Strategy < b.Strategy
+		}
+		if a.Dataset != b.Dataset {
+			return a.Dataset < b.Dataset
+		}
+		return a.Params < b.Params
+	})
+	return res, nil
+}
+
+// normalizeGroupBy validates and dedupes group keys, defaulting to all.
+func normalizeGroupBy(by []string) ([]string, error) {
+	if len(by) == 0 {
+		return []string{GroupByStrategy, GroupByDataset, GroupByParams}, nil
+	}
+	var out []string
+	for _, k := range by {
+		k = strings.ToLower(strings.TrimSpace(k))
+		switch k {
+		case GroupByStrategy, GroupByDataset, GroupByParams:
+		default:
+			return nil, fmt.Errorf("unknown group key %q (use %s, %s or %s)", k, GroupByStrategy, GroupByDataset, GroupByParams)
+		}
+		if !slices.Contains(out, k) {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+// findBacktestSummaries reads every parseable backtest report under dir,
+// skipping index.json, other JSON files, and duplicate copies of a run.
+func findBacktestSummaries(dir string) ([]backtest.BacktestReportSummary, error) {
+	type runID struct{ hash, generated string }
+	seen := map[runID]bool{}
+	var out []backtest.BacktestReportSummary
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") || d.Name() == "index.json" {
+			return nil
+		}
+		s, err := ReadBacktestSummaryFile(path)
+		if err != nil || s.Strategy == "" {
+			return nil // not a backtest report
+		}
+		if s.ConfigHash != "" && s.GeneratedAt != "" {
+			id := runID{s.ConfigHash, s.GeneratedAt}
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+		}
+		out = append(out, s)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan reports in %q: %w", dir, err)
+	}
+	return out, nil
+}
+
+// groupKey is the group s falls in when grouping by the keys in by.
+func groupKey(s backtest.BacktestReportSummary, by []string) ReportGroup {
+	var g ReportGroup
+	for _, k := range by {
+		switch k {
+		case GroupByStrategy:
+			g.Strategy = s.Strategy
+		case GroupByDataset:
+			g.Dataset = datasetLabel(s)
+		case GroupByParams:
+			g.Params = paramsLabel(s.Config.Strategy.Params)
+		}
+	}
+	return g
+}
+
+// datasetLabel names the data a report ran over, e.g.
+// "EURUSD H1 2024-01-01..2024-03-31 dukascopy". The configured range is
+// used when the report has its config, so early-stopped runs group with
+// the rest.
+func datasetLabel(s backtest.BacktestReportSummary) string {
+	inst, tf, from, to := s.Instrument, s.Timeframe, s.Start, s.End
+	if d := s.Config.Data; d.Instrument != "" {
+		inst, tf, from, to = d.Instrument, d.Timeframe, d.From, d.To
+	}
+	label := fmt.Sprintf("%s %s %s..%s", inst, strings.ToUpper(tf), dateOnly(from), dateOnly(to))
+	if s.Dataset != "" {
+		label += " " + s.Dataset
+	}
+	return label
+}
+
+func dateOnly(s string) string {
+	if len(s) >= 10 {
+		return s[:10]
+	}
+	return s
+}
+
+// paramsLabel formats strategy params as sorted "key=value" pairs.
+func paramsLabel(params map[string]any) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, params[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func metricStats(name string, vals []float64) MetricStats {
+	st := MetricStats{Metric: name}
+	n := len(vals)
+	if n == 0 {
+		return st
+	}
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	if n%2 == 1 {
+		st.Median = sorted[n/2]
+	} else {
+		st.Median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	for _, v := range vals {
+		st.Mean += v
+	}
+	st.Mean /= float64(n)
+	if n > 1 {
+		var ss float64
+		for _, v := range vals {
+			ss += (v - st.Mean) * (v - st.Mean)
+		}
+		st.StdDev = math.Sqrt(ss / float64(n-1))
+	}
+	return st
+}
+
+// WriteAggregateCSV writes res as CSV: one row per group with its keys,
+// run count, and the mean, median and standard deviation of each metric
+// (<metric>_mean, <metric>_median, <metric>_stddev).
+func WriteAggregateCSV(w io.Writer, res *AggregateResult) error {
+	cw := csv.NewWriter(w)
+	header := []string{"strategy", "dataset", "params", "runs"}
+	for _, m := range aggregateMetrics {
+		header = append(header, m.name+"_mean", m.name+"_median", m.name+"_stddev")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+	for _, g := range res.Groups {
+		row := []string{g.Strategy, g.Dataset, g.Params, strconv.Itoa(g.Runs)}
+		for _, st := range g.Metrics {
+			row = append(row, format(st.Mean), format(st.Median), format(st.StdDev))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,105 @@
+package backtestsvc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func aggregateSummary(name, hash string, fast int, ret float64) backtest.BacktestReportSummary {
+	return backtest.BacktestReportSummary{
+		Name:        name,
+		Strategy:    "ema-cross",
+		Instrument:  "EURUSD",
+		Timeframe:   "h1",
+		ReturnPct:   ret,
+		NetPL:       ret * 100,
+		Trades:      10,
+		ConfigHash:  hash,
+		GeneratedAt: "2026-01-02T00:00:00Z",
+		Config: backtest.RunConfig{
+			Name:     name,
+			Data:     backtest.DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2025-01-01", To: "2025-06-30"},
+			Strategy: strategy.StrategyConfig{Kind: "ema-cross", Params: map[string]any{"fast": fast, "slow": 30}},
+		},
+	}
+}
+
+func TestAggregateReports_GroupsAndSummarises(t *testing.T) {
+	dir := t.TempDir()
+	a := aggregateSummary("a", "aaaa0001", 10, 2)
+	b := aggregateSummary("b", "aaaa0002", 10, 4)
+	c := aggregateSummary("c", "aaaa0003", 10, 9)
+	d := aggregateSummary("d", "aaaa0004", 20, -1)
+	partial := aggregateSummary("e", "aaaa0005", 20, 50)
+	partial.Interrupted = true
+
+	require.NoError(t, WriteBacktestSummaryJSON(filepath.Join(dir, "a.json"), a))
+	require.NoError(t, WriteBacktestSummaryJSON(filepath.Join(dir, "b.json"), b))
+	require.NoError(t, WriteBacktestSummaryJSON(filepath.Join(dir, "d.json"), d))
+	require.NoError(t, WriteBacktestSummaryJSON(filepath.Join(dir, "e.json"), partial))
+	// c lives in a run directory; a's run directory copy counts once.
+	runDir := filepath.Join(dir, "2026-01-02", "c-aaaa0003")
+	require.NoError(t, os.MkdirAll(runDir, 0o755))
+	require.NoError(t, WriteBacktestSummaryJSON(filepath.Join(runDir, RunReportJSON), c))
+	aDir := filepath.Join(dir, "2026-01-02", "a-aaaa0001")
+	require.NoError(t, os.MkdirAll(aDir, 0o755))
+	require.NoError(t, WriteBacktestSummaryJSON(filepath.Join(aDir, RunReportJSON), a))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), []byte(`[]`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.json"), []byte(`{"x":1}`), 0o644))
+
+	res, err := AggregateReports(AggregateRequest{Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, 4, res.Reports)
+	assert.Equal(t, 1, res.Skipped)
+	require.Len(t, res.Groups, 2)
+
+	g := res.Groups[0]
+	assert.Equal(t, "ema-cross", g.Strategy)
+	assert.Equal(t, "EURUSD H1 2025-01-01..2025-06-30", g.Dataset)
+	assert.Equal(t, "fast=10 slow=30", g.Params)
+	assert.Equal(t, 3, g.Runs)
+	ret := g.Metrics[2]
+	assert.Equal(t, "return_pct", ret.Metric)
+	assert.InDelta(t, 5, ret.Mean, 1e-9)
+	assert.InDelta(t, 4, ret.Median, 1e-9)
+	assert.InDelta(t, 3.605551, ret.StdDev, 1e-6)
+
+	assert.Equal(t, "fast=20 slow=30", res.Groups[1].Params)
+	assert.Zero(t, res.Groups[1].Metrics[2].StdDev, "one run has no spread")
+
+	res, err = AggregateReports(AggregateRequest{Dir: dir, By: []string{"Strategy", "strategy"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"strategy"}, res.By)
+	require.Len(t, res.Groups, 1)
+	assert.Equal(t, 4, res.Groups[0].Runs)
+	assert.Empty(t, res.Groups[0].Params)
+
+	_, err = AggregateReports(AggregateRequest{Dir: dir, By: []string{"seed"}})
+	require.ErrorContains(t, err, `unknown group key "seed"`)
+}
+
+func TestWriteAggregateCSV(t *testing.T) {
+	res := &AggregateResult{Groups: []ReportGroup{{
+		Strategy: "ema-cross",
+		Runs:     2,
+		Metrics:  []MetricStats{{Metric: "trades", Mean: 10, Median: 10}},
+	}}}
+	var buf bytes.Buffer
+	require.NoError(t, WriteAggregateCSV(&buf, res))
+
+	r := csv.NewReader(&buf)
+	r.FieldsPerRecord = -1 // the group above carries just one metric
+	rows, err := r.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"strategy", "dataset", "params", "runs", "trades_mean", "trades_median", "trades_stddev"}, rows[0][:7])
+	assert.Equal(t, []string{"ema-cross", "", "", "2", "10.0000", "10.0000", "0.0000"}, rows[1])
+}
@@ -21,9 +21,12 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/rustyeddy/trader/backtest"
 	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/log"
 )
 
 // Service holds the small, self-contained dependency set backtest
@@ -35,6 +38,38 @@ type Service struct {
 	// TraderBacktestExecutor.
 	Executor backtest.BacktestExecutor
 	Log      *slog.Logger
+
+	// RunLogDir, when set, gives each run a log file of its own,
+	// <name>-<config-hash>.log in that directory, holding the run's
+	// runner, engine and strategy records. The CLI points it at the
+	// reports directory so each report has its log beside it.
+	RunLogDir string
+
+	// RunDirs, when set, gives each run a directory of its own under it,
+	// <date>/<name>-<config-hash> (see RunDir), holding its report, trades,
+	// equity curve, log and the resolved config that reproduces it. The
+	// run's log goes there as log.txt instead of to RunLogDir.
+	RunDirs string
+
+	// now dates the run directories; nil means time.Now.
+	now func() time.Time
+
+	// Progress, when set, receives each run's progress reports. Robustness
+	// reruns do not report.
+	Progress backtest.ProgressFunc
+
+	// CloseOnInterrupt closes a run's open lots when ctx is cancelled
+	// mid-run; see backtest.Backtest.CloseOnInterrupt.
+	CloseOnInterrupt bool
+
+	// Parallel is how many runs a sweep executes at once; 0 or 1 runs
+	// them one at a time. Summaries come back in submission order either
+	// way.
+	Parallel int
+
+	// CandleProviders supplies the providers runs with data.download set
+	// fetch their missing data from. Nil fails such runs.
+	CandleProviders backtest.CandleProviderFunc
 }
 
 // RunBacktest executes one compiled backtest definition end-to-end and returns
@@ -45,17 +80,129 @@ func (s *Service) RunBacktest(ctx context.Context, compiled backtest.CompiledBac
 	if run.Request == nil {
 		return backtest.BacktestReportSummary{}, fmt.Errorf("nil backtest request")
 	}
+	var runDir string
+	logPath := ""
+	switch {
+	case s != nil && s.RunDirs != "":
+		runDir = RunDir(s.clock(), run.Request.Name, run.Request.ConfigHash)
+		logPath = filepath.Join(s.RunDirs, runDir, RunLogFile)
+	case s != nil && s.RunLogDir != "":
+		logPath = filepath.Join(s.RunLogDir, reportStem(run.Request.Name, run.Request.ConfigHash)+".log")
+	}
+	if logPath != "" {
+		rl, err := log.OpenRunLog(logPath, "run", run.Request.Name)
+		if err != nil {
+			return backtest.BacktestReportSummary{}, fmt.Errorf("backtest %q: %w", run.Request.Name, err)
+		}
+		defer rl.Close()
+		run.Log = rl.Logger
+	}
+	if s != nil {
+		run.Progress = s.Progress
+		run.CloseOnInterrupt = s.CloseOnInterrupt
+	}
 	if err := s.backtestExecutor().Execute(ctx, &run); err != nil {
 		return backtest.BacktestReportSummary{}, fmt.Errorf("backtest %q: %w", run.Request.Name, err)
 	}
-	return run.Summary(), nil
+	summary := run.Summary()
+	summary.RunDir = filepath.ToSlash(runDir)
+	if !summary.Interrupted {
+		robustness, err := s.runRobustness(ctx, compiled, run.Log)
+		if err != nil {
+			return backtest.BacktestReportSummary{}, fmt.Errorf("backtest %q: %w", run.Request.Name, err)
+		}
+		summary.Robustness = robustness.Report()
+
+		cv, err := s.runCrossValidation(ctx, compiled, run.Log)
+		if err != nil {
+			return backtest.BacktestReportSummary{}, fmt.Errorf("backtest %q: %w", run.Request.Name, err)
+		}
+		summary.CrossValidation = cv.Report()
+
+		fills, err := s.runSameTick(ctx, compiled, run.Result, run.Log)
+		if err != nil {
+			return backtest.BacktestReportSummary{}, fmt.Errorf("backtest %q: %w", run.Request.Name, err)
+		}
+		summary.FillComparison = fills.Report()
+	}
+	if runDir != "" {
+		if err := WriteRunDir(filepath.Join(s.RunDirs, runDir), &run, summary); err != nil {
+			return backtest.BacktestReportSummary{}, fmt.Errorf("backtest %q: %w", run.Request.Name, err)
+		}
+	}
+	return summary, nil
+}
+
+func (s *Service) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// runRobustness executes compiled's perturbed reruns, if any, and
+// aggregates their outcomes. It returns nil when none are configured.
+// The reruns log to lg, the base run's logger.
+func (s *Service) runRobustness(ctx context.Context, compiled backtest.CompiledBacktest, lg *slog.Logger) (*backtest.RobustnessResult, error) {
+	runs, err := compiled.PerturbedRuns()
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	results := make([]*backtest.BacktestResult, 0, len(runs))
+	for i := range runs {
+		runs[i].Log = lg
+		if err := s.backtestExecutor().Execute(ctx, &runs[i]); err != nil {
+			return nil, fmt.Errorf("robustness run %d: %w", i+1, err)
+		}
+		results = append(results, runs[i].Result)
+	}
+	return backtest.SummarizeRobustness(compiled.Request.Robustness, results), nil
+}
+
+// runCrossValidation executes compiled's cross-validation splits, if any,
+// and aggregates their in- and out-of-sample outcomes. It returns nil
+// when none are configured. The reruns log to lg, the base run's logger.
+func (s *Service) runCrossValidation(ctx context.Context, compiled backtest.CompiledBacktest, lg *slog.Logger) (*backtest.CrossValidationResult, error) {
+	runs, err := compiled.CrossValidationRuns()
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	for i := range runs {
+		for _, run := range []*backtest.Backtest{&runs[i].InSample, &runs[i].OutOfSample} {
+			run.Log = lg
+			if err := s.backtestExecutor().Execute(ctx, run); err != nil {
+				return nil, fmt.Errorf("cross-validation run %s: %w", run.Request.Name, err)
+			}
+		}
+	}
+	return backtest.SummarizeCrossValidation(compiled.Request.CrossValidation, runs), nil
+}
+
+// runSameTick executes the same-tick counterpart of a compiled run whose
+// orders fill on a later tick, and compares it with result, the run's own.
+// It returns nil when compiled fills on the same tick. The rerun logs to
+// lg, the base run's logger.
+func (s *Service) runSameTick(ctx context.Context, compiled backtest.CompiledBacktest, result *backtest.BacktestResult, lg *slog.Logger) (*backtest.FillComparison, error) {
+	run, err := compiled.SameTickRun()
+	if err != nil || run == nil {
+		return nil, err
+	}
+	run.Log = lg
+	if err := s.backtestExecutor().Execute(ctx, run); err != nil {
+		return nil, fmt.Errorf("same-tick run: %w", err)
+	}
+	return backtest.CompareFills(compiled.Request.Fill, result, run.Result), nil
 }
 
 func (s *Service) backtestExecutor() backtest.BacktestExecutor {
 	if s != nil && s.Executor != nil {
 		return s.Executor
 	}
-	return backtest.NewTraderBacktestExecutor(datamanager.GetDataManager())
+	exec := backtest.NewTraderBacktestExecutor(datamanager.GetDataManager())
+	if s != nil {
+		exec.CandleProviders = s.CandleProviders
+	}
+	return exec
 }
 
 // RunBacktestConfigs loads a slice of YAML config files, expands each
@@ -63,34 +210,35 @@ func (s *Service) backtestExecutor() backtest.BacktestExecutor {
 // summaries in submission order along with any per-run errors (errors
 // are non-fatal: one bad run doesn't abort the others).
 //
+// Cancelling ctx stops the sweep after the run in progress, which ends
+// early with a partial summary marked Interrupted; the summaries so far
+// are returned so they can still be written.
+//
 // This is the typical "regression sweep" entry point used by both the
 // CLI and the future REST endpoint.
 func (s *Service) RunBacktestConfigs(ctx context.Context, configPaths []string) ([]backtest.BacktestReportSummary, error) {
-	var summaries []backtest.BacktestReportSummary
+	var runs []backtest.CompiledBacktest
 	var errs []error
 
 	for _, cfgPath := range configPaths {
+		if ctx.Err() != nil {
+			break
+		}
 		cfg, err := backtest.LoadConfig(cfgPath)
 		if err != nil {
-			return summaries, fmt.Errorf("load config %q: %w", cfgPath, err)
+			return nil, fmt.Errorf("load config %q: %w", cfgPath, err)
 		}
-		runs, err := backtest.CompileBacktests(cfg)
+		compiled, err := backtest.CompileBacktests(cfg)
 		if err != nil {
 			s.Log.Warn("service: skipping config", "path", cfgPath, "err", err)
 			errs = append(errs, fmt.Errorf("config %q: %w", cfgPath, err))
 			continue
 		}
-		for _, run := range runs {
-			summary, runErr := s.RunBacktest(ctx, run)
-			if runErr != nil {
-				s.Log.Warn("service: backtest run failed",
-					"name", run.Request.Name, "err", runErr)
-				errs = append(errs, runErr)
-				continue
-			}
-			summaries = append(summaries, summary)
-		}
+		runs = append(runs, compiled...)
 	}
+
+	summaries, runErrs := s.runCompiled(ctx, runs)
+	errs = append(errs, runErrs...)
 	// Individual bad runs/configs don't abort a sweep spanning many of them —
 	// but if nothing survived, silently returning an empty, nil-error result
 	// hides the actual cause (e.g. an unknown strategy/entry/exit kind).
@@ -100,6 +248,56 @@ func (s *Service) RunBacktestConfigs(ctx context.Context, configPaths []string)
 	return summaries, nil
 }
 
+// runCompiled executes runs, Parallel at a time, and returns the summaries
+// of those that succeeded in submission order along with the errors of
+// those that failed. Once ctx is cancelled no further run starts.
+func (s *Service) runCompiled(ctx context.Context, runs []backtest.CompiledBacktest) ([]backtest.BacktestReportSummary, []error) {
+	type outcome struct {
+		summary backtest.BacktestReportSummary
+		err     error
+		ran     bool
+	}
+	out := make([]outcome, len(runs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(s.Parallel, 1), len(runs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if ctx.Err() != nil {
+					continue
+				}
+				summary, err := s.RunBacktest(ctx, runs[i])
+				out[i] = outcome{summary: summary, err: err, ran: true}
+			}
+		}()
+	}
+	for i := range runs {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var summaries []backtest.BacktestReportSummary
+	var errs []error
+	for i, o := range out {
+		switch {
+		case !o.ran:
+		case o.err != nil:
+			s.Log.Warn("service: backtest run failed",
+				"name", runs[i].Request.Name, "err", o.err)
+			errs = append(errs, o.err)
+		default:
+			summaries = append(summaries, o.summary)
+		}
+	}
+	return summaries, errs
+}
+
 // ResolveBacktestConfigPaths expands backtest path specs into concrete config
 // files. Each spec may be a file, a directory, or a glob pattern. Directories
 // expand to sorted *.yml, *.yaml, and *.json files.
@@ -345,11 +543,17 @@ func ReadBacktestOrgReport(dir, name string) ([]byte, string, error) {
 }
 
 func backtestReportStem(summary backtest.BacktestReportSummary) string {
-	hash := strings.TrimSpace(summary.ConfigHash)
+	return reportStem(summary.Name, summary.ConfigHash)
+}
+
+// reportStem is the file stem shared by a run's reports and log:
+// <name>-<config-hash>, or just name when there is no hash.
+func reportStem(name, hash string) string {
+	hash = strings.TrimSpace(hash)
 	if hash == "" {
-		return summary.Name
+		return name
 	}
-	return summary.Name + "-" + hash
+	return name + "-" + hash
 }
 
 func hasGlobMeta(path string) bool {
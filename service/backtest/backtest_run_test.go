@@ -96,6 +96,30 @@ func TestRunBacktest_ExecutorErrorIsWrapped(t *testing.T) {
 	assert.Contains(t, err.Error(), "svc-unit-test")
 }
 
+// loggingExecutor logs one record through the run's logger.
+type loggingExecutor struct{}
+
+func (loggingExecutor) Execute(_ context.Context, run *backtest.Backtest) error {
+	run.Logger().Info("bar processed", "n", 1)
+	return nil
+}
+
+func TestRunBacktest_RunLogDirWritesPerRunLog(t *testing.T) {
+	dir := t.TempDir()
+	svc := newBacktestService()
+	svc.Executor = loggingExecutor{}
+	svc.RunLogDir = dir
+
+	compiled := minCompiledBacktest(t)
+	_, err := svc.RunBacktest(context.Background(), compiled)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "svc-unit-test-"+compiled.Request.ConfigHash+".log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "bar processed")
+	assert.Contains(t, string(data), "run=svc-unit-test")
+}
+
 func TestRunBacktest_FallbackExecutorUsedWhenNilBacktests(t *testing.T) {
 	// Service.Backtests is nil → backtestExecutor() constructs a real
 	// TraderBacktestExecutor. With noop strategy and no candle data available
@@ -107,6 +131,67 @@ func TestRunBacktest_FallbackExecutorUsedWhenNilBacktests(t *testing.T) {
 	assert.Zero(t, summary.Trades)
 }
 
+func TestRunBacktest_RobustnessRunsAttachSpread(t *testing.T) {
+	cfg := &backtest.Config{
+		Defaults: backtest.RunDefaults{
+			StartingBalance: 1000,
+			Robustness:      backtest.RobustnessConfig{Runs: 3, Seed: 5, SkipProbability: 0.5},
+		},
+		Runs: []backtest.RunConfig{{
+			Name:     "svc-robustness",
+			Data:     backtest.DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2026-01-01", To: "2026-01-10"},
+			Strategy: strategy.StrategyConfig{Kind: "noop"},
+		}},
+	}
+	compiled, err := backtest.CompileBacktests(cfg)
+	require.NoError(t, err)
+	require.Len(t, compiled, 1)
+
+	svc := newBacktestService()
+	summary, err := svc.RunBacktest(context.Background(), compiled[0])
+	require.NoError(t, err)
+	require.NotNil(t, summary.Robustness)
+	assert.Equal(t, 3, summary.Robustness.Runs)
+	assert.Equal(t, int64(5), summary.Robustness.Seed)
+	assert.Zero(t, summary.Robustness.Profitable)
+}
+
+func TestRunBacktest_NoRobustnessByDefault(t *testing.T) {
+	svc := newBacktestService()
+	svc.Executor = stubExecutor{}
+	summary, err := svc.RunBacktest(context.Background(), minCompiledBacktest(t))
+	require.NoError(t, err)
+	assert.Nil(t, summary.Robustness)
+}
+
+func TestRunBacktest_CrossValidationAttachesSplits(t *testing.T) {
+	cfg := &backtest.Config{
+		Defaults: backtest.RunDefaults{
+			StartingBalance: 1000,
+			CrossValidation: backtest.CrossValidationConfig{Folds: 4, TestFolds: 2, PurgeBars: 2, EmbargoBars: 1},
+		},
+		Runs: []backtest.RunConfig{{
+			Name:     "svc-cv",
+			Data:     backtest.DataConfig{Instrument: "EURUSD", Timeframe: "H1", From: "2026-01-01", To: "2026-01-10"},
+			Strategy: strategy.StrategyConfig{Kind: "noop"},
+		}},
+	}
+	compiled, err := backtest.CompileBacktests(cfg)
+	require.NoError(t, err)
+	require.Len(t, compiled, 1)
+
+	svc := newBacktestService()
+	summary, err := svc.RunBacktest(context.Background(), compiled[0])
+	require.NoError(t, err)
+	cv := summary.CrossValidation
+	require.NotNil(t, cv)
+	assert.Equal(t, 4, cv.Folds)
+	assert.Equal(t, 3, cv.Paths)
+	assert.Len(t, cv.Splits, 6)
+	assert.Equal(t, 6, cv.OutOfSample.Runs)
+	assert.Equal(t, "1+2", cv.Splits[0].TestFolds)
+}
+
 // ---------------------------------------------------------------------------
 // RunBacktestConfigs
 // ---------------------------------------------------------------------------
@@ -127,6 +212,32 @@ func TestRunBacktestConfigs_Success(t *testing.T) {
 	assert.Len(t, summaries, 2)
 }
 
+// cancellingExecutor cancels the sweep's context during the first run.
+type cancellingExecutor struct{ cancel context.CancelFunc }
+
+func (e cancellingExecutor) Execute(_ context.Context, _ *backtest.Backtest) error {
+	e.cancel()
+	return nil
+}
+
+func TestRunBacktestConfigs_CancelStopsSweepAfterCurrentRun(t *testing.T) {
+	dir := t.TempDir()
+	minYAMLConfig(t, dir, "run-a")
+	minYAMLConfig(t, dir, "run-b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc := newBacktestService()
+	svc.Executor = cancellingExecutor{cancel: cancel}
+
+	summaries, err := svc.RunBacktestConfigs(ctx, []string{
+		filepath.Join(dir, "run-a.yml"),
+		filepath.Join(dir, "run-b.yml"),
+	})
+	require.NoError(t, err)
+	assert.Len(t, summaries, 1, "the run in progress is kept, the rest are skipped")
+}
+
 func TestRunBacktestConfigs_BadConfigPathReturnsError(t *testing.T) {
 	svc := newBacktestService()
 	svc.Executor = stubExecutor{}
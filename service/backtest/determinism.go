@@ -0,0 +1,62 @@
+package backtestsvc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rustyeddy/trader/backtest"
+)
+
+// DeterminismResult compares one run's reports from the two passes of
+// VerifyDeterminism. In each diff Golden holds the first pass's value and
+// Got the second's.
+type DeterminismResult struct {
+	Name       string                `json:"name"`
+	ConfigHash string                `json:"config_hash"`
+	Passed     bool                  `json:"passed"`
+	Diffs      []backtest.GoldenDiff `json:"diffs,omitempty"`
+}
+
+// VerifyDeterminism runs the configs under pathSpecs twice, first with s's
+// Parallel setting and then with parallel runs at once, and diffs each
+// run's report between the passes the way golden reports are compared:
+// apart from the generation time and trade IDs, everything must match
+// exactly. Neither pass writes reports, run directories or run logs.
+func (s *Service) VerifyDeterminism(ctx context.Context, pathSpecs []string, parallel int) ([]DeterminismResult, error) {
+	configPaths, err := ResolveBacktestConfigPaths(pathSpecs)
+	if err != nil {
+		return nil, err
+	}
+	pass := func(n int) ([]backtest.BacktestReportSummary, error) {
+		svc := *s
+		svc.RunDirs, svc.RunLogDir, svc.Parallel = "", "", n
+		summaries, err := svc.RunBacktestConfigs(ctx, configPaths)
+		if err == nil && ctx.Err() != nil {
+			err = fmt.Errorf("interrupted: %w", ctx.Err())
+		}
+		return summaries, err
+	}
+
+	first, err := pass(s.Parallel)
+	if err != nil {
+		return nil, fmt.Errorf("first pass: %w", err)
+	}
+	second, err := pass(parallel)
+	if err != nil {
+		return nil, fmt.Errorf("second pass: %w", err)
+	}
+	if len(first) != len(second) {
+		return nil, fmt.Errorf("first pass produced %d runs, second pass %d", len(first), len(second))
+	}
+
+	results := make([]DeterminismResult, 0, len(first))
+	for i := range first {
+		r := DeterminismResult{Name: first[i].Name, ConfigHash: first[i].ConfigHash}
+		if r.Diffs, err = backtest.CompareGolden(first[i], second[i]); err != nil {
+			return nil, fmt.Errorf("compare %q: %w", r.Name, err)
+		}
+		r.Passed = len(r.Diffs) == 0
+		results = append(results, r)
+	}
+	return results, nil
+}
@@ -0,0 +1,95 @@
+package backtestsvc
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/types"
+)
+
+// resultExecutor gives each run a result derived from its name, plus
+// drift from a shared counter when drift is set, so repeated runs of the
+// same config differ. Each call sleeps less than the one before it, so
+// parallel runs finish out of submission order.
+type resultExecutor struct {
+	calls *atomic.Int64
+	drift bool
+}
+
+func (e resultExecutor) Execute(_ context.Context, run *backtest.Backtest) error {
+	n := e.calls.Add(1)
+	time.Sleep(time.Duration(5-n%5) * time.Millisecond)
+	pl := types.MoneyFromFloat(float64(len(run.Request.Name)))
+	if e.drift {
+		pl += types.Money(n)
+	}
+	run.Result = &backtest.BacktestResult{NetPL: pl}
+	return nil
+}
+
+func TestRunBacktestConfigs_ParallelKeepsSubmissionOrder(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	var paths []string
+	for _, n := range names {
+		paths = append(paths, minYAMLConfig(t, dir, n))
+	}
+
+	svc := newBacktestService()
+	svc.Executor = resultExecutor{calls: new(atomic.Int64)}
+	svc.Parallel = 3
+	summaries, err := svc.RunBacktestConfigs(context.Background(), paths)
+	require.NoError(t, err)
+	require.Len(t, summaries, len(names))
+	for i, s := range summaries {
+		assert.Equal(t, names[i], s.Name)
+	}
+}
+
+func TestVerifyDeterminism(t *testing.T) {
+	dir := t.TempDir()
+	minYAMLConfig(t, dir, "run-a")
+	minYAMLConfig(t, dir, "run-b")
+
+	svc := newBacktestService()
+	svc.RunDirs = filepath.Join(dir, "reports")
+	svc.Executor = resultExecutor{calls: new(atomic.Int64)}
+	results, err := svc.VerifyDeterminism(context.Background(), []string{dir}, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, r := range results {
+		assert.True(t, r.Passed, r.Name)
+		assert.Empty(t, r.Diffs)
+	}
+	assert.NoDirExists(t, svc.RunDirs, "verification writes no run directories")
+
+	svc.Executor = resultExecutor{calls: new(atomic.Int64), drift: true}
+	results, err = svc.VerifyDeterminism(context.Background(), []string{dir}, 1)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "run-a", results[0].Name)
+	assert.False(t, results[0].Passed)
+	require.NotEmpty(t, results[0].Diffs)
+	assert.Equal(t, "net_pl", results[0].Diffs[0].Path)
+}
+
+func TestVerifyDeterminism_Errors(t *testing.T) {
+	svc := newBacktestService()
+	svc.Executor = stubExecutor{}
+	_, err := svc.VerifyDeterminism(context.Background(), []string{filepath.Join(t.TempDir(), "missing.yml")}, 1)
+	require.ErrorContains(t, err, "stat config path")
+
+	dir := t.TempDir()
+	minYAMLConfig(t, dir, "run-a")
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.Executor = cancellingExecutor{cancel: cancel}
+	_, err = svc.VerifyDeterminism(ctx, []string{dir}, 1)
+	require.ErrorContains(t, err, "first pass: interrupted")
+}
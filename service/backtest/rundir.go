@@ -0,0 +1,131 @@
+package backtestsvc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rustyeddy/trader/backtest"
+)
+
+// The files of a run directory (see RunDir).
+const (
+	RunReportJSON  = "report.json"
+	RunReportOrg   = "report.org"
+	RunTradesCSV   = "trades.csv"
+	RunEquityCSV   = "equity.csv"
+	RunSignalsCSV  = "signals.csv"
+	RunEquitySVG   = "equity.svg"
+	RunDrawdownSVG = "drawdown.svg"
+	RunPriceSVG    = "price.svg"
+	RunLogFile     = "log.txt"
+	RunConfigYAML  = "config.yaml"
+)
+
+// RunDir returns the directory of a run started at t, relative to the
+// reports directory: <date>/<name>-<config-hash>, the date being t's UTC
+// date. The name is the run's report stem, so the same config run on the
+// same day lands in the same directory, and any change to it in another.
+func RunDir(t time.Time, name, configHash string) string {
+	return filepath.Join(t.UTC().Format(time.DateOnly), reportStem(name, configHash))
+}
+
+// WriteRunDir writes a finished run's files into dir: report.json and
+// report.org, trades.csv, equity.csv, signals.csv when the run journals
+// signals, the equity.svg and drawdown.svg
+// charts, price.svg with the trades marked when the run is short enough
+// (see backtest.MaxPriceChartBars), and config.yaml, the resolved config
+// (the run with its defaults) that `trader backtest run` reruns as is.
+// log.txt is written by the run itself as it goes.
+func WriteRunDir(dir string, run *backtest.Backtest, summary backtest.BacktestReportSummary) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create run dir %q: %w", dir, err)
+	}
+	if err := WriteBacktestSummaryJSON(filepath.Join(dir, RunReportJSON), summary); err != nil {
+		return fmt.Errorf("write %s: %w", RunReportJSON, err)
+	}
+	if err := WriteBacktestSummaryOrg(filepath.Join(dir, RunReportOrg), summary); err != nil {
+		return fmt.Errorf("write %s: %w", RunReportOrg, err)
+	}
+
+	var buf bytes.Buffer
+	if err := backtest.WriteTradesCSV(&buf, summary.TradeDetails); err != nil {
+		return fmt.Errorf("write %s: %w", RunTradesCSV, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, RunTradesCSV), buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	buf.Reset()
+	if err := run.WriteEquityCSV(&buf); err != nil {
+		return fmt.Errorf("write %s: %w", RunEquityCSV, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, RunEquityCSV), buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	if run.State != nil && len(run.State.Signals) > 0 {
+		buf.Reset()
+		if err := run.WriteSignalsCSV(&buf); err != nil {
+			return fmt.Errorf("write %s: %w", RunSignalsCSV, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, RunSignalsCSV), buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+
+	type chart struct {
+		name  string
+		write func(io.Writer) error
+	}
+	charts := []chart{{RunEquitySVG, run.WriteEquitySVG}, {RunDrawdownSVG, run.WriteDrawdownSVG}}
+	if run.HasPriceChart() {
+		charts = append(charts, chart{RunPriceSVG, run.WritePriceSVG})
+	}
+	for _, c := range charts {
+		buf.Reset()
+		if err := c.write(&buf); err != nil {
+			return fmt.Errorf("write %s: %w", c.name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, c.name), buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := resolvedConfigYAML(run)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", RunConfigYAML, err)
+	}
+	return os.WriteFile(filepath.Join(dir, RunConfigYAML), cfg, 0o644)
+}
+
+// resolvedConfigYAML renders run's resolved config as YAML by way of its
+// JSON form. Encoding the structs directly would write a nil params map
+// as {}, which reloads as an empty map and hashes differently; through
+// JSON it stays null, so the copy reruns under the same config hash.
+func resolvedConfigYAML(run *backtest.Backtest) ([]byte, error) {
+	b, err := json.Marshal(run.ResolvedConfig())
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Resolved config of run %s (config hash %s).\n", run.RunConfig.Name, run.Request.ConfigHash)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,55 @@
+package backtestsvc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/backtest"
+)
+
+func TestRunBacktest_RunDirsWritesRunDirectory(t *testing.T) {
+	root := t.TempDir()
+	svc := newBacktestService()
+	svc.RunDirs = root
+	svc.now = func() time.Time { return time.Date(2026, 3, 4, 23, 30, 0, 0, time.FixedZone("X", -3*3600)) }
+
+	compiled := minCompiledBacktest(t)
+	summary, err := svc.RunBacktest(context.Background(), compiled)
+	require.NoError(t, err)
+
+	want := "2026-03-05/svc-unit-test-" + compiled.Request.ConfigHash
+	assert.Equal(t, want, summary.RunDir, "dated in UTC")
+	dir := filepath.Join(root, want)
+	for _, name := range []string{RunReportJSON, RunReportOrg, RunTradesCSV, RunEquityCSV, RunEquitySVG, RunDrawdownSVG, RunLogFile, RunConfigYAML} {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+	assert.NoFileExists(t, filepath.Join(dir, RunPriceSVG), "no bars, no price chart")
+
+	got, err := ReadBacktestSummaryFile(filepath.Join(dir, RunReportJSON))
+	require.NoError(t, err)
+	assert.Equal(t, want, got.RunDir)
+	trades, err := os.ReadFile(filepath.Join(dir, RunTradesCSV))
+	require.NoError(t, err)
+	assert.Equal(t, "id,instrument,side,units,open_time,open_price,close_time,close_price,pnl,initial_stop,stop,take,close_cause,r_multiple,mae,mfe,reason\n", string(trades))
+
+	// The config copy reruns the same run.
+	cfg, err := backtest.LoadConfig(filepath.Join(dir, RunConfigYAML))
+	require.NoError(t, err)
+	rerun, err := backtest.CompileBacktests(cfg)
+	require.NoError(t, err)
+	require.Len(t, rerun, 1)
+	assert.Equal(t, compiled.Request.ConfigHash, rerun[0].Request.ConfigHash)
+	assert.Equal(t, compiled.RunConfig.Data, rerun[0].RunConfig.Data)
+}
+
+func TestRunDir(t *testing.T) {
+	at := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, filepath.Join("2026-03-04", "ema-abc12345"), RunDir(at, "ema", "abc12345"))
+	assert.Equal(t, filepath.Join("2026-03-04", "ema"), RunDir(at, "ema", ""))
+}
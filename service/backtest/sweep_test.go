@@ -29,6 +29,7 @@ import (
 
 	// Register all real strategies via init().
 	_ "github.com/rustyeddy/trader/strategies/bollingerfade"
+	_ "github.com/rustyeddy/trader/strategies/breakout"
 	_ "github.com/rustyeddy/trader/strategies/donchian"
 	_ "github.com/rustyeddy/trader/strategies/emacross"
 	_ "github.com/rustyeddy/trader/strategies/emacrossadx"
@@ -44,6 +45,7 @@ type sweepStrategy struct {
 
 var sweepStrategies = []sweepStrategy{
 	{kind: "donchian"},
+	{kind: "donchian-v6"},
 	{kind: "ema-cross", params: map[string]any{
 		"fast": 9,
 		"slow": 21,
@@ -2,8 +2,11 @@ package botsvc
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"strings"
 	"time"
 
@@ -26,7 +29,9 @@ import (
 //
 // On first use the adapter fetches the last WarmupBars completed candles from
 // OANDA and replays them silently so all indicators are primed before the first
-// live signal is emitted.
+// live signal is emitted. With a StatePath, a strategy.StatefulStrategy is
+// restored from its last saved snapshot first and only sees the warm-up bars
+// after it; the regime filter and exit strategy still see them all.
 type CandleStrategyAdapter struct {
 	strategy        strategy.Strategy
 	exit            strategy.ExitStrategy
@@ -36,6 +41,7 @@ type CandleStrategyAdapter struct {
 	granularity     string // OANDA granularity, e.g. "H1", "D"
 	warmupBars      int
 	localWarmupBars int
+	statePath       string
 	scale           types.Scale6
 
 	oanda           *oanda.Client
@@ -44,6 +50,7 @@ type CandleStrategyAdapter struct {
 	log             *slog.Logger
 
 	lastBarTime time.Time
+	restoredAt  time.Time // bar time of the restored snapshot
 	warmedUp    bool
 	lots        liveLotsTracker
 }
@@ -61,8 +68,12 @@ type CandleAdapterConfig struct {
 	// indicators are fully primed. Falls back gracefully if local data is absent.
 	WarmupBars      int // bars to fetch from OANDA for indicator warmup (default 100)
 	LocalWarmupBars int
-	OANDA           *oanda.Client
-	AccountID       string
+	// StatePath, if set and Strategy is a strategy.StatefulStrategy, is the
+	// file its snapshot is saved to after every bar and restored from on
+	// start. A strategy wrapped in filters or transforms is not stateful.
+	StatePath string
+	OANDA     *oanda.Client
+	AccountID string
 	// UpdateTradeStop, if non-nil, is called to push a moved trailing stop to
 	// the broker. nil disables trailing stops.
 	UpdateTradeStop func(ctx context.Context, tradeID string, stopPx, takePx float64) error
@@ -96,6 +107,7 @@ func NewCandleStrategyAdapter(cfg CandleAdapterConfig) *CandleStrategyAdapter {
 		granularity:     cfg.Granularity,
 		warmupBars:      warmup,
 		localWarmupBars: cfg.LocalWarmupBars,
+		statePath:       cfg.StatePath,
 		scale:           types.PriceScale,
 		oanda:           cfg.OANDA,
 		accountID:       cfg.AccountID,
@@ -148,13 +160,27 @@ func (a *CandleStrategyAdapter) Tick(ctx context.Context, price account.LivePric
 	bt := a.makeBacktest()
 
 	sig := a.strategy.Update(ctx, &ct, bt)
+	if err := a.saveState(bar.Time); err != nil {
+		a.log.Warn("candle adapter: save strategy state failed", "err", err, "instrument", a.instrument)
+	}
 
 	pc := livePlanContext{instrument: a.instNorm, exit: a.exit, regime: a.regime, candle: ct}
-	plan, _, err := planner.DefaultPlanner{}.PlanSignal(sig, pc)
+	plan, stats, err := planner.DefaultPlanner{}.PlanSignal(sig, pc)
 	if err != nil {
 		a.log.Error("candle adapter: PlanSignal error", "err", err, "instrument", a.instNorm)
 		return nil
 	}
+	for _, d := range stats.Decisions {
+		if !d.Accepted {
+			a.log.Info("live: entry rejected",
+				"instrument", a.instNorm,
+				"side", d.Side,
+				"reason", d.Reason,
+				"detail", d.Detail,
+				"bar_time", bar.Time,
+			)
+		}
+	}
 
 	// In live mode, the planner has no account and cannot populate closes from
 	// account lots. When the strategy signals CloseAll, populate closes directly
@@ -201,6 +227,10 @@ func (a *CandleStrategyAdapter) Tick(ctx context.Context, price account.LivePric
 // (which may span years), then tops up with a short OANDA fetch to cover any
 // gap between the newest local bar and now.
 func (a *CandleStrategyAdapter) warmup(ctx context.Context) error {
+	if err := a.restoreState(); err != nil {
+		a.log.Warn("candle adapter: restore strategy state failed, warming up from scratch",
+			"err", err, "instrument", a.instrument)
+	}
 	if a.localWarmupBars > 0 {
 		if err := a.warmupFromLocalData(ctx); err != nil {
 			a.log.Warn("candle adapter: local warmup failed, continuing with OANDA-only warmup",
@@ -232,10 +262,7 @@ func (a *CandleStrategyAdapter) warmupFromLocalData(ctx context.Context) error {
 
 	count := 0
 	for ct, ok := iter.Next(); ok; ct, ok = iter.Next() {
-		a.regime.Tick(ct)
-		a.exit.Tick(ct)
-		bt := a.makeBacktest()
-		_ = a.strategy.Update(ctx, &ct, bt)
+		a.warmBar(ctx, ct)
 		if barTime := ct.Timestamp.Time(); barTime.After(a.lastBarTime) {
 			a.lastBarTime = barTime
 		}
@@ -282,15 +309,79 @@ func (a *CandleStrategyAdapter) warmupFromOANDA(ctx context.Context) error {
 			continue
 		}
 		ct := oandaCandleToCandleTime(c, a.instNorm)
-		a.regime.Tick(ct)
-		a.exit.Tick(ct)
+		a.warmBar(ctx, ct)
+		a.lastBarTime = c.Time
+	}
+	return nil
+}
+
+// warmBar replays one warm-up bar through the regime filter, exit strategy
+// and, unless a restored snapshot already covers it, the strategy.
+func (a *CandleStrategyAdapter) warmBar(ctx context.Context, ct market.Candle) {
+	a.regime.Tick(ct)
+	a.exit.Tick(ct)
+	if ct.Timestamp.Time().After(a.restoredAt) {
 		bt := a.makeBacktest()
 		_ = a.strategy.Update(ctx, &ct, bt)
-		a.lastBarTime = c.Time
 	}
+}
+
+// adapterState is the file saved at statePath: the strategy's snapshot and
+// the time of the last bar it saw.
+type adapterState struct {
+	BarTime  time.Time       `json:"bar_time"`
+	Strategy json.RawMessage `json:"strategy"`
+}
+
+// restoreState restores the strategy from statePath. A missing file, no
+// statePath, or a strategy that is not stateful is a fresh start.
+func (a *CandleStrategyAdapter) restoreState() error {
+	st, ok := a.strategy.(strategy.StatefulStrategy)
+	if !ok || a.statePath == "" {
+		return nil
+	}
+	b, err := os.ReadFile(a.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved adapterState
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return fmt.Errorf("read %s: %w", a.statePath, err)
+	}
+	if err := st.Restore(saved.Strategy); err != nil {
+		return err
+	}
+	a.restoredAt = saved.BarTime
+	a.log.Info("candle adapter: strategy state restored",
+		"instrument", a.instrument, "path", a.statePath, "bar_time", saved.BarTime)
 	return nil
 }
 
+// saveState writes the strategy's snapshot as of barTime to statePath,
+// through a temp file so a crash mid-write keeps the previous one.
+func (a *CandleStrategyAdapter) saveState(barTime time.Time) error {
+	st, ok := a.strategy.(strategy.StatefulStrategy)
+	if !ok || a.statePath == "" {
+		return nil
+	}
+	snap, err := st.Snapshot()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(adapterState{BarTime: barTime, Strategy: snap})
+	if err != nil {
+		return err
+	}
+	tmp := a.statePath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.statePath)
+}
+
 // latestCompleteBar returns the most recently completed candle, or nil if the
 // current bar is still forming.
 func (a *CandleStrategyAdapter) latestCompleteBar(ctx context.Context) (*oanda.Candle, error) {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -310,3 +312,63 @@ func (n *noopStrategy) StopDescription() string { return "" }
 func (n *noopStrategy) Update(_ context.Context, _ *market.Candle, _ strategy.StrategyContext) strategy.Signal {
 	return strategy.Hold("noop")
 }
+
+// countingStrategy is a StatefulStrategy whose state is the bars it saw.
+type countingStrategy struct {
+	noopStrategy
+	Bars int `json:"bars"`
+}
+
+func (c *countingStrategy) Update(_ context.Context, _ *market.Candle, _ strategy.StrategyContext) strategy.Signal {
+	c.Bars++
+	return strategy.Hold("count")
+}
+
+func (c *countingStrategy) Snapshot() ([]byte, error) { return strategy.MarshalSnapshot(c.Name(), c) }
+func (c *countingStrategy) Restore(data []byte) error {
+	return strategy.UnmarshalSnapshot(data, c.Name(), c)
+}
+
+func TestCandleStrategyAdapter_StateSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "EUR_USD-counting.state.json")
+	newAdapter := func() (*CandleStrategyAdapter, *countingStrategy) {
+		st := &countingStrategy{}
+		a := makeTestAdapter()
+		a.strategy, a.statePath = st, path
+		return a, st
+	}
+	ctx := context.Background()
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	bar := func(h int) market.Candle {
+		return market.Candle{Close: 110000, Timestamp: types.FromTime(start.Add(time.Duration(h) * time.Hour))}
+	}
+
+	a, st := newAdapter()
+	require.NoError(t, a.restoreState(), "no state file yet")
+	for h := 0; h < 3; h++ {
+		a.warmBar(ctx, bar(h))
+	}
+	require.NoError(t, a.saveState(start.Add(2*time.Hour)))
+
+	// The restart's warm-up overlaps the saved bars; only later ones count.
+	b, restored := newAdapter()
+	require.NoError(t, b.restoreState())
+	assert.Equal(t, 3, restored.Bars)
+	for h := 0; h < 5; h++ {
+		b.warmBar(ctx, bar(h))
+	}
+	assert.Equal(t, 5, restored.Bars)
+	assert.Equal(t, 3, st.Bars)
+
+	// A stateless strategy ignores the state path.
+	c := makeTestAdapter()
+	c.strategy, c.statePath = &noopStrategy{}, filepath.Join(t.TempDir(), "noop.state.json")
+	require.NoError(t, c.saveState(start))
+	_, err := os.Stat(c.statePath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+	d, _ := newAdapter()
+	assert.Error(t, d.restoreState())
+	assert.True(t, d.restoredAt.IsZero())
+}
@@ -3,6 +3,7 @@ package botsvc
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,8 +17,11 @@ type PortfolioConfig struct {
 	// LocalWarmupBars is the default number of bars to load from the local
 	// candle store for indicator priming. Per-instrument values override this.
 	// 500 covers ~3 weeks of H1 data; 5000 covers ~7 months. 0 disables.
-	LocalWarmupBars int                       `yaml:"local_warmup_bars"`
-	Instruments     []portfolioInstrumentYAML `yaml:"instruments"`
+	LocalWarmupBars int `yaml:"local_warmup_bars"`
+	// StateDir, when set, is where each instrument's strategy snapshot is
+	// kept across restarts (see StrategyConfig.StatePath). Empty disables.
+	StateDir    string                    `yaml:"state_dir"`
+	Instruments []portfolioInstrumentYAML `yaml:"instruments"`
 }
 
 type portfolioInstrumentYAML struct {
@@ -51,6 +55,15 @@ type portfolioInstrumentYAML struct {
 	} `yaml:"regime"`
 }
 
+// StatePath returns the snapshot file of instrument's strategy of kind
+// under StateDir, or "" when StateDir is not set.
+func (c *PortfolioConfig) StatePath(instrument, kind string) string {
+	if c.StateDir == "" {
+		return ""
+	}
+	return filepath.Join(c.StateDir, instrument+"-"+kind+".state.json")
+}
+
 // LoadPortfolioConfig reads and parses a portfolio YAML file.
 func LoadPortfolioConfig(path string) (*PortfolioConfig, error) {
 	data, err := os.ReadFile(path)
@@ -17,6 +17,7 @@ env: live
 risk_pct: 2.5
 drawdown_circuit_pct: 15.0
 local_warmup_bars: 200
+state_dir: /var/lib/trader
 instruments:
   - instrument: USD_CHF
     timeframe: H1
@@ -35,6 +36,7 @@ instruments:
 	require.Len(t, cfg.Instruments, 1)
 	assert.Equal(t, "USD_CHF", cfg.Instruments[0].Instrument)
 	assert.Equal(t, "H1", cfg.Instruments[0].Timeframe)
+	assert.Equal(t, "/var/lib/trader/USD_CHF-noop.state.json", cfg.StatePath("USD_CHF", "noop"))
 }
 
 func TestLoadPortfolioConfig_InvalidYAML(t *testing.T) {
@@ -57,6 +59,7 @@ func TestLoadPortfolioConfig_Defaults(t *testing.T) {
 	assert.Equal(t, "practice", cfg.Env)
 	assert.Equal(t, 1.0, cfg.RiskPct)
 	assert.Equal(t, 10.0, cfg.DrawdownCircuitPct)
+	assert.Empty(t, cfg.StatePath("EUR_USD", "donchian"))
 }
 
 func TestLoadPortfolioConfig_MissingFile(t *testing.T) {
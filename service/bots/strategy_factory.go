@@ -28,6 +28,9 @@ type StrategyConfig struct {
 	// LocalWarmupBars is the number of bars to read from local candle store before
 	// the OANDA fetch. Use 500+ for long-period regime filters (atr-percentile etc).
 	LocalWarmupBars int `json:"local_warmup_bars" yaml:"local_warmup_bars"`
+	// StatePath is the file a strategy.StatefulStrategy's snapshot is saved
+	// to after every bar and restored from on start. Empty disables.
+	StatePath string `json:"state_path"        yaml:"state_path"`
 }
 
 // BuildLiveStrategy constructs a trader.LiveStrategy from a StrategyConfig.
@@ -106,6 +109,10 @@ func BuildLiveStrategy(cfg StrategyConfig, instrument string, oandaClient *oanda
 		if err != nil {
 			return nil, fmt.Errorf("unknown strategy kind %q: %w", kind, err)
 		}
+		// The live runner has no governor to cap open trades and exposure.
+		if strategy.RequiresSafetyLimits(backtestStrat) {
+			return nil, fmt.Errorf("strategy %q is backtest only: it requires safety limits the live runner does not enforce", kind)
+		}
 		exit, err := strategy.GetExitStrategy(cfg.Exit, types.PriceScale)
 		if err != nil {
 			return nil, fmt.Errorf("exit strategy for %q: %w", kind, err)
@@ -114,6 +121,9 @@ func BuildLiveStrategy(cfg StrategyConfig, instrument string, oandaClient *oanda
 		if err != nil {
 			return nil, fmt.Errorf("regime filter for %q: %w", kind, err)
 		}
+		if len(strategy.PairRegimes(regime)) > 0 {
+			return nil, fmt.Errorf("regime filter for %q is backtest only: it reads other pairs' bars the live runner does not feed", kind)
+		}
 		granularity := cfg.Granularity
 		if granularity == "" {
 			granularity = "D"
@@ -130,6 +140,7 @@ func BuildLiveStrategy(cfg StrategyConfig, instrument string, oandaClient *oanda
 			Granularity:     granularity,
 			WarmupBars:      warmup,
 			LocalWarmupBars: cfg.LocalWarmupBars,
+			StatePath:       cfg.StatePath,
 			OANDA:           oandaClient,
 			AccountID:       accountID,
 			UpdateTradeStop: updateTradeStop,
@@ -12,7 +12,9 @@ import (
 
 	// Register strategies used in these tests.
 	_ "github.com/rustyeddy/trader/strategies/bollingerfade"
+	_ "github.com/rustyeddy/trader/strategies/breakout"
 	_ "github.com/rustyeddy/trader/strategies/donchian"
+	_ "github.com/rustyeddy/trader/strategies/grid"
 	_ "github.com/rustyeddy/trader/strategies/pulse"
 )
 
@@ -78,7 +80,17 @@ func TestBuildLiveStrategy_ScalperInvalidParams(t *testing.T) {
 
 func TestBuildLiveStrategy_Donchian(t *testing.T) {
 	strat, err := buildLiveStrategy(StrategyConfig{
-		Kind:        "donchian-breakout",
+		Kind:        "donchian",
+		Granularity: "H4",
+		Params:      map[string]any{"period": 55, "exit_period": 20, "atr_stop": 2.0, "pyramid": 3},
+	}, "EUR_USD")
+	require.NoError(t, err)
+	assert.NotNil(t, strat)
+}
+
+func TestBuildLiveStrategy_DonchianV6(t *testing.T) {
+	strat, err := buildLiveStrategy(StrategyConfig{
+		Kind:        "donchian-breakout-v6",
 		Granularity: "D",
 		Params:      map[string]any{"period": 55, "close_strength": 0.6, "confirm_bars": 1},
 		Exit:        strategy.ExitConfig{Kind: "chandelier", Params: map[string]any{"atr_period": 14, "multiplier": 6.0}},
@@ -89,7 +101,7 @@ func TestBuildLiveStrategy_Donchian(t *testing.T) {
 
 func TestBuildLiveStrategy_DonchianWithRegime(t *testing.T) {
 	strat, err := buildLiveStrategy(StrategyConfig{
-		Kind:        "donchian-breakout",
+		Kind:        "donchian-breakout-v6",
 		Granularity: "H1",
 		Params:      map[string]any{"period": 20, "close_strength": 0.6, "confirm_bars": 2, "adx_period": 14, "adx_threshold": 25.0},
 		Exit:        strategy.ExitConfig{Kind: "chandelier", Params: map[string]any{"atr_period": 14, "multiplier": 3.0}},
@@ -109,6 +121,11 @@ func TestBuildLiveStrategy_BBFade(t *testing.T) {
 	assert.NotNil(t, strat)
 }
 
+func TestBuildLiveStrategy_GridRefused(t *testing.T) {
+	_, err := buildLiveStrategy(StrategyConfig{Kind: "grid"}, "EUR_USD")
+	require.ErrorContains(t, err, `strategy "grid" is backtest only`)
+}
+
 func TestBuildLiveStrategy_BadExit(t *testing.T) {
 	_, err := buildLiveStrategy(StrategyConfig{
 		Kind:   "donchian-breakout",
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -117,7 +118,7 @@ type CandleCSVMetadata struct {
 
 // WriteCandlesCSV writes the canonical candle CSV format and returns the row
 // count written.
-func WriteCandlesCSV(buf *bytes.Buffer, meta CandleCSVMetadata, iter market.CandleIterator) (int, error) {
+func WriteCandlesCSV(buf io.Writer, meta CandleCSVMetadata, iter market.CandleIterator) (int, error) {
 	if iter == nil {
 		return 0, fmt.Errorf("nil candle iterator")
 	}
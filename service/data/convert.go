@@ -0,0 +1,514 @@
+package datasvc
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// ConvertDialect names an external CSV layout Convert can read.
+type ConvertDialect string
+
+const (
+	// DialectAuto sniffs the dialect from the first line of input.
+	DialectAuto ConvertDialect = ""
+
+	// DialectDukascopy is semicolon-separated bid bars,
+	// "20200102 170000;1.12120;1.12130;1.12110;1.12125;0", stamped in EST.
+	// HistData ships the same layout as DAT_ASCII_*.csv.
+	DialectDukascopy ConvertDialect = "dukascopy"
+
+	// DialectOANDATicks is comma-separated ticks with a header naming the
+	// time, bid and ask (and optionally instrument) columns, RFC3339 times.
+	// The OANDA pricing-stream capture writes this layout.
+	DialectOANDATicks ConvertDialect = "oanda-ticks"
+
+	// DialectMT4 is a MetaTrader history export of bid bars,
+	// "2020.01.02,17:00,1.12120,1.12130,1.12110,1.12125,0", stamped in EST.
+	// HistData ships these as DAT_MT_*.csv.
+	DialectMT4 ConvertDialect = "mt4"
+)
+
+// ParseConvertDialect parses a --dialect value; "auto" or "" is DialectAuto.
+func ParseConvertDialect(s string) (ConvertDialect, error) {
+	switch d := ConvertDialect(strings.ToLower(strings.TrimSpace(s))); d {
+	case "auto", DialectAuto:
+		return DialectAuto, nil
+	case DialectDukascopy, DialectOANDATicks, DialectMT4:
+		return d, nil
+	}
+	return DialectAuto, fmt.Errorf("unknown dialect %q (use auto, dukascopy, oanda-ticks, or mt4)", s)
+}
+
+// Bars reports whether d carries bars (converted to the canonical candle
+// format) rather than ticks (converted to the replay format).
+func (d ConvertDialect) Bars() bool {
+	return d == DialectDukascopy || d == DialectMT4
+}
+
+// sourceEST is the zone Dukascopy and MT4 bar exports are stamped in by
+// default: EST with no daylight saving, so every row is a fixed UTC-5.
+// Exports taken with a DST-observing clock need America/New_York instead
+// (see ParseSourceZone), or half the year lands an hour off.
+var sourceEST = time.FixedZone("EST", -5*60*60)
+
+// ParseSourceZone resolves the zone bar timestamps are written in: "" or
+// "EST" is fixed UTC-5, "UTC"/"GMT" is UTC, and anything else is an IANA
+// name such as America/New_York, whose DST transitions are then honored.
+func ParseSourceZone(name string) (*time.Location, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "", "EST":
+		return sourceEST, nil
+	case "UTC", "GMT":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("bad source zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+var (
+	dukascopyRow = regexp.MustCompile(`^\d{8} \d{6};`)
+	mt4Row       = regexp.MustCompile(`^\d{4}\.\d{2}\.\d{2},\d{2}:\d{2},`)
+)
+
+// DetectDialect identifies the dialect of a file from its first line.
+func DetectDialect(firstLine string) (ConvertDialect, error) {
+	line := strings.TrimSpace(strings.TrimPrefix(firstLine, "\ufeff"))
+	switch {
+	case dukascopyRow.MatchString(line):
+		return DialectDukascopy, nil
+	case mt4Row.MatchString(line):
+		return DialectMT4, nil
+	}
+	if _, ok := tickColumns(strings.Split(line, ",")); ok {
+		return DialectOANDATicks, nil
+	}
+	if f := strings.Split(line, ","); len(f) >= 4 {
+		if _, err := parseTickTime(f[0]); err == nil {
+			return DialectOANDATicks, nil
+		}
+	}
+	return DialectAuto, fmt.Errorf("unrecognized CSV dialect: %q", truncate(line, 60))
+}
+
+// ParseFilename extracts the instrument and timeframe a data file's name
+// carries, for the common vendor naming schemes:
+//
+//	DAT_MT_EURUSD_M1_2020.csv                          (HistData)
+//	EURUSD_Candlestick_1_M_BID_01.01.2020-31.12.2020.csv (Dukascopy)
+//	eur_usd-h1.csv
+//
+// Either result may be empty/zero when the name doesn't say.
+func ParseFilename(name string) (instrument string, tf types.Timeframe) {
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	tokens := strings.FieldsFunc(base, func(r rune) bool { return r == '_' || r == '-' || r == ' ' })
+
+	for i, tok := range tokens {
+		if instrument == "" {
+			switch {
+			case len(tok) == 6 && market.GetInstrument(tok) != nil:
+				instrument = market.NormalizeInstrument(tok)
+			case len(tok) == 3 && i+1 < len(tokens) && len(tokens[i+1]) == 3 &&
+				market.GetInstrument(tok+tokens[i+1]) != nil:
+				instrument = market.NormalizeInstrument(tok + tokens[i+1])
+			}
+		}
+		if tf != 0 {
+			continue
+		}
+		if strings.EqualFold(tok, "Candlestick") && i+2 < len(tokens) {
+			tf = parseCandlestickTF(tokens[i+1], tokens[i+2])
+			continue
+		}
+		switch strings.ToUpper(tok) {
+		case "M1", "H1", "H4", "D1":
+			tf, _ = types.ParseTimeframe(tok)
+		}
+	}
+	return instrument, tf
+}
+
+// parseCandlestickTF reads Dukascopy's "<n>_<unit>" period, e.g. 1_M,
+// 1_Hour, 4_Hour, 1_D.
+func parseCandlestickTF(n, unit string) types.Timeframe {
+	switch strings.ToUpper(n) + strings.ToUpper(unit[:1]) {
+	case "1M":
+		return types.M1
+	case "1H":
+		return types.H1
+	case "4H":
+		return types.H4
+	case "1D":
+		return types.D1
+	}
+	return 0
+}
+
+// ConvertRequest describes one file conversion.
+type ConvertRequest struct {
+	Name   string    // input file name; instrument and timeframe are inferred from it
+	Input  io.Reader // vendor CSV
+	Output io.Writer // canonical CSV
+
+	Dialect    ConvertDialect // DialectAuto sniffs the first line
+	Instrument string         // overrides the instrument inferred from Name
+	Timeframe  string         // overrides the bar timeframe inferred from Name
+	SourceZone string         // zone bar times are stamped in; see ParseSourceZone
+}
+
+// ConvertResult summarizes one conversion.
+type ConvertResult struct {
+	Dialect    ConvertDialect `json:"dialect"`
+	Format     string         `json:"format"` // "ticks" or "candles"
+	Instrument string         `json:"instrument,omitempty"`
+	Timeframe  string         `json:"timeframe,omitempty"`
+	SourceZone string         `json:"source_zone,omitempty"`
+	Rows       int            `json:"rows"`
+}
+
+// Convert reads a vendor CSV and writes it in the trader's canonical form:
+// tick dialects become the replay format (time,instrument,bid,ask with
+// RFC3339 UTC times), bar dialects become the canonical candle format.
+// Timestamps are normalized to UTC.
+func (s *Service) Convert(ctx context.Context, req ConvertRequest) (*ConvertResult, error) {
+	if req.Input == nil || req.Output == nil {
+		return nil, fmt.Errorf("convert needs an input and an output")
+	}
+
+	br := bufio.NewReaderSize(req.Input, 64<<10)
+	dialect := req.Dialect
+	if dialect == DialectAuto {
+		first, err := peekLine(br)
+		if err != nil {
+			return nil, err
+		}
+		if dialect, err = DetectDialect(first); err != nil {
+			return nil, err
+		}
+	}
+
+	nameInst, nameTF := ParseFilename(req.Name)
+	res := &ConvertResult{
+		Dialect:    dialect,
+		Instrument: nameInst,
+	}
+	if req.Instrument != "" {
+		res.Instrument = market.NormalizeInstrument(req.Instrument)
+	}
+
+	if !dialect.Bars() {
+		res.Format = "ticks"
+		meta := market.TickMeta{
+			Source: string(dialect),
+			Price:  market.PriceBidAsk,
+			Params: map[string]string{"generator": "trader-data-convert"},
+		}
+		if req.Name != "" {
+			meta.Params["input"] = filepath.Base(req.Name)
+		}
+		n, err := convertTicks(ctx, br, req.Output, res.Instrument, meta)
+		res.Rows = n
+		return res, err
+	}
+
+	res.Format = "candles"
+	tf := nameTF
+	if req.Timeframe != "" {
+		var err error
+		if tf, err = types.ParseTimeframe(req.Timeframe); err != nil {
+			return nil, err
+		}
+	}
+	if tf == 0 {
+		return nil, fmt.Errorf("bar timeframe unknown from %q; set it explicitly", filepath.Base(req.Name))
+	}
+	if res.Instrument == "" {
+		return nil, fmt.Errorf("instrument unknown from %q; set it explicitly", filepath.Base(req.Name))
+	}
+	res.Timeframe = tf.String()
+
+	loc, err := ParseSourceZone(req.SourceZone)
+	if err != nil {
+		return nil, err
+	}
+	res.SourceZone = loc.String()
+
+	it := &barIterator{ctx: ctx, sc: bufio.NewScanner(br), dialect: dialect, loc: loc}
+	n, err := WriteCandlesCSV(req.Output, CandleCSVMetadata{
+		Source:     string(dialect),
+		Instrument: res.Instrument,
+		Timeframe:  res.Timeframe,
+		Scale:      types.PriceScale,
+	}, it)
+	res.Rows = n
+	return res, err
+}
+
+// peekLine returns the first non-blank line without consuming it.
+func peekLine(br *bufio.Reader) (string, error) {
+	buf, err := br.Peek(br.Size())
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	for _, line := range strings.Split(string(buf), "\n") {
+		if strings.TrimSpace(line) != "" {
+			return line, nil
+		}
+	}
+	return "", fmt.Errorf("empty input")
+}
+
+// tickColumns maps a header row to the indexes of the time, instrument,
+// bid and ask columns (instrument is -1 when absent).
+func tickColumns(header []string) (cols [4]int, ok bool) {
+	cols = [4]int{-1, -1, -1, -1}
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "time", "timestamp", "datetime":
+			cols[0] = i
+		case "instrument", "symbol":
+			cols[1] = i
+		case "bid", "closeoutbid":
+			cols[2] = i
+		case "ask", "closeoutask":
+			cols[3] = i
+		}
+	}
+	return cols, cols[0] >= 0 && cols[2] >= 0 && cols[3] >= 0
+}
+
+// parseTickTime accepts RFC3339 (any offset, any precision) and
+// "2006-01-02 15:04:05[.fff]" taken as UTC.
+func parseTickTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC(), nil
+	}
+	return time.ParseInLocation("2006-01-02 15:04:05.999999999", s, time.UTC)
+}
+
+// convertTicks writes the replay format with meta as its metadata line.
+// The line lists the instrument only when every row takes it from
+// instrument, i.e. the input has no instrument column of its own.
+func convertTicks(ctx context.Context, r io.Reader, w io.Writer, instrument string, meta market.TickMeta) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	cw := csv.NewWriter(w)
+	cols := [4]int{0, 1, 2, 3}
+	started := false
+	start := func() error {
+		started = true
+		if cols[1] < 0 && instrument != "" {
+			meta.Instruments = []string{instrument}
+		}
+		if _, err := fmt.Fprintln(w, meta.String()); err != nil {
+			return err
+		}
+		return cw.Write([]string{"time", "instrument", "bid", "ask"})
+	}
+
+	count := 0
+	for first := true; ; first = false {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+		line, _ := cr.FieldPos(0)
+		if first {
+			row[0] = strings.TrimPrefix(row[0], "\ufeff")
+			c, ok := tickColumns(row)
+			if ok {
+				cols = c
+			}
+			if err := start(); err != nil {
+				return count, err
+			}
+			if ok {
+				continue
+			}
+		}
+
+		if cols[0] >= len(row) || cols[2] >= len(row) || cols[3] >= len(row) {
+			return count, fmt.Errorf("line %d: want time, bid and ask columns, got %d fields", line, len(row))
+		}
+		t, err := parseTickTime(row[cols[0]])
+		if err != nil {
+			return count, fmt.Errorf("line %d: bad time %q", line, row[cols[0]])
+		}
+		inst := instrument
+		if cols[1] >= 0 && cols[1] < len(row) && strings.TrimSpace(row[cols[1]]) != "" {
+			inst = market.NormalizeInstrument(row[cols[1]])
+		}
+		if inst == "" {
+			return count, fmt.Errorf("line %d: no instrument column; set the instrument explicitly", line)
+		}
+		bid, err := parseConvertPrice(row[cols[2]])
+		if err != nil {
+			return count, fmt.Errorf("line %d: bad bid: %w", line, err)
+		}
+		ask, err := parseConvertPrice(row[cols[3]])
+		if err != nil {
+			return count, fmt.Errorf("line %d: bad ask: %w", line, err)
+		}
+
+		if err := cw.Write([]string{t.Format(time.RFC3339Nano), inst, bid.String(), ask.String()}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if !started {
+		if err := start(); err != nil {
+			return count, err
+		}
+	}
+	cw.Flush()
+	return count, cw.Error()
+}
+
+// parseConvertPrice parses a decimal price, rejecting values that don't fit
+// the fixed-point Price range rather than letting PriceFromFloat panic.
+func parseConvertPrice(s string) (types.Price, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	if !(f > 0) || f*float64(types.PriceScale) > float64(1<<31-1) {
+		return 0, fmt.Errorf("price %q out of range", s)
+	}
+	return types.PriceFromFloat(f), nil
+}
+
+// barIterator streams candles from a bar dialect, one row per Next. It
+// stops at the first malformed row and reports it from Err.
+type barIterator struct {
+	ctx     context.Context
+	sc      *bufio.Scanner
+	dialect ConvertDialect
+	loc     *time.Location
+	prev    types.Timestamp
+	line    int
+	err     error
+}
+
+func (it *barIterator) Next() (market.Candle, bool) {
+	for it.err == nil && it.sc.Scan() {
+		it.line++
+		text := strings.TrimSpace(strings.TrimPrefix(it.sc.Text(), "\ufeff"))
+		if text == "" {
+			continue
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return market.Candle{}, false
+		}
+		c, err := parseBar(it.dialect, text, it.loc)
+		if err != nil {
+			it.err = fmt.Errorf("line %d: %w", it.line, err)
+			return market.Candle{}, false
+		}
+		// The hour repeated when DST ends reads the same wall clock twice,
+		// and Go resolves it to the first (daylight) occurrence. A bar that
+		// would step back within that hour is the second occurrence.
+		if c.Timestamp <= it.prev && c.Timestamp+3600 > it.prev && repeatedHour(c.Timestamp, it.loc) {
+			c.Timestamp += 3600
+		}
+		it.prev = c.Timestamp
+		return c, true
+	}
+	if it.err == nil {
+		it.err = it.sc.Err()
+	}
+	return market.Candle{}, false
+}
+
+// repeatedHour reports whether ts falls in a wall-clock hour loc repeats,
+// i.e. the same local time an hour later has a different UTC offset.
+func repeatedHour(ts types.Timestamp, loc *time.Location) bool {
+	t := ts.Time().In(loc)
+	_, before := t.Zone()
+	_, after := t.Add(time.Hour).Zone()
+	return before != after && t.Add(time.Hour).Hour() == t.Hour()
+}
+
+func (it *barIterator) Err() error   { return it.err }
+func (it *barIterator) Close() error { return nil }
+
+// parseBar parses one Dukascopy or MT4 row. Both carry
+// time, open, high, low, close, volume; only the time layout and
+// separator differ.
+func parseBar(d ConvertDialect, line string, loc *time.Location) (market.Candle, error) {
+	var (
+		fields []string
+		stamp  string
+		layout string
+	)
+	switch d {
+	case DialectDukascopy:
+		fields = strings.Split(line, ";")
+		if len(fields) < 5 {
+			return market.Candle{}, fmt.Errorf("want at least 5 fields, got %d", len(fields))
+		}
+		stamp, layout = fields[0], "20060102 150405"
+		fields = fields[1:]
+	case DialectMT4:
+		fields = strings.Split(line, ",")
+		if len(fields) < 6 {
+			return market.Candle{}, fmt.Errorf("want at least 6 fields, got %d", len(fields))
+		}
+		stamp, layout = fields[0]+" "+fields[1], "2006.01.02 15:04"
+		fields = fields[2:]
+	default:
+		return market.Candle{}, fmt.Errorf("%q is not a bar dialect", d)
+	}
+
+	t, err := time.ParseInLocation(layout, strings.TrimSpace(stamp), loc)
+	if err != nil {
+		return market.Candle{}, fmt.Errorf("bad time %q", stamp)
+	}
+
+	var ohlc [4]types.Price
+	for i := range ohlc {
+		if ohlc[i], err = parseConvertPrice(fields[i]); err != nil {
+			return market.Candle{}, err
+		}
+	}
+	c := market.Candle{
+		Open:      ohlc[0],
+		High:      ohlc[1],
+		Low:       ohlc[2],
+		Close:     ohlc[3],
+		Timestamp: types.FromTime(t),
+	}
+	if c.High < c.Low || c.High < c.Open || c.High < c.Close || c.Low > c.Open || c.Low > c.Close {
+		return market.Candle{}, fmt.Errorf("inconsistent OHLC %s %s %s %s", c.Open, c.High, c.Low, c.Close)
+	}
+	return c, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
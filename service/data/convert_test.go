@@ -0,0 +1,197 @@
+package datasvc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+func convert(t *testing.T, name, in string, req ConvertRequest) (*ConvertResult, string, error) {
+	t.Helper()
+	var out strings.Builder
+	req.Name = name
+	req.Input = strings.NewReader(in)
+	req.Output = &out
+	res, err := (&Service{}).Convert(context.Background(), req)
+	return res, out.String(), err
+}
+
+func TestDetectDialect(t *testing.T) {
+	for line, want := range map[string]ConvertDialect{
+		"20200102 170000;1.12120;1.12130;1.12110;1.12125;0":   DialectDukascopy,
+		"\ufeff20200102 170000;1.1;1.1;1.1;1.1;0":             DialectDukascopy,
+		"2020.01.02,17:00,1.12120,1.12130,1.12110,1.12125,0":  DialectMT4,
+		"time,instrument,bid,ask":                             DialectOANDATicks,
+		"Time,Bid,Ask":                                        DialectOANDATicks,
+		"2026-01-24T09:30:00.123Z,EUR_USD,1.10000,1.10020":    DialectOANDATicks,
+		"2026-01-24T09:30:00-05:00,EUR_USD,1.10000,1.10020,x": DialectOANDATicks,
+	} {
+		got, err := DetectDialect(line)
+		require.NoError(t, err, line)
+		assert.Equal(t, want, got, line)
+	}
+
+	_, err := DetectDialect("Gmt time,Open,High,Low,Close,Volume")
+	require.ErrorContains(t, err, "unrecognized CSV dialect")
+}
+
+func TestParseFilename(t *testing.T) {
+	for name, want := range map[string]struct {
+		inst string
+		tf   types.Timeframe
+	}{
+		"data/DAT_MT_EURUSD_M1_2020.csv":                          {"EURUSD", types.M1},
+		"DAT_ASCII_USDJPY_M1_202001.csv":                          {"USDJPY", types.M1},
+		"GBPUSD_Candlestick_1_Hour_BID_01.01.2020-31.12.2020.csv": {"GBPUSD", types.H1},
+		"eur_usd-h4.csv": {"EURUSD", types.H4},
+		"ticks.csv":      {"", 0},
+	} {
+		inst, tf := ParseFilename(name)
+		assert.Equal(t, want.inst, inst, name)
+		assert.Equal(t, want.tf, tf, name)
+	}
+}
+
+func TestConvert_DukascopyBarsToCandles(t *testing.T) {
+	res, out, err := convert(t, "DAT_ASCII_EURUSD_M1_202001.csv",
+		"20200102 170000;1.12120;1.12130;1.12110;1.12125;0\n"+
+			"\n"+
+			"20200102 170100;1.12125;1.12140;1.12120;1.12135;0\n",
+		ConvertRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, DialectDukascopy, res.Dialect)
+	assert.Equal(t, "candles", res.Format)
+	assert.Equal(t, "EURUSD", res.Instrument)
+	assert.Equal(t, 2, res.Rows)
+
+	// 17:00 EST is 22:00 UTC.
+	open := time.Date(2020, 1, 2, 22, 0, 0, 0, time.UTC).Unix()
+	assert.Equal(t, "# schema=candle-v2 source=dukascopy instrument=EURUSD tf=m1 scale=100000\n"+
+		"Timestamp,Open,High,Low,Close,avgspread,maxspread,ticks,flags\n"+
+		itoa(open)+",112120,112130,112110,112125,0,0,0,0x0001\n"+
+		itoa(open+60)+",112125,112140,112120,112135,0,0,0,0x0001\n", out)
+}
+
+func TestConvert_MT4BarsToCandles(t *testing.T) {
+	res, out, err := convert(t, "export.csv",
+		"2020.07.01,17:00,1.12120,1.12130,1.12110,1.12125,0\n",
+		ConvertRequest{Instrument: "EUR_USD", Timeframe: "H1"})
+	require.NoError(t, err)
+	assert.Equal(t, DialectMT4, res.Dialect)
+	assert.Equal(t, "h1", res.Timeframe)
+	assert.Contains(t, out, itoa(time.Date(2020, 7, 1, 22, 0, 0, 0, time.UTC).Unix())+",112120,")
+}
+
+func TestConvert_BarsNeedTimeframeAndInstrument(t *testing.T) {
+	row := "2020.07.01,17:00,1.12120,1.12130,1.12110,1.12125,0\n"
+	_, _, err := convert(t, "export.csv", row, ConvertRequest{Instrument: "EURUSD"})
+	require.ErrorContains(t, err, "bar timeframe unknown")
+	_, _, err = convert(t, "export.csv", row, ConvertRequest{Timeframe: "M1"})
+	require.ErrorContains(t, err, "instrument unknown")
+}
+
+func TestConvert_BadBarReportsLine(t *testing.T) {
+	_, _, err := convert(t, "DAT_MT_EURUSD_M1_2020.csv",
+		"2020.07.01,17:00,1.12120,1.12130,1.12110,1.12125,0\n"+
+			"2020.07.01,17:01,1.12120,1.12100,1.12110,1.12125,0\n",
+		ConvertRequest{})
+	require.ErrorContains(t, err, "line 2: inconsistent OHLC")
+}
+
+func TestConvert_OANDATicksToReplay(t *testing.T) {
+	res, out, err := convert(t, "stream.csv",
+		"Time,Bid,Ask,Volume\n"+
+			"2026-01-24T04:30:00.25-05:00,1.1,1.10020,3\n"+
+			"2026-01-24 09:30:01,1.10001,1.10021,1\n",
+		ConvertRequest{Instrument: "eur_usd"})
+	require.NoError(t, err)
+	assert.Equal(t, DialectOANDATicks, res.Dialect)
+	assert.Equal(t, "ticks", res.Format)
+	assert.Equal(t, 2, res.Rows)
+	assert.Equal(t, "# schema=ticks-v1 source=oanda-ticks instruments=EURUSD price=bid-ask generator=trader-data-convert input=stream.csv\n"+
+		"time,instrument,bid,ask\n"+
+		"2026-01-24T09:30:00.25Z,EURUSD,1.10000,1.10020\n"+
+		"2026-01-24T09:30:01Z,EURUSD,1.10001,1.10021\n", out)
+
+	// Rows that name their own instrument leave it out of the metadata.
+	_, out, err = convert(t, "stream.csv", "time,instrument,bid,ask\n2026-01-24T09:30:00Z,EUR_USD,1.1,1.1002\n", ConvertRequest{})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "# schema=ticks-v1 source=oanda-ticks price=bid-ask "), out)
+
+	_, _, err = convert(t, "stream.csv", "time,bid,ask\n2026-01-24T09:30:00Z,1.1,1.1002\n", ConvertRequest{})
+	require.ErrorContains(t, err, "line 2: no instrument column")
+
+	_, _, err = convert(t, "stream.csv", "time,instrument,bid,ask\n2026-01-24T09:30:00Z,EUR_USD,nope,1.1002\n", ConvertRequest{})
+	require.ErrorContains(t, err, "line 2: bad bid")
+}
+
+func TestParseConvertDialect(t *testing.T) {
+	d, err := ParseConvertDialect("auto")
+	require.NoError(t, err)
+	assert.Equal(t, DialectAuto, d)
+	d, err = ParseConvertDialect("MT4")
+	require.NoError(t, err)
+	assert.Equal(t, DialectMT4, d)
+	_, err = ParseConvertDialect("metastock")
+	require.ErrorContains(t, err, "unknown dialect")
+}
+
+func itoa(v int64) string { return strconv.FormatInt(v, 10) }
+
+func TestConvert_SourceZoneAcrossDST(t *testing.T) {
+	// Friday before and Monday after the 8 March 2020 spring-forward.
+	rows := "2020.03.06,17:00,1.12120,1.12130,1.12110,1.12125,0\n" +
+		"2020.03.09,17:00,1.12120,1.12130,1.12110,1.12125,0\n"
+	fri := time.Date(2020, 3, 6, 22, 0, 0, 0, time.UTC).Unix()
+
+	// Default fixed EST: every row is UTC-5.
+	res, out, err := convert(t, "DAT_MT_EURUSD_H1_2020.csv", rows, ConvertRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "EST", res.SourceZone)
+	assert.Contains(t, out, itoa(fri)+",")
+	assert.Contains(t, out, itoa(time.Date(2020, 3, 9, 22, 0, 0, 0, time.UTC).Unix())+",")
+
+	// America/New_York: Monday's 17:00 is EDT, UTC-4.
+	res, out, err = convert(t, "DAT_MT_EURUSD_H1_2020.csv", rows, ConvertRequest{SourceZone: "America/New_York"})
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", res.SourceZone)
+	assert.Contains(t, out, itoa(fri)+",")
+	assert.Contains(t, out, itoa(time.Date(2020, 3, 9, 21, 0, 0, 0, time.UTC).Unix())+",")
+}
+
+func TestConvert_SourceZoneRepeatedHour(t *testing.T) {
+	// 1 November 2020: 01:00 New York occurs twice, EDT then EST.
+	_, out, err := convert(t, "20201101.csv",
+		"20201101 000000;1.1;1.1;1.1;1.1;0\n"+
+			"20201101 010000;1.1;1.1;1.1;1.1;0\n"+
+			"20201101 010000;1.1;1.1;1.1;1.1;0\n"+
+			"20201101 020000;1.1;1.1;1.1;1.1;0\n",
+		ConvertRequest{Instrument: "EURUSD", Timeframe: "H1", SourceZone: "America/New_York"})
+	require.NoError(t, err)
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n")[2:] {
+		got = append(got, strings.SplitN(line, ",", 2)[0])
+	}
+	base := time.Date(2020, 11, 1, 4, 0, 0, 0, time.UTC).Unix()
+	assert.Equal(t, []string{itoa(base), itoa(base + 3600), itoa(base + 7200), itoa(base + 10800)}, got)
+}
+
+func TestParseSourceZone(t *testing.T) {
+	loc, err := ParseSourceZone("")
+	require.NoError(t, err)
+	assert.Equal(t, "EST", loc.String())
+	loc, err = ParseSourceZone("gmt")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+	_, err = ParseSourceZone("Mars/Olympus")
+	require.ErrorContains(t, err, "bad source zone")
+}
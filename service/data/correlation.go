@@ -0,0 +1,183 @@
+package datasvc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// CorrelationRequest parameterises Correlation.
+type CorrelationRequest struct {
+	Instruments []string // at least two, e.g. ["EURUSD", "GBPUSD"]
+	Timeframe   string   // "M1", "H1", "D1"; defaults to "H1" when empty
+	From        string   // YYYY-MM-DD inclusive
+	To          string   // YYYY-MM-DD inclusive; must not be before From
+	Source      string   // optional; defaults to "oanda"
+	Window      int      // rolling window in bars; defaults to 100 when <= 0
+}
+
+// CorrelationResult holds the return-correlation matrix across the
+// requested instruments plus per-pair rolling statistics. Correlations are
+// in [-1, 1].
+type CorrelationResult struct {
+	Instruments []string          `json:"instruments"`
+	Timeframe   string            `json:"timeframe"`
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	Window      int               `json:"window"`
+	Bars        int               `json:"bars"`   // bars common to every instrument
+	Matrix      [][]float64       `json:"matrix"` // full-period correlation, Instruments × Instruments
+	Pairs       []CorrelationPair `json:"pairs"`
+}
+
+// CorrelationPair summarises one instrument pair's rolling correlation.
+type CorrelationPair struct {
+	A       string  `json:"a"`
+	B       string  `json:"b"`
+	Full    float64 `json:"full"`    // over the whole period
+	Last    float64 `json:"last"`    // most recent window
+	Min     float64 `json:"min"`     // lowest window
+	Max     float64 `json:"max"`     // highest window
+	Windows int     `json:"windows"` // number of rolling windows; 0 if too few bars
+}
+
+const defaultCorrelationWindow = 100
+
+// Correlation loads candles for every requested instrument, aligns them on
+// common bar timestamps, and correlates their bar-over-bar close returns,
+// both over the whole period and over a rolling window.
+func (s *Service) Correlation(ctx context.Context, req CorrelationRequest) (*CorrelationResult, error) {
+	insts := make([]string, 0, len(req.Instruments))
+	seen := make(map[string]bool, len(req.Instruments))
+	for _, raw := range req.Instruments {
+		inst := market.NormalizeInstrument(raw)
+		if inst == "" {
+			continue
+		}
+		if market.GetInstrument(inst) == nil {
+			return nil, fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, inst)
+		}
+		if !seen[inst] {
+			seen[inst] = true
+			insts = append(insts, inst)
+		}
+	}
+	if len(insts) < 2 {
+		return nil, fmt.Errorf("need at least two instruments, got %d", len(insts))
+	}
+
+	from, err := time.Parse("2006-01-02", req.From)
+	if err != nil {
+		return nil, fmt.Errorf("bad from %q: %w", req.From, err)
+	}
+	to, err := time.Parse("2006-01-02", req.To)
+	if err != nil {
+		return nil, fmt.Errorf("bad to %q: %w", req.To, err)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("from must not be after to")
+	}
+	toExcl := to.AddDate(0, 0, 1)
+
+	tf := req.Timeframe
+	if tf == "" {
+		tf = "H1"
+	}
+	tr, err := types.ParseTimeRange(from.Format("2006-01-02"), toExcl.Format("2006-01-02"), tf)
+	if err != nil {
+		return nil, fmt.Errorf("bad range: %w", err)
+	}
+
+	window := req.Window
+	if window <= 0 {
+		window = defaultCorrelationWindow
+	}
+
+	dm := datamanager.NewDataManager(insts, from, toExcl)
+	series := make([][]market.Candle, len(insts))
+	for i, inst := range insts {
+		series[i], err = collectCandles(ctx, dm, datamanager.CandleRequest{
+			Source:     req.Source,
+			Instrument: inst,
+			Range:      tr,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("load %s candles: %w", inst, err)
+		}
+	}
+
+	stamps, closes := market.AlignCloses(series...)
+	returns := make([][]types.Rate, len(insts))
+	for i := range closes {
+		returns[i] = market.Returns(closes[i])
+	}
+
+	result := &CorrelationResult{
+		Instruments: insts,
+		Timeframe:   strings.ToUpper(tf),
+		From:        from.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+		Window:      window,
+		Bars:        len(stamps),
+		Matrix:      make([][]float64, len(insts)),
+	}
+	for i := range insts {
+		result.Matrix[i] = make([]float64, len(insts))
+		result.Matrix[i][i] = 1
+	}
+	for i := 0; i < len(insts); i++ {
+		for j := i + 1; j < len(insts); j++ {
+			full, _ := market.Correlation(returns[i], returns[j])
+			result.Matrix[i][j] = full.Float64()
+			result.Matrix[j][i] = full.Float64()
+
+			pair := CorrelationPair{A: insts[i], B: insts[j], Full: full.Float64()}
+			rolling := market.RollingCorrelation(returns[i], returns[j], window)
+			if len(rolling) > 0 {
+				lo, hi := rolling[0], rolling[0]
+				for _, c := range rolling[1:] {
+					lo = min(lo, c)
+					hi = max(hi, c)
+				}
+				pair.Last = rolling[len(rolling)-1].Float64()
+				pair.Min = lo.Float64()
+				pair.Max = hi.Float64()
+				pair.Windows = len(rolling)
+			}
+			result.Pairs = append(result.Pairs, pair)
+		}
+	}
+	return result, nil
+}
+
+// collectCandles drains one candle request into a slice.
+func collectCandles(ctx context.Context, dm *datamanager.DataManager, req datamanager.CandleRequest) (_ []market.Candle, err error) {
+	itr, err := dm.Candles(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := itr.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+
+	var out []market.Candle
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		c, ok := itr.Next()
+		if !ok {
+			break
+		}
+		out = append(out, c)
+	}
+	return out, itr.Err()
+}
@@ -0,0 +1,80 @@
+package datasvc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// correlationMoves are the bar-over-bar close changes seeded for each
+// instrument in seedCorrelationStore.
+var correlationMoves = []types.Price{0, 120, -80, 40, 200, -150, 60, -30, 90, -110, 70, 10}
+
+// seedCorrelationStore writes twelve H1 candles for EURUSD, GBPUSD (the
+// same moves) and USDCHF (the opposite moves).
+func seedCorrelationStore(t *testing.T) {
+	t.Helper()
+	build := func(start types.Price, sign types.Price) []market.Candle {
+		candles := make([]market.Candle, 744)
+		px := start
+		for i, m := range correlationMoves {
+			px += sign * m
+			candles[i] = market.Candle{Open: px, High: px + 50, Low: px - 50, Close: px, AvgSpread: 10, MaxSpread: 15, Ticks: 60}
+		}
+		return candles
+	}
+	month := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	datamanager.UseTempDataDir(t)
+	datamanager.WriteCandles(t, "oanda", "EURUSD", types.H1, month, build(110000, 1))
+	datamanager.WriteCandles(t, "oanda", "GBPUSD", types.H1, month, build(127000, 1))
+	datamanager.WriteCandles(t, "oanda", "USDCHF", types.H1, month, build(88000, -1))
+}
+
+func TestCorrelation_MatrixAndPairs(t *testing.T) {
+	seedCorrelationStore(t)
+
+	res, err := (&Service{}).Correlation(context.Background(), CorrelationRequest{
+		Instruments: []string{"eurusd", "GBPUSD", "USDCHF", "EURUSD"},
+		From:        "2024-01-01",
+		To:          "2024-01-01",
+		Window:      5,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"EURUSD", "GBPUSD", "USDCHF"}, res.Instruments, "normalized and de-duplicated")
+	assert.Equal(t, "H1", res.Timeframe)
+	assert.Equal(t, len(correlationMoves), res.Bars)
+	require.Len(t, res.Matrix, 3)
+	assert.Equal(t, 1.0, res.Matrix[0][0])
+	assert.InDelta(t, 1.0, res.Matrix[0][1], 0.01)
+	assert.InDelta(t, -1.0, res.Matrix[0][2], 0.01)
+	assert.Equal(t, res.Matrix[0][2], res.Matrix[2][0])
+
+	require.Len(t, res.Pairs, 3)
+	p := res.Pairs[1]
+	assert.Equal(t, "EURUSD", p.A)
+	assert.Equal(t, "USDCHF", p.B)
+	assert.Equal(t, len(correlationMoves)-1-5+1, p.Windows)
+	assert.InDelta(t, -1.0, p.Last, 0.01)
+	assert.LessOrEqual(t, p.Min, p.Max)
+}
+
+func TestCorrelation_RejectsBadRequests(t *testing.T) {
+	svc := &Service{}
+	ctx := context.Background()
+
+	_, err := svc.Correlation(ctx, CorrelationRequest{Instruments: []string{"EURUSD"}, From: "2024-01-01", To: "2024-01-31"})
+	require.ErrorContains(t, err, "at least two")
+
+	_, err = svc.Correlation(ctx, CorrelationRequest{Instruments: []string{"EURUSD", "XXXYYY"}, From: "2024-01-01", To: "2024-01-31"})
+	require.ErrorContains(t, err, "unknown instrument")
+
+	_, err = svc.Correlation(ctx, CorrelationRequest{Instruments: []string{"EURUSD", "GBPUSD"}, From: "2024-02-01", To: "2024-01-31"})
+	require.ErrorContains(t, err, "from must not be after to")
+}
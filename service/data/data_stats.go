@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/types"
 )
@@ -57,7 +58,7 @@ func (s *Service) DataStats(ctx context.Context, req DataStatsRequest) (*DataSta
 	}
 	instMeta := market.GetInstrument(inst)
 	if instMeta == nil {
-		return nil, fmt.Errorf("unknown instrument: %s", inst)
+		return nil, fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, inst)
 	}
 
 	from, err := time.Parse("2006-01-02", req.From)
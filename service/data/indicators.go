@@ -0,0 +1,211 @@
+package datasvc
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/indicator"
+	"github.com/rustyeddy/trader/types"
+)
+
+// IndicatorDumpRequest parameterises DumpIndicators.
+type IndicatorDumpRequest struct {
+	DataPath   string   // candle CSV file, in either layout the csv data feed reads
+	Indicators []string // specs such as "ema:20", "atr:14", "bb:20:2"; see IndicatorSpecs
+}
+
+// IndicatorSpecs documents the specs DumpIndicators takes and the columns
+// each adds, for CLI help.
+const IndicatorSpecs = `  ema:N        ema_N
+  atr:N        atr_N
+  adx:N        adx_N, plus_di_N, minus_di_N
+  bb:N[:K]     bb_N_middle, bb_N_upper, bb_N_lower (K standard deviations, default 2)
+  chop:N       chop_N
+  donchian:N   donchian_N_upper, donchian_N_lower, donchian_N_middle`
+
+// indicatorColumn is one CSV column of a dumped indicator.
+type indicatorColumn struct {
+	name  string
+	value func() string
+}
+
+// dumpedIndicator is an indicator and the columns it is dumped as.
+type dumpedIndicator struct {
+	ind     indicator.CandleIndicator
+	columns []indicatorColumn
+}
+
+// DumpIndicators replays the candles of req.DataPath through the requested
+// streaming indicators and writes one CSV row per bar to w: the bar's time
+// (RFC3339, UTC) and OHLC, then each indicator's values after that bar.
+// A value is blank until its indicator is ready, so rows line up with a
+// charting platform's bars for eyeballing. It returns the rows written.
+func (s *Service) DumpIndicators(ctx context.Context, req IndicatorDumpRequest, w io.Writer) (int, error) {
+	if strings.TrimSpace(req.DataPath) == "" {
+		return 0, fmt.Errorf("missing data file")
+	}
+	if len(req.Indicators) == 0 {
+		return 0, fmt.Errorf("need at least one indicator")
+	}
+	inds := make([]dumpedIndicator, 0, len(req.Indicators))
+	header := []string{"time", "open", "high", "low", "close"}
+	for _, spec := range req.Indicators {
+		d, err := parseIndicatorSpec(spec)
+		if err != nil {
+			return 0, err
+		}
+		inds = append(inds, d)
+		for _, c := range d.columns {
+			header = append(header, c.name)
+		}
+	}
+
+	// No range or instrument: the whole file, whatever pair it holds.
+	it, err := datamanager.NewFeed(ctx, "csv", datamanager.FeedParams{
+		Params: map[string]any{"path": req.DataPath},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return 0, err
+	}
+	rows := 0
+	row := make([]string, 0, len(header))
+	for {
+		c, ok := it.Next()
+		if !ok {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return rows, err
+		}
+		row = append(row[:0],
+			c.Timestamp.Time().UTC().Format(time.RFC3339),
+			c.Open.String(), c.High.String(), c.Low.String(), c.Close.String(),
+		)
+		for _, d := range inds {
+			d.ind.Update(c)
+			for _, col := range d.columns {
+				if d.ind.Ready() {
+					row = append(row, col.value())
+				} else {
+					row = append(row, "")
+				}
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	if err := it.Err(); err != nil {
+		return rows, err
+	}
+	cw.Flush()
+	return rows, cw.Error()
+}
+
+// parseIndicatorSpec builds the indicator a "kind:period[:arg]" spec names.
+func parseIndicatorSpec(spec string) (dumpedIndicator, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(spec)), ":")
+	kind := parts[0]
+	if len(parts) < 2 {
+		return dumpedIndicator{}, fmt.Errorf("indicator %q: want kind:period, e.g. ema:20", spec)
+	}
+	period, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return dumpedIndicator{}, fmt.Errorf("indicator %q: bad period %q", spec, parts[1])
+	}
+	maxParts := 2
+	if kind == "bb" {
+		maxParts = 3
+	}
+	if len(parts) > maxParts {
+		return dumpedIndicator{}, fmt.Errorf("indicator %q: too many fields", spec)
+	}
+
+	scale := types.Scale6(types.PriceScale)
+	name := func(suffix string) string {
+		if suffix == "" {
+			return fmt.Sprintf("%s_%d", kind, period)
+		}
+		return fmt.Sprintf("%s_%d_%s", kind, period, suffix)
+	}
+	price := func(p func() types.Price) func() string {
+		return func() string { return p().String() }
+	}
+	decimal := func(v func() float64, digits int) func() string {
+		return func() string { return strconv.FormatFloat(v(), 'f', digits, 64) }
+	}
+
+	var d dumpedIndicator
+	switch kind {
+	case "ema":
+		ema, err := indicator.NewEMA(period, scale)
+		if err != nil {
+			return d, fmt.Errorf("indicator %q: %w", spec, err)
+		}
+		d = dumpedIndicator{ema, []indicatorColumn{{name(""), price(ema.Price)}}}
+	case "atr":
+		atr, err := indicator.NewATR(period, scale)
+		if err != nil {
+			return d, fmt.Errorf("indicator %q: %w", spec, err)
+		}
+		d = dumpedIndicator{atr, []indicatorColumn{{name(""), price(atr.Price)}}}
+	case "adx":
+		adx, err := indicator.NewADX(period, scale)
+		if err != nil {
+			return d, fmt.Errorf("indicator %q: %w", spec, err)
+		}
+		d = dumpedIndicator{adx, []indicatorColumn{
+			{name(""), decimal(adx.Float64, 2)},
+			{fmt.Sprintf("plus_di_%d", period), decimal(adx.PlusDI, 2)},
+			{fmt.Sprintf("minus_di_%d", period), decimal(adx.MinusDI, 2)},
+		}}
+	case "bb":
+		k := 2.0
+		if len(parts) == 3 {
+			if k, err = strconv.ParseFloat(parts[2], 64); err != nil {
+				return d, fmt.Errorf("indicator %q: bad multiplier %q", spec, parts[2])
+			}
+		}
+		bb, err := indicator.NewBollingerBands(period, k, scale)
+		if err != nil {
+			return d, fmt.Errorf("indicator %q: %w", spec, err)
+		}
+		d = dumpedIndicator{bb, []indicatorColumn{
+			{name("middle"), decimal(bb.Middle, 5)},
+			{name("upper"), decimal(bb.Upper, 5)},
+			{name("lower"), decimal(bb.Lower, 5)},
+		}}
+	case "chop":
+		chop, err := indicator.NewChoppinessIndex(period, scale)
+		if err != nil {
+			return d, fmt.Errorf("indicator %q: %w", spec, err)
+		}
+		d = dumpedIndicator{chop, []indicatorColumn{{name(""), decimal(chop.Float64, 2)}}}
+	case "donchian":
+		dc, err := indicator.NewDonchian(period)
+		if err != nil {
+			return d, fmt.Errorf("indicator %q: %w", spec, err)
+		}
+		d = dumpedIndicator{dc, []indicatorColumn{
+			{name("upper"), price(dc.Upper)},
+			{name("lower"), price(dc.Lower)},
+			{name("middle"), price(dc.Middle)},
+		}}
+	default:
+		return d, fmt.Errorf("indicator %q: unknown kind %q (use ema, atr, adx, bb, chop or donchian)", spec, kind)
+	}
+	return d, nil
+}
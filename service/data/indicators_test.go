@@ -0,0 +1,92 @@
+package datasvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeIndicatorBars writes n plain H1 bars rising one pip a bar.
+func writeIndicatorBars(t *testing.T, n int) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("time,open,high,low,close\n")
+	start := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		c := 1.10000 + float64(i)*0.0001
+		fmt.Fprintf(&b, "%s,%.5f,%.5f,%.5f,%.5f\n", start.Add(time.Duration(i)*time.Hour).Format(time.RFC3339), c, c+0.0005, c-0.0005, c)
+	}
+	path := filepath.Join(t.TempDir(), "bars.csv")
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0o644))
+	return path
+}
+
+func TestDumpIndicators_AlignsValuesWithBars(t *testing.T) {
+	path := writeIndicatorBars(t, 30)
+	var out bytes.Buffer
+	rows, err := (&Service{}).DumpIndicators(context.Background(), IndicatorDumpRequest{
+		DataPath:   path,
+		Indicators: []string{"ema:3", "atr:5", "bb:10:2", "donchian:4"},
+	}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, 30, rows)
+
+	recs, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, recs, 31)
+	assert.Equal(t, []string{
+		"time", "open", "high", "low", "close", "ema_3", "atr_5",
+		"bb_10_middle", "bb_10_upper", "bb_10_lower",
+		"donchian_4_upper", "donchian_4_lower", "donchian_4_middle",
+	}, recs[0])
+
+	first := recs[1]
+	assert.Equal(t, "2024-01-08T00:00:00Z", first[0])
+	assert.Equal(t, "1.10000", first[4])
+	for _, v := range first[5:] {
+		assert.Empty(t, v, "nothing is ready after one bar")
+	}
+
+	// After three bars the EMA is ready; the 10-bar bands are not.
+	third := recs[3]
+	assert.NotEmpty(t, third[5])
+	assert.Empty(t, third[7])
+
+	last := recs[30]
+	assert.Equal(t, "2024-01-09T05:00:00Z", last[0])
+	for i, v := range last[5:] {
+		assert.NotEmpty(t, v, "column %s", recs[0][5+i])
+	}
+	// A steady one-pip climb: the ATR is the ten-pip bar range, and the
+	// Donchian upper is the last high.
+	assert.Equal(t, "0.00100", last[6])
+	assert.Equal(t, "1.10340", last[10])
+}
+
+func TestDumpIndicators_RejectsBadSpecs(t *testing.T) {
+	path := writeIndicatorBars(t, 5)
+	for _, spec := range []string{"ema", "ema:x", "ema:0", "rsi:14", "ema:20:3", "bb:20:x"} {
+		_, err := (&Service{}).DumpIndicators(context.Background(), IndicatorDumpRequest{
+			DataPath:   path,
+			Indicators: []string{spec},
+		}, &bytes.Buffer{})
+		assert.Error(t, err, spec)
+	}
+
+	_, err := (&Service{}).DumpIndicators(context.Background(), IndicatorDumpRequest{DataPath: path}, &bytes.Buffer{})
+	require.Error(t, err)
+	_, err = (&Service{}).DumpIndicators(context.Background(), IndicatorDumpRequest{
+		DataPath:   filepath.Join(t.TempDir(), "missing.csv"),
+		Indicators: []string{"ema:3"},
+	}, &bytes.Buffer{})
+	require.Error(t, err)
+}
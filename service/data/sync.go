@@ -0,0 +1,115 @@
+package datasvc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rustyeddy/trader/datamanager"
+	oandaprovider "github.com/rustyeddy/trader/datamanager/oanda"
+)
+
+// SyncOandaCandlesRequest specifies an incremental sync of the local
+// candle archive for every instrument × timeframe pair.
+type SyncOandaCandlesRequest struct {
+	Instruments []string // any spelling, e.g. ["EUR_USD", "GBPUSD"]
+	Timeframes  []string // e.g. ["M1", "H1", "H4", "D"]
+	// SeedFrom starts a pair with nothing stored yet. Zero reports such a
+	// pair as an error instead.
+	SeedFrom time.Time
+	RawDir   string
+	// OnProgress is called after each instrument+timeframe completes.
+	OnProgress func(msg string)
+}
+
+// SyncOandaCandlesResult summarises one sync run.
+type SyncOandaCandlesResult struct {
+	// Results is keyed by "INSTRUMENT/TIMEFRAME", e.g. "EUR_USD/H1".
+	Results map[string]SyncItemResult
+}
+
+// SyncItemResult is the outcome for one instrument+timeframe pair.
+type SyncItemResult struct {
+	LastCandle time.Time
+	Appended   int
+	UpToDate   bool
+	Err        error
+}
+
+// Failed returns how many pairs failed to sync.
+func (r *SyncOandaCandlesResult) Failed() int {
+	if r == nil {
+		return 0
+	}
+	n := 0
+	for _, item := range r.Results {
+		if item.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// SyncOandaCandles appends the candles that completed since the last stored
+// one for every instrument+timeframe pair, via DataManager.SyncCandles, and
+// leaves each pair's outcome in the data catalog. A failing pair does not
+// stop the others; it is reported in its result. Cancelling ctx stops
+// before the next pair.
+func (s *Service) SyncOandaCandles(ctx context.Context, req SyncOandaCandlesRequest) (*SyncOandaCandlesResult, error) {
+	if len(req.Instruments) == 0 {
+		return nil, fmt.Errorf("sync: at least one instrument required")
+	}
+	if len(req.Timeframes) == 0 {
+		return nil, fmt.Errorf("sync: at least one timeframe required")
+	}
+	if s.OANDA == nil {
+		return nil, fmt.Errorf("sync: OANDA is not configured")
+	}
+
+	provider := oandaprovider.New(s.OANDA)
+	dm := datamanager.GetDataManager()
+	result := &SyncOandaCandlesResult{Results: make(map[string]SyncItemResult)}
+
+	for _, inst := range req.Instruments {
+		for _, tfStr := range req.Timeframes {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			key := inst + "/" + tfStr
+			tf, err := ParseTraderTimeframe(tfStr)
+			if err != nil {
+				result.Results[key] = SyncItemResult{Err: err}
+				syncProgress(req, fmt.Sprintf("%-12s %-4s  ERROR: %v", inst, tfStr, err))
+				continue
+			}
+
+			res, err := dm.SyncCandles(ctx, provider, datamanager.IncrementalSyncRequest{
+				Instrument: inst,
+				Timeframe:  tf,
+				SeedFrom:   req.SeedFrom,
+				RawDir:     req.RawDir,
+			})
+			item := SyncItemResult{Err: err}
+			if res != nil {
+				item.LastCandle, item.Appended, item.UpToDate = res.LastCandle, res.Appended, res.UpToDate
+			}
+			result.Results[key] = item
+
+			switch {
+			case err != nil:
+				syncProgress(req, fmt.Sprintf("%-12s %-4s  ERROR: %v", inst, tfStr, err))
+			case item.Appended == 0:
+				syncProgress(req, fmt.Sprintf("%-12s %-4s  up to date (last %s)", inst, tfStr, item.LastCandle.Format(time.RFC3339)))
+			default:
+				syncProgress(req, fmt.Sprintf("%-12s %-4s  +%d candles through %s", inst, tfStr, item.Appended, item.LastCandle.Format(time.RFC3339)))
+			}
+		}
+	}
+	return result, nil
+}
+
+func syncProgress(req SyncOandaCandlesRequest, msg string) {
+	if req.OnProgress != nil {
+		req.OnProgress(msg)
+	}
+}
@@ -4,10 +4,10 @@ package pipvalues
 import (
 	"context"
 	"maps"
-	"strings"
 
 	"github.com/rustyeddy/trader/brokers/oanda"
 	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
 )
 
 // Service computes pip-value tables. The zero value works for currency-pair
@@ -69,7 +69,7 @@ func (s *Service) PipValues(ctx context.Context, req PipValuesRequest) (*PipValu
 	if s.OANDA != nil {
 		if prices, err := s.OANDA.GetPricing(ctx, s.AccountID, usdBasePairsOanda...); err == nil {
 			for _, p := range prices {
-				traderName := strings.ReplaceAll(p.Instrument, "_", "")
+				traderName := symbols.FromProvider(symbols.OANDA, p.Instrument)
 				rates[traderName] = p.Mid
 			}
 			live = true
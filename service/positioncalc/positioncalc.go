@@ -9,7 +9,9 @@ import (
 	"math"
 
 	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -55,7 +57,7 @@ func (s *Service) PositionCalc(ctx context.Context, req PositionCalcRequest) (*P
 	inst := market.NormalizeInstrument(req.Instrument)
 	instMeta := market.GetInstrument(inst)
 	if instMeta == nil {
-		return nil, fmt.Errorf("unknown instrument: %s", req.Instrument)
+		return nil, fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, req.Instrument)
 	}
 
 	if math.IsNaN(req.Price) {
@@ -84,13 +86,13 @@ func (s *Service) PositionCalc(ctx context.Context, req PositionCalcRequest) (*P
 		if s.OANDA == nil {
 			return nil, fmt.Errorf("price required when OANDA is not configured")
 		}
-		oandaName := instMeta.BaseCurrency + "_" + instMeta.QuoteCurrency
+		oandaName := symbols.ToProvider(symbols.OANDA, instMeta.Name)
 		prices, err := s.OANDA.GetPricing(ctx, s.AccountID, oandaName)
 		if err != nil {
 			return nil, fmt.Errorf("fetch price: %w", err)
 		}
 		if len(prices) == 0 || prices[0].Mid == 0 {
-			return nil, fmt.Errorf("OANDA returned zero price for %s", inst)
+			return nil, errs.Newf(errs.ErrNoPrice, "OANDA returned zero price for %s", inst)
 		}
 		midPrice = types.PriceFromFloat(prices[0].Mid)
 	}
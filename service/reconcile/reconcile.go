@@ -0,0 +1,301 @@
+// Package reconcile checks a live or paper broker account against the
+// journal the run keeps of it. A check compares the broker's open trades,
+// balance and margin with the previous check's (the baseline) and the
+// trades the journal recorded in between: every trade that left the
+// broker's book must have a journal record, the balance must have moved
+// by what those records realized, and margin must not shift while the
+// book is unchanged. Divergences are logged and sent to alert sinks.
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Divergence kinds.
+const (
+	// KindJournaledClosed is a trade the broker holds open that the
+	// journal recorded as closed.
+	KindJournaledClosed = "open-but-journaled-closed"
+	// KindCloseNotJournaled is a baseline trade gone from the broker's
+	// book with no journal record of its close.
+	KindCloseNotJournaled = "closed-not-journaled"
+	// KindUnitsChanged is a trade still open at the broker with different
+	// units than the baseline and no journal record: a partial close or
+	// add the journal missed.
+	KindUnitsChanged = "units-changed"
+	// KindBalance is a broker balance that moved by more than the journal
+	// realized since the baseline, beyond Tolerance.Balance.
+	KindBalance = "balance-mismatch"
+	// KindMargin is broker margin that moved beyond Tolerance.Margin while
+	// the open trades did not, or is in use with no trade open.
+	KindMargin = "margin-mismatch"
+)
+
+// Position is one open trade as a check saw it.
+type Position struct {
+	Instrument string `json:"instrument"`
+	Units      int64  `json:"units"` // signed: positive long, negative short
+}
+
+// Baseline is the broker state one check saw, which the next check
+// measures the broker's changes against.
+type Baseline struct {
+	Time       time.Time           `json:"time"`
+	Balance    types.Money         `json:"balance"`
+	MarginUsed types.Money         `json:"margin_used"`
+	OpenTrades map[string]Position `json:"open_trades"`
+}
+
+// Tolerance bounds the drift a check accepts. Balance absorbs financing
+// and fees, which move the balance without a journaled close; Margin is
+// the fraction margin may move with prices while the book is unchanged.
+type Tolerance struct {
+	Balance types.Money
+	Margin  types.Rate
+}
+
+// DefaultTolerance accepts one unit of account currency of balance drift
+// and a 5% move in margin.
+var DefaultTolerance = Tolerance{
+	Balance: types.MoneyFromFloat(1),
+	Margin:  types.RateFromFloat(0.05),
+}
+
+// Divergence is one disagreement between broker and journal.
+type Divergence struct {
+	Kind       string `json:"kind"`
+	TradeID    string `json:"trade_id,omitempty"`
+	Instrument string `json:"instrument,omitempty"`
+	Detail     string `json:"detail"`
+}
+
+// Report is the outcome of one check.
+type Report struct {
+	AccountID  string    `json:"account_id"`
+	Time       time.Time `json:"time"`
+	Currency   string    `json:"currency,omitempty"`
+	Balance    float64   `json:"balance"`
+	MarginUsed float64   `json:"margin_used"`
+	OpenTrades int       `json:"open_trades"`
+	// Baseline is when the baseline was taken; zero on a first check,
+	// which can only compare open trades with the journal's closes.
+	Baseline time.Time `json:"baseline,omitzero"`
+	// Journaled is how many journal records closed since the baseline.
+	Journaled   int          `json:"journaled"`
+	Divergences []Divergence `json:"divergences"`
+}
+
+// OK reports whether the check found broker and journal in agreement.
+func (r Report) OK() bool { return len(r.Divergences) == 0 }
+
+// Check fetches accountID's summary and open trades from b and reconciles
+// them with trades, the journal's records, and base, the previous check's
+// baseline (nil on a first check). It returns the report and the baseline
+// for the next check.
+func Check(ctx context.Context, b brokers.Broker, accountID string, trades []journal.TradeRecord, base *Baseline, tol Tolerance) (Report, Baseline, error) {
+	summary, err := b.GetAccountSummary(ctx, accountID)
+	if err != nil {
+		return Report{}, Baseline{}, fmt.Errorf("reconcile: account summary: %w", err)
+	}
+	open, err := b.GetOpenTrades(ctx, accountID)
+	if err != nil {
+		return Report{}, Baseline{}, fmt.Errorf("reconcile: open trades: %w", err)
+	}
+
+	now := Baseline{
+		Time:       time.Now().UTC(),
+		Balance:    types.MoneyFromFloat(summary.Balance),
+		MarginUsed: types.MoneyFromFloat(summary.MarginUsed),
+		OpenTrades: make(map[string]Position, len(open)),
+	}
+	for _, t := range open {
+		now.OpenTrades[t.ID] = Position{Instrument: market.NormalizeInstrument(t.Instrument), Units: t.Units}
+	}
+	rep := Report{
+		AccountID:  accountID,
+		Time:       now.Time,
+		Currency:   summary.Currency,
+		Balance:    summary.Balance,
+		MarginUsed: summary.MarginUsed,
+		OpenTrades: len(open),
+	}
+
+	journaled := map[string]bool{}
+	for _, t := range trades {
+		journaled[t.TradeID] = true
+	}
+	for _, id := range sortedIDs(now.OpenTrades) {
+		if journaled[id] {
+			p := now.OpenTrades[id]
+			rep.add(KindJournaledClosed, id, p.Instrument, "open at the broker (%d units) but the journal recorded it closed", p.Units)
+		}
+	}
+
+	if base != nil {
+		rep.Baseline = base.Time
+		since := types.FromTime(base.Time)
+		closedSince := map[string]bool{}
+		realized := types.Money(0)
+		for _, t := range trades {
+			if t.CloseTime > since {
+				closedSince[t.TradeID] = true
+				realized += t.RealizedPL
+				rep.Journaled++
+			}
+		}
+
+		for _, id := range sortedIDs(base.OpenTrades) {
+			was := base.OpenTrades[id]
+			is, stillOpen := now.OpenTrades[id]
+			switch {
+			case closedSince[id]:
+			case !stillOpen:
+				rep.add(KindCloseNotJournaled, id, was.Instrument, "closed at the broker (%d units) with no journal record", was.Units)
+			case is.Units != was.Units:
+				rep.add(KindUnitsChanged, id, was.Instrument, "units %d → %d with no journal record", was.Units, is.Units)
+			}
+		}
+
+		want := base.Balance + realized
+		if diff := now.Balance - want; diff > tol.Balance || -diff > tol.Balance {
+			rep.add(KindBalance, "", "", "broker balance %s, journal expects %s (baseline %s + realized %s): off by %s",
+				now.Balance, want, base.Balance, realized, diff)
+		}
+
+		if sameBook(base.OpenTrades, now.OpenTrades) && base.MarginUsed > 0 {
+			allowed, err := types.SignedMulDivRound(int64(base.MarginUsed), int64(tol.Margin), int64(types.RateScale))
+			if err != nil {
+				return Report{}, Baseline{}, fmt.Errorf("reconcile: margin tolerance: %w", err)
+			}
+			if diff := now.MarginUsed - base.MarginUsed; diff > types.Money(allowed) || -diff > types.Money(allowed) {
+				rep.add(KindMargin, "", "", "margin used %s → %s with the same open trades", base.MarginUsed, now.MarginUsed)
+			}
+		}
+	}
+	if len(now.OpenTrades) == 0 && now.MarginUsed != 0 {
+		rep.add(KindMargin, "", "", "margin used %s with no open trades", now.MarginUsed)
+	}
+	return rep, now, nil
+}
+
+func (r *Report) add(kind, tradeID, instrument, format string, args ...any) {
+	r.Divergences = append(r.Divergences, Divergence{
+		Kind:       kind,
+		TradeID:    tradeID,
+		Instrument: instrument,
+		Detail:     fmt.Sprintf(format, args...),
+	})
+}
+
+func sortedIDs(m map[string]Position) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// sameBook reports whether a and b hold the same trades with the same units.
+func sameBook(a, b map[string]Position) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id, p := range a {
+		if q, ok := b[id]; !ok || q.Units != p.Units {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadBaseline reads the baseline a previous check saved at path. A
+// missing file is a first check: nil and no error.
+func LoadBaseline(path string) (*Baseline, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var base Baseline
+	if err := json.Unmarshal(b, &base); err != nil {
+		return nil, fmt.Errorf("reconcile: read baseline %s: %w", path, err)
+	}
+	return &base, nil
+}
+
+// SaveBaseline writes base to path, replacing it atomically.
+func SaveBaseline(path string, base Baseline) error {
+	b, err := json.MarshalIndent(base, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// JournalTrades reads the trade records of the journal cfg configures.
+// For a rotating journal it reads the files of every period from since
+// (or the current period when since is zero) through now. Files not yet
+// written read as empty.
+func JournalTrades(cfg journal.Config, since time.Time) ([]journal.TradeRecord, error) {
+	paths := []string{cfg.TradesPath}
+	if cfg.Rotate != "" {
+		now := time.Now().UTC()
+		if since.IsZero() || since.After(now) {
+			since = now
+		}
+		paths = paths[:0]
+		seen := map[string]bool{}
+		for t := since; ; t = t.Add(24 * time.Hour) {
+			if t.After(now) {
+				t = now
+			}
+			if p := journal.RotatedPath(cfg.TradesPath, cfg.Rotate, t); !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+			if t.Equal(now) {
+				break
+			}
+		}
+	}
+
+	var out []journal.TradeRecord
+	for _, p := range paths {
+		trades, err := journal.ReadTrades(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reconcile: read journal %s: %w", p, err)
+		}
+		out = append(out, trades...)
+	}
+	return out, nil
+}
@@ -0,0 +1,202 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/types"
+)
+
+// stubBroker answers the two calls a check makes; any other method
+// panics through the nil embedded interface.
+type stubBroker struct {
+	brokers.Broker
+	summary oanda.AccountSummary
+	open    []oanda.OpenTrade
+}
+
+func (s *stubBroker) GetAccountSummary(ctx context.Context, accountID string) (*oanda.AccountSummary, error) {
+	sum := s.summary
+	return &sum, nil
+}
+
+func (s *stubBroker) GetOpenTrades(ctx context.Context, accountID string) ([]oanda.OpenTrade, error) {
+	return s.open, nil
+}
+
+func kinds(r Report) []string {
+	var out []string
+	for _, d := range r.Divergences {
+		out = append(out, d.Kind)
+	}
+	return out
+}
+
+func TestCheck_FirstCheckComparesOpenTradesWithJournal(t *testing.T) {
+	b := &stubBroker{
+		summary: oanda.AccountSummary{Balance: 10000, MarginUsed: 40, Currency: "USD"},
+		open:    []oanda.OpenTrade{{ID: "7", Instrument: "EUR_USD", Units: 1000}},
+	}
+	trades := []journal.TradeRecord{{TradeID: "7", Instrument: "EUR_USD"}}
+
+	rep, base, err := Check(context.Background(), b, "acct", trades, nil, DefaultTolerance)
+	require.NoError(t, err)
+	assert.Equal(t, []string{KindJournaledClosed}, kinds(rep))
+	assert.Equal(t, "7", rep.Divergences[0].TradeID)
+	assert.True(t, rep.Baseline.IsZero())
+	assert.Equal(t, types.MoneyFromFloat(10000), base.Balance)
+	assert.Equal(t, Position{Instrument: "EURUSD", Units: 1000}, base.OpenTrades["7"])
+}
+
+func TestCheck_AgainstBaseline(t *testing.T) {
+	baseTime := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	base := &Baseline{
+		Time:       baseTime,
+		Balance:    types.MoneyFromFloat(10000),
+		MarginUsed: types.MoneyFromFloat(100),
+		OpenTrades: map[string]Position{
+			"1": {Instrument: "EURUSD", Units: 1000},
+			"2": {Instrument: "GBPUSD", Units: -2000},
+			"3": {Instrument: "USDJPY", Units: 500},
+		},
+	}
+	closed := types.FromTime(baseTime.Add(time.Minute))
+	earlier := types.FromTime(baseTime.Add(-time.Hour))
+
+	tests := []struct {
+		name   string
+		open   []oanda.OpenTrade
+		bal    float64
+		margin float64
+		trades []journal.TradeRecord
+		want   []string
+	}{
+		{
+			name: "unchanged",
+			open: []oanda.OpenTrade{
+				{ID: "1", Instrument: "EUR_USD", Units: 1000},
+				{ID: "2", Instrument: "GBP_USD", Units: -2000},
+				{ID: "3", Instrument: "USD_JPY", Units: 500},
+			},
+			bal: 10000.40, margin: 103,
+		},
+		{
+			name: "journaled close",
+			open: []oanda.OpenTrade{
+				{ID: "2", Instrument: "GBP_USD", Units: -2000},
+				{ID: "3", Instrument: "USD_JPY", Units: 500},
+			},
+			bal: 10025, margin: 70,
+			trades: []journal.TradeRecord{
+				{TradeID: "0", CloseTime: earlier, RealizedPL: types.MoneyFromFloat(-50)},
+				{TradeID: "1", CloseTime: closed, RealizedPL: types.MoneyFromFloat(25)},
+			},
+		},
+		{
+			name: "missed close and partial",
+			open: []oanda.OpenTrade{
+				{ID: "2", Instrument: "GBP_USD", Units: -1000},
+				{ID: "3", Instrument: "USD_JPY", Units: 500},
+			},
+			bal: 10000, margin: 50,
+			want: []string{KindCloseNotJournaled, KindUnitsChanged},
+		},
+		{
+			name: "balance moved without journaled closes",
+			open: []oanda.OpenTrade{
+				{ID: "1", Instrument: "EUR_USD", Units: 1000},
+				{ID: "2", Instrument: "GBP_USD", Units: -2000},
+				{ID: "3", Instrument: "USD_JPY", Units: 500},
+			},
+			bal: 9990, margin: 100,
+			want: []string{KindBalance},
+		},
+		{
+			name: "margin jumped with the same trades",
+			open: []oanda.OpenTrade{
+				{ID: "1", Instrument: "EUR_USD", Units: 1000},
+				{ID: "2", Instrument: "GBP_USD", Units: -2000},
+				{ID: "3", Instrument: "USD_JPY", Units: 500},
+			},
+			bal: 10000, margin: 150,
+			want: []string{KindMargin},
+		},
+		{
+			name: "margin with nothing open",
+			bal:  10000, margin: 20,
+			trades: []journal.TradeRecord{
+				{TradeID: "1", CloseTime: closed},
+				{TradeID: "2", CloseTime: closed},
+				{TradeID: "3", CloseTime: closed},
+			},
+			want: []string{KindMargin},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &stubBroker{summary: oanda.AccountSummary{Balance: tc.bal, MarginUsed: tc.margin}, open: tc.open}
+			rep, _, err := Check(context.Background(), b, "acct", tc.trades, base, DefaultTolerance)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, kinds(rep))
+			assert.Equal(t, tc.want == nil, rep.OK())
+			assert.Equal(t, baseTime, rep.Baseline)
+		})
+	}
+}
+
+func TestReconciler_CheckCarriesBaselineAndAlerts(t *testing.T) {
+	var alerts []Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rep Report
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&rep))
+		alerts = append(alerts, rep)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	b := &stubBroker{
+		summary: oanda.AccountSummary{Balance: 10000},
+		open:    []oanda.OpenTrade{{ID: "9", Instrument: "EUR_USD", Units: 1000}},
+	}
+	r := &Reconciler{
+		Broker:    b,
+		AccountID: "acct",
+		Journal:   journal.Config{TradesPath: filepath.Join(dir, "live-trades.jsonl")},
+		StatePath: filepath.Join(dir, "state.json"),
+		Tolerance: DefaultTolerance,
+		Sinks:     []Sink{WebhookSink{URL: srv.URL}},
+	}
+
+	rep, err := r.Check(context.Background())
+	require.NoError(t, err)
+	assert.True(t, rep.OK(), "missing journal reads as empty")
+	base, err := LoadBaseline(r.StatePath)
+	require.NoError(t, err)
+	require.NotNil(t, base)
+	assert.Contains(t, base.OpenTrades, "9")
+
+	// The trade vanishes from the broker without a journal record.
+	b.open = nil
+	rep, err = r.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{KindCloseNotJournaled}, kinds(rep))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "9", alerts[0].Divergences[0].TradeID)
+
+	// Reported once: the next check measures from the new baseline.
+	rep, err = r.Check(context.Background())
+	require.NoError(t, err)
+	assert.True(t, rep.OK())
+	assert.Len(t, alerts, 1)
+}
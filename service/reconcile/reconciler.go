@@ -0,0 +1,133 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/journal"
+)
+
+// Sink receives the report of every check that found divergences.
+type Sink interface {
+	Alert(ctx context.Context, r Report) error
+}
+
+// WebhookSink POSTs the report as JSON to URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client // nil: a client with a 10s timeout
+}
+
+// Alert implements Sink.
+func (w WebhookSink) Alert(ctx context.Context, r Report) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("reconcile: webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("reconcile: webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("reconcile: webhook %s: %s: %s", w.URL, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Reconciler runs checks of one account against its journal, carrying the
+// baseline from check to check in the file at StatePath.
+type Reconciler struct {
+	Broker    brokers.Broker
+	AccountID string
+	Journal   journal.Config
+	StatePath string
+	Tolerance Tolerance
+	Sinks     []Sink
+	Log       *slog.Logger // nil: slog.Default()
+}
+
+// Check runs one check: it loads the baseline, reads the journal, checks
+// the broker against both, logs and alerts on divergences, and saves the
+// new baseline. The baseline is saved even when the check diverges, so a
+// divergence is reported once, by the check that first sees it.
+func (r *Reconciler) Check(ctx context.Context) (Report, error) {
+	lg := r.Log
+	if lg == nil {
+		lg = slog.Default()
+	}
+	base, err := LoadBaseline(r.StatePath)
+	if err != nil {
+		return Report{}, err
+	}
+	var since time.Time
+	if base != nil {
+		since = base.Time
+	}
+	trades, err := JournalTrades(r.Journal, since)
+	if err != nil {
+		return Report{}, err
+	}
+	rep, next, err := Check(ctx, r.Broker, r.AccountID, trades, base, r.Tolerance)
+	if err != nil {
+		return Report{}, err
+	}
+	if err := SaveBaseline(r.StatePath, next); err != nil {
+		return rep, fmt.Errorf("reconcile: save baseline: %w", err)
+	}
+
+	if rep.OK() {
+		lg.Info("reconcile: broker and journal agree",
+			"account", rep.AccountID, "open_trades", rep.OpenTrades, "balance", rep.Balance)
+		return rep, nil
+	}
+	for _, d := range rep.Divergences {
+		lg.Warn("reconcile: divergence", "account", rep.AccountID,
+			"kind", d.Kind, "trade", d.TradeID, "instrument", d.Instrument, "detail", d.Detail)
+	}
+	for _, s := range r.Sinks {
+		if err := s.Alert(ctx, rep); err != nil {
+			lg.Error("reconcile: alert failed", "error", err)
+		}
+	}
+	return rep, nil
+}
+
+// Run checks every interval until ctx is done. A failed check is logged
+// and retried at the next tick.
+func (r *Reconciler) Run(ctx context.Context, every time.Duration) {
+	lg := r.Log
+	if lg == nil {
+		lg = slog.Default()
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		if _, err := r.Check(ctx); err != nil && ctx.Err() == nil {
+			lg.Error("reconcile: check failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
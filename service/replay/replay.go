@@ -150,6 +150,9 @@ func (s *Service) RunReplay(ctx context.Context, req ReplayRequest) (*ReplayResu
 	if err != nil {
 		return nil, fmt.Errorf("build regime filter: %w", err)
 	}
+	if len(strategy.PairRegimes(regime)) > 0 {
+		return nil, fmt.Errorf("build regime filter: %s reads other pairs' bars, which only backtests feed", regime.Name())
+	}
 
 	// Load all bars. We include warmup bars before the requested range.
 	fromWithWarmup := tr.Start.Time().Add(-warmupDuration(req.Timeframe, warmup))
@@ -21,9 +21,11 @@ import (
 
 	"github.com/rustyeddy/trader/brokers/oanda"
 	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/errs"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/review"
 	datasvc "github.com/rustyeddy/trader/service/data"
+	"github.com/rustyeddy/trader/symbols"
 	"github.com/rustyeddy/trader/types"
 )
 
@@ -206,9 +208,9 @@ func (s *Service) fetchReviewCandles(ctx context.Context, instrument, granularit
 func (s *Service) fetchReviewCandleTimes(ctx context.Context, instrument, granularity string, count int) ([]market.Candle, error) {
 	inst := market.GetInstrument(instrument)
 	if inst == nil {
-		return nil, fmt.Errorf("review: unknown instrument %q", instrument)
+		return nil, errs.Newf(errs.ErrUnknownInstrument, "review: unknown instrument %q", instrument)
 	}
-	oandaName := inst.BaseCurrency + "_" + inst.QuoteCurrency
+	oandaName := symbols.ToProvider(symbols.OANDA, inst.Name)
 
 	tf, ok := reviewTimeframe(granularity)
 	if !ok {
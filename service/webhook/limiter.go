@@ -0,0 +1,44 @@
+package webhooksvc
+
+import "time"
+
+// limiter is a token bucket kept as the time the bucket will next be full
+// (the generic cell rate algorithm): each alert taken pushes that time one
+// interval on, and an alert is refused when it would be more than burst
+// intervals ahead of now.
+type limiter struct {
+	interval time.Duration // one alert's worth of refill
+	slack    time.Duration // how far ahead full may run: burst-1 intervals
+	full     time.Time
+}
+
+// newLimiter returns a limiter taking perMinute alerts a minute, burst at
+// once (perMinute when zero); nil when perMinute is zero. A nil limiter
+// allows everything.
+func newLimiter(perMinute, burst int) *limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = perMinute
+	}
+	interval := time.Minute / time.Duration(perMinute)
+	return &limiter{interval: interval, slack: interval * time.Duration(burst-1)}
+}
+
+// allow reports whether an alert at now is within the rate, and takes it
+// when it is.
+func (l *limiter) allow(now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	full := l.full
+	if full.Before(now) {
+		full = now
+	}
+	if full.Sub(now) > l.slack {
+		return false
+	}
+	l.full = full.Add(l.interval)
+	return true
+}
@@ -0,0 +1,522 @@
+// Package webhooksvc drives paper orders from inbound alert webhooks in
+// the TradingView alert format, so a charting tool's alerts can trade a
+// simulated account and land in its journal. It holds the business logic
+// — authentication, symbol mapping, rate limiting and turning an alert
+// into orders — and no HTTP; api/rest serves it.
+package webhooksvc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/oanda"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/engine"
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/symbols"
+	"github.com/rustyeddy/trader/types"
+)
+
+var (
+	// ErrUnauthorized: the alert's token is missing or wrong.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrRateLimited: the alert came in over the configured rate.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrBadAlert: the alert is malformed or asks for something unknown.
+	ErrBadAlert = errors.New("bad alert")
+)
+
+// Alert actions. TradingView strategies send their order action, "buy"
+// or "sell", with the position it leaves ("long", "short" or "flat");
+// "close" and "exit" close the ticker's trades outright.
+const (
+	ActionBuy   = "buy"
+	ActionSell  = "sell"
+	ActionClose = "close"
+	ActionExit  = "exit"
+)
+
+// Alert is an alert webhook's JSON body, in the shape a TradingView alert
+// message template produces:
+//
+//	{
+//	  "token": "secret",
+//	  "ticker": "{{exchange}}:{{ticker}}",
+//	  "action": "{{strategy.order.action}}",
+//	  "contracts": "{{strategy.order.contracts}}",
+//	  "market_position": "{{strategy.market_position}}",
+//	  "price": {{close}},
+//	  "stop_pips": 20
+//	}
+//
+// TradingView can't send headers, so the token travels in the body (or
+// as passphrase) or the URL's token query parameter. Numbers may be sent
+// quoted, as placeholders inside strings often are.
+type Alert struct {
+	Token      string `json:"token,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+
+	Ticker         string `json:"ticker"`
+	Exchange       string `json:"exchange,omitempty"`
+	Action         string `json:"action"`
+	MarketPosition string `json:"market_position,omitempty"`
+
+	Contracts Number `json:"contracts,omitempty"` // units; zero uses Config.Units
+	Price     Number `json:"price,omitempty"`     // the chart's price, which prices the sim
+	Stop      Number `json:"stop,omitempty"`      // stop-loss price
+	Take      Number `json:"take,omitempty"`      // take-profit price
+	StopPips  Number `json:"stop_pips,omitempty"` // stop-loss distance from the fill
+	TakePips  Number `json:"take_pips,omitempty"` // take-profit distance from the fill
+
+	Comment string `json:"comment,omitempty"`
+}
+
+// Alert bounds. An alert's prices and pip distances become fixed-point
+// types.Price and types.Pips, so they are capped well inside int32: a
+// price, or a price a pip distance away, always fits.
+const (
+	maxAlertPrice = 20_000
+	maxAlertPips  = 10_000
+)
+
+// Number is a JSON number that may also be sent as a string.
+type Number float64
+
+// UnmarshalJSON accepts 1.5, "1.5" and "", and refuses NaN and infinities.
+func (n *Number) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(strings.TrimSpace(string(b)), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("bad number %s", b)
+	}
+	*n = Number(f)
+	return nil
+}
+
+// ParseAlert decodes an alert body.
+func ParseAlert(body []byte) (Alert, error) {
+	var a Alert
+	if err := json.Unmarshal(body, &a); err != nil {
+		return Alert{}, fmt.Errorf("%w: %v", ErrBadAlert, err)
+	}
+	return a, nil
+}
+
+// Config configures a Service.
+type Config struct {
+	// Token every alert must carry. Required.
+	Token string
+
+	// Symbols maps alert tickers, with or without their "EXCHANGE:"
+	// prefix, to instruments. When set, tickers not in it are refused;
+	// otherwise a ticker is its instrument with the prefix dropped.
+	Symbols map[string]string
+
+	// Units is the order size of an alert without contracts.
+	Units int64
+
+	// RatePerMinute caps the alerts taken a minute, Burst of them at
+	// once. Zero takes every alert.
+	RatePerMinute int
+	Burst         int
+
+	// SpreadPips is the spread set around an alert's price when it prices
+	// the sim.
+	SpreadPips float64
+
+	// LivePrices is set when a live feed prices the broker (a
+	// paper.Broker): alerts' prices are then ignored and orders fill at
+	// the feed's quotes.
+	LivePrices bool
+}
+
+// Result is what an alert did.
+type Result struct {
+	Instrument string   `json:"instrument"`
+	Action     string   `json:"action"`
+	Closed     []string `json:"closed,omitempty"`   // trades closed
+	TradeID    string   `json:"trade_id,omitempty"` // trade opened
+	Units      int64    `json:"units,omitempty"`
+	Price      float64  `json:"price,omitempty"` // fill price of the trade opened
+	Stop       float64  `json:"stop,omitempty"`
+	Take       float64  `json:"take,omitempty"`
+}
+
+// Status is the traded account as of the latest price the broker has
+// seen, for monitoring a session.
+type Status struct {
+	AccountID    string             `json:"account_id"`
+	Currency     string             `json:"currency"`
+	Balance      float64            `json:"balance"`
+	Equity       float64            `json:"equity"`
+	MarginUsed   float64            `json:"margin_used"`
+	FreeMargin   float64            `json:"free_margin"`
+	OpenTrades   int                `json:"open_trades"`
+	ClosedTrades int                `json:"closed_trades"`
+	Prices       map[string]float64 `json:"prices,omitempty"` // latest mid by instrument
+	AsOf         time.Time          `json:"as_of,omitzero"`
+}
+
+// Service turns alerts into orders on one account of a paper broker.
+// Alerts are handled one at a time.
+type Service struct {
+	broker    brokers.Broker
+	trader    *engine.Trader
+	accountID string
+	token     string
+	symbols   map[string]string
+	units     int64
+	spread    types.Pips
+	live      bool
+	limiter   *limiter
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// New returns a Service trading accountID on b, typically a *sim.Sim.
+func New(b brokers.Broker, accountID string, cfg Config) (*Service, error) {
+	if b == nil {
+		return nil, errs.Newf(errs.ErrBadConfig, "webhook: no broker")
+	}
+	if cfg.Token == "" {
+		return nil, errs.Newf(errs.ErrBadConfig, "webhook: a token is required")
+	}
+	if cfg.Units < 0 || cfg.RatePerMinute < 0 || cfg.Burst < 0 || cfg.SpreadPips < 0 {
+		return nil, errs.Newf(errs.ErrBadConfig, "webhook: units, rate, burst and spread-pips must be >= 0")
+	}
+	s := &Service{
+		broker:    b,
+		trader:    &engine.Trader{Broker: b},
+		accountID: accountID,
+		token:     cfg.Token,
+		units:     cfg.Units,
+		spread:    types.PipsFromFloat(cfg.SpreadPips),
+		live:      cfg.LivePrices,
+		limiter:   newLimiter(cfg.RatePerMinute, cfg.Burst),
+		now:       time.Now,
+	}
+	for ticker, inst := range cfg.Symbols {
+		if market.GetInstrument(inst) == nil {
+			return nil, fmt.Errorf("webhook: symbol %s: %w: %s", ticker, errs.ErrUnknownInstrument, inst)
+		}
+		if s.symbols == nil {
+			s.symbols = make(map[string]string)
+		}
+		s.symbols[strings.ToUpper(strings.TrimSpace(ticker))] = market.NormalizeInstrument(inst)
+	}
+	return s, nil
+}
+
+// Handle authenticates a, rate-limits it and places its orders. token is
+// the request's token parameter, used when the alert carries none.
+func (s *Service) Handle(ctx context.Context, a Alert, token string) (*Result, error) {
+	if !s.authorized(a, token) {
+		return nil, ErrUnauthorized
+	}
+	inst, err := s.instrument(a)
+	if err != nil {
+		return nil, err
+	}
+	action := strings.ToLower(strings.TrimSpace(a.Action))
+	flat := strings.EqualFold(strings.TrimSpace(a.MarketPosition), "flat")
+	switch action {
+	case ActionBuy, ActionSell, ActionClose, ActionExit:
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrBadAlert, a.Action)
+	}
+	if err := checkNumbers(a); err != nil {
+		return nil, err
+	}
+
+	opens := (action == ActionBuy || action == ActionSell) && !flat
+	var units int64
+	if opens {
+		if units = int64(math.Round(float64(a.Contracts))); units == 0 {
+			units = s.units
+		}
+		if units <= 0 {
+			return nil, fmt.Errorf("%w: no contracts and no default units", ErrBadAlert)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if !s.limiter.allow(now) {
+		if opens {
+			s.record(journal.OrderDecision{
+				Time: types.FromTime(now), Instrument: inst, Side: sideOf(action).String(),
+				Reason: journal.RejectRateLimit,
+			})
+		}
+		return nil, ErrRateLimited
+	}
+	quote, err := s.price(inst, a.Price, now)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Instrument: inst, Action: action}
+	if !opens {
+		return res, s.close(ctx, res, inst, types.Flat)
+	}
+	side := sideOf(action)
+	// A buy reverses a short and a sell a long before opening.
+	if err := s.close(ctx, res, inst, -side); err != nil {
+		return res, err
+	}
+	return res, s.open(ctx, res, a, side, units, quote, now)
+}
+
+// checkNumbers refuses an alert whose numbers are negative, not finite
+// (an Alert built in code skips UnmarshalJSON) or too large to convert to
+// fixed point.
+func checkNumbers(a Alert) error {
+	for _, f := range []struct {
+		name string
+		v    Number
+		max  float64
+	}{
+		{"contracts", a.Contracts, math.MaxInt32},
+		{"price", a.Price, maxAlertPrice},
+		{"stop", a.Stop, maxAlertPrice},
+		{"take", a.Take, maxAlertPrice},
+		{"stop_pips", a.StopPips, maxAlertPips},
+		{"take_pips", a.TakePips, maxAlertPips},
+	} {
+		v := float64(f.v)
+		if math.IsNaN(v) || v < 0 || v > f.max {
+			return fmt.Errorf("%w: %s %v must be between 0 and %v", ErrBadAlert, f.name, v, f.max)
+		}
+	}
+	return nil
+}
+
+// Status returns the account's status, for a request carrying token. It
+// errors when the broker is not a simulator.
+func (s *Service) Status(token string) (*Status, error) {
+	if !s.authorized(Alert{}, token) {
+		return nil, ErrUnauthorized
+	}
+	sn, ok := s.broker.(interface{ Snapshot() sim.Snapshot })
+	if !ok {
+		return nil, fmt.Errorf("webhook: status: broker %T does not snapshot its state", s.broker)
+	}
+	snap := sn.Snapshot()
+	acct := snap
+	for _, sub := range snap.SubAccounts {
+		if sub.AccountID == s.accountID {
+			acct = sub
+		}
+	}
+	st := &Status{
+		AccountID:    acct.AccountID,
+		Currency:     acct.Currency,
+		Balance:      acct.Balance.Float64(),
+		Equity:       acct.Equity.Float64(),
+		MarginUsed:   acct.MarginUsed.Float64(),
+		FreeMargin:   acct.FreeMargin.Float64(),
+		OpenTrades:   len(acct.OpenLots),
+		ClosedTrades: acct.ClosedTrades,
+	}
+	if snap.AsOf != 0 {
+		st.AsOf = snap.AsOf.Time()
+	}
+	for inst, tick := range snap.Prices {
+		if st.Prices == nil {
+			st.Prices = make(map[string]float64, len(snap.Prices))
+		}
+		st.Prices[inst] = tick.Mid().Float64()
+	}
+	return st, nil
+}
+
+// authorized reports whether a carries the token, compared in constant
+// time.
+func (s *Service) authorized(a Alert, param string) bool {
+	got := a.Token
+	if got == "" {
+		got = a.Passphrase
+	}
+	if got == "" {
+		got = param
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) == 1
+}
+
+// instrument maps a's ticker to an instrument: the symbols entry for the
+// full ticker or the ticker without its exchange prefix, or with no
+// symbols configured the bare ticker itself.
+func (s *Service) instrument(a Alert) (string, error) {
+	ticker := strings.ToUpper(strings.TrimSpace(a.Ticker))
+	if ticker == "" {
+		return "", fmt.Errorf("%w: ticker is required", ErrBadAlert)
+	}
+	bare := ticker
+	if i := strings.LastIndexByte(ticker, ':'); i >= 0 {
+		bare = ticker[i+1:]
+	}
+	if s.symbols != nil {
+		if a.Exchange != "" {
+			if inst, ok := s.symbols[strings.ToUpper(strings.TrimSpace(a.Exchange))+":"+bare]; ok {
+				return inst, nil
+			}
+		}
+		for _, key := range []string{ticker, bare} {
+			if inst, ok := s.symbols[key]; ok {
+				return inst, nil
+			}
+		}
+		return "", fmt.Errorf("%w: ticker %s is not mapped", errs.ErrUnknownInstrument, a.Ticker)
+	}
+	inst := symbols.Canonical(bare)
+	if market.GetInstrument(inst) == nil {
+		return "", fmt.Errorf("%w: %s", errs.ErrUnknownInstrument, a.Ticker)
+	}
+	return inst, nil
+}
+
+// price moves the sim to the alert's price, SpreadPips wide, so the order
+// fills there and resting stops are checked against it, and returns the
+// quote it set. A broker with its own prices (not a PriceUpdater, or fed
+// LivePrices), or an alert without one, is left alone and the quote is
+// zero.
+func (s *Service) price(inst string, p Number, now time.Time) (market.BA, error) {
+	pu, ok := s.broker.(brokers.PriceUpdater)
+	if !ok || s.live || p == 0 {
+		return market.BA{}, nil
+	}
+	mid := market.RoundPrice(inst, types.PriceFromFloat(float64(p)))
+	half := market.GetInstrument(inst).PriceDeltaFromPips(s.spread) / 2
+	ba := market.BA{Bid: mid - half, Ask: mid + half}
+	return ba, pu.UpdatePrice(market.Tick{Instrument: inst, Timestamp: types.FromTime(now), BA: ba})
+}
+
+// close closes inst's trades on side, both sides when side is Flat.
+func (s *Service) close(ctx context.Context, res *Result, inst string, side types.Side) error {
+	reason := "webhook: " + res.Action
+	var (
+		closed []*oanda.CloseTradeResult
+		err    error
+	)
+	if side == types.Flat {
+		closed, err = brokers.CloseAllForInstrument(ctx, s.broker, s.accountID, inst, reason)
+	} else {
+		closed, err = brokers.FlattenDirection(ctx, s.broker, s.accountID, inst, side, reason)
+	}
+	for _, c := range closed {
+		res.Closed = append(res.Closed, c.TradeID)
+	}
+	return err
+}
+
+// open opens a trade of units on side, with a's stop and take: the
+// prices given, else the pip distances from the entry. With the quote
+// the alert set, the stop goes on the order, measured from the side of
+// the quote it fills at; otherwise both are set from the fill.
+func (s *Service) open(ctx context.Context, res *Result, a Alert, side types.Side, units int64, quote market.BA, now time.Time) error {
+	d := journal.OrderDecision{Time: types.FromTime(now), Instrument: res.Instrument, Side: side.String(), RequestedUnits: types.Units(units)}
+	long := side == types.Long
+	if !long {
+		units = -units
+	}
+	entry := quote.Ask
+	if !long {
+		entry = quote.Bid
+	}
+	var stop, take types.Price
+	if entry > 0 {
+		stop, take = s.levels(res.Instrument, entry, long, a)
+	} else {
+		stop = types.PriceFromFloat(float64(a.Stop))
+	}
+	or, err := s.broker.SubmitMarketOrder(ctx, s.accountID, res.Instrument, units, stop.Float64())
+	if err != nil {
+		d.Reason, d.Detail = journal.RejectBroker, err.Error()
+		if errors.Is(err, sim.ErrMarketClosed) {
+			d.Reason = journal.RejectMarketClosed
+		}
+		s.record(d)
+		return err
+	}
+	d.Accepted, d.Units = true, types.Units(or.Units)
+	s.record(d)
+	res.TradeID, res.Units, res.Price, res.Stop = or.TradeID, or.Units, or.Price, stop.Float64()
+	if or.TradeID == "" {
+		return nil // queued until the market opens, with its stop
+	}
+
+	placed := stop
+	if entry == 0 {
+		stop, take = s.levels(res.Instrument, types.PriceFromFloat(or.Price), long, a)
+	}
+	if stop == placed {
+		stop = 0
+	}
+	if stop == 0 && take == 0 {
+		return nil
+	}
+	if err := s.broker.UpdateTradeStop(ctx, s.accountID, or.TradeID, stop.Float64(), take.Float64()); err != nil {
+		return err
+	}
+	if stop > 0 {
+		res.Stop = stop.Float64()
+	}
+	res.Take = take.Float64()
+	return nil
+}
+
+// levels resolves a's stop and take for a trade filled at fill: the
+// prices when given, otherwise the pip distances on the losing and
+// winning side of the fill. Zero means unset.
+func (s *Service) levels(inst string, fill types.Price, long bool, a Alert) (stop, take types.Price) {
+	in := market.GetInstrument(inst)
+	stop, take = types.PriceFromFloat(float64(a.Stop)), types.PriceFromFloat(float64(a.Take))
+	if a.StopPips > 0 && stop == 0 {
+		if long {
+			stop = in.SubPips(fill, types.PipsFromFloat(float64(a.StopPips)))
+		} else {
+			stop = in.AddPips(fill, types.PipsFromFloat(float64(a.StopPips)))
+		}
+	}
+	if a.TakePips > 0 && take == 0 {
+		if long {
+			take = in.AddPips(fill, types.PipsFromFloat(float64(a.TakePips)))
+		} else {
+			take = in.SubPips(fill, types.PipsFromFloat(float64(a.TakePips)))
+		}
+	}
+	return stop, take
+}
+
+// record journals d when the broker journals order decisions.
+func (s *Service) record(d journal.OrderDecision) {
+	if r, ok := s.broker.(interface{ RecordOrderDecision(journal.OrderDecision) }); ok {
+		r.RecordOrderDecision(d)
+	}
+}
+
+func sideOf(action string) types.Side {
+	if action == ActionSell {
+		return types.Short
+	}
+	return types.Long
+}
@@ -0,0 +1,301 @@
+package webhooksvc
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/brokers/sim"
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestService returns a Service over a fresh sim whose clock starts on
+// a Tuesday and moves a second per alert.
+func newTestService(t *testing.T, cfg Config) (*Service, *sim.Sim, *decisions) {
+	t.Helper()
+	j := &decisions{}
+	engine := sim.NewSimBroker(&account.Account{
+		ID: "SIM", Currency: "USD",
+		Balance: types.MoneyFromFloat(100_000), Equity: types.MoneyFromFloat(100_000),
+	}, j)
+	if cfg.Token == "" {
+		cfg.Token = "secret"
+	}
+	svc, err := New(engine, "SIM", cfg)
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return svc, engine, j
+}
+
+func TestParseAlert_QuotedNumbers(t *testing.T) {
+	a, err := ParseAlert([]byte(`{"ticker":"OANDA:EURUSD","action":"buy","contracts":"1000","price":1.085,"stop_pips":"","take":null}`))
+	require.NoError(t, err)
+	assert.Equal(t, Number(1000), a.Contracts)
+	assert.Equal(t, Number(1.085), a.Price)
+	assert.Zero(t, a.StopPips)
+
+	_, err = ParseAlert([]byte(`{"contracts":"lots"}`))
+	require.ErrorIs(t, err, ErrBadAlert)
+	_, err = ParseAlert([]byte(`not json`))
+	require.ErrorIs(t, err, ErrBadAlert)
+	_, err = ParseAlert([]byte(`{"price":"NaN"}`))
+	require.ErrorIs(t, err, ErrBadAlert)
+	_, err = ParseAlert([]byte(`{"take":"-Inf"}`))
+	require.ErrorIs(t, err, ErrBadAlert)
+}
+
+func TestService_NumberRange(t *testing.T) {
+	svc, _, j := newTestService(t, Config{Units: 1000})
+	a, err := ParseAlert([]byte(`{"token":"secret","ticker":"EURUSD","action":"buy","price":1.085,"stop_pips":1e9}`))
+	require.NoError(t, err)
+	_, err = svc.Handle(context.Background(), a, "")
+	require.ErrorIs(t, err, ErrBadAlert)
+
+	for _, a := range []Alert{
+		{Price: -1}, {Price: 1e9}, {Stop: Number(math.Inf(1))}, {Take: Number(math.NaN())},
+		{TakePips: maxAlertPips + 1}, {Contracts: 1e12},
+	} {
+		a.Token, a.Ticker, a.Action = "secret", "EURUSD", "buy"
+		_, err := svc.Handle(context.Background(), a, "")
+		require.ErrorIs(t, err, ErrBadAlert, "%+v", a)
+	}
+	assert.Empty(t, j.decisions, "refused before any order")
+}
+
+func TestService_Token(t *testing.T) {
+	svc, _, _ := newTestService(t, Config{Units: 1000})
+	a := Alert{Ticker: "EURUSD", Action: "buy", Price: 1.085}
+
+	_, err := svc.Handle(context.Background(), a, "")
+	require.ErrorIs(t, err, ErrUnauthorized)
+	_, err = svc.Handle(context.Background(), a, "wrong")
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = svc.Handle(context.Background(), a, "secret")
+	require.NoError(t, err, "the query token")
+	a.Passphrase = "secret"
+	_, err = svc.Handle(context.Background(), a, "")
+	require.NoError(t, err)
+	a.Token = "wrong"
+	_, err = svc.Handle(context.Background(), a, "secret")
+	require.ErrorIs(t, err, ErrUnauthorized, "the body's token wins")
+
+	_, err = New(&sim.Sim{}, "SIM", Config{})
+	require.ErrorIs(t, err, errs.ErrBadConfig)
+}
+
+func TestService_Symbols(t *testing.T) {
+	svc, _, _ := newTestService(t, Config{})
+	for ticker, want := range map[string]string{"OANDA:EURUSD": "EURUSD", "fx:gbp_usd": "GBPUSD", "USDJPY": "USDJPY"} {
+		got, err := svc.instrument(Alert{Ticker: ticker})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := svc.instrument(Alert{Ticker: "NASDAQ:AAPL"})
+	require.ErrorIs(t, err, errs.ErrUnknownInstrument)
+	_, err = svc.instrument(Alert{})
+	require.ErrorIs(t, err, ErrBadAlert)
+
+	svc, _, _ = newTestService(t, Config{Symbols: map[string]string{"FX:EURUSD": "EUR_USD", "6E1!": "EURUSD"}})
+	got, err := svc.instrument(Alert{Ticker: "EURUSD", Exchange: "fx"})
+	require.NoError(t, err)
+	assert.Equal(t, "EURUSD", got)
+	got, err = svc.instrument(Alert{Ticker: "CME:6E1!"})
+	require.NoError(t, err)
+	assert.Equal(t, "EURUSD", got, "a futures ticker mapped to its pair")
+	_, err = svc.instrument(Alert{Ticker: "OANDA:GBPUSD"})
+	require.ErrorIs(t, err, errs.ErrUnknownInstrument, "a symbols map refuses what it doesn't list")
+
+	_, err = New(&sim.Sim{}, "SIM", Config{Token: "t", Symbols: map[string]string{"X": "NOPE"}})
+	require.ErrorIs(t, err, errs.ErrUnknownInstrument)
+}
+
+func TestLimiter(t *testing.T) {
+	assert.True(t, newLimiter(0, 5).allow(time.Now()), "no rate, no limit")
+
+	l := newLimiter(60, 3) // one a second, three at once
+	t0 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.allow(t0), "burst %d", i)
+	}
+	assert.False(t, l.allow(t0))
+	assert.False(t, l.allow(t0.Add(999*time.Millisecond)))
+	assert.True(t, l.allow(t0.Add(time.Second)))
+	assert.False(t, l.allow(t0.Add(time.Second)))
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.allow(t0.Add(time.Minute)), "refilled after a quiet spell")
+	}
+}
+
+func TestService_RateLimit(t *testing.T) {
+	svc, _, j := newTestService(t, Config{Units: 1000, RatePerMinute: 1, Burst: 1})
+	a := Alert{Token: "secret", Ticker: "EURUSD", Action: "buy", Price: 1.085}
+	_, err := svc.Handle(context.Background(), a, "")
+	require.NoError(t, err)
+	_, err = svc.Handle(context.Background(), a, "")
+	require.ErrorIs(t, err, ErrRateLimited)
+
+	require.Len(t, j.decisions, 2)
+	assert.True(t, j.decisions[0].Accepted)
+	assert.Equal(t, journal.RejectRateLimit, j.decisions[1].Reason)
+}
+
+func TestService_OrderFlow(t *testing.T) {
+	ctx := context.Background()
+	svc, engine, j := newTestService(t, Config{Units: 2000, SpreadPips: 1})
+
+	// A buy with contracts and pip distances.
+	res, err := svc.Handle(ctx, Alert{Token: "secret", Ticker: "OANDA:EURUSD", Action: "buy", Contracts: 1000, Price: 1.085, StopPips: 20, TakePips: 40}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), res.Units)
+	assert.InDelta(t, 1.08505, res.Price, 1e-9, "filled at the ask, half a pip over the alert's price")
+	assert.InDelta(t, 1.08305, res.Stop, 1e-9)
+	assert.InDelta(t, 1.08905, res.Take, 1e-9)
+	open, err := engine.GetOpenTrades(ctx, "SIM")
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.InDelta(t, 1.08305, open[0].StopLoss, 1e-9)
+	long := res.TradeID
+
+	// A sell reverses it, at the default size and an absolute stop.
+	res, err = svc.Handle(ctx, Alert{Token: "secret", Ticker: "EURUSD", Action: "sell", Price: 1.086, Stop: 1.088}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{long}, res.Closed)
+	assert.Equal(t, int64(-2000), res.Units)
+	assert.Equal(t, 1.088, res.Stop)
+	open, err = engine.GetOpenTrades(ctx, "SIM")
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, int64(-2000), open[0].Units)
+
+	// A buy that leaves the position flat only closes the short.
+	res, err = svc.Handle(ctx, Alert{Token: "secret", Ticker: "EURUSD", Action: "buy", MarketPosition: "flat", Price: 1.0855}, "")
+	require.NoError(t, err)
+	assert.Len(t, res.Closed, 1)
+	assert.Empty(t, res.TradeID)
+	open, err = engine.GetOpenTrades(ctx, "SIM")
+	require.NoError(t, err)
+	assert.Empty(t, open)
+
+	// Without a price it trades at the last quote, stops set from the fill.
+	res, err = svc.Handle(ctx, Alert{Token: "secret", Ticker: "EURUSD", Action: "sell", TakePips: 10, StopPips: 10}, "")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.08545, res.Price, 1e-9)
+	assert.InDelta(t, 1.08645, res.Stop, 1e-9)
+	assert.InDelta(t, 1.08445, res.Take, 1e-9)
+	_, err = svc.Handle(ctx, Alert{Token: "secret", Ticker: "EURUSD", Action: "exit"}, "")
+	require.NoError(t, err)
+
+	_, err = svc.Handle(ctx, Alert{Token: "secret", Ticker: "EURUSD", Action: "hold"}, "")
+	require.ErrorIs(t, err, ErrBadAlert)
+	_, err = svc.Handle(ctx, Alert{Token: "secret", Ticker: "EURUSD", Action: "close"}, "")
+	require.NoError(t, err, "nothing to close")
+
+	require.Len(t, j.decisions, 3)
+	assert.Equal(t, "long", j.decisions[0].Side)
+	assert.Equal(t, types.Units(-2000), j.decisions[1].Units)
+}
+
+func TestService_FileJournal(t *testing.T) {
+	dir := t.TempDir()
+	j, err := journal.Open(journal.Config{Kind: "json", TradesPath: filepath.Join(dir, "hook-trades.jsonl"), EquityPath: filepath.Join(dir, "hook-equity.jsonl")})
+	require.NoError(t, err)
+	engine := sim.NewSimBroker(&account.Account{
+		ID: "SIM", Currency: "USD",
+		Balance: types.MoneyFromFloat(100_000), Equity: types.MoneyFromFloat(100_000),
+	}, j)
+	svc, err := New(engine, "SIM", Config{Token: "secret", Units: 1000})
+	require.NoError(t, err)
+	svc.now = func() time.Time { return time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC) }
+
+	_, err = svc.Handle(context.Background(), Alert{Token: "secret", Ticker: "EURUSD", Action: "buy", Price: 1.085}, "")
+	require.NoError(t, err)
+	require.NoError(t, j.Close())
+
+	b, err := os.ReadFile(filepath.Join(dir, "hook-trades.decisions.jsonl"))
+	require.NoError(t, err)
+	var d journal.OrderDecision
+	require.NoError(t, json.Unmarshal(b, &d))
+	assert.True(t, d.Accepted)
+	assert.Equal(t, types.Units(1000), d.Units)
+}
+
+func TestService_NeedsUnits(t *testing.T) {
+	svc, _, _ := newTestService(t, Config{})
+	_, err := svc.Handle(context.Background(), Alert{Token: "secret", Ticker: "EURUSD", Action: "buy", Price: 1.085}, "")
+	require.ErrorIs(t, err, ErrBadAlert)
+}
+
+func TestService_Status(t *testing.T) {
+	svc, engine, _ := newTestService(t, Config{Units: 1000})
+	_, err := svc.Status("wrong")
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	st, err := svc.Status("secret")
+	require.NoError(t, err)
+	assert.Equal(t, "SIM", st.AccountID)
+	assert.True(t, st.AsOf.IsZero(), "no price seen yet")
+
+	_, err = svc.Handle(context.Background(), Alert{Token: "secret", Ticker: "EURUSD", Action: "buy", Price: 1.085}, "")
+	require.NoError(t, err)
+	st, err = svc.Status("secret")
+	require.NoError(t, err)
+	assert.Equal(t, 1, st.OpenTrades)
+	assert.Equal(t, engine.Snapshot().AsOf.Time(), st.AsOf)
+
+	_, err = engine.AllocateSubAccount("ema", types.MoneyFromFloat(5_000))
+	require.NoError(t, err)
+	sub, err := New(engine, "ema", Config{Token: "secret"})
+	require.NoError(t, err)
+	st, err = sub.Status("secret")
+	require.NoError(t, err)
+	assert.Equal(t, "ema", st.AccountID)
+	assert.InDelta(t, 5_000, st.Balance, 1e-9)
+	assert.Zero(t, st.OpenTrades)
+
+	plain, err := New(struct{ brokers.Broker }{engine}, "SIM", Config{Token: "secret"})
+	require.NoError(t, err)
+	_, err = plain.Status("secret")
+	assert.ErrorContains(t, err, "does not snapshot")
+}
+
+func TestService_LivePrices(t *testing.T) {
+	ctx := context.Background()
+	svc, engine, _ := newTestService(t, Config{Units: 1000, LivePrices: true})
+	ts := types.FromTime(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, engine.UpdatePrice(market.Tick{Instrument: "EURUSD", Timestamp: ts, BA: market.BA{Bid: 108_000, Ask: 108_002}}))
+
+	res, err := svc.Handle(ctx, Alert{Token: "secret", Ticker: "EURUSD", Action: "buy", Price: 1.085, StopPips: 20}, "")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.08002, res.Price, 1e-9, "filled at the feed's ask, not the alert's price")
+	assert.InDelta(t, 1.07802, res.Stop, 1e-9)
+}
+
+// decisions is a journal capturing order decisions.
+type decisions struct {
+	decisions []journal.OrderDecision
+}
+
+func (d *decisions) RecordTrade(journal.TradeRecord) error     { return nil }
+func (d *decisions) RecordEquity(journal.EquitySnapshot) error { return nil }
+func (d *decisions) Close() error                              { return nil }
+
+func (d *decisions) RecordOrderDecision(od journal.OrderDecision) error {
+	d.decisions = append(d.decisions, od)
+	return nil
+}
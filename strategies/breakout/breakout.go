@@ -0,0 +1,284 @@
+// Package breakout implements the classic Donchian channel breakout, the
+// trend-following baseline alongside EMA cross.
+//
+// Entry: close breaks above the highest high of the previous period bars
+// (long) or below the lowest low (short). The suggested stop sits atr_stop
+// ATRs behind the entry close; an exit strategy, when configured, overrides it.
+//
+// Exit: close breaks the shorter exit_period channel against the position
+// (0 disables this and leaves exits to the stop). A breakout of the entry
+// channel the other way reverses the position.
+//
+// Pyramiding: with pyramid > 1, another lot is added each time close moves
+// pyramid_step ATRs beyond the best open entry, up to pyramid lots.
+//
+// Registers under "donchian" and "donchian-breakout".
+package breakout
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/indicator"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+func init() {
+	strategy.MustRegisterStrategy(build, "donchian", "donchian-breakout")
+}
+
+// Breakout is the classic Donchian breakout strategy.
+type Breakout struct {
+	entry *indicator.Donchian
+	exit  *indicator.Donchian // nil when exit_period is 0
+	atr   *indicator.ATR
+
+	atrStop     int32 // ×1000; e.g. 2.0 → 2000
+	pyramid     int
+	pyramidStep int32 // ×1000
+
+	atrStopF float64 // display only: stop description
+	name     string
+}
+
+// Config holds constructor parameters.
+type Config struct {
+	Period      int     // entry channel, default 20
+	ExitPeriod  int     // exit channel, default min(10, period/2); negative disables
+	ATRPeriod   int     // default 20
+	ATRStop     float64 // stop distance in ATRs, default 2.0
+	Pyramid     int     // max open lots, default 1 (no pyramiding)
+	PyramidStep float64 // ATRs between pyramid entries, default 0.5
+}
+
+func New(cfg Config) (*Breakout, error) {
+	period := cfg.Period
+	if period <= 0 {
+		period = 20
+	}
+	exitPeriod := cfg.ExitPeriod
+	if exitPeriod == 0 {
+		exitPeriod = max(min(10, period/2), 1)
+	}
+	atrPeriod := cfg.ATRPeriod
+	if atrPeriod <= 0 {
+		atrPeriod = 20
+	}
+	atrStop := cfg.ATRStop
+	if atrStop <= 0 {
+		atrStop = 2.0
+	}
+	pyramid := cfg.Pyramid
+	if pyramid <= 0 {
+		pyramid = 1
+	}
+	step := cfg.PyramidStep
+	if step <= 0 {
+		step = 0.5
+	}
+
+	entry, err := indicator.NewDonchian(period)
+	if err != nil {
+		return nil, fmt.Errorf("donchian: entry channel: %w", err)
+	}
+	var exit *indicator.Donchian
+	if exitPeriod > 0 {
+		if exitPeriod >= period {
+			return nil, fmt.Errorf("donchian: exit_period (%d) must be shorter than period (%d)", exitPeriod, period)
+		}
+		if exit, err = indicator.NewDonchian(exitPeriod); err != nil {
+			return nil, fmt.Errorf("donchian: exit channel: %w", err)
+		}
+	}
+	atr, err := indicator.NewATR(atrPeriod, types.PriceScale)
+	if err != nil {
+		return nil, fmt.Errorf("donchian: ATR: %w", err)
+	}
+
+	name := fmt.Sprintf("DONCHIAN(%d,exit=%d,atr=%d×%.1f)", period, max(exitPeriod, 0), atrPeriod, atrStop)
+	if pyramid > 1 {
+		name = fmt.Sprintf("DONCHIAN(%d,exit=%d,atr=%d×%.1f,pyramid=%d@%.1f)", period, max(exitPeriod, 0), atrPeriod, atrStop, pyramid, step)
+	}
+	return &Breakout{
+		entry:       entry,
+		exit:        exit,
+		atr:         atr,
+		atrStop:     int32(math.Round(atrStop * 1000)),
+		pyramid:     pyramid,
+		pyramidStep: int32(math.Round(step * 1000)),
+		atrStopF:    atrStop,
+		name:        name,
+	}, nil
+}
+
+func (b *Breakout) Name() string            { return b.name }
+func (b *Breakout) StopDescription() string { return fmt.Sprintf("ATR×%.1f", b.atrStopF) }
+
+// Ready reports whether the channels cover enough bars to test the next
+// close against and the ATR is warm.
+func (b *Breakout) Ready() bool {
+	return b.entry.Ready() && (b.exit == nil || b.exit.Ready()) && b.atr.Ready()
+}
+
+func (b *Breakout) Reset() {
+	b.entry.Reset()
+	if b.exit != nil {
+		b.exit.Reset()
+	}
+	b.atr.Reset()
+}
+
+func (b *Breakout) Update(_ context.Context, ct *market.Candle, run strategy.StrategyContext) strategy.Signal {
+	if ct == nil {
+		return strategy.Hold("no candle")
+	}
+
+	// The channels are tested before they take in this bar, so a breakout
+	// is measured against the previous bars only.
+	ready := b.Ready()
+	upper, lower := b.entry.Upper(), b.entry.Lower()
+	var exitUpper, exitLower types.Price
+	if b.exit != nil {
+		exitUpper, exitLower = b.exit.Upper(), b.exit.Lower()
+	}
+	b.entry.Update(*ct)
+	if b.exit != nil {
+		b.exit.Update(*ct)
+	}
+	b.atr.Update(*ct)
+
+	if !ready || !b.atr.Ready() {
+		return strategy.Hold("warming up")
+	}
+	atr := b.atr.Price()
+
+	side := types.Flat
+	lots := 0
+	var best types.Price // most favourable open entry
+	if run != nil {
+		_ = run.OpenLots().Range(func(lot *account.Lot) error {
+			if lot.State != account.LotOpen {
+				return nil
+			}
+			side = lot.Side
+			lots++
+			if best == 0 || (lot.Side == types.Long && lot.EntryPrice > best) ||
+				(lot.Side == types.Short && lot.EntryPrice < best) {
+				best = lot.EntryPrice
+			}
+			return nil
+		})
+	}
+
+	breakLong := ct.Close > upper
+	breakShort := ct.Close < lower
+
+	switch side {
+	case types.Long:
+		if breakShort {
+			return b.enter(ct, types.Short, lower, atr)
+		}
+		if b.exit != nil && ct.Close < exitLower {
+			return strategy.Signal{CloseAll: true, Reason: fmt.Sprintf("donchian-exit-long(close=%.5f<low=%.5f)", ct.Close.Float64(), exitLower.Float64())}
+		}
+		if lots < b.pyramid && ct.Close >= best+b.atrMul(atr, b.pyramidStep) {
+			return b.add(ct, types.Long, lots, atr)
+		}
+		return strategy.Hold("holding long")
+	case types.Short:
+		if breakLong {
+			return b.enter(ct, types.Long, upper, atr)
+		}
+		if b.exit != nil && ct.Close > exitUpper {
+			return strategy.Signal{CloseAll: true, Reason: fmt.Sprintf("donchian-exit-short(close=%.5f>high=%.5f)", ct.Close.Float64(), exitUpper.Float64())}
+		}
+		if lots < b.pyramid && ct.Close <= best-b.atrMul(atr, b.pyramidStep) {
+			return b.add(ct, types.Short, lots, atr)
+		}
+		return strategy.Hold("holding short")
+	}
+
+	switch {
+	case breakLong:
+		return b.enter(ct, types.Long, upper, atr)
+	case breakShort:
+		return b.enter(ct, types.Short, lower, atr)
+	}
+	return strategy.Hold("no signal")
+}
+
+func (b *Breakout) enter(ct *market.Candle, side types.Side, level, atr types.Price) strategy.Signal {
+	dir, cmp := "long", ">"
+	if side == types.Short {
+		dir, cmp = "short", "<"
+	}
+	return strategy.Signal{
+		Side:   side,
+		Stop:   b.stop(ct.Close, side, atr),
+		Reason: fmt.Sprintf("donchian-%s(close=%.5f%s%.5f)", dir, ct.Close.Float64(), cmp, level.Float64()),
+	}
+}
+
+func (b *Breakout) add(ct *market.Candle, side types.Side, lots int, atr types.Price) strategy.Signal {
+	return strategy.Signal{
+		Side:   side,
+		Stop:   b.stop(ct.Close, side, atr),
+		Reason: fmt.Sprintf("donchian-pyramid(%d/%d,close=%.5f)", lots+1, b.pyramid, ct.Close.Float64()),
+	}
+}
+
+// stop returns the price atrStop ATRs behind close.
+func (b *Breakout) stop(close types.Price, side types.Side, atr types.Price) types.Price {
+	d := b.atrMul(atr, b.atrStop)
+	if side == types.Short {
+		return close + d
+	}
+	return close - d
+}
+
+// atrMul returns atr × mult/1000.
+func (b *Breakout) atrMul(atr types.Price, mult int32) types.Price {
+	return types.Price(int64(atr) * int64(mult) / 1000)
+}
+
+func build(params map[string]any) (strategy.Strategy, error) {
+	period, _, err := types.GetInt32Param(params, "period")
+	if err != nil {
+		return nil, err
+	}
+	exitPeriod, ok, err := types.GetInt32Param(params, "exit_period")
+	if err != nil {
+		return nil, err
+	}
+	if ok && exitPeriod == 0 {
+		exitPeriod = -1 // an explicit 0 disables the exit channel
+	}
+	atrPeriod, _, err := types.GetInt32Param(params, "atr_period")
+	if err != nil {
+		return nil, err
+	}
+	atrStop, _, err := types.GetFloat64Param(params, "atr_stop")
+	if err != nil {
+		return nil, err
+	}
+	pyramid, _, err := types.GetInt32Param(params, "pyramid")
+	if err != nil {
+		return nil, err
+	}
+	step, _, err := types.GetFloat64Param(params, "pyramid_step")
+	if err != nil {
+		return nil, err
+	}
+	return New(Config{
+		Period:      int(period),
+		ExitPeriod:  int(exitPeriod),
+		ATRPeriod:   int(atrPeriod),
+		ATRStop:     atrStop,
+		Pyramid:     int(pyramid),
+		PyramidStep: step,
+	})
+}
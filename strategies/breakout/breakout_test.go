@@ -0,0 +1,201 @@
+package breakout
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+func bar(high, low, close float64) *market.Candle {
+	return &market.Candle{
+		Open:  types.PriceFromFloat(close),
+		High:  types.PriceFromFloat(high),
+		Low:   types.PriceFromFloat(low),
+		Close: types.PriceFromFloat(close),
+	}
+}
+
+// warmup feeds ten bars ranging 0.99–1.01 and closing at 1.00, leaving both
+// channels at 0.99–1.01 and the ATR at 0.02.
+func warmup(t *testing.T, b *Breakout) {
+	t.Helper()
+	for range 10 {
+		sig := b.Update(context.Background(), bar(1.01, 0.99, 1.0), nil)
+		require.Equal(t, types.Flat, sig.Side)
+		require.False(t, sig.CloseAll)
+	}
+	require.True(t, b.Ready())
+}
+
+func newTest(t *testing.T, cfg Config) *Breakout {
+	t.Helper()
+	if cfg.Period == 0 {
+		cfg.Period, cfg.ExitPeriod, cfg.ATRPeriod = 5, 3, 3
+	}
+	b, err := New(cfg)
+	require.NoError(t, err)
+	warmup(t, b)
+	return b
+}
+
+// lots returns a run whose open lots are on side at the given entries.
+func lots(side types.Side, entries ...float64) *backtest.Backtest {
+	lb := &account.LotBook{}
+	for i, e := range entries {
+		tc := &account.TradeCommon{ID: fmt.Sprintf("lot-%d", i)}
+		tc.Side = side
+		lb.Add(&account.Lot{TradeCommon: tc, State: account.LotOpen, EntryPrice: types.PriceFromFloat(e)})
+	}
+	return &backtest.Backtest{State: &backtest.BacktestRun{Lots: lb}}
+}
+
+func TestBreakout_Registered(t *testing.T) {
+	t.Parallel()
+	for _, kind := range []string{"donchian", "donchian-breakout"} {
+		s, err := strategy.GetStrategy(strategy.StrategyConfig{Kind: kind, Params: map[string]any{"period": 55, "exit_period": 20, "pyramid": 3}})
+		require.NoError(t, err, kind)
+		assert.Equal(t, "DONCHIAN(55,exit=20,atr=20×2.0,pyramid=3@0.5)", s.Name())
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	t.Parallel()
+	b, err := New(Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "DONCHIAN(20,exit=10,atr=20×2.0)", b.Name())
+	assert.Equal(t, "ATR×2.0", b.StopDescription())
+
+	b, err = New(Config{Period: 6})
+	require.NoError(t, err)
+	assert.Equal(t, "DONCHIAN(6,exit=3,atr=20×2.0)", b.Name(), "exit defaults to half a short entry channel")
+
+	_, err = New(Config{Period: 10, ExitPeriod: 10})
+	assert.ErrorContains(t, err, "exit_period (10) must be shorter than period (10)")
+}
+
+func TestBuild_ExitPeriodZeroDisables(t *testing.T) {
+	t.Parallel()
+	s, err := build(map[string]any{"exit_period": 0})
+	require.NoError(t, err)
+	assert.Equal(t, "DONCHIAN(20,exit=0,atr=20×2.0)", s.Name())
+	assert.Nil(t, s.(*Breakout).exit)
+}
+
+func TestBreakout_NoSignalBeforeReady(t *testing.T) {
+	t.Parallel()
+	b, err := New(Config{Period: 5, ExitPeriod: 3, ATRPeriod: 3})
+	require.NoError(t, err)
+	for i := range 5 {
+		sig := b.Update(context.Background(), bar(1.0+float64(i)*0.01, 0.99, 1.0+float64(i)*0.01), nil)
+		assert.Equal(t, types.Flat, sig.Side, "bar %d", i)
+	}
+}
+
+func TestBreakout_LongEntryWithATRStop(t *testing.T) {
+	t.Parallel()
+	b := newTest(t, Config{})
+
+	sig := b.Update(context.Background(), bar(1.025, 1.0, 1.02), nil)
+	require.Equal(t, types.Long, sig.Side)
+	assert.Equal(t, "donchian-long(close=1.02000>1.01000)", sig.Reason)
+	assert.Equal(t, types.Price(102_000)-2*b.atr.Price(), sig.Stop)
+}
+
+func TestBreakout_ShortEntryWithATRStop(t *testing.T) {
+	t.Parallel()
+	b := newTest(t, Config{})
+
+	sig := b.Update(context.Background(), bar(1.0, 0.975, 0.98), nil)
+	require.Equal(t, types.Short, sig.Side)
+	assert.Equal(t, "donchian-short(close=0.98000<0.99000)", sig.Reason)
+	assert.Equal(t, types.Price(98_000)+2*b.atr.Price(), sig.Stop)
+}
+
+func TestBreakout_NoEntryInsideChannel(t *testing.T) {
+	t.Parallel()
+	b := newTest(t, Config{})
+	sig := b.Update(context.Background(), bar(1.01, 0.99, 1.005), nil)
+	assert.Equal(t, types.Flat, sig.Side)
+	assert.False(t, sig.CloseAll)
+}
+
+func TestBreakout_ExitChannelClosesLong(t *testing.T) {
+	t.Parallel()
+	b := newTest(t, Config{})
+
+	// Three higher bars lift the 3-bar exit channel to 1.03 while the 5-bar
+	// entry channel still reaches down to 0.99.
+	for range 3 {
+		b.Update(context.Background(), bar(1.05, 1.03, 1.04), lots(types.Long, 1.02))
+	}
+	sig := b.Update(context.Background(), bar(1.03, 1.01, 1.02), lots(types.Long, 1.02))
+	assert.True(t, sig.CloseAll)
+	assert.Equal(t, types.Flat, sig.Side)
+	assert.Contains(t, sig.Reason, "donchian-exit-long")
+}
+
+func TestBreakout_NoExitChannel(t *testing.T) {
+	t.Parallel()
+	b := newTest(t, Config{Period: 5, ExitPeriod: -1, ATRPeriod: 3})
+	for range 3 {
+		b.Update(context.Background(), bar(1.05, 1.03, 1.04), lots(types.Long, 1.02))
+	}
+	sig := b.Update(context.Background(), bar(1.03, 1.01, 1.02), lots(types.Long, 1.02))
+	assert.False(t, sig.CloseAll, "exits are left to the stop")
+}
+
+func TestBreakout_OppositeBreakoutReverses(t *testing.T) {
+	t.Parallel()
+	b := newTest(t, Config{})
+	sig := b.Update(context.Background(), bar(1.0, 0.975, 0.98), lots(types.Long, 1.0))
+	assert.Equal(t, types.Short, sig.Side)
+	assert.False(t, sig.CloseAll, "the planner closes the opposing long")
+}
+
+func TestBreakout_Pyramid(t *testing.T) {
+	t.Parallel()
+	up := bar(1.015, 1.005, 1.015)
+
+	b := newTest(t, Config{Period: 5, ExitPeriod: 3, ATRPeriod: 3, Pyramid: 3})
+	sig := b.Update(context.Background(), up, lots(types.Long, 1.0))
+	require.Equal(t, types.Long, sig.Side, "close is more than half an ATR past the entry")
+	assert.Equal(t, "donchian-pyramid(2/3,close=1.01500)", sig.Reason)
+	assert.Less(t, sig.Stop, up.Close)
+
+	b = newTest(t, Config{Period: 5, ExitPeriod: 3, ATRPeriod: 3, Pyramid: 3})
+	sig = b.Update(context.Background(), up, lots(types.Long, 1.0, 1.01))
+	assert.Equal(t, types.Flat, sig.Side, "not far enough past the best entry")
+
+	b = newTest(t, Config{Period: 5, ExitPeriod: 3, ATRPeriod: 3, Pyramid: 2})
+	sig = b.Update(context.Background(), up, lots(types.Long, 0.98, 0.99))
+	assert.Equal(t, types.Flat, sig.Side, "pyramid limit reached")
+
+	b = newTest(t, Config{})
+	sig = b.Update(context.Background(), up, lots(types.Long, 1.0))
+	assert.Equal(t, types.Flat, sig.Side, "pyramiding is off by default")
+}
+
+func TestBreakout_PyramidShort(t *testing.T) {
+	t.Parallel()
+	b := newTest(t, Config{Period: 5, ExitPeriod: 3, ATRPeriod: 3, Pyramid: 2})
+	sig := b.Update(context.Background(), bar(0.995, 0.985, 0.985), lots(types.Short, 1.0))
+	require.Equal(t, types.Short, sig.Side)
+	assert.Greater(t, sig.Stop, types.Price(98_500))
+}
+
+func TestBreakout_Reset(t *testing.T) {
+	t.Parallel()
+	b := newTest(t, Config{})
+	b.Reset()
+	assert.False(t, b.Ready())
+	warmup(t, b)
+}
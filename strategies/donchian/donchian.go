@@ -27,7 +27,7 @@ import (
 )
 
 func init() {
-	strategy.MustRegisterStrategy(build, "donchian", "donchian-breakout")
+	strategy.MustRegisterStrategy(build, "donchian-v6", "donchian-breakout-v6")
 }
 
 const (
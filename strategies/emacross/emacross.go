@@ -33,9 +33,16 @@ type Core struct {
 	ATRMultiplier float64
 }
 
-// Cross generates signals when a fast EMA crosses a slow EMA.
+// Cross generates signals when a fast EMA crosses a slow EMA. With an
+// HTFEMAPeriod it also requires the higher-timeframe trend to agree: a
+// long needs the last completed higher-timeframe close above its EMA, a
+// short below it. The higher-timeframe bars come from the run's
+// StrategyContext.HigherTimeframe feed.
 type Cross struct {
 	core Core
+
+	htfEMA   *indicator.EMA // nil when no higher-timeframe confirmation
+	htfClose types.Price    // last completed higher-timeframe close
 }
 
 type Config struct {
@@ -46,6 +53,7 @@ type Config struct {
 	StopPips      types.Pips
 	ATRPeriod     int
 	ATRMultiplier float64
+	HTFEMAPeriod  int // 0 disables higher-timeframe confirmation
 }
 
 func New(cfg Config) (*Cross, error) {
@@ -82,7 +90,17 @@ func New(cfg Config) (*Cross, error) {
 		return nil, err
 	}
 
+	name := fmt.Sprintf("EMA_CROSS(%d,%d)", cfg.FastPeriod, cfg.SlowPeriod)
+	var htfEMA *indicator.EMA
+	if cfg.HTFEMAPeriod > 0 {
+		if htfEMA, err = indicator.NewEMA(cfg.HTFEMAPeriod, cfg.Scale); err != nil {
+			return nil, err
+		}
+		name += fmt.Sprintf("+HTF_EMA(%d)", cfg.HTFEMAPeriod)
+	}
+
 	return &Cross{
+		htfEMA: htfEMA,
 		core: Core{
 			Fast:          fast,
 			Slow:          slow,
@@ -91,11 +109,100 @@ func New(cfg Config) (*Cross, error) {
 			Scale:         cfg.Scale,
 			StopPips:      cfg.StopPips,
 			ATRMultiplier: mult,
-			Name:          fmt.Sprintf("EMA_CROSS(%d,%d)", cfg.FastPeriod, cfg.SlowPeriod),
+			Name:          name,
 		},
 	}, nil
 }
 
+var _ strategy.StatefulStrategy = (*Cross)(nil)
+
+// CoreState is Core's warm state: its indicators and the last fast/slow
+// relation. Exported, like Core, for emacrossadx.
+type CoreState struct {
+	Fast    indicator.EMAState  `json:"fast"`
+	Slow    indicator.EMAState  `json:"slow"`
+	ATR     *indicator.ATRState `json:"atr,omitempty"`
+	PrevRel int                 `json:"prev_rel"`
+}
+
+// State returns c's warm state.
+func (c *Core) State() CoreState {
+	s := CoreState{Fast: c.Fast.State(), Slow: c.Slow.State(), PrevRel: c.PrevRel}
+	if c.ATR != nil {
+		atr := c.ATR.State()
+		s.ATR = &atr
+	}
+	return s
+}
+
+// SetState replaces c's warm state with s. On error c is unchanged.
+func (c *Core) SetState(s CoreState) error {
+	if (s.ATR != nil) != (c.ATR != nil) {
+		return fmt.Errorf("%s: snapshot ATR configuration differs", c.Name)
+	}
+	fast, slow := *c.Fast, *c.Slow
+	if err := fast.SetState(s.Fast); err != nil {
+		return err
+	}
+	if err := slow.SetState(s.Slow); err != nil {
+		return err
+	}
+	var atr indicator.ATR
+	if c.ATR != nil {
+		atr = *c.ATR
+		if err := atr.SetState(*s.ATR); err != nil {
+			return err
+		}
+		*c.ATR = atr
+	}
+	*c.Fast, *c.Slow = fast, slow
+	c.PrevRel = s.PrevRel
+	return nil
+}
+
+// crossState is Cross's snapshot state.
+type crossState struct {
+	Core     CoreState           `json:"core"`
+	HTFEMA   *indicator.EMAState `json:"htf_ema,omitempty"`
+	HTFClose types.Price         `json:"htf_close,omitempty"`
+}
+
+// Snapshot implements strategy.StatefulStrategy.
+func (x *Cross) Snapshot() ([]byte, error) {
+	s := crossState{Core: x.core.State(), HTFClose: x.htfClose}
+	if x.htfEMA != nil {
+		htf := x.htfEMA.State()
+		s.HTFEMA = &htf
+	}
+	return strategy.MarshalSnapshot(x.Name(), s)
+}
+
+// Restore implements strategy.StatefulStrategy.
+func (x *Cross) Restore(data []byte) error {
+	var s crossState
+	if err := strategy.UnmarshalSnapshot(data, x.Name(), &s); err != nil {
+		return err
+	}
+	var htf indicator.EMA
+	if x.htfEMA != nil {
+		if s.HTFEMA == nil {
+			return fmt.Errorf("%s: snapshot has no higher-timeframe EMA", x.Name())
+		}
+		htf = *x.htfEMA
+		if err := htf.SetState(*s.HTFEMA); err != nil {
+			return err
+		}
+	}
+	if err := x.core.SetState(s.Core); err != nil {
+		return err
+	}
+	if x.htfEMA != nil {
+		*x.htfEMA = htf
+	}
+	x.htfClose = s.HTFClose
+	return nil
+}
+
 func (x *Cross) Name() string            { return x.core.Name }
 func (x *Cross) StopDescription() string { return StopDesc(&x.core) }
 
@@ -106,13 +213,17 @@ func (x *Cross) Reset() {
 		x.core.ATR.Reset()
 	}
 	x.core.PrevRel = 0
+	if x.htfEMA != nil {
+		x.htfEMA.Reset()
+	}
+	x.htfClose = 0
 }
 
 func (x *Cross) Ready() bool {
 	return x.core.Fast.Ready() && x.core.Slow.Ready()
 }
 
-func (x *Cross) Update(_ context.Context, ct *market.Candle, _ strategy.StrategyContext) strategy.Signal {
+func (x *Cross) Update(_ context.Context, ct *market.Candle, sc strategy.StrategyContext) strategy.Signal {
 	if ct == nil {
 		return strategy.Hold("no candle")
 	}
@@ -122,6 +233,7 @@ func (x *Cross) Update(_ context.Context, ct *market.Candle, _ strategy.Strategy
 	if x.core.ATR != nil {
 		x.core.ATR.Update(*c)
 	}
+	x.updateHTF(sc)
 
 	if !x.Ready() {
 		return strategy.Hold("warming up")
@@ -150,6 +262,9 @@ func (x *Cross) Update(_ context.Context, ct *market.Candle, _ strategy.Strategy
 		if x.core.ATR != nil && !x.core.ATR.Ready() {
 			return strategy.Hold("warming up ATR")
 		}
+		if reason := x.htfDisagrees(types.Long); reason != "" {
+			return strategy.Hold(reason)
+		}
 		return EmitOpen(types.Long, "ema-cross-up")
 	}
 
@@ -158,6 +273,9 @@ func (x *Cross) Update(_ context.Context, ct *market.Candle, _ strategy.Strategy
 		if x.core.ATR != nil && !x.core.ATR.Ready() {
 			return strategy.Hold("warming up ATR")
 		}
+		if reason := x.htfDisagrees(types.Short); reason != "" {
+			return strategy.Hold(reason)
+		}
 		return EmitOpen(types.Short, "ema-cross-down")
 	}
 
@@ -165,6 +283,38 @@ func (x *Cross) Update(_ context.Context, ct *market.Candle, _ strategy.Strategy
 	return strategy.Hold("no cross")
 }
 
+// updateHTF folds a newly completed higher-timeframe bar into the
+// higher-timeframe EMA.
+func (x *Cross) updateHTF(sc strategy.StrategyContext) {
+	if x.htfEMA == nil || sc == nil {
+		return
+	}
+	htf := sc.HigherTimeframe()
+	if htf == nil || !htf.Closed() {
+		return
+	}
+	if bar, ok := htf.Last(0); ok {
+		x.htfEMA.Update(bar)
+		x.htfClose = bar.Close
+	}
+}
+
+// htfDisagrees returns why an entry on side is held back by the
+// higher-timeframe trend, or "" when it may go ahead.
+func (x *Cross) htfDisagrees(side types.Side) string {
+	if x.htfEMA == nil {
+		return ""
+	}
+	if !x.htfEMA.Ready() {
+		return "warming up higher-timeframe EMA"
+	}
+	closePrice, ema := types.PriceSum(x.htfClose), x.htfEMA.PriceSum()
+	if (side == types.Long && closePrice <= ema) || (side == types.Short && closePrice >= ema) {
+		return "higher-timeframe trend disagrees"
+	}
+	return ""
+}
+
 func absPriceSum(v types.PriceSum) types.PriceSum {
 	if v < 0 {
 		return -v
@@ -193,47 +343,46 @@ func priceFromFloat(v float64, scale types.Scale6) types.Price {
 	return types.Price(math.Round(v * float64(scale)))
 }
 
-func build(params map[string]any) (strategy.Strategy, error) {
-	fast, ok, err := types.GetInt32Param(params, "fast")
-	if err != nil {
-		return nil, err
-	}
-	if !ok || fast <= 0 {
-		return nil, fmt.Errorf("ema-cross: missing or invalid param %q", "fast")
-	}
-	slow, ok, err := types.GetInt32Param(params, "slow")
-	if err != nil {
-		return nil, err
+// Params are ema-cross's strategy.params: fast and slow are required,
+// the rest optional.
+type Params struct {
+	Fast          int     `yaml:"fast"`
+	Slow          int     `yaml:"slow"`
+	StopPips      float64 `yaml:"stop_pips"`
+	MinSpread     float64 `yaml:"min_spread"`
+	ATRPeriod     int     `yaml:"atr_period"`
+	ATRMultiplier float64 `yaml:"atr_multiplier"`
+	HTFEMA        int     `yaml:"htf_ema"`
+}
+
+// Validate implements strategy.ParamsValidator.
+func (p Params) Validate() error {
+	if p.Fast <= 0 {
+		return fmt.Errorf("missing or invalid param %q", "fast")
 	}
-	if !ok || slow <= 0 {
-		return nil, fmt.Errorf("ema-cross: missing or invalid param %q", "slow")
+	if p.Slow <= 0 {
+		return fmt.Errorf("missing or invalid param %q", "slow")
 	}
-	if fast >= slow {
-		return nil, fmt.Errorf("ema-cross: fast (%d) must be < slow (%d)", fast, slow)
+	if p.Fast >= p.Slow {
+		return fmt.Errorf("fast (%d) must be < slow (%d)", p.Fast, p.Slow)
 	}
-	stopPips, _, err := types.GetFloat64Param(params, "stop_pips")
-	if err != nil {
-		return nil, err
-	}
-	minSpread, _, err := types.GetFloat64Param(params, "min_spread")
-	if err != nil {
-		return nil, err
-	}
-	atrPeriod, _, err := types.GetInt32Param(params, "atr_period")
-	if err != nil {
-		return nil, err
-	}
-	atrMult, _, err := types.GetFloat64Param(params, "atr_multiplier")
-	if err != nil {
-		return nil, err
+	if p.StopPips < 0 || p.MinSpread < 0 || p.ATRPeriod < 0 || p.ATRMultiplier < 0 || p.HTFEMA < 0 {
+		return fmt.Errorf("stop_pips, min_spread, atr_period, atr_multiplier and htf_ema must be >= 0")
 	}
+	return nil
+}
+
+var build = strategy.TypedConstructor("ema-cross", func() Params { return Params{} }, fromParams)
+
+func fromParams(p Params) (strategy.Strategy, error) {
 	return New(Config{
-		FastPeriod:    int(fast),
-		SlowPeriod:    int(slow),
+		FastPeriod:    p.Fast,
+		SlowPeriod:    p.Slow,
 		Scale:         types.PriceScale,
-		StopPips:      types.PipsFromFloat(stopPips),
-		MinSpread:     minSpread,
-		ATRPeriod:     int(atrPeriod),
-		ATRMultiplier: atrMult,
+		StopPips:      types.PipsFromFloat(p.StopPips),
+		MinSpread:     p.MinSpread,
+		ATRPeriod:     p.ATRPeriod,
+		ATRMultiplier: p.ATRMultiplier,
+		HTFEMAPeriod:  p.HTFEMA,
 	})
 }
@@ -3,10 +3,12 @@ package emacross
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/strategy"
 	"github.com/rustyeddy/trader/types"
@@ -176,3 +178,108 @@ func TestNew_ErrorOnInvalidConfig(t *testing.T) {
 	_, err = New(Config{FastPeriod: 3, SlowPeriod: 5, Scale: 0})
 	require.Error(t, err)
 }
+
+func TestBuild_DecodesParams(t *testing.T) {
+	s, err := build(map[string]any{"fast": 3, "slow": float64(5), "htf_ema": 50})
+	require.NoError(t, err)
+	require.Equal(t, "EMA_CROSS(3,5)+HTF_EMA(50)", s.Name())
+
+	_, err = build(map[string]any{"fast": 3, "slow": 5, "stop_pip": 20})
+	require.EqualError(t, err, `ema-cross: unknown param "stop_pip" (did you mean "stop_pips"?); `+
+		`known params: atr_multiplier, atr_period, fast, htf_ema, min_spread, slow, stop_pips`)
+
+	_, err = build(map[string]any{"slow": 5})
+	require.EqualError(t, err, `ema-cross: missing or invalid param "fast"`)
+	_, err = build(map[string]any{"fast": 3, "slow": 5, "atr_period": -1})
+	require.Error(t, err)
+}
+
+// risingHTF is a higher-timeframe feed whose bar closes on every base bar,
+// each one higher than the last.
+type risingHTF struct{ n int }
+
+func (h *risingHTF) Timeframe() types.Timeframe { return types.H4 }
+func (h *risingHTF) Ready() bool                { return true }
+func (h *risingHTF) Closed() bool               { return true }
+func (h *risingHTF) Len() int                   { return h.n }
+func (h *risingHTF) Last(i int) (market.Candle, bool) {
+	h.n++
+	return mkClose(1.0 + float64(h.n)*0.001), true
+}
+
+type htfCtx struct{ htf strategy.HigherTimeframe }
+
+func (c htfCtx) Instrument() string                          { return "EURUSD" }
+func (c htfCtx) OpenLots() strategy.LotView                  { return nil }
+func (c htfCtx) HigherTimeframe() strategy.HigherTimeframe   { return c.htf }
+func (c htfCtx) LastOrderDecisions() []journal.OrderDecision { return nil }
+func (c htfCtx) Logger() *slog.Logger                        { return slog.Default() }
+
+func TestCross_HigherTimeframeTrendGatesEntries(t *testing.T) {
+	s, err := New(Config{FastPeriod: 3, SlowPeriod: 5, Scale: types.PriceScale, HTFEMAPeriod: 2})
+	require.NoError(t, err)
+	require.Equal(t, "EMA_CROSS(3,5)+HTF_EMA(2)", s.Name())
+
+	closes := make([]float64, 0, 100)
+	p := 1.0000
+	for i := 0; i < 20; i++ {
+		closes = append(closes, p)
+	}
+	for i := 0; i < 20; i++ {
+		p -= 0.0002
+		closes = append(closes, p)
+	}
+	for i := 0; i < 30; i++ {
+		p += 0.0003
+		closes = append(closes, p)
+	}
+	for i := 0; i < 30; i++ {
+		p -= 0.0003
+		closes = append(closes, p)
+	}
+
+	ctx := htfCtx{htf: &risingHTF{}}
+	var directional []strategy.Signal
+	var held []string
+	for _, c := range closes {
+		sig := s.Update(context.Background(), mkClosePtr(c), ctx)
+		if sig.Side != types.Flat {
+			directional = append(directional, sig)
+		} else if sig.Reason == "higher-timeframe trend disagrees" {
+			held = append(held, sig.Reason)
+		}
+	}
+	require.Len(t, directional, 1)
+	require.Equal(t, types.Long, directional[0].Side)
+	require.Len(t, held, 1, "the cross down is held against the rising higher timeframe")
+
+	// Without a feed the higher-timeframe EMA never warms, so nothing opens.
+	s.Reset()
+	for _, sig := range feedSignals(s, closes) {
+		require.Equal(t, types.Flat, sig.Side)
+	}
+}
+
+func TestCross_SnapshotRestoreContinuesIdentically(t *testing.T) {
+	cfg := Config{FastPeriod: 3, SlowPeriod: 5, Scale: types.PriceScale, ATRPeriod: 4, HTFEMAPeriod: 2}
+	closes := make([]float64, 0, 120)
+	for i := 0; i < 120; i++ {
+		closes = append(closes, 1.1+0.002*float64((i/10)%2*2-1)*float64(i%10)/10)
+	}
+
+	a, err := New(cfg)
+	require.NoError(t, err)
+	feedSignals(a, closes[:47])
+	snap, err := a.Snapshot()
+	require.NoError(t, err)
+
+	b, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, b.Restore(snap))
+	require.Equal(t, feedSignals(a, closes[47:]), feedSignals(b, closes[47:]))
+
+	other, err := New(Config{FastPeriod: 3, SlowPeriod: 8, Scale: types.PriceScale})
+	require.NoError(t, err)
+	require.Error(t, other.Restore(snap), "snapshot from a differently configured strategy")
+	require.False(t, other.Ready())
+}
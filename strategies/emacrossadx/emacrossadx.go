@@ -101,6 +101,43 @@ func New(cfg Config) (*Strategy, error) {
 	}, nil
 }
 
+var _ strategy.StatefulStrategy = (*Strategy)(nil)
+
+// snapshotState is Strategy's snapshot state. PendingRel is the cross
+// still waiting on ADX/DI confirmation.
+type snapshotState struct {
+	Core       emacross.CoreState `json:"core"`
+	ADX        indicator.ADXState `json:"adx"`
+	PendingRel int                `json:"pending_rel"`
+}
+
+// Snapshot implements strategy.StatefulStrategy.
+func (x *Strategy) Snapshot() ([]byte, error) {
+	return strategy.MarshalSnapshot(x.Name(), snapshotState{
+		Core:       x.core.State(),
+		ADX:        x.adx.State(),
+		PendingRel: x.pendingRel,
+	})
+}
+
+// Restore implements strategy.StatefulStrategy.
+func (x *Strategy) Restore(data []byte) error {
+	var s snapshotState
+	if err := strategy.UnmarshalSnapshot(data, x.Name(), &s); err != nil {
+		return err
+	}
+	adx := *x.adx
+	if err := adx.SetState(s.ADX); err != nil {
+		return err
+	}
+	if err := x.core.SetState(s.Core); err != nil {
+		return err
+	}
+	*x.adx = adx
+	x.pendingRel = s.PendingRel
+	return nil
+}
+
 func (x *Strategy) Name() string            { return x.core.Name }
 func (x *Strategy) StopDescription() string { return emacross.StopDesc(&x.core) }
 
@@ -220,76 +257,63 @@ func absPriceSum(v types.PriceSum) types.PriceSum {
 	return v
 }
 
-func build(params map[string]any) (strategy.Strategy, error) {
-	fast, ok, err := types.GetInt32Param(params, "fast")
-	if err != nil {
-		return nil, err
-	}
-	if !ok || fast <= 0 {
-		return nil, fmt.Errorf("ema-cross-adx: missing or invalid param %q", "fast")
-	}
-	slow, ok, err := types.GetInt32Param(params, "slow")
-	if err != nil {
-		return nil, err
-	}
-	if !ok || slow <= 0 {
-		return nil, fmt.Errorf("ema-cross-adx: missing or invalid param %q", "slow")
-	}
-	if fast >= slow {
-		return nil, fmt.Errorf("ema-cross-adx: fast (%d) must be < slow (%d)", fast, slow)
-	}
-	adxPeriod, _, err := types.GetInt32Param(params, "adx_period")
-	if err != nil {
-		return nil, err
-	}
-	if adxPeriod <= 0 {
-		adxPeriod = 14
-	}
-	adxThreshold, _, err := types.GetFloat64Param(params, "adx_threshold")
-	if err != nil {
-		return nil, err
+// Params are ema-cross-adx's strategy.params: fast and slow are
+// required; adx_period defaults to 14, adx_threshold to 20 and
+// require_adx_ready to true.
+type Params struct {
+	Fast            int     `yaml:"fast"`
+	Slow            int     `yaml:"slow"`
+	ADXPeriod       int     `yaml:"adx_period"`
+	ADXThreshold    float64 `yaml:"adx_threshold"`
+	StopPips        float64 `yaml:"stop_pips"`
+	MinSpread       float64 `yaml:"min_spread"`
+	ATRPeriod       int     `yaml:"atr_period"`
+	ATRMultiplier   float64 `yaml:"atr_multiplier"`
+	RequireDI       bool    `yaml:"require_di"`
+	RequireADXReady bool    `yaml:"require_adx_ready"`
+}
+
+func defaultParams() Params {
+	return Params{ADXPeriod: 14, ADXThreshold: 20.0, RequireADXReady: true}
+}
+
+// Validate implements strategy.ParamsValidator.
+func (p Params) Validate() error {
+	if p.Fast <= 0 {
+		return fmt.Errorf("missing or invalid param %q", "fast")
 	}
-	if adxThreshold <= 0 {
-		adxThreshold = 20.0
+	if p.Slow <= 0 {
+		return fmt.Errorf("missing or invalid param %q", "slow")
 	}
-	stopPips, _, err := types.GetFloat64Param(params, "stop_pips")
-	if err != nil {
-		return nil, err
+	if p.Fast >= p.Slow {
+		return fmt.Errorf("fast (%d) must be < slow (%d)", p.Fast, p.Slow)
 	}
-	minSpread, _, err := types.GetFloat64Param(params, "min_spread")
-	if err != nil {
-		return nil, err
+	if p.ADXPeriod <= 0 {
+		return fmt.Errorf("adx_period (%d) must be > 0", p.ADXPeriod)
 	}
-	atrPeriod, _, err := types.GetInt32Param(params, "atr_period")
-	if err != nil {
-		return nil, err
+	if p.ADXThreshold <= 0 || p.ADXThreshold > 100 {
+		return fmt.Errorf("adx_threshold (%g) must be in (0, 100]", p.ADXThreshold)
 	}
-	atrMult, _, err := types.GetFloat64Param(params, "atr_multiplier")
-	if err != nil {
-		return nil, err
-	}
-	requireDI, _, err := types.GetBoolParam(params, "require_di")
-	if err != nil {
-		return nil, err
-	}
-	requireADXReady, ok, err := types.GetBoolParam(params, "require_adx_ready")
-	if err != nil {
-		return nil, err
-	}
-	if !ok {
-		requireADXReady = true
+	if p.StopPips < 0 || p.MinSpread < 0 || p.ATRPeriod < 0 || p.ATRMultiplier < 0 {
+		return fmt.Errorf("stop_pips, min_spread, atr_period and atr_multiplier must be >= 0")
 	}
+	return nil
+}
+
+var build = strategy.TypedConstructor("ema-cross-adx", defaultParams, fromParams)
+
+func fromParams(p Params) (strategy.Strategy, error) {
 	return New(Config{
-		FastPeriod:      int(fast),
-		SlowPeriod:      int(slow),
-		ADXPeriod:       int(adxPeriod),
+		FastPeriod:      p.Fast,
+		SlowPeriod:      p.Slow,
+		ADXPeriod:       p.ADXPeriod,
 		Scale:           types.PriceScale,
-		StopPips:        types.PipsFromFloat(stopPips),
-		MinSpread:       minSpread,
-		ATRPeriod:       int(atrPeriod),
-		ATRMultiplier:   atrMult,
-		ADXThreshold:    adxThreshold,
-		RequireDI:       requireDI,
-		RequireADXReady: requireADXReady,
+		StopPips:        types.PipsFromFloat(p.StopPips),
+		MinSpread:       p.MinSpread,
+		ATRPeriod:       p.ATRPeriod,
+		ATRMultiplier:   p.ATRMultiplier,
+		ADXThreshold:    p.ADXThreshold,
+		RequireDI:       p.RequireDI,
+		RequireADXReady: p.RequireADXReady,
 	})
 }
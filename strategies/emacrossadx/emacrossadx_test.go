@@ -124,6 +124,21 @@ func TestBuild_FastGteSlowReturnsError(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestBuild_DefaultsAndUnknownParams(t *testing.T) {
+	s, err := build(map[string]any{"fast": 9, "slow": 21})
+	require.NoError(t, err)
+	assert.Equal(t, "EMA_CROSS_ADX(9,21,ADX14@20.0)", s.Name())
+	assert.True(t, s.(*Strategy).requireADXReady)
+
+	_, err = build(map[string]any{"fast": 9, "slow": 21, "adx_treshold": 25})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `ema-cross-adx: unknown param "adx_treshold" (did you mean "adx_threshold"?)`)
+
+	_, err = build(map[string]any{"fast": 9, "slow": 21, "require_di": "yes"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `param "require_di" must be bool`)
+}
+
 // ── Name / Ready / Reset ──────────────────────────────────────────────────────
 
 func TestStrategy_Name(t *testing.T) {
@@ -300,3 +315,36 @@ func TestAbsPriceSum_Negative(t *testing.T) {
 func TestAbsPriceSum_Zero(t *testing.T) {
 	assert.Equal(t, types.PriceSum(0), absPriceSum(0))
 }
+
+func TestStrategy_SnapshotRestoreContinuesIdentically(t *testing.T) {
+	cfg := Config{FastPeriod: 3, SlowPeriod: 5, ADXPeriod: 4, Scale: types.PriceScale, ADXThreshold: 1, RequireDI: true}
+	closes := make([]float64, 0, 150)
+	for i := 0; i < 150; i++ {
+		closes = append(closes, 1.1+0.002*float64((i/12)%2*2-1)*float64(i%12)/12)
+	}
+
+	a, err := New(cfg)
+	require.NoError(t, err)
+	feedUpdates(a, closes[:61])
+	snap, err := a.Snapshot()
+	require.NoError(t, err)
+
+	b, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, b.Restore(snap))
+	assert.Equal(t, a.pendingRel, b.pendingRel)
+	want := feedUpdates(a, closes[61:])
+	require.Equal(t, want, feedUpdates(b, closes[61:]))
+
+	var opens int
+	for _, sig := range want {
+		if sig.Side != types.Flat {
+			opens++
+		}
+	}
+	assert.Positive(t, opens, "the series must exercise entries after the restore")
+
+	other, err := New(minCfg())
+	require.NoError(t, err)
+	require.Error(t, other.Restore(snap))
+}
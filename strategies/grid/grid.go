@@ -0,0 +1,287 @@
+// Package grid implements a grid strategy: a basket of same-side entries
+// spaced step_pips apart, opened in the direction of an EMA trend.
+//
+// Entry: with no basket open, the first level opens long above the
+// EMA(trend_period) and short below it. Further levels are added each time
+// close moves step_pips past the last level, up to levels entries —
+// against the basket (averaging in, the default) or with it (mode: with).
+//
+// Sizing: level n (from 0) risks scale^n times the account's risk-pct, so
+// scale 2 is a martingale. The default 1 sizes every level alike.
+//
+// Exit: the whole basket closes once close is take_pips past its average
+// entry. Against the trend every level shares the basket stop, stop_pips
+// beyond the first level; with the trend each level has its own stop
+// stop_pips behind it.
+//
+// A grid keeps stacking trades, so it only runs with the backtest
+// governor's max-open-trades and max-exposure-pct set (see
+// strategy.SafetyLimited), and is refused for live bots.
+//
+// Registers under "grid".
+package grid
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/indicator"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+func init() {
+	strategy.MustRegisterStrategy(build, "grid")
+}
+
+// Grid modes.
+const (
+	ModeAgainst = "against"
+	ModeWith    = "with"
+)
+
+// Grid is the grid strategy.
+type Grid struct {
+	cfg   Config
+	trend *indicator.EMA
+	name  string
+
+	step, take, stop types.Pips
+	scales           []types.Rate // RiskScale by level, 0 for an unscaled level
+}
+
+// maxPips and maxLevelScale bound the config so its fixed-point forms fit.
+const (
+	maxPips       = 100_000
+	maxLevelScale = 1_000_000
+)
+
+// Config holds constructor parameters.
+type Config struct {
+	StepPips    float64 // distance between levels, default 20
+	Levels      int     // most entries in a basket, default 5
+	Mode        string  // "against" (default) or "with"
+	Scale       float64 // risk multiplier per level, default 1
+	TakePips    float64 // basket target past the average entry, default StepPips
+	StopPips    float64 // default StepPips × (Levels+1)
+	TrendPeriod int     // EMA period for the basket side, default 50
+}
+
+func New(cfg Config) (*Grid, error) {
+	if cfg.StepPips <= 0 {
+		cfg.StepPips = 20
+	}
+	if cfg.Levels <= 0 {
+		cfg.Levels = 5
+	}
+	cfg.Mode = strings.ToLower(strings.TrimSpace(cfg.Mode))
+	if cfg.Mode == "" {
+		cfg.Mode = ModeAgainst
+	}
+	if cfg.Mode != ModeAgainst && cfg.Mode != ModeWith {
+		return nil, fmt.Errorf("grid: mode must be %q or %q, got %q", ModeAgainst, ModeWith, cfg.Mode)
+	}
+	if cfg.Scale <= 0 {
+		cfg.Scale = 1
+	}
+	if cfg.TakePips <= 0 {
+		cfg.TakePips = cfg.StepPips
+	}
+	if cfg.StopPips <= 0 {
+		cfg.StopPips = cfg.StepPips * float64(cfg.Levels+1)
+	}
+	if cfg.Mode == ModeAgainst && cfg.StopPips <= cfg.StepPips*float64(cfg.Levels-1) {
+		return nil, fmt.Errorf("grid: stop_pips (%.1f) must be beyond the last level, %.1f pips from the first", cfg.StopPips, cfg.StepPips*float64(cfg.Levels-1))
+	}
+	if max(cfg.StepPips, cfg.TakePips, cfg.StopPips) > maxPips {
+		return nil, fmt.Errorf("grid: step_pips, take_pips and stop_pips must be at most %d", maxPips)
+	}
+	scales := make([]types.Rate, cfg.Levels)
+	if cfg.Scale != 1 {
+		for n := 1; n < cfg.Levels; n++ {
+			v := math.Pow(cfg.Scale, float64(n))
+			if v > maxLevelScale {
+				return nil, fmt.Errorf("grid: scale %.2f over %d levels passes %d× the base risk", cfg.Scale, cfg.Levels, maxLevelScale)
+			}
+			scales[n] = types.RateFromFloat(v)
+		}
+	}
+	if cfg.TrendPeriod <= 0 {
+		cfg.TrendPeriod = 50
+	}
+	trend, err := indicator.NewEMA(cfg.TrendPeriod, types.PriceScale)
+	if err != nil {
+		return nil, fmt.Errorf("grid: EMA: %w", err)
+	}
+	return &Grid{
+		cfg:    cfg,
+		trend:  trend,
+		step:   types.PipsFromFloat(cfg.StepPips),
+		take:   types.PipsFromFloat(cfg.TakePips),
+		stop:   types.PipsFromFloat(cfg.StopPips),
+		scales: scales,
+		name: fmt.Sprintf("GRID(%s,%.1fp×%d,scale=%.2f,take=%.1fp,stop=%.1fp,ema=%d)",
+			cfg.Mode, cfg.StepPips, cfg.Levels, cfg.Scale, cfg.TakePips, cfg.StopPips, cfg.TrendPeriod),
+	}, nil
+}
+
+func (g *Grid) Name() string            { return g.name }
+func (g *Grid) StopDescription() string { return fmt.Sprintf("%.1f pips", g.cfg.StopPips) }
+func (g *Grid) Ready() bool             { return g.trend.Ready() }
+func (g *Grid) Reset()                  { g.trend.Reset() }
+
+// RequiresSafetyLimits implements strategy.SafetyLimited.
+func (g *Grid) RequiresSafetyLimits() bool { return true }
+
+// basket summarizes the open lots.
+type basket struct {
+	side  types.Side
+	lots  int
+	avg   types.Price // units-weighted average entry
+	first types.Price // entry furthest in the basket's favour: the first level against the trend
+	last  types.Price // entry furthest against it: the last level against the trend
+}
+
+func openBasket(run strategy.StrategyContext) basket {
+	var b basket
+	if run == nil {
+		return b
+	}
+	var units, sum int64
+	_ = run.OpenLots().Range(func(lot *account.Lot) error {
+		if lot.State != account.LotOpen {
+			return nil
+		}
+		b.side = lot.Side
+		b.lots++
+		u := int64(max(lot.RemainingUnits, 1))
+		units += u
+		sum += u * int64(lot.EntryPrice)
+		better := lot.EntryPrice > b.first
+		if lot.Side == types.Short {
+			better = lot.EntryPrice < b.first
+		}
+		if b.lots == 1 || better {
+			b.first = lot.EntryPrice
+		}
+		if b.lots == 1 || (lot.Side == types.Long && lot.EntryPrice < b.last) || (lot.Side == types.Short && lot.EntryPrice > b.last) {
+			b.last = lot.EntryPrice
+		}
+		return nil
+	})
+	if units > 0 {
+		b.avg = types.Price(sum / units)
+	}
+	return b
+}
+
+func (g *Grid) Update(_ context.Context, ct *market.Candle, run strategy.StrategyContext) strategy.Signal {
+	if ct == nil {
+		return strategy.Hold("no candle")
+	}
+	g.trend.Update(*ct)
+	if !g.Ready() {
+		return strategy.Hold("warming up")
+	}
+	if run == nil {
+		return strategy.Hold("no instrument")
+	}
+	inst := market.GetInstrument(run.Instrument())
+	if inst == nil {
+		return strategy.Hold("no instrument")
+	}
+	step, take, stop := inst.PriceDeltaFromPips(g.step), inst.PriceDeltaFromPips(g.take), inst.PriceDeltaFromPips(g.stop)
+
+	b := openBasket(run)
+	if b.lots == 0 {
+		switch ema := g.trend.Price(); {
+		case ct.Close > ema:
+			return g.level(ct, types.Long, 0, ct.Close-stop)
+		case ct.Close < ema:
+			return g.level(ct, types.Short, 0, ct.Close+stop)
+		}
+		return strategy.Hold("on the trend line")
+	}
+
+	// dir is +1 for a long basket and -1 for a short one, so "past x by d in
+	// the basket's favour" is dir*(close-x) >= d for either side.
+	dir := types.Price(1)
+	if b.side == types.Short {
+		dir = -1
+	}
+	if dir*(ct.Close-b.avg) >= take {
+		return strategy.Signal{CloseAll: true, Reason: fmt.Sprintf("grid-take-profit(%d,avg=%.5f)", b.lots, b.avg.Float64())}
+	}
+	if b.lots >= g.cfg.Levels {
+		return strategy.Hold("grid full")
+	}
+	if g.cfg.Mode == ModeWith {
+		if dir*(ct.Close-b.first) >= step {
+			return g.level(ct, b.side, b.lots, ct.Close-dir*stop)
+		}
+		return strategy.Hold("holding grid")
+	}
+	if dir*(b.last-ct.Close) >= step {
+		basketStop := b.first - dir*stop
+		if dir*(ct.Close-basketStop) <= 0 {
+			return strategy.Hold("beyond the basket stop")
+		}
+		return g.level(ct, b.side, b.lots, basketStop)
+	}
+	return strategy.Hold("holding grid")
+}
+
+// level returns the entry for grid level n (from 0).
+func (g *Grid) level(ct *market.Candle, side types.Side, n int, stop types.Price) strategy.Signal {
+	sig := strategy.Signal{
+		Side:   side,
+		Stop:   stop,
+		Reason: fmt.Sprintf("grid-%s(%d/%d,close=%.5f)", side, n+1, g.cfg.Levels, ct.Close.Float64()),
+	}
+	sig.RiskScale = g.scales[n]
+	return sig
+}
+
+func build(params map[string]any) (strategy.Strategy, error) {
+	step, _, err := types.GetFloat64Param(params, "step_pips")
+	if err != nil {
+		return nil, err
+	}
+	levels, _, err := types.GetInt32Param(params, "levels")
+	if err != nil {
+		return nil, err
+	}
+	mode, _, err := types.GetStringParam(params, "mode")
+	if err != nil {
+		return nil, err
+	}
+	scale, _, err := types.GetFloat64Param(params, "scale")
+	if err != nil {
+		return nil, err
+	}
+	take, _, err := types.GetFloat64Param(params, "take_pips")
+	if err != nil {
+		return nil, err
+	}
+	stop, _, err := types.GetFloat64Param(params, "stop_pips")
+	if err != nil {
+		return nil, err
+	}
+	trend, _, err := types.GetInt32Param(params, "trend_period")
+	if err != nil {
+		return nil, err
+	}
+	return New(Config{
+		StepPips:    step,
+		Levels:      int(levels),
+		Mode:        mode,
+		Scale:       scale,
+		TakePips:    take,
+		StopPips:    stop,
+		TrendPeriod: int(trend),
+	})
+}
@@ -0,0 +1,158 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/backtest"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/strategy"
+	"github.com/rustyeddy/trader/types"
+)
+
+func flat(close float64) *market.Candle {
+	p := types.PriceFromFloat(close)
+	return &market.Candle{Open: p, High: p, Low: p, Close: p}
+}
+
+// basketRun returns an EURUSD run holding equal lots on side at entries.
+func basketRun(side types.Side, entries ...float64) *backtest.Backtest {
+	lb := &account.LotBook{}
+	for i, e := range entries {
+		tc := &account.TradeCommon{ID: fmt.Sprintf("lot-%d", i), Instrument: "EURUSD", Side: side}
+		lb.Add(&account.Lot{TradeCommon: tc, State: account.LotOpen, EntryPrice: types.PriceFromFloat(e), RemainingUnits: 1_000})
+	}
+	return &backtest.Backtest{
+		Request: &backtest.BacktestRequest{Instrument: "EURUSD"},
+		State:   &backtest.BacktestRun{Lots: lb},
+	}
+}
+
+// newTest returns a grid whose 3-bar EMA has settled at 1.1000.
+func newTest(t *testing.T, cfg Config) *Grid {
+	t.Helper()
+	cfg.TrendPeriod = 3
+	g, err := New(cfg)
+	require.NoError(t, err)
+	for range 5 {
+		sig := g.Update(context.Background(), flat(1.1), basketRun(types.Long))
+		require.Equal(t, types.Flat, sig.Side)
+	}
+	require.True(t, g.Ready())
+	return g
+}
+
+func TestGrid_Registered(t *testing.T) {
+	t.Parallel()
+	s, err := strategy.GetStrategy(strategy.StrategyConfig{Kind: "grid", Params: map[string]any{"step_pips": 15.0, "levels": 4, "scale": 2.0}})
+	require.NoError(t, err)
+	assert.Equal(t, "GRID(against,15.0p×4,scale=2.00,take=15.0p,stop=75.0p,ema=50)", s.Name())
+	assert.True(t, strategy.RequiresSafetyLimits(s))
+	assert.Equal(t, "75.0 pips", s.StopDescription())
+}
+
+func TestNew_Validation(t *testing.T) {
+	t.Parallel()
+	_, err := New(Config{Mode: "sideways"})
+	assert.ErrorContains(t, err, `grid: mode must be "against" or "with"`)
+
+	_, err = New(Config{StepPips: 20, Levels: 5, StopPips: 80})
+	assert.ErrorContains(t, err, "stop_pips (80.0) must be beyond the last level, 80.0 pips from the first")
+
+	_, err = New(Config{StepPips: 20, Levels: 5, StopPips: 30, Mode: ModeWith})
+	assert.NoError(t, err, "with the trend each level has its own stop")
+
+	_, err = New(Config{StepPips: 1e9, Mode: ModeWith})
+	assert.ErrorContains(t, err, "must be at most 100000")
+
+	_, err = New(Config{Scale: 100, Levels: 5})
+	assert.ErrorContains(t, err, "passes 1000000× the base risk")
+}
+
+func TestGrid_FirstLevelFollowsTrend(t *testing.T) {
+	t.Parallel()
+	g := newTest(t, Config{})
+	sig := g.Update(context.Background(), flat(1.101), basketRun(types.Long))
+	require.Equal(t, types.Long, sig.Side)
+	assert.Equal(t, types.PriceFromFloat(1.089), sig.Stop, "120 pips below")
+	assert.Zero(t, sig.RiskScale)
+	assert.Equal(t, "grid-long(1/5,close=1.10100)", sig.Reason)
+
+	g = newTest(t, Config{})
+	sig = g.Update(context.Background(), flat(1.099), basketRun(types.Long))
+	require.Equal(t, types.Short, sig.Side)
+	assert.Equal(t, types.PriceFromFloat(1.111), sig.Stop)
+}
+
+func TestGrid_AgainstAddsScaledLevels(t *testing.T) {
+	t.Parallel()
+	g := newTest(t, Config{Scale: 2})
+
+	sig := g.Update(context.Background(), flat(1.0985), basketRun(types.Long, 1.1))
+	assert.Equal(t, types.Flat, sig.Side, "not a full step yet")
+
+	sig = g.Update(context.Background(), flat(1.098), basketRun(types.Long, 1.1))
+	require.Equal(t, types.Long, sig.Side)
+	assert.Equal(t, types.PriceFromFloat(1.088), sig.Stop, "the basket stop, 120 pips from the first level")
+	assert.Equal(t, types.RateFromFloat(2), sig.RiskScale)
+
+	sig = g.Update(context.Background(), flat(1.096), basketRun(types.Long, 1.1, 1.098))
+	require.Equal(t, types.Long, sig.Side)
+	assert.Equal(t, types.PriceFromFloat(1.088), sig.Stop)
+	assert.Equal(t, types.RateFromFloat(4), sig.RiskScale)
+	assert.Equal(t, "grid-long(3/5,close=1.09600)", sig.Reason)
+}
+
+func TestGrid_AgainstShort(t *testing.T) {
+	t.Parallel()
+	g := newTest(t, Config{})
+	sig := g.Update(context.Background(), flat(1.102), basketRun(types.Short, 1.1))
+	require.Equal(t, types.Short, sig.Side)
+	assert.Equal(t, types.PriceFromFloat(1.112), sig.Stop)
+}
+
+func TestGrid_TakeProfitClosesBasket(t *testing.T) {
+	t.Parallel()
+	g := newTest(t, Config{})
+	sig := g.Update(context.Background(), flat(1.1009), basketRun(types.Long, 1.1, 1.098))
+	assert.False(t, sig.CloseAll, "19 pips past the 1.0990 average")
+
+	sig = g.Update(context.Background(), flat(1.101), basketRun(types.Long, 1.1, 1.098))
+	assert.True(t, sig.CloseAll)
+	assert.Equal(t, "grid-take-profit(2,avg=1.09900)", sig.Reason)
+
+	sig = g.Update(context.Background(), flat(1.097), basketRun(types.Short, 1.1, 1.101))
+	assert.True(t, sig.CloseAll, "short baskets take profit below the average")
+}
+
+func TestGrid_FullBasketHolds(t *testing.T) {
+	t.Parallel()
+	g := newTest(t, Config{Levels: 2})
+	sig := g.Update(context.Background(), flat(1.096), basketRun(types.Long, 1.1, 1.098))
+	assert.Equal(t, types.Flat, sig.Side)
+	assert.Equal(t, "grid full", sig.Reason)
+}
+
+func TestGrid_WithAddsOnStrength(t *testing.T) {
+	t.Parallel()
+	g := newTest(t, Config{Mode: ModeWith, TakePips: 100})
+
+	sig := g.Update(context.Background(), flat(1.098), basketRun(types.Long, 1.1))
+	assert.Equal(t, types.Flat, sig.Side, "no averaging in with the trend")
+
+	sig = g.Update(context.Background(), flat(1.102), basketRun(types.Long, 1.1))
+	require.Equal(t, types.Long, sig.Side)
+	assert.Equal(t, types.PriceFromFloat(1.09), sig.Stop, "each level's own stop, 120 pips behind it")
+}
+
+func TestGrid_NeedsInstrument(t *testing.T) {
+	t.Parallel()
+	g := newTest(t, Config{})
+	sig := g.Update(context.Background(), flat(1.101), nil)
+	assert.Equal(t, types.Flat, sig.Side)
+}
@@ -3,6 +3,7 @@ package signalreplay
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/market"
 	"github.com/rustyeddy/trader/strategy"
 	"github.com/rustyeddy/trader/types"
@@ -29,8 +31,11 @@ func newFakeCtx(instrument string) *fakeCtx {
 	return &fakeCtx{instrument: instrument, lots: &account.LotBook{}}
 }
 
-func (f *fakeCtx) Instrument() string         { return f.instrument }
-func (f *fakeCtx) OpenLots() strategy.LotView { return f.lots }
+func (f *fakeCtx) Instrument() string                          { return f.instrument }
+func (f *fakeCtx) OpenLots() strategy.LotView                  { return f.lots }
+func (f *fakeCtx) HigherTimeframe() strategy.HigherTimeframe   { return nil }
+func (f *fakeCtx) LastOrderDecisions() []journal.OrderDecision { return nil }
+func (f *fakeCtx) Logger() *slog.Logger                        { return slog.Default() }
 
 func (f *fakeCtx) openLot(id string, side types.Side) {
 	_ = f.lots.Add(&account.Lot{
@@ -0,0 +1,256 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// FilterConfig is one entry of a strategy's filters: chain in a YAML
+// backtest config.
+type FilterConfig struct {
+	Kind   string         `json:"kind"   yaml:"kind"`
+	Params map[string]any `json:"params" yaml:"params"`
+}
+
+// SignalFilter vets the entry signals of a wrapped strategy. Unlike a
+// run-level RegimeFilter, which the planner applies to every strategy, a
+// SignalFilter is part of the strategy itself, so a base signal and its
+// filters can be declared together and reused as one unit.
+type SignalFilter interface {
+	Name() string
+	Reset()
+
+	// Tick is called with every bar before the base strategy's Update.
+	Tick(ct market.Candle)
+
+	// Allow reports whether a directional signal may open a position.
+	Allow(sig Signal) bool
+}
+
+// entryRecorder is implemented by filters that need to know which entries
+// made it through the whole chain, e.g. to count them.
+type entryRecorder interface {
+	Admitted(sig Signal)
+}
+
+// FilteredStrategy wraps a base Strategy with a chain of SignalFilters.
+// Every filter sees every bar; an entry signal opens a position only when
+// all filters allow it. Flat signals (including CloseAll exits) always
+// pass through unchanged.
+//
+// A blocked entry still honours the exit it implied: CloseAll is kept, and
+// a plain reversal becomes CloseAll when every open lot is on the opposing
+// side, matching how the planner's regime gate leaves reversal closes in
+// place while suppressing the open.
+type FilteredStrategy struct {
+	base    Strategy
+	filters []SignalFilter
+
+	// blocked is the entry a filter refused on the last Update, if any.
+	blocked   Signal
+	blockedBy string
+}
+
+// BlockReporter is implemented by strategies that can report the entry
+// signal a filter blocked on their last Update, so a backtest can count it.
+type BlockReporter interface {
+	LastBlocked() (sig Signal, filter string, ok bool)
+}
+
+// NewFilteredStrategy returns base wrapped with filters, applied in order.
+func NewFilteredStrategy(base Strategy, filters ...SignalFilter) (*FilteredStrategy, error) {
+	if base == nil {
+		return nil, fmt.Errorf("filtered strategy: base strategy must not be nil")
+	}
+	for i, f := range filters {
+		if f == nil {
+			return nil, fmt.Errorf("filtered strategy: filter %d is nil", i)
+		}
+	}
+	return &FilteredStrategy{base: base, filters: filters}, nil
+}
+
+func (s *FilteredStrategy) Name() string {
+	names := make([]string, 0, len(s.filters))
+	for _, f := range s.filters {
+		if name := strings.TrimSpace(f.Name()); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return s.base.Name()
+	}
+	return fmt.Sprintf("%s[%s]", s.base.Name(), strings.Join(names, "+"))
+}
+
+func (s *FilteredStrategy) Reset() {
+	s.blocked, s.blockedBy = Signal{}, ""
+	s.base.Reset()
+	for _, f := range s.filters {
+		f.Reset()
+	}
+}
+
+func (s *FilteredStrategy) Ready() bool { return s.base.Ready() }
+
+func (s *FilteredStrategy) StopDescription() string { return s.base.StopDescription() }
+
+func (s *FilteredStrategy) Update(ctx context.Context, c *market.Candle, sc StrategyContext) Signal {
+	s.blocked, s.blockedBy = Signal{}, ""
+	if c != nil {
+		for _, f := range s.filters {
+			f.Tick(*c)
+		}
+	}
+	sig := s.base.Update(ctx, c, sc)
+	if sig.Side == types.Flat {
+		return sig
+	}
+	for _, f := range s.filters {
+		if !f.Allow(sig) {
+			s.blocked, s.blockedBy = sig, f.Name()
+			return blockedEntry(sig, s.blockedBy, sc)
+		}
+	}
+	for _, f := range s.filters {
+		if r, ok := f.(entryRecorder); ok {
+			r.Admitted(sig)
+		}
+	}
+	return sig
+}
+
+// LastBlocked implements BlockReporter: it returns the entry signal a
+// filter refused on the last Update and that filter's name.
+func (s *FilteredStrategy) LastBlocked() (Signal, string, bool) {
+	return s.blocked, s.blockedBy, s.blockedBy != ""
+}
+
+// blockedEntry converts a directional signal that a filter rejected into
+// the exit it implied, if any.
+func blockedEntry(sig Signal, by string, sc StrategyContext) Signal {
+	out := Hold(fmt.Sprintf("%s: blocked by %s", sig.Reason, by))
+	if sig.CloseAll || onlyOpposingLots(sig.Side, sc) {
+		out.CloseAll = true
+	}
+	return out
+}
+
+// onlyOpposingLots reports whether sc has at least one open lot and every
+// open lot is on the side opposite to side.
+func onlyOpposingLots(side types.Side, sc StrategyContext) bool {
+	if sc == nil {
+		return false
+	}
+	lots := sc.OpenLots()
+	if lots == nil || lots.Len() == 0 {
+		return false
+	}
+	opposing := true
+	_ = lots.Range(func(lot *account.Lot) error {
+		if lot.State == account.LotOpen && lot.Side == side {
+			opposing = false
+		}
+		return nil
+	})
+	return opposing
+}
+
+// RegimeSignalFilter adapts a RegimeFilter for use in a filter chain. Like
+// the planner's regime gate, it only blocks once the filter is Ready.
+type RegimeSignalFilter struct {
+	Regime RegimeFilter
+}
+
+func (f RegimeSignalFilter) Name() string { return f.Regime.Name() }
+
+// Reset is a no-op: regime filters carry no reset, and a fresh filter is
+// built for every run.
+func (f RegimeSignalFilter) Reset() {}
+
+func (f RegimeSignalFilter) Tick(ct market.Candle) { f.Regime.Tick(ct) }
+
+func (f RegimeSignalFilter) Allow(sig Signal) bool {
+	if !f.Regime.Ready() {
+		return true
+	}
+	return f.Regime.Trending() && f.Regime.AllowSide(sig.Side)
+}
+
+// MaxTradesPerDay caps the number of entries per UTC day. Only entries
+// admitted by the whole chain count against the cap.
+type MaxTradesPerDay struct {
+	limit int
+	day   int64
+	count int
+}
+
+func NewMaxTradesPerDay(limit int) (*MaxTradesPerDay, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("max-trades-per-day must be > 0")
+	}
+	return &MaxTradesPerDay{limit: limit}, nil
+}
+
+func (f *MaxTradesPerDay) Name() string { return fmt.Sprintf("MaxTradesPerDay(%d)", f.limit) }
+
+func (f *MaxTradesPerDay) Reset() {
+	f.day = 0
+	f.count = 0
+}
+
+func (f *MaxTradesPerDay) Tick(ct market.Candle) {
+	if day := int64(ct.Timestamp) / 86400; day != f.day {
+		f.day = day
+		f.count = 0
+	}
+}
+
+func (f *MaxTradesPerDay) Allow(Signal) bool { return f.count < f.limit }
+
+func (f *MaxTradesPerDay) Admitted(Signal) { f.count++ }
+
+// GetSignalFilter constructs a SignalFilter from cfg. "max-trades-per-day",
+// "session-open" and "abnormal-candle" are filter-only; every other kind is built by GetRegimeFilter and adapted
+// with RegimeSignalFilter, so adx-d1, session, weekly-ema and the rest can
+// all be used in a chain.
+func GetSignalFilter(cfg FilterConfig, scale types.Scale6) (SignalFilter, error) {
+	switch normalizeRegimeKind(cfg.Kind) {
+	case "":
+		return nil, fmt.Errorf("filter kind is required")
+
+	case "max-trades-per-day":
+		limit, ok, err := types.GetInt32Param(cfg.Params, "max")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("max-trades-per-day requires params.max")
+		}
+		return NewMaxTradesPerDay(int(limit))
+
+	case "session-open":
+		return sessionOpenFromParams(cfg.Params)
+
+	case "abnormal-candle", "news-candle":
+		return abnormalCandleFromParams(cfg.Params, scale)
+
+	case "composite":
+		return nil, fmt.Errorf("composite is not a filter kind; list the filters in the chain instead")
+
+	default:
+		regime, err := GetRegimeFilter(RegimeConfig{Kind: cfg.Kind, Params: cfg.Params}, scale)
+		if err != nil {
+			return nil, err
+		}
+		if len(PairRegimes(regime)) > 0 {
+			return nil, fmt.Errorf("%s reads other pairs' bars and only works as the run's regime", cfg.Kind)
+		}
+		return RegimeSignalFilter{Regime: regime}, nil
+	}
+}
@@ -0,0 +1,66 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rustyeddy/trader/indicator"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// AbnormalCandle is a filter-only SignalFilter that blocks entries on a
+// bar whose high-low range is more than multiplier times the ATR of the
+// bars before it — typically a news spike, whose close is a poor entry.
+// The ATR is read before it takes in the bar, so the spike does not
+// excuse itself. Entries pass until the ATR is warm.
+//
+// Default params: atr_period=14, multiplier=3.
+// Registered in the filter factory as "abnormal-candle" (alias
+// "news-candle").
+type AbnormalCandle struct {
+	atr  *indicator.ATR
+	mult int64 // ×1000; e.g. 3.0 → 3000
+
+	multF    float64 // display only
+	abnormal bool
+}
+
+func NewAbnormalCandle(atrPeriod int, multiplier float64, scale types.Scale6) (*AbnormalCandle, error) {
+	if multiplier <= 0 {
+		return nil, fmt.Errorf("abnormal-candle multiplier must be > 0, got %g", multiplier)
+	}
+	atr, err := indicator.NewATR(atrPeriod, scale)
+	if err != nil {
+		return nil, fmt.Errorf("abnormal-candle: %w", err)
+	}
+	return &AbnormalCandle{atr: atr, mult: int64(math.Round(multiplier * 1000)), multF: multiplier}, nil
+}
+
+func (f *AbnormalCandle) Name() string {
+	return fmt.Sprintf("AbnormalCandle(%d×%.1f)", f.atr.Period(), f.multF)
+}
+
+func (f *AbnormalCandle) Reset() {
+	f.atr.Reset()
+	f.abnormal = false
+}
+
+func (f *AbnormalCandle) Tick(ct market.Candle) {
+	f.abnormal = f.atr.Ready() && int64(ct.High-ct.Low)*1000 > int64(f.atr.Price())*f.mult
+	f.atr.Update(ct)
+}
+
+func (f *AbnormalCandle) Allow(Signal) bool { return !f.abnormal }
+
+func abnormalCandleFromParams(params map[string]any, scale types.Scale6) (*AbnormalCandle, error) {
+	period, err := positiveIntParamOrDefault(params, "atr_period", 14)
+	if err != nil {
+		return nil, err
+	}
+	mult, err := positiveFloat64ParamOrDefault(params, "multiplier", 3)
+	if err != nil {
+		return nil, err
+	}
+	return NewAbnormalCandle(period, mult, scale)
+}
@@ -0,0 +1,52 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// rangeCT returns a bar around 1.00000 with the given high-low range in
+// price units.
+func rangeCT(r types.Price) market.Candle {
+	return market.Candle{Open: 100_000, High: 100_000 + r/2, Low: 100_000 - r/2, Close: 100_000}
+}
+
+func TestAbnormalCandle_BlocksWideBars(t *testing.T) {
+	t.Parallel()
+	f, err := abnormalCandleFromParams(map[string]any{"atr_period": 3}, types.Scale6(types.PriceScale))
+	require.NoError(t, err)
+	assert.Equal(t, "AbnormalCandle(3×3.0)", f.Name())
+
+	long := Signal{Side: types.Long}
+	for range 4 {
+		f.Tick(rangeCT(100))
+		assert.True(t, f.Allow(long), "warming up")
+	}
+	f.Tick(rangeCT(300))
+	assert.True(t, f.Allow(long), "exactly 3 ATRs")
+
+	f.Reset()
+	for range 4 {
+		f.Tick(rangeCT(100))
+	}
+	f.Tick(rangeCT(302))
+	assert.False(t, f.Allow(long), "wider than 3 ATRs of the bars before it")
+	f.Tick(rangeCT(100))
+	assert.True(t, f.Allow(long))
+}
+
+func TestAbnormalCandle_ErrorsAndAlias(t *testing.T) {
+	t.Parallel()
+	scale := types.Scale6(types.PriceScale)
+	_, err := NewAbnormalCandle(14, -1, scale)
+	assert.Error(t, err)
+
+	f, err := GetSignalFilter(FilterConfig{Kind: "news-candle", Params: map[string]any{"multiplier": 2.5}}, scale)
+	require.NoError(t, err)
+	assert.Equal(t, "AbnormalCandle(14×2.5)", f.Name())
+}
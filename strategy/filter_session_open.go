@@ -0,0 +1,133 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// DefaultSessionOpens are the session opens a SessionOpen filter watches
+// when none are configured: London at 07:00 and New York at 12:00 UTC
+// (their summer opens; the filter works in fixed UTC times).
+const DefaultSessionOpens = "07:00,12:00"
+
+// SessionOpen is a filter-only SignalFilter that blocks entries during the
+// first N minutes after a session opens, when spreads are wide and the
+// first moves are noisy. Sessions are fixed UTC times of day; the weekly
+// forex market open (Sunday 17:00 New York, later after a holiday) counts
+// too unless market_open is false.
+//
+// A bar is judged by its open time. Bars without a timestamp always pass.
+//
+// Default params: minutes=30, opens="07:00,12:00", market_open=true.
+// Registered in the filter factory as "session-open".
+type SessionOpen struct {
+	window     time.Duration
+	opens      []int // minutes after 00:00 UTC, ascending
+	marketOpen bool
+
+	at types.Timestamp
+}
+
+func NewSessionOpen(minutes int, opens []int, marketOpen bool) (*SessionOpen, error) {
+	if minutes <= 0 {
+		return nil, fmt.Errorf("session-open minutes must be > 0, got %d", minutes)
+	}
+	if len(opens) == 0 && !marketOpen {
+		return nil, fmt.Errorf("session-open needs at least one open or market_open")
+	}
+	for _, o := range opens {
+		if o < 0 || o >= 24*60 {
+			return nil, fmt.Errorf("session-open: open %d is outside the day", o)
+		}
+	}
+	return &SessionOpen{window: time.Duration(minutes) * time.Minute, opens: opens, marketOpen: marketOpen}, nil
+}
+
+func (f *SessionOpen) Name() string {
+	parts := make([]string, 0, len(f.opens)+1)
+	for _, o := range f.opens {
+		parts = append(parts, fmt.Sprintf("%02d:%02d", o/60, o%60))
+	}
+	if f.marketOpen {
+		parts = append(parts, "market")
+	}
+	return fmt.Sprintf("SessionOpen(%dm:%s)", int(f.window/time.Minute), strings.Join(parts, ","))
+}
+
+func (f *SessionOpen) Reset() { f.at = 0 }
+
+func (f *SessionOpen) Tick(ct market.Candle) { f.at = ct.Timestamp }
+
+func (f *SessionOpen) Allow(Signal) bool {
+	if f.at == 0 {
+		return true
+	}
+	return !f.justOpened(f.at.Time().UTC())
+}
+
+// justOpened reports whether t falls within the window after a session
+// open, or after the forex market reopened.
+func (f *SessionOpen) justOpened(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	window := int(f.window / time.Minute)
+	for _, o := range f.opens {
+		// Measured modulo a day so an open late in the evening still
+		// covers the first minutes after midnight.
+		if since := (minute - o + 24*60) % (24 * 60); since < window {
+			return true
+		}
+	}
+	// The market closes for far longer than any sensible window, so it
+	// reopened within the window exactly when it was closed window ago.
+	return f.marketOpen && !market.IsForexMarketClosed(t) && market.IsForexMarketClosed(t.Add(-f.window))
+}
+
+func sessionOpenFromParams(params map[string]any) (*SessionOpen, error) {
+	minutes, err := positiveIntParamOrDefault(params, "minutes", 30)
+	if err != nil {
+		return nil, err
+	}
+	spec, ok, err := types.GetStringParam(params, "opens")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		spec = DefaultSessionOpens
+	}
+	opens, err := parseSessionOpens(spec)
+	if err != nil {
+		return nil, err
+	}
+	marketOpen, ok, err := types.GetBoolParam(params, "market_open")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		marketOpen = true
+	}
+	return NewSessionOpen(minutes, opens, marketOpen)
+}
+
+// parseSessionOpens parses a comma-separated list of HH:MM UTC times into
+// minutes after midnight, sorted.
+func parseSessionOpens(spec string) ([]int, error) {
+	var opens []int
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", field)
+		if err != nil {
+			return nil, fmt.Errorf("session-open: opens: %q is not HH:MM", field)
+		}
+		opens = append(opens, t.Hour()*60+t.Minute())
+	}
+	sort.Ints(opens)
+	return opens, nil
+}
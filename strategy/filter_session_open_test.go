@@ -0,0 +1,69 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+func TestSessionOpen_BlocksAfterOpens(t *testing.T) {
+	t.Parallel()
+	f, err := sessionOpenFromParams(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "SessionOpen(30m:07:00,12:00,market)", f.Name())
+
+	// 2024-01-07 is a Sunday; the market reopens at 17:00 New York, 22:00 UTC.
+	sunday := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		at    time.Duration
+		allow bool
+	}{
+		{22*time.Hour + 10*time.Minute, false}, // market open
+		{22*time.Hour + 30*time.Minute, true},
+		{31 * time.Hour, false}, // Monday 07:00 London
+		{31*time.Hour + 29*time.Minute, false},
+		{31*time.Hour + 30*time.Minute, true},
+		{36*time.Hour + 15*time.Minute, false}, // Monday 12:15 New York
+		{40 * time.Hour, true},
+		{46*time.Hour + 10*time.Minute, true}, // Monday 22:10: no weekly open
+	}
+	for _, tt := range tests {
+		at := sunday.Add(tt.at)
+		f.Tick(sessionCT(at))
+		assert.Equal(t, tt.allow, f.Allow(Signal{Side: types.Long}), "%s", at)
+	}
+
+	f.Reset()
+	f.Tick(market.Candle{})
+	assert.True(t, f.Allow(Signal{Side: types.Long}), "no timestamp")
+}
+
+func TestSessionOpen_WindowWrapsMidnight(t *testing.T) {
+	t.Parallel()
+	f, err := sessionOpenFromParams(map[string]any{"minutes": 20, "opens": "23:50", "market_open": false})
+	require.NoError(t, err)
+	assert.Equal(t, "SessionOpen(20m:23:50)", f.Name())
+
+	f.Tick(sessionCT(time.Date(2024, 1, 9, 0, 5, 0, 0, time.UTC)))
+	assert.False(t, f.Allow(Signal{Side: types.Short}))
+	f.Tick(sessionCT(time.Date(2024, 1, 9, 0, 10, 0, 0, time.UTC)))
+	assert.True(t, f.Allow(Signal{Side: types.Short}))
+}
+
+func TestSessionOpenFromParams_Errors(t *testing.T) {
+	t.Parallel()
+	for _, params := range []map[string]any{
+		{"opens": "7am"},
+		{"opens": "25:00"},
+		{"opens": "", "market_open": false},
+		{"market_open": "yes"},
+	} {
+		_, err := sessionOpenFromParams(params)
+		assert.Error(t, err, "%v", params)
+	}
+}
@@ -0,0 +1,197 @@
+package strategy
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/journal"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedStrategy emits the next signal from sigs on every Update.
+type scriptedStrategy struct {
+	sigs  []Signal
+	n     int
+	reset int
+}
+
+func (s *scriptedStrategy) Name() string            { return "Scripted" }
+func (s *scriptedStrategy) Reset()                  { s.n = 0; s.reset++ }
+func (s *scriptedStrategy) Ready() bool             { return true }
+func (s *scriptedStrategy) StopDescription() string { return "10 pips" }
+func (s *scriptedStrategy) Update(context.Context, *market.Candle, StrategyContext) Signal {
+	if s.n >= len(s.sigs) {
+		return Hold("done")
+	}
+	sig := s.sigs[s.n]
+	s.n++
+	return sig
+}
+
+type lotsContext struct{ lots *account.LotBook }
+
+func (c lotsContext) Instrument() string                          { return "EURUSD" }
+func (c lotsContext) OpenLots() LotView                           { return c.lots }
+func (c lotsContext) HigherTimeframe() HigherTimeframe            { return nil }
+func (c lotsContext) LastOrderDecisions() []journal.OrderDecision { return nil }
+func (c lotsContext) Logger() *slog.Logger                        { return slog.Default() }
+
+func openLots(t *testing.T, sides ...types.Side) lotsContext {
+	t.Helper()
+	lots := &account.LotBook{}
+	for i, side := range sides {
+		require.NoError(t, lots.Add(&account.Lot{
+			TradeCommon: &account.TradeCommon{ID: string(rune('a' + i)), Side: side},
+			State:       account.LotOpen,
+		}))
+	}
+	return lotsContext{lots: lots}
+}
+
+func candleAt(ts time.Time) *market.Candle {
+	c := sessionCT(ts)
+	return &c
+}
+
+func TestFilteredStrategy_PassesFlatSignalsAndDelegates(t *testing.T) {
+	base := &scriptedStrategy{sigs: []Signal{{Side: types.Flat, CloseAll: true, Reason: "exit"}}}
+	session, err := NewSessionFilter(7, 17)
+	require.NoError(t, err)
+	fs, err := NewFilteredStrategy(base, RegimeSignalFilter{Regime: session})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Scripted[Session(07:00-17:00UTC)]", fs.Name())
+	assert.Equal(t, "10 pips", fs.StopDescription())
+	assert.True(t, fs.Ready())
+
+	// Outside the session, but an exit is never filtered.
+	sig := fs.Update(context.Background(), candleAt(time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)), nil)
+	assert.Equal(t, Signal{Side: types.Flat, CloseAll: true, Reason: "exit"}, sig)
+	_, _, blocked := fs.LastBlocked()
+	assert.False(t, blocked)
+
+	fs.Reset()
+	assert.Equal(t, 1, base.reset)
+}
+
+func TestFilteredStrategy_BlockedEntryKeepsImpliedExit(t *testing.T) {
+	night := candleAt(time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name      string
+		sig       Signal
+		sc        StrategyContext
+		wantClose bool
+	}{
+		{name: "no open lots", sig: Signal{Side: types.Long, Reason: "cross"}, sc: openLots(t)},
+		{name: "nil context", sig: Signal{Side: types.Long, Reason: "cross"}},
+		{name: "reversal of opposing lots", sig: Signal{Side: types.Long, Reason: "cross"}, sc: openLots(t, types.Short, types.Short), wantClose: true},
+		{name: "mixed lots are left alone", sig: Signal{Side: types.Long, Reason: "cross"}, sc: openLots(t, types.Short, types.Long)},
+		{name: "explicit close all", sig: Signal{Side: types.Short, CloseAll: true, Reason: "cross"}, sc: openLots(t, types.Short), wantClose: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := NewSessionFilter(7, 17)
+			require.NoError(t, err)
+			fs, err := NewFilteredStrategy(&scriptedStrategy{sigs: []Signal{tt.sig}}, RegimeSignalFilter{Regime: session})
+			require.NoError(t, err)
+
+			sig := fs.Update(context.Background(), night, tt.sc)
+			assert.Equal(t, types.Flat, sig.Side)
+			assert.Equal(t, tt.wantClose, sig.CloseAll)
+			assert.Equal(t, "cross: blocked by Session(07:00-17:00UTC)", sig.Reason)
+
+			blocked, by, ok := fs.LastBlocked()
+			require.True(t, ok)
+			assert.Equal(t, tt.sig, blocked)
+			assert.Equal(t, "Session(07:00-17:00UTC)", by)
+		})
+	}
+}
+
+func TestMaxTradesPerDay_CountsOnlyAdmittedEntries(t *testing.T) {
+	long := Signal{Side: types.Long, Reason: "go"}
+	base := &scriptedStrategy{sigs: []Signal{long, long, long, long, long, long}}
+	capFilter, err := NewMaxTradesPerDay(2)
+	require.NoError(t, err)
+	session, err := NewSessionFilter(7, 17)
+	require.NoError(t, err)
+	fs, err := NewFilteredStrategy(base, RegimeSignalFilter{Regime: session}, capFilter)
+	require.NoError(t, err)
+
+	day1 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	bars := []struct {
+		at   time.Time
+		want types.Side
+	}{
+		{day1.Add(3 * time.Hour), types.Flat},  // outside session: not counted
+		{day1.Add(8 * time.Hour), types.Long},  // 1st
+		{day1.Add(9 * time.Hour), types.Long},  // 2nd
+		{day1.Add(10 * time.Hour), types.Flat}, // cap reached
+		{day1.Add(32 * time.Hour), types.Long}, // next day resets
+		{day1.Add(33 * time.Hour), types.Long},
+	}
+	for i, bar := range bars {
+		sig := fs.Update(context.Background(), candleAt(bar.at), nil)
+		assert.Equal(t, bar.want, sig.Side, "bar %d", i)
+	}
+	assert.Equal(t, "MaxTradesPerDay(2)", capFilter.Name())
+}
+
+func TestGetSignalFilter(t *testing.T) {
+	scale := types.Scale6(types.PriceScale)
+
+	f, err := GetSignalFilter(FilterConfig{Kind: "max-trades-per-day", Params: map[string]any{"max": 3}}, scale)
+	require.NoError(t, err)
+	assert.Equal(t, "MaxTradesPerDay(3)", f.Name())
+
+	f, err = GetSignalFilter(FilterConfig{Kind: "session-open", Params: map[string]any{"minutes": 15}}, scale)
+	require.NoError(t, err)
+	assert.Equal(t, "SessionOpen(15m:07:00,12:00,market)", f.Name())
+
+	f, err = GetSignalFilter(FilterConfig{Kind: "abnormal-candle"}, scale)
+	require.NoError(t, err)
+	assert.Equal(t, "AbnormalCandle(14×3.0)", f.Name())
+
+	f, err = GetSignalFilter(FilterConfig{Kind: "ADX-D1"}, scale)
+	require.NoError(t, err)
+	assert.IsType(t, RegimeSignalFilter{}, f)
+
+	for _, cfg := range []FilterConfig{
+		{},
+		{Kind: "max-trades-per-day"},
+		{Kind: "max-trades-per-day", Params: map[string]any{"max": 0}},
+		{Kind: "composite"},
+		{Kind: "strength"},
+		{Kind: "bogus"},
+	} {
+		_, err := GetSignalFilter(cfg, scale)
+		assert.Error(t, err, "kind %q", cfg.Kind)
+	}
+}
+
+func TestGetStrategy_WrapsFilters(t *testing.T) {
+	name := "test-filtered-base"
+	require.NoError(t, RegisterStrategy(func(map[string]any) (Strategy, error) {
+		return &scriptedStrategy{}, nil
+	}, name))
+
+	strat, err := GetStrategy(StrategyConfig{Kind: name})
+	require.NoError(t, err)
+	assert.IsType(t, &scriptedStrategy{}, strat)
+
+	strat, err = GetStrategy(StrategyConfig{Kind: name, Filters: []FilterConfig{
+		{Kind: "session", Params: map[string]any{"session_start": 8, "session_end": 16}},
+		{Kind: "max-trades-per-day", Params: map[string]any{"max": 1}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "Scripted[Session(08:00-16:00UTC)+MaxTradesPerDay(1)]", strat.Name())
+
+	_, err = GetStrategy(StrategyConfig{Kind: name, Filters: []FilterConfig{{Kind: "session"}, {Kind: "nope"}}})
+	require.ErrorContains(t, err, "strategy filter 2")
+}
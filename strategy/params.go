@@ -0,0 +1,180 @@
+package strategy
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rustyeddy/trader/types"
+)
+
+// ParamsValidator is implemented by a strategy's params struct to check
+// the decoded values as a whole: required fields, ranges, and relations
+// between fields such as fast < slow.
+type ParamsValidator interface {
+	Validate() error
+}
+
+// DecodeParams decodes a strategy.params map into dst, a pointer to a
+// struct whose fields carry yaml tags naming the params. Fields absent
+// from params keep the values dst already holds, so callers preset
+// defaults. A param no field is tagged with is an error naming the
+// closest known param and listing all of them, so a typo ("fsat") fails
+// loudly instead of silently running with the default. Once decoded, dst
+// is validated when it implements ParamsValidator. Errors are prefixed
+// with kind.
+func DecodeParams(kind string, params map[string]any, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%s: params destination must be a struct pointer, got %T", kind, dst)
+	}
+	fields := paramFields(rv.Elem())
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		field, ok := fields[k]
+		if !ok {
+			return unknownParamError(kind, k, fields)
+		}
+		if err := setParam(field, params, k); err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+	}
+
+	if v, ok := dst.(ParamsValidator); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+// TypedConstructor adapts build, which takes a strategy's own params
+// struct, into a StrategyConstructor: each call decodes the params map
+// with DecodeParams into a fresh struct from defaults, then builds from
+// it. Register the result like any other constructor:
+//
+//	strategy.MustRegisterStrategy(strategy.TypedConstructor("ema-cross", defaultParams, fromParams), "ema-cross")
+func TypedConstructor[P any](kind string, defaults func() P, build func(P) (Strategy, error)) StrategyConstructor {
+	return func(params map[string]any) (Strategy, error) {
+		p := defaults()
+		if err := DecodeParams(kind, params, &p); err != nil {
+			return nil, err
+		}
+		return build(p)
+	}
+}
+
+// setParam sets field from params[key]. Scalars go through the types
+// package's param getters, which accept whatever numeric type YAML or
+// JSON decoding produced and reject fractional integers; anything else
+// (lists, nested maps) is round-tripped through YAML.
+func setParam(field reflect.Value, params map[string]any, key string) error {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, _, err := types.GetIntParam(params, key)
+		if err != nil {
+			return err
+		}
+		if field.OverflowInt(int64(v)) {
+			return fmt.Errorf("param %q=%d overflows %s", key, v, field.Type())
+		}
+		field.SetInt(int64(v))
+	case reflect.Float32, reflect.Float64:
+		v, _, err := types.GetFloat64Param(params, key)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(v)
+	case reflect.Bool:
+		v, _, err := types.GetBoolParam(params, key)
+		if err != nil {
+			return err
+		}
+		field.SetBool(v)
+	case reflect.String:
+		v, _, err := types.GetStringParam(params, key)
+		if err != nil {
+			return err
+		}
+		field.SetString(v)
+	default:
+		b, err := yaml.Marshal(params[key])
+		if err != nil {
+			return fmt.Errorf("param %q: %w", key, err)
+		}
+		if err := yaml.Unmarshal(b, field.Addr().Interface()); err != nil {
+			return fmt.Errorf("param %q: want %s, got %v", key, field.Type(), params[key])
+		}
+	}
+	return nil
+}
+
+// paramFields maps each yaml-tagged field of the struct v to its value.
+// Fields tagged "-" or untagged are not params.
+func paramFields(v reflect.Value) map[string]reflect.Value {
+	out := map[string]reflect.Value{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		out[name] = v.Field(i)
+	}
+	return out
+}
+
+func unknownParamError(kind, key string, fields map[string]reflect.Value) error {
+	known := make([]string, 0, len(fields))
+	for name := range fields {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+
+	best, bestDist := "", 0
+	for _, name := range known {
+		d := editDistance(strings.ToLower(key), name)
+		if best == "" || d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	// Only suggest a name a typo could plausibly have come from.
+	if best != "" && bestDist <= max(2, len(best)/3) {
+		return fmt.Errorf("%s: unknown param %q (did you mean %q?); known params: %s",
+			kind, key, best, strings.Join(known, ", "))
+	}
+	return fmt.Errorf("%s: unknown param %q; known params: %s", kind, key, strings.Join(known, ", "))
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
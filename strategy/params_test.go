@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testParams struct {
+	Fast      int     `yaml:"fast"`
+	Slow      int     `yaml:"slow"`
+	MinSpread float64 `yaml:"min_spread"`
+	Confirm   bool    `yaml:"confirm"`
+	internal  int
+}
+
+func (p testParams) Validate() error {
+	if p.Fast >= p.Slow {
+		return errors.New("fast must be < slow")
+	}
+	return nil
+}
+
+func TestDecodeParams_KeepsDefaultsAndConvertsNumbers(t *testing.T) {
+	t.Parallel()
+
+	p := testParams{Slow: 21, Confirm: true}
+	// JSON configs decode every number as float64.
+	require.NoError(t, DecodeParams("test", map[string]any{"fast": float64(9), "min_spread": 3}, &p))
+	assert.Equal(t, testParams{Fast: 9, Slow: 21, MinSpread: 3, Confirm: true}, p)
+}
+
+func TestDecodeParams_UnknownParam(t *testing.T) {
+	t.Parallel()
+
+	var p testParams
+	err := DecodeParams("test", map[string]any{"fsat": 9, "slow": 21}, &p)
+	require.Error(t, err)
+	assert.Equal(t, `test: unknown param "fsat" (did you mean "fast"?); known params: confirm, fast, min_spread, slow`, err.Error())
+
+	err = DecodeParams("test", map[string]any{"internal": 1}, &p)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "did you mean")
+}
+
+func TestDecodeParams_WrongType(t *testing.T) {
+	t.Parallel()
+
+	var p testParams
+	err := DecodeParams("test", map[string]any{"fast": 9.5}, &p)
+	require.Error(t, err)
+	assert.Equal(t, `test: param "fast" must be an integer, got 9.5`, err.Error())
+
+	err = DecodeParams("test", map[string]any{"confirm": "maybe"}, &p)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `param "confirm"`)
+}
+
+func TestDecodeParams_Validates(t *testing.T) {
+	t.Parallel()
+
+	var p testParams
+	err := DecodeParams("test", map[string]any{"fast": 21, "slow": 9}, &p)
+	require.Error(t, err)
+	assert.Equal(t, "test: fast must be < slow", err.Error())
+
+	require.Error(t, DecodeParams("test", nil, p), "non-pointer destination")
+}
+
+func TestTypedConstructor_DecodesFreshDefaults(t *testing.T) {
+	t.Parallel()
+
+	var got []testParams
+	ctor := TypedConstructor("test", func() testParams { return testParams{Slow: 21} },
+		func(p testParams) (Strategy, error) {
+			got = append(got, p)
+			return nil, nil
+		})
+	_, err := ctor(map[string]any{"fast": 5, "slow": 30})
+	require.NoError(t, err)
+	_, err = ctor(map[string]any{"fast": 9})
+	require.NoError(t, err)
+	assert.Equal(t, []testParams{{Fast: 5, Slow: 30}, {Fast: 9, Slow: 21}}, got)
+
+	_, err = ctor(map[string]any{"fast": 9, "slwo": 30})
+	assert.ErrorContains(t, err, `did you mean "slow"`)
+}
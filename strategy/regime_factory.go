@@ -141,6 +141,39 @@ func GetRegimeFilter(cfg RegimeConfig, scale types.Scale6) (RegimeFilter, error)
 		}
 		return NewATRPercentileFilter(atrPeriod, windowSize, threshold, scale)
 
+	case "vol-regime":
+		atrPeriod, err := positiveIntParamOrDefault(cfg.Params, "atr_period", DefaultVolATRPeriod)
+		if err != nil {
+			return nil, err
+		}
+		windowSize, err := positiveIntParamOrDefault(cfg.Params, "window_size", DefaultVolWindowSize)
+		if err != nil {
+			return nil, err
+		}
+		low, err := float64ParamOrDefault(cfg.Params, "low", DefaultVolLow)
+		if err != nil {
+			return nil, err
+		}
+		high, err := float64ParamOrDefault(cfg.Params, "high", DefaultVolHigh)
+		if err != nil {
+			return nil, err
+		}
+		allow, err := volRegimeAllowParam(cfg.Params)
+		if err != nil {
+			return nil, err
+		}
+		c, err := NewVolatilityClassifier(atrPeriod, windowSize, low, high, scale)
+		if err != nil {
+			return nil, err
+		}
+		return NewVolatilityRegimeFilter(c, allow)
+
+	case "sr-levels":
+		return levelsFilterFromParams(cfg.Params, scale)
+
+	case "strength":
+		return strengthFilterFromParams(cfg.Params)
+
 	case "composite":
 		if len(cfg.Filters) == 0 {
 			return nil, fmt.Errorf("composite regime requires at least one filter")
@@ -0,0 +1,110 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rustyeddy/trader/indicator"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// LevelsFilter gates entries on support and resistance: a long needs a
+// support zone below the bar's close, and a short a resistance zone above
+// it, so e.g. EMA-cross longs are only taken above the nearest support.
+// A close inside a zone has not cleared it and is refused. With maxATR
+// set, the close must also be within maxATR × ATR of that zone, so entries
+// are taken near the level rather than anywhere above it. Trending()
+// always returns true; the filter is purely directional. Entries pass
+// until the levels indicator is warm.
+//
+// Default params: strength=3, lookback=200, atr_period=14, zone_atr=0.5,
+// min_touches=2, max_atr=0 (no distance limit).
+// Registered in the factory as "sr-levels".
+type LevelsFilter struct {
+	levels *indicator.Levels
+	maxATR int64 // ×1000; e.g. 1.5 → 1500, 0 for no limit
+	close  types.Price
+
+	maxATRF float64 // display only
+}
+
+func NewLevelsFilter(cfg indicator.LevelsConfig, maxATR float64, scale types.Scale6) (*LevelsFilter, error) {
+	if maxATR < 0 {
+		return nil, fmt.Errorf("sr-levels max_atr must be >= 0, got %g", maxATR)
+	}
+	levels, err := indicator.NewLevels(cfg, scale)
+	if err != nil {
+		return nil, fmt.Errorf("sr-levels: %w", err)
+	}
+	return &LevelsFilter{levels: levels, maxATR: int64(math.Round(maxATR * 1000)), maxATRF: maxATR}, nil
+}
+
+func (f *LevelsFilter) Name() string {
+	if f.maxATR > 0 {
+		return fmt.Sprintf("SRLevels(%s,≤%.1f×ATR)", f.levels.Name(), f.maxATRF)
+	}
+	return fmt.Sprintf("SRLevels(%s)", f.levels.Name())
+}
+
+func (f *LevelsFilter) Ready() bool { return f.levels.Ready() }
+
+func (f *LevelsFilter) Tick(ct market.Candle) {
+	f.levels.Update(ct)
+	f.close = ct.Close
+}
+
+func (f *LevelsFilter) Trending() bool { return true }
+
+// Levels returns the support and resistance zones as of the last bar.
+func (f *LevelsFilter) Levels() []indicator.Level { return f.levels.Levels() }
+
+func (f *LevelsFilter) AllowSide(side types.Side) bool {
+	if !f.Ready() {
+		return true
+	}
+	levels := f.levels.Levels()
+	var dist types.Price
+	switch side {
+	case types.Long:
+		s, ok := indicator.NearestSupport(levels, f.close)
+		if !ok || s.High >= f.close {
+			return false
+		}
+		dist = f.close - s.High
+	case types.Short:
+		r, ok := indicator.NearestResistance(levels, f.close)
+		if !ok || r.Low <= f.close {
+			return false
+		}
+		dist = r.Low - f.close
+	default:
+		return true
+	}
+	return f.maxATR == 0 || int64(dist)*1000 <= int64(f.levels.ATR())*f.maxATR
+}
+
+func levelsFilterFromParams(params map[string]any, scale types.Scale6) (*LevelsFilter, error) {
+	cfg := indicator.DefaultLevelsConfig()
+	var err error
+	if cfg.Strength, err = positiveIntParamOrDefault(params, "strength", cfg.Strength); err != nil {
+		return nil, err
+	}
+	if cfg.Lookback, err = positiveIntParamOrDefault(params, "lookback", cfg.Lookback); err != nil {
+		return nil, err
+	}
+	if cfg.ATRPeriod, err = positiveIntParamOrDefault(params, "atr_period", cfg.ATRPeriod); err != nil {
+		return nil, err
+	}
+	if cfg.ZoneATR, err = positiveFloat64ParamOrDefault(params, "zone_atr", cfg.ZoneATR); err != nil {
+		return nil, err
+	}
+	if cfg.MinTouches, err = positiveIntParamOrDefault(params, "min_touches", cfg.MinTouches); err != nil {
+		return nil, err
+	}
+	maxATR, err := float64ParamOrDefault(params, "max_atr", 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewLevelsFilter(cfg, maxATR, scale)
+}
@@ -0,0 +1,76 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeBar is a bar closing at c with a 10-unit wick either side.
+func rangeBar(c types.Price) market.Candle {
+	return market.Candle{Open: c, High: c + 10, Low: c - 10, Close: c}
+}
+
+// tickRange feeds f three swings between 110_000 and 111_000, leaving
+// support around 109_990 and resistance around 111_010.
+func tickRange(f RegimeFilter) {
+	for range 3 {
+		for p := types.Price(110_000); p < 111_000; p += 200 {
+			f.Tick(rangeBar(p))
+		}
+		for p := types.Price(111_000); p > 110_000; p -= 200 {
+			f.Tick(rangeBar(p))
+		}
+	}
+	f.Tick(rangeBar(110_000))
+}
+
+func TestLevelsFilter(t *testing.T) {
+	t.Parallel()
+	f, err := GetRegimeFilter(RegimeConfig{Kind: "sr-levels", Params: map[string]any{
+		"strength": 2, "atr_period": 5,
+	}}, types.PriceScale)
+	require.NoError(t, err)
+	assert.Equal(t, "SRLevels(Levels(2,200,0.50×ATR(5),2))", f.Name())
+	assert.True(t, f.AllowSide(types.Long), "does not gate while warming up")
+
+	tickRange(f)
+	require.True(t, f.Ready())
+	assert.True(t, f.Trending())
+	require.Len(t, f.(*LevelsFilter).Levels(), 2)
+
+	f.Tick(rangeBar(110_500))
+	assert.True(t, f.AllowSide(types.Long), "above support")
+	assert.True(t, f.AllowSide(types.Short), "below resistance")
+
+	f.Tick(rangeBar(109_990))
+	assert.False(t, f.AllowSide(types.Long), "inside the support zone")
+
+	f.Tick(rangeBar(111_300))
+	assert.True(t, f.AllowSide(types.Long))
+	assert.False(t, f.AllowSide(types.Short), "no resistance above")
+}
+
+func TestLevelsFilter_MaxATR(t *testing.T) {
+	t.Parallel()
+	f, err := GetRegimeFilter(RegimeConfig{Kind: "sr-levels", Params: map[string]any{
+		"strength": 2, "atr_period": 5, "max_atr": 0.5,
+	}}, types.PriceScale)
+	require.NoError(t, err)
+	tickRange(f)
+
+	f.Tick(rangeBar(110_500))
+	assert.False(t, f.AllowSide(types.Long), "too far above support")
+	f.Tick(rangeBar(110_050))
+	assert.True(t, f.AllowSide(types.Long), "close to support")
+
+	_, err = GetRegimeFilter(RegimeConfig{Kind: "sr-levels", Params: map[string]any{"max_atr": -1}}, types.PriceScale)
+	assert.ErrorContains(t, err, "max_atr must be >= 0")
+
+	sf, err := GetSignalFilter(FilterConfig{Kind: "sr-levels"}, types.PriceScale)
+	require.NoError(t, err)
+	assert.Equal(t, "SRLevels(Levels(3,200,0.50×ATR(14),2))", sf.Name())
+}
@@ -0,0 +1,132 @@
+package strategy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rustyeddy/trader/errs"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/market/strength"
+	"github.com/rustyeddy/trader/types"
+)
+
+// MajorPairs are the pairs the strength regime reads by default: every
+// major currency against the USD.
+var MajorPairs = []string{"EURUSD", "GBPUSD", "USDJPY", "USDCHF", "AUDUSD", "USDCAD", "NZDUSD"}
+
+// PairRegime is a RegimeFilter that also reads bars of instruments other
+// than the traded one. The bar loop only sees the traded instrument, so
+// the run binds the filter to it with Bind before the first bar, then
+// calls TickPair with each of Pairs' bars, in time order, before the Tick
+// of the traded bar at or after them. Only backtests feed pairs.
+type PairRegime interface {
+	RegimeFilter
+	Pairs() []string
+	Bind(instrument string) error
+	TickPair(instrument string, ct market.Candle)
+}
+
+// PairRegimes returns the PairRegimes in r, looking inside composites.
+func PairRegimes(r RegimeFilter) []PairRegime {
+	switch f := r.(type) {
+	case PairRegime:
+		return []PairRegime{f}
+	case *CompositeRegimeFilter:
+		var out []PairRegime
+		for _, sub := range f.filters {
+			out = append(out, PairRegimes(sub)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// StrengthFilter gates entries on one instrument by relative currency
+// strength, e.g. only long EURUSD while EUR is strong and USD weak. Tick
+// feeds the traded instrument's bars into the Meter and TickPair the other
+// pairs'.
+//
+// Registered in the factory as "strength". Trending() is always true: the
+// filter is purely directional.
+type StrengthFilter struct {
+	meter      *strength.Meter
+	pairs      []string
+	instrument string
+	margin     types.Rate
+}
+
+// strengthFilterFromParams builds the strength regime: a Meter over
+// params.pairs (default MajorPairs) with an EMA of params.period bars
+// (20), allowing a side when the currencies' strengths differ by at least
+// params.margin (0). It is bound to the traded instrument by the run.
+func strengthFilterFromParams(params map[string]any) (*StrengthFilter, error) {
+	period, err := positiveIntParamOrDefault(params, "period", 20)
+	if err != nil {
+		return nil, err
+	}
+	margin, err := float64ParamOrDefault(params, "margin", 0)
+	if err != nil {
+		return nil, err
+	}
+	if margin < 0 {
+		return nil, fmt.Errorf("strength margin must be >= 0")
+	}
+	pairs, err := stringListParam(params, "pairs", MajorPairs)
+	if err != nil {
+		return nil, err
+	}
+	f := &StrengthFilter{margin: types.RateFromFloat(margin)}
+	for _, p := range pairs {
+		inst := market.NormalizeInstrument(strings.TrimSpace(p))
+		if market.GetInstrument(inst) == nil {
+			return nil, errs.Newf(errs.ErrUnknownInstrument, "strength pairs: unknown instrument %q", p)
+		}
+		f.pairs = append(f.pairs, inst)
+	}
+	if f.meter, err = strength.NewMeter(period); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *StrengthFilter) Name() string {
+	return fmt.Sprintf("Strength(%s,%d)", f.instrument, f.meter.Period())
+}
+
+// Pairs returns the pairs the filter reads besides the traded instrument.
+func (f *StrengthFilter) Pairs() []string { return f.pairs }
+
+// Bind sets the instrument whose entries the filter gates.
+func (f *StrengthFilter) Bind(instrument string) error {
+	inst := market.NormalizeInstrument(instrument)
+	if market.GetInstrument(inst) == nil {
+		return errs.Newf(errs.ErrUnknownInstrument, "strength filter: unknown instrument %q", instrument)
+	}
+	f.instrument = inst
+	return nil
+}
+
+func (f *StrengthFilter) Ready() bool {
+	_, ok := f.meter.Differential(f.instrument)
+	return ok
+}
+
+func (f *StrengthFilter) Tick(ct market.Candle) {
+	if f.instrument != "" {
+		_ = f.meter.Update(f.instrument, ct)
+	}
+}
+
+// TickPair feeds one bar of another pair. The traded instrument's own bars
+// come through Tick.
+func (f *StrengthFilter) TickPair(instrument string, ct market.Candle) {
+	if inst := market.NormalizeInstrument(instrument); inst != f.instrument {
+		_ = f.meter.Update(inst, ct)
+	}
+}
+
+func (f *StrengthFilter) Trending() bool { return true }
+
+func (f *StrengthFilter) AllowSide(side types.Side) bool {
+	return f.meter.AllowSide(f.instrument, side, f.margin)
+}
@@ -0,0 +1,77 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrengthFilter_GatesOnRelativeStrength(t *testing.T) {
+	t.Parallel()
+	for _, margin := range []float64{0, 0.5} {
+		f, err := strengthFilterFromParams(map[string]any{"period": 3, "margin": margin, "pairs": "EURUSD,EURJPY"})
+		require.NoError(t, err)
+		require.NoError(t, f.Bind("EUR_USD"))
+		assert.Equal(t, "Strength(EURUSD,3)", f.Name())
+		assert.False(t, f.Ready())
+		assert.True(t, f.AllowSide(types.Short), "warmup must not block")
+
+		// The filter ticks EURUSD; the run feeds the cross pair.
+		eurusd, eurjpy := types.Price(110_000), types.Price(16_000_000)
+		for i := 0; i < 10; i++ {
+			ts := types.Timestamp(3600 * (i + 1))
+			f.Tick(market.Candle{Close: eurusd, Timestamp: ts})
+			f.TickPair("EURJPY", market.Candle{Close: eurjpy, Timestamp: ts})
+			eurusd += eurusd / 1000
+			eurjpy += eurjpy / 1000
+		}
+
+		assert.True(t, f.Ready())
+		assert.True(t, f.Trending())
+		assert.Equal(t, margin == 0, f.AllowSide(types.Long), "margin %v", margin)
+		assert.False(t, f.AllowSide(types.Short))
+	}
+}
+
+func TestGetRegimeFilter_Strength(t *testing.T) {
+	t.Parallel()
+	r, err := GetRegimeFilter(RegimeConfig{Kind: "strength", Params: map[string]any{"period": 3, "margin": 0.001, "pairs": "EUR_USD,EURJPY"}}, types.PriceScale)
+	require.NoError(t, err)
+	prs := PairRegimes(NewCompositeRegimeFilter([]RegimeFilter{NoopRegime{}, r}))
+	require.Len(t, prs, 1)
+	f := prs[0]
+	assert.Equal(t, []string{"EURUSD", "EURJPY"}, f.Pairs())
+	require.Error(t, f.Bind("XXXYYY"))
+	require.NoError(t, f.Bind("EURUSD"))
+	assert.Equal(t, "Strength(EURUSD,3)", f.Name())
+
+	// EURJPY through TickPair, EURUSD through Tick; a pair bar of the
+	// traded instrument is ignored rather than counted twice.
+	eurusd, eurjpy := types.Price(110_000), types.Price(16_000_000)
+	for i := 0; i < 10; i++ {
+		ts := types.Timestamp(3600 * (i + 1))
+		f.TickPair("EURJPY", market.Candle{Close: eurjpy, Timestamp: ts})
+		f.TickPair("EURUSD", market.Candle{Close: 1, Timestamp: ts})
+		f.Tick(market.Candle{Close: eurusd, Timestamp: ts})
+		eurusd += eurusd / 1000
+		eurjpy += eurjpy / 1000
+	}
+	assert.True(t, f.Ready())
+	assert.True(t, f.AllowSide(types.Long))
+	assert.False(t, f.AllowSide(types.Short))
+
+	r, err = GetRegimeFilter(RegimeConfig{Kind: "strength"}, types.PriceScale)
+	require.NoError(t, err)
+	assert.Equal(t, MajorPairs, r.(PairRegime).Pairs())
+	for _, params := range []map[string]any{
+		{"pairs": "EURUSD,XXXYYY"},
+		{"pairs": 3},
+		{"margin": -0.1},
+	} {
+		_, err := GetRegimeFilter(RegimeConfig{Kind: "strength", Params: params}, types.PriceScale)
+		assert.Error(t, err, "%v", params)
+	}
+}
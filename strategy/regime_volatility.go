@@ -0,0 +1,157 @@
+package strategy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// Volatility regimes, as classified by VolatilityClassifier and recorded on
+// trades.
+const (
+	VolLow    = "low"
+	VolNormal = "normal"
+	VolHigh   = "high"
+)
+
+// VolatilityRegimes lists the regimes from calm to volatile, the order
+// reports show them in.
+var VolatilityRegimes = []string{VolLow, VolNormal, VolHigh}
+
+// Default VolatilityClassifier params.
+const (
+	DefaultVolATRPeriod  = 20
+	DefaultVolWindowSize = 200
+	DefaultVolLow        = 33.0
+	DefaultVolHigh       = 67.0
+)
+
+// VolatilityClassifier labels each bar low, normal or high volatility by
+// where the current ATR(atrPeriod) ranks among its last windowSize readings:
+// below the low percentile is low, at or above the high percentile is high.
+type VolatilityClassifier struct {
+	rank      *ATRPercentileFilter
+	low, high float64
+}
+
+func NewVolatilityClassifier(atrPeriod, windowSize int, low, high float64, scale types.Scale6) (*VolatilityClassifier, error) {
+	if low < minATRPercentileThreshold || high > maxATRPercentileThreshold || low >= high {
+		return nil, fmt.Errorf("volatility percentiles must satisfy 0 <= low < high <= 100, got low %.2f high %.2f", low, high)
+	}
+	rank, err := NewATRPercentileFilter(atrPeriod, windowSize, 0, scale)
+	if err != nil {
+		return nil, err
+	}
+	return &VolatilityClassifier{rank: rank, low: low, high: high}, nil
+}
+
+// NewDefaultVolatilityClassifier returns a classifier with the default
+// params, as the backtest runner uses to tag every trade.
+func NewDefaultVolatilityClassifier(scale types.Scale6) *VolatilityClassifier {
+	c, _ := NewVolatilityClassifier(DefaultVolATRPeriod, DefaultVolWindowSize, DefaultVolLow, DefaultVolHigh, scale)
+	return c
+}
+
+func (c *VolatilityClassifier) Name() string {
+	return fmt.Sprintf("VolRegime(%d,%d,%.0f/%.0f)", c.rank.atrPeriod, c.rank.windowSize, c.low, c.high)
+}
+
+func (c *VolatilityClassifier) Ready() bool { return c.rank.Ready() }
+
+func (c *VolatilityClassifier) Tick(ct market.Candle) { c.rank.Tick(ct) }
+
+// Regime returns the current bar's regime, or "" until the ATR is warm.
+func (c *VolatilityClassifier) Regime() string {
+	if !c.Ready() {
+		return ""
+	}
+	switch p := c.rank.percentile(); {
+	case p < c.low:
+		return VolLow
+	case p >= c.high:
+		return VolHigh
+	default:
+		return VolNormal
+	}
+}
+
+// VolatilityRegimeFilter gates entries to the volatility regimes in allow.
+// AllowSide() always returns true — like atr-percentile it is a regime
+// gate, not directional.
+//
+// Default params: atr_period=20, window_size=200, low=33, high=67,
+// allow="normal,high".
+// Registered in the factory as "vol-regime".
+type VolatilityRegimeFilter struct {
+	*VolatilityClassifier
+	allow map[string]bool
+}
+
+func NewVolatilityRegimeFilter(c *VolatilityClassifier, allow []string) (*VolatilityRegimeFilter, error) {
+	if len(allow) == 0 {
+		return nil, fmt.Errorf("vol-regime allow must list at least one of %s", strings.Join(VolatilityRegimes, ", "))
+	}
+	set := make(map[string]bool, len(allow))
+	for _, r := range allow {
+		r = strings.ToLower(strings.TrimSpace(r))
+		if r != VolLow && r != VolNormal && r != VolHigh {
+			return nil, fmt.Errorf("unknown volatility regime %q (use %s)", r, strings.Join(VolatilityRegimes, ", "))
+		}
+		set[r] = true
+	}
+	return &VolatilityRegimeFilter{VolatilityClassifier: c, allow: set}, nil
+}
+
+func (f *VolatilityRegimeFilter) Name() string {
+	var allowed []string
+	for _, r := range VolatilityRegimes {
+		if f.allow[r] {
+			allowed = append(allowed, r)
+		}
+	}
+	return fmt.Sprintf("VolRegime(%d,%d,%.0f/%.0f:%s)", f.rank.atrPeriod, f.rank.windowSize, f.low, f.high, strings.Join(allowed, "+"))
+}
+
+func (f *VolatilityRegimeFilter) Trending() bool {
+	if !f.Ready() {
+		return true
+	}
+	return f.allow[f.Regime()]
+}
+
+func (f *VolatilityRegimeFilter) AllowSide(_ types.Side) bool { return true }
+
+// volRegimeAllowParam reads params.allow as a comma-separated string or a
+// list of strings, defaulting to normal and high.
+func volRegimeAllowParam(params map[string]any) ([]string, error) {
+	return stringListParam(params, "allow", []string{VolNormal, VolHigh})
+}
+
+// stringListParam reads key as a list or comma-separated string, def when
+// it is missing.
+func stringListParam(params map[string]any, key string, def []string) ([]string, error) {
+	v, ok := params[key]
+	if !ok || v == nil {
+		return def, nil
+	}
+	switch x := v.(type) {
+	case string:
+		return strings.Split(x, ","), nil
+	case []string:
+		return x, nil
+	case []any:
+		out := make([]string, 0, len(x))
+		for _, e := range x {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("param %s: expected strings, got %T", key, e)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("param %s: expected a string or list, got %T", key, v)
+	}
+}
@@ -0,0 +1,79 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVolatilityClassifier_Regimes(t *testing.T) {
+	t.Parallel()
+	c, err := NewVolatilityClassifier(3, 20, 33, 67, types.PriceScale)
+	require.NoError(t, err)
+	assert.Equal(t, "", c.Regime(), "unclassified until the ATR is warm")
+
+	bar := func(rng types.Price) {
+		c.Tick(atrPCT(100000, 100000+rng/2, 100000-rng/2, 100000))
+	}
+	for i := 0; i < 10; i++ {
+		bar(1000)
+	}
+	require.True(t, c.Ready())
+	assert.Equal(t, VolNormal, c.Regime(), "a flat ATR window ranks at the 50th percentile")
+
+	for i := 0; i < 3; i++ {
+		bar(5000)
+	}
+	assert.Equal(t, VolHigh, c.Regime())
+
+	for i := 0; i < 10; i++ {
+		bar(200)
+	}
+	assert.Equal(t, VolLow, c.Regime())
+}
+
+func TestNewVolatilityClassifier_BadPercentiles(t *testing.T) {
+	t.Parallel()
+	_, err := NewVolatilityClassifier(3, 20, 70, 30, types.PriceScale)
+	assert.ErrorContains(t, err, "0 <= low < high <= 100")
+}
+
+func TestVolatilityRegimeFilter(t *testing.T) {
+	t.Parallel()
+	f, err := GetRegimeFilter(RegimeConfig{Kind: "vol-regime", Params: map[string]any{
+		"atr_period": 3, "window_size": 20, "allow": []any{"low"},
+	}}, types.PriceScale)
+	require.NoError(t, err)
+	assert.Equal(t, "VolRegime(3,20,33/67:low)", f.Name())
+	assert.True(t, f.Trending(), "does not gate while warming up")
+
+	for i := 0; i < 10; i++ {
+		f.Tick(atrPCT(100000, 100500, 99500, 100000))
+	}
+	assert.False(t, f.Trending(), "normal volatility is not allowed")
+	for i := 0; i < 10; i++ {
+		f.Tick(atrPCT(100000, 100100, 99900, 100000))
+	}
+	assert.True(t, f.Trending())
+	assert.True(t, f.AllowSide(types.Short))
+}
+
+func TestVolatilityRegimeFilter_Params(t *testing.T) {
+	t.Parallel()
+	f, err := GetRegimeFilter(RegimeConfig{Kind: "vol-regime"}, types.PriceScale)
+	require.NoError(t, err)
+	assert.Equal(t, "VolRegime(20,200,33/67:normal+high)", f.Name())
+
+	f, err = GetRegimeFilter(RegimeConfig{Kind: "vol-regime", Params: map[string]any{"allow": "high, low", "low": 10, "high": 90}}, types.PriceScale)
+	require.NoError(t, err)
+	assert.Equal(t, "VolRegime(20,200,10/90:low+high)", f.Name())
+
+	_, err = GetRegimeFilter(RegimeConfig{Kind: "vol-regime", Params: map[string]any{"allow": "calm"}}, types.PriceScale)
+	assert.ErrorContains(t, err, `unknown volatility regime "calm"`)
+
+	sf, err := GetSignalFilter(FilterConfig{Kind: "vol-regime"}, types.PriceScale)
+	require.NoError(t, err)
+	assert.Equal(t, "VolRegime(20,200,33/67:normal+high)", sf.Name())
+}
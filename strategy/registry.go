@@ -5,14 +5,21 @@ import (
 	"sort"
 	"strings"
 	"sync"
+
+	"github.com/rustyeddy/trader/types"
 )
 
 // StrategyConfig names the strategy and carries arbitrary key/value parameters
 // that are passed to the strategy constructor at build time. It mirrors the
-// strategy: section of a YAML backtest config.
+// strategy: section of a YAML backtest config. A Transform, when present,
+// runs the strategy on Heikin-Ashi candles or Renko bricks built from the
+// run's bars; Filters, when present, wrap the strategy in a
+// FilteredStrategy and see the run's own bars.
 type StrategyConfig struct {
-	Kind   string         `json:"kind" yaml:"kind"`
-	Params map[string]any `json:"params" yaml:"params"`
+	Kind      string           `json:"kind" yaml:"kind"`
+	Params    map[string]any   `json:"params" yaml:"params"`
+	Transform *TransformConfig `json:"transform,omitempty" yaml:"transform"`
+	Filters   []FilterConfig   `json:"filters,omitempty" yaml:"filters"`
 }
 
 // StrategyConstructor builds a Strategy from a config's Params map.
@@ -90,5 +97,30 @@ func GetStrategy(scfg StrategyConfig) (Strategy, error) {
 	if ctor == nil {
 		return nil, fmt.Errorf("unsupported strategy.kind %q (registered: %v)", name, RegisteredStrategies())
 	}
-	return ctor(scfg.Params)
+	strat, err := ctor(scfg.Params)
+	if err != nil {
+		return nil, err
+	}
+	scale := types.Scale6(types.PriceScale)
+	if scfg.Transform != nil {
+		t, err := GetCandleTransform(*scfg.Transform, scale)
+		if err != nil {
+			return nil, fmt.Errorf("strategy transform: %w", err)
+		}
+		if strat, err = NewTransformedStrategy(strat, t); err != nil {
+			return nil, err
+		}
+	}
+	if len(scfg.Filters) == 0 {
+		return strat, nil
+	}
+	filters := make([]SignalFilter, 0, len(scfg.Filters))
+	for i, fc := range scfg.Filters {
+		f, err := GetSignalFilter(fc, scale)
+		if err != nil {
+			return nil, fmt.Errorf("strategy filter %d: %w", i+1, err)
+		}
+		filters = append(filters, f)
+	}
+	return NewFilteredStrategy(strat, filters...)
 }
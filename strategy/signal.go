@@ -16,11 +16,12 @@ import "github.com/rustyeddy/trader/types"
 //
 // Strength is reserved for future conviction-based sizing and may be zero.
 type Signal struct {
-	Side     types.Side
-	Strength types.Rate  // 0 = unset; planner ignores for now
-	CloseAll bool        // close all open lots before (re-)entering
-	Stop     types.Price // optional suggested stop price; exit strategy overrides
-	Reason   string
+	Side      types.Side
+	Strength  types.Rate  // 0 = unset; planner ignores for now
+	CloseAll  bool        // close all open lots before (re-)entering
+	Stop      types.Price // optional suggested stop price; exit strategy overrides
+	RiskScale types.Rate  // multiplies the risk fraction the entry is sized with; 0 = 1×
+	Reason    string
 }
 
 // Hold returns a Signal with Side == Flat, used to express "no action this bar".
@@ -0,0 +1,66 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// StatefulStrategy is implemented by strategies that can save and restore
+// their warm state — indicator values, cross baselines, entries waiting on
+// confirmation — so that a restarted process picks up where the last one
+// stopped instead of warming up again.
+//
+// A snapshot holds state only, not configuration: Restore accepts it only
+// into a strategy built with the same parameters (same Name), and
+// returns an error otherwise, leaving the strategy as it was.
+//
+// The live candle adapter (service/bots) saves a snapshot after every bar
+// when its config names a state file, and restores it on start. That is
+// the only user: backtests always warm strategies up from their first
+// bar and do not checkpoint them.
+type StatefulStrategy interface {
+	Strategy
+	Snapshot() ([]byte, error)
+	Restore([]byte) error
+}
+
+// snapshotVersion is the envelope format written by MarshalSnapshot.
+const snapshotVersion = 1
+
+// snapshotEnvelope wraps a strategy's own state with what Restore needs
+// to check that it belongs to this strategy.
+type snapshotEnvelope struct {
+	Version  int             `json:"version"`
+	Strategy string          `json:"strategy"`
+	State    json.RawMessage `json:"state"`
+}
+
+// MarshalSnapshot encodes state, a JSON-marshalable struct, for the
+// strategy named name. It is a helper for StatefulStrategy.Snapshot.
+func MarshalSnapshot(name string, state any) ([]byte, error) {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("strategy %s: marshal snapshot: %w", name, err)
+	}
+	return json.Marshal(snapshotEnvelope{Version: snapshotVersion, Strategy: name, State: raw})
+}
+
+// UnmarshalSnapshot decodes a MarshalSnapshot snapshot into state,
+// checking that it was taken from a strategy named name. It is a helper
+// for StatefulStrategy.Restore.
+func UnmarshalSnapshot(data []byte, name string, state any) error {
+	var env snapshotEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return fmt.Errorf("strategy %s: bad snapshot: %w", name, err)
+	}
+	if env.Version != snapshotVersion {
+		return fmt.Errorf("strategy %s: unsupported snapshot version %d", name, env.Version)
+	}
+	if env.Strategy != name {
+		return fmt.Errorf("strategy %s: snapshot is for %s", name, env.Strategy)
+	}
+	if err := json.Unmarshal(env.State, state); err != nil {
+		return fmt.Errorf("strategy %s: bad snapshot state: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotEnvelope_RoundTrip(t *testing.T) {
+	type state struct {
+		PrevRel int   `json:"prev_rel"`
+		Value   int64 `json:"value"`
+	}
+	data, err := MarshalSnapshot("EMA_CROSS(3,5)", state{PrevRel: -1, Value: 110123})
+	require.NoError(t, err)
+
+	var got state
+	require.NoError(t, UnmarshalSnapshot(data, "EMA_CROSS(3,5)", &got))
+	assert.Equal(t, state{PrevRel: -1, Value: 110123}, got)
+
+	err = UnmarshalSnapshot(data, "EMA_CROSS(5,8)", &got)
+	require.ErrorContains(t, err, "snapshot is for EMA_CROSS(3,5)")
+
+	require.Error(t, UnmarshalSnapshot([]byte(`{"version":2,"strategy":"x","state":{}}`), "x", &got))
+	require.Error(t, UnmarshalSnapshot([]byte(`not json`), "x", &got))
+
+	_, err = MarshalSnapshot("x", map[string]any{"bad": func() {}})
+	require.ErrorContains(t, err, "strategy x: marshal snapshot")
+}
@@ -2,9 +2,13 @@ package strategy
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/rustyeddy/trader/account"
+	"github.com/rustyeddy/trader/brokers"
+	"github.com/rustyeddy/trader/journal"
 	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
 )
 
 // StrategyContext is the minimal, read-only view of an in-progress run that a
@@ -17,6 +21,59 @@ type StrategyContext interface {
 	// OpenLots returns a read-only view of the currently open lots. It never
 	// returns nil; an empty view is returned when there are no open lots.
 	OpenLots() LotView
+	// HigherTimeframe returns the run's higher-timeframe confirmation feed,
+	// or nil when none is configured.
+	HigherTimeframe() HigherTimeframe
+	// LastOrderDecisions reports what the order path did with the entries
+	// planned on the previous bar: accepted with the sized units, or
+	// rejected with a journal.Reject* reason. Nil when nothing was planned.
+	LastOrderDecisions() []journal.OrderDecision
+	// Logger returns the run's logger. Strategies log through it rather
+	// than printing, so their records land in the run's log file next to
+	// the runner's.
+	Logger() *slog.Logger
+}
+
+// HigherTimeframe is a read-only view of a second candle series that the
+// runner aggregates from the bars being traded, e.g. H1 bars built from an
+// M1 run. Only completed bars are visible, so a strategy never sees a
+// higher-timeframe close before it has happened.
+type HigherTimeframe interface {
+	Timeframe() types.Timeframe
+	// Ready reports whether the feed has its configured warm-up of
+	// completed bars. The runner blocks new entries until it does.
+	Ready() bool
+	// Closed reports whether a higher-timeframe bar completed on the
+	// current bar; Last(0) is then that bar. Strategies feed their
+	// higher-timeframe indicators on it.
+	Closed() bool
+	// Len returns the number of completed bars retained.
+	Len() int
+	// Last returns the i-th most recent completed bar, 0 being the latest.
+	Last(i int) (market.Candle, bool)
+}
+
+// OrderPreviewer is implemented by StrategyContexts whose venue can preview
+// an order without placing it. A strategy type-asserts its context against
+// it to decide on margin before it signals, e.g. holding when an entry's
+// MarginUsed would pass 30% of Equity, rather than learning of the refusal
+// from LastOrderDecisions a bar later. *Backtest implements it.
+type OrderPreviewer interface {
+	// PreviewOrder reports what a market order for units on side would
+	// fill at, and the margin required and left free after it, at the
+	// current price.
+	PreviewOrder(ctx context.Context, side types.Side, units types.Units) (brokers.OrderPreview, error)
+}
+
+// TickHistory is implemented by StrategyContexts whose venue keeps the
+// recent ticks it priced the run at. A strategy that wants tick
+// microstructure (tick momentum, spread averaging) declares how many it
+// reads with TickDepth and type-asserts its context against it, rather
+// than keeping a buffer of its own. *Backtest implements it.
+type TickHistory interface {
+	// RecentTicks returns up to the last n ticks on the run's instrument,
+	// oldest first; fewer until that many have been seen.
+	RecentTicks(n int) []market.Tick
 }
 
 // LotView is a read-only view over a set of open lots. *LotBook satisfies it.
@@ -36,3 +93,45 @@ type Strategy interface {
 	// places stops, e.g. "ATR(14)×1.5", "25 pips", or "" if none.
 	StopDescription() string
 }
+
+// SafetyLimited is implemented by strategies that keep stacking positions,
+// such as grids, and so must only run under engine-enforced limits on open
+// trades and aggregate exposure. The backtest refuses to compile a run of
+// such a strategy without both limits set.
+type SafetyLimited interface {
+	RequiresSafetyLimits() bool
+}
+
+// TickHistoryUser is implemented by strategies that read TickHistory:
+// the runner keeps the last TickDepth ticks for them.
+type TickHistoryUser interface {
+	TickDepth() int
+}
+
+// TickDepth returns how many ticks of history s, or the strategy a filter
+// chain or transform wraps, reads through TickHistory; 0 for none.
+func TickDepth(s Strategy) int {
+	if f, ok := s.(*FilteredStrategy); ok {
+		s = f.base
+	}
+	if t, ok := s.(*TransformedStrategy); ok {
+		s = t.base
+	}
+	if u, ok := s.(TickHistoryUser); ok {
+		return max(u.TickDepth(), 0)
+	}
+	return 0
+}
+
+// RequiresSafetyLimits reports whether s, or the strategy a filter chain
+// or transform wraps, declares that it needs safety limits.
+func RequiresSafetyLimits(s Strategy) bool {
+	if f, ok := s.(*FilteredStrategy); ok {
+		s = f.base
+	}
+	if t, ok := s.(*TransformedStrategy); ok {
+		s = t.base
+	}
+	l, ok := s.(SafetyLimited)
+	return ok && l.RequiresSafetyLimits()
+}
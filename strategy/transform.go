@@ -0,0 +1,119 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+)
+
+// TransformConfig is a strategy's transform: section in a YAML backtest
+// config. It names the candle series the strategy runs on in place of the
+// run's bars.
+type TransformConfig struct {
+	Kind   string         `json:"kind"   yaml:"kind"`
+	Params map[string]any `json:"params" yaml:"params"`
+}
+
+// GetCandleTransform constructs a candle transform from cfg:
+//
+//	heikin-ashi                 Heikin-Ashi candles
+//	renko   brick: 0.0010       fixed Renko bricks, in price units
+//	renko   atr_period, atr_mult  Renko bricks of atr_mult × ATR(atr_period),
+//	                            the default (ATR(14) × 1) when brick is unset
+func GetCandleTransform(cfg TransformConfig, scale types.Scale6) (datamanager.CandleTransform, error) {
+	switch normalizeRegimeKind(cfg.Kind) {
+	case "":
+		return nil, fmt.Errorf("transform kind is required")
+
+	case "heikin-ashi", "heikinashi", "ha":
+		return datamanager.NewHeikinAshi(), nil
+
+	case "renko":
+		brick, ok, err := types.GetFloat64Param(cfg.Params, "brick")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if brick <= 0 {
+				return nil, fmt.Errorf("renko brick must be > 0")
+			}
+			return datamanager.NewRenko(types.PriceFromFloat(brick))
+		}
+		period, err := positiveIntParamOrDefault(cfg.Params, "atr_period", 14)
+		if err != nil {
+			return nil, err
+		}
+		mult, err := positiveFloat64ParamOrDefault(cfg.Params, "atr_mult", 1)
+		if err != nil {
+			return nil, err
+		}
+		return datamanager.NewATRRenko(period, mult, scale)
+
+	default:
+		return nil, fmt.Errorf("unsupported transform kind %q (heikin-ashi, renko)", cfg.Kind)
+	}
+}
+
+// TransformedStrategy runs a base Strategy on a transformed candle series,
+// such as Renko bricks, rather than on the run's bars. Every bar is fed
+// through the transform and the base strategy is updated once per candle
+// that comes out, so it may see no update on a bar or several. The run
+// itself still trades the real bars: the planner sizes and places the
+// signal at the bar's prices, and filters wrapped around a
+// TransformedStrategy tick on the real bars too.
+type TransformedStrategy struct {
+	base      Strategy
+	transform datamanager.CandleTransform
+}
+
+// NewTransformedStrategy returns base run on the output of transform.
+func NewTransformedStrategy(base Strategy, transform datamanager.CandleTransform) (*TransformedStrategy, error) {
+	if base == nil {
+		return nil, fmt.Errorf("transformed strategy: base strategy must not be nil")
+	}
+	if transform == nil {
+		return nil, fmt.Errorf("transformed strategy: transform must not be nil")
+	}
+	return &TransformedStrategy{base: base, transform: transform}, nil
+}
+
+func (s *TransformedStrategy) Name() string {
+	return fmt.Sprintf("%s@%s", s.base.Name(), s.transform.Name())
+}
+
+func (s *TransformedStrategy) Reset() {
+	s.transform.Reset()
+	s.base.Reset()
+}
+
+func (s *TransformedStrategy) Ready() bool { return s.base.Ready() }
+
+func (s *TransformedStrategy) StopDescription() string { return s.base.StopDescription() }
+
+// Update feeds c through the transform and the resulting candles through
+// the base strategy, returning the last directional signal among them, or
+// else the last signal. A bar that completes no transformed candle holds.
+func (s *TransformedStrategy) Update(ctx context.Context, c *market.Candle, sc StrategyContext) Signal {
+	if c == nil {
+		return Hold("no candle")
+	}
+	out := s.transform.Add(*c)
+	if len(out) == 0 {
+		return Hold(fmt.Sprintf("waiting for %s candle", s.transform.Name()))
+	}
+	var sig, entry Signal
+	for i := range out {
+		tc := out[i]
+		sig = s.base.Update(ctx, &tc, sc)
+		if sig.Side != types.Flat || sig.CloseAll {
+			entry = sig
+		}
+	}
+	if entry.Side != types.Flat || entry.CloseAll {
+		return entry
+	}
+	return sig
+}
@@ -0,0 +1,105 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rustyeddy/trader/datamanager"
+	"github.com/rustyeddy/trader/market"
+	"github.com/rustyeddy/trader/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingStrategy records the candles it is updated with and goes long
+// on up candles.
+type recordingStrategy struct {
+	seen []market.Candle
+}
+
+func (s *recordingStrategy) Name() string            { return "Recording" }
+func (s *recordingStrategy) Reset()                  { s.seen = nil }
+func (s *recordingStrategy) Ready() bool             { return true }
+func (s *recordingStrategy) StopDescription() string { return "" }
+func (s *recordingStrategy) Update(_ context.Context, c *market.Candle, _ StrategyContext) Signal {
+	s.seen = append(s.seen, *c)
+	if c.Close > c.Open {
+		return Signal{Side: types.Long, Reason: "up brick"}
+	}
+	return Hold("down brick")
+}
+
+func flat(p types.Price, ts types.Timestamp) *market.Candle {
+	return &market.Candle{Open: p, High: p, Low: p, Close: p, Timestamp: ts}
+}
+
+func TestTransformedStrategy_UpdatesBaseOncePerBrick(t *testing.T) {
+	base := &recordingStrategy{}
+	renko, err := datamanager.NewRenko(10)
+	require.NoError(t, err)
+	s, err := NewTransformedStrategy(base, renko)
+	require.NoError(t, err)
+	assert.Equal(t, "Recording@Renko(0.00010)", s.Name())
+
+	ctx := context.Background()
+	assert.Equal(t, types.Flat, s.Update(ctx, flat(100, 1), nil).Side)
+	assert.Equal(t, types.Flat, s.Update(ctx, flat(105, 2), nil).Side)
+	assert.Empty(t, base.seen, "no brick, no update")
+
+	sig := s.Update(ctx, flat(125, 3), nil)
+	assert.Equal(t, types.Long, sig.Side)
+	require.Len(t, base.seen, 2)
+	assert.Equal(t, types.Price(120), base.seen[1].Close)
+
+	// A down brick after an up brick: the down brick's Hold is returned.
+	sig = s.Update(ctx, flat(95, 4), nil)
+	assert.Equal(t, types.Flat, sig.Side)
+	assert.Len(t, base.seen, 3)
+
+	s.Reset()
+	assert.Empty(t, base.seen)
+	assert.Equal(t, types.Flat, s.Update(ctx, flat(200, 5), nil).Side, "the transform re-anchors after Reset")
+}
+
+func TestGetCandleTransform(t *testing.T) {
+	scale := types.Scale6(types.PriceScale)
+
+	tr, err := GetCandleTransform(TransformConfig{Kind: "Heikin-Ashi"}, scale)
+	require.NoError(t, err)
+	assert.Equal(t, "HeikinAshi", tr.Name())
+
+	tr, err = GetCandleTransform(TransformConfig{Kind: "renko", Params: map[string]any{"brick": 0.0010}}, scale)
+	require.NoError(t, err)
+	assert.Equal(t, types.Price(100), tr.(*datamanager.Renko).BrickSize())
+
+	tr, err = GetCandleTransform(TransformConfig{Kind: "renko", Params: map[string]any{"atr_period": 10, "atr_mult": 1.5}}, scale)
+	require.NoError(t, err)
+	assert.Equal(t, "Renko(1.5×ATR(10))", tr.Name())
+
+	for _, cfg := range []TransformConfig{
+		{},
+		{Kind: "kagi"},
+		{Kind: "renko", Params: map[string]any{"brick": -1}},
+		{Kind: "renko", Params: map[string]any{"atr_mult": "wide"}},
+	} {
+		_, err := GetCandleTransform(cfg, scale)
+		assert.Error(t, err, "config %+v", cfg)
+	}
+}
+
+func TestGetStrategy_WrapsTransformInsideFilters(t *testing.T) {
+	name := "test-transformed-base"
+	require.NoError(t, RegisterStrategy(func(map[string]any) (Strategy, error) {
+		return &scriptedStrategy{}, nil
+	}, name))
+
+	strat, err := GetStrategy(StrategyConfig{Kind: name,
+		Transform: &TransformConfig{Kind: "heikin-ashi"},
+		Filters:   []FilterConfig{{Kind: "max-trades-per-day", Params: map[string]any{"max": 1}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Scripted@HeikinAshi[MaxTradesPerDay(1)]", strat.Name())
+
+	_, err = GetStrategy(StrategyConfig{Kind: name, Transform: &TransformConfig{Kind: "nope"}})
+	require.ErrorContains(t, err, "strategy transform")
+}
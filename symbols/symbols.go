@@ -0,0 +1,153 @@
+// Package symbols maps instrument names between trader's canonical IDs and
+// the symbols each venue uses for them.
+//
+// The canonical ID is the bare uppercase pair, e.g. "EURUSD"; it is what the
+// instrument registry, the data store and strategies key on. Venues spell the
+// same pair differently — Dukascopy "EURUSD", OANDA "EUR_USD", most others
+// "EUR/USD" — so every conversion at a venue boundary goes through ToProvider
+// and FromProvider rather than ad-hoc string surgery.
+//
+// Each provider formats six-letter pairs by joining base and quote with its
+// separator. Symbols that do not follow that rule (indices, metals with odd
+// names, venue-specific suffixes) are added as explicit entries with Register
+// or Load, typically from the `symbols:` section of the global config.
+package symbols
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Provider names. They match the datamanager source names where one exists.
+const (
+	OANDA      = "oanda"
+	Dukascopy  = "dukascopy"
+	Slash      = "slash"      // generic "EUR/USD" form used by most other venues
+	MetaTrader = "metatrader" // MT4/MT5 statements; suffixed broker symbols ("EURUSD.m") are entries
+)
+
+type table struct {
+	sep       string
+	toVenue   map[string]string // canonical → venue symbol
+	fromVenue map[string]string // venue symbol → canonical
+}
+
+var (
+	mu     sync.RWMutex
+	tables = map[string]*table{}
+)
+
+func init() {
+	resetDefaults()
+}
+
+func resetDefaults() {
+	mu.Lock()
+	defer mu.Unlock()
+	tables = map[string]*table{
+		OANDA:      newTable("_"),
+		Dukascopy:  newTable(""),
+		Slash:      newTable("/"),
+		MetaTrader: newTable(""),
+	}
+}
+
+func newTable(sep string) *table {
+	return &table{sep: sep, toVenue: map[string]string{}, fromVenue: map[string]string{}}
+}
+
+// Canonical returns the canonical ID for sym: trimmed, uppercased, with the
+// "_" and "/" separators removed. "eur_usd", "EUR/USD" and "EURUSD" all
+// become "EURUSD".
+func Canonical(sym string) string {
+	sym = strings.TrimSpace(sym)
+	sym = strings.ReplaceAll(sym, "_", "")
+	sym = strings.ReplaceAll(sym, "/", "")
+	return strings.ToUpper(sym)
+}
+
+// ToProvider returns provider's symbol for sym, which may be given in any
+// spelling Canonical accepts. An explicit entry wins; otherwise a six-letter
+// pair is split with the provider's separator and anything else is returned
+// in canonical form. Unknown providers get the canonical form.
+func ToProvider(provider, sym string) string {
+	id := Canonical(sym)
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := tables[strings.ToLower(provider)]
+	if !ok {
+		return id
+	}
+	if v, ok := t.toVenue[id]; ok {
+		return v
+	}
+	if t.sep != "" && len(id) == 6 {
+		return id[:3] + t.sep + id[3:]
+	}
+	return id
+}
+
+// FromProvider returns the canonical ID for provider's symbol sym, using an
+// explicit entry when one matches and Canonical otherwise.
+func FromProvider(provider, sym string) string {
+	mu.RLock()
+	t, ok := tables[strings.ToLower(provider)]
+	if ok {
+		if id, ok := t.fromVenue[strings.TrimSpace(sym)]; ok {
+			mu.RUnlock()
+			return id
+		}
+	}
+	mu.RUnlock()
+	return Canonical(sym)
+}
+
+// Register maps canonical to symbol for provider, in both directions. The
+// provider is created with no separator if it is not known yet.
+func Register(provider, canonical, symbol string) error {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	id := Canonical(canonical)
+	symbol = strings.TrimSpace(symbol)
+	if provider == "" || id == "" || symbol == "" {
+		return fmt.Errorf("symbols: provider, canonical and symbol are required (got %q, %q, %q)", provider, canonical, symbol)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	t, ok := tables[provider]
+	if !ok {
+		t = newTable("")
+		tables[provider] = t
+	}
+	if old, ok := t.toVenue[id]; ok {
+		delete(t.fromVenue, old)
+	}
+	t.toVenue[id] = symbol
+	t.fromVenue[symbol] = id
+	return nil
+}
+
+// Load registers every entry of m, keyed provider → canonical → symbol, as
+// read from the `symbols:` section of the global config. Entries are applied
+// in sorted order so errors are reported deterministically.
+func Load(m map[string]map[string]string) error {
+	providers := make([]string, 0, len(m))
+	for p := range m {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	for _, p := range providers {
+		ids := make([]string, 0, len(m[p]))
+		for id := range m[p] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			if err := Register(p, id, m[p][id]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package symbols
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonical(t *testing.T) {
+	for _, in := range []string{"EURUSD", "eurusd", "EUR_USD", "eur/usd", " EUR/USD "} {
+		assert.Equal(t, "EURUSD", Canonical(in), "in=%q", in)
+	}
+}
+
+func TestToFromProvider_Defaults(t *testing.T) {
+	t.Cleanup(resetDefaults)
+
+	cases := []struct{ provider, want string }{
+		{OANDA, "EUR_USD"},
+		{Dukascopy, "EURUSD"},
+		{Slash, "EUR/USD"},
+		{"unknown", "EURUSD"},
+	}
+	for _, tc := range cases {
+		for _, in := range []string{"EURUSD", "EUR_USD", "eur/usd"} {
+			got := ToProvider(tc.provider, in)
+			assert.Equal(t, tc.want, got, "provider=%s in=%q", tc.provider, in)
+			assert.Equal(t, "EURUSD", FromProvider(tc.provider, got))
+		}
+	}
+
+	// Non-pair symbols pass through in canonical form.
+	assert.Equal(t, "SPX500USD", ToProvider(OANDA, "spx500usd"))
+}
+
+func TestRegisterAndLoad(t *testing.T) {
+	t.Cleanup(resetDefaults)
+
+	require.NoError(t, Load(map[string]map[string]string{
+		"oanda":  {"SPX500USD": "SPX500_USD"},
+		"broker": {"EURUSD": "EURUSD.m"},
+	}))
+	assert.Equal(t, "SPX500_USD", ToProvider(OANDA, "SPX500USD"))
+	assert.Equal(t, "SPX500USD", FromProvider(OANDA, "SPX500_USD"))
+	assert.Equal(t, "EURUSD.m", ToProvider("broker", "EUR_USD"))
+	assert.Equal(t, "EURUSD", FromProvider("broker", "EURUSD.m"))
+
+	// Re-registering replaces the reverse entry too.
+	require.NoError(t, Register("broker", "EURUSD", "EURUSD.pro"))
+	assert.Equal(t, "EURUSD.pro", ToProvider("broker", "EURUSD"))
+	assert.Equal(t, "EURUSD.M", FromProvider("broker", "EURUSD.m"))
+
+	assert.Error(t, Register("", "EURUSD", "x"))
+	assert.Error(t, Register(OANDA, "EURUSD", " "))
+}
@@ -0,0 +1,104 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Rollover is the daily boundary at which one trading day ends and the
+// next begins: Hour:00 wall-clock time in Location, DST-aware. D1 (and so
+// H4) candles open at it, rollover financing is charged at it, and it is
+// the day boundary of daily P/L rollups that follow the broker's day.
+type Rollover struct {
+	Location *time.Location
+	Hour     int
+}
+
+// DefaultRollover is the FX market's and OANDA's rollover: 17:00 New York.
+var DefaultRollover = Rollover{Location: dailyAlignmentLocation, Hour: 17}
+
+var rollover atomic.Pointer[Rollover]
+
+// SetRollover sets the process-wide rollover that DailyAlignmentBoundary,
+// candle aggregation and swap charging use. Set it once at startup, before
+// any candles are built: stored D1 and H4 data aggregated under another
+// boundary must be rebuilt to match.
+func SetRollover(r Rollover) {
+	if r.Location == nil {
+		r.Location = time.UTC
+	}
+	rollover.Store(&r)
+}
+
+// CurrentRollover returns the process-wide rollover, DefaultRollover unless
+// SetRollover changed it.
+func CurrentRollover() Rollover {
+	if r := rollover.Load(); r != nil {
+		return *r
+	}
+	return DefaultRollover
+}
+
+// ParseRollover parses a rollover written "HH:00 Zone", e.g.
+// "17:00 America/New_York" or "00:00 UTC". The zone defaults to UTC when
+// omitted. Rollovers fall on the hour, the only alignment brokers offer.
+func ParseRollover(s string) (Rollover, error) {
+	clock, zone, _ := strings.Cut(strings.TrimSpace(s), " ")
+	zone = strings.TrimSpace(zone)
+	hh, mm, hasMinutes := strings.Cut(clock, ":")
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 23 || (hasMinutes && mm != "00") {
+		return Rollover{}, fmt.Errorf("rollover %q: want \"HH:00 Zone\", e.g. \"17:00 America/New_York\"", s)
+	}
+	loc := time.UTC
+	if zone != "" {
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return Rollover{}, fmt.Errorf("rollover %q: %w", s, err)
+		}
+	}
+	return Rollover{Location: loc, Hour: h}, nil
+}
+
+// String formats r the way ParseRollover reads it.
+func (r Rollover) String() string {
+	return fmt.Sprintf("%02d:00 %s", r.Hour, r.loc())
+}
+
+func (r Rollover) loc() *time.Location {
+	if r.Location == nil {
+		return time.UTC
+	}
+	return r.Location
+}
+
+// DayStart returns the most recent boundary at or before t, in UTC.
+func (r Rollover) DayStart(t time.Time) time.Time {
+	loc := r.loc()
+	lt := t.In(loc)
+	b := time.Date(lt.Year(), lt.Month(), lt.Day(), r.Hour, 0, 0, 0, loc)
+	if b.After(lt) {
+		b = time.Date(lt.Year(), lt.Month(), lt.Day()-1, r.Hour, 0, 0, 0, loc)
+	}
+	return b.UTC()
+}
+
+// Next returns the first boundary strictly after t, in UTC. Days run 23
+// or 25 hours across a DST transition, so this is not DayStart(t)+24h.
+func (r Rollover) Next(t time.Time) time.Time {
+	loc := r.loc()
+	b := r.DayStart(t).In(loc)
+	return time.Date(b.Year(), b.Month(), b.Day()+1, r.Hour, 0, 0, 0, loc).UTC()
+}
+
+// Day returns the trading day named by date's calendar date: the day in
+// progress at noon of that date in r's zone. Under a 17:00 New York
+// rollover, 2024-03-15 runs from 17:00 on the 14th to 17:00 on the 15th,
+// as FX value dates count it; under 00:00 UTC it is the calendar day.
+func (r Rollover) Day(date time.Time) (start, end time.Time) {
+	y, m, d := date.Date()
+	start = r.DayStart(time.Date(y, m, d, 12, 0, 0, 0, r.loc()))
+	return start, r.Next(start)
+}
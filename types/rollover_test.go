@@ -0,0 +1,57 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRollover(t *testing.T) {
+	r, err := ParseRollover("17:00 America/New_York")
+	require.NoError(t, err)
+	assert.Equal(t, 17, r.Hour)
+	assert.Equal(t, "America/New_York", r.Location.String())
+	assert.Equal(t, "17:00 America/New_York", r.String())
+
+	r, err = ParseRollover("0")
+	require.NoError(t, err)
+	assert.Equal(t, "00:00 UTC", r.String())
+
+	for _, bad := range []string{"", "5pm", "24:00 UTC", "17:30 UTC", "17:00 Mars/Base"} {
+		_, err := ParseRollover(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestRollover_DayBoundariesAcrossDST(t *testing.T) {
+	r := DefaultRollover
+	utc := func(m time.Month, d, h int) time.Time { return time.Date(2024, m, d, h, 0, 0, 0, time.UTC) }
+
+	// EST (UTC-5) until 2024-03-10, EDT (UTC-4) after.
+	assert.Equal(t, utc(3, 8, 22), r.DayStart(utc(3, 9, 12)))
+	assert.Equal(t, utc(3, 8, 22), r.DayStart(utc(3, 8, 22)), "the boundary is its own day start")
+	assert.Equal(t, utc(3, 9, 22), r.Next(utc(3, 8, 22)))
+	assert.Equal(t, utc(3, 10, 21), r.Next(utc(3, 9, 22)), "the DST day is 23 hours")
+
+	start, end := r.Day(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, utc(3, 14, 21), start)
+	assert.Equal(t, utc(3, 15, 21), end)
+
+	mid, err := ParseRollover("00:00 UTC")
+	require.NoError(t, err)
+	start, end = mid.Day(time.Date(2024, 3, 15, 18, 0, 0, 0, time.UTC))
+	assert.Equal(t, utc(3, 15, 0), start)
+	assert.Equal(t, utc(3, 16, 0), end)
+}
+
+func TestSetRollover_MovesDailyAlignment(t *testing.T) {
+	defer SetRollover(DefaultRollover)
+	at := time.Date(2024, 6, 5, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 6, 4, 21, 0, 0, 0, time.UTC), DailyAlignmentBoundary(at))
+
+	SetRollover(Rollover{Hour: 0})
+	assert.Equal(t, time.UTC, DailyAlignmentLocation())
+	assert.Equal(t, time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC), DailyAlignmentBoundary(at))
+}
@@ -314,25 +314,22 @@ func mustLoadLocation(name string) *time.Location {
 	return loc
 }
 
-// DailyAlignmentLocation returns the broker alignment timezone
-// (America/New_York). Exposed for callers that need to evaluate local
-// wall-clock rules beyond the 17:00 day boundary itself — e.g. H4 candle
-// opens, which OANDA anchors to fixed local hours (1/5/9/13/17/21:00),
-// so their UTC phase shifts at the DST transition instant.
+// DailyAlignmentLocation returns the zone of the configured rollover (see
+// SetRollover; America/New_York by default). Exposed for callers that need
+// to evaluate local wall-clock rules beyond the day boundary itself — e.g.
+// H4 candle opens, which OANDA anchors to fixed local hours (1/5/9/13/17/
+// 21:00 under the default rollover), so their UTC phase shifts at the DST
+// transition instant.
 func DailyAlignmentLocation() *time.Location {
-	return dailyAlignmentLocation
+	return CurrentRollover().Location
 }
 
-// DailyAlignmentBoundary returns the most recent broker daily-alignment
-// boundary at or before t: 17:00 in America/New_York, DST-aware. This is
-// OANDA's default dailyAlignment/alignmentTimezone (this repo never
-// overrides either), and it is the true boundary D1 candles — and, by
-// subdivision, H4 candles — are anchored to. It is not UTC midnight.
+// DailyAlignmentBoundary returns the most recent daily boundary at or
+// before t under the configured rollover: by default 17:00 in
+// America/New_York, DST-aware, which is OANDA's default dailyAlignment/
+// alignmentTimezone. It is the boundary D1 candles — and, by subdivision,
+// H4 candles — are anchored to. It is not UTC midnight unless the rollover
+// is configured that way.
 func DailyAlignmentBoundary(t time.Time) time.Time {
-	nyT := t.In(dailyAlignmentLocation)
-	boundary := time.Date(nyT.Year(), nyT.Month(), nyT.Day(), 17, 0, 0, 0, dailyAlignmentLocation)
-	if boundary.After(nyT) {
-		boundary = boundary.AddDate(0, 0, -1)
-	}
-	return boundary.UTC()
+	return CurrentRollover().DayStart(t)
 }